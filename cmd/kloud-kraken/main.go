@@ -1,46 +1,483 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"cmp"
 	"context"
 	"crypto/tls"
 	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"net"
+	"net/netip"
 	"os"
+	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	kkconfig "github.com/ngimb64/Kloud-Kraken/config"
 	"github.com/ngimb64/Kloud-Kraken/internal/color"
 	"github.com/ngimb64/Kloud-Kraken/internal/conf"
 	"github.com/ngimb64/Kloud-Kraken/internal/globals"
 	"github.com/ngimb64/Kloud-Kraken/internal/validate"
+	"github.com/ngimb64/Kloud-Kraken/pkg/admin"
+	"github.com/ngimb64/Kloud-Kraken/pkg/admission"
+	"github.com/ngimb64/Kloud-Kraken/pkg/audit"
+	"github.com/ngimb64/Kloud-Kraken/pkg/clientdir"
 	"github.com/ngimb64/Kloud-Kraken/pkg/awsutils"
+	"github.com/ngimb64/Kloud-Kraken/pkg/controlplane"
+	"github.com/ngimb64/Kloud-Kraken/pkg/cost"
 	"github.com/ngimb64/Kloud-Kraken/pkg/data"
 	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
+	"github.com/ngimb64/Kloud-Kraken/pkg/downscale"
 	"github.com/ngimb64/Kloud-Kraken/pkg/display"
+	"github.com/ngimb64/Kloud-Kraken/pkg/eventstream"
+	"github.com/ngimb64/Kloud-Kraken/pkg/exceptions"
+	"github.com/ngimb64/Kloud-Kraken/pkg/gpu"
+	"github.com/ngimb64/Kloud-Kraken/pkg/harden"
+	"github.com/ngimb64/Kloud-Kraken/pkg/identify"
+	"github.com/ngimb64/Kloud-Kraken/pkg/hashcat"
+	"github.com/ngimb64/Kloud-Kraken/pkg/hibernate"
+	"github.com/ngimb64/Kloud-Kraken/pkg/inspect"
+	"github.com/ngimb64/Kloud-Kraken/pkg/instance"
+	"github.com/ngimb64/Kloud-Kraken/pkg/keyspace"
 	"github.com/ngimb64/Kloud-Kraken/pkg/kloudlogs"
+	"github.com/ngimb64/Kloud-Kraken/pkg/logstream"
+	"github.com/ngimb64/Kloud-Kraken/pkg/manifest"
+	"github.com/ngimb64/Kloud-Kraken/pkg/metrics"
 	"github.com/ngimb64/Kloud-Kraken/pkg/netio"
+	"github.com/ngimb64/Kloud-Kraken/pkg/notify"
+	"github.com/ngimb64/Kloud-Kraken/pkg/partition"
+	"github.com/ngimb64/Kloud-Kraken/pkg/peer"
+	"github.com/ngimb64/Kloud-Kraken/pkg/potfile"
+	"github.com/ngimb64/Kloud-Kraken/pkg/protocol"
+	"github.com/ngimb64/Kloud-Kraken/pkg/publicip"
+	"github.com/ngimb64/Kloud-Kraken/pkg/rebalance"
+	"github.com/ngimb64/Kloud-Kraken/pkg/relay"
+	"github.com/ngimb64/Kloud-Kraken/pkg/report"
+	"github.com/ngimb64/Kloud-Kraken/pkg/restore"
+	"github.com/ngimb64/Kloud-Kraken/pkg/schedule"
+	"github.com/ngimb64/Kloud-Kraken/pkg/storage"
+	"github.com/ngimb64/Kloud-Kraken/pkg/summary"
 	"github.com/ngimb64/Kloud-Kraken/pkg/tlsutils"
 	"github.com/ngimb64/Kloud-Kraken/pkg/tui"
+	"github.com/ngimb64/Kloud-Kraken/pkg/update"
+	"github.com/ngimb64/Kloud-Kraken/pkg/userdata"
+	"github.com/ngimb64/Kloud-Kraken/pkg/webui"
 	"github.com/ngimb64/Kloud-Kraken/pkg/wordlist"
 	"go.uber.org/zap"
 )
 
+// Clients below this ratio of the fleet average throughput are fed smaller files
+const SlowClientRatio = 0.5
+// Completed transfers needed before a client throughput is compared to the fleet
+const SlowClientMinTransfers = 2
+
+// Default hashcat release built from source on Graviton instances and downloaded on Windows
+const HashcatRelease = "v6.2.6"
+
 // Package level variables
+var Aborting atomic.Bool               // Set through the admin socket to abort the run early
+var AddedInstances atomic.Int32        // Instances added mid-run whose clients are not yet accepted
+var Admin *admin.Server                // Local JSON-RPC admin socket server, nil when disabled
+var Audit *audit.Log                   // Audit log of the privileged actions, nil when disabled
+var Brain *hashcat.BrainServer         // Local hashcat brain server, nil when disabled
+var BrainSsmParam string               // SSM parameter of the brain password, empty if unused
+var ClientLogs *logstream.Store        // Live client log files and tail view, nil when disabled
+var ClientConns sync.Map               // Connection of each connected client by address
+var ClientDirs *clientdir.Index        // Dir of each client its received files are stored in
+var ClientRestarts *update.Restarts    // Clients restarting on a new version, nil when disabled
+var ClientUpdate *update.Publisher     // Client binary version publisher, nil when disabled
+var CertSsmParam string                // SSM parameter holding the server certificate, empty when testing
+var ConnectionToken string             // Token clients present in their hello, empty if unused
+var ControlPlane *controlplane.Listener  // SQS control plane listener, nil when clients use TLS
+var CrackedHashes atomic.Int64         // Total number of hashes cracked by all clients
 var CurrentConnections atomic.Int32	   // Tracks current active connections
-var ReceivedDir = "/tmp/received"      // Path where cracked hashes & client logs are stored
+var DiskIo *disk.IoScheduler           // Lets wordlist transfers preempt merging disk IO
+var Downscale *downscale.Downscaler   // Ends idle clients and terminates them, nil if unused
+var Draining atomic.Bool               // Set through the admin socket to stop assigning new work
+var ErrClientRejected = errors.New("client connection rejected")  // Client refused on accept
+var Events *eventstream.Emitter        // JSON event stream on stdout, nil when disabled
+var Exceptions = exceptions.NewTracker(3)  // Retried, requeued, and dead-lettered work
+var ExitCleanups []func()              // Cleanups run in reverse order when the server exits
+var ExitMutex sync.Mutex               // Guards the exit cleanups against concurrent exits
+var FirstCrack sync.Once               // Notifies operators of the first cracked hashes once
+var FleetStopped = make(chan struct{}) // Closed when the watchdog terminates the fleet
+var Ec2States atomic.Value             // Last polled EC2 instance counts by state name
+var HashRate = hashcat.NewFleet(3 * hashcat.StatusInterval)  // Fleet speed from client statuses
+var HashShards []string                // Hash file shards, empty when splitting is disabled
+var HashTargets []conf.HashFile        // Hash files sent to clients, without prior cracked hashes
+var Hibernation = hibernate.New()      // Run paused overnight with its fleet stopped
+var HibernatedIds []string             // Instances stopped by the hibernation, started on resume
+var IamResources *awsutils.IamRun      // IAM resources created for the run, nil when none
+var Instances = instance.NewRegistry() // Instance metadata reported by each client
+var InstancesAdded = make(chan struct{}, 1)  // Signaled when instances are added mid-run
+var Keyspace *keyspace.Scheduler       // Mask keyspace range scheduler, nil when disabled
+var LocalClients []*exec.Cmd           // Client processes spawned in local mode, empty when disabled
+var LocalClientsDir = filepath.Join(os.TempDir(), "kloud-kraken-local")  // Local client data dirs
+var LookupInstance func(host string) (string, error)  // Finds a client instance, nil if unused
+var LootMutex sync.Mutex               // Mutex for synchronizing the received loot paths
+var LootFiles []report.LootFile        // Cracked hash files received from clients
+var Manifest *manifest.Manifest        // Digests of the distributed wordlists, nil until merged
+var Metrics *metrics.Registry          // Prometheus metrics endpoint, nil when disabled
+var NextDeviceGroup atomic.Int32       // Index of the next device group assigned to local clients
+var Notifier *notify.Notifier          // Sends run events to notification sinks, nil when disabled
+var Paused atomic.Bool                 // Set through the admin socket to hold new work until resumed
+var Peers *peer.Registry               // Registry of seeding clients, nil when disabled
+var PlanMerge bool                     // Set by --merge-plan to plan the wordlist merge and exit
+var Potfile *potfile.Potfile           // Unique cracked hashes of the run, nil when unopened
+var PrunedDir = filepath.Join(os.TempDir(), "pruned")  // Hash files without prior cracks
+var QueuePrefix string                 // Prefix of the SQS control plane queue names of the run
+var RangeIndex []disk.Candidate        // Line ranges of the load dir wordlists, nil unless ranged
+var Rebalance *rebalance.Tracker       // Queued wordlists of each client, nil when work stealing is off
+var ReceivedDir = filepath.Join(os.TempDir(), "received")  // Cracked hashes & client logs
+var RelayBacklog = 32                  // Max connections kept waiting at the relay broker
+var RemainingClients atomic.Int32      // Clients yet to finish without a pending update
+var Results storage.Store              // Where cracked hashes, logs, and reports are persisted
+var RunId string                       // Unique ID of the run scoping its AWS resource names
+var RunName string                     // Name labeling the resources, logs, and reports of the run
+var Schedule *schedule.Scheduler       // Orders the load dir wordlists, nil keeps the dir order
+var S3Stage *awsutils.S3Manager        // Stages wordlists in S3 for the SQS control plane, nil when unused
+var ShardDir = filepath.Join(os.TempDir(), "shards")  // Hash file shards of the clients
 var TlsMan = new(tlsutils.TlsManager)  // Struct for managing TLS certs, keys, etc.
+var TokenSsmParam string               // SSM parameter of the connection token, empty if unused
+var TransferProgressInterval = 1 * time.Second  // Duration between transfer progress updates
+var UpdateReconnectTimeout = 10 * time.Minute   // Time an updating client has to reconnect
+var Transfers = data.NewTransferManager()  // Throughput, retry, and failure stats per client
+var VerifiedDir = filepath.Join(os.TempDir(), "verified")  // Hash files without invalid hashes
+var VpcCidrs []netip.Prefix            // CIDR blocks of the VPC the server runs in, nil outside EC2
+var WebUi *webui.Dashboard             // Optional web dashboard, nil when disabled
+
+
+// Records the exception for the final report, emits it on the event stream, and
+// displays it in the right panel.
+//
+// @Parameters
+// - kind:  The category of the exception
+// - client:  The address of the client involved, empty if not client specific
+// - detail:  The description of what happened
+// - t:  The tui interface for displaying output
+//
+func recordException(kind exceptions.Kind, client string, detail string, t *tui.TUI) {
+    Exceptions.Record(kind, client, detail)
+
+    Events.Emit(eventstream.ExceptionRecorded, map[string]any{
+        "client": client,
+        "detail": detail,
+        "kind":   string(kind),
+    })
+
+    // Display the exception in the right panel
+    t.PostRight(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "X"), "",
+                                   color.BrightCoral, string(kind) + " ",
+                                   color.NeonAzure, detail))
+}
+
+
+// Logs the error handling a message from the client. A malformed message can only come
+// from a misbehaving or compromised client, so it is also emitted on the event stream
+// before the caller closes the connection.
+//
+// @Parameters
+// - logMan:  The kloudlogs logger manager for local logging
+// - remoteAddr:  IP address to remote client that has connected
+// - description:  Description of what failed
+// - err:  The error handling the message
+//
+func logClientError(logMan *kloudlogs.LoggerManager, remoteAddr string, description string,
+                    err error) {
+    // If the message was not malformed, such as the connection closing mid-message
+    if !errors.Is(err, netio.ErrMalformedMessage) {
+        logMan.LogMessage("error", description + ":  %v", err)
+        return
+    }
+
+    logMan.LogMessage("warn", "Malformed message from client, closing its connection",
+                      zap.String("client", remoteAddr), zap.String("context", description),
+                      zap.Error(err))
+
+    Events.Emit(eventstream.MalformedMessage, map[string]any{
+        "client":  remoteAddr,
+        "context": description,
+        "detail":  err.Error(),
+    })
+}
+
+
+// Notifies operators the first time hashes are cracked in the run, later cracks are
+// counted in the run complete notification.
+//
+// @Parameters
+// - remoteAddr:  IP address to remote client that cracked the hashes
+// - cracked:  The number of new hashes cracked
+//
+func notifyFirstCrack(remoteAddr string, cracked int) {
+    // If no new hashes were cracked
+    if cracked <= 0 {
+        return
+    }
+
+    FirstCrack.Do(func() {
+        Notifier.Notify(notify.FirstCrack, map[string]any{
+            "client":  remoteAddr,
+            "cracked": cracked,
+        })
+    })
+}
+
+
+// Returns a wordlist that was not processed back to the load dir selection pool so
+// another transfer request picks it up, or dead-letters it once out of retries.
+//
+// @Parameters
+// - filePath:  The path of the wordlist in the load dir
+// - kind:  The exception kind to record if the wordlist is returned to the pool
+// - client:  The address of the client the wordlist was sent to
+// - reason:  The reason the wordlist was not processed
+// - t:  The tui interface for displaying output
+//
+func requeueFile(filePath string, kind exceptions.Kind, client string, reason string,
+                 t *tui.TUI) {
+    fileName := filepath.Base(filePath)
+
+    // If the wordlist failed to transfer, count it against the client
+    if kind == exceptions.TransferRetried {
+        Transfers.RecordFailure(client)
+    }
+
+    // If the wordlist has retries remaining, make it selectable again
+    if Exceptions.Retry(filePath) {
+        Transfers.RecordRetry(client)
+        disk.Claims.Release(filePath)
+        recordException(kind, client, fileName + " requeued, " + reason, t)
+        return
+    }
+
+    recordException(exceptions.DeadLettered, client, fileName + " exceeded retries, " +
+                    reason, t)
+}
+
+
+// Checks whether the wordlist data is sent to the client over plaintext TCP from the
+// config, the negotiated features, and whether the client address is inside the VPC. The
+// client is told the outcome in the port exchange rather than deciding it on its own.
+//
+// @Parameters
+// - appConfig:  The configuration struct with loaded yaml program data
+// - session:  The protocol version and features negotiated with the client
+// - ipAddr:  The IP address of the remote client connected to the server
+//
+// @Returns
+// - true if the data connections skip TLS, otherwise false
+//
+func plaintextTransfer(appConfig *conf.AppConfig, session protocol.Hello,
+                       ipAddr string) bool {
+    return appConfig.LocalConfig.PlaintextTransfers &&
+           session.Supports(protocol.FeaturePlaintext) && netio.PrivateAddr(ipAddr, VpcCidrs)
+}
+
+
+// Connects to the transfer listener of a client, through the relay broker when the
+// clients are in private subnets the server can not reach.
+//
+// @Parameters
+// - appConfig:  The configuration struct with loaded yaml program data
+// - remoteAddr:  The IP address and port of the client listener
+// - ipAddr:  The IP address the client certificate is verified against
+// - plaintext:  Whether the client listens for plaintext TCP inside the VPC
+//
+// @Returns
+// - The TLS connection to the client, or TCP connection when plaintext
+// - Error if it occurs, otherwise nil on success
+//
+func dialClient(appConfig *conf.AppConfig, remoteAddr string, ipAddr string,
+                plaintext bool) (net.Conn, error) {
+    // If the data channel skips TLS, the relay is never used alongside it
+    if plaintext {
+        return net.Dial("tcp", remoteAddr)
+    }
+
+    tlsConfig := tlsutils.NewClientTLSConfig(TlsMan.CaCertPool, ipAddr)
+
+    // If the clients are reached directly
+    if appConfig.LocalConfig.RelayAddress == "" {
+        return tls.Dial("tcp", remoteAddr, tlsConfig)
+    }
+
+    relayConn, err := relay.Dial(appConfig.LocalConfig.RelayAddress,
+                                 appConfig.LocalConfig.RelayToken,
+                                 appConfig.LocalConfig.RelayFingerprint, remoteAddr)
+    if err != nil {
+        return nil, err
+    }
+
+    // Complete the handshake end to end with the client through the relay
+    tlsConn := tls.Client(relayConn, tlsConfig)
+    err = tlsConn.Handshake()
+    if err != nil {
+        tlsConn.Close()
+        return nil, fmt.Errorf("error with TLS handshake through relay - %w", err)
+    }
+
+    return tlsConn, nil
+}
+
+
+// Gets the manifest digest sent in the transfer reply so the client can verify the
+// wordlist, empty if the client can not verify it or the reply would not fit its buffer.
+//
+// @Parameters
+// - filePath:  The path of the wordlist to be sent
+// - fileSize:  The size of the wordlist to be sent
+// - encoding:  The encoding the wordlist is sent with
+// - session:  The protocol version and features negotiated with the client
+// - logMan:  The kloudlogs logger manager for local logging
+//
+// @Returns
+// - The digest of the wordlist, empty if it is not verified
+//
+func wordlistDigest(filePath string, fileSize int64, encoding string,
+                    session protocol.Hello, logMan *kloudlogs.LoggerManager) string {
+    // If the client would misread a digest in the transfer reply
+    if Manifest == nil || !session.Supports(protocol.FeatureManifest) {
+        return ""
+    }
+
+    entry, err := Manifest.Get(filePath)
+    if err != nil {
+        logMan.LogMessage("error", "Error getting manifest of %s, sent unverified:  %v",
+                          filePath, err)
+        return ""
+    }
+
+    digest := entry.Digest()
+    replySize := len(globals.START_TRANSFER_PREFIX) + len(filepath.Base(filePath)) +
+                 len(strconv.FormatInt(fileSize, 10)) + len(encoding) + len(digest) + 4
+    // If the name is too long for the digest to fit the client message buffer
+    if replySize > globals.MESSAGE_BUFFER_SIZE {
+        logMan.LogMessage("warn", "Wordlist name too long to send its manifest, sent " +
+                          "unverified", zap.String("wordlist", filepath.Base(filePath)))
+        return ""
+    }
+
+    return digest
+}
+
+
+// Selects and claims the next wordlist for the client, a line range of one when wordlists
+// are assigned by range.
+//
+// @Parameters
+// - loadDir:  The directory the wordlists are selected from
+// - maxFileSize:  The max size of the selected wordlist
+// - clientAddr:  The address of the client the wordlist is claimed for
+//
+// @Returns
+// - Path of the selected wordlist or range, empty if none are available
+// - Size of the selected wordlist or range
+// - Error if it occurs, otherwise nil on success
+//
+func selectWordlist(loadDir string, maxFileSize int64, clientAddr string) (string, int64,
+                                                                           error) {
+    // If wordlists are assigned by range, select from the indexed ranges
+    if RangeIndex != nil {
+        filePath, fileSize := disk.SelectRange(RangeIndex, maxFileSize, clientAddr,
+                                               Schedule.Order)
+        return filePath, fileSize, nil
+    }
+
+    return disk.SelectFile(loadDir, maxFileSize, clientAddr, Schedule.Order)
+}
+
+
+// Opens the selected wordlist for reading, only its line range when assigned by range.
+//
+// @Parameters
+// - filePath:  The path of the selected wordlist or range
+//
+// @Returns
+// - The opened wordlist, closed by the caller
+// - Error if it occurs, otherwise nil on success
+//
+func openWordlist(filePath string) (io.ReadCloser, error) {
+    // If wordlists are assigned by range
+    if RangeIndex != nil {
+        return disk.OpenRange(filePath)
+    }
+
+    return os.Open(filePath)
+}
+
+
+// Sends the selected wordlist to the client, only the bytes of its line range when assigned
+// by range.
+//
+// @Parameters
+// - connection:  The transfer connection to the client, closed by the caller
+// - dial:  Dials a new transfer connection for each range sent in parallel
+// - filePath:  The path of the selected wordlist or range
+// - fileSize:  The size of the selected wordlist or range
+// - encoding:  The encoding the wordlist is sent with
+// - parallelConnections:  The number of connections a wordlist sent in ranges is split across
+// - tracker:  Tracks the progress of the transfer
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func transferWordlist(connection net.Conn, dial func() (net.Conn, error), filePath string,
+                      fileSize int64, encoding string, parallelConnections int,
+                      tracker *netio.Tracker) error {
+    // Hold the transfer priority so any merging disk IO yields while the wordlist is read
+    release, err := DiskIo.Acquire(context.Background(), disk.PriorityTransfer)
+    if err != nil {
+        return err
+    }
+    // Release the transfer priority on local exit
+    defer release()
+
+    sourcePath, offset, _, isRange := disk.ParseRangePath(filePath)
+    // If the wordlist is assigned by range, send only the bytes of the range
+    if RangeIndex != nil && isRange {
+        section := netio.Range{Length: fileSize, Offset: offset}
+
+        // If the range is split, transfer its ranges over parallel connections
+        if encoding == netio.EncodingRanges {
+            return netio.TransferSectionRanges(connection, dial, sourcePath, section,
+                                               parallelConnections, tracker)
+        }
+
+        return netio.TransferSection(connection, sourcePath, section, encoding, tracker)
+    }
+
+    // If the file is split, transfer its ranges over parallel connections
+    if encoding == netio.EncodingRanges {
+        return netio.TransferFileRanges(connection, dial, filePath, fileSize,
+                                        parallelConnections, tracker)
+    }
+
+    return netio.TransferFile(connection, filePath, fileSize, encoding, tracker)
+}
 
 
 // Select next available file for transfer, if there are no more available send the end transfer
@@ -55,19 +492,93 @@ var TlsMan = new(tlsutils.TlsManager)  // Struct for managing TLS certs, keys, e
 // - appConfig:  The configuration struct with loaded yaml program data
 // - logMan:  The kloudlogs logger manager for local logging
 // - ipAddr:  The IP address of the remote client connected to the server
+// - session:  The protocol version and features negotiated with the client
+// - available:  The bytes the client has free for the wordlist, 0 when not reported
 // - t:  The tui interface for displaying output
 //
 func handleTransfer(connection net.Conn, buffer []byte, waitGroup *sync.WaitGroup,
                     appConfig *conf.AppConfig, logMan *kloudlogs.LoggerManager,
-                    ipAddr string, t *tui.TUI) {
+                    ipAddr string, session protocol.Hello, available int64, t *tui.TUI) {
+    // Save the full client address before the port is stripped
+    clientAddr := ipAddr
+
+    // The largest wordlist the client can take, capped by its free space when reported
+    fitSize := appConfig.ClientConfig.MaxFileSizeInt64
+    if available > 0 {
+        fitSize = min(fitSize, available)
+    }
+    maxFileSize := fitSize
+
+    // If the run is being drained through the admin socket, assign no new work
+    if Draining.Load() {
+        _, err := netio.WriteHandler(connection, globals.END_TRANSFER_MARKER,
+                                     len(globals.END_TRANSFER_MARKER))
+        if err != nil {
+            logMan.LogMessage("error", "Error sending the end transfer message:  %v", err)
+        }
+
+        return
+    }
+
+    // If the run was paused or is hibernating through the admin socket, have the client
+    // request again later instead of ending its transfers or holding the handler
+    if Paused.Load() || Hibernation.Active() {
+        sendTransferWait(connection, logMan)
+        return
+    }
+
+    // If the client is well below the fleet throughput, feed it smaller files first
+    if Transfers.IsSlow(clientAddr, SlowClientRatio, SlowClientMinTransfers) {
+        maxFileSize /= 2
+    }
+
     // Select the next avaible file in the load dir from YAML data
-    filePath, fileSize, err := disk.SelectFile(appConfig.LocalConfig.LoadDir,
-                                               appConfig.ClientConfig.MaxFileSizeInt64)
+    filePath, fileSize, err := selectWordlist(appConfig.LocalConfig.LoadDir, maxFileSize,
+                                              clientAddr)
     if err != nil {
         logMan.LogMessage("error", "Error selecting the next available file to transfer:  %v", err)
         return
     }
 
+    // If only larger files remain, the slow client still takes them so none are left behind
+    if filePath == "" && maxFileSize < fitSize {
+        filePath, fileSize, err = selectWordlist(appConfig.LocalConfig.LoadDir, fitSize,
+                                                 clientAddr)
+        if err != nil {
+            logMan.LogMessage("error", "Error selecting the next available file to transfer:  %v",
+                              err)
+            return
+        }
+    }
+
+    // If the load dir is still being merged, have the client retry once more are ready
+    // instead of holding the handler until the merge readies one
+    if filePath == "" && disk.Ready.Pending() {
+        sendTransferWait(connection, logMan)
+        return
+    }
+
+    // If the load dir is drained, split the queued wordlist of a client far from finishing
+    if filePath == "" && session.Supports(protocol.FeatureWorkStealing) &&
+       stealWork(clientAddr, logMan, t) {
+        filePath, fileSize, err = selectWordlist(appConfig.LocalConfig.LoadDir, fitSize,
+                                                 clientAddr)
+        if err != nil {
+            logMan.LogMessage("error", "Error selecting the next available file to transfer:  %v",
+                              err)
+            return
+        }
+    }
+
+    // If no wordlist fits the free space of the client but larger ones remain, have it
+    // wait for space to be freed instead of ending its work, unless it is idle with no
+    // wordlists queued to free space and idle clients are downscaled
+    if filePath == "" && fitSize < appConfig.ClientConfig.MaxFileSizeInt64 &&
+       wordlistsRemain(appConfig) && !Downscale.Idle(Exceptions.Pending(clientAddr), 0) {
+        sendTransferWait(connection, logMan)
+        return
+    }
+
     // If there are no more files available to be transfered
     if filePath == "" {
         // Send the end transfer message then exit function
@@ -80,11 +591,38 @@ func handleTransfer(connection net.Conn, buffer []byte, waitGroup *sync.WaitGrou
         return
     }
 
-    // Format transfer reply to inform client of selected file name and size
-    sendLength, err := netio.FormatTransferReply(filePath, fileSize, &buffer,
+    // If the SQS control plane is used, stage the file in S3 instead of dialing the client
+    if S3Stage != nil {
+        stageTransfer(connection, buffer, filePath, fileSize,
+                      wordlistDigest(filePath, fileSize, netio.EncodingS3, session, logMan),
+                      appConfig.LocalConfig.BucketName, logMan, clientAddr, t)
+        return
+    }
+
+    encoding := netio.EncodingGzip
+    // If compression is disabled, such as for already compressed data, or the client
+    // does not support it, send as is
+    if appConfig.LocalConfig.DisableCompression ||
+       !session.Supports(protocol.FeatureCompression) {
+        encoding = netio.EncodingNone
+    }
+
+    // If the wordlist is large enough, split it into ranges sent over parallel connections
+    // since a single TLS stream rarely saturates the instance network
+    if appConfig.LocalConfig.ParallelConnections > 1 &&
+       fileSize >= appConfig.LocalConfig.ParallelMinSizeInt64 &&
+       session.Supports(protocol.FeatureParallel) {
+        encoding = netio.EncodingRanges
+    }
+
+    digest := wordlistDigest(filePath, fileSize, encoding, session, logMan)
+    // Format transfer reply to inform client of selected file name, size, encoding, and digest
+    sendLength, err := netio.FormatTransferReply(filePath, fileSize, encoding, digest, &buffer,
                                                  globals.START_TRANSFER_PREFIX)
     if err != nil {
         logMan.LogMessage("error", "Error formatting transfer reply:  %v", err)
+        requeueFile(filePath, exceptions.TransferRetried, clientAddr,
+                    "transfer reply could not be formatted", t)
         return
     }
 
@@ -92,6 +630,8 @@ func handleTransfer(connection net.Conn, buffer []byte, waitGroup *sync.WaitGrou
     _, err = netio.WriteHandler(connection, buffer, sendLength)
     if err != nil {
         logMan.LogMessage("error", "Error sending the transfer reply:  %v", err)
+        requeueFile(filePath, exceptions.TransferRetried, clientAddr,
+                    "transfer reply failed", t)
         return
     }
 
@@ -100,41 +640,75 @@ func handleTransfer(connection net.Conn, buffer []byte, waitGroup *sync.WaitGrou
     err = binary.Read(connection, binary.LittleEndian, &port)
     if err != nil {
         logMan.LogMessage("error", "Error receiving client listener port:  %v", err)
+        requeueFile(filePath, exceptions.TransferRetried, clientAddr,
+                    "client transfer port not received", t)
         return
     }
 
     // Strip the original port used for connection from address
-    ipAddr = strings.Split(ipAddr, ":")[0]
+    ipAddr, _, err = net.SplitHostPort(ipAddr)
+    if err != nil {
+        logMan.LogMessage("error", "Error parsing client address:  %v", err)
+        requeueFile(filePath, exceptions.TransferRetried, clientAddr,
+                    "client address could not be parsed", t)
+        return
+    }
+
     // Format remote address with parsed IP and received port for transfer
-    remoteAddr := ipAddr + ":" + strconv.Itoa(int(port))
+    remoteAddr := net.JoinHostPort(ipAddr, strconv.Itoa(int(port)))
+
+    plaintext := plaintextTransfer(appConfig, session, ipAddr)
+    // If the client can receive over plaintext, tell it the transport of the data
+    // connection so both sides never disagree on whether it uses TLS
+    if session.Supports(protocol.FeaturePlaintext) {
+        transport := netio.TransportTls
+        if plaintext {
+            transport = netio.TransportPlaintext
+        }
+
+        _, err = netio.WriteHandler(connection, []byte{transport}, 1)
+        if err != nil {
+            logMan.LogMessage("error", "Error sending the transfer transport:  %v", err)
+            requeueFile(filePath, exceptions.TransferRetried, clientAddr,
+                        "transfer transport not sent", t)
+            return
+        }
+    }
+
+    dial := func() (net.Conn, error) {
+        return dialClient(appConfig, remoteAddr, ipAddr, plaintext)
+    }
 
     // Make a connection to the remote brain server
-    transferConn, err := tls.Dial("tcp", remoteAddr,
-                                  tlsutils.NewClientTLSConfig(TlsMan.CaCertPool, ipAddr))
+    transferConn, err := dial()
     if err != nil {
         logMan.LogMessage("error", "Error connecting to remote client for transfer:  %v", err)
+        requeueFile(filePath, exceptions.TransferRetried, clientAddr,
+                    "transfer connection failed", t)
         return
     }
 
     // Display the remote client connected for file transfer in left panel
-    t.LeftPanelCh <- display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
-                                                            color.LightCyan, "!"), "",
-                                        color.NeonAzure, "Connected ",
-                                        color.RadiantAmethyst, ipAddr,
-                                        color.NeonAzure, " on port ",
-                                        color.KrakenGlowGreen, strconv.Itoa(int(port)))
+    t.PostLeft(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                      color.LightCyan, "!"), "",
+                                  color.NeonAzure, "Connected ",
+                                  color.RadiantAmethyst, ipAddr,
+                                  color.NeonAzure, " on port ",
+                                  color.KrakenGlowGreen, strconv.Itoa(int(port))))
 
     // Display the file name to be transfered in right panel
-    t.RightPanelCh <- display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
-                                                             color.LightCyan, "!"), "",
-                                         color.RadiantAmethyst, filepath.Base(filePath),
-                                         color.NeonAzure, " transfering to ",
-                                         color.RadiantAmethyst, ipAddr)
+    t.PostRight(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "!"), "",
+                                   color.RadiantAmethyst, filepath.Base(filePath),
+                                   color.NeonAzure, " transfering to ",
+                                   color.RadiantAmethyst, ipAddr))
 
     logMan.LogMessage("info", "Connected remote client %s on port %d, %s to be transfered",
-                      ipAddr, port, filePath)
+                      ipAddr, port, filePath, zap.Bool("plaintext", plaintext))
     // Increment waitgroup counter
     waitGroup.Add(1)
+    // Track the transfer in the web dashboard
+    WebUi.TransferStarted(clientAddr)
 
     go func() {
         // Close transfer connection on local exit
@@ -149,839 +723,6705 @@ func handleTransfer(connection net.Conn, buffer []byte, waitGroup *sync.WaitGrou
             waitGroup.Done()
         }()
 
-        // Transfer the file to client
-        err = netio.TransferFile(transferConn, filePath, fileSize)
+        progressKey := clientAddr + " " + filePath
+        reportProgress := func(progress netio.Progress) {
+            t.SetProgress(progressKey, formatTransferProgress(filepath.Base(filePath), ipAddr,
+                                                              progress))
+
+            Events.Emit(eventstream.TransferProgress, map[string]any{
+                "client":      clientAddr,
+                "done":        progress.Done,
+                "eta_seconds": int64(progress.Eta.Seconds()),
+                "file":        filepath.Base(filePath),
+                "rate":        progress.Rate,
+                "size":        progress.Total,
+            })
+        }
+        // Display a progress bar of the transfer below the right panel until it completes
+        tracker := netio.NewTracker(fileSize, TransferProgressInterval, reportProgress)
+        defer t.ClearProgress(progressKey)
+
+        transferStart := time.Now()
+        // Transfer the wordlist to client
+        err = transferWordlist(transferConn, dial, filePath, fileSize, encoding,
+                               appConfig.LocalConfig.ParallelConnections, tracker)
         if err != nil {
             logMan.LogMessage("error", "Error occured transfering file to client %s:  %v",
                               remoteAddr, err)
+
+            reason := "transfer failed"
+            // If the error is of a known class, include it in the reason
+            if class := netio.ErrorClass(err); class != "" {
+                reason += " (" + class + ")"
+            }
+
+            requeueFile(filePath, exceptions.TransferRetried, clientAddr, reason, t)
+        } else {
+            // Record the throughput of the transfer for scheduling decisions
+            Transfers.RecordTransfer(clientAddr, fileSize, time.Since(transferStart))
+            // Track the wordlist as pending until the client returns its results
+            Exceptions.AddPending(clientAddr, filePath)
+
+            // If the client claims its wordlists, queue it so idle clients can steal from it,
+            // ranges are already bounded by the max file size so they are never split
+            if session.Supports(protocol.FeatureWorkStealing) && RangeIndex == nil {
+                Rebalance.Add(clientAddr, filePath, fileSize)
+            }
+
+            auditFile("file_sent", filePath, clientAddr, logMan)
         }
 
+        // Update the transfer status in the web dashboard
+        WebUi.TransferCompleted(clientAddr, err == nil)
+
+        Events.Emit(eventstream.TransferComplete, map[string]any{
+            "client":      clientAddr,
+            "error_class": netio.ErrorClass(err),
+            "file":        filepath.Base(filePath),
+            "size":        fileSize,
+            "success":     err == nil,
+        })
+
         // Display the file path to be transfered in right panel
-        t.RightPanelCh <- display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
-                                                                 color.LightCyan, "$"), "",
-                                             color.RadiantAmethyst, filepath.Base(filePath),
-                                             color.NeonAzure, " transfer completed to ",
-                                             color.RadiantAmethyst, ipAddr)
+        t.PostRight(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                           color.LightCyan, "$"), "",
+                                       color.RadiantAmethyst, filepath.Base(filePath),
+                                       color.NeonAzure, " transfer completed to ",
+                                       color.RadiantAmethyst, ipAddr))
     } ()
 }
 
 
-// Upload the hash and ruleset files (if optional ruleset applied). Goes into continual loop
-// where data is read from the message sockets connection-buffer, checks for a processing complete
-// message which signals exiting the loop, finally after the loop received cracked hash and log file.
+// Sets up the merger of the load dir wordlists from the configured sizes, with merging
+// preempted by the transfers of the disk IO scheduler.
 //
 // @Parameters
-// - connection:  Network socket connection for handling messaging
-// - waitGroup:  Used to synchronize the Goroutines running
 // - appConfig:  The configuration struct with loaded yaml program data
-// - logMan:  The kloudlogs logger manager for local logging
-// - remoteAddr:  IP address to remote client that has connected
-// - t:  The tui interface for displaying output
+// - report:  Receives the progress of the merge, nil when unreported
+// - ready:  Receives each wordlist once it is final, nil when unreported
 //
-func handleConnection(connection net.Conn, waitGroup *sync.WaitGroup,
-                      appConfig *conf.AppConfig, logMan *kloudlogs.LoggerManager,
-                      remoteAddr string, t *tui.TUI) {
-    var buffer []byte
-    var err error
-    // Close the connection on local exit
-    defer func() {
-        err = connection.Close()
-        if err != nil {
-            logMan.LogMessage("Error", "Error closing client connection %s:  %v",
-                              connection.RemoteAddr(), err)
-        }
+// @Returns
+// - The configured wordlist merger
+//
+func newMerger(appConfig *conf.AppConfig, report wordlist.MergeProgressFunc,
+               ready wordlist.MergeReadyFunc) *wordlist.Merger {
+    merger, err := wordlist.NewMerger(wordlist.Options{
+        MaxCutSize:     int64(1 * globals.GB),
+        MaxFileSize:    appConfig.ClientConfig.MaxFileSizeInt64,
+        MaxMergingSize: appConfig.LocalConfig.MaxMergingSizeInt64,
+        MaxRange:       appConfig.LocalConfig.MaxSizeRange,
+        OnProgress:     report,
+        OnReady:        ready,
+        Scheduler:      DiskIo,
+    })
+    if err != nil {
+        log.Fatalf("Error setting up wordlist merger:  %v", err)
+    }
 
-        // Decrement the active connection count
-        CurrentConnections.Add(-1)
+    return merger
+}
 
-        // Display the connection termination information in the left tui panel
-        t.LeftPanelCh <- display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
-                                                                color.LightCyan, "-"), "",
-                                            color.NeonAzure, "Connection closed for ",
-                                            color.RadiantAmethyst, remoteAddr)
 
-        logMan.LogMessage("info", "Connection processing handled",
-                        zap.Int32("remaining connections", CurrentConnections.Load()))
+// Formats the progress line of the wordlist merge displayed before the TUI starts.
+//
+// @Parameters
+// - progress:  The current progress of the merge
+//
+// @Returns
+// - The formatted progress line
+//
+func formatMergeProgress(progress wordlist.MergeProgress) string {
+    fraction := 1.0
+    // If the load dir has data, calculate how much was merged
+    if progress.BytesTotal > 0 {
+        fraction = float64(progress.BytesMerged) / float64(progress.BytesTotal)
+    }
 
-        // Decrement waitGroup counter on local exit
-        waitGroup.Done()
-    } ()
+    eta := "--"
+    // If enough was merged to estimate the remaining time
+    if progress.Eta > 0 {
+        eta = progress.Eta.String()
+    }
 
-    defer func () {
-        // Receive log file from client
-        _, err = netio.ReceiveFile(connection, buffer, ReceivedDir,
-                                globals.LOG_TRANSFER_PREFIX)
-        if err != nil {
-            logMan.LogMessage("error", "Error receiving log file:  %v", err)
-            return
-        }
+    return display.CtextMulti(color.NeonAzure, "Merging wordlists ",
+                              color.KrakenGlowGreen, tui.ProgressBar(fraction, 20),
+                              color.NeonAzure, fmt.Sprintf(" %3.0f%%  %d/%d files  " +
+                                                           "%.2f/%.2f MB  ETA %s",
+                                                           fraction * 100,
+                                                           progress.FilesProcessed,
+                                                           progress.FilesTotal,
+                                                           float64(progress.BytesMerged) /
+                                                           float64(globals.MB),
+                                                           float64(progress.BytesTotal) /
+                                                           float64(globals.MB), eta))
+}
 
-        // Notify the log file has been received in the tui right panel
-        t.RightPanelCh <- display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
-                                                                color.LightCyan, "$"), "",
-                                             color.NeonAzure, "Log file received from client ",
-                                             color.RadiantAmethyst, remoteAddr)
-    } ()
 
-    // Set buffer to receive client PEM certificate
-    buffer = make([]byte, 2 * globals.KB)
+// Merges the wordlists in the load dir into files of up to the max file size, then digests
+// the merged wordlists so clients can verify them after receipt.
+//
+// @Parameters
+// - appConfig:  The configuration struct with loaded yaml program data
+//
+func mergeLoadDir(appConfig *conf.AppConfig) {
+    // Stop merging on Ctrl-C, leaving the load dir to be resumed by the next run
+    mergeCtx, stopMerge := signal.NotifyContext(context.Background(), os.Interrupt,
+                                                syscall.SIGTERM)
+
+    // Merge the wordlists in the load dir based on max file size, rewriting the progress
+    // line in place unless JSON output keeps stdout clean
+    merger := newMerger(appConfig, func(progress wordlist.MergeProgress) {
+        if Events == nil {
+            fmt.Print("\r" + formatMergeProgress(progress))
+        }
+    }, nil)
+    err := merger.Run(mergeCtx, appConfig.LocalConfig.LoadDir)
+    stopMerge()
 
-    // Receive the client PEM certificate bytes
-    bytesRead, err := netio.ReadHandler(connection, &buffer)
-    if err != nil {
-        logMan.LogMessage("error", "Error reading client PEM cert:  %v", err)
-        return
+    // End the progress line
+    if Events == nil {
+        fmt.Println()
     }
 
-    // Add the read client PEM cert to the cert pool
-    err = TlsMan.AddCACert(buffer[:bytesRead])
+    // If the merge was interrupted, the wordlists merged so far are kept for the next run
+    if errors.Is(err, context.Canceled) {
+        log.Fatalf("Wordlist merging interrupted, rerun to resume merging the load dir")
+    } else if err != nil {
+        log.Fatalf("Error merging wordlists:  %v", err)
+    }
+
+    // Delete any leftover folders in load dir
+    err = wordlist.RemoveMergeSubdirs(appConfig.LocalConfig.LoadDir)
     if err != nil {
-        logMan.LogMessage("error", "Error adding PEM cert to pool:  %v", err)
-        return
+        log.Fatalf("Error deleting load dir subdirs:  %v", err)
     }
 
-    // Notify TLS cerificate has been received in the tui right panel
-    t.RightPanelCh <- display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
-                                                             color.LightCyan, "$"), "",
-                                         color.NeonAzure, "TLS certificate received from client ",
-                                         color.RadiantAmethyst, remoteAddr)
-
-    // Reset buffer to messaging size
-    buffer = make([]byte, globals.MESSAGE_BUFFER_SIZE)
+    printMessage(display.CtextMulti(color.FoamWhite, "\\-->",
+                                   display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "$"), "",
+                                   color.NeonAzure, "Wordlist merging process completed"))
 
-    // Upload the hash file to connection client
-    err = netio.UploadFile(connection, buffer, appConfig.LocalConfig.HashFilePath,
-                           globals.HASHES_TRANSFER_PREFIX)
+    // Digest the merged wordlists so clients can verify them after receipt
+    Manifest = manifest.New()
+    err = Manifest.AddDir(appConfig.LocalConfig.LoadDir)
     if err != nil {
-        logMan.LogMessage("error", "Error sending the hash file to client:  %v", err)
-        return
+        log.Fatalf("Error generating wordlist manifest:  %v", err)
     }
+}
 
-    // Notify the hash file has been sent in the tui right panel
-    t.RightPanelCh <- display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
-                                                             color.LightCyan, "$"), "",
-                                         color.NeonAzure, "Hash file sent to client ",
-                                         color.RadiantAmethyst, remoteAddr)
 
-    // If a ruleset path was specified
-    if appConfig.LocalConfig.RulesetPath != "" {
-        // Upload the ruleset file to connection client
-        err = netio.UploadFile(connection, buffer, appConfig.LocalConfig.RulesetPath,
-                               globals.RULESET_TRANSFER_PREFIX)
-        if err != nil {
-            logMan.LogMessage("error", "Error sending the ruleset to server:  %v", err)
-            return
+// Merges the load dir wordlists in the background while the server runs, marking each
+// merged wordlist ready to be selected as soon as it is final. Once the merge ends every
+// wordlist left in the load dir becomes selectable. The merge is cancelled on Ctrl-C,
+// SIGTERM, or once the fleet is stopped, leaving the load dir to be resumed by the next
+// run, and the server exits once an interrupted merge stopped.
+//
+// @Parameters
+// - appConfig:  The configuration struct with loaded yaml program data
+//
+// @Returns
+// - Channel receiving the errors of the merge, closed once merging ends
+// - Function cancelling the merge and waiting for it to stop, called before exiting
+//
+func pipelineMerge(appConfig *conf.AppConfig) (<-chan error, func()) {
+    disk.Ready = disk.NewReadySet()
+    // The load dir changes until merged, so wordlists are digested as they are sent
+    Manifest = manifest.New()
+    merger := newMerger(appConfig, nil, disk.Ready.Add)
+    // Buffered for both errors so the merge never blocks before the logger is set up
+    mergeErrs := make(chan error, 2)
+    // Closed once the merge stopped
+    merged := make(chan struct{})
+
+    signalCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt,
+                                                   syscall.SIGTERM)
+    mergeCtx, cancelMerge := context.WithCancel(signalCtx)
+
+    // Cancel the merge once the fleet is stopped, the run is ending without it
+    go func() {
+        select {
+        case <-FleetStopped:
+            cancelMerge()
+        case <-mergeCtx.Done():
         }
+    } ()
 
-        // Notify the ruleset file has been sent in the tui right panel
-        t.RightPanelCh <- display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
-                                                                 color.LightCyan, "$"), "",
-                                             color.NeonAzure, "Ruleset file sent to client ",
-                                             color.RadiantAmethyst, remoteAddr)
-    }
+    go func() {
+        err := merger.Run(mergeCtx, appConfig.LocalConfig.LoadDir)
+        interrupted := signalCtx.Err() != nil
+        // Signals end the server as usual once the merge is done
+        stopSignals()
+        cancelMerge()
 
-    for {
-        // Read data from connected client
-        bytesRead, err := netio.ReadHandler(connection, &buffer)
-        if err != nil {
-            logMan.LogMessage("error", "Error reading data from socket:  %v", err)
+        // Make the wordlists left in the load dir selectable once merging ends
+        defer func() {
+            disk.Ready.Complete()
+            close(mergeErrs)
+            close(merged)
+
+            // If the server was interrupted, exit now that no merge output is partial
+            if interrupted {
+                fatalExit(nil, "Server interrupted, rerun to resume merging the load dir")
+            }
+        } ()
+
+        // If the merge was cancelled, the wordlists merged so far are kept for the next run
+        if errors.Is(err, context.Canceled) {
             return
         }
 
-        // Save read content into isolated buffer
-        readBuffer := buffer[:bytesRead]
-
-        // If the read data contains the processing complete message
-        if bytes.Contains(readBuffer, globals.PROCESSING_COMPLETE) {
-            break
+        // If merging failed, the load dir is served as is instead of ending the run
+        if err != nil {
+            mergeErrs <- fmt.Errorf("error merging wordlists, serving the rest unmerged - %w",
+                                    err)
+            return
         }
 
-        // If the read data contains transfer request message
-        if bytes.Contains(readBuffer, globals.TRANSFER_REQUEST_MARKER) {
-            // Call method to handle file transfer based
-            handleTransfer(connection, buffer, waitGroup,
-                           appConfig, logMan, remoteAddr, t)
+        // Delete any leftover folders in load dir
+        err = wordlist.RemoveMergeSubdirs(appConfig.LocalConfig.LoadDir)
+        if err != nil {
+            mergeErrs <- fmt.Errorf("error deleting load dir subdirs - %w", err)
         }
+    } ()
+
+    return mergeErrs, func() {
+        cancelMerge()
+        <-merged
     }
+}
 
-    // Receive cracked user hash file from client
-    _, err = netio.ReceiveFile(connection, buffer, ReceivedDir,
-                               globals.LOOT_TRANSFER_PREFIX)
+
+// Replies to the transfer request that no wordlist is ready for the client yet, so it
+// requests again later instead of ending its work.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - logMan:  The kloudlogs logger manager for local logging
+//
+func sendTransferWait(connection net.Conn, logMan *kloudlogs.LoggerManager) {
+    _, err := netio.WriteHandler(connection, globals.TRANSFER_WAIT_MARKER,
+                                 len(globals.TRANSFER_WAIT_MARKER))
     if err != nil {
-        logMan.LogMessage("error", "Error receiving cracked user hashes:  %v", err)
-        return
+        logMan.LogMessage("error", "Error sending the transfer wait message:  %v", err)
     }
+}
 
-    // Notify the cracked hashes file has been received in the tui right panel
-    t.RightPanelCh <- display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
-                                                             color.LightCyan, "$"), "",
-                                         color.NeonAzure, "Cracked hashes received from client ",
-                                         color.RadiantAmethyst, remoteAddr)
+
+// Formats the progress line of a file transfer displayed below the right panel.
+//
+// @Parameters
+// - fileName:  The name of the file being transferred
+// - ipAddr:  The IP address of the client receiving the file
+// - progress:  The current progress of the transfer
+//
+// @Returns
+// - The formatted progress line
+//
+func formatTransferProgress(fileName string, ipAddr string, progress netio.Progress) string {
+    fraction := 0.0
+    // If the file has data, calculate how much was sent
+    if progress.Total > 0 {
+        fraction = float64(progress.Done) / float64(progress.Total)
+    }
+
+    eta := "--"
+    // If there is enough throughput to estimate the remaining time
+    if progress.Eta > 0 {
+        eta = progress.Eta.String()
+    }
+
+    return display.CtextMulti(color.RadiantAmethyst, fileName,
+                              color.NeonAzure, " -> " + ipAddr + " ",
+                              color.KrakenGlowGreen, tui.ProgressBar(fraction, 20),
+                              color.NeonAzure, fmt.Sprintf(" %3.0f%%  %.2f MB/s  ETA %s",
+                                                           fraction * 100,
+                                                           progress.Rate / float64(globals.MB),
+                                                           eta))
 }
 
 
-// Set up listener and enter loop where the amount of active connections is checked
-// until the specified number of instances is equal to the active connections the
-// listener will wait until a connection is accepted. Increment the active connections
-// counter and waitgroup, and pass the connection with other args into handler goroutine.
+// Splits the largest unstarted wordlist queued on the client estimated to finish last,
+// leaving the second half in the load dir for the idle client requesting work.
 //
 // @Parameters
-// - appConfig:  The configuration struct with loaded yaml program data
+// - idleAddr:  The address of the idle client requesting work
 // - logMan:  The kloudlogs logger manager for local logging
+// - t:  The tui interface for displaying output
 //
-func startServer(appConfig *conf.AppConfig, logMan *kloudlogs.LoggerManager) {
-    // Establish wait group for Goroutine synchronization
-    var waitGroup sync.WaitGroup
+// @Returns
+// - true if a wordlist was split, otherwise false
+//
+func stealWork(idleAddr string, logMan *kloudlogs.LoggerManager, t *tui.TUI) bool {
+    victimAddr, filePath, ok := Rebalance.Steal(idleAddr)
+    if !ok {
+        return false
+    }
 
-    // Setup TUI interface for and ensure it closes on local exit
-    t := tui.NewTUI(100, "Connections", 500 * time.Millisecond, 3, "File Transfers")
-    go t.Start(color.SkyBlue, color.BrightMagenta, color.BrightMint)
-    defer t.Stop()
+    splitPath := rebalance.SplitPath(filePath)
+    // Copy the second half of the wordlist while its client is free to start it whole
+    offset, err := rebalance.CopyHalf(filePath, splitPath)
+    if err != nil {
+        logMan.LogMessage("error", "Error splitting %s queued on %s:  %v", filePath,
+                          victimAddr, err)
+        // Release the wordlist so it can be selected again
+        Rebalance.Abort(victimAddr, filePath)
+        return false
+    }
 
-    // Set up context handler for TLS listener
-    ctx, cancel := context.WithCancel(context.Background())
-    defer cancel()
-    // Set up the TLS listener to accept incoming connections
-    tlsListener, err := TlsMan.SetupTlsListenerHandler(TlsMan.TlsCertificate,
-                                                       TlsMan.CaCertPool, ctx, "",
-                                                       appConfig.LocalConfig.ListenerPort, nil)
+    // If the client started the wordlist while it was copied, it is processed whole
+    if !Rebalance.Claim(victimAddr, filePath) {
+        os.Remove(splitPath + disk.PartSuffix)
+        return false
+    }
+
+    // Move the copied second half into the load dir, the client waits on this to start
+    err = rebalance.CommitHalf(filePath, splitPath, offset)
     if err != nil {
-        logMan.LogMessage("fatal", "Error setting up TLS listener:  %v", err)
+        logMan.LogMessage("error", "Error splitting %s queued on %s:  %v", filePath,
+                          victimAddr, err)
+        // Release the wordlist so the client is not held waiting for the split
+        Rebalance.Abort(victimAddr, filePath)
+        return false
     }
 
-    // Close the TLS listener on local exit
-    defer func() {
-        err = tlsListener.Close()
-        if err != nil {
-            logMan.LogMessage("error", "Error closing TLS listener:  %v", err)
-        }
-    } ()
+    // Inform the victim of the truncation once it starts the wordlist
+    Rebalance.Split(victimAddr, filePath, offset)
 
-    // Display port TLS listener is on in the left panel
-    t.LeftPanelCh <- display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
-                                                            color.LightCyan, "!"), "",
-                                        color.NeonAzure, "Listening on port ",
-                                        color.KrakenGlowGreen,
-                                        strconv.Itoa(appConfig.LocalConfig.ListenerPort))
+    // Display the split wordlist in the right panel
+    t.PostRight(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "!"), "",
+                                   color.RadiantAmethyst, filepath.Base(filePath),
+                                   color.NeonAzure, " split from ",
+                                   color.RadiantAmethyst, victimAddr,
+                                   color.NeonAzure, " for ",
+                                   color.RadiantAmethyst, idleAddr))
+
+    Events.Emit(eventstream.WorkSplit, map[string]any{
+        "client": idleAddr,
+        "file":   filepath.Base(filePath),
+        "offset": offset,
+        "split":  filepath.Base(splitPath),
+        "victim": victimAddr,
+    })
+
+    logMan.LogMessage("info", "Split %s queued on %s at byte %d into %s for %s", filePath,
+                      victimAddr, offset, splitPath, idleAddr)
+    return true
+}
 
-    logMan.LogMessage("info", "Listening for connections on port %d ..",
-                      appConfig.LocalConfig.ListenerPort)
 
-    for {
-        // If current number of connection is greater than or equal to number of instances
-        if CurrentConnections.Load() >= int32(appConfig.LocalConfig.NumberInstances) {
-            logMan.LogMessage("info", "All remote clients are connected")
-            break
-        }
+// Uploads the selected file to S3 under the run prefix, then sends the transfer reply
+// so the client downloads it, used by the SQS control plane where clients can not be
+// dialed back.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - buffer:  The buffer storing network messaging
+// - filePath:  The path of the file to be staged
+// - fileSize:  The size of the file to be staged
+// - digest:  The manifest digest of the file, empty if it is not verified
+// - bucketName:  The name of the S3 bucket the file is staged in
+// - logMan:  The kloudlogs logger manager for local logging
+// - clientAddr:  The ID of the client the file is staged for
+// - t:  The tui interface for displaying output
+//
+func stageTransfer(connection net.Conn, buffer []byte, filePath string, fileSize int64,
+                   digest string, bucketName string, logMan *kloudlogs.LoggerManager,
+                   clientAddr string, t *tui.TUI) {
+    file, err := openWordlist(filePath)
+    if err != nil {
+        logMan.LogMessage("error", "Error opening file to stage in S3:  %v", err)
+        requeueFile(filePath, exceptions.TransferRetried, clientAddr,
+                    "staged file could not be opened", t)
+        return
+    }
 
-        // Wait for an incoming connection
-        connection, err := tlsListener.Accept()
-        if err != nil {
-            logMan.LogMessage("error", "Error accepting client connection:  %v", err)
-            return
+    // Close the file on local exit
+    defer file.Close()
+
+    // Track the transfer in the web dashboard
+    WebUi.TransferStarted(clientAddr)
+    transferStart := time.Now()
+
+    // Upload the file to the key the client downloads it from
+    err = S3Stage.UploadS3Object(bucketName,
+                                 controlplane.TransferKey(QueuePrefix, filepath.Base(filePath)),
+                                 file, 30 * time.Minute)
+    if err == nil {
+        // Format transfer reply to inform client of the staged file name and size
+        var sendLength int
+        sendLength, err = netio.FormatTransferReply(filePath, fileSize, netio.EncodingS3,
+                                                    digest, &buffer,
+                                                    globals.START_TRANSFER_PREFIX)
+        if err == nil {
+            // Send the transfer reply so the client downloads the staged file
+            _, err = netio.WriteHandler(connection, buffer, sendLength)
         }
+    }
 
-        // Increment the active connection count
-        CurrentConnections.Add(1)
+    if err != nil {
+        logMan.LogMessage("error", "Error staging file for client %s:  %v", clientAddr, err)
+        requeueFile(filePath, exceptions.TransferRetried, clientAddr, "staging failed", t)
+    } else {
+        // Record the throughput of the transfer for scheduling decisions
+        Transfers.RecordTransfer(clientAddr, fileSize, time.Since(transferStart))
+        // Track the wordlist as pending until the client returns its results
+        Exceptions.AddPending(clientAddr, filePath)
 
-        // Get the remote IP address for output/logging
-        remoteAddr := connection.RemoteAddr().String()
+        auditFile("file_staged", filePath, clientAddr, logMan)
+    }
 
-        // Display the connection spawning information in the left tui panel
-        t.LeftPanelCh <- display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
-                                                                color.LightCyan, "+"), "",
-                                            color.NeonAzure, "Accepted ",
-                                            color.RadiantAmethyst, remoteAddr)
+    // Update the transfer status in the web dashboard
+    WebUi.TransferCompleted(clientAddr, err == nil)
 
-        logMan.LogMessage("info", "Connection accepted from %s", remoteAddr,
-                          zap.Int32("active connections", CurrentConnections.Load()))
+    Events.Emit(eventstream.TransferComplete, map[string]any{
+        "client":  clientAddr,
+        "file":    filepath.Base(filePath),
+        "size":    fileSize,
+        "success": err == nil,
+    })
 
-        // Increment wait group and handle connection in separate Goroutine
-        waitGroup.Add(1)
-        go handleConnection(connection, &waitGroup, appConfig, logMan, remoteAddr, t)
+    // If the file was staged, display it in the right panel
+    if err == nil {
+        t.PostRight(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                           color.LightCyan, "$"), "",
+                                       color.RadiantAmethyst, filepath.Base(filePath),
+                                       color.NeonAzure, " staged in S3 for ",
+                                       color.RadiantAmethyst, clientAddr))
     }
+}
 
-    // Wait for all active Goroutines to finish before shutting down the server
-    waitGroup.Wait()
 
-    // Sleep for a few seconds so information can be displayed before tui is stopped
-    time.Sleep(5 * time.Second)
+// Deletes the files staged in S3 for a client that disconnected before downloading them,
+// so the objects of a dead client are not left in the bucket.
+//
+// @Parameters
+// - filePaths:  The paths of the files staged for the client
+// - bucketName:  The name of the S3 bucket the files are staged in
+// - logMan:  The kloudlogs logger manager for local logging
+// - clientAddr:  The ID of the disconnected client
+//
+func deleteStagedFiles(filePaths []string, bucketName string,
+                       logMan *kloudlogs.LoggerManager, clientAddr string) {
+    // Iterate through the staged files deleting each, missing objects were downloaded
+    for _, filePath := range filePaths {
+        key := controlplane.TransferKey(QueuePrefix, filepath.Base(filePath))
+
+        err := S3Stage.DeleteS3Object(bucketName, key, 1 * time.Minute)
+        if err != nil {
+            logMan.LogMessage("error", "Error deleting file staged for client %s:  %v",
+                              clientAddr, err)
+        }
+    }
 }
 
 
-// Takes passed in args and formats into user data generated for EC2 creation.
+// Reads a batch of streamed log lines from the client and appends it to the live log
+// file of the client.
 //
 // @Parameters
-// - appConf:  The configuration instance that stores program YAML data
-// - keyName:  The name of the key of the S3 bucket
-// - ipAddrs:  Slice of IP addresses to be formatted into CSV string
-// - ssmParam:  The path where the certificate is stored in SSM param store
+// - connection:  Network socket connection for handling messaging
+// - message:  The read message starting with the log batch header
+// - remoteAddr:  IP address to remote client that has connected
 //
 // @Returns
-// - The generated EC2 user data with args formatted into it
 // - Error if it occurs, otherwise nil on success
 //
-func ec2UserDataGen(appConf *conf.AppConfig, keyName string, ipAddrs []string,
-                    ssmParam string) (string, error) {
-    var hasRuleset bool
-    // Convert the slice of IP addresses to CSV string
-    ipAddrsCsv, err := data.SliceToCsv(ipAddrs)
+func handleLogBatch(connection net.Conn, message []byte, remoteAddr string) error {
+    // Read the rest of the batch following the header
+    logData, err := logstream.ReadBatch(connection, message)
     if err != nil {
-        return "", err
+        return err
     }
 
-    // If a ruleset path was specified
-    if appConf.LocalConfig.RulesetPath != "" {
-        hasRuleset = true
-    } else {
-        hasRuleset = false
-    }
-
-    data := fmt.Sprintf(`#!/bin/bash
-set -euxo pipefail
-exec > >(tee /var/log/user-data.log | logger -t user-data -s 2>/dev/console) 2>&1
-
-# === NVMe RAID0 instance-store setup ===
-mapfile -t DEVICES < <(lsblk -d -n -o NAME,TYPE |
-    awk '$2=="disk" && $1 ~ /^nvme[0-9]+n1$/ {print "/dev/" $1}')
-if (( ${#DEVICES[@]} == 0 )); then
-    echo "ERROR: no NVMe instance‐store devices found"
-    shutdown -h now
-    exit 1
-fi
-
-retries=0
-until DEBIAN_FRONTEND=noninteractive apt-get update && apt-get install -y mdadm; do
-    ((retries++))
-    (( retries>=3 )) && { echo "ERROR: apt-get install failed"; shutdown -h now; exit 1; }
-    sleep 5
-done
-
-if ! mdadm --detail /dev/md0 &>/dev/null; then
-    yes | mdadm --create /dev/md0 --level=0 --raid-devices=${#DEVICES[@]} "${DEVICES[@]}"
-fi
-
-mdadm --detail --scan | tee /etc/mdadm/mdadm.conf
-update-initramfs -u
-
-if ! blkid /dev/md0 &>/dev/null; then
-    mkfs.ext4 -F /dev/md0
-fi
-
-mkdir -p /mnt/instance-store
-grep -q '/mnt/instance-store' /etc/fstab || \
-    echo "/dev/md0  /mnt/instance-store  ext4  defaults,nofail  0 2" >> /etc/fstab
-mountpoint -q /mnt/instance-store || mount /mnt/instance-store
-
-echo "✓ Instance-store ready at /mnt/instance-store"
-
-# === Application bootstrap ===
-apt update && apt upgrade -y && apt install -y hashcat
-
-CWD=$(pwd)
-aws s3 cp s3://%s/%s $CWD/client --region %s --no-progress
-chmod +x $CWD/client
-$CWD/client -applyOptimization=%t \
-            -awsRegion=%s \
-            -certSsmParam=%s \
-            -charSet1=%s \
-            -charSet2=%s \
-            -charSet3=%s \
-            -charSet4=%s \
-            -crackingMode=%s \
-            -hashMask=%s \
-            -hashType=%s \
-            -hasRuleset=%t \
-            -ipAddrs=%s \
-            -isTesting=%t \
-            -logMode=%s \
-            -logPath=%s \
-            -maxFileSizeInt64=%d \
-            -maxTransfers=%d \
-            -port=%d \
-            -workload=%s
-`, appConf.LocalConfig.BucketName, keyName,
-   appConf.ClientConfig.Region, true,
-   appConf.ClientConfig.Region, ssmParam,
-   appConf.ClientConfig.CharSet1, appConf.ClientConfig.CharSet2,
-   appConf.ClientConfig.CharSet3, appConf.ClientConfig.CharSet4,
-   appConf.ClientConfig.CrackingMode, appConf.ClientConfig.HashMask,
-   appConf.ClientConfig.HashType, hasRuleset, ipAddrsCsv, false,
-   appConf.ClientConfig.LogMode, appConf.ClientConfig.LogPath,
-   appConf.ClientConfig.MaxFileSizeInt64, appConf.ClientConfig.MaxTransfers,
-   appConf.LocalConfig.ListenerPort, appConf.ClientConfig.Workload)
-
-    return data, nil
+    return ClientLogs.Append(remoteAddr, logData)
 }
 
 
-// Generates permission policy for the server.
+// Reads a hashcat execution reported by the client and records it in the audit log.
 //
 // @Parameters
-// - region:  The AWS region where actions will be performed
-// - accountId:  The AWS account ID where actions will be performed
-// - ssmParam:  The path where the certificate is stored in SSM param store
-// - bucketName:  The name of the S3 bucket where actions will be performed
-// - clientRoleName:  The name of IAM role the client will be using
+// - connection:  Network socket connection for handling messaging
+// - message:  The read message starting with the execution header
+// - remoteAddr:  IP address to remote client that has connected
 //
 // @Returns
-// - The generated permissions policy with args formatted into it
+// - Error if it occurs, otherwise nil on success
 //
-func serverPermPolicyGen(region string, accountId string, ssmParam string,
-                         bucketName string, clientRoleName string) string {
-    return fmt.Sprintf(`{
-  "Version": "2012-10-17",
-  "Statement": [
-    {
-      "Sid": "SSMUploadClientCert",
-      "Effect": "Allow",
-      "Action": [
-        "ssm:PutParameter"
-      ],
-      "Resource": "arn:aws:ssm:%s:%s:parameter%s*"
-    },
-    {
-      "Sid": "S3UploadClientBinary",
-      "Effect": "Allow",
-      "Action": [
-        "s3:PutObject",
-        "s3:PutObjectAcl"
-      ],
-      "Resource": "arn:aws:s3:::%s/*"
-    },
-    {
-      "Sid": "EC2LifecycleControl",
-      "Effect": "Allow",
-      "Action": [
-        "ec2:RunInstances",
-        "ec2:TerminateInstances",
-        "ec2:DescribeInstances",
-        "ec2:CreateTags"
-      ],
-      "Resource": [
-        "arn:aws:ec2:%s:%s:instance/*",
-        "arn:aws:ec2:%s:%s:subnet/*",
-        "arn:aws:ec2:%s:%s:security-group/*"
-      ]
-    },
-    {
-      "Sid": "EC2PassRoleForInstanceProfile",
-      "Effect": "Allow",
-      "Action": [
-        "iam:PassRole"
-      ],
-      "Resource": "arn:aws:iam::%s:role/%s"
+func handleHashcatExecution(connection net.Conn, message []byte, remoteAddr string) error {
+    // Read the rest of the execution following the header
+    execution, err := audit.ReadExecution(connection, message)
+    if err != nil {
+        return err
     }
-  ]
-}`, region, accountId, ssmParam, bucketName, region, accountId, region,
-    accountId, region, accountId, accountId, clientRoleName)
+
+    Audit.Record(audit.CategoryHashcat, "hashcat_executed", execution.Fields(remoteAddr))
+    return nil
 }
 
 
-// Generates trust policy for the server.
+// Reads a hashcat status forwarded by the client while cracking, displaying the speed,
+// progress, rejected candidates, and hottest device temperature of the client below the
+// right panel until the client disconnects. The status is combined into the fleet rate.
 //
 // @Parameters
-// - accountId:  The AWS account ID where actions will be performed
-// - iamUser:  The IAM user that the policy will apply to
+// - connection:  Network socket connection for handling messaging
+// - message:  The read message starting with the status header
+// - appConfig:  The configuration struct with loaded yaml program data
+// - logMan:  The kloudlogs logger manager for local logging
+// - remoteAddr:  IP address to remote client that has connected
+// - t:  The tui interface for displaying output
 //
 // @Returns
-// - The generated trust policy with args formatted into it
+// - Error if it occurs, otherwise nil on success
 //
-func serverTrustPolicyGen(accountId string, iamUser string) string {
-    return fmt.Sprintf(`{
-  "Version":"2012-10-17",
-  "Statement":[{
-    "Effect":"Allow",
-    "Principal":{
-      "AWS":"arn:aws:iam::%s:user/%s"
-    },
-    "Action":"sts:AssumeRole"
-  }]
-}`, accountId, iamUser)
+func handleHashcatStatus(connection net.Conn, message []byte, appConfig *conf.AppConfig,
+                         logMan *kloudlogs.LoggerManager, remoteAddr string,
+                         t *tui.TUI) error {
+    // Read the rest of the status following the header
+    status, err := hashcat.ReadStatus(connection, message)
+    if err != nil {
+        return err
+    }
+
+    now := time.Now()
+    // Count the work no client has started so the fleet estimate covers the whole run
+    HashRate.SetUnstarted(unstartedJobs(appConfig))
+    HashRate.Update(remoteAddr, status, now)
+
+    var eta time.Duration
+    // If hashcat estimated when the job stops
+    if status.EstimatedStop > 0 {
+        eta = time.Unix(status.EstimatedStop, 0).Sub(now)
+    }
+
+    // Mirror the progress of the client and the fleet on the web dashboard
+    WebUi.HashcatProgress(remoteAddr, status.Name(), status.Fraction(), status.Speed(), eta)
+    fleet := HashRate.Snapshot(now)
+    WebUi.SetFleetRate(fleet.Speed, fleet.Coverage, fleet.Eta)
+
+    logMan.LogMessage("debug", "Client hashcat status",
+                      append(status.LogArgs(), zap.String("client", remoteAddr))...)
+    t.SetProgress(hashcatStatusKey(remoteAddr), formatHashcatStatus(remoteAddr, status))
+
+    Events.Emit(eventstream.HashcatStatus, map[string]any{
+        "client":    remoteAddr,
+        "progress":  status.Progress[0],
+        "recovered": status.RecoveredHashes[0],
+        "rejected":  status.Rejected,
+        "speed":     status.Speed(),
+        "status":    status.Name(),
+        "temp_max":  status.MaxTemp(),
+        "total":     status.Progress[1],
+    })
+    return nil
 }
 
 
-// Generates permission policy for the client.
+// Counts the wordlists, line ranges, and keyspace ranges no client has been assigned.
 //
 // @Parameters
-// - bucketName:  The name of the S3 bucket where actions will be performed
-// - region:  The AWS region where actions will be performed
-// - accountId:  The AWS account ID where actions will be performed
-// - paramPath:  The path where the certificate is stored in SSM param store
-// - logGroup:  The name of the CloudWatch group being utilized
+// - appConfig:  The configuration struct with loaded yaml program data
 //
 // @Returns
-// - The generated permissions policy with args formatted into it
+// - The number of unstarted jobs
 //
-func clientPermPolicyGen(bucketName string, region string, accountId string,
-                         paramPath string, logGroup string) string {
-    return fmt.Sprintf(`{
-  "Version": "2012-10-17",
-  "Statement": [
-    {
-      "Sid": "S3DownloadBinary",
-      "Effect": "Allow",
-      "Action": [
-        "s3:GetObject"
-      ],
-      "Resource": "arn:aws:s3:::%s/*"
-    },
-    {
-      "Sid": "SSMFetchParameters",
-      "Effect": "Allow",
-      "Action": [
-        "ssm:GetParameter",
-        "ssm:GetParameters",
-        "ssm:GetParametersByPath"
-      ],
-      "Resource": [
-        "arn:aws:ssm:%s:%s:parameter%s*"
-      ]
-    },
-    {
-      "Sid": "CloudWatchLogging",
-      "Effect": "Allow",
-      "Action": [
-        "logs:CreateLogGroup",
-        "logs:CreateLogStream",
-        "logs:PutLogEvents"
-      ],
-      "Resource": "arn:aws:logs:%s:%s:log-group:/%s*"
+func unstartedJobs(appConfig *conf.AppConfig) int {
+    jobs := Keyspace.Unassigned()
+    maxFileSize := appConfig.ClientConfig.MaxFileSizeInt64
+
+    // If wordlists are assigned by range, count the unclaimed ranges
+    if RangeIndex != nil {
+        return jobs + disk.RemainingRanges(RangeIndex, maxFileSize)
     }
-  ]
-}`, bucketName, region, accountId, paramPath, region, accountId, logGroup)
+
+    remaining, err := disk.RemainingFiles(appConfig.LocalConfig.LoadDir, maxFileSize)
+    // If the load dir could be read, add its unclaimed wordlists
+    if err == nil {
+        jobs += remaining
+    }
+
+    return jobs
 }
 
 
-// Generates trust policy for the client.
+// Checks whether any wordlist or line range within the max file size is still unclaimed.
 //
-// @Returns
-// - The generated trust policy with args formatted into it
+// @Parameters
+// - appConfig:  The configuration struct with loaded yaml program data
 //
-func clientTrustPolicyGen() string {
-    return `{
-  "Version": "2012-10-17",
-  "Statement": [{
-    "Effect":    "Allow",
-    "Principal": { "Service": "ec2.amazonaws.com" },
-    "Action":    "sts:AssumeRole"
-  }]
-}`
+// @Returns
+// - true if a wordlist remains to be assigned, otherwise false
+//
+func wordlistsRemain(appConfig *conf.AppConfig) bool {
+    maxFileSize := appConfig.ClientConfig.MaxFileSizeInt64
+
+    // If wordlists are assigned by range, check the unclaimed ranges
+    if RangeIndex != nil {
+        return disk.RemainingRanges(RangeIndex, maxFileSize) > 0
+    }
+
+    remaining, err := disk.RemainingFiles(appConfig.LocalConfig.LoadDir, maxFileSize)
+    return err == nil && remaining > 0
 }
 
 
-// Sets up AWS credentials, uses IAM permissions in the credentials to set up
-// client and server roles in IAM. Then assumes created server role via STS
-// service. Puts generated TLS certificate in SSM parameter store and client
-// binary in S3 bucket for later retrieval. Concludes by launching EC2 instances.
+// Formats the combined rate of the fleet as the header line of the TUI.
 //
 // @Parameters
-// - appConfig:  The configuration instance with program YAML data
-// - publicIps:  List of public IPs to format into user data template
+// - fleet:  The combined progress of the fleet
 //
 // @Returns
-// - The initialized AWS configuration instance
-// - The EC2 manager instance to utilize for later operations
-// - Error if it occurs, otherwise nil on success
+// - The formatted header line
 //
-func awsSetup(appConfig *conf.AppConfig, publicIps []string) (
-              aws.Config, *awsutils.Ec2Manger, error) {
-    var ec2Man *awsutils.Ec2Manger
-    // Set up the AWS credentials based on local chain or environment variables
-    awsConfig, _, _, err := awsutils.AwsConfigSetup(appConfig.LocalConfig.Region,
-                                                    1 * time.Minute)
+func formatFleetRate(fleet hashcat.FleetStatus) string {
+    // If no client has forwarded a status yet
+    if fleet.Total == 0 {
+        return display.Ctext(color.NeonAzure, "Fleet:  waiting for hashcat status")
+    }
+
+    eta := "--"
+    // If the fleet is cracking at a known speed
+    if fleet.Eta > 0 {
+        eta = fleet.Eta.String()
+    }
+
+    return display.CtextMulti(color.NeonAzure, "Fleet:  ",
+                              color.KrakenGlowGreen, formatHashRate(fleet.Speed),
+                              color.NeonAzure, fmt.Sprintf(" over %d clients  ", fleet.Clients),
+                              color.KrakenGlowGreen, tui.ProgressBar(fleet.Coverage, 20),
+                              color.NeonAzure, fmt.Sprintf(" %5.1f%% keyspace  ETA %s",
+                                                           fleet.Coverage * 100, eta))
+}
+
+
+// Formats the key of the hashcat status line of a client in the TUI.
+//
+// @Parameters
+// - remoteAddr:  IP address to remote client that has connected
+//
+// @Returns
+// - The progress key of the status line
+//
+func hashcatStatusKey(remoteAddr string) string {
+    return "hashcat " + remoteAddr
+}
+
+
+// Formats the hashcat status of a client as a progress line.
+//
+// @Parameters
+// - remoteAddr:  IP address to remote client that has connected
+// - status:  The hashcat status forwarded by the client
+//
+// @Returns
+// - The formatted status line
+//
+func formatHashcatStatus(remoteAddr string, status hashcat.Status) string {
+    temp := "--"
+    // If the temperature of the devices is monitored
+    if status.MaxTemp() > 0 {
+        temp = strconv.Itoa(status.MaxTemp()) + "c"
+    }
+
+    return display.CtextMulti(color.RadiantAmethyst, remoteAddr,
+                              color.NeonAzure, " " + status.Name() + " ",
+                              color.KrakenGlowGreen, tui.ProgressBar(status.Fraction(), 20),
+                              color.NeonAzure, fmt.Sprintf(" %5.1f%%  %s  rejected %d  %s",
+                                                           status.Fraction() * 100,
+                                                           formatHashRate(status.Speed()),
+                                                           status.Rejected, temp))
+}
+
+
+// Formats a hash rate with the largest unit it has at least one of.
+//
+// @Parameters
+// - speed:  The speed in hashes per second
+//
+// @Returns
+// - The formatted hash rate
+//
+func formatHashRate(speed float64) string {
+    units := []string{"H/s", "kH/s", "MH/s", "GH/s", "TH/s", "PH/s"}
+    index := 0
+
+    // Scale the speed down until it is under a thousand of the unit
+    for speed >= 1000 && index < len(units) - 1 {
+        speed /= 1000
+        index++
+    }
+
+    return fmt.Sprintf("%.1f %s", speed, units[index])
+}
+
+
+// Creates the sink delivering the audit log entries to a kloudlogs logger.
+//
+// @Parameters
+// - logger:  The logger the entries are delivered to
+//
+// @Returns
+// - The sink passed into the audit log
+//
+func auditSink(logger kloudlogs.Logger) func(entry audit.Entry) {
+    return func(entry audit.Entry) {
+        logger.Info(entry.Action, zap.String("category", entry.Category),
+                    zap.Any("fields", entry.Fields), zap.String("hash", entry.Hash),
+                    zap.String("prev", entry.Prev), zap.Int64("seq", entry.Seq),
+                    zap.Time("time", entry.Time))
+    }
+}
+
+
+// Records a file sent to or received from a client in the audit log along with the hash
+// of its contents.
+//
+// @Parameters
+// - action:  The name of the audited action
+// - filePath:  The path of the transferred file
+// - clientAddr:  The ID of the client the file was transferred with
+// - logMan:  The kloudlogs logger manager for local logging
+//
+func auditFile(action string, filePath string, clientAddr string,
+               logMan *kloudlogs.LoggerManager) {
+    // If auditing is disabled, skip hashing the file
+    if Audit == nil {
+        return
+    }
+
+    fields := map[string]any{"client": clientAddr, "path": filePath}
+
+    var hash string
+    wordlist, err := openWordlist(filePath)
+    if err == nil {
+        // Hash the file so the audit log shows exactly what was transferred
+        hash, err = audit.ReaderSha256(wordlist)
+        wordlist.Close()
+    }
     if err != nil {
-        return awsConfig, ec2Man, err
+        logMan.LogMessage("error", "Error hashing file for audit log:  %v", err)
+    } else {
+        fields["sha256"] = hash
     }
 
-    // Setup client to IAM service
-    iamClient := iam.NewFromConfig(awsConfig)
+    // If the size of the file is available
+    if fileInfo, err := os.Stat(filePath); err == nil {
+        fields["size"] = fileInfo.Size()
+    } else if _, _, length, isRange := disk.ParseRangePath(filePath); isRange {
+        fields["size"] = length
+    }
 
-    // Generate the EC2 clients trust and permissions policy templates
-    trustPolicy := clientTrustPolicyGen()
-    permissionsPolicy := clientPermPolicyGen(appConfig.LocalConfig.BucketName,
-                                             appConfig.ClientConfig.Region,
-                                             appConfig.LocalConfig.AccountId,
-                                             "/kloud-kraken/tls/cert", "Kloud-Kraken")
-    // Create and apply the EC2 client role
-    _, err = awsutils.IamRoleCreation(iamClient, 2 * time.Minute, "ClientRole",
-                                      trustPolicy, "ClientPermissions",
-                                      permissionsPolicy, true)
+    Audit.Record(audit.CategoryFile, action, fields)
+}
+
+
+// Reads a flush of cracked hashes from the client and merges the ones not yet cracked
+// into the run potfile.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - message:  The read message starting with the loot flush header
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+// - remoteAddr:  IP address to remote client that has connected
+// - t:  The TUI instance for displaying the flushed hashes
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func handleLootFlush(connection net.Conn, message []byte, logMan *kloudlogs.LoggerManager,
+                     remoteAddr string, t *tui.TUI) error {
+    // Read the rest of the flush following the header
+    lootData, err := potfile.ReadFlush(connection, message)
     if err != nil {
-        return awsConfig, ec2Man, err
+        return err
     }
 
-    // Generate the servers trust and permissions policy templates
-    trustPolicy = serverTrustPolicyGen(appConfig.LocalConfig.AccountId,
-                                       appConfig.LocalConfig.IamUsername)
-    permissionsPolicy = serverPermPolicyGen(appConfig.LocalConfig.Region,
-                                            appConfig.LocalConfig.AccountId,
-                                            "/kloud-kraken/tls/cert",
-                                            appConfig.LocalConfig.BucketName,
-                                            "ClientRole")
-    // Create and apply role for local server permissions
-    serverArn, err := awsutils.IamRoleCreation(iamClient, 2 * time.Minute, "ServerRole",
-                                               trustPolicy, "ServerPermissions",
-                                               permissionsPolicy, false)
+    added, err := Potfile.Add(lootData)
     if err != nil {
-        return awsConfig, ec2Man, err
+        return err
+    }
+
+    // If the flush only had hashes already in the potfile
+    if added == 0 {
+        return nil
     }
 
-    fmt.Println(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+    notifyFirstCrack(remoteAddr, added)
+
+    logMan.LogMessage("info", "Cracked hashes flushed", zap.String("client", remoteAddr),
+                      zap.Int("new", added), zap.Int("total", Potfile.Count()))
+
+    // Display the newly cracked hashes in the tui right panel
+    t.PostRight(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
                                                        color.LightCyan, "$"), "",
-                                   color.NeonAzure, "IAM server and client roles created"))
+                                   color.NeonAzure, fmt.Sprintf("%d new cracked " +
+                                                                "hashes flushed from ",
+                                                                added),
+                                   color.RadiantAmethyst, remoteAddr))
+    return nil
+}
 
-    // Set up client to Security Token Service
-    stsClient := sts.NewFromConfig(awsConfig)
-    // Format role ARN from created role
-    roleArn := "arn:aws:iam::" + serverArn + ":role/ServerRole"
-    // Create a provider that will call STS AssumeRole under the covers
-    assumeProvider := stscreds.NewAssumeRoleProvider(stsClient, roleArn)
 
-    // Create fresh AWS config from new STS provider
-    awsConfig, err = config.LoadDefaultConfig(
-        context.TODO(),
-        config.WithRegion(appConfig.LocalConfig.Region),
-        config.WithCredentialsProvider(aws.NewCredentialsCache(assumeProvider)),
-    )
+// Reads lines from stdin to select the client shown in the TUI log tail, an empty
+// line cycles to the next client and an IP address selects its client.
+//
+func selectTailClient() {
+    scanner := bufio.NewScanner(os.Stdin)
+
+    for scanner.Scan() {
+        input := strings.TrimSpace(scanner.Text())
+        // If only Enter was pressed, cycle to the next client
+        if input == "" {
+            ClientLogs.Cycle()
+        } else {
+            ClientLogs.Select(input)
+        }
+    }
+}
+
+
+// Assigns the next keyspace range to the client and replies with the range, a wait
+// message if the remaining ranges are assigned to other clients, or the end transfer
+// message once all ranges have been completed.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - logMan:  The kloudlogs logger manager for local logging
+// - remoteAddr:  IP address to remote client that has connected
+// - t:  The tui interface for displaying output
+//
+func handleKeyspaceRequest(connection net.Conn, logMan *kloudlogs.LoggerManager,
+                           remoteAddr string, t *tui.TUI) {
+    var reply []byte
+    var rng keyspace.Range
+    assigned := false
+    done := true
+
+    switch {
+    // If the run is being drained through the admin socket, assign no new ranges
+    case Draining.Load():
+    // If the run was paused or is hibernating through the admin socket, the client waits
+    case Paused.Load(), Hibernation.Active():
+        done = false
+    // If keyspace splitting is in use, get the next range for the client
+    case Keyspace != nil:
+        rng, assigned, done = Keyspace.Next(remoteAddr)
+
+        // If the remaining ranges are in progress on other clients, end an idle client
+        // instead of having it wait on them when idle clients are downscaled
+        if !assigned && !done && Downscale.Idle(Exceptions.Pending(remoteAddr),
+                                                Keyspace.Assigned(remoteAddr)) {
+            done = true
+        }
+    }
+
+    switch {
+    case assigned:
+        reply = keyspace.FormatRange(globals.KEYSPACE_RANGE_PREFIX, rng)
+    case done:
+        reply = globals.END_TRANSFER_MARKER
+    default:
+        reply = globals.KEYSPACE_WAIT_MARKER
+    }
+
+    // Send the keyspace reply to the client
+    _, err := netio.WriteHandler(connection, reply, len(reply))
     if err != nil {
-        return awsConfig, ec2Man, err
+        logMan.LogMessage("error", "Error sending keyspace reply:  %v", err)
+        return
     }
 
-    // Establish client to SSM
-    ssmMan := awsutils.NewSsmManager(awsConfig)
-    // Push the servers certificate PEM into SSM parameter store
-    param, err := ssmMan.PutSsmParameter("/kloud-kraken/tls/cert",
-                                         string(TlsMan.CertPemBlock),
-                                         1 * time.Minute)
+    // If a range was not assigned there is nothing to display
+    if !assigned {
+        return
+    }
+
+    // Display the assigned keyspace range in the right panel
+    t.PostRight(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "!"), "",
+                                   color.NeonAzure, "Keyspace range ",
+                                   color.KrakenGlowGreen,
+                                   strconv.FormatInt(rng.Skip, 10) + "+" +
+                                   strconv.FormatInt(rng.Limit, 10),
+                                   color.NeonAzure, " assigned to ",
+                                   color.RadiantAmethyst, remoteAddr))
+
+    logMan.LogMessage("info", "Keyspace range assigned", zap.Int64("skip", rng.Skip),
+                      zap.Int64("limit", rng.Limit), zap.String("client", remoteAddr))
+}
+
+
+// Parses the completed keyspace range reported by the client and marks it complete.
+//
+// @Parameters
+// - message:  The keyspace complete message received from the client
+// - logMan:  The kloudlogs logger manager for local logging
+// - remoteAddr:  IP address to remote client that has connected
+// - t:  The tui interface for displaying output
+//
+func handleKeyspaceComplete(message []byte, logMan *kloudlogs.LoggerManager,
+                            remoteAddr string, t *tui.TUI) {
+    // If keyspace splitting is not in use
+    if Keyspace == nil {
+        return
+    }
+
+    // Parse the completed range from the message
+    rng, err := keyspace.ParseRange(message, globals.KEYSPACE_COMPLETE_PREFIX)
     if err != nil {
-        return awsConfig, ec2Man, err
+        logMan.LogMessage("error", "Error parsing keyspace complete message:  %v", err)
+        return
     }
 
-    fmt.Println(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+    // If the range was not assigned to the client
+    if !Keyspace.Complete(rng, remoteAddr) {
+        logMan.LogMessage("error", "Keyspace range completed by client it was not assigned to",
+                          zap.Int64("skip", rng.Skip), zap.String("client", remoteAddr))
+        return
+    }
+
+    completed, total := Keyspace.Progress()
+    // Display the keyspace progress in the right panel
+    t.PostRight(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
                                                        color.LightCyan, "$"), "",
-                                   color.NeonAzure, "TLS certificate uploaded to " +
-                                   "SSM Parameter Store for client retrieval"))
+                                   color.NeonAzure, "Keyspace range completed by ",
+                                   color.RadiantAmethyst, remoteAddr,
+                                   color.NeonAzure, " (",
+                                   color.KrakenGlowGreen, strconv.Itoa(completed) +
+                                   "/" + strconv.Itoa(total),
+                                   color.NeonAzure, ")"))
+
+    logMan.LogMessage("info", "Keyspace range completed", zap.Int64("skip", rng.Skip),
+                      zap.Int64("limit", rng.Limit), zap.String("client", remoteAddr),
+                      zap.Int("completed", completed), zap.Int("total", total))
+}
 
-    // Establish client to S3
-    s3Man := awsutils.NewS3Manager(awsConfig)
-    // Check to see if S3 bucket exists
-    exists, err := s3Man.BucketExists(appConfig.LocalConfig.BucketName, 1 * time.Minute)
+
+// Parses the cracked hashes the client reported for a processed wordlist and records
+// them so the remaining wordlists are reordered by hit rate.
+//
+// @Parameters
+// - message:  The wordlist stats message received from the client
+// - logMan:  The kloudlogs logger manager for local logging
+// - remoteAddr:  IP address to remote client that has connected
+//
+func handleWordlistStats(message []byte, logMan *kloudlogs.LoggerManager, remoteAddr string) {
+    // Parse the wordlist stats from the message
+    fileName, cracked, size, err := schedule.ParseStats(message)
     if err != nil {
-        return awsConfig, ec2Man, err
+        logMan.LogMessage("error", "Error parsing wordlist stats message:  %v", err)
+        return
     }
 
-    // If S3 bucket does not exist create one
-    if !exists {
-        err = s3Man.CreateBucket(appConfig.LocalConfig.BucketName, 1 * time.Minute)
-        if err != nil {
-            return awsConfig, ec2Man, err
+    Schedule.Record(fileName, cracked, size)
+    yield, _ := Schedule.Yield(fileName)
+    // Update the processing rate the completion estimate of the client is based on
+    Rebalance.Done(remoteAddr, fileName, size)
+
+    // If the hash file was split, the wordlist is done once it ran against every shard
+    if disk.Shards != nil {
+        filePath, found := Exceptions.RemovePending(remoteAddr, fileName)
+        // If the wordlist still has to run against other shards, make it selectable again
+        if found && !disk.Shards.Complete(filePath, remoteAddr) {
+            disk.Claims.Release(filePath)
         }
+    }
 
-        fmt.Println(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
-                                                           color.LightCyan, "$"), "",
-                                       color.NeonAzure, "Created S3 bucket ",
-                                       color.RadiantAmethyst, appConfig.LocalConfig.BucketName))
+    logMan.LogMessage("info", "Wordlist stats reported", zap.String("wordlist", fileName),
+                      zap.Int64("cracked", cracked), zap.Int64("size", size),
+                      zap.String("family", schedule.Family(fileName)),
+                      zap.Float64("family cracked per MB", yield.Rate()),
+                      zap.String("client", remoteAddr))
+}
+
+
+// Requeues a wordlist the client deleted after it failed manifest verification, so it
+// is sent again rather than left pending on the client.
+//
+// @Parameters
+// - message:  The wordlist rejection message received from the client
+// - logMan:  The kloudlogs logger manager for local logging
+// - remoteAddr:  IP address to remote client that has connected
+// - t:  The tui interface for displaying output
+//
+func handleWordlistRejected(message []byte, logMan *kloudlogs.LoggerManager,
+                            remoteAddr string, t *tui.TUI) {
+    fileName, err := manifest.ParseRejection(message)
+    if err != nil {
+        logMan.LogMessage("error", "Error parsing wordlist rejection message:  %v", err)
+        return
+    }
+
+    filePath, found := Exceptions.RemovePending(remoteAddr, fileName)
+    // If the wordlist was not delivered to the client
+    if !found {
+        logMan.LogMessage("error", "Rejected wordlist was not pending on the client",
+                          zap.String("wordlist", fileName), zap.String("client", remoteAddr))
+        return
     }
 
-    // Read the client binary into memory
-    binData, err := os.ReadFile("./client")
+    // The client never processes the rejected wordlist, so drop it from its queue
+    Rebalance.Done(remoteAddr, fileName, 0)
+    requeueFile(filePath, exceptions.TransferRetried, remoteAddr,
+                "failed manifest verification", t)
+}
+
+
+// Marks the wordlist the client is about to process as started so it can no longer be
+// split, replying with the offset to truncate it at if it was split while queued.
+//
+// @Parameters
+// - connection:  The network socket connection for handling messaging
+// - message:  The work start message received from the client
+// - logMan:  The kloudlogs logger manager for local logging
+// - remoteAddr:  IP address to remote client that has connected
+//
+func handleWorkStart(connection net.Conn, message []byte,
+                     logMan *kloudlogs.LoggerManager, remoteAddr string) {
+    reply := globals.WORK_KEEP_MARKER
+    // Parse the name of the wordlist the client is starting
+    fileName, err := rebalance.ParseStart(message)
     if err != nil {
-        return awsConfig, ec2Man, err
+        // Still reply so the client is not left waiting, keeping the wordlist whole
+        logMan.LogMessage("error", "Error parsing work start message:  %v", err)
+    } else {
+        // Waits for an in progress split of the wordlist to finish
+        offset, truncate := Rebalance.Start(remoteAddr, fileName)
+        reply = rebalance.FormatReply(offset, truncate)
     }
 
-    // Upload the client binary to S3 Bucket
-    keyName, err := s3Man.PutS3Object(appConfig.LocalConfig.BucketName, "client",
-                                      binData, 1 * time.Minute)
+    _, err = netio.WriteHandler(connection, reply, len(reply))
     if err != nil {
-        return awsConfig, ec2Man, err
+        logMan.LogMessage("error", "Error sending work start reply:  %v", err)
     }
+}
 
-    fmt.Println(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
-                                                       color.LightCyan, "$"), "",
-                                   color.NeonAzure, "Uploaded client binary to S3 bucket ",
-                                   color.RadiantAmethyst, appConfig.LocalConfig.BucketName))
 
-    // Generate user data script to set up client program in EC2
-    userData, err := ec2UserDataGen(appConfig, keyName, publicIps, param)
+// Directs the client to fetch the shared file from a seeding peer, then waits for the
+// client to report whether the peer fetch succeeded.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - buffer:  The buffer storing network messaging
+// - filePath:  The path to the shared file on the server
+// - seed:  The seeding peer selected to serve the file
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func sendPeerFetch(connection net.Conn, buffer []byte, filePath string,
+                   seed peer.SeedInfo) error {
+    // Get the size of the shared file
+    fileInfo, err := os.Stat(filePath)
     if err != nil {
-        return awsConfig, ec2Man, err
+        return fmt.Errorf("error getting shared file info - %w", err)
+    }
+
+    fileName := filepath.Base(filePath)
+    // Create a one-time token for the client to present to the seeder
+    nonce, mac, err := peer.NewToken(seed.Secret, fileName)
+    if err != nil {
+        return err
+    }
+
+    fetchMsg := peer.FormatFetch(peer.FetchInfo{FileName: fileName,
+                                                FileSize: fileInfo.Size(),
+                                                IpAddr:   seed.IpAddr,
+                                                Mac:      mac,
+                                                Nonce:    nonce,
+                                                Port:     seed.Port})
+    // If the fetch message would be truncated by the client message buffer
+    if len(fetchMsg) > globals.MESSAGE_BUFFER_SIZE {
+        return fmt.Errorf("peer fetch message exceeds message buffer size")
+    }
+
+    // Send the peer fetch message to the client
+    _, err = netio.WriteHandler(connection, fetchMsg, len(fetchMsg))
+    if err != nil {
+        return err
+    }
+
+    // Send the seeding peers PEM certificate to the client
+    _, err = netio.WriteHandler(connection, seed.CertPem, len(seed.CertPem))
+    if err != nil {
+        return err
+    }
+
+    // Wait for the client to report the result of the peer fetch
+    bytesRead, err := netio.ReadHandler(connection, &buffer)
+    if err != nil {
+        return err
+    }
+
+    // If the client was unable to fetch the file from the peer
+    if !bytes.Equal(buffer[:bytesRead], globals.PEER_DONE_MARKER) {
+        return fmt.Errorf("client failed to fetch shared file from peer %s", seed.IpAddr)
+    }
+
+    return nil
+}
+
+
+// Sends a file shared by every client, if a peer is seeding the file the client is
+// directed to fetch it from the peer, otherwise or on failure it is uploaded directly.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - buffer:  The buffer storing network messaging
+// - filePath:  The path to the shared file to be sent
+// - prefix:  The transfer prefix for uploading the file directly
+// - remoteAddr:  IP address to remote client that has connected
+// - logMan:  The kloudlogs logger manager for local logging
+// - t:  The tui interface for displaying output
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func sendSharedFile(connection net.Conn, buffer []byte, filePath string, prefix []byte,
+                    remoteAddr string, logMan *kloudlogs.LoggerManager, t *tui.TUI) error {
+    // If a peer is seeding the shared file
+    if seed, found := Peers.Select(filePath, remoteAddr); found {
+        err := sendPeerFetch(connection, buffer, filePath, seed)
+        if err == nil {
+            logMan.LogMessage("info", "Shared file fetched from peer",
+                              zap.String("file", filePath), zap.String("peer", seed.IpAddr),
+                              zap.String("client", remoteAddr))
+            return nil
+        }
+
+        logMan.LogMessage("error", "Peer fetch failed, uploading file directly:  %v", err)
+        recordException(exceptions.TransferRetried, remoteAddr, filepath.Base(filePath) +
+                        " peer fetch failed, uploaded directly", t)
+    }
+
+    return netio.UploadFile(connection, buffer, filePath, prefix)
+}
+
+
+// Registers the client as a seeder of the shared files it has received.
+//
+// @Parameters
+// - message:  The peer seed message received from the client
+// - clientPem:  The PEM certificate the client seeds with
+// - appConfig:  The configuration struct with loaded yaml program data
+// - logMan:  The kloudlogs logger manager for local logging
+// - hashFilePaths:  The paths to the hash files sent to the client
+// - remoteAddr:  IP address to remote client that has connected
+// - t:  The tui interface for displaying output
+//
+func handlePeerSeed(message []byte, clientPem []byte, appConfig *conf.AppConfig,
+                    logMan *kloudlogs.LoggerManager, hashFilePaths []string,
+                    remoteAddr string, t *tui.TUI) {
+    // If peer sharing is not in use
+    if Peers == nil {
+        return
+    }
+
+    // Parse the seeder port and secret from the message
+    port, secret, err := peer.ParseSeed(message)
+    if err != nil {
+        logMan.LogMessage("error", "Error parsing peer seed message:  %v", err)
+        return
+    }
+
+    // If rulesets are being shared, the client seeds them as well
+    filePaths := append(slices.Clone(hashFilePaths), appConfig.LocalConfig.RulesetInputs...)
+
+    host, _, err := net.SplitHostPort(remoteAddr)
+    if err != nil {
+        logMan.LogMessage("error", "Error parsing seeder address:  %v", err)
+        return
+    }
+
+    Peers.Register(remoteAddr, peer.SeedInfo{CertPem: clientPem,
+                                             IpAddr:  host,
+                                             Port:    port,
+                                             Secret:  secret}, filePaths...)
+
+    // Display the registered seeder in the right panel
+    t.PostRight(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "$"), "",
+                                   color.NeonAzure, "Client seeding shared files ",
+                                   color.RadiantAmethyst, remoteAddr))
+
+    logMan.LogMessage("info", "Client registered as peer seeder",
+                      zap.String("client", remoteAddr), zap.Int("port", port))
+}
+
+
+// Replies to the client version check with the current client binary version and
+// the S3 key it is stored under, when auto update is disabled the client version is
+// echoed back so the client never updates.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - message:  The version check message received from the client
+// - logMan:  The kloudlogs logger manager for local logging
+// - remoteAddr:  IP address to remote client that has connected
+//
+func handleVersionCheck(connection net.Conn, message []byte,
+                        logMan *kloudlogs.LoggerManager, remoteAddr string) {
+    // Parse the running version from the client message
+    clientVersion, err := update.ParseCheck(message)
+    if err != nil {
+        logMan.LogMessage("error", "Error parsing version check message:  %v", err)
+        return
+    }
+
+    version, key := ClientUpdate.Current()
+    // If auto update is not in use, reply with the clients own version
+    if version == "" {
+        version = clientVersion
+        key = "none"
+    }
+
+    reply := update.FormatVersion(version, key)
+    // Send the current client version to the client
+    _, err = netio.WriteHandler(connection, reply, len(reply))
+    if err != nil {
+        logMan.LogMessage("error", "Error sending client version reply:  %v", err)
+        return
+    }
+
+    // If the client is running an outdated version
+    if version != clientVersion {
+        logMan.LogMessage("info", "Client notified of new binary version",
+                          zap.String("client", remoteAddr), zap.String("version", version))
+    }
+}
+
+
+// Waits for the client restarting on a new version to reconnect, counting it as finished
+// once the reconnect deadline passes so a failed restart does not hold up the run.
+//
+// @Parameters
+// - remoteAddr:  IP address to remote client that has disconnected to restart
+// - logMan:  The kloudlogs logger manager for local logging
+// - t:  The tui interface for displaying output
+//
+func expectRestart(remoteAddr string, logMan *kloudlogs.LoggerManager, t *tui.TUI) {
+    host, _, _ := net.SplitHostPort(remoteAddr)
+
+    ClientRestarts.Expect(host, UpdateReconnectTimeout, func() {
+        RemainingClients.Add(-1)
+
+        logMan.LogMessage("warn", "Updating client did not reconnect, counted as finished",
+                          zap.String("client", remoteAddr),
+                          zap.Duration("timeout", UpdateReconnectTimeout))
+        recordException(exceptions.ResultMissing, remoteAddr, "client did not reconnect " +
+                        "after restarting on a new version", t)
+    })
+}
+
+
+// Replies to the certificate check of a client with the current server certificate if
+// it was rotated since the client last trusted one, otherwise with the current marker.
+//
+// @Parameters
+// - connection:  The network socket connection for handling messaging
+// - message:  The certificate check message received from the client
+// - logMan:  The kloudlogs logger manager for local logging
+// - remoteAddr:  IP address to remote client that has connected
+//
+func handleCertCheck(connection net.Conn, message []byte,
+                     logMan *kloudlogs.LoggerManager, remoteAddr string) {
+    // Parse the fingerprint of the certificate the client trusts
+    clientFingerprint, err := tlsutils.ParseCertCheck(message)
+    if err != nil {
+        logMan.LogMessage("error", "Error parsing certificate check message:  %v", err)
+        return
+    }
+
+    certPem, _ := TlsMan.Current()
+    fingerprint, err := tlsutils.Fingerprint(certPem)
+    if err != nil {
+        logMan.LogMessage("error", "Error computing server certificate fingerprint:  %v", err)
+        return
+    }
+
+    reply := globals.CERT_CURRENT_MARKER
+    // If the certificate was rotated, send the new one over the authenticated connection
+    if fingerprint != clientFingerprint {
+        reply = certPem
+    }
+
+    _, err = netio.WriteHandler(connection, reply, len(reply))
+    if err != nil {
+        logMan.LogMessage("error", "Error sending certificate check reply:  %v", err)
+        return
+    }
+
+    // If the client was sent the rotated certificate
+    if fingerprint != clientFingerprint {
+        logMan.LogMessage("info", "Client sent rotated TLS certificate",
+                          zap.String("client", remoteAddr),
+                          zap.String("fingerprint", fingerprint))
+    }
+}
+
+
+// Replies to the abort check of a client with the abort marker once the operator aborted
+// the run, or the hibernate marker once the operator hibernated it, so the client
+// interrupts hashcat and returns its restore files.
+//
+// @Parameters
+// - connection:  The network socket connection for handling messaging
+// - logMan:  The kloudlogs logger manager for local logging
+// - remoteAddr:  IP address to remote client that has connected
+// - session:  The protocol version and features negotiated with the client
+//
+// @Returns
+// - Whether the client was told to checkpoint for the hibernation
+//
+func handleAbortCheck(connection net.Conn, logMan *kloudlogs.LoggerManager,
+                      remoteAddr string, session protocol.Hello) bool {
+    reply := globals.CONTINUE_MARKER
+
+    switch {
+    // If the operator aborted the run
+    case Aborting.Load():
+        reply = globals.ABORT_MARKER
+    // If the operator hibernated the run and the client can checkpoint for it
+    case Hibernation.Active() && session.Supports(protocol.FeatureHibernate):
+        reply = globals.HIBERNATE_MARKER
+    }
+
+    _, err := netio.WriteHandler(connection, reply, len(reply))
+    if err != nil {
+        logMan.LogMessage("error", "Error sending abort check reply:  %v", err)
+        return false
+    }
+
+    switch {
+    // If the client was told to abort
+    case bytes.Equal(reply, globals.ABORT_MARKER):
+        logMan.LogMessage("info", "Client notified of run abort",
+                          zap.String("client", remoteAddr))
+    // If the client was told to checkpoint
+    case bytes.Equal(reply, globals.HIBERNATE_MARKER):
+        logMan.LogMessage("info", "Client notified of run hibernation",
+                          zap.String("client", remoteAddr))
+        return true
+    }
+
+    return false
+}
+
+
+// Exchanges hellos with a newly connected client, negotiating the protocol version and
+// features of the session. A client that can not be downgraded to, or that does not
+// present the connection token of the run, is sent the reason it was refused in place of
+// the hello reply.
+//
+// @Parameters
+// - connection:  The network socket connection for handling messaging
+// - keyspaceMode:  Whether the run assigns keyspace ranges the client must process
+//
+// @Returns
+// - The negotiated session
+// - Error if it occurs or the client was refused, otherwise nil on success
+//
+func negotiateSession(connection net.Conn, keyspaceMode bool) (protocol.Hello, error) {
+    buffer := make([]byte, globals.MESSAGE_BUFFER_SIZE)
+
+    // Receive the hello the client sends first
+    bytesRead, err := netio.ReadHandler(connection, &buffer)
+    if err != nil {
+        return protocol.Hello{}, fmt.Errorf("error reading client hello - %w", err)
+    }
+
+    var reason string
+    var session protocol.Hello
+
+    remote, err := protocol.ParseHello(buffer[:bytesRead])
+    // If the client predates the hello exchange, it sends its certificate first
+    if err != nil {
+        reason = fmt.Sprintf("no hello received, protocol version %d or newer is required",
+                             protocol.MinVersion)
+    // If a token is required but the client did not present the one of the run
+    } else if ConnectionToken != "" && !protocol.TokenMatches(ConnectionToken, remote.Token) {
+        reason = "missing or improper connection token"
+    } else {
+        session, err = protocol.Negotiate(protocol.Local(), remote)
+        if err != nil {
+            reason = err.Error()
+        // If keyspace ranges are assigned but the client can not process them
+        } else if keyspaceMode && !session.Supports(protocol.FeatureKeyspace) {
+            reason = "keyspace ranges are assigned but not supported by the client"
+        }
+    }
+
+    // If auditing is disabled, the client does not need to report its hashcat executions
+    if Audit == nil {
+        session.Features = slices.DeleteFunc(session.Features, func(feature string) bool {
+            return feature == protocol.FeatureAudit
+        })
+    }
+
+    // If the client can not be downgraded to, send why it was refused
+    if reason != "" {
+        refused := protocol.FormatRefused(reason)
+        _, err = netio.WriteHandler(connection, refused, len(refused))
+        if err != nil {
+            return protocol.Hello{}, fmt.Errorf("%s, error sending refusal - %w", reason, err)
+        }
+
+        return protocol.Hello{}, errors.New(reason)
+    }
+
+    reply := protocol.FormatHello(session)
+    // Reply with the negotiated session
+    _, err = netio.WriteHandler(connection, reply, len(reply))
+    if err != nil {
+        return protocol.Hello{}, fmt.Errorf("error sending hello reply - %w", err)
+    }
+
+    return session, nil
+}
+
+
+// Receives the restore bundle of a client that stopped processing on the run abort,
+// holding its hashcat restore files, uncracked hashes, and unprocessed wordlists.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - buffer:  The buffer used for processing socket messaging
+// - logMan:  The kloudlogs logger manager for local logging
+// - remoteAddr:  IP address to remote client that has connected
+// - t:  The tui interface for displaying output
+//
+// @Returns
+// - The path of the received restore bundle, empty if it was not received
+//
+func receiveRestoreBundle(connection net.Conn, buffer []byte, logMan *kloudlogs.LoggerManager,
+                          remoteAddr string, t *tui.TUI) string {
+    // Receive the restore bundle from client
+    bundlePath, err := netio.ReceiveFile(connection, buffer,
+                                         clientReceivedDir(remoteAddr, logMan),
+                                         globals.RESTORE_TRANSFER_PREFIX)
+    if err != nil {
+        logClientError(logMan, remoteAddr, "Error receiving restore bundle", err)
+        return ""
+    }
+
+    // Persist the restore bundle to the results store
+    persistResult(bundlePath, logMan)
+    auditFile("file_received", bundlePath, remoteAddr, logMan)
+
+    logMan.LogMessage("info", "Restore bundle received from aborted client",
+                      zap.String("client", remoteAddr), zap.String("path", bundlePath))
+
+    // Notify the restore bundle has been received in the tui right panel
+    t.PostRight(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "$"), "",
+                                   color.NeonAzure, "Restore bundle received from client ",
+                                   color.RadiantAmethyst, remoteAddr))
+    return bundlePath
+}
+
+
+// Releases the wordlists a client checkpointed for the hibernation did not process, so
+// they are selected again once the fleet is resumed. Without a restore bundle listing
+// them, every wordlist delivered to the client is released.
+//
+// @Parameters
+// - bundlePath:  The path of the restore bundle of the client, empty if not received
+// - logMan:  The kloudlogs logger manager for local logging
+// - remoteAddr:  IP address to remote client that has connected
+// - t:  The tui interface for displaying output
+//
+func releaseHibernatedWork(bundlePath string, logMan *kloudlogs.LoggerManager,
+                           remoteAddr string, t *tui.TUI) {
+    var err error
+    var filePaths, remaining []string
+
+    // If the restore bundle was received, read the wordlists it lists as unprocessed
+    if bundlePath != "" {
+        remaining, err = restore.BundleWordlists(bundlePath)
+        if err != nil {
+            logMan.LogMessage("error", "Error reading hibernated client wordlists:  %v", err)
+        }
+    }
+
+    // If the unprocessed wordlists are unknown, release all those delivered
+    if bundlePath == "" || err != nil {
+        filePaths = Exceptions.TakePending(remoteAddr)
+    }
+
+    // Iterate through the unprocessed wordlists taking them from the client
+    for _, fileName := range remaining {
+        filePath, found := Exceptions.RemovePending(remoteAddr, fileName)
+        if found {
+            filePaths = append(filePaths, filePath)
+        }
+    }
+
+    // Iterate through the taken wordlists making them selectable again
+    for _, filePath := range filePaths {
+        disk.Claims.Release(filePath)
+    }
+
+    logMan.LogMessage("info", "Client checkpointed for hibernation",
+                      zap.String("client", remoteAddr), zap.Int("released", len(filePaths)))
+
+    // Display the checkpointed client in the left panel
+    t.PostLeft(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                      color.LightCyan, "!"), "",
+                                  color.NeonAzure, "Client checkpointed for hibernation ",
+                                  color.RadiantAmethyst, remoteAddr,
+                                  color.NeonAzure, ", wordlists released:  ",
+                                  color.KrakenGlowGreen, strconv.Itoa(len(filePaths))))
+}
+
+
+// Upload the hash and ruleset files (if optional ruleset applied). Goes into continual loop
+// where data is read from the message sockets connection-buffer, checks for a processing complete
+// message which signals exiting the loop, finally after the loop received cracked hash and log file.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - waitGroup:  Used to synchronize the Goroutines running
+// - appConfig:  The configuration struct with loaded yaml program data
+// - logMan:  The kloudlogs logger manager for local logging
+// - remoteAddr:  IP address to remote client that has connected
+// - session:  The session negotiated with the client when it was accepted
+// - t:  The tui interface for displaying output
+//
+func handleConnection(connection net.Conn, waitGroup *sync.WaitGroup,
+                      appConfig *conf.AppConfig, logMan *kloudlogs.LoggerManager,
+                      remoteAddr string, session protocol.Hello, t *tui.TUI) {
+    var buffer []byte
+    var err error
+    aborted := false
+    completed := false
+    hibernated := false
+    restarting := false
+    // Close the connection on local exit
+    defer func() {
+        err = connection.Close()
+        if err != nil {
+            logMan.LogMessage("Error", "Error closing client connection %s:  %v",
+                              connection.RemoteAddr(), err)
+        }
+
+        // Decrement the active connection count
+        CurrentConnections.Add(-1)
+        // Stop listing the client on the admin socket
+        ClientConns.Delete(remoteAddr)
+        // Mark the client as disconnected in the web dashboard
+        WebUi.ClientDisconnected(remoteAddr)
+        // Stop displaying the hashcat status of the client
+        t.ClearProgress(hashcatStatusKey(remoteAddr))
+        // Keep the progress of the job of the client without counting its speed
+        HashRate.Remove(remoteAddr)
+        fleet := HashRate.Snapshot(time.Now())
+        WebUi.SetFleetRate(fleet.Speed, fleet.Coverage, fleet.Eta)
+
+        // Stop selecting the client as a seeder for other peers
+        Peers.Remove(remoteAddr)
+        // Stop estimating the queue of the client for work stealing
+        Rebalance.Remove(remoteAddr)
+        // Free the hash file shard of the client for the next client to connect
+        disk.Shards.Release(remoteAddr)
+
+        // If auto update is in use and the client is not restarting on a new version
+        if ClientUpdate != nil && !restarting {
+            RemainingClients.Add(-1)
+        }
+
+        // If the client is restarting, count it as gone unless it reconnects in time
+        if restarting {
+            expectRestart(remoteAddr, logMan, t)
+        }
+
+        // Requeue any keyspace ranges the client did not complete
+        requeued := Keyspace.Requeue(remoteAddr)
+        if requeued > 0 {
+            logMan.LogMessage("info", "Keyspace ranges requeued from disconnected client",
+                              zap.Int("ranges", requeued), zap.String("client", remoteAddr))
+            recordException(exceptions.WorkRequeued, remoteAddr,
+                            strconv.Itoa(requeued) + " keyspace ranges requeued", t)
+        }
+
+        // Take the wordlists delivered to the client that are now done or abandoned
+        pending := Exceptions.TakePending(remoteAddr)
+
+        // If the client disconnected before returning its results
+        if !completed {
+            recordException(exceptions.ResultMissing, remoteAddr,
+                            "client disconnected before returning cracked hashes", t)
+
+            Notifier.Notify(notify.ClientFailure, map[string]any{
+                "client":  remoteAddr,
+                "pending": len(pending),
+                "reason":  "disconnected before returning cracked hashes",
+            })
+
+            // If the wordlists were staged in S3, delete them before they are staged again
+            if S3Stage != nil {
+                deleteStagedFiles(pending, appConfig.LocalConfig.BucketName, logMan,
+                                  remoteAddr)
+            }
+
+            // Requeue the wordlists the client may not have processed
+            for _, filePath := range pending {
+                requeueFile(filePath, exceptions.WorkRequeued, remoteAddr,
+                            "client disconnected before completing", t)
+            }
+        }
+
+        // Stop billing the instance of the client to the run
+        Instances.Remove(remoteAddr, time.Now())
+
+        Events.Emit(eventstream.ClientDisconnected, map[string]any{
+            "client":                remoteAddr,
+            "remaining_connections": CurrentConnections.Load(),
+        })
+
+        // If the client returned its results with no work left for it, stop it billing,
+        // unless it only stopped to be relaunched when the hibernated fleet is resumed
+        if completed && !restarting && !hibernated && Downscale != nil {
+            downscaleClient(remoteAddr, logMan, t)
+        }
+
+        // Display the connection termination information in the left tui panel
+        t.PostLeft(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                          color.LightCyan, "-"), "",
+                                      color.NeonAzure, "Connection closed for ",
+                                      color.RadiantAmethyst, remoteAddr))
+
+        logMan.LogMessage("info", "Connection processing handled",
+                        zap.Int32("remaining connections", CurrentConnections.Load()))
+
+        // Decrement waitGroup counter on local exit
+        waitGroup.Done()
+    } ()
+
+    // Recover from a panic handling the client so a message that slips past validation
+    // closes the connection of the client rather than bringing down the server
+    defer func() {
+        if recovered := recover(); recovered != nil {
+            logMan.LogMessage("error", "Panic handling client, closing its connection",
+                              zap.String("client", remoteAddr), zap.Any("panic", recovered),
+                              zap.Stack("stack"))
+
+            Events.Emit(eventstream.MalformedMessage, map[string]any{
+                "client":  remoteAddr,
+                "context": "panic",
+                "detail":  fmt.Sprint(recovered),
+            })
+        }
+    } ()
+
+    logMan.LogMessage("info", "Protocol negotiated with client",
+                      zap.String("client", remoteAddr), zap.Int("version", session.Version),
+                      zap.Strings("features", session.Features))
+
+    // If the client restarted on a new version after it was counted as gone, count it again
+    if ClientRestarts != nil {
+        host, _, _ := net.SplitHostPort(remoteAddr)
+        if ClientRestarts.Reconnected(host) {
+            RemainingClients.Add(1)
+        }
+    }
+
+    defer func () {
+        // Receive log file from client
+        logPath, err := netio.ReceiveFile(connection, buffer,
+                                          clientReceivedDir(remoteAddr, logMan),
+                                          globals.LOG_TRANSFER_PREFIX)
+        if err != nil {
+            logClientError(logMan, remoteAddr, "Error receiving log file", err)
+            return
+        }
+
+        // Persist the client log to the results store
+        persistResult(logPath, logMan)
+        auditFile("file_received", logPath, remoteAddr, logMan)
+
+        // Notify the log file has been received in the tui right panel
+        t.PostRight(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                          color.LightCyan, "$"), "",
+                                       color.NeonAzure, "Log file received from client ",
+                                       color.RadiantAmethyst, remoteAddr))
+    } ()
+
+    // Set buffer to receive client PEM certificate
+    buffer = make([]byte, 2 * globals.KB)
+
+    // Receive the client PEM certificate bytes
+    bytesRead, err := netio.ReadHandler(connection, &buffer)
+    if err != nil {
+        logMan.LogMessage("error", "Error reading client PEM cert:  %v", err)
+        return
+    }
+
+    // Save the client PEM cert for peers verifying the client as a seeder
+    clientPem := bytes.Clone(buffer[:bytesRead])
+
+    // Add the read client PEM cert to the cert pool
+    err = TlsMan.AddCACert(clientPem)
+    if err != nil {
+        logMan.LogMessage("error", "Error adding PEM cert to pool:  %v", err)
+        return
+    }
+
+    // Notify TLS cerificate has been received in the tui right panel
+    t.PostRight(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "$"), "",
+                                   color.NeonAzure, "TLS certificate received from client ",
+                                   color.RadiantAmethyst, remoteAddr))
+
+    // Reset buffer to messaging size
+    buffer = make([]byte, globals.MESSAGE_BUFFER_SIZE)
+
+    // Receive the GPU inventory the client reports before work is assigned
+    bytesRead, err = netio.ReadHandler(connection, &buffer)
+    if err != nil {
+        logMan.LogMessage("error", "Error reading client GPU inventory:  %v", err)
+        return
+    }
+
+    inventory, err := gpu.ParseInventory(buffer[:bytesRead])
+    if err != nil {
+        logMan.LogMessage("error", "Error parsing client GPU inventory:  %v", err)
+        return
+    }
+
+    logMan.LogMessage("info", "Client GPU inventory received", zap.String("client", remoteAddr),
+                      zap.Int("gpus", inventory.Gpus),
+                      zap.Int("hashcat gpus", inventory.HashcatGpus),
+                      zap.String("devices", inventory.Summary))
+
+    Events.Emit(eventstream.GpuInventory, map[string]any{
+        "client":       remoteAddr,
+        "devices":      inventory.Summary,
+        "gpus":         inventory.Gpus,
+        "hashcat_gpus": inventory.HashcatGpus,
+    })
+
+    // Display the client GPU inventory in the right panel
+    t.PostRight(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "$"), "",
+                                   color.RadiantAmethyst, remoteAddr,
+                                   color.NeonAzure, " GPUs:  ",
+                                   color.KrakenGlowGreen, inventory.Summary))
+
+    // If the client reports the instance it runs on after its inventory
+    if session.Supports(protocol.FeatureMetadata) {
+        bytesRead, err = netio.ReadHandler(connection, &buffer)
+        if err != nil {
+            logMan.LogMessage("error", "Error reading client instance metadata:  %v", err)
+            return
+        }
+
+        metadata, err := instance.ParseMetadata(buffer[:bytesRead])
+        if err != nil {
+            logMan.LogMessage("error", "Error parsing client instance metadata:  %v", err)
+            return
+        }
+
+        // Store the metadata for the report and cost accounting of the run
+        Instances.Add(remoteAddr, metadata, time.Now())
+
+        logMan.LogMessage("info", "Client instance metadata received",
+                          zap.String("client", remoteAddr),
+                          zap.String("instance id", metadata.InstanceId),
+                          zap.String("instance type", metadata.InstanceType),
+                          zap.String("availability zone", metadata.AvailabilityZone),
+                          zap.String("ami", metadata.AmiId),
+                          zap.String("lifecycle", metadata.Lifecycle))
+
+        Events.Emit(eventstream.InstanceMetadata, map[string]any{
+            "ami_id":            metadata.AmiId,
+            "availability_zone": metadata.AvailabilityZone,
+            "client":            remoteAddr,
+            "instance_id":       metadata.InstanceId,
+            "instance_type":     metadata.InstanceType,
+            "lifecycle":         metadata.Lifecycle,
+        })
+
+        // Display the client instance in the left panel client list
+        t.PostLeft(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                          color.LightCyan, "$"), "",
+                                      color.RadiantAmethyst, remoteAddr,
+                                      color.NeonAzure, " instance:  ",
+                                      color.KrakenGlowGreen, metadata.Summary()))
+    }
+
+    // If the client lets the server assign its backend devices
+    if session.Supports(protocol.FeatureDevices) {
+        devices := ""
+
+        // Local clients share the GPUs of the server host, so unless devices are configured
+        // each is assigned its own subset to avoid contending for the same GPUs
+        if appConfig.LocalConfig.LocalClients && appConfig.ClientConfig.BackendDevices == "" {
+            groups := gpu.PartitionDevices(inventory.HashcatGpus,
+                                           appConfig.LocalConfig.NumberInstances)
+            if groups != nil {
+                devices = groups[int(NextDeviceGroup.Add(1) - 1) % len(groups)]
+            }
+        }
+
+        assignment := gpu.FormatAssignment(devices)
+        // Send the assigned devices, empty leaves the client on its configured devices
+        _, err = netio.WriteHandler(connection, assignment, len(assignment))
+        if err != nil {
+            logMan.LogMessage("error", "Error sending device assignment:  %v", err)
+            return
+        }
+
+        // If the client was assigned a subset of the devices
+        if devices != "" {
+            logMan.LogMessage("info", "Backend devices assigned to client",
+                              zap.String("client", remoteAddr), zap.String("devices", devices))
+        }
+    }
+
+    var hashFilePaths []string
+    var hashTypes []string
+
+    // Iterate through the hash files sent to clients collecting their paths and hash types
+    for _, hashTarget := range HashTargets {
+        hashFilePaths = append(hashFilePaths, hashTarget.Path)
+        hashTypes = append(hashTypes, hashTarget.HashType)
+    }
+
+    // If the hash file was split, assign the client the shard with the fewest clients,
+    // which picks up the shards of disconnected clients
+    if len(HashShards) > 0 {
+        hashFilePaths[0] = HashShards[disk.Shards.Assign(remoteAddr)]
+
+        logMan.LogMessage("info", "Hash file shard assigned to client",
+                          zap.String("shard", hashFilePaths[0]),
+                          zap.String("client", remoteAddr))
+    }
+
+    hashTypesMsg := hashcat.FormatHashTypes(hashTypes)
+    // Send the hash type of each hash file in the order they are sent
+    _, err = netio.WriteHandler(connection, hashTypesMsg, len(hashTypesMsg))
+    if err != nil {
+        logMan.LogMessage("error", "Error sending the hash types to client:  %v", err)
+        return
+    }
+
+    // Iterate through the hash files sending each to the client
+    for _, hashFilePath := range hashFilePaths {
+        // Send the hash file to connection client directly or via a seeding peer
+        err = sendSharedFile(connection, buffer, hashFilePath,
+                             globals.HASHES_TRANSFER_PREFIX, remoteAddr, logMan, t)
+        if err != nil {
+            logMan.LogMessage("error", "Error sending the hash file to client:  %v", err)
+            return
+        }
+
+        auditFile("file_sent", hashFilePath, remoteAddr, logMan)
+
+        // Notify the hash file has been sent in the tui right panel
+        t.PostRight(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                           color.LightCyan, "$"), "",
+                                       color.NeonAzure, "Hash file ",
+                                       color.RadiantAmethyst, filepath.Base(hashFilePath),
+                                       color.NeonAzure, " sent to client ",
+                                       color.RadiantAmethyst, remoteAddr))
+    }
+
+    // Iterate through the rulesets sending each to the client
+    for _, rulesetPath := range appConfig.LocalConfig.RulesetInputs {
+        // Send the ruleset file to connection client directly or via a seeding peer
+        err = sendSharedFile(connection, buffer, rulesetPath,
+                             globals.RULESET_TRANSFER_PREFIX, remoteAddr, logMan, t)
+        if err != nil {
+            logMan.LogMessage("error", "Error sending the ruleset to client:  %v", err)
+            return
+        }
+
+        auditFile("file_sent", rulesetPath, remoteAddr, logMan)
+
+        // Notify the ruleset file has been sent in the tui right panel
+        t.PostRight(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                           color.LightCyan, "$"), "",
+                                       color.NeonAzure, "Ruleset ",
+                                       color.RadiantAmethyst, filepath.Base(rulesetPath),
+                                       color.NeonAzure, " sent to client ",
+                                       color.RadiantAmethyst, remoteAddr))
+    }
+
+    var idleTimeout time.Duration
+    // If the client probes the connection while idle, drop it once it goes silent
+    if session.Supports(protocol.FeatureKeepalive) {
+        idleTimeout = appConfig.LocalConfig.IdleTimeoutDuration
+    }
+
+    for {
+        // Read data from connected client
+        bytesRead, err := netio.ReadHandlerTimeout(connection, &buffer, idleTimeout)
+        if err != nil {
+            // If the client closed its connection rather than the read failing
+            if errors.Is(err, netio.ErrPeerClosed) {
+                logMan.LogMessage("info", "Client disconnected before processing completed",
+                                  zap.String("client", remoteAddr))
+            // If the client sent nothing, not even a keepalive probe, within the timeout
+            } else if errors.Is(err, netio.ErrTimeout) {
+                logMan.LogMessage("warn", "Client hung past the idle timeout, dropping it",
+                                  zap.String("client", remoteAddr),
+                                  zap.Duration("idle_timeout", idleTimeout))
+            } else {
+                logMan.LogMessage("error", "Error reading data from socket:  %v", err)
+            }
+            return
+        }
+
+        // Save read content into isolated buffer
+        readBuffer := buffer[:bytesRead]
+
+        // If the read data is a batch of streamed log lines, handle it before the other
+        // messages since the log data may contain their markers
+        if bytes.HasPrefix(readBuffer, globals.LOG_BATCH_PREFIX) {
+            err = handleLogBatch(connection, readBuffer, remoteAddr)
+            if err != nil {
+                logClientError(logMan, remoteAddr, "Error handling streamed client log", err)
+                return
+            }
+
+            continue
+        }
+
+        // If the read data is a hashcat execution, handle it before the other messages
+        // since its args may contain their markers
+        if bytes.HasPrefix(readBuffer, globals.HASHCAT_EXECUTION_PREFIX) {
+            err = handleHashcatExecution(connection, readBuffer, remoteAddr)
+            if err != nil {
+                logClientError(logMan, remoteAddr, "Error handling hashcat execution", err)
+                return
+            }
+
+            continue
+        }
+
+        // If the read data is a hashcat status, handle it before the other messages since
+        // its target may contain their markers
+        if bytes.HasPrefix(readBuffer, globals.HASHCAT_STATUS_PREFIX) {
+            err = handleHashcatStatus(connection, readBuffer, appConfig, logMan, remoteAddr,
+                                      t)
+            if err != nil {
+                logClientError(logMan, remoteAddr, "Error handling hashcat status", err)
+                return
+            }
+
+            continue
+        }
+
+        // If the read data is a flush of cracked hashes, handle it before the other
+        // messages since the cracked plaintexts may contain their markers
+        if bytes.HasPrefix(readBuffer, globals.LOOT_FLUSH_PREFIX) {
+            err = handleLootFlush(connection, readBuffer, logMan, remoteAddr, t)
+            if err != nil {
+                logClientError(logMan, remoteAddr, "Error handling flushed cracked hashes",
+                               err)
+                return
+            }
+
+            continue
+        }
+
+        // If the read data contains the processing complete message
+        if bytes.Contains(readBuffer, globals.PROCESSING_COMPLETE) {
+            break
+        }
+
+        // If the client stopped processing on the run abort, its restore bundle follows
+        if bytes.Equal(readBuffer, globals.PROCESSING_ABORTED) {
+            aborted = true
+            break
+        }
+
+        // If the read data is a keepalive probe, acknowledge the connection is alive
+        if bytes.Equal(readBuffer, globals.KEEPALIVE_MARKER) {
+            _, err = netio.WriteHandler(connection, globals.KEEPALIVE_ACK,
+                                        len(globals.KEEPALIVE_ACK))
+            if err != nil {
+                logMan.LogMessage("error", "Error sending keepalive acknowledgement:  %v", err)
+                return
+            }
+
+            continue
+        }
+
+        // If the read data contains an abort check
+        if bytes.Equal(readBuffer, globals.ABORT_CHECK_MARKER) {
+            // If the client checkpoints for the hibernation, its work is released once
+            // its restore bundle lists the wordlists it did not process
+            if handleAbortCheck(connection, logMan, remoteAddr, session) {
+                hibernated = true
+            }
+        }
+
+        // If the read data contains transfer request message
+        if bytes.Contains(readBuffer, globals.TRANSFER_REQUEST_MARKER) {
+            // Call method to handle file transfer based
+            handleTransfer(connection, buffer, waitGroup,
+                           appConfig, logMan, remoteAddr, session, 0, t)
+        }
+
+        // If the read data contains a transfer request with the free space of the client
+        if bytes.HasPrefix(readBuffer, globals.TRANSFER_FIT_PREFIX) {
+            available, err := admission.ParseRequest(readBuffer)
+            // If the free space is improper, the request is handled without a limit
+            if err != nil {
+                logMan.LogMessage("warn", "Improper transfer request, handled without the " +
+                                  "free space:  %v", err)
+                available = 0
+            }
+
+            handleTransfer(connection, buffer, waitGroup,
+                           appConfig, logMan, remoteAddr, session, available, t)
+        }
+
+        // If the read data contains keyspace request message
+        if bytes.Contains(readBuffer, globals.KEYSPACE_REQUEST_MARKER) {
+            handleKeyspaceRequest(connection, logMan, remoteAddr, t)
+        }
+
+        // If the read data contains a completed keyspace range
+        if bytes.HasPrefix(readBuffer, globals.KEYSPACE_COMPLETE_PREFIX) {
+            handleKeyspaceComplete(readBuffer, logMan, remoteAddr, t)
+        }
+
+        // If the read data contains the stats of a processed wordlist
+        if bytes.HasPrefix(readBuffer, globals.WORDLIST_STATS_PREFIX) {
+            handleWordlistStats(readBuffer, logMan, remoteAddr)
+        }
+
+        // If the read data contains a wordlist that failed manifest verification
+        if bytes.HasPrefix(readBuffer, globals.WORDLIST_REJECTED_PREFIX) {
+            handleWordlistRejected(readBuffer, logMan, remoteAddr, t)
+        }
+
+        // If the read data contains a client version check
+        if bytes.HasPrefix(readBuffer, globals.VERSION_CHECK_PREFIX) {
+            handleVersionCheck(connection, readBuffer, logMan, remoteAddr)
+        }
+
+        // If the read data contains a server certificate check
+        if bytes.HasPrefix(readBuffer, globals.CERT_CHECK_PREFIX) {
+            handleCertCheck(connection, readBuffer, logMan, remoteAddr)
+        }
+
+        // If the read data contains the wordlist the client is about to process
+        if bytes.HasPrefix(readBuffer, globals.WORK_START_PREFIX) {
+            handleWorkStart(connection, readBuffer, logMan, remoteAddr)
+        }
+
+        // If the client is restarting on a new binary version once it finishes
+        if bytes.Equal(readBuffer, globals.CLIENT_UPDATE_MARKER) {
+            restarting = true
+
+            // Display the updating client in the left panel
+            t.PostLeft(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                              color.LightCyan, "!"), "",
+                                          color.NeonAzure, "Client updating to new version ",
+                                          color.RadiantAmethyst, remoteAddr))
+        }
+
+        // If the read data contains a peer seed registration
+        if bytes.HasPrefix(readBuffer, globals.PEER_SEED_PREFIX) {
+            handlePeerSeed(readBuffer, clientPem, appConfig, logMan,
+                           hashFilePaths, remoteAddr, t)
+        }
+    }
+
+    // Receive cracked user hash file from client
+    lootPath, err := netio.ReceiveFile(connection, buffer,
+                                       clientReceivedDir(remoteAddr, logMan),
+                                       globals.LOOT_TRANSFER_PREFIX)
+    if err != nil {
+        logClientError(logMan, remoteAddr, "Error receiving cracked user hashes", err)
+        return
+    }
+
+    completed = true
+    // Persist the cracked hashes to the results store
+    persistResult(lootPath, logMan)
+    auditFile("file_received", lootPath, remoteAddr, logMan)
+
+    // Merge any cracked hashes that were not flushed during the run into the potfile
+    added, err := Potfile.AddFile(lootPath)
+    if err != nil {
+        logMan.LogMessage("error", "Error adding cracked hashes to potfile:  %v", err)
+    }
+
+    notifyFirstCrack(remoteAddr, added)
+
+    resultsStored := true
+    // If the client aborted, receive the bundle the run is resumed from
+    if aborted {
+        bundlePath := receiveRestoreBundle(connection, buffer, logMan, remoteAddr, t)
+        resultsStored = bundlePath != ""
+
+        // If the client checkpointed for the hibernation, the wordlists it did not
+        // process are sent again once the fleet is resumed
+        if hibernated {
+            releaseHibernatedWork(bundlePath, logMan, remoteAddr, t)
+        }
+    }
+
+    // Save the loot path for merging once all clients are handled
+    LootMutex.Lock()
+    LootFiles = append(LootFiles, report.LootFile{Client: remoteAddr, Path: lootPath})
+    LootMutex.Unlock()
+
+    // If the client scrubs its instance once the results are stored, confirm they are,
+    // leaving a client that failed to send its restore bundle with its restore files
+    if resultsStored && session.Supports(protocol.FeatureResultsAck) {
+        _, err = netio.WriteHandler(connection, globals.RESULTS_ACK, len(globals.RESULTS_ACK))
+        if err != nil {
+            logClientError(logMan, remoteAddr, "Error acknowledging received results", err)
+        }
+    }
+
+    // Count the cracked hashes for the web dashboard and event stream
+    crackedCount, err := countCrackedHashes(lootPath)
+    if err != nil {
+        logMan.LogMessage("error", "Error counting cracked hashes:  %v", err)
+    } else {
+        CrackedHashes.Add(int64(crackedCount))
+        WebUi.AddCrackedHashes(remoteAddr, crackedCount)
+
+        Events.Emit(eventstream.HashesCracked, map[string]any{
+            "client": remoteAddr,
+            "count":  crackedCount,
+            "path":   lootPath,
+        })
+    }
+
+    // Notify the cracked hashes file has been received in the tui right panel
+    t.PostRight(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "$"), "",
+                                   color.NeonAzure, "Cracked hashes received from client ",
+                                   color.RadiantAmethyst, remoteAddr))
+}
+
+
+// Gets the dir the files received from the client are stored in, creating it on the first
+// file with the instance ID of the client when it runs on EC2. If the client dirs of the
+// run are unavailable, the files are stored in a dir of the client under the received
+// dir, so the files of different clients are never received or persisted under one name.
+//
+// @Parameters
+// - remoteAddr:  IP address to remote client that has connected
+// - logMan:  The kloudlogs logger manager for local logging
+//
+// @Returns
+// - The path of the dir the received files are stored in
+//
+func clientReceivedDir(remoteAddr string, logMan *kloudlogs.LoggerManager) string {
+    key := clientdir.Key(remoteAddr)
+    // If the client dirs are not set up, such as in subcommands
+    if ClientDirs == nil {
+        return fallbackClientDir(key, logMan)
+    }
+
+    // If the dir of the client was already created
+    if dirPath, ok := ClientDirs.Lookup(key); ok {
+        return dirPath
+    }
+
+    metadata, _ := Instances.Lookup(remoteAddr)
+    instanceId := metadata.InstanceId
+    // If the client did not report its instance but runs on EC2, map it to its instance
+    if instanceId == "" && LookupInstance != nil {
+        var err error
+        instanceId, err = LookupInstance(key)
+        if err != nil {
+            logMan.LogMessage("warn", "Error finding instance of client:  %v", err,
+                              zap.String("client", remoteAddr))
+        }
+    }
+
+    dirPath, err := ClientDirs.Add(key, instanceId)
+    if err != nil {
+        logMan.LogMessage("error", "Error creating client dir:  %v", err,
+                          zap.String("client", remoteAddr))
+        return fallbackClientDir(key, logMan)
+    }
+
+    return dirPath
+}
+
+
+// Gets the dir of the client directly under the received dir, used when the client dirs
+// of the run are unavailable.
+//
+// @Parameters
+// - key:  The key of the client dir
+// - logMan:  The kloudlogs logger manager for local logging
+//
+// @Returns
+// - The path of the dir the received files are stored in
+//
+func fallbackClientDir(key string, logMan *kloudlogs.LoggerManager) string {
+    dirPath := filepath.Join(ReceivedDir, clientdir.DirName(key, ""))
+
+    err := os.MkdirAll(dirPath, 0755)
+    if err != nil {
+        logMan.LogMessage("error", "Error creating fallback client dir:  %v", err,
+                          zap.String("client", key))
+        return ReceivedDir
+    }
+
+    return dirPath
+}
+
+
+// Sets up where the run results are persisted, a results bucket stores them under a
+// per-run prefix with an optional expiration, otherwise they are kept locally.
+//
+// @Parameters
+// - appConfig:  The configuration struct with loaded yaml program data
+// - awsConfig:  The AWS credential configuration for connecting to S3
+// - start:  The time the run started, used for the per-run prefix
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func setupResults(appConfig *conf.AppConfig, awsConfig aws.Config, start time.Time) error {
+    var err error
+    resultsBucket := appConfig.LocalConfig.ResultsBucket
+
+    // If results are kept on the local filesystem
+    if resultsBucket == "" {
+        resultsDir := appConfig.LocalConfig.ResultsDir
+        // If no results dir was set, keep them where they are received
+        if resultsDir == "" {
+            resultsDir = ReceivedDir
+        }
+
+        Results, err = storage.NewLocalStore(resultsDir)
+        return err
+    }
+
+    s3Man := awsutils.NewS3ManagerFromConfig(awsConfig)
+    // Check to see if the results bucket exists
+    exists, err := s3Man.BucketExists(resultsBucket, 1 * time.Minute)
+    if err != nil {
+        return err
+    }
+
+    // If the results bucket does not exist create one
+    if !exists {
+        err = s3Man.CreateBucket(resultsBucket, 1 * time.Minute)
+        if err != nil {
+            return err
+        }
+    }
+
+    // Apply the hardening settings before any results are persisted
+    err = hardenBucket(s3Man, resultsBucket, &appConfig.LocalConfig)
+    if err != nil {
+        return err
+    }
+
+    // If results should expire, apply the lifecycle rule to every run prefix
+    if appConfig.LocalConfig.ResultsExpirationDays > 0 {
+        err = s3Man.SetBucketExpiration(resultsBucket, "kloud-kraken-results", "runs/",
+                                        appConfig.LocalConfig.ResultsExpirationDays,
+                                        1 * time.Minute)
+        if err != nil {
+            return fmt.Errorf("error applying results lifecycle rule - %w", err)
+        }
+    }
+
+    Results = storage.NewS3Store(s3Man, resultsBucket, storage.RunPrefix(start))
+    return nil
+}
+
+
+// Persists the result file to the results store, logging if it fails since the
+// file is still available in the received dir.
+//
+// @Parameters
+// - filePath:  The path to the result file
+// - logMan:  The kloudlogs logger manager for local logging
+//
+func persistResult(filePath string, logMan *kloudlogs.LoggerManager) {
+    // Persist the file to the results store, keeping the dir of the client it came from
+    err := Results.Put(filePath, storage.Name(ReceivedDir, filePath))
+    if err != nil {
+        logMan.LogMessage("error", "Error persisting result:  %v", err,
+                          zap.String("path", filePath))
+    }
+}
+
+
+// Gets the file name of a report, prefixed with the name of the run when it is named so
+// the reports of different engagements are not mixed up.
+//
+// @Parameters
+// - name:  The base name of the report file
+//
+// @Returns
+// - The report file name
+//
+func reportName(name string) string {
+    // If the run is unnamed
+    if RunName == "" {
+        return name
+    }
+
+    return RunName + "_" + name
+}
+
+
+// Counts the cracked hashes in the received loot file, a file that only
+// contains the no cracked hashes message counts as zero.
+//
+// @Parameters
+// - lootPath:  The path to the received cracked hashes file
+//
+// @Returns
+// - The number of cracked hashes in the file
+// - Error if it occurs, otherwise nil on success
+//
+func countCrackedHashes(lootPath string) (int, error) {
+    // Parse the cracked lines skipping source markers and the no cracked hashes message
+    cracks, err := report.ParseLoot(lootPath)
+    if err != nil {
+        return -1, err
+    }
+
+    return len(cracks), nil
+}
+
+
+// Merges the cracked hashes received from all clients into a single file,
+// skipping any loot files where the client did not crack any hashes.
+//
+// @Parameters
+// - mergedPath:  The path of the merged cracked hashes file
+//
+// @Returns
+// - The number of loot files merged into the output file
+// - Error if it occurs, otherwise nil on success
+//
+func mergeLootFiles(mergedPath string) (int, error) {
+    var mergedCount int
+
+    // Open the merged file for writing, truncating any previous data
+    mergedFile, err := os.Create(mergedPath)
+    if err != nil {
+        return 0, fmt.Errorf("error creating merged loot file - %w", err)
+    }
+    // Close the merged file on local exit
+    defer mergedFile.Close()
+
+    LootMutex.Lock()
+    defer LootMutex.Unlock()
+
+    // Iterate through the received loot files
+    for _, lootFile := range LootFiles {
+        // Parse the cracked lines of the current loot file without the source markers
+        cracks, err := report.ParseLoot(lootFile.Path)
+        if err != nil {
+            return mergedCount, fmt.Errorf("error reading loot file - %w", err)
+        }
+
+        // If the client did not crack any hashes, skip it
+        if len(cracks) == 0 {
+            continue
+        }
+
+        // Write each cracked line to the merged file
+        for _, crack := range cracks {
+            _, err = mergedFile.WriteString(crack.Line + "\n")
+            if err != nil {
+                return mergedCount, fmt.Errorf("error writing merged loot file - %w", err)
+            }
+        }
+
+        mergedCount += 1
+    }
+
+    return mergedCount, nil
+}
+
+
+// Builds the report of cracked hashes from every client joined against the hash
+// files, then writes and persists it in JSON and CSV formats.
+//
+// @Parameters
+// - hashFilePaths:  The paths of the original hash files
+// - logMan:  The kloudlogs logger manager for local logging
+//
+// @Returns
+// - The built report
+// - Error if it occurs, otherwise nil on success
+//
+func writeReports(hashFilePaths []string, logMan *kloudlogs.LoggerManager) (*report.Report,
+                                                                             error) {
+    LootMutex.Lock()
+    lootFiles := slices.Clone(LootFiles)
+    LootMutex.Unlock()
+
+    // Merge, deduplicate, and join the cracked hashes against the hash file
+    crackReport, err := report.Build(hashFilePaths, lootFiles)
+    if err != nil {
+        return nil, err
+    }
+
+    jsonPath := filepath.Join(ReceivedDir, reportName("cracked_report.json"))
+    // Write the JSON report with the summary
+    err = crackReport.WriteJson(jsonPath)
+    if err != nil {
+        return crackReport, fmt.Errorf("error writing JSON report - %w", err)
+    }
+
+    csvPath := filepath.Join(ReceivedDir, reportName("cracked_report.csv"))
+    // Write the CSV report of the cracked hashes
+    err = crackReport.WriteCsv(csvPath)
+    if err != nil {
+        return crackReport, fmt.Errorf("error writing CSV report - %w", err)
+    }
+
+    // Persist the reports to the results store
+    persistResult(jsonPath, logMan)
+    persistResult(csvPath, logMan)
+
+    logMan.LogMessage("info", "Cracked hashes reports written",
+                      zap.Int("cracked", crackReport.Summary.Cracked),
+                      zap.Int("total hashes", crackReport.Summary.TotalHashes),
+                      zap.Float64("crack rate", crackReport.Summary.CrackRate))
+
+    return crackReport, nil
+}
+
+
+// Checks every hash of the hash files sent to clients against the format of its hash type.
+// In reject mode the invalid hashes are split into a rejects file in the received dir and
+// the hash files are replaced with the valid hashes, hash files without any not being sent.
+// Otherwise the run is aborted listing the line numbers of the invalid hashes so hashcat
+// does not choke on them remotely.
+//
+// @Parameters
+// - appConfig:  The configuration struct with loaded yaml program data
+//
+// @Returns
+// - The number of invalid hashes across the hash files
+// - Error if it occurs, otherwise nil on success
+//
+func verifyHashFiles(appConfig *conf.AppConfig) (int, error) {
+    reject := appConfig.LocalConfig.VerifyHashes == "reject"
+    var invalid int
+    var problems []string
+    var verified []conf.HashFile
+
+    // If invalid hashes are rejected, create the dir of the valid hash files
+    if reject {
+        err := os.MkdirAll(VerifiedDir, 0755)
+        if err != nil {
+            return 0, fmt.Errorf("error creating verified hash file dir - %w", err)
+        }
+    }
+
+    // Iterate through the hash files verifying their hashes
+    for _, hashTarget := range HashTargets {
+        hashFile := filepath.Base(hashTarget.Path)
+
+        // If the hash type has no known format, the hashes can not be checked
+        if !identify.Verifiable(hashTarget.HashType) {
+            printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                               color.LightCyan, "!"), "",
+                                           color.NeonAzure, "No known format for hash type ",
+                                           color.KrakenGlowGreen, hashTarget.HashType,
+                                           color.NeonAzure, ", skipped verifying " + hashFile))
+            verified = append(verified, hashTarget)
+            continue
+        }
+
+        var validPath, rejectsPath string
+        // If invalid hashes are rejected, the valid file keeps the name clients mark loot with
+        if reject {
+            validPath = filepath.Join(VerifiedDir, hashFile)
+            rejectsPath = filepath.Join(ReceivedDir, reportName(hashFile + ".rejects"))
+        }
+
+        verification, err := identify.VerifyFile(hashTarget.Path, hashTarget.HashType,
+                                                 validPath, rejectsPath)
+        if err != nil {
+            return 0, fmt.Errorf("error verifying %s - %w", hashFile, err)
+        }
+
+        // If every hash of the file matches its hash type
+        if verification.Invalid == 0 {
+            // Remove the empty rejects file
+            if reject {
+                os.Remove(rejectsPath)
+            }
+
+            verified = append(verified, hashTarget)
+            continue
+        }
+
+        invalid += verification.Invalid
+        problems = append(problems, fmt.Sprintf("%s lines %s", hashFile,
+                                                verification.FormatLines()))
+
+        // If invalid hashes are rejected, send clients the valid hashes
+        if reject {
+            printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                               color.LightCyan, "!"), "",
+                                           color.NeonAzure, "Rejected ",
+                                           color.KrakenGlowGreen,
+                                           strconv.Itoa(verification.Invalid),
+                                           color.NeonAzure, " invalid hashes of " + hashFile +
+                                           " (lines " + verification.FormatLines() +
+                                           ") into " + rejectsPath))
+
+            // If any hash of the file is valid, send it without the invalid ones
+            if verification.Invalid < verification.Checked {
+                verified = append(verified, conf.HashFile{HashType: hashTarget.HashType,
+                                                          Path: validPath})
+            }
+        }
+    }
+
+    HashTargets = verified
+
+    // If invalid hashes are only reported, stop before they reach hashcat
+    if !reject && invalid > 0 {
+        return invalid, fmt.Errorf("%d hashes do not match their hash type:  %s", invalid,
+                                   strings.Join(problems, "; "))
+    }
+
+    return invalid, nil
+}
+
+
+// Loads the cracked hashes of the prior results into a loot file merged into the report
+// ahead of the loot of clients, and writes the hash files sent to clients without them.
+// Hash files with every hash already cracked are not sent.
+//
+// @Parameters
+// - appConfig:  The configuration struct with loaded yaml program data
+//
+// @Returns
+// - The number of prior cracked hashes in the hash files of the run
+// - The number of hashes left to crack across the hash files
+// - Error if it occurs, otherwise nil on success
+//
+func loadPriorResults(appConfig *conf.AppConfig) (int, int, error) {
+    var cracks []report.Crack
+
+    // Iterate through the prior results loading their cracked hashes
+    for _, priorPath := range appConfig.LocalConfig.PriorResults {
+        priorCracks, err := report.LoadPrior(priorPath)
+        if err != nil {
+            return 0, 0, err
+        }
+
+        cracks = append(cracks, priorCracks...)
+    }
+
+    var hashFilePaths []string
+    // Iterate through the hash files of the run collecting their paths
+    for _, hashInput := range appConfig.LocalConfig.HashInputs {
+        hashFilePaths = append(hashFilePaths, hashInput.Path)
+    }
+
+    // Drop the cracks of hashes outside of the run, such as those of a shared potfile
+    cracks, err := report.KeepMatched(hashFilePaths, cracks)
+    if err != nil {
+        return 0, 0, err
+    }
+
+    lootPath := filepath.Join(ReceivedDir, reportName("prior_results.txt"))
+    // Write the prior cracked hashes as a loot file so they are merged like a client's
+    err = report.WriteLoot(lootPath, cracks)
+    if err != nil {
+        return 0, 0, fmt.Errorf("error writing prior results loot file - %w", err)
+    }
+
+    LootMutex.Lock()
+    LootFiles = append(LootFiles, report.LootFile{Client: report.PriorClient, Path: lootPath})
+    LootMutex.Unlock()
+
+    err = os.MkdirAll(PrunedDir, 0755)
+    if err != nil {
+        return 0, 0, fmt.Errorf("error creating pruned hash file dir - %w", err)
+    }
+
+    hashFiles := make(map[string]bool)
+    // Iterate through the hash files of the run collecting their names
+    for _, hashInput := range appConfig.LocalConfig.HashInputs {
+        hashFiles[filepath.Base(hashInput.Path)] = true
+    }
+
+    var remaining int
+    // Prune the hash files that would be sent, which may be without their invalid hashes
+    hashTargets := HashTargets
+    HashTargets = nil
+
+    // Iterate through the hash files removing the hashes already cracked
+    for _, hashInput := range hashTargets {
+        hashFile := filepath.Base(hashInput.Path)
+
+        // Keep the cracks of the hash file along with those not tied to one of the run,
+        // such as potfile lines, so the same hash of another hash file is not removed
+        fileCracks := slices.DeleteFunc(slices.Clone(cracks), func(crack report.Crack) bool {
+            return hashFiles[crack.HashFile] && crack.HashFile != hashFile
+        })
+
+        // The pruned hash file keeps the name clients mark their loot with
+        prunedPath := filepath.Join(PrunedDir, hashFile)
+        count, err := report.Uncracked(hashInput.Path, fileCracks, prunedPath)
+        if err != nil {
+            return 0, 0, fmt.Errorf("error removing prior cracked hashes - %w", err)
+        }
+
+        // If every hash of the file was already cracked, do not send it
+        if count == 0 {
+            continue
+        }
+
+        HashTargets = append(HashTargets, conf.HashFile{HashType: hashInput.HashType,
+                                                        Path: prunedPath})
+        remaining += count
+    }
+
+    return len(cracks), remaining, nil
+}
+
+
+// Spawns number_instances client processes on the server host connecting over the
+// loopback address, each with its own data dir so their files do not collide.
+//
+// @Parameters
+// - appConfig:  The configuration struct with loaded yaml program data
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func startLocalClients(appConfig *conf.AppConfig, logMan *kloudlogs.LoggerManager) error {
+    // Resolve the client binary and server certificate since clients run in their own dir
+    clientPath, err := filepath.Abs("./client")
+    if err != nil {
+        return err
+    }
+
+    certPath, err := filepath.Abs("tls-cert.pem")
+    if err != nil {
+        return err
+    }
+
+    // Iterate through the number of instances spawning a client for each
+    for index := 1; index <= appConfig.LocalConfig.NumberInstances; index++ {
+        clientDir := filepath.Join(LocalClientsDir, fmt.Sprintf("client-%d", index))
+
+        // Create the data dir of the client
+        err = os.MkdirAll(clientDir, 0755)
+        if err != nil {
+            return fmt.Errorf("error creating local client dir - %w", err)
+        }
+
+        // Later flags override the AWS specific values of the shared flags
+        flags := append(clientFlags(appConfig, "127.0.0.1", "", true),
+                        "-autoUpdate=false",
+                        "-brainPassword=" + appConfig.LocalConfig.BrainPassword,
+                        "-connectionToken=" + ConnectionToken,
+                        "-dataPath=" + clientDir,
+                        "-logMode=local",
+                        "-logPath=" + filepath.Join(clientDir, "KloudKraken.log"),
+                        "-testPemCert=" + certPath)
+
+        // Create the file the output of the client is written to
+        outputFile, err := os.Create(filepath.Join(clientDir, "output.log"))
+        if err != nil {
+            return fmt.Errorf("error creating local client output file - %w", err)
+        }
+
+        cmd := exec.Command(clientPath, flags...)
+        cmd.Dir = clientDir
+        cmd.Stdout = outputFile
+        cmd.Stderr = outputFile
+
+        err = cmd.Start()
+        // Close the parent copy of the output file, the client holds its own
+        outputFile.Close()
+        if err != nil {
+            return fmt.Errorf("error starting local client %d - %w", index, err)
+        }
+
+        LocalClients = append(LocalClients, cmd)
+        logMan.LogMessage("info", "Local client started", zap.Int("client", index),
+                          zap.Int("pid", cmd.Process.Pid), zap.String("dir", clientDir))
+    }
+
+    return nil
+}
+
+
+// Waits for the local client processes to exit, killing any still running after
+// the timeout.
+//
+// @Parameters
+// - timeout:  The length of time to wait for each client to exit
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+//
+func stopLocalClients(timeout time.Duration, logMan *kloudlogs.LoggerManager) {
+    // Iterate through the spawned clients waiting on each
+    for _, cmd := range LocalClients {
+        done := make(chan error, 1)
+        go func() {
+            done <- cmd.Wait()
+        } ()
+
+        select {
+        case err := <-done:
+            // If the client exited with a failure
+            if err != nil {
+                logMan.LogMessage("error", "Local client %d exited with error:  %v",
+                                  cmd.Process.Pid, err)
+            }
+        case <-time.After(timeout):
+            logMan.LogMessage("error", "Local client %d did not exit, killing it",
+                              cmd.Process.Pid)
+            cmd.Process.Kill()
+            <-done
+        }
+    }
+
+    LocalClients = nil
+}
+
+
+// Registers the run metrics and starts serving them for Prometheus to scrape on the
+// configured port.
+//
+// @Parameters
+// - appConfig:  The configuration struct with loaded yaml program data
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func startMetrics(appConfig *conf.AppConfig) error {
+    var cert *tls.Certificate
+    Metrics = metrics.NewRegistry()
+
+    Metrics.Gauge("kloud_kraken_active_connections", "Clients currently connected",
+                  func() float64 {
+                      return float64(CurrentConnections.Load())
+                  })
+    Metrics.Counter("kloud_kraken_transferred_bytes_total",
+                    "Bytes of wordlists transferred to clients", func() float64 {
+                        return float64(Transfers.Aggregate().Bytes)
+                    })
+    Metrics.Counter("kloud_kraken_transfers_total", "Wordlist transfers completed",
+                    func() float64 {
+                        return float64(Transfers.Aggregate().Transfers)
+                    })
+    Metrics.Counter("kloud_kraken_transfer_failures_total", "Wordlist transfers failed",
+                    func() float64 {
+                        return float64(Transfers.Aggregate().Failures)
+                    })
+    Metrics.Gauge("kloud_kraken_load_dir_files_remaining",
+                  "Files in the load dir not yet assigned to a client", func() float64 {
+                      // If wordlists are assigned by range, count the unclaimed ranges
+                      if RangeIndex != nil {
+                          maxFileSize := appConfig.ClientConfig.MaxFileSizeInt64
+                          return float64(disk.RemainingRanges(RangeIndex, maxFileSize))
+                      }
+
+                      remaining, err := disk.RemainingFiles(appConfig.LocalConfig.LoadDir,
+                                                            appConfig.ClientConfig.MaxFileSizeInt64)
+                      // If the load dir could not be read, report the value as unknown
+                      if err != nil {
+                          return math.NaN()
+                      }
+
+                      return float64(remaining)
+                  })
+    Metrics.Gauge("kloud_kraken_fleet_hash_rate",
+                  "Combined hashcat speed of the clients in hashes per second",
+                  func() float64 {
+                      return HashRate.Snapshot(time.Now()).Speed
+                  })
+    Metrics.Gauge("kloud_kraken_keyspace_coverage_ratio",
+                  "Fraction of the estimated keyspace of the run processed by the fleet",
+                  func() float64 {
+                      return HashRate.Snapshot(time.Now()).Coverage
+                  })
+    Metrics.Gauge("kloud_kraken_completion_eta_seconds",
+                  "Estimated seconds until the fleet processes the rest of the keyspace",
+                  func() float64 {
+                      eta := HashRate.Snapshot(time.Now()).Eta
+                      // If the fleet is not cracking, the estimate is unknown
+                      if eta == 0 {
+                          return math.NaN()
+                      }
+
+                      return eta.Seconds()
+                  })
+    Metrics.Counter("kloud_kraken_cracked_hashes_total", "Cracked hashes received from clients",
+                    func() float64 {
+                        return float64(CrackedHashes.Load())
+                    })
+    Metrics.GaugeVec("kloud_kraken_ec2_instances", "EC2 instances of the run by state",
+                     "state", func() map[string]float64 {
+                         values := make(map[string]float64)
+                         states, _ := Ec2States.Load().(map[string]int)
+                         // Iterate through the last polled states converting the counts
+                         for state, count := range states {
+                             values[state] = float64(count)
+                         }
+
+                         return values
+                     })
+
+    // If the metrics should be served over HTTPS
+    if appConfig.LocalConfig.MetricsTls {
+        cert = &TlsMan.TlsCertificate
+    }
+
+    // Start serving the metrics
+    err := Metrics.Start("", appConfig.LocalConfig.MetricsPort, cert)
+    if err != nil {
+        Metrics = nil
+        return err
+    }
+
+    return nil
+}
+
+
+// Polls the state of the EC2 instances of the run at an interval so they can be
+// served as metrics without querying AWS on every scrape.
+//
+// @Parameters
+// - ctx:  Context that stops the polling when canceled
+// - ec2Man:  The EC2 manager of the launched instances
+// - interval:  The length of time between polls
+// - logMan:  The kloudlogs logger manager for local logging
+//
+func pollEc2States(ctx context.Context, ec2Man *awsutils.Ec2Manger, interval time.Duration,
+                   logMan *kloudlogs.LoggerManager) {
+    for {
+        // Query the current state of the instances
+        states, err := ec2Man.InstanceStates(30 * time.Second)
+        if err != nil {
+            logMan.LogMessage("error", "Error polling EC2 instance states:  %v", err)
+        } else {
+            Ec2States.Store(states)
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-time.After(interval):
+        }
+    }
+}
+
+
+// Registers the admin methods and starts serving them on the configured unix socket, so
+// scripts can inspect and control the run without the TUI.
+//
+// @Parameters
+// - appConfig:  The configuration struct with loaded yaml program data
+// - ec2Man:  The EC2 manager of the launched fleet, nil when testing locally
+// - watchdog:  The watchdog tracking the fleet spend, nil when not tracked
+// - runStart:  The time the run started
+// - logMan:  The kloudlogs logger manager for local logging
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func startAdmin(appConfig *conf.AppConfig, ec2Man *awsutils.Ec2Manger, watchdog *cost.Watchdog,
+                runStart time.Time, logMan *kloudlogs.LoggerManager) error {
+    Admin = admin.NewServer()
+
+    Admin.Register("status", func(params json.RawMessage) (any, error) {
+        var clients []map[string]any
+        transferStats := Transfers.Snapshot()
+
+        // Iterate through the connected clients collecting their state
+        ClientConns.Range(func(key, value any) bool {
+            addr := key.(string)
+            queued, queuedBytes := Rebalance.Depth(addr)
+            stats := transferStats[addr]
+
+            clients = append(clients, map[string]any{
+                "address":           addr,
+                "pending_wordlists": Exceptions.Pending(addr),
+                "queued_bytes":      queuedBytes,
+                "queued_wordlists":  queued,
+                "transfer_failures": stats.Failures,
+                "transferred_bytes": stats.Bytes,
+                "transfers":         stats.Transfers,
+            })
+            return true
+        })
+
+        // Sort the clients by address for stable output
+        sort.Slice(clients, func(i, j int) bool {
+            return clients[i]["address"].(string) < clients[j]["address"].(string)
+        })
+
+        status := map[string]any{
+            "aborting":           Aborting.Load(),
+            "active_connections": CurrentConnections.Load(),
+            "clients":            clients,
+            "cracked_hashes":     CrackedHashes.Load(),
+            "draining":           Draining.Load(),
+            "hibernation":        Hibernation.State(),
+            "paused":             Paused.Load(),
+            "run_id":             RunId,
+            "uptime":             time.Since(runStart).Round(time.Second).String(),
+        }
+
+        // If the fleet spend is tracked, include it
+        if watchdog != nil {
+            status["max_cost"] = watchdog.MaxCost()
+            status["runtime"] = watchdog.Runtime(time.Now()).Round(time.Second).String()
+            status["spent"] = watchdog.Spent(time.Now())
+        }
+
+        return status, nil
+    })
+
+    Admin.Register("pause", func(params json.RawMessage) (any, error) {
+        var args struct {
+            Hibernate bool `json:"hibernate"`
+        }
+        // If params were passed, a plain pause takes none
+        if len(params) > 0 {
+            err := admin.ParseParams(params, &args)
+            if err != nil {
+                return nil, err
+            }
+        }
+
+        // If the run is only held without stopping the fleet
+        if !args.Hibernate {
+            Paused.Store(true)
+            logMan.LogMessage("info", "Run paused through the admin socket")
+            return nil, nil
+        }
+
+        // If the clients do not run on a fleet of instances
+        if ec2Man == nil {
+            return nil, fmt.Errorf("only a fleet launched on EC2 can be hibernated")
+        }
+
+        // If the clients can not be relaunched when their instances boot again
+        if appConfig.LocalConfig.ClientOs == awsutils.OsWindows {
+            return nil, fmt.Errorf("a fleet of windows clients can not be hibernated")
+        }
+
+        // If some clients run on spot instances, which can not be stopped and started
+        if Instances.Spot() {
+            return nil, fmt.Errorf("a fleet with spot instances can not be hibernated")
+        }
+
+        err := Hibernation.Begin()
+        if err != nil {
+            return nil, err
+        }
+
+        Paused.Store(true)
+        go func() {
+            err := hibernateFleet(ec2Man, watchdog, 15 * time.Minute, logMan)
+            if err != nil {
+                logMan.LogMessage("error", "Error stopping hibernated instances:  %v", err)
+            }
+        } ()
+
+        logMan.LogMessage("info", "Run hibernating through the admin socket, clients " +
+                          "checkpoint hashcat before their instances are stopped")
+        return map[string]any{"hibernation": Hibernation.State()}, nil
+    })
+
+    Admin.Register("resume", func(params json.RawMessage) (any, error) {
+        switch Hibernation.State() {
+        // If the clients are still checkpointing, the instances are not stopped yet
+        case hibernate.StateCheckpointing:
+            return nil, fmt.Errorf("run is still hibernating, resume once the fleet stopped")
+        case hibernate.StateStopped:
+            // Start the stopped instances, which relaunch their clients on boot
+            err := ec2Man.StartEc2Instances(HibernatedIds, 5 * time.Minute)
+            if err != nil {
+                return nil, fmt.Errorf("error starting hibernated instances - %w", err)
+            }
+
+            // If the fleet spend is tracked, accrue the started instances and runtime from now
+            if watchdog != nil {
+                watchdog.AddInstances(len(HibernatedIds), time.Now())
+                watchdog.Resume(time.Now())
+            }
+
+            err = Hibernation.Resume(len(HibernatedIds))
+            if err != nil {
+                return nil, err
+            }
+
+            // Accept the clients of the started instances
+            expectClients(len(HibernatedIds))
+
+            Events.Emit(eventstream.FleetResumed, map[string]any{
+                "instance_ids": HibernatedIds,
+            })
+            logMan.LogMessage("info", "Hibernated fleet started through the admin socket",
+                              zap.Strings("instance_ids", HibernatedIds))
+        }
+
+        Paused.Store(false)
+        logMan.LogMessage("info", "Run resumed through the admin socket")
+        return nil, nil
+    })
+
+    Admin.Register("drain", func(params json.RawMessage) (any, error) {
+        Draining.Store(true)
+        logMan.LogMessage("info", "Run draining through the admin socket, clients finish " +
+                          "their queued work without new assignments")
+        return nil, nil
+    })
+
+    Admin.Register("abort", func(params json.RawMessage) (any, error) {
+        // Stop assigning work while clients interrupt hashcat and return restore files
+        Aborting.Store(true)
+        Draining.Store(true)
+        logMan.LogMessage("info", "Run aborted through the admin socket, clients return " +
+                          "their restore files and remaining hashes")
+        return nil, nil
+    })
+
+    Admin.Register("add_instances", func(params json.RawMessage) (any, error) {
+        var args struct {
+            Count int `json:"count"`
+        }
+        err := admin.ParseParams(params, &args)
+        if err != nil {
+            return nil, err
+        }
+
+        // If the count would not add any instances
+        if args.Count < 1 {
+            return nil, &admin.Error{Code: admin.CodeInvalidParams,
+                                     Message: "count must be at least 1"}
+        }
+
+        // If the clients do not run on a fleet of instances
+        if ec2Man == nil {
+            return nil, fmt.Errorf("instances can only be added to a fleet launched on EC2")
+        }
+
+        // If the run no longer assigns work, added clients would sit idle
+        if Draining.Load() {
+            return nil, fmt.Errorf("run is draining, added instances would get no work")
+        }
+
+        // Launch with the user data of the fleet, which fetches the uploaded client
+        // binary and the server certificate from SSM
+        instanceIds, err := ec2Man.AddEc2Instances(args.Count, 20 * time.Minute)
+        if err != nil {
+            return nil, fmt.Errorf("error adding instances - %w", err)
+        }
+
+        // If the fleet spend is tracked, accrue the added instances from now
+        if watchdog != nil {
+            watchdog.AddInstances(args.Count, time.Now())
+        }
+
+        // Accept the clients of the added instances
+        expectClients(args.Count)
+
+        Events.Emit(eventstream.InstancesLaunched, map[string]any{
+            "instance_ids":  instanceIds,
+            "instance_type": appConfig.LocalConfig.InstanceType,
+        })
+
+        logMan.LogMessage("info", "Instances added through the admin socket",
+                          zap.Strings("instance_ids", instanceIds),
+                          zap.Int("instances", ec2Man.Count()))
+        return map[string]any{"instance_ids": instanceIds, "instances": ec2Man.Count()}, nil
+    })
+
+    Admin.Register("terminate_client", func(params json.RawMessage) (any, error) {
+        var args struct {
+            Client string `json:"client"`
+        }
+        err := admin.ParseParams(params, &args)
+        if err != nil {
+            return nil, err
+        }
+
+        value, exists := ClientConns.Load(args.Client)
+        // If the client is not connected
+        if !exists {
+            return nil, &admin.Error{Code: admin.CodeInvalidParams,
+                                     Message: "client not connected:  " + args.Client}
+        }
+
+        // Closing the connection requeues the wordlists the client did not complete
+        value.(net.Conn).Close()
+        result := map[string]any{"client": args.Client}
+
+        // If the client runs on an instance of the fleet, terminate the instance
+        if ec2Man != nil {
+            host, _, _ := net.SplitHostPort(args.Client)
+
+            instanceId, err := ec2Man.TerminateByIp(host, 1 * time.Minute)
+            if err != nil {
+                return nil, fmt.Errorf("connection closed but instance not terminated - %w",
+                                       err)
+            }
+
+            result["instance_id"] = instanceId
+        }
+
+        logMan.LogMessage("info", "Client terminated through the admin socket",
+                          zap.String("client", args.Client))
+        return result, nil
+    })
+
+    Admin.Register("add_budget", func(params json.RawMessage) (any, error) {
+        var args struct {
+            Amount float64 `json:"amount"`
+        }
+        err := admin.ParseParams(params, &args)
+        if err != nil {
+            return nil, err
+        }
+
+        // If the fleet spend is not tracked
+        if watchdog == nil {
+            return nil, fmt.Errorf("max_cost is not set for the run")
+        }
+
+        maxCost, err := watchdog.AddBudget(args.Amount)
+        if err != nil {
+            return nil, &admin.Error{Code: admin.CodeInvalidParams, Message: err.Error()}
+        }
+
+        logMan.LogMessage("info", "Budget raised through the admin socket",
+                          zap.Float64("max_cost", maxCost))
+        return map[string]float64{"max_cost": maxCost}, nil
+    })
+
+    // Start serving the admin methods
+    err := Admin.Start(appConfig.LocalConfig.AdminSocket)
+    if err != nil {
+        Admin = nil
+        return err
+    }
+
+    return nil
+}
+
+
+// Creates the web dashboard, mirrors the TUI panel messages into it, and
+// starts serving it on the configured port.
+//
+// @Parameters
+// - appConfig:  The configuration struct with loaded yaml program data
+// - t:  The tui interface whose output is mirrored
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func startWebUi(appConfig *conf.AppConfig, t *tui.TUI) error {
+    var cert *tls.Certificate
+
+    WebUi = webui.NewDashboard(500, appConfig.LocalConfig.WebUiToken)
+    // Mirror all TUI panel messages into the dashboard
+    t.AddHook(WebUi.AddEvent)
+
+    // If the web UI should be served over HTTPS
+    if appConfig.LocalConfig.WebUiTls {
+        cert = &TlsMan.TlsCertificate
+    }
+
+    // Start serving the dashboard
+    err := WebUi.Start(appConfig.LocalConfig.WebUiAddress, appConfig.LocalConfig.WebUiPort, cert)
+    if err != nil {
+        WebUi = nil
+        return err
+    }
+
+    return nil
+}
+
+
+// Waits for an incoming client connection and negotiates its session, then increments the
+// active connections counter and waitgroup, and passes the connection with other args into
+// handler goroutine. Connections from outside the allowed client ranges or refused in the
+// protocol negotiation are closed without being counted.
+//
+// @Parameters
+// - tlsListener:  The TLS listener accepting client connections
+// - waitGroup:  Used to synchronize the Goroutines running
+// - appConfig:  The configuration struct with loaded yaml program data
+// - logMan:  The kloudlogs logger manager for local logging
+// - t:  The tui interface for displaying output
+//
+// @Returns
+// - ErrClientRejected if the client was refused, otherwise error if accepting failed or
+//   nil on success
+//
+func acceptConnection(tlsListener net.Listener, waitGroup *sync.WaitGroup,
+                      appConfig *conf.AppConfig, logMan *kloudlogs.LoggerManager,
+                      t *tui.TUI) error {
+    // Wait for an incoming connection
+    connection, err := tlsListener.Accept()
+    if err != nil {
+        // If the listener was closed because the server is shutting down
+        if !errors.Is(err, net.ErrClosed) {
+            logMan.LogMessage("error", "Error accepting client connection:  %v", err)
+        }
+
+        return err
+    }
+
+    // Get the remote IP address for output/logging
+    remoteAddr := connection.RemoteAddr().String()
+
+    // If the client is outside the allowed ranges, close it before the TLS handshake
+    if !clientAllowed(remoteAddr, appConfig.LocalConfig.AllowedClientNets) {
+        connection.Close()
+        recordException(exceptions.ClientRefused, remoteAddr,
+                        "source outside of allowed_client_cidrs", t)
+
+        logMan.LogMessage("warn", "Connection rejected from outside the allowed client ranges",
+                          zap.String("client", remoteAddr))
+        return ErrClientRejected
+    }
+
+    // Bound the hello exchange so a client that stalls it does not hold the listener
+    connection.SetDeadline(time.Now().Add(30 * time.Second))
+
+    // Exchange hellos first so clients speaking an incompatible protocol are refused
+    // before the rest of the stream is misread
+    session, err := negotiateSession(connection,
+                                     appConfig.ClientConfig.KeyspaceChunks > 0)
+    if err != nil {
+        connection.Close()
+        recordException(exceptions.ClientRefused, remoteAddr, err.Error(), t)
+
+        logMan.LogMessage("error", "Client refused in protocol negotiation:  %v", err,
+                          zap.String("client", remoteAddr))
+        return ErrClientRejected
+    }
+
+    connection.SetDeadline(time.Time{})
+
+    // Increment the active connection count
+    CurrentConnections.Add(1)
+    // List the client on the admin socket so it can be terminated
+    ClientConns.Store(remoteAddr, connection)
+    // Mark the client as connected in the web dashboard
+    WebUi.ClientConnected(remoteAddr)
+
+    Events.Emit(eventstream.ClientConnected, map[string]any{
+        "active_connections": CurrentConnections.Load(),
+        "client":             remoteAddr,
+    })
+
+    // Display the connection spawning information in the left tui panel
+    t.PostLeft(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                      color.LightCyan, "+"), "",
+                                  color.NeonAzure, "Accepted ",
+                                  color.RadiantAmethyst, remoteAddr))
+
+    logMan.LogMessage("info", "Connection accepted from %s", remoteAddr,
+                      zap.Int32("active connections", CurrentConnections.Load()))
+
+    // Increment wait group and handle connection in separate Goroutine
+    waitGroup.Add(1)
+    // Count the client toward those reattaching to a resumed hibernation
+    Hibernation.Attached()
+    go handleConnection(connection, waitGroup, appConfig, logMan, remoteAddr, session, t)
+
+    return nil
+}
+
+
+// Checks whether the remote address of a connection is within the allowed client ranges.
+//
+// @Parameters
+// - remoteAddr:  The host:port address the connection came from
+// - allowedNets:  The ranges clients may connect from, empty allows any
+//
+// @Returns
+// - true/false depending on whether the client is allowed
+//
+func clientAllowed(remoteAddr string, allowedNets []*net.IPNet) bool {
+    // If no ranges are set, accept any source
+    if len(allowedNets) == 0 {
+        return true
+    }
+
+    host, _, err := net.SplitHostPort(remoteAddr)
+    if err != nil {
+        host = remoteAddr
+    }
+
+    ip := net.ParseIP(host)
+    // If the address is not an IP, such as the client ID of an SQS connection
+    if ip == nil {
+        return false
+    }
+
+    return slices.ContainsFunc(allowedNets, func(allowedNet *net.IPNet) bool {
+        return allowedNet.Contains(ip)
+    })
+}
+
+
+// Terminates the instance of a client that returned its results because no work remains
+// for it, so it stops billing while the rest of the fleet finishes.
+//
+// @Parameters
+// - remoteAddr:  IP address to remote client that finished
+// - logMan:  The kloudlogs logger manager for local logging
+// - t:  The tui interface for displaying output
+//
+func downscaleClient(remoteAddr string, logMan *kloudlogs.LoggerManager, t *tui.TUI) {
+    instanceId, err := Downscale.Terminate(remoteAddr, 1 * time.Minute)
+    if err != nil {
+        logMan.LogMessage("error", "Error terminating instance of finished client:  %v", err,
+                          zap.String("client", remoteAddr))
+        return
+    }
+
+    Events.Emit(eventstream.InstanceTerminated, map[string]any{
+        "client":      remoteAddr,
+        "instance_id": instanceId,
+    })
+
+    // Display the terminated instance in the left tui panel
+    t.PostLeft(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                      color.LightCyan, "-"), "",
+                                  color.NeonAzure, "Idle instance terminated for ",
+                                  color.RadiantAmethyst, remoteAddr))
+
+    logMan.LogMessage("info", "Instance of finished client terminated",
+                      zap.String("client", remoteAddr), zap.String("instance_id", instanceId))
+}
+
+
+// Stops the instances of a hibernated fleet once its clients checkpointed hashcat and
+// disconnected, or once the timeout passes for clients that never did.
+//
+// @Parameters
+// - ec2Man:  The EC2 manager of the launched fleet
+// - watchdog:  The watchdog tracking the fleet spend, nil when not tracked
+// - timeout:  The max time to wait on the clients to disconnect
+// - logMan:  The kloudlogs logger manager for local logging
+//
+// @Returns
+// - Error if the instances could not be stopped, leaving the run checkpointing,
+//   otherwise nil on success
+//
+func hibernateFleet(ec2Man *awsutils.Ec2Manger, watchdog *cost.Watchdog,
+                    timeout time.Duration, logMan *kloudlogs.LoggerManager) error {
+    deadline := time.Now().Add(timeout)
+
+    // Wait for the clients to return their restore bundles and disconnect
+    for CurrentConnections.Load() > 0 && time.Now().Before(deadline) {
+        time.Sleep(1 * time.Second)
+    }
+
+    // If some clients never disconnected, their instances are stopped regardless
+    if CurrentConnections.Load() > 0 {
+        logMan.LogMessage("warn", "Clients still connected when stopping hibernated fleet",
+                          zap.Int32("clients", CurrentConnections.Load()))
+    }
+
+    instanceIds, err := ec2Man.StopEc2Instances(5 * time.Minute)
+    if err != nil {
+        return err
+    }
+
+    // If the fleet spend is tracked, stop accruing the stopped instances
+    if watchdog != nil && len(instanceIds) > 0 {
+        watchdog.RemoveInstances(len(instanceIds), time.Now())
+    }
+    // If the fleet is watched, the time hibernated does not count towards max_runtime
+    if watchdog != nil {
+        watchdog.Pause(time.Now())
+    }
+
+    HibernatedIds = instanceIds
+    Hibernation.Stopped()
+
+    Events.Emit(eventstream.FleetHibernated, map[string]any{
+        "instance_ids": instanceIds,
+    })
+    logMan.LogMessage("info", "Hibernated fleet stopped, resume through the admin socket",
+                      zap.Strings("instance_ids", instanceIds))
+    return nil
+}
+
+
+// Raises the number of clients the listener accepts for instances added mid-run.
+//
+// @Parameters
+// - count:  The number of clients added
+//
+func expectClients(count int) {
+    // If clients auto update, the listener accepts until the remaining clients finish
+    if ClientUpdate != nil {
+        RemainingClients.Add(int32(count))
+        return
+    }
+
+    AddedInstances.Add(int32(count))
+    // Wake the listener without blocking if it was already signaled
+    select {
+    case InstancesAdded <- struct{}{}:
+    default:
+    }
+}
+
+
+// Set up listener and enter loop where the amount of active connections is checked
+// until the specified number of instances is equal to the active connections the
+// listener will wait until a connection is accepted. Increment the active connections
+// counter and waitgroup, and pass the connection with other args into handler goroutine.
+//
+// @Parameters
+// - appConfig:  The configuration struct with loaded yaml program data
+// - logMan:  The kloudlogs logger manager for local logging
+//
+func startServer(appConfig *conf.AppConfig, logMan *kloudlogs.LoggerManager) {
+    // Establish wait group for Goroutine synchronization
+    var waitGroup sync.WaitGroup
+
+    // Setup TUI interface for and ensure it closes on local exit
+    t := tui.NewTUI(100, "Connections", 500 * time.Millisecond, 3, "File Transfers")
+    // Pin the exception counts below the panels
+    t.SetFooter(func() string {
+        return color.BrightCoral + Exceptions.Summary() + color.AnsiReset
+    })
+    // Display the combined speed, keyspace coverage, and ETA of the fleet above the panels
+    t.SetHeader(func() string {
+        return formatFleetRate(HashRate.Snapshot(time.Now()))
+    })
+    // If the TUI is disabled or JSON output is used, consume panel messages without rendering
+    t.SetHeadless(appConfig.LocalConfig.DisableTui || Events != nil)
+
+    // If log streaming is enabled, write client logs live and tail the selected one
+    if appConfig.LocalConfig.LogStreaming {
+        ClientLogs = logstream.NewStore(ReceivedDir, 8)
+        // Close the client log files on local exit
+        defer func() {
+            err := ClientLogs.Close()
+            if err != nil {
+                logMan.LogMessage("error", "Error closing client log files:  %v", err)
+            }
+        } ()
+
+        t.SetTail(8, func() (string, []string) {
+            client, lines := ClientLogs.Tail()
+            // If no client has streamed logs yet
+            if client == "" {
+                return display.Ctext(color.NeonAzure, "Log tail:  waiting for clients"), lines
+            }
+
+            return display.CtextMulti(color.NeonAzure, "Log tail:  ",
+                                      color.RadiantAmethyst, client,
+                                      color.NeonAzure, "  (Enter to cycle, or type an IP)"),
+                   lines
+        })
+
+        // If the TUI is rendered, read the tail selection from the terminal
+        if !appConfig.LocalConfig.DisableTui && Events == nil {
+            go selectTailClient()
+        }
+    }
+
+    // If the web UI port is set, start the web dashboard
+    if appConfig.LocalConfig.WebUiPort != 0 {
+        err := startWebUi(appConfig, t)
+        if err != nil {
+            logMan.LogMessage("error", "Error starting web UI:  %v", err)
+        } else {
+            logMan.LogMessage("info", "Web UI listening on %s",
+                              net.JoinHostPort(appConfig.LocalConfig.WebUiAddress,
+                                               strconv.Itoa(appConfig.LocalConfig.WebUiPort)))
+        }
+
+        // Stop the web dashboard on local exit
+        defer func() {
+            err := WebUi.Stop(5 * time.Second)
+            if err != nil {
+                logMan.LogMessage("error", "Error stopping web UI:  %v", err)
+            }
+        } ()
+    }
+
+    go t.Start(color.SkyBlue, color.BrightMagenta, color.BrightMint)
+
+    // If the wordlist data skips TLS, warn that it crosses the VPC unencrypted
+    if appConfig.LocalConfig.PlaintextTransfers {
+        // If not running on an instance, only local clients are inside the VPC of the server
+        if !appConfig.LocalConfig.LocalTesting {
+            var err error
+
+            VpcCidrs, err = instance.VpcCidrs(10 * time.Second)
+            if err != nil {
+                logMan.LogMessage("warn", "Error fetching the VPC CIDR blocks, wordlist data " +
+                                  "is only sent without TLS to local clients:  %v", err)
+            }
+        }
+
+        logMan.LogMessage("warn", "Plaintext transfers enabled, wordlist data is sent to " +
+                          "clients inside the VPC without TLS")
+        t.PostLeft(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                          color.LightCyan, "!"), "",
+                                      color.BrightCoral, "WARNING: ",
+                                      color.NeonAzure, "wordlist data is sent to clients " +
+                                      "in the VPC over plaintext TCP"))
+    }
+    // Stop the TUI on local exit, noting any messages dropped while it was stalled
+    defer func() {
+        t.Stop()
+
+        // If the display fell behind the connection handlers
+        if dropped := t.Dropped(); dropped > 0 {
+            logMan.LogMessage("info", "TUI dropped %d stale panel messages", dropped)
+        }
+    } ()
+
+    // Set up context handler for TLS listener
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+
+    var tlsListener net.Listener
+    var err error
+    // If the SQS control plane is used, clients register on its queues instead
+    if ControlPlane != nil {
+        tlsListener = ControlPlane
+    } else {
+        var relayListener net.Listener
+        // If clients connect through the relay broker, receive them over connections the
+        // server makes out to it so no inbound port is needed
+        if appConfig.LocalConfig.RelayAddress != "" {
+            relayListener = relay.Listen(appConfig.LocalConfig.RelayAddress,
+                                         appConfig.LocalConfig.RelayToken,
+                                         appConfig.LocalConfig.RelayFingerprint,
+                                         min(appConfig.LocalConfig.NumberInstances,
+                                             RelayBacklog))
+        }
+
+        // Set up the TLS listener to accept incoming connections
+        tlsListener, err = TlsMan.SetupTlsListenerHandler(TlsMan.TlsCertificate,
+                                                          TlsMan.CaCertPool, ctx, "",
+                                                          appConfig.LocalConfig.ListenerPort,
+                                                          relayListener)
+        if err != nil {
+            fatalExit(logMan, "Error setting up TLS listener:  %v", err)
+        }
+    }
+
+    // Close the listener on local exit, which deletes the queues of the SQS control plane
+    defer func() {
+        err = tlsListener.Close()
+        // If the listener was not already closed by the watchdog
+        if err != nil && !errors.Is(err, net.ErrClosed) {
+            logMan.LogMessage("error", "Error closing listener:  %v", err)
+        }
+    } ()
+
+    // If the watchdog terminates the fleet, stop accepting connections
+    go func() {
+        select {
+        case <-ctx.Done():
+        case <-FleetStopped:
+            t.PostLeft(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                              color.LightCyan, "!"), "",
+                                          color.BrightCoral, "Budget guardrail " +
+                                          "tripped, fleet terminated"))
+            tlsListener.Close()
+        }
+    } ()
+
+    // If the SQS control plane is used, display the queues clients register on
+    if ControlPlane != nil {
+        t.PostLeft(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                          color.LightCyan, "!"), "",
+                                      color.NeonAzure, "Listening on SQS queue ",
+                                      color.KrakenGlowGreen,
+                                      ControlPlane.Addr().String()))
+
+        logMan.LogMessage("info", "Listening for connections on SQS queue %s ..",
+                          ControlPlane.Addr().String())
+    // If clients connect through the relay broker, display the relay address
+    } else if appConfig.LocalConfig.RelayAddress != "" {
+        t.PostLeft(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                          color.LightCyan, "!"), "",
+                                      color.NeonAzure, "Listening through relay ",
+                                      color.KrakenGlowGreen,
+                                      appConfig.LocalConfig.RelayAddress))
+
+        logMan.LogMessage("info", "Listening for connections through relay %s ..",
+                          appConfig.LocalConfig.RelayAddress)
+    } else {
+        // Display port TLS listener is on in the left panel
+        t.PostLeft(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                          color.LightCyan, "!"), "",
+                                      color.NeonAzure, "Listening on port ",
+                                      color.KrakenGlowGreen,
+                                      strconv.Itoa(appConfig.LocalConfig.ListenerPort)))
+
+        logMan.LogMessage("info", "Listening for connections on port %d ..",
+                          appConfig.LocalConfig.ListenerPort)
+    }
+
+    Events.Emit(eventstream.ServerListening, map[string]any{
+        "port": appConfig.LocalConfig.ListenerPort,
+    })
+
+    // If local mode is enabled, spawn the clients now the listener is accepting
+    if appConfig.LocalConfig.LocalClients {
+        err = startLocalClients(appConfig, logMan)
+        if err != nil {
+            logMan.LogMessage("error", "Error starting local clients:  %v", err)
+            return
+        }
+
+        t.PostLeft(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                          color.LightCyan, "!"), "",
+                                      color.NeonAzure, "Local clients started in ",
+                                      color.RadiantAmethyst, LocalClientsDir))
+    }
+
+    // If clients auto update, keep accepting so restarted clients can reconnect
+    if ClientUpdate != nil {
+        RemainingClients.Store(int32(appConfig.LocalConfig.NumberInstances))
+
+        go func() {
+            for {
+                err := acceptConnection(tlsListener, &waitGroup, appConfig, logMan, t)
+                if err != nil && !errors.Is(err, ErrClientRejected) {
+                    return
+                }
+            }
+        } ()
+
+        // Wait until every client has finished without a pending update or hibernation
+        for RemainingClients.Load() > 0 || !Hibernation.Idle() {
+            // If the watchdog terminated the fleet, stop waiting on the clients
+            select {
+            case <-FleetStopped:
+                return
+            case <-time.After(1 * time.Second):
+            }
+        }
+    } else {
+        for {
+            // If current number of connection is greater than or equal to number of instances
+            if CurrentConnections.Load() >= int32(appConfig.LocalConfig.NumberInstances) {
+                logMan.LogMessage("info", "All remote clients are connected")
+                break
+            }
+
+            // Accept the next client connection and handle it, a rejected client is not
+            // counted so the listener keeps waiting for the clients of the fleet
+            err = acceptConnection(tlsListener, &waitGroup, appConfig, logMan, t)
+            if err != nil && !errors.Is(err, ErrClientRejected) {
+                return
+            }
+        }
+
+        finished := make(chan struct{})
+        // Signal once every connected client has finished, the clients of a hibernated
+        // fleet disconnecting only once it is resumed and they have all reattached
+        go func() {
+            for {
+                waitGroup.Wait()
+
+                changed := Hibernation.Changed()
+                // If the run is not hibernated or waiting on clients to reattach
+                if Hibernation.Idle() {
+                    break
+                }
+
+                <-changed
+            }
+
+            close(finished)
+        } ()
+
+        for {
+            // If instances were added mid-run, accept their clients
+            if AddedInstances.Load() > 0 {
+                err = acceptConnection(tlsListener, &waitGroup, appConfig, logMan, t)
+                // If the client was refused, keep waiting for the added instance
+                if errors.Is(err, ErrClientRejected) {
+                    continue
+                }
+                if err != nil {
+                    return
+                }
+
+                AddedInstances.Add(-1)
+                continue
+            }
+
+            // Wait for the clients to finish unless more instances are added
+            select {
+            case <-InstancesAdded:
+                continue
+            case <-finished:
+            }
+
+            break
+        }
+    }
+
+    // Wait for all active Goroutines to finish before shutting down the server
+    waitGroup.Wait()
+
+    // Sleep for a few seconds so information can be displayed before tui is stopped
+    time.Sleep(5 * time.Second)
+}
+
+
+// Takes passed in args and renders them into the user data template for EC2 creation,
+// with the operator pre and post hook scripts read from the config.
+//
+// @Parameters
+// - appConf:  The configuration instance that stores program YAML data
+// - keyName:  The name of the key of the S3 bucket
+// - ipAddrs:  Slice of IP addresses to be formatted into CSV string
+// - ssmParam:  The path where the certificate is stored in SSM param store
+//
+// @Returns
+// - The generated EC2 user data with args formatted into it
+// - Error if it occurs, otherwise nil on success
+//
+func ec2UserDataGen(appConf *conf.AppConfig, keyName string, ipAddrs []string,
+                    ssmParam string) (string, error) {
+    // Convert the slice of IP addresses to CSV string
+    ipAddrsCsv, err := data.SliceToCsv(ipAddrs)
+    if err != nil {
+        return "", err
+    }
+
+    params := userdata.Params{
+        BucketName:    appConf.LocalConfig.BucketName,
+        EbsFallback:   appConf.LocalConfig.EbsFallback,
+        KeyName:       keyName,
+        Region:        appConf.ClientConfig.Region,
+        SelfTerminate: appConf.ClientConfig.SelfTerminate,
+        SsmSessions:   appConf.LocalConfig.SsmSessions,
+        Windows:       appConf.LocalConfig.ClientOs == awsutils.OsWindows,
+    }
+
+    // If a custom hashcat build was uploaded alongside the client, install it in place of
+    // the package or release
+    if appConf.LocalConfig.HashcatArtifact != "" {
+        params.HashcatArtifact = awsutils.HashcatArtifactKey(RunId)
+        params.HashcatSha256 = appConf.LocalConfig.HashcatArtifactSha256
+    } else {
+        params.HashcatRelease = hashcatRelease(appConf)
+    }
+
+    // If a candidate generator is set, install the package providing it
+    params.GeneratorPackage = hashcat.GeneratorPackages[appConf.ClientConfig.CandidateGenerator]
+
+    // If the client is hardened, create the unprivileged user it drops to after setup
+    // with access to the GPU device groups
+    if appConf.ClientConfig.Hardening {
+        params.HardeningUser = appConf.ClientConfig.HardeningUser
+    }
+
+    hookPaths := []string{appConf.LocalConfig.UserDataPreHook,
+                          appConf.LocalConfig.UserDataPostHook}
+    hookScripts := []*string{&params.PreHook, &params.PostHook}
+
+    // Iterate through the operator hook scripts reading the ones that are set
+    for index, hookPath := range hookPaths {
+        if hookPath == "" {
+            continue
+        }
+
+        hookData, err := os.ReadFile(hookPath)
+        if err != nil {
+            return "", fmt.Errorf("error reading user data hook - %w", err)
+        }
+
+        *hookScripts[index] = string(hookData)
+    }
+
+    flags := clientFlags(appConf, ipAddrsCsv, ssmParam, false)
+    params.Launch = "$CWD/client " + strings.Join(flags, " \\\n            ")
+
+    // If the client runs on Windows, quote the flags so PowerShell passes them verbatim
+    if params.Windows {
+        quotedFlags := make([]string, 0, len(flags))
+        // Iterate through the flags quoting each
+        for _, arg := range flags {
+            quotedFlags = append(quotedFlags, userdata.PowerShellQuote(arg))
+        }
+
+        params.Launch = "& \"$Cwd\\client.exe\" " + strings.Join(quotedFlags, " `\n    ")
+    }
+
+    // If the client is confined, run it under the generated systemd unit instead
+    if appConf.ClientConfig.SystemdConfinement {
+        execPath := "/usr/local/bin/kloud-kraken-client"
+        params.Launch = fmt.Sprintf(`install -m 0755 $CWD/client %s
+cat > %s <<'UNIT'
+%sUNIT
+systemctl daemon-reload
+systemctl start --wait %s`, execPath, harden.UnitPath,
+                                    harden.SystemdUnit(execPath, flags, "/mnt/instance-store"),
+                                    harden.UnitName)
+    }
+
+    return userdata.Render(params)
+}
+
+
+// Gets the path of the client binary built for the OS and architecture of the instances,
+// the arm64 build is expected next to the amd64 build as client-arm64 and the Windows
+// build as client.exe.
+//
+// @Parameters
+// - clientOs:  The operating system the clients run on
+// - instanceType:  The EC2 instance type the clients run on
+//
+// @Returns
+// - The path of the client binary
+//
+func clientBinaryPath(clientOs string, instanceType string) string {
+    // If the instances run Windows, use the Windows build
+    if clientOs == awsutils.OsWindows {
+        return "./client.exe"
+    }
+
+    // If the instances are Graviton, use the arm64 build
+    if awsutils.InstanceArchitecture(instanceType) == awsutils.ArchArm64 {
+        return "./client-arm64"
+    }
+
+    return "./client"
+}
+
+
+// Gets the hashcat release installed on the clients, the configured release or otherwise
+// the default release on Graviton instances, since the arm64 package lags behind the
+// release and its CUDA support, and on Windows, which has no package.
+//
+// @Parameters
+// - appConf:  The configuration instance that stores program YAML data
+//
+// @Returns
+// - The hashcat release tag, empty if the Ubuntu package is installed
+//
+func hashcatRelease(appConf *conf.AppConfig) string {
+    // If a release is configured, every client builds or downloads it
+    if appConf.LocalConfig.HashcatRelease != "" {
+        return appConf.LocalConfig.HashcatRelease
+    }
+
+    // If the instances are Graviton or run Windows, use the default release
+    if awsutils.InstanceArchitecture(appConf.LocalConfig.InstanceType) == awsutils.ArchArm64 ||
+       appConf.LocalConfig.ClientOs == awsutils.OsWindows {
+        return HashcatRelease
+    }
+
+    return ""
+}
+
+
+// Gets the path of the hashcat binary the clients execute, so they do not depend on
+// whichever hashcat is first in PATH. The user data places the custom build in
+// /opt/hashcat, installs built releases to /usr/local/bin, and the Ubuntu package to
+// /usr/bin, while Windows clients always extract hashcat to C:\hashcat.
+//
+// @Parameters
+// - appConf:  The configuration instance that stores program YAML data
+// - isTesting:  Whether the client runs in testing mode without AWS
+//
+// @Returns
+// - The path of the hashcat binary
+//
+func hashcatBinaryPath(appConf *conf.AppConfig, isTesting bool) string {
+    // If an explicit path is configured, it is used as is
+    if appConf.ClientConfig.HashcatPath != "" {
+        return appConf.ClientConfig.HashcatPath
+    }
+
+    // If the clients are spawned locally, hashcat is resolved from PATH
+    if isTesting {
+        return "hashcat"
+    }
+
+    // If the instances run Windows, the release or custom build is extracted to C:\hashcat
+    if appConf.LocalConfig.ClientOs == awsutils.OsWindows {
+        return `C:\hashcat\hashcat.exe`
+    }
+
+    // If a custom hashcat build is installed
+    if appConf.LocalConfig.HashcatArtifact != "" {
+        return "/opt/hashcat/hashcat"
+    }
+
+    // If a release is built from source and installed
+    if hashcatRelease(appConf) != "" {
+        return "/usr/local/bin/hashcat"
+    }
+
+    return "/usr/bin/hashcat"
+}
+
+
+// Gets the addresses clients connect to the server at, which the server certificate is
+// issued for. Clients in private subnets connect to the private IP of the relay broker,
+// otherwise they connect to the configured or discovered public IPs of the server.
+//
+// @Parameters
+// - appConf:  The configuration instance that stores program YAML data
+//
+// @Returns
+// - The addresses clients connect to
+// - Error if it occurs, otherwise nil on success
+//
+func serverHosts(appConf *conf.AppConfig) ([]string, error) {
+    // If clients connect through the relay broker
+    if appConf.LocalConfig.RelayClientHost != "" {
+        return []string{appConf.LocalConfig.RelayClientHost}, nil
+    }
+
+    // If the public IPs are set, such as for a static IP or port-forwarded NAT
+    if len(appConf.LocalConfig.PublicIps) > 0 {
+        return appConf.LocalConfig.PublicIps, nil
+    }
+
+    discoverer := publicip.NewDiscoverer(appConf.LocalConfig.IpDiscoveryEndpoints,
+                                         appConf.LocalConfig.StunServers)
+    // If discovered IPs are cached, keep them in the user cache dir between runs
+    if appConf.LocalConfig.PublicIpTtlDuration > 0 {
+        cacheDir, err := os.UserCacheDir()
+        if err == nil {
+            discoverer.CachePath = filepath.Join(cacheDir, "kloud-kraken", "public_ips.json")
+            discoverer.CacheTtl = appConf.LocalConfig.PublicIpTtlDuration
+        }
+    }
+
+    return discoverer.Discover()
+}
+
+
+// Gets the port clients connect to the server on, the client port of the relay broker
+// when clients connect through it.
+//
+// @Parameters
+// - appConf:  The configuration instance that stores program YAML data
+//
+// @Returns
+// - The port clients connect to
+//
+func serverPort(appConf *conf.AppConfig) int {
+    // If clients connect through the relay broker
+    if appConf.LocalConfig.RelayClientPort != 0 {
+        return appConf.LocalConfig.RelayClientPort
+    }
+
+    return appConf.LocalConfig.ListenerPort
+}
+
+
+// Gets the address the hashcat brain server listens on, the loopback address when the
+// clients run locally, otherwise the private address of the server in the VPC so the
+// brain is not exposed on every interface.
+//
+// @Parameters
+// - appConf:  The configuration instance that stores program YAML data
+//
+// @Returns
+// - The IP address the brain server listens on
+// - Error if it occurs, otherwise nil on success
+//
+func brainListenIp(appConf *conf.AppConfig) (string, error) {
+    // If the clients run on the server host
+    if appConf.LocalConfig.LocalTesting {
+        return "127.0.0.1", nil
+    }
+
+    privateIp, err := instance.PrivateIp(10 * time.Second)
+    if err != nil {
+        return "", fmt.Errorf("brain_server requires the server to run on an instance in " +
+                              "the VPC of the clients, use brain_host otherwise - %w", err)
+    }
+
+    return privateIp, nil
+}
+
+
+// Formats the command line flags a client is started with, shared by the EC2 user data
+// and the client processes spawned in local mode.
+//
+// @Parameters
+// - appConf:  The configuration instance that stores program YAML data
+// - ipAddrsCsv:  CSV string of the IP addresses of the server
+// - ssmParam:  The path where the certificate is stored in SSM param store
+// - isTesting:  Whether the client runs in testing mode without AWS
+//
+// @Returns
+// - The client command line flags in alphabetical order
+//
+func clientFlags(appConf *conf.AppConfig, ipAddrsCsv string, ssmParam string,
+                 isTesting bool) []string {
+    return []string{
+        "-applyOptimization=true",
+        "-autoUpdate=" + strconv.FormatBool(appConf.LocalConfig.ClientAutoUpdate),
+        "-awsRegion=" + appConf.ClientConfig.Region,
+        "-backendDevices=" + appConf.ClientConfig.BackendDevices,
+        "-brainClient=" + strconv.FormatBool(appConf.LocalConfig.BrainServer ||
+                                             appConf.LocalConfig.BrainHost != ""),
+        "-brainHost=" + appConf.LocalConfig.BrainHost,
+        "-brainPort=" + strconv.Itoa(appConf.LocalConfig.BrainPort),
+        "-brainSsmParam=" + BrainSsmParam,
+        "-bucketName=" + appConf.LocalConfig.BucketName,
+        "-candidateGenerator=" + appConf.ClientConfig.CandidateGenerator,
+        "-certSsmParam=" + ssmParam,
+        "-charSet1=" + appConf.ClientConfig.CharSet1,
+        "-charSet2=" + appConf.ClientConfig.CharSet2,
+        "-charSet3=" + appConf.ClientConfig.CharSet3,
+        "-charSet4=" + appConf.ClientConfig.CharSet4,
+        "-controlPlane=" + appConf.LocalConfig.ControlPlane,
+        "-crackingMode=" + appConf.ClientConfig.CrackingMode,
+        "-deviceTypes=" + appConf.ClientConfig.DeviceTypes,
+        "-extraHashcatArgs=" + hashcat.FormatExtraArgs(appConf.ClientConfig.ExtraHashcatArgs),
+        "-gpuMonitorInterval=" + appConf.ClientConfig.GpuMonitorIntervalDuration.String(),
+        "-gpuTempLimit=" + strconv.Itoa(appConf.ClientConfig.GpuTempLimit),
+        "-hardening=" + strconv.FormatBool(appConf.ClientConfig.Hardening),
+        "-hardeningUser=" + appConf.ClientConfig.HardeningUser,
+        "-hashcatJobs=" + strconv.Itoa(appConf.ClientConfig.HashcatJobs),
+        "-hashcatPath=" + hashcatBinaryPath(appConf, isTesting),
+        "-hashMask=" + appConf.ClientConfig.HashMask,
+        "-hashQuota=" + strconv.FormatInt(appConf.ClientConfig.HashQuotaInt64, 10),
+        "-hashType=" + appConf.ClientConfig.HashType,
+        "-idleTimeout=" + appConf.LocalConfig.IdleTimeoutDuration.String(),
+        "-ipAddrs=" + ipAddrsCsv,
+        "-isTesting=" + strconv.FormatBool(isTesting),
+        "-jobTimeout=" + appConf.ClientConfig.JobTimeoutDuration.String(),
+        "-kernelAccel=" + appConf.ClientConfig.KernelAccel,
+        "-kernelLoops=" + appConf.ClientConfig.KernelLoops,
+        "-kernelThreads=" + appConf.ClientConfig.KernelThreads,
+        "-keyspaceMode=" + strconv.FormatBool(appConf.ClientConfig.KeyspaceChunks > 0),
+        "-logMode=" + appConf.ClientConfig.LogMode,
+        "-logPath=" + appConf.ClientConfig.LogPath,
+        "-logStreaming=" + strconv.FormatBool(appConf.LocalConfig.LogStreaming),
+        "-lootFlushCracks=" + strconv.Itoa(appConf.ClientConfig.LootFlushCracks),
+        "-lootFlushInterval=" + appConf.ClientConfig.LootFlushIntervalDuration.String(),
+        "-maxFileSizeInt64=" + strconv.FormatInt(appConf.ClientConfig.MaxFileSizeInt64, 10),
+        "-maxTransfers=" + strconv.Itoa(int(appConf.ClientConfig.MaxTransfers)),
+        "-peerSharing=" + strconv.FormatBool(appConf.LocalConfig.PeerSharing),
+        "-plaintextTransfers=" + strconv.FormatBool(appConf.LocalConfig.PlaintextTransfers),
+        "-port=" + strconv.Itoa(serverPort(appConf)),
+        "-queuePrefix=" + QueuePrefix,
+        "-reservedSpace=" + appConf.ClientConfig.ReservedSpace,
+        "-rulesetCount=" + strconv.Itoa(len(appConf.LocalConfig.RulesetInputs)),
+        "-rulesetPairings=" + schedule.FormatPairings(appConf.LocalConfig.RulesetPairings),
+        "-rulesetQuota=" + strconv.FormatInt(appConf.ClientConfig.RulesetQuotaInt64, 10),
+        "-runId=" + RunId,
+        "-runName=" + RunName,
+        "-scrubOnCompletion=" + strconv.FormatBool(appConf.ClientConfig.ScrubOnCompletion),
+        "-selfTerminate=" + strconv.FormatBool(appConf.ClientConfig.SelfTerminate),
+        "-streamWordlists=" + strconv.FormatBool(appConf.ClientConfig.StreamWordlists),
+        "-tokenSsmParam=" + TokenSsmParam,
+        "-tunings=" + hashcat.FormatTunings(appConf.ClientConfig.Tunings),
+        "-wordlistQuota=" + strconv.FormatInt(appConf.ClientConfig.WordlistQuotaInt64, 10),
+        "-workStealing=" + strconv.FormatBool(appConf.LocalConfig.WorkStealing),
+        "-workload=" + cmp.Or(appConf.ClientConfig.Workload, hashcat.DefaultWorkload),
+    }
+}
+
+
+// Generates permission policy for the server.
+//
+// @Parameters
+// - region:  The AWS region where actions will be performed
+// - accountId:  The AWS account ID where actions will be performed
+// - ssmPrefix:  The path prefix the run parameters are stored under in SSM param store
+// - bucketName:  The name of the S3 bucket where actions will be performed
+// - resultsBucket:  The name of the S3 bucket where results are persisted, empty if unused
+// - clientRoleArn:  The ARN of the IAM role the client will be using
+// - sqsControl:  Whether clients connect over the SQS control plane
+// - hardening:  Whether encryption, public access, policy, or lifecycle settings are
+//               applied to the buckets
+// - kmsKeyArn:  The ARN of the KMS key the buckets are encrypted with, empty if unused
+// - snsTopics:  The ARNs of the SNS topics notifications are published to
+//
+// @Returns
+// - The generated permissions policy with args formatted into it
+//
+func serverPermPolicyGen(region string, accountId string, ssmPrefix string,
+                         bucketName string, resultsBucket string,
+                         clientRoleArn string, sqsControl bool, hardening bool,
+                         kmsKeyArn string, snsTopics []string) string {
+    // Format the ARNs in the partition of the region, aws-us-gov in GovCloud for example
+    arnPartition := partition.Id(region)
+
+    sqsStatement := ""
+    // If the SQS control plane is used, allow managing the run queues and staging wordlists
+    if sqsControl {
+        sqsStatement = fmt.Sprintf(`
+    {
+      "Sid": "SQSControlPlane",
+      "Effect": "Allow",
+      "Action": [
+        "sqs:CreateQueue",
+        "sqs:DeleteQueue",
+        "sqs:DeleteMessage",
+        "sqs:GetQueueUrl",
+        "sqs:ReceiveMessage",
+        "sqs:SendMessage"
+      ],
+      "Resource": "arn:%s:sqs:%s:%s:kloud-kraken-*"
+    },
+    {
+      "Sid": "S3StageWordlists",
+      "Effect": "Allow",
+      "Action": [
+        "s3:PutObject"
+      ],
+      "Resource": "arn:%s:s3:::%s/transfers/*"
+    },`, arnPartition, region, accountId, arnPartition, bucketName)
+    }
+
+    resultsStatement := ""
+    // If results are persisted to a bucket, allow uploading them and managing its lifecycle
+    if resultsBucket != "" {
+        resultsStatement = fmt.Sprintf(`
+    {
+      "Sid": "S3PersistResults",
+      "Effect": "Allow",
+      "Action": [
+        "s3:PutObject"
+      ],
+      "Resource": "arn:%s:s3:::%s/*"
+    },
+    {
+      "Sid": "S3ManageResultsBucket",
+      "Effect": "Allow",
+      "Action": [
+        "s3:CreateBucket",
+        "s3:ListBucket",
+        "s3:GetLifecycleConfiguration",
+        "s3:PutLifecycleConfiguration"
+      ],
+      "Resource": "arn:%s:s3:::%s"
+    },`, arnPartition, resultsBucket, arnPartition, resultsBucket)
+    }
+
+    notifyStatement := ""
+    // If notifications are published to SNS topics, allow publishing to them
+    if len(snsTopics) > 0 {
+        notifyStatement = fmt.Sprintf(`
+    {
+      "Sid": "SNSNotifications",
+      "Effect": "Allow",
+      "Action": [
+        "sns:Publish"
+      ],
+      "Resource": [
+        "%s"
+      ]
+    },`, strings.Join(snsTopics, `",
+        "`))
+    }
+
+    hardeningStatement := ""
+    // If the buckets are hardened, allow applying the settings to them
+    if hardening {
+        bucketArns := fmt.Sprintf(`"arn:%s:s3:::%s"`, arnPartition, bucketName)
+        // If results are persisted to a bucket, it is hardened as well
+        if resultsBucket != "" {
+            bucketArns += fmt.Sprintf(`,
+        "arn:%s:s3:::%s"`, arnPartition, resultsBucket)
+        }
+
+        hardeningStatement = fmt.Sprintf(`
+    {
+      "Sid": "S3HardenBuckets",
+      "Effect": "Allow",
+      "Action": [
+        "s3:GetLifecycleConfiguration",
+        "s3:PutBucketPolicy",
+        "s3:PutBucketPublicAccessBlock",
+        "s3:PutEncryptionConfiguration",
+        "s3:PutLifecycleConfiguration"
+      ],
+      "Resource": [
+        %s
+      ]
+    },`, bucketArns)
+    }
+
+    // If the buckets are encrypted with a KMS key, allow encrypting objects with it
+    if kmsKeyArn != "" {
+        hardeningStatement += fmt.Sprintf(`
+    {
+      "Sid": "KMSEncryptObjects",
+      "Effect": "Allow",
+      "Action": [
+        "kms:Decrypt",
+        "kms:GenerateDataKey"
+      ],
+      "Resource": "%s"
+    },`, kmsKeyArn)
+    }
+
+    return fmt.Sprintf(`{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Sid": "SSMUploadClientCert",
+      "Effect": "Allow",
+      "Action": [
+        "ssm:PutParameter",
+        "ssm:AddTagsToResource"
+      ],
+      "Resource": "arn:%s:ssm:%s:%s:parameter%s*"
+    },
+    {
+      "Sid": "SSMResolvePublicAmi",
+      "Effect": "Allow",
+      "Action": [
+        "ssm:GetParameter"
+      ],
+      "Resource": "arn:%s:ssm:%s::parameter/aws/service/*"
+    },
+    {
+      "Sid": "S3UploadClientBinary",
+      "Effect": "Allow",
+      "Action": [
+        "s3:PutObject",
+        "s3:PutObjectAcl",
+        "s3:PutObjectTagging"
+      ],
+      "Resource": "arn:%s:s3:::%s/*"
+    },%s%s%s%s
+    {
+      "Sid": "EC2LifecycleControl",
+      "Effect": "Allow",
+      "Action": [
+        "ec2:RunInstances",
+        "ec2:StartInstances",
+        "ec2:StopInstances",
+        "ec2:TerminateInstances",
+        "ec2:DescribeInstances",
+        "ec2:CreateTags"
+      ],
+      "Resource": [
+        "arn:%s:ec2:%s:%s:instance/*",
+        "arn:%s:ec2:%s:%s:subnet/*",
+        "arn:%s:ec2:%s:%s:security-group/*",
+        "arn:%s:ec2:%s:%s:volume/*",
+        "arn:%s:ec2:%s::image/*"
+      ]
+    },
+    {
+      "Sid": "PreflightChecks",
+      "Effect": "Allow",
+      "Action": [
+        "ec2:DescribeAccountAttributes",
+        "ec2:DescribeImages",
+        "ec2:DescribeInstanceTypes",
+        "ec2:DescribeInstances",
+        "servicequotas:GetServiceQuota"
+      ],
+      "Resource": "*"
+    },
+    {
+      "Sid": "EC2PassRoleForInstanceProfile",
+      "Effect": "Allow",
+      "Action": [
+        "iam:PassRole"
+      ],
+      "Resource": "%s"
+    }
+  ]
+}`, arnPartition, region, accountId, ssmPrefix, arnPartition, region, arnPartition,
+    bucketName, sqsStatement, resultsStatement, hardeningStatement, notifyStatement,
+    arnPartition, region, accountId, arnPartition, region, accountId,
+    arnPartition, region, accountId, arnPartition, region, accountId,
+    arnPartition, region, clientRoleArn)
+}
+
+
+// Generates trust policy for the server.
+//
+// @Parameters
+// - region:  The AWS region the ARN partition is determined from
+// - accountId:  The AWS account ID where actions will be performed
+// - iamUser:  The IAM user that the policy will apply to
+//
+// @Returns
+// - The generated trust policy with args formatted into it
+//
+func serverTrustPolicyGen(region string, accountId string, iamUser string) string {
+    return fmt.Sprintf(`{
+  "Version":"2012-10-17",
+  "Statement":[{
+    "Effect":"Allow",
+    "Principal":{
+      "AWS":"arn:%s:iam::%s:user/%s"
+    },
+    "Action":"sts:AssumeRole"
+  }]
+}`, partition.Id(region), accountId, iamUser)
+}
+
+
+// Generates permission policy for the client.
+//
+// @Parameters
+// - bucketName:  The name of the S3 bucket where actions will be performed
+// - region:  The AWS region where actions will be performed
+// - accountId:  The AWS account ID where actions will be performed
+// - paramPath:  The path prefix the run parameters are stored under in SSM param store
+// - logGroup:  The name of the CloudWatch group being utilized
+// - sqsControl:  Whether the client connects over the SQS control plane
+// - kmsKeyArn:  The ARN of the KMS key the bucket is encrypted with, empty if unused
+// - windowsDrivers:  Whether the clients download the NVIDIA drivers for Windows
+//
+// @Returns
+// - The generated permissions policy with args formatted into it
+//
+func clientPermPolicyGen(bucketName string, region string, accountId string,
+                         paramPath string, logGroup string, sqsControl bool,
+                         kmsKeyArn string, windowsDrivers bool) string {
+    // Format the ARNs in the partition of the region, aws-us-gov in GovCloud for example
+    arnPartition := partition.Id(region)
+
+    sqsStatement := ""
+    // If the SQS control plane is used, allow messaging on the run queues and
+    // removing staged wordlists once downloaded
+    if sqsControl {
+        sqsStatement = fmt.Sprintf(`
+    {
+      "Sid": "SQSControlPlane",
+      "Effect": "Allow",
+      "Action": [
+        "sqs:DeleteMessage",
+        "sqs:GetQueueUrl",
+        "sqs:ReceiveMessage",
+        "sqs:SendMessage"
+      ],
+      "Resource": "arn:%s:sqs:%s:%s:kloud-kraken-*"
+    },
+    {
+      "Sid": "S3FetchWordlists",
+      "Effect": "Allow",
+      "Action": [
+        "s3:DeleteObject"
+      ],
+      "Resource": "arn:%s:s3:::%s/transfers/*"
+    },`, arnPartition, region, accountId, arnPartition, bucketName)
+    }
+
+    kmsStatement := ""
+    // If the bucket is encrypted with a KMS key, allow decrypting the downloaded objects
+    if kmsKeyArn != "" {
+        kmsStatement = fmt.Sprintf(`
+    {
+      "Sid": "KMSDecryptObjects",
+      "Effect": "Allow",
+      "Action": [
+        "kms:Decrypt"
+      ],
+      "Resource": "%s"
+    },`, kmsKeyArn)
+    }
+
+    driverStatement := ""
+    // If the clients run Windows, allow fetching the NVIDIA drivers AWS publishes for EC2
+    if windowsDrivers {
+        driverStatement = fmt.Sprintf(`
+    {
+      "Sid": "S3FetchNvidiaDrivers",
+      "Effect": "Allow",
+      "Action": [
+        "s3:GetObject",
+        "s3:ListBucket"
+      ],
+      "Resource": [
+        "arn:%s:s3:::ec2-windows-nvidia-drivers",
+        "arn:%s:s3:::ec2-windows-nvidia-drivers/*"
+      ]
+    },`, arnPartition, arnPartition)
+    }
+
+    return fmt.Sprintf(`{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Sid": "S3DownloadBinary",
+      "Effect": "Allow",
+      "Action": [
+        "s3:GetObject"
+      ],
+      "Resource": "arn:%s:s3:::%s/*"
+    },%s%s%s
+    {
+      "Sid": "SSMFetchParameters",
+      "Effect": "Allow",
+      "Action": [
+        "ssm:GetParameter",
+        "ssm:GetParameters",
+        "ssm:GetParametersByPath"
+      ],
+      "Resource": [
+        "arn:%s:ssm:%s:%s:parameter%s*"
+      ]
+    },
+    {
+      "Sid": "CloudWatchLogging",
+      "Effect": "Allow",
+      "Action": [
+        "logs:CreateLogGroup",
+        "logs:CreateLogStream",
+        "logs:PutLogEvents"
+      ],
+      "Resource": "arn:%s:logs:%s:%s:log-group:%s*"
+    }
+  ]
+}`, arnPartition, bucketName, sqsStatement, kmsStatement, driverStatement, arnPartition,
+    region, accountId, paramPath, arnPartition, region, accountId, logGroup)
+}
+
+
+// Generates trust policy for the client.
+//
+// @Parameters
+// - region:  The AWS region the EC2 service principal is determined from
+//
+// @Returns
+// - The generated trust policy with args formatted into it
+//
+func clientTrustPolicyGen(region string) string {
+    return fmt.Sprintf(`{
+  "Version": "2012-10-17",
+  "Statement": [{
+    "Effect":    "Allow",
+    "Principal": { "Service": "%s" },
+    "Action":    "sts:AssumeRole"
+  }]
+}`, partition.ServicePrincipal("ec2", region))
+}
+
+
+// Generates the bucket policy restricting access to the Kloud-Kraken roles and the IAM
+// user running it, roles of every run are matched so later runs can still use the bucket.
+//
+// @Parameters
+// - bucketName:  The name of the S3 bucket the policy is applied to
+// - region:  The AWS region the ARN partition is determined from
+// - accountId:  The AWS account ID the roles and user belong to
+// - iamUser:  The IAM user that runs Kloud-Kraken
+// - roleArns:  The ARNs of pre-existing roles used in place of the run roles
+//
+// @Returns
+// - The generated bucket policy with args formatted into it
+//
+func bucketPolicyGen(bucketName string, region string, accountId string,
+                     iamUser string, roleArns []string) string {
+    arnPartition := partition.Id(region)
+
+    existingRoles := ""
+    // Iterate through the pre-existing roles allowing them alongside the run roles
+    for _, roleArn := range roleArns {
+        existingRoles += fmt.Sprintf(`,
+            "%s"`, roleArn)
+    }
+
+    return fmt.Sprintf(`{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Sid": "DenyOutsideRunRoles",
+      "Effect": "Deny",
+      "Principal": "*",
+      "Action": "s3:*",
+      "Resource": [
+        "arn:%s:s3:::%s",
+        "arn:%s:s3:::%s/*"
+      ],
+      "Condition": {
+        "StringNotLike": {
+          "aws:PrincipalArn": [
+            "arn:%s:iam::%s:role/%s*",
+            "arn:%s:iam::%s:user/%s"%s
+          ]
+        }
+      }
+    },
+    {
+      "Sid": "DenyInsecureTransport",
+      "Effect": "Deny",
+      "Principal": "*",
+      "Action": "s3:*",
+      "Resource": [
+        "arn:%s:s3:::%s",
+        "arn:%s:s3:::%s/*"
+      ],
+      "Condition": {
+        "Bool": {
+          "aws:SecureTransport": "false"
+        }
+      }
+    }
+  ]
+}`, arnPartition, bucketName, arnPartition, bucketName, arnPartition, accountId,
+    awsutils.RolePrefix, arnPartition, accountId, iamUser, existingRoles, arnPartition,
+    bucketName, arnPartition, bucketName)
+}
+
+
+// Formats the ARN of the KMS key the buckets are encrypted with, for granting its use in
+// the role policies.
+//
+// @Parameters
+// - localConfig:  The local config with the key ID or ARN, region, and account ID
+//
+// @Returns
+// - The ARN of the key, empty if no key is set
+//
+func kmsKeyArn(localConfig *conf.LocalConfig) string {
+    // If no key is set or it is already an ARN
+    if localConfig.S3KmsKeyId == "" || strings.HasPrefix(localConfig.S3KmsKeyId, "arn:") {
+        return localConfig.S3KmsKeyId
+    }
+
+    return partition.Arn(localConfig.Region, "kms", localConfig.Region, localConfig.AccountId,
+                         "key/" + localConfig.S3KmsKeyId)
+}
+
+
+// Applies the encryption, public access block, and bucket policy set in the config to
+// the bucket, leaving any setting not enabled as it is.
+//
+// @Parameters
+// - s3Man:  The S3 manager the settings are applied with
+// - bucketName:  The name of the S3 bucket to harden
+// - localConfig:  The local config with the hardening settings
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func hardenBucket(s3Man *awsutils.S3Manager, bucketName string,
+                  localConfig *conf.LocalConfig) error {
+    // If a KMS key is set, encrypt the objects stored in the bucket with it by default
+    if localConfig.S3KmsKeyId != "" {
+        err := s3Man.SetBucketEncryption(bucketName, localConfig.S3KmsKeyId, 1 * time.Minute)
+        if err != nil {
+            return fmt.Errorf("error applying SSE-KMS to %s - %w", bucketName, err)
+        }
+    }
+
+    // If public access should be blocked
+    if localConfig.S3BlockPublicAccess {
+        err := s3Man.BlockPublicAccess(bucketName, 1 * time.Minute)
+        if err != nil {
+            return fmt.Errorf("error blocking public access to %s - %w", bucketName, err)
+        }
+    }
+
+    // If access should be restricted to the run roles
+    if localConfig.S3RestrictToRoles {
+        var roleArns []string
+        // Iterate through the pre-existing roles collecting the ones set
+        for _, roleArn := range []string{localConfig.ClientRoleArn, localConfig.ServerRoleArn} {
+            if roleArn != "" {
+                roleArns = append(roleArns, roleArn)
+            }
+        }
+
+        policy := bucketPolicyGen(bucketName, localConfig.Region, localConfig.AccountId,
+                                  localConfig.IamUsername, roleArns)
+
+        err := s3Man.SetBucketPolicy(bucketName, policy, 1 * time.Minute)
+        if err != nil {
+            return fmt.Errorf("error applying bucket policy to %s - %w", bucketName, err)
+        }
+    }
+
+    return nil
+}
+
+
+// Uploads the custom hashcat build the clients install to the run prefix of the bucket,
+// after ensuring it matches the configured checksum. The checksum of the uploaded build is
+// stored in the config, so the user data verifies the download against it.
+//
+// @Parameters
+// - s3Man:  The S3 manager the build is uploaded with
+// - localConfig:  The local config with the hashcat build settings
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func uploadHashcatArtifact(s3Man *awsutils.S3Manager, localConfig *conf.LocalConfig) error {
+    artifactData, err := os.ReadFile(localConfig.HashcatArtifact)
+    if err != nil {
+        return fmt.Errorf("error reading hashcat artifact - %w", err)
+    }
+
+    artifactSum := update.HashBytes(artifactData)
+    // If the build does not match the configured checksum
+    if localConfig.HashcatArtifactSha256 != "" &&
+       !strings.EqualFold(artifactSum, localConfig.HashcatArtifactSha256) {
+        return fmt.Errorf("hashcat artifact checksum %s does not match " +
+                          "hashcat_artifact_sha256 %s", artifactSum,
+                          localConfig.HashcatArtifactSha256)
+    }
+
+    localConfig.HashcatArtifactSha256 = artifactSum
+
+    _, err = s3Man.PutS3Object(localConfig.BucketName, awsutils.HashcatArtifactKey(RunId),
+                               artifactData, 1 * time.Minute)
+    return err
+}
+
+
+// Ensures the pre-existing client role is held by the instance profile the clients are
+// launched with and grants the client permissions, along with the permissions of the SSM
+// agent when SSM sessions are enabled since the managed policy is not attached to it.
+//
+// @Parameters
+// - iamClient:  The client to the IAM service
+// - localConfig:  The local config with the client role and instance profile
+// - permissionsPolicy:  The JSON permissions policy the client role needs to grant
+//
+// @Returns
+// - Error if it occurs or a permission is missing, otherwise nil on success
+//
+func verifyClientRole(iamClient *iam.Client, localConfig *conf.LocalConfig,
+                      permissionsPolicy string) error {
+    err := awsutils.VerifyInstanceProfile(iamClient, localConfig.ClientInstanceProfile,
+                                          localConfig.ClientRoleArn, 1 * time.Minute)
+    if err != nil {
+        return err
+    }
+
+    err = awsutils.SimulateRolePolicy(iamClient, localConfig.ClientRoleArn,
+                                      permissionsPolicy, 2 * time.Minute)
+    if err != nil {
+        return err
+    }
+
+    // If SSM sessions are enabled, ensure the role grants what the SSM agent needs
+    if localConfig.SsmSessions {
+        return awsutils.SimulateRolePolicy(iamClient, localConfig.ClientRoleArn, `{
+  "Version": "2012-10-17",
+  "Statement": [{
+    "Effect": "Allow",
+    "Action": [
+      "ssm:UpdateInstanceInformation",
+      "ssmmessages:CreateControlChannel",
+      "ssmmessages:CreateDataChannel",
+      "ssmmessages:OpenControlChannel",
+      "ssmmessages:OpenDataChannel"
+    ],
+    "Resource": "*"
+  }]
+}`, 2 * time.Minute)
+    }
+
+    return nil
+}
+
+
+// Sets up AWS credentials, uses IAM permissions in the credentials to set up
+// client and server roles in IAM, or verifies the pre-existing roles with IAM
+// policy simulation. Then assumes created server role via STS
+// service. Puts generated TLS certificate in SSM parameter store and client
+// binary in S3 bucket for later retrieval. Concludes by launching EC2 instances.
+//
+// @Parameters
+// - appConfig:  The configuration instance with program YAML data
+// - publicIps:  List of public IPs to format into user data template
+//
+// @Returns
+// - The initialized AWS configuration instance
+// - The EC2 manager instance to utilize for later operations
+// - Error if it occurs, otherwise nil on success
+//
+func awsSetup(appConfig *conf.AppConfig, publicIps []string) (
+              aws.Config, *awsutils.Ec2Manger, error) {
+    var ec2Man *awsutils.Ec2Manger
+    // Set up the AWS credentials based on local chain or environment variables
+    awsConfig, _, _, err := awsutils.AwsConfigSetup(appConfig.LocalConfig.Region,
+                                                    1 * time.Minute)
+    if err != nil {
+        return awsConfig, ec2Man, err
+    }
+
+    // Setup client to IAM service
+    iamClient := iam.NewFromConfig(awsConfig)
+    // Track the IAM resources of the run with the original credentials so the server
+    // role can be deleted in teardown
+    IamResources = awsutils.NewIamRun(iamClient, RunId)
+    // Apply the permissions boundary the account requires to the roles the run creates
+    awsutils.PermissionsBoundary = appConfig.LocalConfig.IamPermissionsBoundary
+    clientRole := IamResources.RoleName("ClientRole")
+    serverRole := IamResources.RoleName("ServerRole")
+    clientProfile := clientRole
+    clientRoleArn := partition.Arn(appConfig.LocalConfig.Region, "iam", "",
+                                   appConfig.LocalConfig.AccountId, "role/" + clientRole)
+    // If a pre-existing client role is used, launch the clients with its instance profile
+    if appConfig.LocalConfig.ClientRoleArn != "" {
+        clientProfile = appConfig.LocalConfig.ClientInstanceProfile
+        clientRoleArn = appConfig.LocalConfig.ClientRoleArn
+    }
+    // Whether clients connect over the SQS control plane instead of TLS
+    sqsControl := appConfig.LocalConfig.ControlPlane == controlplane.ModeSqs
+    // Whether any hardening settings are applied to the buckets
+    hardening := appConfig.LocalConfig.S3KmsKeyId != "" ||
+                 appConfig.LocalConfig.S3BlockPublicAccess ||
+                 appConfig.LocalConfig.S3RestrictToRoles ||
+                 appConfig.LocalConfig.ClientBinaryExpiration > 0
+
+    // Generate the EC2 clients trust and permissions policy templates
+    trustPolicy := clientTrustPolicyGen(appConfig.ClientConfig.Region)
+    permissionsPolicy := clientPermPolicyGen(appConfig.LocalConfig.BucketName,
+                                             appConfig.ClientConfig.Region,
+                                             appConfig.LocalConfig.AccountId,
+                                             awsutils.ParameterPrefix(RunId),
+                                             awsutils.LogGroup(RunId), sqsControl,
+                                             kmsKeyArn(&appConfig.LocalConfig),
+                                             appConfig.LocalConfig.ClientOs == awsutils.OsWindows)
+    // If a pre-existing client role is used, ensure it grants the client permissions
+    if appConfig.LocalConfig.ClientRoleArn != "" {
+        err = verifyClientRole(iamClient, &appConfig.LocalConfig, permissionsPolicy)
+        if err != nil {
+            return awsConfig, ec2Man, fmt.Errorf("client_role_arn can not be used - %w", err)
+        }
+    } else {
+        // Track the client role first so a partially created role is still torn down
+        IamResources.AddRole(clientRole, "ClientPermissions", true)
+        // Create and apply the EC2 client role
+        _, err = awsutils.IamRoleCreation(iamClient, 2 * time.Minute, clientRole,
+                                          trustPolicy, "ClientPermissions",
+                                          permissionsPolicy, true)
+        if err != nil {
+            return awsConfig, ec2Man, err
+        }
+    }
+
+    // If SSM sessions are enabled, attach the SSM managed instance policy to the client role
+    if appConfig.LocalConfig.SsmSessions && appConfig.LocalConfig.ClientRoleArn == "" {
+        ssmPolicyArn := partition.Arn(appConfig.ClientConfig.Region, "iam", "", "aws",
+                                      "policy/AmazonSSMManagedInstanceCore")
+        IamResources.AddManagedPolicy(clientRole, ssmPolicyArn)
+
+        err = awsutils.SetManagedRolePolicy(iamClient, 1 * time.Minute, clientRole,
+                                            ssmPolicyArn, true)
+        if err != nil {
+            return awsConfig, ec2Man, err
+        }
+    }
+
+    // Generate the servers trust and permissions policy templates
+    trustPolicy = serverTrustPolicyGen(appConfig.LocalConfig.Region,
+                                       appConfig.LocalConfig.AccountId,
+                                       appConfig.LocalConfig.IamUsername)
+    permissionsPolicy = serverPermPolicyGen(appConfig.LocalConfig.Region,
+                                            appConfig.LocalConfig.AccountId,
+                                            awsutils.ParameterPrefix(RunId),
+                                            appConfig.LocalConfig.BucketName,
+                                            appConfig.LocalConfig.ResultsBucket,
+                                            clientRoleArn, sqsControl, hardening,
+                                            kmsKeyArn(&appConfig.LocalConfig),
+                                            notify.SnsTopics(
+                                                appConfig.LocalConfig.Notifications))
+    serverArn := appConfig.LocalConfig.ServerRoleArn
+    // If a pre-existing server role is used, ensure it grants the server permissions before
+    // it is assumed
+    if serverArn != "" {
+        err = awsutils.SimulateRolePolicy(iamClient, serverArn, permissionsPolicy,
+                                          2 * time.Minute)
+        if err != nil {
+            return awsConfig, ec2Man, fmt.Errorf("server_role_arn can not be used - %w", err)
+        }
+    } else {
+        // Track the server role first so a partially created role is still torn down
+        IamResources.AddRole(serverRole, "ServerPermissions", false)
+        // Create and apply role for local server permissions
+        serverArn, err = awsutils.IamRoleCreation(iamClient, 2 * time.Minute, serverRole,
+                                                  trustPolicy, "ServerPermissions",
+                                                  permissionsPolicy, false)
+        if err != nil {
+            return awsConfig, ec2Man, err
+        }
+    }
+
+    rolesMessage := "IAM server and client roles created"
+    // If either role is pre-existing, it was verified rather than created
+    if appConfig.LocalConfig.ClientRoleArn != "" || appConfig.LocalConfig.ServerRoleArn != "" {
+        rolesMessage = "IAM server and client roles ready, existing roles passed " +
+                       "policy simulation"
+    }
+
+    printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "$"), "",
+                                   color.NeonAzure, rolesMessage))
+
+    // Set up client to Security Token Service
+    stsClient := sts.NewFromConfig(awsConfig)
+    // Create a provider that will call STS AssumeRole under the covers
+    assumeProvider := aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(stsClient,
+                                                                             serverArn))
+    // Wait for the new server role to be assumable
+    err = awsutils.WaitForCredentials(assumeProvider, 2 * time.Minute)
+    if err != nil {
+        return awsConfig, ec2Man, err
+    }
+
+    // Create fresh AWS config from new STS provider
+    awsConfig, err = config.LoadDefaultConfig(
+        context.TODO(),
+        config.WithRegion(appConfig.LocalConfig.Region),
+        config.WithCredentialsProvider(assumeProvider),
+    )
+    if err != nil {
+        return awsConfig, ec2Man, err
+    }
+
+    // Establish client to SSM
+    ssmMan := awsutils.NewSsmManagerFromConfig(awsConfig)
+
+    amiParameter := appConfig.LocalConfig.AmiSsmParameter
+    // If no parameter is set, use the default parameter of the client OS and instance
+    // architecture
+    if amiParameter == "" {
+        amiParameter = awsutils.AmiParameter(appConfig.LocalConfig.ClientOs,
+            awsutils.InstanceArchitecture(appConfig.LocalConfig.InstanceType))
+    }
+
+    // Resolve the AMI for the region unless an override is set
+    ami, err := ssmMan.ResolveAmi(appConfig.LocalConfig.Ami, amiParameter, 1 * time.Minute)
+    if err != nil {
+        return awsConfig, ec2Man, err
+    }
+
+    // Ensure the fleet fits the account quotas and the AMI is usable before launching
+    results, err := awsutils.Preflight(awsConfig, appConfig.LocalConfig.InstanceType,
+                                       appConfig.LocalConfig.NumberInstances, ami,
+                                       1 * time.Minute)
+    if err != nil {
+        return awsConfig, ec2Man, fmt.Errorf("pre-flight checks failed:\n%w", err)
+    }
+
+    // Iterate through the passed checks displaying each
+    for _, result := range results {
+        printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                           color.LightCyan, "$"), "",
+                                       color.NeonAzure, "Pre-flight check passed, ",
+                                       color.RadiantAmethyst, result))
+    }
+
+    // Push the servers certificate PEM into SSM parameter store
+    param, err := ssmMan.PutSsmParameter(awsutils.CertParameter(RunId),
+                                         string(TlsMan.CertPemBlock),
+                                         1 * time.Minute)
+    if err != nil {
+        return awsConfig, ec2Man, err
+    }
+    // Save the parameter so rotated certificates replace the value clients fetch
+    CertSsmParam = param
+
+    printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "$"), "",
+                                   color.NeonAzure, "TLS certificate uploaded to " +
+                                   "SSM Parameter Store for client retrieval"))
+
+    // If clients must authenticate, push the connection token beside the certificate
+    if ConnectionToken != "" {
+        TokenSsmParam, err = ssmMan.PutSsmParameter(awsutils.TokenParameter(RunId),
+                                                    ConnectionToken, 1 * time.Minute)
+        if err != nil {
+            return awsConfig, ec2Man, err
+        }
+    }
+
+    // If clients use the brain, push its password so it is kept out of the user data
+    if appConfig.LocalConfig.BrainPassword != "" {
+        BrainSsmParam, err = ssmMan.PutSsmParameter(awsutils.BrainParameter(RunId),
+                                                    appConfig.LocalConfig.BrainPassword,
+                                                    1 * time.Minute)
+        if err != nil {
+            return awsConfig, ec2Man, err
+        }
+    }
+
+    // Establish client to S3
+    s3Man := awsutils.NewS3ManagerFromConfig(awsConfig)
+    // Check to see if S3 bucket exists
+    exists, err := s3Man.BucketExists(appConfig.LocalConfig.BucketName, 1 * time.Minute)
+    if err != nil {
+        return awsConfig, ec2Man, err
+    }
+
+    // If S3 bucket does not exist create one
+    if !exists {
+        err = s3Man.CreateBucket(appConfig.LocalConfig.BucketName, 1 * time.Minute)
+        if err != nil {
+            return awsConfig, ec2Man, err
+        }
+
+        printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                           color.LightCyan, "$"), "",
+                                       color.NeonAzure, "Created S3 bucket ",
+                                       color.RadiantAmethyst, appConfig.LocalConfig.BucketName))
+    }
+
+    // Apply the hardening settings before anything is uploaded to the bucket
+    err = hardenBucket(s3Man, appConfig.LocalConfig.BucketName, &appConfig.LocalConfig)
+    if err != nil {
+        return awsConfig, ec2Man, err
+    }
+
+    // If client binaries should expire, apply the lifecycle rule to every run prefix
+    if appConfig.LocalConfig.ClientBinaryExpiration > 0 {
+        err = s3Man.SetBucketExpiration(appConfig.LocalConfig.BucketName,
+                                        "kloud-kraken-client-binaries",
+                                        awsutils.RunPathPrefix + "/",
+                                        appConfig.LocalConfig.ClientBinaryExpiration,
+                                        1 * time.Minute)
+        if err != nil {
+            return awsConfig, ec2Man, fmt.Errorf("error applying client binary lifecycle " +
+                                                 "rule - %w", err)
+        }
+    }
+
+    // Read the client binary built for the OS and architecture of the instances into memory
+    binData, err := os.ReadFile(clientBinaryPath(appConfig.LocalConfig.ClientOs,
+                                                 appConfig.LocalConfig.InstanceType))
+    if err != nil {
+        return awsConfig, ec2Man, err
+    }
+
+    // Upload the client binary to S3 Bucket
+    keyName, err := s3Man.PutS3Object(appConfig.LocalConfig.BucketName,
+                                      awsutils.ClientBinaryKey(RunId), binData,
+                                      1 * time.Minute)
+    if err != nil {
+        return awsConfig, ec2Man, err
+    }
+
+    printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "$"), "",
+                                   color.NeonAzure, "Uploaded client binary to S3 bucket ",
+                                   color.RadiantAmethyst, appConfig.LocalConfig.BucketName))
+
+    // If a custom hashcat build is installed on the clients, upload it alongside the client
+    if appConfig.LocalConfig.HashcatArtifact != "" {
+        err = uploadHashcatArtifact(s3Man, &appConfig.LocalConfig)
+        if err != nil {
+            return awsConfig, ec2Man, err
+        }
+
+        printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                           color.LightCyan, "$"), "",
+                                       color.NeonAzure, "Uploaded hashcat build to S3 bucket ",
+                                       color.RadiantAmethyst,
+                                       appConfig.LocalConfig.BucketName))
+    }
+
+    // If clients should update to new binary versions mid-run, publish the initial version
+    if appConfig.LocalConfig.ClientAutoUpdate {
+        ClientUpdate = update.NewPublisher(update.HashBytes(binData), keyName)
+        ClientRestarts = update.NewRestarts()
+    }
+
+    // If clients connect over the SQS control plane, create its queues before launching
+    if sqsControl {
+        QueuePrefix = "kloud-kraken-" + RunId
+
+        // If dry-run is enabled, record the queue creation instead of executing it
+        if awsutils.DryRun != nil {
+            awsutils.DryRun.Record("sqs", "CreateQueue", map[string]any{
+                "queue": controlplane.RegisterQueue(QueuePrefix),
+            })
+        } else {
+            ControlPlane, err = controlplane.NewListener(sqs.NewFromConfig(awsConfig),
+                                                         QueuePrefix, 1 * time.Minute)
+            if err != nil {
+                return awsConfig, ec2Man, err
+            }
+
+            awsutils.Audit.Record(audit.CategoryAws, "sqs:CreateQueue", map[string]any{
+                "queue": controlplane.RegisterQueue(QueuePrefix),
+            })
+
+            printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                               color.LightCyan, "$"), "",
+                                           color.NeonAzure, "Created SQS control plane queue ",
+                                           color.RadiantAmethyst,
+                                           ControlPlane.Addr().String()))
+        }
+
+        // Wordlists are staged in the bucket the client binary is stored in
+        S3Stage = s3Man
+    }
+
+    // Generate user data script to set up client program in EC2
+    userData, err := ec2UserDataGen(appConfig, keyName, publicIps, param)
+    if err != nil {
+        return awsConfig, ec2Man, err
+    }
+
+    dataVolumeSize := 0
+    // If the EBS fallback is enabled, attach a data volume for when instance store is absent
+    if appConfig.LocalConfig.EbsFallback {
+        dataVolumeSize = appConfig.LocalConfig.EbsVolumeSize
+    }
+
+    printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "$"), "",
+                                   color.NeonAzure, "Launching instances with AMI ",
+                                   color.RadiantAmethyst, ami))
+
+    // Setup EC2 creation instance with populated args
+    ec2Man = awsutils.NewEc2ManagerFromConfig(ami, awsConfig,
+                                              appConfig.LocalConfig.NumberInstances,
+                                              appConfig.LocalConfig.InstanceType,
+                                              awsutils.ServiceTagValue, clientProfile, RunId,
+                                              appConfig.LocalConfig.SecurityGroupIds,
+                                              appConfig.LocalConfig.SecurityGroups,
+                                              appConfig.LocalConfig.SubnetId,
+                                              dataVolumeSize,
+                                              appConfig.ClientConfig.SelfTerminate,
+                                              []byte(userData))
+    // Create number of EC2 instances based on passed in data
+    err = ec2Man.CreateEc2Instances(20 * time.Minute)
+    if err != nil {
+        return awsConfig, ec2Man, err
+    }
+
+    printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "$"), "",
+                                   color.NeonAzure, "EC2 instance creation completed"))
+
+    // If instances were actually launched (not dry-run)
+    if awsutils.DryRun == nil {
+        Events.Emit(eventstream.InstancesLaunched, map[string]any{
+            "instance_ids":  ec2Man.InstanceIds(),
+            "instance_type": appConfig.LocalConfig.InstanceType,
+        })
+    }
+
+    return awsConfig, ec2Man, nil
+}
+
+
+// Polls SSM until the agent on every launched instance is online or the timeout
+// expires, logging the instances that Session Manager sessions can not be opened to.
+//
+// @Parameters
+// - awsConfig:  The AWS credential configuration for connecting to SSM
+// - instanceIds:  The IDs of the launched instances
+// - timeout:  The maximum length of time to wait for the agents
+// - logMan:  The kloudlogs logger manager for local logging
+//
+func checkSsmAgents(awsConfig aws.Config, instanceIds []string, timeout time.Duration,
+                    logMan *kloudlogs.LoggerManager) {
+    var online []string
+    var err error
+    ssmMan := awsutils.NewSsmManagerFromConfig(awsConfig)
+    deadline := time.Now().Add(timeout)
+
+    for time.Now().Before(deadline) {
+        // Get the instances with an online SSM agent
+        online, err = ssmMan.OnlineInstances(instanceIds, 1 * time.Minute)
+        if err != nil {
+            logMan.LogMessage("error", "Error checking SSM agents:  %v", err)
+        } else if len(online) == len(instanceIds) {
+            logMan.LogMessage("info", "SSM agents online for all instances",
+                              zap.Strings("instances", online))
+            return
+        }
+
+        time.Sleep(30 * time.Second)
+    }
+
+    // Iterate through the instances logging the ones without an online agent
+    for _, instanceId := range instanceIds {
+        if !slices.Contains(online, instanceId) {
+            logMan.LogMessage("error", "SSM agent not online, sessions unavailable",
+                              zap.String("instance", instanceId))
+        }
+    }
+}
+
+
+// Handles the init subcommand, which interactively asks for the main config keys,
+// validates each answer, and writes a commented config generated from the template.
+//
+// @Parameters
+// - args:  The command line args following the init subcommand
+//
+func runInit(args []string) {
+    initFlags := flag.NewFlagSet("init", flag.ExitOnError)
+    force := initFlags.Bool("force", false, "Overwrite the output config if it exists")
+    skipQuota := initFlags.Bool("skip-quota-check", false,
+                                "Skip checking the instance type against the account quotas")
+    initFlags.Parse(args)
+
+    // If more than the optional output path was passed in
+    if initFlags.NArg() > 1 {
+        log.Fatal("Usage:  kloud-kraken init [-force] [-skip-quota-check] [config.yml]")
+    }
+
+    outputPath := "config.yml"
+    if initFlags.NArg() == 1 {
+        outputPath = initFlags.Arg(0)
+    }
+
+    // If the output config exists and overwriting was not requested
+    if _, err := os.Stat(outputPath); err == nil && !*force {
+        log.Fatalf("%s already exists, pass -force to overwrite it", outputPath)
+    }
+
+    var check conf.InstanceCheck
+    // If quota checks are enabled, check the instances against the live account quotas
+    if !*skipQuota {
+        check = func(region string, instanceType string, count int) error {
+            // Set up the AWS credentials based on local chain or environment variables
+            awsConfig, _, _, err := awsutils.AwsConfigSetup(region, 30 * time.Second)
+            if err != nil {
+                fmt.Printf("  Skipping quota check, no AWS credentials - %v\n", err)
+                return nil
+            }
+
+            required, available, err := awsutils.CheckVcpuQuota(awsConfig, instanceType,
+                                                                count, 30 * time.Second)
+            if err != nil {
+                return err
+            }
+
+            fmt.Printf("  %d of %d available vCPUs in the %s quota\n", required, available,
+                       region)
+            return nil
+        }
+    }
+
+    fmt.Println("Answer each question, leaving it empty uses the default in brackets")
+
+    answers, err := conf.RunWizard(os.Stdin, os.Stdout, conf.WizardQuestions(check))
+    if err != nil {
+        log.Fatalf("Error running init wizard:  %v", err)
+    }
+
+    output, err := conf.RenderConfig(kkconfig.Template, kkconfig.Instructions, answers)
+    if err != nil {
+        log.Fatalf("Error generating config:  %v", err)
+    }
+
+    err = os.WriteFile(outputPath, output, 0600)
+    if err != nil {
+        log.Fatalf("Error writing config:  %v", err)
+    }
+
+    fmt.Printf("Config written to %s, review the remaining keys before running\n",
+               outputPath)
+}
+
+
+// Handles the inspect-loaddir subcommand, which samples the wordlists in the load dir and
+// predicts their layout after merging without modifying any of them.
+//
+// @Parameters
+// - args:  The command line args following the inspect-loaddir subcommand
+//
+func runInspectLoadDir(args []string) {
+    inspectFlags := flag.NewFlagSet("inspect-loaddir", flag.ExitOnError)
+    sampleSize := inspectFlags.String("sample-size", "1MB",
+                                      "The bytes sampled from the start of each wordlist")
+    inspectFlags.Parse(args)
+
+    // If the config file path was not passed in
+    if inspectFlags.NArg() != 1 {
+        log.Fatal("Usage:  kloud-kraken inspect-loaddir [-sample-size <size>] <config.yml>")
+    }
+
+    // Parse and convert the sample size to raw bytes from any units
+    sampleBytes, err := validate.ValidateFileSize(*sampleSize)
+    if err != nil {
+        log.Fatalf("Invalid sample size - %v", err)
+    }
+
+    appConfig := conf.LoadConfig(inspectFlags.Arg(0))
+
+    report, err := inspect.InspectDir(appConfig.LocalConfig.LoadDir, sampleBytes,
+                                      appConfig.LocalConfig.MaxMergingSizeInt64,
+                                      appConfig.ClientConfig.MaxFileSizeInt64,
+                                      appConfig.LocalConfig.MaxSizeRange)
+    if err != nil {
+        log.Fatalf("Error inspecting load dir:  %v", err)
+    }
+
+    fmt.Printf("Load dir:  %s\n", appConfig.LocalConfig.LoadDir)
+    fmt.Print(inspect.FormatReport(report))
+}
+
+
+// Handles the verify-audit subcommand, which checks the hash chain of an audit log is
+// unbroken so it can be trusted in engagement reports.
+//
+// @Parameters
+// - args:  The command line args following the verify-audit subcommand
+//
+func runVerifyAudit(args []string) {
+    verifyFlags := flag.NewFlagSet("verify-audit", flag.ExitOnError)
+    verifyFlags.Parse(args)
+
+    // If the audit log path was not passed in
+    if verifyFlags.NArg() != 1 {
+        log.Fatal("Usage:  kloud-kraken verify-audit <audit_log>")
+    }
+
+    count, err := audit.Verify(verifyFlags.Arg(0))
+    if err != nil {
+        log.Fatalf("Audit log failed verification after %d entries:  %v", count, err)
+    }
+
+    fmt.Printf("Audit log verified, %d entries with an unbroken hash chain\n", count)
+}
+
+
+// Handles the identify subcommand, which samples the hash file and suggests the hash_type
+// of the hashes from their length, charset, and prefix.
+//
+// @Parameters
+// - args:  The command line args following the identify subcommand
+//
+func runIdentify(args []string) {
+    identifyFlags := flag.NewFlagSet("identify", flag.ExitOnError)
+    sampleLines := identifyFlags.Int("sample", 1000, "The number of hashes sampled")
+    identifyFlags.Parse(args)
+
+    // If the hash file path was not passed in
+    if identifyFlags.NArg() != 1 || *sampleLines < 1 {
+        log.Fatal("Usage:  kloud-kraken identify [-sample <lines>] <hashfile>")
+    }
+
+    report, err := identify.IdentifyFile(identifyFlags.Arg(0), *sampleLines)
+    if err != nil {
+        log.Fatalf("Error identifying hash file:  %v", err)
+    }
+
+    fmt.Printf("Hash file:  %s\n", identifyFlags.Arg(0))
+    fmt.Print(identify.FormatReport(report))
+}
+
+
+// Handles the shell subcommand, which opens an interactive SSM Session Manager
+// session to a launched instance for debugging failed clients.
+//
+// @Parameters
+// - args:  The command line args following the shell subcommand
+//
+func runShell(args []string) {
+    shellFlags := flag.NewFlagSet("shell", flag.ExitOnError)
+    region := shellFlags.String("region", "us-east-1", "The AWS region the instance is in")
+    shellFlags.Parse(args)
+
+    // If the instance ID was not passed in
+    if shellFlags.NArg() != 1 {
+        log.Fatal("Usage:  kloud-kraken shell [-region <region>] <instance-id>")
+    }
+
+    instanceId := shellFlags.Arg(0)
+    // Ensure the instance ID is of proper format
+    err := validate.ValidateInstanceId(instanceId)
+    if err != nil {
+        log.Fatal(err)
+    }
+
+    // Ensure the region is valid
+    if !validate.ValidateRegion(*region) {
+        log.Fatalf("Invalid AWS region - %q", *region)
+    }
+
+    // Ensure the AWS CLI and Session Manager plugin used to open the session are installed
+    for _, binary := range []string{"aws", "session-manager-plugin"} {
+        _, err = exec.LookPath(binary)
+        if err != nil {
+            log.Fatalf("%s must be installed to open SSM sessions", binary)
+        }
+    }
+
+    // Set up the AWS credentials based on local chain or environment variables
+    awsConfig, _, _, err := awsutils.AwsConfigSetup(*region, 1 * time.Minute)
+    if err != nil {
+        log.Fatalf("Error setting up AWS credentials:  %v", err)
+    }
+
+    ssmMan := awsutils.NewSsmManagerFromConfig(awsConfig)
+    // Ensure the SSM agent on the instance is online
+    online, err := ssmMan.OnlineInstances([]string{instanceId}, 1 * time.Minute)
+    if err != nil {
+        log.Fatalf("Error checking SSM agent:  %v", err)
+    }
+
+    // If the agent is not registered or online
+    if len(online) == 0 {
+        log.Fatalf("SSM agent on %s is not online, ensure ssm_sessions was " +
+                   "enabled when the instance was launched", instanceId)
+    }
+
+    // Open the interactive session attached to the terminal
+    cmd := exec.Command("aws", "ssm", "start-session", "--target", instanceId,
+                        "--region", *region)
+    cmd.Stdin = os.Stdin
+    cmd.Stdout = os.Stdout
+    cmd.Stderr = os.Stderr
+
+    accessKey := os.Getenv("AWS_ACCESS_KEY")
+    secretKey := os.Getenv("AWS_SECRET_KEY")
+    // If the credentials are in the program environment variables, pass them to the AWS CLI
+    if accessKey != "" && secretKey != "" {
+        cmd.Env = append(os.Environ(), "AWS_ACCESS_KEY_ID=" + accessKey,
+                         "AWS_SECRET_ACCESS_KEY=" + secretKey)
+    }
+
+    err = cmd.Run()
+    if err != nil {
+        log.Fatalf("Error running SSM session:  %v", err)
+    }
+}
+
+
+// Handles the admin subcommand, which calls a method on the admin socket of a running
+// server and prints its JSON result.
+//
+// @Parameters
+// - args:  The command line args following the admin subcommand
+//
+func runAdmin(args []string) {
+    adminFlags := flag.NewFlagSet("admin", flag.ExitOnError)
+    socketPath := adminFlags.String("socket",
+                                    filepath.Join(os.TempDir(), "kloud-kraken.sock"),
+                                    "The admin socket of the running server")
+    adminFlags.Parse(args)
+
+    // If the method was not passed in
+    if adminFlags.NArg() < 1 || adminFlags.NArg() > 2 {
+        log.Fatal("Usage:  kloud-kraken admin [-socket <path>] " +
+                  "<status|pause|resume|drain|abort|terminate_client|add_budget|" +
+                  "add_instances> [params-json]")
+    }
+
+    var params json.RawMessage
+    // If params were passed in, ensure they are valid JSON before sending
+    if adminFlags.NArg() == 2 {
+        params = json.RawMessage(adminFlags.Arg(1))
+        if !json.Valid(params) {
+            log.Fatalf("Invalid params JSON:  %s", adminFlags.Arg(1))
+        }
+    }
+
+    result, err := admin.Call(*socketPath, adminFlags.Arg(0), params, 2 * time.Minute)
+    if err != nil {
+        log.Fatalf("Error calling admin method:  %v", err)
+    }
+
+    var indented bytes.Buffer
+    // If the result can not be indented, print it as received
+    if json.Indent(&indented, result, "", "  ") != nil {
+        fmt.Println(string(result))
+        return
+    }
+
+    fmt.Println(indented.String())
+}
+
+
+// Handles the relay subcommand, which runs the relay broker on an instance in the VPC so
+// clients in private subnets and a server behind NAT connect out to it instead of to each
+// other.
+//
+// @Parameters
+// - args:  The command line args following the relay subcommand
+//
+func runRelay(args []string) {
+    relayFlags := flag.NewFlagSet("relay", flag.ExitOnError)
+    backlog := relayFlags.Int("backlog", 256,
+                              "The max server connections waiting for clients")
+    certPath := relayFlags.String("cert", "relay-cert.pem",
+                                  "The relay certificate, generated on the first start")
+    clientPort := relayFlags.Int("clientPort", 7001, "The port the clients connect to")
+    keyPath := relayFlags.String("key", "relay-key.pem",
+                                 "The relay key, generated on the first start")
+    serverPort := relayFlags.Int("serverPort", 7000, "The port the server connects to")
+    relayFlags.Parse(args)
+
+    // The token is only read from the environment so it is not visible in the process list
+    token := os.Getenv("KLOUD_KRAKEN_RELAY_TOKEN")
+    // If the token was not set or is too short
+    if len(token) < 16 || *backlog < 1 {
+        log.Fatal("Usage:  KLOUD_KRAKEN_RELAY_TOKEN=<relay_token> kloud-kraken relay " +
+                  "[-serverPort <port>] [-clientPort <port>] [-backlog <count>] " +
+                  "[-cert <path>] [-key <path>]")
+    }
+
+    cert, fingerprint, err := relay.LoadCert(*certPath, *keyPath)
+    if err != nil {
+        log.Fatalf("Error loading the relay certificate:  %v", err)
+    }
+
+    serverListener, err := net.Listen("tcp", ":" + strconv.Itoa(*serverPort))
+    if err != nil {
+        log.Fatalf("Error listening for the server:  %v", err)
+    }
+    // Serve the server connections over TLS so the token is never sent in the clear
+    serverListener = relay.TlsListener(serverListener, cert)
+
+    clientListener, err := net.Listen("tcp", ":" + strconv.Itoa(*clientPort))
+    if err != nil {
+        log.Fatalf("Error listening for clients:  %v", err)
+    }
+
+    broker := relay.NewBroker(token, *backlog)
+    fmt.Printf("Relaying server port %d to client port %d\n", *serverPort, *clientPort)
+    fmt.Printf("Set relay_fingerprint in the server config to %s\n", fingerprint)
+
+    // Serve the clients in the background, the server connections in the foreground
+    go func() {
+        err := broker.ServeClients(clientListener)
+        if err != nil {
+            log.Fatalf("Error accepting clients:  %v", err)
+        }
+    } ()
+
+    err = broker.ServeServers(serverListener)
+    if err != nil {
+        log.Fatalf("Error accepting server connections:  %v", err)
+    }
+}
+
+
+// Handles the print-effective-config subcommand, which prints the config merged from the
+// defaults, YAML file, profile, environment, and flags after it is validated.
+//
+// @Parameters
+// - args:  The command line args following the print-effective-config subcommand
+//
+func runPrintEffectiveConfig(args []string) {
+    var setFlag conf.SetFlag
+
+    printFlags := flag.NewFlagSet("print-effective-config", flag.ExitOnError)
+    profile := printFlags.String("profile", "",
+                                 "The config profile applied over the YAML file")
+    printFlags.Var(&setFlag, "set", "Override a config key in section.key=value format, " +
+                   "can be repeated")
+    printFlags.Parse(args)
+
+    // If the config file path was not passed in
+    if printFlags.NArg() != 1 {
+        log.Fatal("Usage:  kloud-kraken print-effective-config [-profile <name>] " +
+                  "[-set <section.key=value>]... <config.yml>")
+    }
+
+    appConfig := conf.LoadConfigLayers(printFlags.Arg(0),
+                                       conf.Overrides{Profile: *profile, Set: setFlag})
+
+    effective, err := conf.EffectiveYaml(appConfig)
+    if err != nil {
+        log.Fatalf("Error formatting effective config:  %v", err)
+    }
+
+    fmt.Print(string(effective))
+}
+
+
+// Handles the teardown subcommand, which discovers the resources tagged by runs across
+// the regions, shows the plan, and deletes them once confirmed. Used when a run crashed
+// before it could clean up after itself. Without a picked run, only the resources of runs
+// with no running or stopped instances that are older than the grace period are deleted.
+//
+// @Parameters
+// - args:  The command line args following the teardown subcommand
+//
+func runTeardown(args []string) {
+    teardownFlags := flag.NewFlagSet("teardown", flag.ExitOnError)
+    bucketName := teardownFlags.String("bucket", "",
+                                       "The S3 bucket searched for tagged objects")
+    regionsCsv := teardownFlags.String("regions", "",
+                                       "The AWS regions to search in CSV format")
+    assumeYes := teardownFlags.Bool("yes", false, "Delete without confirmation")
+    grace := teardownFlags.Duration("grace", 24 * time.Hour,
+                                    "The age resources of dead runs must reach to be deleted")
+    runId := teardownFlags.String("run", "",
+                                  "The ID of the run to tear down, even if still live")
+    teardownFlags.Parse(args)
+
+    // If more than the optional config file path was passed in
+    if teardownFlags.NArg() > 1 {
+        log.Fatal("Usage:  kloud-kraken teardown [-regions <regions>] [-bucket <bucket>] " +
+                  "[-run <run id>] [-grace <duration>] [-yes] [config.yml]")
+    }
+
+    var regions []string
+    // If a config was passed in, search its regions and bucket
+    if teardownFlags.NArg() == 1 {
+        appConfig := conf.LoadConfig(teardownFlags.Arg(0))
+        regions = append(regions, appConfig.LocalConfig.Region, appConfig.ClientConfig.Region)
+
+        // If no bucket was passed in, use the bucket of the config
+        if *bucketName == "" {
+            *bucketName = appConfig.LocalConfig.BucketName
+        }
+    }
+
+    // If regions were passed in, search them as well
+    if *regionsCsv != "" {
+        regions = append(regions, strings.Split(*regionsCsv, ",")...)
+    }
+
+    slices.Sort(regions)
+    regions = slices.Compact(regions)
+
+    // If there are no regions to search
+    if len(regions) == 0 {
+        log.Fatal("A config file or -regions is required to search for orphaned resources")
+    }
+
+    // Ensure each region is valid
+    for _, region := range regions {
+        if !validate.ValidateRegion(region) {
+            log.Fatalf("Invalid AWS region - %q", region)
+        }
+    }
+
+    // Ensure the bucket name is of proper format if passed in
+    err := validate.ValidateBucketName(*bucketName)
+    if err != nil {
+        log.Fatal(err)
+    }
+
+    var orphans []awsutils.Orphan
+    awsConfigs := map[string]aws.Config{}
+
+    // Iterate through the regions discovering the orphans in each
+    for index, region := range regions {
+        // Set up the AWS credentials based on local chain or environment variables
+        awsConfig, _, _, err := awsutils.AwsConfigSetup(region, 1 * time.Minute)
+        if err != nil {
+            log.Fatalf("Error setting up AWS credentials:  %v", err)
+        }
+
+        awsConfigs[region] = awsConfig
+        bucket := ""
+        // The bucket and global IAM resources are only searched once
+        if index == 0 {
+            bucket = *bucketName
+        }
+
+        found, err := awsutils.DiscoverOrphans(awsConfig, bucket, index == 0, 5 * time.Minute)
+        if err != nil {
+            log.Fatalf("Error discovering orphaned resources in %s:  %v", region, err)
+        }
+
+        orphans = append(orphans, found...)
+    }
+
+    // If no run was picked, list the live runs that are left alone
+    if *runId == "" {
+        for _, liveRun := range awsutils.LiveRuns(orphans) {
+            fmt.Printf("Skipping live run %s with running or stopped instances, pass " +
+                       "-run %s to tear it down\n", liveRun, liveRun)
+        }
+    }
+
+    orphans = awsutils.SelectOrphans(orphans, *runId, *grace, time.Now())
+    fmt.Print(awsutils.FormatPlan(orphans))
+
+    // If nothing was left behind
+    if len(orphans) == 0 {
+        return
+    }
+
+    // If deletion was not confirmed by flag, prompt for it
+    if !*assumeYes {
+        fmt.Print("Delete these resources? [y/N]: ")
+
+        var answer string
+        // Read the confirmation from the user
+        fmt.Scanln(&answer)
+
+        // If the user did not confirm the deletion
+        if !strings.EqualFold(strings.TrimSpace(answer), "y") {
+            fmt.Println("Teardown cancelled")
+            return
+        }
+    }
+
+    var errs []error
+    // Iterate through the regions deleting their orphans, global IAM resources are
+    // deleted with the first region they were discovered in
+    for index, region := range regions {
+        regionOrphans := slices.DeleteFunc(slices.Clone(orphans),
+                                           func(orphan awsutils.Orphan) bool {
+            return orphan.Region != region && !(orphan.Region == "" && index == 0)
+        })
+
+        errs = append(errs, awsutils.DeleteOrphans(awsConfigs[region], regionOrphans,
+                                                   10 * time.Minute))
+    }
+
+    err = errors.Join(errs...)
+    if err != nil {
+        log.Fatalf("Error deleting orphaned resources:  %v", err)
+    }
+
+    fmt.Printf("Deleted %d orphaned resources\n", len(orphans))
+}
+
+
+// Estimates the projected spend of the fleet before launch, if the estimate exceeds the
+// budget limit the user must confirm the launch.
+//
+// @Parameters
+// - appConfig:  The configuration struct with loaded yaml program data
+//
+// @Returns
+// - The hourly price in USD of a single instance
+// - Error if it occurs, otherwise nil on success
+//
+func confirmCostEstimate(appConfig *conf.AppConfig) (float64, error) {
+    // Get the hourly price of the configured instance type
+    rate, err := cost.HourlyRate(appConfig.LocalConfig.InstanceType,
+                                 appConfig.LocalConfig.HourlyPrice)
+    if err != nil {
+        return 0, err
+    }
+
+    // If there is no expected runtime to project the spend with
+    if appConfig.LocalConfig.ExpectedRuntimeDuration == 0 {
+        return rate, nil
+    }
+
+    estimate := cost.Estimate(rate, appConfig.LocalConfig.NumberInstances,
+                              appConfig.LocalConfig.ExpectedRuntimeDuration)
+
+    printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "$"), "",
+                                   color.NeonAzure, "Projected spend of ",
+                                   color.KrakenGlowGreen, fmt.Sprintf("$%.2f", estimate),
+                                   color.NeonAzure, fmt.Sprintf(" for %d x %s over %s",
+                                   appConfig.LocalConfig.NumberInstances,
+                                   appConfig.LocalConfig.InstanceType,
+                                   appConfig.LocalConfig.ExpectedRuntimeDuration)))
+
+    Events.Emit(eventstream.CostEstimated, map[string]any{
+        "budget_limit": appConfig.LocalConfig.BudgetLimit,
+        "estimate":     estimate,
+        "hourly_rate":  rate,
+    })
+
+    // If there is no budget limit or the estimate is within it or nothing will be launched
+    if appConfig.LocalConfig.BudgetLimit == 0 ||
+    estimate <= appConfig.LocalConfig.BudgetLimit || awsutils.DryRun != nil {
+        return rate, nil
+    }
+
+    // If JSON output is enabled, there is no interactive prompt
+    if Events != nil {
+        return 0, fmt.Errorf("projected spend $%.2f exceeds budget_limit $%.2f", estimate,
+                             appConfig.LocalConfig.BudgetLimit)
+    }
+
+    fmt.Printf("Projected spend exceeds budget limit of $%.2f, launch anyway? [y/N]: ",
+               appConfig.LocalConfig.BudgetLimit)
+
+    var answer string
+    // Read the confirmation from the user
+    fmt.Scanln(&answer)
+
+    // If the user did not confirm the launch
+    if !strings.EqualFold(strings.TrimSpace(answer), "y") {
+        return 0, fmt.Errorf("launch cancelled, projected spend $%.2f exceeds " +
+                             "budget_limit $%.2f", estimate, appConfig.LocalConfig.BudgetLimit)
+    }
+
+    return rate, nil
+}
+
+
+// Periodically checks the fleet against the max cost and max runtime thresholds, if
+// either is exceeded the fleet is terminated and the server is signalled to shut down.
+//
+// @Parameters
+// - ctx:  The context that stops the watchdog when cancelled
+// - watchdog:  The watchdog tracking the fleet runtime and spend
+// - ec2Man:  The EC2 manager of the launched fleet
+// - logMan:  The kloudlogs logger manager for local logging
+//
+func runCostWatchdog(ctx context.Context, watchdog *cost.Watchdog, ec2Man *awsutils.Ec2Manger,
+                     logMan *kloudlogs.LoggerManager) {
+    ticker := time.NewTicker(1 * time.Minute)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case now := <-ticker.C:
+            // If the spend reached the warning percent of the max cost, warn once
+            if warned, reason := watchdog.Warn(now); warned {
+                logMan.LogMessage("warn", "Budget warning threshold reached",
+                                  zap.String("reason", reason))
+
+                Notifier.Notify(notify.BudgetThreshold, map[string]any{
+                    "action": "warning",
+                    "reason": reason,
+                    "spent":  fmt.Sprintf("$%.2f", watchdog.Spent(now)),
+                })
+            }
+
+            // If neither threshold has been exceeded
+            exceeded, reason := watchdog.Check(now)
+            if !exceeded {
+                continue
+            }
+
+            spent := watchdog.Spent(now)
+            logMan.LogMessage("error", "Budget guardrail tripped, terminating fleet",
+                              zap.String("reason", reason), zap.Float64("spent", spent))
+
+            Events.Emit(eventstream.BudgetExceeded, map[string]any{
+                "reason": reason,
+                "spent":  spent,
+            })
+
+            Notifier.Notify(notify.BudgetThreshold, map[string]any{
+                "action": "fleet terminated",
+                "reason": reason,
+                "spent":  fmt.Sprintf("$%.2f", spent),
+            })
+
+            Exceptions.Record(exceptions.DeadLettered, "", "fleet terminated, " + reason)
+
+            // Terminate the EC2 instances before any more spend accumulates
+            _, err := ec2Man.TerminateEc2Instances(10 * time.Minute)
+            if err != nil {
+                logMan.LogMessage("error", "Error terminating EC2 instances:  %v", err)
+            }
+
+            // Signal the server to stop waiting on the terminated clients
+            close(FleetStopped)
+            return
+        }
+    }
+}
+
+
+// Periodically reissues the server TLS certificate so no certificate outlives the run
+// configured lifetime. The new certificate replaces the SSM parameter for clients that
+// connect later, while connected clients receive it on their next certificate check.
+//
+// @Parameters
+// - ctx:  The context that stops the rotation when cancelled
+// - interval:  How often the certificate is reissued
+// - awsConfig:  The AWS configuration used to update the SSM parameter
+// - logMan:  The kloudlogs logger manager for local logging
+//
+func runCertRotation(ctx context.Context, interval time.Duration, awsConfig aws.Config,
+                     logMan *kloudlogs.LoggerManager) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            // Reissue the certificate, new handshakes use it from here on
+            certPem, err := TlsMan.Rotate()
+            if err != nil {
+                logMan.LogMessage("error", "Error rotating TLS certificate:  %v", err)
+                continue
+            }
+
+            // If clients fetch the certificate from SSM, replace the value they fetch
+            if CertSsmParam != "" {
+                ssmMan := awsutils.NewSsmManagerFromConfig(awsConfig)
+
+                err = ssmMan.UpdateSsmParameter(CertSsmParam, string(certPem), 1 * time.Minute)
+                if err != nil {
+                    logMan.LogMessage("error", "Error distributing rotated TLS certificate " +
+                                      "via SSM:  %v", err)
+                }
+            }
+
+            fingerprint, err := tlsutils.Fingerprint(certPem)
+            if err != nil {
+                logMan.LogMessage("error", "Error computing rotated certificate fingerprint:  %v",
+                                  err)
+                continue
+            }
+
+            logMan.LogMessage("info", "TLS certificate rotated",
+                              zap.String("fingerprint", fingerprint))
+
+            Events.Emit(eventstream.CertRotated, map[string]any{
+                "fingerprint": fingerprint,
+            })
+        }
+    }
+}
+
+
+// Runs the AWS setup with every action recorded instead of executed, then prints the
+// plan and writes it as JSON to the received dir for review before a real run.
+//
+// @Parameters
+// - appConfig:  The configuration struct with loaded yaml program data
+//
+func runDryRun(appConfig *conf.AppConfig) {
+    // If the program is being tested locally, there are no AWS actions to plan
+    if appConfig.LocalConfig.LocalTesting {
+        log.Fatal("Dry-run has no AWS actions to plan when local_testing is enabled")
+    }
+
+    // Query IP lookup APIs for public IP addresses used in the user data
+    publicIps, err := serverHosts(appConfig)
+    if err != nil {
+        log.Fatalf("Error getting public IP addresses:  %v", err)
+    }
+
+    // Generate the servers TLS PEM certificate and key uploaded to SSM
+    err = TlsMan.PemCertAndKeyGenHandler("Kloud Kraken", false, publicIps...)
+    if err != nil {
+        log.Fatalf("Error creating TLS PEM certificate & key:  %v", err)
+    }
+
+    // Estimate the spend of the planned fleet
+    hourlyRate, err := confirmCostEstimate(appConfig)
+    if err != nil {
+        log.Fatalf("Error with cost estimate:  %v", err)
+    }
+
+    // Record the AWS setup actions
+    awsConfig, _, err := awsSetup(appConfig, publicIps)
+    if err != nil {
+        log.Fatalf("Error planning AWS setup:  %v", err)
+    }
+
+    // Record the results bucket setup actions
+    err = setupResults(appConfig, awsConfig, time.Now())
+    if err != nil {
+        log.Fatalf("Error planning results storage:  %v", err)
+    }
+
+    // Record the IAM resource deletions performed in teardown
+    err = IamResources.Teardown(time.Minute)
+    if err != nil {
+        log.Fatalf("Error planning IAM teardown:  %v", err)
+    }
+
+    planPath := filepath.Join(ReceivedDir, "dry_run_plan.json")
+    // Write the plan as JSON for review
+    err = awsutils.DryRun.WriteJson(planPath)
+    if err != nil {
+        log.Fatalf("Error writing dry-run plan:  %v", err)
+    }
+
+    // If JSON output is enabled, emit the plan as a single event
+    if Events != nil {
+        Events.Emit(eventstream.DryRunPlan, map[string]any{
+            "actions":     awsutils.DryRun.Actions(),
+            "hourly_rate": hourlyRate,
+            "plan_path":   planPath,
+        })
+        return
+    }
+
+    fmt.Print(awsutils.DryRun.Format())
+    fmt.Printf("\nFleet of %d x %s at $%.3f/hour per instance\n",
+               appConfig.LocalConfig.NumberInstances, appConfig.LocalConfig.InstanceType,
+               hourlyRate)
+
+    // If an expected runtime is set, show the projected spend
+    if appConfig.LocalConfig.ExpectedRuntimeDuration > 0 {
+        fmt.Printf("Projected spend of $%.2f over %s\n",
+                   cost.Estimate(hourlyRate, appConfig.LocalConfig.NumberInstances,
+                                 appConfig.LocalConfig.ExpectedRuntimeDuration),
+                   appConfig.LocalConfig.ExpectedRuntimeDuration)
+    }
+
+    fmt.Println("JSON plan written to " + planPath)
+}
+
+
+// Computes how the load dir wordlists would be merged with the configured sizes without
+// writing to them, then prints the plan and writes it as JSON to the received dir so the
+// settings can be tuned before the merge runs.
+//
+// @Parameters
+// - appConfig:  The configuration struct with loaded yaml program data
+//
+func runMergePlan(appConfig *conf.AppConfig) {
+    // If wordlists are assigned by range, the load dir is indexed instead of merged
+    if appConfig.LocalConfig.RangeAssignment {
+        log.Fatal("Merge plan has nothing to plan when range_assignment is enabled")
+    }
+
+    plan, err := newMerger(appConfig, nil, nil).Plan(appConfig.LocalConfig.LoadDir)
+    if err != nil {
+        log.Fatalf("Error planning wordlist merge:  %v", err)
+    }
+
+    planPath := filepath.Join(ReceivedDir, "merge_plan.json")
+    // Write the plan as JSON for review
+    err = plan.WriteJson(planPath)
+    if err != nil {
+        log.Fatalf("Error writing merge plan:  %v", err)
+    }
+
+    // If JSON output is enabled, emit the plan as a single event
+    if Events != nil {
+        Events.Emit(eventstream.MergePlan, map[string]any{
+            "plan":      plan,
+            "plan_path": planPath,
+        })
+        return
+    }
+
+    fmt.Print(plan.Format())
+    fmt.Println("\nJSON plan written to " + planPath)
+}
+
+
+// Registers a cleanup run when the server exits, including on a fatal error, such as
+// terminating the fleet or deleting the IAM resources of the run.
+//
+// @Parameters
+// - cleanup:  The cleanup to run on exit
+//
+func onExit(cleanup func()) {
+    ExitMutex.Lock()
+    defer ExitMutex.Unlock()
+
+    ExitCleanups = append(ExitCleanups, cleanup)
+}
+
+
+// Runs the registered exit cleanups once in the reverse order they were registered,
+// the way deferred calls are.
+//
+func runExitCleanups() {
+    ExitMutex.Lock()
+    cleanups := ExitCleanups
+    ExitCleanups = nil
+    ExitMutex.Unlock()
+
+    // Iterate through the cleanups from the last registered
+    for index := len(cleanups) - 1; index >= 0; index-- {
+        cleanups[index]()
+    }
+}
+
+
+// Logs the fatal error, runs the exit cleanups that log.Fatalf would skip along with
+// the deferred calls, then exits with a failure status.
+//
+// @Parameters
+// - logMan:  The kloudlogs logger manager for local logging, nil before it is set up
+// - format:  The message of the error, supports printf format with below args
+// - args:  The args of the message
+//
+func fatalExit(logMan *kloudlogs.LoggerManager, format string, args ...any) {
+    // If the logger is set up, record the error in the run log before exiting
+    if logMan != nil {
+        logMan.LogMessage("error", format, args...)
+    }
+
+    runExitCleanups()
+    log.Fatalf(format, args...)
+}
+
+
+// Prints the message to stdout unless the JSON event stream is enabled,
+// in which case stdout is reserved for JSON events.
+//
+// @Parameters
+// - message:  The message to be printed
+//
+func printMessage(message string) {
+    // If JSON output is enabled, keep stdout clean, in dry-run mode
+    // only the plan is printed since no actions are executed
+    if Events != nil || awsutils.DryRun != nil {
+        return
+    }
+
+    fmt.Println(message)
+}
+
+
+// Displays the Kloud Kraken ascii banner.
+//
+func printBanner() {
+    // If JSON output is enabled, keep stdout clean
+    if Events != nil {
+        return
+    }
+
+    // Print program banner
+    fmt.Println(color.MistyAqua + `
+          ,.                                     ..
+           xO:     c. .                  .    'o0,
+           .XM'  l0. .d     co    c.  d  kKdOWMk
+           .MM.;NN' xM;   lKKxXx.lMl  MX,oMx,oM0'
+           .MM0Ml   OM.   dM. dM'lMc  MN oMl  xMk
+           .MMXMx   OM.  'dM. dM'lMc  MN oMl  dMo
+           .MM cMO. OM..0'kM' xM,lMd .MW oMl .0M0.
+           xMM. :MX,xXNOx:'dN0Kc..cKKNd'.KMXOWK:.
+      .;, .'Wl '..cko.,   . .d    . :..;o:..,:. .  ,c'.
+        dWd.'lXd:0coO:. .dWl. 'O; lkd:llo0c.d0'  KWd
+        .Md dN: oMl'KWd .M0M. ;Mc,Nk. KW';o dM0  OM.
+        ,MKNO.  lMx0Xc  xX.Md ;MKMo   KMco  dMN0 0M
+        .MNdWO  lMkxWo .MKkNW.;MOdW;  KM;' ,dM'KKXM
+        .Mx :Wx oM: oM.OM  'MO:Ml oM: XMldN,xM..0MM.
+        lM0  lX0;0.  Oldx   xk.X. .c0d''ok,'Nd. :MMo
+        OWo.   ,o,    :.     . .     .,   .o.   .cXX.
+       dd.       .                        .        :O.
+      ;.                                             :.
+    ` + color.AnsiReset)
+}
+
+
+// Create the required dirs for program operation.
+//
+func makeServerDirs() {
+    // Set the program directories
+    programDirs := []string{ReceivedDir, ShardDir}
+    // Create needed directories
+    disk.MakeDirs(programDirs)
+}
+
+
+// Parses command line flags and args (path to yaml config file), if args not present
+// or invalid then proceeds to user input until valid yaml file is specified. When JSON
+// output is enabled there is no user prompting and an invalid path is fatal.
+//
+// @Returns
+// - Pointer to AppConfig struct populated from yaml data
+//
+func parseArgs() *conf.AppConfig {
+    var configFilePath string
+    var setFlag conf.SetFlag
+
+    // Define command line flags
+    dryRun := flag.Bool("dry-run", false, "Print the planned AWS actions without " +
+                        "executing them")
+    jsonOutput := flag.Bool("json", false, "Emit events as JSON lines on stdout " +
+                            "instead of colored text, disables the TUI")
+    flag.BoolVar(&PlanMerge, "merge-plan", false, "Print how the load dir wordlists would " +
+                 "be merged without writing them")
+    profile := flag.String("profile", "", "The config profile applied over the YAML file")
+    flag.Var(&setFlag, "set", "Override a config key in section.key=value format, " +
+             "can be repeated")
+    flag.Parse()
+
+    // If dry-run was enabled, record AWS actions instead of executing them
+    if *dryRun {
+        awsutils.DryRun = awsutils.NewPlan()
+    }
+
+    // If JSON output was enabled, set up the event stream on stdout
+    if *jsonOutput {
+        Events = eventstream.NewEmitter(os.Stdout)
+    }
+
+    // If the config file path was not passed in
+    if flag.NArg() < 1 {
+        // If JSON output is enabled, there is no interactive prompt
+        if Events != nil {
+            log.Fatal("YAML config file path arg is required with --json")
+        }
+
+        // Prompt the user until proper path is passed in
+        validate.ValidateConfigPath(&configFilePath)
+    // If the config file path arg was passed in
+    } else {
+        // Set the provided arg as the config file path
+        configFilePath = flag.Arg(0)
+
+        // Check to see if the input path exists and is a file or dir
+        exists, isDir, hasData, err := disk.PathExists(configFilePath)
+        if err != nil {
+            log.Fatal("Error checking config file path existence: ", err)
+        }
+
+        // If the path does not exist OR is a dir OR does not have data OR is not YAML file
+        if !exists || isDir || !hasData || !strings.HasSuffix(configFilePath, ".yml") {
+            // If JSON output is enabled, there is no interactive prompt
+            if Events != nil {
+                log.Fatal("Provided YAML config file path invalid: ", configFilePath)
+            }
+
+            fmt.Println("Provided YAML config file path invalid: ", configFilePath)
+            // Sleep for a few seconds and clear screen
+            display.ClearScreen(3)
+            // Prompt the user until proper path is passed in
+            validate.ValidateConfigPath(&configFilePath)
+        }
+    }
+
+    // Load the configuration from the YAML file merged with the rest of the layers
+    return conf.LoadConfigLayers(configFilePath, conf.Overrides{Profile: *profile,
+                                                                Set: setFlag})
+}
+
+
+// Parse command line args, make needed directories, merge wordlists and remove remaining
+// empty dirs. Set up AWS access config with key and secret, set up logging manager
+// instance, set up EC2 code passing command line args via user data, and start server.
+//
+func main() {
+    // If the shell subcommand was passed in, open an SSM session instead of running
+    if len(os.Args) > 1 && os.Args[1] == "shell" {
+        runShell(os.Args[2:])
+        return
+    }
+
+    // If the init subcommand was passed in, generate a config interactively and exit
+    if len(os.Args) > 1 && os.Args[1] == "init" {
+        runInit(os.Args[2:])
+        return
+    }
+
+    // If the inspect-loaddir subcommand was passed in, report on the load dir and exit
+    if len(os.Args) > 1 && os.Args[1] == "inspect-loaddir" {
+        runInspectLoadDir(os.Args[2:])
+        return
+    }
+
+    // If the teardown subcommand was passed in, clean up orphaned resources and exit
+    if len(os.Args) > 1 && os.Args[1] == "teardown" {
+        runTeardown(os.Args[2:])
+        return
+    }
+
+    // If the identify subcommand was passed in, suggest the hash type and exit
+    if len(os.Args) > 1 && os.Args[1] == "identify" {
+        runIdentify(os.Args[2:])
+        return
+    }
+
+    // If the verify-audit subcommand was passed in, check the audit log chain and exit
+    if len(os.Args) > 1 && os.Args[1] == "verify-audit" {
+        runVerifyAudit(os.Args[2:])
+        return
+    }
+
+    // If the admin subcommand was passed in, call the running server and exit
+    if len(os.Args) > 1 && os.Args[1] == "admin" {
+        runAdmin(os.Args[2:])
+        return
+    }
+
+    // If the relay subcommand was passed in, run the relay broker until killed
+    if len(os.Args) > 1 && os.Args[1] == "relay" {
+        runRelay(os.Args[2:])
+        return
+    }
+
+    // If the print-effective-config subcommand was passed in, show the merged config and exit
+    if len(os.Args) > 1 && os.Args[1] == "print-effective-config" {
+        runPrintEffectiveConfig(os.Args[2:])
+        return
+    }
+
+    // Handle selecting the YAML file if no arg provided
+    // and load YAML data into struct configuration class
+    appConfig := parseArgs()
+    runStart := time.Now()
+
+    var err error
+    // Generate the unique ID of the run used to scope AWS resource names
+    RunId, err = data.RandHex(4)
+    if err != nil {
+        log.Fatalf("Error generating run ID:  %v", err)
+    }
+
+    RunName = appConfig.LocalConfig.RunName
+    // Tag every AWS resource of the run with its name and custom tags
+    awsutils.SetRunTags(RunName, appConfig.LocalConfig.RunTags)
+
+    // If the run is named, prefix its CloudWatch streams with the name
+    if RunName != "" {
+        kloudlogs.StreamPrefix = RunName + "-"
+    }
+
+    // Make the server directories
+    makeServerDirs()
+
+    // Create the dir of the run the files received from each client are stored under
+    ClientDirs, err = clientdir.New(filepath.Join(ReceivedDir, "clients"), RunId)
+    if err != nil {
+        log.Fatalf("Error creating client dirs:  %v", err)
+    }
+
+    // Bound the waits of client messaging so a hung client does not block its Goroutine
+    netio.ReadTimeout = appConfig.LocalConfig.IdleTimeoutDuration
+    netio.WriteTimeout = appConfig.LocalConfig.IdleTimeoutDuration
+
+    // If the brain runs on the server host without a password, generate one for the clients
+    if appConfig.LocalConfig.BrainServer && appConfig.LocalConfig.BrainPassword == "" {
+        brainPassword, err := peer.GenerateSecret()
+        if err != nil {
+            log.Fatalf("Error generating brain password:  %v", err)
+        }
+
+        appConfig.LocalConfig.BrainPassword = brainPassword
+    }
+
+    // If the merge plan is requested, print how the load dir would be merged and exit
+    if PlanMerge {
+        runMergePlan(appConfig)
+        return
+    }
+
+    // If dry-run is enabled, print the planned AWS actions and exit
+    if awsutils.DryRun != nil {
+        runDryRun(appConfig)
+        return
+    }
+
+    // If an audit log is set, record the privileged actions of the run in it
+    if appConfig.LocalConfig.AuditLog != "" {
+        Audit, err = audit.Open(appConfig.LocalConfig.AuditLog,
+                                appConfig.LocalConfig.AuditCloudwatch)
+        if err != nil {
+            log.Fatalf("Error opening audit log:  %v", err)
+        }
+
+        awsutils.Audit = Audit
+        // Registered first so the audit log closes after every other deferred action
+        defer func() {
+            Audit.Record(audit.CategoryRun, "run_finished", map[string]any{
+                "cracked_hashes": CrackedHashes.Load(),
+                "run_id":         RunId,
+            })
+
+            err := Audit.Close()
+            if err != nil {
+                log.Printf("Error closing audit log:  %v", err)
+            }
+        } ()
+
+        Audit.Record(audit.CategoryRun, "run_started", map[string]any{
+            "local_testing":    appConfig.LocalConfig.LocalTesting,
+            "number_instances": appConfig.LocalConfig.NumberInstances,
+            "run_id":           RunId,
+            "run_name":         RunName,
+        })
+    }
+
+    Events.Emit(eventstream.RunStarted, map[string]any{
+        "load_dir":         appConfig.LocalConfig.LoadDir,
+        "local_testing":    appConfig.LocalConfig.LocalTesting,
+        "number_instances": appConfig.LocalConfig.NumberInstances,
+    })
+    // Display the kloud kraken banner
+    printBanner()
+
+    printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "!"), "",
+                                   color.NeonAzure, "Wordlist merging started, time varies " +
+                                   "greatly depending on how much data"))
+
+    // Merge one wordlist at a time and let transfers preempt it, throttling merging
+    // while transfers run without starving it for over a minute
+    DiskIo = disk.NewIoScheduler(map[int]int{disk.PriorityMerge: 1}, time.Minute)
+
+    // Decompress any compressed wordlists so they can be preprocessed and merged
+    decompressed, err := wordlist.DecompressDir(appConfig.LocalConfig.LoadDir)
+    if err != nil {
+        log.Fatalf("Error decompressing wordlists:  %v", err)
+    }
+
+    // If any wordlists were compressed, display how many were decompressed
+    if decompressed > 0 {
+        printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                           color.LightCyan, "!"), "",
+                                       color.NeonAzure, "Decompressed ",
+                                       color.KrakenGlowGreen, strconv.Itoa(decompressed),
+                                       color.NeonAzure, " compressed wordlists"))
+    }
+
+    // If normalization is enabled, rewrite the wordlists as LF terminated UTF-8 before
+    // they are filtered so mixed sources produce matching candidates
+    if appConfig.LocalConfig.NormalizeWordlists {
+        normalizer := wordlist.NewNormalizer(appConfig.LocalConfig.NormalizeMaxLength)
+
+        normalizeStats, err := normalizer.NormalizeDir(appConfig.LocalConfig.LoadDir)
+        if err != nil {
+            log.Fatalf("Error normalizing wordlists:  %v", err)
+        }
+
+        printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                           color.LightCyan, "!"), "",
+                                       color.NeonAzure, "Normalization kept ",
+                                       color.KrakenGlowGreen,
+                                       strconv.FormatInt(normalizeStats.Kept, 10),
+                                       color.NeonAzure, " lines, dropped ",
+                                       color.KrakenGlowGreen,
+                                       strconv.FormatInt(normalizeStats.Binary, 10),
+                                       color.NeonAzure, " binary and ",
+                                       color.KrakenGlowGreen,
+                                       strconv.FormatInt(normalizeStats.TooLong, 10),
+                                       color.NeonAzure, " overlong, and transcoded ",
+                                       color.KrakenGlowGreen,
+                                       strconv.Itoa(normalizeStats.Transcoded),
+                                       color.NeonAzure, " UTF-16 wordlists"))
+    }
+
+    // If a password policy is set, drop the candidates it does not allow so they are
+    // never merged, transferred, or run through hashcat
+    if appConfig.LocalConfig.PasswordPolicyFilter != nil {
+        policyStats, err := appConfig.LocalConfig.PasswordPolicyFilter.FilterDir(
+            appConfig.LocalConfig.LoadDir)
+        if err != nil {
+            log.Fatalf("Error filtering wordlists by password policy:  %v", err)
+        }
+
+        printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                           color.LightCyan, "!"), "",
+                                       color.NeonAzure, "Password policy kept ",
+                                       color.KrakenGlowGreen,
+                                       strconv.FormatInt(policyStats.Kept, 10),
+                                       color.NeonAzure, " and dropped ",
+                                       color.KrakenGlowGreen,
+                                       strconv.FormatInt(policyStats.Dropped, 10),
+                                       color.NeonAzure, " candidates"))
+    }
+
+    // If preprocessing stages are set, run them over the wordlists before merging
+    if len(appConfig.LocalConfig.PreprocessStages) > 0 {
+        stats, err := wordlist.Preprocess(appConfig.LocalConfig.LoadDir,
+                                          appConfig.LocalConfig.PreprocessStages)
+        if err != nil {
+            log.Fatalf("Error preprocessing wordlists:  %v", err)
+        }
+
+        // If the stats stage ran, save the candidate stats and display the summary
+        if slices.Contains(appConfig.LocalConfig.PreprocessStages, wordlist.StageStats) {
+            err = stats.WriteJson(filepath.Join(ReceivedDir, "candidate_stats.json"))
+            if err != nil {
+                log.Fatalf("Error writing candidate stats:  %v", err)
+            }
+
+            printMessage(stats.Format())
+        }
+    }
+
+    var mergeErrs <-chan error
+    // If wordlists are assigned by range, index the load dir instead of merging it
+    if appConfig.LocalConfig.RangeAssignment {
+        RangeIndex, err = disk.IndexRangeDir(appConfig.LocalConfig.LoadDir,
+                                             appConfig.ClientConfig.MaxFileSizeInt64)
+        if err != nil {
+            log.Fatalf("Error indexing wordlist ranges:  %v", err)
+        }
+
+        // Keep the index set even when the load dir is empty so no file is sent whole
+        if RangeIndex == nil {
+            RangeIndex = []disk.Candidate{}
+        }
+
+        printMessage(display.CtextMulti(color.FoamWhite, "\\-->",
+                                       display.CtextPrefix(color.KrakenPurple,
+                                                           color.LightCyan, "$"), "",
+                                       color.NeonAzure, "Wordlists indexed into ",
+                                       color.KrakenGlowGreen, strconv.Itoa(len(RangeIndex)),
+                                       color.NeonAzure, " line ranges"))
+    // If merging is pipelined, serve the merged wordlists while the rest are merged
+    } else if appConfig.LocalConfig.PipelinedMerge {
+        var stopMerge func()
+
+        mergeErrs, stopMerge = pipelineMerge(appConfig)
+        // Stop the merge before exiting so no partial merge output is left in the load dir
+        defer stopMerge()
+        onExit(stopMerge)
+    } else {
+        mergeLoadDir(appConfig)
+    }
+
+    var rulesetNames []string
+    // Iterate through the rulesets collecting the names clients store them by
+    for _, rulesetPath := range appConfig.LocalConfig.RulesetInputs {
+        rulesetNames = append(rulesetNames, filepath.Base(rulesetPath))
     }
 
-    // Setup EC2 creation instance with populated args
-    ec2Man = awsutils.NewEc2Manager("ami-0eb94e3d16a6eea5f", awsConfig,
-                                    appConfig.LocalConfig.NumberInstances,
-                                    appConfig.LocalConfig.InstanceType,
-                                    "Kloud-Kraken", "ClientRole",
-                                    appConfig.LocalConfig.SecurityGroupIds,
-                                    appConfig.LocalConfig.SecurityGroups,
-                                    appConfig.LocalConfig.SubnetId,
-                                    []byte(userData))
-    // Create number of EC2 instances based on passed in data
-    err = ec2Man.CreateEc2Instances(20 * time.Minute)
+    // Set up the order the merged wordlists are distributed in
+    Schedule, err = schedule.New(appConfig.LocalConfig.ScheduleStrategy,
+                                 appConfig.LocalConfig.PriorityFile, rulesetNames,
+                                 appConfig.LocalConfig.RulesetPairings)
     if err != nil {
-        return awsConfig, ec2Man, err
+        fatalExit(nil, "Error setting up wordlist schedule:  %v", err)
     }
 
-    fmt.Println(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
-                                                       color.LightCyan, "$"), "",
-                                   color.NeonAzure, "EC2 instance creation completed"))
+    // If idle clients split the queued wordlists of clients far from finishing
+    if appConfig.LocalConfig.WorkStealing {
+        Rebalance = rebalance.New(appConfig.LocalConfig.WorkStealMinSizeInt64)
+    }
 
-    return awsConfig, ec2Man, nil
-}
+    // The hash files are sent as is unless invalid or prior cracked hashes are removed
+    HashTargets = slices.Clone(appConfig.LocalConfig.HashInputs)
 
+    // If the hashes should be verified against their hash types before launch
+    if appConfig.LocalConfig.VerifyHashes != "" {
+        invalid, err := verifyHashFiles(appConfig)
+        if err != nil {
+            fatalExit(nil, "Error verifying hash files:  %v", err)
+        }
 
-// Displays the Kloud Kraken ascii banner.
-//
-func printBanner() {
-    // Print program banner
-    fmt.Println(color.MistyAqua + `
-          ,.                                     ..
-           xO:     c. .                  .    'o0,
-           .XM'  l0. .d     co    c.  d  kKdOWMk
-           .MM.;NN' xM;   lKKxXx.lMl  MX,oMx,oM0'
-           .MM0Ml   OM.   dM. dM'lMc  MN oMl  xMk
-           .MMXMx   OM.  'dM. dM'lMc  MN oMl  dMo
-           .MM cMO. OM..0'kM' xM,lMd .MW oMl .0M0.
-           xMM. :MX,xXNOx:'dN0Kc..cKKNd'.KMXOWK:.
-      .;, .'Wl '..cko.,   . .d    . :..;o:..,:. .  ,c'.
-        dWd.'lXd:0coO:. .dWl. 'O; lkd:llo0c.d0'  KWd
-        .Md dN: oMl'KWd .M0M. ;Mc,Nk. KW';o dM0  OM.
-        ,MKNO.  lMx0Xc  xX.Md ;MKMo   KMco  dMN0 0M
-        .MNdWO  lMkxWo .MKkNW.;MOdW;  KM;' ,dM'KKXM
-        .Mx :Wx oM: oM.OM  'MO:Ml oM: XMldN,xM..0MM.
-        lM0  lX0;0.  Oldx   xk.X. .c0d''ok,'Nd. :MMo
-        OWo.   ,o,    :.     . .     .,   .o.   .cXX.
-       dd.       .                        .        :O.
-      ;.                                             :.
-    ` + color.AnsiReset)
-}
+        // If every hash of the run was invalid, there is nothing to distribute
+        if invalid > 0 && len(HashTargets) == 0 {
+            printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                               color.LightCyan, "$"), "",
+                                           color.NeonAzure, "Every hash was rejected by " +
+                                           "verify_hashes .. exiting"))
+            return
+        }
+    }
 
+    // If prior results are set, remove their cracked hashes before distribution
+    if len(appConfig.LocalConfig.PriorResults) > 0 {
+        priorCracked, remaining, err := loadPriorResults(appConfig)
+        if err != nil {
+            fatalExit(nil, "Error loading prior results:  %v", err)
+        }
 
-// Create the required dirs for program operation.
-//
-func makeServerDirs() {
-    // Set the program directories
-    programDirs := []string{ReceivedDir}
-    // Create needed directories
-    disk.MakeDirs(programDirs)
-}
+        printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                           color.LightCyan, "$"), "",
+                                       color.NeonAzure, "Prior results loaded, ",
+                                       color.KrakenGlowGreen, strconv.Itoa(priorCracked),
+                                       color.NeonAzure, " cracked and ",
+                                       color.KrakenGlowGreen, strconv.Itoa(remaining),
+                                       color.NeonAzure, " hashes left to crack"))
+
+        // If the prior results cracked every hash, there is nothing to distribute
+        if remaining == 0 {
+            printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                               color.LightCyan, "$"), "",
+                                           color.NeonAzure, "Every hash is already " +
+                                           "cracked in prior_results .. exiting"))
+            return
+        }
+    }
 
+    // If the hash file should be split into a distinct shard per instance
+    if appConfig.LocalConfig.SplitHashFile && appConfig.LocalConfig.NumberInstances > 1 {
+        HashShards, err = disk.SplitFileLines(HashTargets[0].Path,
+                                              ShardDir, appConfig.LocalConfig.NumberInstances)
+        if err != nil {
+            fatalExit(nil, "Error splitting hash file into shards:  %v", err)
+        }
 
-// Parses command line args (path to yaml config file), if args not present
-// or invalid then proceeds to user input until valid yaml file is specified.
-//
-// @Returns
-// - Pointer to AppConfig struct populated from yaml data
-//
-func parseArgs() *conf.AppConfig {
-    var configFilePath string
+        // Track the shards each wordlist ran against, so every wordlist runs against all
+        disk.Shards = disk.NewShardCoverage(len(HashShards))
 
-    // If the config file path was not passed in
-    if len(os.Args) < 2 {
-        // Prompt the user until proper path is passed in
-        validate.ValidateConfigPath(&configFilePath)
-    // If the config file path arg was passed in
-    } else {
-        // Set the provided arg as the config file path
-        configFilePath = os.Args[1]
+        printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                           color.LightCyan, "$"), "",
+                                       color.NeonAzure, "Hash file split into ",
+                                       color.KrakenGlowGreen, strconv.Itoa(len(HashShards)),
+                                       color.NeonAzure, " shards"))
+    }
 
-        // Check to see if the input path exists and is a file or dir
-        exists, isDir, hasData, err := disk.PathExists(configFilePath)
+    // If the mask attack keyspace should be split into ranges across clients
+    if appConfig.ClientConfig.KeyspaceChunks > 0 {
+        charsets := []string{appConfig.ClientConfig.CharSet1, appConfig.ClientConfig.CharSet2,
+                             appConfig.ClientConfig.CharSet3, appConfig.ClientConfig.CharSet4}
+
+        // Compute the mask keyspace with the local hashcat install
+        totalKeyspace, err := hashcat.GetKeyspace(charsets, appConfig.ClientConfig.HashMask)
         if err != nil {
-            log.Fatal("Error checking config file path existence: ", err)
+            fatalExit(nil, "Error computing mask keyspace:  %v", err)
         }
 
-        // If the path does not exist OR is a dir OR does not have data OR is not YAML file
-        if !exists || isDir || !hasData || !strings.HasSuffix(configFilePath, ".yml") {
-            fmt.Println("Provided YAML config file path invalid: ", configFilePath)
-            // Sleep for a few seconds and clear screen
-            display.ClearScreen(3)
-            // Prompt the user until proper path is passed in
-            validate.ValidateConfigPath(&configFilePath)
-        }
+        Audit.Record(audit.CategoryHashcat, "hashcat_keyspace", map[string]any{
+            "keyspace": totalKeyspace,
+            "mask":     appConfig.ClientConfig.HashMask,
+        })
+
+        Keyspace = keyspace.NewScheduler(totalKeyspace,
+                                         int64(appConfig.ClientConfig.KeyspaceChunks))
+        _, totalRanges := Keyspace.Progress()
+
+        printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                            color.LightCyan, "$"), "",
+                                        color.NeonAzure, "Mask keyspace of ",
+                                        color.KrakenGlowGreen, strconv.FormatInt(totalKeyspace, 10),
+                                        color.NeonAzure, " split into ",
+                                        color.KrakenGlowGreen, strconv.Itoa(totalRanges),
+                                        color.NeonAzure, " ranges"))
     }
 
-    // Load the configuration from the YAML file
-    return conf.LoadConfig(configFilePath)
-}
+    // If shared files should be distributed between clients
+    if appConfig.LocalConfig.PeerSharing {
+        Peers = peer.NewRegistry()
+    }
 
+    // If the brain runs on the server host, start it before any clients launch
+    if appConfig.LocalConfig.BrainServer {
+        brainLog, err := os.OpenFile(filepath.Join(ReceivedDir, "brain_server.log"),
+                                     os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+        if err != nil {
+            fatalExit(nil, "Error opening brain server log:  %v", err)
+        }
+        defer brainLog.Close()
 
-// Parse command line args, make needed directories, merge wordlists and remove remaining
-// empty dirs. Set up AWS access config with key and secret, set up logging manager
-// instance, set up EC2 code passing command line args via user data, and start server.
-//
-func main() {
-    // Handle selecting the YAML file if no arg provided
-    // and load YAML data into struct configuration class
-    appConfig := parseArgs()
-    // Make the server directories
-    makeServerDirs()
-    // Display the kloud kraken banner
-    printBanner()
+        brainIp, err := brainListenIp(appConfig)
+        if err != nil {
+            fatalExit(nil, "Error getting hashcat brain server address:  %v", err)
+        }
 
-    fmt.Println(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
-                                                       color.LightCyan, "!"), "",
-                                   color.NeonAzure, "Wordlist merging started, time varies " +
-                                   "greatly depending on how much data"))
+        Brain, err = hashcat.StartBrainServer(brainIp, appConfig.LocalConfig.BrainPort,
+                                              appConfig.LocalConfig.BrainPassword, brainLog)
+        if err != nil {
+            fatalExit(nil, "Error starting hashcat brain server:  %v", err)
+        }
 
-    // Merge the wordlists in the load dir based on max file size
-    err := wordlist.MergeWordlistDir(appConfig.LocalConfig.LoadDir,
-                                     appConfig.LocalConfig.MaxMergingSizeInt64,
-                                     appConfig.ClientConfig.MaxFileSizeInt64,
-                                     appConfig.LocalConfig.MaxSizeRange,
-                                     int64(1 * globals.GB))
-    if err != nil {
-        log.Fatalf("Error merging wordlists:  %v", err)
-    }
+        Audit.Record(audit.CategoryHashcat, "hashcat_brain_server", map[string]any{
+            "address": brainIp,
+            "port":    appConfig.LocalConfig.BrainPort,
+        })
 
-    // Delete any leftover folders in load dir
-    err = wordlist.RemoveMergeSubdirs(appConfig.LocalConfig.LoadDir)
-    if err != nil {
-        log.Fatalf("Error deleting load dir subdirs:  %v", err)
-    }
+        // Stop the brain server once every client is finished
+        defer func() {
+            err := Brain.Stop(30 * time.Second)
+            if err != nil {
+                log.Printf("Error stopping hashcat brain server:  %v", err)
+            }
+        } ()
 
-    fmt.Println(display.CtextMulti(color.FoamWhite, "\\-->",
-                                   display.CtextPrefix(color.KrakenPurple,
-                                                       color.LightCyan, "$"), "",
-                                   color.NeonAzure, "Wordlist merging process completed"))
+        printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                           color.LightCyan, "$"), "",
+                                       color.NeonAzure, "Hashcat brain server listening on ",
+                                       color.KrakenGlowGreen,
+                                       net.JoinHostPort(brainIp,
+                                           strconv.Itoa(appConfig.LocalConfig.BrainPort))))
+    }
 
     var awsConfig aws.Config
     var ec2Man *awsutils.Ec2Manger
+    var hourlyRate float64
     var logMan *kloudlogs.LoggerManager
+    var watchdog *cost.Watchdog
+
+    // Generated server certificates are valid for the configured lifetime
+    TlsMan.Lifetime = appConfig.LocalConfig.CertLifetimeDuration
+
+    // If clients must authenticate, generate the token of the run they present in their hello
+    if appConfig.LocalConfig.ConnectionToken {
+        ConnectionToken, err = protocol.NewToken()
+        if err != nil {
+            fatalExit(nil, "Error generating connection token:  %v", err)
+        }
+    }
 
     // If the program is being run in full mode (not testing)
     if !appConfig.LocalConfig.LocalTesting {
-        // Query IP lookup APIs for public IP addresses
-        publicIps, err := tlsutils.GetPublicIps()
+        // Query IP lookup APIs for public IP addresses, or use the relay address
+        publicIps, err := serverHosts(appConfig)
         if err != nil {
-            log.Fatalf("Error getting public IP addresses:  %v", err)
+            fatalExit(nil, "Error getting public IP addresses:  %v", err)
         }
 
-        fmt.Println(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+        printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
                                                            color.LightCyan, "$"), "",
                                        color.NeonAzure, "Server public IP addresses retrieved"))
 
         // Generate the servers TLS PEM certificate and key and save in TLS manager
         err = TlsMan.PemCertAndKeyGenHandler("Kloud Kraken", false, publicIps...)
         if err != nil {
-            log.Fatalf("Error creating TLS PEM certificate & key:  %v", err)
+            fatalExit(nil, "Error creating TLS PEM certificate & key:  %v", err)
         }
 
-        fmt.Println(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+        printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
                                                            color.LightCyan, "$"), "",
                                        color.NeonAzure, "Server TLS PEM certificate " +
                                        "and key generated"))
 
+        // Estimate the spend and confirm the launch if it exceeds the budget limit
+        hourlyRate, err = confirmCostEstimate(appConfig)
+        if err != nil {
+            fatalExit(nil, "Error with cost estimate:  %v", err)
+        }
+
         // Call handler function that sets up AWS IAM user permissions,
         // transfers client binary via S3, set TLS certificate via SSM
         // parameter store, and launches EC2 instances
         awsConfig, ec2Man, err = awsSetup(appConfig, publicIps)
         if err != nil {
-            log.Fatalf("Error with AWS setup:  %v", err)
+            // Delete any IAM resources created before the failure
+            teardownErr := IamResources.Teardown(time.Minute)
+            if teardownErr != nil {
+                log.Printf("Error deleting IAM resources:  %v", teardownErr)
+            }
+
+            fatalExit(nil, "Error with AWS setup:  %v", err)
         }
 
-        defer func() {
+        // If the audit log is forwarded, deliver its entries to CloudWatch
+        if appConfig.LocalConfig.AuditCloudwatch {
+            auditLogger, err := kloudlogs.NewCloudWatchLogger(awsConfig,
+                                                              awsutils.LogGroup(RunId) +
+                                                              "/audit")
+            if err != nil {
+                log.Printf("Error setting up audit log delivery to CloudWatch:  %v", err)
+            } else {
+                // Registered before the teardown so its audit entries are delivered
+                defer auditLogger.Close()
+                Audit.SetSink(auditSink(auditLogger))
+            }
+        }
+
+        // Run the exit cleanups on return, fatal errors run them through fatalExit
+        defer runExitCleanups()
+
+        // Registered before termination so the IAM resources are deleted after it
+        onExit(func() {
+            err := IamResources.Teardown(time.Minute)
+            if err != nil {
+                log.Printf("Error deleting IAM resources:  %v", err)
+            }
+        })
+
+        // If a max cost or max runtime is set, track the fleet from launch
+        if appConfig.LocalConfig.MaxCost > 0 || appConfig.LocalConfig.MaxRuntimeDuration > 0 {
+            watchdog = cost.NewWatchdog(hourlyRate, appConfig.LocalConfig.NumberInstances,
+                                        appConfig.LocalConfig.MaxCost,
+                                        appConfig.LocalConfig.BudgetWarning,
+                                        appConfig.LocalConfig.MaxRuntimeDuration, time.Now())
+        }
+
+        // Map each client to its instance for the client dir index
+        LookupInstance = func(host string) (string, error) {
+            return ec2Man.InstanceIdByIp(host, 1 * time.Minute)
+        }
+
+        // If idle clients are downscaled, end them and terminate the instance of each
+        if appConfig.LocalConfig.DownscaleIdle {
+            Downscale = downscale.New(ec2Man, watchdog)
+        }
+
+        onExit(func() {
             // Terminate the EC2 instances when processing is complete
             termOutput, err := ec2Man.TerminateEc2Instances(time.Minute * 10)
             if err != nil {
@@ -1001,17 +7441,18 @@ func main() {
                                 string(instance.CurrentState.Name))
                 }
             }
-        } ()
+        })
 
     // If the program is being run in testing mode
     } else {
-        // Generate the servers TLS PEM certificate & key and save in TLS manager
-        err = TlsMan.PemCertAndKeyGenHandler("Kloud Kraken", true)
+        // Generate the servers TLS PEM certificate & key and save in TLS manager, the
+        // loopback address is included for clients on the same host such as local clients
+        err = TlsMan.PemCertAndKeyGenHandler("Kloud Kraken", true, "127.0.0.1")
         if err != nil {
-            log.Fatalf("Error creating TLS PEM certificate and key:  %v", err)
+            fatalExit(nil, "Error creating TLS PEM certificate and key:  %v", err)
         }
 
-        fmt.Println(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+        printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
                                                            color.LightCyan, "TESTING"), "",
                                        color.NeonAzure, "PEM cert generated, transfer " +
                                        " to client before execution"))
@@ -1021,19 +7462,171 @@ func main() {
     err = TlsMan.CertGenAndPool(TlsMan.CertPemBlock, TlsMan.KeyPemBlock,
                                 TlsMan.CaCertPemBlocks)
     if err != nil {
-        log.Fatalf("Error generating TLS certificate:  %v", err)
+        fatalExit(nil, "Error generating TLS certificate:  %v", err)
     }
 
-    fmt.Println(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+    printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
                                                        color.LightCyan, "$"), "",
                                    color.NeonAzure, "X509 cerificate pool generated " +
                                    "and server certifcate added to pool"))
 
     // Initialize the LoggerManager based on the flags
     logMan, err = kloudlogs.NewLoggerManager("local", appConfig.LocalConfig.LogPath,
-                                             awsConfig, "Kloud-Kraken", false)
+                                             awsConfig, awsutils.LogGroup(RunId), false)
+    if err != nil {
+        fatalExit(nil, "Error initializing logger manager:  %v", err)
+    }
+
+    // If the run is named, label every log line with the name
+    if RunName != "" {
+        logMan.Fields = []zap.Field{zap.String("run_name", RunName)}
+    }
+
+    // If merging is pipelined, log its errors now that the logger is set up
+    if mergeErrs != nil {
+        go func() {
+            for err := range mergeErrs {
+                logMan.LogMessage("error", "Error in pipelined merge:  %v", err)
+            }
+        } ()
+    }
+
+    snsTopics := notify.SnsTopics(appConfig.LocalConfig.Notifications)
+    // If results go to a bucket or notifications to SNS in testing mode, load the local
+    // AWS credentials for them
+    if (appConfig.LocalConfig.ResultsBucket != "" || len(snsTopics) > 0) &&
+    appConfig.LocalConfig.LocalTesting {
+        awsConfig, _, _, err = awsutils.AwsConfigSetup(appConfig.LocalConfig.Region,
+                                                       1 * time.Minute)
+        if err != nil {
+            fatalExit(logMan, "Error setting up AWS config for results:  %v", err)
+        }
+    }
+
+    // If notification sinks are configured, notify operators of the run events
+    if len(appConfig.LocalConfig.Notifications) > 0 {
+        Notifier, err = notify.NewNotifier(appConfig.LocalConfig.Notifications, awsConfig,
+                                           RunName, func(sink string, event string,
+                                                         err error) {
+            logMan.LogMessage("error", "Error sending notification:  %v", err,
+                              zap.String("sink", sink), zap.String("event", event))
+        })
+        if err != nil {
+            fatalExit(logMan, "Error setting up notifications:  %v", err)
+        }
+    }
+
+    // Set up where the cracked hashes, client logs, and reports are persisted
+    err = setupResults(appConfig, awsConfig, runStart)
+    if err != nil {
+        fatalExit(logMan, "Error setting up results storage:  %v", err)
+    }
+
+    // If the brain server is running, log if it exits before the clients finish
+    if Brain != nil {
+        go func() {
+            <-Brain.Exited()
+            // If the brain server was stopped during shutdown
+            if Brain.Stopped() {
+                return
+            }
+
+            logMan.LogMessage("error", "Hashcat brain server exited, clients will fail " +
+                              "to reach the brain, see brain_server.log")
+        } ()
+    }
+
+    // If SSM sessions are enabled, check the agents on the launched instances register
+    if appConfig.LocalConfig.SsmSessions && !appConfig.LocalConfig.LocalTesting {
+        go checkSsmAgents(awsConfig, ec2Man.InstanceIds(), 10 * time.Minute, logMan)
+    }
+
+    // If the metrics port is set, serve the run metrics for Prometheus
+    if appConfig.LocalConfig.MetricsPort != 0 {
+        err = startMetrics(appConfig)
+        if err != nil {
+            logMan.LogMessage("error", "Error starting metrics endpoint:  %v", err)
+        } else {
+            logMan.LogMessage("info", "Metrics served on port %d",
+                              appConfig.LocalConfig.MetricsPort)
+
+            // Stop the metrics endpoint on local exit
+            defer func() {
+                err := Metrics.Stop(5 * time.Second)
+                if err != nil {
+                    logMan.LogMessage("error", "Error stopping metrics endpoint:  %v", err)
+                }
+            } ()
+
+            // If instances were launched, poll their states for the metrics
+            if ec2Man != nil {
+                pollCtx, cancel := context.WithCancel(context.Background())
+                defer cancel()
+
+                go pollEc2States(pollCtx, ec2Man, 1 * time.Minute, logMan)
+            }
+        }
+    }
+
+    // If the admin socket is set, serve the admin methods for external tooling
+    if appConfig.LocalConfig.AdminSocket != "" {
+        err = startAdmin(appConfig, ec2Man, watchdog, runStart, logMan)
+        if err != nil {
+            logMan.LogMessage("error", "Error starting admin socket:  %v", err)
+        } else {
+            logMan.LogMessage("info", "Admin methods served on %s",
+                              appConfig.LocalConfig.AdminSocket)
+
+            // Stop the admin socket on local exit
+            defer Admin.Stop(5 * time.Second)
+        }
+    }
+
+    // If the fleet is tracked, terminate it when a budget threshold is exceeded
+    if watchdog != nil {
+        watchdogCtx, cancel := context.WithCancel(context.Background())
+        defer cancel()
+
+        go runCostWatchdog(watchdogCtx, watchdog, ec2Man, logMan)
+    }
+
+    // If the server certificate is rotated, reissue it on the configured interval
+    if appConfig.LocalConfig.CertRotationDuration > 0 {
+        rotationCtx, cancel := context.WithCancel(context.Background())
+        defer cancel()
+
+        go runCertRotation(rotationCtx, appConfig.LocalConfig.CertRotationDuration, awsConfig,
+                           logMan)
+    }
+
+    // If clients auto update, watch the local client binary for new versions
+    if ClientUpdate != nil {
+        watchCtx, cancel := context.WithCancel(context.Background())
+        defer cancel()
+
+        s3Man := awsutils.NewS3ManagerFromConfig(awsConfig)
+        // Upload new versions of the client binary to the S3 bucket
+        upload := func(binData []byte) (string, error) {
+            return s3Man.PutS3Object(appConfig.LocalConfig.BucketName,
+                                     awsutils.ClientBinaryKey(RunId), binData,
+                                     1 * time.Minute)
+        }
+
+        go ClientUpdate.Watch(watchCtx, clientBinaryPath(appConfig.LocalConfig.ClientOs,
+                                                         appConfig.LocalConfig.InstanceType),
+                              30 * time.Second, upload, logMan)
+    }
+
+    potfileName := "potfile.txt"
+    // If the run has an ID, scope the potfile to it so reruns resume their own
+    if RunId != "" {
+        potfileName = RunId + ".potfile"
+    }
+
+    // Open the potfile the cracked hashes of every client are deduplicated into
+    Potfile, err = potfile.Open(filepath.Join(ReceivedDir, potfileName))
     if err != nil {
-        log.Fatalf("Error initializing logger manager:  %v", err)
+        logMan.LogMessage("error", "Error opening potfile:  %v", err)
     }
 
     // Sleep briefly to so output can be read before tui starts
@@ -1042,13 +7635,219 @@ func main() {
     // Listen for incoming client connections and handle them
     startServer(appConfig, logMan)
 
+    // If the potfile was opened, persist the unique cracked hashes of the run
+    if Potfile != nil {
+        logMan.LogMessage("info", "Potfile written", zap.Int("cracked hashes", Potfile.Count()),
+                          zap.String("path", Potfile.Path()))
+
+        err = Potfile.Close()
+        if err != nil {
+            logMan.LogMessage("error", "Error closing potfile:  %v", err)
+        } else {
+            persistResult(Potfile.Path(), logMan)
+        }
+    }
+
+    // If clients were spawned in local mode, ensure they exit with the server
+    if len(LocalClients) > 0 {
+        stopLocalClients(1 * time.Minute, logMan)
+    }
+
+    // If the hash file was split, merge the cracked hashes from each shard
+    if len(HashShards) > 0 {
+        mergedPath := filepath.Join(ReceivedDir, "merged_loot.txt")
+
+        mergedCount, err := mergeLootFiles(mergedPath)
+        if err != nil {
+            logMan.LogMessage("error", "Error merging cracked hashes:  %v", err)
+        } else {
+            logMan.LogMessage("info", "Cracked hashes merged", zap.Int("loot files", mergedCount),
+                              zap.String("path", mergedPath))
+            // Persist the merged cracked hashes to the results store
+            persistResult(mergedPath, logMan)
+        }
+    }
+
+    // Build the JSON and CSV reports of the cracked hashes joined against the hash file
+    var hashFilePaths []string
+    // Iterate through the hash files of the run collecting their paths
+    for _, hashInput := range appConfig.LocalConfig.HashInputs {
+        hashFilePaths = append(hashFilePaths, hashInput.Path)
+    }
+
+    crackReport, err := writeReports(hashFilePaths, logMan)
+    if err != nil {
+        logMan.LogMessage("error", "Error writing cracked hashes reports:  %v", err)
+    }
+
+    // Iterate through the requeued wordlists, any not selected again were never processed
+    for _, filePath := range Exceptions.Requeued() {
+        if _, selected := disk.Claims.Owner(filePath); !selected {
+            Exceptions.Record(exceptions.DeadLettered, "", filepath.Base(filePath) +
+                              " requeued but no client remained to process it")
+        }
+    }
+
+    // Iterate through the wordlists that did not run against every hash file shard
+    for _, filePath := range disk.Shards.Partial() {
+        Exceptions.Record(exceptions.DeadLettered, "", filepath.Base(filePath) +
+                          " did not run against every hash file shard")
+    }
+
+    // If the keyspace was split, check for ranges that were never completed
+    if Keyspace != nil {
+        completed, total := Keyspace.Progress()
+        if completed < total {
+            Exceptions.Record(exceptions.DeadLettered, "", fmt.Sprintf("%d of %d keyspace " +
+                              "ranges never completed", total - completed, total))
+        }
+    }
+
+    // If the client dirs are archived, zip the tree of the run once every client is handled
+    if appConfig.LocalConfig.ArchiveClientDirs {
+        archivePath := filepath.Join(ReceivedDir, reportName("clients-" + RunId + ".zip"))
+
+        archivedCount, err := ClientDirs.Archive(archivePath)
+        if err != nil {
+            logMan.LogMessage("error", "Error archiving client dirs:  %v", err)
+        } else {
+            logMan.LogMessage("info", "Client dirs archived", zap.Int("files", archivedCount),
+                              zap.String("path", archivePath))
+            // Persist the archive to the results store
+            persistResult(archivePath, logMan)
+        }
+    }
+
+    reportPath := filepath.Join(ReceivedDir, reportName("exceptions_report.txt"))
+    // Write the final exceptions report
+    err = Exceptions.WriteReport(reportPath)
+    if err != nil {
+        logMan.LogMessage("error", "Error writing exceptions report:  %v", err)
+    } else {
+        persistResult(reportPath, logMan)
+    }
+
     // Redisplay banner once processing is complete
     printBanner()
 
-    fmt.Println(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+    printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "$"), "",
+                                   color.NeonAzure, Exceptions.Summary() + ", report at ",
+                                   color.RadiantAmethyst, reportPath))
+
+    var crackSummary report.Summary
+    // If the cracked hashes reports were written, display the crack rate
+    if crackReport != nil {
+        crackSummary = crackReport.Summary
+
+        printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                           color.LightCyan, "$"), "",
+                                       color.NeonAzure, crackReport.FormatSummary() +
+                                       ", reports at ",
+                                       color.RadiantAmethyst,
+                                       filepath.Join(ReceivedDir,
+                                                     reportName("cracked_report.{json,csv}"))))
+    }
+
+    printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "$"), "",
+                                   color.NeonAzure, "Results persisted to ",
+                                   color.RadiantAmethyst, Results.Location()))
+
+    printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
                                                        color.LightCyan, "$"), "",
                                    color.NeonAzure, "All connections handled " +
                                    ".. server shutting down"))
 
+    transferStats := Transfers.Aggregate()
+    transferSummary := fmt.Sprintf("%d transfers at %.2f MB/s average, %d failed, %d retried",
+                                   transferStats.Transfers,
+                                   transferStats.Throughput() / float64(globals.MB),
+                                   transferStats.Failures, transferStats.Retries)
+
+    printMessage(display.CtextMulti(display.CtextPrefix(color.KrakenPurple,
+                                                       color.LightCyan, "$"), "",
+                                   color.NeonAzure, transferSummary))
+
+    // Iterate through the per client transfer stats and log them
+    for client, stats := range Transfers.Snapshot() {
+        logMan.LogMessage("info", "Transfer stats", zap.String("client", client),
+                          zap.Int("transfers", stats.Transfers),
+                          zap.Int64("bytes", stats.Bytes),
+                          zap.Duration("duration", stats.Duration),
+                          zap.Float64("throughput", stats.Throughput()),
+                          zap.Int("failures", stats.Failures),
+                          zap.Int("retries", stats.Retries))
+    }
+
+    runtime := time.Since(runStart)
+    runSummary := summary.New(RunId, runtime, crackReport, CrackedHashes.Load(),
+                              Transfers.Snapshot(),
+                              cost.Estimate(hourlyRate, appConfig.LocalConfig.NumberInstances,
+                                            runtime))
+    runSummary.RunName = RunName
+    // Account the spend of the instances the clients reported running on
+    runSummary.AddInstances(Instances.Records(), appConfig.LocalConfig.HourlyPrice, time.Now())
+    // Include the manifest of the distributed wordlists so the run can be reproduced
+    runSummary.Wordlists = Manifest.Entries()
+
+    // If the wordlists were manifested, write the manifest alongside the results
+    if Manifest != nil {
+        manifestPath := filepath.Join(ReceivedDir, reportName("wordlist_manifest.json"))
+
+        err = Manifest.WriteJson(manifestPath)
+        if err != nil {
+            logMan.LogMessage("error", "Error writing wordlist manifest:  %v", err)
+        } else {
+            persistResult(manifestPath, logMan)
+        }
+    }
+
+    // If export formats are set, write the run summary to the received dir
+    if len(appConfig.LocalConfig.SummaryExport) > 0 {
+        summaryPaths, err := runSummary.Export(ReceivedDir, appConfig.LocalConfig.SummaryExport)
+        if err != nil {
+            logMan.LogMessage("error", "Error exporting run summary:  %v", err)
+        }
+
+        // Persist the exported summaries to the results store
+        for _, summaryPath := range summaryPaths {
+            persistResult(summaryPath, logMan)
+        }
+    }
+
+    // If the terminal output is in use, display the final summary view
+    if Events == nil && awsutils.DryRun == nil {
+        tui.RenderSummary("Run summary", runSummary.Lines(), color.SkyBlue, color.BrightMint)
+    }
+
     logMan.LogMessage("info", "All connections handled .. server shutting down")
+
+    Events.Emit(eventstream.RunComplete, map[string]any{
+        "crack_report":   crackSummary,
+        "cracked_hashes": CrackedHashes.Load(),
+        "exceptions":     Exceptions.Counts(),
+        "received_dir":   ReceivedDir,
+        "results":        Results.Location(),
+        "summary":        runSummary,
+        "transfers":      map[string]any{
+            "bytes":      transferStats.Bytes,
+            "failures":   transferStats.Failures,
+            "retries":    transferStats.Retries,
+            "throughput": transferStats.Throughput(),
+            "transfers":  transferStats.Transfers,
+        },
+    })
+
+    Notifier.Notify(notify.RunComplete, map[string]any{
+        "cracked":        runSummary.Cracked,
+        "estimated_cost": fmt.Sprintf("$%.2f", runSummary.EstimatedCost),
+        "exceptions":     Exceptions.Counts(),
+        "results":        Results.Location(),
+        "run_id":         RunId,
+        "runtime":        runtime.Round(time.Second).String(),
+        "total_hashes":   runSummary.TotalHashes,
+    })
+    // Wait for the final notifications to be delivered before exiting
+    Notifier.Wait()
 }
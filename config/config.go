@@ -0,0 +1,11 @@
+// Package config embeds the template config and the instructions describing each of its
+// keys, used by the init subcommand to generate a commented config.
+package config
+
+import _ "embed"
+
+// Package level variables
+//go:embed config.yml
+var Template []byte  // The template config with every key
+//go:embed instructions.yml
+var Instructions []byte  // The description, default, and options of every key
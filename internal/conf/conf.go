@@ -3,140 +3,712 @@ package conf
 import (
 	"fmt"
 	"log"
+	"net"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ngimb64/Kloud-Kraken/internal/validate"
+	"github.com/ngimb64/Kloud-Kraken/pkg/awsutils"
+	"github.com/ngimb64/Kloud-Kraken/pkg/harden"
+	"github.com/ngimb64/Kloud-Kraken/pkg/hashcat"
+	"github.com/ngimb64/Kloud-Kraken/pkg/netio"
+	"github.com/ngimb64/Kloud-Kraken/pkg/notify"
+	"github.com/ngimb64/Kloud-Kraken/pkg/publicip"
+	"github.com/ngimb64/Kloud-Kraken/pkg/partition"
+	"github.com/ngimb64/Kloud-Kraken/pkg/schedule"
+	"github.com/ngimb64/Kloud-Kraken/pkg/wordlist"
 	"gopkg.in/yaml.v3"
 )
 
+// Largest wordlist that can be staged in S3 with the SQS control plane
+const MaxS3ObjectSize = 5 * 1024 * 1024 * 1024
+// Most hash files a run can crack, their hash types are sent in a single message
+const MaxHashFiles = 32
+
 // AppConfig is a wrapper that ties the local and client yaml configs
 type AppConfig struct {
     LocalConfig  LocalConfig  `yaml:"local_config"`
     ClientConfig ClientConfig `yaml:"client_config"`
 }
 
+// HashFile is a hash file, or dir of hash files, cracked as a single hash type
+type HashFile struct {
+    HashType string `yaml:"hash_type"`
+    Path     string `yaml:"path"`
+}
+
 // LocalConfig contains the yaml configuration for local server settings
 type LocalConfig struct {
-    AccountId           string   `yaml:"account_id"`
-    BucketName          string   `yaml:"bucket_name"`
-    HashFilePath        string   `yaml:"hash_file_path"`
-    IamUsername         string   `yaml:"iam_username"`
-    InstanceType        string   `yaml:"instance_type"`
-    ListenerPort        int      `yaml:"listener_port"`
-    LoadDir	   	        string   `yaml:"load_dir"`
-    LocalTesting        bool     `yaml:"local_testing"`
-    LogPath             string   `yaml:"log_path"`
-    MaxMergingSize      string   `yaml:"max_merging_size"`
-    MaxMergingSizeInt64 int64    `yaml:"-"`                 // Parsed later
-    MaxSizeRange        float64  `yaml:"max_size_range"`
-    NumberInstances     int      `yaml:"number_instances"`
-    Region              string   `yaml:"region"`
-    RulesetPath         string   `yaml:"ruleset_path"`
-    SecurityGroupIds    []string `yaml:"security_group_ids"`
-    SecurityGroups      []string `yaml:"security_groups"`
-    SubnetId            string   `yaml:"subnet_id"`
+    AccountId               string              `yaml:"account_id"`
+    AdminSocket             string              `yaml:"admin_socket"`
+    AllowedClientCidrs      []string            `yaml:"allowed_client_cidrs"`
+    AllowedClientNets       []*net.IPNet        `yaml:"-"`                // Parsed later
+    Ami                     string              `yaml:"ami"`
+    AmiSsmParameter         string              `yaml:"ami_ssm_parameter"`
+    ArchiveClientDirs       bool                `yaml:"archive_client_dirs"`
+    AuditCloudwatch         bool                `yaml:"audit_cloudwatch"`
+    AuditLog                string              `yaml:"audit_log"`
+    BrainHost               string              `yaml:"brain_host"`
+    BrainPassword           string              `yaml:"brain_password"`
+    BrainPort               int                 `yaml:"brain_port"`
+    BrainServer             bool                `yaml:"brain_server"`
+    BucketName              string              `yaml:"bucket_name"`
+    BudgetLimit             float64             `yaml:"budget_limit"`
+    BudgetWarning           float64             `yaml:"budget_warning"`
+    CertLifetime            string              `yaml:"cert_lifetime"`
+    CertLifetimeDuration    time.Duration       `yaml:"-"`                // Parsed later
+    CertRotation            string              `yaml:"cert_rotation"`
+    CertRotationDuration    time.Duration       `yaml:"-"`                // Parsed later
+    ClientAutoUpdate        bool                `yaml:"client_auto_update"`
+    ClientBinaryExpiration  int                 `yaml:"client_binary_expiration_days"`
+    ClientInstanceProfile   string              `yaml:"client_instance_profile"`
+    ClientOs                string              `yaml:"client_os"`
+    ClientRoleArn           string              `yaml:"client_role_arn"`
+    ConnectionToken         bool                `yaml:"connection_token"`
+    ControlPlane            string              `yaml:"control_plane"`
+    DisableCompression      bool                `yaml:"disable_compression"`
+    DisableTui              bool                `yaml:"disable_tui"`
+    DownscaleIdle           bool                `yaml:"downscale_idle"`
+    EbsFallback             bool                `yaml:"ebs_fallback"`
+    EbsVolumeSize           int                 `yaml:"ebs_volume_size"`
+    ExpectedRuntime         string              `yaml:"expected_runtime"`
+    ExpectedRuntimeDuration time.Duration       `yaml:"-"`                // Parsed later
+    HashcatArtifact         string              `yaml:"hashcat_artifact"`
+    HashcatArtifactSha256   string              `yaml:"hashcat_artifact_sha256"`
+    HashcatRelease          string              `yaml:"hashcat_release"`
+    HashFilePath            string              `yaml:"hash_file_path"`
+    HashFiles               []HashFile          `yaml:"hash_files"`
+    HashInputs              []HashFile          `yaml:"-"`                // Parsed later
+    HourlyPrice             float64             `yaml:"hourly_price"`
+    IamPermissionsBoundary  string              `yaml:"iam_permissions_boundary"`
+    IamUsername             string              `yaml:"iam_username"`
+    IdleTimeout             string              `yaml:"idle_timeout"`
+    IdleTimeoutDuration     time.Duration       `yaml:"-"`                // Parsed later
+    InstanceType            string              `yaml:"instance_type"`
+    IpDiscoveryEndpoints    []string            `yaml:"ip_discovery_endpoints"`
+    ListenerPort            int                 `yaml:"listener_port"`
+    LoadDir                 string              `yaml:"load_dir"`
+    LocalClients            bool                `yaml:"local_clients"`
+    LocalTesting            bool                `yaml:"local_testing"`
+    LogPath                 string              `yaml:"log_path"`
+    LogStreaming            bool                `yaml:"log_streaming"`
+    MaxCost                 float64             `yaml:"max_cost"`
+    MaxMergingSize          string              `yaml:"max_merging_size"`
+    MaxMergingSizeInt64     int64               `yaml:"-"`                // Parsed later
+    MaxRuntime              string              `yaml:"max_runtime"`
+    MaxRuntimeDuration      time.Duration       `yaml:"-"`                // Parsed later
+    MaxSizeRange            float64             `yaml:"max_size_range"`
+    MetricsPort             int                 `yaml:"metrics_port"`
+    MetricsTls              bool                `yaml:"metrics_tls"`
+    NormalizeMaxLength      int                 `yaml:"normalize_max_length"`
+    NormalizeWordlists      bool                `yaml:"normalize_wordlists"`
+    Notifications           []notify.SinkConfig `yaml:"notifications"`
+    NumberInstances         int                 `yaml:"number_instances"`
+    ParallelConnections     int                 `yaml:"parallel_connections"`
+    ParallelMinSize         string              `yaml:"parallel_min_size"`
+    ParallelMinSizeInt64    int64               `yaml:"-"`                // Parsed later
+    PasswordPolicy          string              `yaml:"password_policy"`
+    PasswordPolicyFilter    *wordlist.Policy    `yaml:"-"`                // Parsed later
+    PasswordPolicyRegex     string              `yaml:"password_policy_regex"`
+    PeerSharing             bool                `yaml:"peer_sharing"`
+    PipelinedMerge          bool                `yaml:"pipelined_merge"`
+    PlaintextTransfers      bool                `yaml:"plaintext_transfers"`
+    PreprocessStages        []string            `yaml:"preprocess_stages"`
+    PriorResults            []string            `yaml:"prior_results"`
+    PriorityFile            string              `yaml:"priority_file"`
+    PublicIpTtl             string              `yaml:"public_ip_ttl"`
+    PublicIpTtlDuration     time.Duration       `yaml:"-"`                // Parsed later
+    PublicIps               []string            `yaml:"public_ips"`
+    RangeAssignment         bool                `yaml:"range_assignment"`
+    Region                  string              `yaml:"region"`
+    RelayAddress            string              `yaml:"relay_address"`
+    RelayClientAddress      string              `yaml:"relay_client_address"`
+    RelayClientHost         string              `yaml:"-"`                // Parsed later
+    RelayClientPort         int                 `yaml:"-"`                // Parsed later
+    RelayFingerprint        string              `yaml:"relay_fingerprint"`
+    RelayToken              string              `yaml:"relay_token"`
+    ResultsBucket           string              `yaml:"results_bucket"`
+    ResultsDir              string              `yaml:"results_dir"`
+    ResultsExpirationDays   int                 `yaml:"results_expiration_days"`
+    RulesetInputs           []string            `yaml:"-"`                // Parsed later
+    RulesetPairings         []schedule.Pairing  `yaml:"ruleset_pairings"`
+    RulesetPath             string              `yaml:"ruleset_path"`
+    Rulesets                []string            `yaml:"rulesets"`
+    RunName                 string              `yaml:"run_name"`
+    RunTags                 map[string]string   `yaml:"run_tags"`
+    S3BlockPublicAccess     bool                `yaml:"s3_block_public_access"`
+    S3KmsKeyId              string              `yaml:"s3_kms_key_id"`
+    S3RestrictToRoles       bool                `yaml:"s3_restrict_to_roles"`
+    ScheduleStrategy        string              `yaml:"schedule_strategy"`
+    SecurityGroupIds        []string            `yaml:"security_group_ids"`
+    SecurityGroups          []string            `yaml:"security_groups"`
+    ServerRoleArn           string              `yaml:"server_role_arn"`
+    SplitHashFile           bool                `yaml:"split_hash_file"`
+    SsmSessions             bool                `yaml:"ssm_sessions"`
+    StunServers             []string            `yaml:"stun_servers"`
+    SubnetId                string              `yaml:"subnet_id"`
+    SummaryExport           []string            `yaml:"summary_export"`
+    UserDataPostHook        string              `yaml:"user_data_post_hook"`
+    UserDataPreHook         string              `yaml:"user_data_pre_hook"`
+    VerifyHashes            string              `yaml:"verify_hashes"`
+    WebUiAddress            string              `yaml:"web_ui_address"`
+    WebUiPort               int                 `yaml:"web_ui_port"`
+    WebUiTls                bool                `yaml:"web_ui_tls"`
+    WebUiToken              string              `yaml:"web_ui_token"`
+    WorkStealMinSize        string              `yaml:"work_steal_min_size"`
+    WorkStealMinSizeInt64   int64               `yaml:"-"`                // Parsed later
+    WorkStealing            bool                `yaml:"work_stealing"`
 }
 
 // ClientConfig contains the yaml configuration for the client settings
 type ClientConfig struct {
-    ApplyOptimization bool   `yaml:"apply_optimization"`
-    CharSet1          string `yaml:"char_set1"`
-    CharSet2          string `yaml:"char_set2"`
-    CharSet3          string `yaml:"char_set3"`
-    CharSet4          string `yaml:"char_set4"`
-    CrackingMode      string `yaml:"cracking_mode"`
-    HashMask          string `yaml:"hash_mask"`
-    HashType          string `yaml:"hash_type"`
-    LogMode           string `yaml:"log_mode"`
-    LogPath           string `yaml:"log_path"`
-    MaxFileSize       string `yaml:"max_file_size"`
-    MaxFileSizeInt64  int64  `yaml:"-"`              // Parsed later
-    MaxTransfers      int32  `yaml:"max_transfers"`
-    Region            string `yaml:"region"`
-    Workload          string `yaml:"workload"`
+    ApplyOptimization          bool                      `yaml:"apply_optimization"`
+    BackendDevices             string                    `yaml:"backend_devices"`
+    CandidateGenerator         string                    `yaml:"candidate_generator"`
+    CharSet1                   string                    `yaml:"char_set1"`
+    CharSet2                   string                    `yaml:"char_set2"`
+    CharSet3                   string                    `yaml:"char_set3"`
+    CharSet4                   string                    `yaml:"char_set4"`
+    CrackingMode               string                    `yaml:"cracking_mode"`
+    DeviceTypes                string                    `yaml:"device_types"`
+    DisableTuning              bool                      `yaml:"disable_tuning"`
+    ExtraHashcatArgs           []string                  `yaml:"extra_hashcat_args"`
+    GpuMonitorInterval         string                    `yaml:"gpu_monitor_interval"`
+    GpuMonitorIntervalDuration time.Duration             `yaml:"-"`              // Parsed later
+    GpuTempLimit               int                       `yaml:"gpu_temp_limit"`
+    Hardening                  bool                      `yaml:"hardening"`
+    HardeningUser              string                    `yaml:"hardening_user"`
+    HashcatJobs                int                       `yaml:"hashcat_jobs"`
+    HashcatPath                string                    `yaml:"hashcat_path"`
+    HashMask                   string                    `yaml:"hash_mask"`
+    HashQuota                  string                    `yaml:"hash_quota"`
+    HashQuotaInt64             int64                     `yaml:"-"`              // Parsed later
+    HashType                   string                    `yaml:"hash_type"`
+    JobTimeout                 string                    `yaml:"job_timeout"`
+    JobTimeoutDuration         time.Duration             `yaml:"-"`              // Parsed later
+    KernelAccel                string                    `yaml:"kernel_accel"`
+    KernelLoops                string                    `yaml:"kernel_loops"`
+    KernelThreads              string                    `yaml:"kernel_threads"`
+    KeyspaceChunks             int                       `yaml:"keyspace_chunks"`
+    LogMode                    string                    `yaml:"log_mode"`
+    LogPath                    string                    `yaml:"log_path"`
+    LootFlushCracks            int                       `yaml:"loot_flush_cracks"`
+    LootFlushInterval          string                    `yaml:"loot_flush_interval"`
+    LootFlushIntervalDuration  time.Duration             `yaml:"-"`              // Parsed later
+    MaxFileSize                string                    `yaml:"max_file_size"`
+    MaxFileSizeInt64           int64                     `yaml:"-"`              // Parsed later
+    MaxTransfers               int32                     `yaml:"max_transfers"`
+    Region                     string                    `yaml:"region"`
+    ReservedSpace              string                    `yaml:"reserved_space"`
+    RulesetQuota               string                    `yaml:"ruleset_quota"`
+    RulesetQuotaInt64          int64                     `yaml:"-"`              // Parsed later
+    ScrubOnCompletion          bool                      `yaml:"scrub_on_completion"`
+    SelfTerminate              bool                      `yaml:"self_terminate"`
+    StreamWordlists            bool                      `yaml:"stream_wordlists"`
+    SystemdConfinement         bool                      `yaml:"systemd_confinement"`
+    TuningProfiles             map[string]hashcat.Tuning `yaml:"tuning_profiles"`
+    Tunings                    map[string]hashcat.Tuning `yaml:"-"`              // Parsed later
+    Workload                   string                    `yaml:"workload"`
+    WordlistQuota              string                    `yaml:"wordlist_quota"`
+    WordlistQuotaInt64         int64                     `yaml:"-"`              // Parsed later
 }
 
 
 // LoadConfig reads the YAML file and unmarshals it into AppConfig struct in
 // memory, then validates the parsed data from local and client sections of yaml.
 //
+// @Parameters
+// - filePath:  The path to the YAML file
+//
 // @Returns
 // - The initialized AppConfig struct loaded with validated data
 //
 func LoadConfig(filePath string) *AppConfig {
-    // Open the YAML file
-    file, err := os.Open(filePath)
+    return LoadConfigLayers(filePath, Overrides{})
+}
+
+
+// LoadConfigLayers reads the YAML file and merges it with the built-in defaults, the
+// selected profile, environment, and command line overrides into the AppConfig struct,
+// then validates the merged data from local and client sections of yaml.
+//
+// @Parameters
+// - filePath:  The path to the YAML file
+// - overrides:  The profile and keys passed on the command line
+//
+// @Returns
+// - The initialized AppConfig struct loaded with validated data
+//
+func LoadConfigLayers(filePath string, overrides Overrides) *AppConfig {
+    // Read the YAML file
+    fileData, err := os.ReadFile(filePath)
     if err != nil {
         log.Fatalf("Could not open YAML file:  %v", err)
     }
-    // Close file on local exit
-    defer file.Close()
+
+    // Merge the YAML file with the rest of the config layers
+    merged, err := MergeLayers(fileData, overrides)
+    if err != nil {
+        log.Fatalf("Could not merge config layers:  %v", err)
+    }
 
     // Create a new AppConfig instance
     var config AppConfig
 
-    // Decode YAML into AppConfig struct
-    decoder := yaml.NewDecoder(file)
-    err = decoder.Decode(&config)
+    // Decode merged YAML into AppConfig struct
+    err = yaml.Unmarshal(merged, &config)
     if err != nil {
         log.Fatalf("Could not decode YAML into AppConfig:  %v", err)
     }
 
-    // Validate local config section of YAML data
-    err = validateLocalConfig(&config.LocalConfig)
+    // Validate client config section of YAML data
+    err = validateClientConfig(&config.ClientConfig)
+    if err != nil {
+        log.Fatalf("Invalid client config:  %v", err)
+    }
+
+    // Validate local config section of YAML data against the parsed client config
+    err = validateLocalConfig(&config.LocalConfig, &config.ClientConfig)
     if err != nil {
         log.Fatalf("Invalid local config:  %v", err)
     }
 
-    // Validate client config section of YAML data
-    err = validateClientConfig(&config.ClientConfig)
+    // Credentials, ARNs, and IAM roles do not cross partitions, so the server and clients
+    // must be in the same one (both in GovCloud for example)
+    if !partition.SamePartition(config.LocalConfig.Region, config.ClientConfig.Region) {
+        log.Fatalf("Invalid config:  the local region %s and client region %s are in " +
+                   "different AWS partitions", config.LocalConfig.Region,
+                   config.ClientConfig.Region)
+    }
+
+    // Expand the hash files cracked in the run with their hash types
+    config.LocalConfig.HashInputs, err = expandHashFiles(&config.LocalConfig,
+                                                         config.ClientConfig.HashType)
     if err != nil {
-        log.Fatalf("Invalid client config:  %v", err)
+        log.Fatalf("Invalid hash files:  %v", err)
+    }
+
+    // Resolve the tuning profiles of the hash types cracked in the run
+    config.ClientConfig.Tunings = resolveTunings(&config)
+
+    // Expand the rulesets each wordlist is run with
+    config.LocalConfig.RulesetInputs, err = expandRulesets(&config.LocalConfig)
+    if err != nil {
+        log.Fatalf("Invalid rulesets:  %v", err)
+    }
+
+    // Ensure the hash and ruleset files sent to each client fit in their dir quotas
+    err = validateQuotas(&config)
+    if err != nil {
+        log.Fatalf("Invalid quotas:  %v", err)
+    }
+
+    // The hardened client can not replace its own binary, and local clients are not
+    // started as root so there are no privileges to drop
+    if config.ClientConfig.Hardening && (config.LocalConfig.ClientAutoUpdate ||
+                                         config.LocalConfig.LocalTesting) {
+        log.Fatalf("Invalid config:  hardening can not be used with client_auto_update " +
+                   "or local_testing")
+    }
+
+    // Windows clients have no unprivileged user, systemd or apt generator packages, and
+    // can not replace their running binary
+    if config.LocalConfig.ClientOs == "windows" &&
+       (config.ClientConfig.Hardening || config.ClientConfig.SystemdConfinement ||
+        config.ClientConfig.CandidateGenerator != "" || config.LocalConfig.ClientAutoUpdate) {
+        log.Fatalf("Invalid config:  client_os windows can not be used with hardening, " +
+                   "systemd_confinement, candidate_generator, or client_auto_update")
+    }
+
+    // Streamed wordlists are read once from hashcat stdin, so only straight mode against a
+    // single hash file is possible and the wordlists must be sent over a socket
+    if config.ClientConfig.StreamWordlists &&
+       (config.ClientConfig.CrackingMode != "0" || len(config.LocalConfig.HashInputs) != 1 ||
+        config.LocalConfig.ControlPlane == "sqs") {
+        log.Fatalf("Invalid config:  stream_wordlists requires cracking_mode 0, a single " +
+                   "hash file, and control_plane tls")
+    }
+
+    // Streamed wordlists are read in order, so they can not be split into ranges
+    if config.ClientConfig.StreamWordlists && config.LocalConfig.ParallelConnections > 1 {
+        log.Fatalf("Invalid config:  stream_wordlists can not be used with parallel_connections")
+    }
+
+    // Streamed wordlists are read once and mask ranges have no wordlist to pair, so
+    // neither can be run with more than one rule pass
+    if len(config.LocalConfig.RulesetInputs) > 1 &&
+       (config.ClientConfig.StreamWordlists || config.ClientConfig.KeyspaceChunks > 0) {
+        log.Fatalf("Invalid config:  stream_wordlists and keyspace_chunks can only be used " +
+                   "with a single ruleset")
+    }
+
+    // Only wordlists stored on the clients over a TLS connection can be split
+    if config.LocalConfig.WorkStealing && (config.ClientConfig.StreamWordlists ||
+                                           config.ClientConfig.KeyspaceChunks > 0 ||
+                                           config.LocalConfig.ControlPlane == "sqs") {
+        log.Fatalf("Invalid config:  work_stealing can not be used with stream_wordlists, " +
+                   "keyspace_chunks, or control_plane sqs")
     }
 
     return &config
 }
 
 
+// Ensure the relay settings are complete and parse the address clients connect to the
+// relay on, the host must be an IP address since the server certificate is issued for it.
+//
+// @Parameters
+// - localConfig:  The LocalConfig section of the parsed yaml data
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func validateRelay(localConfig *LocalConfig) error {
+    // If only part of the relay settings are set
+    if localConfig.RelayAddress == "" || localConfig.RelayClientAddress == "" ||
+       localConfig.RelayFingerprint == "" || localConfig.RelayToken == "" {
+        return fmt.Errorf("relay_address, relay_client_address, relay_fingerprint, and " +
+                          "relay_token must be set together")
+    }
+
+    // If the pinned relay certificate is not a SHA-256 fingerprint
+    if !validate.ReSha256.MatchString(localConfig.RelayFingerprint) {
+        return fmt.Errorf("relay_fingerprint must be the 64 hex character SHA-256 " +
+                          "fingerprint printed by the relay subcommand")
+    }
+
+    // If the token is short enough to be guessed
+    if len(localConfig.RelayToken) < 16 || strings.ContainsAny(localConfig.RelayToken, " \t\n") {
+        return fmt.Errorf("relay_token must be at least 16 characters without whitespace")
+    }
+
+    // The relay pipes the direct TLS connections of clients in the VPC, SQS queues have
+    // none to pipe and local clients are not in the VPC
+    if localConfig.ControlPlane == "sqs" || localConfig.LocalTesting {
+        return fmt.Errorf("relay_address can not be used with control_plane sqs or " +
+                          "local_testing")
+    }
+
+    _, _, err := net.SplitHostPort(localConfig.RelayAddress)
+    if err != nil {
+        return fmt.Errorf("improper relay_address - %w", err)
+    }
+
+    host, port, err := net.SplitHostPort(localConfig.RelayClientAddress)
+    if err != nil {
+        return fmt.Errorf("improper relay_client_address - %w", err)
+    }
+
+    localConfig.RelayClientPort, err = strconv.Atoi(port)
+    // If the host is not an IP address or the port is out of range
+    if net.ParseIP(host) == nil || err != nil || !validate.ValidateListenerPort(
+       localConfig.RelayClientPort) || localConfig.RelayClientPort > 65535 {
+        return fmt.Errorf("relay_client_address must be an IP address and port above 1000")
+    }
+
+    localConfig.RelayClientHost = host
+    return nil
+}
+
+
 // Takes the parsed data in LocalConfig struct and passes each
 // struct member into its corresponding validation routine.
 //
 // @Parameters
 // - localConfig:  The LocalConfig section of the parsed yaml data
+// - clientConfig:  The validated ClientConfig section the local settings depend on
 //
 // @Returns
 // - Error if it occurs, otherwise nil on success
 //
-func validateLocalConfig(localConfig *LocalConfig) error {
+func validateLocalConfig(localConfig *LocalConfig, clientConfig *ClientConfig) error {
     // Ensure the account id is of proper format
     err := validate.ValidateAccountId(localConfig.AccountId)
     if err != nil {
         return err
     }
 
+    // Iterate through the user data hook scripts ensuring the ones set exist
+    for key, hookPath := range map[string]*string{
+        "user_data_post_hook": &localConfig.UserDataPostHook,
+        "user_data_pre_hook":  &localConfig.UserDataPreHook,
+    } {
+        if *hookPath == "" {
+            continue
+        }
+
+        *hookPath, err = validate.ValidatePath(*hookPath)
+        if err != nil {
+            return fmt.Errorf("improper %s specified - %w", key, err)
+        }
+
+        info, err := os.Stat(*hookPath)
+        if err != nil || !info.Mode().IsRegular() {
+            return fmt.Errorf("%s %s is not a readable file", key, *hookPath)
+        }
+    }
+
+    // If the admin socket is enabled, ensure its path is proper format and its dir exists
+    if localConfig.AdminSocket != "" {
+        localConfig.AdminSocket, err = validate.ValidatePath(localConfig.AdminSocket)
+        if err != nil {
+            return fmt.Errorf("improper admin_socket specified - %w", err)
+        }
+
+        info, err := os.Stat(filepath.Dir(localConfig.AdminSocket))
+        if err != nil || !info.IsDir() {
+            return fmt.Errorf("admin_socket dir %s does not exist",
+                              filepath.Dir(localConfig.AdminSocket))
+        }
+    }
+
+    // Ensure the AMI ID override is of proper format if set
+    err = validate.ValidateAmi(localConfig.Ami)
+    if err != nil {
+        return err
+    }
+
+    // Ensure the SSM parameter the AMI is resolved from is of proper format if set
+    err = validate.ValidateAmiSsmParameter(localConfig.AmiSsmParameter)
+    if err != nil {
+        return err
+    }
+
+    // If the brain is in use, either on the server host or a dedicated host
+    if localConfig.BrainServer || localConfig.BrainHost != "" {
+        // If both the local brain server and a dedicated brain host are set
+        if localConfig.BrainServer && localConfig.BrainHost != "" {
+            return fmt.Errorf("brain_server and brain_host can not both be set")
+        }
+
+        // Ensure the dedicated brain host is a valid IP address or hostname
+        err = validate.ValidateBrainHost(localConfig.BrainHost)
+        if err != nil {
+            return err
+        }
+
+        // If a dedicated brain host is used, its password can not be generated
+        if localConfig.BrainHost != "" && localConfig.BrainPassword == "" {
+            return fmt.Errorf("brain_password is required when brain_host is set")
+        }
+
+        // Ensure the brain password is safe to pass to clients
+        err = validate.ValidateBrainPassword(localConfig.BrainPassword)
+        if err != nil {
+            return err
+        }
+
+        // Ensure the brain port is usable
+        if !validate.ValidateBrainPort(localConfig.BrainPort, localConfig.ListenerPort,
+                                       localConfig.WebUiPort) {
+            return fmt.Errorf("brain_port must be greater than 1000 and different " +
+                              "from listener_port and web_ui_port")
+        }
+    }
+
     // Ensure the S3 bucket name is of proper format if exists
     err = validate.ValidateBucketName(localConfig.BucketName)
     if err != nil {
         return err
     }
 
-    // Ensure the hash file path exists
-    err = validate.ValidateHashFile(localConfig.HashFilePath)
+    // Ensure the client binary expiration is not negative
+    if localConfig.ClientBinaryExpiration < 0 {
+        return fmt.Errorf("client_binary_expiration_days must be 0 (disabled) or a " +
+                          "positive number of days")
+    }
+
+    // Ensure the budget limit is not negative
+    if !validate.ValidateCost(localConfig.BudgetLimit) {
+        return fmt.Errorf("budget_limit must be 0 (disabled) or a positive amount")
+    }
+
+    // If the client operating system is not supported
+    if !validate.ValidateClientOs(localConfig.ClientOs) {
+        return fmt.Errorf("improper client_os specified")
+    }
+
+    // Windows clients run on the x86 Windows Server AMI and are never spawned locally
+    if localConfig.ClientOs == "windows" &&
+       (awsutils.InstanceArchitecture(localConfig.InstanceType) == awsutils.ArchArm64 ||
+        localConfig.LocalTesting) {
+        return fmt.Errorf("client_os windows can not be used with Graviton instance types " +
+                          "or local_testing")
+    }
+
+    // Ensure the hashcat release built or downloaded on the clients is a version tag
+    err = validate.ValidateHashcatRelease(localConfig.HashcatRelease)
     if err != nil {
         return err
     }
 
+    // If a custom hashcat build is uploaded alongside the client
+    if localConfig.HashcatArtifact != "" {
+        // The clients install either the custom build or the release
+        if localConfig.HashcatRelease != "" {
+            return fmt.Errorf("hashcat_artifact can not be used with hashcat_release")
+        }
+
+        err = validate.ValidateHashcatArtifact(localConfig.HashcatArtifact,
+                                               localConfig.ClientOs)
+        if err != nil {
+            return err
+        }
+    }
+
+    // If the expected checksum of the custom hashcat build is set
+    if localConfig.HashcatArtifactSha256 != "" {
+        if localConfig.HashcatArtifact == "" {
+            return fmt.Errorf("hashcat_artifact_sha256 requires hashcat_artifact")
+        }
+
+        err = validate.ValidateSha256(localConfig.HashcatArtifactSha256)
+        if err != nil {
+            return err
+        }
+    }
+
+    // If the control plane mode is not supported
+    if !validate.ValidateControlPlane(localConfig.ControlPlane) {
+        return fmt.Errorf("improper control_plane specified")
+    }
+
+    // The SQS control plane has no direct connections between hosts
+    if localConfig.ControlPlane == "sqs" &&
+       (localConfig.LocalTesting || localConfig.PeerSharing || localConfig.BrainServer) {
+        return fmt.Errorf("control_plane sqs can not be used with local_testing, " +
+                          "peer_sharing, or brain_server")
+    }
+
+    // Wordlists are staged in S3 with a single PutObject call in SQS mode
+    if localConfig.ControlPlane == "sqs" && clientConfig.MaxFileSizeInt64 > MaxS3ObjectSize {
+        return fmt.Errorf("max_file_size can not exceed 5GB with control_plane sqs")
+    }
+
+    // Range assignment skips merging, so there is no merge to pipeline
+    if localConfig.PipelinedMerge && localConfig.RangeAssignment {
+        return fmt.Errorf("pipelined_merge can not be used with range_assignment")
+    }
+
+    // If privileged actions are recorded, ensure the audit log path is proper format
+    if localConfig.AuditLog != "" {
+        localConfig.AuditLog, err = validate.ValidatePath(localConfig.AuditLog)
+        if err != nil {
+            return fmt.Errorf("improper audit_log specified - %w", err)
+        }
+    }
+
+    // The audit log is forwarded to the CloudWatch log group of the run in AWS
+    if localConfig.AuditCloudwatch && (localConfig.AuditLog == "" || localConfig.LocalTesting) {
+        return fmt.Errorf("audit_cloudwatch requires audit_log and can not be used with " +
+                          "local_testing")
+    }
+
+    // Plaintext transfers rely on direct connections that never leave the VPC
+    if localConfig.PlaintextTransfers &&
+       (localConfig.RelayAddress != "" || localConfig.ControlPlane == "sqs") {
+        return fmt.Errorf("plaintext_transfers can not be used with relay_address or " +
+                          "control_plane sqs")
+    }
+
+    // If connections are relayed through a broker in the VPC
+    if localConfig.RelayAddress != "" || localConfig.RelayClientAddress != "" {
+        err = validateRelay(localConfig)
+        if err != nil {
+            return err
+        }
+    }
+
+    // If instances without instance store fall back to an EBS data volume, ensure its size
+    if localConfig.EbsFallback && !validate.ValidateEbsVolumeSize(localConfig.EbsVolumeSize) {
+        return fmt.Errorf("ebs_volume_size must be between 1 and 16384 GiB")
+    }
+
+    // Parse the expected runtime used to estimate the cost before launch
+    localConfig.ExpectedRuntimeDuration, err = validate.ValidateDuration(
+        localConfig.ExpectedRuntime)
+    if err != nil {
+        return fmt.Errorf("improper expected_runtime - %w", err)
+    }
+
+    // If a budget is set there must be an expected runtime to estimate the cost with
+    if localConfig.BudgetLimit > 0 && localConfig.ExpectedRuntimeDuration == 0 {
+        return fmt.Errorf("expected_runtime is required when budget_limit is set")
+    }
+
+    // If a single hash file is used, or no hash files are listed, ensure the path exists
+    if localConfig.HashFilePath != "" || len(localConfig.HashFiles) == 0 {
+        err = validate.ValidateHashFile(localConfig.HashFilePath)
+        if err != nil {
+            return err
+        }
+    }
+
+    // Ensure the hourly price override is not negative
+    if !validate.ValidateCost(localConfig.HourlyPrice) {
+        return fmt.Errorf("hourly_price must be 0 (use estimate) or a positive amount")
+    }
+
     // Ensure the IAM username is valid
     err = validate.ValidateIamUsername(localConfig.IamUsername)
     if err != nil {
         return err
     }
 
+    // Iterate through the pre-existing roles ensuring the ones set are proper format
+    for key, roleArn := range map[string]string{
+        "client_role_arn": localConfig.ClientRoleArn,
+        "server_role_arn": localConfig.ServerRoleArn,
+    } {
+        if roleArn == "" {
+            continue
+        }
+
+        err = validate.ValidateIamRoleArn(roleArn)
+        if err != nil {
+            return fmt.Errorf("improper %s - %w", key, err)
+        }
+    }
+
+    // If an existing client role is used, its instance profile defaults to the role name
+    if localConfig.ClientRoleArn != "" && localConfig.ClientInstanceProfile == "" {
+        localConfig.ClientInstanceProfile = awsutils.RoleNameFromArn(localConfig.ClientRoleArn)
+    }
+
+    // If an instance profile is set, ensure it is for an existing client role
+    if localConfig.ClientInstanceProfile != "" {
+        if localConfig.ClientRoleArn == "" {
+            return fmt.Errorf("client_instance_profile requires client_role_arn to be set")
+        }
+
+        err = validate.ValidateInstanceProfile(localConfig.ClientInstanceProfile)
+        if err != nil {
+            return fmt.Errorf("improper client_instance_profile - %w", err)
+        }
+    }
+
+    // If the created roles are given a permissions boundary, ensure it is a policy ARN
+    if localConfig.IamPermissionsBoundary != "" {
+        err = validate.ValidateIamPolicyArn(localConfig.IamPermissionsBoundary)
+        if err != nil {
+            return fmt.Errorf("improper iam_permissions_boundary - %w", err)
+        }
+    }
+
     // Ensure instance type is in supported list
     if !validate.ValidateInstanceType(localConfig.InstanceType) {
         return fmt.Errorf("improper instance_type - %w", err)
     }
 
+    // G5g instances have no instance store, so the data dir needs the EBS volume
+    if strings.HasPrefix(localConfig.InstanceType, "g5g.") && !localConfig.EbsFallback {
+        return fmt.Errorf("g5g instance types have no instance store and require ebs_fallback")
+    }
+
     // If the listerner port is less than 1000
     if !validate.ValidateListenerPort(localConfig.ListenerPort) {
         return fmt.Errorf("listener_port must greater than 1000")
@@ -148,39 +720,271 @@ func validateLocalConfig(localConfig *LocalConfig) error {
         return err
     }
 
+    // Local clients are spawned on the server host so AWS must not be in use
+    if localConfig.LocalClients && !localConfig.LocalTesting {
+        return fmt.Errorf("local_clients requires local_testing to be enabled")
+    }
+
     // Ensure log path is proper format and reset ruleset path with validated
     localConfig.LogPath, err = validate.ValidatePath(localConfig.LogPath)
     if err != nil {
         return fmt.Errorf("improper log_path specified - %w", err)
     }
 
+    // Ensure the max cost is not negative
+    if !validate.ValidateCost(localConfig.MaxCost) {
+        return fmt.Errorf("max_cost must be 0 (disabled) or a positive amount")
+    }
+
+    // Ensure the budget warning is a percent below the max cost
+    if localConfig.BudgetWarning < 0 || localConfig.BudgetWarning >= 100 {
+        return fmt.Errorf("budget_warning must be 0 (disabled) or a percent below 100")
+    }
+
+    // Ensure the budget warning has a max cost to warn before
+    if localConfig.BudgetWarning > 0 && localConfig.MaxCost == 0 {
+        return fmt.Errorf("budget_warning requires max_cost to be set")
+    }
+
     // Parse and convert the max merging size to raw bytes from any units
     localConfig.MaxMergingSizeInt64, err = validate.ValidateFileSize(localConfig.MaxMergingSize)
     if err != nil {
         return fmt.Errorf("improper max_merging_size - %w", err)
     }
 
+    // Parse how long the server TLS certificates are valid for
+    localConfig.CertLifetimeDuration, err = validate.ValidateDuration(localConfig.CertLifetime)
+    if err != nil {
+        return fmt.Errorf("improper cert_lifetime - %w", err)
+    }
+
+    // Parse the interval the server TLS certificate is rotated on
+    localConfig.CertRotationDuration, err = validate.ValidateDuration(localConfig.CertRotation)
+    if err != nil {
+        return fmt.Errorf("improper cert_rotation - %w", err)
+    }
+
+    // If certificates are rotated, ensure each one is replaced before it expires
+    if localConfig.CertRotationDuration > 0 {
+        if localConfig.CertLifetimeDuration > 0 &&
+           localConfig.CertRotationDuration >= localConfig.CertLifetimeDuration {
+            return fmt.Errorf("cert_rotation must be shorter than cert_lifetime")
+        }
+
+        // If clients connect over SQS, there is no TLS listener to rotate the certificate of
+        if localConfig.ControlPlane == "sqs" {
+            return fmt.Errorf("cert_rotation can not be used with control_plane sqs")
+        }
+    }
+
+    // Parse the max runtime the fleet is allowed before it is terminated
+    localConfig.MaxRuntimeDuration, err = validate.ValidateDuration(localConfig.MaxRuntime)
+    if err != nil {
+        return fmt.Errorf("improper max_runtime - %w", err)
+    }
+
+    // Parse how long a connection may sit without traffic before the peer is considered hung
+    localConfig.IdleTimeoutDuration, err = validate.ValidateDuration(localConfig.IdleTimeout)
+    if err != nil {
+        return fmt.Errorf("improper idle_timeout - %w", err)
+    }
+
     // Ensure the max size range is less or equal to 50 percent
     if !validate.ValidateMaxSizeRange(localConfig.MaxSizeRange) {
         return fmt.Errorf("max_size_range greater than 50 percent")
     }
 
+    usedPorts := []int{localConfig.ListenerPort, localConfig.WebUiPort}
+    // If the brain server runs on the server host, its port is also in use
+    if localConfig.BrainServer {
+        usedPorts = append(usedPorts, localConfig.BrainPort)
+    }
+
+    // Ensure the metrics port is disabled or a usable port
+    if !validate.ValidateMetricsPort(localConfig.MetricsPort, usedPorts...) {
+        return fmt.Errorf("metrics_port must be 0 (disabled) or greater than 1000 and " +
+                          "different from listener_port, web_ui_port, and brain_port")
+    }
+
     // If the number of instances is less than one
     if !validate.ValidateNumberInstances(localConfig.NumberInstances) {
         return fmt.Errorf("number_instances must be a positive integer")
     }
 
+    // Ensure the parallel connections are disabled or within the max
+    if !validate.ValidateParallelConnections(localConfig.ParallelConnections) {
+        return fmt.Errorf("parallel_connections must be between 0 (disabled) and %d",
+                          netio.MaxRangeConnections)
+    }
+
+    // If wordlists are split over parallel connections, parse the size it applies from
+    if localConfig.ParallelConnections > 1 {
+        localConfig.ParallelMinSizeInt64, err = validate.ValidateFileSize(
+            localConfig.ParallelMinSize)
+        if err != nil {
+            return fmt.Errorf("improper parallel_min_size - %w", err)
+        }
+    }
+
+    // If unstarted wordlists are split with idle clients, parse the size it applies from
+    if localConfig.WorkStealing {
+        localConfig.WorkStealMinSizeInt64, err = validate.ValidateFileSize(
+            localConfig.WorkStealMinSize)
+        if err != nil {
+            return fmt.Errorf("improper work_steal_min_size - %w", err)
+        }
+    }
+
     // Ensure a proper region was specified in the local config
     if !validate.ValidateRegion(localConfig.Region) {
         return fmt.Errorf("improper region specified")
     }
 
+    // Ensure the results bucket name is of proper format if exists
+    err = validate.ValidateBucketName(localConfig.ResultsBucket)
+    if err != nil {
+        return fmt.Errorf("improper results_bucket - %w", err)
+    }
+
+    // If a local results dir is set, ensure it is a proper path
+    if localConfig.ResultsDir != "" {
+        localConfig.ResultsDir, err = validate.ValidatePath(localConfig.ResultsDir)
+        if err != nil {
+            return fmt.Errorf("improper results_dir specified - %w", err)
+        }
+    }
+
+    // Ensure the results expiration is not negative and only set with a results bucket
+    if localConfig.ResultsExpirationDays < 0 ||
+    (localConfig.ResultsExpirationDays > 0 && localConfig.ResultsBucket == "") {
+        return fmt.Errorf("results_expiration_days must be 0 (disabled) or a positive " +
+                          "number of days with results_bucket set")
+    }
+
+    // If a KMS key is set, ensure it is a key ID or key ARN
+    if localConfig.S3KmsKeyId != "" {
+        err = validate.ValidateKmsKeyId(localConfig.S3KmsKeyId)
+        if err != nil {
+            return fmt.Errorf("improper s3_kms_key_id - %w", err)
+        }
+    }
+
+    // Ensure the normalization max length is not negative and only set with normalization
+    if localConfig.NormalizeMaxLength < 0 ||
+    (localConfig.NormalizeMaxLength > 0 && !localConfig.NormalizeWordlists) {
+        return fmt.Errorf("normalize_max_length must be 0 (hashcat limit) or a positive " +
+                          "number of bytes with normalize_wordlists enabled")
+    }
+
+    // Ensure the notification sinks have a destination and subscribe to known events
+    err = notify.ValidateSinks(localConfig.Notifications)
+    if err != nil {
+        return fmt.Errorf("improper notifications - %w", err)
+    }
+
+    // Parse the password policy candidates not matching it are filtered by
+    localConfig.PasswordPolicyFilter, err = wordlist.ParsePolicy(localConfig.PasswordPolicy,
+                                                                 localConfig.PasswordPolicyRegex)
+    if err != nil {
+        return fmt.Errorf("improper password_policy - %w", err)
+    }
+
+    // Ensure the wordlist preprocessing stages are supported
+    err = validate.ValidatePreprocessStages(localConfig.PreprocessStages)
+    if err != nil {
+        return err
+    }
+
     // Ensure the ruleset file path exists
     err = validate.ValidateRulesetFile(localConfig.RulesetPath)
     if err != nil {
         return err
     }
 
+    // Ensure the run name is usable in tags, CloudWatch stream names, and file names
+    if !validate.ValidateRunName(localConfig.RunName) {
+        return fmt.Errorf("run_name must start with a letter or digit followed by up to " +
+                          "63 letters, digits, dots, underscores, or dashes")
+    }
+
+    // Ensure the run tags are accepted by AWS and do not replace the tags of the run
+    err = validate.ValidateRunTags(localConfig.RunTags, awsutils.ServiceTagKey,
+                                   awsutils.RunTagKey, awsutils.RunNameTagKey)
+    if err != nil {
+        return fmt.Errorf("improper run_tags - %w", err)
+    }
+
+    // If the wordlist schedule strategy is not supported
+    if !validate.ValidateScheduleStrategy(localConfig.ScheduleStrategy) {
+        return fmt.Errorf("improper schedule_strategy specified")
+    }
+
+    // Merging flattens the load dir subdirs, so only ranges keep the subdir they are in
+    if localConfig.ScheduleStrategy == "round_robin" && !localConfig.RangeAssignment {
+        return fmt.Errorf("schedule_strategy round_robin requires range_assignment")
+    }
+
+    // Iterate through the prior results ensuring each exists
+    for index, priorPath := range localConfig.PriorResults {
+        localConfig.PriorResults[index], err = validate.ValidatePath(priorPath)
+        if err != nil {
+            return fmt.Errorf("improper prior_results entry %q - %w", priorPath, err)
+        }
+
+        err = validate.ValidateFile(localConfig.PriorResults[index])
+        if err != nil {
+            return fmt.Errorf("error validating prior_results entry %q - %w", priorPath, err)
+        }
+    }
+
+    // If wordlists are ordered by a priority file, ensure it exists
+    if localConfig.ScheduleStrategy == "priority" {
+        localConfig.PriorityFile, err = validate.ValidatePath(localConfig.PriorityFile)
+        if err != nil {
+            return fmt.Errorf("improper priority_file specified - %w", err)
+        }
+
+        err = validate.ValidateFile(localConfig.PriorityFile)
+        if err != nil {
+            return fmt.Errorf("error validating priority_file - %w", err)
+        }
+    }
+
+    // Ensure the public IPs set in place of discovery are valid addresses
+    err = publicip.ValidateIps(localConfig.PublicIps)
+    if err != nil {
+        return fmt.Errorf("improper public_ips - %w", err)
+    }
+
+    // Ensure the IP discovery endpoints and STUN servers can be queried
+    err = publicip.ValidateResolvers(localConfig.IpDiscoveryEndpoints, localConfig.StunServers)
+    if err != nil {
+        return err
+    }
+
+    // Parse how long discovered public IPs are cached for
+    localConfig.PublicIpTtlDuration, err = validate.ValidateDuration(localConfig.PublicIpTtl)
+    if err != nil {
+        return fmt.Errorf("improper public_ip_ttl - %w", err)
+    }
+
+    // Iterate through the allowed client ranges parsing each
+    for _, cidr := range localConfig.AllowedClientCidrs {
+        _, ipNet, err := net.ParseCIDR(cidr)
+        if err != nil {
+            return fmt.Errorf("improper allowed_client_cidrs entry %q - %w", cidr, err)
+        }
+
+        localConfig.AllowedClientNets = append(localConfig.AllowedClientNets, ipNet)
+    }
+
+    // The SQS control plane has no connections to screen or authenticate
+    if localConfig.ControlPlane == "sqs" &&
+       (len(localConfig.AllowedClientCidrs) > 0 || localConfig.ConnectionToken) {
+        return fmt.Errorf("allowed_client_cidrs and connection_token can not be used with " +
+                          "control_plane sqs")
+    }
+
     // Ensure specified security group IDs are valid
     err = validate.ValidateSecurityGroupIds(localConfig.SecurityGroupIds)
     if err != nil {
@@ -199,10 +1003,211 @@ func validateLocalConfig(localConfig *LocalConfig) error {
         return err
     }
 
+    // Ensure the run summary export formats are supported
+    err = validate.ValidateSummaryExport(localConfig.SummaryExport)
+    if err != nil {
+        return err
+    }
+
+    // If the hash verification mode is not supported
+    if !validate.ValidateVerifyHashes(localConfig.VerifyHashes) {
+        return fmt.Errorf("improper verify_hashes specified")
+    }
+
+    // Ensure the web UI port is disabled or a usable port
+    if !validate.ValidateWebUiPort(localConfig.WebUiPort, localConfig.ListenerPort) {
+        return fmt.Errorf("web_ui_port must be 0 (disabled) or greater than 1000 " +
+                          "and different from listener_port")
+    }
+
+    // If the web UI is enabled, ensure it is not exposed beyond the host without a token
+    if localConfig.WebUiPort != 0 {
+        webUiIp := net.ParseIP(localConfig.WebUiAddress)
+        // If the web UI address is not an IP address
+        if webUiIp == nil {
+            return fmt.Errorf("web_ui_address must be an IP address")
+        }
+
+        // If the web UI token is set but short enough to be guessed
+        if localConfig.WebUiToken != "" && (len(localConfig.WebUiToken) < 16 ||
+           strings.ContainsAny(localConfig.WebUiToken, " \t\n")) {
+            return fmt.Errorf("web_ui_token must be at least 16 characters without whitespace")
+        }
+
+        // If the web UI is reachable from other hosts without a token
+        if !webUiIp.IsLoopback() && localConfig.WebUiToken == "" {
+            return fmt.Errorf("web_ui_token must be set when web_ui_address is not a " +
+                              "loopback address")
+        }
+    }
+
     return nil
 }
 
 
+// Expands the hash file path and the listed hash files into the individual hash files
+// cracked in the run, with dirs replaced by the files in them and any missing hash
+// type set to the client config hash type.
+//
+// @Parameters
+// - localConfig:  The validated LocalConfig section of the parsed yaml data
+// - defaultHashType:  The client config hash type used when none is listed
+//
+// @Returns
+// - The hash files in the order they are listed
+// - Error if it occurs, otherwise nil on success
+//
+func expandHashFiles(localConfig *LocalConfig, defaultHashType string) ([]HashFile, error) {
+    var hashInputs []HashFile
+    names := map[string]bool{}
+
+    entries := localConfig.HashFiles
+    // If the single hash file path is set, it is cracked first
+    if localConfig.HashFilePath != "" {
+        entries = append([]HashFile{{Path: localConfig.HashFilePath}}, entries...)
+    }
+
+    // Iterate through the entries expanding each into its hash files
+    for _, entry := range entries {
+        // If no hash type is listed, use the client config hash type
+        if entry.HashType == "" {
+            entry.HashType = defaultHashType
+        }
+
+        // If the hash type is not in supported types
+        if !validate.ValidateHashType(entry.HashType) {
+            return nil, fmt.Errorf("improper hash_type %s for %s", entry.HashType, entry.Path)
+        }
+
+        validPath, err := validate.ValidatePath(entry.Path)
+        if err != nil {
+            return nil, fmt.Errorf("improper hash_files path specified - %w", err)
+        }
+
+        filePaths := []string{validPath}
+        // If the path is a dir, crack every file with data in it
+        if validate.ValidateDir(validPath) == nil {
+            items, err := os.ReadDir(validPath)
+            if err != nil {
+                return nil, err
+            }
+
+            filePaths = nil
+            // Iterate through the dir items collecting the files
+            for _, item := range items {
+                if !item.IsDir() {
+                    filePaths = append(filePaths, filepath.Join(validPath, item.Name()))
+                }
+            }
+        }
+
+        // Iterate through the hash files validating each
+        for _, filePath := range filePaths {
+            err = validate.ValidateFile(filePath)
+            if err != nil {
+                return nil, fmt.Errorf("error validating hash file %s - %w", filePath, err)
+            }
+
+            // Clients store hash files by name, so the names must be unique
+            name := filepath.Base(filePath)
+            if names[name] {
+                return nil, fmt.Errorf("hash file name %s is used more than once", name)
+            }
+            names[name] = true
+
+            hashInputs = append(hashInputs, HashFile{HashType: entry.HashType,
+                                                     Path: filePath})
+        }
+    }
+
+    // If there are more hash files than can be sent to clients
+    if len(hashInputs) > MaxHashFiles {
+        return nil, fmt.Errorf("no more than %d hash files can be cracked in a run",
+                               MaxHashFiles)
+    }
+
+    // Hash file shards are only split from a single hash file
+    if localConfig.SplitHashFile && len(hashInputs) > 1 {
+        return nil, fmt.Errorf("split_hash_file can only be used with a single hash file")
+    }
+
+    return hashInputs, nil
+}
+
+
+// Expands the ruleset path and the listed rulesets into the individual ruleset files sent
+// to clients, with dirs replaced by the files in them, then validates the pairings of
+// wordlists with the rulesets they are run with.
+//
+// @Parameters
+// - localConfig:  The validated LocalConfig section of the parsed yaml data
+//
+// @Returns
+// - The paths of the ruleset files in the order they are listed
+// - Error if it occurs, otherwise nil on success
+//
+func expandRulesets(localConfig *LocalConfig) ([]string, error) {
+    var rulesetInputs []string
+    var rulesetNames []string
+
+    entries := localConfig.Rulesets
+    // If the single ruleset path is set, it is listed first
+    if localConfig.RulesetPath != "" {
+        entries = append([]string{localConfig.RulesetPath}, entries...)
+    }
+
+    // Iterate through the entries expanding each into its ruleset files
+    for _, entry := range entries {
+        validPath, err := validate.ValidatePath(entry)
+        if err != nil {
+            return nil, fmt.Errorf("improper rulesets path specified - %w", err)
+        }
+
+        filePaths := []string{validPath}
+        // If the path is a dir, use every file in it
+        if validate.ValidateDir(validPath) == nil {
+            items, err := os.ReadDir(validPath)
+            if err != nil {
+                return nil, err
+            }
+
+            filePaths = nil
+            // Iterate through the dir items collecting the files
+            for _, item := range items {
+                if !item.IsDir() {
+                    filePaths = append(filePaths, filepath.Join(validPath, item.Name()))
+                }
+            }
+        }
+
+        // Iterate through the ruleset files validating each
+        for _, filePath := range filePaths {
+            err = validate.ValidateFile(filePath)
+            if err != nil {
+                return nil, fmt.Errorf("error validating ruleset %s - %w", filePath, err)
+            }
+
+            // Clients store rulesets by name, so the names must be unique
+            name := filepath.Base(filePath)
+            if slices.Contains(rulesetNames, name) {
+                return nil, fmt.Errorf("ruleset name %s is used more than once", name)
+            }
+
+            rulesetInputs = append(rulesetInputs, filePath)
+            rulesetNames = append(rulesetNames, name)
+        }
+    }
+
+    // Ensure the pairings only reference the rulesets that were listed
+    err := schedule.ValidatePairings(localConfig.RulesetPairings, rulesetNames)
+    if err != nil {
+        return nil, err
+    }
+
+    return rulesetInputs, nil
+}
+
+
 // Takes the parsed data in ClientConfig struct and passes each
 // struct member into its corresponding validation routine.
 //
@@ -228,6 +1233,81 @@ func validateClientConfig(clientConfig *ClientConfig) error {
         return fmt.Errorf("improper cracking_mode specified")
     }
 
+    // If the candidate generator is not supported
+    if !validate.ValidateCandidateGenerator(clientConfig.CandidateGenerator) {
+        return fmt.Errorf("improper candidate_generator specified")
+    }
+
+    // Generated candidates are fed into hashcat stdin in place of the wordlist, which is
+    // only possible in straight mode with the wordlist stored on disk
+    if clientConfig.CandidateGenerator != "" &&
+       (clientConfig.CrackingMode != "0" || clientConfig.StreamWordlists) {
+        return fmt.Errorf("candidate_generator requires cracking_mode 0 and can not be " +
+                          "used with stream_wordlists")
+    }
+
+    // Ensure the selected backend devices are a list of device IDs
+    if !validate.ValidateNumberList(clientConfig.BackendDevices) {
+        return fmt.Errorf("backend_devices must be a comma separated list of device IDs")
+    }
+
+    // Ensure the device types are supported by hashcat
+    if !validate.ValidateDeviceTypes(clientConfig.DeviceTypes) {
+        return fmt.Errorf("device_types must be a comma separated list of 1, 2, or 3")
+    }
+
+    tuning := map[string]string{"kernel_accel": clientConfig.KernelAccel,
+                                "kernel_loops": clientConfig.KernelLoops,
+                                "kernel_threads": clientConfig.KernelThreads}
+    // Iterate through the tuning values
+    for key, value := range tuning {
+        // Ensure the tuning is a single positive value, hashcat applies it to every device
+        if !validate.ValidateNumberList(value) || strings.Contains(value, ",") {
+            return fmt.Errorf("%s must be a single positive number", key)
+        }
+    }
+
+    // Parse the interval the clients sample their GPUs on
+    clientConfig.GpuMonitorIntervalDuration, err = validate.ValidateDuration(
+        clientConfig.GpuMonitorInterval)
+    if err != nil {
+        return fmt.Errorf("improper gpu_monitor_interval - %w", err)
+    }
+
+    // Ensure the temperature limit is disabled or one the GPUs can reach
+    if !validate.ValidateGpuTempLimit(clientConfig.GpuTempLimit) {
+        return fmt.Errorf("gpu_temp_limit must be 0 (disabled) or between %d and %d",
+                          validate.MinGpuTempLimit, validate.MaxGpuTempLimit)
+    }
+
+    // The temperature is only known from the samples of the monitor
+    if clientConfig.GpuTempLimit > 0 && clientConfig.GpuMonitorIntervalDuration == 0 {
+        return fmt.Errorf("gpu_temp_limit requires gpu_monitor_interval")
+    }
+
+    // If the hardened client drops to the default user
+    if clientConfig.HardeningUser == "" {
+        clientConfig.HardeningUser = harden.DefaultUser
+    }
+
+    // If the user the hardened client drops to is improper
+    if !validate.ValidateUsername(clientConfig.HardeningUser) {
+        return fmt.Errorf("improper hardening_user specified")
+    }
+
+    // The hashcat path is passed on the client launch line, so it can not hold characters
+    // the shell splits or expands
+    if strings.ContainsAny(clientConfig.HashcatPath, " \t\n\"'`$&;|<>()*?") {
+        return fmt.Errorf("hashcat_path can not contain whitespace, quotes, or shell " +
+                          "metacharacters")
+    }
+
+    // Ensure the number of concurrent hashcat jobs is within the max
+    if !validate.ValidateHashcatJobs(clientConfig.HashcatJobs) {
+        return fmt.Errorf("hashcat_jobs must be between 0 (disabled) and %d",
+                          validate.MaxHashcatJobs)
+    }
+
     // If the hash mask is present but not supported by cracking mode
     if !validate.ValidateHashMask(clientConfig.CrackingMode, clientConfig.HashMask) {
         return fmt.Errorf("hash_mask specified but not supported by cracking mode")
@@ -238,6 +1318,13 @@ func validateClientConfig(clientConfig *ClientConfig) error {
         return fmt.Errorf("improper hash_type specified")
     }
 
+    // If keyspace splitting is enabled without a pure mask attack
+    if !validate.ValidateKeyspaceChunks(clientConfig.KeyspaceChunks, clientConfig.CrackingMode,
+                                        clientConfig.HashMask) {
+        return fmt.Errorf("keyspace_chunks must be 0 (disabled) or positive with " +
+                          "cracking_mode 3 and a hash_mask")
+    }
+
     // If an improper region was specified in client config
     if !validate.ValidateLogMode(clientConfig.LogMode) {
         return fmt.Errorf("improper log_mode specified")
@@ -265,10 +1352,202 @@ func validateClientConfig(clientConfig *ClientConfig) error {
         return fmt.Errorf("improper region specified")
     }
 
-    // If the workload was not in supported profiles
-    if !validate.ValidateWorkload(clientConfig.Workload) {
+    // Ensure the reserved space is a percentage or a size
+    _, err = validate.ValidateReservedSpace(clientConfig.ReservedSpace)
+    if err != nil {
+        return fmt.Errorf("improper reserved_space - %w", err)
+    }
+
+    // Parse and convert the dir quotas to raw bytes from any units
+    clientConfig.HashQuotaInt64, err = validate.ValidateQuota(clientConfig.HashQuota)
+    if err != nil {
+        return fmt.Errorf("improper hash_quota - %w", err)
+    }
+
+    clientConfig.RulesetQuotaInt64, err = validate.ValidateQuota(clientConfig.RulesetQuota)
+    if err != nil {
+        return fmt.Errorf("improper ruleset_quota - %w", err)
+    }
+
+    // Parse the max runtime of each hashcat process before it is killed
+    clientConfig.JobTimeoutDuration, err = validate.ValidateDuration(clientConfig.JobTimeout)
+    if err != nil {
+        return fmt.Errorf("improper job_timeout - %w", err)
+    }
+
+    // Ensure the loot flush threshold is not negative
+    if clientConfig.LootFlushCracks < 0 {
+        return fmt.Errorf("loot_flush_cracks must be 0 (disabled) or a positive count")
+    }
+
+    clientConfig.LootFlushIntervalDuration, err = validate.ValidateDuration(
+        clientConfig.LootFlushInterval)
+    if err != nil {
+        return fmt.Errorf("improper loot_flush_interval - %w", err)
+    }
+
+    clientConfig.WordlistQuotaInt64, err = validate.ValidateQuota(clientConfig.WordlistQuota)
+    if err != nil {
+        return fmt.Errorf("improper wordlist_quota - %w", err)
+    }
+
+    // If the wordlist quota is too small to ever receive a wordlist of the max file size
+    if clientConfig.WordlistQuotaInt64 > 0 &&
+       clientConfig.WordlistQuotaInt64 < clientConfig.MaxFileSizeInt64 {
+        return fmt.Errorf("wordlist_quota must be at least the max_file_size")
+    }
+
+    // The systemd unit expects the client to drop its privileges after setup
+    if clientConfig.SystemdConfinement && !clientConfig.Hardening {
+        return fmt.Errorf("systemd_confinement requires hardening")
+    }
+
+    // If the workload is set but not in supported profiles, unset leaves it to tuning
+    if clientConfig.Workload != "" && !validate.ValidateWorkload(clientConfig.Workload) {
         return fmt.Errorf("improper workload specified")
     }
 
+    // Iterate through the extra hashcat args ensuring each is in the allow-list
+    for _, arg := range clientConfig.ExtraHashcatArgs {
+        err = hashcat.ValidateExtraArg(arg)
+        if err != nil {
+            return fmt.Errorf("improper extra_hashcat_args - %w", err)
+        }
+    }
+
+    // Iterate through the custom tuning profiles validating each
+    for hashType, tuning := range clientConfig.TuningProfiles {
+        err = validateTuning(hashType, tuning)
+        if err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+
+// Ensure the custom tuning profile of a hash type has supported values.
+//
+// @Parameters
+// - hashType:  The hash type the profile is registered for
+// - tuning:  The custom tuning profile
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func validateTuning(hashType string, tuning hashcat.Tuning) error {
+    // If the profile is not registered for a supported hash type
+    if !validate.ValidateHashType(hashType) {
+        return fmt.Errorf("improper tuning_profiles hash type %s", hashType)
+    }
+
+    kernelValues := []string{tuning.KernelAccel, tuning.KernelLoops, tuning.KernelThreads}
+    // Iterate through the kernel values of the profile
+    for _, value := range kernelValues {
+        // Ensure the value is a single positive number, hashcat applies it to every device
+        if !validate.ValidateNumberList(value) || strings.Contains(value, ",") {
+            return fmt.Errorf("improper kernel values in tuning_profiles %s", hashType)
+        }
+    }
+
+    // If the workload is set but not in supported profiles
+    if tuning.Workload != "" && !validate.ValidateWorkload(tuning.Workload) {
+        return fmt.Errorf("improper workload in tuning_profiles %s", hashType)
+    }
+
+    return nil
+}
+
+
+// Resolves the tuning of each hash type cracked in the run from the built-in and custom
+// profiles, leaving out the values set explicitly in the client config.
+//
+// @Parameters
+// - config:  The validated AppConfig with its hash files expanded
+//
+// @Returns
+// - The tuning applied to each hash type, empty when tuning is disabled
+//
+func resolveTunings(config *AppConfig) map[string]hashcat.Tuning {
+    tunings := map[string]hashcat.Tuning{}
+
+    // If automatic tuning is disabled
+    if config.ClientConfig.DisableTuning {
+        return tunings
+    }
+
+    explicit := hashcat.Tuning{
+        KernelAccel:   config.ClientConfig.KernelAccel,
+        KernelLoops:   config.ClientConfig.KernelLoops,
+        KernelThreads: config.ClientConfig.KernelThreads,
+        Workload:      config.ClientConfig.Workload,
+    }
+
+    // Iterate through the hash files resolving the profile of their hash types
+    for _, hashInput := range config.LocalConfig.HashInputs {
+        tuning, exists := hashcat.ResolveTuning(hashInput.HashType,
+                                                config.ClientConfig.TuningProfiles)
+        if exists {
+            tunings[hashInput.HashType] = tuning.Override(explicit)
+        }
+    }
+
+    return tunings
+}
+
+
+// Ensure the hash files and ruleset sent to each client fit within the client dir
+// quotas, so a run is rejected before launch instead of failing on every client.
+//
+// @Parameters
+// - config:  The validated AppConfig with its hash files expanded
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func validateQuotas(config *AppConfig) error {
+    // If the hash files dir has a quota
+    if config.ClientConfig.HashQuotaInt64 > 0 {
+        var hashesSize int64
+        // Iterate through the hash files summing their sizes
+        for _, hashInput := range config.LocalConfig.HashInputs {
+            info, err := os.Stat(hashInput.Path)
+            if err != nil {
+                return err
+            }
+
+            hashesSize += info.Size()
+        }
+
+        // If the hash file is split, each client only receives its shard
+        if config.LocalConfig.SplitHashFile && config.LocalConfig.NumberInstances > 0 {
+            hashesSize /= int64(config.LocalConfig.NumberInstances)
+        }
+
+        if hashesSize > config.ClientConfig.HashQuotaInt64 {
+            return fmt.Errorf("hash files of %d bytes exceed the hash_quota", hashesSize)
+        }
+    }
+
+    // If rulesets are in use and the rulesets dir has a quota
+    if len(config.LocalConfig.RulesetInputs) > 0 && config.ClientConfig.RulesetQuotaInt64 > 0 {
+        var rulesetsSize int64
+
+        // Iterate through the rulesets summing their sizes
+        for _, rulesetPath := range config.LocalConfig.RulesetInputs {
+            info, err := os.Stat(rulesetPath)
+            if err != nil {
+                return err
+            }
+
+            rulesetsSize += info.Size()
+        }
+
+        if rulesetsSize > config.ClientConfig.RulesetQuotaInt64 {
+            return fmt.Errorf("rulesets of %d bytes exceed the ruleset_quota", rulesetsSize)
+        }
+    }
+
     return nil
 }
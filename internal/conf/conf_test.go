@@ -2,13 +2,16 @@ package conf_test
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/ngimb64/Kloud-Kraken/internal/conf"
 	"github.com/ngimb64/Kloud-Kraken/internal/globals"
 	"github.com/ngimb64/Kloud-Kraken/pkg/data"
+	"github.com/ngimb64/Kloud-Kraken/pkg/hashcat"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -51,6 +54,16 @@ func TestLoadConfig(t *testing.T) {
         file.Close()
     }
 
+    hookPath := filepath.Join(testDir, "pre-hook.sh")
+    err = os.WriteFile(hookPath, []byte("echo pre hook\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    priorPath := filepath.Join(testDir, "prior.potfile")
+    err = os.WriteFile(priorPath, []byte("8846f7eaee8fb117ad06bdd830b7586c:password\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
 
     // TODO:  add security_group_ids, security_groups, and subnet_id
 
@@ -59,19 +72,108 @@ func TestLoadConfig(t *testing.T) {
     testData := fmt.Sprintf(`
 local_config:
   account_id: "123456789123"
+  admin_socket: "%s"
+  allowed_client_cidrs:
+    - "10.0.0.0/16"
+  ami: "ami-0eb94e3d16a6eea5f"
+  ami_ssm_parameter: ""
+  archive_client_dirs: true
+  audit_cloudwatch: false
+  audit_log: "audit.jsonl"
+  brain_host: ""
+  brain_password: "brain-password"
+  brain_port: 13743
+  brain_server: true
   bucket_name: "test-bucket"
+  budget_limit: 150.0
+  budget_warning: 80.0
+  cert_lifetime: "24h"
+  cert_rotation: "6h"
+  client_auto_update: true
+  client_binary_expiration_days: 3
+  client_os: "linux"
+  client_role_arn: "arn:aws:iam::123456789012:role/platform/KrakenClient"
+  connection_token: true
+  control_plane: "tls"
+  disable_compression: true
+  disable_tui: true
+  downscale_idle: true
+  ebs_fallback: true
+  ebs_volume_size: 250
+  expected_runtime: "2h"
+  hashcat_release: "v6.2.6"
   hash_file_path: "%s"
+  hourly_price: 32.77
+  iam_permissions_boundary: "arn:aws:iam::123456789012:policy/KrakenBoundary"
   iam_username: "doug"
+  idle_timeout: "5m"
   instance_type: "p4d.24xlarge"
+  ip_discovery_endpoints:
+    - "https://checkip.amazonaws.com"
   listener_port: 6969
   load_dir: "%s"
+  local_clients: true
   local_testing: true
   log_path: "KloudKraken.log"
+  log_streaming: true
+  max_cost: 500.0
   max_merging_size: "50MB"
+  max_runtime: "12h"
   max_size_range: 25.0
+  metrics_port: 9100
+  metrics_tls: true
+  normalize_max_length: 64
+  normalize_wordlists: true
+  notifications:
+    - type: "slack"
+      url: "https://hooks.slack.com/services/T0/B0/x"
+      events:
+        - "first_crack"
+        - "run_complete"
+    - type: "sns"
+      topic_arn: "arn:aws:sns:us-east-1:123456789123:kloud-kraken"
   number_instances: 3
+  parallel_connections: 4
+  parallel_min_size: "1GB"
+  password_policy: "min_length=8 min_classes=3"
+  password_policy_regex: "^[^ ]+$"
+  peer_sharing: true
+  pipelined_merge: false
+  plaintext_transfers: true
+  preprocess_stages:
+    - "stats"
+    - "frequency_sort"
+  prior_results:
+    - "%s"
+  priority_file: ""
+  public_ip_ttl: "1h"
+  public_ips:
+    - "203.0.113.7"
+  range_assignment: true
   region: "us-east-1"
+  relay_address: ""
+  relay_client_address: ""
+  relay_fingerprint: ""
+  relay_token: ""
+  results_bucket: "test-results"
+  results_dir: "./results"
+  results_expiration_days: 30
+  ruleset_pairings:
+    - wordlists: "rockyou*"
+      rulesets:
+        - "ruleset"
+    - wordlists: "names"
+      rulesets: []
   ruleset_path: "%s"
+  rulesets: []
+  run_name: "acme-q3"
+  run_tags:
+    Client: "Acme Corp"
+    Ticket: "SEC-42"
+  s3_block_public_access: true
+  s3_kms_key_id: "1234abcd-12ab-34cd-56ef-1234567890ab"
+  s3_restrict_to_roles: true
+  schedule_strategy: "hit_rate"
   security_group_ids:
     - "sg-01234567"
     - "sg-0a1b2c3d4e5f6a7b8"
@@ -79,25 +181,73 @@ local_config:
   security_groups:
     - "my-security-group"
     - "web.server@frontend"
+  server_role_arn: "arn:aws:iam::123456789012:role/KrakenServer"
+  split_hash_file: true
+  ssm_sessions: true
+  stun_servers:
+    - "stun.l.google.com:19302"
   subnet_id: "subnet-0a1b2c3d4e5f6a7b8"
+  summary_export:
+    - "json"
+    - "markdown"
+  user_data_post_hook: ""
+  user_data_pre_hook: "%s"
+  verify_hashes: "reject"
+  web_ui_address: "0.0.0.0"
+  web_ui_port: 8443
+  web_ui_tls: true
+  web_ui_token: "0123456789abcdef"
+  work_steal_min_size: "256MB"
+  work_stealing: false
 
 
 client_config:
   apply_optimization: true
+  backend_devices: "1,2"
+  candidate_generator: ""
   char_set1: "charset1"
   char_set2: "charset2"
   char_set3: "charset3"
   char_set4: "charset4"
   cracking_mode: "3"
+  device_types: "2"
+  disable_tuning: false
+  extra_hashcat_args: ["--bitmap-max=24", "--hwmon-disable"]
+  gpu_monitor_interval: "30s"
+  gpu_temp_limit: 85
+  hardening: false
+  hardening_user: "kraken"
+  hashcat_jobs: 2
+  hashcat_path: "/opt/hashcat/hashcat"
   hash_mask: "?u?l?l?l?l?l?l?l?d"
+  hash_quota: "1GB"
   hash_type: "1000"
+  job_timeout: "6h"
+  kernel_accel: "64"
+  kernel_loops: ""
+  kernel_threads: "256"
+  keyspace_chunks: 32
   log_mode: "local"
   log_path: "KloudKraken.log"
+  loot_flush_cracks: 500
+  loot_flush_interval: "10m"
   max_file_size: "100MB"
   max_transfers: 2
   region: "us-west-1"
+  reserved_space: "5%%"
+  ruleset_quota: "500MB"
+  scrub_on_completion: true
+  self_terminate: true
+  stream_wordlists: false
+  systemd_confinement: false
+  tuning_profiles:
+    "3200":
+      kernel_loops: "16"
+      pure_kernel: true
   workload: "4"
-`, testFiles[0], testDir, testFiles[1])
+  wordlist_quota: "200GB"
+`, filepath.Join(testDir, "admin.sock"), testFiles[0], testDir, priorPath, testFiles[1],
+   hookPath)
     // Writing the YAML string to a file
     err = os.WriteFile(yamlPath, []byte(testData), 0644)
     // Ensure the error is nil meaning successful operation
@@ -108,43 +258,180 @@ client_config:
 
     // Validate local config fields to original data
     assert.Equal("123456789123", config.LocalConfig.AccountId)
+    assert.Equal(filepath.Join(testDir, "admin.sock"), config.LocalConfig.AdminSocket)
+    assert.Equal([]string{"10.0.0.0/16"}, config.LocalConfig.AllowedClientCidrs)
+    // Ensure the allowed client ranges are parsed
+    assert.Len(config.LocalConfig.AllowedClientNets, 1)
+    assert.True(config.LocalConfig.AllowedClientNets[0].Contains(net.ParseIP("10.0.4.20")))
+    assert.Equal("ami-0eb94e3d16a6eea5f", config.LocalConfig.Ami)
+    assert.Equal("", config.LocalConfig.AmiSsmParameter)
+    assert.True(config.LocalConfig.ArchiveClientDirs)
+    assert.False(config.LocalConfig.AuditCloudwatch)
+    assert.Equal("audit.jsonl", config.LocalConfig.AuditLog)
+    assert.Equal("", config.LocalConfig.BrainHost)
+    assert.Equal("brain-password", config.LocalConfig.BrainPassword)
+    assert.Equal(13743, config.LocalConfig.BrainPort)
+    assert.True(config.LocalConfig.BrainServer)
     assert.Equal("test-bucket", config.LocalConfig.BucketName)
+    assert.Equal(150.0, config.LocalConfig.BudgetLimit)
+    assert.Equal(80.0, config.LocalConfig.BudgetWarning)
+    assert.Equal("24h", config.LocalConfig.CertLifetime)
+    assert.Equal(24 * time.Hour, config.LocalConfig.CertLifetimeDuration)
+    assert.Equal("6h", config.LocalConfig.CertRotation)
+    assert.Equal(6 * time.Hour, config.LocalConfig.CertRotationDuration)
+    assert.True(config.LocalConfig.ClientAutoUpdate)
+    assert.Equal(3, config.LocalConfig.ClientBinaryExpiration)
+    // Ensure the instance profile defaults to the name of the existing client role
+    assert.Equal("KrakenClient", config.LocalConfig.ClientInstanceProfile)
+    assert.Equal("linux", config.LocalConfig.ClientOs)
+    assert.Equal("arn:aws:iam::123456789012:role/platform/KrakenClient",
+                 config.LocalConfig.ClientRoleArn)
+    assert.True(config.LocalConfig.ConnectionToken)
+    assert.Equal("tls", config.LocalConfig.ControlPlane)
+    assert.True(config.LocalConfig.DisableCompression)
+    assert.True(config.LocalConfig.DisableTui)
+    assert.True(config.LocalConfig.DownscaleIdle)
+    assert.True(config.LocalConfig.EbsFallback)
+    assert.Equal(250, config.LocalConfig.EbsVolumeSize)
+    assert.Equal("2h", config.LocalConfig.ExpectedRuntime)
+    assert.Equal(2 * time.Hour, config.LocalConfig.ExpectedRuntimeDuration)
+    assert.Equal("v6.2.6", config.LocalConfig.HashcatRelease)
     assert.Equal(testFiles[0], config.LocalConfig.HashFilePath)
+    assert.Equal(32.77, config.LocalConfig.HourlyPrice)
+    assert.Equal("arn:aws:iam::123456789012:policy/KrakenBoundary",
+                 config.LocalConfig.IamPermissionsBoundary)
     assert.Equal("doug", config.LocalConfig.IamUsername)
+    assert.Equal("5m", config.LocalConfig.IdleTimeout)
+    assert.Equal(5 * time.Minute, config.LocalConfig.IdleTimeoutDuration)
     assert.Equal("p4d.24xlarge", config.LocalConfig.InstanceType)
+    assert.Equal([]string{"https://checkip.amazonaws.com"},
+                 config.LocalConfig.IpDiscoveryEndpoints)
     assert.Equal(6969, config.LocalConfig.ListenerPort)
     assert.Equal(testDir, config.LocalConfig.LoadDir)
+    assert.True(config.LocalConfig.LocalClients)
     assert.True(config.LocalConfig.LocalTesting)
     assert.Equal("KloudKraken.log", config.LocalConfig.LogPath)
+    assert.True(config.LocalConfig.LogStreaming)
+    assert.Equal(500.0, config.LocalConfig.MaxCost)
     assert.Equal("50MB", config.LocalConfig.MaxMergingSize)
     assert.Equal(int64(50 * globals.MB), config.LocalConfig.MaxMergingSizeInt64)
+    assert.Equal("12h", config.LocalConfig.MaxRuntime)
+    assert.Equal(12 * time.Hour, config.LocalConfig.MaxRuntimeDuration)
     assert.Equal(25.0, config.LocalConfig.MaxSizeRange)
+    assert.Equal(9100, config.LocalConfig.MetricsPort)
+    assert.True(config.LocalConfig.MetricsTls)
+    assert.Equal(64, config.LocalConfig.NormalizeMaxLength)
+    assert.True(config.LocalConfig.NormalizeWordlists)
+    assert.Equal(2, len(config.LocalConfig.Notifications))
+    assert.Equal([]string{"first_crack", "run_complete"},
+                 config.LocalConfig.Notifications[0].Events)
+    assert.Equal("arn:aws:sns:us-east-1:123456789123:kloud-kraken",
+                 config.LocalConfig.Notifications[1].TopicArn)
     assert.Equal(3, config.LocalConfig.NumberInstances)
+    assert.Equal(4, config.LocalConfig.ParallelConnections)
+    assert.Equal(int64(globals.GB), config.LocalConfig.ParallelMinSizeInt64)
+    assert.Equal(8, config.LocalConfig.PasswordPolicyFilter.MinLength)
+    assert.Equal(3, config.LocalConfig.PasswordPolicyFilter.MinClasses)
+    assert.Equal("^[^ ]+$", config.LocalConfig.PasswordPolicyRegex)
+    assert.True(config.LocalConfig.PeerSharing)
+    assert.False(config.LocalConfig.PipelinedMerge)
+    assert.True(config.LocalConfig.PlaintextTransfers)
+    assert.Equal([]string{"stats", "frequency_sort"}, config.LocalConfig.PreprocessStages)
+    assert.Equal([]string{priorPath}, config.LocalConfig.PriorResults)
+    assert.Equal("", config.LocalConfig.PriorityFile)
+    assert.Equal(time.Hour, config.LocalConfig.PublicIpTtlDuration)
+    assert.Equal([]string{"203.0.113.7"}, config.LocalConfig.PublicIps)
+    assert.True(config.LocalConfig.RangeAssignment)
     assert.Equal("us-east-1", config.LocalConfig.Region)
+    assert.Equal("", config.LocalConfig.RelayAddress)
+    assert.Equal("", config.LocalConfig.RelayClientAddress)
+    assert.Equal("", config.LocalConfig.RelayToken)
+    assert.Equal("test-results", config.LocalConfig.ResultsBucket)
+    assert.Equal("results", config.LocalConfig.ResultsDir)
+    assert.Equal(30, config.LocalConfig.ResultsExpirationDays)
+    assert.Equal([]string{testFiles[1]}, config.LocalConfig.RulesetInputs)
+    assert.Equal(2, len(config.LocalConfig.RulesetPairings))
+    assert.Equal([]string{"ruleset"}, config.LocalConfig.RulesetPairings[0].Rulesets)
     assert.Equal(testFiles[1], config.LocalConfig.RulesetPath)
+    assert.Equal(0, len(config.LocalConfig.Rulesets))
+    assert.Equal("acme-q3", config.LocalConfig.RunName)
+    assert.Equal(map[string]string{"Client": "Acme Corp", "Ticket": "SEC-42"},
+                 config.LocalConfig.RunTags)
+    assert.True(config.LocalConfig.S3BlockPublicAccess)
+    assert.Equal("1234abcd-12ab-34cd-56ef-1234567890ab", config.LocalConfig.S3KmsKeyId)
+    assert.True(config.LocalConfig.S3RestrictToRoles)
+    assert.Equal("hit_rate", config.LocalConfig.ScheduleStrategy)
     assert.Equal(3, len(config.LocalConfig.SecurityGroupIds))
     assert.Equal(2, len(config.LocalConfig.SecurityGroups))
+    assert.Equal("arn:aws:iam::123456789012:role/KrakenServer", config.LocalConfig.ServerRoleArn)
+    assert.True(config.LocalConfig.SplitHashFile)
+    assert.True(config.LocalConfig.SsmSessions)
+    assert.Equal([]string{"stun.l.google.com:19302"}, config.LocalConfig.StunServers)
     assert.Equal("subnet-0a1b2c3d4e5f6a7b8", config.LocalConfig.SubnetId)
+    assert.Equal([]string{"json", "markdown"}, config.LocalConfig.SummaryExport)
+    assert.Equal("", config.LocalConfig.UserDataPostHook)
+    assert.Equal(hookPath, config.LocalConfig.UserDataPreHook)
+    assert.Equal("reject", config.LocalConfig.VerifyHashes)
+    assert.Equal("0.0.0.0", config.LocalConfig.WebUiAddress)
+    assert.Equal(8443, config.LocalConfig.WebUiPort)
+    assert.True(config.LocalConfig.WebUiTls)
+    assert.Equal("0123456789abcdef", config.LocalConfig.WebUiToken)
+    assert.Equal("256MB", config.LocalConfig.WorkStealMinSize)
+    assert.False(config.LocalConfig.WorkStealing)
 
     // Validate client config fields to original data
     assert.True(config.ClientConfig.ApplyOptimization)
+    assert.Equal("1,2", config.ClientConfig.BackendDevices)
+    assert.Equal("", config.ClientConfig.CandidateGenerator)
     assert.Equal("charset1", config.ClientConfig.CharSet1)
     assert.Equal("charset2", config.ClientConfig.CharSet2)
     assert.Equal("charset3", config.ClientConfig.CharSet3)
     assert.Equal("charset4", config.ClientConfig.CharSet4)
     assert.Equal("3", config.ClientConfig.CrackingMode)
+    assert.Equal("2", config.ClientConfig.DeviceTypes)
+    assert.Equal([]string{"--bitmap-max=24", "--hwmon-disable"},
+                 config.ClientConfig.ExtraHashcatArgs)
+    assert.Equal("30s", config.ClientConfig.GpuMonitorInterval)
+    assert.Equal(30 * time.Second, config.ClientConfig.GpuMonitorIntervalDuration)
+    assert.Equal(85, config.ClientConfig.GpuTempLimit)
+    assert.False(config.ClientConfig.Hardening)
+    assert.Equal("kraken", config.ClientConfig.HardeningUser)
+    assert.Equal(2, config.ClientConfig.HashcatJobs)
+    assert.Equal("/opt/hashcat/hashcat", config.ClientConfig.HashcatPath)
     assert.Equal("?u?l?l?l?l?l?l?l?d", config.ClientConfig.HashMask)
+    assert.Equal(int64(1 * globals.GB), config.ClientConfig.HashQuotaInt64)
     assert.Equal("1000", config.ClientConfig.HashType)
+    assert.Equal("6h", config.ClientConfig.JobTimeout)
+    assert.Equal(6 * time.Hour, config.ClientConfig.JobTimeoutDuration)
+    assert.Equal("64", config.ClientConfig.KernelAccel)
+    assert.Equal("", config.ClientConfig.KernelLoops)
+    assert.Equal("256", config.ClientConfig.KernelThreads)
+    assert.Equal(32, config.ClientConfig.KeyspaceChunks)
     assert.Equal("local", config.ClientConfig.LogMode)
     assert.Equal("KloudKraken.log", config.ClientConfig.LogPath)
+    assert.Equal(500, config.ClientConfig.LootFlushCracks)
+    assert.Equal("10m", config.ClientConfig.LootFlushInterval)
+    assert.Equal(10 * time.Minute, config.ClientConfig.LootFlushIntervalDuration)
     assert.Equal("100MB", config.ClientConfig.MaxFileSize)
     assert.Equal(int64(100 * globals.MB), config.ClientConfig.MaxFileSizeInt64)
     assert.Equal(int32(2), config.ClientConfig.MaxTransfers)
     assert.Equal("us-west-1", config.ClientConfig.Region)
+    assert.Equal("5%", config.ClientConfig.ReservedSpace)
+    assert.Equal(int64(500 * globals.MB), config.ClientConfig.RulesetQuotaInt64)
+    assert.True(config.ClientConfig.ScrubOnCompletion)
+    assert.True(config.ClientConfig.SelfTerminate)
+    assert.False(config.ClientConfig.StreamWordlists)
+    assert.False(config.ClientConfig.SystemdConfinement)
+    assert.Equal(hashcat.Tuning{KernelLoops: "16", PureKernel: true},
+                 config.ClientConfig.TuningProfiles["3200"])
+    // Ensure the explicit workload and kernel values override the NTLM profile
+    assert.Equal(map[string]hashcat.Tuning{"1000": {KernelLoops: "1024"}},
+                 config.ClientConfig.Tunings)
     assert.Equal("4", config.ClientConfig.Workload)
+    assert.Equal(int64(200 * globals.GB), config.ClientConfig.WordlistQuotaInt64)
 
-    // Append the yaml data file to test file for deletion
-    testFiles = append(testFiles, yamlPath)
+    // Append the hook script, prior results, and yaml data file to test files for deletion
+    testFiles = append(testFiles, hookPath, priorPath, yamlPath)
 
     // Iterate through test files to be deleted
     for _, file := range testFiles {
@@ -159,3 +446,84 @@ client_config:
     // Ensure the error is nil meaning successful operation
     assert.Equal(nil, err)
 }
+
+
+func TestLoadConfigHashFiles(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    testDir := t.TempDir()
+    hashDir := filepath.Join(testDir, "hashes")
+    // Create the dir of hash files listed as a single entry
+    err := os.Mkdir(hashDir, os.ModePerm)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    testFiles := map[string]string{
+        filepath.Join(testDir, "ntlm.txt"):     "b4b9b02e6f09a9bd760f388b67351e2b\n",
+        filepath.Join(hashDir, "md5_a.txt"):    "5f4dcc3b5aa765d61d8327deb882cf99\n",
+        filepath.Join(hashDir, "md5_b.txt"):    "e10adc3949ba59abbe56e057f20f883e\n",
+        filepath.Join(testDir, "wordlist.txt"): "password\n",
+    }
+
+    // Iterate through the test files writing each
+    for filePath, fileData := range testFiles {
+        err = os.WriteFile(filePath, []byte(fileData), 0644)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+    }
+
+    yamlPath := filepath.Join(testDir, "testdata.yml")
+    testData := fmt.Sprintf(`
+local_config:
+  account_id: "123456789123"
+  hash_files:
+    - path: "%s"
+    - path: "%s"
+      hash_type: "0"
+  iam_username: "doug"
+  instance_type: "p4d.24xlarge"
+  listener_port: 6969
+  load_dir: "%s"
+  log_path: "KloudKraken.log"
+  max_merging_size: "50MB"
+  max_size_range: 25.0
+  number_instances: 1
+  region: "us-east-1"
+  relay_address: "203.0.113.10:7000"
+  relay_client_address: "10.0.1.5:7001"
+  relay_fingerprint: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+  relay_token: "0123456789abcdef"
+
+client_config:
+  candidate_generator: "prince"
+  cracking_mode: "0"
+  hash_type: "1000"
+  log_mode: "local"
+  log_path: "KloudKraken.log"
+  max_file_size: "100MB"
+  max_transfers: 2
+  region: "us-east-1"
+  workload: "4"
+`, filepath.Join(testDir, "ntlm.txt"), hashDir, testDir)
+    // Writing the YAML string to a file
+    err = os.WriteFile(yamlPath, []byte(testData), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    config := conf.LoadConfig(yamlPath)
+
+    // Ensure the candidate generator is loaded with straight mode
+    assert.Equal("prince", config.ClientConfig.CandidateGenerator)
+    // Ensure the dir is expanded and unlisted hash types use the client hash type
+    assert.Equal("", config.LocalConfig.HashFilePath)
+    assert.Equal([]conf.HashFile{{HashType: "1000", Path: filepath.Join(testDir, "ntlm.txt")},
+                                 {HashType: "0", Path: filepath.Join(hashDir, "md5_a.txt")},
+                                 {HashType: "0", Path: filepath.Join(hashDir, "md5_b.txt")}},
+                 config.LocalConfig.HashInputs)
+    // Ensure the address clients connect to the relay on is parsed
+    assert.Equal("10.0.1.5", config.LocalConfig.RelayClientHost)
+    assert.Equal(7001, config.LocalConfig.RelayClientPort)
+    assert.Equal("9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+                 config.LocalConfig.RelayFingerprint)
+}
@@ -0,0 +1,301 @@
+package conf
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/harden"
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variable selecting the profile applied over the YAML file
+const EnvProfile = "KK_PROFILE"
+// Sections of the YAML file the layers are merged into
+const SectionClient = "client_config"
+const SectionLocal = "local_config"
+
+// Package level variables
+var Defaults = map[string]map[string]any{  // Built-in values applied beneath the YAML file
+    SectionLocal: {
+        "brain_port":        13743,
+        "control_plane":     "tls",
+        "ebs_volume_size":   100,
+        "listener_port":     6969,
+        "number_instances":  1,
+        "parallel_min_size": "1GB",
+        "region":            "us-east-1",
+        "web_ui_address":    "127.0.0.1",
+    },
+    SectionClient: {
+        "cracking_mode":  "0",
+        "hardening_user": harden.DefaultUser,
+        "hash_type":      "1000",
+        "log_mode":       "local",
+        "max_transfers":  3,
+        "region":         "us-east-1",
+        "reserved_space": "20GB",
+    },
+}
+var EnvPrefixes = map[string]string{  // Prefix of the variables overriding each section
+    SectionClient: "KK_CLIENT_",
+    SectionLocal:  "KK_LOCAL_",
+}
+
+
+// Overrides are the layers applied over the YAML file from the command line
+type Overrides struct {
+    Profile string    // Profile applied in place of the one selected by env or file
+    Set     []string  // Keys set in section.key=value format
+}
+
+// SetFlag collects repeated -set flags in section.key=value format
+type SetFlag []string
+
+// layeredFile is the YAML file with its profile sections before the layers are merged
+type layeredFile struct {
+    ClientConfig map[string]any                       `yaml:"client_config"`
+    LocalConfig  map[string]any                       `yaml:"local_config"`
+    Profile      string                               `yaml:"profile"`
+    Profiles     map[string]map[string]map[string]any `yaml:"profiles"`
+}
+
+
+// Formats the effective config as YAML, secrets are masked so the output can be shared.
+//
+// @Parameters
+// - config:  The loaded config
+//
+// @Returns
+// - The YAML of the effective config
+// - Error if it occurs, otherwise nil on success
+//
+func EffectiveYaml(config *AppConfig) ([]byte, error) {
+    masked := *config
+
+    // If a brain password is set, mask it
+    if masked.LocalConfig.BrainPassword != "" {
+        masked.LocalConfig.BrainPassword = "********"
+    }
+
+    // If a relay token is set, mask it
+    if masked.LocalConfig.RelayToken != "" {
+        masked.LocalConfig.RelayToken = "********"
+    }
+
+    // If a web UI token is set, mask it
+    if masked.LocalConfig.WebUiToken != "" {
+        masked.LocalConfig.WebUiToken = "********"
+    }
+
+    // Copy the sinks so masking their webhook URLs leaves the loaded config intact
+    masked.LocalConfig.Notifications = slices.Clone(config.LocalConfig.Notifications)
+    // Iterate through the notification sinks masking the webhook URLs holding tokens
+    for index := range masked.LocalConfig.Notifications {
+        if masked.LocalConfig.Notifications[index].Url != "" {
+            masked.LocalConfig.Notifications[index].Url = "********"
+        }
+    }
+
+    return yaml.Marshal(&masked)
+}
+
+
+// Records a repeated -set flag, satisfying the flag.Value interface.
+//
+// @Parameters
+// - value:  The section.key=value passed to the flag
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (setFlag *SetFlag) Set(value string) error {
+    *setFlag = append(*setFlag, value)
+    return nil
+}
+
+
+// Formats the recorded -set flags, satisfying the flag.Value interface.
+//
+// @Returns
+// - The recorded flags separated by commas
+//
+func (setFlag *SetFlag) String() string {
+    return strings.Join(*setFlag, ",")
+}
+
+
+// Merges the config layers in order of precedence, each overriding the last: the built-in
+// defaults, the YAML file, the selected profile, KK_LOCAL_<KEY> and KK_CLIENT_<KEY>
+// environment variables, then the keys set on the command line.
+//
+// @Parameters
+// - fileData:  The contents of the YAML file
+// - overrides:  The profile and keys passed on the command line
+//
+// @Returns
+// - The merged YAML ready to be decoded into the AppConfig
+// - Error if it occurs, otherwise nil on success
+//
+func MergeLayers(fileData []byte, overrides Overrides) ([]byte, error) {
+    var file layeredFile
+
+    err := yaml.Unmarshal(fileData, &file)
+    if err != nil {
+        return nil, fmt.Errorf("could not decode YAML - %w", err)
+    }
+
+    merged := map[string]map[string]any{}
+    // Start from a copy of the defaults so they are never modified
+    for section, values := range Defaults {
+        merged[section] = maps.Clone(values)
+    }
+
+    maps.Copy(merged[SectionLocal], file.LocalConfig)
+    maps.Copy(merged[SectionClient], file.ClientConfig)
+
+    profile := overrides.Profile
+    // If no profile was passed on the command line, fall back to env then the file
+    if profile == "" {
+        profile = os.Getenv(EnvProfile)
+    }
+    if profile == "" {
+        profile = file.Profile
+    }
+
+    // If a profile was selected, apply its sections
+    if profile != "" {
+        sections, ok := file.Profiles[profile]
+        if !ok {
+            return nil, fmt.Errorf("profile %q is not defined in profiles", profile)
+        }
+
+        // Iterate through the sections of the profile
+        for section, values := range sections {
+            err = setKeys(merged, section, values)
+            if err != nil {
+                return nil, fmt.Errorf("profile %q - %w", profile, err)
+            }
+        }
+    }
+
+    // Iterate through the environment applying the variables of each section
+    for _, variable := range os.Environ() {
+        name, value, _ := strings.Cut(variable, "=")
+
+        for section, prefix := range EnvPrefixes {
+            // If the variable does not override this section
+            if !strings.HasPrefix(name, prefix) {
+                continue
+            }
+
+            key := strings.ToLower(strings.TrimPrefix(name, prefix))
+            err = setKeys(merged, section, map[string]any{key: parseValue(value)})
+            if err != nil {
+                return nil, fmt.Errorf("%s - %w", name, err)
+            }
+        }
+    }
+
+    // Iterate through the keys set on the command line
+    for _, assignment := range overrides.Set {
+        path, value, found := strings.Cut(assignment, "=")
+        section, key, dotted := strings.Cut(path, ".")
+        // If the assignment is not in section.key=value format
+        if !found || !dotted {
+            return nil, fmt.Errorf("%q is not in section.key=value format", assignment)
+        }
+
+        err = setKeys(merged, section, map[string]any{key: parseValue(value)})
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    return yaml.Marshal(merged)
+}
+
+
+// Parses an override value as YAML so numbers, booleans, and lists keep their types,
+// falling back to the raw string if it is not valid YAML.
+//
+// @Parameters
+// - value:  The raw override value
+//
+// @Returns
+// - The parsed value
+//
+func parseValue(value string) any {
+    var parsed any
+
+    // If the value is empty or not valid YAML, it is used as is
+    if value == "" || yaml.Unmarshal([]byte(value), &parsed) != nil || parsed == nil {
+        return value
+    }
+
+    return parsed
+}
+
+
+// Gets the YAML keys of a config section from its struct tags, skipping fields parsed
+// after loading.
+//
+// @Parameters
+// - section:  The struct of the config section
+//
+// @Returns
+// - The YAML keys of the section
+//
+func sectionKeys(section any) []string {
+    var keys []string
+    sectionType := reflect.TypeOf(section)
+
+    // Iterate through the fields of the section collecting their YAML keys
+    for index := range sectionType.NumField() {
+        key := sectionType.Field(index).Tag.Get("yaml")
+        if key != "" && key != "-" {
+            keys = append(keys, key)
+        }
+    }
+
+    return keys
+}
+
+
+// Sets the keys in the section of the merged layers, ensuring the section and keys
+// exist so typos in overrides are not silently ignored.
+//
+// @Parameters
+// - merged:  The merged layers
+// - section:  The section the keys are set in
+// - values:  The keys and their values
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func setKeys(merged map[string]map[string]any, section string, values map[string]any) error {
+    var keys []string
+
+    switch section {
+    case SectionLocal:
+        keys = sectionKeys(LocalConfig{})
+    case SectionClient:
+        keys = sectionKeys(ClientConfig{})
+    default:
+        return fmt.Errorf("unknown config section %q", section)
+    }
+
+    // Iterate through the keys ensuring each exists before setting it
+    for key, value := range values {
+        if !slices.Contains(keys, key) {
+            return fmt.Errorf("unknown config key %s.%s", section, key)
+        }
+
+        merged[section][key] = value
+    }
+
+    return nil
+}
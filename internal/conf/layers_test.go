@@ -0,0 +1,115 @@
+package conf_test
+
+import (
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/conf"
+	"github.com/ngimb64/Kloud-Kraken/pkg/notify"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+
+func TestMergeLayers(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    testData := []byte(`
+local_config:
+  instance_type: "p3.2xlarge"
+  number_instances: 2
+
+client_config:
+  max_transfers: 2
+  workload: "4"
+
+profiles:
+  max-power:
+    local_config:
+      instance_type: "p4d.24xlarge"
+      number_instances: 8
+    client_config:
+      max_transfers: 4
+`)
+    // Override the workload and number of instances through the environment
+    t.Setenv("KK_CLIENT_WORKLOAD", "2")
+    t.Setenv("KK_LOCAL_NUMBER_INSTANCES", "6")
+
+    merged, err := conf.MergeLayers(testData, conf.Overrides{
+        Profile: "max-power",
+        Set:     []string{"local_config.number_instances=4", "client_config.hash_type=0"},
+    })
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    var config conf.AppConfig
+    err = yaml.Unmarshal(merged, &config)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure unset keys fall back to the built-in defaults
+    assert.Equal(100, config.LocalConfig.EbsVolumeSize)
+    assert.Equal("local", config.ClientConfig.LogMode)
+    // Ensure the profile overrides the file
+    assert.Equal("p4d.24xlarge", config.LocalConfig.InstanceType)
+    assert.Equal(int32(4), config.ClientConfig.MaxTransfers)
+    // Ensure the environment overrides the file
+    assert.Equal("2", config.ClientConfig.Workload)
+    // Ensure the flags override the environment
+    assert.Equal(4, config.LocalConfig.NumberInstances)
+    assert.Equal("0", config.ClientConfig.HashType)
+}
+
+
+func TestMergeLayersErrors(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    testData := []byte(`
+profile: "cheap"
+local_config:
+  number_instances: 2
+`)
+    falacies := []conf.Overrides{
+        {Set: []string{"local_config.number_instance=4"}},
+        {Set: []string{"remote_config.region=us-east-1"}},
+        {Set: []string{"local_config.region"}},
+        {Profile: "max-power"},
+    }
+
+    // Ensure a profile selected in the file must be defined
+    _, err := conf.MergeLayers(testData, conf.Overrides{})
+    assert.NotEqual(nil, err)
+
+    // Iterate through the improper overrides ensuring each is rejected
+    for _, overrides := range falacies {
+        _, err = conf.MergeLayers([]byte("profiles:\n  cheap: {}\n"), overrides)
+        assert.NotEqual(nil, err)
+    }
+}
+
+
+func TestEffectiveYaml(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    config := &conf.AppConfig{}
+    config.LocalConfig.WebUiToken = "web-token"
+    config.LocalConfig.Notifications = []notify.SinkConfig{
+        {Type: "slack", Url: "https://hooks.slack.com/services/T000/B000/secret"},
+        {Type: "sns", TopicArn: "arn:aws:sns:us-east-1:123456789012:run-events"},
+    }
+
+    output, err := conf.EffectiveYaml(config)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the secrets and webhook URLs are masked while the topic stays readable
+    assert.NotContains(string(output), "web-token")
+    assert.NotContains(string(output), "hooks.slack.com")
+    assert.Contains(string(output), "run-events")
+
+    // Ensure the loaded config keeps its webhook URL
+    assert.Equal("https://hooks.slack.com/services/T000/B000/secret",
+                 config.LocalConfig.Notifications[0].Url)
+}
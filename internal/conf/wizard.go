@@ -0,0 +1,313 @@
+package conf
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/validate"
+)
+
+// Package level variables
+var ReConfigEntry = regexp.MustCompile(`^(  )?([a-z0-9_]+):\s*(.*)$`)  // Key of a YAML line
+var ReInstructionDesc = regexp.MustCompile(`^"([^"]*)"`)  // Description of an instruction
+
+
+// InstanceCheck checks the instances can be launched, such as against the account quotas
+type InstanceCheck func(region string, instanceType string, count int) error
+
+// Question is a config key the init wizard asks for along with how the answer is validated
+type Question struct {
+    Default  string                                       // Answer used when left empty
+    Key      string                                       // Key of the config section
+    Prompt   string                                       // Text shown when asking
+    Section  string                                       // Section of the config
+    Validate func(answer string, answers map[string]string) error  // Checks the answer
+}
+
+
+// Gets the path of a key in section.key format, used to index the answers.
+//
+// @Parameters
+// - section:  The section of the config
+// - key:  The key of the section
+//
+// @Returns
+// - The key in section.key format
+//
+func keyPath(section string, key string) string {
+    return section + "." + key
+}
+
+
+// Wraps a validator returning a boolean into one returning an error.
+//
+// @Parameters
+// - name:  The name of the key reported when invalid
+// - valid:  The validator of the key
+//
+// @Returns
+// - The validator of the answer
+//
+func validBool(name string, valid func(string) bool) func(string, map[string]string) error {
+    return func(answer string, _ map[string]string) error {
+        if !valid(answer) {
+            return fmt.Errorf("invalid %s - %q", name, answer)
+        }
+
+        return nil
+    }
+}
+
+
+// Wraps a validator returning an error into one validating the answer.
+//
+// @Parameters
+// - valid:  The validator of the key
+//
+// @Returns
+// - The validator of the answer
+//
+func validErr(valid func(string) error) func(string, map[string]string) error {
+    return func(answer string, _ map[string]string) error {
+        return valid(answer)
+    }
+}
+
+
+// Gets the questions asked by the init wizard in order, each validated with the same
+// validators as the loaded config. The instance type and count are also checked live
+// when an instance check is passed in.
+//
+// @Parameters
+// - check:  Checks the instances can be launched, nil to skip the live check
+//
+// @Returns
+// - The questions of the wizard
+//
+func WizardQuestions(check InstanceCheck) []Question {
+    regionPath := keyPath(SectionLocal, "region")
+    typePath := keyPath(SectionLocal, "instance_type")
+
+    return []Question{
+        {Section: SectionLocal, Key: "region", Prompt: "AWS region", Default: "us-east-1",
+         Validate: validBool("region", validate.ValidateRegion)},
+        {Section: SectionLocal, Key: "account_id", Prompt: "AWS account ID",
+         Validate: validErr(validate.ValidateAccountId)},
+        {Section: SectionLocal, Key: "iam_username", Prompt: "IAM username",
+         Validate: validErr(validate.ValidateIamUsername)},
+        {Section: SectionLocal, Key: "bucket_name", Prompt: "S3 bucket name",
+         Validate: validErr(validate.ValidateBucketName)},
+        {Section: SectionLocal, Key: "instance_type", Prompt: "EC2 instance type",
+         Default: "g4dn.xlarge",
+         Validate: func(answer string, answers map[string]string) error {
+             if !validate.ValidateInstanceType(answer) {
+                 return fmt.Errorf("unsupported instance type - %q", answer)
+             }
+
+             // If live checks are enabled, ensure a single instance can be launched
+             if check != nil {
+                 return check(answers[regionPath], answer, 1)
+             }
+
+             return nil
+         }},
+        {Section: SectionLocal, Key: "number_instances", Prompt: "Number of instances",
+         Default: "1",
+         Validate: func(answer string, answers map[string]string) error {
+             count, err := strconv.Atoi(answer)
+             if err != nil || !validate.ValidateNumberInstances(count) {
+                 return fmt.Errorf("invalid number of instances - %q", answer)
+             }
+
+             // If live checks are enabled, ensure every instance fits in the quota
+             if check != nil {
+                 return check(answers[regionPath], answers[typePath], count)
+             }
+
+             return nil
+         }},
+        {Section: SectionLocal, Key: "load_dir", Prompt: "Wordlist load dir",
+         Validate: validErr(validate.ValidateLoadDir)},
+        {Section: SectionLocal, Key: "hash_file_path", Prompt: "Hash file path",
+         Validate: validErr(validate.ValidateHashFile)},
+        {Section: SectionClient, Key: "hash_type", Prompt: "Hashcat hash type",
+         Default: "1000", Validate: validBool("hash type", validate.ValidateHashType)},
+        {Section: SectionClient, Key: "cracking_mode", Prompt: "Hashcat cracking mode",
+         Default: "0", Validate: validBool("cracking mode", validate.ValidateCrackingMode)},
+        {Section: SectionClient, Key: "workload", Prompt: "Hashcat workload profile (1-4)",
+         Default: "3", Validate: validBool("workload", validate.ValidateWorkload)},
+    }
+}
+
+
+// Runs the init wizard, asking each question until a valid answer is given. The client
+// region follows the local region and local testing is disabled for the generated
+// config.
+//
+// @Parameters
+// - input:  Where the answers are read from
+// - output:  Where the questions are written to
+// - questions:  The questions to ask in order
+//
+// @Returns
+// - The answers in section.key format
+// - Error if it occurs, otherwise nil on success
+//
+func RunWizard(input io.Reader, output io.Writer, questions []Question) (map[string]string,
+                                                                        error) {
+    answers := map[string]string{}
+    scanner := bufio.NewScanner(input)
+
+    // Iterate through the questions asking each until the answer is valid
+    for _, question := range questions {
+        for {
+            // If the question has a default, show it as the answer left empty
+            if question.Default != "" {
+                fmt.Fprintf(output, "%s [%s]: ", question.Prompt, question.Default)
+            } else {
+                fmt.Fprintf(output, "%s: ", question.Prompt)
+            }
+
+            // If the input ended before every question was answered
+            if !scanner.Scan() {
+                if scanner.Err() != nil {
+                    return nil, scanner.Err()
+                }
+                return nil, errors.New("input ended before the wizard completed")
+            }
+
+            answer := strings.TrimSpace(scanner.Text())
+            if answer == "" {
+                answer = question.Default
+            }
+
+            err := question.Validate(answer, answers)
+            if err != nil {
+                fmt.Fprintf(output, "  %v\n", err)
+                continue
+            }
+
+            answers[keyPath(question.Section, question.Key)] = answer
+            break
+        }
+    }
+
+    answers[keyPath(SectionClient, "region")] = answers[keyPath(SectionLocal, "region")]
+    answers[keyPath(SectionLocal, "local_testing")] = "false"
+
+    return answers, nil
+}
+
+
+// Parses the descriptions of the config keys from the instructions.
+//
+// @Parameters
+// - instructions:  The instructions describing each key
+//
+// @Returns
+// - The descriptions of the keys in section.key format, top level keys by name
+//
+func parseInstructions(instructions []byte) map[string]string {
+    var section string
+    descriptions := map[string]string{}
+
+    // Iterate through the lines of the instructions collecting the descriptions
+    for _, line := range strings.Split(string(instructions), "\n") {
+        match := ReConfigEntry.FindStringSubmatch(line)
+        if match == nil {
+            continue
+        }
+
+        path := match[2]
+        // If the line is a top level key, it starts a new section
+        if match[1] == "" {
+            section = match[2]
+        } else {
+            path = keyPath(section, match[2])
+        }
+
+        // If the entry has a description
+        if desc := ReInstructionDesc.FindStringSubmatch(match[3]); desc != nil {
+            descriptions[path] = desc[1]
+        }
+    }
+
+    return descriptions
+}
+
+
+// Renders the answers into the template config, commenting every key with its
+// description from the instructions.
+//
+// @Parameters
+// - template:  The template config with every key
+// - instructions:  The instructions describing each key
+// - answers:  The answers of the wizard in section.key format
+//
+// @Returns
+// - The commented config
+// - Error if it occurs, otherwise nil on success
+//
+func RenderConfig(template []byte, instructions []byte, answers map[string]string) (
+                  []byte, error) {
+    var builder strings.Builder
+    var section string
+    descriptions := parseInstructions(instructions)
+    rendered := map[string]bool{}
+
+    builder.WriteString("# Generated by kloud-kraken init, the options of each key are " +
+                        "listed in config/instructions.yml\n")
+
+    // Iterate through the lines of the template rendering the answers
+    for _, line := range strings.Split(strings.TrimRight(string(template), "\n"), "\n") {
+        match := ReConfigEntry.FindStringSubmatch(line)
+        // If the line is not a key of a section or the top level
+        if match == nil || (match[1] != "" && section == "profiles") {
+            builder.WriteString(line + "\n")
+            continue
+        }
+
+        indent := match[1]
+        path := match[2]
+        // If the line is a top level key, it starts a new section
+        if indent == "" {
+            section = match[2]
+        } else {
+            path = keyPath(section, match[2])
+        }
+
+        // If the key has a description, comment the key with it
+        if desc, ok := descriptions[path]; ok {
+            builder.WriteString(indent + "# " + desc + "\n")
+        }
+
+        answer, ok := answers[path]
+        // If the key was not answered, keep the template value
+        if !ok {
+            builder.WriteString(line + "\n")
+            continue
+        }
+
+        // If the template value is quoted, quote the answer so it stays a string
+        if strings.HasPrefix(match[3], `"`) {
+            answer = strconv.Quote(answer)
+        }
+
+        builder.WriteString(indent + match[2] + ": " + answer + "\n")
+        rendered[path] = true
+    }
+
+    // Ensure every answer has a key in the template
+    for path := range answers {
+        if !rendered[path] {
+            return nil, fmt.Errorf("answered key %s is not in the template config", path)
+        }
+    }
+
+    return []byte(builder.String()), nil
+}
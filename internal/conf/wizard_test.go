@@ -0,0 +1,106 @@
+package conf_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/config"
+	"github.com/ngimb64/Kloud-Kraken/internal/conf"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+
+func TestRenderConfig(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    answers := map[string]string{
+        "client_config.hash_type":       "1000",
+        "local_config.instance_type":    "g4dn.xlarge",
+        "local_config.local_testing":    "false",
+        "local_config.number_instances": "2",
+    }
+
+    output, err := conf.RenderConfig(config.Template, config.Instructions, answers)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    var appConfig conf.AppConfig
+    err = yaml.Unmarshal(output, &appConfig)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the answers replaced the template values
+    assert.Equal("1000", appConfig.ClientConfig.HashType)
+    assert.Equal("g4dn.xlarge", appConfig.LocalConfig.InstanceType)
+    assert.Equal(false, appConfig.LocalConfig.LocalTesting)
+    assert.Equal(2, appConfig.LocalConfig.NumberInstances)
+    // Ensure the keys are commented with their descriptions
+    assert.True(strings.Contains(string(output),
+                                 "  # The AWS account ID where operations will occur\n"))
+
+    // Ensure answers without a key in the template are rejected
+    _, err = conf.RenderConfig(config.Template, config.Instructions,
+                               map[string]string{"local_config.missing": "1"})
+    assert.NotEqual(nil, err)
+}
+
+
+func TestRunWizard(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    loadDir := t.TempDir()
+    err := os.WriteFile(filepath.Join(loadDir, "wordlist.txt"), []byte("password\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    hashFile := filepath.Join(t.TempDir(), "hashes.txt")
+    err = os.WriteFile(hashFile, []byte("8846f7eaee8fb117ad06bdd830b7586c\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    var checked []int
+    // Record the checked counts, rejecting more than two instances
+    check := func(region string, instanceType string, count int) error {
+        checked = append(checked, count)
+        if count > 2 {
+            return errors.New("quota exceeded")
+        }
+        return nil
+    }
+
+    // Answer with an invalid region and a count over the quota, each asked again
+    input := strings.Join([]string{"mars-north-1", "", "123456789123", "test-user",
+                                   "test-bucket", "", "4", "2", loadDir, hashFile,
+                                   "", "", ""}, "\n") + "\n"
+    var output bytes.Buffer
+
+    answers, err := conf.RunWizard(strings.NewReader(input), &output,
+                                   conf.WizardQuestions(check))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the defaults are used for the empty answers
+    assert.Equal("us-east-1", answers["local_config.region"])
+    assert.Equal("us-east-1", answers["client_config.region"])
+    assert.Equal("g4dn.xlarge", answers["local_config.instance_type"])
+    assert.Equal("1000", answers["client_config.hash_type"])
+    // Ensure the count rejected by the check was asked again
+    assert.Equal("2", answers["local_config.number_instances"])
+    assert.Equal([]int{1, 4, 2}, checked)
+    assert.Equal("false", answers["local_config.local_testing"])
+    // Ensure the invalid answers were reported
+    assert.True(strings.Contains(output.String(), "invalid region"))
+    assert.True(strings.Contains(output.String(), "quota exceeded"))
+
+    // Ensure input ending before the wizard completes is an error
+    _, err = conf.RunWizard(strings.NewReader("us-east-1\n"), &output,
+                            conf.WizardQuestions(nil))
+    assert.NotEqual(nil, err)
+}
@@ -0,0 +1,111 @@
+//go:build e2e
+
+package e2e_test
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/e2e"
+	"github.com/ngimb64/Kloud-Kraken/pkg/eventstream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalRun(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // If hashcat is not installed, the clients have nothing to crack with
+    if _, err := exec.LookPath("hashcat"); err != nil {
+        t.Skip("hashcat is required on the PATH for the e2e run")
+    }
+
+    harness := e2e.New(t.TempDir())
+    // Build the binaries from the root of the module
+    err := harness.Build(filepath.Join("..", ".."))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    loadDir := filepath.Join(harness.Dir, "load")
+    err = os.MkdirAll(loadDir, 0755)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    plaintexts := []string{"hunter2", "kraken123"}
+    candidates := []string{"letmein", "hunter2", "qwerty", "kraken123", "dragon"}
+    // Write a tiny wordlist holding the plaintexts of the hashes
+    err = os.WriteFile(filepath.Join(loadDir, "wordlist.txt"),
+                       []byte(strings.Join(candidates, "\n") + "\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    var hashes []string
+    // Hash the plaintexts with MD5 since it cracks fastest
+    for _, plaintext := range plaintexts {
+        sum := md5.Sum([]byte(plaintext))
+        hashes = append(hashes, hex.EncodeToString(sum[:]))
+    }
+
+    hashPath := filepath.Join(harness.Dir, "hashes.txt")
+    err = os.WriteFile(hashPath, []byte(strings.Join(hashes, "\n") + "\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    configPath, err := harness.WriteConfig(map[string]string{
+        "client_config.hash_type":        "0",
+        "local_config.hash_file_path":    hashPath,
+        "local_config.load_dir":          loadDir,
+        "local_config.number_instances":  "2",
+    })
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10 * time.Minute)
+    defer cancel()
+
+    events, err := harness.Run(ctx, configPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the run completed and both clients reported their cracked hashes
+    assert.Equal(1, len(e2e.FindEvents(events, eventstream.RunComplete)))
+    assert.Equal(2, len(e2e.FindEvents(events, eventstream.ClientConnected)))
+
+    var loot strings.Builder
+    logs := 0
+    // Iterate through the persisted results collecting the loot and counting the logs,
+    // the files of each client are persisted under its own client dir
+    err = filepath.WalkDir(harness.ResultsDir, func(filePath string, entry os.DirEntry,
+                                                    err error) error {
+        if err != nil || entry.IsDir() {
+            return err
+        }
+
+        switch {
+        case strings.HasSuffix(entry.Name(), "loot.txt"):
+            data, err := os.ReadFile(filePath)
+            // Ensure the error is nil meaning successful operation
+            assert.Equal(nil, err)
+            loot.Write(data)
+        case strings.HasSuffix(entry.Name(), "KloudKraken.log"):
+            logs += 1
+        }
+
+        return nil
+    })
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the log of each client arrived
+    assert.Equal(2, logs)
+    // Ensure every hash was cracked into the loot
+    for index, plaintext := range plaintexts {
+        assert.Contains(loot.String(), hashes[index] + ":" + plaintext)
+    }
+}
@@ -0,0 +1,224 @@
+// Package e2e runs the server and its local client processes end to end over localhost.
+// The runs use local_testing, so the protocol is exercised without any AWS services or
+// credentials, and the events emitted with --json are collected for assertions.
+package e2e
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	kkconfig "github.com/ngimb64/Kloud-Kraken/config"
+	"github.com/ngimb64/Kloud-Kraken/internal/conf"
+)
+
+// Names of the built binaries, the server spawns local clients from ./client
+const ClientBinary = "client"
+const ServerBinary = "kloud-kraken-server"
+// Path where the server stores the data dirs of local clients
+var LocalClientsDir = filepath.Join(os.TempDir(), "kloud-kraken-local")
+
+
+// Event is a single JSON line emitted by the server
+type Event map[string]any
+
+// Harness builds the binaries into a working dir and runs the server from it
+type Harness struct {
+    Dir        string  // Working dir of the server with the binaries and generated files
+    ResultsDir string  // Dir the server persists the loot and client logs into
+}
+
+
+// Creates a harness working out of the passed in dir.
+//
+// @Parameters
+// - dir:  The working dir of the server, usually a test temp dir
+//
+// @Returns
+// - The initialized harness
+//
+func New(dir string) *Harness {
+    return &Harness{Dir: dir, ResultsDir: filepath.Join(dir, "results")}
+}
+
+
+// Builds the server and client binaries from the module into the working dir.
+//
+// @Parameters
+// - moduleDir:  The root dir of the module
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (harness *Harness) Build(moduleDir string) error {
+    builds := map[string]string{
+        ClientBinary: "./service",
+        ServerBinary: "./cmd/kloud-kraken",
+    }
+
+    // Iterate through the binaries building each
+    for binary, pkg := range builds {
+        cmd := exec.Command("go", "build", "-o", filepath.Join(harness.Dir, binary), pkg)
+        cmd.Dir = moduleDir
+
+        output, err := cmd.CombinedOutput()
+        if err != nil {
+            return fmt.Errorf("error building %s - %w - %s", binary, err, output)
+        }
+    }
+
+    return nil
+}
+
+
+// Gets a free TCP port on the loopback address for the server to listen on.
+//
+// @Returns
+// - The free port
+// - Error if it occurs, otherwise nil on success
+//
+func FreePort() (int, error) {
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        return 0, err
+    }
+    // Release the port for the server on local exit
+    defer listener.Close()
+
+    return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+
+// Writes the config of a local run into the working dir, rendered from the template
+// config with the answers overriding the defaults of the harness.
+//
+// @Parameters
+// - answers:  The config keys in section.key format, such as local_config.load_dir
+//
+// @Returns
+// - The path to the written config
+// - Error if it occurs, otherwise nil on success
+//
+func (harness *Harness) WriteConfig(answers map[string]string) (string, error) {
+    port, err := FreePort()
+    if err != nil {
+        return "", fmt.Errorf("error getting a free listener port - %w", err)
+    }
+
+    values := map[string]string{
+        "local_config.disable_tui":    "true",
+        "local_config.listener_port":  strconv.Itoa(port),
+        "local_config.local_clients":  "true",
+        "local_config.local_testing":  "true",
+        "local_config.log_path":       filepath.Join(harness.Dir, "KloudKraken.log"),
+        "local_config.results_dir":    harness.ResultsDir,
+        "client_config.log_mode":      "local",
+    }
+    maps.Copy(values, answers)
+
+    configData, err := conf.RenderConfig(kkconfig.Template, kkconfig.Instructions, values)
+    if err != nil {
+        return "", err
+    }
+
+    configPath := filepath.Join(harness.Dir, "config.yml")
+    return configPath, os.WriteFile(configPath, configData, 0644)
+}
+
+
+// Runs the server with JSON output until it exits, collecting the emitted events. The
+// output of the server besides the events is written to server.log in the working dir.
+//
+// @Parameters
+// - ctx:  Context bounding the run, the server is killed when it is done
+// - configPath:  The path to the config of the run
+//
+// @Returns
+// - The events emitted by the server in order
+// - Error if it occurs, otherwise nil on success
+//
+func (harness *Harness) Run(ctx context.Context, configPath string) ([]Event, error) {
+    var events []Event
+
+    // Remove the data dirs left by earlier local runs so stale wordlists are not processed
+    err := os.RemoveAll(LocalClientsDir)
+    if err != nil {
+        return nil, fmt.Errorf("error removing local client dirs - %w", err)
+    }
+
+    logFile, err := os.Create(filepath.Join(harness.Dir, "server.log"))
+    if err != nil {
+        return nil, err
+    }
+    // Close the server log on local exit
+    defer logFile.Close()
+
+    cmd := exec.CommandContext(ctx, filepath.Join(harness.Dir, ServerBinary), "--json",
+                               configPath)
+    cmd.Dir = harness.Dir
+    cmd.Stderr = logFile
+
+    stdout, err := cmd.StdoutPipe()
+    if err != nil {
+        return nil, err
+    }
+
+    err = cmd.Start()
+    if err != nil {
+        return nil, fmt.Errorf("error starting server - %w", err)
+    }
+
+    scanner := bufio.NewScanner(stdout)
+    scanner.Buffer(make([]byte, 64 * 1024), 1024 * 1024)
+
+    // Iterate through the lines of output collecting the events
+    for scanner.Scan() {
+        var event Event
+
+        // If the line is not an event, keep it with the rest of the output
+        if json.Unmarshal(scanner.Bytes(), &event) != nil {
+            fmt.Fprintln(logFile, scanner.Text())
+            continue
+        }
+
+        events = append(events, event)
+    }
+
+    err = cmd.Wait()
+    if err != nil {
+        return events, fmt.Errorf("server exited with error, see %s - %w",
+                                  logFile.Name(), err)
+    }
+
+    return events, nil
+}
+
+
+// Gets the events of the passed in type.
+//
+// @Parameters
+// - events:  The events emitted by the server
+// - eventType:  The type of the events to get
+//
+// @Returns
+// - The events of the type in order
+//
+func FindEvents(events []Event, eventType string) []Event {
+    var found []Event
+
+    // Iterate through the events collecting those of the type
+    for _, event := range events {
+        if event["event"] == eventType {
+            found = append(found, event)
+        }
+    }
+
+    return found
+}
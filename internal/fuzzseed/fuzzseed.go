@@ -0,0 +1,28 @@
+// Package fuzzseed seeds the fuzz targets of the length framed messages, so each target
+// starts from the same valid, oversized, negative, and unterminated frames.
+package fuzzseed
+
+import (
+	"strconv"
+	"testing"
+)
+
+
+// Adds the seeds of a length framed message (<PREFIX:size>body) to the corpus of a fuzz
+// target taking the read message and the rest of the connection: the body split between
+// the two, a size past any max, a negative size, and a header missing its suffix.
+//
+// @Parameters
+// - f:  The fuzz target the seeds are added to
+// - prefix:  The prefix of the message including its opening bracket and delimiter
+// - body:  A valid body of the message
+//
+func Framed(f *testing.F, prefix []byte, body string) {
+    header := string(prefix)
+    split := len(body) / 2
+
+    f.Add([]byte(header + strconv.Itoa(len(body)) + ">" + body[:split]), []byte(body[split:]))
+    f.Add([]byte(header + "999999999>"), []byte(nil))
+    f.Add([]byte(header + "-1>"), []byte(body))
+    f.Add([]byte(header + strconv.Itoa(len(body))), []byte(body))
+}
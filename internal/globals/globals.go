@@ -12,10 +12,54 @@ var HASHES_TRANSFER_PREFIX = []byte("<TRANSFER_HASHES:")
 var RULESET_TRANSFER_PREFIX = []byte("<TRANSFER_RULESET:")
 var TRANSFER_INITIATED_MARKER = []byte("<TRANSFER_INITIATED>")
 var TRANSFER_REQUEST_MARKER = []byte("<TRANSFER_REQUEST>")
+var TRANSFER_FIT_PREFIX = []byte("<TRANSFER_FIT:")
 var START_TRANSFER_PREFIX = []byte("<START_TRANSFER:")
 var LOOT_TRANSFER_PREFIX = []byte("<TRANSFER_LOOT:")
 var LOG_TRANSFER_PREFIX = []byte("<TRANSFER_LOG:")
+var LOG_BATCH_PREFIX = []byte("<LOG_BATCH:")
+var LOOT_FLUSH_PREFIX = []byte("<LOOT_FLUSH:")
+var LOOT_SOURCE_PREFIX = []byte("#KLOUD_KRAKEN_SOURCE:")
+var LOOT_HASH_FILE_PREFIX = []byte("#KLOUD_KRAKEN_HASH_FILE:")
+var LOOT_TIMED_OUT_PREFIX = []byte("#KLOUD_KRAKEN_TIMED_OUT:")
 var TRANSFER_SUFFIX = []byte(">")
 var END_TRANSFER_MARKER = []byte("<END_TRANSFER>")
+var TRANSFER_WAIT_MARKER = []byte("<TRANSFER_WAIT>")
 var PROCESSING_COMPLETE = []byte("<PROCESSING_COMPLETE>")
+var PROCESSING_ABORTED = []byte("<PROCESSING_ABORTED>")
+var ABORT_CHECK_MARKER = []byte("<ABORT_CHECK>")
+var ABORT_MARKER = []byte("<ABORT>")
+var HIBERNATE_MARKER = []byte("<HIBERNATE>")
+var CONTINUE_MARKER = []byte("<CONTINUE>")
+var RESTORE_TRANSFER_PREFIX = []byte("<TRANSFER_RESTORE:")
+var RESULTS_ACK = []byte("<RESULTS_ACK>")
+var KEEPALIVE_MARKER = []byte("<KEEPALIVE>")
+var KEEPALIVE_ACK = []byte("<KEEPALIVE_ACK>")
+var KEYSPACE_REQUEST_MARKER = []byte("<KEYSPACE_REQUEST>")
+var KEYSPACE_RANGE_PREFIX = []byte("<KEYSPACE_RANGE:")
+var KEYSPACE_COMPLETE_PREFIX = []byte("<KEYSPACE_COMPLETE:")
+var KEYSPACE_WAIT_MARKER = []byte("<KEYSPACE_WAIT>")
+var PEER_FETCH_PREFIX = []byte("<PEER_FETCH:")
+var PEER_SEED_PREFIX = []byte("<PEER_SEED:")
+var PEER_GET_PREFIX = []byte("<PEER_GET:")
+var PEER_DONE_MARKER = []byte("<PEER_DONE>")
+var PEER_FAILED_MARKER = []byte("<PEER_FAILED>")
+var VERSION_CHECK_PREFIX = []byte("<VERSION_CHECK:")
+var CLIENT_VERSION_PREFIX = []byte("<CLIENT_VERSION:")
+var CLIENT_UPDATE_MARKER = []byte("<CLIENT_UPDATE>")
+var CERT_CHECK_PREFIX = []byte("<CERT_CHECK:")
+var CERT_CURRENT_MARKER = []byte("<CERT_CURRENT>")
+var GPU_INVENTORY_PREFIX = []byte("<GPU_INVENTORY:")
+var INSTANCE_METADATA_PREFIX = []byte("<INSTANCE_METADATA:")
+var DEVICE_ASSIGNMENT_PREFIX = []byte("<DEVICE_ASSIGNMENT:")
+var HASH_TYPES_PREFIX = []byte("<HASH_TYPES:")
+var WORDLIST_STATS_PREFIX = []byte("<WORDLIST_STATS:")
+var WORDLIST_REJECTED_PREFIX = []byte("<WORDLIST_REJECTED:")
+var WORK_START_PREFIX = []byte("<WORK_START:")
+var WORK_KEEP_MARKER = []byte("<WORK_KEEP>")
+var WORK_TRUNCATE_PREFIX = []byte("<WORK_TRUNCATE:")
+var HASHCAT_EXECUTION_PREFIX = []byte("<HASHCAT_EXECUTION:")
+var HASHCAT_STATUS_PREFIX = []byte("<HASHCAT_STATUS:")
+var HELLO_PREFIX = []byte("<HELLO:")
+var HELLO_REFUSED_PREFIX = []byte("<HELLO_REFUSED:")
+var NO_CRACKED_HASHES = []byte("No available cracked hashses after processing")
 var FILE_SIZE_TYPES = []string{"KB", "MB", "GB"}
@@ -6,24 +6,63 @@ import (
 	"net"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/ngimb64/Kloud-Kraken/internal/globals"
 	"github.com/ngimb64/Kloud-Kraken/pkg/data"
 	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
 	"github.com/ngimb64/Kloud-Kraken/pkg/display"
+	"github.com/ngimb64/Kloud-Kraken/pkg/netio"
+	"github.com/ngimb64/Kloud-Kraken/pkg/partition"
 )
 
+// Max number of hashcat processes a client runs concurrently
+const MaxHashcatJobs = 16
+
+// Range of GPU temperature limits in Celsius, below the range GPUs pause under normal load
+// and above it they throttle themselves before the limit is reached
+const MinGpuTempLimit = 60
+const MaxGpuTempLimit = 100
+
+// Max number of custom tags of a run, leaving room under the 50 tag limit of AWS resources
+const MaxRunTags = 40
+
 // Package level variables
 var ReAccountId = regexp.MustCompile(`^\d{12}$`)
+var ReAmi = regexp.MustCompile(`^ami-[0-9a-f]{8,17}$`)
+var ReAmiSsmParameter = regexp.MustCompile(`^/[A-Za-z0-9_.\-/]+$`)
+var ReBrainPassword = regexp.MustCompile(`^[\w.@%+=:-]{8,128}$`)
+var ReHashcatRelease = regexp.MustCompile(`^v\d+\.\d+\.\d+$`)
+var ReHostname = regexp.MustCompile(
+    `^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*$`,
+)
+var ReIamPolicyArn = regexp.MustCompile(
+    `^arn:aws[a-z-]*:iam::(\d{12}|aws):policy/([\w+=,.@-]+/)*[\w+=,.@-]{1,128}$`,
+)
+var ReIamRoleArn = regexp.MustCompile(
+    `^arn:aws[a-z-]*:iam::\d{12}:role/([\w+=,.@-]+/)*[\w+=,.@-]{1,64}$`,
+)
 var ReIamUsername = regexp.MustCompile(`^[\w+=,.@-]{1,64}$`)
+var ReInstanceId = regexp.MustCompile(`^i-[0-9a-f]{8,17}$`)
+var ReInstanceProfile = regexp.MustCompile(`^[\w+=,.@-]{1,128}$`)
+var ReKmsKeyId = regexp.MustCompile(
+    `^(arn:aws[a-z-]*:kms:[a-z0-9-]+:\d{12}:key/)?` +
+    `(mrk-[0-9a-f]{32}|[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})$`,
+)
+var ReNumberList = regexp.MustCompile(`^[1-9]\d*(,[1-9]\d*)*$`)
+var ReRunName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+var ReSha256 = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
 var ReSecurityGroupId = regexp.MustCompile(`^sg-[0-9a-f]{8,}$`)
 var ReSecurityGroupName = regexp.MustCompile(
     `^[A-Za-z0-9\s\.\_\-\:\/\(\)\#\,\@\[\]\+\=\&\;\{\}\!\$\*]{1,255}$`,
 )
 var ReSubnetId = regexp.MustCompile(`^subnet-[0-9a-f]{8,}$`)
+var ReTagKey = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]{1,128}$`)
+var ReTagValue = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]{0,256}$`)
+var ReUsername = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
 
 
 // Ensure the AWS account ID is of proper format.
@@ -44,6 +83,44 @@ func ValidateAccountId(accountId string) error {
 }
 
 
+// Ensures the AMI ID override is of proper format if set, empty resolves the AMI.
+//
+// @Parameters
+// - ami:  The ID of the Amazon Machine Image to be validated
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func ValidateAmi(ami string) error {
+    // If the AMI ID is set but not of proper format
+    if ami != "" && !ReAmi.MatchString(ami) {
+        return errors.New("invalid AMI ID, must be ami- followed by 8-17 hex characters")
+    }
+
+    return nil
+}
+
+
+// Ensures the SSM parameter the AMI is resolved from is of proper format if set, empty
+// uses the default Ubuntu parameter.
+//
+// @Parameters
+// - parameter:  The name of the SSM parameter to be validated
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func ValidateAmiSsmParameter(parameter string) error {
+    // If the parameter is set but not of proper format
+    if parameter != "" && (len(parameter) > 1011 || !ReAmiSsmParameter.MatchString(parameter)) {
+        return errors.New("invalid AMI SSM parameter, must start with /, be at most 1011 " +
+                          "characters, and only contain alphanumeric characters and _.-/")
+    }
+
+    return nil
+}
+
+
 // Ensures the S3 bucket name is of proper format.
 //
 // @Parameters
@@ -82,6 +159,83 @@ func ValidateBucketName(name string) error {
 }
 
 
+// Ensure the passed in hashcat brain host is a valid IP address or hostname, an empty
+// host is allowed since the brain may run on the server host.
+//
+// @Parameters
+// - brainHost:  The host of a dedicated brain server
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func ValidateBrainHost(brainHost string) error {
+    // If the brain host is not set or is an IP address
+    if brainHost == "" || net.ParseIP(brainHost) != nil {
+        return nil
+    }
+
+    // If the brain host is not a proper hostname
+    if !ReHostname.MatchString(brainHost) {
+        return fmt.Errorf("invalid brain host - %q", brainHost)
+    }
+
+    return nil
+}
+
+
+// Ensure the passed in hashcat brain password is safe to pass as a command line arg, an
+// empty password is allowed since one is generated when not set.
+//
+// @Parameters
+// - brainPassword:  The password clients authenticate to the brain server with
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func ValidateBrainPassword(brainPassword string) error {
+    // If the brain password is set and not of proper format
+    if brainPassword != "" && !ReBrainPassword.MatchString(brainPassword) {
+        return fmt.Errorf("brain password must be 8-128 letters, digits, or _.@%%+=:- characters")
+    }
+
+    return nil
+}
+
+
+// Ensure the passed in hashcat brain port is usable and does not collide with the
+// listener or web UI ports.
+//
+// @Parameters
+// - brainPort:  The port the brain server listens on
+// - listenerPort:  The port the TLS listener is on
+// - webUiPort:  The port the web UI is on, 0 if disabled
+//
+// @Returns
+// - true/false boolean depending on whether the brain port is usable
+//
+func ValidateBrainPort(brainPort int, listenerPort int, webUiPort int) bool {
+    return ValidateListenerPort(brainPort) && brainPort <= 65535 &&
+           brainPort != listenerPort && brainPort != webUiPort
+}
+
+
+// Ensure the passed in candidate generator is supported, empty runs hashcat directly
+// against the wordlists.
+//
+// @Parameters
+// - generator:  The candidate generator to be validated
+//
+// @Returns
+// - true/false depending on whether the candidate generator is supported or not
+//
+func ValidateCandidateGenerator(generator string) bool {
+    generators := []string{"", "combinator", "prince"}
+
+    // Check to see if arg generator is in allowed generators
+    return data.StringSliceHasItem(generators, generator)
+}
+
+
 // Ensures that if there is a char set that is present and the proper cracking
 // mode that supports a hash mask with custom charsets is present.
 //
@@ -114,6 +268,23 @@ func ValidateCharsets(crackingMode string, hashMask string, args ...string) bool
 }
 
 
+// Ensure the passed in client OS is one the user data and AMI are resolved for, empty
+// selects linux.
+//
+// @Parameters
+// - clientOs:  The operating system of the client instances to be validated
+//
+// @Returns
+// - true/false depending on whether the client OS is supported or not
+//
+func ValidateClientOs(clientOs string) bool {
+    clientOses := []string{"", "linux", "windows"}
+
+    // Check to see if arg client OS is in supported systems
+    return data.StringSliceHasItem(clientOses, clientOs)
+}
+
+
 // In a continous loop, the input is gathered and tested to see if the path
 // exists that is a yaml file with data inside it.
 //
@@ -163,6 +334,35 @@ func ValidateConfigPath(configFilePath *string) {
 }
 
 
+// Ensure the passed in control plane mode is supported, empty selects the default.
+//
+// @Parameters
+// - controlPlane:  The control plane mode to be validated
+//
+// @Returns
+// - true/false depending on whether the control plane mode is supported or not
+//
+func ValidateControlPlane(controlPlane string) bool {
+    controlPlanes := []string{"", "tls", "sqs"}
+
+    // Check to see if arg control plane is in allowed modes
+    return data.StringSliceHasItem(controlPlanes, controlPlane)
+}
+
+
+// Ensure the passed in cost is not negative, zero is used to disable the setting.
+//
+// @Parameters
+// - cost:  The dollar amount to validate
+//
+// @Returns
+// - true/false boolean depending on whether the cost is 0 or greater
+//
+func ValidateCost(cost float64) bool {
+    return cost >= 0
+}
+
+
 // Validate the hashcat cracking mode to ensure it is supported.
 //
 // @Parameters
@@ -179,6 +379,32 @@ func ValidateCrackingMode(hashMode string) bool {
 }
 
 
+// Ensure the hashcat device types are empty (all types) or a comma separated list of
+// the supported types, 1 (CPU), 2 (GPU), and 3 (FPGA, DSP, Co-Processor).
+//
+// @Parameters
+// - deviceTypes:  The device types to validate
+//
+// @Returns
+// - true/false boolean depending on whether the device types are valid or not
+//
+func ValidateDeviceTypes(deviceTypes string) bool {
+    // If all device types are used
+    if deviceTypes == "" {
+        return true
+    }
+
+    // Iterate through the listed types ensuring each is supported
+    for _, deviceType := range strings.Split(deviceTypes, ",") {
+        if !slices.Contains([]string{"1", "2", "3"}, deviceType) {
+            return false
+        }
+    }
+
+    return true
+}
+
+
 // Ensure the passed in directory path exists and is a dir that has data.
 //
 // @Parameters
@@ -204,6 +430,50 @@ func ValidateDir(dirPath string) error {
 }
 
 
+// Parses the passed in duration string (ex: 90m, 4h) and ensures it is positive, an
+// empty string is used to disable the setting.
+//
+// @Parameters
+// - duration:  The duration string to validate
+//
+// @Returns
+// - The parsed duration, 0 if the string is empty
+// - Error if it occurs, otherwise nil on success
+//
+func ValidateDuration(duration string) (time.Duration, error) {
+    // If the duration is not set
+    if duration == "" {
+        return 0, nil
+    }
+
+    // Parse the duration string into time.Duration
+    parsed, err := time.ParseDuration(duration)
+    if err != nil {
+        return 0, err
+    }
+
+    // If the duration is not positive
+    if parsed <= 0 {
+        return 0, fmt.Errorf("duration must be greater than zero - %q", duration)
+    }
+
+    return parsed, nil
+}
+
+
+// Ensure the EBS data volume size is within the gp3 limits of 1 GiB to 16 TiB.
+//
+// @Parameters
+// - volumeSize:  The size of the volume in GiB
+//
+// @Returns
+// - true/false boolean depending on whether the size is within the gp3 limits
+//
+func ValidateEbsVolumeSize(volumeSize int) bool {
+    return volumeSize >= 1 && volumeSize <= 16384
+}
+
+
 // Ensure the passed in file path exists and is a file that has data.
 //
 // @Parameters
@@ -229,6 +499,94 @@ func ValidateFile(filePath string) error {
 }
 
 
+// Ensure the GPU temperature limit is disabled or within the supported range.
+//
+// @Parameters
+// - tempLimit:  The temperature in Celsius hashcat is paused at
+//
+// @Returns
+// - true/false boolean depending on whether the limit is 0 or within the range
+//
+func ValidateGpuTempLimit(tempLimit int) bool {
+    return tempLimit == 0 || (tempLimit >= MinGpuTempLimit && tempLimit <= MaxGpuTempLimit)
+}
+
+
+// Validate the path to the custom hashcat build archive uploaded alongside the client and
+// the archive itself, a gzipped tarball for Linux clients and a zip for Windows clients.
+//
+// @Parameters
+// - filePath:  The path to the hashcat build archive to validate
+// - clientOs:  The operating system of the client instances the archive is built for
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func ValidateHashcatArtifact(filePath string, clientOs string) error {
+    // Validate the archive path
+    validPath, err := ValidatePath(filePath)
+    if err != nil {
+        return fmt.Errorf("improper hashcat_artifact specified in local config - %w", err)
+    }
+
+    // Validate the archive file
+    err = ValidateFile(validPath)
+    if err != nil {
+        return fmt.Errorf("error validating hashcat artifact based on %s path - %w",
+                          validPath, err)
+    }
+
+    // If the clients run Windows, the archive is expanded with Expand-Archive
+    if clientOs == "windows" {
+        if !strings.HasSuffix(validPath, ".zip") {
+            return errors.New("hashcat artifact of Windows clients must be a .zip archive")
+        }
+
+        return nil
+    }
+
+    // If the archive is not a gzipped tarball extracted with tar
+    if !strings.HasSuffix(validPath, ".tar.gz") && !strings.HasSuffix(validPath, ".tgz") {
+        return errors.New("hashcat artifact of Linux clients must be a .tar.gz archive")
+    }
+
+    return nil
+}
+
+
+// Ensures the hashcat release tag is of proper format if set, empty uses the distro
+// package or the default release.
+//
+// @Parameters
+// - release:  The hashcat release tag to be validated
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func ValidateHashcatRelease(release string) error {
+    // If the release is set but not a version tag
+    if release != "" && !ReHashcatRelease.MatchString(release) {
+        return fmt.Errorf("invalid hashcat release %q, must be a tag such as v6.2.6", release)
+    }
+
+    return nil
+}
+
+
+// Ensure the passed in number of concurrent hashcat jobs is disabled (0 or 1) or does
+// not exceed the max number of jobs a client runs.
+//
+// @Parameters
+// - hashcatJobs:  The number of hashcat processes run concurrently on each client
+//
+// @Returns
+// - true/false boolean depending on whether the hashcat jobs is valid or not
+//
+func ValidateHashcatJobs(hashcatJobs int) bool {
+    return hashcatJobs >= 0 && hashcatJobs <= MaxHashcatJobs
+}
+
+
 // Validate the path to the hash file and the file itself via ValidateFile().
 //
 // @Parameters
@@ -305,6 +663,44 @@ func ValidateHashType(hashType string) bool {
 }
 
 
+// Ensures the IAM managed policy ARN is of proper format, either an AWS managed policy or
+// one in an account.
+//
+// @Parameters
+// - policyArn:  The ARN of the IAM policy to be validated
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func ValidateIamPolicyArn(policyArn string) error {
+    // If the policy ARN is not of proper format
+    if !ReIamPolicyArn.MatchString(policyArn) {
+        return fmt.Errorf("invalid IAM policy ARN - %q", policyArn)
+    }
+
+    return nil
+}
+
+
+// Ensures the IAM role ARN is of proper format, including any path the role was
+// created under.
+//
+// @Parameters
+// - roleArn:  The ARN of the IAM role to be validated
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func ValidateIamRoleArn(roleArn string) error {
+    // If the role ARN is not of proper format
+    if !ReIamRoleArn.MatchString(roleArn) {
+        return fmt.Errorf("invalid IAM role ARN - %q", roleArn)
+    }
+
+    return nil
+}
+
+
 // Ensures the AWS IAM username is of proper format.
 //
 // @Parameters
@@ -324,6 +720,42 @@ func ValidateIamUsername(iamUsername string) error {
 }
 
 
+// Ensures the EC2 instance ID is of proper format.
+//
+// @Parameters
+// - instanceId:  The ID of the EC2 instance to be validated
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func ValidateInstanceId(instanceId string) error {
+    // If the instance ID is not of proper format
+    if !ReInstanceId.MatchString(instanceId) {
+        return fmt.Errorf("invalid instance ID - %q", instanceId)
+    }
+
+    return nil
+}
+
+
+// Ensures the IAM instance profile name is of proper format.
+//
+// @Parameters
+// - profileName:  The name of the instance profile to be validated
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func ValidateInstanceProfile(profileName string) error {
+    // If the instance profile name is not of proper format
+    if !ReInstanceProfile.MatchString(profileName) {
+        return fmt.Errorf("invalid instance profile name - %q", profileName)
+    }
+
+    return nil
+}
+
+
 // Ensures the passed in instance type is in the supported slice.
 //
 // @Parameters
@@ -343,6 +775,10 @@ func ValidateInstanceType(instanceType string) bool {
         "g5d.12xlarge", "g5d.16xlarge", "g5d.24xlarge",
         "g5d.48xlarge",
 
+        // === G5g (Graviton with NVIDIA T4G, no instance store) ===
+        "g5g.xlarge",   "g5g.2xlarge",  "g5g.4xlarge",
+        "g5g.8xlarge",  "g5g.16xlarge", "g5g.metal",
+
         // === G6gd (Graviton d-variant of G6) ===
         "g6gd.xlarge",   "g6gd.2xlarge",  "g6gd.4xlarge",
         "g6gd.8xlarge",  "g6gd.12xlarge", "g6gd.16xlarge",
@@ -367,6 +803,46 @@ func ValidateInstanceType(instanceType string) bool {
 }
 
 
+// Ensure the keyspace chunks is disabled (0) or positive and only used with
+// a pure mask attack that has a hash mask to split.
+//
+// @Parameters
+// - keyspaceChunks:  The number of ranges to split the mask keyspace into
+// - crackingMode:  The hashcat cracking mode
+// - hashMask:  The hashcat mask whose keyspace is split
+//
+// @Returns
+// - true/false boolean depending on whether the keyspace chunks is valid or not
+//
+func ValidateKeyspaceChunks(keyspaceChunks int, crackingMode string, hashMask string) bool {
+    // If keyspace splitting is disabled
+    if keyspaceChunks == 0 {
+        return true
+    }
+
+    return keyspaceChunks > 0 && crackingMode == "3" && hashMask != ""
+}
+
+
+// Ensures the KMS key is a key ID or key ARN, aliases are not accepted since IAM
+// policies can not grant use of a key through its alias.
+//
+// @Parameters
+// - keyId:  The ID or ARN of the KMS key to be validated
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func ValidateKmsKeyId(keyId string) error {
+    // If the key is not a key ID or key ARN
+    if !ReKmsKeyId.MatchString(keyId) {
+        return fmt.Errorf("invalid KMS key ID or ARN - %q", keyId)
+    }
+
+    return nil
+}
+
+
 // Ensure the listener is above a non-privileged TCP port (over 1000).
 //
 // @Parameters
@@ -496,6 +972,27 @@ func ValidateMaxTransfers(maxTransfers int32) bool {
 }
 
 
+// Ensure the metrics port is either disabled (0) or a non-privileged port that does
+// not collide with any of the other ports the server listens on.
+//
+// @Parameters
+// - metricsPort:  The metrics port to be validated
+// - usedPorts:  The other ports the server listens on
+//
+// @Returns
+// - true/false boolean depending on whether the metrics port is valid or not
+//
+func ValidateMetricsPort(metricsPort int, usedPorts ...int) bool {
+    // If the metrics endpoint is disabled
+    if metricsPort == 0 {
+        return true
+    }
+
+    return ValidateListenerPort(metricsPort) && metricsPort <= 65535 &&
+           !slices.Contains(usedPorts, metricsPort)
+}
+
+
 // Ensure the passed in number instances is greater than zero.
 //
 // @Parameters
@@ -509,6 +1006,34 @@ func ValidateNumberInstances(numberInstances int) bool {
 }
 
 
+// Ensure the passed in list is empty (unset) or a comma separated list of positive
+// numbers, used for hashcat device IDs and their per-device tuning values.
+//
+// @Parameters
+// - list:  The number list to validate
+//
+// @Returns
+// - true/false boolean depending on whether the list is valid or not
+//
+func ValidateNumberList(list string) bool {
+    return list == "" || ReNumberList.MatchString(list)
+}
+
+
+// Ensure the passed in parallel connections is disabled (0 or 1) or does not exceed the
+// max number of connections a file can be split across.
+//
+// @Parameters
+// - parallelConnections:  The number of connections large files are split across
+//
+// @Returns
+// - true/false boolean depending on whether the parallel connections is valid or not
+//
+func ValidateParallelConnections(parallelConnections int) bool {
+    return parallelConnections >= 0 && parallelConnections <= netio.MaxRangeConnections
+}
+
+
 // Cleans the passed in path and ensures it is of proper format.
 //
 // @Parameters
@@ -541,6 +1066,53 @@ func ValidatePath(path string) (string, error) {
 }
 
 
+// Ensures the wordlist preprocessing stages are supported and listed once each.
+//
+// @Parameters
+// - stages:  Slice of preprocessing stages to validate
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func ValidatePreprocessStages(stages []string) error {
+    supported := []string{"frequency_sort", "stats"}
+
+    // Iterate through the passed in stages
+    for index, stage := range stages {
+        // If the stage is not supported
+        if !data.StringSliceHasItem(supported, stage) {
+            return fmt.Errorf("unsupported preprocessing stage - %q", stage)
+        }
+
+        // If the stage was already listed
+        if slices.Contains(stages[:index], stage) {
+            return fmt.Errorf("duplicate preprocessing stage - %q", stage)
+        }
+    }
+
+    return nil
+}
+
+
+// Ensure the passed in dir quota is empty or a size in raw bytes or unit format.
+//
+// @Parameters
+// - quota:  The dir quota prior to parse and conversion
+//
+// @Returns
+// - The quota in raw bytes, 0 if unlimited
+// - Error if it occurs, otherwise nil on success
+//
+func ValidateQuota(quota string) (int64, error) {
+    // If no quota was specified or it was explicitly disabled
+    if quota == "" || quota == "0" {
+        return 0, nil
+    }
+
+    return ValidateFileSize(quota)
+}
+
+
 // Ensure the passed in region is a valid AWS region.
 //
 // @Parameters
@@ -550,18 +1122,49 @@ func ValidatePath(path string) (string, error) {
 // - true/false boolean depending on whether the AWS region is valid or not
 //
 func ValidateRegion(region string) bool {
-    // Iterate through the endpoint partitions
-    for _, currPartitions := range endpoints.DefaultPartitions() {
-        // Iterate through the regions in the current partition
-        for _, currRegion := range currPartitions.Regions() {
-            // It the current region ID matches arg string
-            if currRegion.ID() == region {
-                return true
-            }
+    // Check the region against the known regions of every partition
+    return partition.IsRegion(region)
+}
+
+
+// Ensure the passed in reserved space is a percentage of the disk (ex: 5%) or a size in
+// raw bytes or unit format. If empty the default fixed OS reserved space is used.
+//
+// @Parameters
+// - reservedSpace:  The reserved space prior to parse and conversion
+//
+// @Returns
+// - The parsed reserve policy
+// - Error if it occurs, otherwise nil on success
+//
+func ValidateReservedSpace(reservedSpace string) (disk.Reserve, error) {
+    // If no reserved space was specified
+    if reservedSpace == "" {
+        return disk.Reserve{Bytes: globals.OS_RESERVED_SPACE}, nil
+    }
+
+    // If the reserved space is a percentage of the disk
+    if strings.HasSuffix(reservedSpace, "%") {
+        percent, err := strconv.ParseFloat(strings.TrimSuffix(reservedSpace, "%"), 64)
+        if err != nil {
+            return disk.Reserve{}, fmt.Errorf("error converting percentage to float64 - %w", err)
         }
+
+        // If the percentage leaves no space or reserves the whole disk
+        if percent <= 0 || percent >= 100 {
+            return disk.Reserve{}, fmt.Errorf("reserved space percentage must be " +
+                                              "between 0 and 100")
+        }
+
+        return disk.Reserve{Percent: percent}, nil
     }
 
-    return false
+    byteSize, err := ValidateFileSize(reservedSpace)
+    if err != nil {
+        return disk.Reserve{}, err
+    }
+
+    return disk.Reserve{Bytes: byteSize}, nil
 }
 
 
@@ -595,6 +1198,74 @@ func ValidateRulesetFile(filePath string) error {
 }
 
 
+// Ensures the run name is usable in tag values, CloudWatch stream names, and file names.
+//
+// @Parameters
+// - runName:  The name of the run to validate, empty if unnamed
+//
+// @Returns
+// - true/false boolean depending on whether the name is empty or of proper format
+//
+func ValidateRunName(runName string) bool {
+    return runName == "" || ReRunName.MatchString(runName)
+}
+
+
+// Ensures the custom tags of the run are accepted by every tagged AWS service and do not
+// overwrite the tags Kloud-Kraken relies on.
+//
+// @Parameters
+// - tags:  The map of tag keys to values to validate
+// - reservedKeys:  The keys of the tags set by Kloud-Kraken
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func ValidateRunTags(tags map[string]string, reservedKeys ...string) error {
+    // If there are more tags than leave room for the reserved ones
+    if len(tags) > MaxRunTags {
+        return fmt.Errorf("%d tags exceeds the max of %d", len(tags), MaxRunTags)
+    }
+
+    // Iterate through the tags validating each
+    for key, value := range tags {
+        // If the key is reserved by AWS or Kloud-Kraken
+        if strings.HasPrefix(strings.ToLower(key), "aws:") ||
+        slices.Contains(reservedKeys, key) {
+            return fmt.Errorf("tag key %q is reserved", key)
+        }
+
+        if !ReTagKey.MatchString(key) {
+            return fmt.Errorf("invalid tag key - %q", key)
+        }
+
+        if !ReTagValue.MatchString(value) {
+            return fmt.Errorf("invalid value of tag %q - %q", key, value)
+        }
+    }
+
+    return nil
+}
+
+
+// Ensure the passed in wordlist schedule strategy is supported, empty keeps the load
+// dir order.
+//
+// @Parameters
+// - strategy:  The schedule strategy to be validated
+//
+// @Returns
+// - true/false depending on whether the schedule strategy is supported or not
+//
+func ValidateScheduleStrategy(strategy string) bool {
+    strategies := []string{"", "hit_rate", "largest_first", "priority", "round_robin", "size",
+                           "smallest_first"}
+
+    // Check to see if arg strategy is in allowed strategies
+    return data.StringSliceHasItem(strategies, strategy)
+}
+
+
 // Ensures any security group IDs are of proper format.
 //
 // @Parameters
@@ -647,6 +1318,24 @@ func ValidateSecurityGroups(securityGroups []string) error {
 }
 
 
+// Ensures the checksum is a hex encoded SHA-256 digest.
+//
+// @Parameters
+// - sum:  The checksum to be validated
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func ValidateSha256(sum string) error {
+    // If the checksum is not 64 hex characters
+    if !ReSha256.MatchString(sum) {
+        return fmt.Errorf("invalid SHA-256 checksum %q, must be 64 hex characters", sum)
+    }
+
+    return nil
+}
+
+
 // Ensures the AWS subnet ID is of proper format.
 //
 // @Parameters
@@ -669,6 +1358,86 @@ func ValidateSubnetId(subnetId string) error {
 }
 
 
+// Ensures the run summary export formats are supported and not repeated.
+//
+// @Parameters
+// - formats:  Slice of summary export formats to validate
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func ValidateSummaryExport(formats []string) error {
+    supported := []string{"json", "markdown"}
+
+    // Iterate through the passed in formats
+    for index, format := range formats {
+        // If the format is not supported
+        if !data.StringSliceHasItem(supported, format) {
+            return fmt.Errorf("unsupported summary export format - %q", format)
+        }
+
+        // If the format was already listed
+        if slices.Contains(formats[:index], format) {
+            return fmt.Errorf("duplicate summary export format - %q", format)
+        }
+    }
+
+    return nil
+}
+
+
+// Ensure the Linux username the hardened client drops to is of proper format and
+// not root.
+//
+// @Parameters
+// - username:  The username to be validated
+//
+// @Returns
+// - true/false depending on whether the username is valid or not
+//
+func ValidateUsername(username string) bool {
+    return username != "root" && ReUsername.MatchString(username)
+}
+
+
+// Ensure the passed in hash verification mode is supported, empty skips verifying the
+// hashes before launch.
+//
+// @Parameters
+// - mode:  The hash verification mode to be validated
+//
+// @Returns
+// - true/false depending on whether the hash verification mode is supported or not
+//
+func ValidateVerifyHashes(mode string) bool {
+    modes := []string{"", "reject", "report"}
+
+    // Check to see if arg mode is in allowed modes
+    return data.StringSliceHasItem(modes, mode)
+}
+
+
+// Ensure the web UI port is either disabled (0) or a non-privileged
+// port that does not collide with the listener port.
+//
+// @Parameters
+// - webUiPort:  The web UI port to be validated
+// - listenerPort:  The port of the TLS listener
+//
+// @Returns
+// - true/false boolean depending on whether the web UI port is valid or not
+//
+func ValidateWebUiPort(webUiPort int, listenerPort int) bool {
+    // If the web UI is disabled
+    if webUiPort == 0 {
+        return true
+    }
+
+    return ValidateListenerPort(webUiPort) && webUiPort <= 65535 &&
+           webUiPort != listenerPort
+}
+
+
 // Ensure the passed in workload is suppported by hashcat.
 //
 // @Parameters
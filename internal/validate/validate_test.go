@@ -4,11 +4,14 @@ import (
 	"crypto/sha512"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ngimb64/Kloud-Kraken/internal/globals"
 	"github.com/ngimb64/Kloud-Kraken/internal/validate"
 	"github.com/ngimb64/Kloud-Kraken/pkg/data"
+	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -29,6 +32,48 @@ func TestValidateAccountId(t *testing.T) {
 }
 
 
+func TestValidateAmi(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Try test with proper values
+    err := validate.ValidateAmi("ami-0eb94e3d16a6eea5f")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    err = validate.ValidateAmi("")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Try test with bad value
+    err = validate.ValidateAmi("ami-XYZ")
+    // Ensure the error is not nil meaning failed operation
+    assert.NotEqual(nil, err)
+}
+
+
+func TestValidateAmiSsmParameter(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Try test with proper values
+    err := validate.ValidateAmiSsmParameter(
+        "/aws/service/canonical/ubuntu/server/22.04/stable/current/amd64/hvm/ebs-gp2/ami-id")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    err = validate.ValidateAmiSsmParameter("")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Try test with bad values
+    err = validate.ValidateAmiSsmParameter("aws/service/ami-id")
+    // Ensure the error is not nil meaning failed operation
+    assert.NotEqual(nil, err)
+    err = validate.ValidateAmiSsmParameter("/aws/service/ami id")
+    // Ensure the error is not nil meaning failed operation
+    assert.NotEqual(nil, err)
+}
+
+
 func TestValidateBucketName(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -47,6 +92,86 @@ func TestValidateBucketName(t *testing.T) {
 }
 
 
+func TestValidateBrainHost(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    goodHosts := []string{"", "10.0.0.5", "brain.example.com", "brain-server"}
+    // Iterate through the good hosts and test them
+    for _, goodHost := range goodHosts {
+        err := validate.ValidateBrainHost(goodHost)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+    }
+
+    badHosts := []string{"brain host", "-brain.example.com", "brain;reboot"}
+    // Iterate through the bad hosts and test them
+    for _, badHost := range badHosts {
+        err := validate.ValidateBrainHost(badHost)
+        // Ensure the error is not nil meaning failed operation
+        assert.NotEqual(nil, err)
+    }
+}
+
+
+func TestValidateBrainPassword(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Try test with empty value
+    err := validate.ValidateBrainPassword("")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Try test with proper value
+    err = validate.ValidateBrainPassword("s3cret.brain-password")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    badPasswords := []string{"short", "has space in it", "semi;colon$(id)"}
+    // Iterate through the bad passwords and test them
+    for _, badPassword := range badPasswords {
+        err = validate.ValidateBrainPassword(badPassword)
+        // Ensure the error is not nil meaning failed operation
+        assert.NotEqual(nil, err)
+    }
+}
+
+
+func TestValidateBrainPort(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Test a usable port
+    assert.True(validate.ValidateBrainPort(13743, 6969, 8443))
+    // Test a port below 1000
+    assert.False(validate.ValidateBrainPort(80, 6969, 0))
+    // Test a port above 65535
+    assert.False(validate.ValidateBrainPort(70000, 6969, 0))
+    // Test ports colliding with the listener and web UI
+    assert.False(validate.ValidateBrainPort(6969, 6969, 0))
+    assert.False(validate.ValidateBrainPort(8443, 6969, 8443))
+}
+
+
+func TestValidateCandidateGenerator(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := []string{"", "combinator", "prince"}
+    // Iterate through slice of truths and test them
+    for _, truth := range truths {
+        assert.True(validate.ValidateCandidateGenerator(truth))
+    }
+
+    falacies := []string{"markov", "PRINCE", "pp64"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        assert.False(validate.ValidateCandidateGenerator(falacy))
+    }
+}
+
+
 func TestValidateCharsets(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -62,6 +187,24 @@ func TestValidateCharsets(t *testing.T) {
 }
 
 
+func TestValidateClientOs(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := []string{"", "linux", "windows"}
+    // Iterate through slice of truths and test them
+    for _, truth := range truths {
+        assert.True(validate.ValidateClientOs(truth))
+    }
+
+    falacies := []string{"darwin", "Windows", "ubuntu"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        assert.False(validate.ValidateClientOs(falacy))
+    }
+}
+
+
 func TestValidateConfigPath(t *testing.T) {
     configPath := "../../config/config.yml"
     // Test with the default yaml config file
@@ -69,6 +212,37 @@ func TestValidateConfigPath(t *testing.T) {
 }
 
 
+func TestValidateControlPlane(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := []string{"", "tls", "sqs"}
+    // Iterate through slice of truths and test them
+    for _, truth := range truths {
+        assert.True(validate.ValidateControlPlane(truth))
+    }
+
+    falacies := []string{"udp", "SQS", "nonsense"}
+    // Iterate through slice of truths and test them
+    for _, falacy := range falacies {
+        assert.False(validate.ValidateControlPlane(falacy))
+    }
+}
+
+
+func TestValidateCost(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Test negative value
+    assert.False(validate.ValidateCost(-0.5))
+    // Test zero value
+    assert.True(validate.ValidateCost(0))
+    // Test positive value
+    assert.True(validate.ValidateCost(25.75))
+}
+
+
 func TestValidateCrackingMode(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -87,6 +261,24 @@ func TestValidateCrackingMode(t *testing.T) {
 }
 
 
+func TestValidateDeviceTypes(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := []string{"", "1", "2", "1,2", "1,2,3"}
+    // Iterate through slice of truths and test them
+    for _, truth := range truths {
+        assert.True(validate.ValidateDeviceTypes(truth))
+    }
+
+    falacies := []string{"0", "4", "1,", "1,,2", "gpu"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        assert.False(validate.ValidateDeviceTypes(falacy))
+    }
+}
+
+
 func TestValidateDir(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -130,6 +322,46 @@ func TestValidateDir(t *testing.T) {
 }
 
 
+func TestValidateDuration(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Try test with empty value
+    duration, err := validate.ValidateDuration("")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(time.Duration(0), duration)
+
+    // Try test with proper value
+    duration, err = validate.ValidateDuration("1h30m")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(90*time.Minute, duration)
+
+    badValues := []string{"-5m", "0s", "ten minutes"}
+    // Iterate through bad values and test them
+    for _, badValue := range badValues {
+        _, err = validate.ValidateDuration(badValue)
+        // Ensure the error is not nil meaning failed operation
+        assert.NotEqual(nil, err)
+    }
+}
+
+
+func TestValidateEbsVolumeSize(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Test zero value
+    assert.False(validate.ValidateEbsVolumeSize(0))
+    // Test value above the gp3 limit
+    assert.False(validate.ValidateEbsVolumeSize(16385))
+    // Test values within the gp3 limits
+    assert.True(validate.ValidateEbsVolumeSize(1))
+    assert.True(validate.ValidateEbsVolumeSize(500))
+}
+
+
 func TestValidateFile(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -173,6 +405,89 @@ func TestValidateFile(t *testing.T) {
 }
 
 
+func TestValidateGpuTempLimit(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := []int{0, 60, 85, 100}
+    // Iterate through slice of truths and test them
+    for _, truth := range truths {
+        assert.True(validate.ValidateGpuTempLimit(truth))
+    }
+
+    falacies := []int{-1, 1, 59, 101}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        assert.False(validate.ValidateGpuTempLimit(falacy))
+    }
+}
+
+
+func TestValidateHashcatArtifact(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    testDir := t.TempDir()
+    // Iterate through the archive names writing a file for each
+    for _, name := range []string{"hashcat.tar.gz", "hashcat.tgz", "hashcat.zip",
+                                  "hashcat.7z"} {
+        err := os.WriteFile(testDir + "/" + name, []byte("archive"), 0644)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+    }
+
+    // Ensure the archive formats of each client OS are accepted
+    assert.Equal(nil, validate.ValidateHashcatArtifact(testDir + "/hashcat.tar.gz", "linux"))
+    assert.Equal(nil, validate.ValidateHashcatArtifact(testDir + "/hashcat.tgz", ""))
+    assert.Equal(nil, validate.ValidateHashcatArtifact(testDir + "/hashcat.zip", "windows"))
+
+    // Ensure the archive formats of the other client OS are rejected
+    assert.NotEqual(nil, validate.ValidateHashcatArtifact(testDir + "/hashcat.zip", "linux"))
+    assert.NotEqual(nil, validate.ValidateHashcatArtifact(testDir + "/hashcat.tgz", "windows"))
+    assert.NotEqual(nil, validate.ValidateHashcatArtifact(testDir + "/hashcat.7z", "linux"))
+    // Ensure a missing archive is rejected
+    assert.NotEqual(nil, validate.ValidateHashcatArtifact(testDir + "/missing.tgz", "linux"))
+}
+
+
+func TestValidateHashcatJobs(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := []int{0, 1, 8, 16}
+    // Iterate through slice of truths and test them
+    for _, truth := range truths {
+        assert.True(validate.ValidateHashcatJobs(truth))
+    }
+
+    falacies := []int{-1, 17, 32}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        assert.False(validate.ValidateHashcatJobs(falacy))
+    }
+}
+
+
+func TestValidateHashcatRelease(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    successes := []string{"", "v6.2.6", "v7.0.0"}
+    // Iterate through slice of proper values and test them
+    for _, success := range successes {
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, validate.ValidateHashcatRelease(success))
+    }
+
+    falacies := []string{"6.2.6", "v6.2", "master", "v6.2.6; reboot"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        // Ensure the error is not nil meaning failed operation
+        assert.NotEqual(nil, validate.ValidateHashcatRelease(falacy))
+    }
+}
+
+
 func TestValidateHashFile(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -258,6 +573,53 @@ func TestValidateHashType(t *testing.T) {
 }
 
 
+func TestValidateIamPolicyArn(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    successes := []string{"arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore",
+                          "arn:aws:iam::123456789012:policy/boundaries/KrakenBoundary",
+                          "arn:aws-us-gov:iam::123456789012:policy/KrakenBoundary"}
+    // Iterate through slice of proper values and test them
+    for _, success := range successes {
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, validate.ValidateIamPolicyArn(success))
+    }
+
+    falacies := []string{"", "KrakenBoundary", "arn:aws:iam::1234:policy/KrakenBoundary",
+                         "arn:aws:iam::123456789012:role/KrakenBoundary"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        // Ensure the error is not nil meaning failed operation
+        assert.NotEqual(nil, validate.ValidateIamPolicyArn(falacy))
+    }
+}
+
+
+func TestValidateIamRoleArn(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    successes := []string{"arn:aws:iam::123456789012:role/KrakenClient",
+                          "arn:aws:iam::123456789012:role/service-roles/KrakenClient",
+                          "arn:aws-cn:iam::123456789012:role/KrakenClient"}
+    // Iterate through slice of proper values and test them
+    for _, success := range successes {
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, validate.ValidateIamRoleArn(success))
+    }
+
+    falacies := []string{"", "KrakenClient", "arn:aws:iam::aws:role/KrakenClient",
+                         "arn:aws:iam::123456789012:user/KrakenClient",
+                         "arn:aws:iam::123456789012:role/Kraken Client"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        // Ensure the error is not nil meaning failed operation
+        assert.NotEqual(nil, validate.ValidateIamRoleArn(falacy))
+    }
+}
+
+
 func TestValidateIamUsername(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -274,6 +636,38 @@ func TestValidateIamUsername(t *testing.T) {
 }
 
 
+func TestValidateInstanceId(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Try test with proper value
+    err := validate.ValidateInstanceId("i-0a1b2c3d4e5f6a7b8")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Try test with bad value
+    err = validate.ValidateInstanceId("i-0a1b2c3g")
+    // Ensure the error is not nil meaning failed operation
+    assert.NotEqual(nil, err)
+}
+
+
+func TestValidateInstanceProfile(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Try test with proper value
+    err := validate.ValidateInstanceProfile("KrakenClientProfile")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Try test with bad value
+    err = validate.ValidateInstanceProfile("Kraken/Client Profile")
+    // Ensure the error is not nil meaning failed operation
+    assert.NotEqual(nil, err)
+}
+
+
 func TestValidateInstanceType(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -282,12 +676,58 @@ func TestValidateInstanceType(t *testing.T) {
     isType := validate.ValidateInstanceType("g4dn.12xlarge")
     assert.True(isType)
 
+    // Try test with Graviton GPU value
+    isType = validate.ValidateInstanceType("g5g.4xlarge")
+    assert.True(isType)
+
     // Try test with bad value
     isType = validate.ValidateInstanceType("blahblah")
     assert.False(isType)
 }
 
 
+func TestValidateKeyspaceChunks(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Ensure disabled keyspace splitting is valid with any mode
+    assert.True(validate.ValidateKeyspaceChunks(0, "0", ""))
+    // Ensure keyspace splitting is valid with a mask attack
+    assert.True(validate.ValidateKeyspaceChunks(64, "3", "?a?a?a?a?a?a"))
+
+    // Ensure negative chunks are invalid
+    assert.False(validate.ValidateKeyspaceChunks(-1, "3", "?a?a?a"))
+    // Ensure keyspace splitting is invalid without a pure mask attack
+    assert.False(validate.ValidateKeyspaceChunks(64, "6", "?d?d?d"))
+    // Ensure keyspace splitting is invalid without a hash mask
+    assert.False(validate.ValidateKeyspaceChunks(64, "3", ""))
+}
+
+
+func TestValidateKmsKeyId(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    successes := []string{"1234abcd-12ab-34cd-56ef-1234567890ab",
+                          "mrk-1234abcd12ab34cd56ef1234567890ab",
+                          "arn:aws-us-gov:kms:us-gov-west-1:123456789012:key/" +
+                          "1234abcd-12ab-34cd-56ef-1234567890ab"}
+    // Iterate through slice of proper values and test them
+    for _, success := range successes {
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, validate.ValidateKmsKeyId(success))
+    }
+
+    falacies := []string{"", "alias/kloud-kraken", "1234abcd-12ab-34cd-56ef",
+                         "arn:aws:kms:us-east-1:123456789012:alias/kloud-kraken"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        // Ensure the error is not nil meaning failed operation
+        assert.NotEqual(nil, validate.ValidateKmsKeyId(falacy))
+    }
+}
+
+
 func TestValidateListenerPort(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -411,6 +851,24 @@ func TestValidateMaxTransfers(t *testing.T) {
 }
 
 
+func TestValidateMetricsPort(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := []int{0, 1001, 9100, 65535}
+    // Iterate through slice of truths and test them
+    for _, truth := range truths {
+        assert.True(validate.ValidateMetricsPort(truth, 6969, 8443))
+    }
+
+    falacies := []int{80, 1000, 6969, 8443, 70000}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        assert.False(validate.ValidateMetricsPort(falacy, 6969, 8443))
+    }
+}
+
+
 func TestValidateNumberInstances(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -424,6 +882,42 @@ func TestValidateNumberInstances(t *testing.T) {
 }
 
 
+func TestValidateNumberList(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := []string{"", "1", "1,2,3,4", "64,128", "10"}
+    // Iterate through slice of truths and test them
+    for _, truth := range truths {
+        assert.True(validate.ValidateNumberList(truth))
+    }
+
+    falacies := []string{"0", "-1", "1,", ",1", "1 ,2", "1;2", "01"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        assert.False(validate.ValidateNumberList(falacy))
+    }
+}
+
+
+func TestValidateParallelConnections(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := []int{0, 1, 4, 16}
+    // Iterate through slice of truths and test them
+    for _, truth := range truths {
+        assert.True(validate.ValidateParallelConnections(truth))
+    }
+
+    falacies := []int{-1, 17, 64}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        assert.False(validate.ValidateParallelConnections(falacy))
+    }
+}
+
+
 func TestValidatePath(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -458,17 +952,59 @@ func TestValidatePath(t *testing.T) {
 }
 
 
+func TestValidatePreprocessStages(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := [][]string{nil, {"stats"}, {"frequency_sort"}, {"stats", "frequency_sort"}}
+    // Iterate through slice of truths and test them
+    for _, truth := range truths {
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, validate.ValidatePreprocessStages(truth))
+    }
+
+    falacies := [][]string{{"markov"}, {"Stats"}, {"stats", "stats"}, {""}}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        // Ensure the error is not nil meaning failed operation
+        assert.NotEqual(nil, validate.ValidatePreprocessStages(falacy))
+    }
+}
+
+
+func TestValidateQuota(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := map[string]int64{"": 0, "0": 0, "50GB": 50 * globals.GB, "1024": 1024}
+    // Iterate through map of truths and test them
+    for truth, expected := range truths {
+        quota, err := validate.ValidateQuota(truth)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        assert.Equal(expected, quota)
+    }
+
+    falacies := []string{"-1", "abc", "10TB"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        _, err := validate.ValidateQuota(falacy)
+        assert.NotNil(err)
+    }
+}
+
+
 func TestValidateRegion(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
 
-    truths := []string{"us-east-1", "us-east-2", "us-west-2"}
+    truths := []string{"us-east-1", "us-east-2", "us-west-2", "us-gov-west-1", "cn-north-1"}
     // Iterate through slice of truths and test them
     for _, truth := range truths {
         assert.True(validate.ValidateRegion(truth))
     }
 
-    falacies := []string{"test", "string", "nonsense"}
+    falacies := []string{"test", "string", "nonsense", "aws-global"}
     // Iterate through slice of truths and test them
     for _, falacy := range falacies {
         assert.False(validate.ValidateRegion(falacy))
@@ -476,6 +1012,33 @@ func TestValidateRegion(t *testing.T) {
 }
 
 
+func TestValidateReservedSpace(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := map[string]disk.Reserve{
+        "":     {Bytes: globals.OS_RESERVED_SPACE},
+        "10GB": {Bytes: 10 * globals.GB},
+        "5%":   {Percent: 5},
+        "2.5%": {Percent: 2.5},
+    }
+    // Iterate through map of truths and test them
+    for truth, expected := range truths {
+        reserve, err := validate.ValidateReservedSpace(truth)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        assert.Equal(expected, reserve)
+    }
+
+    falacies := []string{"0%", "100%", "-5%", "x%", "0", "abc"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        _, err := validate.ValidateReservedSpace(falacy)
+        assert.NotNil(err)
+    }
+}
+
+
 func TestValidateRulesetFile(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -519,6 +1082,72 @@ func TestValidateRulesetFile(t *testing.T) {
 }
 
 
+func TestValidateRunName(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := []string{"", "acme-q3", "Engagement_2024.1", strings.Repeat("a", 64)}
+    // Iterate through slice of truths and test them
+    for _, truth := range truths {
+        assert.True(validate.ValidateRunName(truth))
+    }
+
+    falacies := []string{"-acme", "acme q3", "acme/q3", "acme:q3", strings.Repeat("a", 65)}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        assert.False(validate.ValidateRunName(falacy))
+    }
+}
+
+
+func TestValidateRunTags(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Try test with proper value
+    err := validate.ValidateRunTags(map[string]string{"Client": "Acme Corp",
+                                                      "cost-center": "sec/ops:42",
+                                                      "Ticket": ""}, "Service", "RunId")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    tooMany := map[string]string{}
+    for index := range validate.MaxRunTags + 1 {
+        tooMany[fmt.Sprintf("tag%d", index)] = "value"
+    }
+
+    falacies := []map[string]string{tooMany, {"Service": "other"}, {"aws:created": "x"},
+                                    {"": "x"}, {"bad*key": "x"}, {"key": "bad$value"},
+                                    {strings.Repeat("k", 129): "x"},
+                                    {"key": strings.Repeat("v", 257)}}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        err = validate.ValidateRunTags(falacy, "Service", "RunId")
+        // Ensure the error is not nil meaning failed operation
+        assert.NotEqual(nil, err)
+    }
+}
+
+
+func TestValidateScheduleStrategy(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := []string{"", "hit_rate", "largest_first", "priority", "round_robin", "size",
+                       "smallest_first"}
+    // Iterate through slice of truths and test them
+    for _, truth := range truths {
+        assert.True(validate.ValidateScheduleStrategy(truth))
+    }
+
+    falacies := []string{"hitrate", "SIZE", "random", "largest", "roundrobin"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        assert.False(validate.ValidateScheduleStrategy(falacy))
+    }
+}
+
+
 func TestValidateSecurityGroupIds(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -562,6 +1191,26 @@ func TestValidateSecurityGroups(t *testing.T) {
 }
 
 
+func TestValidateSha256(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Ensure a hex encoded digest is accepted in either case
+    assert.Equal(nil, validate.ValidateSha256(
+        "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"))
+    assert.Equal(nil, validate.ValidateSha256(
+        "9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08"))
+
+    falacies := []string{"", "9f86d081884c7d659a2feaa0c55ad015",
+                         "zz86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        // Ensure the error is not nil meaning failed operation
+        assert.NotEqual(nil, validate.ValidateSha256(falacy))
+    }
+}
+
+
 func TestValidateSubnetId(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -578,6 +1227,81 @@ func TestValidateSubnetId(t *testing.T) {
 }
 
 
+func TestValidateSummaryExport(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := [][]string{nil, {"json"}, {"markdown"}, {"json", "markdown"}}
+    // Iterate through slice of truths and test them
+    for _, truth := range truths {
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, validate.ValidateSummaryExport(truth))
+    }
+
+    falacies := [][]string{{"md"}, {"JSON"}, {"json", "json"}, {""}}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        // Ensure the error is not nil meaning failed operation
+        assert.NotEqual(nil, validate.ValidateSummaryExport(falacy))
+    }
+}
+
+
+func TestValidateUsername(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := []string{"kloudkraken", "_cracker", "hash-user1"}
+    // Iterate through slice of truths and test them
+    for _, truth := range truths {
+        assert.True(validate.ValidateUsername(truth))
+    }
+
+    falacies := []string{"", "root", "Kraken", "1user", "user name",
+                         "averyveryveryverylongusernameover32"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        assert.False(validate.ValidateUsername(falacy))
+    }
+}
+
+
+func TestValidateVerifyHashes(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := []string{"", "reject", "report"}
+    // Iterate through slice of truths and test them
+    for _, truth := range truths {
+        assert.True(validate.ValidateVerifyHashes(truth))
+    }
+
+    falacies := []string{"rejects", "REPORT", "warn"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        assert.False(validate.ValidateVerifyHashes(falacy))
+    }
+}
+
+
+func TestValidateWebUiPort(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := []int{0, 1001, 8443, 65535}
+    // Iterate through slice of truths and test them
+    for _, truth := range truths {
+        assert.True(validate.ValidateWebUiPort(truth, 6969))
+    }
+
+    falacies := []int{80, 1000, 6969, 70000}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        assert.False(validate.ValidateWebUiPort(falacy, 6969))
+    }
+}
+
+
 func TestValidateWorkload(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -0,0 +1,348 @@
+package admin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+    CodeParse          = -32700
+    CodeInvalidRequest = -32600
+    CodeMethodNotFound = -32601
+    CodeInvalidParams  = -32602
+    CodeInternal       = -32603
+)
+
+// Package level variables
+var MaxRequestSize = 1024 * 1024  // Largest request line read from an admin connection
+
+
+// Request is a single JSON-RPC 2.0 request read from the admin socket
+type Request struct {
+    Id      json.RawMessage `json:"id,omitempty"`
+    JsonRpc string          `json:"jsonrpc"`
+    Method  string          `json:"method"`
+    Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is the JSON-RPC 2.0 response written back for a request
+type Response struct {
+    Error   *Error          `json:"error,omitempty"`
+    Id      json.RawMessage `json:"id"`
+    JsonRpc string          `json:"jsonrpc"`
+    Result  any             `json:"result,omitempty"`
+}
+
+// Error is the error object of a failed JSON-RPC request
+type Error struct {
+    Code    int    `json:"code"`
+    Message string `json:"message"`
+}
+
+// Formats the error object as a Go error message.
+//
+// @Returns
+// - The formatted error message
+//
+func (rpcErr *Error) Error() string {
+    return fmt.Sprintf("admin error %d - %s", rpcErr.Code, rpcErr.Message)
+}
+
+
+// Handler runs an admin method with its raw params and returns its result, an *Error
+// is passed through to the caller as is while any other error is an internal error
+type Handler func(params json.RawMessage) (any, error)
+
+
+// Server serves the registered admin methods over a unix socket, one JSON request per
+// line and one JSON response per line
+type Server struct {
+    handlers   map[string]Handler
+    listener   net.Listener
+    mutx       sync.Mutex
+    socketPath string
+    waitGroup  sync.WaitGroup
+}
+
+// Creates an admin server without any methods registered.
+//
+// @Returns
+// - The initialized admin server
+//
+func NewServer() *Server {
+    return &Server{handlers: map[string]Handler{}}
+}
+
+// Registers the handler of an admin method, replacing any existing one.
+//
+// @Parameters
+// - method:  The name of the method
+// - handler:  The handler run when the method is called
+//
+func (server *Server) Register(method string, handler Handler) {
+    server.mutx.Lock()
+    defer server.mutx.Unlock()
+
+    server.handlers[method] = handler
+}
+
+// Runs the handler of the requested method and formats its response.
+//
+// @Parameters
+// - request:  The request to be dispatched
+//
+// @Returns
+// - The response to the request
+//
+func (server *Server) Dispatch(request Request) Response {
+    response := Response{Id: request.Id, JsonRpc: "2.0"}
+    // If the id was omitted, it is returned as null
+    if len(response.Id) == 0 {
+        response.Id = json.RawMessage("null")
+    }
+
+    // If the request is not JSON-RPC 2.0 or has no method
+    if request.JsonRpc != "2.0" || request.Method == "" {
+        response.Error = &Error{Code: CodeInvalidRequest, Message: "invalid request"}
+        return response
+    }
+
+    server.mutx.Lock()
+    handler, exists := server.handlers[request.Method]
+    server.mutx.Unlock()
+    // If the method is not registered
+    if !exists {
+        response.Error = &Error{
+            Code:    CodeMethodNotFound,
+            Message: "method not found:  " + request.Method,
+        }
+        return response
+    }
+
+    result, err := handler(request.Params)
+    if err != nil {
+        var rpcErr *Error
+        // If the handler did not return a JSON-RPC error, it is an internal error
+        if !errors.As(err, &rpcErr) {
+            rpcErr = &Error{Code: CodeInternal, Message: err.Error()}
+        }
+
+        response.Error = rpcErr
+        return response
+    }
+
+    // A method without a result still returns one so success is distinguishable
+    if result == nil {
+        result = true
+    }
+    response.Result = result
+
+    return response
+}
+
+// Listens on the unix socket and serves admin connections in a separate goroutine. A
+// stale socket left by a previous run is removed first, and the socket is only
+// accessible by the user running the server.
+//
+// @Parameters
+// - socketPath:  The path of the unix socket
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (server *Server) Start(socketPath string) error {
+    // If a socket file remains from a run that did not stop cleanly
+    if info, err := os.Lstat(socketPath); err == nil {
+        if info.Mode().Type() != os.ModeSocket {
+            return fmt.Errorf("admin socket path %s exists and is not a socket", socketPath)
+        }
+
+        os.Remove(socketPath)
+    }
+
+    listener, err := net.Listen("unix", socketPath)
+    if err != nil {
+        return fmt.Errorf("error listening on admin socket - %w", err)
+    }
+
+    err = os.Chmod(socketPath, 0600)
+    if err != nil {
+        listener.Close()
+        return fmt.Errorf("error setting admin socket permissions - %w", err)
+    }
+
+    server.listener = listener
+    server.socketPath = socketPath
+
+    server.waitGroup.Add(1)
+    go func() {
+        defer server.waitGroup.Done()
+
+        // Accept connections until the listener is closed
+        for {
+            connection, err := listener.Accept()
+            if err != nil {
+                return
+            }
+
+            server.waitGroup.Add(1)
+            go server.serve(connection)
+        }
+    } ()
+
+    return nil
+}
+
+// Reads requests from the admin connection and writes their responses until the
+// connection is closed.
+//
+// @Parameters
+// - connection:  The admin connection
+//
+func (server *Server) serve(connection net.Conn) {
+    defer server.waitGroup.Done()
+    // Close the connection on local exit
+    defer connection.Close()
+
+    scanner := bufio.NewScanner(connection)
+    scanner.Buffer(make([]byte, 0, 4096), MaxRequestSize)
+    encoder := json.NewEncoder(connection)
+
+    // Iterate through the request lines until the client disconnects
+    for scanner.Scan() {
+        // If the line is blank
+        if len(scanner.Bytes()) == 0 {
+            continue
+        }
+
+        var request Request
+        var response Response
+
+        err := json.Unmarshal(scanner.Bytes(), &request)
+        if err != nil {
+            response = Response{
+                Error:   &Error{Code: CodeParse, Message: "parse error"},
+                Id:      json.RawMessage("null"),
+                JsonRpc: "2.0",
+            }
+        } else {
+            response = server.Dispatch(request)
+        }
+
+        err = encoder.Encode(response)
+        if err != nil {
+            return
+        }
+    }
+}
+
+// Stops accepting admin connections, waits for the open ones to close, and removes
+// the socket.
+//
+// @Parameters
+// - timeout:  The max amount of time to wait for open connections
+//
+func (server *Server) Stop(timeout time.Duration) {
+    if server == nil || server.listener == nil {
+        return
+    }
+
+    server.listener.Close()
+
+    done := make(chan struct{})
+    go func() {
+        server.waitGroup.Wait()
+        close(done)
+    } ()
+
+    select {
+    case <-done:
+    case <-time.After(timeout):
+    }
+
+    os.Remove(server.socketPath)
+}
+
+
+// Unmarshals the params of a request into the passed in value, formatting any failure
+// as an invalid params error.
+//
+// @Parameters
+// - params:  The raw params of the request
+// - value:  Pointer to the value the params are unmarshaled into
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func ParseParams(params json.RawMessage, value any) error {
+    // If the method was called without params
+    if len(params) == 0 {
+        return &Error{Code: CodeInvalidParams, Message: "missing params"}
+    }
+
+    err := json.Unmarshal(params, value)
+    if err != nil {
+        return &Error{Code: CodeInvalidParams, Message: "invalid params - " + err.Error()}
+    }
+
+    return nil
+}
+
+
+// Calls an admin method over the unix socket of a running server.
+//
+// @Parameters
+// - socketPath:  The path of the unix socket
+// - method:  The name of the method
+// - params:  The JSON params of the method, nil for none
+// - timeout:  The max amount of time to wait for the response
+//
+// @Returns
+// - The raw result of the method
+// - Error if it occurs, otherwise nil on success
+//
+func Call(socketPath string, method string, params json.RawMessage,
+          timeout time.Duration) (json.RawMessage, error) {
+    connection, err := net.DialTimeout("unix", socketPath, timeout)
+    if err != nil {
+        return nil, fmt.Errorf("error connecting to admin socket - %w", err)
+    }
+    // Close the connection on local exit
+    defer connection.Close()
+
+    connection.SetDeadline(time.Now().Add(timeout))
+
+    request := Request{
+        Id:      json.RawMessage("1"),
+        JsonRpc: "2.0",
+        Method:  method,
+        Params:  params,
+    }
+    err = json.NewEncoder(connection).Encode(request)
+    if err != nil {
+        return nil, fmt.Errorf("error sending admin request - %w", err)
+    }
+
+    var response struct {
+        Error  *Error          `json:"error"`
+        Result json.RawMessage `json:"result"`
+    }
+    err = json.NewDecoder(connection).Decode(&response)
+    if err != nil {
+        return nil, fmt.Errorf("error reading admin response - %w", err)
+    }
+
+    // If the method failed on the server
+    if response.Error != nil {
+        return nil, response.Error
+    }
+
+    return response.Result, nil
+}
@@ -0,0 +1,91 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/admin"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestDispatch(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    server := admin.NewServer()
+    server.Register("pause", func(params json.RawMessage) (any, error) {
+        return nil, nil
+    })
+    server.Register("fail", func(params json.RawMessage) (any, error) {
+        return nil, errors.New("boom")
+    })
+
+    response := server.Dispatch(admin.Request{JsonRpc: "2.0", Method: "pause",
+                                              Id: json.RawMessage("7")})
+    assert.Nil(response.Error)
+    assert.Equal(true, response.Result)
+    assert.Equal(json.RawMessage("7"), response.Id)
+
+    // Ensure handler errors are returned as internal errors
+    response = server.Dispatch(admin.Request{JsonRpc: "2.0", Method: "fail"})
+    assert.Equal(admin.CodeInternal, response.Error.Code)
+    assert.Equal(json.RawMessage("null"), response.Id)
+
+    falacies := []admin.Request{
+        {JsonRpc: "1.0", Method: "pause"},
+        {JsonRpc: "2.0", Method: ""},
+        {JsonRpc: "2.0", Method: "missing"},
+    }
+    codes := []int{admin.CodeInvalidRequest, admin.CodeInvalidRequest,
+                   admin.CodeMethodNotFound}
+    // Iterate through slice of falacies and test them
+    for index, falacy := range falacies {
+        assert.Equal(codes[index], server.Dispatch(falacy).Error.Code)
+    }
+}
+
+
+func TestCall(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    socketPath := filepath.Join(t.TempDir(), "admin.sock")
+    server := admin.NewServer()
+    server.Register("add_budget", func(params json.RawMessage) (any, error) {
+        var args struct {
+            Amount float64 `json:"amount"`
+        }
+        err := admin.ParseParams(params, &args)
+        if err != nil {
+            return nil, err
+        }
+
+        return map[string]float64{"max_cost": 10 + args.Amount}, nil
+    })
+
+    err := server.Start(socketPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    defer server.Stop(time.Second)
+
+    result, err := admin.Call(socketPath, "add_budget", json.RawMessage(`{"amount":5}`),
+                              time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.JSONEq(`{"max_cost":15}`, string(result))
+
+    // Ensure missing params are returned as a JSON-RPC error
+    _, err = admin.Call(socketPath, "add_budget", nil, time.Second)
+    var rpcErr *admin.Error
+    assert.True(errors.As(err, &rpcErr))
+    assert.Equal(admin.CodeInvalidParams, rpcErr.Code)
+
+    // Ensure the socket is removed once stopped
+    server.Stop(time.Second)
+    _, err = admin.Call(socketPath, "add_budget", nil, time.Second)
+    assert.NotEqual(nil, err)
+}
@@ -0,0 +1,155 @@
+package admission
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+)
+
+
+// SpaceFunc gets the bytes the wordlists can still fill, such as the smaller of the free
+// disk space and the remaining wordlist quota
+type SpaceFunc func() (int64, error)
+
+
+// Controller admits wordlist transfers by the exact space they need, woken by the events
+// that free space, such as a processed wordlist being deleted, instead of polling the disk
+type Controller struct {
+    changed chan struct{}  // Closed and replaced whenever space may have been freed
+    mutx    sync.Mutex
+    recheck time.Duration  // Max time between space checks when no event arrives
+    space   SpaceFunc
+}
+
+// Creates a new admission controller.
+//
+// @Parameters
+// - space:  Gets the bytes the wordlists can still fill
+// - recheck:  The max time waited for an event before the space is checked again, which
+//             catches space freed outside of the client
+//
+// @Returns
+// - The initialized admission controller
+//
+func New(space SpaceFunc, recheck time.Duration) *Controller {
+    return &Controller{changed: make(chan struct{}), recheck: recheck, space: space}
+}
+
+// Signals that space may have been freed, such as a wordlist deleted after processing
+// or a transfer ending, waking any wait for the next admission. A nil controller ignores it.
+func (controller *Controller) Notify() {
+    // If no controller is used, nothing waits on admission
+    if controller == nil {
+        return
+    }
+
+    controller.mutx.Lock()
+    defer controller.mutx.Unlock()
+
+    close(controller.changed)
+    controller.changed = make(chan struct{})
+}
+
+// Gets a channel closed on the next event, taken before the space is checked so an
+// event between the check and the wait is not missed.
+//
+// @Returns
+// - The channel closed on the next event
+//
+func (controller *Controller) Changed() <-chan struct{} {
+    controller.mutx.Lock()
+    defer controller.mutx.Unlock()
+
+    return controller.changed
+}
+
+// Gets the bytes free for the next transfer once the transfers still being received
+// are accounted for.
+//
+// @Parameters
+// - reserved:  The bytes of the transfers still being received
+//
+// @Returns
+// - The bytes free for the next transfer, 0 when none are
+// - Error if it occurs, otherwise nil on success
+//
+func (controller *Controller) Available(reserved int64) (int64, error) {
+    space, err := controller.space()
+    if err != nil {
+        return 0, err
+    }
+
+    return max(space - reserved, 0), nil
+}
+
+// Waits until the passed in change channel is closed by an event, the recheck interval
+// passes, or the context is done.
+//
+// @Parameters
+// - ctx:  Stops the wait when canceled
+// - changed:  The change channel taken before the space was last checked
+//
+// @Returns
+// - Error if the context is done, otherwise nil
+//
+func (controller *Controller) Wait(ctx context.Context, changed <-chan struct{}) error {
+    timer := time.NewTimer(controller.recheck)
+    defer timer.Stop()
+
+    select {
+    case <-changed:
+    case <-timer.C:
+    case <-ctx.Done():
+        return ctx.Err()
+    }
+
+    return nil
+}
+
+
+// Formats the transfer request carrying the bytes the client has free, so the server
+// selects a wordlist that fits.
+//
+// @Parameters
+// - available:  The bytes free for the transfer
+//
+// @Returns
+// - The formatted transfer request
+//
+func FormatRequest(available int64) []byte {
+    message := append([]byte{}, globals.TRANSFER_FIT_PREFIX...)
+    message = strconv.AppendInt(message, available, 10)
+    return append(message, globals.TRANSFER_SUFFIX...)
+}
+
+
+// Parses the bytes the client has free from the transfer request.
+//
+// @Parameters
+// - message:  The transfer request
+//
+// @Returns
+// - The bytes free for the transfer
+// - Error if it occurs, otherwise nil on success
+//
+func ParseRequest(message []byte) (int64, error) {
+    // If the message does not start with prefix or end with closed bracket
+    if !bytes.HasPrefix(message, globals.TRANSFER_FIT_PREFIX) ||
+       !bytes.HasSuffix(message, globals.TRANSFER_SUFFIX) {
+        return 0, fmt.Errorf("improper prefix or suffix in transfer request")
+    }
+
+    body := bytes.TrimSuffix(bytes.TrimPrefix(message, globals.TRANSFER_FIT_PREFIX),
+                             globals.TRANSFER_SUFFIX)
+    available, err := strconv.ParseInt(string(body), 10, 64)
+    if err != nil || available <= 0 {
+        return 0, fmt.Errorf("improper free space in transfer request")
+    }
+
+    return available, nil
+}
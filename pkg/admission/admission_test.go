@@ -0,0 +1,89 @@
+package admission_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/admission"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestRequest(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    available, err := admission.ParseRequest(admission.FormatRequest(1048576))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(int64(1048576), available)
+
+    // Ensure improper requests are rejected
+    for _, message := range []string{"<TRANSFER_REQUEST>", "<TRANSFER_FIT:0>",
+                                     "<TRANSFER_FIT:-5>", "<TRANSFER_FIT:big>"} {
+        _, err = admission.ParseRequest([]byte(message))
+        assert.NotEqual(nil, err)
+    }
+}
+
+
+func TestController(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    space := int64(4096)
+
+    controller := admission.New(func() (int64, error) {
+        return space, nil
+    }, time.Hour)
+
+    available, err := controller.Available(1024)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(int64(3072), available)
+
+    // Ensure the space reserved by ongoing transfers never goes negative
+    available, err = controller.Available(8192)
+    assert.Equal(nil, err)
+    assert.Equal(int64(0), available)
+
+    changed := controller.Changed()
+    woken := make(chan error, 1)
+    go func() {
+        woken <- controller.Wait(context.Background(), changed)
+    } ()
+
+    controller.Notify()
+    // Ensure the wait returns once space is freed
+    select {
+    case err = <-woken:
+        assert.Equal(nil, err)
+    case <-time.After(5 * time.Second):
+        t.Fatal("admission wait was not woken by the notify")
+    }
+
+    // Ensure a nil controller ignores notifies
+    var unset *admission.Controller
+    unset.Notify()
+}
+
+
+func TestControllerRecheck(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    controller := admission.New(func() (int64, error) {
+        return 0, nil
+    }, 50 * time.Millisecond)
+
+    start := time.Now()
+    // Ensure the wait returns after the recheck interval without an event
+    err := controller.Wait(context.Background(), controller.Changed())
+    assert.Equal(nil, err)
+    assert.GreaterOrEqual(time.Since(start), 50 * time.Millisecond)
+
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    controller = admission.New(nil, time.Hour)
+    // Ensure the wait stops when the context is canceled
+    assert.Equal(context.Canceled, controller.Wait(ctx, controller.Changed()))
+}
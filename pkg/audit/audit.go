@@ -0,0 +1,384 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Categories of the audited actions
+const (
+    CategoryAws     = "aws"      // AWS API calls that create, change, or delete resources
+    CategoryFile    = "file"     // Files sent to or received from clients
+    CategoryHashcat = "hashcat"  // Hashcat executions reported by clients
+    CategoryRun     = "run"      // Start and end of the run
+)
+
+// Max bytes of a single audit log line read back when resuming or verifying
+const MaxEntrySize = 1024 * 1024
+
+
+// Entry is a single audited action, chained to the previous entry by its hash so a
+// removed or altered line breaks the chain
+type Entry struct {
+    Action   string         `json:"action"`
+    Category string         `json:"category"`
+    Fields   map[string]any `json:"fields,omitempty"`
+    Hash     string         `json:"hash,omitempty"`
+    Prev     string         `json:"prev"`
+    Seq      int64          `json:"seq"`
+    Time     time.Time      `json:"time"`
+}
+
+
+// Log is the append-only JSONL audit log, safe for concurrent use
+type Log struct {
+    err     error
+    file    *os.File
+    forward bool
+    mutx    sync.Mutex
+    path    string
+    pending []Entry
+    prev    string
+    seq     int64
+    sink    func(entry Entry)
+}
+
+
+// Computes the hash of the entry chained to the hash of the previous entry.
+//
+// @Parameters
+// - entry:  The entry to hash, its own hash is ignored
+//
+// @Returns
+// - The hex SHA-256 of the previous hash and the JSON of the entry
+// - Error if it occurs, otherwise nil on success
+//
+func chainHash(entry Entry) (string, error) {
+    entry.Hash = ""
+
+    data, err := json.Marshal(entry)
+    if err != nil {
+        return "", err
+    }
+
+    hasher := sha256.New()
+    hasher.Write([]byte(entry.Prev))
+    hasher.Write(data)
+
+    return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+
+// Reads the entries of the audit log in order, numbers are kept as written so the
+// entries hash the same as when they were recorded.
+//
+// @Parameters
+// - reader:  The reader of the audit log
+// - visit:  Called with each entry and its line number, stops the read on error
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func readEntries(reader io.Reader, visit func(entry Entry, line int) error) error {
+    scanner := bufio.NewScanner(reader)
+    scanner.Buffer(make([]byte, 64 * 1024), MaxEntrySize)
+    line := 0
+
+    for scanner.Scan() {
+        line++
+        // If the line is empty
+        if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+            continue
+        }
+
+        var entry Entry
+        decoder := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+        decoder.UseNumber()
+
+        err := decoder.Decode(&entry)
+        if err != nil {
+            return fmt.Errorf("error parsing audit entry on line %d - %w", line, err)
+        }
+
+        err = visit(entry, line)
+        if err != nil {
+            return err
+        }
+    }
+
+    return scanner.Err()
+}
+
+
+// Opens the audit log at the passed in path for appending, continuing the hash chain of
+// the entries already in it.
+//
+// @Parameters
+// - path:  The path of the audit log
+// - forward:  Whether entries are held for a sink set later with SetSink()
+//
+// @Returns
+// - The opened audit log
+// - Error if it occurs, otherwise nil on success
+//
+func Open(path string, forward bool) (*Log, error) {
+    auditLog := &Log{forward: forward, path: path}
+
+    existing, err := os.Open(path)
+    // If the audit log already exists, resume its chain from the last entry
+    if err == nil {
+        err = readEntries(existing, func(entry Entry, _ int) error {
+            auditLog.prev = entry.Hash
+            auditLog.seq = entry.Seq
+            return nil
+        })
+        existing.Close()
+        if err != nil {
+            return nil, fmt.Errorf("error reading existing audit log - %w", err)
+        }
+    } else if !errors.Is(err, os.ErrNotExist) {
+        return nil, fmt.Errorf("error opening existing audit log - %w", err)
+    }
+
+    auditLog.file, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+    if err != nil {
+        return nil, fmt.Errorf("error opening audit log - %w", err)
+    }
+
+    return auditLog, nil
+}
+
+
+// Records an audited action, appending it to the log file and passing it to the sink.
+// A failed write is kept and returned by Close() so the action is never blocked on it.
+//
+// @Parameters
+// - category:  The category of the action
+// - action:  The name of the action
+// - fields:  The details of the action
+//
+func (auditLog *Log) Record(category string, action string, fields map[string]any) {
+    // If auditing is disabled
+    if auditLog == nil {
+        return
+    }
+
+    auditLog.mutx.Lock()
+    defer auditLog.mutx.Unlock()
+
+    entry := Entry{
+        Action:   action,
+        Category: category,
+        Fields:   fields,
+        Prev:     auditLog.prev,
+        Seq:      auditLog.seq + 1,
+        Time:     time.Now().UTC(),
+    }
+
+    var data []byte
+    hash, err := chainHash(entry)
+    if err == nil {
+        entry.Hash = hash
+        data, err = json.Marshal(entry)
+    }
+    if err == nil {
+        _, err = auditLog.file.Write(append(data, '\n'))
+    }
+    if err != nil {
+        // Keep the first error, the chain continues from the last written entry
+        if auditLog.err == nil {
+            auditLog.err = fmt.Errorf("error writing audit entry %q - %w", action, err)
+        }
+        return
+    }
+
+    auditLog.prev = entry.Hash
+    auditLog.seq = entry.Seq
+
+    // If entries are forwarded, pass it to the sink or hold it until one is set
+    if auditLog.sink != nil {
+        auditLog.sink(entry)
+    } else if auditLog.forward {
+        auditLog.pending = append(auditLog.pending, entry)
+    }
+}
+
+
+// Sets where the recorded entries are forwarded to, passing it the held entries first.
+//
+// @Parameters
+// - sink:  Receives each recorded entry in order
+//
+func (auditLog *Log) SetSink(sink func(entry Entry)) {
+    // If auditing is disabled
+    if auditLog == nil {
+        return
+    }
+
+    auditLog.mutx.Lock()
+    defer auditLog.mutx.Unlock()
+
+    // Iterate through the entries recorded before the sink was set
+    for _, entry := range auditLog.pending {
+        sink(entry)
+    }
+
+    auditLog.pending = nil
+    auditLog.sink = sink
+}
+
+
+// Gets the path of the audit log.
+//
+// @Returns
+// - The path of the audit log file
+//
+func (auditLog *Log) Path() string {
+    return auditLog.path
+}
+
+
+// Closes the audit log file.
+//
+// @Returns
+// - The first error writing an entry or closing the file, otherwise nil on success
+//
+func (auditLog *Log) Close() error {
+    // If auditing is disabled
+    if auditLog == nil {
+        return nil
+    }
+
+    auditLog.mutx.Lock()
+    defer auditLog.mutx.Unlock()
+
+    auditLog.sink = nil
+    auditLog.pending = nil
+
+    err := auditLog.file.Close()
+    // If an entry failed to write, report it over the close error
+    if auditLog.err != nil {
+        return auditLog.err
+    }
+
+    return err
+}
+
+
+// Verifies the hash chain of the audit log is unbroken.
+//
+// @Parameters
+// - path:  The path of the audit log
+//
+// @Returns
+// - The number of verified entries
+// - Error naming the first broken line if the chain is broken, otherwise nil on success
+//
+func Verify(path string) (int, error) {
+    file, err := os.Open(path)
+    if err != nil {
+        return 0, err
+    }
+
+    // Close the file on local exit
+    defer file.Close()
+
+    var prev string
+    var seq int64
+    count := 0
+
+    err = readEntries(file, func(entry Entry, line int) error {
+        // If the entry does not follow the one before it
+        if entry.Prev != prev || (count > 0 && entry.Seq != seq + 1) {
+            return fmt.Errorf("audit chain broken on line %d, entry does not follow " +
+                              "the previous one", line)
+        }
+
+        hash, err := chainHash(entry)
+        if err != nil {
+            return fmt.Errorf("error hashing audit entry on line %d - %w", line, err)
+        }
+
+        // If the entry was altered after it was recorded
+        if hash != entry.Hash {
+            return fmt.Errorf("audit chain broken on line %d, entry hash mismatch", line)
+        }
+
+        prev = entry.Hash
+        seq = entry.Seq
+        count++
+        return nil
+    })
+
+    return count, err
+}
+
+
+// Computes the SHA-256 of the passed in data.
+//
+// @Parameters
+// - data:  The data to hash
+//
+// @Returns
+// - The hex SHA-256 of the data
+//
+func Sha256(data []byte) string {
+    sum := sha256.Sum256(data)
+    return hex.EncodeToString(sum[:])
+}
+
+
+// Computes the SHA-256 of the file at the passed in path.
+//
+// @Parameters
+// - path:  The path of the file to hash
+//
+// @Returns
+// - The hex SHA-256 of the file contents
+// - Error if it occurs, otherwise nil on success
+//
+func FileSha256(path string) (string, error) {
+    file, err := os.Open(path)
+    if err != nil {
+        return "", err
+    }
+
+    // Close the file on local exit
+    defer file.Close()
+
+    hash, err := ReaderSha256(file)
+    if err != nil {
+        return "", fmt.Errorf("error hashing %s - %w", path, err)
+    }
+
+    return hash, nil
+}
+
+
+// Computes the SHA-256 of the data read until the end of the reader.
+//
+// @Parameters
+// - reader:  The reader of the data to hash
+//
+// @Returns
+// - The hex SHA-256 of the data
+// - Error if it occurs, otherwise nil on success
+//
+func ReaderSha256(reader io.Reader) (string, error) {
+    hasher := sha256.New()
+    _, err := io.Copy(hasher, reader)
+    if err != nil {
+        return "", err
+    }
+
+    return hex.EncodeToString(hasher.Sum(nil)), nil
+}
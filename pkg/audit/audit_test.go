@@ -0,0 +1,136 @@
+package audit_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/fuzzseed"
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/audit"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestLogVerify(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    logPath := filepath.Join(t.TempDir(), "audit.jsonl")
+
+    auditLog, err := audit.Open(logPath, true)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    auditLog.Record(audit.CategoryAws, "ec2:RunInstances",
+                    map[string]any{"count": 2, "instance_ids": []string{"i-1", "i-2"}})
+    auditLog.Record(audit.CategoryFile, "file_sent",
+                    map[string]any{"sha256": "abc", "size": int64(1 << 40)})
+
+    var forwarded []audit.Entry
+    // Ensure the entries recorded before the sink was set are forwarded to it
+    auditLog.SetSink(func(entry audit.Entry) { forwarded = append(forwarded, entry) })
+    auditLog.Record(audit.CategoryRun, "run_finished", nil)
+    assert.Equal(3, len(forwarded))
+    assert.Equal(int64(3), forwarded[2].Seq)
+    assert.Equal(forwarded[1].Hash, forwarded[2].Prev)
+    assert.Equal(nil, auditLog.Close())
+
+    count, err := audit.Verify(logPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(3, count)
+
+    // Ensure reopening the log continues the chain
+    auditLog, err = audit.Open(logPath, false)
+    assert.Equal(nil, err)
+    auditLog.Record(audit.CategoryRun, "run_started", nil)
+    assert.Equal(nil, auditLog.Close())
+
+    count, err = audit.Verify(logPath)
+    assert.Equal(nil, err)
+    assert.Equal(4, count)
+
+    data, err := os.ReadFile(logPath)
+    assert.Equal(nil, err)
+    lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+
+    // Ensure an altered entry breaks the chain
+    tampered := bytes.Replace(data, []byte(`"count":2`), []byte(`"count":9`), 1)
+    assert.Equal(nil, os.WriteFile(logPath, tampered, 0600))
+    _, err = audit.Verify(logPath)
+    assert.ErrorContains(err, "line 1")
+
+    // Ensure a removed entry breaks the chain
+    removed := append(bytes.Join(append([][]byte{lines[0]}, lines[2:]...), []byte("\n")),
+                      '\n')
+    assert.Equal(nil, os.WriteFile(logPath, removed, 0600))
+    _, err = audit.Verify(logPath)
+    assert.ErrorContains(err, "line 2")
+
+    // Ensure recording to a nil log is a no-op
+    var disabled *audit.Log
+    disabled.Record(audit.CategoryRun, "run_started", nil)
+    assert.Equal(nil, disabled.Close())
+}
+
+
+func TestExecution(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    execution := audit.Execution{
+        Args:     []string{"-a", "0", "--brain-password", "secret", "--brain-password=secret"},
+        ExitCode: 1,
+        HashFile: "hashes.txt",
+        Source:   "rockyou.txt",
+        Start:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+    }
+
+    var sent []byte
+    reporter := audit.NewReporter(func(message []byte) error {
+        sent = message
+        return nil
+    })
+
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, reporter.Report(execution))
+
+    // Split the message so part of the data is read along with the header
+    headerEnd := bytes.IndexByte(sent, '>') + 10
+    read, err := audit.ReadExecution(bytes.NewReader(sent[headerEnd:]), sent[:headerEnd])
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the secret values were redacted before sending
+    execution.Args = []string{"-a", "0", "--brain-password", "********",
+                              "--brain-password=********"}
+    assert.Equal(execution, read)
+    assert.False(strings.Contains(string(sent), "secret"))
+
+    falacies := [][]byte{[]byte("<HASHCAT_EXECUTION:abc>"), []byte("<HASHCAT_EXECUTION:5"),
+                         []byte("<HASHCAT_EXECUTION:99999999>"), []byte("<LOOT_FLUSH:2>{}")}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        _, err = audit.ReadExecution(bytes.NewReader(nil), falacy)
+        assert.NotEqual(nil, err)
+    }
+}
+
+
+func FuzzReadExecution(f *testing.F) {
+    fuzzseed.Framed(f, globals.HASHCAT_EXECUTION_PREFIX, `{"exit_code":1}`)
+
+    f.Fuzz(func(t *testing.T, message []byte, rest []byte) {
+        reader := bytes.NewReader(rest)
+        audit.ReadExecution(reader, message)
+
+        // Ensure no more than the max execution size is ever read from the connection
+        if bytesRead := int(reader.Size()) - reader.Len(); bytesRead > audit.MaxExecutionSize {
+            t.Fatalf("read %d bytes past the max execution size", bytesRead)
+        }
+    })
+}
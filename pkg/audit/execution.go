@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/netio"
+)
+
+// Max bytes of a reported execution, large enough for long rule and mask args
+const MaxExecutionSize = 64 * 1024
+
+// Package level variables
+var SecretFlags = []string{"--brain-password"}  // Hashcat flags whose values are redacted
+
+
+// Execution is a hashcat run on a client, reported to the server for the audit log
+type Execution struct {
+    Args           []string  `json:"args"`
+    Duration       float64   `json:"duration_seconds"`
+    ExitCode       int       `json:"exit_code"`
+    HashFile       string    `json:"hash_file"`
+    HashFileSha256 string    `json:"hash_file_sha256"`
+    Source         string    `json:"source"`
+    Start          time.Time `json:"start"`
+    TimedOut       bool      `json:"timed_out"`
+}
+
+
+// Reporter sends the executions of a client to the server
+type Reporter struct {
+    send func(message []byte) error
+}
+
+
+// Copies the hashcat args with the values of the secret flags redacted.
+//
+// @Parameters
+// - args:  The args hashcat was run with
+//
+// @Returns
+// - The args safe to record
+//
+func RedactArgs(args []string) []string {
+    redacted := slices.Clone(args)
+
+    for index := 0; index < len(redacted); index++ {
+        // If the arg is a secret flag with its value joined by an equal sign
+        if flag, _, found := strings.Cut(redacted[index], "="); found &&
+           slices.Contains(SecretFlags, flag) {
+            redacted[index] = flag + "=********"
+            continue
+        }
+
+        // If the arg is a secret flag followed by its value
+        if slices.Contains(SecretFlags, redacted[index]) && index + 1 < len(redacted) {
+            redacted[index+1] = "********"
+            index++
+        }
+    }
+
+    return redacted
+}
+
+
+// Gets the execution as the fields of an audit entry.
+//
+// @Parameters
+// - client:  The address of the client that ran hashcat
+//
+// @Returns
+// - The fields of the execution
+//
+func (execution Execution) Fields(client string) map[string]any {
+    return map[string]any{
+        "args":             execution.Args,
+        "client":           client,
+        "duration_seconds": execution.Duration,
+        "exit_code":        execution.ExitCode,
+        "hash_file":        execution.HashFile,
+        "hash_file_sha256": execution.HashFileSha256,
+        "source":           execution.Source,
+        "start":            execution.Start.UTC().Format(time.RFC3339Nano),
+        "timed_out":        execution.TimedOut,
+    }
+}
+
+
+// Formats an execution message with the JSON of the execution following the header.
+//
+// @Parameters
+// - execution:  The hashcat execution to report
+//
+// @Returns
+// - The formatted execution message
+// - Error if it occurs, otherwise nil on success
+//
+func FormatExecution(execution Execution) ([]byte, error) {
+    data, err := json.Marshal(execution)
+    if err != nil {
+        return nil, fmt.Errorf("error encoding execution - %w", err)
+    }
+
+    // If the execution is larger than the server reads
+    if len(data) > MaxExecutionSize {
+        return nil, fmt.Errorf("execution of %d bytes exceeds the max size", len(data))
+    }
+
+    message := slices.Clone(globals.HASHCAT_EXECUTION_PREFIX)
+    message = append(message, strconv.Itoa(len(data))...)
+    message = append(message, globals.TRANSFER_SUFFIX...)
+
+    return append(message, data...), nil
+}
+
+
+// Reads an execution, starting with any data read along with the header.
+//
+// @Parameters
+// - connection:  The connection the execution is read from
+// - message:  The message read from the connection starting with the execution header
+//
+// @Returns
+// - The reported execution
+// - Error if it occurs, otherwise nil on success
+//
+func ReadExecution(connection io.Reader, message []byte) (Execution, error) {
+    var execution Execution
+
+    // If the message does not start with the execution header
+    if !bytes.HasPrefix(message, globals.HASHCAT_EXECUTION_PREFIX) {
+        return execution, fmt.Errorf("message is not a hashcat execution - %w",
+                                     netio.ErrMalformedMessage)
+    }
+
+    message = message[len(globals.HASHCAT_EXECUTION_PREFIX):]
+    suffixPos := bytes.Index(message, globals.TRANSFER_SUFFIX)
+    // If the header is not terminated
+    if suffixPos == -1 {
+        return execution, fmt.Errorf("invalid execution header, suffix missing - %w",
+                                     netio.ErrMalformedMessage)
+    }
+
+    size, err := strconv.Atoi(string(message[:suffixPos]))
+    // If the size is not a number or larger than an execution can be
+    if err != nil || size < 0 || size > MaxExecutionSize {
+        return execution, fmt.Errorf("invalid execution size - %q - %w", message[:suffixPos],
+                                     netio.ErrMalformedMessage)
+    }
+
+    data := make([]byte, size)
+    // Copy the data read along with the header
+    copied := copy(data, message[suffixPos+1:])
+
+    // Read the rest of the execution data
+    _, err = io.ReadFull(connection, data[copied:])
+    if err != nil {
+        return execution, fmt.Errorf("error reading execution - %w", err)
+    }
+
+    err = json.Unmarshal(data, &execution)
+    if err != nil {
+        return execution, fmt.Errorf("error parsing execution - %w - %w",
+                                     netio.ErrMalformedMessage, err)
+    }
+
+    return execution, nil
+}
+
+
+// Creates a reporter sending the executions through the passed in function.
+//
+// @Parameters
+// - send:  Sends a formatted execution message
+//
+// @Returns
+// - The initialized reporter
+//
+func NewReporter(send func(message []byte) error) *Reporter {
+    return &Reporter{send: send}
+}
+
+
+// Reports the execution to the server with the secret args redacted.
+//
+// @Parameters
+// - execution:  The hashcat execution to report
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (reporter *Reporter) Report(execution Execution) error {
+    // If executions are not reported
+    if reporter == nil {
+        return nil
+    }
+
+    execution.Args = RedactArgs(execution.Args)
+
+    message, err := FormatExecution(execution)
+    if err != nil {
+        return err
+    }
+
+    return reporter.send(message)
+}
@@ -0,0 +1,70 @@
+package awsutils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CPU architectures of the instances clients run on
+const (
+    ArchAmd64 = "amd64"
+    ArchArm64 = "arm64"
+)
+
+// Operating systems of the instances clients run on
+const (
+    OsLinux   = "linux"
+    OsWindows = "windows"
+)
+
+// SSM public parameter with the current Windows Server 2022 AMI of the region
+const WindowsAmiParameter = "/aws/service/ami-windows-latest/Windows_Server-2022-English-Full-Base"
+
+// Package level variables
+var ReGravitonFamily = regexp.MustCompile(`^[a-z]+\d+g[a-z]*$`)  // Graviton families (g5g, c7gd)
+
+
+// Gets the CPU architecture of the instance type, Graviton families are marked with a
+// g after the generation number (g5g, g6gd) and run arm64.
+//
+// @Parameters
+// - instanceType:  The EC2 instance type
+//
+// @Returns
+// - The architecture of the instance type (amd64 or arm64)
+//
+func InstanceArchitecture(instanceType string) string {
+    family, _, _ := strings.Cut(instanceType, ".")
+
+    // If the family is a Graviton family
+    if ReGravitonFamily.MatchString(family) {
+        return ArchArm64
+    }
+
+    return ArchAmd64
+}
+
+
+// Gets the SSM public parameter with the current AMI of the client OS and architecture,
+// Canonical Ubuntu 22.04 for linux and Windows Server 2022 for windows.
+//
+// @Parameters
+// - clientOs:  The operating system of the instances (linux or windows)
+// - arch:  The architecture of the instances (amd64 or arm64)
+//
+// @Returns
+// - The SSM public parameter of the OS and architecture
+//
+func AmiParameter(clientOs string, arch string) string {
+    // If the instances run Windows, there is only the x86 Windows Server image
+    if clientOs == OsWindows {
+        return WindowsAmiParameter
+    }
+
+    // If the instances are arm64, swap the architecture in the default parameter
+    if arch == ArchArm64 {
+        return strings.Replace(DefaultAmiParameter, "/amd64/", "/arm64/", 1)
+    }
+
+    return DefaultAmiParameter
+}
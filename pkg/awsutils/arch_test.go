@@ -0,0 +1,41 @@
+package awsutils_test
+
+import (
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/awsutils"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestInstanceArchitecture(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    arm64Types := []string{"g5g.xlarge", "g5g.metal", "g6gd.2xlarge"}
+    // Iterate through the Graviton instance types
+    for _, instanceType := range arm64Types {
+        assert.Equal(awsutils.ArchArm64, awsutils.InstanceArchitecture(instanceType))
+    }
+
+    amd64Types := []string{"g4dn.xlarge", "g5d.2xlarge", "g6ed.4xlarge", "p4d.24xlarge",
+                           "p6-b200.48xlarge"}
+    // Iterate through the x86 instance types
+    for _, instanceType := range amd64Types {
+        assert.Equal(awsutils.ArchAmd64, awsutils.InstanceArchitecture(instanceType))
+    }
+}
+
+
+func TestAmiParameter(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    assert.Equal(awsutils.DefaultAmiParameter,
+                 awsutils.AmiParameter(awsutils.OsLinux, awsutils.ArchAmd64))
+    assert.Equal("/aws/service/canonical/ubuntu/server/22.04/stable/current/" +
+                 "arm64/hvm/ebs-gp2/ami-id",
+                 awsutils.AmiParameter(awsutils.OsLinux, awsutils.ArchArm64))
+    assert.Equal(awsutils.WindowsAmiParameter,
+                 awsutils.AmiParameter(awsutils.OsWindows, awsutils.ArchAmd64))
+}
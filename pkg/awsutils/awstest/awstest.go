@@ -0,0 +1,79 @@
+// Package awstest provides in-memory fakes of the AWS service clients used by awsutils,
+// so the managers can be unit tested without AWS credentials or network access.
+package awstest
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/aws/smithy-go"
+)
+
+
+// Recorder records the operations called on a fake and the errors injected into them
+type Recorder struct {
+    calls    []string
+    failures map[string]error  // Errors returned by operation name instead of calling it
+    mutx     sync.Mutex
+}
+
+// Records the operation, returning the error injected into it if any.
+//
+// @Parameters
+// - operation:  The name of the called operation (ex: RunInstances)
+//
+// @Returns
+// - The injected error of the operation, otherwise nil
+//
+func (recorder *Recorder) record(operation string) error {
+    recorder.mutx.Lock()
+    defer recorder.mutx.Unlock()
+
+    recorder.calls = append(recorder.calls, operation)
+    return recorder.failures[operation]
+}
+
+// Gets the names of the called operations in order.
+//
+// @Returns
+// - The called operations
+//
+func (recorder *Recorder) Calls() []string {
+    recorder.mutx.Lock()
+    defer recorder.mutx.Unlock()
+
+    return slices.Clone(recorder.calls)
+}
+
+// Injects an error returned by the operation instead of calling it.
+//
+// @Parameters
+// - operation:  The name of the operation to fail
+// - err:  The error returned by the operation, nil removes the injected error
+//
+func (recorder *Recorder) Fail(operation string, err error) {
+    recorder.mutx.Lock()
+    defer recorder.mutx.Unlock()
+
+    // If no errors have been injected yet
+    if recorder.failures == nil {
+        recorder.failures = map[string]error{}
+    }
+
+    recorder.failures[operation] = err
+}
+
+
+// Creates an API error with the code AWS would return, for codes without a modeled
+// error type such as NotFound.
+//
+// @Parameters
+// - code:  The AWS error code
+// - message:  The message of the error
+//
+// @Returns
+// - The API error
+//
+func ApiError(code string, message string) error {
+    return &smithy.GenericAPIError{Code: code, Message: message}
+}
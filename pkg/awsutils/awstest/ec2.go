@@ -0,0 +1,234 @@
+package awstest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/ngimb64/Kloud-Kraken/pkg/awsutils"
+)
+
+// Package level variables
+var _ awsutils.Ec2Api = (*Ec2)(nil)  // Ensure the fake satisfies the interface
+
+
+// Ec2 is an in-memory fake of the EC2 client where launched instances run immediately
+type Ec2 struct {
+    Recorder
+    Launched  []*ec2.RunInstancesInput  // Inputs of every RunInstances call
+    instances []ec2types.Instance
+    mutx      sync.Mutex
+}
+
+// Creates an empty EC2 fake.
+//
+// @Returns
+// - The initialized fake
+//
+func NewEc2() *Ec2 {
+    return &Ec2{}
+}
+
+// Lists the instances, filtered to the passed in IDs if any.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The instance IDs to describe
+// - optFns:  Unused client options
+//
+// @Returns
+// - The instances in a single reservation
+// - Error if one was injected, otherwise nil
+//
+func (fake *Ec2) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput,
+                                   optFns ...func(*ec2.Options)) (
+                                   *ec2.DescribeInstancesOutput, error) {
+    var instances []ec2types.Instance
+
+    err := fake.record("DescribeInstances")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    // Iterate through the instances keeping those requested
+    for _, instance := range fake.instances {
+        if len(params.InstanceIds) == 0 ||
+           slices.Contains(params.InstanceIds, aws.ToString(instance.InstanceId)) {
+            instances = append(instances, instance)
+        }
+    }
+
+    return &ec2.DescribeInstancesOutput{
+        Reservations: []ec2types.Reservation{{Instances: instances}},
+    }, nil
+}
+
+// Launches MaxCount instances in the running state.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The launch input, recorded in Launched
+// - optFns:  Unused client options
+//
+// @Returns
+// - The launched instances
+// - Error if one was injected, otherwise nil
+//
+func (fake *Ec2) RunInstances(ctx context.Context, params *ec2.RunInstancesInput,
+                              optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
+    var launched []ec2types.Instance
+
+    err := fake.record("RunInstances")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    fake.Launched = append(fake.Launched, params)
+
+    // Iterate through the requested count creating each instance
+    for range aws.ToInt32(params.MaxCount) {
+        instance := ec2types.Instance{
+            ImageId:          params.ImageId,
+            InstanceId:       aws.String(fmt.Sprintf("i-%017x", len(fake.instances) + 1)),
+            InstanceType:     params.InstanceType,
+            PrivateIpAddress: aws.String(fmt.Sprintf("10.0.0.%d", len(fake.instances) + 1)),
+            State:            &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+        }
+
+        fake.instances = append(fake.instances, instance)
+        launched = append(launched, instance)
+    }
+
+    return &ec2.RunInstancesOutput{Instances: launched}, nil
+}
+
+// Moves the passed in instances to the passed in state, recording their state changes.
+//
+// @Parameters
+// - instanceIds:  The IDs of the instances to change
+// - state:  The state the instances are moved to
+//
+// @Returns
+// - The state changes of the instances
+// - Error if an instance does not exist, otherwise nil
+//
+func (fake *Ec2) changeStates(instanceIds []string, state ec2types.InstanceStateName) (
+                              []ec2types.InstanceStateChange, error) {
+    var changes []ec2types.InstanceStateChange
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    // Iterate through the requested IDs changing the state of each instance
+    for _, instanceId := range instanceIds {
+        index := slices.IndexFunc(fake.instances, func(instance ec2types.Instance) bool {
+            return aws.ToString(instance.InstanceId) == instanceId
+        })
+        // If the instance was never launched
+        if index == -1 {
+            return nil, ApiError("InvalidInstanceID.NotFound",
+                                 "the instance ID " + instanceId + " does not exist")
+        }
+
+        previous := *fake.instances[index].State
+        fake.instances[index].State = &ec2types.InstanceState{Name: state}
+
+        changes = append(changes, ec2types.InstanceStateChange{
+            CurrentState:  fake.instances[index].State,
+            InstanceId:    aws.String(instanceId),
+            PreviousState: &previous,
+        })
+    }
+
+    return changes, nil
+}
+
+// Moves the passed in instances to the running state.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The instance IDs to start
+// - optFns:  Unused client options
+//
+// @Returns
+// - The state changes of the started instances
+// - Error if one was injected or an instance does not exist, otherwise nil
+//
+func (fake *Ec2) StartInstances(ctx context.Context, params *ec2.StartInstancesInput,
+                                optFns ...func(*ec2.Options)) (
+                                *ec2.StartInstancesOutput, error) {
+    err := fake.record("StartInstances")
+    if err != nil {
+        return nil, err
+    }
+
+    changes, err := fake.changeStates(params.InstanceIds, ec2types.InstanceStateNameRunning)
+    if err != nil {
+        return nil, err
+    }
+
+    return &ec2.StartInstancesOutput{StartingInstances: changes}, nil
+}
+
+// Moves the passed in instances to the stopped state.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The instance IDs to stop
+// - optFns:  Unused client options
+//
+// @Returns
+// - The state changes of the stopped instances
+// - Error if one was injected or an instance does not exist, otherwise nil
+//
+func (fake *Ec2) StopInstances(ctx context.Context, params *ec2.StopInstancesInput,
+                               optFns ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error) {
+    err := fake.record("StopInstances")
+    if err != nil {
+        return nil, err
+    }
+
+    changes, err := fake.changeStates(params.InstanceIds, ec2types.InstanceStateNameStopped)
+    if err != nil {
+        return nil, err
+    }
+
+    return &ec2.StopInstancesOutput{StoppingInstances: changes}, nil
+}
+
+// Moves the passed in instances to the terminated state.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The instance IDs to terminate
+// - optFns:  Unused client options
+//
+// @Returns
+// - The state changes of the terminated instances
+// - Error if one was injected or an instance does not exist, otherwise nil
+//
+func (fake *Ec2) TerminateInstances(ctx context.Context, params *ec2.TerminateInstancesInput,
+                                    optFns ...func(*ec2.Options)) (
+                                    *ec2.TerminateInstancesOutput, error) {
+    err := fake.record("TerminateInstances")
+    if err != nil {
+        return nil, err
+    }
+
+    changes, err := fake.changeStates(params.InstanceIds, ec2types.InstanceStateNameTerminated)
+    if err != nil {
+        return nil, err
+    }
+
+    return &ec2.TerminateInstancesOutput{TerminatingInstances: changes}, nil
+}
@@ -0,0 +1,613 @@
+package awstest
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/ngimb64/Kloud-Kraken/pkg/awsutils"
+)
+
+// Package level variables
+var _ awsutils.IamApi = (*Iam)(nil)  // Ensure the fake satisfies the interface
+
+
+// IamRole is the state of a role in the IAM fake
+type IamRole struct {
+    Boundary string             // ARN of the permissions boundary, empty for none
+    Managed  []string           // ARNs of the attached managed policies
+    Policies map[string]string  // Inline policy documents by name
+    Trust    string             // The trust policy document
+}
+
+// Iam is an in-memory fake of the IAM client with roles and instance profiles
+type Iam struct {
+    Recorder
+    mutx     sync.Mutex
+    profiles map[string][]string
+    roles    map[string]*IamRole
+}
+
+// Creates an empty IAM fake.
+//
+// @Returns
+// - The initialized fake
+//
+func NewIam() *Iam {
+    return &Iam{
+        profiles: map[string][]string{},
+        roles:    map[string]*IamRole{},
+    }
+}
+
+// Gets a copy of the role state.
+//
+// @Parameters
+// - roleName:  The name of the role
+//
+// @Returns
+// - The state of the role
+// - Whether the role exists
+//
+func (fake *Iam) Role(roleName string) (IamRole, bool) {
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    role, ok := fake.roles[roleName]
+    if !ok {
+        return IamRole{}, false
+    }
+
+    return IamRole{Boundary: role.Boundary, Managed: slices.Clone(role.Managed),
+                   Policies: maps.Clone(role.Policies), Trust: role.Trust}, true
+}
+
+// Gets the roles of the instance profile.
+//
+// @Parameters
+// - profileName:  The name of the instance profile
+//
+// @Returns
+// - The names of the roles in the profile
+// - Whether the instance profile exists
+//
+func (fake *Iam) Profile(profileName string) ([]string, bool) {
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    roles, ok := fake.profiles[profileName]
+    return slices.Clone(roles), ok
+}
+
+// Gets the role or the error IAM returns when it does not exist.
+//
+// @Parameters
+// - roleName:  The name of the role
+//
+// @Returns
+// - The state of the role
+// - Error if the role does not exist, otherwise nil
+//
+func (fake *Iam) role(roleName *string) (*IamRole, error) {
+    role, ok := fake.roles[aws.ToString(roleName)]
+    // If the role was never created or was deleted
+    if !ok {
+        return nil, &iamtypes.NoSuchEntityException{
+            Message: aws.String("the role " + aws.ToString(roleName) + " does not exist"),
+        }
+    }
+
+    return role, nil
+}
+
+// Formats the ARN of the role.
+//
+// @Parameters
+// - roleName:  The name of the role
+//
+// @Returns
+// - The ARN of the role in the fake account
+//
+func roleArn(roleName string) string {
+    return "arn:aws:iam::123456789012:role/" + roleName
+}
+
+// Adds the role to the instance profile.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The instance profile and role names
+// - optFns:  Unused client options
+//
+// @Returns
+// - The empty output
+// - Error if one was injected, either does not exist, or the profile has a role
+//
+func (fake *Iam) AddRoleToInstanceProfile(ctx context.Context,
+                                          params *iam.AddRoleToInstanceProfileInput,
+                                          optFns ...func(*iam.Options)) (
+                                          *iam.AddRoleToInstanceProfileOutput, error) {
+    err := fake.record("AddRoleToInstanceProfile")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    _, err = fake.role(params.RoleName)
+    if err != nil {
+        return nil, err
+    }
+
+    profileName := aws.ToString(params.InstanceProfileName)
+    roles, ok := fake.profiles[profileName]
+    // If the instance profile does not exist
+    if !ok {
+        return nil, &iamtypes.NoSuchEntityException{}
+    }
+
+    // If the instance profile already holds its single role
+    if len(roles) > 0 {
+        return nil, &iamtypes.LimitExceededException{}
+    }
+
+    fake.profiles[profileName] = []string{aws.ToString(params.RoleName)}
+    return &iam.AddRoleToInstanceProfileOutput{}, nil
+}
+
+// Attaches the managed policy to the role, attaching it twice is a no-op.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The role name and policy ARN
+// - optFns:  Unused client options
+//
+// @Returns
+// - The empty output
+// - Error if one was injected or the role does not exist, otherwise nil
+//
+func (fake *Iam) AttachRolePolicy(ctx context.Context, params *iam.AttachRolePolicyInput,
+                                  optFns ...func(*iam.Options)) (
+                                  *iam.AttachRolePolicyOutput, error) {
+    err := fake.record("AttachRolePolicy")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    role, err := fake.role(params.RoleName)
+    if err != nil {
+        return nil, err
+    }
+
+    // If the policy is not already attached
+    if !slices.Contains(role.Managed, aws.ToString(params.PolicyArn)) {
+        role.Managed = append(role.Managed, aws.ToString(params.PolicyArn))
+    }
+
+    return &iam.AttachRolePolicyOutput{}, nil
+}
+
+// Creates the instance profile, failing if it already exists.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The instance profile name
+// - optFns:  Unused client options
+//
+// @Returns
+// - The created instance profile
+// - Error if one was injected or the profile exists, otherwise nil
+//
+func (fake *Iam) CreateInstanceProfile(ctx context.Context,
+                                       params *iam.CreateInstanceProfileInput,
+                                       optFns ...func(*iam.Options)) (
+                                       *iam.CreateInstanceProfileOutput, error) {
+    err := fake.record("CreateInstanceProfile")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    profileName := aws.ToString(params.InstanceProfileName)
+    // If the instance profile already exists
+    if _, ok := fake.profiles[profileName]; ok {
+        return nil, &iamtypes.EntityAlreadyExistsException{}
+    }
+
+    fake.profiles[profileName] = []string{}
+    return &iam.CreateInstanceProfileOutput{
+        InstanceProfile: &iamtypes.InstanceProfile{
+            InstanceProfileName: params.InstanceProfileName,
+        },
+    }, nil
+}
+
+// Creates the role, failing if it already exists.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The role name and trust policy
+// - optFns:  Unused client options
+//
+// @Returns
+// - The created role with its ARN
+// - Error if one was injected or the role exists, otherwise nil
+//
+func (fake *Iam) CreateRole(ctx context.Context, params *iam.CreateRoleInput,
+                            optFns ...func(*iam.Options)) (*iam.CreateRoleOutput, error) {
+    err := fake.record("CreateRole")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    roleName := aws.ToString(params.RoleName)
+    // If the role already exists
+    if _, ok := fake.roles[roleName]; ok {
+        return nil, &iamtypes.EntityAlreadyExistsException{}
+    }
+
+    fake.roles[roleName] = &IamRole{Boundary: aws.ToString(params.PermissionsBoundary),
+                                    Policies: map[string]string{},
+                                    Trust: aws.ToString(params.AssumeRolePolicyDocument)}
+    return &iam.CreateRoleOutput{
+        Role: &iamtypes.Role{Arn: aws.String(roleArn(roleName)), RoleName: params.RoleName},
+    }, nil
+}
+
+// Deletes the instance profile, failing if it still holds a role.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The instance profile name
+// - optFns:  Unused client options
+//
+// @Returns
+// - The empty output
+// - Error if one was injected, the profile does not exist, or it holds a role
+//
+func (fake *Iam) DeleteInstanceProfile(ctx context.Context,
+                                       params *iam.DeleteInstanceProfileInput,
+                                       optFns ...func(*iam.Options)) (
+                                       *iam.DeleteInstanceProfileOutput, error) {
+    err := fake.record("DeleteInstanceProfile")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    profileName := aws.ToString(params.InstanceProfileName)
+    roles, ok := fake.profiles[profileName]
+    // If the instance profile does not exist
+    if !ok {
+        return nil, &iamtypes.NoSuchEntityException{}
+    }
+
+    // If a role must be removed from the profile first
+    if len(roles) > 0 {
+        return nil, &iamtypes.DeleteConflictException{}
+    }
+
+    delete(fake.profiles, profileName)
+    return &iam.DeleteInstanceProfileOutput{}, nil
+}
+
+// Deletes the role, failing if it still has policies or is in an instance profile.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The role name
+// - optFns:  Unused client options
+//
+// @Returns
+// - The empty output
+// - Error if one was injected, the role does not exist, or it is still in use
+//
+func (fake *Iam) DeleteRole(ctx context.Context, params *iam.DeleteRoleInput,
+                            optFns ...func(*iam.Options)) (*iam.DeleteRoleOutput, error) {
+    err := fake.record("DeleteRole")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    role, err := fake.role(params.RoleName)
+    if err != nil {
+        return nil, err
+    }
+
+    // If the policies of the role have not been removed
+    if len(role.Managed) > 0 || len(role.Policies) > 0 {
+        return nil, &iamtypes.DeleteConflictException{}
+    }
+
+    // Iterate through the instance profiles ensuring none hold the role
+    for _, roles := range fake.profiles {
+        if slices.Contains(roles, aws.ToString(params.RoleName)) {
+            return nil, &iamtypes.DeleteConflictException{}
+        }
+    }
+
+    delete(fake.roles, aws.ToString(params.RoleName))
+    return &iam.DeleteRoleOutput{}, nil
+}
+
+// Deletes the inline policy of the role.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The role and policy names
+// - optFns:  Unused client options
+//
+// @Returns
+// - The empty output
+// - Error if one was injected or the role or policy does not exist, otherwise nil
+//
+func (fake *Iam) DeleteRolePolicy(ctx context.Context, params *iam.DeleteRolePolicyInput,
+                                  optFns ...func(*iam.Options)) (
+                                  *iam.DeleteRolePolicyOutput, error) {
+    err := fake.record("DeleteRolePolicy")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    role, err := fake.role(params.RoleName)
+    if err != nil {
+        return nil, err
+    }
+
+    // If the inline policy does not exist
+    if _, ok := role.Policies[aws.ToString(params.PolicyName)]; !ok {
+        return nil, &iamtypes.NoSuchEntityException{}
+    }
+
+    delete(role.Policies, aws.ToString(params.PolicyName))
+    return &iam.DeleteRolePolicyOutput{}, nil
+}
+
+// Detaches the managed policy from the role.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The role name and policy ARN
+// - optFns:  Unused client options
+//
+// @Returns
+// - The empty output
+// - Error if one was injected or the role or attachment does not exist, otherwise nil
+//
+func (fake *Iam) DetachRolePolicy(ctx context.Context, params *iam.DetachRolePolicyInput,
+                                  optFns ...func(*iam.Options)) (
+                                  *iam.DetachRolePolicyOutput, error) {
+    err := fake.record("DetachRolePolicy")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    role, err := fake.role(params.RoleName)
+    if err != nil {
+        return nil, err
+    }
+
+    index := slices.Index(role.Managed, aws.ToString(params.PolicyArn))
+    // If the policy was never attached
+    if index == -1 {
+        return nil, &iamtypes.NoSuchEntityException{}
+    }
+
+    role.Managed = slices.Delete(role.Managed, index, index + 1)
+    return &iam.DetachRolePolicyOutput{}, nil
+}
+
+// Gets the instance profile with the roles it holds.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The instance profile name
+// - optFns:  Unused client options
+//
+// @Returns
+// - The instance profile with the ARNs of its roles
+// - Error if one was injected or the profile does not exist, otherwise nil
+//
+func (fake *Iam) GetInstanceProfile(ctx context.Context, params *iam.GetInstanceProfileInput,
+                                    optFns ...func(*iam.Options)) (
+                                    *iam.GetInstanceProfileOutput, error) {
+    err := fake.record("GetInstanceProfile")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    roleNames, ok := fake.profiles[aws.ToString(params.InstanceProfileName)]
+    // If the instance profile was never created or was deleted
+    if !ok {
+        return nil, &iamtypes.NoSuchEntityException{}
+    }
+
+    var roles []iamtypes.Role
+    for _, roleName := range roleNames {
+        roles = append(roles, iamtypes.Role{Arn: aws.String(roleArn(roleName)),
+                                            RoleName: aws.String(roleName)})
+    }
+
+    return &iam.GetInstanceProfileOutput{
+        InstanceProfile: &iamtypes.InstanceProfile{
+            InstanceProfileName: params.InstanceProfileName,
+            Roles:               roles,
+        },
+    }, nil
+}
+
+// Gets the role.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The role name
+// - optFns:  Unused client options
+//
+// @Returns
+// - The role with its ARN
+// - Error if one was injected or the role does not exist, otherwise nil
+//
+func (fake *Iam) GetRole(ctx context.Context, params *iam.GetRoleInput,
+                         optFns ...func(*iam.Options)) (*iam.GetRoleOutput, error) {
+    err := fake.record("GetRole")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    _, err = fake.role(params.RoleName)
+    if err != nil {
+        return nil, err
+    }
+
+    return &iam.GetRoleOutput{
+        Role: &iamtypes.Role{Arn: aws.String(roleArn(aws.ToString(params.RoleName))),
+                             RoleName: params.RoleName},
+    }, nil
+}
+
+// Adds or replaces the inline policy of the role.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The role name, policy name, and policy document
+// - optFns:  Unused client options
+//
+// @Returns
+// - The empty output
+// - Error if one was injected or the role does not exist, otherwise nil
+//
+func (fake *Iam) PutRolePolicy(ctx context.Context, params *iam.PutRolePolicyInput,
+                               optFns ...func(*iam.Options)) (*iam.PutRolePolicyOutput, error) {
+    err := fake.record("PutRolePolicy")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    role, err := fake.role(params.RoleName)
+    if err != nil {
+        return nil, err
+    }
+
+    role.Policies[aws.ToString(params.PolicyName)] = aws.ToString(params.PolicyDocument)
+    return &iam.PutRolePolicyOutput{}, nil
+}
+
+// Removes the role from the instance profile.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The instance profile and role names
+// - optFns:  Unused client options
+//
+// @Returns
+// - The empty output
+// - Error if one was injected or the profile does not hold the role, otherwise nil
+//
+func (fake *Iam) RemoveRoleFromInstanceProfile(ctx context.Context,
+                                               params *iam.RemoveRoleFromInstanceProfileInput,
+                                               optFns ...func(*iam.Options)) (
+                                               *iam.RemoveRoleFromInstanceProfileOutput,
+                                               error) {
+    err := fake.record("RemoveRoleFromInstanceProfile")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    profileName := aws.ToString(params.InstanceProfileName)
+    // If the instance profile does not hold the role
+    if !slices.Contains(fake.profiles[profileName], aws.ToString(params.RoleName)) {
+        return nil, &iamtypes.NoSuchEntityException{}
+    }
+
+    fake.profiles[profileName] = []string{}
+    return &iam.RemoveRoleFromInstanceProfileOutput{}, nil
+}
+
+// Simulates the actions against the role, allowing the ones named in an inline policy of
+// the role regardless of the resources.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The ARN of the role and the actions to simulate
+// - optFns:  Unused client options
+//
+// @Returns
+// - A result for each action in a single page
+// - Error if one was injected or the role does not exist, otherwise nil
+//
+func (fake *Iam) SimulatePrincipalPolicy(ctx context.Context,
+                                         params *iam.SimulatePrincipalPolicyInput,
+                                         optFns ...func(*iam.Options)) (
+                                         *iam.SimulatePrincipalPolicyOutput, error) {
+    err := fake.record("SimulatePrincipalPolicy")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    role, err := fake.role(aws.String(awsutils.RoleNameFromArn(
+        aws.ToString(params.PolicySourceArn))))
+    if err != nil {
+        return nil, err
+    }
+
+    var results []iamtypes.EvaluationResult
+    // Iterate through the actions allowing the ones an inline policy names
+    for _, action := range params.ActionNames {
+        decision := iamtypes.PolicyEvaluationDecisionTypeImplicitDeny
+
+        for _, policy := range role.Policies {
+            if strings.Contains(policy, `"` + action + `"`) {
+                decision = iamtypes.PolicyEvaluationDecisionTypeAllowed
+                break
+            }
+        }
+
+        results = append(results, iamtypes.EvaluationResult{
+            EvalActionName:   aws.String(action),
+            EvalDecision:     decision,
+            EvalResourceName: aws.String(strings.Join(params.ResourceArns, ",")),
+        })
+    }
+
+    return &iam.SimulatePrincipalPolicyOutput{EvaluationResults: results}, nil
+}
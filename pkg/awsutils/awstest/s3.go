@@ -0,0 +1,496 @@
+package awstest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/ngimb64/Kloud-Kraken/pkg/awsutils"
+)
+
+// Package level variables
+var _ awsutils.S3Api = (*S3)(nil)  // Ensure the fake satisfies the interface
+
+
+// S3 is an in-memory fake of the S3 client with buckets of objects, lifecycle rules,
+// and the hardening settings applied to them
+type S3 struct {
+    Recorder
+    buckets      map[string]map[string][]byte
+    encryption   map[string]s3types.ServerSideEncryptionByDefault
+    lifecycle    map[string][]s3types.LifecycleRule
+    mutx         sync.Mutex
+    policies     map[string]string
+    publicAccess map[string]s3types.PublicAccessBlockConfiguration
+}
+
+// Creates an S3 fake with the passed in buckets already existing.
+//
+// @Parameters
+// - buckets:  The names of the existing buckets
+//
+// @Returns
+// - The initialized fake
+//
+func NewS3(buckets ...string) *S3 {
+    fake := &S3{
+        buckets:      map[string]map[string][]byte{},
+        encryption:   map[string]s3types.ServerSideEncryptionByDefault{},
+        lifecycle:    map[string][]s3types.LifecycleRule{},
+        policies:     map[string]string{},
+        publicAccess: map[string]s3types.PublicAccessBlockConfiguration{},
+    }
+
+    // Iterate through the bucket names creating each
+    for _, bucket := range buckets {
+        fake.buckets[bucket] = map[string][]byte{}
+    }
+
+    return fake
+}
+
+// Gets the object stored at the key.
+//
+// @Parameters
+// - bucket:  The name of the bucket
+// - key:  The key of the object
+//
+// @Returns
+// - The data of the object
+// - Whether the object exists
+//
+func (fake *S3) Object(bucket string, key string) ([]byte, bool) {
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    data, ok := fake.buckets[bucket][key]
+    return data, ok
+}
+
+// Gets the default encryption applied to the bucket.
+//
+// @Parameters
+// - bucket:  The name of the bucket
+//
+// @Returns
+// - The default encryption of the bucket
+// - Whether default encryption was applied
+//
+func (fake *S3) Encryption(bucket string) (s3types.ServerSideEncryptionByDefault, bool) {
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    encryption, ok := fake.encryption[bucket]
+    return encryption, ok
+}
+
+// Gets the lifecycle rules applied to the bucket.
+//
+// @Parameters
+// - bucket:  The name of the bucket
+//
+// @Returns
+// - The lifecycle rules of the bucket
+//
+func (fake *S3) Lifecycle(bucket string) []s3types.LifecycleRule {
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    return fake.lifecycle[bucket]
+}
+
+// Gets the policy applied to the bucket.
+//
+// @Parameters
+// - bucket:  The name of the bucket
+//
+// @Returns
+// - The JSON policy document of the bucket
+// - Whether a policy was applied
+//
+func (fake *S3) Policy(bucket string) (string, bool) {
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    policy, ok := fake.policies[bucket]
+    return policy, ok
+}
+
+// Gets the public access block applied to the bucket.
+//
+// @Parameters
+// - bucket:  The name of the bucket
+//
+// @Returns
+// - The public access block of the bucket
+// - Whether a public access block was applied
+//
+func (fake *S3) PublicAccessBlock(bucket string) (s3types.PublicAccessBlockConfiguration,
+                                                  bool) {
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    block, ok := fake.publicAccess[bucket]
+    return block, ok
+}
+
+// Gets the objects of the bucket by key, used to check bucket exists and is accessible.
+//
+// @Parameters
+// - bucket:  The name of the bucket
+//
+// @Returns
+// - The objects of the bucket
+// - Error if the bucket does not exist, otherwise nil
+//
+func (fake *S3) bucket(bucket *string) (map[string][]byte, error) {
+    objects, ok := fake.buckets[aws.ToString(bucket)]
+    // If the bucket was never created
+    if !ok {
+        return nil, &s3types.NoSuchBucket{Message: aws.String("the bucket does not exist")}
+    }
+
+    return objects, nil
+}
+
+// Creates the bucket, failing if it already exists.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The name of the bucket
+// - optFns:  Unused client options
+//
+// @Returns
+// - The empty output
+// - Error if one was injected or the bucket exists, otherwise nil
+//
+func (fake *S3) CreateBucket(ctx context.Context, params *s3.CreateBucketInput,
+                             optFns ...func(*s3.Options)) (*s3.CreateBucketOutput, error) {
+    err := fake.record("CreateBucket")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    // If the bucket already exists
+    if _, ok := fake.buckets[aws.ToString(params.Bucket)]; ok {
+        return nil, ApiError("BucketAlreadyOwnedByYou", "the bucket already exists")
+    }
+
+    fake.buckets[aws.ToString(params.Bucket)] = map[string][]byte{}
+    return &s3.CreateBucketOutput{}, nil
+}
+
+// Deletes the object, deleting a missing object succeeds as it does on S3.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The bucket and key of the object
+// - optFns:  Unused client options
+//
+// @Returns
+// - The empty output
+// - Error if one was injected or the bucket does not exist, otherwise nil
+//
+func (fake *S3) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput,
+                             optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+    err := fake.record("DeleteObject")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    objects, err := fake.bucket(params.Bucket)
+    if err != nil {
+        return nil, err
+    }
+
+    delete(objects, aws.ToString(params.Key))
+    return &s3.DeleteObjectOutput{}, nil
+}
+
+// Gets the lifecycle rules of the bucket.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The name of the bucket
+// - optFns:  Unused client options
+//
+// @Returns
+// - The lifecycle rules of the bucket
+// - Error if one was injected or the bucket has no rules, otherwise nil
+//
+func (fake *S3) GetBucketLifecycleConfiguration(ctx context.Context,
+                                                params *s3.GetBucketLifecycleConfigurationInput,
+                                                optFns ...func(*s3.Options)) (
+                                                *s3.GetBucketLifecycleConfigurationOutput,
+                                                error) {
+    err := fake.record("GetBucketLifecycleConfiguration")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    rules, ok := fake.lifecycle[aws.ToString(params.Bucket)]
+    // If no lifecycle rules have been applied to the bucket
+    if !ok {
+        return nil, ApiError("NoSuchLifecycleConfiguration",
+                             "the lifecycle configuration does not exist")
+    }
+
+    return &s3.GetBucketLifecycleConfigurationOutput{Rules: rules}, nil
+}
+
+// Gets the object stored at the key.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The bucket and key of the object
+// - optFns:  Unused client options
+//
+// @Returns
+// - The object with its data as the body
+// - Error if one was injected or the object does not exist, otherwise nil
+//
+func (fake *S3) GetObject(ctx context.Context, params *s3.GetObjectInput,
+                          optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+    err := fake.record("GetObject")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    objects, err := fake.bucket(params.Bucket)
+    if err != nil {
+        return nil, err
+    }
+
+    data, ok := objects[aws.ToString(params.Key)]
+    // If there is no object at the key
+    if !ok {
+        return nil, &s3types.NoSuchKey{Message: aws.String("the key does not exist")}
+    }
+
+    return &s3.GetObjectOutput{
+        Body:          io.NopCloser(bytes.NewReader(data)),
+        ContentLength: aws.Int64(int64(len(data))),
+    }, nil
+}
+
+// Checks the bucket exists.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The name of the bucket
+// - optFns:  Unused client options
+//
+// @Returns
+// - The empty output
+// - Error if one was injected or the bucket does not exist, otherwise nil
+//
+func (fake *S3) HeadBucket(ctx context.Context, params *s3.HeadBucketInput,
+                           optFns ...func(*s3.Options)) (*s3.HeadBucketOutput, error) {
+    err := fake.record("HeadBucket")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    // If the bucket was never created, HeadBucket reports it without a modeled type
+    if _, ok := fake.buckets[aws.ToString(params.Bucket)]; !ok {
+        return nil, ApiError("NotFound", "the bucket does not exist")
+    }
+
+    return &s3.HeadBucketOutput{}, nil
+}
+
+// Replaces the default encryption of the bucket.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The bucket and its encryption configuration
+// - optFns:  Unused client options
+//
+// @Returns
+// - The empty output
+// - Error if one was injected, the bucket does not exist, or no rule was passed
+//
+func (fake *S3) PutBucketEncryption(ctx context.Context, params *s3.PutBucketEncryptionInput,
+                                    optFns ...func(*s3.Options)) (
+                                    *s3.PutBucketEncryptionOutput, error) {
+    err := fake.record("PutBucketEncryption")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    _, err = fake.bucket(params.Bucket)
+    if err != nil {
+        return nil, err
+    }
+
+    config := params.ServerSideEncryptionConfiguration
+    // If no default encryption rule was passed
+    if config == nil || len(config.Rules) == 0 ||
+    config.Rules[0].ApplyServerSideEncryptionByDefault == nil {
+        return nil, ApiError("MalformedXML", "the encryption configuration has no rule")
+    }
+
+    fake.encryption[aws.ToString(params.Bucket)] =
+        *config.Rules[0].ApplyServerSideEncryptionByDefault
+    return &s3.PutBucketEncryptionOutput{}, nil
+}
+
+// Replaces the lifecycle rules of the bucket.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The bucket and its lifecycle rules
+// - optFns:  Unused client options
+//
+// @Returns
+// - The empty output
+// - Error if one was injected or the bucket does not exist, otherwise nil
+//
+func (fake *S3) PutBucketLifecycleConfiguration(ctx context.Context,
+                                                params *s3.PutBucketLifecycleConfigurationInput,
+                                                optFns ...func(*s3.Options)) (
+                                                *s3.PutBucketLifecycleConfigurationOutput,
+                                                error) {
+    err := fake.record("PutBucketLifecycleConfiguration")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    _, err = fake.bucket(params.Bucket)
+    if err != nil {
+        return nil, err
+    }
+
+    fake.lifecycle[aws.ToString(params.Bucket)] = params.LifecycleConfiguration.Rules
+    return &s3.PutBucketLifecycleConfigurationOutput{}, nil
+}
+
+// Replaces the policy of the bucket.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The bucket and its JSON policy document
+// - optFns:  Unused client options
+//
+// @Returns
+// - The empty output
+// - Error if one was injected or the bucket does not exist, otherwise nil
+//
+func (fake *S3) PutBucketPolicy(ctx context.Context, params *s3.PutBucketPolicyInput,
+                                optFns ...func(*s3.Options)) (
+                                *s3.PutBucketPolicyOutput, error) {
+    err := fake.record("PutBucketPolicy")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    _, err = fake.bucket(params.Bucket)
+    if err != nil {
+        return nil, err
+    }
+
+    fake.policies[aws.ToString(params.Bucket)] = aws.ToString(params.Policy)
+    return &s3.PutBucketPolicyOutput{}, nil
+}
+
+// Stores the object at the key, honoring If-None-Match so existing keys are not
+// overwritten when it is set.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The bucket, key, and body of the object
+// - optFns:  Unused client options
+//
+// @Returns
+// - The empty output
+// - Error if one was injected, the bucket does not exist, or the precondition failed
+//
+func (fake *S3) PutObject(ctx context.Context, params *s3.PutObjectInput,
+                          optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+    err := fake.record("PutObject")
+    if err != nil {
+        return nil, err
+    }
+
+    // Read the body before locking since it may be streamed
+    data, err := io.ReadAll(params.Body)
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    objects, err := fake.bucket(params.Bucket)
+    if err != nil {
+        return nil, err
+    }
+
+    key := aws.ToString(params.Key)
+    // If the object exists and must not be overwritten
+    if _, ok := objects[key]; ok && aws.ToString(params.IfNoneMatch) == "*" {
+        return nil, ApiError("PreconditionFailed", "the key already exists")
+    }
+
+    objects[key] = data
+    return &s3.PutObjectOutput{}, nil
+}
+
+// Replaces the public access block of the bucket.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The bucket and its public access block
+// - optFns:  Unused client options
+//
+// @Returns
+// - The empty output
+// - Error if one was injected or the bucket does not exist, otherwise nil
+//
+func (fake *S3) PutPublicAccessBlock(ctx context.Context, params *s3.PutPublicAccessBlockInput,
+                                     optFns ...func(*s3.Options)) (
+                                     *s3.PutPublicAccessBlockOutput, error) {
+    err := fake.record("PutPublicAccessBlock")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    _, err = fake.bucket(params.Bucket)
+    if err != nil {
+        return nil, err
+    }
+
+    fake.publicAccess[aws.ToString(params.Bucket)] = *params.PublicAccessBlockConfiguration
+    return &s3.PutPublicAccessBlockOutput{}, nil
+}
@@ -0,0 +1,207 @@
+package awstest
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/ngimb64/Kloud-Kraken/pkg/awsutils"
+)
+
+// Package level variables
+var _ awsutils.SsmApi = (*Ssm)(nil)  // Ensure the fake satisfies the interface
+
+
+// Ssm is an in-memory fake of the SSM client with parameters and registered agents
+type Ssm struct {
+    Recorder
+    mutx       sync.Mutex
+    online     []string
+    parameters map[string]string
+}
+
+// Creates an SSM fake with the passed in parameters already stored.
+//
+// @Parameters
+// - parameters:  The stored parameter values by name
+//
+// @Returns
+// - The initialized fake
+//
+func NewSsm(parameters map[string]string) *Ssm {
+    fake := &Ssm{parameters: map[string]string{}}
+
+    // Copy the parameters so the passed in map is not modified
+    for name, value := range parameters {
+        fake.parameters[name] = value
+    }
+
+    return fake
+}
+
+// Registers the SSM agents of the instances as online.
+//
+// @Parameters
+// - instanceIds:  The IDs of the instances with online agents
+//
+func (fake *Ssm) SetOnline(instanceIds ...string) {
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    fake.online = append(fake.online, instanceIds...)
+}
+
+// Gets the stored value of the parameter.
+//
+// @Parameters
+// - name:  The name of the parameter
+//
+// @Returns
+// - The value of the parameter
+// - Whether the parameter exists
+//
+func (fake *Ssm) Parameter(name string) (string, bool) {
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    value, ok := fake.parameters[name]
+    return value, ok
+}
+
+// Lists the online agents of the instances filtered by the InstanceIds filter, in a
+// page per instance so callers exercise pagination.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The instance ID filter and the token of the page
+// - optFns:  Unused client options
+//
+// @Returns
+// - A page with the next online instance
+// - Error if one was injected, otherwise nil
+//
+func (fake *Ssm) DescribeInstanceInformation(ctx context.Context,
+                                             params *ssm.DescribeInstanceInformationInput,
+                                             optFns ...func(*ssm.Options)) (
+                                             *ssm.DescribeInstanceInformationOutput, error) {
+    var matched []string
+
+    err := fake.record("DescribeInstanceInformation")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    // Iterate through the online instances keeping those matching the filters
+    for _, instanceId := range fake.online {
+        match := true
+
+        for _, filter := range params.Filters {
+            if aws.ToString(filter.Key) == "InstanceIds" &&
+               !slices.Contains(filter.Values, instanceId) {
+                match = false
+            }
+        }
+
+        if match {
+            matched = append(matched, instanceId)
+        }
+    }
+
+    offset := 0
+    // If a later page was requested, the token is the offset of its instance
+    if params.NextToken != nil {
+        offset, err = strconv.Atoi(aws.ToString(params.NextToken))
+        if err != nil {
+            return nil, ApiError("InvalidNextToken", "the token is invalid")
+        }
+    }
+
+    // If there are no online instances past the offset
+    if offset >= len(matched) {
+        return &ssm.DescribeInstanceInformationOutput{}, nil
+    }
+
+    output := &ssm.DescribeInstanceInformationOutput{
+        InstanceInformationList: []ssmtypes.InstanceInformation{{
+            InstanceId: aws.String(matched[offset]),
+            PingStatus: ssmtypes.PingStatusOnline,
+        }},
+    }
+
+    // If there are more online instances, point the token at the next one
+    if offset + 1 < len(matched) {
+        output.NextToken = aws.String(strconv.Itoa(offset + 1))
+    }
+
+    return output, nil
+}
+
+// Gets the value of the parameter.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The name of the parameter
+// - optFns:  Unused client options
+//
+// @Returns
+// - The parameter with its value
+// - Error if one was injected or the parameter does not exist, otherwise nil
+//
+func (fake *Ssm) GetParameter(ctx context.Context, params *ssm.GetParameterInput,
+                              optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+    err := fake.record("GetParameter")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    value, ok := fake.parameters[aws.ToString(params.Name)]
+    // If the parameter was never stored
+    if !ok {
+        return nil, &ssmtypes.ParameterNotFound{}
+    }
+
+    return &ssm.GetParameterOutput{
+        Parameter: &ssmtypes.Parameter{Name: params.Name, Value: aws.String(value)},
+    }, nil
+}
+
+// Stores the value of the parameter, failing if it exists and overwriting is disabled.
+//
+// @Parameters
+// - ctx:  Unused, the fake does not block
+// - params:  The name and value of the parameter
+// - optFns:  Unused client options
+//
+// @Returns
+// - The output with the version of the parameter
+// - Error if one was injected or the parameter exists, otherwise nil
+//
+func (fake *Ssm) PutParameter(ctx context.Context, params *ssm.PutParameterInput,
+                              optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+    err := fake.record("PutParameter")
+    if err != nil {
+        return nil, err
+    }
+
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    name := aws.ToString(params.Name)
+    // If the parameter exists and must not be overwritten
+    if _, ok := fake.parameters[name]; ok && !aws.ToBool(params.Overwrite) {
+        return nil, &ssmtypes.ParameterAlreadyExists{}
+    }
+
+    fake.parameters[name] = aws.ToString(params.Value)
+    return &ssm.PutParameterOutput{Version: 1}, nil
+}
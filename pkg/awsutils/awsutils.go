@@ -3,14 +3,19 @@ package awsutils
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/ngimb64/Kloud-Kraken/pkg/audit"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
@@ -19,11 +24,33 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
 	"github.com/aws/smithy-go"
 )
 
+// SSM public parameter with the current Canonical Ubuntu 22.04 AMI of the region
+const DefaultAmiParameter = "/aws/service/canonical/ubuntu/server/22.04/stable/current/" +
+                            "amd64/hvm/ebs-gp2/ami-id"
+
+// Package level variables
+var Audit *audit.Log  // Records the AWS resources changed by the run, nil when disabled
+
+
+// Records a completed AWS call that created, changed, or deleted a resource in the
+// audit log.
+//
+// @Parameters
+// - service:  The AWS service the action belongs to
+// - action:  The name of the API action
+// - params:  The parameters the action was called with
+//
+func recordMutation(service string, action string, params map[string]any) {
+    Audit.Record(audit.CategoryAws, service + ":" + action, params)
+}
+
+
 // Attempts to load AWS access and secret keys from the default keychain.
 //
 // @Parameters
@@ -71,6 +98,12 @@ func AttemptLoadDefaultCredChain(region string, callTime time.Duration) (
 // - Error if it occurs, otherwise nil on success
 //
 func AwsConfigSetup(region string, callTime time.Duration) (aws.Config, string, string, error) {
+    // If dry-run is enabled, no credentials are needed since nothing is executed
+    if DryRun != nil {
+        DryRun.Record("sts", "LoadCredentials", map[string]any{"region": region})
+        return aws.Config{Region: region}, "", "", nil
+    }
+
     // Attempt to load credentials from default credential chain
     awsConfig, accessKey, secretKey, exists := AttemptLoadDefaultCredChain(region, callTime)
     if exists {
@@ -101,68 +134,154 @@ func AwsConfigSetup(region string, callTime time.Duration) (aws.Config, string,
 }
 
 
+// Waits until the credentials provider can retrieve credentials, used after creating
+// a role to assume since IAM changes take time to propagate.
+//
+// @Parameters
+// - provider:  The credentials provider, such as a cached STS assume role provider
+// - timeout:  The maximum length of time to wait for the credentials
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func WaitForCredentials(provider aws.CredentialsProvider, timeout time.Duration) error {
+    // If dry-run is enabled, no credentials are needed since nothing is executed
+    if DryRun != nil {
+        return nil
+    }
+
+    deadline := time.Now().Add(timeout)
+
+    for {
+        ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+        _, err := provider.Retrieve(ctx)
+        cancel()
+        // If the credentials were retrieved
+        if err == nil {
+            return nil
+        }
+
+        // If the credentials are still unavailable at the deadline
+        if time.Now().After(deadline) {
+            return fmt.Errorf("credentials not available - %w", err)
+        }
+
+        time.Sleep(5 * time.Second)
+    }
+}
+
+
 // Struct for managing EC2 operations
 type Ec2Manger struct {
     ami              string
-    client           *ec2.Client
+    client           Ec2Api
     count            int
+    dataVolumeSize   int
     instanceType     string
+    mutx             sync.Mutex
     name             string
     roleName         string
+    runId            string
     runResult        *ec2.RunInstancesOutput
     securityGroupIds []string
     securityGroups   []string
+    selfTerminate    bool
     subnetId         string
     userData         []byte
 }
 
-// Establishes connection to EC2 service and generates EC2 manager struct
+// Generates the EC2 manager struct around the passed in EC2 client
 //
 // @Parameters
 // - ami:  The Amazon Machine Image that the EC2 instances will be using
-// - awsConfig:  The AWS credential configuration for connecting to service
+// - ec2Client:  The client to the EC2 service, a fake from awstest in tests
 // - count:  The number of instances to be spawned
 // - instanceType:  The type of instance to be used
 // - name:  The name of the service to be tagged for easy reference
 // - roleName:  The name of the IAM role to be utilized
+// - runId:  The unique ID of the run to be tagged on the instances
 // - securityGroupIds:  List of security group IDs to apply
 // - securityGroups:  List of security group names to apply
 // - subnetId:  The subnet ID to apply
+// - dataVolumeSize:  The size in GiB of the gp3 data volume to attach, 0 attaches none
+// - selfTerminate:  Toggle to terminate rather than stop the instances on OS shutdown
 // - userData:   The user data to be fed into each EC2 and executed
 //
 // @Returns
 // - The initialized EC2 manager with populated data
 //
-func NewEc2Manager(ami string, awsConfig aws.Config, count int, instanceType string,
-                   name string, roleName string, securityGroupIds []string,
-                   securityGroups []string, subnetId string, userData []byte) *Ec2Manger {
-    // Setup a new EC2 client
-    ec2Client := ec2.NewFromConfig(awsConfig)
-
+func NewEc2Manager(ami string, ec2Client Ec2Api, count int, instanceType string,
+                   name string, roleName string, runId string, securityGroupIds []string,
+                   securityGroups []string, subnetId string, dataVolumeSize int,
+                   selfTerminate bool, userData []byte) *Ec2Manger {
     return &Ec2Manger{
         ami:              ami,
         client:           ec2Client,
         count:            count,
+        dataVolumeSize:   dataVolumeSize,
         instanceType:     instanceType,
         name:             name,
         roleName:         roleName,
+        runId:            runId,
         securityGroupIds: securityGroupIds,
         securityGroups:   securityGroups,
+        selfTerminate:    selfTerminate,
         subnetId:         subnetId,
         userData:         userData,
     }
 }
 
-// Launches EC2 instances based on passed in count, pausing between each based on
-// based in delay.
+// Establishes connection to EC2 service and generates EC2 manager struct, the args
+// besides the AWS config are the same as NewEc2Manager
+//
+// @Parameters
+// - awsConfig:  The AWS credential configuration for connecting to service
+//
+// @Returns
+// - The initialized EC2 manager with populated data
+//
+func NewEc2ManagerFromConfig(ami string, awsConfig aws.Config, count int, instanceType string,
+                             name string, roleName string, runId string,
+                             securityGroupIds []string, securityGroups []string,
+                             subnetId string, dataVolumeSize int, selfTerminate bool,
+                             userData []byte) *Ec2Manger {
+    return NewEc2Manager(ami, ec2.NewFromConfig(awsConfig), count, instanceType, name,
+                         roleName, runId, securityGroupIds, securityGroups, subnetId,
+                         dataVolumeSize, selfTerminate, userData)
+}
+
+// Launches the passed in number of EC2 instances with the configuration of the manager,
+// retrying while the instance profile created for the run is still propagating.
 //
 // @Parameters
+// - count:  The number of instances to launch
 // - callTime:  The length of time the API call is allowed to execute
 //
 // @Returns
+// - The output of the launch, nil when dry-run is enabled
 // - Error if it occurs, otherwise nil on success
 //
-func (Ec2Man *Ec2Manger) CreateEc2Instances(callTime time.Duration) (error) {
+func (Ec2Man *Ec2Manger) runInstances(count int, callTime time.Duration) (
+                                      *ec2.RunInstancesOutput, error) {
+    // If dry-run is enabled, record the launch instead of executing it
+    if DryRun != nil {
+        DryRun.Record("ec2", "RunInstances", map[string]any{
+            "ami":                Ec2Man.ami,
+            "count":              count,
+            "data_volume_gib":    Ec2Man.dataVolumeSize,
+            "instance_profile":   Ec2Man.roleName,
+            "instance_type":      Ec2Man.instanceType,
+            "security_group_ids": Ec2Man.securityGroupIds,
+            "security_groups":    Ec2Man.securityGroups,
+            "self_terminate":     Ec2Man.selfTerminate,
+            "subnet_id":          Ec2Man.subnetId,
+            "tags":               ServiceTagKey + "=" + Ec2Man.name + "," + RunTagKey +
+                                  "=" + Ec2Man.runId + formatRunTags(),
+            "user_data":          string(Ec2Man.userData),
+        })
+        return nil, nil
+    }
+
     // Ensure AWS API calls do not hang for longer specified timeout
     ctx, cancel := context.WithTimeout(context.Background(), callTime)
     defer cancel()
@@ -174,8 +293,8 @@ func (Ec2Man *Ec2Manger) CreateEc2Instances(callTime time.Duration) (error) {
     input := &ec2.RunInstancesInput{
         ImageId:      aws.String(Ec2Man.ami),
         InstanceType: ec2types.InstanceType(Ec2Man.instanceType),
-        MinCount:     aws.Int32(int32(Ec2Man.count)),
-        MaxCount:     aws.Int32(int32(Ec2Man.count)),
+        MinCount:     aws.Int32(int32(count)),
+        MaxCount:     aws.Int32(int32(count)),
         UserData:     aws.String(encodedUserData),
         IamInstanceProfile: &ec2types.IamInstanceProfileSpecification{
             Name: aws.String(Ec2Man.roleName),
@@ -184,9 +303,10 @@ func (Ec2Man *Ec2Manger) CreateEc2Instances(callTime time.Duration) (error) {
         TagSpecifications: []ec2types.TagSpecification{
             {
                 ResourceType: ec2types.ResourceTypeInstance,
-                Tags: []ec2types.Tag{
-                    {Key: aws.String("Service"), Value: aws.String(Ec2Man.name)},
-                },
+                Tags: append([]ec2types.Tag{
+                    {Key: aws.String(ServiceTagKey), Value: aws.String(Ec2Man.name)},
+                    {Key: aws.String(RunTagKey), Value: aws.String(Ec2Man.runId)},
+                }, ec2RunTags()...),
             },
         },
     }
@@ -206,253 +326,808 @@ func (Ec2Man *Ec2Manger) CreateEc2Instances(callTime time.Duration) (error) {
         input.SubnetId = &Ec2Man.subnetId
     }
 
-    // Execute call to run the EC2 instance
-    runOutput, err := Ec2Man.client.RunInstances(ctx, input)
-    if err != nil {
-        return err
+    // If the clients shut down once done, terminate the instances instead of stopping them
+    if Ec2Man.selfTerminate {
+        input.InstanceInitiatedShutdownBehavior = ec2types.ShutdownBehaviorTerminate
     }
 
-    // Assign run API call to EC2 manager struct
-    Ec2Man.runResult = runOutput
-    return nil
+    // If a data volume is used in place of absent instance store, attach it at launch
+    if Ec2Man.dataVolumeSize > 0 {
+        input.BlockDeviceMappings = []ec2types.BlockDeviceMapping{
+            {
+                DeviceName: aws.String("/dev/sdf"),
+                Ebs: &ec2types.EbsBlockDevice{
+                    DeleteOnTermination: aws.Bool(true),
+                    Encrypted:           aws.Bool(true),
+                    VolumeSize:          aws.Int32(int32(Ec2Man.dataVolumeSize)),
+                    VolumeType:          ec2types.VolumeTypeGp3,
+                },
+            },
+        }
+    }
+
+    var apiErr smithy.APIError
+
+    for {
+        // Execute call to run the EC2 instance
+        runOutput, err := Ec2Man.client.RunInstances(ctx, input)
+        if err == nil {
+            recordMutation("ec2", "RunInstances", map[string]any{
+                "ami":              Ec2Man.ami,
+                "instance_ids":     outputIds(runOutput),
+                "instance_type":    Ec2Man.instanceType,
+                "run_id":           Ec2Man.runId,
+                "user_data_sha256": audit.Sha256(Ec2Man.userData),
+            })
+            return runOutput, nil
+        }
+
+        // If the error is not the instance profile created for the run still propagating
+        if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "InvalidParameterValue" ||
+           !strings.Contains(apiErr.ErrorMessage(), "iamInstanceProfile") {
+            return nil, err
+        }
+
+        // Wait before retrying, unless the call time has run out
+        select {
+        case <-ctx.Done():
+            return nil, err
+        case <-time.After(5 * time.Second):
+        }
+    }
 }
 
-// Terminates the EC2 instances by ID's collected from creation method result.
+// Launches EC2 instances based on passed in count.
 //
 // @Parameters
 // - callTime:  The length of time the API call is allowed to execute
 //
 // @Returns
-// - The output from the EC2 termination API call
 // - Error if it occurs, otherwise nil on success
 //
-func (Ec2Man *Ec2Manger) TerminateEc2Instances(callTime time.Duration) (
-                                               *ec2.TerminateInstancesOutput, error) {
-    var ids []string
-
-    // Ensure AWS API calls do not hang for longer specified timeout
-    ctx, cancel := context.WithTimeout(context.Background(), callTime)
-    defer cancel()
-
-    // Iterate through instances from result output
-    for _, instance := range Ec2Man.runResult.Instances {
-        // If the instance ID is present add to ids slice
-        if instance.InstanceId != nil {
-            ids = append(ids, *instance.InstanceId)
-        }
-    }
-
-    // build termination input with parsed id's
-    terminateInput := &ec2.TerminateInstancesInput{
-        InstanceIds: ids,
-    }
-
-    // Terminate all the collected instance id's
-    termOutput, err := Ec2Man.client.TerminateInstances(ctx, terminateInput)
+func (Ec2Man *Ec2Manger) CreateEc2Instances(callTime time.Duration) (error) {
+    runOutput, err := Ec2Man.runInstances(Ec2Man.count, callTime)
     if err != nil {
-        return nil, err
+        return err
     }
 
-    return termOutput, nil
-}
+    Ec2Man.mutx.Lock()
+    defer Ec2Man.mutx.Unlock()
 
+    // Assign run API call to EC2 manager struct
+    Ec2Man.runResult = runOutput
+    return nil
+}
 
-// Creates an IAM role with the passed in JSON policy data applied.
+// Launches additional EC2 instances while the run is in progress, with the same AMI,
+// user data, and role as the instances created at startup. The added instances are
+// counted with the created ones so they are queried and terminated with the fleet.
 //
 // @Parameters
-// - iamClient:  The client to the IAM service
+// - count:  The number of instances to add
 // - callTime:  The length of time the API call is allowed to execute
-// - roleName:  The IAM Role to attach to
-// - trustPolicyJson:  The JSON trust policy
-// - permPolicyName:  An identifier name for permissions policy
-// - permPolicyJSON:  The JSON permissions policy
-// - createProfile:  Toggle to set whether instance profiles are created or not
 //
 // @Returns
-// - The ARN of the existing or created role
+// - The IDs of the added instances, empty when dry-run is enabled
 // - Error if it occurs, otherwise nil on success
 //
-func IamRoleCreation(iamClient *iam.Client, callTime time.Duration, roleName string,
-                     trustPolicyJson string, permPolicyName string,
-                     permPolicyJson string, createProfile bool) (string, error) {
-    var roleArn string
-    // Ensure AWS API calls do not hang for longer specified timeout
-    ctx, cancel := context.WithTimeout(context.Background(), callTime)
-    defer cancel()
+func (Ec2Man *Ec2Manger) AddEc2Instances(count int, callTime time.Duration) ([]string,
+                                                                             error) {
+    // If the count is not positive
+    if count < 1 {
+        return nil, fmt.Errorf("number of instances to add must be at least 1, got %d", count)
+    }
 
-    // Check if the IAM role exists
-    getOut, err := iamClient.GetRole(ctx, &iam.GetRoleInput{
-        RoleName: aws.String(roleName),
-    })
+    runOutput, err := Ec2Man.runInstances(count, callTime)
     if err != nil {
-        var notFound *iamtypes.NoSuchEntityException
+        return nil, err
+    }
 
-        // If the IAM role does not exist
-        if ok := errors.As(err, &notFound); ok {
-            // Create the IAM role
-            createOut, err := iamClient.CreateRole(ctx, &iam.CreateRoleInput{
-                RoleName:                 aws.String(roleName),
-                AssumeRolePolicyDocument: aws.String(trustPolicyJson),
-            })
-            if err != nil {
-                return "", fmt.Errorf("CreateRole failed: %w", err)
-            }
+    Ec2Man.mutx.Lock()
+    defer Ec2Man.mutx.Unlock()
 
-            // Set the role ARN from output
-            roleArn = aws.ToString(createOut.Role.Arn)
-        } else {
-            return "", fmt.Errorf("GetRole failed: %w", err)
-        }
-    } else {
-        // Role existed, grab its ARN
-        roleArn = aws.ToString(getOut.Role.Arn)
+    Ec2Man.count += count
+    // If dry-run is enabled, no instances were launched
+    if runOutput == nil {
+        return nil, nil
     }
 
-    // Attach or overwrite the inline permissions policy
-    _, err = iamClient.PutRolePolicy(ctx, &iam.PutRolePolicyInput{
-        RoleName:       aws.String(roleName),
-        PolicyName:     aws.String(permPolicyName),
-        PolicyDocument: aws.String(permPolicyJson),
-    })
-    if err != nil {
-        return "", fmt.Errorf("PutRolePolicy failed: %w", err)
+    // If no instances were created at startup, the added ones are the run result
+    if Ec2Man.runResult == nil {
+        Ec2Man.runResult = runOutput
+    } else {
+        Ec2Man.runResult.Instances = append(Ec2Man.runResult.Instances,
+                                            runOutput.Instances...)
     }
 
-    if createProfile {
-        // Create the instance profile
-        _, err = iamClient.CreateInstanceProfile(ctx, &iam.CreateInstanceProfileInput{
-            InstanceProfileName: aws.String(roleName),
-        })
-        if err != nil {
-            var entityExists *iamtypes.EntityAlreadyExistsException
+    return outputIds(runOutput), nil
+}
 
-            // If the error is not that the instance profile already exists
-            if !errors.As(err, &entityExists) {
-                return "", fmt.Errorf("CreateInstanceProfile failed: %w", err)
-            }
-        }
+// Collects the instance ID's from the output of a launch.
+//
+// @Parameters
+// - runOutput:  The output of the RunInstances call, nil if none were launched
+//
+// @Returns
+// - Slice of the launched instance ID's
+//
+func outputIds(runOutput *ec2.RunInstancesOutput) []string {
+    var ids []string
 
-        // Add role to the instance profile
-        _, err = iamClient.AddRoleToInstanceProfile(ctx, &iam.AddRoleToInstanceProfileInput{
-            InstanceProfileName: aws.String(roleName),
-            RoleName:            aws.String(roleName),
-        })
-        if err != nil {
-            return "", fmt.Errorf("AddRoleToInstanceProfile failed: %w", err)
+    // If no instances have been created yet
+    if runOutput == nil {
+        return ids
+    }
+
+    // Iterate through instances from result output
+    for _, instance := range runOutput.Instances {
+        // If the instance ID is present add to ids slice
+        if instance.InstanceId != nil {
+            ids = append(ids, *instance.InstanceId)
         }
     }
 
-    return roleArn, nil
+    return ids
 }
 
+// Collects the instance ID's of the created and added instances.
+//
+// @Returns
+// - Slice of the created instance ID's, empty if no instances were created
+//
+func (Ec2Man *Ec2Manger) InstanceIds() []string {
+    Ec2Man.mutx.Lock()
+    defer Ec2Man.mutx.Unlock()
 
-// Struct for managing S3 bucket operations
-type S3Manager struct {
-    client     *s3.Client
+    return outputIds(Ec2Man.runResult)
 }
 
-// Establishes connection to EC2 service and generates EC2 manager struct
-//
-// @Parameters
-// - awsConfig:  The AWS credential configuration for connecting to service
+// Gets the number of instances of the run, the created and added instances.
 //
 // @Returns
-// - The initialized S3 manager with client reference
+// - The number of instances
 //
-func NewS3Manager(config aws.Config) *S3Manager {
-    // Set up a new S3 client
-    s3Client := s3.NewFromConfig(config)
+func (Ec2Man *Ec2Manger) Count() int {
+    Ec2Man.mutx.Lock()
+    defer Ec2Man.mutx.Unlock()
 
-    return &S3Manager{
-        client:     s3Client,
-    }
+    return Ec2Man.count
 }
 
-// Checks to see if an S3 bucket already exists.
+// Queries the current state of the created instances and counts them by state name.
 //
 // @Parameters
-// - bucketName:  The name of the S3 bucket to check existence
 // - callTime:  The length of time the API call is allowed to execute
 //
 // @Returns
-// - Boolean toggle whether the bucket exists or not
+// - The number of instances keyed by state name (ex: running)
 // - Error if it occurs, otherwise nil on success
 //
-func (S3Man *S3Manager) BucketExists(bucketName string, callTime time.Duration) (
-                                     bool, error) {
+func (Ec2Man *Ec2Manger) InstanceStates(callTime time.Duration) (map[string]int, error) {
+    states := make(map[string]int)
+    instanceIds := Ec2Man.InstanceIds()
+
+    // If no instances have been created or dry-run is enabled, there is nothing to query
+    if len(instanceIds) == 0 || DryRun != nil {
+        return states, nil
+    }
+
     // Ensure AWS API calls do not hang for longer specified timeout
     ctx, cancel := context.WithTimeout(context.Background(), callTime)
     defer cancel()
 
-    // Check if the bucket exists and get information
-    _, err := S3Man.client.HeadBucket(ctx, &s3.HeadBucketInput{
-        Bucket: aws.String(bucketName),
-    })
-    // If there was no error, bucket exists and is accessible
-    if err == nil {
-        return true, nil
-    }
-
-    var apiErr smithy.APIError
+    paginator := ec2.NewDescribeInstancesPaginator(Ec2Man.client,
+                                                   &ec2.DescribeInstancesInput{
+                                                       InstanceIds: instanceIds,
+                                                   })
+    // Iterate through the pages of the described instances
+    for paginator.HasMorePages() {
+        page, err := paginator.NextPage(ctx)
+        if err != nil {
+            return nil, err
+        }
 
-    // If an API error occured
-    if errors.As(err, &apiErr) {
-        // Get the error code
-        errCode := apiErr.ErrorCode()
-        // If the error code signals the buck does not exist
-        if errCode == "NotFound" || errCode == "NoSuchBucket" {
-            return false, nil
+        // Iterate through the instances of each reservation counting their state
+        for _, reservation := range page.Reservations {
+            for _, instance := range reservation.Instances {
+                if instance.State != nil {
+                    states[string(instance.State.Name)] += 1
+                }
+            }
         }
     }
 
-    // Any other error (403 Forbidden, network, etc)
-    return false, err
+    return states, nil
 }
 
-// Create an S3 bucket.
+// Finds the instance of the run with the passed in public or private IP, used to map
+// a client connection to the instance it runs on.
 //
 // @Parameters
-// - bucketName:  The name of the bucket to be created
+// - ipAddr:  The public or private IP address of the instance
 // - callTime:  The length of time the API call is allowed to execute
 //
 // @Returns
+// - The ID of the instance
 // - Error if it occurs, otherwise nil on success
 //
-func (S3Man *S3Manager) CreateBucket(bucketName string, callTime time.Duration) error {
+func (Ec2Man *Ec2Manger) InstanceIdByIp(ipAddr string, callTime time.Duration) (string, error) {
+    instanceIds := Ec2Man.InstanceIds()
+    // If no instances have been created
+    if len(instanceIds) == 0 {
+        return "", fmt.Errorf("no instances have been created")
+    }
+
     // Ensure AWS API calls do not hang for longer specified timeout
     ctx, cancel := context.WithTimeout(context.Background(), callTime)
     defer cancel()
 
-    // Create the bucket based on the bucket name in S3 manager
-    _, err := S3Man.client.CreateBucket(ctx, &s3.CreateBucketInput{
-        Bucket: aws.String(bucketName),
-    })
-    // If the bucket was successfully created
-    if err == nil {
-        return nil
-    }
-
-    var apiErr smithy.APIError
+    var instanceId string
+    paginator := ec2.NewDescribeInstancesPaginator(Ec2Man.client,
+                                                   &ec2.DescribeInstancesInput{
+                                                       InstanceIds: instanceIds,
+                                                   })
+    // Iterate through the pages of the described instances until the IP is found
+    for paginator.HasMorePages() && instanceId == "" {
+        page, err := paginator.NextPage(ctx)
+        if err != nil {
+            return "", err
+        }
 
-    // If an API error occured
-    if errors.As(err, &apiErr){
-        // Get the error code
-        errCode := apiErr.ErrorCode()
-        // If the error code signals the bucket already exists
-        if errCode == "BucketAlreadyExists" || errCode == "BucketAlreadyOwnedByYou" {
-            return errors.New("S3 bucket already exists")
+        for _, reservation := range page.Reservations {
+            for _, instance := range reservation.Instances {
+                if aws.ToString(instance.PublicIpAddress) == ipAddr ||
+                   aws.ToString(instance.PrivateIpAddress) == ipAddr {
+                    instanceId = aws.ToString(instance.InstanceId)
+                }
+            }
         }
     }
 
-    // For any other errors
-    return err
+    // If the IP does not belong to an instance of the run
+    if instanceId == "" {
+        return "", fmt.Errorf("no instance of the run has IP %s", ipAddr)
+    }
+
+    return instanceId, nil
 }
 
-// Retrieve object from S3 bucket.
+// Terminates the single instance of the run with the passed in public or private IP,
+// used to remove a misbehaving client without stopping the rest of the fleet.
 //
 // @Parameters
-// - bucketName:  The name of the bucket where the object will be retrieved
-// - key:  The key in bucket used to identify the object to retrieve
+// - ipAddr:  The public or private IP address of the instance
+// - callTime:  The length of time the API calls are allowed to execute
+//
+// @Returns
+// - The ID of the terminated instance
+// - Error if it occurs, otherwise nil on success
+//
+func (Ec2Man *Ec2Manger) TerminateByIp(ipAddr string, callTime time.Duration) (string, error) {
+    instanceId, err := Ec2Man.InstanceIdByIp(ipAddr, callTime)
+    if err != nil {
+        return "", err
+    }
+
+    // If dry-run is enabled, record the termination instead of executing it
+    if DryRun != nil {
+        DryRun.Record("ec2", "TerminateInstances", map[string]any{
+            "instance_ids": []string{instanceId},
+        })
+        return instanceId, nil
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    _, err = Ec2Man.client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
+        InstanceIds: []string{instanceId},
+    })
+    if err != nil {
+        return "", err
+    }
+
+    recordMutation("ec2", "TerminateInstances", map[string]any{
+        "instance_ids": []string{instanceId},
+    })
+    return instanceId, nil
+}
+
+// Stops the running instances of the run without terminating them, so their EBS volumes
+// are kept and the instances stop billing until they are started again.
+//
+// @Parameters
+// - callTime:  The length of time the API calls are allowed to execute
+//
+// @Returns
+// - The IDs of the stopped instances
+// - Error if it occurs, otherwise nil on success
+//
+func (Ec2Man *Ec2Manger) StopEc2Instances(callTime time.Duration) ([]string, error) {
+    var runningIds []string
+    instanceIds := Ec2Man.InstanceIds()
+
+    // If dry-run is enabled, record the stop instead of executing it
+    if DryRun != nil {
+        DryRun.Record("ec2", "StopInstances", map[string]any{"instance_ids": instanceIds})
+        return instanceIds, nil
+    }
+
+    // If no instances have been created, there is nothing to stop
+    if len(instanceIds) == 0 {
+        return nil, nil
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    paginator := ec2.NewDescribeInstancesPaginator(Ec2Man.client,
+                                                   &ec2.DescribeInstancesInput{
+                                                       InstanceIds: instanceIds,
+                                                   })
+    // Iterate through the pages of the described instances
+    for paginator.HasMorePages() {
+        page, err := paginator.NextPage(ctx)
+        if err != nil {
+            return nil, err
+        }
+
+        // Iterate through the instances of each reservation keeping the running ones,
+        // skipping those already terminated such as downscaled clients
+        for _, reservation := range page.Reservations {
+            for _, instance := range reservation.Instances {
+                if instance.State != nil &&
+                   (instance.State.Name == ec2types.InstanceStateNameRunning ||
+                    instance.State.Name == ec2types.InstanceStateNamePending) {
+                    runningIds = append(runningIds, aws.ToString(instance.InstanceId))
+                }
+            }
+        }
+    }
+
+    // If none of the instances are running
+    if len(runningIds) == 0 {
+        return nil, nil
+    }
+
+    _, err := Ec2Man.client.StopInstances(ctx, &ec2.StopInstancesInput{
+        InstanceIds: runningIds,
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    recordMutation("ec2", "StopInstances", map[string]any{"instance_ids": runningIds})
+    return runningIds, nil
+}
+
+// Starts the passed in stopped instances of the run again.
+//
+// @Parameters
+// - instanceIds:  The IDs of the instances to start
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (Ec2Man *Ec2Manger) StartEc2Instances(instanceIds []string,
+                                           callTime time.Duration) error {
+    // If dry-run is enabled, record the start instead of executing it
+    if DryRun != nil {
+        DryRun.Record("ec2", "StartInstances", map[string]any{"instance_ids": instanceIds})
+        return nil
+    }
+
+    // If no instances were stopped, there is nothing to start
+    if len(instanceIds) == 0 {
+        return nil
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    _, err := Ec2Man.client.StartInstances(ctx, &ec2.StartInstancesInput{
+        InstanceIds: instanceIds,
+    })
+    if err != nil {
+        return err
+    }
+
+    recordMutation("ec2", "StartInstances", map[string]any{"instance_ids": instanceIds})
+    return nil
+}
+
+// Terminates the EC2 instances by ID's collected from creation method result.
+//
+// @Parameters
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - The output from the EC2 termination API call
+// - Error if it occurs, otherwise nil on success
+//
+func (Ec2Man *Ec2Manger) TerminateEc2Instances(callTime time.Duration) (
+                                               *ec2.TerminateInstancesOutput, error) {
+    // If dry-run is enabled, record the termination instead of executing it
+    if DryRun != nil {
+        DryRun.Record("ec2", "TerminateInstances", map[string]any{
+            "instance_ids": Ec2Man.InstanceIds(),
+        })
+        return &ec2.TerminateInstancesOutput{}, nil
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    // build termination input with parsed id's
+    terminateInput := &ec2.TerminateInstancesInput{
+        InstanceIds: Ec2Man.InstanceIds(),
+    }
+
+    // Terminate all the collected instance id's
+    termOutput, err := Ec2Man.client.TerminateInstances(ctx, terminateInput)
+    if err != nil {
+        return nil, err
+    }
+
+    recordMutation("ec2", "TerminateInstances", map[string]any{
+        "instance_ids": terminateInput.InstanceIds,
+    })
+    return termOutput, nil
+}
+
+
+// Creates an IAM role with the passed in JSON policy data applied.
+//
+// @Parameters
+// - iamClient:  The client to the IAM service
+// - callTime:  The length of time the API call is allowed to execute
+// - roleName:  The IAM Role to attach to
+// - trustPolicyJson:  The JSON trust policy
+// - permPolicyName:  An identifier name for permissions policy
+// - permPolicyJSON:  The JSON permissions policy
+// - createProfile:  Toggle to set whether instance profiles are created or not
+//
+// @Returns
+// - The ARN of the existing or created role
+// - Error if it occurs, otherwise nil on success
+//
+func IamRoleCreation(iamClient IamApi, callTime time.Duration, roleName string,
+                     trustPolicyJson string, permPolicyName string,
+                     permPolicyJson string, createProfile bool) (string, error) {
+    var roleArn string
+
+    // If dry-run is enabled, record the role setup instead of executing it
+    if DryRun != nil {
+        DryRun.Record("iam", "CreateRole", map[string]any{
+            "permissions_boundary": PermissionsBoundary,
+            "role_name":            roleName,
+            "trust_policy":         trustPolicyJson,
+        })
+        DryRun.Record("iam", "PutRolePolicy", map[string]any{
+            "policy_document": permPolicyJson,
+            "policy_name":     permPolicyName,
+            "role_name":       roleName,
+        })
+
+        // If the instance profile would be created
+        if createProfile {
+            DryRun.Record("iam", "CreateInstanceProfile", map[string]any{
+                "instance_profile": roleName,
+            })
+            DryRun.Record("iam", "AddRoleToInstanceProfile", map[string]any{
+                "instance_profile": roleName,
+                "role_name":        roleName,
+            })
+        }
+
+        return "arn:aws:iam::dry-run:role/" + roleName, nil
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    // Check if the IAM role exists
+    getOut, err := iamClient.GetRole(ctx, &iam.GetRoleInput{
+        RoleName: aws.String(roleName),
+    })
+    if err != nil {
+        var notFound *iamtypes.NoSuchEntityException
+
+        // If the IAM role does not exist
+        if ok := errors.As(err, &notFound); ok {
+            createInput := &iam.CreateRoleInput{
+                RoleName:                 aws.String(roleName),
+                AssumeRolePolicyDocument: aws.String(trustPolicyJson),
+                Tags:                     append([]iamtypes.Tag{serviceIamTag()},
+                                                  iamRunTags()...),
+            }
+
+            // If the account requires a permissions boundary on created roles
+            if PermissionsBoundary != "" {
+                createInput.PermissionsBoundary = aws.String(PermissionsBoundary)
+            }
+
+            // Create the IAM role
+            createOut, err := iamClient.CreateRole(ctx, createInput)
+            if err != nil {
+                return "", fmt.Errorf("CreateRole failed: %w", err)
+            }
+
+            // Set the role ARN from output
+            roleArn = aws.ToString(createOut.Role.Arn)
+
+            recordMutation("iam", "CreateRole", map[string]any{
+                "permissions_boundary": PermissionsBoundary,
+                "role_arn":             roleArn,
+                "trust_policy_sha256":  audit.Sha256([]byte(trustPolicyJson)),
+            })
+        } else {
+            return "", fmt.Errorf("GetRole failed: %w", err)
+        }
+    } else {
+        // Role existed, grab its ARN
+        roleArn = aws.ToString(getOut.Role.Arn)
+    }
+
+    // Attach or overwrite the inline permissions policy
+    _, err = iamClient.PutRolePolicy(ctx, &iam.PutRolePolicyInput{
+        RoleName:       aws.String(roleName),
+        PolicyName:     aws.String(permPolicyName),
+        PolicyDocument: aws.String(permPolicyJson),
+    })
+    if err != nil {
+        return "", fmt.Errorf("PutRolePolicy failed: %w", err)
+    }
+
+    recordMutation("iam", "PutRolePolicy", map[string]any{
+        "policy_name":   permPolicyName,
+        "policy_sha256": audit.Sha256([]byte(permPolicyJson)),
+        "role_name":     roleName,
+    })
+
+    if createProfile {
+        // Create the instance profile
+        _, err = iamClient.CreateInstanceProfile(ctx, &iam.CreateInstanceProfileInput{
+            InstanceProfileName: aws.String(roleName),
+            Tags:                append([]iamtypes.Tag{serviceIamTag()}, iamRunTags()...),
+        })
+        if err != nil {
+            var entityExists *iamtypes.EntityAlreadyExistsException
+
+            // If the error is not that the instance profile already exists
+            if !errors.As(err, &entityExists) {
+                return "", fmt.Errorf("CreateInstanceProfile failed: %w", err)
+            }
+        } else {
+            recordMutation("iam", "CreateInstanceProfile", map[string]any{
+                "instance_profile": roleName,
+            })
+        }
+
+        // Add role to the instance profile
+        _, err = iamClient.AddRoleToInstanceProfile(ctx, &iam.AddRoleToInstanceProfileInput{
+            InstanceProfileName: aws.String(roleName),
+            RoleName:            aws.String(roleName),
+        })
+        if err != nil {
+            return "", fmt.Errorf("AddRoleToInstanceProfile failed: %w", err)
+        }
+
+        recordMutation("iam", "AddRoleToInstanceProfile", map[string]any{
+            "instance_profile": roleName,
+            "role_name":        roleName,
+        })
+    }
+
+    return roleArn, nil
+}
+
+// Attaches or detaches an AWS managed policy on the IAM role, so roles that persist
+// between runs only keep the policy while the option requiring it is enabled.
+//
+// @Parameters
+// - iamClient:  The IAM client used to manage the role
+// - callTime:  The length of time the API call is allowed to execute
+// - roleName:  The name of the IAM role
+// - policyArn:  The ARN of the managed policy
+// - attach:  Whether the policy should be attached or detached
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func SetManagedRolePolicy(iamClient IamApi, callTime time.Duration, roleName string,
+                          policyArn string, attach bool) error {
+    // If dry-run is enabled, record the policy change instead of executing it
+    if DryRun != nil {
+        action := "DetachRolePolicy"
+        if attach {
+            action = "AttachRolePolicy"
+        }
+
+        DryRun.Record("iam", action, map[string]any{"policy_arn": policyArn,
+                                                    "role_name": roleName})
+        return nil
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    // If the policy should be attached, attaching an already attached policy is a no-op
+    if attach {
+        _, err := iamClient.AttachRolePolicy(ctx, &iam.AttachRolePolicyInput{
+            RoleName:  aws.String(roleName),
+            PolicyArn: aws.String(policyArn),
+        })
+        if err != nil {
+            return fmt.Errorf("AttachRolePolicy failed: %w", err)
+        }
+
+        recordMutation("iam", "AttachRolePolicy", map[string]any{"policy_arn": policyArn,
+                                                                  "role_name": roleName})
+        return nil
+    }
+
+    // Detach the policy from the role
+    _, err := iamClient.DetachRolePolicy(ctx, &iam.DetachRolePolicyInput{
+        RoleName:  aws.String(roleName),
+        PolicyArn: aws.String(policyArn),
+    })
+    if err != nil {
+        var notFound *iamtypes.NoSuchEntityException
+
+        // If the error is not that the policy was never attached
+        if !errors.As(err, &notFound) {
+            return fmt.Errorf("DetachRolePolicy failed: %w", err)
+        }
+
+        return nil
+    }
+
+    recordMutation("iam", "DetachRolePolicy", map[string]any{"policy_arn": policyArn,
+                                                              "role_name": roleName})
+    return nil
+}
+
+
+// Struct for managing S3 bucket operations
+type S3Manager struct {
+    client     S3Api
+}
+
+// Generates the S3 manager struct around the passed in S3 client
+//
+// @Parameters
+// - s3Client:  The client to the S3 service, a fake from awstest in tests
+//
+// @Returns
+// - The initialized S3 manager with client reference
+//
+func NewS3Manager(s3Client S3Api) *S3Manager {
+    return &S3Manager{
+        client:     s3Client,
+    }
+}
+
+// Establishes connection to S3 service and generates S3 manager struct
+//
+// @Parameters
+// - awsConfig:  The AWS credential configuration for connecting to service
+//
+// @Returns
+// - The initialized S3 manager with client reference
+//
+func NewS3ManagerFromConfig(awsConfig aws.Config) *S3Manager {
+    return NewS3Manager(s3.NewFromConfig(awsConfig))
+}
+
+// Checks to see if an S3 bucket already exists.
+//
+// @Parameters
+// - bucketName:  The name of the S3 bucket to check existence
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - Boolean toggle whether the bucket exists or not
+// - Error if it occurs, otherwise nil on success
+//
+func (S3Man *S3Manager) BucketExists(bucketName string, callTime time.Duration) (
+                                     bool, error) {
+    // If dry-run is enabled, report the bucket missing so its creation is planned
+    if DryRun != nil {
+        DryRun.Record("s3", "HeadBucket", map[string]any{"bucket": bucketName})
+        return false, nil
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    // Check if the bucket exists and get information
+    _, err := S3Man.client.HeadBucket(ctx, &s3.HeadBucketInput{
+        Bucket: aws.String(bucketName),
+    })
+    // If there was no error, bucket exists and is accessible
+    if err == nil {
+        return true, nil
+    }
+
+    var apiErr smithy.APIError
+
+    // If an API error occured
+    if errors.As(err, &apiErr) {
+        // Get the error code
+        errCode := apiErr.ErrorCode()
+        // If the error code signals the buck does not exist
+        if errCode == "NotFound" || errCode == "NoSuchBucket" {
+            return false, nil
+        }
+    }
+
+    // Any other error (403 Forbidden, network, etc)
+    return false, err
+}
+
+// Create an S3 bucket.
+//
+// @Parameters
+// - bucketName:  The name of the bucket to be created
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (S3Man *S3Manager) CreateBucket(bucketName string, callTime time.Duration) error {
+    // If dry-run is enabled, record the creation instead of executing it
+    if DryRun != nil {
+        DryRun.Record("s3", "CreateBucket", map[string]any{
+            "bucket": bucketName,
+            "note":   "only created if the bucket does not already exist",
+        })
+        return nil
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    // Create the bucket based on the bucket name in S3 manager
+    _, err := S3Man.client.CreateBucket(ctx, &s3.CreateBucketInput{
+        Bucket: aws.String(bucketName),
+    })
+    // If the bucket was successfully created
+    if err == nil {
+        recordMutation("s3", "CreateBucket", map[string]any{"bucket": bucketName})
+        return nil
+    }
+
+    var apiErr smithy.APIError
+
+    // If an API error occured
+    if errors.As(err, &apiErr){
+        // Get the error code
+        errCode := apiErr.ErrorCode()
+        // If the error code signals the bucket already exists
+        if errCode == "BucketAlreadyExists" || errCode == "BucketAlreadyOwnedByYou" {
+            return errors.New("S3 bucket already exists")
+        }
+    }
+
+    // For any other errors
+    return err
+}
+
+// Retrieve object from S3 bucket.
+//
+// @Parameters
+// - bucketName:  The name of the bucket where the object will be retrieved
+// - key:  The key in bucket used to identify the object to retrieve
 // - callTime:  The length of time the API call is allowed to execute
 //
 // @Returns
@@ -462,6 +1137,58 @@ func (S3Man *S3Manager) CreateBucket(bucketName string, callTime time.Duration)
 func (S3Man *S3Manager) GetS3Object(bucketName string, key string,
                                     callTime time.Duration) (
                                     []byte, error) {
+    // If dry-run is enabled, record the retrieval instead of executing it
+    if DryRun != nil {
+        DryRun.Record("s3", "GetObject", map[string]any{"bucket": bucketName, "key": key})
+        return []byte{}, nil
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    // Retrieve the object from S3 storage
+    resp, err := S3Man.client.GetObject(ctx, &s3.GetObjectInput{
+        Bucket: aws.String(bucketName),
+        Key:    aws.String(key),
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    // Close response body on local exit
+    defer resp.Body.Close()
+
+    // Read all the data from the request
+    rawData, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return nil, err
+    }
+
+    return rawData, nil
+}
+
+// Downloads an object from the S3 bucket straight to a file, used for objects too
+// large to hold in memory.
+//
+// @Parameters
+// - bucketName:  The name of the bucket where the object will be retrieved
+// - key:  The key in bucket used to identify the object to retrieve
+// - destPath:  The path of the file the object is written to
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - The number of bytes written to the file
+// - Error if it occurs, otherwise nil on success
+//
+func (S3Man *S3Manager) DownloadS3Object(bucketName string, key string, destPath string,
+                                         callTime time.Duration) (int64, error) {
+    // If dry-run is enabled, record the retrieval instead of executing it
+    if DryRun != nil {
+        DryRun.Record("s3", "GetObject", map[string]any{"bucket": bucketName, "key": key})
+        return 0, nil
+    }
+
     // Ensure AWS API calls do not hang for longer specified timeout
     ctx, cancel := context.WithTimeout(context.Background(), callTime)
     defer cancel()
@@ -472,19 +1199,61 @@ func (S3Man *S3Manager) GetS3Object(bucketName string, key string,
         Key:    aws.String(key),
     })
     if err != nil {
-        return nil, err
+        return 0, err
     }
 
     // Close response body on local exit
     defer resp.Body.Close()
 
-    // Read all the data from the request
-    rawData, err := io.ReadAll(resp.Body)
+    // Create the file the object is written to
+    file, err := os.Create(destPath)
     if err != nil {
-        return nil, err
+        return 0, err
     }
 
-    return rawData, nil
+    // Stream the object body into the file
+    bytesWrote, err := io.Copy(file, resp.Body)
+    if err != nil {
+        file.Close()
+        return bytesWrote, err
+    }
+
+    return bytesWrote, file.Close()
+}
+
+// Deletes an object from the S3 bucket.
+//
+// @Parameters
+// - bucketName:  The name of the bucket where the object will be deleted
+// - key:  The key in bucket used to identify the object to delete
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (S3Man *S3Manager) DeleteS3Object(bucketName string, key string,
+                                       callTime time.Duration) error {
+    // If dry-run is enabled, record the deletion instead of executing it
+    if DryRun != nil {
+        DryRun.Record("s3", "DeleteObject", map[string]any{"bucket": bucketName, "key": key})
+        return nil
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    // Delete the object from S3 storage
+    _, err := S3Man.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+        Bucket: aws.String(bucketName),
+        Key:    aws.String(key),
+    })
+    if err != nil {
+        return err
+    }
+
+    recordMutation("s3", "DeleteObject", map[string]any{"bucket": bucketName, "key": key})
+    return nil
 }
 
 // Put an object into a S3 bucket.
@@ -503,6 +1272,16 @@ func (S3Man *S3Manager) PutS3Object(bucketName string, key string, data []byte,
                                     callTime time.Duration) (string, error) {
     var apiErr smithy.APIError
 
+    // If dry-run is enabled, record the upload under the first candidate key
+    if DryRun != nil {
+        DryRun.Record("s3", "PutObject", map[string]any{
+            "bucket": bucketName,
+            "bytes":  len(data),
+            "key":    key + "-1",
+        })
+        return key + "-1", nil
+    }
+
     // Keep attemping key with number added until unused is found
     for i := 1; ; i++ {
         // Add number to end of key name
@@ -516,12 +1295,19 @@ func (S3Man *S3Manager) PutS3Object(bucketName string, key string, data []byte,
             Key:         aws.String(candidate),
             Body:        bytes.NewReader(data),
             IfNoneMatch: aws.String("*"),
+            Tagging:     aws.String(s3Tagging()),
         })
         // Cancel context per API call
         cancel()
 
         // If the candiate was successful
         if err == nil {
+            recordMutation("s3", "PutObject", map[string]any{
+                "bucket": bucketName,
+                "bytes":  len(data),
+                "key":    candidate,
+                "sha256": audit.Sha256(data),
+            })
             return candidate, nil
         }
 
@@ -535,29 +1321,282 @@ func (S3Man *S3Manager) PutS3Object(bucketName string, key string, data []byte,
     }
 }
 
+// Uploads the data to the exact key in the S3 bucket, overwriting any existing object,
+// used for results where the key is already unique to the run.
+//
+// @Parameters
+// - bucketName:  The name of the S3 bucket where the object will be stored
+// - key:  The key in bucket where the object will be stored
+// - body:  The reader of the data to be stored
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (S3Man *S3Manager) UploadS3Object(bucketName string, key string, body io.Reader,
+                                       callTime time.Duration) error {
+    // If dry-run is enabled, record the upload instead of executing it
+    if DryRun != nil {
+        DryRun.Record("s3", "PutObject", map[string]any{"bucket": bucketName, "key": key})
+        return nil
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    hasher := sha256.New()
+    // Hash the data as it is uploaded for the audit log
+    body = io.TeeReader(body, hasher)
+
+    // Put the object in S3 storage at the key
+    _, err := S3Man.client.PutObject(ctx, &s3.PutObjectInput{
+        Bucket:  aws.String(bucketName),
+        Key:     aws.String(key),
+        Body:    body,
+        Tagging: aws.String(s3Tagging()),
+    })
+    if err != nil {
+        return err
+    }
+
+    recordMutation("s3", "PutObject", map[string]any{
+        "bucket": bucketName,
+        "key":    key,
+        "sha256": hex.EncodeToString(hasher.Sum(nil)),
+    })
+    return nil
+}
+
+// Sets a lifecycle rule expiring objects under the prefix after the passed in number
+// of days, any other lifecycle rules on the bucket are preserved.
+//
+// @Parameters
+// - bucketName:  The name of the S3 bucket to apply the rule to
+// - ruleId:  The ID of the rule, an existing rule with the ID is replaced
+// - prefix:  The key prefix the rule applies to
+// - days:  The number of days objects are kept before they expire
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (S3Man *S3Manager) SetBucketExpiration(bucketName string, ruleId string, prefix string,
+                                            days int, callTime time.Duration) error {
+    // If dry-run is enabled, record the lifecycle rule instead of applying it
+    if DryRun != nil {
+        DryRun.Record("s3", "PutBucketLifecycleConfiguration", map[string]any{
+            "bucket":          bucketName,
+            "expiration_days": days,
+            "prefix":          prefix,
+            "rule_id":         ruleId,
+        })
+        return nil
+    }
+
+    var rules []s3types.LifecycleRule
+    var apiErr smithy.APIError
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    // Get the existing lifecycle rules so they are not overwritten
+    getOut, err := S3Man.client.GetBucketLifecycleConfiguration(ctx,
+        &s3.GetBucketLifecycleConfigurationInput{Bucket: aws.String(bucketName)})
+    if err != nil {
+        // If the error is not that the bucket has no lifecycle configuration
+        if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "NoSuchLifecycleConfiguration" {
+            return err
+        }
+    } else {
+        // Iterate through the existing rules keeping all but the replaced rule
+        for _, rule := range getOut.Rules {
+            if aws.ToString(rule.ID) != ruleId {
+                rules = append(rules, rule)
+            }
+        }
+    }
+
+    rules = append(rules, s3types.LifecycleRule{
+        ID:         aws.String(ruleId),
+        Status:     s3types.ExpirationStatusEnabled,
+        Filter:     &s3types.LifecycleRuleFilter{Prefix: aws.String(prefix)},
+        Expiration: &s3types.LifecycleExpiration{Days: aws.Int32(int32(days))},
+    })
+
+    // Apply the lifecycle rules to the bucket
+    _, err = S3Man.client.PutBucketLifecycleConfiguration(ctx,
+        &s3.PutBucketLifecycleConfigurationInput{
+            Bucket:                 aws.String(bucketName),
+            LifecycleConfiguration: &s3types.BucketLifecycleConfiguration{Rules: rules},
+        })
+    if err != nil {
+        return err
+    }
+
+    recordMutation("s3", "PutBucketLifecycleConfiguration", map[string]any{
+        "bucket":          bucketName,
+        "expiration_days": days,
+        "prefix":          prefix,
+        "rule_id":         ruleId,
+    })
+    return nil
+}
+
+// Sets the default encryption of the bucket to SSE-KMS with the passed in key, so every
+// object stored after is encrypted with it.
+//
+// @Parameters
+// - bucketName:  The name of the S3 bucket to encrypt
+// - kmsKeyId:  The ID or ARN of the KMS key objects are encrypted with
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (S3Man *S3Manager) SetBucketEncryption(bucketName string, kmsKeyId string,
+                                            callTime time.Duration) error {
+    // If dry-run is enabled, record the encryption instead of applying it
+    if DryRun != nil {
+        DryRun.Record("s3", "PutBucketEncryption", map[string]any{"bucket": bucketName,
+                                                                  "kms_key_id": kmsKeyId})
+        return nil
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    // Apply the KMS key as the default encryption, with a bucket key to limit KMS calls
+    _, err := S3Man.client.PutBucketEncryption(ctx, &s3.PutBucketEncryptionInput{
+        Bucket: aws.String(bucketName),
+        ServerSideEncryptionConfiguration: &s3types.ServerSideEncryptionConfiguration{
+            Rules: []s3types.ServerSideEncryptionRule{{
+                ApplyServerSideEncryptionByDefault: &s3types.ServerSideEncryptionByDefault{
+                    SSEAlgorithm:   s3types.ServerSideEncryptionAwsKms,
+                    KMSMasterKeyID: aws.String(kmsKeyId),
+                },
+                BucketKeyEnabled: aws.Bool(true),
+            }},
+        },
+    })
+    if err != nil {
+        return err
+    }
+
+    recordMutation("s3", "PutBucketEncryption", map[string]any{"bucket": bucketName,
+                                                              "kms_key_id": kmsKeyId})
+    return nil
+}
+
+// Blocks all public access to the bucket, through both ACLs and bucket policies.
+//
+// @Parameters
+// - bucketName:  The name of the S3 bucket to block public access to
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (S3Man *S3Manager) BlockPublicAccess(bucketName string, callTime time.Duration) error {
+    // If dry-run is enabled, record the block instead of applying it
+    if DryRun != nil {
+        DryRun.Record("s3", "PutPublicAccessBlock", map[string]any{"bucket": bucketName})
+        return nil
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    // Block and ignore public ACLs and policies on the bucket
+    _, err := S3Man.client.PutPublicAccessBlock(ctx, &s3.PutPublicAccessBlockInput{
+        Bucket: aws.String(bucketName),
+        PublicAccessBlockConfiguration: &s3types.PublicAccessBlockConfiguration{
+            BlockPublicAcls:       aws.Bool(true),
+            BlockPublicPolicy:     aws.Bool(true),
+            IgnorePublicAcls:      aws.Bool(true),
+            RestrictPublicBuckets: aws.Bool(true),
+        },
+    })
+    if err != nil {
+        return err
+    }
+
+    recordMutation("s3", "PutPublicAccessBlock", map[string]any{"bucket": bucketName})
+    return nil
+}
+
+// Replaces the policy of the bucket with the passed in policy document.
+//
+// @Parameters
+// - bucketName:  The name of the S3 bucket to apply the policy to
+// - policy:  The JSON policy document
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (S3Man *S3Manager) SetBucketPolicy(bucketName string, policy string,
+                                        callTime time.Duration) error {
+    // If dry-run is enabled, record the policy instead of applying it
+    if DryRun != nil {
+        DryRun.Record("s3", "PutBucketPolicy", map[string]any{"bucket": bucketName,
+                                                              "policy": policy})
+        return nil
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    // Apply the policy to the bucket
+    _, err := S3Man.client.PutBucketPolicy(ctx, &s3.PutBucketPolicyInput{
+        Bucket: aws.String(bucketName),
+        Policy: aws.String(policy),
+    })
+    if err != nil {
+        return err
+    }
+
+    recordMutation("s3", "PutBucketPolicy", map[string]any{"bucket": bucketName,
+                                                          "sha256": audit.Sha256([]byte(policy))})
+    return nil
+}
+
 
 // Struct for managing S3 bucket operations
 type SsmManager struct {
-    client    *ssm.Client
+    client    SsmApi
 }
 
-// Establishes connection to SSM service and generates SSM manager struct
+// Generates the SSM manager struct around the passed in SSM client
 //
 // @Parameters
-// - awsConfig:  The AWS credential configuration for connecting to service
+// - ssmClient:  The client to the SSM service, a fake from awstest in tests
 //
 // @Returns
 // - The initialized SSM manager with client reference
 //
-func NewSsmManager(config aws.Config) *SsmManager {
-    // Set up a new SSM client
-    ssmClient := ssm.NewFromConfig(config)
-
+func NewSsmManager(ssmClient SsmApi) *SsmManager {
     return &SsmManager{
         client:    ssmClient,
     }
 }
 
+// Establishes connection to SSM service and generates SSM manager struct
+//
+// @Parameters
+// - awsConfig:  The AWS credential configuration for connecting to service
+//
+// @Returns
+// - The initialized SSM manager with client reference
+//
+func NewSsmManagerFromConfig(awsConfig aws.Config) *SsmManager {
+    return NewSsmManager(ssm.NewFromConfig(awsConfig))
+}
+
 // Retrieve value from AWS SSM Parameter Store.
 //
 // @Parameters
@@ -570,6 +1609,12 @@ func NewSsmManager(config aws.Config) *SsmManager {
 //
 func (SsmMan *SsmManager) GetSsmParameter(parameter string, callTime time.Duration) (
                                           string, error) {
+    // If dry-run is enabled, record the retrieval instead of executing it
+    if DryRun != nil {
+        DryRun.Record("ssm", "GetParameter", map[string]any{"name": parameter})
+        return "", nil
+    }
+
     // Ensure AWS API calls do not hang for longer specified timeout
     ctx, cancel := context.WithTimeout(context.Background(), callTime)
     defer cancel()
@@ -586,6 +1631,44 @@ func (SsmMan *SsmManager) GetSsmParameter(parameter string, callTime time.Durati
     return aws.ToString(output.Parameter.Value), nil
 }
 
+// Resolves the region specific AMI ID from an SSM public parameter, unless an AMI ID
+// override is set.
+//
+// @Parameters
+// - ami:  The AMI ID override, empty to resolve from the parameter
+// - parameter:  The SSM public parameter with the AMI ID, empty uses the default
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - The AMI ID to launch instances with
+// - Error if it occurs, otherwise nil on success
+//
+func (SsmMan *SsmManager) ResolveAmi(ami string, parameter string, callTime time.Duration) (
+                                     string, error) {
+    // If an AMI ID override is set, use it as is
+    if ami != "" {
+        return ami, nil
+    }
+
+    // If no parameter is set, use the default Ubuntu parameter
+    if parameter == "" {
+        parameter = DefaultAmiParameter
+    }
+
+    // Get the AMI ID for the region from the public parameter
+    resolved, err := SsmMan.GetSsmParameter(parameter, callTime)
+    if err != nil {
+        return "", fmt.Errorf("error resolving AMI from %s - %w", parameter, err)
+    }
+
+    // If dry-run is enabled, let EC2 resolve the parameter at launch
+    if DryRun != nil {
+        return "resolve:ssm:" + parameter, nil
+    }
+
+    return resolved, nil
+}
+
 // Put value into AWS SSM Parameter Store.
 //
 // @Parameters
@@ -602,6 +1685,16 @@ func (SsmMan *SsmManager) PutSsmParameter(parameter string, data string,
                                           string, error) {
     var existsErr *ssmtypes.ParameterAlreadyExists
 
+    // If dry-run is enabled, record the write under the first candidate name
+    if DryRun != nil {
+        DryRun.Record("ssm", "PutParameter", map[string]any{
+            "bytes": len(data),
+            "name":  parameter + "-1",
+            "type":  string(ssmtypes.ParameterTypeSecureString),
+        })
+        return parameter + "-1", nil
+    }
+
     // Keep attemping parameters with number added until unused is found
     for i := 1;; i++ {
         // Add number to end of parameter name
@@ -615,6 +1708,9 @@ func (SsmMan *SsmManager) PutSsmParameter(parameter string, data string,
             Value:     aws.String(data),
             Type:      ssmtypes.ParameterTypeSecureString,
             Overwrite: aws.Bool(false),
+            Tags:      append([]ssmtypes.Tag{{Key: aws.String(ServiceTagKey),
+                                              Value: aws.String(ServiceTagValue)}},
+                              ssmRunTags()...),
         })
         // Cancel context per API call
         cancel()
@@ -629,6 +1725,114 @@ func (SsmMan *SsmManager) PutSsmParameter(parameter string, data string,
             return "", err
         }
 
+        recordMutation("ssm", "PutParameter", map[string]any{
+            "name":         candidate,
+            "value_sha256": audit.Sha256([]byte(data)),
+        })
         return candidate, nil
     }
 }
+
+// Overwrites the value of an existing parameter in AWS SSM Parameter Store, used to
+// replace values such as rotated certificates under the path clients already know.
+//
+// @Parameters
+// - parameter:  name of the existing parameter to overwrite
+// - data:  The data to store with associated parameter
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (SsmMan *SsmManager) UpdateSsmParameter(parameter string, data string,
+                                             callTime time.Duration) error {
+    // If dry-run is enabled, record the overwrite instead of executing it
+    if DryRun != nil {
+        DryRun.Record("ssm", "PutParameter", map[string]any{
+            "bytes":     len(data),
+            "name":      parameter,
+            "overwrite": true,
+            "type":      string(ssmtypes.ParameterTypeSecureString),
+        })
+        return nil
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    // Tags can not be passed when overwriting, the parameter keeps those it was created with
+    _, err := SsmMan.client.PutParameter(ctx, &ssm.PutParameterInput{
+        Name:      aws.String(parameter),
+        Value:     aws.String(data),
+        Type:      ssmtypes.ParameterTypeSecureString,
+        Overwrite: aws.Bool(true),
+    })
+    if err != nil {
+        return fmt.Errorf("error overwriting parameter %s - %w", parameter, err)
+    }
+
+    recordMutation("ssm", "PutParameter", map[string]any{
+        "name":         parameter,
+        "overwrite":    true,
+        "value_sha256": audit.Sha256([]byte(data)),
+    })
+    return nil
+}
+
+// Gets which of the passed in instances have an SSM agent registered and online,
+// meaning Session Manager sessions can be opened to them.
+//
+// @Parameters
+// - instanceIds:  The IDs of the instances to check
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - The IDs of the instances with an online SSM agent
+// - Error if it occurs, otherwise nil on success
+//
+func (SsmMan *SsmManager) OnlineInstances(instanceIds []string, callTime time.Duration) (
+                                          []string, error) {
+    var online []string
+    var nextToken *string
+
+    // If dry-run is enabled, record the check and treat every instance as online
+    if DryRun != nil {
+        DryRun.Record("ssm", "DescribeInstanceInformation", map[string]any{
+            "instance_ids": instanceIds,
+        })
+        return instanceIds, nil
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    for {
+        // Get the SSM agent information for the instances
+        output, err := SsmMan.client.DescribeInstanceInformation(ctx,
+            &ssm.DescribeInstanceInformationInput{
+                Filters: []ssmtypes.InstanceInformationStringFilter{
+                    {Key: aws.String("InstanceIds"), Values: instanceIds},
+                },
+                NextToken: nextToken,
+            })
+        if err != nil {
+            return nil, err
+        }
+
+        // Iterate through the registered instances saving the online ones
+        for _, info := range output.InstanceInformationList {
+            if info.PingStatus == ssmtypes.PingStatusOnline {
+                online = append(online, aws.ToString(info.InstanceId))
+            }
+        }
+
+        // If there are no more pages of results
+        if output.NextToken == nil {
+            return online, nil
+        }
+
+        nextToken = output.NextToken
+    }
+}
@@ -0,0 +1,346 @@
+package awsutils_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/ngimb64/Kloud-Kraken/pkg/awsutils"
+	"github.com/ngimb64/Kloud-Kraken/pkg/awsutils/awstest"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestEc2Manager(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    fake := awstest.NewEc2()
+    ec2Man := awsutils.NewEc2Manager("ami-0123456789abcdef0", fake, 2, "g4dn.xlarge",
+                                     awsutils.ServiceTagValue, "ClientRole", "a1b2c3d4",
+                                     nil, nil, "subnet-01", 100, true,
+                                     []byte("#!/bin/bash"))
+
+    err := ec2Man.CreateEc2Instances(time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the launch applied the subnet and attached the data volume
+    assert.Equal("subnet-01", *fake.Launched[0].SubnetId)
+    assert.Equal(1, len(fake.Launched[0].BlockDeviceMappings))
+    // Ensure self terminating clients terminate their instances on shutdown
+    assert.Equal(ec2types.ShutdownBehaviorTerminate,
+                 fake.Launched[0].InstanceInitiatedShutdownBehavior)
+    // Ensure the instances are tagged with the service and run
+    tags := fake.Launched[0].TagSpecifications[0].Tags
+    assert.Equal(awsutils.RunTagKey, *tags[1].Key)
+    assert.Equal("a1b2c3d4", *tags[1].Value)
+    assert.Equal(2, len(ec2Man.InstanceIds()))
+
+    states, err := ec2Man.InstanceStates(time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(map[string]int{"running": 2}, states)
+
+    // Ensure an instance is found by its IP
+    instanceId, err := ec2Man.InstanceIdByIp("10.0.0.1", time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(ec2Man.InstanceIds()[0], instanceId)
+
+    // Ensure a single instance is terminated by its IP
+    instanceId, err = ec2Man.TerminateByIp("10.0.0.2", time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(ec2Man.InstanceIds()[1], instanceId)
+    _, err = ec2Man.TerminateByIp("10.0.0.9", time.Second)
+    assert.NotEqual(nil, err)
+
+    termOutput, err := ec2Man.TerminateEc2Instances(time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(2, len(termOutput.TerminatingInstances))
+
+    states, err = ec2Man.InstanceStates(time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(map[string]int{"terminated": 2}, states)
+    assert.Equal([]string{"RunInstances", "DescribeInstances", "DescribeInstances",
+                          "DescribeInstances", "TerminateInstances", "DescribeInstances",
+                          "TerminateInstances", "DescribeInstances"}, fake.Calls())
+
+    // Ensure launch failures other than the propagating profile are returned as is
+    fake.Fail("RunInstances", awstest.ApiError("InsufficientInstanceCapacity", "no capacity"))
+    assert.NotEqual(nil, ec2Man.CreateEc2Instances(time.Second))
+}
+
+
+func TestAddEc2Instances(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    fake := awstest.NewEc2()
+    ec2Man := awsutils.NewEc2Manager("ami-0123456789abcdef0", fake, 2, "g4dn.xlarge",
+                                     awsutils.ServiceTagValue, "ClientRole", "a1b2c3d4",
+                                     nil, nil, "", 0, false, []byte("#!/bin/bash"))
+
+    err := ec2Man.CreateEc2Instances(time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    addedIds, err := ec2Man.AddEc2Instances(3, time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the added launch used the same user data with its own count
+    assert.Equal(int32(3), *fake.Launched[1].MaxCount)
+    assert.Equal(fake.Launched[0].UserData, fake.Launched[1].UserData)
+    // Ensure instances of clients that do not self terminate keep the default shutdown
+    assert.Equal(ec2types.ShutdownBehavior(""), fake.Launched[1].InstanceInitiatedShutdownBehavior)
+    // Ensure the added instances are part of the fleet
+    assert.Equal(3, len(addedIds))
+    assert.Equal(5, len(ec2Man.InstanceIds()))
+    assert.Equal(5, ec2Man.Count())
+    assert.Equal(addedIds, ec2Man.InstanceIds()[2:])
+
+    // Ensure the added instances are terminated with the fleet
+    termOutput, err := ec2Man.TerminateEc2Instances(time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(5, len(termOutput.TerminatingInstances))
+
+    falacies := []int{0, -1}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        _, err = ec2Man.AddEc2Instances(falacy, time.Second)
+        assert.NotEqual(nil, err)
+    }
+}
+
+
+func TestStopStartEc2Instances(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    fake := awstest.NewEc2()
+    ec2Man := awsutils.NewEc2Manager("ami-0123456789abcdef0", fake, 3, "g4dn.xlarge",
+                                     awsutils.ServiceTagValue, "ClientRole", "a1b2c3d4",
+                                     nil, nil, "", 0, false, []byte("#!/bin/bash"))
+
+    err := ec2Man.CreateEc2Instances(time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    _, err = ec2Man.TerminateByIp("10.0.0.3", time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure only the running instances are stopped, skipping the terminated one
+    stoppedIds, err := ec2Man.StopEc2Instances(time.Second)
+    assert.Equal(nil, err)
+    assert.Equal(ec2Man.InstanceIds()[:2], stoppedIds)
+
+    states, err := ec2Man.InstanceStates(time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(map[string]int{"stopped": 2, "terminated": 1}, states)
+
+    // Ensure the stopped instances are started again
+    err = ec2Man.StartEc2Instances(stoppedIds, time.Second)
+    assert.Equal(nil, err)
+
+    states, err = ec2Man.InstanceStates(time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(map[string]int{"running": 2, "terminated": 1}, states)
+
+    // Ensure a failed stop is returned
+    fake.Fail("StopInstances", awstest.ApiError("UnsupportedOperation", "spot instance"))
+    _, err = ec2Man.StopEc2Instances(time.Second)
+    assert.NotEqual(nil, err)
+}
+
+
+func TestS3Manager(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    fake := awstest.NewS3()
+    s3Man := awsutils.NewS3Manager(fake)
+
+    exists, err := s3Man.BucketExists("test-bucket", time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(false, exists)
+
+    err = s3Man.CreateBucket("test-bucket", time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure creating the bucket again reports it exists
+    assert.NotEqual(nil, s3Man.CreateBucket("test-bucket", time.Second))
+
+    exists, err = s3Man.BucketExists("test-bucket", time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(true, exists)
+
+    // Ensure each put of the same key is numbered instead of overwritten
+    for _, expected := range []string{"client-1", "client-2"} {
+        key, err := s3Man.PutS3Object("test-bucket", "client", []byte("binary"),
+                                      time.Second)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        assert.Equal(expected, key)
+    }
+
+    data, err := s3Man.GetS3Object("test-bucket", "client-2", time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal([]byte("binary"), data)
+
+    err = s3Man.UploadS3Object("test-bucket", "runs/loot.txt",
+                               bytes.NewReader([]byte("hash:plain")), time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    err = s3Man.DeleteS3Object("test-bucket", "client-1", time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    _, ok := fake.Object("test-bucket", "client-1")
+    assert.Equal(false, ok)
+
+    // Ensure setting the expiration twice replaces the rule instead of adding another
+    for range 2 {
+        err = s3Man.SetBucketExpiration("test-bucket", "kloud-kraken-results", "runs/", 7,
+                                        time.Second)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+    }
+    assert.Equal(1, len(fake.Lifecycle("test-bucket")))
+
+    err = s3Man.SetBucketEncryption("test-bucket", "1234abcd-12ab-34cd-56ef-1234567890ab",
+                                    time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    encryption, ok := fake.Encryption("test-bucket")
+    assert.True(ok)
+    assert.Equal(s3types.ServerSideEncryptionAwsKms, encryption.SSEAlgorithm)
+    assert.Equal("1234abcd-12ab-34cd-56ef-1234567890ab", *encryption.KMSMasterKeyID)
+
+    err = s3Man.BlockPublicAccess("test-bucket", time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    block, ok := fake.PublicAccessBlock("test-bucket")
+    assert.True(ok)
+    assert.True(*block.BlockPublicPolicy && *block.RestrictPublicBuckets)
+
+    err = s3Man.SetBucketPolicy("test-bucket", `{"Version":"2012-10-17"}`, time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    policy, ok := fake.Policy("test-bucket")
+    assert.True(ok)
+    assert.Equal(`{"Version":"2012-10-17"}`, policy)
+
+    // Ensure hardening a missing bucket fails
+    assert.NotEqual(nil, s3Man.BlockPublicAccess("missing-bucket", time.Second))
+
+    // Ensure injected errors are returned
+    fake.Fail("GetObject", errors.New("connection reset"))
+    _, err = s3Man.GetS3Object("test-bucket", "client-2", time.Second)
+    assert.NotEqual(nil, err)
+}
+
+
+func TestSsmManager(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    fake := awstest.NewSsm(map[string]string{awsutils.DefaultAmiParameter: "ami-0abc"})
+    ssmMan := awsutils.NewSsmManager(fake)
+
+    ami, err := ssmMan.ResolveAmi("", "", time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("ami-0abc", ami)
+
+    // Ensure a missing parameter is reported
+    _, err = ssmMan.ResolveAmi("", "/missing/parameter", time.Second)
+    assert.NotEqual(nil, err)
+
+    // Ensure each put of the same parameter is numbered instead of overwritten
+    for _, expected := range []string{"kloud-kraken-cert-1", "kloud-kraken-cert-2"} {
+        parameter, err := ssmMan.PutSsmParameter("kloud-kraken-cert", "PEM", time.Second)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        assert.Equal(expected, parameter)
+    }
+
+    value, err := ssmMan.GetSsmParameter("kloud-kraken-cert-2", time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("PEM", value)
+
+    err = ssmMan.UpdateSsmParameter("kloud-kraken-cert-2", "ROTATED", time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the value was overwritten in place rather than stored under a new name
+    value, _ = fake.Parameter("kloud-kraken-cert-2")
+    assert.Equal("ROTATED", value)
+
+    fake.SetOnline("i-01", "i-02", "i-03")
+    online, err := ssmMan.OnlineInstances([]string{"i-01", "i-03", "i-04"}, time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure every page of online instances was collected
+    assert.Equal([]string{"i-01", "i-03"}, online)
+}
+
+
+func TestRunScopedNames(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    assert.Equal("/kloud-kraken/a1b2c3d4/tls/cert", awsutils.CertParameter("a1b2c3d4"))
+    assert.Equal("/kloud-kraken/a1b2c3d4/auth/token", awsutils.TokenParameter("a1b2c3d4"))
+    assert.Equal("/kloud-kraken/a1b2c3d4/brain/password", awsutils.BrainParameter("a1b2c3d4"))
+    assert.Equal("/kloud-kraken/a1b2c3d4/", awsutils.ParameterPrefix("a1b2c3d4"))
+    assert.Equal("kloud-kraken/a1b2c3d4/client", awsutils.ClientBinaryKey("a1b2c3d4"))
+    assert.Equal("kloud-kraken/a1b2c3d4/hashcat", awsutils.HashcatArtifactKey("a1b2c3d4"))
+    assert.Equal("/kloud-kraken/a1b2c3d4", awsutils.LogGroup("a1b2c3d4"))
+    // Ensure concurrent runs do not share names
+    assert.NotEqual(awsutils.LogGroup("a1b2c3d4"), awsutils.LogGroup("e5f6a7b8"))
+}
+
+
+func TestRunTags(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    awsutils.SetRunTags("acme-q3", map[string]string{"Client": "Acme Corp"})
+    // Clear the run tags on local exit so other tests launch without them
+    defer awsutils.SetRunTags("", nil)
+
+    fake := awstest.NewEc2()
+    ec2Man := awsutils.NewEc2Manager("ami-0123456789abcdef0", fake, 1, "g4dn.xlarge",
+                                     awsutils.ServiceTagValue, "ClientRole", "a1b2c3d4",
+                                     nil, nil, "", 0, false, []byte("#!/bin/bash"))
+
+    err := ec2Man.CreateEc2Instances(time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    tags := map[string]string{}
+    for _, tag := range fake.Launched[0].TagSpecifications[0].Tags {
+        tags[*tag.Key] = *tag.Value
+    }
+
+    // Ensure the custom tags and run name are applied alongside the service and run tags
+    assert.Equal(map[string]string{"Client": "Acme Corp", awsutils.RunNameTagKey: "acme-q3",
+                                   awsutils.RunTagKey: "a1b2c3d4",
+                                   awsutils.ServiceTagKey: awsutils.ServiceTagValue}, tags)
+
+    // Ensure an unnamed run without custom tags has none
+    awsutils.SetRunTags("", nil)
+    assert.Equal(0, len(awsutils.RunTags))
+}
@@ -0,0 +1,105 @@
+package awsutils
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+
+// Ec2Api is the subset of the EC2 client used by the EC2 manager, satisfied by
+// *ec2.Client and the fakes in awstest
+type Ec2Api interface {
+    DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput,
+                      optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+    RunInstances(ctx context.Context, params *ec2.RunInstancesInput,
+                 optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error)
+    StartInstances(ctx context.Context, params *ec2.StartInstancesInput,
+                   optFns ...func(*ec2.Options)) (*ec2.StartInstancesOutput, error)
+    StopInstances(ctx context.Context, params *ec2.StopInstancesInput,
+                  optFns ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error)
+    TerminateInstances(ctx context.Context, params *ec2.TerminateInstancesInput,
+                       optFns ...func(*ec2.Options)) (*ec2.TerminateInstancesOutput, error)
+}
+
+// IamApi is the subset of the IAM client used to create, verify, and tear down the roles
+// of a run, satisfied by *iam.Client and the fakes in awstest
+type IamApi interface {
+    AddRoleToInstanceProfile(ctx context.Context, params *iam.AddRoleToInstanceProfileInput,
+                             optFns ...func(*iam.Options)) (
+                             *iam.AddRoleToInstanceProfileOutput, error)
+    AttachRolePolicy(ctx context.Context, params *iam.AttachRolePolicyInput,
+                     optFns ...func(*iam.Options)) (*iam.AttachRolePolicyOutput, error)
+    CreateInstanceProfile(ctx context.Context, params *iam.CreateInstanceProfileInput,
+                          optFns ...func(*iam.Options)) (
+                          *iam.CreateInstanceProfileOutput, error)
+    CreateRole(ctx context.Context, params *iam.CreateRoleInput,
+               optFns ...func(*iam.Options)) (*iam.CreateRoleOutput, error)
+    DeleteInstanceProfile(ctx context.Context, params *iam.DeleteInstanceProfileInput,
+                          optFns ...func(*iam.Options)) (
+                          *iam.DeleteInstanceProfileOutput, error)
+    DeleteRole(ctx context.Context, params *iam.DeleteRoleInput,
+               optFns ...func(*iam.Options)) (*iam.DeleteRoleOutput, error)
+    DeleteRolePolicy(ctx context.Context, params *iam.DeleteRolePolicyInput,
+                     optFns ...func(*iam.Options)) (*iam.DeleteRolePolicyOutput, error)
+    DetachRolePolicy(ctx context.Context, params *iam.DetachRolePolicyInput,
+                     optFns ...func(*iam.Options)) (*iam.DetachRolePolicyOutput, error)
+    GetInstanceProfile(ctx context.Context, params *iam.GetInstanceProfileInput,
+                       optFns ...func(*iam.Options)) (*iam.GetInstanceProfileOutput, error)
+    GetRole(ctx context.Context, params *iam.GetRoleInput,
+            optFns ...func(*iam.Options)) (*iam.GetRoleOutput, error)
+    PutRolePolicy(ctx context.Context, params *iam.PutRolePolicyInput,
+                  optFns ...func(*iam.Options)) (*iam.PutRolePolicyOutput, error)
+    RemoveRoleFromInstanceProfile(ctx context.Context,
+                                  params *iam.RemoveRoleFromInstanceProfileInput,
+                                  optFns ...func(*iam.Options)) (
+                                  *iam.RemoveRoleFromInstanceProfileOutput, error)
+    SimulatePrincipalPolicy(ctx context.Context, params *iam.SimulatePrincipalPolicyInput,
+                            optFns ...func(*iam.Options)) (
+                            *iam.SimulatePrincipalPolicyOutput, error)
+}
+
+// S3Api is the subset of the S3 client used by the S3 manager, satisfied by *s3.Client
+// and the fakes in awstest
+type S3Api interface {
+    CreateBucket(ctx context.Context, params *s3.CreateBucketInput,
+                 optFns ...func(*s3.Options)) (*s3.CreateBucketOutput, error)
+    DeleteObject(ctx context.Context, params *s3.DeleteObjectInput,
+                 optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+    GetBucketLifecycleConfiguration(ctx context.Context,
+                                    params *s3.GetBucketLifecycleConfigurationInput,
+                                    optFns ...func(*s3.Options)) (
+                                    *s3.GetBucketLifecycleConfigurationOutput, error)
+    GetObject(ctx context.Context, params *s3.GetObjectInput,
+              optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+    HeadBucket(ctx context.Context, params *s3.HeadBucketInput,
+               optFns ...func(*s3.Options)) (*s3.HeadBucketOutput, error)
+    PutBucketEncryption(ctx context.Context, params *s3.PutBucketEncryptionInput,
+                        optFns ...func(*s3.Options)) (*s3.PutBucketEncryptionOutput, error)
+    PutBucketLifecycleConfiguration(ctx context.Context,
+                                    params *s3.PutBucketLifecycleConfigurationInput,
+                                    optFns ...func(*s3.Options)) (
+                                    *s3.PutBucketLifecycleConfigurationOutput, error)
+    PutBucketPolicy(ctx context.Context, params *s3.PutBucketPolicyInput,
+                    optFns ...func(*s3.Options)) (*s3.PutBucketPolicyOutput, error)
+    PutObject(ctx context.Context, params *s3.PutObjectInput,
+              optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+    PutPublicAccessBlock(ctx context.Context, params *s3.PutPublicAccessBlockInput,
+                         optFns ...func(*s3.Options)) (*s3.PutPublicAccessBlockOutput, error)
+}
+
+// SsmApi is the subset of the SSM client used by the SSM manager, satisfied by
+// *ssm.Client and the fakes in awstest
+type SsmApi interface {
+    DescribeInstanceInformation(ctx context.Context,
+                                params *ssm.DescribeInstanceInformationInput,
+                                optFns ...func(*ssm.Options)) (
+                                *ssm.DescribeInstanceInformationOutput, error)
+    GetParameter(ctx context.Context, params *ssm.GetParameterInput,
+                 optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+    PutParameter(ctx context.Context, params *ssm.PutParameterInput,
+                 optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error)
+}
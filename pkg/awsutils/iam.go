@@ -0,0 +1,255 @@
+package awsutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+// Package level variables
+var PermissionsBoundary string  // ARN of the boundary policy set on created roles, empty for none
+
+// IamRun tracks the IAM roles, policies, and instance profiles created for a run so
+// they can be deleted in teardown
+type IamRun struct {
+    client   IamApi
+    managed  map[string][]string
+    mutx     sync.Mutex
+    policies map[string][]string
+    profiles []string
+    roles    []string
+    runId    string
+}
+
+// Creates a tracker for the IAM resources of the run.
+//
+// @Parameters
+// - iamClient:  The IAM client used to delete the resources, it must not use a role
+//               created by the run since it is deleted first
+// - runId:  The unique ID of the run the resource names are suffixed with
+//
+// @Returns
+// - The initialized tracker
+//
+func NewIamRun(iamClient IamApi, runId string) *IamRun {
+    return &IamRun{
+        client:   iamClient,
+        managed:  map[string][]string{},
+        policies: map[string][]string{},
+        runId:    runId,
+    }
+}
+
+// Creates a tracker for the IAM resources of the run with a client from the AWS config.
+//
+// @Parameters
+// - awsConfig:  The AWS credential configuration for connecting to service, it must
+//               not use a role created by the run since it is deleted first
+// - runId:  The unique ID of the run the resource names are suffixed with
+//
+// @Returns
+// - The initialized tracker
+//
+func NewIamRunFromConfig(awsConfig aws.Config, runId string) *IamRun {
+    return NewIamRun(iam.NewFromConfig(awsConfig), runId)
+}
+
+// Formats the name of a role scoped to the run, which is also the name of its
+// instance profile.
+//
+// @Parameters
+// - role:  The base name of the role
+//
+// @Returns
+// - The role name suffixed with the run ID
+//
+func (run *IamRun) RoleName(role string) string {
+    return RolePrefix + role + "-" + run.runId
+}
+
+// Records a created role and its inline policy, along with the instance profile of
+// the same name if one was created.
+//
+// @Parameters
+// - roleName:  The name of the created role
+// - policyName:  The name of the inline policy put on the role
+// - profile:  Whether an instance profile was created for the role
+//
+func (run *IamRun) AddRole(roleName string, policyName string, profile bool) {
+    // If IAM resources are not tracked
+    if run == nil {
+        return
+    }
+
+    run.mutx.Lock()
+    defer run.mutx.Unlock()
+
+    // If the role is not already tracked
+    if !slices.Contains(run.roles, roleName) {
+        run.roles = append(run.roles, roleName)
+    }
+
+    run.policies[roleName] = append(run.policies[roleName], policyName)
+
+    // If the instance profile is not already tracked
+    if profile && !slices.Contains(run.profiles, roleName) {
+        run.profiles = append(run.profiles, roleName)
+    }
+}
+
+// Records a managed policy attached to a created role.
+//
+// @Parameters
+// - roleName:  The name of the role the policy is attached to
+// - policyArn:  The ARN of the managed policy
+//
+func (run *IamRun) AddManagedPolicy(roleName string, policyArn string) {
+    // If IAM resources are not tracked
+    if run == nil {
+        return
+    }
+
+    run.mutx.Lock()
+    defer run.mutx.Unlock()
+
+    run.managed[roleName] = append(run.managed[roleName], policyArn)
+}
+
+// Deletes the tracked instance profiles and roles, removing the roles from their
+// profiles and detaching or deleting their policies first. Resources that no longer
+// exist are skipped, and every resource is attempted even if one fails.
+//
+// @Parameters
+// - callTime:  The length of time each API call is allowed to execute
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (run *IamRun) Teardown(callTime time.Duration) error {
+    // If IAM resources are not tracked
+    if run == nil {
+        return nil
+    }
+
+    run.mutx.Lock()
+    defer run.mutx.Unlock()
+
+    var errs []error
+
+    // Iterate through the instance profiles, removing the role before deleting each
+    for _, profile := range run.profiles {
+        errs = append(errs, run.call("RemoveRoleFromInstanceProfile",
+                                     map[string]any{"instance_profile": profile,
+                                                    "role_name":        profile},
+                                     callTime, func(ctx context.Context) error {
+            _, err := run.client.RemoveRoleFromInstanceProfile(ctx,
+                &iam.RemoveRoleFromInstanceProfileInput{
+                    InstanceProfileName: aws.String(profile),
+                    RoleName:            aws.String(profile),
+                })
+            return err
+        }))
+
+        errs = append(errs, run.call("DeleteInstanceProfile",
+                                     map[string]any{"instance_profile": profile},
+                                     callTime, func(ctx context.Context) error {
+            _, err := run.client.DeleteInstanceProfile(ctx, &iam.DeleteInstanceProfileInput{
+                InstanceProfileName: aws.String(profile),
+            })
+            return err
+        }))
+    }
+
+    // Iterate through the roles, clearing their policies before deleting each
+    for _, roleName := range run.roles {
+        // Iterate through the attached managed policies detaching each
+        for _, policyArn := range run.managed[roleName] {
+            errs = append(errs, run.call("DetachRolePolicy",
+                                         map[string]any{"policy_arn": policyArn,
+                                                        "role_name":  roleName},
+                                         callTime, func(ctx context.Context) error {
+                _, err := run.client.DetachRolePolicy(ctx, &iam.DetachRolePolicyInput{
+                    PolicyArn: aws.String(policyArn),
+                    RoleName:  aws.String(roleName),
+                })
+                return err
+            }))
+        }
+
+        // Iterate through the inline policies deleting each
+        for _, policyName := range run.policies[roleName] {
+            errs = append(errs, run.call("DeleteRolePolicy",
+                                         map[string]any{"policy_name": policyName,
+                                                        "role_name":   roleName},
+                                         callTime, func(ctx context.Context) error {
+                _, err := run.client.DeleteRolePolicy(ctx, &iam.DeleteRolePolicyInput{
+                    PolicyName: aws.String(policyName),
+                    RoleName:   aws.String(roleName),
+                })
+                return err
+            }))
+        }
+
+        errs = append(errs, run.call("DeleteRole", map[string]any{"role_name": roleName},
+                                     callTime, func(ctx context.Context) error {
+            _, err := run.client.DeleteRole(ctx, &iam.DeleteRoleInput{
+                RoleName: aws.String(roleName),
+            })
+            return err
+        }))
+    }
+
+    // Clear the tracked resources so teardown is only performed once
+    run.managed = map[string][]string{}
+    run.policies = map[string][]string{}
+    run.profiles = nil
+    run.roles = nil
+
+    return errors.Join(errs...)
+}
+
+// Executes a single teardown API call, recording it instead in dry-run mode.
+//
+// @Parameters
+// - action:  The name of the API action
+// - params:  The parameters recorded in dry-run mode
+// - callTime:  The length of time the API call is allowed to execute
+// - apiCall:  The function executing the API call
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success or if the resource no longer exists
+//
+func (run *IamRun) call(action string, params map[string]any, callTime time.Duration,
+                        apiCall func(ctx context.Context) error) error {
+    // If dry-run is enabled, record the deletion instead of executing it
+    if DryRun != nil {
+        DryRun.Record("iam", action, params)
+        return nil
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    err := apiCall(ctx)
+    if err != nil {
+        var notFound *iamtypes.NoSuchEntityException
+
+        // If the resource was already deleted
+        if errors.As(err, &notFound) {
+            return nil
+        }
+
+        return fmt.Errorf("%s failed: %w", action, err)
+    }
+
+    recordMutation("iam", action, params)
+    return nil
+}
@@ -0,0 +1,154 @@
+package awsutils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/awsutils"
+	"github.com/ngimb64/Kloud-Kraken/pkg/awsutils/awstest"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestIamRunTeardown(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Route the AWS calls through the plan and disable it when complete
+    awsutils.DryRun = awsutils.NewPlan()
+    defer func() { awsutils.DryRun = nil } ()
+
+    run := awsutils.NewIamRun(nil, "a1b2c3d4")
+    clientRole := run.RoleName("ClientRole")
+    serverRole := run.RoleName("ServerRole")
+    // Ensure the role names are scoped to the run
+    assert.Equal("KloudKraken-ClientRole-a1b2c3d4", clientRole)
+    assert.Equal("KloudKraken-ServerRole-a1b2c3d4", serverRole)
+
+    // Track a client role with a profile and managed policy, and a server role
+    run.AddRole(clientRole, "ClientPermissions", true)
+    run.AddManagedPolicy(clientRole, "arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore")
+    run.AddRole(serverRole, "ServerPermissions", false)
+
+    err := run.Teardown(time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    var actions []string
+    for _, action := range awsutils.DryRun.Actions() {
+        actions = append(actions, action.Action)
+    }
+
+    // Ensure profiles are emptied and roles are cleared of policies before deletion
+    assert.Equal([]string{"RemoveRoleFromInstanceProfile", "DeleteInstanceProfile",
+                          "DetachRolePolicy", "DeleteRolePolicy", "DeleteRole",
+                          "DeleteRolePolicy", "DeleteRole"}, actions)
+
+    // Ensure a second teardown has nothing left to delete
+    err = run.Teardown(time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(7, len(awsutils.DryRun.Actions()))
+
+    // Ensure a nil tracker is safe to use when no resources were created
+    var disabled *awsutils.IamRun
+    disabled.AddRole(clientRole, "ClientPermissions", true)
+    assert.Equal(nil, disabled.Teardown(time.Second))
+}
+
+
+func TestIamRoleCreation(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    fake := awstest.NewIam()
+    run := awsutils.NewIamRun(fake, "a1b2c3d4")
+    clientRole := run.RoleName("ClientRole")
+    policyArn := "arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore"
+
+    // Ensure creating the role twice reuses the existing role
+    for range 2 {
+        arn, err := awsutils.IamRoleCreation(fake, time.Second, clientRole, "{}",
+                                             "ClientPermissions", `{"Version": "2012"}`,
+                                             false)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        assert.Equal("arn:aws:iam::123456789012:role/" + clientRole, arn)
+    }
+    run.AddRole(clientRole, "ClientPermissions", false)
+
+    err := awsutils.SetManagedRolePolicy(fake, time.Second, clientRole, policyArn, true)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    run.AddManagedPolicy(clientRole, policyArn)
+
+    role, ok := fake.Role(clientRole)
+    // Ensure the role has the inline and managed policies
+    assert.Equal(true, ok)
+    assert.Equal(`{"Version": "2012"}`, role.Policies["ClientPermissions"])
+    assert.Equal([]string{policyArn}, role.Managed)
+
+    err = run.Teardown(time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the role was cleared of its policies and deleted
+    _, ok = fake.Role(clientRole)
+    assert.Equal(false, ok)
+
+    // Ensure detaching a policy that is no longer attached is not an error
+    err = awsutils.SetManagedRolePolicy(fake, time.Second, clientRole, policyArn, false)
+    assert.Equal(nil, err)
+}
+
+
+func TestExistingRoles(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    fake := awstest.NewIam()
+    boundaryArn := "arn:aws:iam::123456789012:policy/KrakenBoundary"
+    // Apply the permissions boundary to created roles and clear it when complete
+    awsutils.PermissionsBoundary = boundaryArn
+    defer func() { awsutils.PermissionsBoundary = "" } ()
+
+    roleArn, err := awsutils.IamRoleCreation(fake, time.Second, "KrakenClient", "{}",
+                                             "ClientPermissions",
+                                             `{"Statement": {"Effect": "Allow", ` +
+                                             `"Action": "s3:GetObject"}}`, true)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    role, _ := fake.Role("KrakenClient")
+    // Ensure the created role has the permissions boundary
+    assert.Equal(boundaryArn, role.Boundary)
+    assert.Equal("KrakenClient", awsutils.RoleNameFromArn(
+        "arn:aws:iam::123456789012:role/platform/KrakenClient"))
+
+    // Ensure a policy the role grants passes the simulation
+    err = awsutils.SimulateRolePolicy(fake, roleArn, `{"Statement": [{"Effect": "Allow", ` +
+                                      `"Action": ["s3:GetObject"], "Resource": ` +
+                                      `"arn:aws:s3:::bucket/*"}]}`, time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the actions the role does not grant are reported
+    err = awsutils.SimulateRolePolicy(fake, roleArn, `{"Statement": [{"Effect": "Allow", ` +
+                                      `"Action": ["s3:GetObject", "ssm:GetParameter"]}, ` +
+                                      `{"Effect": "Deny", "Action": "s3:DeleteObject"}]}`,
+                                      time.Second)
+    assert.NotEqual(nil, err)
+    assert.Contains(err.Error(), "ssm:GetParameter on * is implicitDeny")
+    assert.NotContains(err.Error(), "s3:GetObject")
+    assert.NotContains(err.Error(), "s3:DeleteObject")
+    // Ensure a malformed policy is an error
+    assert.NotEqual(nil, awsutils.SimulateRolePolicy(fake, roleArn, "{", time.Second))
+
+    // Ensure the profile holds the role and a profile without it is rejected
+    err = awsutils.VerifyInstanceProfile(fake, "KrakenClient", roleArn, time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.NotEqual(nil, awsutils.VerifyInstanceProfile(fake, "KrakenClient",
+                                                        "arn:aws:iam::123456789012:role/Other",
+                                                        time.Second))
+    assert.NotEqual(nil, awsutils.VerifyInstanceProfile(fake, "Missing", roleArn,
+                                                        time.Second))
+}
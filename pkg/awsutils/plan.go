@@ -0,0 +1,127 @@
+package awsutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Package level variables
+var DryRun *Plan  // Records AWS actions instead of executing them, nil when disabled
+
+
+// PlannedAction is a single AWS action recorded in dry-run mode
+type PlannedAction struct {
+    Service string         `json:"service"`
+    Action  string         `json:"action"`
+    Params  map[string]any `json:"params"`
+}
+
+
+// Plan stores the AWS actions that would be executed in the order they occur
+type Plan struct {
+    actions []PlannedAction
+    mutx    sync.Mutex
+}
+
+// Creates a new empty dry-run plan.
+//
+// @Returns
+// - The initialized plan
+//
+func NewPlan() *Plan {
+    return &Plan{}
+}
+
+// Records an AWS action that would have been executed.
+//
+// @Parameters
+// - service:  The AWS service the action belongs to
+// - action:  The name of the API action
+// - params:  The parameters the action would have been called with
+//
+func (plan *Plan) Record(service string, action string, params map[string]any) {
+    plan.mutx.Lock()
+    defer plan.mutx.Unlock()
+
+    plan.actions = append(plan.actions, PlannedAction{Service: service, Action: action,
+                                                      Params: params})
+}
+
+// Gets a copy of the recorded actions in the order they occurred.
+//
+// @Returns
+// - The recorded actions
+//
+func (plan *Plan) Actions() []PlannedAction {
+    plan.mutx.Lock()
+    defer plan.mutx.Unlock()
+
+    return slices.Clone(plan.actions)
+}
+
+// Formats the recorded actions into a human-readable plan, multi-line parameters such
+// as policies and user data are printed indented below their name.
+//
+// @Returns
+// - The formatted plan
+//
+func (plan *Plan) Format() string {
+    var output strings.Builder
+    actions := plan.Actions()
+
+    output.WriteString(fmt.Sprintf("Dry-run plan - %d AWS actions\n", len(actions)))
+
+    // Iterate through the recorded actions
+    for index, action := range actions {
+        output.WriteString(fmt.Sprintf("\n%2d. [%s] %s\n", index + 1, action.Service,
+                                       action.Action))
+
+        keys := make([]string, 0, len(action.Params))
+        for key := range action.Params {
+            keys = append(keys, key)
+        }
+        slices.Sort(keys)
+
+        // Iterate through the parameters in sorted order
+        for _, key := range keys {
+            value := fmt.Sprint(action.Params[key])
+
+            // If the value is multi-line, indent it below the parameter name
+            if strings.Contains(value, "\n") {
+                output.WriteString(fmt.Sprintf("      %s:\n", key))
+
+                for _, line := range strings.Split(strings.TrimRight(value, "\n"), "\n") {
+                    output.WriteString("          " + line + "\n")
+                }
+
+                continue
+            }
+
+            output.WriteString(fmt.Sprintf("      %s: %s\n", key, value))
+        }
+    }
+
+    return output.String()
+}
+
+// Writes the recorded actions as a JSON array to the passed in path.
+//
+// @Parameters
+// - planPath:  The path where the JSON plan is written
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (plan *Plan) WriteJson(planPath string) error {
+    // Encode the actions into indented JSON
+    planJson, err := json.MarshalIndent(plan.Actions(), "", "  ")
+    if err != nil {
+        return err
+    }
+
+    return os.WriteFile(planPath, append(planJson, '\n'), 0644)
+}
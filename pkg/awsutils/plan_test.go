@@ -0,0 +1,76 @@
+package awsutils_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/ngimb64/Kloud-Kraken/pkg/awsutils"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestDryRunPlan(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Route the AWS calls through the plan and disable it when complete
+    awsutils.DryRun = awsutils.NewPlan()
+    defer func() { awsutils.DryRun = nil } ()
+
+    // Ensure no credentials are needed in dry-run mode
+    awsConfig, _, _, err := awsutils.AwsConfigSetup("us-east-1", time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("us-east-1", awsConfig.Region)
+
+    // Record a role creation with an instance profile
+    _, err = awsutils.IamRoleCreation(nil, time.Second, "ClientRole", "{}",
+                                      "ClientPermissions", "{\n  \"Version\": \"2012\"\n}",
+                                      true)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Record an upload to S3
+    s3Man := awsutils.NewS3ManagerFromConfig(aws.Config{Region: "us-east-1"})
+    key, err := s3Man.PutS3Object("test-bucket", "client", []byte("binary"), time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("client-1", key)
+
+    actions := awsutils.DryRun.Actions()
+    // Ensure every call was recorded in order
+    assert.Equal(6, len(actions))
+    assert.Equal("LoadCredentials", actions[0].Action)
+    assert.Equal("CreateRole", actions[1].Action)
+    assert.Equal("AddRoleToInstanceProfile", actions[4].Action)
+    assert.Equal("s3", actions[5].Service)
+    assert.Equal(6, actions[5].Params["bytes"])
+
+    plan := awsutils.DryRun.Format()
+    // Ensure the plan lists the actions with multi-line params indented
+    assert.Contains(plan, "Dry-run plan - 6 AWS actions")
+    assert.Contains(plan, " 6. [s3] PutObject")
+    assert.Contains(plan, "      policy_document:\n          {\n")
+
+    planPath := filepath.Join(t.TempDir(), "plan.json")
+    // Write the plan as JSON
+    err = awsutils.DryRun.WriteJson(planPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    planJson, err := os.ReadFile(planPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    var parsed []awsutils.PlannedAction
+    // Ensure the JSON plan parses back into the actions
+    err = json.Unmarshal(planJson, &parsed)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(6, len(parsed))
+    assert.Equal("PutRolePolicy", parsed[2].Action)
+}
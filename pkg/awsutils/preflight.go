@@ -0,0 +1,168 @@
+package awsutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// Package level variables
+var Ec2Architectures = map[string]ec2types.ArchitectureValues{  // EC2 names of the architectures
+    ArchAmd64: ec2types.ArchitectureValuesX8664,
+    ArchArm64: ec2types.ArchitectureValuesArm64,
+}
+
+
+// Runs the pre-flight checks of the fleet before anything is launched, so launches
+// that would fail on account limits fail in seconds instead of minutes into the run.
+// Checks the instance type is offered, its vCPU quota fits the instances, and the AMI
+// exists in the region for the architecture of the instance type. If the vCPU quota can
+// not be retrieved, the legacy max-instances account attribute is checked instead.
+//
+// @Parameters
+// - awsConfig:  The AWS config with the region the instances launch in
+// - instanceType:  The EC2 instance type
+// - count:  The number of instances to be launched
+// - ami:  The AMI ID the instances are launched with
+// - callTime:  The length of time each API call is allowed to execute
+//
+// @Returns
+// - The results of the passed checks
+// - Error with every failed check if any failed, otherwise nil
+//
+func Preflight(awsConfig aws.Config, instanceType string, count int, ami string,
+               callTime time.Duration) ([]string, error) {
+    var failures []error
+    var results []string
+
+    // If dry-run is enabled, record the checks instead of executing them
+    if DryRun != nil {
+        DryRun.Record("servicequotas", "GetServiceQuota", map[string]any{
+            "quota_code":   VcpuQuotaCode(instanceType),
+            "service_code": QuotasServiceEc2,
+        })
+        DryRun.Record("ec2", "DescribeImages", map[string]any{"image_id": ami})
+        return nil, nil
+    }
+
+    required, available, err := CheckVcpuQuota(awsConfig, instanceType, count, callTime)
+    switch {
+    case err == nil:
+        results = append(results, fmt.Sprintf("%d of %d available vCPUs used by %d %s " +
+                                              "instances", required, available, count,
+                                              instanceType))
+    // If the quota could not be retrieved, fall back to the account instance limit
+    case errors.Is(err, ErrQuotaUnavailable):
+        limit, limitErr := MaxInstances(awsConfig, callTime)
+        if limitErr != nil {
+            failures = append(failures, fmt.Errorf("vCPU quota could not be checked, " +
+                                                   "allow servicequotas:GetServiceQuota " +
+                                                   "- %w", err))
+        } else if limit > 0 && count > limit {
+            failures = append(failures, fmt.Errorf("%d instances exceed the max-instances " +
+                                                   "account limit of %d, lower " +
+                                                   "number_instances", count, limit))
+        } else {
+            results = append(results, fmt.Sprintf("vCPU quota unavailable, %d instances " +
+                                                  "within the max-instances account limit",
+                                                  count))
+        }
+    default:
+        failures = append(failures, err)
+    }
+
+    err = CheckAmi(awsConfig, ami, InstanceArchitecture(instanceType), callTime)
+    if err != nil {
+        failures = append(failures, err)
+    } else {
+        results = append(results, "AMI " + ami + " available in " + awsConfig.Region)
+    }
+
+    return results, errors.Join(failures...)
+}
+
+
+// Checks the AMI exists and is available in the region of the AWS config for the
+// architecture of the instances, since AMI IDs are region specific.
+//
+// @Parameters
+// - awsConfig:  The AWS config with the region the instances launch in
+// - ami:  The AMI ID the instances are launched with
+// - arch:  The architecture of the instances (amd64 or arm64)
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - Error if the AMI is unusable or it could not be checked, otherwise nil
+//
+func CheckAmi(awsConfig aws.Config, ami string, arch string, callTime time.Duration) error {
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    output, err := ec2.NewFromConfig(awsConfig).DescribeImages(ctx, &ec2.DescribeImagesInput{
+        ImageIds: []string{ami},
+    })
+    // If the AMI is not found in the region, the describe call fails
+    if err != nil || len(output.Images) == 0 {
+        return fmt.Errorf("AMI %s was not found in %s, AMI IDs are region specific so " +
+                          "copy it to the region or clear ami to resolve it from " +
+                          "ami_ssm_parameter - %v", ami, awsConfig.Region, err)
+    }
+
+    image := output.Images[0]
+    // If the AMI is still pending or failed
+    if image.State != ec2types.ImageStateAvailable {
+        return fmt.Errorf("AMI %s is %s in %s, wait for it to become available", ami,
+                          image.State, awsConfig.Region)
+    }
+
+    // If the AMI is built for another architecture than the instance type
+    if image.Architecture != Ec2Architectures[arch] {
+        return fmt.Errorf("AMI %s is %s but the instance type runs %s, select an AMI of " +
+                          "the instance architecture", ami, image.Architecture,
+                          Ec2Architectures[arch])
+    }
+
+    return nil
+}
+
+
+// Gets the legacy max-instances account attribute, the number of On-Demand instances the
+// account is limited to in the region.
+//
+// @Parameters
+// - awsConfig:  The AWS config with the region of the limit
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - The max number of instances, 0 if the account has no such limit
+// - Error if it occurs, otherwise nil on success
+//
+func MaxInstances(awsConfig aws.Config, callTime time.Duration) (int, error) {
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    output, err := ec2.NewFromConfig(awsConfig).DescribeAccountAttributes(ctx,
+        &ec2.DescribeAccountAttributesInput{
+            AttributeNames: []ec2types.AccountAttributeName{"max-instances"},
+        })
+    if err != nil {
+        return 0, fmt.Errorf("error describing account attributes - %w", err)
+    }
+
+    // Iterate through the attributes parsing the max instances
+    for _, attribute := range output.AccountAttributes {
+        if len(attribute.AttributeValues) > 0 {
+            return strconv.Atoi(aws.ToString(attribute.AttributeValues[0].AttributeValue))
+        }
+    }
+
+    return 0, nil
+}
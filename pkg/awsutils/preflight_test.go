@@ -0,0 +1,33 @@
+package awsutils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/ngimb64/Kloud-Kraken/pkg/awsutils"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestPreflightDryRun(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Route the AWS calls through the plan and disable it when complete
+    awsutils.DryRun = awsutils.NewPlan()
+    defer func() { awsutils.DryRun = nil } ()
+
+    results, err := awsutils.Preflight(aws.Config{Region: "us-east-1"}, "p4d.24xlarge", 2,
+                                       "ami-0123456789abcdef0", time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(0, len(results))
+
+    actions := awsutils.DryRun.Actions()
+    // Ensure the quota and AMI checks were recorded instead of executed
+    assert.Equal(2, len(actions))
+    assert.Equal("GetServiceQuota", actions[0].Action)
+    assert.Equal("L-417A185B", actions[0].Params["quota_code"])
+    assert.Equal("DescribeImages", actions[1].Action)
+}
@@ -0,0 +1,284 @@
+package awsutils
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/ngimb64/Kloud-Kraken/pkg/partition"
+)
+
+// Service Quotas API target and the service code of EC2 quotas
+const quotasTarget = "ServiceQuotasV20190624.GetServiceQuota"
+const QuotasServiceEc2 = "ec2"
+// Code of the Running On-Demand Standard (A, C, D, H, I, M, R, T, Z) instances quota
+const StandardQuotaCode = "L-1216C47A"
+
+// Package level variables
+var ErrQuotaUnavailable = errors.New("quota unavailable")  // The quota could not be retrieved
+var ReInstanceFamily = regexp.MustCompile(`^[a-z]+`)  // Family letters of the instance type
+var VcpuQuotaCodes = map[string]string{  // Running On-Demand vCPU quota code of the families
+    "dl":  "L-6E869C2A",
+    "f":   "L-74FC7D96",
+    "g":   "L-DB2E81BA",
+    "inf": "L-1945791B",
+    "p":   "L-417A185B",
+    "trn": "L-2C3B7624",
+    "vt":  "L-DB2E81BA",
+    "x":   "L-7295265B",
+}
+
+
+// Gets the code of the Running On-Demand vCPU quota the instance type counts against.
+//
+// @Parameters
+// - instanceType:  The EC2 instance type
+//
+// @Returns
+// - The Service Quotas code of the vCPU quota
+//
+func VcpuQuotaCode(instanceType string) string {
+    family := ReInstanceFamily.FindString(instanceType)
+
+    // If the family has a dedicated quota
+    if code, ok := VcpuQuotaCodes[family]; ok {
+        return code
+    }
+
+    return StandardQuotaCode
+}
+
+
+// Gets the number of vCPUs of the instance type, which is also verified to be offered.
+//
+// @Parameters
+// - awsConfig:  The AWS config with the region the instances launch in
+// - instanceType:  The EC2 instance type
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - The default number of vCPUs of the instance type
+// - Error if it occurs, otherwise nil on success
+//
+func InstanceVcpus(awsConfig aws.Config, instanceType string, callTime time.Duration) (
+                   int32, error) {
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    output, err := ec2.NewFromConfig(awsConfig).DescribeInstanceTypes(ctx,
+        &ec2.DescribeInstanceTypesInput{
+            InstanceTypes: []ec2types.InstanceType{ec2types.InstanceType(instanceType)},
+        })
+    if err != nil {
+        return 0, fmt.Errorf("error describing instance type %s - %w", instanceType, err)
+    }
+
+    // If the instance type is not offered in the region
+    if len(output.InstanceTypes) == 0 || output.InstanceTypes[0].VCpuInfo == nil {
+        return 0, fmt.Errorf("instance type %s is not offered in %s", instanceType,
+                             awsConfig.Region)
+    }
+
+    return aws.ToInt32(output.InstanceTypes[0].VCpuInfo.DefaultVCpus), nil
+}
+
+
+// Gets the applied value of a quota through the Service Quotas API, signing the request
+// with the credentials of the AWS config.
+//
+// @Parameters
+// - awsConfig:  The AWS config with the credentials and region of the quota
+// - serviceCode:  The code of the service the quota belongs to
+// - quotaCode:  The code of the quota
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - The value of the quota
+// - Error if it occurs, otherwise nil on success
+//
+func GetServiceQuota(awsConfig aws.Config, serviceCode string, quotaCode string,
+                     callTime time.Duration) (float64, error) {
+    var reply struct {
+        Quota struct {
+            Value float64 `json:"Value"`
+        } `json:"Quota"`
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    payload, err := json.Marshal(map[string]string{
+        "QuotaCode":   quotaCode,
+        "ServiceCode": serviceCode,
+    })
+    if err != nil {
+        return 0, err
+    }
+
+    endpoint := "https://" + partition.EndpointHost("servicequotas", awsConfig.Region) + "/"
+    request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint,
+                                               bytes.NewReader(payload))
+    if err != nil {
+        return 0, err
+    }
+    request.Header.Set("Content-Type", "application/x-amz-json-1.1")
+    request.Header.Set("X-Amz-Target", quotasTarget)
+
+    credentials, err := awsConfig.Credentials.Retrieve(ctx)
+    if err != nil {
+        return 0, fmt.Errorf("error retrieving AWS credentials - %w", err)
+    }
+
+    payloadHash := sha256.Sum256(payload)
+    // Sign the request as the SDK would for the servicequotas service
+    err = v4.NewSigner().SignHTTP(ctx, credentials, request,
+                                  hex.EncodeToString(payloadHash[:]), "servicequotas",
+                                  awsConfig.Region, time.Now())
+    if err != nil {
+        return 0, fmt.Errorf("error signing quota request - %w", err)
+    }
+
+    response, err := http.DefaultClient.Do(request)
+    if err != nil {
+        return 0, fmt.Errorf("error requesting quota %s - %w - %w", quotaCode,
+                             ErrQuotaUnavailable, err)
+    }
+    // Close the response body on local exit
+    defer response.Body.Close()
+
+    body, err := io.ReadAll(response.Body)
+    if err != nil {
+        return 0, fmt.Errorf("%w - %w", ErrQuotaUnavailable, err)
+    }
+
+    // If the request was rejected, such as by missing servicequotas:GetServiceQuota
+    if response.StatusCode != http.StatusOK {
+        return 0, fmt.Errorf("quota %s request failed with status %d - %w - %s", quotaCode,
+                             response.StatusCode, ErrQuotaUnavailable, bytes.TrimSpace(body))
+    }
+
+    err = json.Unmarshal(body, &reply)
+    if err != nil {
+        return 0, fmt.Errorf("error parsing quota %s - %w - %w", quotaCode,
+                             ErrQuotaUnavailable, err)
+    }
+
+    return reply.Quota.Value, nil
+}
+
+
+// Gets the vCPUs used by the pending and running instances counting against the quota,
+// which are unavailable to the instances about to be launched.
+//
+// @Parameters
+// - awsConfig:  The AWS config with the region of the instances
+// - quotaCode:  The code of the vCPU quota
+// - callTime:  The length of time the API calls are allowed to execute
+//
+// @Returns
+// - The vCPUs in use under the quota
+// - Error if it occurs, otherwise nil on success
+//
+func RunningVcpus(awsConfig aws.Config, quotaCode string, callTime time.Duration) (int,
+                                                                                   error) {
+    var used int32
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    paginator := ec2.NewDescribeInstancesPaginator(ec2.NewFromConfig(awsConfig),
+        &ec2.DescribeInstancesInput{
+            Filters: []ec2types.Filter{{
+                Name:   aws.String("instance-state-name"),
+                Values: []string{"pending", "running"},
+            }},
+        })
+
+    // Iterate through the pages of instances summing the vCPUs under the quota
+    for paginator.HasMorePages() {
+        page, err := paginator.NextPage(ctx)
+        if err != nil {
+            return 0, fmt.Errorf("error describing running instances - %w", err)
+        }
+
+        for _, reservation := range page.Reservations {
+            for _, instance := range reservation.Instances {
+                // If the instance counts against another quota or has no CPU options
+                if VcpuQuotaCode(string(instance.InstanceType)) != quotaCode ||
+                   instance.CpuOptions == nil {
+                    continue
+                }
+
+                used += aws.ToInt32(instance.CpuOptions.CoreCount) *
+                        aws.ToInt32(instance.CpuOptions.ThreadsPerCore)
+            }
+        }
+    }
+
+    return int(used), nil
+}
+
+
+// Checks the vCPUs of the requested instances fit in the Running On-Demand vCPU quota
+// of the instance type in the region of the AWS config, after the vCPUs of the
+// instances already running under it.
+//
+// @Parameters
+// - awsConfig:  The AWS config with the region the instances launch in
+// - instanceType:  The EC2 instance type
+// - count:  The number of instances to be launched
+// - callTime:  The length of time each API call is allowed to execute
+//
+// @Returns
+// - The vCPUs required by the instances
+// - The vCPUs available under the quota
+// - Error if the quota is exceeded or it could not be checked, otherwise nil
+//
+func CheckVcpuQuota(awsConfig aws.Config, instanceType string, count int,
+                    callTime time.Duration) (int, int, error) {
+    vcpus, err := InstanceVcpus(awsConfig, instanceType, callTime)
+    if err != nil {
+        return 0, 0, err
+    }
+
+    quotaCode := VcpuQuotaCode(instanceType)
+    quota, err := GetServiceQuota(awsConfig, QuotasServiceEc2, quotaCode, callTime)
+    if err != nil {
+        return 0, 0, err
+    }
+
+    used, err := RunningVcpus(awsConfig, quotaCode, callTime)
+    if err != nil {
+        return 0, 0, err
+    }
+
+    required := int(vcpus) * count
+    available := int(quota) - used
+    // If the instances need more vCPUs than the quota has available
+    if required > available {
+        return required, available, fmt.Errorf("%d %s instances need %d vCPUs but the " +
+                                               "quota %s in %s allows %d with %d in use, " +
+                                               "request an increase in the Service " +
+                                               "Quotas console, stop running instances, " +
+                                               "or lower number_instances", count,
+                                               instanceType, required, quotaCode,
+                                               awsConfig.Region, int(quota), used)
+    }
+
+    return required, available, nil
+}
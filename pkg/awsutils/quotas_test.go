@@ -0,0 +1,22 @@
+package awsutils_test
+
+import (
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/awsutils"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestVcpuQuotaCode(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Ensure GPU families count against their dedicated quotas
+    assert.Equal("L-DB2E81BA", awsutils.VcpuQuotaCode("g4dn.xlarge"))
+    assert.Equal("L-DB2E81BA", awsutils.VcpuQuotaCode("g6gd.2xlarge"))
+    assert.Equal("L-417A185B", awsutils.VcpuQuotaCode("p4d.24xlarge"))
+    assert.Equal("L-417A185B", awsutils.VcpuQuotaCode("p6-b200.48xlarge"))
+    // Ensure other families count against the standard quota
+    assert.Equal(awsutils.StandardQuotaCode, awsutils.VcpuQuotaCode("c7gd.large"))
+}
@@ -0,0 +1,222 @@
+package awsutils
+
+import (
+	"maps"
+	"net/url"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// Tag put on the instances of a run so concurrent runs from one account can be told apart
+const RunTagKey = "RunId"
+// Tag put on the resources of a named run so the engagement it belongs to is known
+const RunNameTagKey = "RunName"
+// Prefix of the SSM parameter paths, S3 keys, and log groups scoped to a run
+const RunPathPrefix = "kloud-kraken"
+
+// Package level variables
+var RunTags map[string]string  // Tags put on every resource created for the run, nil if none
+
+
+// Sets the tags put on every resource created for the run from the custom tags and the
+// name of the run.
+//
+// @Parameters
+// - runName:  The name of the run, empty if unnamed
+// - tags:  The custom tags of the run
+//
+func SetRunTags(runName string, tags map[string]string) {
+    RunTags = maps.Clone(tags)
+
+    // If the run is named, tag the resources with the name
+    if runName != "" {
+        if RunTags == nil {
+            RunTags = make(map[string]string)
+        }
+
+        RunTags[RunNameTagKey] = runName
+    }
+}
+
+
+// Gets the keys of the run tags in sorted order, so the tags are applied the same way
+// on every call.
+//
+// @Returns
+// - The sorted tag keys
+//
+func runTagKeys() []string {
+    return slices.Sorted(maps.Keys(RunTags))
+}
+
+
+// Creates the run tags put on EC2 resources.
+//
+// @Returns
+// - The EC2 run tags
+//
+func ec2RunTags() []ec2types.Tag {
+    var tags []ec2types.Tag
+    for _, key := range runTagKeys() {
+        tags = append(tags, ec2types.Tag{Key: aws.String(key), Value: aws.String(RunTags[key])})
+    }
+
+    return tags
+}
+
+
+// Creates the run tags put on IAM resources.
+//
+// @Returns
+// - The IAM run tags
+//
+func iamRunTags() []iamtypes.Tag {
+    var tags []iamtypes.Tag
+    for _, key := range runTagKeys() {
+        tags = append(tags, iamtypes.Tag{Key: aws.String(key), Value: aws.String(RunTags[key])})
+    }
+
+    return tags
+}
+
+
+// Creates the run tags put on SSM parameters.
+//
+// @Returns
+// - The SSM run tags
+//
+func ssmRunTags() []ssmtypes.Tag {
+    var tags []ssmtypes.Tag
+    for _, key := range runTagKeys() {
+        tags = append(tags, ssmtypes.Tag{Key: aws.String(key), Value: aws.String(RunTags[key])})
+    }
+
+    return tags
+}
+
+
+// Formats the service tag and run tags as the URL encoded tagging of an S3 object.
+//
+// @Returns
+// - The S3 object tagging
+//
+func s3Tagging() string {
+    values := url.Values{ServiceTagKey: {ServiceTagValue}}
+    for key, value := range RunTags {
+        values.Set(key, value)
+    }
+
+    return values.Encode()
+}
+
+
+// Formats the tags of the run as key=value pairs for the dry-run plan.
+//
+// @Returns
+// - The tags in CSV format
+//
+func formatRunTags() string {
+    var formatted string
+    for _, key := range runTagKeys() {
+        formatted += "," + key + "=" + RunTags[key]
+    }
+
+    return formatted
+}
+
+
+// Formats the SSM parameter path the server TLS certificate of the run is stored at.
+//
+// @Parameters
+// - runId:  The unique ID of the run
+//
+// @Returns
+// - The parameter path scoped to the run
+//
+func CertParameter(runId string) string {
+    return ParameterPrefix(runId) + "tls/cert"
+}
+
+
+// Formats the SSM parameter path the hashcat brain password of the run is stored at.
+//
+// @Parameters
+// - runId:  The unique ID of the run
+//
+// @Returns
+// - The parameter path scoped to the run
+//
+func BrainParameter(runId string) string {
+    return ParameterPrefix(runId) + "brain/password"
+}
+
+
+// Formats the SSM parameter path the connection token of the run is stored at.
+//
+// @Parameters
+// - runId:  The unique ID of the run
+//
+// @Returns
+// - The parameter path scoped to the run
+//
+func TokenParameter(runId string) string {
+    return ParameterPrefix(runId) + "auth/token"
+}
+
+
+// Formats the SSM parameter path prefix every parameter of the run is stored under,
+// which the IAM policies of the run are scoped to.
+//
+// @Parameters
+// - runId:  The unique ID of the run
+//
+// @Returns
+// - The parameter path prefix scoped to the run
+//
+func ParameterPrefix(runId string) string {
+    return "/" + RunPathPrefix + "/" + runId + "/"
+}
+
+
+// Formats the S3 key the client binary of the run is uploaded to.
+//
+// @Parameters
+// - runId:  The unique ID of the run
+//
+// @Returns
+// - The object key scoped to the run
+//
+func ClientBinaryKey(runId string) string {
+    return RunPathPrefix + "/" + runId + "/client"
+}
+
+
+// Formats the S3 key the custom hashcat build of the run is uploaded to.
+//
+// @Parameters
+// - runId:  The unique ID of the run
+//
+// @Returns
+// - The object key scoped to the run
+//
+func HashcatArtifactKey(runId string) string {
+    return RunPathPrefix + "/" + runId + "/hashcat"
+}
+
+
+// Formats the CloudWatch log group of the run, each client logs to a stream named
+// after its instance ID within it.
+//
+// @Parameters
+// - runId:  The unique ID of the run
+//
+// @Returns
+// - The log group name scoped to the run
+//
+func LogGroup(runId string) string {
+    return "/" + RunPathPrefix + "/" + runId
+}
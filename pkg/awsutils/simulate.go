@@ -0,0 +1,235 @@
+package awsutils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+
+// policyValues is a policy element that is either a single string or a list of strings
+type policyValues []string
+
+// policyStatement is the part of a policy statement the simulation is built from
+type policyStatement struct {
+    Action   policyValues `json:"Action"`
+    Effect   string       `json:"Effect"`
+    Resource policyValues `json:"Resource"`
+}
+
+// Parses the policy element from a single string or a list of strings.
+//
+// @Parameters
+// - data:  The JSON of the policy element
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (values *policyValues) UnmarshalJSON(data []byte) error {
+    var value string
+    // If the element is a single string
+    if json.Unmarshal(data, &value) == nil {
+        *values = policyValues{value}
+        return nil
+    }
+
+    return json.Unmarshal(data, (*[]string)(values))
+}
+
+
+// Parses the allow statements out of the JSON policy document, the statement element
+// may be a single statement or a list of them.
+//
+// @Parameters
+// - policyJson:  The JSON permissions policy
+//
+// @Returns
+// - The allow statements with their resources defaulting to every resource
+// - Error if it occurs, otherwise nil on success
+//
+func allowStatements(policyJson string) ([]policyStatement, error) {
+    var document struct {
+        Statement json.RawMessage `json:"Statement"`
+    }
+
+    err := json.Unmarshal([]byte(policyJson), &document)
+    if err != nil {
+        return nil, fmt.Errorf("error parsing policy document - %w", err)
+    }
+
+    var statements []policyStatement
+    // If the statement element is not a list, parse it as a single statement
+    if json.Unmarshal(document.Statement, &statements) != nil {
+        var statement policyStatement
+
+        err = json.Unmarshal(document.Statement, &statement)
+        if err != nil {
+            return nil, fmt.Errorf("error parsing policy statements - %w", err)
+        }
+
+        statements = []policyStatement{statement}
+    }
+
+    var allowed []policyStatement
+    // Iterate through the statements keeping the ones granting actions
+    for _, statement := range statements {
+        if statement.Effect != "Allow" || len(statement.Action) == 0 {
+            continue
+        }
+
+        // If the statement has no resource, simulate it against every resource
+        if len(statement.Resource) == 0 {
+            statement.Resource = policyValues{"*"}
+        }
+
+        allowed = append(allowed, statement)
+    }
+
+    return allowed, nil
+}
+
+
+// Gets the name of the role from its ARN, dropping any path the role was created under.
+//
+// @Parameters
+// - roleArn:  The ARN of the IAM role
+//
+// @Returns
+// - The name of the role
+//
+func RoleNameFromArn(roleArn string) string {
+    return roleArn[strings.LastIndex(roleArn, "/") + 1:]
+}
+
+
+// Simulates the actions the JSON policy allows against the policies of an existing role,
+// so a role created outside Kloud-Kraken is known to grant what the run needs before it
+// is used. The simulation includes the permissions boundary of the role and the
+// organization policies of the account. Results denied only for lack of the context keys
+// of a condition are not reported, since the simulation has no request to take them from.
+//
+// @Parameters
+// - iamClient:  The client to the IAM service
+// - roleArn:  The ARN of the role to simulate
+// - policyJson:  The JSON permissions policy the role needs to grant
+// - callTime:  The length of time each API call is allowed to execute
+//
+// @Returns
+// - Error with every denied action if any were denied, otherwise nil
+//
+func SimulateRolePolicy(iamClient IamApi, roleArn string, policyJson string,
+                        callTime time.Duration) error {
+    statements, err := allowStatements(policyJson)
+    if err != nil {
+        return err
+    }
+
+    // If dry-run is enabled, record the simulation instead of executing it
+    if DryRun != nil {
+        var actions []string
+        for _, statement := range statements {
+            actions = append(actions, statement.Action...)
+        }
+
+        DryRun.Record("iam", "SimulatePrincipalPolicy", map[string]any{
+            "actions":  actions,
+            "role_arn": roleArn,
+        })
+        return nil
+    }
+
+    var denied []error
+
+    // Iterate through the statements simulating the actions on their resources
+    for _, statement := range statements {
+        paginator := iam.NewSimulatePrincipalPolicyPaginator(iamClient,
+            &iam.SimulatePrincipalPolicyInput{
+                ActionNames:     statement.Action,
+                PolicySourceArn: aws.String(roleArn),
+                ResourceArns:    statement.Resource,
+            })
+
+        // Iterate through the pages of evaluation results
+        for paginator.HasMorePages() {
+            ctx, cancel := context.WithTimeout(context.Background(), callTime)
+            page, err := paginator.NextPage(ctx)
+            cancel()
+            if err != nil {
+                return fmt.Errorf("SimulatePrincipalPolicy failed: %w", err)
+            }
+
+            for _, result := range page.EvaluationResults {
+                // If the action is allowed or only denied for missing condition context
+                if result.EvalDecision == iamtypes.PolicyEvaluationDecisionTypeAllowed ||
+                len(result.MissingContextValues) > 0 {
+                    continue
+                }
+
+                denied = append(denied, fmt.Errorf("%s on %s is %s",
+                                                   aws.ToString(result.EvalActionName),
+                                                   aws.ToString(result.EvalResourceName),
+                                                   result.EvalDecision))
+            }
+        }
+    }
+
+    // If any of the actions were denied
+    if len(denied) > 0 {
+        return fmt.Errorf("role %s is missing permissions:\n%w", roleArn,
+                          errors.Join(denied...))
+    }
+
+    return nil
+}
+
+
+// Ensures the existing instance profile holds the role, since the instances launched
+// with the profile receive the credentials of the role it holds.
+//
+// @Parameters
+// - iamClient:  The client to the IAM service
+// - profileName:  The name of the instance profile
+// - roleArn:  The ARN of the role the profile should hold
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - Error if it occurs or the profile does not hold the role, otherwise nil on success
+//
+func VerifyInstanceProfile(iamClient IamApi, profileName string, roleArn string,
+                           callTime time.Duration) error {
+    // If dry-run is enabled, record the lookup instead of executing it
+    if DryRun != nil {
+        DryRun.Record("iam", "GetInstanceProfile", map[string]any{
+            "instance_profile": profileName,
+        })
+        return nil
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    profileOut, err := iamClient.GetInstanceProfile(ctx, &iam.GetInstanceProfileInput{
+        InstanceProfileName: aws.String(profileName),
+    })
+    if err != nil {
+        return fmt.Errorf("GetInstanceProfile failed: %w", err)
+    }
+
+    // If the profile holds the role
+    if slices.ContainsFunc(profileOut.InstanceProfile.Roles, func(role iamtypes.Role) bool {
+        return aws.ToString(role.Arn) == roleArn
+    }) {
+        return nil
+    }
+
+    return fmt.Errorf("instance profile %s does not hold role %s", profileName, roleArn)
+}
@@ -0,0 +1,764 @@
+package awsutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// Tag put on the resources of a run so orphans can be found after a crash
+const ServiceTagKey = "Service"
+const ServiceTagValue = "Kloud-Kraken"
+// Prefix of the role and instance profile names of runs
+const RolePrefix = "KloudKraken-"
+
+// Kinds of orphaned resources, listed in the order they are deleted since instances
+// hold security groups and instance profiles hold roles
+const (
+    KindInstance        = "ec2-instance"
+    KindS3Object        = "s3-object"
+    KindSsmParameter    = "ssm-parameter"
+    KindInstanceProfile = "iam-instance-profile"
+    KindRole            = "iam-role"
+    KindSecurityGroup   = "security-group"
+    KindVpc             = "vpc"
+)
+
+// Package level variables
+var KindOrder = []string{KindInstance, KindS3Object, KindSsmParameter, KindInstanceProfile,
+                         KindRole, KindSecurityGroup, KindVpc}
+
+
+// Orphan is a tagged resource left behind by a run
+type Orphan struct {
+    Created time.Time  // Zero if the resource has no creation time
+    Id      string     // Instance or group ID, parameter or role name, or bucket/key
+    Kind    string
+    Region  string     // Empty for global IAM resources
+    RunId   string     // Empty if the run of the resource is unknown
+    State   string     // State of an instance, empty for other kinds
+}
+
+
+// Discovers the resources tagged with the service tag in the region of the config. The
+// objects of the bucket and the global IAM resources are only discovered when requested,
+// so they are not listed once per region.
+//
+// @Parameters
+// - awsConfig:  The AWS config of the region to search
+// - bucketName:  The bucket searched for tagged objects, empty to skip
+// - includeIam:  Whether to search for tagged IAM roles and instance profiles
+// - callTime:  The length of time the discovery is allowed to execute
+//
+// @Returns
+// - The discovered orphans
+// - Error if it occurs, otherwise nil on success
+//
+func DiscoverOrphans(awsConfig aws.Config, bucketName string, includeIam bool,
+                     callTime time.Duration) ([]Orphan, error) {
+    var orphans []Orphan
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    region := awsConfig.Region
+    ec2Client := ec2.NewFromConfig(awsConfig)
+    tagFilter := ec2types.Filter{Name: aws.String("tag:" + ServiceTagKey),
+                                 Values: []string{ServiceTagValue}}
+
+    // Find the tagged instances that have not been terminated
+    instancePages := ec2.NewDescribeInstancesPaginator(ec2Client, &ec2.DescribeInstancesInput{
+        Filters: []ec2types.Filter{tagFilter, {
+            Name:   aws.String("instance-state-name"),
+            Values: []string{"pending", "running", "stopping", "stopped"},
+        }},
+    })
+    for instancePages.HasMorePages() {
+        page, err := instancePages.NextPage(ctx)
+        if err != nil {
+            return nil, fmt.Errorf("DescribeInstances failed: %w", err)
+        }
+
+        for _, reservation := range page.Reservations {
+            for _, instance := range reservation.Instances {
+                orphan := Orphan{Created: aws.ToTime(instance.LaunchTime),
+                                 Id: aws.ToString(instance.InstanceId), Kind: KindInstance,
+                                 Region: region, RunId: ec2TagValue(instance.Tags, RunTagKey)}
+                // If the state of the instance is known
+                if instance.State != nil {
+                    orphan.State = string(instance.State.Name)
+                }
+
+                orphans = append(orphans, orphan)
+            }
+        }
+    }
+
+    // Find the tagged security groups
+    groupPages := ec2.NewDescribeSecurityGroupsPaginator(ec2Client,
+        &ec2.DescribeSecurityGroupsInput{Filters: []ec2types.Filter{tagFilter}})
+    for groupPages.HasMorePages() {
+        page, err := groupPages.NextPage(ctx)
+        if err != nil {
+            return nil, fmt.Errorf("DescribeSecurityGroups failed: %w", err)
+        }
+
+        for _, group := range page.SecurityGroups {
+            orphans = append(orphans, Orphan{Id: aws.ToString(group.GroupId),
+                                             Kind: KindSecurityGroup, Region: region,
+                                             RunId: ec2TagValue(group.Tags, RunTagKey)})
+        }
+    }
+
+    // Find the tagged VPCs
+    vpcPages := ec2.NewDescribeVpcsPaginator(ec2Client,
+        &ec2.DescribeVpcsInput{Filters: []ec2types.Filter{tagFilter}})
+    for vpcPages.HasMorePages() {
+        page, err := vpcPages.NextPage(ctx)
+        if err != nil {
+            return nil, fmt.Errorf("DescribeVpcs failed: %w", err)
+        }
+
+        for _, vpc := range page.Vpcs {
+            orphans = append(orphans, Orphan{Id: aws.ToString(vpc.VpcId), Kind: KindVpc,
+                                             Region: region,
+                                             RunId: ec2TagValue(vpc.Tags, RunTagKey)})
+        }
+    }
+
+    // Find the tagged SSM parameters, such as the TLS certificate of a run
+    paramPages := ssm.NewDescribeParametersPaginator(ssm.NewFromConfig(awsConfig),
+        &ssm.DescribeParametersInput{
+            ParameterFilters: []ssmtypes.ParameterStringFilter{{
+                Key:    aws.String("tag:" + ServiceTagKey),
+                Values: []string{ServiceTagValue},
+            }},
+        })
+    for paramPages.HasMorePages() {
+        page, err := paramPages.NextPage(ctx)
+        if err != nil {
+            return nil, fmt.Errorf("DescribeParameters failed: %w", err)
+        }
+
+        for _, param := range page.Parameters {
+            name := aws.ToString(param.Name)
+            orphans = append(orphans, Orphan{Created: aws.ToTime(param.LastModifiedDate),
+                                             Id: name, Kind: KindSsmParameter,
+                                             Region: region, RunId: pathRunId(name)})
+        }
+    }
+
+    // If there is a bucket to search for tagged objects, such as client binaries
+    if bucketName != "" {
+        objects, err := discoverS3Objects(ctx, s3.NewFromConfig(awsConfig), bucketName,
+                                          region)
+        if err != nil {
+            return nil, err
+        }
+
+        orphans = append(orphans, objects...)
+    }
+
+    // If the global IAM resources are to be searched
+    if includeIam {
+        iamOrphans, err := discoverIam(ctx, iam.NewFromConfig(awsConfig))
+        if err != nil {
+            return nil, err
+        }
+
+        orphans = append(orphans, iamOrphans...)
+    }
+
+    return orphans, nil
+}
+
+
+// Discovers the tagged IAM roles and instance profiles of runs, only those with the
+// role prefix are checked for tags to limit the API calls.
+//
+// @Parameters
+// - ctx:  The context the API calls are bound to
+// - iamClient:  The IAM client used to list the resources
+//
+// @Returns
+// - The discovered orphans
+// - Error if it occurs, otherwise nil on success
+//
+func discoverIam(ctx context.Context, iamClient *iam.Client) ([]Orphan, error) {
+    var orphans []Orphan
+
+    profilePages := iam.NewListInstanceProfilesPaginator(iamClient,
+                                                          &iam.ListInstanceProfilesInput{})
+    for profilePages.HasMorePages() {
+        page, err := profilePages.NextPage(ctx)
+        if err != nil {
+            return nil, fmt.Errorf("ListInstanceProfiles failed: %w", err)
+        }
+
+        for _, profile := range page.InstanceProfiles {
+            name := aws.ToString(profile.InstanceProfileName)
+            // If the profile is not named like one created by a run
+            if !strings.HasPrefix(name, RolePrefix) {
+                continue
+            }
+
+            tags, err := iamClient.ListInstanceProfileTags(ctx,
+                &iam.ListInstanceProfileTagsInput{InstanceProfileName: aws.String(name)})
+            if err != nil {
+                return nil, fmt.Errorf("ListInstanceProfileTags failed: %w", err)
+            }
+
+            // If the profile has the service tag
+            if hasServiceIamTag(tags.Tags) {
+                orphans = append(orphans, Orphan{Created: aws.ToTime(profile.CreateDate),
+                                                 Id: name, Kind: KindInstanceProfile,
+                                                 RunId: roleRunId(name)})
+            }
+        }
+    }
+
+    rolePages := iam.NewListRolesPaginator(iamClient, &iam.ListRolesInput{})
+    for rolePages.HasMorePages() {
+        page, err := rolePages.NextPage(ctx)
+        if err != nil {
+            return nil, fmt.Errorf("ListRoles failed: %w", err)
+        }
+
+        for _, role := range page.Roles {
+            name := aws.ToString(role.RoleName)
+            // If the role is not named like one created by a run
+            if !strings.HasPrefix(name, RolePrefix) {
+                continue
+            }
+
+            tags, err := iamClient.ListRoleTags(ctx,
+                                                &iam.ListRoleTagsInput{RoleName: aws.String(name)})
+            if err != nil {
+                return nil, fmt.Errorf("ListRoleTags failed: %w", err)
+            }
+
+            // If the role has the service tag
+            if hasServiceIamTag(tags.Tags) {
+                orphans = append(orphans, Orphan{Created: aws.ToTime(role.CreateDate),
+                                                 Id: name, Kind: KindRole,
+                                                 RunId: roleRunId(name)})
+            }
+        }
+    }
+
+    return orphans, nil
+}
+
+
+// Discovers the objects in the bucket tagged with the service tag, results persisted
+// to a bucket are not tagged so they are never discovered.
+//
+// @Parameters
+// - ctx:  The context the API calls are bound to
+// - s3Client:  The S3 client used to list the objects
+// - bucketName:  The bucket to search
+// - region:  The region recorded on the orphans
+//
+// @Returns
+// - The discovered orphans
+// - Error if it occurs, otherwise nil on success
+//
+func discoverS3Objects(ctx context.Context, s3Client *s3.Client, bucketName string,
+                       region string) ([]Orphan, error) {
+    var orphans []Orphan
+
+    objectPages := s3.NewListObjectsV2Paginator(s3Client,
+        &s3.ListObjectsV2Input{Bucket: aws.String(bucketName)})
+    for objectPages.HasMorePages() {
+        page, err := objectPages.NextPage(ctx)
+        if err != nil {
+            return nil, fmt.Errorf("ListObjectsV2 failed: %w", err)
+        }
+
+        for _, object := range page.Contents {
+            tagging, err := s3Client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+                Bucket: aws.String(bucketName),
+                Key:    object.Key,
+            })
+            if err != nil {
+                return nil, fmt.Errorf("GetObjectTagging failed: %w", err)
+            }
+
+            // Iterate through the tags of the object looking for the service tag
+            for _, tag := range tagging.TagSet {
+                if aws.ToString(tag.Key) == ServiceTagKey &&
+                   aws.ToString(tag.Value) == ServiceTagValue {
+                    orphans = append(orphans, Orphan{
+                        Created: aws.ToTime(object.LastModified),
+                        Id:      bucketName + "/" + aws.ToString(object.Key),
+                        Kind:    KindS3Object,
+                        Region:  region,
+                        RunId:   pathRunId(aws.ToString(object.Key)),
+                    })
+                    break
+                }
+            }
+        }
+    }
+
+    return orphans, nil
+}
+
+
+// Gets the runs that are still live, meaning they have instances that are not terminated.
+// Running instances belong to a run in progress and stopped ones to a hibernated run.
+//
+// @Parameters
+// - orphans:  The discovered orphans across every region
+//
+// @Returns
+// - The IDs of the live runs in sorted order
+//
+func LiveRuns(orphans []Orphan) []string {
+    var runIds []string
+
+    // Iterate through the instances collecting the runs they belong to
+    for _, orphan := range orphans {
+        if orphan.Kind == KindInstance && orphan.RunId != "" {
+            runIds = append(runIds, orphan.RunId)
+        }
+    }
+
+    slices.Sort(runIds)
+    return slices.Compact(runIds)
+}
+
+
+// Selects the orphans to be deleted. If a run is picked every resource of it is selected,
+// otherwise only resources of runs that are not live and older than the grace period are,
+// so runs in progress or hibernated and resources of unknown runs are never swept.
+//
+// @Parameters
+// - orphans:  The discovered orphans across every region
+// - runId:  The ID of the run picked to be torn down, empty to sweep dead runs
+// - grace:  The age resources must reach before they are swept
+// - now:  The current time the age of resources is measured from
+//
+// @Returns
+// - The selected orphans
+//
+func SelectOrphans(orphans []Orphan, runId string, grace time.Duration,
+                   now time.Time) []Orphan {
+    // If a run was picked, select only its resources
+    if runId != "" {
+        return slices.DeleteFunc(slices.Clone(orphans), func(orphan Orphan) bool {
+            return orphan.RunId != runId
+        })
+    }
+
+    liveRuns := LiveRuns(orphans)
+
+    return slices.DeleteFunc(slices.Clone(orphans), func(orphan Orphan) bool {
+        // If the run or age of the resource is unknown, it can not be judged an orphan
+        if orphan.RunId == "" || orphan.Created.IsZero() {
+            return true
+        }
+
+        // If the run is still in progress or hibernated
+        if slices.Contains(liveRuns, orphan.RunId) {
+            return true
+        }
+
+        return now.Sub(orphan.Created) < grace
+    })
+}
+
+
+// Deletes the orphans in the order of their kinds, terminated instances are waited on so
+// their security groups can be deleted after. Every orphan is attempted even if one fails.
+//
+// @Parameters
+// - awsConfig:  The AWS config of the region the orphans are in
+// - orphans:  The orphans to delete
+// - callTime:  The length of time each API call is allowed to execute
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func DeleteOrphans(awsConfig aws.Config, orphans []Orphan, callTime time.Duration) error {
+    var errs []error
+
+    ec2Client := ec2.NewFromConfig(awsConfig)
+    iamClient := iam.NewFromConfig(awsConfig)
+    s3Client := s3.NewFromConfig(awsConfig)
+    ssmClient := ssm.NewFromConfig(awsConfig)
+
+    var instanceIds []string
+    // Collect the instances so they are terminated in a single call
+    for _, orphan := range orphans {
+        if orphan.Kind == KindInstance {
+            instanceIds = append(instanceIds, orphan.Id)
+        }
+    }
+
+    // If there are instances to terminate
+    if len(instanceIds) > 0 {
+        errs = append(errs, teardownCall("ec2", "TerminateInstances",
+                                         map[string]any{"instance_ids": instanceIds},
+                                         callTime, func(ctx context.Context) error {
+            _, err := ec2Client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
+                InstanceIds: instanceIds,
+            })
+            if err != nil {
+                return err
+            }
+
+            // Wait for the instances to terminate so they release their security groups
+            waiter := ec2.NewInstanceTerminatedWaiter(ec2Client)
+            return waiter.Wait(ctx, &ec2.DescribeInstancesInput{InstanceIds: instanceIds},
+                               callTime)
+        }))
+    }
+
+    // Iterate through the remaining orphans in delete order
+    for _, orphan := range SortOrphans(orphans) {
+        switch orphan.Kind {
+        case KindS3Object:
+            bucketName, key, _ := strings.Cut(orphan.Id, "/")
+            errs = append(errs, teardownCall("s3", "DeleteObject",
+                                             map[string]any{"bucket": bucketName, "key": key},
+                                             callTime, func(ctx context.Context) error {
+                _, err := s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+                    Bucket: aws.String(bucketName),
+                    Key:    aws.String(key),
+                })
+                return err
+            }))
+        case KindSsmParameter:
+            errs = append(errs, teardownCall("ssm", "DeleteParameter",
+                                             map[string]any{"name": orphan.Id}, callTime,
+                                             func(ctx context.Context) error {
+                _, err := ssmClient.DeleteParameter(ctx, &ssm.DeleteParameterInput{
+                    Name: aws.String(orphan.Id),
+                })
+                return err
+            }))
+        case KindInstanceProfile:
+            errs = append(errs, deleteInstanceProfile(iamClient, orphan.Id, callTime))
+        case KindRole:
+            errs = append(errs, deleteRole(iamClient, orphan.Id, callTime))
+        case KindSecurityGroup:
+            errs = append(errs, teardownCall("ec2", "DeleteSecurityGroup",
+                                             map[string]any{"group_id": orphan.Id}, callTime,
+                                             func(ctx context.Context) error {
+                _, err := ec2Client.DeleteSecurityGroup(ctx, &ec2.DeleteSecurityGroupInput{
+                    GroupId: aws.String(orphan.Id),
+                })
+                return err
+            }))
+        case KindVpc:
+            errs = append(errs, teardownCall("ec2", "DeleteVpc",
+                                             map[string]any{"vpc_id": orphan.Id}, callTime,
+                                             func(ctx context.Context) error {
+                _, err := ec2Client.DeleteVpc(ctx, &ec2.DeleteVpcInput{
+                    VpcId: aws.String(orphan.Id),
+                })
+                return err
+            }))
+        }
+    }
+
+    return errors.Join(errs...)
+}
+
+
+// Deletes the instance profile after removing the roles it holds.
+//
+// @Parameters
+// - iamClient:  The IAM client used to delete the profile
+// - name:  The name of the instance profile
+// - callTime:  The length of time each API call is allowed to execute
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func deleteInstanceProfile(iamClient *iam.Client, name string, callTime time.Duration) error {
+    return teardownCall("iam", "DeleteInstanceProfile", map[string]any{"instance_profile": name},
+                        callTime, func(ctx context.Context) error {
+        output, err := iamClient.GetInstanceProfile(ctx, &iam.GetInstanceProfileInput{
+            InstanceProfileName: aws.String(name),
+        })
+        if err != nil {
+            return err
+        }
+
+        // Iterate through the roles of the profile removing each
+        for _, role := range output.InstanceProfile.Roles {
+            _, err = iamClient.RemoveRoleFromInstanceProfile(ctx,
+                &iam.RemoveRoleFromInstanceProfileInput{
+                    InstanceProfileName: aws.String(name),
+                    RoleName:            role.RoleName,
+                })
+            if err != nil {
+                return err
+            }
+        }
+
+        _, err = iamClient.DeleteInstanceProfile(ctx, &iam.DeleteInstanceProfileInput{
+            InstanceProfileName: aws.String(name),
+        })
+        return err
+    })
+}
+
+
+// Deletes the role after detaching its managed policies and deleting its inline ones.
+//
+// @Parameters
+// - iamClient:  The IAM client used to delete the role
+// - roleName:  The name of the role
+// - callTime:  The length of time each API call is allowed to execute
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func deleteRole(iamClient *iam.Client, roleName string, callTime time.Duration) error {
+    return teardownCall("iam", "DeleteRole", map[string]any{"role_name": roleName}, callTime,
+                        func(ctx context.Context) error {
+        attached, err := iamClient.ListAttachedRolePolicies(ctx,
+            &iam.ListAttachedRolePoliciesInput{RoleName: aws.String(roleName)})
+        if err != nil {
+            return err
+        }
+
+        // Iterate through the managed policies detaching each
+        for _, policy := range attached.AttachedPolicies {
+            _, err = iamClient.DetachRolePolicy(ctx, &iam.DetachRolePolicyInput{
+                PolicyArn: policy.PolicyArn,
+                RoleName:  aws.String(roleName),
+            })
+            if err != nil {
+                return err
+            }
+        }
+
+        inline, err := iamClient.ListRolePolicies(ctx,
+                                                  &iam.ListRolePoliciesInput{
+                                                      RoleName: aws.String(roleName),
+                                                  })
+        if err != nil {
+            return err
+        }
+
+        // Iterate through the inline policies deleting each
+        for _, policyName := range inline.PolicyNames {
+            _, err = iamClient.DeleteRolePolicy(ctx, &iam.DeleteRolePolicyInput{
+                PolicyName: aws.String(policyName),
+                RoleName:   aws.String(roleName),
+            })
+            if err != nil {
+                return err
+            }
+        }
+
+        _, err = iamClient.DeleteRole(ctx, &iam.DeleteRoleInput{RoleName: aws.String(roleName)})
+        return err
+    })
+}
+
+
+// Formats the teardown plan listing the orphans grouped by kind in delete order.
+//
+// @Parameters
+// - orphans:  The orphans to be deleted
+//
+// @Returns
+// - The formatted plan
+//
+func FormatPlan(orphans []Orphan) string {
+    // If nothing was left behind
+    if len(orphans) == 0 {
+        return "No orphaned resources found\n"
+    }
+
+    var builder strings.Builder
+    fmt.Fprintf(&builder, "%d orphaned resources will be deleted:\n", len(orphans))
+
+    // Iterate through the orphans in the order they are deleted
+    for _, orphan := range SortOrphans(orphans) {
+        region := orphan.Region
+        // If the resource is global
+        if region == "" {
+            region = "global"
+        }
+
+        runId := orphan.RunId
+        // If the run of the resource is unknown
+        if runId == "" {
+            runId = "-"
+        }
+
+        fmt.Fprintf(&builder, "  %-22s %-12s %-10s %s\n", orphan.Kind, region, runId,
+                    orphan.Id)
+    }
+
+    return builder.String()
+}
+
+
+// Gets the value of the EC2 tag with the passed in key.
+//
+// @Parameters
+// - tags:  The tags of the EC2 resource
+// - key:  The key of the tag
+//
+// @Returns
+// - The value of the tag, empty if not present
+//
+func ec2TagValue(tags []ec2types.Tag, key string) string {
+    // Iterate through the tags looking for the key
+    for _, tag := range tags {
+        if aws.ToString(tag.Key) == key {
+            return aws.ToString(tag.Value)
+        }
+    }
+
+    return ""
+}
+
+
+// Gets the run ID from an SSM parameter path or S3 key scoped to a run, formatted by
+// ParameterPrefix() or ClientBinaryKey().
+//
+// @Parameters
+// - path:  The parameter path or object key
+//
+// @Returns
+// - The ID of the run, empty if the path is not scoped to a run
+//
+func pathRunId(path string) string {
+    parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+    // If the path is not under the prefix of a run
+    if len(parts) < 3 || parts[0] != RunPathPrefix {
+        return ""
+    }
+
+    return parts[1]
+}
+
+
+// Gets the run ID from the name of a role or instance profile, which is suffixed
+// with it by IamRun.RoleName().
+//
+// @Parameters
+// - name:  The name of the role or instance profile
+//
+// @Returns
+// - The ID of the run, empty if the name has no suffix
+//
+func roleRunId(name string) string {
+    index := strings.LastIndex(name, "-")
+    // If the name is not suffixed past the role prefix
+    if index < len(RolePrefix) {
+        return ""
+    }
+
+    return name[index + 1:]
+}
+
+
+// Checks whether the IAM tags contain the service tag.
+//
+// @Parameters
+// - tags:  The tags of the IAM resource
+//
+// @Returns
+// - true/false depending on whether the service tag is present
+//
+func hasServiceIamTag(tags []iamtypes.Tag) bool {
+    return slices.ContainsFunc(tags, func(tag iamtypes.Tag) bool {
+        return aws.ToString(tag.Key) == ServiceTagKey &&
+               aws.ToString(tag.Value) == ServiceTagValue
+    })
+}
+
+
+// Creates the service tag put on the IAM resources of a run.
+//
+// @Returns
+// - The service tag
+//
+func serviceIamTag() iamtypes.Tag {
+    return iamtypes.Tag{Key: aws.String(ServiceTagKey), Value: aws.String(ServiceTagValue)}
+}
+
+
+// Sorts a copy of the orphans into the order they are deleted, by kind then region and ID.
+//
+// @Parameters
+// - orphans:  The orphans to sort
+//
+// @Returns
+// - The sorted copy of the orphans
+//
+func SortOrphans(orphans []Orphan) []Orphan {
+    sorted := slices.Clone(orphans)
+
+    slices.SortStableFunc(sorted, func(a, b Orphan) int {
+        // If the kinds differ, order by their position in the delete order
+        if a.Kind != b.Kind {
+            return slices.Index(KindOrder, a.Kind) - slices.Index(KindOrder, b.Kind)
+        }
+
+        // If the regions differ, order alphabetically by region
+        if a.Region != b.Region {
+            return strings.Compare(a.Region, b.Region)
+        }
+
+        return strings.Compare(a.Id, b.Id)
+    })
+
+    return sorted
+}
+
+
+// Executes a single teardown API call, recording it instead in dry-run mode.
+//
+// @Parameters
+// - service:  The name of the AWS service
+// - action:  The name of the API action
+// - params:  The parameters recorded in dry-run mode
+// - callTime:  The length of time the API call is allowed to execute
+// - apiCall:  The function executing the API call
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func teardownCall(service string, action string, params map[string]any,
+                  callTime time.Duration, apiCall func(ctx context.Context) error) error {
+    // If dry-run is enabled, record the deletion instead of executing it
+    if DryRun != nil {
+        DryRun.Record(service, action, params)
+        return nil
+    }
+
+    // Ensure AWS API calls do not hang for longer specified timeout
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    err := apiCall(ctx)
+    if err != nil {
+        return fmt.Errorf("%s failed: %w", action, err)
+    }
+
+    recordMutation(service, action, params)
+    return nil
+}
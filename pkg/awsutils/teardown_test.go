@@ -0,0 +1,101 @@
+package awsutils_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/awsutils"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestSortOrphans(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    orphans := []awsutils.Orphan{
+        {Id: "sg-2", Kind: awsutils.KindSecurityGroup, Region: "us-west-2"},
+        {Id: "KloudKraken-ClientRole-ab12", Kind: awsutils.KindRole},
+        {Id: "sg-1", Kind: awsutils.KindSecurityGroup, Region: "us-east-1"},
+        {Id: "KloudKraken-ClientRole-ab12", Kind: awsutils.KindInstanceProfile},
+        {Id: "i-0abc", Kind: awsutils.KindInstance, Region: "us-east-1"},
+    }
+
+    sorted := awsutils.SortOrphans(orphans)
+    kinds := []string{}
+    // Collect the kinds in sorted order
+    for _, orphan := range sorted {
+        kinds = append(kinds, orphan.Kind)
+    }
+
+    // Ensure instances are first, profiles precede roles, and groups follow IAM
+    assert.Equal([]string{awsutils.KindInstance, awsutils.KindInstanceProfile,
+                          awsutils.KindRole, awsutils.KindSecurityGroup,
+                          awsutils.KindSecurityGroup}, kinds)
+    // Ensure orphans of the same kind are ordered by region
+    assert.Equal("sg-1", sorted[3].Id)
+    // Ensure the passed in orphans are not modified
+    assert.Equal("sg-2", orphans[0].Id)
+}
+
+
+func TestFormatPlan(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Ensure an empty plan says nothing was found
+    assert.Equal("No orphaned resources found\n", awsutils.FormatPlan(nil))
+
+    plan := awsutils.FormatPlan([]awsutils.Orphan{
+        {Id: "KloudKraken-ServerRole-ab12", Kind: awsutils.KindRole},
+        {Id: "/kloud-kraken/tls/cert-1", Kind: awsutils.KindSsmParameter,
+         Region: "us-east-1"},
+    })
+
+    lines := strings.Split(strings.TrimSpace(plan), "\n")
+    // Ensure the header and both orphans are listed
+    assert.Equal(3, len(lines))
+    assert.Equal("2 orphaned resources will be deleted:", lines[0])
+    assert.Contains(lines[1], "/kloud-kraken/tls/cert-1")
+    // Ensure global resources are listed as global
+    assert.Contains(lines[2], "global")
+}
+
+
+func TestSelectOrphans(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    now := time.Now()
+    old := now.Add(-48 * time.Hour)
+    orphans := []awsutils.Orphan{
+        {Created: old, Id: "i-live", Kind: awsutils.KindInstance, RunId: "live0001",
+         State: "running"},
+        {Created: old, Id: "i-hibernated", Kind: awsutils.KindInstance, RunId: "hib00001",
+         State: "stopped"},
+        {Created: old, Id: "kloud-kraken/live0001/client", Kind: awsutils.KindS3Object,
+         RunId: "live0001"},
+        {Created: old, Id: "/kloud-kraken/hib00001/tls/cert-1",
+         Kind: awsutils.KindSsmParameter, RunId: "hib00001"},
+        {Created: old, Id: "KloudKraken-ClientRole-dead0001", Kind: awsutils.KindRole,
+         RunId: "dead0001"},
+        {Created: now, Id: "KloudKraken-ClientRole-new00001", Kind: awsutils.KindRole,
+         RunId: "new00001"},
+        {Id: "sg-1", Kind: awsutils.KindSecurityGroup},
+    }
+
+    // Ensure the runs with instances are live
+    assert.Equal([]string{"hib00001", "live0001"}, awsutils.LiveRuns(orphans))
+
+    // Ensure only the resources of dead runs past the grace period are swept
+    selected := awsutils.SelectOrphans(orphans, "", 24 * time.Hour, now)
+    assert.Equal(1, len(selected))
+    assert.Equal("KloudKraken-ClientRole-dead0001", selected[0].Id)
+
+    // Ensure a picked run is selected whole, even if live
+    selected = awsutils.SelectOrphans(orphans, "live0001", 24 * time.Hour, now)
+    assert.Equal(2, len(selected))
+    assert.Equal("i-live", selected[0].Id)
+    assert.Equal("kloud-kraken/live0001/client", selected[1].Id)
+}
@@ -0,0 +1,290 @@
+package clientdir
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Name of the index file mapping the client dirs to the clients they store the files of
+const IndexName = "index.json"
+
+
+// Client is the index entry of a client and the dir its received files are stored in
+type Client struct {
+    Addr       string    `json:"addr"`
+    Created    time.Time `json:"created"`
+    Dir        string    `json:"dir"`
+    InstanceId string    `json:"instance_id,omitempty"`
+}
+
+
+// Index stores the dir of each client under the dir of the run, recording the mapping in
+// an index file next to them
+type Index struct {
+    Clients []Client          `json:"clients"`
+    RunId   string            `json:"run_id"`
+    dirs    map[string]string
+    mutx    sync.Mutex
+    runDir  string
+}
+
+
+// Gets the key a client dir is created for from the remote address of the client. Each
+// EC2 client has its own IP, so the port is dropped to keep a reconnecting client in the
+// same dir, while local clients share the loopback IP and are told apart by their port.
+//
+// @Parameters
+// - remoteAddr:  The remote address of the client connection
+//
+// @Returns
+// - The key of the client dir
+//
+func Key(remoteAddr string) string {
+    host, _, err := net.SplitHostPort(remoteAddr)
+    if err != nil {
+        return remoteAddr
+    }
+
+    // If the client is on the loopback address, keep the port to tell local clients apart
+    if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+        return remoteAddr
+    }
+
+    return host
+}
+
+
+// Formats the name of the dir of a client, prefixed with its instance ID when known.
+//
+// @Parameters
+// - key:  The key of the client dir
+// - instanceId:  The EC2 instance ID of the client, empty if unknown
+//
+// @Returns
+// - The dir name with the characters of IPv6 addresses and ports replaced
+//
+func DirName(key string, instanceId string) string {
+    name := strings.NewReplacer(":", "_", "[", "", "]", "").Replace(key)
+    // If the instance of the client is known, lead with its ID
+    if instanceId != "" {
+        name = instanceId + "_" + name
+    }
+
+    return name
+}
+
+
+// Creates the dir of the run under the base dir and an empty index in it.
+//
+// @Parameters
+// - baseDir:  The dir the run dirs are created in
+// - runId:  The unique ID of the run
+//
+// @Returns
+// - The initialized index
+// - Error if it occurs, otherwise nil on success
+//
+func New(baseDir string, runId string) (*Index, error) {
+    runDir := filepath.Join(baseDir, runId)
+    err := os.MkdirAll(runDir, 0755)
+    if err != nil {
+        return nil, fmt.Errorf("error creating client dirs of run - %w", err)
+    }
+
+    index := &Index{Clients: []Client{}, RunId: runId, dirs: make(map[string]string),
+                    runDir: runDir}
+    return index, index.write()
+}
+
+
+// Gets the dir of the run holding the client dirs and the index.
+//
+// @Returns
+// - The path of the run dir
+//
+func (index *Index) RunDir() string {
+    return index.runDir
+}
+
+
+// Gets the dir of the client with the passed in key if it was already created.
+//
+// @Parameters
+// - key:  The key of the client dir
+//
+// @Returns
+// - The path of the client dir
+// - Whether the client dir exists
+//
+func (index *Index) Lookup(key string) (string, bool) {
+    index.mutx.Lock()
+    defer index.mutx.Unlock()
+
+    dirPath, ok := index.dirs[key]
+    return dirPath, ok
+}
+
+
+// Creates the dir of the client with the passed in key and records it in the index, the
+// existing dir is returned if another connection of the client already created it.
+//
+// @Parameters
+// - key:  The key of the client dir
+// - instanceId:  The EC2 instance ID of the client, empty if unknown
+//
+// @Returns
+// - The path of the client dir
+// - Error if it occurs, otherwise nil on success
+//
+func (index *Index) Add(key string, instanceId string) (string, error) {
+    index.mutx.Lock()
+    defer index.mutx.Unlock()
+
+    // If the dir was created while the instance ID was resolved
+    if dirPath, ok := index.dirs[key]; ok {
+        return dirPath, nil
+    }
+
+    name := DirName(key, instanceId)
+    dirPath := filepath.Join(index.runDir, name)
+
+    err := os.MkdirAll(dirPath, 0755)
+    if err != nil {
+        return "", fmt.Errorf("error creating client dir - %w", err)
+    }
+
+    index.dirs[key] = dirPath
+    index.Clients = append(index.Clients, Client{Addr: key, Created: time.Now().UTC(),
+                                                 Dir: name, InstanceId: instanceId})
+    return dirPath, index.write()
+}
+
+
+// Writes the index to a temporary file renamed over the index file, so it is never left
+// partially written. Called with the mutex held.
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (index *Index) write() error {
+    indexData, err := json.MarshalIndent(index, "", "  ")
+    if err != nil {
+        return err
+    }
+
+    indexPath := filepath.Join(index.runDir, IndexName)
+    tempPath := indexPath + ".tmp"
+
+    err = os.WriteFile(tempPath, indexData, 0644)
+    if err != nil {
+        return fmt.Errorf("error writing client dir index - %w", err)
+    }
+
+    return os.Rename(tempPath, indexPath)
+}
+
+
+// Writes the run dir with the client dirs and the index to a zip archive, with the paths
+// of the files relative to the run dir.
+//
+// @Parameters
+// - zipPath:  The path where the archive is written
+//
+// @Returns
+// - The number of files archived
+// - Error if it occurs, otherwise nil on success
+//
+func (index *Index) Archive(zipPath string) (int, error) {
+    index.mutx.Lock()
+    defer index.mutx.Unlock()
+
+    archive, err := os.Create(zipPath)
+    if err != nil {
+        return 0, err
+    }
+    // Close the file on local exit
+    defer archive.Close()
+
+    zipWriter := zip.NewWriter(archive)
+    count := 0
+
+    // Iterate through the run dir adding each file to the archive
+    err = filepath.WalkDir(index.runDir, func(filePath string, entry os.DirEntry,
+                                              err error) error {
+        if err != nil || entry.IsDir() {
+            return err
+        }
+
+        relPath, err := filepath.Rel(index.runDir, filePath)
+        if err != nil {
+            return err
+        }
+
+        err = addFile(zipWriter, filePath, filepath.ToSlash(relPath))
+        if err != nil {
+            return fmt.Errorf("error adding %s to client dir archive - %w", relPath, err)
+        }
+
+        count++
+        return nil
+    })
+    if err != nil {
+        return 0, err
+    }
+
+    err = zipWriter.Close()
+    if err != nil {
+        return 0, err
+    }
+
+    return count, archive.Close()
+}
+
+
+// Adds the file at the passed in path to the zip archive under the passed in name.
+//
+// @Parameters
+// - zipWriter:  The zip archive writer
+// - filePath:  The path of the file to add
+// - name:  The name the file is stored under in the archive
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func addFile(zipWriter *zip.Writer, filePath string, name string) error {
+    file, err := os.Open(filePath)
+    if err != nil {
+        return err
+    }
+    // Close the file on local exit
+    defer file.Close()
+
+    fileInfo, err := file.Stat()
+    if err != nil {
+        return err
+    }
+
+    header, err := zip.FileInfoHeader(fileInfo)
+    if err != nil {
+        return err
+    }
+
+    header.Name = name
+    header.Method = zip.Deflate
+
+    writer, err := zipWriter.CreateHeader(header)
+    if err != nil {
+        return err
+    }
+
+    _, err = io.Copy(writer, file)
+    return err
+}
@@ -0,0 +1,106 @@
+package clientdir_test
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/clientdir"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestKey(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Ensure EC2 clients are keyed by IP and local clients by IP and port
+    assert.Equal("10.0.0.5", clientdir.Key("10.0.0.5:50123"))
+    assert.Equal("127.0.0.1:50123", clientdir.Key("127.0.0.1:50123"))
+    assert.Equal("[::1]:50123", clientdir.Key("[::1]:50123"))
+    assert.Equal("client", clientdir.Key("client"))
+}
+
+
+func TestDirName(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Ensure every client gets a distinct dir name safe for the filesystem
+    assert.Equal("10.0.0.5", clientdir.DirName("10.0.0.5", ""))
+    assert.Equal("127.0.0.1_50123", clientdir.DirName("127.0.0.1:50123", ""))
+    assert.Equal("__1_50123", clientdir.DirName("[::1]:50123", ""))
+    assert.Equal("i-0abc_10.0.0.5", clientdir.DirName("10.0.0.5", "i-0abc"))
+}
+
+
+func TestIndex(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    testDir := t.TempDir()
+
+    index, err := clientdir.New(testDir, "a1b2c3d4")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(filepath.Join(testDir, "a1b2c3d4"), index.RunDir())
+
+    ec2Dir, err := index.Add("10.0.0.5", "i-0123456789abcdef0")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(filepath.Join(index.RunDir(), "i-0123456789abcdef0_10.0.0.5"), ec2Dir)
+
+    localDir, err := index.Add("127.0.0.1:50123", "")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(filepath.Join(index.RunDir(), "127.0.0.1_50123"), localDir)
+
+    // Ensure a reconnecting client reuses its dir
+    sameDir, err := index.Add("10.0.0.5", "")
+    assert.Equal(nil, err)
+    assert.Equal(ec2Dir, sameDir)
+    lookupDir, ok := index.Lookup("10.0.0.5")
+    assert.True(ok)
+    assert.Equal(ec2Dir, lookupDir)
+    _, ok = index.Lookup("10.0.0.6")
+    assert.False(ok)
+
+    indexData, err := os.ReadFile(filepath.Join(index.RunDir(), clientdir.IndexName))
+    assert.Equal(nil, err)
+
+    var written clientdir.Index
+    assert.Equal(nil, json.Unmarshal(indexData, &written))
+    // Ensure the index maps each client to its dir once
+    assert.Equal("a1b2c3d4", written.RunId)
+    assert.Equal(2, len(written.Clients))
+    assert.Equal("i-0123456789abcdef0", written.Clients[0].InstanceId)
+    assert.Equal("127.0.0.1_50123", written.Clients[1].Dir)
+
+    assert.Equal(nil, os.WriteFile(filepath.Join(ec2Dir, "loot.txt"), []byte("hash:pass\n"),
+                                   0644))
+    assert.Equal(nil, os.WriteFile(filepath.Join(localDir, "client.log"), []byte("log\n"),
+                                   0644))
+
+    zipPath := filepath.Join(testDir, "clients.zip")
+    count, err := index.Archive(zipPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(3, count)
+
+    zipReader, err := zip.OpenReader(zipPath)
+    assert.Equal(nil, err)
+    defer zipReader.Close()
+
+    var names []string
+    // Iterate through the archive collecting the names of the files
+    for _, file := range zipReader.File {
+        names = append(names, file.Name)
+    }
+    sort.Strings(names)
+
+    // Ensure the files are stored relative to the run dir
+    assert.Equal([]string{"127.0.0.1_50123/client.log", "i-0123456789abcdef0_10.0.0.5/loot.txt",
+                          clientdir.IndexName}, names)
+}
@@ -0,0 +1,689 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/ngimb64/Kloud-Kraken/pkg/data"
+)
+
+// Control plane modes selectable in the config
+const ModeSqs = "sqs"
+const ModeTls = "tls"
+
+// Max raw bytes sent per queue message, base64 encoded it stays under the 256 KB limit
+const ChunkSize = 128 * 1024
+// Seconds each receive long polls the queue for messages
+const WaitSeconds = 20
+
+// Package level variables
+var EofAttribute = "kloud-kraken-eof"
+var PollInterval = 5 * time.Second
+var ReClientId = regexp.MustCompile(`^[a-f0-9]{16}$`)
+
+
+// SqsApi is the subset of the SQS client the control plane uses
+type SqsApi interface {
+    CreateQueue(ctx context.Context, params *sqs.CreateQueueInput,
+                optFns ...func(*sqs.Options)) (*sqs.CreateQueueOutput, error)
+    DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput,
+                  optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+    DeleteQueue(ctx context.Context, params *sqs.DeleteQueueInput,
+                optFns ...func(*sqs.Options)) (*sqs.DeleteQueueOutput, error)
+    GetQueueUrl(ctx context.Context, params *sqs.GetQueueUrlInput,
+                optFns ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error)
+    ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput,
+                   optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+    SendMessage(ctx context.Context, params *sqs.SendMessageInput,
+                optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+}
+
+
+// Addr identifies a client of the control plane by its generated ID
+type Addr struct {
+    Id string
+}
+
+// Gets the name of the network.
+//
+// @Returns
+// - The network name
+//
+func (addr Addr) Network() string {
+    return ModeSqs
+}
+
+// Gets the client ID, which takes the place of the IP address.
+//
+// @Returns
+// - The client ID
+//
+func (addr Addr) String() string {
+    return addr.Id
+}
+
+
+// Conn is a connection between the server and a client over a pair of FIFO queues,
+// each write is sent as one or more ordered messages and each read returns the data
+// of a single message so message boundaries are preserved
+type Conn struct {
+    api          SqsApi
+    cancel       context.CancelFunc
+    closeOnce    sync.Once
+    ctx          context.Context
+    eof          bool
+    id           string
+    pending      [][]byte
+    readDeadline time.Time
+    readMutx     sync.Mutex
+    recvUrl      string
+    sendMutx     sync.Mutex
+    sendUrl      string
+    sequence     int64
+}
+
+// Creates a connection that receives from and sends to the passed in queues.
+//
+// @Parameters
+// - api:  The SQS client
+// - id:  The ID of the client the connection belongs to
+// - recvUrl:  The URL of the queue messages are received from
+// - sendUrl:  The URL of the queue messages are sent to
+//
+// @Returns
+// - The initialized connection
+//
+func NewConn(api SqsApi, id string, recvUrl string, sendUrl string) *Conn {
+    ctx, cancel := context.WithCancel(context.Background())
+
+    return &Conn{
+        api:     api,
+        cancel:  cancel,
+        ctx:     ctx,
+        id:      id,
+        recvUrl: recvUrl,
+        sendUrl: sendUrl,
+    }
+}
+
+// Reads the data of the next message, waiting until one arrives, the peer closes
+// the connection, or the read deadline passes.
+//
+// @Parameters
+// - buffer:  The buffer where the read data is stored
+//
+// @Returns
+// - The number of bytes read into the buffer
+// - Error if it occurs, otherwise nil on success
+//
+func (conn *Conn) Read(buffer []byte) (int, error) {
+    conn.readMutx.Lock()
+    defer conn.readMutx.Unlock()
+
+    // Keep receiving until a message with data is available
+    for len(conn.pending) == 0 {
+        // If the peer closed the connection
+        if conn.eof {
+            return 0, io.EOF
+        }
+
+        // If the connection was closed locally
+        if conn.ctx.Err() != nil {
+            return 0, net.ErrClosed
+        }
+
+        // If the read deadline has passed
+        if !conn.readDeadline.IsZero() && time.Now().After(conn.readDeadline) {
+            return 0, os.ErrDeadlineExceeded
+        }
+
+        err := conn.receive()
+        if err != nil {
+            return 0, err
+        }
+    }
+
+    bytesRead := copy(buffer, conn.pending[0])
+    // If the buffer could not hold the whole message, keep the rest for the next read
+    if bytesRead < len(conn.pending[0]) {
+        conn.pending[0] = conn.pending[0][bytesRead:]
+    } else {
+        conn.pending = conn.pending[1:]
+    }
+
+    return bytesRead, nil
+}
+
+// Long polls the receive queue, decoding and deleting each received message.
+// The read mutex must be held by the caller.
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (conn *Conn) receive() error {
+    waitSeconds := int32(WaitSeconds)
+    // If a read deadline is set, do not poll past it
+    if !conn.readDeadline.IsZero() {
+        remaining := int32(time.Until(conn.readDeadline).Seconds())
+        waitSeconds = max(0, min(waitSeconds, remaining))
+    }
+
+    output, err := conn.api.ReceiveMessage(conn.ctx, &sqs.ReceiveMessageInput{
+        MaxNumberOfMessages:   10,
+        MessageAttributeNames: []string{"All"},
+        QueueUrl:              aws.String(conn.recvUrl),
+        WaitTimeSeconds:       waitSeconds,
+    })
+    if err != nil {
+        // If the connection was closed during the poll
+        if conn.ctx.Err() != nil {
+            return net.ErrClosed
+        }
+
+        return fmt.Errorf("error receiving control plane message - %w", err)
+    }
+
+    // Iterate through the received messages in order
+    for _, message := range output.Messages {
+        // If the message marks the peer closing the connection
+        if _, isEof := message.MessageAttributes[EofAttribute]; isEof {
+            conn.eof = true
+        } else {
+            data, err := base64.StdEncoding.DecodeString(aws.ToString(message.Body))
+            if err != nil {
+                return fmt.Errorf("error decoding control plane message - %w", err)
+            }
+
+            conn.pending = append(conn.pending, data)
+        }
+
+        // Delete the message now that it is buffered
+        _, err = conn.api.DeleteMessage(conn.ctx, &sqs.DeleteMessageInput{
+            QueueUrl:      aws.String(conn.recvUrl),
+            ReceiptHandle: message.ReceiptHandle,
+        })
+        if err != nil {
+            return fmt.Errorf("error deleting control plane message - %w", err)
+        }
+    }
+
+    return nil
+}
+
+// Sends the data as one or more ordered messages.
+//
+// @Parameters
+// - data:  The data to be sent
+//
+// @Returns
+// - The number of bytes sent
+// - Error if it occurs, otherwise nil on success
+//
+func (conn *Conn) Write(data []byte) (int, error) {
+    conn.sendMutx.Lock()
+    defer conn.sendMutx.Unlock()
+
+    // If the connection was closed locally
+    if conn.ctx.Err() != nil {
+        return 0, net.ErrClosed
+    }
+
+    var bytesWrote int
+    // Iterate through the data sending a message per chunk
+    for bytesWrote < len(data) {
+        chunk := data[bytesWrote:min(len(data), bytesWrote + ChunkSize)]
+
+        err := conn.send(base64.StdEncoding.EncodeToString(chunk), nil)
+        if err != nil {
+            return bytesWrote, err
+        }
+
+        bytesWrote += len(chunk)
+    }
+
+    return bytesWrote, nil
+}
+
+// Sends a single message in the connection message group, the send mutex must be
+// held by the caller.
+//
+// @Parameters
+// - body:  The body of the message
+// - attributes:  Any message attributes, nil if none
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (conn *Conn) send(body string,
+                       attributes map[string]sqstypes.MessageAttributeValue) error {
+    conn.sequence += 1
+
+    _, err := conn.api.SendMessage(conn.ctx, &sqs.SendMessageInput{
+        MessageAttributes:      attributes,
+        MessageBody:            aws.String(body),
+        MessageDeduplicationId: aws.String(strconv.FormatInt(conn.sequence, 10)),
+        MessageGroupId:         aws.String(conn.id),
+        QueueUrl:               aws.String(conn.sendUrl),
+    })
+    if err != nil {
+        return fmt.Errorf("error sending control plane message - %w", err)
+    }
+
+    return nil
+}
+
+// Notifies the peer the connection is closed and stops any pending reads.
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (conn *Conn) Close() error {
+    var err error
+
+    conn.closeOnce.Do(func() {
+        conn.sendMutx.Lock()
+        // Send the end of connection marker so the peer reads EOF
+        err = conn.send("EOF", map[string]sqstypes.MessageAttributeValue{
+            EofAttribute: {DataType: aws.String("String"), StringValue: aws.String("true")},
+        })
+        conn.sendMutx.Unlock()
+
+        conn.cancel()
+    })
+
+    return err
+}
+
+// Gets the local address of the connection.
+//
+// @Returns
+// - The address with the client ID
+//
+func (conn *Conn) LocalAddr() net.Addr {
+    return Addr{Id: conn.id}
+}
+
+// Gets the remote address of the connection.
+//
+// @Returns
+// - The address with the client ID
+//
+func (conn *Conn) RemoteAddr() net.Addr {
+    return Addr{Id: conn.id}
+}
+
+// Sets the read deadline, writes are single API calls and have no deadline.
+//
+// @Parameters
+// - deadline:  The time reads stop waiting, zero for no deadline
+//
+// @Returns
+// - Always nil
+//
+func (conn *Conn) SetDeadline(deadline time.Time) error {
+    return conn.SetReadDeadline(deadline)
+}
+
+// Sets the time reads stop waiting for a message.
+//
+// @Parameters
+// - deadline:  The time reads stop waiting, zero for no deadline
+//
+// @Returns
+// - Always nil
+//
+func (conn *Conn) SetReadDeadline(deadline time.Time) error {
+    conn.readMutx.Lock()
+    defer conn.readMutx.Unlock()
+
+    conn.readDeadline = deadline
+    return nil
+}
+
+// Writes are single API calls and have no deadline.
+//
+// @Parameters
+// - deadline:  Unused
+//
+// @Returns
+// - Always nil
+//
+func (conn *Conn) SetWriteDeadline(deadline time.Time) error {
+    return nil
+}
+
+
+// Listener accepts clients registering on the run register queue, creating the pair of
+// queues each client connection uses
+type Listener struct {
+    api         SqsApi
+    cancel      context.CancelFunc
+    closeOnce   sync.Once
+    ctx         context.Context
+    mutx        sync.Mutex
+    prefix      string
+    queueUrls   []string
+    registerUrl string
+}
+
+// Creates the register queue of the run and a listener accepting clients from it.
+//
+// @Parameters
+// - api:  The SQS client
+// - prefix:  The prefix of the run queue names
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - The initialized listener
+// - Error if it occurs, otherwise nil on success
+//
+func NewListener(api SqsApi, prefix string, callTime time.Duration) (*Listener, error) {
+    ctx, cancel := context.WithCancel(context.Background())
+    listener := &Listener{api: api, cancel: cancel, ctx: ctx, prefix: prefix}
+
+    // Create the queue clients register on
+    registerUrl, err := listener.createQueue(RegisterQueue(prefix), callTime)
+    if err != nil {
+        cancel()
+        return nil, err
+    }
+
+    listener.registerUrl = registerUrl
+    return listener, nil
+}
+
+// Waits for the next client to register and creates the queues of its connection.
+//
+// @Returns
+// - The connection to the registered client
+// - Error if it occurs, otherwise nil on success
+//
+func (listener *Listener) Accept() (net.Conn, error) {
+    for {
+        // If the listener was closed
+        if listener.ctx.Err() != nil {
+            return nil, net.ErrClosed
+        }
+
+        output, err := listener.api.ReceiveMessage(listener.ctx, &sqs.ReceiveMessageInput{
+            MaxNumberOfMessages: 1,
+            QueueUrl:            aws.String(listener.registerUrl),
+            WaitTimeSeconds:     WaitSeconds,
+        })
+        if err != nil {
+            // If the listener was closed during the poll
+            if listener.ctx.Err() != nil {
+                return nil, net.ErrClosed
+            }
+
+            return nil, fmt.Errorf("error receiving client registration - %w", err)
+        }
+
+        // If no client registered during the poll
+        if len(output.Messages) == 0 {
+            continue
+        }
+
+        message := output.Messages[0]
+        // Delete the registration so it is only accepted once
+        _, err = listener.api.DeleteMessage(listener.ctx, &sqs.DeleteMessageInput{
+            QueueUrl:      aws.String(listener.registerUrl),
+            ReceiptHandle: message.ReceiptHandle,
+        })
+        if err != nil {
+            return nil, fmt.Errorf("error deleting client registration - %w", err)
+        }
+
+        clientId := aws.ToString(message.Body)
+        // If the registration does not contain a valid client ID, ignore it
+        if !ReClientId.MatchString(clientId) {
+            continue
+        }
+
+        // Create the queue the client sends to
+        upUrl, err := listener.createQueue(UpQueue(listener.prefix, clientId), time.Minute)
+        if err != nil {
+            return nil, err
+        }
+
+        // Create the queue the client receives from
+        downUrl, err := listener.createQueue(DownQueue(listener.prefix, clientId), time.Minute)
+        if err != nil {
+            return nil, err
+        }
+
+        return NewConn(listener.api, clientId, upUrl, downUrl), nil
+    }
+}
+
+// Creates a FIFO queue and tracks it for deletion when the listener is closed.
+//
+// @Parameters
+// - name:  The name of the queue
+// - callTime:  The length of time the API call is allowed to execute
+//
+// @Returns
+// - The URL of the created queue
+// - Error if it occurs, otherwise nil on success
+//
+func (listener *Listener) createQueue(name string, callTime time.Duration) (string, error) {
+    ctx, cancel := context.WithTimeout(listener.ctx, callTime)
+    defer cancel()
+
+    output, err := listener.api.CreateQueue(ctx, &sqs.CreateQueueInput{
+        Attributes: map[string]string{
+            "FifoQueue":              "true",
+            "MessageRetentionPeriod": "86400",
+        },
+        QueueName: aws.String(name),
+    })
+    if err != nil {
+        return "", fmt.Errorf("error creating control plane queue %s - %w", name, err)
+    }
+
+    listener.mutx.Lock()
+    listener.queueUrls = append(listener.queueUrls, aws.ToString(output.QueueUrl))
+    listener.mutx.Unlock()
+
+    return aws.ToString(output.QueueUrl), nil
+}
+
+// Stops accepting clients and deletes every queue the listener created.
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (listener *Listener) Close() error {
+    var err error
+
+    listener.closeOnce.Do(func() {
+        listener.cancel()
+
+        listener.mutx.Lock()
+        defer listener.mutx.Unlock()
+
+        // Iterate through the created queues deleting each
+        for _, queueUrl := range listener.queueUrls {
+            ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+            _, deleteErr := listener.api.DeleteQueue(ctx, &sqs.DeleteQueueInput{
+                QueueUrl: aws.String(queueUrl),
+            })
+            cancel()
+
+            // Keep the first error but still attempt to delete the remaining queues
+            if deleteErr != nil && err == nil {
+                err = fmt.Errorf("error deleting control plane queue - %w", deleteErr)
+            }
+        }
+    })
+
+    return err
+}
+
+// Gets the address of the listener.
+//
+// @Returns
+// - The address with the register queue name
+//
+func (listener *Listener) Addr() net.Addr {
+    return Addr{Id: RegisterQueue(listener.prefix)}
+}
+
+
+// Registers the client on the run register queue and waits for the server to create
+// the queues of its connection.
+//
+// @Parameters
+// - api:  The SQS client
+// - prefix:  The prefix of the run queue names
+// - clientId:  The ID of the client, generated with NewClientId()
+// - timeout:  The length of time to wait for the queues to exist
+//
+// @Returns
+// - The connection to the server
+// - Error if it occurs, otherwise nil on success
+//
+func Dial(api SqsApi, prefix string, clientId string, timeout time.Duration) (*Conn, error) {
+    deadline := time.Now().Add(timeout)
+
+    // Wait for the server to create the register queue
+    registerUrl, err := waitForQueue(api, RegisterQueue(prefix), deadline)
+    if err != nil {
+        return nil, err
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+    // Register the client so the server creates its queues
+    _, err = api.SendMessage(ctx, &sqs.SendMessageInput{
+        MessageBody:            aws.String(clientId),
+        MessageDeduplicationId: aws.String(clientId),
+        MessageGroupId:         aws.String("register"),
+        QueueUrl:               aws.String(registerUrl),
+    })
+    cancel()
+    if err != nil {
+        return nil, fmt.Errorf("error registering with control plane - %w", err)
+    }
+
+    // Wait for the server to create the queue the client sends to
+    upUrl, err := waitForQueue(api, UpQueue(prefix, clientId), deadline)
+    if err != nil {
+        return nil, err
+    }
+
+    // Wait for the server to create the queue the client receives from
+    downUrl, err := waitForQueue(api, DownQueue(prefix, clientId), deadline)
+    if err != nil {
+        return nil, err
+    }
+
+    return NewConn(api, clientId, downUrl, upUrl), nil
+}
+
+
+// Polls for the URL of the queue until it exists or the deadline passes.
+//
+// @Parameters
+// - api:  The SQS client
+// - name:  The name of the queue
+// - deadline:  The time to stop waiting for the queue
+//
+// @Returns
+// - The URL of the queue
+// - Error if it occurs, otherwise nil on success
+//
+func waitForQueue(api SqsApi, name string, deadline time.Time) (string, error) {
+    for {
+        ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+        output, err := api.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{QueueName: aws.String(name)})
+        cancel()
+        // If the queue exists
+        if err == nil {
+            return aws.ToString(output.QueueUrl), nil
+        }
+
+        // If the queue still does not exist at the deadline
+        if time.Now().After(deadline) {
+            return "", fmt.Errorf("control plane queue %s not available - %w", name, err)
+        }
+
+        time.Sleep(PollInterval)
+    }
+}
+
+
+// Generates a random client ID used in the names of the client queues.
+//
+// @Returns
+// - The generated client ID
+// - Error if it occurs, otherwise nil on success
+//
+func NewClientId() (string, error) {
+    return data.RandHex(8)
+}
+
+
+// Formats the name of the queue clients register on.
+//
+// @Parameters
+// - prefix:  The prefix of the run queue names
+//
+// @Returns
+// - The register queue name
+//
+func RegisterQueue(prefix string) string {
+    return prefix + "-register.fifo"
+}
+
+
+// Formats the name of the queue a client sends to the server on.
+//
+// @Parameters
+// - prefix:  The prefix of the run queue names
+// - clientId:  The ID of the client
+//
+// @Returns
+// - The up queue name
+//
+func UpQueue(prefix string, clientId string) string {
+    return prefix + "-" + clientId + "-up.fifo"
+}
+
+
+// Formats the name of the queue the server sends to a client on.
+//
+// @Parameters
+// - prefix:  The prefix of the run queue names
+// - clientId:  The ID of the client
+//
+// @Returns
+// - The down queue name
+//
+func DownQueue(prefix string, clientId string) string {
+    return prefix + "-" + clientId + "-down.fifo"
+}
+
+
+// Formats the S3 key a wordlist is staged under for a client to download.
+//
+// @Parameters
+// - prefix:  The prefix of the run queue names
+// - fileName:  The name of the staged wordlist
+//
+// @Returns
+// - The S3 key of the staged wordlist
+//
+func TransferKey(prefix string, fileName string) string {
+    return "transfers/" + prefix + "/" + fileName
+}
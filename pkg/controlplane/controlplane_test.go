@@ -0,0 +1,224 @@
+package controlplane_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/ngimb64/Kloud-Kraken/pkg/controlplane"
+	"github.com/stretchr/testify/assert"
+)
+
+
+// fakeSqs is an in-memory queue service implementing the control plane SqsApi
+type fakeSqs struct {
+    mutx     sync.Mutex
+    queues   map[string]string
+    messages map[string][]sqstypes.Message
+    receipts int
+}
+
+func newFakeSqs() *fakeSqs {
+    return &fakeSqs{
+        queues:   map[string]string{},
+        messages: map[string][]sqstypes.Message{},
+    }
+}
+
+func (fake *fakeSqs) CreateQueue(ctx context.Context, params *sqs.CreateQueueInput,
+                                 optFns ...func(*sqs.Options)) (*sqs.CreateQueueOutput, error) {
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    queueUrl := "https://sqs.local/" + aws.ToString(params.QueueName)
+    fake.queues[aws.ToString(params.QueueName)] = queueUrl
+    return &sqs.CreateQueueOutput{QueueUrl: aws.String(queueUrl)}, nil
+}
+
+func (fake *fakeSqs) DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput,
+                                   optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+    return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (fake *fakeSqs) DeleteQueue(ctx context.Context, params *sqs.DeleteQueueInput,
+                                 optFns ...func(*sqs.Options)) (*sqs.DeleteQueueOutput, error) {
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    // Iterate through the queues removing the matching one
+    for name, queueUrl := range fake.queues {
+        if queueUrl == aws.ToString(params.QueueUrl) {
+            delete(fake.queues, name)
+        }
+    }
+
+    return &sqs.DeleteQueueOutput{}, nil
+}
+
+func (fake *fakeSqs) GetQueueUrl(ctx context.Context, params *sqs.GetQueueUrlInput,
+                                 optFns ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    queueUrl, exists := fake.queues[aws.ToString(params.QueueName)]
+    if !exists {
+        return nil, errors.New("queue does not exist")
+    }
+
+    return &sqs.GetQueueUrlOutput{QueueUrl: aws.String(queueUrl)}, nil
+}
+
+func (fake *fakeSqs) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput,
+                                    optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+    deadline := time.Now().Add(time.Duration(params.WaitTimeSeconds) * time.Second)
+
+    for {
+        fake.mutx.Lock()
+        queued := fake.messages[aws.ToString(params.QueueUrl)]
+        // If messages are queued, hand out up to the requested max
+        if len(queued) > 0 {
+            count := min(len(queued), int(params.MaxNumberOfMessages))
+            fake.messages[aws.ToString(params.QueueUrl)] = queued[count:]
+            fake.mutx.Unlock()
+            return &sqs.ReceiveMessageOutput{Messages: queued[:count]}, nil
+        }
+        fake.mutx.Unlock()
+
+        // If the poll was canceled
+        if ctx.Err() != nil {
+            return nil, ctx.Err()
+        }
+
+        // If the long poll elapsed without messages
+        if time.Now().After(deadline) {
+            return &sqs.ReceiveMessageOutput{}, nil
+        }
+
+        time.Sleep(5 * time.Millisecond)
+    }
+}
+
+func (fake *fakeSqs) SendMessage(ctx context.Context, params *sqs.SendMessageInput,
+                                 optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+    fake.mutx.Lock()
+    defer fake.mutx.Unlock()
+
+    fake.receipts += 1
+    queueUrl := aws.ToString(params.QueueUrl)
+    fake.messages[queueUrl] = append(fake.messages[queueUrl], sqstypes.Message{
+        Body:              params.MessageBody,
+        MessageAttributes: params.MessageAttributes,
+        ReceiptHandle:     aws.String(strconv.Itoa(fake.receipts)),
+    })
+
+    return &sqs.SendMessageOutput{}, nil
+}
+
+
+func TestListenerDial(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    controlplane.PollInterval = 10 * time.Millisecond
+    fake := newFakeSqs()
+
+    listener, err := controlplane.NewListener(fake, "kloud-kraken-test", time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    clientId, err := controlplane.NewClientId()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Regexp(controlplane.ReClientId, clientId)
+
+    var clientConn *controlplane.Conn
+    var dialErr error
+    var waitGroup sync.WaitGroup
+
+    waitGroup.Add(1)
+    // Dial the control plane while the listener accepts
+    go func() {
+        defer waitGroup.Done()
+        clientConn, dialErr = controlplane.Dial(fake, "kloud-kraken-test", clientId,
+                                                time.Second)
+    }()
+
+    serverConn, err := listener.Accept()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    waitGroup.Wait()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, dialErr)
+    assert.Equal(clientId, serverConn.RemoteAddr().String())
+
+    // Send data larger than one message from the client to the server
+    payload := make([]byte, controlplane.ChunkSize + 100)
+    for index := range payload {
+        payload[index] = byte(index)
+    }
+
+    bytesWrote, err := clientConn.Write(payload)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(len(payload), bytesWrote)
+
+    received, err := io.ReadAll(io.LimitReader(serverConn, int64(len(payload))))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(payload, received)
+
+    // Send a message from the server to the client read with a small buffer
+    _, err = serverConn.Write([]byte("PROCESSING_COMPLETE"))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    buffer := make([]byte, 10)
+    bytesRead, err := clientConn.Read(buffer)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("PROCESSING", string(buffer[:bytesRead]))
+
+    bytesRead, err = clientConn.Read(buffer)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("_COMPLETE", string(buffer[:bytesRead]))
+
+    // Closing the server side makes the client read EOF
+    assert.Equal(nil, serverConn.Close())
+    _, err = clientConn.Read(buffer)
+    assert.Equal(io.EOF, err)
+
+    // Closing the listener deletes every queue it created
+    assert.Equal(nil, listener.Close())
+    assert.Empty(fake.queues)
+
+    _, err = listener.Accept()
+    assert.NotNil(err)
+}
+
+
+func TestReadDeadline(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    fake := newFakeSqs()
+    conn := controlplane.NewConn(fake, "0123456789abcdef", "recv", "send")
+
+    // Reads stop waiting once the deadline passes
+    assert.Equal(nil, conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond)))
+    _, err := conn.Read(make([]byte, 10))
+    assert.NotNil(err)
+
+    // Reads after closing the connection fail
+    assert.Equal(nil, conn.SetReadDeadline(time.Time{}))
+    assert.Equal(nil, conn.Close())
+    _, err = conn.Read(make([]byte, 10))
+    assert.NotNil(err)
+}
@@ -0,0 +1,323 @@
+package cost
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Approximate us-east-1 on-demand hourly prices in USD of the supported instance types,
+// the hourly_price config setting overrides these when more accurate pricing is known
+var hourlyPrices = map[string]float64{
+    // === G4dn ===
+    "g4dn.xlarge":   0.526,  "g4dn.2xlarge":  0.752,  "g4dn.4xlarge":  1.204,
+    "g4dn.8xlarge":  2.176,  "g4dn.12xlarge": 3.912,  "g4dn.16xlarge": 4.352,
+
+    // === G5d ===
+    "g5d.2xlarge":   1.212,  "g5d.4xlarge":   1.624,  "g5d.8xlarge":   2.448,
+    "g5d.12xlarge":  5.672,  "g5d.16xlarge":  4.096,  "g5d.24xlarge":  8.144,
+    "g5d.48xlarge":  16.288,
+
+    // === G5g ===
+    "g5g.xlarge":    0.420,  "g5g.2xlarge":   0.556,  "g5g.4xlarge":   0.828,
+    "g5g.8xlarge":   1.372,  "g5g.16xlarge":  2.744,  "g5g.metal":     2.744,
+
+    // === G6gd ===
+    "g6gd.xlarge":   0.805,  "g6gd.2xlarge":  0.978,  "g6gd.4xlarge":  1.323,
+    "g6gd.8xlarge":  2.014,  "g6gd.12xlarge": 4.602,  "g6gd.16xlarge": 3.397,
+    "g6gd.24xlarge": 6.675,  "g6gd.48xlarge": 13.350,
+
+    // === G6ed ===
+    "g6ed.xlarge":   1.861,  "g6ed.2xlarge":  2.242,  "g6ed.4xlarge":  3.004,
+    "g6ed.8xlarge":  4.529,  "g6ed.12xlarge": 10.493, "g6ed.16xlarge": 7.577,
+    "g6ed.24xlarge": 15.066, "g6ed.48xlarge": 30.131,
+
+    // === P4 families ===
+    "p4d.24xlarge":  32.773, "p4de.24xlarge": 40.966,
+
+    // === P5 families ===
+    "p5.48xlarge":   55.040, "p5e.48xlarge":  60.544,
+
+    // === P6-B200 ===
+    "p6-b200.48xlarge": 113.933,
+}
+
+
+// Watchdog tracks the runtime and accumulated spend of the fleet against the max
+// cost and max runtime thresholds
+type Watchdog struct {
+    count       int
+    maxCost     float64
+    maxRuntime  time.Duration
+    mutx        sync.Mutex
+    paused      time.Time      // Time the fleet was hibernated, zero while running
+    pausedFor   time.Duration  // Time spent hibernated before the current pause
+    rate        float64
+    start       time.Time
+    unbilled    float64
+    warned      bool     // Whether the warning was sent for the current max cost
+    warnPercent float64  // Percent of the max cost where a warning is sent, 0 disables
+}
+
+
+// Gets the hourly price of a single instance of the passed in type, preferring the
+// override when it is set.
+//
+// @Parameters
+// - instanceType:  The EC2 instance type to get the price of
+// - override:  The configured hourly price, 0 uses the built in estimate
+//
+// @Returns
+// - The hourly price in USD of a single instance
+// - Error if it occurs, otherwise nil on success
+//
+func HourlyRate(instanceType string, override float64) (float64, error) {
+    // If the hourly price was set in the config
+    if override > 0 {
+        return override, nil
+    }
+
+    rate, ok := hourlyPrices[instanceType]
+    // If there is no built in estimate for the instance type
+    if !ok {
+        return 0, fmt.Errorf("no price estimate for instance type %s, set hourly_price",
+                             instanceType)
+    }
+
+    return rate, nil
+}
+
+
+// Computes the projected spend of the fleet over the passed in runtime.
+//
+// @Parameters
+// - rate:  The hourly price in USD of a single instance
+// - count:  The number of instances in the fleet
+// - runtime:  The runtime of the fleet
+//
+// @Returns
+// - The projected spend in USD
+//
+func Estimate(rate float64, count int, runtime time.Duration) float64 {
+    return rate * float64(count) * runtime.Hours()
+}
+
+
+// Creates a watchdog for a fleet launched at the passed in start time.
+//
+// @Parameters
+// - rate:  The hourly price in USD of a single instance
+// - count:  The number of instances in the fleet
+// - maxCost:  The spend in USD where the fleet is terminated, 0 disables
+// - warnPercent:  The percent of the max cost where a warning is sent, 0 disables
+// - maxRuntime:  The runtime where the fleet is terminated, 0 disables
+// - start:  The time the fleet was launched
+//
+// @Returns
+// - The initialized watchdog
+//
+func NewWatchdog(rate float64, count int, maxCost float64, warnPercent float64,
+                 maxRuntime time.Duration, start time.Time) *Watchdog {
+    return &Watchdog{count: count, maxCost: maxCost, maxRuntime: maxRuntime,
+                     rate: rate, start: start, warnPercent: warnPercent}
+}
+
+// Computes the accumulated spend of the fleet at the passed in time.
+//
+// @Parameters
+// - now:  The time to compute the spend at
+//
+// @Returns
+// - The accumulated spend in USD
+//
+func (watchdog *Watchdog) Spent(now time.Time) float64 {
+    watchdog.mutx.Lock()
+    defer watchdog.mutx.Unlock()
+
+    // Instances added mid-run are not billed for the time before they were launched
+    return Estimate(watchdog.rate, watchdog.count, now.Sub(watchdog.start)) -
+           watchdog.rate * watchdog.unbilled
+}
+
+// Checks whether the fleet has exceeded the max cost or max runtime thresholds.
+//
+// @Parameters
+// - now:  The time to check the thresholds at
+//
+// @Returns
+// - Whether a threshold was exceeded
+// - The reason the threshold was exceeded, empty if not
+//
+func (watchdog *Watchdog) Check(now time.Time) (bool, string) {
+    runtime := watchdog.Runtime(now)
+
+    // If the fleet has run longer than allowed
+    if watchdog.maxRuntime > 0 && runtime >= watchdog.maxRuntime {
+        return true, fmt.Sprintf("runtime %s exceeded max_runtime %s",
+                                 runtime.Round(time.Second), watchdog.maxRuntime)
+    }
+
+    spent := watchdog.Spent(now)
+    maxCost := watchdog.MaxCost()
+    // If the fleet has spent more than allowed
+    if maxCost > 0 && spent >= maxCost {
+        return true, fmt.Sprintf("spend $%.2f exceeded max_cost $%.2f", spent, maxCost)
+    }
+
+    return false, ""
+}
+
+// Computes the runtime of the fleet at the passed in time, leaving out the time it spent
+// hibernated.
+//
+// @Parameters
+// - now:  The time to compute the runtime at
+//
+// @Returns
+// - The runtime of the fleet
+//
+func (watchdog *Watchdog) Runtime(now time.Time) time.Duration {
+    watchdog.mutx.Lock()
+    defer watchdog.mutx.Unlock()
+
+    runtime := now.Sub(watchdog.start) - watchdog.pausedFor
+    // If the fleet is hibernated, the runtime stopped when it was
+    if !watchdog.paused.IsZero() {
+        runtime -= now.Sub(watchdog.paused)
+    }
+
+    return runtime
+}
+
+// Stops counting the runtime of the fleet while it is hibernated.
+//
+// @Parameters
+// - now:  The time the fleet was hibernated
+//
+func (watchdog *Watchdog) Pause(now time.Time) {
+    watchdog.mutx.Lock()
+    defer watchdog.mutx.Unlock()
+
+    // If the fleet is not already hibernated
+    if watchdog.paused.IsZero() {
+        watchdog.paused = now
+    }
+}
+
+// Counts the runtime of a hibernated fleet again once it is resumed.
+//
+// @Parameters
+// - now:  The time the fleet was resumed
+//
+func (watchdog *Watchdog) Resume(now time.Time) {
+    watchdog.mutx.Lock()
+    defer watchdog.mutx.Unlock()
+
+    // If the fleet is hibernated
+    if !watchdog.paused.IsZero() {
+        watchdog.pausedFor += now.Sub(watchdog.paused)
+        watchdog.paused = time.Time{}
+    }
+}
+
+// Checks whether the spend of the fleet has reached the warning percent of the max cost,
+// reporting it once per max cost so raising the budget warns again before the new cutoff.
+//
+// @Parameters
+// - now:  The time to check the spend at
+//
+// @Returns
+// - Whether the warning threshold was newly reached
+// - The reason the warning was sent, empty if not
+//
+func (watchdog *Watchdog) Warn(now time.Time) (bool, string) {
+    spent := watchdog.Spent(now)
+
+    watchdog.mutx.Lock()
+    defer watchdog.mutx.Unlock()
+
+    // If warnings are disabled or the warning was already sent
+    if watchdog.warnPercent <= 0 || watchdog.maxCost <= 0 || watchdog.warned {
+        return false, ""
+    }
+
+    // If the spend is still below the warning threshold
+    if spent < watchdog.maxCost * watchdog.warnPercent / 100 {
+        return false, ""
+    }
+
+    watchdog.warned = true
+    return true, fmt.Sprintf("spend $%.2f reached %g%% of max_cost $%.2f", spent,
+                             watchdog.warnPercent, watchdog.maxCost)
+}
+
+// Gets the current spend where the fleet is terminated.
+//
+// @Returns
+// - The max cost in USD, 0 if disabled
+//
+func (watchdog *Watchdog) MaxCost() float64 {
+    watchdog.mutx.Lock()
+    defer watchdog.mutx.Unlock()
+
+    return watchdog.maxCost
+}
+
+// Raises the max cost of a running fleet so it can keep going past the original budget.
+//
+// @Parameters
+// - amount:  The amount in USD added to the max cost
+//
+// @Returns
+// - The new max cost in USD
+// - Error if the amount is not positive or the max cost is disabled
+//
+func (watchdog *Watchdog) AddBudget(amount float64) (float64, error) {
+    // If the amount would not raise the budget
+    if amount <= 0 {
+        return 0, fmt.Errorf("budget amount must be greater than 0")
+    }
+
+    watchdog.mutx.Lock()
+    defer watchdog.mutx.Unlock()
+
+    // If there is no max cost to raise
+    if watchdog.maxCost <= 0 {
+        return 0, fmt.Errorf("max_cost is not set for the run")
+    }
+
+    watchdog.maxCost += amount
+    // Warn again as the spend approaches the raised max cost
+    watchdog.warned = false
+    return watchdog.maxCost, nil
+}
+
+// Adds instances launched mid-run to the fleet, accruing their spend from the passed in
+// launch time rather than the start of the fleet.
+//
+// @Parameters
+// - count:  The number of instances added
+// - now:  The time the instances were launched
+//
+func (watchdog *Watchdog) AddInstances(count int, now time.Time) {
+    watchdog.mutx.Lock()
+    defer watchdog.mutx.Unlock()
+
+    watchdog.count += count
+    watchdog.unbilled += float64(count) * now.Sub(watchdog.start).Hours()
+}
+
+// Removes instances terminated mid-run from the fleet, keeping what they spent until the
+// passed in termination time.
+//
+// @Parameters
+// - count:  The number of instances removed
+// - now:  The time the instances were terminated
+//
+func (watchdog *Watchdog) RemoveInstances(count int, now time.Time) {
+    watchdog.mutx.Lock()
+    defer watchdog.mutx.Unlock()
+
+    watchdog.count -= count
+    watchdog.unbilled -= float64(count) * now.Sub(watchdog.start).Hours()
+}
@@ -0,0 +1,142 @@
+package cost_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/cost"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestHourlyRate(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Ensure the built in estimate is used without an override
+    rate, err := cost.HourlyRate("p4d.24xlarge", 0)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(32.773, rate)
+
+    // Ensure the override is preferred when set
+    rate, err = cost.HourlyRate("p4d.24xlarge", 20.5)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(20.5, rate)
+
+    // Ensure an unknown instance type without an override is rejected
+    _, err = cost.HourlyRate("t2.micro", 0)
+    assert.NotEqual(nil, err)
+}
+
+
+func TestEstimate(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Ensure the estimate scales with the rate, count, and runtime
+    assert.InDelta(30.0, cost.Estimate(5.0, 3, 2 * time.Hour), 0.0001)
+    assert.InDelta(1.25, cost.Estimate(0.5, 5, 30 * time.Minute), 0.0001)
+}
+
+
+func TestWatchdog(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    start := time.Now()
+    watchdog := cost.NewWatchdog(10.0, 2, 50.0, 80.0, 4 * time.Hour, start)
+
+    // Ensure nothing is exceeded after an hour at $20
+    exceeded, reason := watchdog.Check(start.Add(time.Hour))
+    assert.False(exceeded)
+    assert.Equal("", reason)
+    assert.InDelta(20.0, watchdog.Spent(start.Add(time.Hour)), 0.0001)
+    warned, _ := watchdog.Warn(start.Add(time.Hour))
+    assert.False(warned)
+
+    // Ensure the warning is sent once the spend reaches 80 percent of the max cost
+    warned, reason = watchdog.Warn(start.Add(2 * time.Hour))
+    assert.True(warned)
+    assert.Contains(reason, "80% of max_cost")
+    exceeded, _ = watchdog.Check(start.Add(2 * time.Hour))
+    assert.False(exceeded)
+    // Ensure the warning is only sent once
+    warned, _ = watchdog.Warn(start.Add(2 * time.Hour + time.Minute))
+    assert.False(warned)
+
+    // Ensure the max cost is exceeded after three hours at $60
+    exceeded, reason = watchdog.Check(start.Add(3 * time.Hour))
+    assert.True(exceeded)
+    assert.Contains(reason, "max_cost")
+
+    // Ensure raising the budget lets the fleet keep running
+    maxCost, err := watchdog.AddBudget(25.0)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.InDelta(75.0, maxCost, 0.0001)
+    exceeded, _ = watchdog.Check(start.Add(3 * time.Hour))
+    assert.False(exceeded)
+    // Ensure the warning is sent again for the raised max cost
+    warned, _ = watchdog.Warn(start.Add(3 * time.Hour))
+    assert.True(warned)
+    _, err = watchdog.AddBudget(-5.0)
+    assert.NotEqual(nil, err)
+
+    // Ensure instances added after three hours accrue spend from when they launched
+    watchdog.AddInstances(2, start.Add(3 * time.Hour))
+    assert.InDelta(60.0, watchdog.Spent(start.Add(3 * time.Hour)), 0.0001)
+    assert.InDelta(100.0, watchdog.Spent(start.Add(4 * time.Hour)), 0.0001)
+
+    // Ensure an instance terminated after four hours stops accruing spend
+    watchdog.RemoveInstances(1, start.Add(4 * time.Hour))
+    assert.InDelta(130.0, watchdog.Spent(start.Add(5 * time.Hour)), 0.0001)
+
+    // Ensure the max runtime is exceeded when the cost is disabled
+    watchdog = cost.NewWatchdog(10.0, 2, 0, 80.0, 4 * time.Hour, start)
+    exceeded, reason = watchdog.Check(start.Add(5 * time.Hour))
+    assert.True(exceeded)
+    assert.Contains(reason, "max_runtime")
+    // Ensure no warning is sent without a max cost
+    warned, _ = watchdog.Warn(start.Add(5 * time.Hour))
+    assert.False(warned)
+
+    // Ensure a budget can not be added when the max cost is disabled
+    _, err = watchdog.AddBudget(25.0)
+    assert.NotEqual(nil, err)
+}
+
+
+func TestWatchdogHibernation(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    start := time.Now()
+    watchdog := cost.NewWatchdog(10.0, 2, 0, 0, 4 * time.Hour, start)
+
+    // Hibernate the fleet overnight after two hours of runtime
+    watchdog.Pause(start.Add(2 * time.Hour))
+    // Ensure a repeated pause keeps the original hibernation time
+    watchdog.Pause(start.Add(3 * time.Hour))
+
+    // Ensure the runtime stops counting while hibernated
+    assert.Equal(2 * time.Hour, watchdog.Runtime(start.Add(14 * time.Hour)))
+    exceeded, _ := watchdog.Check(start.Add(14 * time.Hour))
+    assert.False(exceeded)
+
+    // Ensure the runtime counts again from when the fleet resumed
+    watchdog.Resume(start.Add(14 * time.Hour))
+    assert.Equal(3 * time.Hour, watchdog.Runtime(start.Add(15 * time.Hour)))
+    exceeded, _ = watchdog.Check(start.Add(15 * time.Hour))
+    assert.False(exceeded)
+
+    // Ensure the max runtime is exceeded once the runtime outside hibernation reaches it
+    exceeded, reason := watchdog.Check(start.Add(16 * time.Hour))
+    assert.True(exceeded)
+    assert.Contains(reason, "runtime 4h0m0s exceeded max_runtime")
+
+    // Ensure resuming a fleet that is not hibernated changes nothing
+    watchdog.Resume(start.Add(17 * time.Hour))
+    assert.Equal(5 * time.Hour, watchdog.Runtime(start.Add(17 * time.Hour)))
+}
@@ -2,11 +2,14 @@ package data
 
 import (
 	"bytes"
+	cryptorand "crypto/rand"
 	"encoding/csv"
+	"encoding/hex"
 	"fmt"
 	"math/rand"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -91,6 +94,27 @@ func ParseFileSizeType(unitFileSize string) (float64, string, error) {
 }
 
 
+// Generates a cryptographically random hex string, used for unique resource names.
+//
+// @Parameters
+// - numberBytes:  The number of random bytes, the string is twice as long
+//
+// @Returns
+// - The random hex string
+// - Error if it occurs, otherwise nil on success
+//
+func RandHex(numberBytes int) (string, error) {
+    byteSlice := make([]byte, numberBytes)
+
+    _, err := cryptorand.Read(byteSlice)
+    if err != nil {
+        return "", err
+    }
+
+    return hex.EncodeToString(byteSlice), nil
+}
+
+
 // Creates buffer and populates it with random bytes and returns as string.
 //
 // @Parameters
@@ -218,14 +242,142 @@ func ToBytes(size float64, unit string) int64 {
 }
 
 
-// TransferManager tracks the size of all ongoing transfers.
+// TransferStats aggregates the transfers to or from a single peer.
+type TransferStats struct {
+    Bytes     int64
+    Duration  time.Duration
+    Failures  int
+    LastRate  float64
+    Retries   int
+    Transfers int
+}
+
+// Throughput returns the average rate of the completed transfers in bytes per second.
+func (stats TransferStats) Throughput() float64 {
+    // If no transfer time has been recorded yet
+    if stats.Duration <= 0 {
+        return 0
+    }
+
+    return float64(stats.Bytes) / stats.Duration.Seconds()
+}
+
+
+// TransferManager tracks the size of all ongoing transfers and the statistics
+// of completed transfers per peer.
 type TransferManager struct {
     OngoingTransfersSize int64
+    mutx                 sync.Mutex
+    stats                map[string]*TransferStats
 }
 
 // NewTransferManager initializes and returns a new TransferManager instance.
 func NewTransferManager() *TransferManager {
-    return &TransferManager{}
+    return &TransferManager{stats: make(map[string]*TransferStats)}
+}
+
+// peerStats returns the stats of the peer, creating them if they do not exist yet,
+// the mutex must be held by the caller.
+func (tm *TransferManager) peerStats(peer string) *TransferStats {
+    stats, ok := tm.stats[peer]
+    // If the peer has no recorded transfers yet
+    if !ok {
+        stats = &TransferStats{}
+        tm.stats[peer] = stats
+    }
+
+    return stats
+}
+
+// RecordTransfer records a completed transfer of the size and duration for the peer.
+func (tm *TransferManager) RecordTransfer(peer string, size int64, duration time.Duration) {
+    tm.mutx.Lock()
+    defer tm.mutx.Unlock()
+
+    stats := tm.peerStats(peer)
+    stats.Bytes += size
+    stats.Duration += duration
+    stats.Transfers += 1
+
+    // If the duration is measurable, save the rate of the latest transfer
+    if duration > 0 {
+        stats.LastRate = float64(size) / duration.Seconds()
+    }
+}
+
+// RecordFailure records a failed transfer for the peer.
+func (tm *TransferManager) RecordFailure(peer string) {
+    tm.mutx.Lock()
+    defer tm.mutx.Unlock()
+
+    tm.peerStats(peer).Failures += 1
+}
+
+// RecordRetry records a transfer for the peer that is being retried.
+func (tm *TransferManager) RecordRetry(peer string) {
+    tm.mutx.Lock()
+    defer tm.mutx.Unlock()
+
+    tm.peerStats(peer).Retries += 1
+}
+
+// Snapshot returns a copy of the transfer statistics of each peer.
+func (tm *TransferManager) Snapshot() map[string]TransferStats {
+    tm.mutx.Lock()
+    defer tm.mutx.Unlock()
+
+    snapshot := make(map[string]TransferStats, len(tm.stats))
+    // Iterate through the peers copying their stats
+    for peer, stats := range tm.stats {
+        snapshot[peer] = *stats
+    }
+
+    return snapshot
+}
+
+// Aggregate returns the transfer statistics totaled across every peer.
+func (tm *TransferManager) Aggregate() TransferStats {
+    var total TransferStats
+
+    // Iterate through the peer stats adding them to the total
+    for _, stats := range tm.Snapshot() {
+        total.Bytes += stats.Bytes
+        total.Duration += stats.Duration
+        total.Failures += stats.Failures
+        total.Retries += stats.Retries
+        total.Transfers += stats.Transfers
+    }
+
+    return total
+}
+
+// IsSlow reports whether the peer throughput is below the ratio of the average peer
+// throughput, peers without enough completed transfers are never considered slow.
+func (tm *TransferManager) IsSlow(peer string, ratio float64, minTransfers int) bool {
+    var peerRate float64
+    var rateSum float64
+    var ratedPeers int
+
+    // Iterate through the peers with enough transfers to be rated
+    for name, stats := range tm.Snapshot() {
+        if stats.Transfers < minTransfers {
+            continue
+        }
+
+        rateSum += stats.Throughput()
+        ratedPeers += 1
+
+        if name == peer {
+            peerRate = stats.Throughput()
+        }
+    }
+
+    // If the peer is not rated or there are no other peers to compare against
+    if peerRate == 0 || ratedPeers < 2 {
+        return false
+    }
+
+    return peerRate < ratio * (rateSum / float64(ratedPeers))
 }
 
 // AddTransferSize adds the specified size to the ongoing transfers.
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/ngimb64/Kloud-Kraken/internal/globals"
 	"github.com/ngimb64/Kloud-Kraken/pkg/data"
@@ -109,6 +110,18 @@ func TestParseFileSizeType(t *testing.T) {
 }
 
 
+func TestRandHex(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    randHex, err := data.RandHex(4)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure 4 random bytes are returned as 8 hex characters
+    assert.Regexp("^[0-9a-f]{8}$", randHex)
+}
+
+
 func TestRandStringBytes(t *testing.T) {
     stringLen := 12
     // Ensure a dozen random bytes are returned as a string
@@ -206,6 +219,42 @@ func TestTransferManager(t *testing.T) {
 }
 
 
+func TestTransferManagerStats(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    // Create and initialize new transfer manager
+    tMan := data.NewTransferManager()
+
+    // Record transfers for a fast and a slow peer
+    tMan.RecordTransfer("10.0.0.1:5000", 400 * globals.MB, 4 * time.Second)
+    tMan.RecordTransfer("10.0.0.1:5000", 400 * globals.MB, 4 * time.Second)
+    tMan.RecordTransfer("10.0.0.2:5000", 100 * globals.MB, 10 * time.Second)
+    tMan.RecordTransfer("10.0.0.2:5000", 100 * globals.MB, 10 * time.Second)
+    tMan.RecordFailure("10.0.0.2:5000")
+    tMan.RecordRetry("10.0.0.2:5000")
+
+    snapshot := tMan.Snapshot()
+    // Ensure the stats of each peer are tracked separately
+    assert.Equal(2, snapshot["10.0.0.1:5000"].Transfers)
+    assert.Equal(float64(100 * globals.MB), snapshot["10.0.0.1:5000"].Throughput())
+    assert.Equal(float64(10 * globals.MB), snapshot["10.0.0.2:5000"].LastRate)
+    assert.Equal(1, snapshot["10.0.0.2:5000"].Failures)
+    assert.Equal(1, snapshot["10.0.0.2:5000"].Retries)
+
+    // Ensure the aggregate totals every peer
+    total := tMan.Aggregate()
+    assert.Equal(4, total.Transfers)
+    assert.Equal(int64(1000 * globals.MB), total.Bytes)
+    assert.Equal(28 * time.Second, total.Duration)
+
+    // Ensure only the peer well below the average rate is slow
+    assert.True(tMan.IsSlow("10.0.0.2:5000", 0.5, 2))
+    assert.False(tMan.IsSlow("10.0.0.1:5000", 0.5, 2))
+    // Ensure peers without enough transfers are not rated
+    assert.False(tMan.IsSlow("10.0.0.2:5000", 0.5, 3))
+}
+
+
 func TestTrimAfterLast(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -0,0 +1,97 @@
+package disk
+
+import (
+	"slices"
+	"sync"
+)
+
+// ClaimRegistry tracks the client each file in the load dir is claimed by, ensuring
+// a file is only ever handed to a single client at a time
+type ClaimRegistry struct {
+    claims map[string]string
+    mutx   sync.Mutex
+}
+
+// Creates a new empty claim registry.
+//
+// @Returns
+// - The initialized claim registry
+//
+func NewClaimRegistry() *ClaimRegistry {
+    return &ClaimRegistry{claims: make(map[string]string)}
+}
+
+// Atomically claims the file for the client if no other client holds a claim on it.
+//
+// @Parameters
+// - filePath:  The path of the file to claim
+// - client:  The address of the client claiming the file
+//
+// @Returns
+// - true if the claim was made, false if the file is already claimed
+//
+func (registry *ClaimRegistry) Claim(filePath string, client string) bool {
+    registry.mutx.Lock()
+    defer registry.mutx.Unlock()
+
+    // If the file is already claimed by a client
+    if _, claimed := registry.claims[filePath]; claimed {
+        return false
+    }
+
+    registry.claims[filePath] = client
+    return true
+}
+
+// Releases the claim on the file so it can be selected again.
+//
+// @Parameters
+// - filePath:  The path of the file to release
+//
+func (registry *ClaimRegistry) Release(filePath string) {
+    registry.mutx.Lock()
+    defer registry.mutx.Unlock()
+
+    delete(registry.claims, filePath)
+}
+
+// Gets the client holding the claim on the file.
+//
+// @Parameters
+// - filePath:  The path of the file to look up
+//
+// @Returns
+// - The address of the client holding the claim
+// - true if the file is claimed, otherwise false
+//
+func (registry *ClaimRegistry) Owner(filePath string) (string, bool) {
+    registry.mutx.Lock()
+    defer registry.mutx.Unlock()
+
+    client, claimed := registry.claims[filePath]
+    return client, claimed
+}
+
+// Gets the files claimed by the client in sorted order.
+//
+// @Parameters
+// - client:  The address of the client to look up
+//
+// @Returns
+// - The paths of the files claimed by the client
+//
+func (registry *ClaimRegistry) ClaimedBy(client string) []string {
+    registry.mutx.Lock()
+    defer registry.mutx.Unlock()
+
+    var filePaths []string
+    // Iterate through the claims collecting those held by the client
+    for filePath, owner := range registry.claims {
+        if owner == client {
+            filePaths = append(filePaths, filePath)
+        }
+    }
+
+    slices.Sort(filePaths)
+    return filePaths
+}
@@ -0,0 +1,123 @@
+package disk_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestClaimRegistry(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    registry := disk.NewClaimRegistry()
+
+    // Ensure the first claim succeeds and a second client is rejected
+    assert.True(registry.Claim("/load/words1.txt", "10.0.0.1:5000"))
+    assert.False(registry.Claim("/load/words1.txt", "10.0.0.2:5000"))
+    assert.True(registry.Claim("/load/words2.txt", "10.0.0.1:5000"))
+
+    owner, claimed := registry.Owner("/load/words1.txt")
+    // Ensure the claim belongs to the first client
+    assert.True(claimed)
+    assert.Equal("10.0.0.1:5000", owner)
+    assert.Equal([]string{"/load/words1.txt", "/load/words2.txt"},
+                 registry.ClaimedBy("10.0.0.1:5000"))
+
+    // Ensure a released file can be claimed by another client
+    registry.Release("/load/words1.txt")
+    _, claimed = registry.Owner("/load/words1.txt")
+    assert.False(claimed)
+    assert.True(registry.Claim("/load/words1.txt", "10.0.0.2:5000"))
+}
+
+
+func TestClaimRegistryConcurrent(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    registry := disk.NewClaimRegistry()
+
+    var successes sync.Map
+    var waitGroup sync.WaitGroup
+
+    // Race many clients to claim the same file
+    for index := 0; index < 64; index++ {
+        waitGroup.Add(1)
+
+        go func(client string) {
+            defer waitGroup.Done()
+
+            if registry.Claim("/load/words.txt", client) {
+                successes.Store(client, true)
+            }
+        } (fmt.Sprintf("10.0.0.%d:5000", index))
+    }
+
+    waitGroup.Wait()
+
+    count := 0
+    successes.Range(func(_, _ any) bool {
+        count += 1
+        return true
+    })
+    // Ensure exactly one client won the claim
+    assert.Equal(1, count)
+}
+
+
+func TestSelectFileConcurrent(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    loadDir := t.TempDir()
+
+    // Write a file too large to be selected first, followed by selectable files
+    err := os.WriteFile(filepath.Join(loadDir, "a_large.txt"), make([]byte, 4096), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    for index := 0; index < 8; index++ {
+        err = os.WriteFile(filepath.Join(loadDir, fmt.Sprintf("words%d.txt", index)),
+                           []byte("password\n"), 0644)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+    }
+
+    var selected sync.Map
+    var waitGroup sync.WaitGroup
+
+    // Race more selections than there are files
+    for index := 0; index < 32; index++ {
+        waitGroup.Add(1)
+
+        go func(client string) {
+            defer waitGroup.Done()
+
+            filePath, _, err := disk.SelectFile(loadDir, 1024, client, nil)
+            assert.Equal(nil, err)
+
+            // If a file was selected, ensure no other selection returned it
+            if filePath != "" {
+                _, loaded := selected.LoadOrStore(filePath, client)
+                assert.False(loaded, "%s selected more than once", filePath)
+            }
+        } (fmt.Sprintf("10.0.1.%d:5000", index))
+    }
+
+    waitGroup.Wait()
+
+    count := 0
+    selected.Range(func(key, value any) bool {
+        count += 1
+        owner, _ := disk.Claims.Owner(key.(string))
+        // Ensure the claim belongs to the client the file was returned to
+        assert.Equal(value, owner)
+        return true
+    })
+    // Ensure every selectable file was handed out exactly once
+    assert.Equal(8, count)
+}
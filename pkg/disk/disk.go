@@ -1,19 +1,26 @@
 package disk
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"os"
-	"sync"
+	"path/filepath"
+	"strings"
 
 	"github.com/ngimb64/Kloud-Kraken/pkg/data"
-	"golang.org/x/sys/unix"
 )
 
+// Suffix of files still being received, renamed away once the file is complete
+const PartSuffix = ".part"
+
 // Package level variables
-var SelectedFiles sync.Map		  // Global map to track selected files
-var FileSelectionLock sync.Mutex  // Mutex for synchronizing the file selection
+var Claims = NewClaimRegistry()  // Claims of the files selected for transfer by client
+var Ready *ReadySet              // Files selectable while the load dir is merged, nil if all are
+var Shards *ShardCoverage        // Hash file shards each file ran against, nil if not split
 
 
 // AppendFile appends the contents of srcFile to destFile if the source file has data.
@@ -69,8 +76,8 @@ func AppendFile(sourceFilePath string, destFilePath string) error {
 }
 
 
-// Reads the passed in path (dir) and attempts to get the first file,
-// returning its name and size.
+// Reads the passed in path (dir) and attempts to get the first file, returning its name
+// and size. Part files still being received are skipped.
 //
 // @Parameters
 // - path:  The path to the directory to attempt to read a file
@@ -81,6 +88,24 @@ func AppendFile(sourceFilePath string, destFilePath string) error {
 // - Error if it occurs, otherwise nil on success
 //
 func CheckDirFiles(path string) (string, int64, error) {
+    return CheckUnclaimedFiles(path, nil)
+}
+
+
+// Reads the passed in path (dir) and attempts to get the first file that is not
+// claimed in the registry, returning its name and size. Part files still being
+// received are skipped.
+//
+// @Parameters
+// - path:  The path to the directory to attempt to read a file
+// - claims:  The registry of files already claimed, nil if none are skipped
+//
+// @Returns
+// - The name of the retrieved file
+// - The size of the retrieved file
+// - Error if it occurs, otherwise nil on success
+//
+func CheckUnclaimedFiles(path string, claims *ClaimRegistry) (string, int64, error) {
     var fileName string
     var fileSize int64
 
@@ -92,11 +117,18 @@ func CheckDirFiles(path string) (string, int64, error) {
 
     // Loop over the directory contents
     for _, item := range items {
-        // If the current item is a directory
-        if item.IsDir() {
+        // If the current item is a directory or a file still being received
+        if item.IsDir() || strings.HasSuffix(item.Name(), PartSuffix) {
             continue
         }
 
+        // If the file is already claimed
+        if claims != nil {
+            if _, claimed := claims.Owner(filepath.Join(path, item.Name())); claimed {
+                continue
+            }
+        }
+
         // Get the file name and size
         info, err := item.Info()
         if err != nil {
@@ -118,6 +150,97 @@ func CheckDirFiles(path string) (string, int64, error) {
 }
 
 
+// Copies the contents of the source file to the destination file, truncating
+// the destination if it already exists.
+//
+// @Parameters
+// - sourceFilePath:  The source file whose data will be copied
+// - destFilePath:  The destination file where the source files data will be copied
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func CopyFile(sourceFilePath string, destFilePath string) error {
+    // Open the source file for reading
+    sourceFile, err := os.Open(sourceFilePath)
+    if err != nil {
+        return fmt.Errorf("error opening source file - %w", err)
+    }
+    // Close source file on local exit
+    defer sourceFile.Close()
+
+    // Create the destination file, truncating any previous data
+    destFile, err := os.Create(destFilePath)
+    if err != nil {
+        return fmt.Errorf("error creating destination file - %w", err)
+    }
+
+    // Copy the contents of the source file to the destination file
+    _, err = io.Copy(destFile, sourceFile)
+    if err != nil {
+        destFile.Close()
+        return fmt.Errorf("error copying data - %w", err)
+    }
+
+    // Close the destination file ensuring the data was written
+    err = destFile.Close()
+    if err != nil {
+        return fmt.Errorf("error closing destination file - %w", err)
+    }
+
+    return nil
+}
+
+
+// Counts the number of lines in the passed in file, including a
+// final line that does not end with a newline.
+//
+// @Parameters
+// - filePath:  The path to the file to count the lines of
+//
+// @Returns
+// - The number of lines in the file
+// - Error if it occurs, otherwise nil on success
+//
+func CountLines(filePath string) (int64, error) {
+    var lineCount int64
+    var lastByte byte = '\n'
+
+    // Open the file for reading
+    file, err := os.Open(filePath)
+    if err != nil {
+        return -1, err
+    }
+    // Close file on local exit
+    defer file.Close()
+
+    buffer := make([]byte, 64 * 1024)
+
+    for {
+        // Read the next chunk of the file
+        bytesRead, err := file.Read(buffer)
+        if bytesRead > 0 {
+            // Count the newlines in the read chunk
+            lineCount += int64(bytes.Count(buffer[:bytesRead], []byte("\n")))
+            lastByte = buffer[bytesRead-1]
+        }
+
+        if err == io.EOF {
+            break
+        } else if err != nil {
+            return -1, err
+        }
+    }
+
+    // If the last line does not end with a newline
+    if lastByte != '\n' {
+        lineCount += 1
+    }
+
+    return lineCount, nil
+}
+
+
 // Creates a random text file based on length of name and extension.
 // Provides boolean toggle to specify whether file handle should stay open and
 // returned or be closed and not be returned.
@@ -178,35 +301,66 @@ func CreateRandFile(dirPath string, nameLen int, baseName string, extension stri
 }
 
 
-// Gets the total and available space on the root disk.
+// Gets the size of the files in a dir and its sub dirs.
 //
 // @Parameters
-// - path:  path to location on disk where size will be queried
-// - reservedSpace:  The amount of space reserved for the OS
+// - dirPath:  The path to the dir to be sized
 //
 // @Returns
-// - The total space on disk
-// - the available free space
+// - The total size of the files in the dir
 // - Error if it occurs, otherwise nil on success
 //
-func GetDiskSpace(path string, reservedSpace int) (
-                  total int64, free int64, err error) {
-    var statfs unix.Statfs_t
+func DirSize(dirPath string) (int64, error) {
+    var size int64
+
+    err := filepath.WalkDir(dirPath, func(path string, entry os.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
 
-    // Get the stats of the passed in path
-    err = unix.Statfs(path, &statfs)
+        // If the entry is a dir, its files are sized as they are walked
+        if entry.IsDir() {
+            return nil
+        }
+
+        info, err := entry.Info()
+        if err != nil {
+            return err
+        }
+
+        size += info.Size()
+        return nil
+    })
     if err != nil {
-        return -1, -1, err
+        return -1, fmt.Errorf("error sizing dir %s - %w", dirPath, err)
     }
 
-    // Total space is (blocks * block size)
-    total = int64(statfs.Blocks) * statfs.Bsize
-    // Free space is (free blocks * block size)
-    free = int64(statfs.Bfree) * statfs.Bsize
-    // Subtract the reserved  OS space from from available
-    remaining := free - int64(reservedSpace)
+    return size, nil
+}
+
 
-    return remaining, total, nil
+// Reserve is the space kept free on a disk for the OS, either a fixed number of bytes or
+// a percentage of the disk total so it scales with the size of the instance store
+type Reserve struct {
+    Bytes   int64
+    Percent float64
+}
+
+// Computes the reserved space of a disk from its total size.
+//
+// @Parameters
+// - total:  The total space on the disk
+//
+// @Returns
+// - The number of bytes reserved on the disk
+//
+func (reserve Reserve) Amount(total int64) int64 {
+    // If the reserve is a percentage of the disk
+    if reserve.Percent > 0 {
+        return int64(float64(total) * reserve.Percent / 100)
+    }
+
+    return reserve.Bytes
 }
 
 
@@ -279,20 +433,110 @@ func PathExists(filePath string) (bool, bool, bool, error) {
 }
 
 
-// Function for each goroutine to walk the directory and select a unique file.
+// Gets the space remaining in a dir before its quota is reached.
+//
+// @Parameters
+// - dirPath:  The path to the dir the quota applies to
+// - quota:  The max size of the files in the dir, 0 or less is unlimited
+//
+// @Returns
+// - The space remaining under the quota, math.MaxInt64 if unlimited
+// - Error if it occurs, otherwise nil on success
+//
+func QuotaRemaining(dirPath string, quota int64) (int64, error) {
+    // If the dir has no quota
+    if quota <= 0 {
+        return math.MaxInt64, nil
+    }
+
+    used, err := DirSize(dirPath)
+    if err != nil {
+        return -1, err
+    }
+
+    return quota - used, nil
+}
+
+
+// Counts the files in the load dir that are still available to be selected, meaning
+// they are non-empty, within the max file size, and not claimed by a client.
+//
+// @Parameters
+// - loadDir:  The directory where wordlists are stored
+// - maxFileSizeInt64:  The max file size a file can be selected with
+//
+// @Returns
+// - The number of files remaining to be selected
+// - Error if it occurs, otherwise nil on success
+//
+func RemainingFiles(loadDir string, maxFileSizeInt64 int64) (int, error) {
+    var remaining int
+
+    // Read the contents of the directory
+    items, err := os.ReadDir(loadDir)
+    if err != nil {
+        return 0, err
+    }
+
+    // Iterate through the items in the load dir
+    for _, item := range items {
+        if item.IsDir() {
+            continue
+        }
+
+        itemPath := loadDir + "/" + item.Name()
+        // If the file has been claimed by a client
+        if _, claimed := Claims.Owner(itemPath); claimed {
+            continue
+        }
+
+        // Get the file statistics for the current file
+        itemInfo, err := item.Info()
+        if err != nil {
+            continue
+        }
+
+        // If the file is within the max file size and not empty
+        if itemInfo.Size() <= maxFileSizeInt64 && itemInfo.Size() > 0 {
+            remaining += 1
+        }
+    }
+
+    return remaining, nil
+}
+
+
+// Candidate is a file in the load dir, or a line range of one, that can be selected for
+// transfer
+type Candidate struct {
+    Dir  string  // Subdir of the load dir the file is in, empty at the top level
+    Name string
+    Path string
+    Size int64
+}
+
+// Ordering sorts the candidate files in place into the order they are selected
+type Ordering func(candidates []Candidate)
+
+
+// Function for each goroutine to walk the directory and select a unique file, the
+// file is claimed for the client so concurrent selections never return the same file.
+// While the directory is still being merged, only the files marked ready are selected.
 //
 // @Parameters
 // - loadDir:  The directory to attempt to select a file
 // - maxFileSizeInt64:  The max file size to ensure any violators are not selected
+// - client:  The address of the client the file is claimed for
+// - order:  Sorts the candidate files before selection, nil keeps the dir order
 //
 // @Returns
 // - Path of the selected file
 // - Size of the selected file
 // - Error if it occurs, otherwise nil on success
 //
-func SelectFile(loadDir string, maxFileSizeInt64 int64) (string, int64, error) {
-    var returnPath string
-    var returnSize int64
+func SelectFile(loadDir string, maxFileSizeInt64 int64, client string,
+                order Ordering) (string, int64, error) {
+    var candidates []Candidate
 
     // Read the contents of the directory
     items, err := os.ReadDir(loadDir)
@@ -306,11 +550,6 @@ func SelectFile(loadDir string, maxFileSizeInt64 int64) (string, int64, error) {
             continue
         }
 
-        // Lock selection process to ensure a single goroutine selects the file
-        FileSelectionLock.Lock()
-        // Unlock selection process on local exit
-        defer FileSelectionLock.Unlock()
-
         // Format the current file path
         itemPath := loadDir + "/" + item.Name()
 
@@ -326,18 +565,152 @@ func SelectFile(loadDir string, maxFileSizeInt64 int64) (string, int64, error) {
             continue
         }
 
-        // Check if the file has already been selected by another goroutine,
-        // otherwise store the file path in the sync map
-        _, loaded := SelectedFiles.LoadOrStore(itemPath, true)
-        // The file was already selected, so skip it
-        if loaded {
+        // If the file is already claimed by another goroutine, skip it
+        if _, claimed := Claims.Owner(itemPath); claimed {
             continue
         }
 
-        returnPath = itemPath
-        returnSize = itemInfo.Size()
-        break
+        // If the file is still being merged, skip it until it is ready
+        if !Ready.Allows(itemPath) {
+            continue
+        }
+
+        // If the file already ran against the hash file shard of the client, skip it
+        if !Shards.Needs(itemPath, client) {
+            continue
+        }
+
+        candidates = append(candidates, Candidate{Name: item.Name(), Path: itemPath,
+                                                  Size: itemInfo.Size()})
+    }
+
+    filePath, fileSize := claimFirst(candidates, client, order)
+    return filePath, fileSize, nil
+}
+
+
+// Sorts the candidates into selection order and claims the first one still available.
+//
+// @Parameters
+// - candidates:  The unclaimed candidates within the max file size, sorted in place
+// - client:  The address of the client the candidate is claimed for
+// - order:  Sorts the candidates before selection, nil keeps the passed in order
+//
+// @Returns
+// - Path of the claimed candidate, empty if none could be claimed
+// - Size of the claimed candidate
+//
+func claimFirst(candidates []Candidate, client string, order Ordering) (string, int64) {
+    // If an ordering is set, sort the candidates into selection order
+    if order != nil {
+        order(candidates)
+    }
+
+    // Iterate through the candidates claiming the first one still available
+    for _, candidate := range candidates {
+        // If the file was claimed by another goroutine since it was listed, skip it
+        if !Claims.Claim(candidate.Path, client) {
+            continue
+        }
+
+        return candidate.Path, candidate.Size
+    }
+
+    return "", 0
+}
+
+
+// Splits the passed in file into the specified number of shard files by distributing
+// its lines round-robin, so each shard ends up with a near equal number of lines.
+//
+// @Parameters
+// - filePath:  The path to the file to be split into shards
+// - outDir:  The directory where the shard files will be stored
+// - numShards:  The number of shard files to create
+//
+// @Returns
+// - Slice of the created shard file paths
+// - Error if it occurs, otherwise nil on success
+//
+func SplitFileLines(filePath string, outDir string, numShards int) ([]string, error) {
+    // If an invalid number of shards was passed in
+    if numShards < 1 {
+        return nil, fmt.Errorf("number of shards must be at least 1")
+    }
+
+    // Open the source file for reading
+    sourceFile, err := os.Open(filePath)
+    if err != nil {
+        return nil, fmt.Errorf("error opening source file - %w", err)
+    }
+    // Close source file on local exit
+    defer sourceFile.Close()
+
+    fileName := filepath.Base(filePath)
+    extension := filepath.Ext(fileName)
+    baseName := strings.TrimSuffix(fileName, extension)
+
+    shardPaths := make([]string, numShards)
+    shardFiles := make([]*os.File, numShards)
+    shardWriters := make([]*bufio.Writer, numShards)
+
+    // Close any opened shard files on local exit
+    defer func() {
+        for _, shardFile := range shardFiles {
+            if shardFile != nil {
+                shardFile.Close()
+            }
+        }
+    } ()
+
+    // Iterate through the number of shards and create their files
+    for index := range numShards {
+        shardPaths[index] = filepath.Join(outDir, fmt.Sprintf("%s_shard%d%s", baseName,
+                                                              index + 1, extension))
+
+        // Create the shard file, truncating any previous data
+        shardFiles[index], err = os.Create(shardPaths[index])
+        if err != nil {
+            return nil, fmt.Errorf("error creating shard file - %w", err)
+        }
+
+        shardWriters[index] = bufio.NewWriter(shardFiles[index])
+    }
+
+    var lineIndex int
+    scanner := bufio.NewScanner(sourceFile)
+    // Allow for long lines like salted hashes with large data blobs
+    scanner.Buffer(make([]byte, 64 * 1024), 1024 * 1024)
+
+    for scanner.Scan() {
+        line := scanner.Bytes()
+        // Skip empty lines
+        if len(bytes.TrimSpace(line)) == 0 {
+            continue
+        }
+
+        writer := shardWriters[lineIndex % numShards]
+        // Write the line to the current shard
+        _, err = writer.Write(append(line, '\n'))
+        if err != nil {
+            return nil, fmt.Errorf("error writing shard data - %w", err)
+        }
+
+        lineIndex += 1
+    }
+
+    // If an error occurred scanning the source file
+    if err = scanner.Err(); err != nil {
+        return nil, fmt.Errorf("error reading source file - %w", err)
+    }
+
+    // Flush any buffered data to the shard files
+    for _, writer := range shardWriters {
+        err = writer.Flush()
+        if err != nil {
+            return nil, fmt.Errorf("error flushing shard data - %w", err)
+        }
     }
 
-    return returnPath, returnSize, nil
+    return shardPaths, nil
 }
@@ -2,7 +2,10 @@ package disk_test
 
 import (
 	"fmt"
+	"math"
 	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/ngimb64/Kloud-Kraken/internal/globals"
@@ -81,6 +84,97 @@ func TestCheckDirFiles(t *testing.T) {
 }
 
 
+func TestCheckUnclaimedFiles(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    testPath := t.TempDir()
+    // Iterate through the test file names writing each, with a file still being received
+    for _, fileName := range []string{"a.txt", "a.txt" + disk.PartSuffix, "b.txt"} {
+        err := os.WriteFile(filepath.Join(testPath, fileName), []byte("password\n"), 0644)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+    }
+
+    claims := disk.NewClaimRegistry()
+    claims.Claim(filepath.Join(testPath, "a.txt"), "worker")
+
+    // Ensure the claimed file and the part file are skipped
+    fileName, fileSize, err := disk.CheckUnclaimedFiles(testPath, claims)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("b.txt", fileName)
+    assert.Equal(int64(9), fileSize)
+
+    claims.Claim(filepath.Join(testPath, "b.txt"), "worker")
+    // Ensure nothing is returned once every file is claimed
+    fileName, _, err = disk.CheckUnclaimedFiles(testPath, claims)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("", fileName)
+}
+
+
+func TestCopyFile(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    testFiles := []string{"testcopysrc.txt", "testcopydest.txt"}
+    // Write the source test file
+    err := os.WriteFile(testFiles[0], []byte("copy test data"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Copy the source file to the destination
+    err = disk.CopyFile(testFiles[0], testFiles[1])
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Read the copied data
+    copyData, err := os.ReadFile(testFiles[1])
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the copied data matches the source
+    assert.Equal("copy test data", string(copyData))
+
+    // Iterate through the test files and delete them
+    for _, testFile := range testFiles {
+        err = os.Remove(testFile)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+    }
+}
+
+
+func TestCountLines(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    testFile := "testlines.txt"
+    // Write test data where the final line has no newline
+    err := os.WriteFile(testFile, []byte("line1\nline2\nline3"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Count the lines in the test file
+    lineCount, err := disk.CountLines(testFile)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the last line without newline was counted
+    assert.Equal(int64(3), lineCount)
+
+    // Delete the test file
+    err = os.Remove(testFile)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Attempt to count lines of non-existent file
+    _, err = disk.CountLines(testFile)
+    // Ensure the error is present since the file does not exist
+    assert.NotEqual(nil, err)
+}
+
+
 func TestCreateRandFile(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -117,14 +211,20 @@ func TestGetDiskSpace(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
 
-    // Get the total and free disk space
-    total, free, err := disk.GetDiskSpace("/", 20 * (1024 * 1024 * 1024))
+    // Get the remaining and total disk space
+    remaining, total, err := disk.GetDiskSpace("/", disk.Reserve{})
     // Ensure the error is nil meaning successful operation
     assert.Equal(nil, err)
     // Ensure the total size is greater than 0
     assert.Less(int64(0), total)
-    // Ensure the free size is greater than 0
-    assert.Less(int64(0), free)
+    // Ensure the remaining size is greater than 0
+    assert.Less(int64(0), remaining)
+
+    // Ensure a percentage reserve scales with the disk total
+    reserved, _, err := disk.GetDiskSpace("/", disk.Reserve{Percent: 50})
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.InDelta(float64(remaining - total / 2), float64(reserved), float64(globals.MB))
 }
 
 
@@ -191,6 +291,57 @@ func TestPathExists(t *testing.T) {
 }
 
 
+func TestQuotaRemaining(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    dirPath := t.TempDir()
+    err := os.MkdirAll(filepath.Join(dirPath, "sub"), os.ModePerm)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Write files to the dir and its sub dir
+    err = os.WriteFile(filepath.Join(dirPath, "a.txt"), make([]byte, 100), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    err = os.WriteFile(filepath.Join(dirPath, "sub", "b.txt"), make([]byte, 50), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    size, err := disk.DirSize(dirPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(int64(150), size)
+
+    remaining, err := disk.QuotaRemaining(dirPath, 100)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure an exceeded quota has negative space remaining
+    assert.Equal(int64(-50), remaining)
+
+    // Ensure no quota is unlimited
+    remaining, err = disk.QuotaRemaining(dirPath, 0)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(int64(math.MaxInt64), remaining)
+
+    // Ensure a missing dir is an error
+    _, err = disk.DirSize(filepath.Join(dirPath, "missing"))
+    assert.NotNil(err)
+}
+
+
+func TestReserveAmount(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    assert.Equal(int64(20 * globals.GB), disk.Reserve{Bytes: 20 * globals.GB}.Amount(globals.GB))
+    assert.Equal(int64(80 * globals.GB),
+                 disk.Reserve{Percent: 1}.Amount(8000 * globals.GB))
+    assert.Equal(int64(0), disk.Reserve{}.Amount(100 * globals.GB))
+}
+
+
 func TestSelectFile(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -200,7 +351,7 @@ func TestSelectFile(t *testing.T) {
     maxFileSize := int64(104857600)
 
     // Attempt to select from non-existent dir
-    _, _, err := disk.SelectFile(fakeDir, maxFileSize)
+    _, _, err := disk.SelectFile(fakeDir, maxFileSize, "10.0.0.1:5000", nil)
     // Ensure the error present since dir path is fake
     assert.NotEqual(nil, err)
 
@@ -243,8 +394,21 @@ func TestSelectFile(t *testing.T) {
         assert.Equal(bytesWrote, bufferSizes[index])
     }
 
+    // Count the files available before any are selected
+    remaining, err := disk.RemainingFiles(realDirPath, int64(100 * globals.MB))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(5, remaining)
+
+    // Ensure files over the max size are not counted
+    remaining, err = disk.RemainingFiles(realDirPath, 1024)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(3, remaining)
+
     // Attempt to select a file with proper max size
-    filePath, fileSize, err := disk.SelectFile(realDirPath, int64(100 * globals.MB))
+    filePath, fileSize, err := disk.SelectFile(realDirPath, int64(100 * globals.MB),
+                                               "10.0.0.1:5000", nil)
     // Ensure the error is nil meaning successful operation
     assert.Equal(nil, err)
     // Ensure a file path was selected
@@ -252,8 +416,74 @@ func TestSelectFile(t *testing.T) {
     // Ensure the file size is greater than zero
     assert.Less(int64(0), fileSize)
 
+    // Ensure the claimed file is no longer counted
+    remaining, err = disk.RemainingFiles(realDirPath, int64(100 * globals.MB))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(4, remaining)
+
+    // Select with an ordering that puts the largest files first
+    filePath, fileSize, err = disk.SelectFile(realDirPath, int64(100 * globals.MB),
+                                              "10.0.0.2:5000", func(candidates []disk.Candidate) {
+        slices.SortFunc(candidates, func(a disk.Candidate, b disk.Candidate) int {
+            return int(b.Size - a.Size)
+        })
+    })
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the largest unclaimed file was selected
+    assert.Equal("test5.txt", filepath.Base(filePath))
+    assert.Equal(int64(4096), fileSize)
+
     // Delete the testdir and its contents
     err = os.RemoveAll(realDirPath)
     // Ensure the error is nil meaning successful operation
     assert.Equal(nil, err)
 }
+
+
+func TestSplitFileLines(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    testDir := "TestSplitFileLines"
+    // Create the test dir for shards
+    err := os.Mkdir(testDir, os.ModePerm)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    testFile := filepath.Join(testDir, "hashes.txt")
+    // Write test hashes with an empty line to be skipped
+    err = os.WriteFile(testFile, []byte("hash1\nhash2\n\nhash3\nhash4\nhash5\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Split the test file into shards
+    shardPaths, err := disk.SplitFileLines(testFile, testDir, 2)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the expected number of shards were created
+    assert.Equal(2, len(shardPaths))
+    assert.Equal(filepath.Join(testDir, "hashes_shard1.txt"), shardPaths[0])
+
+    // Read the data of each shard
+    shard1, err := os.ReadFile(shardPaths[0])
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    shard2, err := os.ReadFile(shardPaths[1])
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the lines were distributed round-robin
+    assert.Equal("hash1\nhash3\nhash5\n", string(shard1))
+    assert.Equal("hash2\nhash4\n", string(shard2))
+
+    // Ensure an invalid number of shards is rejected
+    _, err = disk.SplitFileLines(testFile, testDir, 0)
+    assert.NotEqual(nil, err)
+
+    // Delete the test dir and its files
+    err = os.RemoveAll(testDir)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+
+package disk
+
+import "golang.org/x/sys/unix"
+
+
+// Gets the total space and space available after the reserve on the disk of the path.
+//
+// @Parameters
+// - path:  path to location on disk where size will be queried
+// - reserve:  The space reserved for the OS
+//
+// @Returns
+// - The free space remaining after the reserve is subtracted
+// - The total space on disk
+// - Error if it occurs, otherwise nil on success
+//
+func GetDiskSpace(path string, reserve Reserve) (remaining int64, total int64, err error) {
+    var statfs unix.Statfs_t
+
+    // Get the stats of the passed in path
+    err = unix.Statfs(path, &statfs)
+    if err != nil {
+        return -1, -1, err
+    }
+
+    // Total space is (blocks * block size)
+    total = int64(statfs.Blocks) * int64(statfs.Bsize)
+    // Free space is (free blocks * block size)
+    free := int64(statfs.Bfree) * int64(statfs.Bsize)
+    // Subtract the reserved OS space from available
+    remaining = free - reserve.Amount(total)
+
+    return remaining, total, nil
+}
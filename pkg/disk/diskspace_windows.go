@@ -0,0 +1,36 @@
+package disk
+
+import "golang.org/x/sys/windows"
+
+
+// Gets the total space and space available after the reserve on the volume of the path.
+//
+// @Parameters
+// - path:  path to location on disk where size will be queried
+// - reserve:  The space reserved for the OS
+//
+// @Returns
+// - The free space remaining after the reserve is subtracted
+// - The total space on disk
+// - Error if it occurs, otherwise nil on success
+//
+func GetDiskSpace(path string, reserve Reserve) (remaining int64, total int64, err error) {
+    var freeBytes, totalBytes, totalFree uint64
+
+    pathPtr, err := windows.UTF16PtrFromString(path)
+    if err != nil {
+        return -1, -1, err
+    }
+
+    // Get the space of the volume holding the passed in path
+    err = windows.GetDiskFreeSpaceEx(pathPtr, &freeBytes, &totalBytes, &totalFree)
+    if err != nil {
+        return -1, -1, err
+    }
+
+    total = int64(totalBytes)
+    // Subtract the reserved OS space from the space available to the process
+    remaining = int64(freeBytes) - reserve.Amount(total)
+
+    return remaining, total, nil
+}
@@ -0,0 +1,236 @@
+package disk
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// Priorities of disk IO operations, higher priorities preempt lower ones
+const (
+    PriorityMerge    = iota  // Wordlist merging, which can run for hours
+    PriorityTransfer         // Wordlists being sent to clients waiting on them
+)
+
+
+// IoScheduler orders large sequential disk operations by priority, an operation is only
+// admitted while no higher priority operation is running or waiting, and the operations
+// of each priority can be limited to serialize them. Long running operations yield at
+// checkpoints so higher priority operations started after them preempt them.
+type IoScheduler struct {
+    active  map[int]int    // Operations running at each priority
+    changed chan struct{}  // Closed and replaced whenever the running or waiting ops change
+    limits  map[int]int    // Max operations running at each priority, unlimited when unset
+    maxWait time.Duration  // Max time an op waits on higher priorities, 0 waits forever
+    mutx    sync.Mutex
+    waiting map[int]int    // Operations waiting to run at each priority
+}
+
+// Creates a new IO scheduler with the passed in limits.
+//
+// @Parameters
+// - limits:  The max operations running at each priority, unlimited when unset or 0
+// - maxWait:  The max time an operation waits on higher priorities before running anyway
+//             so it is throttled but not starved, 0 to wait until they finish
+//
+// @Returns
+// - The initialized IO scheduler
+//
+func NewIoScheduler(limits map[int]int, maxWait time.Duration) *IoScheduler {
+    return &IoScheduler{active: make(map[int]int), changed: make(chan struct{}),
+                        limits: limits, maxWait: maxWait, waiting: make(map[int]int)}
+}
+
+// Wakes the operations waiting on the scheduler, must be called with the mutex held.
+func (scheduler *IoScheduler) notify() {
+    close(scheduler.changed)
+    scheduler.changed = make(chan struct{})
+}
+
+// Checks whether any operation of a higher priority is running or waiting, must be
+// called with the mutex held.
+//
+// @Parameters
+// - priority:  The priority of the operation checking
+//
+// @Returns
+// - true if a higher priority operation is running or waiting, otherwise false
+//
+func (scheduler *IoScheduler) preempted(priority int) bool {
+    // Iterate through the running and waiting operations by priority
+    for _, counts := range []map[int]int{scheduler.active, scheduler.waiting} {
+        for other, count := range counts {
+            if other > priority && count > 0 {
+                return true
+            }
+        }
+    }
+
+    return false
+}
+
+// Waits until the passed in check is satisfied, the context is done, or the max wait
+// passes when the check is only blocked by higher priorities.
+//
+// @Parameters
+// - ctx:  Stops waiting when canceled
+// - priority:  The priority of the waiting operation
+// - admit:  Checks whether the operation can proceed, passed whether higher priorities
+//           are still respected, called with the mutex held
+//
+// @Returns
+// - Error if the context is done, otherwise nil once admitted with the mutex held
+//
+func (scheduler *IoScheduler) wait(ctx context.Context, priority int,
+                                   admit func(respectHigher bool) bool) error {
+    var deadline <-chan time.Time
+    respectHigher := true
+
+    // If waiting on higher priorities is bounded, stop respecting them after the max
+    if scheduler.maxWait > 0 {
+        timer := time.NewTimer(scheduler.maxWait)
+        defer timer.Stop()
+        deadline = timer.C
+    }
+
+    scheduler.mutx.Lock()
+    scheduler.waiting[priority] += 1
+    scheduler.notify()
+
+    for !admit(respectHigher) {
+        changed := scheduler.changed
+        scheduler.mutx.Unlock()
+
+        select {
+        case <-changed:
+        case <-deadline:
+            respectHigher = false
+        case <-ctx.Done():
+            scheduler.mutx.Lock()
+            scheduler.waiting[priority] -= 1
+            scheduler.notify()
+            scheduler.mutx.Unlock()
+            return ctx.Err()
+        }
+
+        scheduler.mutx.Lock()
+    }
+
+    scheduler.waiting[priority] -= 1
+    scheduler.notify()
+    return nil
+}
+
+// Waits until the operation can run at its priority and marks it as running. A nil
+// scheduler admits every operation immediately.
+//
+// @Parameters
+// - ctx:  Stops waiting when canceled
+// - priority:  The priority of the operation
+//
+// @Returns
+// - Releases the operation once it completes
+// - Error if the context is done before the operation is admitted, otherwise nil
+//
+func (scheduler *IoScheduler) Acquire(ctx context.Context, priority int) (func(), error) {
+    // If no scheduler is set, IO is not scheduled
+    if scheduler == nil {
+        return func() {}, nil
+    }
+
+    err := scheduler.wait(ctx, priority, func(respectHigher bool) bool {
+        // If a higher priority operation is still running or waiting
+        if respectHigher && scheduler.preempted(priority) {
+            return false
+        }
+
+        limit := scheduler.limits[priority]
+        return limit <= 0 || scheduler.active[priority] < limit
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    scheduler.active[priority] += 1
+    scheduler.mutx.Unlock()
+
+    var once sync.Once
+    return func() {
+        once.Do(func() {
+            scheduler.mutx.Lock()
+            defer scheduler.mutx.Unlock()
+
+            scheduler.active[priority] -= 1
+            scheduler.notify()
+        })
+    }, nil
+}
+
+// Pauses a running operation while higher priority operations are running or waiting,
+// called at checkpoints of long operations so they are preempted. A nil scheduler
+// returns immediately.
+//
+// @Parameters
+// - ctx:  Stops waiting when canceled
+// - priority:  The priority of the running operation
+//
+// @Returns
+// - Error if the context is done while paused, otherwise nil
+//
+func (scheduler *IoScheduler) Yield(ctx context.Context, priority int) error {
+    // If no scheduler is set, IO is not scheduled
+    if scheduler == nil {
+        return nil
+    }
+
+    err := scheduler.wait(ctx, priority, func(respectHigher bool) bool {
+        return !respectHigher || !scheduler.preempted(priority)
+    })
+    if err != nil {
+        return err
+    }
+
+    scheduler.mutx.Unlock()
+    return nil
+}
+
+// ioYieldReader yields to higher priority operations before each read
+type ioYieldReader struct {
+    ctx       context.Context
+    priority  int
+    reader    io.Reader
+    scheduler *IoScheduler
+}
+
+// Reads from the wrapped reader once no higher priority operation preempts it.
+func (yieldReader *ioYieldReader) Read(buffer []byte) (int, error) {
+    err := yieldReader.scheduler.Yield(yieldReader.ctx, yieldReader.priority)
+    if err != nil {
+        return 0, err
+    }
+
+    return yieldReader.reader.Read(buffer)
+}
+
+// Wraps the reader of a running operation so each read yields to higher priority
+// operations, preempting copies done in Go between their chunks.
+//
+// @Parameters
+// - ctx:  Stops the reads when canceled
+// - reader:  The reader of the operation
+// - priority:  The priority of the running operation
+//
+// @Returns
+// - The reader yielding before each read, the passed in reader if the scheduler is nil
+//
+func (scheduler *IoScheduler) Reader(ctx context.Context, reader io.Reader,
+                                     priority int) io.Reader {
+    // If no scheduler is set, IO is not scheduled
+    if scheduler == nil {
+        return reader
+    }
+
+    return &ioYieldReader{ctx: ctx, priority: priority, reader: reader,
+                          scheduler: scheduler}
+}
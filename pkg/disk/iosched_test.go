@@ -0,0 +1,97 @@
+package disk_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestIoScheduler(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Gets a context that expires shortly so blocked operations return
+    shortly := func() context.Context {
+        ctx, cancel := context.WithTimeout(context.Background(), 50 * time.Millisecond)
+        t.Cleanup(cancel)
+        return ctx
+    }
+
+    var unscheduled *disk.IoScheduler
+    // Ensure a nil scheduler admits everything immediately
+    release, err := unscheduled.Acquire(shortly(), disk.PriorityMerge)
+    assert.Equal(nil, err)
+    release()
+    assert.Equal(nil, unscheduled.Yield(shortly(), disk.PriorityMerge))
+
+    scheduler := disk.NewIoScheduler(map[int]int{disk.PriorityMerge: 1}, 0)
+
+    releaseMerge, err := scheduler.Acquire(shortly(), disk.PriorityMerge)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure merges are serialized by their limit
+    _, err = scheduler.Acquire(shortly(), disk.PriorityMerge)
+    assert.Equal(context.DeadlineExceeded, err)
+
+    // Ensure transfers are admitted while a merge runs
+    releaseTransfer, err := scheduler.Acquire(shortly(), disk.PriorityTransfer)
+    assert.Equal(nil, err)
+
+    // Ensure the running merge is paused while the transfer runs
+    assert.Equal(context.DeadlineExceeded, scheduler.Yield(shortly(), disk.PriorityMerge))
+
+    resumed := make(chan error, 1)
+    go func() {
+        resumed <- scheduler.Yield(context.Background(), disk.PriorityMerge)
+    } ()
+
+    releaseTransfer()
+    // Ensure the merge resumes once the transfer completes
+    select {
+    case err = <-resumed:
+        assert.Equal(nil, err)
+    case <-time.After(5 * time.Second):
+        t.Fatal("merge was not resumed after the transfer completed")
+    }
+
+    releaseMerge()
+    // Ensure the merge slot is free once released, even if released twice
+    releaseMerge()
+    releaseMerge, err = scheduler.Acquire(shortly(), disk.PriorityMerge)
+    assert.Equal(nil, err)
+    releaseMerge()
+}
+
+
+func TestIoSchedulerMaxWait(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    scheduler := disk.NewIoScheduler(nil, 50 * time.Millisecond)
+
+    releaseTransfer, err := scheduler.Acquire(context.Background(), disk.PriorityTransfer)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Release the transfer on local exit
+    defer releaseTransfer()
+
+    start := time.Now()
+    // Ensure the merge runs after the max wait even though the transfer still runs
+    releaseMerge, err := scheduler.Acquire(context.Background(), disk.PriorityMerge)
+    assert.Equal(nil, err)
+    assert.GreaterOrEqual(time.Since(start), 50 * time.Millisecond)
+    releaseMerge()
+
+    reader := scheduler.Reader(context.Background(), strings.NewReader("rockyou"),
+                               disk.PriorityMerge)
+    // Ensure the reader is throttled but still reads while the transfer runs
+    readData, err := io.ReadAll(reader)
+    assert.Equal(nil, err)
+    assert.Equal("rockyou", string(readData))
+}
@@ -0,0 +1,400 @@
+package disk
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// Bytes read at a time while searching for the line boundaries of the ranges
+const RangeScanSize = 64 * 1024
+
+// Package level variables
+var ReRangePath = regexp.MustCompile(`^(.+)@(\d+)\+(\d+)$`)  // Source path@offset+length
+
+
+// RangeFile is an open line range of a wordlist, read as if it were a file of its own
+type RangeFile struct {
+    *io.SectionReader
+    file *os.File
+}
+
+
+// Closes the wordlist the range is read from.
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (rangeFile *RangeFile) Close() error {
+    return rangeFile.file.Close()
+}
+
+
+// Formats the path a line range of a wordlist is selected, claimed, and sent by, its base
+// name is the name of the wordlist with the offset and length of the range appended.
+//
+// @Parameters
+// - filePath:  The path of the wordlist the range is read from
+// - offset:  The byte offset the range starts at
+// - length:  The length of the range in bytes
+//
+// @Returns
+// - The path of the range
+//
+func RangePath(filePath string, offset int64, length int64) string {
+    return fmt.Sprintf("%s@%d+%d", filePath, offset, length)
+}
+
+
+// Parses the path of a line range formatted by RangePath().
+//
+// @Parameters
+// - rangePath:  The path of the range
+//
+// @Returns
+// - The path of the wordlist the range is read from
+// - The byte offset the range starts at
+// - The length of the range in bytes
+// - Whether the path is the path of a range
+//
+func ParseRangePath(rangePath string) (string, int64, int64, bool) {
+    match := ReRangePath.FindStringSubmatch(rangePath)
+    // If the path does not end with a range
+    if match == nil {
+        return "", 0, 0, false
+    }
+
+    offset, err := strconv.ParseInt(match[2], 10, 64)
+    if err != nil {
+        return "", 0, 0, false
+    }
+
+    length, err := strconv.ParseInt(match[3], 10, 64)
+    if err != nil {
+        return "", 0, 0, false
+    }
+
+    return match[1], offset, length, true
+}
+
+
+// Opens the line range of the wordlist for reading.
+//
+// @Parameters
+// - rangePath:  The path of the range formatted by RangePath()
+//
+// @Returns
+// - The opened range, closed by the caller
+// - Error if it occurs, otherwise nil on success
+//
+func OpenRange(rangePath string) (*RangeFile, error) {
+    filePath, offset, length, ok := ParseRangePath(rangePath)
+    // If the path is not the path of a range
+    if !ok {
+        return nil, fmt.Errorf("improper range path %q", rangePath)
+    }
+
+    file, err := os.Open(filePath)
+    if err != nil {
+        return nil, err
+    }
+
+    return &RangeFile{SectionReader: io.NewSectionReader(file, offset, length),
+                      file: file}, nil
+}
+
+
+// Finds the end of the last complete line in the span of the file by reading backwards
+// from the end of the span.
+//
+// @Parameters
+// - file:  The open file to search
+// - start:  The byte offset the span starts at
+// - end:  The byte offset the span ends at
+// - window:  The buffer the file is read into
+//
+// @Returns
+// - The offset just past the last newline in the span, start if it has none
+// - Error if it occurs, otherwise nil on success
+//
+func lastLineEnd(file *os.File, start int64, end int64, window []byte) (int64, error) {
+    // Iterate backwards through the span a window at a time
+    for end > start {
+        windowStart := max(start, end - int64(len(window)))
+        chunk := window[:end - windowStart]
+
+        _, err := file.ReadAt(chunk, windowStart)
+        if err != nil {
+            return 0, fmt.Errorf("error reading %s - %w", file.Name(), err)
+        }
+
+        // If the window has a newline, the range ends just past it
+        if index := bytes.LastIndexByte(chunk, '\n'); index != -1 {
+            return windowStart + int64(index) + 1, nil
+        }
+
+        end = windowStart
+    }
+
+    return start, nil
+}
+
+
+// Finds the end of the line the offset is in by reading forwards, used when a single line
+// is longer than the max range length.
+//
+// @Parameters
+// - file:  The open file to search
+// - offset:  The byte offset the search starts at
+// - size:  The size of the file
+// - window:  The buffer the file is read into
+//
+// @Returns
+// - The offset just past the next newline, the size of the file if there is none
+// - Error if it occurs, otherwise nil on success
+//
+func nextLineEnd(file *os.File, offset int64, size int64, window []byte) (int64, error) {
+    // Iterate forwards through the file a window at a time
+    for offset < size {
+        chunk := window[:min(int64(len(window)), size - offset)]
+
+        _, err := file.ReadAt(chunk, offset)
+        if err != nil {
+            return 0, fmt.Errorf("error reading %s - %w", file.Name(), err)
+        }
+
+        // If the window has a newline, the line ends just past it
+        if index := bytes.IndexByte(chunk, '\n'); index != -1 {
+            return offset + int64(index) + 1, nil
+        }
+
+        offset += int64(len(chunk))
+    }
+
+    return size, nil
+}
+
+
+// Indexes the wordlist into line aligned ranges of at most the max length, only reading
+// around the boundaries of the ranges so even the largest wordlists are indexed in moments.
+// A line longer than the max length is kept whole in a range of its own, which is left
+// unselected like a wordlist over the max file size.
+//
+// @Parameters
+// - filePath:  The path of the wordlist to index
+// - maxLength:  The max length of a range in bytes
+//
+// @Returns
+// - The ranges of the wordlist as candidates to be selected, none if the file is empty
+// - Error if it occurs, otherwise nil on success
+//
+func IndexRanges(filePath string, maxLength int64) ([]Candidate, error) {
+    // If the ranges could never hold a line
+    if maxLength < 1 {
+        return nil, fmt.Errorf("max range length must be at least 1")
+    }
+
+    file, err := os.Open(filePath)
+    if err != nil {
+        return nil, err
+    }
+    // Close the file on local exit
+    defer file.Close()
+
+    fileInfo, err := file.Stat()
+    if err != nil {
+        return nil, err
+    }
+
+    var candidates []Candidate
+    size := fileInfo.Size()
+    window := make([]byte, min(maxLength, RangeScanSize))
+
+    // Iterate through the file a range at a time
+    for offset := int64(0); offset < size; {
+        end := min(offset + maxLength, size)
+
+        // If the range ends before the file, end it on the last line that fits
+        if end < size {
+            end, err = lastLineEnd(file, offset, end, window)
+            if err != nil {
+                return nil, err
+            }
+
+            // If the first line does not fit, keep it whole
+            if end == offset {
+                end, err = nextLineEnd(file, offset + maxLength, size, window)
+                if err != nil {
+                    return nil, err
+                }
+            }
+        }
+
+        rangePath := RangePath(filePath, offset, end - offset)
+        candidates = append(candidates, Candidate{Name: filepath.Base(rangePath),
+                                                  Path: rangePath, Size: end - offset})
+        offset = end
+    }
+
+    return candidates, nil
+}
+
+
+// Indexes each wordlist in the load dir and its immediate subdirs into line aligned
+// ranges. Since the load dir is not merged, each range keeps the subdir it is in for
+// strategies that select across subdirs.
+//
+// @Parameters
+// - loadDir:  The directory of the wordlists to index
+// - maxLength:  The max length of a range in bytes
+//
+// @Returns
+// - The ranges of the wordlists as candidates to be selected
+// - Error if it occurs, otherwise nil on success
+//
+func IndexRangeDir(loadDir string, maxLength int64) ([]Candidate, error) {
+    candidates, err := indexRangeSubdir(loadDir, "", maxLength)
+    if err != nil {
+        return nil, err
+    }
+
+    // Read the contents of the directory
+    items, err := os.ReadDir(loadDir)
+    if err != nil {
+        return nil, err
+    }
+
+    // Iterate through the subdirs in the load dir indexing their files
+    for _, item := range items {
+        if !item.IsDir() {
+            continue
+        }
+
+        ranges, err := indexRangeSubdir(loadDir, item.Name(), maxLength)
+        if err != nil {
+            return nil, err
+        }
+
+        candidates = append(candidates, ranges...)
+    }
+
+    return candidates, nil
+}
+
+
+// Indexes the wordlists directly in the subdir of the load dir into line aligned ranges.
+//
+// @Parameters
+// - loadDir:  The directory of the wordlists to index
+// - subdir:  The name of the subdir to index, empty for the top level of the load dir
+// - maxLength:  The max length of a range in bytes
+//
+// @Returns
+// - The ranges of the wordlists, each with the subdir set
+// - Error if it occurs, otherwise nil on success
+//
+func indexRangeSubdir(loadDir string, subdir string, maxLength int64) ([]Candidate,
+                                                                        error) {
+    var candidates []Candidate
+
+    dirPath := loadDir
+    // If a subdir is indexed, append it to the load dir
+    if subdir != "" {
+        dirPath += "/" + subdir
+    }
+
+    // Read the contents of the directory
+    items, err := os.ReadDir(dirPath)
+    if err != nil {
+        return nil, err
+    }
+
+    // Iterate through the items in the dir indexing each file
+    for _, item := range items {
+        if item.IsDir() {
+            continue
+        }
+
+        ranges, err := IndexRanges(dirPath + "/" + item.Name(), maxLength)
+        if err != nil {
+            return nil, err
+        }
+
+        // Iterate through the ranges setting the subdir they are in
+        for index := range ranges {
+            ranges[index].Dir = subdir
+        }
+
+        candidates = append(candidates, ranges...)
+    }
+
+    return candidates, nil
+}
+
+
+// Selects and claims a unique range for the client from the indexed ranges, the
+// counterpart of SelectFile() when wordlists are assigned by range.
+//
+// @Parameters
+// - ranges:  The indexed ranges of the load dir, never modified
+// - maxFileSizeInt64:  The max size of a range that can be selected
+// - client:  The address of the client the range is claimed for
+// - order:  Sorts the candidate ranges before selection, nil keeps the index order
+//
+// @Returns
+// - Path of the selected range, empty if none are available
+// - Size of the selected range
+//
+func SelectRange(ranges []Candidate, maxFileSizeInt64 int64, client string,
+                 order Ordering) (string, int64) {
+    var candidates []Candidate
+
+    // Iterate through the ranges collecting the ones that can be selected
+    for _, candidate := range ranges {
+        if candidate.Size > maxFileSizeInt64 {
+            continue
+        }
+
+        // If the range is already claimed by a client, skip it
+        if _, claimed := Claims.Owner(candidate.Path); claimed {
+            continue
+        }
+
+        // If the range already ran against the hash file shard of the client, skip it
+        if !Shards.Needs(candidate.Path, client) {
+            continue
+        }
+
+        candidates = append(candidates, candidate)
+    }
+
+    return claimFirst(candidates, client, order)
+}
+
+
+// Counts the indexed ranges that are still available to be selected.
+//
+// @Parameters
+// - ranges:  The indexed ranges of the load dir
+// - maxFileSizeInt64:  The max size of a range that can be selected
+//
+// @Returns
+// - The number of ranges remaining to be selected
+//
+func RemainingRanges(ranges []Candidate, maxFileSizeInt64 int64) int {
+    var remaining int
+
+    // Iterate through the ranges counting the unclaimed ones
+    for _, candidate := range ranges {
+        if _, claimed := Claims.Owner(candidate.Path); !claimed &&
+           candidate.Size <= maxFileSizeInt64 {
+            remaining += 1
+        }
+    }
+
+    return remaining
+}
@@ -0,0 +1,151 @@
+package disk_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestRangePath(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    rangePath := disk.RangePath("/load/rock@you.txt", 1024, 512)
+    assert.Equal("/load/rock@you.txt@1024+512", rangePath)
+
+    // Ensure the range is parsed from the end so the name may hold the separator
+    filePath, offset, length, ok := disk.ParseRangePath(rangePath)
+    assert.True(ok)
+    assert.Equal("/load/rock@you.txt", filePath)
+    assert.Equal(int64(1024), offset)
+    assert.Equal(int64(512), length)
+
+    falacies := []string{"/load/rockyou.txt", "/load/rockyou.txt@1024", "@1+2",
+                         "/load/rockyou.txt@-1+2", "/load/rockyou.txt@1+99999999999999999999"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        _, _, _, ok = disk.ParseRangePath(falacy)
+        // Ensure the path is not parsed as a range
+        assert.False(ok)
+    }
+}
+
+
+func TestIndexRanges(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    testDir := t.TempDir()
+    lines := []string{"password\n", "letmein\n", "qwerty\n", "dragon\n", "monkey"}
+    filePath := filepath.Join(testDir, "words.txt")
+    // Write the wordlist to be indexed
+    err := os.WriteFile(filePath, []byte(strings.Join(lines, "")), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    ranges, err := disk.IndexRanges(filePath, 18)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure each range ends on a line boundary without exceeding the max length
+    assert.Equal([]disk.Candidate{
+        {Name: "words.txt@0+17", Path: filePath + "@0+17", Size: 17},
+        {Name: "words.txt@17+14", Path: filePath + "@17+14", Size: 14},
+        {Name: "words.txt@31+6", Path: filePath + "@31+6", Size: 6},
+    }, ranges)
+
+    var joined []byte
+    // Iterate through the ranges reading each back
+    for _, rng := range ranges {
+        rangeFile, err := disk.OpenRange(rng.Path)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+
+        data, err := io.ReadAll(rangeFile)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        rangeFile.Close()
+
+        joined = append(joined, data...)
+    }
+    // Ensure the ranges cover the wordlist exactly
+    assert.Equal(strings.Join(lines, ""), string(joined))
+
+    // Ensure a line longer than the max length is kept whole
+    ranges, err = disk.IndexRanges(filePath, 4)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(int64(9), ranges[0].Size)
+    assert.Equal(5, len(ranges))
+
+    // Ensure the ranges of the load dir are indexed and empty files are skipped
+    err = os.WriteFile(filepath.Join(testDir, "empty.txt"), nil, 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    ranges, err = disk.IndexRangeDir(testDir, 1024)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(1, len(ranges))
+    assert.Equal(int64(37), ranges[0].Size)
+    assert.Equal("", ranges[0].Dir)
+
+    // Ensure the wordlists in the subdirs are indexed with the subdir they are in
+    err = os.Mkdir(filepath.Join(testDir, "leaks"), 0755)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    err = os.WriteFile(filepath.Join(testDir, "leaks", "leak.txt"), []byte("hunter2\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    ranges, err = disk.IndexRangeDir(testDir, 1024)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(2, len(ranges))
+    assert.Equal("leaks", ranges[1].Dir)
+    assert.Equal(filepath.Join(testDir, "leaks", "leak.txt") + "@0+8", ranges[1].Path)
+}
+
+
+func TestSelectRange(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    ranges := []disk.Candidate{
+        {Name: "words.txt@0+100", Path: "select_range/words.txt@0+100", Size: 100},
+        {Name: "words.txt@100+300", Path: "select_range/words.txt@100+300", Size: 300},
+        {Name: "words.txt@400+50", Path: "select_range/words.txt@400+50", Size: 50},
+    }
+    // Release the claims on the ranges on local exit
+    defer func() {
+        for _, rng := range ranges {
+            disk.Claims.Release(rng.Path)
+        }
+    }()
+
+    assert.Equal(2, disk.RemainingRanges(ranges, 200))
+
+    // Ensure the ranges are selected in order, skipping ones over the max size
+    rangePath, size := disk.SelectRange(ranges, 200, "10.0.0.1:5000", nil)
+    assert.Equal(ranges[0].Path, rangePath)
+    assert.Equal(int64(100), size)
+
+    rangePath, _ = disk.SelectRange(ranges, 200, "10.0.0.2:5000", nil)
+    assert.Equal(ranges[2].Path, rangePath)
+
+    // Ensure nothing is selected once the ranges are claimed
+    rangePath, _ = disk.SelectRange(ranges, 200, "10.0.0.3:5000", nil)
+    assert.Equal("", rangePath)
+    assert.Equal(0, disk.RemainingRanges(ranges, 200))
+
+    // Ensure a released range can be selected again
+    disk.Claims.Release(ranges[0].Path)
+    rangePath, _ = disk.SelectRange(ranges, 1024, "10.0.0.3:5000", nil)
+    assert.Equal(ranges[0].Path, rangePath)
+}
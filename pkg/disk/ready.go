@@ -0,0 +1,115 @@
+package disk
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+// ReadySet tracks the files of a dir that are ready to be selected while the dir is
+// still being prepared, such as merged, so files still being written are never selected
+type ReadySet struct {
+    changed chan struct{}        // Closed and replaced whenever a file is ready or it completes
+    done    bool
+    files   map[string]struct{}
+    mutx    sync.Mutex
+}
+
+// Creates a new ready set with no files ready.
+//
+// @Returns
+// - The initialized ready set
+//
+func NewReadySet() *ReadySet {
+    return &ReadySet{changed: make(chan struct{}), files: make(map[string]struct{})}
+}
+
+// Wakes the waiters on the set, must be called with the mutex held.
+func (set *ReadySet) notify() {
+    close(set.changed)
+    set.changed = make(chan struct{})
+}
+
+// Marks the file as ready to be selected.
+//
+// @Parameters
+// - filePath:  The path of the file that is ready
+//
+func (set *ReadySet) Add(filePath string) {
+    set.mutx.Lock()
+    defer set.mutx.Unlock()
+
+    set.files[filepath.Clean(filePath)] = struct{}{}
+    set.notify()
+}
+
+// Marks the preparation of the dir as complete, making every file selectable.
+func (set *ReadySet) Complete() {
+    set.mutx.Lock()
+    defer set.mutx.Unlock()
+
+    // If the set was already completed
+    if set.done {
+        return
+    }
+
+    set.done = true
+    set.notify()
+}
+
+// Checks whether the file can be selected, every file can once the set is complete or
+// when there is no set.
+//
+// @Parameters
+// - filePath:  The path of the file to check
+//
+// @Returns
+// - true if the file can be selected, otherwise false
+//
+func (set *ReadySet) Allows(filePath string) bool {
+    // If no set is used, every file is ready
+    if set == nil {
+        return true
+    }
+
+    set.mutx.Lock()
+    defer set.mutx.Unlock()
+
+    _, ready := set.files[filepath.Clean(filePath)]
+    return set.done || ready
+}
+
+// Checks whether the dir is still being prepared, so more files may become ready.
+//
+// @Returns
+// - true if the set is not complete yet, otherwise false
+//
+func (set *ReadySet) Pending() bool {
+    // If no set is used, nothing is pending
+    if set == nil {
+        return false
+    }
+
+    set.mutx.Lock()
+    defer set.mutx.Unlock()
+
+    return !set.done
+}
+
+// Gets a channel closed the next time a file is ready or the set completes.
+//
+// @Returns
+// - The channel closed on the next change, already closed when there is no set
+//
+func (set *ReadySet) Changed() <-chan struct{} {
+    // If no set is used, nothing changes so waiters return immediately
+    if set == nil {
+        closed := make(chan struct{})
+        close(closed)
+        return closed
+    }
+
+    set.mutx.Lock()
+    defer set.mutx.Unlock()
+
+    return set.changed
+}
@@ -0,0 +1,79 @@
+package disk_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestReadySet(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    var unset *disk.ReadySet
+    // Ensure every file is ready when no set is used
+    assert.True(unset.Allows("/load/words.txt"))
+    assert.False(unset.Pending())
+
+    set := disk.NewReadySet()
+    changed := set.Changed()
+    set.Add("/load//merged.txt")
+
+    // Ensure adding a file signals the waiters
+    select {
+    case <-changed:
+    default:
+        t.Fatal("ready set change was not signaled")
+    }
+
+    // Ensure only the ready files are allowed while pending
+    assert.True(set.Pending())
+    assert.True(set.Allows("/load/merged.txt"))
+    assert.False(set.Allows("/load/words.txt"))
+
+    // Ensure every file is allowed once complete
+    set.Complete()
+    assert.False(set.Pending())
+    assert.True(set.Allows("/load/words.txt"))
+}
+
+
+func TestSelectFileReady(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    loadDir := t.TempDir()
+
+    // Iterate through the wordlists writing them to the load dir
+    for _, name := range []string{"merging.txt", "merged.txt"} {
+        err := os.WriteFile(filepath.Join(loadDir, name), []byte("password\n"), 0644)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+    }
+
+    disk.Ready = disk.NewReadySet()
+    // Reset the ready set on local exit
+    defer func() {
+        disk.Ready = nil
+    } ()
+
+    disk.Ready.Add(filepath.Join(loadDir, "merged.txt"))
+
+    // Ensure only the ready wordlist is selected while merging
+    filePath, _, err := disk.SelectFile(loadDir, 1024, "10.0.0.1:5000", nil)
+    assert.Equal(nil, err)
+    assert.Equal(filepath.Join(loadDir, "merged.txt"), filePath)
+
+    filePath, _, err = disk.SelectFile(loadDir, 1024, "10.0.0.1:5000", nil)
+    assert.Equal(nil, err)
+    assert.Equal("", filePath)
+
+    // Ensure the rest are selected once merging completes
+    disk.Ready.Complete()
+    filePath, _, err = disk.SelectFile(loadDir, 1024, "10.0.0.1:5000", nil)
+    assert.Equal(nil, err)
+    assert.Equal(filepath.Join(loadDir, "merging.txt"), filePath)
+}
@@ -0,0 +1,160 @@
+package disk
+
+import (
+	"slices"
+	"sync"
+)
+
+// ShardCoverage tracks the hash file shard assigned to each client and the shards each
+// wordlist was processed against when the hash file is split, so a wordlist is only done
+// once it ran against every shard
+type ShardCoverage struct {
+    assigned map[string]int               // Shard assigned to each connected client
+    covered  map[string]map[int]struct{}  // Shards each wordlist was processed against
+    mutx     sync.Mutex
+    shards   int
+}
+
+// Creates a shard coverage tracker with no clients assigned and nothing covered.
+//
+// @Parameters
+// - shards:  The number of shards the hash file was split into
+//
+// @Returns
+// - The initialized shard coverage tracker
+//
+func NewShardCoverage(shards int) *ShardCoverage {
+    return &ShardCoverage{
+        assigned: make(map[string]int),
+        covered:  make(map[string]map[int]struct{}),
+        shards:   shards,
+    }
+}
+
+// Assigns the client the shard with the fewest connected clients, lowest index first,
+// so the shards of disconnected clients are handed to the next clients to connect.
+//
+// @Parameters
+// - client:  The address of the client to assign a shard
+//
+// @Returns
+// - The index of the assigned shard
+//
+func (coverage *ShardCoverage) Assign(client string) int {
+    coverage.mutx.Lock()
+    defer coverage.mutx.Unlock()
+
+    counts := make([]int, coverage.shards)
+    // Iterate through the connected clients counting them per shard
+    for _, shard := range coverage.assigned {
+        counts[shard] += 1
+    }
+
+    shard := slices.Index(counts, slices.Min(counts))
+    coverage.assigned[client] = shard
+    return shard
+}
+
+// Releases the shard of the disconnected client so it can be assigned again.
+//
+// @Parameters
+// - client:  The address of the disconnected client
+//
+func (coverage *ShardCoverage) Release(client string) {
+    // If the hash file is not split
+    if coverage == nil {
+        return
+    }
+
+    coverage.mutx.Lock()
+    defer coverage.mutx.Unlock()
+
+    delete(coverage.assigned, client)
+}
+
+// Checks whether the wordlist still has to run against the shard of the client.
+//
+// @Parameters
+// - filePath:  The path of the wordlist
+// - client:  The address of the client selecting the wordlist
+//
+// @Returns
+// - true if the wordlist was not processed against the shard of the client, otherwise false
+//
+func (coverage *ShardCoverage) Needs(filePath string, client string) bool {
+    // If the hash file is not split, every wordlist is needed
+    if coverage == nil {
+        return true
+    }
+
+    coverage.mutx.Lock()
+    defer coverage.mutx.Unlock()
+
+    shard, assigned := coverage.assigned[client]
+    // If the client has no shard, it is not limited by coverage
+    if !assigned {
+        return true
+    }
+
+    _, covered := coverage.covered[filePath][shard]
+    return !covered
+}
+
+// Records the wordlist as processed against the shard of the client.
+//
+// @Parameters
+// - filePath:  The path of the processed wordlist
+// - client:  The address of the client that processed the wordlist
+//
+// @Returns
+// - true if the wordlist has now run against every shard, otherwise false
+//
+func (coverage *ShardCoverage) Complete(filePath string, client string) bool {
+    // If the hash file is not split, a single run covers it
+    if coverage == nil {
+        return true
+    }
+
+    coverage.mutx.Lock()
+    defer coverage.mutx.Unlock()
+
+    shard, assigned := coverage.assigned[client]
+    // If the client has no shard, nothing is covered
+    if !assigned {
+        return len(coverage.covered[filePath]) == coverage.shards
+    }
+
+    // If the wordlist has not been processed against any shard yet
+    if coverage.covered[filePath] == nil {
+        coverage.covered[filePath] = make(map[int]struct{})
+    }
+
+    coverage.covered[filePath][shard] = struct{}{}
+    return len(coverage.covered[filePath]) == coverage.shards
+}
+
+// Gets the wordlists processed against some but not every shard.
+//
+// @Returns
+// - The paths of the partially covered wordlists in sorted order
+//
+func (coverage *ShardCoverage) Partial() []string {
+    // If the hash file is not split
+    if coverage == nil {
+        return nil
+    }
+
+    coverage.mutx.Lock()
+    defer coverage.mutx.Unlock()
+
+    var filePaths []string
+    // Iterate through the covered wordlists collecting those missing shards
+    for filePath, shards := range coverage.covered {
+        if len(shards) < coverage.shards {
+            filePaths = append(filePaths, filePath)
+        }
+    }
+
+    slices.Sort(filePaths)
+    return filePaths
+}
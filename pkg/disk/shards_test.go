@@ -0,0 +1,83 @@
+package disk_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestShardCoverage(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    coverage := disk.NewShardCoverage(2)
+
+    // Ensure each client gets its own shard while one is free
+    assert.Equal(0, coverage.Assign("10.0.0.1:5000"))
+    assert.Equal(1, coverage.Assign("[fd00::2]:5000"))
+
+    // Ensure a wordlist is only done once it ran against both shards
+    assert.False(coverage.Complete("/load/words.txt", "10.0.0.1:5000"))
+    assert.False(coverage.Needs("/load/words.txt", "10.0.0.1:5000"))
+    assert.True(coverage.Needs("/load/words.txt", "[fd00::2]:5000"))
+    assert.Equal([]string{"/load/words.txt"}, coverage.Partial())
+
+    // Ensure the shard of a disconnected client is handed to the next client
+    coverage.Release("[fd00::2]:5000")
+    assert.Equal(1, coverage.Assign("10.0.0.3:5000"))
+    assert.True(coverage.Complete("/load/words.txt", "10.0.0.3:5000"))
+    assert.Equal(0, len(coverage.Partial()))
+
+    // Ensure no coverage tracker limits nothing
+    var none *disk.ShardCoverage
+    assert.True(none.Needs("/load/words.txt", "10.0.0.1:5000"))
+    assert.True(none.Complete("/load/words.txt", "10.0.0.1:5000"))
+}
+
+
+func TestSelectFileShards(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    loadDir := t.TempDir()
+    filePath := filepath.Join(loadDir, "words.txt")
+    // Write the wordlist to be selected
+    err := os.WriteFile(filePath, []byte("password\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    disk.Shards = disk.NewShardCoverage(2)
+    // Stop tracking coverage and release the claim on local exit
+    defer func() {
+        disk.Shards = nil
+        disk.Claims.Release(loadDir + "/words.txt")
+    }()
+
+    disk.Shards.Assign("10.0.0.1:5000")
+    disk.Shards.Assign("10.0.0.2:5000")
+
+    // Ensure the first client selects the wordlist for its shard
+    selected, _, err := disk.SelectFile(loadDir, 1024, "10.0.0.1:5000", nil)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(loadDir + "/words.txt", selected)
+
+    // Once processed against the first shard, the wordlist is released for the other
+    disk.Shards.Complete(selected, "10.0.0.1:5000")
+    disk.Claims.Release(selected)
+
+    // Ensure the first client does not select it again
+    selected, _, err = disk.SelectFile(loadDir, 1024, "10.0.0.1:5000", nil)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("", selected)
+
+    // Ensure the client of the other shard selects it
+    selected, _, err = disk.SelectFile(loadDir, 1024, "10.0.0.2:5000", nil)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(loadDir + "/words.txt", selected)
+}
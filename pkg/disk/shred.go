@@ -0,0 +1,114 @@
+package disk
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Size of the chunks of random data files are overwritten with
+const ShredChunkSize = 1024 * 1024
+
+
+// Overwrites the file with random data, flushing it to the disk before it is removed, so
+// its contents can not be recovered from the freed blocks. A file that does not exist is
+// already scrubbed.
+//
+// @Parameters
+// - filePath:  The path of the file to shred
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func ShredFile(filePath string) error {
+    info, err := os.Lstat(filePath)
+    // If the file was never created or already removed
+    if errors.Is(err, fs.ErrNotExist) {
+        return nil
+    }
+    if err != nil {
+        return fmt.Errorf("error checking file to shred - %w", err)
+    }
+
+    // If the path is not a regular file, there is no data of its own to overwrite
+    if !info.Mode().IsRegular() {
+        return os.Remove(filePath)
+    }
+
+    file, err := os.OpenFile(filePath, os.O_WRONLY, 0)
+    if err != nil {
+        return fmt.Errorf("error opening file to shred - %w", err)
+    }
+
+    // Overwrite the existing length of the file with random data
+    _, err = io.CopyBuffer(file, io.LimitReader(rand.Reader, info.Size()),
+                           make([]byte, ShredChunkSize))
+    if err == nil {
+        // Flush the random data so it replaces the contents on the disk
+        err = file.Sync()
+    }
+    if err != nil {
+        file.Close()
+        return fmt.Errorf("error overwriting file %s - %w", filePath, err)
+    }
+
+    err = file.Close()
+    if err != nil {
+        return fmt.Errorf("error closing shredded file - %w", err)
+    }
+
+    return os.Remove(filePath)
+}
+
+
+// Shreds each file in the dir and its sub dirs before removing the dir. A file that fails
+// to shred does not stop the rest from being shredded. A dir that does not exist is
+// already scrubbed.
+//
+// @Parameters
+// - dirPath:  The path of the dir to shred
+//
+// @Returns
+// - The errors of the files that failed to shred joined, otherwise nil on success
+//
+func ShredDir(dirPath string) error {
+    var errs []error
+
+    err := filepath.WalkDir(dirPath, func(path string, entry os.DirEntry, err error) error {
+        // If the dir was never created or already removed
+        if errors.Is(err, fs.ErrNotExist) && path == dirPath {
+            return fs.SkipAll
+        }
+        if err != nil {
+            errs = append(errs, err)
+            return nil
+        }
+
+        // If the entry is a dir, its files are shredded as they are walked
+        if entry.IsDir() {
+            return nil
+        }
+
+        err = ShredFile(path)
+        if err != nil {
+            errs = append(errs, err)
+        }
+
+        return nil
+    })
+    if err != nil {
+        errs = append(errs, err)
+    }
+
+    // Remove the emptied dir tree along with any files that failed to shred
+    err = os.RemoveAll(dirPath)
+    if err != nil {
+        errs = append(errs, err)
+    }
+
+    return errors.Join(errs...)
+}
@@ -0,0 +1,62 @@
+package disk_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestShredFile(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    filePath := filepath.Join(t.TempDir(), "loot.txt")
+    err := os.WriteFile(filePath, []byte("hash:password\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    err = disk.ShredFile(filePath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the shredded file is removed
+    _, err = os.Stat(filePath)
+    assert.True(os.IsNotExist(err))
+
+    // Ensure a file that does not exist is already scrubbed
+    assert.Equal(nil, disk.ShredFile(filePath))
+}
+
+
+func TestShredDir(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    dirPath := filepath.Join(t.TempDir(), "wordlists")
+    disk.MakeDirs([]string{filepath.Join(dirPath, "nested")})
+
+    filePaths := []string{filepath.Join(dirPath, "rockyou.txt"),
+                          filepath.Join(dirPath, "nested", "empty.txt")}
+    contents := []string{"password\nletmein\n", ""}
+    // Iterate through the files writing each
+    for index, filePath := range filePaths {
+        err := os.WriteFile(filePath, []byte(contents[index]), 0644)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+    }
+
+    err := disk.ShredDir(dirPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the dir is removed along with its files
+    _, err = os.Stat(dirPath)
+    assert.True(os.IsNotExist(err))
+
+    // Ensure a dir that does not exist is already scrubbed
+    assert.Equal(nil, disk.ShredDir(dirPath))
+}
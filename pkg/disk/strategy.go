@@ -0,0 +1,141 @@
+package disk
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Strategy sorts the candidates into the order they are selected, its Order method
+// matches the Ordering signature so it can be passed to SelectFile() and SelectRange()
+type Strategy interface {
+    Order(candidates []Candidate)
+}
+
+
+// Compares the candidates by size ascending, ties broken by name.
+//
+// @Parameters
+// - a:  The first candidate to compare
+// - b:  The second candidate to compare
+//
+// @Returns
+// - Negative if a goes first, positive if b goes first, 0 if equal
+//
+func CompareSize(a Candidate, b Candidate) int {
+    if a.Size != b.Size {
+        if a.Size < b.Size {
+            return -1
+        }
+
+        return 1
+    }
+
+    return strings.Compare(a.Name, b.Name)
+}
+
+
+// LargestFirst selects the largest candidates first, keeping the big files moving early
+// so the run does not end waiting on them
+type LargestFirst struct{}
+
+// Sorts the candidates by size descending, ties broken by name.
+//
+// @Parameters
+// - candidates:  The candidates to sort in place
+//
+func (LargestFirst) Order(candidates []Candidate) {
+    slices.SortStableFunc(candidates, func(a Candidate, b Candidate) int {
+        // If the sizes differ, the larger goes first
+        if a.Size != b.Size {
+            return CompareSize(b, a)
+        }
+
+        return strings.Compare(a.Name, b.Name)
+    })
+}
+
+
+// SmallestFirst selects the smallest candidates first, for fast wins early in the run
+type SmallestFirst struct{}
+
+// Sorts the candidates by size ascending, ties broken by name.
+//
+// @Parameters
+// - candidates:  The candidates to sort in place
+//
+func (SmallestFirst) Order(candidates []Candidate) {
+    slices.SortStableFunc(candidates, CompareSize)
+}
+
+
+// RoundRobin selects candidates from each subdir of the load dir in turn, so every
+// wordlist collection gets its fair share of the fleet instead of one being drained first
+type RoundRobin struct {
+    last   string  // Dir of the candidate most recently put first
+    mutx   sync.Mutex
+    served bool    // Whether any dir was put first yet
+}
+
+// Creates a round-robin strategy starting with the first dir by name.
+//
+// @Returns
+// - The initialized round-robin strategy
+//
+func NewRoundRobin() *RoundRobin {
+    return &RoundRobin{}
+}
+
+// Interleaves the candidates across their dirs, starting with the dir after the one
+// put first by the previous call. Within a dir the passed in order is kept.
+//
+// @Parameters
+// - candidates:  The candidates to sort in place
+//
+func (roundRobin *RoundRobin) Order(candidates []Candidate) {
+    // If there is nothing to interleave
+    if len(candidates) < 2 {
+        return
+    }
+
+    roundRobin.mutx.Lock()
+    defer roundRobin.mutx.Unlock()
+
+    var dirs []string
+    groups := make(map[string][]Candidate)
+
+    // Iterate through the candidates grouping them by dir
+    for _, candidate := range candidates {
+        if _, exists := groups[candidate.Dir]; !exists {
+            dirs = append(dirs, candidate.Dir)
+        }
+
+        groups[candidate.Dir] = append(groups[candidate.Dir], candidate)
+    }
+
+    slices.Sort(dirs)
+
+    // If a dir was served before, rotate the dirs so the one after it goes first
+    if roundRobin.served {
+        start, found := slices.BinarySearch(dirs, roundRobin.last)
+        if found {
+            start += 1
+        }
+
+        start %= len(dirs)
+        dirs = slices.Concat(dirs[start:], dirs[:start])
+    }
+
+    ordered := candidates[:0]
+    // Iterate through the groups taking one candidate from each per pass
+    for pass := 0; len(ordered) < len(candidates); pass++ {
+        for _, dir := range dirs {
+            if pass < len(groups[dir]) {
+                ordered = append(ordered, groups[dir][pass])
+            }
+        }
+    }
+
+    roundRobin.last = ordered[0].Dir
+    roundRobin.served = true
+}
@@ -0,0 +1,88 @@
+package disk_test
+
+import (
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
+	"github.com/stretchr/testify/assert"
+)
+
+
+// Gets the paths of the candidates in their current order
+func candidatePaths(candidates []disk.Candidate) []string {
+    var paths []string
+    for _, candidate := range candidates {
+        paths = append(paths, candidate.Path)
+    }
+
+    return paths
+}
+
+
+func sizedCandidates() []disk.Candidate {
+    return []disk.Candidate{
+        {Name: "b.txt", Path: "/load/b.txt", Size: 300},
+        {Name: "a.txt", Path: "/load/a.txt", Size: 100},
+        {Name: "c.txt", Path: "/load/c.txt", Size: 300},
+        {Name: "d.txt", Path: "/load/d.txt", Size: 200},
+    }
+}
+
+
+func TestSizeStrategies(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    candidates := sizedCandidates()
+    disk.SmallestFirst{}.Order(candidates)
+    // Ensure the smallest go first with ties broken by name
+    assert.Equal([]string{"/load/a.txt", "/load/d.txt", "/load/b.txt", "/load/c.txt"},
+                 candidatePaths(candidates))
+
+    candidates = sizedCandidates()
+    disk.LargestFirst{}.Order(candidates)
+    // Ensure the largest go first with ties broken by name
+    assert.Equal([]string{"/load/b.txt", "/load/c.txt", "/load/d.txt", "/load/a.txt"},
+                 candidatePaths(candidates))
+}
+
+
+func TestRoundRobin(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    dirCandidates := func() []disk.Candidate {
+        return []disk.Candidate{
+            {Dir: "leaks", Name: "l1", Path: "leaks/l1"},
+            {Dir: "leaks", Name: "l2", Path: "leaks/l2"},
+            {Dir: "leaks", Name: "l3", Path: "leaks/l3"},
+            {Dir: "", Name: "t1", Path: "t1"},
+            {Dir: "names", Name: "n1", Path: "names/n1"},
+        }
+    }
+
+    var order disk.Ordering = disk.NewRoundRobin().Order
+
+    candidates := dirCandidates()
+    order(candidates)
+    // Ensure the dirs take turns in name order, keeping the order within each dir
+    assert.Equal([]string{"t1", "leaks/l1", "names/n1", "leaks/l2", "leaks/l3"},
+                 candidatePaths(candidates))
+
+    candidates = dirCandidates()
+    order(candidates)
+    // Ensure the next call starts with the dir after the one served first
+    assert.Equal([]string{"leaks/l1", "names/n1", "t1", "leaks/l2", "leaks/l3"},
+                 candidatePaths(candidates))
+
+    // Ensure the rotation continues past a dir that no longer has candidates
+    candidates = []disk.Candidate{{Dir: "", Name: "t1", Path: "t1"},
+                                  {Dir: "names", Name: "n1", Path: "names/n1"}}
+    order(candidates)
+    assert.Equal([]string{"names/n1", "t1"}, candidatePaths(candidates))
+
+    candidates = dirCandidates()
+    order(candidates)
+    assert.Equal([]string{"t1", "leaks/l1", "names/n1", "leaks/l2", "leaks/l3"},
+                 candidatePaths(candidates))
+}
@@ -2,12 +2,14 @@ package display
 
 import (
 	"fmt"
+	"regexp"
 	"time"
 )
 
 // Package level variables
 const AnsiClear = "\x1b[H\x1b[2J"
 const AnsiReset = "\033[0m"
+var ReAnsiSequence = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
 
 
 // Clear the terminal display with a sleep prior if specified.
@@ -75,3 +77,17 @@ func CtextPrefix(bracketColor string, innerColor string,
                  innerContent string) string {
     return bracketColor + "[" + innerColor + innerContent + bracketColor + "] " + AnsiReset
 }
+
+
+// Removes any ANSI escape sequences from the passed in text so it
+// can be displayed or stored outside of a terminal.
+//
+// @Parameters
+// - text:  The text that may contain ANSI escape sequences
+//
+// @Returns
+// - The text with all ANSI escape sequences removed
+//
+func StripAnsi(text string) string {
+    return ReAnsiSequence.ReplaceAllString(text, "")
+}
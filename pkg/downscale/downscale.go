@@ -0,0 +1,82 @@
+package downscale
+
+import (
+	"net"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/awsutils"
+	"github.com/ngimb64/Kloud-Kraken/pkg/cost"
+)
+
+// Downscaler ends the clients left idle with no work remaining for them and terminates
+// their instances, so they stop billing while the rest of the fleet finishes
+type Downscaler struct {
+    ec2Man   *awsutils.Ec2Manger
+    watchdog *cost.Watchdog  // Watchdog tracking the fleet spend, nil when not tracked
+}
+
+
+// Creates a downscaler terminating the instances of the passed in fleet.
+//
+// @Parameters
+// - ec2Man:  The EC2 manager of the launched fleet
+// - watchdog:  The watchdog tracking the fleet spend, nil when not tracked
+//
+// @Returns
+// - The initialized downscaler
+//
+func New(ec2Man *awsutils.Ec2Manger, watchdog *cost.Watchdog) *Downscaler {
+    return &Downscaler{ec2Man: ec2Man, watchdog: watchdog}
+}
+
+
+// Checks whether a client with no work to assign is idle, holding no wordlists delivered
+// to it and no keyspace ranges, so it is ended instead of told to wait for more.
+//
+// @Parameters
+// - pending:  The number of wordlists delivered to the client it has not completed
+// - ranges:  The number of keyspace ranges assigned to the client it has not completed
+//
+// @Returns
+// - true if idle clients are downscaled and the client holds no work, otherwise false
+//
+func (downscaler *Downscaler) Idle(pending int, ranges int) bool {
+    // If idle clients are not downscaled
+    if downscaler == nil {
+        return false
+    }
+
+    return pending == 0 && ranges == 0
+}
+
+
+// Terminates the instance of a client that returned its results with no work remaining
+// for it, removing it from the tracked fleet spend.
+//
+// @Parameters
+// - client:  The host:port address of the finished client
+// - callTime:  The length of time the API calls are allowed to execute
+//
+// @Returns
+// - The ID of the terminated instance
+// - Error if it occurs, otherwise nil on success
+//
+func (downscaler *Downscaler) Terminate(client string,
+                                        callTime time.Duration) (string, error) {
+    host, _, err := net.SplitHostPort(client)
+    if err != nil {
+        host = client
+    }
+
+    instanceId, err := downscaler.ec2Man.TerminateByIp(host, callTime)
+    if err != nil {
+        return "", err
+    }
+
+    // If the fleet spend is tracked, stop accruing the terminated instance
+    if downscaler.watchdog != nil {
+        downscaler.watchdog.RemoveInstances(1, time.Now())
+    }
+
+    return instanceId, nil
+}
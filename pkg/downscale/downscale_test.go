@@ -0,0 +1,67 @@
+package downscale_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/awsutils"
+	"github.com/ngimb64/Kloud-Kraken/pkg/awsutils/awstest"
+	"github.com/ngimb64/Kloud-Kraken/pkg/cost"
+	"github.com/ngimb64/Kloud-Kraken/pkg/downscale"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestIdle(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    var disabled *downscale.Downscaler
+    // Ensure no client is idle when idle clients are not downscaled
+    assert.False(disabled.Idle(0, 0))
+
+    downscaler := downscale.New(nil, nil)
+    // Ensure only a client holding no wordlists or ranges is idle
+    assert.True(downscaler.Idle(0, 0))
+    assert.False(downscaler.Idle(1, 0))
+    assert.False(downscaler.Idle(0, 1))
+}
+
+
+func TestTerminate(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    fake := awstest.NewEc2()
+    ec2Man := awsutils.NewEc2Manager("ami-0123456789abcdef0", fake, 2, "g4dn.xlarge",
+                                     awsutils.ServiceTagValue, "ClientRole", "a1b2c3d4",
+                                     nil, nil, "", 0, false, []byte("#!/bin/bash"))
+
+    err := ec2Man.CreateEc2Instances(time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    start := time.Now().Add(-time.Hour)
+    watchdog := cost.NewWatchdog(10.0, 2, 0, 0, 0, start)
+    downscaler := downscale.New(ec2Man, watchdog)
+
+    // Ensure the instance of the finished client is terminated by its address
+    instanceId, err := downscaler.Terminate("10.0.0.2:5000", time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(ec2Man.InstanceIds()[1], instanceId)
+
+    states, err := ec2Man.InstanceStates(time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(map[string]int{"running": 1, "terminated": 1}, states)
+
+    // Ensure the terminated instance stops accruing spend while the other keeps billing
+    spent := watchdog.Spent(time.Now())
+    assert.InDelta(spent + 10.0, watchdog.Spent(time.Now().Add(time.Hour)), 0.01)
+
+    // Ensure a client without an instance of the run is not removed from the spend
+    _, err = downscaler.Terminate("10.0.0.9:5000", time.Second)
+    assert.NotEqual(nil, err)
+    assert.InDelta(spent + 10.0, watchdog.Spent(time.Now().Add(time.Hour)), 0.01)
+}
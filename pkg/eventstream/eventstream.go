@@ -0,0 +1,92 @@
+package eventstream
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Event types emitted by the server
+const (
+    BudgetExceeded     = "budget_exceeded"
+    CertRotated        = "cert_rotated"
+    ClientConnected    = "client_connected"
+    ClientDisconnected = "client_disconnected"
+    CostEstimated      = "cost_estimated"
+    DryRunPlan         = "dry_run_plan"
+    ExceptionRecorded  = "exception_recorded"
+    FleetHibernated    = "fleet_hibernated"
+    FleetResumed       = "fleet_resumed"
+    GpuInventory       = "gpu_inventory"
+    HashcatStatus      = "hashcat_status"
+    HashesCracked      = "hashes_cracked"
+    InstanceMetadata   = "instance_metadata"
+    InstanceTerminated = "instance_terminated"
+    InstancesLaunched  = "instances_launched"
+    MalformedMessage   = "malformed_message"
+    MergePlan          = "merge_plan"
+    RunComplete        = "run_complete"
+    RunStarted         = "run_started"
+    ServerListening    = "server_listening"
+    TransferComplete   = "transfer_complete"
+    TransferProgress   = "transfer_progress"
+    WorkSplit          = "work_split"
+)
+
+
+// Emitter writes events as single line JSON objects
+type Emitter struct {
+    mutx   sync.Mutex
+    writer io.Writer
+}
+
+// Creates a new emitter that writes JSON lines to the passed in writer.
+//
+// @Parameters
+// - writer:  The writer where JSON events are written (usually stdout)
+//
+// @Returns
+// - The initialized emitter
+//
+func NewEmitter(writer io.Writer) *Emitter {
+    return &Emitter{writer: writer}
+}
+
+// Writes the event as a single JSON line with the event type and a UTC
+// timestamp, the reserved event and time keys can not be overwritten by fields.
+//
+// @Parameters
+// - eventType:  The type of event being emitted
+// - fields:  The key-value data associated with the event
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (emitter *Emitter) Emit(eventType string, fields map[string]any) error {
+    if emitter == nil {
+        return nil
+    }
+
+    event := make(map[string]any, len(fields) + 2)
+    // Copy the fields into the event
+    for key, value := range fields {
+        event[key] = value
+    }
+
+    event["event"] = eventType
+    event["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+
+    // Encode the event into JSON
+    eventJson, err := json.Marshal(event)
+    if err != nil {
+        return err
+    }
+
+    emitter.mutx.Lock()
+    defer emitter.mutx.Unlock()
+
+    // Write the JSON event followed by newline
+    _, err = emitter.writer.Write(append(eventJson, '\n'))
+    return err
+}
@@ -0,0 +1,67 @@
+package eventstream_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/eventstream"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestEmit(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    var output bytes.Buffer
+    emitter := eventstream.NewEmitter(&output)
+
+    // Emit an event attempting to overwrite a reserved key
+    err := emitter.Emit(eventstream.TransferComplete, map[string]any{
+        "client": "10.0.0.1:5000",
+        "event":  "overwritten",
+        "size":   1024,
+    })
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Emit a second event without fields
+    err = emitter.Emit(eventstream.RunComplete, nil)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    var events []map[string]any
+    scanner := bufio.NewScanner(&output)
+    // Parse each JSON line of output
+    for scanner.Scan() {
+        var event map[string]any
+
+        err = json.Unmarshal(scanner.Bytes(), &event)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+
+        events = append(events, event)
+    }
+
+    // Ensure each event was written on its own line
+    assert.Equal(2, len(events))
+    // Ensure the reserved event key was not overwritten
+    assert.Equal(eventstream.TransferComplete, events[0]["event"])
+    assert.Equal("10.0.0.1:5000", events[0]["client"])
+    assert.Equal(float64(1024), events[0]["size"])
+    assert.NotEmpty(events[0]["time"])
+    assert.Equal(eventstream.RunComplete, events[1]["event"])
+}
+
+
+func TestNilEmitter(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    var emitter *eventstream.Emitter
+
+    // Ensure emitting on a disabled emitter is a no-op
+    assert.Equal(nil, emitter.Emit(eventstream.RunStarted, nil))
+}
@@ -0,0 +1,286 @@
+package exceptions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind is the category of an exception recorded during a run
+type Kind string
+
+// Package level variables
+const (
+    ClientRefused   Kind = "client_refused"    // Clients refused for an incompatible protocol
+    DeadLettered    Kind = "dead_lettered"     // Work that was given up on and never completed
+    ResultMissing   Kind = "result_missing"    // Cracked hashes that were never received
+    TransferRetried Kind = "transfer_retried"  // Transfers that failed and were attempted again
+    WorkRequeued    Kind = "work_requeued"     // Work units returned to the queue from a client
+)
+
+// Kinds in the order they are displayed and reported
+var reportOrder = []Kind{TransferRetried, WorkRequeued, DeadLettered, ResultMissing,
+                         ClientRefused}
+
+
+// Entry stores a single exception that occurred during the run
+type Entry struct {
+    Client string
+    Detail string
+    Kind   Kind
+    Time   time.Time
+}
+
+
+// Tracker aggregates every exception during a run, tracks retry attempts per work
+// item, and the work items delivered to each client that are not yet confirmed done
+type Tracker struct {
+    attempts   map[string]int
+    entries    []Entry
+    maxRetries int
+    mutx       sync.Mutex
+    pending    map[string][]string
+    requeued   []string
+}
+
+// Creates a new exception tracker.
+//
+// @Parameters
+// - maxRetries:  The number of times a work item is retried before it is dead-lettered
+//
+// @Returns
+// - The initialized tracker
+//
+func NewTracker(maxRetries int) *Tracker {
+    return &Tracker{
+        attempts:   make(map[string]int),
+        maxRetries: maxRetries,
+        pending:    make(map[string][]string),
+    }
+}
+
+// Records an exception that occurred during the run.
+//
+// @Parameters
+// - kind:  The category of the exception
+// - client:  The address of the client involved, empty if not client specific
+// - detail:  The description of what happened
+//
+func (tracker *Tracker) Record(kind Kind, client string, detail string) {
+    tracker.mutx.Lock()
+    defer tracker.mutx.Unlock()
+
+    tracker.entries = append(tracker.entries, Entry{Client: client, Detail: detail,
+                                                    Kind: kind, Time: time.Now()})
+}
+
+// Counts a failed attempt for the work item and decides if it should be retried.
+//
+// @Parameters
+// - item:  The work item that failed, such as a wordlist path
+//
+// @Returns
+// - true if the item has attempts remaining, false if it should be dead-lettered
+//
+func (tracker *Tracker) Retry(item string) bool {
+    tracker.mutx.Lock()
+    defer tracker.mutx.Unlock()
+
+    tracker.attempts[item] += 1
+    // If the item has not exceeded the max number of retries, track it as requeued
+    if tracker.attempts[item] <= tracker.maxRetries {
+        if !slices.Contains(tracker.requeued, item) {
+            tracker.requeued = append(tracker.requeued, item)
+        }
+
+        return true
+    }
+
+    return false
+}
+
+// Gets the work items that were requeued during the run, used to verify they were
+// picked up again before the run completed.
+//
+// @Returns
+// - The requeued work items
+//
+func (tracker *Tracker) Requeued() []string {
+    tracker.mutx.Lock()
+    defer tracker.mutx.Unlock()
+
+    return slices.Clone(tracker.requeued)
+}
+
+// Adds a work item delivered to the client that is not yet confirmed done.
+//
+// @Parameters
+// - client:  The address of the client the item was delivered to
+// - item:  The delivered work item
+//
+func (tracker *Tracker) AddPending(client string, item string) {
+    tracker.mutx.Lock()
+    defer tracker.mutx.Unlock()
+
+    tracker.pending[client] = append(tracker.pending[client], item)
+}
+
+// Counts the work items pending on the client without removing them.
+//
+// @Parameters
+// - client:  The address of the client
+//
+// @Returns
+// - The number of work items pending on the client
+//
+func (tracker *Tracker) Pending(client string) int {
+    tracker.mutx.Lock()
+    defer tracker.mutx.Unlock()
+
+    return len(tracker.pending[client])
+}
+
+// Removes and returns the work items pending on the client, called when the client
+// confirms its work is done or disconnects before it is.
+//
+// @Parameters
+// - client:  The address of the client
+//
+// @Returns
+// - The work items that were pending on the client
+//
+func (tracker *Tracker) TakePending(client string) []string {
+    tracker.mutx.Lock()
+    defer tracker.mutx.Unlock()
+
+    items := tracker.pending[client]
+    delete(tracker.pending, client)
+    return items
+}
+
+// Removes the work item pending on the client by its file name, called when the client
+// rejects a delivered item so it is requeued rather than left pending.
+//
+// @Parameters
+// - client:  The address of the client
+// - name:  The file name of the work item
+//
+// @Returns
+// - The removed work item
+// - true/false depending on whether the item was pending on the client
+//
+func (tracker *Tracker) RemovePending(client string, name string) (string, bool) {
+    tracker.mutx.Lock()
+    defer tracker.mutx.Unlock()
+
+    // Iterate through the pending items removing the first with the name
+    for index, item := range tracker.pending[client] {
+        if filepath.Base(item) == name {
+            tracker.pending[client] = slices.Delete(tracker.pending[client], index, index+1)
+            return item, true
+        }
+    }
+
+    return "", false
+}
+
+// Gets a copy of the recorded exceptions in the order they occurred.
+//
+// @Returns
+// - The recorded exception entries
+//
+func (tracker *Tracker) Entries() []Entry {
+    tracker.mutx.Lock()
+    defer tracker.mutx.Unlock()
+
+    return slices.Clone(tracker.entries)
+}
+
+// Counts the recorded exceptions of each kind.
+//
+// @Returns
+// - Map of exception kind to the number recorded
+//
+func (tracker *Tracker) Counts() map[Kind]int {
+    tracker.mutx.Lock()
+    defer tracker.mutx.Unlock()
+
+    counts := make(map[Kind]int)
+    // Iterate through the entries counting each kind
+    for _, entry := range tracker.entries {
+        counts[entry.Kind] += 1
+    }
+
+    return counts
+}
+
+// Formats the exception counts into a single line summary.
+//
+// @Returns
+// - The formatted exception summary
+//
+func (tracker *Tracker) Summary() string {
+    counts := tracker.Counts()
+    parts := make([]string, 0, len(reportOrder))
+
+    // Iterate through the kinds in report order
+    for _, kind := range reportOrder {
+        parts = append(parts, fmt.Sprintf("%s: %d", kind, counts[kind]))
+    }
+
+    return "Exceptions - " + strings.Join(parts, ", ")
+}
+
+// Writes the final exceptions report grouped by kind to the passed in path, a run
+// with no dead-lettered work or missing results is reported as complete.
+//
+// @Parameters
+// - reportPath:  The path where the report is written
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (tracker *Tracker) WriteReport(reportPath string) error {
+    var report strings.Builder
+    entries := tracker.Entries()
+    counts := tracker.Counts()
+
+    report.WriteString("Kloud Kraken exceptions report\n")
+    report.WriteString(tracker.Summary() + "\n\n")
+
+    // If all the work was completed and every result was received
+    if counts[DeadLettered] == 0 && counts[ResultMissing] == 0 {
+        report.WriteString("Status: complete, all work units were processed " +
+                           "and all results received\n")
+    } else {
+        report.WriteString("Status: incomplete, see the dead-lettered work and " +
+                           "missing results below\n")
+    }
+
+    // Iterate through the kinds in report order
+    for _, kind := range reportOrder {
+        report.WriteString(fmt.Sprintf("\n[%s] %d\n", kind, counts[kind]))
+
+        // Iterate through the entries of the current kind
+        for _, entry := range entries {
+            if entry.Kind != kind {
+                continue
+            }
+
+            client := entry.Client
+            if client == "" {
+                client = "-"
+            }
+
+            report.WriteString(fmt.Sprintf("  %s  %s  %s\n",
+                                           entry.Time.Format(time.RFC3339), client,
+                                           entry.Detail))
+        }
+    }
+
+    return os.WriteFile(reportPath, []byte(report.String()), 0644)
+}
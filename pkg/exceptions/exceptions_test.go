@@ -0,0 +1,96 @@
+package exceptions_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/exceptions"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestRetry(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    tracker := exceptions.NewTracker(2)
+
+    // Ensure the item is retried up to the max retries then given up on
+    assert.True(tracker.Retry("/load/wordlist.txt"))
+    assert.True(tracker.Retry("/load/wordlist.txt"))
+    assert.False(tracker.Retry("/load/wordlist.txt"))
+
+    // Ensure the requeued item is only tracked once
+    assert.Equal([]string{"/load/wordlist.txt"}, tracker.Requeued())
+}
+
+
+func TestPending(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    tracker := exceptions.NewTracker(3)
+    tracker.AddPending("10.0.0.1:5000", "/load/first.txt")
+    tracker.AddPending("10.0.0.1:5000", "/load/second.txt")
+    tracker.AddPending("10.0.0.2:5000", "/load/third.txt")
+    tracker.AddPending("10.0.0.1:5000", "/load/rejected.txt")
+    assert.Equal(3, tracker.Pending("10.0.0.1:5000"))
+
+    // Ensure a rejected item is removed by its file name
+    item, found := tracker.RemovePending("10.0.0.1:5000", "rejected.txt")
+    assert.True(found)
+    assert.Equal("/load/rejected.txt", item)
+    _, found = tracker.RemovePending("10.0.0.2:5000", "first.txt")
+    assert.False(found)
+
+    // Ensure only the items of the client are taken
+    assert.Equal([]string{"/load/first.txt", "/load/second.txt"},
+                 tracker.TakePending("10.0.0.1:5000"))
+    // Ensure the pending items are cleared once taken
+    assert.Empty(tracker.TakePending("10.0.0.1:5000"))
+    assert.Equal([]string{"/load/third.txt"}, tracker.TakePending("10.0.0.2:5000"))
+}
+
+
+func TestWriteReport(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    reportPath := filepath.Join(t.TempDir(), "exceptions_report.txt")
+    tracker := exceptions.NewTracker(3)
+
+    // Write the report for a run without exceptions
+    err := tracker.WriteReport(reportPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    report, err := os.ReadFile(reportPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the run is reported as complete
+    assert.Contains(string(report), "Status: complete")
+
+    tracker.Record(exceptions.TransferRetried, "10.0.0.1:5000", "wordlist.txt transfer failed")
+    tracker.Record(exceptions.DeadLettered, "", "wordlist.txt exceeded retries")
+
+    // Ensure the counts reflect the recorded exceptions
+    counts := tracker.Counts()
+    assert.Equal(1, counts[exceptions.TransferRetried])
+    assert.Equal(1, counts[exceptions.DeadLettered])
+    assert.Equal(0, counts[exceptions.WorkRequeued])
+
+    // Write the report with exceptions
+    err = tracker.WriteReport(reportPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    report, err = os.ReadFile(reportPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the run is reported incomplete with each exception listed
+    assert.Contains(string(report), "Status: incomplete")
+    assert.Contains(string(report), "[dead_lettered] 1")
+    assert.True(strings.Contains(string(report), "10.0.0.1:5000  wordlist.txt transfer failed"))
+}
@@ -0,0 +1,417 @@
+package gpu
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/hashcat"
+)
+
+// Package level variables
+var ReBackendDevice = regexp.MustCompile(`Backend Device ID #(\d+)(?: \(Alias: #(\d+)\))?`)
+var ReDeviceType = regexp.MustCompile(`^\s*Type\.+:\s*(\S+)`)
+
+
+// Device is a single GPU reported by nvidia-smi
+type Device struct {
+    Driver    string
+    Index     int
+    MemoryMiB int
+    Name      string
+}
+
+
+// Inventory stores the GPUs of the host and the backend devices hashcat can use
+type Inventory struct {
+    Devices        []Device
+    HashcatDevices int
+    HashcatGpus    int
+}
+
+// Summarizes the GPUs grouped by model, ex: 8x NVIDIA A100-SXM4-40GB 40960MiB driver 535.
+//
+// @Returns
+// - The summary of the GPUs, or none if no GPUs were detected
+//
+func (inventory Inventory) Summary() string {
+    // If no GPUs were detected
+    if len(inventory.Devices) == 0 {
+        return "none"
+    }
+
+    var models []string
+    counts := make(map[string]int)
+
+    // Iterate through the devices counting each model in the order first seen
+    for _, device := range inventory.Devices {
+        model := fmt.Sprintf("%s %dMiB driver %s", device.Name, device.MemoryMiB,
+                             device.Driver)
+        if counts[model] == 0 {
+            models = append(models, model)
+        }
+
+        counts[model] += 1
+    }
+
+    summaries := make([]string, 0, len(models))
+    // Iterate through the models formatting their count
+    for _, model := range models {
+        summaries = append(summaries, fmt.Sprintf("%dx %s", counts[model], model))
+    }
+
+    return strings.Join(summaries, ", ")
+}
+
+
+// Report is the inventory a client sends to the server before work is assigned
+type Report struct {
+    Gpus        int
+    HashcatGpus int
+    Summary     string
+}
+
+
+// Parses the output of nvidia-smi queried for index, name, memory.total, and
+// driver_version in CSV format without a header or units.
+//
+// @Parameters
+// - output:  The nvidia-smi output to parse
+//
+// @Returns
+// - The parsed GPU devices
+// - Error if it occurs, otherwise nil on success
+//
+func ParseNvidiaSmi(output []byte) ([]Device, error) {
+    var devices []Device
+    scanner := bufio.NewScanner(bytes.NewReader(output))
+
+    // Iterate through the output line by line
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        // If the line is empty
+        if line == "" {
+            continue
+        }
+
+        fields := strings.Split(line, ",")
+        // If the line does not have the queried fields
+        if len(fields) != 4 {
+            return nil, fmt.Errorf("improper nvidia-smi line - %q", line)
+        }
+
+        index, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+        if err != nil {
+            return nil, fmt.Errorf("improper nvidia-smi index - %w", err)
+        }
+
+        memory, err := strconv.Atoi(strings.TrimSpace(fields[2]))
+        if err != nil {
+            return nil, fmt.Errorf("improper nvidia-smi memory - %w", err)
+        }
+
+        devices = append(devices, Device{Driver: strings.TrimSpace(fields[3]), Index: index,
+                                         MemoryMiB: memory,
+                                         Name: strings.TrimSpace(fields[1])})
+    }
+
+    return devices, scanner.Err()
+}
+
+
+// Parses the output of hashcat -I counting the unique backend devices, devices listed
+// under both CUDA and OpenCL are aliases of each other and only counted once.
+//
+// @Parameters
+// - output:  The hashcat -I output to parse
+//
+// @Returns
+// - The number of unique backend devices
+// - The number of unique backend devices that are GPUs
+//
+func ParseHashcatInfo(output []byte) (int, int) {
+    var devices int
+    var gpus int
+    var counted bool
+    var section string
+    scanner := bufio.NewScanner(bytes.NewReader(output))
+
+    // Iterate through the output line by line
+    for scanner.Scan() {
+        line := scanner.Text()
+
+        // If the line starts a backend API section (ex: CUDA Info:)
+        if strings.HasSuffix(line, " Info:") {
+            section = strings.TrimSuffix(line, " Info:")
+            continue
+        }
+
+        // If the line starts a backend device
+        if match := ReBackendDevice.FindStringSubmatch(line); match != nil {
+            id, _ := strconv.Atoi(match[1])
+            alias, _ := strconv.Atoi(match[2])
+            // Only count the first listing of a device that has an alias
+            counted = match[2] == "" || id < alias
+
+            if counted {
+                devices += 1
+
+                // If the device is under CUDA or HIP it is always a GPU
+                if section == "CUDA" || section == "HIP" {
+                    gpus += 1
+                }
+            }
+
+            continue
+        }
+
+        // If the type of a counted OpenCL device is a GPU
+        if match := ReDeviceType.FindStringSubmatch(line); match != nil && counted &&
+        section == "OpenCL" && match[1] == "GPU" {
+            gpus += 1
+        }
+    }
+
+    return devices, gpus
+}
+
+
+// Detects the GPUs with nvidia-smi and the backend devices hashcat can use. When the
+// GPUs are required, missing GPUs or GPUs not visible to hashcat are returned as errors.
+//
+// @Parameters
+// - required:  Whether the host must have GPUs usable by hashcat
+// - callTime:  The length of time each detection command is allowed to execute
+//
+// @Returns
+// - The detected inventory
+// - Error if it occurs, otherwise nil on success
+//
+func Detect(required bool, callTime time.Duration) (Inventory, error) {
+    var inventory Inventory
+
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    // Query the GPUs of the host
+    output, err := exec.CommandContext(ctx, "nvidia-smi",
+                                       "--query-gpu=index,name,memory.total,driver_version",
+                                       "--format=csv,noheader,nounits").Output()
+    // If nvidia-smi is missing or the driver is not loaded
+    if err != nil {
+        if required {
+            return inventory, fmt.Errorf("error querying GPUs with nvidia-smi - %w", err)
+        }
+    } else {
+        inventory.Devices, err = ParseNvidiaSmi(output)
+        if err != nil {
+            return inventory, err
+        }
+    }
+
+    // Query the backend devices available to hashcat
+    output, err = exec.CommandContext(ctx, hashcat.Binary, "-I").Output()
+    if err != nil {
+        if required {
+            return inventory, fmt.Errorf("error querying hashcat backend devices - %w", err)
+        }
+    } else {
+        inventory.HashcatDevices, inventory.HashcatGpus = ParseHashcatInfo(output)
+    }
+
+    // If GPUs are required, ensure they exist and hashcat can use them
+    if required && len(inventory.Devices) == 0 {
+        return inventory, fmt.Errorf("no GPUs detected by nvidia-smi")
+    }
+
+    if required && inventory.HashcatGpus == 0 {
+        return inventory, fmt.Errorf("hashcat has no CUDA or OpenCL GPU devices, %d GPUs " +
+                                     "detected by nvidia-smi", len(inventory.Devices))
+    }
+
+    return inventory, nil
+}
+
+
+// Formats the inventory message a client sends before work is assigned, the summary
+// is truncated to fit the messaging buffer.
+//
+// @Parameters
+// - inventory:  The detected inventory of the client
+//
+// @Returns
+// - The formatted inventory message
+//
+func FormatInventory(inventory Inventory) []byte {
+    header := fmt.Sprintf("%s%d:%d:", globals.GPU_INVENTORY_PREFIX, len(inventory.Devices),
+                          inventory.HashcatGpus)
+    summary := inventory.Summary()
+    maxSummary := globals.MESSAGE_BUFFER_SIZE - len(header) - len(globals.TRANSFER_SUFFIX)
+
+    // If the summary is too long for the messaging buffer
+    if len(summary) > maxSummary {
+        summary = summary[:maxSummary]
+    }
+
+    return append([]byte(header + summary), globals.TRANSFER_SUFFIX...)
+}
+
+
+// Parses the inventory report from the client inventory message.
+//
+// @Parameters
+// - message:  The inventory message
+//
+// @Returns
+// - The parsed inventory report
+// - Error if it occurs, otherwise nil on success
+//
+func ParseInventory(message []byte) (Report, error) {
+    var report Report
+
+    // If the message does not start with prefix or end with closed bracket
+    if !bytes.HasPrefix(message, globals.GPU_INVENTORY_PREFIX) ||
+    !bytes.HasSuffix(message, globals.TRANSFER_SUFFIX) {
+        return report, fmt.Errorf("improper prefix or suffix in GPU inventory message")
+    }
+
+    body := bytes.TrimSuffix(bytes.TrimPrefix(message, globals.GPU_INVENTORY_PREFIX),
+                             globals.TRANSFER_SUFFIX)
+    fields := bytes.SplitN(body, globals.COLON_DELIMITER, 3)
+    // If the counts or summary are missing
+    if len(fields) != 3 {
+        return report, fmt.Errorf("improper fields in GPU inventory message")
+    }
+
+    var err error
+    // Parse the number of GPUs detected by nvidia-smi
+    report.Gpus, err = strconv.Atoi(string(fields[0]))
+    if err != nil {
+        return report, fmt.Errorf("improper GPU count in inventory message - %w", err)
+    }
+
+    // Parse the number of GPUs visible to hashcat
+    report.HashcatGpus, err = strconv.Atoi(string(fields[1]))
+    if err != nil {
+        return report, fmt.Errorf("improper hashcat GPU count in inventory message - %w", err)
+    }
+
+    report.Summary = string(fields[2])
+    return report, nil
+}
+
+
+// Partitions the backend device IDs 1 through devices into contiguous groups, one per
+// cracking process sharing the host. Hashcat lists CUDA devices first, so on NVIDIA
+// hosts the IDs of the GPUs are 1 through the GPU count.
+//
+// @Parameters
+// - devices:  The number of backend devices to partition
+// - groups:  The number of cracking processes sharing the devices
+//
+// @Returns
+// - The comma separated device IDs of each group, nil if there are not enough devices
+//
+func PartitionDevices(devices int, groups int) []string {
+    ids := make([]string, 0, max(devices, 0))
+    // Iterate through the device count building the IDs
+    for id := 1; id <= devices; id++ {
+        ids = append(ids, strconv.Itoa(id))
+    }
+
+    return PartitionIds(ids, groups)
+}
+
+
+// Partitions the backend device IDs into contiguous groups, one per cracking process
+// sharing the devices. IDs that do not divide evenly are spread over the first groups.
+//
+// @Parameters
+// - ids:  The backend device IDs to partition
+// - groups:  The number of cracking processes sharing the devices
+//
+// @Returns
+// - The comma separated device IDs of each group, nil if there are not enough devices
+//
+func PartitionIds(ids []string, groups int) []string {
+    // If there are not enough devices to give each group at least one
+    if groups < 1 || len(ids) < groups {
+        return nil
+    }
+
+    partitions := make([]string, 0, groups)
+    start := 0
+
+    // Iterate through the groups assigning each its share of the devices
+    for index := range groups {
+        count := len(ids) / groups
+        // If the group takes one of the remaining devices
+        if index < len(ids) % groups {
+            count += 1
+        }
+
+        partitions = append(partitions, strings.Join(ids[start:start + count], ","))
+        start += count
+    }
+
+    return partitions
+}
+
+
+// Formats the device assignment message the server sends after the inventory, an empty
+// assignment leaves the client on its configured devices.
+//
+// @Parameters
+// - devices:  The comma separated backend device IDs assigned to the client
+//
+// @Returns
+// - The formatted device assignment message
+//
+func FormatAssignment(devices string) []byte {
+    message := append([]byte{}, globals.DEVICE_ASSIGNMENT_PREFIX...)
+    message = append(message, devices...)
+    return append(message, globals.TRANSFER_SUFFIX...)
+}
+
+
+// Parses the backend devices from the device assignment message.
+//
+// @Parameters
+// - message:  The device assignment message
+//
+// @Returns
+// - The comma separated backend device IDs, empty if none were assigned
+// - Error if it occurs, otherwise nil on success
+//
+func ParseAssignment(message []byte) (string, error) {
+    // If the message does not start with prefix or end with closed bracket
+    if !bytes.HasPrefix(message, globals.DEVICE_ASSIGNMENT_PREFIX) ||
+    !bytes.HasSuffix(message, globals.TRANSFER_SUFFIX) {
+        return "", fmt.Errorf("improper prefix or suffix in device assignment message")
+    }
+
+    devices := string(bytes.TrimSuffix(bytes.TrimPrefix(message,
+                                                        globals.DEVICE_ASSIGNMENT_PREFIX),
+                                       globals.TRANSFER_SUFFIX))
+
+    // If no devices were assigned
+    if devices == "" {
+        return "", nil
+    }
+
+    // Iterate through the assigned IDs ensuring each is numeric
+    for _, id := range strings.Split(devices, ",") {
+        if _, err := strconv.Atoi(id); err != nil {
+            return "", fmt.Errorf("improper device ID %q in device assignment message", id)
+        }
+    }
+
+    return devices, nil
+}
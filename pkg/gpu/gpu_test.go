@@ -0,0 +1,157 @@
+package gpu_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/gpu"
+	"github.com/stretchr/testify/assert"
+)
+
+// Trimmed hashcat -I output of a single GPU listed under both CUDA and OpenCL with a CPU
+const hashcatInfo = `hashcat (v6.2.6) starting in backend information mode
+
+CUDA Info:
+==========
+
+CUDA.Version.: 12.2
+
+Backend Device ID #1 (Alias: #2)
+  Name...........: Tesla T4
+  Processor(s)...: 40
+
+OpenCL Info:
+============
+
+OpenCL Platform ID #1
+  Vendor..: NVIDIA Corporation
+
+  Backend Device ID #2 (Alias: #1)
+    Type...........: GPU
+    Name...........: Tesla T4
+
+OpenCL Platform ID #2
+  Vendor..: The pocl project
+
+  Backend Device ID #3
+    Type...........: CPU
+    Name...........: pthread-Intel(R) Xeon(R) Platinum 8259CL CPU @ 2.50GHz
+`
+
+
+func TestParseNvidiaSmi(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    output := "0, NVIDIA A100-SXM4-40GB, 40960, 535.183.01\n" +
+              "1, NVIDIA A100-SXM4-40GB, 40960, 535.183.01\n\n"
+    // Parse the queried GPUs
+    devices, err := gpu.ParseNvidiaSmi([]byte(output))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(2, len(devices))
+    assert.Equal(gpu.Device{Driver: "535.183.01", Index: 1, MemoryMiB: 40960,
+                            Name: "NVIDIA A100-SXM4-40GB"}, devices[1])
+
+    // Ensure improper output is rejected
+    _, err = gpu.ParseNvidiaSmi([]byte("NVIDIA-SMI has failed"))
+    assert.NotEqual(nil, err)
+}
+
+
+func TestParseHashcatInfo(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    devices, gpus := gpu.ParseHashcatInfo([]byte(hashcatInfo))
+    // Ensure the aliased GPU is counted once and the CPU is not a GPU
+    assert.Equal(2, devices)
+    assert.Equal(1, gpus)
+
+    devices, gpus = gpu.ParseHashcatInfo([]byte("No devices found/left."))
+    // Ensure no devices are counted from empty output
+    assert.Equal(0, devices)
+    assert.Equal(0, gpus)
+}
+
+
+func TestInventoryMessage(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    inventory := gpu.Inventory{HashcatDevices: 2, HashcatGpus: 2}
+    // Add two of the same GPU model
+    for index := 0; index < 2; index++ {
+        inventory.Devices = append(inventory.Devices, gpu.Device{Driver: "535", Index: index,
+                                                                 MemoryMiB: 15360,
+                                                                 Name: "Tesla T4"})
+    }
+
+    // Ensure the GPUs are grouped by model
+    assert.Equal("2x Tesla T4 15360MiB driver 535", inventory.Summary())
+
+    // Format then parse the inventory message
+    report, err := gpu.ParseInventory(gpu.FormatInventory(inventory))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(gpu.Report{Gpus: 2, HashcatGpus: 2,
+                            Summary: "2x Tesla T4 15360MiB driver 535"}, report)
+
+    // Ensure a long summary is truncated to fit the messaging buffer
+    inventory.Devices[1].Name = strings.Repeat("A", 300)
+    assert.Equal(globals.MESSAGE_BUFFER_SIZE, len(gpu.FormatInventory(inventory)))
+
+    // Ensure an empty inventory reports no GPUs
+    report, err = gpu.ParseInventory(gpu.FormatInventory(gpu.Inventory{}))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("none", report.Summary)
+
+    // Ensure improper messages are rejected
+    _, err = gpu.ParseInventory([]byte("<GPU_INVENTORY:x:1:none>"))
+    assert.NotEqual(nil, err)
+    _, err = gpu.ParseInventory([]byte("<GPU_INVENTORY:1>"))
+    assert.NotEqual(nil, err)
+}
+
+
+func TestPartitionDevices(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Ensure the devices are split evenly into contiguous groups
+    assert.Equal([]string{"1,2,3,4", "5,6,7,8"}, gpu.PartitionDevices(8, 2))
+    // Ensure remaining devices are spread over the first groups
+    assert.Equal([]string{"1,2", "3,4", "5"}, gpu.PartitionDevices(5, 3))
+    // Ensure there is nothing to partition without a device for each group
+    assert.Equal([]string(nil), gpu.PartitionDevices(1, 2))
+    assert.Equal([]string(nil), gpu.PartitionDevices(4, 0))
+
+    // Ensure configured device IDs are partitioned in their listed order
+    assert.Equal([]string{"2,4", "6"}, gpu.PartitionIds([]string{"2", "4", "6"}, 2))
+}
+
+
+func TestAssignmentMessage(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Format then parse the assignment message
+    devices, err := gpu.ParseAssignment(gpu.FormatAssignment("3,4"))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("3,4", devices)
+
+    // Ensure an empty assignment is parsed as no devices
+    devices, err = gpu.ParseAssignment(gpu.FormatAssignment(""))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("", devices)
+
+    // Ensure improper messages are rejected
+    _, err = gpu.ParseAssignment([]byte("<DEVICE_ASSIGNMENT:1,x>"))
+    assert.NotEqual(nil, err)
+    _, err = gpu.ParseAssignment([]byte("<GPU_INVENTORY:1>"))
+    assert.NotEqual(nil, err)
+}
@@ -0,0 +1,396 @@
+package gpu
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Degrees Celsius the GPUs must cool below the temperature limit before hashcat resumes,
+// so a GPU hovering at the limit does not pause and resume hashcat on every sample
+const TempHysteresis = 10
+
+
+// Sample is the telemetry of a single GPU queried from nvidia-smi
+type Sample struct {
+    Index          int
+    MemoryTotalMiB int
+    MemoryUsedMiB  int
+    PowerWatts     float64  // 0 when the GPU does not report its power draw
+    Temp           int      // Celsius
+    Util           int      // Percent
+}
+
+
+// Parses the output of nvidia-smi queried for index, temperature.gpu, utilization.gpu,
+// memory.used, memory.total, and power.draw in CSV format without a header or units.
+//
+// @Parameters
+// - output:  The nvidia-smi output to parse
+//
+// @Returns
+// - The parsed GPU samples
+// - Error if it occurs, otherwise nil on success
+//
+func ParseSamples(output []byte) ([]Sample, error) {
+    var samples []Sample
+    scanner := bufio.NewScanner(bytes.NewReader(output))
+
+    // Iterate through the output line by line
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        // If the line is empty
+        if line == "" {
+            continue
+        }
+
+        fields := strings.Split(line, ",")
+        // If the line does not have the queried fields
+        if len(fields) != 6 {
+            return nil, fmt.Errorf("improper nvidia-smi sample line - %q", line)
+        }
+
+        var values [5]int
+        // Iterate through the integer fields parsing each
+        for index := range values {
+            value, err := strconv.Atoi(strings.TrimSpace(fields[index]))
+            if err != nil {
+                return nil, fmt.Errorf("improper nvidia-smi sample field - %w", err)
+            }
+
+            values[index] = value
+        }
+
+        sample := Sample{Index: values[0], MemoryTotalMiB: values[4],
+                         MemoryUsedMiB: values[3], Temp: values[1], Util: values[2]}
+        power := strings.TrimSpace(fields[5])
+
+        // If the GPU reports its power draw, GPUs without it report [N/A]
+        if !strings.HasPrefix(power, "[") {
+            watts, err := strconv.ParseFloat(power, 64)
+            if err != nil {
+                return nil, fmt.Errorf("improper nvidia-smi power draw - %w", err)
+            }
+
+            sample.PowerWatts = watts
+        }
+
+        samples = append(samples, sample)
+    }
+
+    return samples, scanner.Err()
+}
+
+
+// Queries the current telemetry of the GPUs from nvidia-smi.
+//
+// @Parameters
+// - callTime:  The length of time the query is allowed to execute
+//
+// @Returns
+// - The sampled GPUs
+// - Error if it occurs, otherwise nil on success
+//
+func QuerySamples(callTime time.Duration) ([]Sample, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), callTime)
+    defer cancel()
+
+    output, err := exec.CommandContext(ctx, "nvidia-smi",
+        "--query-gpu=index,temperature.gpu,utilization.gpu,memory.used,memory.total," +
+        "power.draw", "--format=csv,noheader,nounits").Output()
+    if err != nil {
+        return nil, fmt.Errorf("error sampling GPUs with nvidia-smi - %w", err)
+    }
+
+    return ParseSamples(output)
+}
+
+
+// Gets the temperature of the hottest GPU in the samples.
+//
+// @Parameters
+// - samples:  The sampled GPUs
+//
+// @Returns
+// - The temperature in Celsius, 0 if there are no samples
+//
+func MaxTemp(samples []Sample) int {
+    temp := 0
+    for _, sample := range samples {
+        temp = max(temp, sample.Temp)
+    }
+
+    return temp
+}
+
+
+// Gets the samples as the fields of a log message.
+//
+// @Parameters
+// - samples:  The sampled GPUs
+//
+// @Returns
+// - The zap fields of the samples
+//
+func SampleLogArgs(samples []Sample) []any {
+    var memoryUsed int
+    var power float64
+    var util int
+
+    // Iterate through the samples totaling the GPUs of the host
+    for _, sample := range samples {
+        memoryUsed += sample.MemoryUsedMiB
+        power += sample.PowerWatts
+        util += sample.Util
+    }
+
+    // Average the utilization over the GPUs
+    if len(samples) > 0 {
+        util /= len(samples)
+    }
+
+    return []any{zap.Any("gpus", samples),
+                 zap.Int("memory_used_mib", memoryUsed),
+                 zap.Float64("power_watts", power),
+                 zap.Int("temp_max", MaxTemp(samples)),
+                 zap.Int("util_avg", util)}
+}
+
+
+// Monitor samples the GPUs on an interval and pauses the tracked hashcat processes while
+// the hottest GPU is at or above the temperature limit, resuming them once it cools
+type Monitor struct {
+    interval  time.Duration
+    mutex     sync.Mutex
+    paused    bool
+    processes map[int]*os.Process
+    stopCh    chan struct{}
+    stopOnce  sync.Once
+    tempLimit int
+    wg        sync.WaitGroup
+}
+
+
+// Creates a monitor sampling on the passed in interval.
+//
+// @Parameters
+// - interval:  The duration of time between samples
+// - tempLimit:  The temperature in Celsius hashcat is paused at, 0 only logs the samples
+//
+// @Returns
+// - The initialized monitor
+//
+func NewMonitor(interval time.Duration, tempLimit int) *Monitor {
+    return &Monitor{interval: interval, processes: make(map[int]*os.Process),
+                    stopCh: make(chan struct{}), tempLimit: tempLimit}
+}
+
+
+// Starts sampling in a Goroutine until Stop() is called.
+//
+// @Parameters
+// - query:  Samples the GPUs, usually QuerySamples
+// - onSample:  Called with each sample or the error sampling failed with
+// - onChange:  Called with the hottest temperature when hashcat is paused or resumed
+//
+func (monitor *Monitor) Start(query func() ([]Sample, error),
+                              onSample func(samples []Sample, err error),
+                              onChange func(paused bool, temp int, err error)) {
+    monitor.wg.Add(1)
+
+    go func() {
+        defer monitor.wg.Done()
+
+        ticker := time.NewTicker(monitor.interval)
+        defer ticker.Stop()
+
+        for {
+            select {
+            case <-ticker.C:
+                samples, err := query()
+                onSample(samples, err)
+                // If sampling failed it is retried on the next tick
+                if err != nil {
+                    continue
+                }
+
+                temp := MaxTemp(samples)
+                changed, err := monitor.Update(temp)
+                if changed {
+                    onChange(monitor.Paused(), temp, err)
+                }
+            case <-monitor.stopCh:
+                return
+            }
+        }
+    } ()
+}
+
+
+// Updates the pause state from the hottest temperature, pausing the tracked processes
+// at the limit and resuming them once the temperature falls below the hysteresis.
+//
+// @Parameters
+// - temp:  The temperature of the hottest GPU in Celsius
+//
+// @Returns
+// - Whether hashcat was paused or resumed
+// - Error if signaling a process failed, otherwise nil on success
+//
+func (monitor *Monitor) Update(temp int) (bool, error) {
+    // If the monitor only logs the samples
+    if monitor == nil || monitor.tempLimit <= 0 {
+        return false, nil
+    }
+
+    monitor.mutex.Lock()
+    defer monitor.mutex.Unlock()
+
+    // If the GPUs reached the limit while running
+    if !monitor.paused && temp >= monitor.tempLimit {
+        monitor.paused = true
+        return true, monitor.signalAll(suspendProcess)
+    }
+
+    // If the GPUs cooled below the hysteresis while paused
+    if monitor.paused && temp < monitor.tempLimit - TempHysteresis {
+        monitor.paused = false
+        return true, monitor.signalAll(resumeProcess)
+    }
+
+    return false, nil
+}
+
+
+// Signals each of the tracked processes, collecting the first error.
+//
+// @Parameters
+// - signal:  Suspends or resumes the process
+//
+// @Returns
+// - The first error signaling a process, otherwise nil on success
+//
+func (monitor *Monitor) signalAll(signal func(process *os.Process) error) error {
+    var firstErr error
+
+    // Iterate through the tracked processes signaling each
+    for pid, process := range monitor.processes {
+        err := signal(process)
+        if err != nil && firstErr == nil {
+            firstErr = fmt.Errorf("error signaling hashcat process %d - %w", pid, err)
+        }
+    }
+
+    return firstErr
+}
+
+
+// Gets whether hashcat is paused on the temperature limit.
+//
+// @Returns
+// - Whether the tracked processes are paused
+//
+func (monitor *Monitor) Paused() bool {
+    // If the monitor was never created
+    if monitor == nil {
+        return false
+    }
+
+    monitor.mutex.Lock()
+    defer monitor.mutex.Unlock()
+
+    return monitor.paused
+}
+
+
+// Tracks the started hashcat process so it is paused with the others, a process started
+// while the GPUs are over the limit is paused immediately.
+//
+// @Parameters
+// - process:  The started hashcat process
+//
+// @Returns
+// - Error if pausing the process failed, otherwise nil on success
+//
+func (monitor *Monitor) Track(process *os.Process) error {
+    // If the monitor was never created or only logs the samples
+    if monitor == nil || monitor.tempLimit <= 0 {
+        return nil
+    }
+
+    monitor.mutex.Lock()
+    defer monitor.mutex.Unlock()
+
+    monitor.processes[process.Pid] = process
+
+    // If the GPUs are over the limit, pause the process with the others
+    if monitor.paused {
+        return suspendProcess(process)
+    }
+
+    return nil
+}
+
+
+// Stops tracking the hashcat process, resuming it if paused so it can handle the signal to
+// exit. Called before the process is signaled and once it exits, releasing a process that
+// is not tracked does nothing.
+//
+// @Parameters
+// - process:  The hashcat process to release
+//
+func (monitor *Monitor) Release(process *os.Process) {
+    // If the monitor was never created or only logs the samples
+    if monitor == nil || monitor.tempLimit <= 0 {
+        return
+    }
+
+    monitor.mutex.Lock()
+    defer monitor.mutex.Unlock()
+
+    // If the process is not tracked
+    if _, ok := monitor.processes[process.Pid]; !ok {
+        return
+    }
+
+    delete(monitor.processes, process.Pid)
+    // If the process is paused, resume it
+    if monitor.paused {
+        resumeProcess(process)
+    }
+}
+
+
+// Stops the monitor, waiting for a sample in progress to complete and resuming any
+// paused processes.
+//
+func (monitor *Monitor) Stop() {
+    // If the monitor was never created
+    if monitor == nil {
+        return
+    }
+
+    monitor.stopOnce.Do(func() {
+        close(monitor.stopCh)
+    })
+
+    monitor.wg.Wait()
+
+    monitor.mutex.Lock()
+    defer monitor.mutex.Unlock()
+
+    // If the processes are paused, resume them since nothing will resume them later
+    if monitor.paused {
+        monitor.paused = false
+        monitor.signalAll(resumeProcess)
+    }
+}
@@ -0,0 +1,129 @@
+package gpu_test
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/gpu"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestParseSamples(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    output := "0, 67, 98, 31245, 40960, 287.41\n" +
+              "1, 71, 97, 30112, 40960, [N/A]\n\n"
+    // Parse the sampled GPUs
+    samples, err := gpu.ParseSamples([]byte(output))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(2, len(samples))
+    assert.Equal(gpu.Sample{Index: 0, MemoryTotalMiB: 40960, MemoryUsedMiB: 31245,
+                            PowerWatts: 287.41, Temp: 67, Util: 98}, samples[0])
+    // Ensure GPUs without a power reading report none
+    assert.Equal(0.0, samples[1].PowerWatts)
+    assert.Equal(71, gpu.MaxTemp(samples))
+    assert.Equal(0, gpu.MaxTemp(nil))
+
+    falacies := []string{"NVIDIA-SMI has failed", "0, 67, 98, 31245, 40960",
+                         "0, hot, 98, 31245, 40960, 287.41", "0, 67, 98, 31245, 40960, high"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        _, err = gpu.ParseSamples([]byte(falacy))
+        // Ensure the error is not nil meaning failed operation
+        assert.NotEqual(nil, err)
+    }
+}
+
+
+func TestMonitorUpdate(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    monitor := gpu.NewMonitor(time.Minute, 85)
+    // Ensure hashcat keeps running under the limit
+    changed, err := monitor.Update(84)
+    assert.Equal(nil, err)
+    assert.False(changed)
+
+    // Ensure hashcat is paused at the limit
+    changed, err = monitor.Update(85)
+    assert.Equal(nil, err)
+    assert.True(changed)
+    assert.True(monitor.Paused())
+
+    // Ensure hashcat stays paused until the GPUs cool below the hysteresis
+    changed, _ = monitor.Update(76)
+    assert.False(changed)
+    assert.True(monitor.Paused())
+
+    changed, err = monitor.Update(74)
+    assert.Equal(nil, err)
+    assert.True(changed)
+    assert.False(monitor.Paused())
+
+    // Ensure a monitor without a limit never pauses hashcat
+    logOnly := gpu.NewMonitor(time.Minute, 0)
+    changed, _ = logOnly.Update(110)
+    assert.False(changed)
+    assert.False(logOnly.Paused())
+
+    // Ensure a monitor that was never created is safe to use
+    var nilMonitor *gpu.Monitor
+    assert.False(nilMonitor.Paused())
+    assert.Equal(nil, nilMonitor.Track(nil))
+    nilMonitor.Release(nil)
+    nilMonitor.Stop()
+}
+
+
+func TestMonitorTrack(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    cmd := exec.Command("sleep", "30")
+    err := cmd.Start()
+    if err != nil {
+        t.Skipf("sleep is unavailable - %v", err)
+    }
+    // Kill the process on local exit
+    defer cmd.Process.Kill()
+
+    monitor := gpu.NewMonitor(time.Minute, 85)
+    _, err = monitor.Update(90)
+    assert.Equal(nil, err)
+
+    // Ensure a process started while paused is paused with the others
+    err = monitor.Track(cmd.Process)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the released process is resumed so it can exit and releasing it is repeatable
+    monitor.Release(cmd.Process)
+    monitor.Release(cmd.Process)
+    assert.Equal(nil, cmd.Process.Kill())
+    cmd.Wait()
+
+    // Ensure samples are reported and the change in temperature pauses hashcat
+    changes := make(chan bool, 1)
+    monitor = gpu.NewMonitor(time.Millisecond, 85)
+    monitor.Start(func() ([]gpu.Sample, error) {
+        return []gpu.Sample{{Temp: 88}}, nil
+    }, func(samples []gpu.Sample, err error) {}, func(paused bool, temp int, err error) {
+        changes <- paused
+    })
+
+    select {
+    case paused := <-changes:
+        assert.True(paused)
+    case <-time.After(5 * time.Second):
+        t.Fatal("monitor never paused on the temperature limit")
+    }
+
+    monitor.Stop()
+    // Ensure stopping the monitor resumes hashcat
+    assert.False(monitor.Paused())
+}
@@ -0,0 +1,34 @@
+//go:build !windows
+
+package gpu
+
+import (
+	"os"
+	"syscall"
+)
+
+
+// Suspends the process until it is resumed, the GPUs idle while hashcat is stopped.
+//
+// @Parameters
+// - process:  The process to suspend
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func suspendProcess(process *os.Process) error {
+    return process.Signal(syscall.SIGSTOP)
+}
+
+
+// Resumes the suspended process.
+//
+// @Parameters
+// - process:  The process to resume
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func resumeProcess(process *os.Process) error {
+    return process.Signal(syscall.SIGCONT)
+}
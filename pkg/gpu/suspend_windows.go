@@ -0,0 +1,32 @@
+package gpu
+
+import (
+	"errors"
+	"os"
+)
+
+
+// Windows has no stop signal, so the monitor only logs the samples of Windows clients.
+//
+// @Parameters
+// - process:  Unused, the process keeps running
+//
+// @Returns
+// - Error since suspending processes is unsupported
+//
+func suspendProcess(process *os.Process) error {
+    return errors.New("suspending processes is not supported on windows")
+}
+
+
+// Windows has no stop signal, so there is never a suspended process to resume.
+//
+// @Parameters
+// - process:  Unused, the process was never suspended
+//
+// @Returns
+// - Error since resuming processes is unsupported
+//
+func resumeProcess(process *os.Process) error {
+    return errors.New("resuming processes is not supported on windows")
+}
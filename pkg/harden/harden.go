@@ -0,0 +1,177 @@
+package harden
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+// Package level variables
+const DefaultUser = "kloudkraken"                       // Unprivileged user the client drops to
+const UnitName = "kloud-kraken-client.service"          // Name of the generated systemd unit
+const UnitPath = "/etc/systemd/system/" + UnitName      // Path the systemd unit is written to
+
+
+// Identity is the unprivileged user the client drops to after setup
+type Identity struct {
+    Gid    int
+    Groups []int
+    Name   string
+    Uid    int
+}
+
+
+// Looks up the unprivileged user along with its supplementary groups.
+//
+// @Parameters
+// - name:  The name of the user to look up
+//
+// @Returns
+// - The identity of the user
+// - Error if it occurs, otherwise nil on success
+//
+func LookupUser(name string) (Identity, error) {
+    account, err := user.Lookup(name)
+    if err != nil {
+        return Identity{}, fmt.Errorf("error looking up user %s - %w", name, err)
+    }
+
+    identity := Identity{Name: name}
+
+    identity.Uid, err = strconv.Atoi(account.Uid)
+    if err != nil {
+        return Identity{}, fmt.Errorf("error parsing uid of %s - %w", name, err)
+    }
+
+    identity.Gid, err = strconv.Atoi(account.Gid)
+    if err != nil {
+        return Identity{}, fmt.Errorf("error parsing gid of %s - %w", name, err)
+    }
+
+    // If the user is root there are no privileges to drop
+    if identity.Uid == 0 {
+        return Identity{}, fmt.Errorf("user %s is root", name)
+    }
+
+    groupIds, err := account.GroupIds()
+    if err != nil {
+        return Identity{}, fmt.Errorf("error getting groups of %s - %w", name, err)
+    }
+
+    // Iterate through the supplementary groups, such as video for GPU device access
+    for _, groupId := range groupIds {
+        gid, err := strconv.Atoi(groupId)
+        if err != nil {
+            return Identity{}, fmt.Errorf("error parsing group of %s - %w", name, err)
+        }
+
+        identity.Groups = append(identity.Groups, gid)
+    }
+
+    return identity, nil
+}
+
+
+// Hands the paths over to the unprivileged user, restricting dirs to 0700 and files to
+// 0600 so loot and hashes are not readable by any other user on the instance.
+//
+// @Parameters
+// - identity:  The unprivileged user the paths are handed to
+// - paths:  The dirs and files to restrict
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func RestrictPaths(identity Identity, paths []string) error {
+    // Iterate through the paths restricting each
+    for _, filePath := range paths {
+        info, err := os.Stat(filePath)
+        if err != nil {
+            return err
+        }
+
+        mode := os.FileMode(0600)
+        // If the path is a dir, it needs to be traversable by its owner
+        if info.IsDir() {
+            mode = 0700
+        }
+
+        err = os.Chown(filePath, identity.Uid, identity.Gid)
+        if err != nil {
+            return fmt.Errorf("error changing owner of %s - %w", filePath, err)
+        }
+
+        err = os.Chmod(filePath, mode)
+        if err != nil {
+            return fmt.Errorf("error changing mode of %s - %w", filePath, err)
+        }
+    }
+
+    return nil
+}
+
+
+// Quotes an arg for a systemd ExecStart line, escaping the specifier and environment
+// variable characters systemd would otherwise expand.
+//
+// @Parameters
+// - arg:  The arg to be quoted
+//
+// @Returns
+// - The quoted arg
+//
+func quoteArg(arg string) string {
+    replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$")
+    return `"` + replacer.Replace(arg) + `"`
+}
+
+
+// Generates a systemd unit that confines the client to the data dir and temp dir with
+// the network and capabilities it needs to set up and drop privileges. The client
+// still starts as root so it can hand the data dirs over to the unprivileged user.
+//
+// @Parameters
+// - execPath:  The absolute path to the client binary
+// - args:  The args passed to the client
+// - dataPath:  The path where the client stores its data dirs
+//
+// @Returns
+// - The generated systemd unit
+//
+func SystemdUnit(execPath string, args []string, dataPath string) string {
+    execStart := []string{quoteArg(execPath)}
+    for _, arg := range args {
+        execStart = append(execStart, quoteArg(arg))
+    }
+
+    return fmt.Sprintf(`[Unit]
+Description=Kloud Kraken client
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=exec
+ExecStart=%s
+WorkingDirectory=%s
+NoNewPrivileges=yes
+ProtectSystem=strict
+ProtectHome=yes
+ReadWritePaths=%s /tmp
+ProtectKernelModules=yes
+ProtectKernelTunables=yes
+ProtectKernelLogs=yes
+ProtectControlGroups=yes
+ProtectClock=yes
+RestrictSUIDSGID=yes
+RestrictRealtime=yes
+RestrictNamespaces=yes
+LockPersonality=yes
+CapabilityBoundingSet=CAP_CHOWN CAP_FOWNER CAP_SETGID CAP_SETUID
+RestrictAddressFamilies=AF_INET AF_INET6 AF_UNIX AF_NETLINK
+SystemCallArchitectures=native
+SystemCallFilter=@system-service
+SystemCallErrorNumber=EPERM
+`, strings.Join(execStart, " "), dataPath, dataPath)
+}
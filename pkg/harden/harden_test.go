@@ -0,0 +1,73 @@
+package harden_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/harden"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestLookupUser(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Ensure root is refused since there would be no privileges to drop
+    _, err := harden.LookupUser("root")
+    assert.ErrorContains(err, "is root")
+
+    // Ensure a missing user is an error
+    _, err = harden.LookupUser("kloud-kraken-missing-user")
+    assert.NotNil(err)
+}
+
+
+func TestRestrictPaths(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    dirPath := t.TempDir()
+    filePath := filepath.Join(dirPath, "loot.txt")
+    err := os.WriteFile(filePath, []byte("hash:plain\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Hand the paths to the current user, which is allowed without root
+    identity := harden.Identity{Gid: os.Getgid(), Uid: os.Getuid()}
+    err = harden.RestrictPaths(identity, []string{dirPath, filePath})
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    dirInfo, err := os.Stat(dirPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(os.FileMode(0700), dirInfo.Mode().Perm())
+
+    fileInfo, err := os.Stat(filePath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(os.FileMode(0600), fileInfo.Mode().Perm())
+
+    // Ensure a missing path is an error
+    err = harden.RestrictPaths(identity, []string{filepath.Join(dirPath, "missing")})
+    assert.NotNil(err)
+}
+
+
+func TestSystemdUnit(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    unit := harden.SystemdUnit("/usr/local/bin/kloud-kraken-client",
+                               []string{"-hashMask=?d?d%d", "-brainPassword=a$b\"c"},
+                               "/mnt/instance-store")
+
+    // Ensure systemd specifiers, variables and quotes are escaped
+    assert.Contains(unit, `ExecStart="/usr/local/bin/kloud-kraken-client" ` +
+                          `"-hashMask=?d?d%%d" "-brainPassword=a$$b\"c"`)
+    assert.Contains(unit, "ReadWritePaths=/mnt/instance-store /tmp")
+    assert.True(strings.HasPrefix(unit, "[Unit]"))
+}
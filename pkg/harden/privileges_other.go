@@ -0,0 +1,43 @@
+//go:build !windows
+
+package harden
+
+import (
+	"fmt"
+	"syscall"
+)
+
+
+// Permanently drops the root privileges of the process to the unprivileged user, the
+// groups are set before the uid since they can not be changed afterwards.
+//
+// @Parameters
+// - identity:  The unprivileged user to drop to
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func DropPrivileges(identity Identity) error {
+    err := syscall.Setgroups(identity.Groups)
+    if err != nil {
+        return fmt.Errorf("error setting groups - %w", err)
+    }
+
+    err = syscall.Setgid(identity.Gid)
+    if err != nil {
+        return fmt.Errorf("error setting gid - %w", err)
+    }
+
+    err = syscall.Setuid(identity.Uid)
+    if err != nil {
+        return fmt.Errorf("error setting uid - %w", err)
+    }
+
+    // Ensure root can not be regained
+    if syscall.Setuid(0) == nil {
+        return fmt.Errorf("root privileges were regained after dropping to %s",
+                          identity.Name)
+    }
+
+    return nil
+}
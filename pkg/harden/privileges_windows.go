@@ -0,0 +1,16 @@
+package harden
+
+import "errors"
+
+
+// Windows has no uid to drop to, so hardening is rejected in the config for Windows clients.
+//
+// @Parameters
+// - identity:  Unused, there are no privileges to drop
+//
+// @Returns
+// - Error since dropping privileges is unsupported
+//
+func DropPrivileges(identity Identity) error {
+    return errors.New("dropping privileges is not supported on windows")
+}
@@ -0,0 +1,118 @@
+package hashcat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Package level variables
+var ExtraArgs = map[string]bool{  // Flags allowed as extra args, true if they take a number
+    "--backend-ignore-cuda":      false,
+    "--backend-ignore-hip":       false,
+    "--backend-ignore-metal":     false,
+    "--backend-ignore-opencl":    false,
+    "--backend-vector-width":     true,
+    "--bitmap-max":               true,
+    "--bitmap-min":               true,
+    "--hex-charset":              false,
+    "--hex-salt":                 false,
+    "--hex-wordlist":             false,
+    "--hwmon-disable":            false,
+    "--hwmon-temp-abort":         true,
+    "--keep-guessing":            false,
+    "--markov-classic":           false,
+    "--markov-disable":           false,
+    "--markov-threshold":         true,
+    "--scrypt-tmto":              true,
+    "--segment-size":             true,
+    "--self-test-disable":        false,
+    "--slow-candidates":          false,
+    "--spin-damp":                true,
+    "--wordlist-autohex-disable": false,
+}
+
+
+// Ensures the extra hashcat arg is an allowed flag, with a number if the flag takes one
+// (ex: --bitmap-max=24). Flags the client manages itself, like the output, session, and
+// status flags, are not allowed so they can not break the run.
+//
+// @Parameters
+// - arg:  The extra arg to validate
+//
+// @Returns
+// - Error if the arg is not allowed or its value is improper, otherwise nil
+//
+func ValidateExtraArg(arg string) error {
+    flag, value, hasValue := strings.Cut(arg, "=")
+
+    takesValue, allowed := ExtraArgs[flag]
+    // If the flag is not in the allow-list
+    if !allowed {
+        return fmt.Errorf("hashcat flag %q is not allowed as an extra arg", flag)
+    }
+
+    // If the flag takes a number but none was set
+    if takesValue && !hasValue {
+        return fmt.Errorf("hashcat flag %s requires a value (ex: %s=1)", flag, flag)
+    }
+
+    // If the flag is a toggle but a value was set
+    if !takesValue && hasValue {
+        return fmt.Errorf("hashcat flag %s takes no value", flag)
+    }
+
+    // If the flag takes a number, ensure it is a non-negative integer
+    if takesValue {
+        number, err := strconv.Atoi(value)
+        if err != nil || number < 0 {
+            return fmt.Errorf("hashcat flag %s requires a non-negative number", flag)
+        }
+    }
+
+    return nil
+}
+
+
+// Formats the extra hashcat args into a flag value, separated by commas so the user
+// data needs no quoting.
+//
+// @Parameters
+// - args:  The validated extra hashcat args
+//
+// @Returns
+// - The formatted extra args
+//
+func FormatExtraArgs(args []string) string {
+    return strings.Join(args, ",")
+}
+
+
+// Parses the extra hashcat args from the flag value formatted by FormatExtraArgs(),
+// validating each against the allow-list.
+//
+// @Parameters
+// - formatted:  The formatted extra args
+//
+// @Returns
+// - The extra args in their configured order
+// - Error if any arg is not allowed, otherwise nil on success
+//
+func ParseExtraArgs(formatted string) ([]string, error) {
+    // If no extra args are set
+    if formatted == "" {
+        return nil, nil
+    }
+
+    args := strings.Split(formatted, ",")
+    // Iterate through the args validating each
+    for _, arg := range args {
+        err := ValidateExtraArg(arg)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    return args, nil
+}
+
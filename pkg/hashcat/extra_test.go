@@ -0,0 +1,47 @@
+package hashcat_test
+
+import (
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/hashcat"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestExtraArgs(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    args := []string{"--bitmap-max=24", "--spin-damp=0", "--hwmon-disable"}
+    // Ensure the allowed args pass validation
+    for _, arg := range args {
+        assert.Equal(nil, hashcat.ValidateExtraArg(arg))
+    }
+
+    // Ensure the args survive the round trip through the flag value
+    parsed, err := hashcat.ParseExtraArgs(hashcat.FormatExtraArgs(args))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(args, parsed)
+
+    parsed, err = hashcat.ParseExtraArgs("")
+    assert.Equal(nil, err)
+    assert.Equal(0, len(parsed))
+
+    falacies := []string{
+        "--outfile=/tmp/loot.txt",
+        "--session=other",
+        "--bitmap-max",
+        "--bitmap-max=-1",
+        "--bitmap-max=24;reboot",
+        "--hwmon-disable=1",
+        "-w",
+    }
+    // Iterate through the improper args ensuring each is rejected
+    for _, falacy := range falacies {
+        assert.NotEqual(nil, hashcat.ValidateExtraArg(falacy))
+    }
+
+    _, err = hashcat.ParseExtraArgs("--hwmon-disable,--potfile-disable")
+    assert.NotEqual(nil, err)
+}
@@ -0,0 +1,187 @@
+package hashcat
+
+import (
+	"sync"
+	"time"
+)
+
+// Fleet combines the hashcat statuses forwarded by the clients into the speed, keyspace
+// coverage, and completion estimate of the whole fleet
+type Fleet struct {
+    clients       map[string]*fleetClient
+    finishedDone  int64          // Candidates processed by the finished jobs
+    finishedJobs  int
+    finishedTotal int64          // Keyspace of the finished jobs
+    mutx          sync.Mutex
+    staleAfter    time.Duration
+    unstarted     int            // Jobs queued on the server not yet started by a client
+}
+
+
+// fleetClient is the last status of the job a client is running
+type fleetClient struct {
+    status  Status
+    updated time.Time
+}
+
+
+// FleetStatus is the combined progress of the jobs of the fleet
+type FleetStatus struct {
+    Clients  int            // Clients with a current status
+    Coverage float64        // Fraction of the estimated keyspace processed from 0 to 1
+    Done     int64          // Candidates processed
+    Eta      time.Duration  // Time to process the rest of the keyspace, 0 when unknown
+    Speed    float64        // Hashes per second
+    Total    int64          // Keyspace of the started jobs plus the estimate of the rest
+}
+
+
+// Creates an empty fleet aggregate.
+//
+// @Parameters
+// - staleAfter:  The duration a status counts towards the fleet speed after it is received
+//
+// @Returns
+// - The initialized fleet aggregate
+//
+func NewFleet(staleAfter time.Duration) *Fleet {
+    return &Fleet{clients: make(map[string]*fleetClient), staleAfter: staleAfter}
+}
+
+
+// Records the latest status of the client, finishing its previous job if the status is
+// of a new one.
+//
+// @Parameters
+// - client:  The address of the client that forwarded the status
+// - status:  The hashcat status of the client
+// - now:  The time the status was received
+//
+func (fleet *Fleet) Update(client string, status Status, now time.Time) {
+    // If the fleet is not aggregated
+    if fleet == nil {
+        return
+    }
+
+    fleet.mutx.Lock()
+    defer fleet.mutx.Unlock()
+
+    previous, exists := fleet.clients[client]
+    // If the status is of a new job, the previous one is finished
+    if exists && (previous.status.TimeStart != status.TimeStart ||
+       previous.status.Progress[1] != status.Progress[1] ||
+       previous.status.Progress[0] > status.Progress[0]) {
+        fleet.finish(previous.status)
+    }
+
+    fleet.clients[client] = &fleetClient{status: status, updated: now}
+}
+
+
+// Finishes the job of the client, called when the client disconnects.
+//
+// @Parameters
+// - client:  The address of the client
+//
+func (fleet *Fleet) Remove(client string) {
+    // If the fleet is not aggregated
+    if fleet == nil {
+        return
+    }
+
+    fleet.mutx.Lock()
+    defer fleet.mutx.Unlock()
+
+    // If the client forwarded a status
+    if previous, exists := fleet.clients[client]; exists {
+        fleet.finish(previous.status)
+        delete(fleet.clients, client)
+    }
+}
+
+
+// Adds the progress of the finished job to the totals, must be called with the lock held.
+//
+// @Parameters
+// - status:  The last status of the finished job
+//
+func (fleet *Fleet) finish(status Status) {
+    fleet.finishedDone += status.Progress[0]
+    fleet.finishedJobs += 1
+    fleet.finishedTotal += status.Progress[1]
+}
+
+
+// Sets the number of jobs queued on the server that no client has started, their keyspace
+// is estimated from the average keyspace of the jobs seen so far since the merged
+// wordlists and keyspace ranges are near equal in size.
+//
+// @Parameters
+// - jobs:  The number of unstarted jobs
+//
+func (fleet *Fleet) SetUnstarted(jobs int) {
+    // If the fleet is not aggregated
+    if fleet == nil {
+        return
+    }
+
+    fleet.mutx.Lock()
+    defer fleet.mutx.Unlock()
+
+    fleet.unstarted = max(jobs, 0)
+}
+
+
+// Combines the statuses of the clients into the progress of the fleet.
+//
+// @Parameters
+// - now:  The time the statuses are compared against to leave out stale speeds
+//
+// @Returns
+// - The combined progress of the fleet
+//
+func (fleet *Fleet) Snapshot(now time.Time) FleetStatus {
+    // If the fleet is not aggregated
+    if fleet == nil {
+        return FleetStatus{}
+    }
+
+    fleet.mutx.Lock()
+    defer fleet.mutx.Unlock()
+
+    snapshot := FleetStatus{Done: fleet.finishedDone, Total: fleet.finishedTotal}
+    jobs := fleet.finishedJobs
+
+    // Iterate through the current jobs of the clients
+    for _, client := range fleet.clients {
+        snapshot.Done += client.status.Progress[0]
+        snapshot.Total += client.status.Progress[1]
+        jobs += 1
+
+        // If the client stopped forwarding its status, its speed is no longer current
+        if now.Sub(client.updated) > fleet.staleAfter {
+            continue
+        }
+
+        snapshot.Clients += 1
+        snapshot.Speed += client.status.Speed()
+    }
+
+    // If jobs were seen, estimate the keyspace of the unstarted jobs from their average
+    if jobs > 0 {
+        snapshot.Total += snapshot.Total / int64(jobs) * int64(fleet.unstarted)
+    }
+
+    // If the keyspace is known, calculate how much of it was processed
+    if snapshot.Total > 0 {
+        snapshot.Coverage = min(float64(snapshot.Done) / float64(snapshot.Total), 1)
+    }
+
+    // If the fleet is cracking, estimate the time to process the rest of the keyspace
+    if snapshot.Speed > 0 && snapshot.Total > snapshot.Done {
+        seconds := float64(snapshot.Total - snapshot.Done) / snapshot.Speed
+        snapshot.Eta = time.Duration(seconds * float64(time.Second)).Round(time.Second)
+    }
+
+    return snapshot
+}
@@ -0,0 +1,67 @@
+package hashcat_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/hashcat"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestFleet(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    start := time.Unix(1739401305, 0)
+    fleet := hashcat.NewFleet(45 * time.Second)
+
+    fleet.Update("10.0.0.5:4444", hashcat.Status{Devices: []hashcat.Device{{Speed: 600},
+                                                                          {Speed: 400}},
+                                                Progress: [2]int64{2000, 10000},
+                                                TimeStart: 1}, start)
+    fleet.Update("10.0.0.6:4444", hashcat.Status{Devices: []hashcat.Device{{Speed: 1000}},
+                                                Progress: [2]int64{4000, 10000},
+                                                TimeStart: 1}, start)
+    fleet.SetUnstarted(2)
+
+    snapshot := fleet.Snapshot(start)
+    // Ensure the speeds of the clients are combined
+    assert.Equal(2, snapshot.Clients)
+    assert.Equal(2000.0, snapshot.Speed)
+    // Ensure the unstarted jobs are estimated from the average keyspace of the jobs
+    assert.Equal(int64(6000), snapshot.Done)
+    assert.Equal(int64(40000), snapshot.Total)
+    assert.Equal(0.15, snapshot.Coverage)
+    assert.Equal(17 * time.Second, snapshot.Eta)
+
+    // Ensure a status of a new job finishes the previous one
+    fleet.Update("10.0.0.5:4444", hashcat.Status{Devices: []hashcat.Device{{Speed: 1000}},
+                                                Progress: [2]int64{1000, 10000},
+                                                TimeStart: 2}, start.Add(30 * time.Second))
+    fleet.SetUnstarted(1)
+
+    snapshot = fleet.Snapshot(start.Add(60 * time.Second))
+    // Ensure the stale status is left out of the speed but its progress is kept
+    assert.Equal(1, snapshot.Clients)
+    assert.Equal(1000.0, snapshot.Speed)
+    assert.Equal(int64(7000), snapshot.Done)
+    assert.Equal(int64(40000), snapshot.Total)
+    assert.Equal(33 * time.Second, snapshot.Eta)
+
+    // Ensure a disconnected client keeps its progress without adding speed
+    fleet.Remove("10.0.0.5:4444")
+    fleet.Remove("10.0.0.6:4444")
+    fleet.SetUnstarted(0)
+
+    snapshot = fleet.Snapshot(start.Add(60 * time.Second))
+    assert.Equal(0, snapshot.Clients)
+    assert.Equal(int64(7000), snapshot.Done)
+    assert.Equal(int64(30000), snapshot.Total)
+    assert.Equal(time.Duration(0), snapshot.Eta)
+
+    // Ensure a disabled fleet can be used without checks
+    var disabled *hashcat.Fleet
+    disabled.Update("10.0.0.5:4444", hashcat.Status{}, start)
+    assert.Equal(hashcat.FleetStatus{}, disabled.Snapshot(start))
+}
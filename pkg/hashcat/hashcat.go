@@ -2,15 +2,36 @@ package hashcat
 
 import (
 	"bytes"
-	"log"
-	"regexp"
-	"sort"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
-	"github.com/ngimb64/Kloud-Kraken/pkg/data"
-	"go.uber.org/zap"
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
 )
 
+// Candidate generators the wordlists can be fed through into hashcat stdin
+const (
+    GeneratorCombinator = "combinator"
+    GeneratorPrince     = "prince"
+)
+
+// Package level variables
+var Binary = "hashcat"  // Path of the hashcat binary executed, resolved from PATH by default
+var GeneratorPackages = map[string]string{  // Apt packages providing each generator
+    GeneratorCombinator: "hashcat-utils",
+    GeneratorPrince:     "princeprocessor",
+}
+var GeneratorScripts = map[string]string{  // Shell commands generating from the wordlist $1
+    GeneratorCombinator: `exec /usr/lib/hashcat-utils/combinator.bin "$1" "$1"`,
+    GeneratorPrince:     `exec princeprocessor < "$1"`,
+}
+
+
 // Iterate through the parsed charsets and append them to the command options slice
 // until an empty charset is met.
 //
@@ -36,82 +57,297 @@ func AppendCharsets(cmdOptions *[]string, charsets []string) {
 
 // Data structure for managing hashcat program arguments
 type HashcatArgs struct {
-    CrackingMode      string
-    HashType          string
-    ApplyOptimization bool
-    Workload          string
-    CharSet1          string
-    CharSet2          string
-    CharSet3          string
-    CharSet4          string
-    HashMask          string
+    CrackingMode       string
+    HashType           string
+    ApplyOptimization  bool
+    Workload           string
+    CharSet1           string
+    CharSet2           string
+    CharSet3           string
+    CharSet4           string
+    HashMask           string
+    BrainClient        bool
+    BrainHost          string
+    BrainPassword      string
+    BrainPort          int
+    BackendDevices     string
+    CandidateGenerator string
+    DeviceTypes        string
+    KernelAccel        string
+    KernelLoops        string
+    KernelThreads      string
+    ExtraArgs          []string
+}
+
+
+// Appends the backend device selection and tuning options to the command options slice,
+// hashcat takes a single tuning value applied to every selected device. Unset options are
+// left to hashcat autotuning.
+//
+// @Parameters
+// - cmdOptions:  The string slice of command args to be passed into hashcat
+// - args:  The hashcat args storing the device settings
+//
+func AppendDeviceArgs(cmdOptions *[]string, args *HashcatArgs) {
+    options := []struct {
+        flag  string
+        value string
+    }{
+        {"-d", args.BackendDevices},
+        {"-D", args.DeviceTypes},
+        {"-n", args.KernelAccel},
+        {"-u", args.KernelLoops},
+        {"-T", args.KernelThreads},
+    }
+
+    // Iterate through the device options appending the ones that are set
+    for _, option := range options {
+        if option.value != "" {
+            *cmdOptions = append(*cmdOptions, option.flag, option.value)
+        }
+    }
+}
+
+
+// Builds the command generating candidates from the wordlist into its stdout, which is
+// fed into hashcat stdin. The generator replaces the shell so killing the command stops it.
+//
+// @Parameters
+// - generator:  The candidate generator (prince or combinator)
+// - wordlistPath:  The path to the wordlist the candidates are generated from
+//
+// @Returns
+// - The generator command ready to be started
+// - Error if it occurs, otherwise nil on success
+//
+func GeneratorCommand(generator string, wordlistPath string) (*exec.Cmd, error) {
+    script, ok := GeneratorScripts[generator]
+    if !ok {
+        return nil, fmt.Errorf("unsupported candidate generator - %q", generator)
+    }
+
+    // The wordlist is passed as a positional arg so its path is never parsed by the shell
+    return exec.Command("sh", "-c", script, "sh", wordlistPath), nil
 }
 
 
-// Parses the final section of hashcat output where result statistics reside,
-// splits the parsed section by newlines into slice, iterates through split slice
-// and trims the data before and after the colon delimiter into key-value variables
-// that are mapped to a map. The keys are sorted and iterated over to log the parsed
-// output in order established by the keys.
+// Appends the brain client options to the command options slice so candidates already
+// attempted by other clients are skipped, nothing is appended if the brain is not in use.
 //
 // @Parameters
-// - output:  Buffer where hashcat output is stored and to be parsed
+// - cmdOptions:  The string slice of command args to be passed into hashcat
+// - args:  The hashcat args storing the brain settings
 //
-func ParseHashcatOutput(output []byte, delimiter []byte) []any {
-    var keys []string
-    var logArgs []any
-    // Make a map to store parsed data
-    outputMap := make(map[string]string)
+func AppendBrainArgs(cmdOptions *[]string, args *HashcatArgs) {
+    // If the brain is not in use
+    if !args.BrainClient || args.BrainHost == "" {
+        return
+    }
 
-    // Trim up to the end section with result data
-    parsedOutput, err := data.TrimAfterLast(output, delimiter)
+    *cmdOptions = append(*cmdOptions, "--brain-client", "--brain-host", args.BrainHost,
+                         "--brain-port", strconv.Itoa(args.BrainPort),
+                         "--brain-password", args.BrainPassword)
+}
+
+
+// BrainServer manages a hashcat brain server process run alongside the server
+type BrainServer struct {
+    cmd     *exec.Cmd
+    err     error
+    exited  chan struct{}
+    stopped atomic.Bool
+}
+
+
+// Starts a hashcat brain server listening on the passed in address and port.
+//
+// @Parameters
+// - host:  The IP address of the interface the brain server listens on
+// - port:  The port the brain server listens on
+// - password:  The password clients authenticate with
+// - output:  Where the brain server output is written
+//
+// @Returns
+// - The running brain server
+// - Error if it occurs, otherwise nil on success
+//
+func StartBrainServer(host string, port int, password string,
+                      output io.Writer) (*BrainServer, error) {
+    cmd := exec.Command(Binary, "--brain-server", "--brain-host", host,
+                        "--brain-port", strconv.Itoa(port), "--brain-password", password)
+    cmd.Stdout = output
+    cmd.Stderr = output
+
+    // Start the brain server process
+    err := cmd.Start()
     if err != nil {
-        log.Fatalf("Error pre-trimming:  %v", err)
+        return nil, fmt.Errorf("error starting hashcat brain server - %w", err)
     }
 
-    // Split the byte slice into lines base on newlines
-    lines := bytes.Split(parsedOutput, []byte("\n"))
-    // Compile regular expression to match a period at end variable length
-    rePeriodTrim := regexp.MustCompile(`\.*$`)
-
-    // Iterate through slice of byte slice lines
-    for _, line := range lines {
-        // Find the first occurance of the colon separator
-        index := bytes.Index(line, []byte(":"))
-        // If the line does not contain the index, skip it
-        if index == -1 {
-            continue
-        }
+    server := &BrainServer{cmd: cmd, exited: make(chan struct{})}
 
-        // Extract the key/value based on the colon separator
-        key := bytes.TrimSpace(line[:index])
-        value := bytes.TrimSpace(line[index+1:])
+    // Wait for the process in the background so an early exit is detected
+    go func() {
+        server.err = server.cmd.Wait()
+        close(server.exited)
+    } ()
 
-        // If there are any periods at the ent of the string
-        if rePeriodTrim.Match(key) {
-            key = data.TrimEndChars(key, byte('.'))
-        }
+    return server, nil
+}
 
-        // If there is a $ delimiter on end, trim it
-        if bytes.HasSuffix(value, []byte("$")) {
-            value = bytes.TrimSuffix(value, []byte("$"))
-        }
+// Gets the channel that is closed when the brain server process exits.
+//
+// @Returns
+// - The exit channel of the brain server
+//
+func (server *BrainServer) Exited() <-chan struct{} {
+    return server.exited
+}
+
+// Checks whether the brain server was stopped, as opposed to exiting on its own.
+//
+// @Returns
+// - true/false boolean depending on whether Stop was called
+//
+func (server *BrainServer) Stopped() bool {
+    return server.stopped.Load()
+}
 
-        keyStr := string(key)
-        // Append the key to the keys string slice
-        keys = append(keys, keyStr)
-        // Store the key and value as strings in map
-        outputMap[keyStr] = string(value)
+// Interrupts the brain server so it saves its state, killing it if it does not exit
+// before the timeout.
+//
+// @Parameters
+// - timeout:  The time allowed for the brain server to exit after being interrupted
+//
+// @Returns
+// - Error if the brain server exited early, otherwise nil on success
+//
+func (server *BrainServer) Stop(timeout time.Duration) error {
+    // If the brain server is not in use
+    if server == nil {
+        return nil
     }
 
-    // Sort the keys by alphabetical order
-    sort.Strings(keys)
+    select {
+    // If the brain server already exited on its own
+    case <-server.exited:
+        return fmt.Errorf("hashcat brain server exited early - %v", server.err)
+    default:
+    }
+
+    server.stopped.Store(true)
+    // Interrupt the brain server for a graceful exit
+    server.cmd.Process.Signal(os.Interrupt)
 
-    // Iterate through the sorted keys
-    for _, key := range keys {
-        // Add the key/value from output map based on sorted key value
-        logArgs = append(logArgs, zap.String(key, outputMap[key]))
+    select {
+    case <-server.exited:
+    case <-time.After(timeout):
+        server.cmd.Process.Kill()
+        <-server.exited
+    }
+
+    return nil
+}
+
+
+// Runs hashcat locally to compute the base keyspace of the mask attack, which
+// is the unit used by the --skip and --limit options for splitting the work.
+//
+// @Parameters
+// - charsets:  The custom charsets used in the hash mask
+// - hashMask:  The hash mask to compute the keyspace of
+//
+// @Returns
+// - The base keyspace of the mask attack
+// - Error if it occurs, otherwise nil on success
+//
+func GetKeyspace(charsets []string, hashMask string) (int64, error) {
+    cmdArgs := []string{"--keyspace", "-a", "3"}
+    // Append the custom charsets then the hash mask
+    AppendCharsets(&cmdArgs, charsets)
+    cmdArgs = append(cmdArgs, hashMask)
+
+    // Execute hashcat to compute the keyspace
+    output, err := exec.Command(Binary, cmdArgs...).Output()
+    if err != nil {
+        return -1, fmt.Errorf("error computing keyspace with hashcat - %w", err)
+    }
+
+    return ParseKeyspace(output)
+}
+
+
+// Parses the keyspace from hashcat --keyspace output, which is the last
+// non-empty line of the output.
+//
+// @Parameters
+// - output:  Buffer where hashcat keyspace output is stored
+//
+// @Returns
+// - The parsed keyspace
+// - Error if it occurs, otherwise nil on success
+//
+func ParseKeyspace(output []byte) (int64, error) {
+    // Split the trimmed output into lines
+    lines := bytes.Split(bytes.TrimSpace(output), []byte("\n"))
+    // Parse the last line as the keyspace
+    keyspace, err := strconv.ParseInt(string(bytes.TrimSpace(lines[len(lines)-1])), 10, 64)
+    if err != nil {
+        return -1, fmt.Errorf("error parsing keyspace output - %w", err)
+    }
+
+    // If the keyspace is not a positive number
+    if keyspace < 1 {
+        return -1, fmt.Errorf("invalid keyspace %d", keyspace)
+    }
+
+    return keyspace, nil
+}
+
+
+// Formats the hash types message sent to a client before its hash files, with the
+// hash type of each hash file in the order they are sent.
+//
+// @Parameters
+// - hashTypes:  The hashcat hash type of each hash file
+//
+// @Returns
+// - The formatted hash types message
+//
+func FormatHashTypes(hashTypes []string) []byte {
+    message := append([]byte{}, globals.HASH_TYPES_PREFIX...)
+    message = append(message, strings.Join(hashTypes, ",")...)
+    return append(message, globals.TRANSFER_SUFFIX...)
+}
+
+
+// Parses the hash types message sent to a client before its hash files.
+//
+// @Parameters
+// - message:  The message containing the hash types
+//
+// @Returns
+// - The hashcat hash type of each hash file in the order they are sent
+// - Error if it occurs, otherwise nil on success
+//
+func ParseHashTypes(message []byte) ([]string, error) {
+    // If the message does not start with prefix or end with closed bracket
+    if !bytes.HasPrefix(message, globals.HASH_TYPES_PREFIX) ||
+       !bytes.HasSuffix(message, globals.TRANSFER_SUFFIX) {
+        return nil, fmt.Errorf("improper prefix or suffix in hash types message")
+    }
+
+    // Strip the prefix and suffix then split on the delimiter
+    body := bytes.TrimSuffix(bytes.TrimPrefix(message, globals.HASH_TYPES_PREFIX),
+                             globals.TRANSFER_SUFFIX)
+    hashTypes := strings.Split(string(body), ",")
+
+    // Iterate through the hash types ensuring each is numeric
+    for _, hashType := range hashTypes {
+        if _, err := strconv.Atoi(hashType); err != nil {
+            return nil, fmt.Errorf("improper hash type %q in hash types message", hashType)
+        }
     }
 
-    return logArgs
+    return hashTypes, nil
 }
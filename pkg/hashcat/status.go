@@ -0,0 +1,437 @@
+package hashcat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/netio"
+	"go.uber.org/zap"
+)
+
+// Max bytes of a forwarded status, large enough for the devices of the largest instances
+const MaxStatusSize = 64 * 1024
+
+// Interval hashcat prints its status at while cracking
+const StatusInterval = 15 * time.Second
+
+// Package level variables
+var StatusNames = []string{  // Names of the hashcat status codes indexed by code
+    "Initializing", "Autotuning", "Self-testing", "Running", "Paused", "Exhausted",
+    "Cracked", "Aborted", "Quit", "Bypass", "Aborted (Checkpoint)", "Aborted (Runtime)",
+    "Running (Checkpoint Quit requested)", "Error", "Aborted (Finish)",
+    "Running (Quit after attack requested)", "Autodetect",
+}
+
+
+// Device is the status of a single backend device of a hashcat process
+type Device struct {
+    Id    int     `json:"device_id"`
+    Name  string  `json:"device_name,omitempty"`
+    Speed float64 `json:"speed"`           // Hashes per second
+    Temp  int     `json:"temp,omitempty"`  // Celsius, 0 when not monitored
+    Util  int     `json:"util"`            // Percent
+}
+
+
+// Status is a status update printed by hashcat with --status-json or --machine-readable,
+// the JSON tags match the fields of --status-json
+type Status struct {
+    Devices         []Device `json:"devices"`
+    EstimatedStop   int64    `json:"estimated_stop"`
+    Progress        [2]int64 `json:"progress"`          // Candidates done and total
+    RecoveredHashes [2]int64 `json:"recovered_hashes"`  // Hashes cracked and total
+    Rejected        int64    `json:"rejected"`
+    Session         string   `json:"session"`
+    Status          int      `json:"status"`
+    Target          string   `json:"target"`
+    TimeStart       int64    `json:"time_start"`
+}
+
+
+// Gets the options making hashcat print its status as JSON lines at the passed in
+// interval, along with a final status when it exits.
+//
+// @Parameters
+// - interval:  The interval the status is printed at
+//
+// @Returns
+// - The status options to append to the hashcat args
+//
+func StatusArgs(interval time.Duration) []string {
+    return []string{"--status", "--status-json",
+                    "--status-timer=" + strconv.Itoa(max(int(interval.Seconds()), 1))}
+}
+
+
+// Parses a status line of hashcat output, either a --status-json object or a
+// tab separated --machine-readable status.
+//
+// @Parameters
+// - line:  The line of hashcat output
+//
+// @Returns
+// - The parsed status
+// - Error if it occurs, otherwise nil on success
+//
+func ParseStatus(line []byte) (Status, error) {
+    var status Status
+    line = bytes.TrimSpace(line)
+
+    // If the line is a JSON status
+    if bytes.HasPrefix(line, []byte("{")) {
+        err := json.Unmarshal(line, &status)
+        if err != nil {
+            return status, fmt.Errorf("error parsing JSON status - %w", err)
+        }
+
+        // If the object is not a status, such as other JSON printed by hashcat
+        if status.Session == "" && len(status.Devices) == 0 {
+            return status, fmt.Errorf("JSON line is not a hashcat status")
+        }
+
+        return status, nil
+    }
+
+    // If the line is not a machine readable status
+    if !bytes.HasPrefix(line, []byte("STATUS\t")) {
+        return status, fmt.Errorf("line is not a hashcat status")
+    }
+
+    return parseMachineReadable(string(line))
+}
+
+
+// Parses a tab separated --machine-readable status, where each key is followed by its
+// values and the per-device keys have a value or pair of values for every device.
+//
+// @Parameters
+// - line:  The machine readable status line
+//
+// @Returns
+// - The parsed status
+// - Error if it occurs, otherwise nil on success
+//
+func parseMachineReadable(line string) (Status, error) {
+    var status Status
+    keys := []string{"STATUS", "SPEED", "EXEC_RUNTIME", "CURKU", "PROGRESS", "RECHASH",
+                     "RECSALT", "TEMP", "REJECTED", "UTIL"}
+    values := map[string][]int64{}
+    key := ""
+
+    // Iterate through the fields grouping the values under the key before them
+    for _, field := range strings.Split(line, "\t") {
+        if field == "" {
+            continue
+        }
+
+        if slices.Contains(keys, field) {
+            key = field
+            continue
+        }
+
+        // Runtimes are fractional milliseconds and not part of the status
+        if key == "EXEC_RUNTIME" {
+            continue
+        }
+
+        value, err := strconv.ParseInt(field, 10, 64)
+        if err != nil {
+            return status, fmt.Errorf("improper %s value in status - %q", key, field)
+        }
+
+        values[key] = append(values[key], value)
+    }
+
+    // If the status code is missing
+    if len(values["STATUS"]) != 1 {
+        return status, fmt.Errorf("status code missing from machine readable status")
+    }
+
+    status.Status = int(values["STATUS"][0])
+    copy(status.Progress[:], values["PROGRESS"])
+    copy(status.RecoveredHashes[:], values["RECHASH"])
+
+    // If the rejected candidates are reported
+    if len(values["REJECTED"]) > 0 {
+        status.Rejected = values["REJECTED"][0]
+    }
+
+    speeds := values["SPEED"]
+    // Iterate through the speed pairs, the hashes counted over a period in milliseconds
+    for index := 0; index + 1 < len(speeds); index += 2 {
+        device := Device{Id: index / 2 + 1}
+
+        // If the period is known, convert the count to hashes per second
+        if speeds[index+1] > 0 {
+            device.Speed = float64(speeds[index]) * 1000 / float64(speeds[index+1])
+        }
+
+        // If the temperature of the device is monitored, unmonitored devices report -1
+        if index / 2 < len(values["TEMP"]) && values["TEMP"][index/2] > 0 {
+            device.Temp = int(values["TEMP"][index/2])
+        }
+        // If the utilization of the device is monitored
+        if index / 2 < len(values["UTIL"]) && values["UTIL"][index/2] >= 0 {
+            device.Util = int(values["UTIL"][index/2])
+        }
+
+        status.Devices = append(status.Devices, device)
+    }
+
+    return status, nil
+}
+
+
+// Gets the name of the status code.
+//
+// @Returns
+// - The name of the status, or the code if it is unknown
+//
+func (status Status) Name() string {
+    // If the code is not a known status
+    if status.Status < 0 || status.Status >= len(StatusNames) {
+        return strconv.Itoa(status.Status)
+    }
+
+    return StatusNames[status.Status]
+}
+
+
+// Gets the combined speed of the devices.
+//
+// @Returns
+// - The speed in hashes per second
+//
+func (status Status) Speed() float64 {
+    speed := 0.0
+    for _, device := range status.Devices {
+        speed += device.Speed
+    }
+
+    return speed
+}
+
+
+// Gets the fraction of the candidates processed.
+//
+// @Returns
+// - The fraction complete from 0 to 1, 0 if the total is unknown
+//
+func (status Status) Fraction() float64 {
+    // If the total number of candidates is unknown
+    if status.Progress[1] <= 0 {
+        return 0
+    }
+
+    return float64(status.Progress[0]) / float64(status.Progress[1])
+}
+
+
+// Gets the temperature of the hottest device.
+//
+// @Returns
+// - The temperature in Celsius, 0 if no device is monitored
+//
+func (status Status) MaxTemp() int {
+    temp := 0
+    for _, device := range status.Devices {
+        temp = max(temp, device.Temp)
+    }
+
+    return temp
+}
+
+
+// Gets the status as the fields of a log message.
+//
+// @Returns
+// - The zap fields of the status
+//
+func (status Status) LogArgs() []any {
+    return []any{zap.Any("devices", status.Devices),
+                 zap.Int64("progress", status.Progress[0]),
+                 zap.Float64("progress_percent", status.Fraction() * 100),
+                 zap.Int64("progress_total", status.Progress[1]),
+                 zap.Int64("recovered", status.RecoveredHashes[0]),
+                 zap.Int64("recovered_total", status.RecoveredHashes[1]),
+                 zap.Int64("rejected", status.Rejected),
+                 zap.String("session", status.Session),
+                 zap.Float64("speed", status.Speed()),
+                 zap.String("status", status.Name()),
+                 zap.Int("temp_max", status.MaxTemp())}
+}
+
+
+// StatusWriter collects the output of hashcat, parsing its status lines as they are
+// written and keeping the rest of the output for error messages
+type StatusWriter struct {
+    found    bool
+    last     Status
+    onStatus func(status Status)
+    output   bytes.Buffer
+    partial  []byte
+}
+
+
+// Creates a writer the output of hashcat is written to.
+//
+// @Parameters
+// - onStatus:  Called with each status as it is written, nil if unused
+//
+// @Returns
+// - The initialized status writer
+//
+func NewStatusWriter(onStatus func(status Status)) *StatusWriter {
+    return &StatusWriter{onStatus: onStatus}
+}
+
+
+// Writes hashcat output, the status lines are parsed and the other lines are kept.
+//
+// @Parameters
+// - data:  The output written by hashcat
+//
+// @Returns
+// - The number of bytes written, which is always all of them
+// - Always nil
+//
+func (writer *StatusWriter) Write(data []byte) (int, error) {
+    writer.partial = append(writer.partial, data...)
+
+    // Iterate through the complete lines of the written output
+    for {
+        index := bytes.IndexByte(writer.partial, '\n')
+        if index == -1 {
+            break
+        }
+
+        line := writer.partial[:index+1]
+        writer.partial = writer.partial[index+1:]
+
+        status, err := ParseStatus(line)
+        // If the line is not a status, keep it as output
+        if err != nil {
+            writer.output.Write(line)
+            continue
+        }
+
+        writer.found = true
+        writer.last = status
+
+        if writer.onStatus != nil {
+            writer.onStatus(status)
+        }
+    }
+
+    return len(data), nil
+}
+
+
+// Gets the output that was not a status, including any unterminated last line.
+//
+// @Returns
+// - The output of hashcat without its status lines
+//
+func (writer *StatusWriter) Output() []byte {
+    return append(slices.Clone(writer.output.Bytes()), writer.partial...)
+}
+
+
+// Gets the last status written, which is the final status once hashcat exits.
+//
+// @Returns
+// - The last status
+// - Whether any status was written
+//
+func (writer *StatusWriter) Last() (Status, bool) {
+    return writer.last, writer.found
+}
+
+
+// Formats a status message with the JSON of the status following the header.
+//
+// @Parameters
+// - status:  The hashcat status to forward
+//
+// @Returns
+// - The formatted status message
+// - Error if it occurs, otherwise nil on success
+//
+func FormatStatus(status Status) ([]byte, error) {
+    data, err := json.Marshal(status)
+    if err != nil {
+        return nil, fmt.Errorf("error encoding status - %w", err)
+    }
+
+    // If the status is larger than the server reads
+    if len(data) > MaxStatusSize {
+        return nil, fmt.Errorf("status of %d bytes exceeds the max size", len(data))
+    }
+
+    message := slices.Clone(globals.HASHCAT_STATUS_PREFIX)
+    message = append(message, strconv.Itoa(len(data))...)
+    message = append(message, globals.TRANSFER_SUFFIX...)
+
+    return append(message, data...), nil
+}
+
+
+// Reads a status, starting with any data read along with the header.
+//
+// @Parameters
+// - connection:  The connection the status is read from
+// - message:  The message read from the connection starting with the status header
+//
+// @Returns
+// - The forwarded status
+// - Error if it occurs, otherwise nil on success
+//
+func ReadStatus(connection io.Reader, message []byte) (Status, error) {
+    var status Status
+
+    // If the message does not start with the status header
+    if !bytes.HasPrefix(message, globals.HASHCAT_STATUS_PREFIX) {
+        return status, fmt.Errorf("message is not a hashcat status - %w",
+                                  netio.ErrMalformedMessage)
+    }
+
+    message = message[len(globals.HASHCAT_STATUS_PREFIX):]
+    suffixPos := bytes.Index(message, globals.TRANSFER_SUFFIX)
+    // If the header is not terminated
+    if suffixPos == -1 {
+        return status, fmt.Errorf("invalid status header, suffix missing - %w",
+                                  netio.ErrMalformedMessage)
+    }
+
+    size, err := strconv.Atoi(string(message[:suffixPos]))
+    // If the size is not a number or larger than a status can be
+    if err != nil || size < 0 || size > MaxStatusSize {
+        return status, fmt.Errorf("invalid status size - %q - %w", message[:suffixPos],
+                                  netio.ErrMalformedMessage)
+    }
+
+    data := make([]byte, size)
+    // Copy the data read along with the header
+    copied := copy(data, message[suffixPos+1:])
+
+    // Read the rest of the status data
+    _, err = io.ReadFull(connection, data[copied:])
+    if err != nil {
+        return status, fmt.Errorf("error reading status - %w", err)
+    }
+
+    err = json.Unmarshal(data, &status)
+    if err != nil {
+        return status, fmt.Errorf("error parsing status - %w - %w",
+                                  netio.ErrMalformedMessage, err)
+    }
+
+    return status, nil
+}
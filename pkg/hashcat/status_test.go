@@ -0,0 +1,209 @@
+package hashcat_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/ngimb64/Kloud-Kraken/internal/fuzzseed"
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/hashcat"
+	"github.com/ngimb64/Kloud-Kraken/pkg/kloudlogs"
+	"github.com/stretchr/testify/assert"
+)
+
+var statusJson = `{ "session": "hashcat", "guess": { "guess_base": ` +
+    `"/usr/share/wordlists/rockyou.txt", "guess_base_count": 1, "guess_base_offset": 1, ` +
+    `"guess_base_percent": 100.00, "guess_mask_length": 0, "guess_mod": null, ` +
+    `"guess_mod_count": 1, "guess_mod_offset": 1, "guess_mod_percent": 100.00, ` +
+    `"guess_mode": 0 }, "status": 3, "target": "ab6a34a451b061203fb9e7cbeae89f8b", ` +
+    `"progress": [2048, 14344385], "restore_point": 0, "recovered_hashes": [1, 2], ` +
+    `"recovered_salts": [1, 1], "rejected": 12, "devices": [ { "device_id": 1, ` +
+    `"device_name": "NVIDIA A100-SXM4-40GB", "device_type": "GPU", "speed": 487000, ` +
+    `"temp": 67, "util": 98 }, { "device_id": 2, "device_name": "NVIDIA A100-SXM4-40GB", ` +
+    `"device_type": "GPU", "speed": 513000, "temp": 71, "util": 97 } ], ` +
+    `"time_start": 1739401305, "estimated_stop": 1739401345 }`
+
+
+func TestStatusArgs(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    assert.Equal([]string{"--status", "--status-json", "--status-timer=15"},
+                 hashcat.StatusArgs(15 * time.Second))
+    // Ensure intervals under a second are raised to the minimum hashcat accepts
+    assert.Equal("--status-timer=1", hashcat.StatusArgs(time.Millisecond)[2])
+}
+
+
+func TestParseStatus(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    status, err := hashcat.ParseStatus([]byte(statusJson + "\n"))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("hashcat", status.Session)
+    assert.Equal("Running", status.Name())
+    assert.Equal([2]int64{2048, 14344385}, status.Progress)
+    assert.Equal([2]int64{1, 2}, status.RecoveredHashes)
+    assert.Equal(int64(12), status.Rejected)
+    assert.Equal(2, len(status.Devices))
+    assert.Equal("NVIDIA A100-SXM4-40GB", status.Devices[0].Name)
+    // Ensure the speeds of the devices are combined and the hottest device is found
+    assert.Equal(1000000.0, status.Speed())
+    assert.Equal(71, status.MaxTemp())
+    assert.InDelta(0.000143, status.Fraction(), 0.000001)
+
+    status, err = hashcat.ParseStatus([]byte("STATUS\t6\tSPEED\t4870\t10\t5130\t10\t" +
+                                             "EXEC_RUNTIME\t1.963\t1.874\tCURKU\t0\t" +
+                                             "PROGRESS\t2048\t14344385\tRECHASH\t2\t2\t" +
+                                             "RECSALT\t1\t1\tTEMP\t67\t-1\tREJECTED\t0\t" +
+                                             "UTIL\t98\t-1\t\r\n"))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("Cracked", status.Name())
+    assert.Equal([2]int64{2, 2}, status.RecoveredHashes)
+    // Ensure the hash counts over the periods are converted to hashes per second
+    assert.Equal(2, len(status.Devices))
+    assert.Equal(487000.0, status.Devices[0].Speed)
+    assert.Equal(2, status.Devices[1].Id)
+    // Ensure unmonitored devices report no temperature or utilization
+    assert.Equal(0, status.Devices[1].Temp)
+    assert.Equal(0, status.Devices[1].Util)
+    assert.Equal(67, status.MaxTemp())
+
+    falacies := []string{"", "Session..........: hashcat", `{"guess": {}}`, "{",
+                         "STATUS\tx\t", "SPEED\t1\t1\t", "STATUS\t3\tPROGRESS\tmany\t"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        _, err = hashcat.ParseStatus([]byte(falacy))
+        // Ensure the error is not nil meaning failed operation
+        assert.NotEqual(nil, err)
+    }
+
+    // Ensure unknown status codes are named by their code
+    assert.Equal("42", hashcat.Status{Status: 42}.Name())
+    assert.Equal(0.0, hashcat.Status{}.Fraction())
+}
+
+
+func TestStatusWriter(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    var statuses []hashcat.Status
+    writer := hashcat.NewStatusWriter(func(status hashcat.Status) {
+        statuses = append(statuses, status)
+    })
+
+    _, ok := writer.Last()
+    assert.False(ok)
+
+    // Write the output split mid-line so the status is parsed once its line completes
+    output := "hashcat (v6.2.6) starting\n\n" + statusJson + "\nStarted: Wed Feb 12\n" +
+              "Stopped"
+    for _, chunk := range []string{output[:40], output[40:200], output[200:]} {
+        written, err := writer.Write([]byte(chunk))
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        assert.Equal(len(chunk), written)
+    }
+
+    last, ok := writer.Last()
+    assert.True(ok)
+    assert.Equal(1, len(statuses))
+    assert.Equal(statuses[0], last)
+    // Ensure the status line is left out of the output while the rest is kept
+    assert.Equal("hashcat (v6.2.6) starting\n\nStarted: Wed Feb 12\nStopped",
+                 string(writer.Output()))
+}
+
+
+func TestFormatReadStatus(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    status, err := hashcat.ParseStatus([]byte(statusJson))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    message, err := hashcat.FormatStatus(status)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Split the message as if the first read only returned part of the status
+    read, err := hashcat.ReadStatus(bytes.NewReader(message[40:]), message[:40])
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(status, read)
+
+    // Ensure other messages and oversized statuses are rejected
+    _, err = hashcat.ReadStatus(bytes.NewReader(nil), []byte("<HASHCAT_EXECUTION:10>"))
+    assert.NotEqual(nil, err)
+    _, err = hashcat.ReadStatus(bytes.NewReader(nil), []byte("<HASHCAT_STATUS:999999999>"))
+    assert.NotEqual(nil, err)
+}
+
+
+func FuzzReadStatus(f *testing.F) {
+    fuzzseed.Framed(f, globals.HASHCAT_STATUS_PREFIX, statusJson)
+    f.Add([]byte(`<HASHCAT_STATUS:2>{"devices":[{}]}`), []byte(nil))
+
+    f.Fuzz(func(t *testing.T, message []byte, rest []byte) {
+        status, err := hashcat.ReadStatus(bytes.NewReader(rest), message)
+        // If the status was rejected
+        if err != nil {
+            return
+        }
+
+        // Ensure an accepted status can be summarized for the TUI without panicking
+        status.Name()
+        status.Fraction()
+        status.LogArgs()
+    })
+}
+
+
+func TestStatusLogArgs(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    status, err := hashcat.ParseStatus([]byte(statusJson))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    awsCreds := credentials.NewStaticCredentialsProvider("blah", "blah", "")
+    // Load default config and override with custom credentials and region
+    awsConfig, err := config.LoadDefaultConfig(
+        context.TODO(),
+        config.WithRegion("test-region"),
+        config.WithCredentialsProvider(awsCreds),
+    )
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Initialize the LoggerManager based on the flags
+    logMan, err := kloudlogs.NewLoggerManager("local", "", awsConfig, "", true)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Log the hashcat status with kloudlogs
+    logMan.LogMessage("info", "TestStatusLogArgs test message", status.LogArgs()...)
+    // Get the log message from memory and parse it as a map
+    logMap, err := kloudlogs.LogToMap(logMan.GetLog())
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the typed fields were logged
+    assert.Equal("hashcat", logMap["session"])
+    assert.Equal("Running", logMap["status"])
+    assert.Equal(1000000.0, logMap["speed"])
+    assert.Equal(2048.0, logMap["progress"])
+    assert.Equal(12.0, logMap["rejected"])
+    assert.Equal(71.0, logMap["temp_max"])
+    assert.Equal(2, len(logMap["devices"].([]any)))
+}
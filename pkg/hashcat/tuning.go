@@ -0,0 +1,254 @@
+package hashcat
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Workload hashcat runs with when neither the config or a tuning profile set one
+const DefaultWorkload = "3"
+
+// Package level variables
+var TuningProfiles = map[string]Tuning{  // Recommended tuning of common hash types
+    // Fast unsalted hashes, long kernel loops keep the GPUs saturated between candidates
+    "0":     {KernelLoops: "1024", Workload: "4"},  // MD5
+    "100":   {KernelLoops: "1024", Workload: "4"},  // SHA1
+    "1000":  {KernelLoops: "1024", Workload: "4"},  // NTLM
+    "1400":  {KernelLoops: "1024", Workload: "4"},  // SHA2-256
+    "1700":  {KernelLoops: "1024", Workload: "4"},  // SHA2-512
+    "5500":  {Workload: "4"},                       // NetNTLMv1
+    "5600":  {Workload: "4"},                       // NetNTLMv2
+    "13100": {Workload: "4"},                       // Kerberos 5 TGS-REP etype 23
+    "18200": {Workload: "4"},                       // Kerberos 5 AS-REP etype 23
+    // Slow iterated hashes are bound by the iterations rather than the candidate length,
+    // so the pure kernels lift the 31 character plaintext limit of -O at little cost
+    "500":   {PureKernel: true, Workload: "3"},     // md5crypt
+    "1800":  {PureKernel: true, Workload: "3"},     // sha512crypt
+    "3200":  {PureKernel: true, Workload: "4"},     // bcrypt
+    "7500":  {PureKernel: true, Workload: "3"},     // Kerberos 5 AS-REQ etype 23
+    "13400": {PureKernel: true, Workload: "3"},     // KeePass
+    "22000": {PureKernel: true, Workload: "3"},     // WPA-PBKDF2-PMKID+EAPOL
+}
+
+
+// Tuning is the recommended hashcat tuning of a hash type, unset values are left to the
+// config and hashcat autotuning
+type Tuning struct {
+    KernelAccel   string `yaml:"kernel_accel"`
+    KernelLoops   string `yaml:"kernel_loops"`
+    KernelThreads string `yaml:"kernel_threads"`
+    PureKernel    bool   `yaml:"pure_kernel"`    // Runs without -O for long plaintexts
+    Workload      string `yaml:"workload"`
+}
+
+
+// Gets the tuning profile of the hash type, a custom profile registered for the hash type
+// replaces the built-in one.
+//
+// @Parameters
+// - hashType:  The hashcat hash type
+// - custom:  The custom profiles registered in the config by hash type
+//
+// @Returns
+// - The tuning profile of the hash type
+// - true/false depending on whether the hash type has a profile
+//
+func ResolveTuning(hashType string, custom map[string]Tuning) (Tuning, bool) {
+    // If a custom profile is registered for the hash type
+    if tuning, exists := custom[hashType]; exists {
+        return tuning, true
+    }
+
+    tuning, exists := TuningProfiles[hashType]
+    return tuning, exists
+}
+
+
+// Clears the profile values the config sets explicitly, so they override the profile.
+//
+// @Parameters
+// - explicit:  The tuning values set in the config
+//
+// @Returns
+// - The profile without the overridden values
+//
+func (tuning Tuning) Override(explicit Tuning) Tuning {
+    // If the kernel accel is set in the config
+    if explicit.KernelAccel != "" {
+        tuning.KernelAccel = ""
+    }
+
+    // If the kernel loops are set in the config
+    if explicit.KernelLoops != "" {
+        tuning.KernelLoops = ""
+    }
+
+    // If the kernel threads are set in the config
+    if explicit.KernelThreads != "" {
+        tuning.KernelThreads = ""
+    }
+
+    // If the workload is set in the config
+    if explicit.Workload != "" {
+        tuning.Workload = ""
+    }
+
+    return tuning
+}
+
+
+// Applies the tuning to a copy of the hashcat options, replacing the workload, dropping
+// -O for pure kernels, and appending the kernel values.
+//
+// @Parameters
+// - cmdOptions:  The hashcat options the tuning is applied to
+// - tuning:  The tuning of the hash type being cracked
+//
+// @Returns
+// - The tuned hashcat options
+//
+func ApplyTuning(cmdOptions []string, tuning Tuning) []string {
+    tuned := make([]string, 0, len(cmdOptions) + 6)
+
+    for index := 0; index < len(cmdOptions); index++ {
+        option := cmdOptions[index]
+
+        // If the pure kernels are used, drop the optimized kernel option
+        if option == "-O" && tuning.PureKernel {
+            continue
+        }
+
+        // If the workload is tuned, replace the configured one
+        if option == "-w" && tuning.Workload != "" && index + 1 < len(cmdOptions) {
+            tuned = append(tuned, option, tuning.Workload)
+            index++
+            continue
+        }
+
+        tuned = append(tuned, option)
+    }
+
+    kernelOptions := []struct {
+        flag  string
+        value string
+    }{
+        {"-n", tuning.KernelAccel},
+        {"-u", tuning.KernelLoops},
+        {"-T", tuning.KernelThreads},
+    }
+
+    // Iterate through the kernel options appending the tuned ones not already set
+    for _, option := range kernelOptions {
+        if option.value != "" && !slices.Contains(tuned, option.flag) {
+            tuned = append(tuned, option.flag, option.value)
+        }
+    }
+
+    return tuned
+}
+
+
+// Formats the tuning of each hash type into a flag value, hash type entries are separated
+// by plus signs and their values by slashes so the user data needs no quoting
+// (ex: 1000:kernel_loops=1024/workload=4+3200:pure_kernel=true/workload=4).
+//
+// @Parameters
+// - tunings:  The tuning of each hash type
+//
+// @Returns
+// - The formatted tunings in hash type order
+//
+func FormatTunings(tunings map[string]Tuning) string {
+    hashTypes := make([]string, 0, len(tunings))
+    for hashType := range tunings {
+        hashTypes = append(hashTypes, hashType)
+    }
+    sort.Strings(hashTypes)
+
+    entries := make([]string, 0, len(hashTypes))
+    // Iterate through the hash types formatting their set values
+    for _, hashType := range hashTypes {
+        tuning := tunings[hashType]
+        values := []string{}
+
+        pairs := [][2]string{
+            {"kernel_accel", tuning.KernelAccel},
+            {"kernel_loops", tuning.KernelLoops},
+            {"kernel_threads", tuning.KernelThreads},
+            {"workload", tuning.Workload},
+        }
+        for _, pair := range pairs {
+            if pair[1] != "" {
+                values = append(values, pair[0] + "=" + pair[1])
+            }
+        }
+
+        // If the pure kernels are used
+        if tuning.PureKernel {
+            values = append(values, "pure_kernel=true")
+        }
+
+        // If the profile has no values left after the config overrides
+        if len(values) == 0 {
+            continue
+        }
+
+        entries = append(entries, hashType + ":" + strings.Join(values, "/"))
+    }
+
+    return strings.Join(entries, "+")
+}
+
+
+// Parses the tuning of each hash type from the flag value formatted by FormatTunings().
+//
+// @Parameters
+// - formatted:  The formatted tunings
+//
+// @Returns
+// - The tuning of each hash type
+// - Error if it occurs, otherwise nil on success
+//
+func ParseTunings(formatted string) (map[string]Tuning, error) {
+    tunings := map[string]Tuning{}
+
+    // If no hash type is tuned
+    if formatted == "" {
+        return tunings, nil
+    }
+
+    // Iterate through the hash type entries
+    for _, entry := range strings.Split(formatted, "+") {
+        hashType, values, found := strings.Cut(entry, ":")
+        if !found || hashType == "" {
+            return nil, fmt.Errorf("invalid tuning entry - %q", entry)
+        }
+
+        var tuning Tuning
+        // Iterate through the values of the entry
+        for _, value := range strings.Split(values, "/") {
+            key, setting, _ := strings.Cut(value, "=")
+
+            switch key {
+            case "kernel_accel":
+                tuning.KernelAccel = setting
+            case "kernel_loops":
+                tuning.KernelLoops = setting
+            case "kernel_threads":
+                tuning.KernelThreads = setting
+            case "pure_kernel":
+                tuning.PureKernel = setting == "true"
+            case "workload":
+                tuning.Workload = setting
+            default:
+                return nil, fmt.Errorf("unknown tuning value - %q", value)
+            }
+        }
+
+        tunings[hashType] = tuning
+    }
+
+    return tunings, nil
+}
@@ -0,0 +1,82 @@
+package hashcat_test
+
+import (
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/hashcat"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestResolveTuning(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    custom := map[string]hashcat.Tuning{"3200": {KernelLoops: "16"}}
+
+    // Ensure a custom profile replaces the built-in one
+    tuning, exists := hashcat.ResolveTuning("3200", custom)
+    assert.True(exists)
+    assert.Equal(hashcat.Tuning{KernelLoops: "16"}, tuning)
+
+    tuning, exists = hashcat.ResolveTuning("1000", custom)
+    assert.True(exists)
+    assert.Equal("4", tuning.Workload)
+
+    _, exists = hashcat.ResolveTuning("99999", custom)
+    assert.False(exists)
+
+    // Ensure the values set in the config override the profile
+    tuning = hashcat.Tuning{KernelLoops: "1024", PureKernel: true, Workload: "4"}
+    assert.Equal(hashcat.Tuning{KernelLoops: "1024", PureKernel: true},
+                 tuning.Override(hashcat.Tuning{Workload: "2"}))
+}
+
+
+func TestApplyTuning(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    cmdOptions := []string{"-O", "-a", "0", "-w", "3", "-u", "8"}
+
+    tuned := hashcat.ApplyTuning(cmdOptions, hashcat.Tuning{KernelAccel: "64",
+                                                           KernelLoops: "1024",
+                                                           PureKernel: true,
+                                                           Workload: "4"})
+    assert.Equal([]string{"-a", "0", "-w", "4", "-u", "8", "-n", "64"}, tuned)
+    // Ensure the passed in options are not modified
+    assert.Equal("-O", cmdOptions[0])
+
+    // Ensure an empty tuning leaves the options as they are
+    assert.Equal(cmdOptions, hashcat.ApplyTuning(cmdOptions, hashcat.Tuning{}))
+}
+
+
+func TestFormatTunings(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    tunings := map[string]hashcat.Tuning{
+        "1000": {KernelLoops: "1024", Workload: "4"},
+        "3200": {KernelThreads: "64,128", PureKernel: true},
+        "0":    {},
+    }
+
+    formatted := hashcat.FormatTunings(tunings)
+    assert.Equal("1000:kernel_loops=1024/workload=4+3200:kernel_threads=64,128/" +
+                 "pure_kernel=true", formatted)
+
+    parsed, err := hashcat.ParseTunings(formatted)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the empty profile was left out
+    delete(tunings, "0")
+    assert.Equal(tunings, parsed)
+
+    falacies := []string{"1000", ":workload=4", "1000:speed=9", "1000:workload=4+"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        _, err = hashcat.ParseTunings(falacy)
+        assert.NotEqual(nil, err)
+    }
+}
@@ -0,0 +1,171 @@
+package hibernate
+
+import (
+	"fmt"
+	"sync"
+)
+
+// States of a run hibernated to stop it overnight
+const (
+    StateCheckpointing = "checkpointing"  // Clients are saving their hashcat sessions
+    StateResuming      = "resuming"       // Instances started, waiting on their clients
+    StateRunning       = "running"
+    StateStopped       = "stopped"        // Instances stopped until the run is resumed
+)
+
+
+// Hibernation tracks a run paused with its fleet stopped, from the clients checkpointing
+// hashcat through the instances being started again and their clients reattaching
+type Hibernation struct {
+    changed  chan struct{}  // Closed and replaced whenever the state changes
+    mutx     sync.Mutex
+    reattach int            // Clients yet to reconnect after the instances were started
+    state    string
+}
+
+// Creates a new hibernation in the running state.
+//
+// @Returns
+// - The initialized hibernation
+//
+func New() *Hibernation {
+    return &Hibernation{changed: make(chan struct{}), state: StateRunning}
+}
+
+// Sets the state and wakes anything waiting on a change, must be called with the lock held.
+//
+// @Parameters
+// - state:  The new state of the hibernation
+//
+func (hibernation *Hibernation) setState(state string) {
+    hibernation.state = state
+    close(hibernation.changed)
+    hibernation.changed = make(chan struct{})
+}
+
+// Begins hibernating the run, the clients are told to checkpoint and disconnect.
+//
+// @Returns
+// - Error if the run is already hibernating, otherwise nil on success
+//
+func (hibernation *Hibernation) Begin() error {
+    hibernation.mutx.Lock()
+    defer hibernation.mutx.Unlock()
+
+    // If the run is not running normally
+    if hibernation.state != StateRunning {
+        return fmt.Errorf("run is already %s", hibernation.state)
+    }
+
+    hibernation.setState(StateCheckpointing)
+    return nil
+}
+
+// Marks the instances of the fleet as stopped once the clients checkpointed.
+//
+func (hibernation *Hibernation) Stopped() {
+    hibernation.mutx.Lock()
+    defer hibernation.mutx.Unlock()
+
+    // If the hibernation was not waiting on the clients to checkpoint
+    if hibernation.state != StateCheckpointing {
+        return
+    }
+
+    hibernation.setState(StateStopped)
+}
+
+// Resumes the run once the instances were started, waiting on their clients to reattach.
+//
+// @Parameters
+// - count:  The number of clients expected to reconnect
+//
+// @Returns
+// - Error if the fleet is not stopped, otherwise nil on success
+//
+func (hibernation *Hibernation) Resume(count int) error {
+    hibernation.mutx.Lock()
+    defer hibernation.mutx.Unlock()
+
+    // If the instances were not stopped yet, or the run was never hibernated
+    if hibernation.state != StateStopped {
+        return fmt.Errorf("fleet can not be resumed while the run is %s", hibernation.state)
+    }
+
+    hibernation.reattach = count
+    // If no clients are expected back, the run is running again right away
+    if count < 1 {
+        hibernation.setState(StateRunning)
+        return nil
+    }
+
+    hibernation.setState(StateResuming)
+    return nil
+}
+
+// Counts a client that connected, the run is running again once every client expected
+// after the resume has reattached.
+//
+func (hibernation *Hibernation) Attached() {
+    hibernation.mutx.Lock()
+    defer hibernation.mutx.Unlock()
+
+    // If no clients are expected back
+    if hibernation.state != StateResuming {
+        return
+    }
+
+    hibernation.reattach--
+    if hibernation.reattach < 1 {
+        hibernation.setState(StateRunning)
+    }
+}
+
+// Checks whether the clients are to checkpoint and disconnect.
+//
+// @Returns
+// - true if the run is checkpointing or stopped, otherwise false
+//
+func (hibernation *Hibernation) Active() bool {
+    hibernation.mutx.Lock()
+    defer hibernation.mutx.Unlock()
+
+    return hibernation.state == StateCheckpointing || hibernation.state == StateStopped
+}
+
+// Checks whether the run is running normally, so it may finish once its clients have.
+//
+// @Returns
+// - true if the run is neither hibernating nor waiting on clients to reattach
+//
+func (hibernation *Hibernation) Idle() bool {
+    hibernation.mutx.Lock()
+    defer hibernation.mutx.Unlock()
+
+    return hibernation.state == StateRunning
+}
+
+// Gets the current state of the hibernation.
+//
+// @Returns
+// - The current state
+//
+func (hibernation *Hibernation) State() string {
+    hibernation.mutx.Lock()
+    defer hibernation.mutx.Unlock()
+
+    return hibernation.state
+}
+
+// Gets a channel closed on the next state change, taken before the state is checked so
+// a change between the check and the wait is not missed.
+//
+// @Returns
+// - The channel closed on the next state change
+//
+func (hibernation *Hibernation) Changed() <-chan struct{} {
+    hibernation.mutx.Lock()
+    defer hibernation.mutx.Unlock()
+
+    return hibernation.changed
+}
@@ -0,0 +1,59 @@
+package hibernate_test
+
+import (
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/hibernate"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestHibernation(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    hibernation := hibernate.New()
+    // Ensure a running run can not be resumed
+    assert.True(hibernation.Idle())
+    assert.NotEqual(nil, hibernation.Resume(2))
+
+    changed := hibernation.Changed()
+    err := hibernation.Begin()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure beginning signals the waiters
+    select {
+    case <-changed:
+    default:
+        t.Fatal("hibernation change was not signaled")
+    }
+
+    // Ensure the clients checkpoint and the run can not be hibernated twice
+    assert.True(hibernation.Active())
+    assert.False(hibernation.Idle())
+    assert.NotEqual(nil, hibernation.Begin())
+
+    // Ensure the fleet can only be resumed once stopped
+    assert.NotEqual(nil, hibernation.Resume(2))
+    hibernation.Stopped()
+    assert.Equal(hibernate.StateStopped, hibernation.State())
+    assert.True(hibernation.Active())
+
+    err = hibernation.Resume(2)
+    assert.Equal(nil, err)
+    assert.False(hibernation.Active())
+    assert.False(hibernation.Idle())
+
+    // Ensure the run is running again once every client reattached
+    hibernation.Attached()
+    assert.Equal(hibernate.StateResuming, hibernation.State())
+    hibernation.Attached()
+    assert.True(hibernation.Idle())
+
+    // Ensure a resume expecting no clients is running right away
+    assert.Equal(nil, hibernation.Begin())
+    hibernation.Stopped()
+    assert.Equal(nil, hibernation.Resume(0))
+    assert.True(hibernation.Idle())
+}
@@ -0,0 +1,298 @@
+package identify
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/validate"
+)
+
+// Package level variables
+var rules = []rule{  // Formats in order of likelihood when several match the same hash
+    {"3200", "bcrypt $2*$, Blowfish (Unix)", `^\$2[abxy]?\$\d{2}\$[./A-Za-z0-9]{53}$`},
+    {"1800", "sha512crypt $6$, SHA512 (Unix)",
+     `^\$6\$(rounds=\d+\$)?[^$]{0,16}\$[./A-Za-z0-9]{86}$`},
+    {"7400", "sha256crypt $5$, SHA256 (Unix)",
+     `^\$5\$(rounds=\d+\$)?[^$]{0,16}\$[./A-Za-z0-9]{43}$`},
+    {"500", "md5crypt $1$, MD5 (Unix)", `^\$1\$[^$]{0,8}\$[./A-Za-z0-9]{22}$`},
+    {"1600", "Apache $apr1$ MD5", `^\$apr1\$[^$]{0,8}\$[./A-Za-z0-9]{22}$`},
+    {"400", "phpass, WordPress (MD5), phpBB3 (MD5)", `^\$[PH]\$[./A-Za-z0-9]{31}$`},
+    {"13100", "Kerberos 5 TGS-REP etype 23", `^\$krb5tgs\$23\$`},
+    {"18200", "Kerberos 5 AS-REP etype 23", `^\$krb5asrep\$23\$`},
+    {"8900", "scrypt", `^SCRYPT:\d+:\d+:\d+:[A-Za-z0-9+/=]+:[A-Za-z0-9+/=]+$`},
+    {"10000", "Django (PBKDF2-SHA256)", `^pbkdf2_sha256\$\d+\$[^$]+\$[A-Za-z0-9+/=]{44}$`},
+    {"111", "nsldaps, SSHA-1(Base64), Netscape LDAP SSHA", `^\{SSHA\}[A-Za-z0-9+/=]{28,}$`},
+    {"101", "nsldap, SHA-1(Base64), Netscape LDAP SHA", `^\{SHA\}[A-Za-z0-9+/]{27}=$`},
+    {"132", "MSSQL (2005)", `^0x0100[0-9A-Fa-f]{48}$`},
+    {"1731", "MSSQL (2012, 2014)", `^0x0200[0-9A-Fa-f]{136}$`},
+    {"5600", "NetNTLMv2", `^[^:]+::[^:]*:[0-9A-Fa-f]{16}:[0-9A-Fa-f]{32}:[0-9A-Fa-f]+$`},
+    {"5500", "NetNTLMv1 / NetNTLMv1+ESS",
+     `^[^:]+::[^:]*:[0-9A-Fa-f]{48}:[0-9A-Fa-f]{48}:[0-9A-Fa-f]{16}$`},
+    {"300", "MySQL4.1/MySQL5", `^\*[0-9A-Fa-f]{40}$`},
+    {"0", "MD5", `^[0-9A-Fa-f]{32}$`},
+    {"1000", "NTLM", `^[0-9A-Fa-f]{32}$`},
+    {"900", "MD4", `^[0-9A-Fa-f]{32}$`},
+    {"3000", "LM", `^[0-9A-Fa-f]{32}$`},
+    {"100", "SHA1", `^[0-9A-Fa-f]{40}$`},
+    {"1300", "SHA2-224", `^[0-9A-Fa-f]{56}$`},
+    {"1400", "SHA2-256", `^[0-9A-Fa-f]{64}$`},
+    {"6900", "GOST R 34.11-94", `^[0-9A-Fa-f]{64}$`},
+    {"10800", "SHA2-384", `^[0-9A-Fa-f]{96}$`},
+    {"1700", "SHA2-512", `^[0-9A-Fa-f]{128}$`},
+    {"200", "MySQL323", `^[0-9A-Fa-f]{16}$`},
+    {"5100", "Half MD5", `^[0-9A-Fa-f]{16}$`},
+    {"11", "Joomla < 2.5.18", `^[0-9A-Fa-f]{32}:[A-Za-z0-9]{32}$`},
+    {"10", "md5($pass.$salt)", `^[0-9A-Fa-f]{32}:.+$`},
+    {"20", "md5($salt.$pass)", `^[0-9A-Fa-f]{32}:.+$`},
+    {"110", "sha1($pass.$salt)", `^[0-9A-Fa-f]{40}:.+$`},
+    {"120", "sha1($salt.$pass)", `^[0-9A-Fa-f]{40}:.+$`},
+    {"1410", "sha256($pass.$salt)", `^[0-9A-Fa-f]{64}:.+$`},
+    {"1420", "sha256($salt.$pass)", `^[0-9A-Fa-f]{64}:.+$`},
+    {"1710", "sha512($pass.$salt)", `^[0-9A-Fa-f]{128}:.+$`},
+    {"1720", "sha512($salt.$pass)", `^[0-9A-Fa-f]{128}:.+$`},
+}
+var patterns = compileRules()  // Compiled patterns in the same order as the rules
+
+
+// rule is a hash format and the pattern hashes of the format match
+type rule struct {
+    hashType string
+    name     string
+    pattern  string
+}
+
+
+// Candidate is a hashcat hash type a hash may be
+type Candidate struct {
+    HashType  string
+    Name      string
+    Supported bool
+}
+
+
+// Suggestion is a candidate hash type with the number of sampled hashes it matched
+type Suggestion struct {
+    Candidate
+    Matches int
+}
+
+
+// Report is the result of identifying the sampled hashes of a hash file
+type Report struct {
+    Sampled     int
+    Suggestions []Suggestion
+    Unmatched   int
+}
+
+
+// Compiles the patterns of the rules.
+//
+// @Returns
+// - The compiled patterns in the order of the rules
+//
+func compileRules() []*regexp.Regexp {
+    compiled := make([]*regexp.Regexp, len(rules))
+    // Iterate through the rules compiling each pattern
+    for index, rule := range rules {
+        compiled[index] = regexp.MustCompile(rule.pattern)
+    }
+
+    return compiled
+}
+
+
+// Identifies the hash types the hash may be from its length, charset, and prefix, in
+// order of likelihood. Types not supported by hash_type are included but marked.
+//
+// @Parameters
+// - hash:  The hash to identify
+//
+// @Returns
+// - The candidate hash types, empty if the format is not recognized
+//
+func Identify(hash string) []Candidate {
+    var candidates []Candidate
+    hash = strings.TrimSpace(hash)
+
+    // Iterate through the rules collecting the ones the hash matches
+    for index, rule := range rules {
+        if patterns[index].MatchString(hash) {
+            candidates = append(candidates, Candidate{
+                HashType:  rule.hashType,
+                Name:      rule.name,
+                Supported: validate.ValidateHashType(rule.hashType),
+            })
+        }
+    }
+
+    return candidates
+}
+
+
+// Samples up to the passed in number of hashes from the start of the hash file and
+// identifies them, ranking the candidate types by how many hashes they matched.
+//
+// @Parameters
+// - filePath:  The path of the hash file
+// - sampleLines:  The max number of hashes sampled
+//
+// @Returns
+// - The report of the identified hashes
+// - Error if it occurs, otherwise nil on success
+//
+func IdentifyFile(filePath string, sampleLines int) (Report, error) {
+    var report Report
+
+    file, err := os.Open(filePath)
+    if err != nil {
+        return report, fmt.Errorf("error opening hash file - %w", err)
+    }
+    // Close file on local exit
+    defer file.Close()
+
+    matches := map[string]int{}
+    var candidates []Candidate
+
+    scanner := bufio.NewScanner(file)
+    scanner.Buffer(make([]byte, 0, 64 * 1024), 1024 * 1024)
+    // Iterate through the lines of the hash file until enough are sampled
+    for report.Sampled < sampleLines && scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        // If the line is blank
+        if line == "" {
+            continue
+        }
+
+        report.Sampled++
+        lineCandidates := Identify(line)
+        // If the format of the hash is not recognized
+        if len(lineCandidates) == 0 {
+            report.Unmatched++
+            continue
+        }
+
+        // Iterate through the candidates of the line counting the matches
+        for _, candidate := range lineCandidates {
+            if _, exists := matches[candidate.HashType]; !exists {
+                candidates = append(candidates, candidate)
+            }
+
+            matches[candidate.HashType]++
+        }
+    }
+
+    err = scanner.Err()
+    if err != nil {
+        return report, fmt.Errorf("error reading hash file - %w", err)
+    }
+
+    // Iterate through the candidates adding their match counts
+    for _, candidate := range candidates {
+        report.Suggestions = append(report.Suggestions, Suggestion{
+            Candidate: candidate,
+            Matches:   matches[candidate.HashType],
+        })
+    }
+
+    // Rank by the matches, then by the likelihood of the rule
+    sort.SliceStable(report.Suggestions, func(i, j int) bool {
+        first, second := report.Suggestions[i], report.Suggestions[j]
+        if first.Matches != second.Matches {
+            return first.Matches > second.Matches
+        }
+
+        return ruleIndex(first.HashType) < ruleIndex(second.HashType)
+    })
+
+    return report, nil
+}
+
+
+// Gets the index of the rule of the hash type, which orders its likelihood.
+//
+// @Parameters
+// - hashType:  The hash type of the rule
+//
+// @Returns
+// - The index of the rule, the number of rules if not found
+//
+func ruleIndex(hashType string) int {
+    // Iterate through the rules matching the hash type
+    for index, rule := range rules {
+        if rule.hashType == hashType {
+            return index
+        }
+    }
+
+    return len(rules)
+}
+
+
+// Gets the most likely supported hash type of the report.
+//
+// @Parameters
+// - report:  The report of the identified hashes
+//
+// @Returns
+// - The suggested hash type
+// - true/false depending on whether any supported type was identified
+//
+func (report Report) Suggested() (Suggestion, bool) {
+    // Iterate through the ranked suggestions returning the first supported
+    for _, suggestion := range report.Suggestions {
+        if suggestion.Supported {
+            return suggestion, true
+        }
+    }
+
+    return Suggestion{}, false
+}
+
+
+// Formats the report as the text printed by the identify subcommand.
+//
+// @Parameters
+// - report:  The report of the identified hashes
+//
+// @Returns
+// - The formatted report
+//
+func FormatReport(report Report) string {
+    var builder strings.Builder
+
+    fmt.Fprintf(&builder, "Sampled hashes:  %d\n", report.Sampled)
+    fmt.Fprintf(&builder, "Unrecognized:    %d\n", report.Unmatched)
+
+    // If no hash was recognized
+    if len(report.Suggestions) == 0 {
+        builder.WriteString("No known hash format matched the sampled hashes\n")
+        return builder.String()
+    }
+
+    builder.WriteString("Candidate hash types:\n")
+    // Iterate through the ranked suggestions
+    for _, suggestion := range report.Suggestions {
+        note := ""
+        // If the type can not be set as the hash_type
+        if !suggestion.Supported {
+            note = "  (not supported by hash_type)"
+        }
+
+        fmt.Fprintf(&builder, "  %-6s %-45s %d/%d%s\n", suggestion.HashType, suggestion.Name,
+                    suggestion.Matches, report.Sampled, note)
+    }
+
+    suggested, found := report.Suggested()
+    // If only unsupported types were identified
+    if !found {
+        builder.WriteString("None of the candidate hash types are supported by hash_type\n")
+        return builder.String()
+    }
+
+    fmt.Fprintf(&builder, "\nSuggested config:\n  hash_type: %q\n", suggested.HashType)
+    return builder.String()
+}
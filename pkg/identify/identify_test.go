@@ -0,0 +1,75 @@
+package identify_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/identify"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestIdentify(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    hashes := map[string]string{
+        "$2y$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy": "3200",
+        "$1$28772684$iEwNOgGugqO9.bIz5sk8k/":                           "500",
+        "$P$984478476IagS59wHZvyQMArzfx58u.":                           "400",
+        "*2470C0C06DEE42FD1618BB99005ADCA2EC9D1E19":                    "300",
+        "8846f7eaee8fb117ad06bdd830b7586c":                             "0",
+        "5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8":                     "100",
+    }
+    // Iterate through the hashes ensuring the most likely type is first
+    for hash, hashType := range hashes {
+        candidates := identify.Identify(hash)
+        assert.NotEmpty(candidates, hash)
+        assert.Equal(hashType, candidates[0].HashType, hash)
+    }
+
+    // Ensure every type a 32 character hex hash may be is listed with its support
+    candidates := identify.Identify("8846f7eaee8fb117ad06bdd830b7586c")
+    assert.Equal("1000", candidates[1].HashType)
+    assert.True(candidates[1].Supported)
+    assert.Equal("3000", candidates[3].HashType)
+    assert.False(candidates[3].Supported)
+
+    // Ensure unrecognized formats have no candidates
+    for _, falacy := range []string{"", "password", "8846f7eaee8fb117ad06bdd830b7586"} {
+        assert.Empty(identify.Identify(falacy))
+    }
+}
+
+
+func TestIdentifyFile(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    hashPath := filepath.Join(t.TempDir(), "hashes.txt")
+    lines := []string{"8846f7eaee8fb117ad06bdd830b7586c", "",
+                      "b4b9b02e6f09a9bd760f388b67351e2b", "not a hash",
+                      "5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8",
+                      "209c6174da490caeb422f3fa5a7ae634"}
+    err := os.WriteFile(hashPath, []byte(strings.Join(lines, "\n")), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    report, err := identify.IdentifyFile(hashPath, 4)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure blank lines are skipped and sampling stops at the limit
+    assert.Equal(4, report.Sampled)
+    assert.Equal(1, report.Unmatched)
+
+    suggested, found := report.Suggested()
+    assert.True(found)
+    assert.Equal("0", suggested.HashType)
+    assert.Equal(2, suggested.Matches)
+    assert.Contains(identify.FormatReport(report), "hash_type: \"0\"")
+
+    _, err = identify.IdentifyFile(filepath.Join(t.TempDir(), "missing.txt"), 4)
+    assert.NotEqual(nil, err)
+}
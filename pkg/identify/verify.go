@@ -0,0 +1,192 @@
+package identify
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Package level variables
+var MaxReportedLines = 20  // Max line numbers of invalid hashes kept for the report
+
+
+// Verification is the result of checking the hashes of a hash file against its hash type
+type Verification struct {
+    Checked      int    // Hashes checked, blank lines excluded
+    HashType     string
+    Invalid      int    // Hashes not matching the format of the hash type
+    InvalidLines []int  // Line numbers of the first invalid hashes
+    Verifiable   bool   // Whether the hash type has a known format to check against
+}
+
+
+// Checks whether the hash type has a known format the hashes can be checked against.
+//
+// @Parameters
+// - hashType:  The hashcat hash type
+//
+// @Returns
+// - true if the format of the hash type is known, otherwise false
+//
+func Verifiable(hashType string) bool {
+    return ruleIndex(hashType) < len(rules)
+}
+
+
+// Checks whether the hash matches the format of the hash type, hashes of a type
+// without a known format are always valid.
+//
+// @Parameters
+// - hashType:  The hashcat hash type
+// - hash:  The hash to check
+//
+// @Returns
+// - true if the hash matches the hash type or the format is unknown, otherwise false
+//
+func Valid(hashType string, hash string) bool {
+    known := false
+    hash = strings.TrimSpace(hash)
+
+    // Iterate through the rules of the hash type checking the hash against them
+    for index, rule := range rules {
+        if rule.hashType != hashType {
+            continue
+        }
+
+        known = true
+        if patterns[index].MatchString(hash) {
+            return true
+        }
+    }
+
+    return !known
+}
+
+
+// Checks every hash of the hash file against the format of the hash type by its length,
+// charset, and prefix. If a valid path is passed in, the valid hashes are written to it
+// and the invalid ones to the rejects path, so hashcat is never handed lines it rejects.
+//
+// @Parameters
+// - filePath:  The path of the hash file
+// - hashType:  The hashcat hash type of the hash file
+// - validPath:  The path the valid hashes are written to, empty to only check them
+// - rejectsPath:  The path the invalid hashes are written to when the valid path is set
+//
+// @Returns
+// - The verification of the hash file
+// - Error if it occurs, otherwise nil on success
+//
+func VerifyFile(filePath string, hashType string, validPath string,
+                rejectsPath string) (Verification, error) {
+    verification := Verification{HashType: hashType, Verifiable: Verifiable(hashType)}
+
+    file, err := os.Open(filePath)
+    if err != nil {
+        return verification, fmt.Errorf("error opening hash file - %w", err)
+    }
+    // Close file on local exit
+    defer file.Close()
+
+    var validWriter, rejectsWriter *bufio.Writer
+    // If the hashes are split, create the valid and rejects files
+    if validPath != "" {
+        validFile, err := os.Create(validPath)
+        if err != nil {
+            return verification, fmt.Errorf("error creating valid hash file - %w", err)
+        }
+        // Close the valid file on local exit
+        defer validFile.Close()
+
+        rejectsFile, err := os.Create(rejectsPath)
+        if err != nil {
+            return verification, fmt.Errorf("error creating rejected hash file - %w", err)
+        }
+        // Close the rejects file on local exit
+        defer rejectsFile.Close()
+
+        validWriter = bufio.NewWriter(validFile)
+        rejectsWriter = bufio.NewWriter(rejectsFile)
+    }
+
+    lineNumber := 0
+    scanner := bufio.NewScanner(file)
+    scanner.Buffer(make([]byte, 0, 64 * 1024), 1024 * 1024)
+
+    // Iterate through the lines of the hash file checking each hash
+    for scanner.Scan() {
+        lineNumber++
+        line := scanner.Text()
+
+        // If the line is blank
+        if strings.TrimSpace(line) == "" {
+            continue
+        }
+
+        verification.Checked++
+        writer := validWriter
+
+        // If the hash does not match the format of the hash type
+        if !Valid(hashType, line) {
+            verification.Invalid++
+            writer = rejectsWriter
+
+            if len(verification.InvalidLines) < MaxReportedLines {
+                verification.InvalidLines = append(verification.InvalidLines, lineNumber)
+            }
+        }
+
+        // If the hashes are split, write the line to its file
+        if writer != nil {
+            _, err = writer.WriteString(line + "\n")
+            if err != nil {
+                return verification, fmt.Errorf("error writing verified hash - %w", err)
+            }
+        }
+    }
+
+    err = scanner.Err()
+    if err != nil {
+        return verification, fmt.Errorf("error reading hash file - %w", err)
+    }
+
+    // If the hashes are split, flush the buffered lines
+    if validWriter != nil {
+        err = validWriter.Flush()
+        if err != nil {
+            return verification, fmt.Errorf("error writing valid hash file - %w", err)
+        }
+
+        err = rejectsWriter.Flush()
+        if err != nil {
+            return verification, fmt.Errorf("error writing rejected hash file - %w", err)
+        }
+    }
+
+    return verification, nil
+}
+
+
+// Formats the line numbers of the invalid hashes of the verification for display,
+// noting how many more were found than kept.
+//
+// @Returns
+// - The formatted line numbers, empty when every hash was valid
+//
+func (verification Verification) FormatLines() string {
+    numbers := make([]string, len(verification.InvalidLines))
+    // Iterate through the kept line numbers formatting each
+    for index, lineNumber := range verification.InvalidLines {
+        numbers[index] = fmt.Sprint(lineNumber)
+    }
+
+    formatted := strings.Join(numbers, ", ")
+    extra := verification.Invalid - len(verification.InvalidLines)
+    // If more hashes were invalid than line numbers kept
+    if extra > 0 {
+        formatted += fmt.Sprintf(" (+%d more)", extra)
+    }
+
+    return formatted
+}
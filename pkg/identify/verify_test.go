@@ -0,0 +1,81 @@
+package identify_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/identify"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestValid(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Ensure hashes are checked against every format of their type
+    assert.True(identify.Valid("0", "8846f7eaee8fb117ad06bdd830b7586c"))
+    assert.True(identify.Valid("3200",
+                               "$2y$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"))
+    assert.False(identify.Valid("0", "8846f7eaee8fb117ad06bdd830b7586"))
+    assert.False(identify.Valid("100", "8846f7eaee8fb117ad06bdd830b7586c"))
+
+    // Ensure hashes of types without a known format are never rejected
+    assert.False(identify.Verifiable("22000"))
+    assert.True(identify.Valid("22000", "anything"))
+}
+
+
+func TestVerifyFile(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    testDir := t.TempDir()
+
+    hashPath := filepath.Join(testDir, "hashes.txt")
+    hashes := "8846f7eaee8fb117ad06bdd830b7586c\n" +
+              "not-a-hash\n" +
+              "\n" +
+              "5f4dcc3b5aa765d61d8327deb882cf99\n" +
+              "5f4dcc3b5aa765d61d8327deb882cf99:salt\n"
+    err := os.WriteFile(hashPath, []byte(hashes), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    verification, err := identify.VerifyFile(hashPath, "0", "", "")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the invalid hashes are reported by their line numbers
+    assert.True(verification.Verifiable)
+    assert.Equal(4, verification.Checked)
+    assert.Equal(2, verification.Invalid)
+    assert.Equal([]int{2, 5}, verification.InvalidLines)
+    assert.Equal("2, 5", verification.FormatLines())
+
+    validPath := filepath.Join(testDir, "valid.txt")
+    rejectsPath := filepath.Join(testDir, "rejects.txt")
+    _, err = identify.VerifyFile(hashPath, "0", validPath, rejectsPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    validData, err := os.ReadFile(validPath)
+    assert.Equal(nil, err)
+    // Ensure the valid hashes are kept and the invalid ones split into the rejects
+    assert.Equal("8846f7eaee8fb117ad06bdd830b7586c\n5f4dcc3b5aa765d61d8327deb882cf99\n",
+                 string(validData))
+
+    rejectsData, err := os.ReadFile(rejectsPath)
+    assert.Equal(nil, err)
+    assert.Equal("not-a-hash\n5f4dcc3b5aa765d61d8327deb882cf99:salt\n", string(rejectsData))
+
+    identify.MaxReportedLines = 1
+    // Reset the reported lines on local exit
+    defer func() {
+        identify.MaxReportedLines = 20
+    } ()
+
+    verification, err = identify.VerifyFile(hashPath, "0", "", "")
+    assert.Equal(nil, err)
+    // Ensure the line numbers past the max are only counted
+    assert.Equal("2 (+1 more)", verification.FormatLines())
+}
@@ -0,0 +1,423 @@
+package inspect
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/data"
+)
+
+// Default number of bytes sampled from the start of each wordlist
+const DefaultSampleSize = 1 * globals.MB
+
+
+// FileStats is the inspection result of a single wordlist, the line count is estimated
+// from the sample when only part of the file was read
+type FileStats struct {
+    CrlfLines      int64
+    DuplicateRatio float64
+    Estimated      bool
+    InvalidLines   int64
+    Lines          int64
+    NullBytes      bool
+    Path           string
+    Size           int64
+}
+
+// Checks whether the sample of the wordlist had any encoding problems.
+//
+// @Returns
+// - true/false depending on whether any problems were found
+//
+func (stats FileStats) HasEncodingProblems() bool {
+    return stats.CrlfLines > 0 || stats.InvalidLines > 0 || stats.NullBytes
+}
+
+
+// Report is the inspection result of the load dir and its predicted merge layout
+type Report struct {
+    DuplicateRatio   float64
+    EmptyFiles       int
+    EncodingProblems int
+    Files            []FileStats
+    Layout           []int64
+    MaxFileSize      int64
+    Oversized        int
+    TotalLines       int64
+    TotalSize        int64
+}
+
+
+// Samples the start of a wordlist counting its lines, duplicates, and encoding problems.
+// Line hashes are added to the passed in set so duplicates across wordlists are found.
+//
+// @Parameters
+// - filePath:  The path of the wordlist to inspect
+// - sampleSize:  The max number of bytes to read from the start of the wordlist
+// - seen:  The set of line hashes sampled from every wordlist so far
+//
+// @Returns
+// - The stats of the wordlist
+// - The number of sampled lines already in the set before this wordlist
+// - The number of lines sampled
+// - Error if it occurs, otherwise nil on success
+//
+func InspectFile(filePath string, sampleSize int64,
+                 seen map[uint64]struct{}) (FileStats, int64, int64, error) {
+    file, err := os.Open(filePath)
+    if err != nil {
+        return FileStats{}, 0, 0, err
+    }
+    // Close file on local exit
+    defer file.Close()
+
+    info, err := file.Stat()
+    if err != nil {
+        return FileStats{}, 0, 0, err
+    }
+
+    stats := FileStats{Path: filePath, Size: info.Size()}
+    local := map[uint64]struct{}{}
+    reader := bufio.NewReader(io.LimitReader(file, sampleSize))
+
+    var bytesRead int64
+    var crossDuplicates int64
+    var localDuplicates int64
+    var sampled int64
+
+    for {
+        line, err := reader.ReadBytes('\n')
+        // If the sample ended in the middle of a line, it is not counted
+        if errors.Is(err, io.EOF) && bytesRead + int64(len(line)) < stats.Size {
+            break
+        }
+
+        // If a line was read
+        if len(line) > 0 {
+            bytesRead += int64(len(line))
+            sampled += 1
+            line = bytes.TrimSuffix(line, []byte("\n"))
+
+            // If the line has a Windows line ending
+            if bytes.HasSuffix(line, []byte("\r")) {
+                stats.CrlfLines += 1
+            }
+
+            // If the line is not valid UTF-8 text
+            if !utf8.Valid(line) {
+                stats.InvalidLines += 1
+            }
+
+            // If the line has null bytes, the file is likely binary
+            if bytes.IndexByte(line, 0) >= 0 {
+                stats.NullBytes = true
+            }
+
+            hasher := fnv.New64a()
+            hasher.Write(line)
+            lineHash := hasher.Sum64()
+
+            // If the line was already sampled from this wordlist
+            if _, exists := local[lineHash]; exists {
+                localDuplicates += 1
+            } else {
+                local[lineHash] = struct{}{}
+
+                // If the line was already sampled from another wordlist
+                if _, exists = seen[lineHash]; exists {
+                    crossDuplicates += 1
+                }
+            }
+        }
+
+        if err != nil {
+            // If the end of the sample was reached
+            if errors.Is(err, io.EOF) {
+                break
+            }
+
+            return FileStats{}, 0, 0, err
+        }
+    }
+
+    // Add the sampled lines to the set shared across wordlists
+    for lineHash := range local {
+        seen[lineHash] = struct{}{}
+    }
+
+    stats.Lines = sampled
+    // If the sample did not cover the whole file, estimate from the sampled line length
+    if bytesRead < stats.Size {
+        stats.Estimated = true
+        // If any whole line was sampled
+        if bytesRead > 0 {
+            stats.Lines = int64(float64(sampled) * float64(stats.Size) / float64(bytesRead))
+        }
+    }
+
+    // If any lines were sampled
+    if sampled > 0 {
+        stats.DuplicateRatio = float64(localDuplicates) / float64(sampled)
+    }
+
+    return stats, localDuplicates + crossDuplicates, sampled, nil
+}
+
+
+// Walks the load dir inspecting every wordlist and predicting the layout of the
+// wordlists after merging, without modifying any of them.
+//
+// @Parameters
+// - loadDir:  The path to the load dir to inspect
+// - sampleSize:  The max number of bytes to sample from the start of each wordlist
+// - maxMergingSize:  The size wordlists are merged up to
+// - maxFileSize:  The maximum size a wordlist can be sent as
+// - maxRange:  The range within the max that makes a file register as full
+//
+// @Returns
+// - The inspection report
+// - Error if it occurs, otherwise nil on success
+//
+func InspectDir(loadDir string, sampleSize int64, maxMergingSize int64, maxFileSize int64,
+                maxRange float64) (Report, error) {
+    report := Report{MaxFileSize: maxFileSize}
+    seen := map[uint64]struct{}{}
+
+    var duplicates int64
+    var sampled int64
+    var sizes []int64
+
+    // Walk the load dir in the same lexical order wordlists are merged in
+    err := filepath.Walk(loadDir, func(path string, itemInfo os.FileInfo, err error) error {
+        if err != nil {
+            return err
+        }
+
+        // If the item is a dir, skip to next
+        if itemInfo.IsDir() {
+            return nil
+        }
+
+        stats, fileDuplicates, fileSampled, err := InspectFile(path, sampleSize, seen)
+        if err != nil {
+            return fmt.Errorf("error inspecting %s - %w", path, err)
+        }
+
+        duplicates += fileDuplicates
+        sampled += fileSampled
+
+        // If the wordlist is empty it is never sent
+        if stats.Size == 0 {
+            report.EmptyFiles += 1
+        } else {
+            sizes = append(sizes, stats.Size)
+        }
+
+        // If the wordlist is split when merged
+        if stats.Size > maxFileSize {
+            report.Oversized += 1
+        }
+
+        // If the sample had any encoding problems
+        if stats.HasEncodingProblems() {
+            report.EncodingProblems += 1
+        }
+
+        report.Files = append(report.Files, stats)
+        report.TotalLines += stats.Lines
+        report.TotalSize += stats.Size
+        return nil
+    })
+    if err != nil {
+        return Report{}, err
+    }
+
+    // If any lines were sampled, estimate the ratio removed by duplicut when merged
+    if sampled > 0 {
+        report.DuplicateRatio = float64(duplicates) / float64(sampled)
+    }
+
+    report.Layout = PredictLayout(sizes, maxMergingSize, maxFileSize, maxRange,
+                                  report.DuplicateRatio)
+    return report, nil
+}
+
+
+// Predicts the sizes of the wordlists after merging by following the merge process with
+// file sizes, small wordlists are concatenated and reduced by the duplicate ratio until
+// they reach the merging size and oversized wordlists are shaved into max size files.
+//
+// @Parameters
+// - sizes:  The sizes of the wordlists in merge order
+// - maxMergingSize:  The size wordlists are merged up to
+// - maxFileSize:  The maximum size a wordlist can be sent as
+// - maxRange:  The range within the max that makes a file register as full
+// - duplicateRatio:  The estimated ratio of lines removed as duplicates
+//
+// @Returns
+// - The predicted sizes of the merged wordlists
+//
+func PredictLayout(sizes []int64, maxMergingSize int64, maxFileSize int64, maxRange float64,
+                   duplicateRatio float64) []int64 {
+    var layout []int64
+    var pending []int64
+
+    // Checks whether the size is considered full at the merging size
+    isFull := func(size int64) bool {
+        return (size <= maxFileSize && size >= maxMergingSize) ||
+               data.IsInPercentRange(float64(maxMergingSize), float64(size), maxRange)
+    }
+
+    // Shaves max size files off the size, returning the remainder to merge
+    shave := func(size int64) {
+        for size > maxFileSize {
+            layout = append(layout, maxFileSize)
+            size -= maxFileSize
+        }
+
+        // If the remainder is full it is kept, otherwise it is merged further
+        if isFull(size) {
+            layout = append(layout, size)
+        } else if size > 0 {
+            pending = append(pending, size)
+        }
+    }
+
+    // Iterate through the sizes in merge order
+    for _, size := range sizes {
+        // If the wordlist is already full
+        if isFull(size) {
+            layout = append(layout, size)
+            continue
+        }
+
+        // If the wordlist is oversized, shave it into max size files
+        if size >= maxMergingSize {
+            shave(size)
+            continue
+        }
+
+        pending = append(pending, size)
+        // If there is nothing to merge the wordlist with yet
+        if len(pending) < 2 {
+            continue
+        }
+
+        // The previous result was already deduplicated, only the new wordlist shrinks
+        merged := pending[0]
+        for _, pendingSize := range pending[1:] {
+            merged += int64(float64(pendingSize) * (1 - duplicateRatio))
+        }
+        pending = nil
+
+        // If the merged wordlist is full
+        if merged == maxMergingSize || (merged < maxMergingSize && isFull(merged)) {
+            layout = append(layout, merged)
+        } else if merged < maxMergingSize {
+            pending = append(pending, merged)
+        } else {
+            shave(merged)
+        }
+    }
+
+    // Any wordlists never merged are sent as is
+    return append(layout, pending...)
+}
+
+
+// Formats the inspection report for display.
+//
+// @Parameters
+// - report:  The inspection report to format
+//
+// @Returns
+// - The formatted report
+//
+func FormatReport(report Report) string {
+    var builder strings.Builder
+
+    builder.WriteString(fmt.Sprintf("Wordlists:  %d (%d empty, %d over max file size)\n",
+                                    len(report.Files), report.EmptyFiles, report.Oversized))
+    builder.WriteString(fmt.Sprintf("Total size:  %s\n", formatSize(report.TotalSize)))
+    builder.WriteString(fmt.Sprintf("Estimated lines:  %d\n", report.TotalLines))
+    builder.WriteString(fmt.Sprintf("Estimated duplicate ratio:  %.2f%%\n",
+                                    report.DuplicateRatio * 100))
+    builder.WriteString(fmt.Sprintf("Files with encoding problems:  %d\n",
+                                    report.EncodingProblems))
+
+    // Iterate through the wordlists listing each
+    builder.WriteString("\nPath | Size | Lines | Duplicates | Problems\n")
+    for _, stats := range report.Files {
+        lines := fmt.Sprintf("%d", stats.Lines)
+        // If the line count is estimated from the sample
+        if stats.Estimated {
+            lines = "~" + lines
+        }
+
+        var problems []string
+        if stats.CrlfLines > 0 {
+            problems = append(problems, fmt.Sprintf("%d CRLF lines", stats.CrlfLines))
+        }
+        if stats.InvalidLines > 0 {
+            problems = append(problems, fmt.Sprintf("%d invalid UTF-8 lines",
+                                                    stats.InvalidLines))
+        }
+        if stats.NullBytes {
+            problems = append(problems, "null bytes")
+        }
+        if len(problems) == 0 {
+            problems = append(problems, "-")
+        }
+
+        builder.WriteString(fmt.Sprintf("%s | %s | %s | %.2f%% | %s\n", stats.Path,
+                                        formatSize(stats.Size), lines,
+                                        stats.DuplicateRatio * 100,
+                                        strings.Join(problems, ", ")))
+    }
+
+    var layoutSize int64
+    for _, size := range report.Layout {
+        layoutSize += size
+    }
+
+    builder.WriteString(fmt.Sprintf("\nPredicted merge layout:  %d files, %s total " +
+                                    "(max file size %s)\n", len(report.Layout),
+                                    formatSize(layoutSize), formatSize(report.MaxFileSize)))
+    // Iterate through the predicted files listing their sizes
+    for index, size := range report.Layout {
+        builder.WriteString(fmt.Sprintf("  %d.  %s\n", index + 1, formatSize(size)))
+    }
+
+    return builder.String()
+}
+
+
+// Formats a size in bytes with the largest fitting unit.
+//
+// @Parameters
+// - size:  The size in bytes
+//
+// @Returns
+// - The formatted size
+//
+func formatSize(size int64) string {
+    switch {
+    case size >= globals.GB:
+        return fmt.Sprintf("%.2fGB", float64(size) / globals.GB)
+    case size >= globals.MB:
+        return fmt.Sprintf("%.2fMB", float64(size) / globals.MB)
+    case size >= globals.KB:
+        return fmt.Sprintf("%.2fKB", float64(size) / globals.KB)
+    default:
+        return fmt.Sprintf("%dB", size)
+    }
+}
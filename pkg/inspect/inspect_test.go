@@ -0,0 +1,111 @@
+package inspect_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/inspect"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestInspectFile(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    filePath := filepath.Join(t.TempDir(), "words.txt")
+    err := os.WriteFile(filePath, []byte("alpha\nbeta\nalpha\ngamma\r\n\xff\xfe\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    seen := map[uint64]struct{}{}
+    stats, duplicates, sampled, err := inspect.InspectFile(filePath, inspect.DefaultSampleSize,
+                                                           seen)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.False(stats.Estimated)
+    assert.Equal(int64(5), stats.Lines)
+    assert.Equal(int64(1), stats.CrlfLines)
+    assert.Equal(int64(1), stats.InvalidLines)
+    assert.True(stats.HasEncodingProblems())
+    assert.Equal(int64(1), duplicates)
+    assert.Equal(int64(5), sampled)
+    assert.InDelta(0.2, stats.DuplicateRatio, 0.001)
+
+    // Ensure lines already sampled from another wordlist count as duplicates
+    _, duplicates, _, err = inspect.InspectFile(filePath, inspect.DefaultSampleSize, seen)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(int64(5), duplicates)
+
+    // Ensure a partial sample estimates the lines from the sampled line length
+    err = os.WriteFile(filePath, []byte(strings.Repeat("123456789\n", 100)), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    stats, _, sampled, err = inspect.InspectFile(filePath, 105, map[uint64]struct{}{})
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.True(stats.Estimated)
+    assert.Equal(int64(10), sampled)
+    assert.Equal(int64(100), stats.Lines)
+}
+
+
+func TestInspectDir(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    loadDir := t.TempDir()
+    files := map[string]string{
+        "a.txt":     "one\ntwo\nthree\n",
+        "b.txt":     "three\nfour\n",
+        "empty.txt": "",
+        "sub/c.txt": "five\n",
+    }
+
+    // Iterate through the files writing each to the load dir
+    for name, content := range files {
+        filePath := filepath.Join(loadDir, name)
+        err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+
+        err = os.WriteFile(filePath, []byte(content), 0644)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+    }
+
+    report, err := inspect.InspectDir(loadDir, inspect.DefaultSampleSize, 1024, 2048, 15.0)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(4, len(report.Files))
+    assert.Equal(1, report.EmptyFiles)
+    assert.Equal(int64(6), report.TotalLines)
+    assert.Equal(int64(30), report.TotalSize)
+    // Ensure the line shared across wordlists is found
+    assert.InDelta(1.0 / 6.0, report.DuplicateRatio, 0.001)
+    // Ensure the small wordlists are predicted to merge into one
+    assert.Equal(1, len(report.Layout))
+    assert.Contains(inspect.FormatReport(report), "Predicted merge layout:  1 files")
+
+    // Ensure a missing load dir is an error
+    _, err = inspect.InspectDir(filepath.Join(loadDir, "missing"), 1024, 1024, 2048, 15.0)
+    assert.NotNil(err)
+}
+
+
+func TestPredictLayout(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Ensure full wordlists are kept and oversized ones are shaved into max size files
+    layout := inspect.PredictLayout([]int64{95, 250}, 100, 100, 10.0, 0)
+    assert.Equal([]int64{95, 100, 100, 50}, layout)
+
+    // Ensure small wordlists merge until full, reduced by the duplicate ratio
+    layout = inspect.PredictLayout([]int64{40, 40, 40, 40, 10}, 100, 200, 10.0, 0.5)
+    assert.Equal([]int64{100, 10}, layout)
+}
@@ -0,0 +1,481 @@
+package instance
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/cost"
+)
+
+// Purchase options an instance is launched with
+const (
+    LifecycleOnDemand = "on-demand"
+    LifecycleSpot     = "spot"
+)
+
+// Package level variables
+var Client = &http.Client{Timeout: 10 * time.Second}  // Shared client for the metadata service
+var Endpoint = "http://169.254.169.254"               // EC2 instance metadata service (IMDSv2)
+
+
+// Metadata is the EC2 identity of the instance a client runs on, empty when not on EC2
+type Metadata struct {
+    AmiId            string `json:"ami_id,omitempty"`
+    AvailabilityZone string `json:"availability_zone,omitempty"`
+    InstanceId       string `json:"instance_id,omitempty"`
+    InstanceType     string `json:"instance_type,omitempty"`
+    Lifecycle        string `json:"lifecycle,omitempty"`  // Spot or on-demand
+}
+
+
+// Formats the metadata as a short line for display.
+//
+// @Returns
+// - The instance type, purchase option, zone, and ID, or unknown when not on EC2
+//
+func (metadata Metadata) Summary() string {
+    var fields []string
+    // Iterate through the fields that were reported, skipping the empty ones
+    for _, field := range []string{metadata.InstanceType, metadata.Lifecycle,
+                                   metadata.AvailabilityZone, metadata.InstanceId} {
+        if field != "" {
+            fields = append(fields, field)
+        }
+    }
+
+    // If nothing was reported, such as a local client
+    if len(fields) == 0 {
+        return "unknown instance"
+    }
+
+    return strings.Join(fields, " ")
+}
+
+
+// Requests a path of the instance metadata service with the session token.
+//
+// @Parameters
+// - ctx:  The context the request is cancelled with
+// - token:  The IMDSv2 session token
+// - path:  The metadata path to request
+//
+// @Returns
+// - The value of the metadata path
+// - Error if it occurs, otherwise nil on success
+//
+func get(ctx context.Context, token string, path string) (string, error) {
+    request, err := http.NewRequestWithContext(ctx, http.MethodGet,
+                                               Endpoint + "/latest/meta-data/" + path, nil)
+    if err != nil {
+        return "", err
+    }
+    request.Header.Set("X-aws-ec2-metadata-token", token)
+
+    response, err := Client.Do(request)
+    if err != nil {
+        return "", err
+    }
+    // Close the response body on local exit
+    defer response.Body.Close()
+
+    // If the metadata service did not reply with the value
+    if response.StatusCode != http.StatusOK {
+        return "", fmt.Errorf("metadata %s replied with status %d", path, response.StatusCode)
+    }
+
+    value, err := io.ReadAll(io.LimitReader(response.Body, 1024))
+    if err != nil {
+        return "", err
+    }
+
+    return strings.TrimSpace(string(value)), nil
+}
+
+
+// Requests an IMDSv2 session token from the instance metadata service.
+//
+// @Parameters
+// - ctx:  The context the request is cancelled with
+//
+// @Returns
+// - The session token
+// - Error if it occurs, such as when not running on EC2, otherwise nil on success
+//
+func session(ctx context.Context) (string, error) {
+    request, err := http.NewRequestWithContext(ctx, http.MethodPut,
+                                               Endpoint + "/latest/api/token", nil)
+    if err != nil {
+        return "", err
+    }
+    request.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+
+    response, err := Client.Do(request)
+    if err != nil {
+        return "", fmt.Errorf("error requesting metadata token - %w", err)
+    }
+    // Close the response body on local exit
+    defer response.Body.Close()
+
+    // If the metadata service did not issue a token
+    if response.StatusCode != http.StatusOK {
+        return "", fmt.Errorf("metadata token replied with status %d", response.StatusCode)
+    }
+
+    token, err := io.ReadAll(io.LimitReader(response.Body, 1024))
+    if err != nil {
+        return "", fmt.Errorf("error reading metadata token - %w", err)
+    }
+
+    return string(token), nil
+}
+
+
+// Fetches the identity of the instance from the EC2 instance metadata service with an
+// IMDSv2 session token.
+//
+// @Parameters
+// - timeout:  The max time the metadata service has to reply
+//
+// @Returns
+// - The metadata of the instance
+// - Error if it occurs, such as when not running on EC2, otherwise nil on success
+//
+func Fetch(timeout time.Duration) (Metadata, error) {
+    var metadata Metadata
+
+    ctx, cancel := context.WithTimeout(context.Background(), timeout)
+    // Cancel the requests on local exit
+    defer cancel()
+
+    token, err := session(ctx)
+    if err != nil {
+        return metadata, err
+    }
+
+    // Iterate through the metadata paths filling in their fields
+    for path, field := range map[string]*string{
+        "ami-id":                      &metadata.AmiId,
+        "instance-id":                 &metadata.InstanceId,
+        "instance-life-cycle":         &metadata.Lifecycle,
+        "instance-type":               &metadata.InstanceType,
+        "placement/availability-zone": &metadata.AvailabilityZone,
+    } {
+        *field, err = get(ctx, token, path)
+        if err != nil {
+            return Metadata{}, err
+        }
+    }
+
+    return metadata, nil
+}
+
+
+// Fetches the private IPv4 address of the primary network interface of the instance in
+// its VPC from the EC2 instance metadata service.
+//
+// @Parameters
+// - timeout:  The max time the metadata service has to reply
+//
+// @Returns
+// - The private IP address of the instance
+// - Error if it occurs, such as when not running on EC2, otherwise nil on success
+//
+func PrivateIp(timeout time.Duration) (string, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), timeout)
+    // Cancel the requests on local exit
+    defer cancel()
+
+    token, err := session(ctx)
+    if err != nil {
+        return "", err
+    }
+
+    return get(ctx, token, "local-ipv4")
+}
+
+
+// Fetches the IPv4 CIDR blocks of the VPC the primary network interface of the instance
+// is in from the EC2 instance metadata service.
+//
+// @Parameters
+// - timeout:  The max time the metadata service has to reply
+//
+// @Returns
+// - The CIDR blocks of the VPC
+// - Error if it occurs, such as when not running on EC2, otherwise nil on success
+//
+func VpcCidrs(timeout time.Duration) ([]netip.Prefix, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), timeout)
+    // Cancel the requests on local exit
+    defer cancel()
+
+    token, err := session(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    mac, err := get(ctx, token, "mac")
+    if err != nil {
+        return nil, err
+    }
+
+    blocks, err := get(ctx, token, "network/interfaces/macs/" + mac + "/vpc-ipv4-cidr-blocks")
+    if err != nil {
+        return nil, err
+    }
+
+    var cidrs []netip.Prefix
+    // Iterate through the CIDR blocks, one per line
+    for _, block := range strings.Fields(blocks) {
+        cidr, err := netip.ParsePrefix(block)
+        if err != nil {
+            return nil, fmt.Errorf("invalid VPC CIDR block %q - %w", block, err)
+        }
+
+        cidrs = append(cidrs, cidr)
+    }
+
+    // If the VPC reported no CIDR blocks
+    if len(cidrs) == 0 {
+        return nil, fmt.Errorf("network interface %s has no VPC CIDR blocks", mac)
+    }
+
+    return cidrs, nil
+}
+
+
+// Formats the metadata message the client reports itself with after its GPU inventory.
+//
+// @Parameters
+// - metadata:  The metadata of the instance the client runs on
+//
+// @Returns
+// - The formatted metadata message
+//
+func FormatMetadata(metadata Metadata) []byte {
+    fields := []string{metadata.InstanceId, metadata.InstanceType, metadata.AvailabilityZone,
+                       metadata.AmiId, metadata.Lifecycle}
+    // Iterate through the fields dropping delimiters so the fields stay aligned
+    for index, field := range fields {
+        fields[index] = strings.ReplaceAll(field, string(globals.COLON_DELIMITER), "")
+    }
+
+    message := append([]byte{}, globals.INSTANCE_METADATA_PREFIX...)
+    message = append(message, strings.Join(fields, string(globals.COLON_DELIMITER))...)
+    return append(message, globals.TRANSFER_SUFFIX...)
+}
+
+
+// Parses the metadata the client reported itself with.
+//
+// @Parameters
+// - message:  The metadata message
+//
+// @Returns
+// - The parsed metadata
+// - Error if it occurs, otherwise nil on success
+//
+func ParseMetadata(message []byte) (Metadata, error) {
+    // If the message does not start with prefix or end with closed bracket
+    if !bytes.HasPrefix(message, globals.INSTANCE_METADATA_PREFIX) ||
+       !bytes.HasSuffix(message, globals.TRANSFER_SUFFIX) {
+        return Metadata{}, fmt.Errorf("improper prefix or suffix in instance metadata message")
+    }
+
+    body := bytes.TrimSuffix(bytes.TrimPrefix(message, globals.INSTANCE_METADATA_PREFIX),
+                             globals.TRANSFER_SUFFIX)
+    fields := strings.Split(string(body), string(globals.COLON_DELIMITER))
+    // If any of the fields are missing
+    if len(fields) != 5 {
+        return Metadata{}, fmt.Errorf("improper fields in instance metadata message")
+    }
+
+    metadata := Metadata{InstanceId: fields[0], InstanceType: fields[1],
+                         AvailabilityZone: fields[2], AmiId: fields[3], Lifecycle: fields[4]}
+    // If the purchase option is not one EC2 reports
+    if metadata.Lifecycle != "" && metadata.Lifecycle != LifecycleOnDemand &&
+       metadata.Lifecycle != LifecycleSpot {
+        return Metadata{}, fmt.Errorf("improper lifecycle in instance metadata message")
+    }
+
+    return metadata, nil
+}
+
+
+// Record is the metadata a client reported along with how long it was connected
+type Record struct {
+    Metadata
+    Addr         string    `json:"addr"`
+    Connected    time.Time `json:"connected"`
+    Disconnected time.Time `json:"disconnected,omitempty"`
+}
+
+
+// Registry stores the metadata reported by each client for the report, display, and
+// cost accounting of the run
+type Registry struct {
+    clients map[string]*Record
+    mutx    sync.Mutex
+}
+
+// Creates a new empty registry.
+//
+// @Returns
+// - The initialized registry
+//
+func NewRegistry() *Registry {
+    return &Registry{clients: make(map[string]*Record)}
+}
+
+// Stores the metadata reported by the client as it connects.
+//
+// @Parameters
+// - addr:  The address of the client
+// - metadata:  The metadata the client reported
+// - now:  The time the client reported it
+//
+func (registry *Registry) Add(addr string, metadata Metadata, now time.Time) {
+    registry.mutx.Lock()
+    defer registry.mutx.Unlock()
+
+    registry.clients[addr] = &Record{Metadata: metadata, Addr: addr, Connected: now}
+}
+
+// Marks the client as disconnected so it is no longer billed to the run.
+//
+// @Parameters
+// - addr:  The address of the client
+// - now:  The time the client disconnected
+//
+func (registry *Registry) Remove(addr string, now time.Time) {
+    // If no registry is used, nothing was stored
+    if registry == nil {
+        return
+    }
+
+    registry.mutx.Lock()
+    defer registry.mutx.Unlock()
+
+    // If the client reported its metadata, record when it disconnected
+    if client, exists := registry.clients[addr]; exists {
+        client.Disconnected = now
+    }
+}
+
+// Gets the metadata the client reported.
+//
+// @Parameters
+// - addr:  The address of the client
+//
+// @Returns
+// - The metadata of the client
+// - true if the client reported its metadata, otherwise false
+//
+func (registry *Registry) Lookup(addr string) (Metadata, bool) {
+    // If no registry is used, nothing was stored
+    if registry == nil {
+        return Metadata{}, false
+    }
+
+    registry.mutx.Lock()
+    defer registry.mutx.Unlock()
+
+    client, exists := registry.clients[addr]
+    if !exists {
+        return Metadata{}, false
+    }
+
+    return client.Metadata, true
+}
+
+// Gets the records of the clients that reported their metadata, ordered by address.
+//
+// @Returns
+// - The records of the reported clients
+//
+func (registry *Registry) Records() []Record {
+    // If no registry is used, nothing was stored
+    if registry == nil {
+        return nil
+    }
+
+    registry.mutx.Lock()
+    defer registry.mutx.Unlock()
+
+    clients := make([]Record, 0, len(registry.clients))
+    // Iterate through the clients copying each
+    for _, client := range registry.clients {
+        clients = append(clients, *client)
+    }
+
+    slices.SortFunc(clients, func(a, b Record) int {
+        return strings.Compare(a.Addr, b.Addr)
+    })
+    return clients
+}
+
+// Checks whether any connected client runs on a spot instance, which can not be stopped
+// and started again like an on-demand instance.
+//
+// @Returns
+// - true if a connected client reported a spot lifecycle, otherwise false
+//
+func (registry *Registry) Spot() bool {
+    // If no registry is used, nothing was stored
+    if registry == nil {
+        return false
+    }
+
+    registry.mutx.Lock()
+    defer registry.mutx.Unlock()
+
+    // Iterate through the clients checking the lifecycle of those still connected
+    for _, client := range registry.clients {
+        if client.Disconnected.IsZero() && client.Lifecycle == LifecycleSpot {
+            return true
+        }
+    }
+
+    return false
+}
+
+
+// Estimates the spend of the client from the hourly price of its reported instance type
+// over the time it was connected. Spot instances are estimated at the on-demand price,
+// an upper bound of what they cost.
+//
+// @Parameters
+// - override:  The configured hourly price, 0 uses the built in estimate
+// - now:  The time a client still connected is billed until
+//
+// @Returns
+// - The estimated spend in USD, 0 when the instance type is unknown or has no price
+//
+func (record Record) Cost(override float64, now time.Time) float64 {
+    // If the client is not on EC2, it is not billed
+    if record.InstanceType == "" {
+        return 0
+    }
+
+    rate, err := cost.HourlyRate(record.InstanceType, override)
+    if err != nil {
+        return 0
+    }
+
+    end := now
+    // If the client already disconnected, it is billed until then
+    if !record.Disconnected.IsZero() {
+        end = record.Disconnected
+    }
+
+    return cost.Estimate(rate, 1, end.Sub(record.Connected))
+}
@@ -0,0 +1,153 @@
+package instance_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/instance"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestFetch(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    values := map[string]string{
+        "ami-id":                      "ami-0abc",
+        "instance-id":                 "i-0123456789",
+        "instance-life-cycle":         "spot",
+        "instance-type":               "g4dn.xlarge",
+        "local-ipv4":                  "172.31.4.20",
+        "mac":                         "0e:49:61:0f:c3:11",
+        "placement/availability-zone": "us-east-1a",
+    }
+    values["network/interfaces/macs/" + values["mac"] + "/vpc-ipv4-cidr-blocks"] =
+        "172.31.0.0/16\n10.8.0.0/24"
+
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter,
+                                                       request *http.Request) {
+        // If the token is requested
+        if request.Method == http.MethodPut && request.URL.Path == "/latest/api/token" {
+            writer.Write([]byte("token"))
+            return
+        }
+
+        value, exists := values[strings.TrimPrefix(request.URL.Path, "/latest/meta-data/")]
+        // If the token is missing or the path is unknown
+        if request.Header.Get("X-aws-ec2-metadata-token") != "token" || !exists {
+            writer.WriteHeader(http.StatusUnauthorized)
+            return
+        }
+
+        writer.Write([]byte(value + "\n"))
+    }))
+    // Close the server on local exit
+    defer server.Close()
+
+    endpoint := instance.Endpoint
+    instance.Endpoint = server.URL
+    // Reset the endpoint on local exit
+    defer func() {
+        instance.Endpoint = endpoint
+    } ()
+
+    metadata, err := instance.Fetch(time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(instance.Metadata{AmiId: "ami-0abc", AvailabilityZone: "us-east-1a",
+                                   InstanceId: "i-0123456789", InstanceType: "g4dn.xlarge",
+                                   Lifecycle: "spot"}, metadata)
+    assert.Equal("g4dn.xlarge spot us-east-1a i-0123456789", metadata.Summary())
+
+    privateIp, err := instance.PrivateIp(time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("172.31.4.20", privateIp)
+
+    vpcCidrs, err := instance.VpcCidrs(time.Second)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal([]netip.Prefix{netip.MustParsePrefix("172.31.0.0/16"),
+                                netip.MustParsePrefix("10.8.0.0/24")}, vpcCidrs)
+
+    // Ensure a service without metadata fails
+    delete(values, "instance-type")
+    _, err = instance.Fetch(time.Second)
+    assert.NotEqual(nil, err)
+}
+
+
+func TestMetadataMessage(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    metadata := instance.Metadata{AmiId: "ami-0abc", AvailabilityZone: "us-east-1a",
+                                  InstanceId: "i-0123456789", InstanceType: "p3.2xlarge",
+                                  Lifecycle: instance.LifecycleOnDemand}
+    message := instance.FormatMetadata(metadata)
+    parsed, err := instance.ParseMetadata(message)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(metadata, parsed)
+
+    // Ensure a client off EC2 reports empty metadata
+    parsed, err = instance.ParseMetadata(instance.FormatMetadata(instance.Metadata{}))
+    assert.Equal(nil, err)
+    assert.Equal("unknown instance", parsed.Summary())
+
+    falacies := [][]byte{
+        []byte("<INSTANCE_METADATA:i-0123:p3.2xlarge>"),
+        []byte("<INSTANCE_METADATA:i-0123:p3.2xlarge:us-east-1a:ami-0abc:reserved>"),
+        append([]byte("<GPU_INVENTORY:"), globals.TRANSFER_SUFFIX...),
+    }
+    // Iterate through the improper messages ensuring each fails to parse
+    for _, falacy := range falacies {
+        _, err = instance.ParseMetadata(falacy)
+        assert.NotEqual(nil, err)
+    }
+}
+
+
+func TestRegistry(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    var unset *instance.Registry
+    // Ensure nothing is stored when no registry is used
+    _, exists := unset.Lookup("10.0.0.1:5000")
+    assert.False(exists)
+    assert.Equal(0, len(unset.Records()))
+    assert.False(unset.Spot())
+
+    start := time.Now()
+    registry := instance.NewRegistry()
+    registry.Add("10.0.0.2:5000", instance.Metadata{InstanceType: "p3.2xlarge"}, start)
+    registry.Add("10.0.0.1:5000", instance.Metadata{InstanceType: "unknown.type"}, start)
+
+    metadata, exists := registry.Lookup("10.0.0.2:5000")
+    assert.True(exists)
+    assert.Equal("p3.2xlarge", metadata.InstanceType)
+
+    registry.Remove("10.0.0.2:5000", start.Add(time.Hour))
+    records := registry.Records()
+    // Ensure the records are ordered by address
+    assert.Equal(2, len(records))
+    assert.Equal("10.0.0.1:5000", records[0].Addr)
+
+    // Ensure an unpriced instance type costs nothing and a disconnected one stops billing
+    assert.Equal(0.0, records[0].Cost(0, start.Add(time.Hour)))
+    assert.InDelta(records[1].Cost(2.5, start.Add(5 * time.Hour)), 2.5, 0.001)
+
+    // Ensure only a connected spot client is reported
+    assert.False(registry.Spot())
+    registry.Add("10.0.0.3:5000", instance.Metadata{Lifecycle: instance.LifecycleSpot}, start)
+    assert.True(registry.Spot())
+    registry.Remove("10.0.0.3:5000", start.Add(time.Hour))
+    assert.False(registry.Spot())
+}
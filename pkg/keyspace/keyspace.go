@@ -0,0 +1,271 @@
+package keyspace
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+)
+
+// Range is a portion of the keyspace passed to hashcat via --skip and --limit
+type Range struct {
+    Skip  int64
+    Limit int64
+}
+
+
+// Scheduler splits a keyspace into ranges and tracks which client each range
+// is assigned to, so ranges from dead clients can be requeued
+type Scheduler struct {
+    assigned  map[Range]string
+    completed int
+    mutx      sync.Mutex
+    pending   []Range
+    total     int
+}
+
+// Splits the keyspace into the passed in number of near equal ranges, if
+// the keyspace is smaller than the number of chunks each range is a single unit.
+//
+// @Parameters
+// - keyspace:  The total base keyspace of the mask attack
+// - chunks:  The number of ranges to split the keyspace into
+//
+// @Returns
+// - The initialized scheduler with all ranges pending
+//
+func NewScheduler(keyspace int64, chunks int64) *Scheduler {
+    // If there are more chunks than keyspace units
+    if chunks > keyspace {
+        chunks = keyspace
+    }
+
+    // If an invalid number of chunks was passed in
+    if chunks < 1 {
+        chunks = 1
+    }
+
+    scheduler := &Scheduler{assigned: make(map[Range]string)}
+    chunkSize := keyspace / chunks
+    remainder := keyspace % chunks
+    var skip int64
+
+    // Iterate through the chunks spreading any remainder over the first ranges
+    for index := range chunks {
+        limit := chunkSize
+        if index < remainder {
+            limit += 1
+        }
+
+        scheduler.pending = append(scheduler.pending, Range{Skip: skip, Limit: limit})
+        skip += limit
+    }
+
+    scheduler.total = len(scheduler.pending)
+    return scheduler
+}
+
+// Assigns the next pending range to the passed in client.
+//
+// @Parameters
+// - client:  The address of the client requesting work
+//
+// @Returns
+// - The assigned range
+// - Whether a range was assigned
+// - Whether all ranges have been completed
+//
+func (sched *Scheduler) Next(client string) (Range, bool, bool) {
+    sched.mutx.Lock()
+    defer sched.mutx.Unlock()
+
+    // If there are no pending ranges
+    if len(sched.pending) == 0 {
+        return Range{}, false, sched.completed == sched.total
+    }
+
+    // Pop the first pending range and assign it to the client
+    rng := sched.pending[0]
+    sched.pending = sched.pending[1:]
+    sched.assigned[rng] = client
+
+    return rng, true, false
+}
+
+// Marks the range as completed if it is assigned to the passed in client.
+//
+// @Parameters
+// - rng:  The range that was completed
+// - client:  The address of the client reporting completion
+//
+// @Returns
+// - True if the range was assigned to the client and marked complete, otherwise false
+//
+func (sched *Scheduler) Complete(rng Range, client string) bool {
+    sched.mutx.Lock()
+    defer sched.mutx.Unlock()
+
+    // If the range is not assigned to the client
+    if owner, exists := sched.assigned[rng]; !exists || owner != client {
+        return false
+    }
+
+    delete(sched.assigned, rng)
+    sched.completed += 1
+    return true
+}
+
+// Returns any ranges assigned to the passed in client back to the pending
+// queue, used when a client disconnects before completing its work.
+//
+// @Parameters
+// - client:  The address of the client whose ranges are requeued
+//
+// @Returns
+// - The number of ranges that were requeued
+//
+func (sched *Scheduler) Requeue(client string) int {
+    // If keyspace scheduling is not in use
+    if sched == nil {
+        return 0
+    }
+
+    sched.mutx.Lock()
+    defer sched.mutx.Unlock()
+
+    var requeued int
+
+    // Iterate through the assigned ranges
+    for rng, owner := range sched.assigned {
+        // If the range is assigned to the client, move it back to pending
+        if owner == client {
+            delete(sched.assigned, rng)
+            sched.pending = append(sched.pending, rng)
+            requeued += 1
+        }
+    }
+
+    return requeued
+}
+
+// Gets the progress of the keyspace ranges.
+//
+// @Returns
+// - The number of completed ranges
+// - The total number of ranges
+//
+func (sched *Scheduler) Progress() (int, int) {
+    sched.mutx.Lock()
+    defer sched.mutx.Unlock()
+
+    return sched.completed, sched.total
+}
+
+// Gets the number of ranges waiting to be assigned to a client.
+//
+// @Returns
+// - The number of pending ranges, 0 when keyspace scheduling is not in use
+//
+func (sched *Scheduler) Unassigned() int {
+    // If keyspace scheduling is not in use
+    if sched == nil {
+        return 0
+    }
+
+    sched.mutx.Lock()
+    defer sched.mutx.Unlock()
+
+    return len(sched.pending)
+}
+
+// Gets the number of ranges assigned to the client that it has not completed.
+//
+// @Parameters
+// - client:  The address of the client
+//
+// @Returns
+// - The number of ranges in progress on the client, 0 when keyspace scheduling is not in use
+//
+func (sched *Scheduler) Assigned(client string) int {
+    // If keyspace scheduling is not in use
+    if sched == nil {
+        return 0
+    }
+
+    sched.mutx.Lock()
+    defer sched.mutx.Unlock()
+
+    var assigned int
+    // Iterate through the assigned ranges counting those of the client
+    for _, owner := range sched.assigned {
+        if owner == client {
+            assigned += 1
+        }
+    }
+
+    return assigned
+}
+
+
+// Formats the range into a protocol message with the passed in prefix.
+//
+// @Parameters
+// - prefix:  The message prefix to format the range with
+// - rng:  The range to be formatted
+//
+// @Returns
+// - The formatted range message
+//
+func FormatRange(prefix []byte, rng Range) []byte {
+    message := append([]byte{}, prefix...)
+    message = strconv.AppendInt(message, rng.Skip, 10)
+    message = append(message, globals.COLON_DELIMITER...)
+    message = strconv.AppendInt(message, rng.Limit, 10)
+    return append(message, globals.TRANSFER_SUFFIX...)
+}
+
+
+// Parses the range from a protocol message with the passed in prefix.
+//
+// @Parameters
+// - message:  The message containing the range
+// - prefix:  The message prefix the range is formatted with
+//
+// @Returns
+// - The parsed range
+// - Error if it occurs, otherwise nil on success
+//
+func ParseRange(message []byte, prefix []byte) (Range, error) {
+    // If the message does not start with prefix or end with closed bracket
+    if !bytes.HasPrefix(message, prefix) || !bytes.HasSuffix(message, globals.TRANSFER_SUFFIX) {
+        return Range{}, fmt.Errorf("improper prefix or suffix in range message")
+    }
+
+    // Strip the prefix and suffix then split on the delimiter
+    body := bytes.TrimSuffix(bytes.TrimPrefix(message, prefix), globals.TRANSFER_SUFFIX)
+    parts := bytes.Split(body, globals.COLON_DELIMITER)
+    if len(parts) != 2 {
+        return Range{}, fmt.Errorf("improper number of fields in range message")
+    }
+
+    // Parse the skip value
+    skip, err := strconv.ParseInt(string(parts[0]), 10, 64)
+    if err != nil {
+        return Range{}, fmt.Errorf("error parsing range skip - %w", err)
+    }
+
+    // Parse the limit value
+    limit, err := strconv.ParseInt(string(parts[1]), 10, 64)
+    if err != nil {
+        return Range{}, fmt.Errorf("error parsing range limit - %w", err)
+    }
+
+    // If the range values are invalid
+    if skip < 0 || limit < 1 {
+        return Range{}, fmt.Errorf("invalid range skip %d limit %d", skip, limit)
+    }
+
+    return Range{Skip: skip, Limit: limit}, nil
+}
@@ -0,0 +1,117 @@
+package keyspace_test
+
+import (
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/keyspace"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestNewScheduler(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    sched := keyspace.NewScheduler(10, 3)
+
+    var ranges []keyspace.Range
+    // Pop all the ranges from the scheduler
+    for {
+        rng, ok, _ := sched.Next("client")
+        if !ok {
+            break
+        }
+
+        ranges = append(ranges, rng)
+    }
+
+    // Ensure the keyspace was split with the remainder spread across the first ranges
+    assert.Equal([]keyspace.Range{{Skip: 0, Limit: 4}, {Skip: 4, Limit: 3},
+                                  {Skip: 7, Limit: 3}}, ranges)
+
+    // Ensure chunks larger than the keyspace are capped
+    sched = keyspace.NewScheduler(2, 5)
+    _, total := sched.Progress()
+    assert.Equal(2, total)
+}
+
+
+func TestSchedulerLifecycle(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    sched := keyspace.NewScheduler(100, 2)
+
+    // Assign a range to each client
+    first, ok, done := sched.Next("client1")
+    assert.True(ok)
+    assert.False(done)
+    second, ok, _ := sched.Next("client2")
+    assert.True(ok)
+
+    // Ensure no ranges are available but the work is not done
+    _, ok, done = sched.Next("client3")
+    assert.False(ok)
+    assert.False(done)
+
+    // Ensure a client can not complete a range it does not own
+    assert.False(sched.Complete(first, "client2"))
+    assert.True(sched.Complete(first, "client1"))
+
+    // Ensure only the range still in progress is counted
+    assert.Equal(0, sched.Assigned("client1"))
+    assert.Equal(1, sched.Assigned("client2"))
+
+    // Requeue the range of the dead client
+    assert.Equal(0, sched.Unassigned())
+    assert.Equal(1, sched.Requeue("client2"))
+    assert.Equal(1, sched.Unassigned())
+
+    // Ensure the requeued range is assigned to the next client
+    requeued, ok, _ := sched.Next("client3")
+    assert.True(ok)
+    assert.Equal(second, requeued)
+    assert.True(sched.Complete(requeued, "client3"))
+
+    // Ensure the scheduler reports completion
+    _, ok, done = sched.Next("client3")
+    assert.False(ok)
+    assert.True(done)
+
+    completed, total := sched.Progress()
+    assert.Equal(2, completed)
+    assert.Equal(2, total)
+
+    var nilSched *keyspace.Scheduler
+    // Ensure requeue on disabled scheduler is a no-op
+    assert.Equal(0, nilSched.Requeue("client1"))
+    assert.Equal(0, nilSched.Unassigned())
+    assert.Equal(0, nilSched.Assigned("client1"))
+}
+
+
+func TestFormatParseRange(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    rng := keyspace.Range{Skip: 1000, Limit: 250}
+    // Format the range into a message
+    message := keyspace.FormatRange(globals.KEYSPACE_RANGE_PREFIX, rng)
+    assert.Equal("<KEYSPACE_RANGE:1000:250>", string(message))
+
+    // Parse the range back from the message
+    parsed, err := keyspace.ParseRange(message, globals.KEYSPACE_RANGE_PREFIX)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the parsed range matches the original
+    assert.Equal(rng, parsed)
+
+    // Ensure improper messages are rejected
+    _, err = keyspace.ParseRange([]byte("<KEYSPACE_RANGE:1000>"), globals.KEYSPACE_RANGE_PREFIX)
+    assert.NotEqual(nil, err)
+    _, err = keyspace.ParseRange([]byte("<KEYSPACE_RANGE:-1:5>"), globals.KEYSPACE_RANGE_PREFIX)
+    assert.NotEqual(nil, err)
+    _, err = keyspace.ParseRange(message, globals.KEYSPACE_COMPLETE_PREFIX)
+    assert.NotEqual(nil, err)
+}
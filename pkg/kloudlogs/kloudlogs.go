@@ -7,10 +7,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
@@ -20,8 +22,26 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
+// Limits of the CloudWatch delivery queue, its retries and the PutLogEvents batches
+const (
+    CloudWatchQueueSize     = 10000             // Events buffered before new ones are dropped
+    CloudWatchMaxAttempts   = 5                 // Attempts to deliver a batch before dropping it
+    CloudWatchCallTime      = 10 * time.Second  // Time each PutLogEvents call is allowed
+    CloudWatchEventOverhead = 26                // Bytes CloudWatch adds to the size of each event
+    CloudWatchMaxBatchCount = 10000             // Max events in a single batch
+    CloudWatchMaxBatchSize  = 1048576           // Max bytes in a single batch
+    CloudWatchMaxEventSize  = 262144            // Max bytes of a single event
+)
+
+// Package level variables
+var CloudWatchBaseBackoff = 200 * time.Millisecond  // Backoff doubled after each failed attempt
+var CloudWatchMaxBackoff = 5 * time.Second          // Cap on the backoff between attempts
+var CloudWatchFlushInterval = 5 * time.Second       // Max time an event waits to be batched
+var StreamPrefix string  // Prefixed to the CloudWatch stream names, such as the run name
+
 // Logger interface defines logging methods
 type Logger interface {
+    Close() error
     GetMemoryLog () string
     Debug(msg string, field ...zap.Field)
     Info(msg string, fields ...zap.Field)
@@ -36,6 +56,7 @@ type Logger interface {
 type LoggerManager struct {
     LocalLogger Logger
     CloudLogger Logger
+    Fields      []zap.Field  // Added to every message, such as the run name
 }
 
 // NewLoggerManager initializes local and CloudWatch loggers based on the flag.
@@ -79,6 +100,40 @@ func NewLoggerManager(logDestination, localLogFile string, awsConfig aws.Config,
     }, nil
 }
 
+// Flushes and closes the loggers, waiting for queued CloudWatch events to be delivered.
+//
+// @Returns
+// - Error if the loggers failed to flush or CloudWatch delivery is unhealthy
+//
+func (logMan *LoggerManager) Close() error {
+    var errs []error
+
+    if logMan.CloudLogger != nil {
+        errs = append(errs, logMan.CloudLogger.Close())
+    }
+
+    if logMan.LocalLogger != nil {
+        errs = append(errs, logMan.LocalLogger.Close())
+    }
+
+    return errors.Join(errs...)
+}
+
+// Gets the delivery status of the CloudWatch logger.
+//
+// @Returns
+// - The delivery status of the CloudWatch logger
+// - true/false depending on whether CloudWatch logging is in use
+//
+func (logMan *LoggerManager) CloudHealth() (Health, bool) {
+    cloudWatchLog, ok := logMan.CloudLogger.(*CloudWatchLogger)
+    if !ok {
+        return Health{}, false
+    }
+
+    return cloudWatchLog.Health(), true
+}
+
 // Gets the log from the logging instance and
 // returns it be stored in memory variable.
 //
@@ -124,7 +179,7 @@ func (manager *LoggerManager) LogMessage(level string, message string, args ...a
     }
 
     // Log based on the level (info, error, warn) and include the fields
-    switch level {
+    switch strings.ToLower(level) {
     case "debug":
         manager.LogDebug(formattedMessage, zapFields...)
     case "info":
@@ -140,12 +195,16 @@ func (manager *LoggerManager) LogMessage(level string, message string, args ...a
     case "fatal":
         manager.LogFatal(formattedMessage, zapFields...)
     default:
-        log.Fatalf("[*] Error: Unknown logging level specified %v", level)
+        // An unknown level is logged as an error instead of exiting mid-run
+        manager.LogError(formattedMessage,
+                         append(zapFields, zap.String("unknown level", level))...)
     }
 }
 
 // Logs info message using both local and CloudWatch loggers
 func (logMan *LoggerManager) LogDebug(msg string, fields ...zap.Field) {
+    fields = append(fields, logMan.Fields...)
+
     if logMan.LocalLogger != nil {
         logMan.LocalLogger.Debug(msg, fields...)
     }
@@ -157,6 +216,8 @@ func (logMan *LoggerManager) LogDebug(msg string, fields ...zap.Field) {
 
 // Logs info message using both local and CloudWatch loggers
 func (logMan *LoggerManager) LogInfo(msg string, fields ...zap.Field) {
+    fields = append(fields, logMan.Fields...)
+
     if logMan.LocalLogger != nil {
         logMan.LocalLogger.Info(msg, fields...)
     }
@@ -168,6 +229,8 @@ func (logMan *LoggerManager) LogInfo(msg string, fields ...zap.Field) {
 
 // Logs warning message using both local and CloudWatch loggers
 func (logMan *LoggerManager) LogWarn(msg string, fields ...zap.Field) {
+    fields = append(fields, logMan.Fields...)
+
     if logMan.LocalLogger != nil {
         logMan.LocalLogger.Warn(msg, fields...)
     }
@@ -179,6 +242,8 @@ func (logMan *LoggerManager) LogWarn(msg string, fields ...zap.Field) {
 
 // Logs error message using both local and CloudWatch loggers
 func (logMan *LoggerManager) LogError(msg string, fields ...zap.Field) {
+    fields = append(fields, logMan.Fields...)
+
     if logMan.LocalLogger != nil {
         logMan.LocalLogger.Error(msg, fields...)
     }
@@ -190,6 +255,8 @@ func (logMan *LoggerManager) LogError(msg string, fields ...zap.Field) {
 
 // Logs developer panic message using both local and CloudWatch loggers
 func (logMan *LoggerManager) LogDPanic(msg string, fields ...zap.Field) {
+    fields = append(fields, logMan.Fields...)
+
     if logMan.LocalLogger != nil {
         logMan.LocalLogger.DPanic(msg, fields...)
     }
@@ -201,6 +268,8 @@ func (logMan *LoggerManager) LogDPanic(msg string, fields ...zap.Field) {
 
 // Logs panic message using both local and CloudWatch loggers
 func (logMan *LoggerManager) LogPanic(msg string, fields ...zap.Field) {
+    fields = append(fields, logMan.Fields...)
+
     if logMan.LocalLogger != nil {
         logMan.LocalLogger.Panic(msg, fields...)
     }
@@ -212,6 +281,8 @@ func (logMan *LoggerManager) LogPanic(msg string, fields ...zap.Field) {
 
 // Logs fatal message using both local and CloudWatch loggers
 func (logMan *LoggerManager) LogFatal(msg string, fields ...zap.Field) {
+    fields = append(fields, logMan.Fields...)
+
     if logMan.CloudLogger != nil {
         logMan.CloudLogger.Fatal(msg, fields...)
 
@@ -288,6 +359,20 @@ func NewZapLogger(logFile string, logToMemory bool) (Logger, error) {
     }
 }
 
+// Flushes any buffered zap log entries.
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (zapLog *ZapLogger) Close() error {
+    // If logging to memory there is nothing to flush
+    if zapLog.memoryBuffer != nil {
+        return nil
+    }
+
+    return zapLog.logger.Sync()
+}
+
 // Gets the zap log from the zap logging instance and
 // returns it be stored in memory variable.
 //
@@ -337,13 +422,36 @@ func (zapLog *ZapLogger) Fatal(msg string, fields ...zap.Field) {
 }
 
 
-// CloudWatchLogger implements Logger interface for CloudWatch
+// PutLogEventsApi is the CloudWatch Logs call used to deliver events, satisfied by the
+// CloudWatch Logs client
+type PutLogEventsApi interface {
+    PutLogEvents(ctx context.Context, params *cwl.PutLogEventsInput,
+                 optFns ...func(*cwl.Options)) (*cwl.PutLogEventsOutput, error)
+}
+
+
+// Health is the delivery status of the CloudWatch logger, unhealthy from when a batch
+// is given up on until the next one is delivered
+type Health struct {
+    Delivered int64
+    Dropped   int64
+    Healthy   bool
+    LastError error
+}
+
+
+// CloudWatchLogger implements Logger interface for CloudWatch, events are queued and
+// delivered in the background so a failing API never blocks or exits the program
 type CloudWatchLogger struct {
-    client       *cwl.Client
+    client       PutLogEventsApi
+    closeOnce    sync.Once
     cwMutex      sync.Mutex
+    done         chan struct{}
+    health       Health
     logGroup     string
     logStream    string
     nextSequence *string
+    queue        chan cwlTypes.InputLogEvent
 }
 
 // Creates and returns CloudWatch logger instance.
@@ -384,6 +492,9 @@ func NewCloudWatchLogger(awsConfig aws.Config, group string) (
         stream = string(streamData)
     }
 
+    // Prefix the stream so the streams of runs sharing a log group are told apart
+    stream = StreamPrefix + stream
+
     // Create the CloudWatch log group
     _, err = client.CreateLogGroup(ctx, &cwl.CreateLogGroupInput{
         LogGroupName: aws.String(group),
@@ -422,15 +533,39 @@ func NewCloudWatchLogger(awsConfig aws.Config, group string) (
         token = res.LogStreams[0].UploadSequenceToken
     }
 
-    return &CloudWatchLogger{
+    return NewCloudWatchWriter(client, group, stream, token), nil
+}
+
+// Creates a CloudWatch logger delivering to an existing log stream and starts its
+// background delivery routine.
+//
+// @Parameters
+// - client:  The client used to deliver the log events
+// - group:  The CloudWatch logging group
+// - stream:  The CloudWatch logging stream
+// - token:  The upload sequence token of the stream, nil if fresh
+//
+// @Returns
+// - The initialized CloudWatch logger
+//
+func NewCloudWatchWriter(client PutLogEventsApi, group string, stream string,
+                         token *string) *CloudWatchLogger {
+    cloudWatchLog := &CloudWatchLogger{
         client:       client,
+        done:         make(chan struct{}),
+        health:       Health{Healthy: true},
         logGroup:     group,
         logStream:    stream,
         nextSequence: token,
-    }, nil
+        queue:        make(chan cwlTypes.InputLogEvent, CloudWatchQueueSize),
+    }
+
+    go cloudWatchLog.deliver(cloudWatchLog.queue)
+    return cloudWatchLog
 }
 
-// Method that packages message & fields, sends to CW, and updates token.
+// Method that packages message & fields into an event and queues it for delivery, the
+// event is dropped if the queue is full or the logger is closed.
 //
 // @Parameters
 // - level:  The level that the log event will be set to
@@ -438,50 +573,239 @@ func NewCloudWatchLogger(awsConfig aws.Config, group string) (
 // - fields:  Any additional zap field to be added to log entry
 //
 func (cloudWatchLog *CloudWatchLogger) log(level string, msg string, fields ...zap.Field) {
-    // Build log entry
-    entry := map[string]any{
-        "timestamp": time.Now().UTC().Format(time.RFC3339Nano),
-        "level":     level,
-        "message":   msg,
-    }
-
-    // Iterate through the the slice of fields
+    // Encode the fields with zap so every field type keeps its value
+    encoder := zapcore.NewMapObjectEncoder()
     for _, field := range fields {
-        // Add fields in log entry map
-        entry[field.Key] = field.Interface
+        field.AddTo(encoder)
     }
 
+    // Build log entry
+    entry := encoder.Fields
+    entry["timestamp"] = time.Now().UTC().Format(time.RFC3339Nano)
+    entry["level"] = level
+    entry["message"] = msg
+
     // Format the data into JSON for transporting to CloudWatch
     payload, err := json.Marshal(entry)
     if err != nil {
-        log.Fatalf("marshal log entry: %v\n", err)
+        // Fall back to the message alone so the event is not lost
+        payload, _ = json.Marshal(map[string]any{
+            "timestamp":     entry["timestamp"],
+            "level":         level,
+            "message":       msg,
+            "marshal error": err.Error(),
+        })
+    }
+
+    // If the event is over the CloudWatch limit, truncate it rather than reject the batch
+    if len(payload) + CloudWatchEventOverhead > CloudWatchMaxEventSize {
+        cut := CloudWatchMaxEventSize - CloudWatchEventOverhead
+        // Back off to the start of the character so a multi-byte one is not split
+        for cut > 0 && !utf8.RuneStart(payload[cut]) {
+            cut--
+        }
+
+        payload = payload[:cut]
     }
 
     // Set up input log event message
     event := cwlTypes.InputLogEvent{
         Message:   aws.String(string(payload)),
-        Timestamp: aws.Int64(time.Now().UnixNano() / int64(time.Millisecond)),
+        Timestamp: aws.Int64(time.Now().UnixMilli()),
     }
 
-    // Set mutex for logging operation
     cloudWatchLog.cwMutex.Lock()
     defer cloudWatchLog.cwMutex.Unlock()
 
-    inputEvent := &cwl.PutLogEventsInput{
-        LogGroupName:  aws.String(cloudWatchLog.logGroup),
-        LogStreamName: aws.String(cloudWatchLog.logStream),
-        LogEvents:     []cwlTypes.InputLogEvent{event},
-        SequenceToken: cloudWatchLog.nextSequence,
+    // If the logger was closed, the queue no longer accepts events
+    if cloudWatchLog.queue == nil {
+        cloudWatchLog.health.Dropped += 1
+        return
+    }
+
+    select {
+    case cloudWatchLog.queue <- event:
+    // If the queue is full, drop the event rather than blocking the caller
+    default:
+        cloudWatchLog.health.Dropped += 1
+    }
+}
+
+// Delivers the queued events in batches until the queue is closed, a batch is sent when
+// it reaches the CloudWatch count or size limit or when the flush interval passes.
+//
+// @Parameters
+// - queue:  The queue of events to deliver, passed in since Close clears the field
+//
+func (cloudWatchLog *CloudWatchLogger) deliver(queue chan cwlTypes.InputLogEvent) {
+    // Signal delivery is complete on exit
+    defer close(cloudWatchLog.done)
+
+    ticker := time.NewTicker(CloudWatchFlushInterval)
+    // Stop the ticker on local exit
+    defer ticker.Stop()
+
+    var batch []cwlTypes.InputLogEvent
+    var batchSize int
+
+    for {
+        select {
+        case event, ok := <-queue:
+            // If the queue was closed, flush what remains and exit
+            if !ok {
+                cloudWatchLog.flush(batch)
+                return
+            }
+
+            eventSize := len(*event.Message) + CloudWatchEventOverhead
+            // If the event would put the batch over the limits, send the batch first
+            if len(batch) + 1 > CloudWatchMaxBatchCount ||
+               batchSize + eventSize > CloudWatchMaxBatchSize {
+                cloudWatchLog.flush(batch)
+                batch = nil
+                batchSize = 0
+            }
+
+            batch = append(batch, event)
+            batchSize += eventSize
+        case <-ticker.C:
+            // If events were queued since the last flush
+            if len(batch) > 0 {
+                cloudWatchLog.flush(batch)
+                batch = nil
+                batchSize = 0
+            }
+        }
+    }
+}
+
+// Sends the batch of events and updates the delivery status with the result.
+//
+// @Parameters
+// - batch:  The batch of log events to be delivered
+//
+func (cloudWatchLog *CloudWatchLogger) flush(batch []cwlTypes.InputLogEvent) {
+    // If there is nothing to send
+    if len(batch) == 0 {
+        return
     }
 
-    // Upload log entry via the log stream
-    resp, err := cloudWatchLog.client.PutLogEvents(context.Background(), inputEvent)
+    // CloudWatch requires the events of a batch in chronological order
+    sort.SliceStable(batch, func(i, j int) bool {
+        return *batch[i].Timestamp < *batch[j].Timestamp
+    })
+
+    err := cloudWatchLog.put(batch)
+
+    cloudWatchLog.cwMutex.Lock()
+    defer cloudWatchLog.cwMutex.Unlock()
+
+    // If the batch was given up on, mark delivery unhealthy
     if err != nil {
-        log.Fatalf("PutLogEvents: %v\n", err)
+        cloudWatchLog.health.Dropped += int64(len(batch))
+        cloudWatchLog.health.Healthy = false
+        cloudWatchLog.health.LastError = err
+    } else {
+        cloudWatchLog.health.Delivered += int64(len(batch))
+        cloudWatchLog.health.Healthy = true
+    }
+}
+
+// Puts the batch in the log stream, retrying with exponential backoff. A rejected
+// sequence token is replaced with the one CloudWatch expects and retried immediately.
+//
+// @Parameters
+// - batch:  The batch of log events to be delivered
+//
+// @Returns
+// - Error if every attempt failed, otherwise nil on success
+//
+func (cloudWatchLog *CloudWatchLogger) put(batch []cwlTypes.InputLogEvent) error {
+    var err error
+    backoff := CloudWatchBaseBackoff
+
+    for attempt := 1; attempt <= CloudWatchMaxAttempts; attempt++ {
+        // Ensure the API call does not hang for longer than the call time
+        ctx, cancel := context.WithTimeout(context.Background(), CloudWatchCallTime)
+        var resp *cwl.PutLogEventsOutput
+
+        // Upload the batch via the log stream
+        resp, err = cloudWatchLog.client.PutLogEvents(ctx, &cwl.PutLogEventsInput{
+            LogGroupName:  aws.String(cloudWatchLog.logGroup),
+            LogStreamName: aws.String(cloudWatchLog.logStream),
+            LogEvents:     batch,
+            SequenceToken: cloudWatchLog.nextSequence,
+        })
+        cancel()
+
+        if err == nil {
+            // Set the next sequence token fron the response
+            cloudWatchLog.nextSequence = resp.NextSequenceToken
+            return nil
+        }
+
+        var alreadyAccepted *cwlTypes.DataAlreadyAcceptedException
+        // If a previous attempt was accepted but its response was lost
+        if errors.As(err, &alreadyAccepted) {
+            cloudWatchLog.nextSequence = alreadyAccepted.ExpectedSequenceToken
+            return nil
+        }
+
+        var invalidToken *cwlTypes.InvalidSequenceTokenException
+        // If the stream was written by another writer, retry with the expected token
+        if errors.As(err, &invalidToken) {
+            cloudWatchLog.nextSequence = invalidToken.ExpectedSequenceToken
+            continue
+        }
+
+        // If there are attempts remaining, back off before the next
+        if attempt < CloudWatchMaxAttempts {
+            time.Sleep(backoff)
+            backoff = min(backoff * 2, CloudWatchMaxBackoff)
+        }
+    }
+
+    return fmt.Errorf("PutLogEvents failed after %d attempts - %w",
+                      CloudWatchMaxAttempts, err)
+}
+
+// Gets the delivery status of the logger.
+//
+// @Returns
+// - The delivery status of the logger
+//
+func (cloudWatchLog *CloudWatchLogger) Health() Health {
+    cloudWatchLog.cwMutex.Lock()
+    defer cloudWatchLog.cwMutex.Unlock()
+
+    return cloudWatchLog.health
+}
+
+// Closes the queue and waits for the queued events to be delivered, events logged
+// after are dropped.
+//
+// @Returns
+// - Error if delivery is unhealthy when the queue is drained, otherwise nil on success
+//
+func (cloudWatchLog *CloudWatchLogger) Close() error {
+    cloudWatchLog.closeOnce.Do(func() {
+        cloudWatchLog.cwMutex.Lock()
+        close(cloudWatchLog.queue)
+        cloudWatchLog.queue = nil
+        cloudWatchLog.cwMutex.Unlock()
+    })
+
+    // Wait for the delivery routine to drain the queue
+    <-cloudWatchLog.done
+
+    health := cloudWatchLog.Health()
+    // If the last event could not be delivered
+    if !health.Healthy {
+        return fmt.Errorf("CloudWatch delivery unhealthy, %d events dropped - %w",
+                          health.Dropped, health.LastError)
     }
 
-    // Set the next sequence token fron the response
-    cloudWatchLog.nextSequence = resp.NextSequenceToken
+    return nil
 }
 
 // Current dummy handler to follow interface contract (zap only)
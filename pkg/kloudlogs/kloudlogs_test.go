@@ -2,12 +2,19 @@ package kloudlogs_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	cwl "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwlTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 	"github.com/ngimb64/Kloud-Kraken/pkg/kloudlogs"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
@@ -87,3 +94,183 @@ func TestLogMessage(t *testing.T) {
     // (usually 179 but on rare occasion 178)
     assert.Equal(expectedSize, logFileSize)
 }
+
+
+// Fake PutLogEvents API failing a set number of calls before succeeding
+type fakePutLogEvents struct {
+    batches     []int
+    calls       int
+    failures    int
+    invalidOnce bool
+    messages    []string
+    tokens      []string
+}
+
+func (fake *fakePutLogEvents) PutLogEvents(ctx context.Context, params *cwl.PutLogEventsInput,
+                                           optFns ...func(*cwl.Options)) (
+                                           *cwl.PutLogEventsOutput, error) {
+    fake.calls += 1
+    // If the call is set to fail
+    if fake.calls <= fake.failures {
+        return nil, errors.New("transient network error")
+    }
+
+    // If the sequence token is set to be rejected once
+    if fake.invalidOnce {
+        fake.invalidOnce = false
+        return nil, &cwlTypes.InvalidSequenceTokenException{
+            ExpectedSequenceToken: aws.String("expected"),
+        }
+    }
+
+    // If a sequence token was sent
+    if params.SequenceToken != nil {
+        fake.tokens = append(fake.tokens, *params.SequenceToken)
+    }
+
+    fake.batches = append(fake.batches, len(params.LogEvents))
+    for _, event := range params.LogEvents {
+        fake.messages = append(fake.messages, *event.Message)
+    }
+
+    return &cwl.PutLogEventsOutput{NextSequenceToken: aws.String("next")}, nil
+}
+
+
+func TestLoggerFields(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    awsCreds := credentials.NewStaticCredentialsProvider("blah", "blah", "")
+    // Load default config and override with custom credentials and region
+    awsConfig, err := config.LoadDefaultConfig(
+        context.TODO(),
+        config.WithRegion("test-region"),
+        config.WithCredentialsProvider(awsCreds),
+    )
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Initialize the LoggerManager based on the flags
+    logMan, err := kloudlogs.NewLoggerManager("local", "", awsConfig, "", true)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    logMan.Fields = []zap.Field{zap.String("run_name", "acme-q3")}
+    logMan.LogMessage("warn", "TestLoggerFields test message", zap.Int("attempt", 2))
+    // Get the log message from memory and parse it as a map
+    logMap, err := kloudlogs.LogToMap(logMan.GetLog())
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the manager fields are added alongside the fields of the message
+    assert.Equal("acme-q3", logMap["run_name"])
+    assert.Equal(2.0, logMap["attempt"])
+}
+
+
+func TestCloudWatchBatching(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Ensure events queued within the flush interval are sent as a single batch
+    fake := &fakePutLogEvents{invalidOnce: true}
+    writer := kloudlogs.NewCloudWatchWriter(fake, "group", "stream", aws.String("stale"))
+    for counter := 1; counter <= 3; counter++ {
+        writer.Info(fmt.Sprintf("message %d", counter))
+    }
+
+    err := writer.Close()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal([]int{3}, fake.batches)
+    assert.Equal(int64(3), writer.Health().Delivered)
+    // Ensure the rejected token was replaced with the expected one
+    assert.Equal([]string{"expected"}, fake.tokens)
+    assert.Contains(fake.messages[0], "message 1")
+    assert.Contains(fake.messages[2], "message 3")
+
+    // Ensure a batch over the size limit is split
+    fake = &fakePutLogEvents{}
+    writer = kloudlogs.NewCloudWatchWriter(fake, "group", "stream", nil)
+    message := strings.Repeat("a", kloudlogs.CloudWatchMaxBatchSize / 5)
+    for counter := 1; counter <= 6; counter++ {
+        writer.Info(message)
+    }
+
+    err = writer.Close()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal([]int{4, 2}, fake.batches)
+
+    // Ensure oversized events are truncated without splitting multi-byte characters
+    fake = &fakePutLogEvents{}
+    writer = kloudlogs.NewCloudWatchWriter(fake, "group", "stream", nil)
+    // Iterate through the offsets so the limit falls on each byte of a character
+    for padding := range 3 {
+        writer.Info(strings.Repeat("a", padding) +
+                    strings.Repeat("€", kloudlogs.CloudWatchMaxEventSize / 3))
+    }
+
+    err = writer.Close()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(3, len(fake.messages))
+    for _, message := range fake.messages {
+        assert.True(utf8.ValidString(message))
+        assert.LessOrEqual(len(message), kloudlogs.CloudWatchMaxEventSize -
+                                         kloudlogs.CloudWatchEventOverhead)
+    }
+
+    // Ensure events are flushed on the interval without closing
+    kloudlogs.CloudWatchFlushInterval = 10 * time.Millisecond
+    defer func() { kloudlogs.CloudWatchFlushInterval = 5 * time.Second } ()
+
+    fake = &fakePutLogEvents{}
+    writer = kloudlogs.NewCloudWatchWriter(fake, "group", "stream", nil)
+    writer.Info("interval message")
+    assert.Eventually(func() bool { return writer.Health().Delivered == 1 },
+                      time.Second, 5 * time.Millisecond)
+
+    err = writer.Close()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+}
+
+
+func TestCloudWatchWriter(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Shorten the backoff and restore it when complete
+    kloudlogs.CloudWatchBaseBackoff = time.Millisecond
+    defer func() { kloudlogs.CloudWatchBaseBackoff = 200 * time.Millisecond } ()
+
+    // Ensure transient failures are retried and the event is delivered
+    fake := &fakePutLogEvents{failures: 2}
+    writer := kloudlogs.NewCloudWatchWriter(fake, "group", "stream", nil)
+    writer.Info("retried message", zap.String("key", "value"))
+
+    err := writer.Close()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(3, fake.calls)
+    assert.Equal(1, len(fake.messages))
+    assert.Contains(fake.messages[0], `"key":"value"`)
+    assert.Equal(int64(1), writer.Health().Delivered)
+
+    // Ensure events logged after close are dropped instead of panicking
+    writer.Info("late message")
+    assert.Equal(int64(1), writer.Health().Dropped)
+
+    // Ensure persistent failures surface as unhealthy instead of exiting
+    fake = &fakePutLogEvents{failures: kloudlogs.CloudWatchMaxAttempts}
+    writer = kloudlogs.NewCloudWatchWriter(fake, "group", "stream", nil)
+    writer.Error("lost message")
+
+    err = writer.Close()
+    assert.ErrorContains(err, "transient network error")
+    health := writer.Health()
+    assert.False(health.Healthy)
+    assert.Equal(int64(1), health.Dropped)
+}
@@ -0,0 +1,419 @@
+package logstream
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/netio"
+)
+
+// Max bytes of log data forwarded in a single batch
+const MaxBatchSize = 32 * 1024
+// Name of the live log file written in each client dir
+const LogFileName = "client.log"
+
+
+// Forwarder tails the client log file, sending the data appended since the last batch
+// on an interval, reading from the file offset so a slow connection never buffers more
+// than a single batch in memory
+type Forwarder struct {
+    interval time.Duration
+    logPath  string
+    offset   int64
+    stopCh   chan struct{}
+    stopOnce sync.Once
+    wg       sync.WaitGroup
+}
+
+// Creates a forwarder of the log file at the passed in path.
+//
+// @Parameters
+// - logPath:  The path of the log file to be forwarded
+// - interval:  The duration of time between batches
+//
+// @Returns
+// - The initialized forwarder
+//
+func NewForwarder(logPath string, interval time.Duration) *Forwarder {
+    return &Forwarder{
+        interval: interval,
+        logPath:  logPath,
+        stopCh:   make(chan struct{}),
+    }
+}
+
+// Starts forwarding batches in a Goroutine until Stop() is called.
+//
+// @Parameters
+// - send:  Sends a formatted batch message, called once per batch
+//
+func (forwarder *Forwarder) Start(send func(batch []byte) error) {
+    forwarder.wg.Add(1)
+
+    go func() {
+        defer forwarder.wg.Done()
+
+        ticker := time.NewTicker(forwarder.interval)
+        defer ticker.Stop()
+
+        for {
+            select {
+            case <-ticker.C:
+                forwarder.Flush(send)
+            case <-forwarder.stopCh:
+                // Send anything logged since the last batch before exiting
+                forwarder.Flush(send)
+                return
+            }
+        }
+    } ()
+}
+
+// Sends the data appended to the log file since the last batch, split into batches
+// of at most MaxBatchSize bytes ending on a line boundary when possible.
+//
+// @Parameters
+// - send:  Sends a formatted batch message, called once per batch
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (forwarder *Forwarder) Flush(send func(batch []byte) error) error {
+    file, err := os.Open(forwarder.logPath)
+    if err != nil {
+        // If nothing has been logged to a file yet
+        if os.IsNotExist(err) {
+            return nil
+        }
+
+        return err
+    }
+
+    // Close the file on local exit
+    defer file.Close()
+
+    chunk := make([]byte, MaxBatchSize)
+
+    for {
+        bytesRead, err := file.ReadAt(chunk, forwarder.offset)
+        if err != nil && err != io.EOF {
+            return err
+        }
+
+        // If there is no new data
+        if bytesRead == 0 {
+            return nil
+        }
+
+        data := chunk[:bytesRead]
+        // If the chunk is full, end the batch on the last complete line
+        if bytesRead == MaxBatchSize {
+            if lastNewline := bytes.LastIndexByte(data, '\n'); lastNewline != -1 {
+                data = data[:lastNewline+1]
+            }
+        // Otherwise hold back a partially written last line until it completes
+        } else if lastNewline := bytes.LastIndexByte(data, '\n'); lastNewline != -1 {
+            data = data[:lastNewline+1]
+        } else {
+            return nil
+        }
+
+        err = send(FormatBatch(data))
+        if err != nil {
+            return err
+        }
+
+        forwarder.offset += int64(len(data))
+    }
+}
+
+// Stops the forwarder after sending a final batch, waiting for it to complete.
+//
+func (forwarder *Forwarder) Stop() {
+    // If the forwarder was never created
+    if forwarder == nil {
+        return
+    }
+
+    forwarder.stopOnce.Do(func() {
+        close(forwarder.stopCh)
+    })
+
+    forwarder.wg.Wait()
+}
+
+
+// Formats a batch message with the log data following the header.
+//
+// @Parameters
+// - data:  The log data of the batch
+//
+// @Returns
+// - The formatted batch message
+//
+func FormatBatch(data []byte) []byte {
+    batch := slices.Clone(globals.LOG_BATCH_PREFIX)
+    batch = append(batch, strconv.Itoa(len(data))...)
+    batch = append(batch, globals.TRANSFER_SUFFIX...)
+
+    return append(batch, data...)
+}
+
+
+// Reads the log data of a batch, starting with any data read along with the header.
+//
+// @Parameters
+// - connection:  The connection the batch is read from
+// - message:  The message read from the connection starting with the batch header
+//
+// @Returns
+// - The log data of the batch
+// - Error if it occurs, otherwise nil on success
+//
+func ReadBatch(connection io.Reader, message []byte) ([]byte, error) {
+    // If the message does not start with the batch header
+    if !bytes.HasPrefix(message, globals.LOG_BATCH_PREFIX) {
+        return nil, fmt.Errorf("message is not a log batch - %w",
+                               netio.ErrMalformedMessage)
+    }
+
+    message = message[len(globals.LOG_BATCH_PREFIX):]
+    suffixPos := bytes.Index(message, globals.TRANSFER_SUFFIX)
+    // If the header is not terminated
+    if suffixPos == -1 {
+        return nil, fmt.Errorf("invalid log batch header, suffix missing - %w",
+                               netio.ErrMalformedMessage)
+    }
+
+    size, err := strconv.Atoi(string(message[:suffixPos]))
+    // If the size is not a number or larger than a batch can be
+    if err != nil || size < 0 || size > MaxBatchSize {
+        return nil, fmt.Errorf("invalid log batch size - %q - %w", message[:suffixPos],
+                               netio.ErrMalformedMessage)
+    }
+
+    data := make([]byte, size)
+    // Copy the data read along with the header
+    copied := copy(data, message[suffixPos+1:])
+
+    // Read the rest of the batch data
+    _, err = io.ReadFull(connection, data[copied:])
+    if err != nil {
+        return nil, fmt.Errorf("error reading log batch - %w", err)
+    }
+
+    return data, nil
+}
+
+
+// Store writes the streamed logs of each client to its own file and keeps the most
+// recent lines of each in memory for the TUI tail view
+type Store struct {
+    clients   []string
+    dir       string
+    files     map[string]*os.File
+    mutx      sync.Mutex
+    selected  int
+    tailLines int
+    tails     map[string][]string
+}
+
+// Creates a store writing client logs under the passed in dir.
+//
+// @Parameters
+// - dir:  The dir where a sub dir is created for each client
+// - tailLines:  The number of recent lines kept per client
+//
+// @Returns
+// - The initialized store
+//
+func NewStore(dir string, tailLines int) *Store {
+    return &Store{
+        dir:       dir,
+        files:     map[string]*os.File{},
+        tailLines: tailLines,
+        tails:     map[string][]string{},
+    }
+}
+
+// Appends the batch data to the log file of the client and its recent lines.
+//
+// @Parameters
+// - client:  The client the log data came from
+// - data:  The log data to append
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (store *Store) Append(client string, data []byte) error {
+    // If the store was never created
+    if store == nil {
+        return nil
+    }
+
+    store.mutx.Lock()
+    defer store.mutx.Unlock()
+
+    file, exists := store.files[client]
+    // If this is the first batch from the client, open its log file
+    if !exists {
+        clientDir := filepath.Join(store.dir, ClientDir(client))
+
+        err := os.MkdirAll(clientDir, 0755)
+        if err != nil {
+            return err
+        }
+
+        file, err = os.OpenFile(filepath.Join(clientDir, LogFileName),
+                                os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+        if err != nil {
+            return err
+        }
+
+        store.files[client] = file
+        store.clients = append(store.clients, client)
+    }
+
+    _, err := file.Write(data)
+    if err != nil {
+        return err
+    }
+
+    lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+    tail := append(store.tails[client], lines...)
+    // Keep only the most recent lines
+    if len(tail) > store.tailLines {
+        tail = slices.Clone(tail[len(tail) - store.tailLines:])
+    }
+    store.tails[client] = tail
+
+    return nil
+}
+
+// Selects the next client with streamed logs for the tail view.
+//
+func (store *Store) Cycle() {
+    // If the store was never created
+    if store == nil {
+        return
+    }
+
+    store.mutx.Lock()
+    defer store.mutx.Unlock()
+
+    // If there are clients to cycle through
+    if len(store.clients) > 0 {
+        store.selected = (store.selected + 1) % len(store.clients)
+    }
+}
+
+// Selects the client for the tail view by its address.
+//
+// @Parameters
+// - client:  The address of the client, the host alone also matches
+//
+// @Returns
+// - true/false depending on whether a client with streamed logs matched
+//
+func (store *Store) Select(client string) bool {
+    // If the store was never created
+    if store == nil {
+        return false
+    }
+
+    store.mutx.Lock()
+    defer store.mutx.Unlock()
+
+    // Iterate through the clients to find a matching one
+    for index, name := range store.clients {
+        if name == client || ClientDir(name) == client {
+            store.selected = index
+            return true
+        }
+    }
+
+    return false
+}
+
+// Gets the selected client and its most recent log lines.
+//
+// @Returns
+// - The selected client, empty if no logs have been streamed yet
+// - The most recent log lines of the selected client
+//
+func (store *Store) Tail() (string, []string) {
+    // If the store was never created
+    if store == nil {
+        return "", nil
+    }
+
+    store.mutx.Lock()
+    defer store.mutx.Unlock()
+
+    // If no client has streamed logs yet
+    if len(store.clients) == 0 {
+        return "", nil
+    }
+
+    client := store.clients[store.selected]
+    return client, slices.Clone(store.tails[client])
+}
+
+// Closes the log file of every client.
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (store *Store) Close() error {
+    // If the store was never created
+    if store == nil {
+        return nil
+    }
+
+    store.mutx.Lock()
+    defer store.mutx.Unlock()
+
+    var err error
+    // Iterate through the open files closing each
+    for client, file := range store.files {
+        closeErr := file.Close()
+        if closeErr != nil && err == nil {
+            err = closeErr
+        }
+
+        delete(store.files, client)
+    }
+
+    return err
+}
+
+
+// Formats the dir name of a client from its address, the host without the port, so
+// IPv6 clients keep their full address.
+//
+// @Parameters
+// - client:  The address of the client
+//
+// @Returns
+// - The dir name of the client
+//
+func ClientDir(client string) string {
+    host, _, err := net.SplitHostPort(client)
+    // If the address has no port, such as the client ID of an SQS connection
+    if err != nil {
+        host = client
+    }
+
+    // Ensure the name can not traverse out of the store dir
+    return strings.ReplaceAll(filepath.Base(host), "..", "_")
+}
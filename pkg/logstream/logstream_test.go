@@ -0,0 +1,166 @@
+package logstream_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/fuzzseed"
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/logstream"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestForwarderFlush(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    logPath := filepath.Join(t.TempDir(), "client.log")
+    forwarder := logstream.NewForwarder(logPath, time.Hour)
+    var batches [][]byte
+
+    send := func(batch []byte) error {
+        batches = append(batches, batch)
+        return nil
+    }
+
+    // Flushing before anything is logged sends nothing
+    assert.Equal(nil, forwarder.Flush(send))
+    assert.Empty(batches)
+
+    // Write a complete line followed by a partial one
+    err := os.WriteFile(logPath, []byte("line one\nline tw"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    assert.Equal(nil, forwarder.Flush(send))
+    assert.Equal([][]byte{logstream.FormatBatch([]byte("line one\n"))}, batches)
+
+    // Complete the partial line, only the new data is sent
+    file, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    _, err = file.WriteString("o\n")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    file.Close()
+
+    assert.Equal(nil, forwarder.Flush(send))
+    assert.Len(batches, 2)
+    assert.Equal(logstream.FormatBatch([]byte("line two\n")), batches[1])
+
+    // Data larger than a batch is split on line boundaries
+    line := strings.Repeat("a", 1023) + "\n"
+    err = os.WriteFile(logPath, []byte("line one\nline two\n" +
+                                       strings.Repeat(line, 40)), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    batches = nil
+    assert.Equal(nil, forwarder.Flush(send))
+    assert.Len(batches, 2)
+
+    // Each batch is read back in full with the data read along with the header
+    var total []byte
+    for _, batch := range batches {
+        reader := bytes.NewReader(batch[100:])
+        data, err := logstream.ReadBatch(reader, batch[:100])
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        total = append(total, data...)
+    }
+    assert.Equal(strings.Repeat(line, 40), string(total))
+}
+
+
+func TestReadBatchInvalid(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    falacies := []string{"<TRANSFER_LOG:5>", "<LOG_BATCH:5", "<LOG_BATCH:abc>",
+                         "<LOG_BATCH:-1>", "<LOG_BATCH:99999999>", "<LOG_BATCH:5>abc"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        _, err := logstream.ReadBatch(bytes.NewReader(nil), []byte(falacy))
+        assert.NotNil(err)
+    }
+}
+
+
+func FuzzReadBatch(f *testing.F) {
+    fuzzseed.Framed(f, globals.LOG_BATCH_PREFIX, "line one\nline two\n")
+
+    f.Fuzz(func(t *testing.T, message []byte, rest []byte) {
+        data, err := logstream.ReadBatch(bytes.NewReader(rest), message)
+        // If the batch was rejected
+        if err != nil {
+            return
+        }
+
+        // Ensure accepted batches are within the max size
+        if len(data) > logstream.MaxBatchSize {
+            t.Fatalf("accepted batch of %d bytes", len(data))
+        }
+    })
+}
+
+
+func TestStore(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    dir := t.TempDir()
+    store := logstream.NewStore(dir, 2)
+
+    // Nothing is selected before logs are streamed
+    client, lines := store.Tail()
+    assert.Equal("", client)
+    assert.Empty(lines)
+
+    assert.Equal(nil, store.Append("10.0.0.1:5000", []byte("one\ntwo\nthree\n")))
+    assert.Equal(nil, store.Append("10.0.0.2:5001", []byte("other\n")))
+
+    // Only the most recent lines of the first client are kept
+    client, lines = store.Tail()
+    assert.Equal("10.0.0.1:5000", client)
+    assert.Equal([]string{"two", "three"}, lines)
+
+    // Cycling selects the next client, then wraps around
+    store.Cycle()
+    client, _ = store.Tail()
+    assert.Equal("10.0.0.2:5001", client)
+    store.Cycle()
+    client, _ = store.Tail()
+    assert.Equal("10.0.0.1:5000", client)
+
+    // Selecting by host alone matches the client
+    assert.True(store.Select("10.0.0.2"))
+    assert.False(store.Select("10.0.0.3"))
+    client, _ = store.Tail()
+    assert.Equal("10.0.0.2:5001", client)
+
+    assert.Equal(nil, store.Close())
+
+    // The full log is written under the client dir
+    logData, err := os.ReadFile(filepath.Join(dir, "10.0.0.1", logstream.LogFileName))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("one\ntwo\nthree\n", string(logData))
+
+    truths := map[string]string{"10.0.0.1:5000": "10.0.0.1", "[fd00::2]:5000": "fd00::2",
+                                "client-1": "client-1", "../../etc:5000": "etc"}
+    // Ensure each client dir is its host without the port and stays in the store dir
+    for client, dirName := range truths {
+        assert.Equal(dirName, logstream.ClientDir(client))
+    }
+
+    // A nil store is safe to use when streaming is disabled
+    var disabled *logstream.Store
+    assert.Equal(nil, disabled.Append("10.0.0.1", []byte("x\n")))
+    disabled.Cycle()
+    assert.Equal(nil, disabled.Close())
+}
@@ -0,0 +1,416 @@
+package manifest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+)
+
+// Package level variables
+var ErrMismatch = errors.New("file does not match its manifest")  // Failed verification
+
+
+// Entry is the manifest of a single wordlist
+type Entry struct {
+    Lines  int64  `json:"lines"`
+    Name   string `json:"name"`
+    Sha256 string `json:"sha256"`
+    Size   int64  `json:"size"`
+}
+
+
+// Digester computes the manifest of the data written to it, so a file can be verified
+// as it is read or streamed
+type Digester struct {
+    hasher   hash.Hash
+    last     byte
+    newlines int64
+    size     int64
+}
+
+
+// Manifest holds the entries of the wordlists distributed in the run, safe for
+// concurrent use
+type Manifest struct {
+    entries map[string]Entry
+    mutx    sync.Mutex
+}
+
+
+// Creates a digester with nothing written to it.
+//
+// @Returns
+// - The initialized digester
+//
+func NewDigester() *Digester {
+    return &Digester{hasher: sha256.New()}
+}
+
+
+// Adds the data to the hash, line count, and size of the digester.
+//
+// @Parameters
+// - data:  The data to digest
+//
+// @Returns
+// - The number of bytes digested, always the length of the data
+// - Always nil so it can be the writer of a copy or tee
+//
+func (digester *Digester) Write(data []byte) (int, error) {
+    // If there is no data to digest
+    if len(data) == 0 {
+        return 0, nil
+    }
+
+    digester.hasher.Write(data)
+    digester.newlines += int64(bytes.Count(data, []byte("\n")))
+    digester.size += int64(len(data))
+    digester.last = data[len(data)-1]
+
+    return len(data), nil
+}
+
+
+// Gets the manifest entry of the digested data.
+//
+// @Parameters
+// - name:  The name of the file the data belongs to
+//
+// @Returns
+// - The manifest entry, a last line without a trailing newline is counted
+//
+func (digester *Digester) Entry(name string) Entry {
+    lines := digester.newlines
+    // If the last line is not terminated by a newline
+    if digester.size > 0 && digester.last != '\n' {
+        lines++
+    }
+
+    return Entry{
+        Lines:  lines,
+        Name:   name,
+        Sha256: hex.EncodeToString(digester.hasher.Sum(nil)),
+        Size:   digester.size,
+    }
+}
+
+
+// Computes the manifest entry of the file at the passed in path.
+//
+// @Parameters
+// - filePath:  The path of the file
+//
+// @Returns
+// - The manifest entry of the file
+// - Error if it occurs, otherwise nil on success
+//
+func Compute(filePath string) (Entry, error) {
+    file, err := os.Open(filePath)
+    if err != nil {
+        return Entry{}, err
+    }
+
+    // Close the file on local exit
+    defer file.Close()
+
+    digester := NewDigester()
+    _, err = io.CopyBuffer(digester, file, make([]byte, 1024 * 1024))
+    if err != nil {
+        return Entry{}, fmt.Errorf("error reading %s for manifest - %w", filePath, err)
+    }
+
+    return digester.Entry(filepath.Base(filePath)), nil
+}
+
+
+// Formats the hash and line count of the entry sent in the transfer reply.
+//
+// @Returns
+// - The digest in sha256:lines format
+//
+func (entry Entry) Digest() string {
+    return entry.Sha256 + ":" + strconv.FormatInt(entry.Lines, 10)
+}
+
+
+// Parses a digest formatted by Digest().
+//
+// @Parameters
+// - digest:  The digest in sha256:lines format
+//
+// @Returns
+// - The hex SHA-256 of the file
+// - The line count of the file
+// - Error if it occurs, otherwise nil on success
+//
+func ParseDigest(digest string) (string, int64, error) {
+    sum, lineCount, found := strings.Cut(digest, ":")
+    // If the hash is not a hex SHA-256
+    if _, err := hex.DecodeString(sum); !found || err != nil || len(sum) != 64 {
+        return "", 0, fmt.Errorf("invalid manifest digest - %q", digest)
+    }
+
+    lines, err := strconv.ParseInt(lineCount, 10, 64)
+    if err != nil || lines < 0 {
+        return "", 0, fmt.Errorf("invalid manifest line count - %q", digest)
+    }
+
+    return sum, lines, nil
+}
+
+
+// Checks the digested data matches the passed in digest.
+//
+// @Parameters
+// - digest:  The expected digest in sha256:lines format
+//
+// @Returns
+// - ErrMismatch if the data differs, otherwise error if the digest is malformed or nil
+//   on success
+//
+func (digester *Digester) Verify(digest string) error {
+    sum, lines, err := ParseDigest(digest)
+    if err != nil {
+        return err
+    }
+
+    entry := digester.Entry("")
+    // If the content differs from what the server sent
+    if entry.Sha256 != sum || entry.Lines != lines {
+        return fmt.Errorf("%w, expected %d lines with SHA-256 %s, got %d lines with %s",
+                          ErrMismatch, lines, sum, entry.Lines, entry.Sha256)
+    }
+
+    return nil
+}
+
+
+// Verifies the file at the passed in path matches the digest.
+//
+// @Parameters
+// - filePath:  The path of the file to verify
+// - digest:  The expected digest in sha256:lines format
+//
+// @Returns
+// - ErrMismatch if the file differs, otherwise error if it occurs or nil on success
+//
+func Verify(filePath string, digest string) error {
+    file, err := os.Open(filePath)
+    if err != nil {
+        return err
+    }
+
+    // Close the file on local exit
+    defer file.Close()
+
+    digester := NewDigester()
+    _, err = io.CopyBuffer(digester, file, make([]byte, 1024 * 1024))
+    if err != nil {
+        return fmt.Errorf("error reading %s for verification - %w", filePath, err)
+    }
+
+    return digester.Verify(digest)
+}
+
+
+// Creates an empty manifest.
+//
+// @Returns
+// - The initialized manifest
+//
+func New() *Manifest {
+    return &Manifest{entries: map[string]Entry{}}
+}
+
+
+// Computes the entries of every file in the dir, hashing the files in parallel.
+//
+// @Parameters
+// - dirPath:  The dir of the files to add
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (manifest *Manifest) AddDir(dirPath string) error {
+    dirEntries, err := os.ReadDir(dirPath)
+    if err != nil {
+        return err
+    }
+
+    paths := make(chan string)
+    errs := make(chan error, len(dirEntries))
+    var waitGroup sync.WaitGroup
+
+    // Start a worker per CPU computing the entries of the files
+    for range runtime.NumCPU() {
+        waitGroup.Add(1)
+
+        go func() {
+            defer waitGroup.Done()
+
+            for filePath := range paths {
+                entry, err := Compute(filePath)
+                if err != nil {
+                    errs <- err
+                    continue
+                }
+
+                manifest.mutx.Lock()
+                manifest.entries[entry.Name] = entry
+                manifest.mutx.Unlock()
+            }
+        } ()
+    }
+
+    // Iterate through the dir passing the regular files to the workers
+    for _, dirEntry := range dirEntries {
+        if dirEntry.Type().IsRegular() {
+            paths <- filepath.Join(dirPath, dirEntry.Name())
+        }
+    }
+
+    close(paths)
+    waitGroup.Wait()
+    close(errs)
+
+    return <-errs
+}
+
+
+// Gets the entry of the file, computing it if the file is not in the manifest or was
+// resized since, such as the parts of a wordlist split by work stealing.
+//
+// @Parameters
+// - filePath:  The path of the file
+//
+// @Returns
+// - The manifest entry of the file
+// - Error if it occurs, otherwise nil on success
+//
+func (manifest *Manifest) Get(filePath string) (Entry, error) {
+    fileInfo, err := os.Stat(filePath)
+    if err != nil {
+        return Entry{}, err
+    }
+
+    manifest.mutx.Lock()
+    entry, exists := manifest.entries[filepath.Base(filePath)]
+    manifest.mutx.Unlock()
+
+    // If the entry is current
+    if exists && entry.Size == fileInfo.Size() {
+        return entry, nil
+    }
+
+    entry, err = Compute(filePath)
+    if err != nil {
+        return Entry{}, err
+    }
+
+    manifest.mutx.Lock()
+    manifest.entries[entry.Name] = entry
+    manifest.mutx.Unlock()
+
+    return entry, nil
+}
+
+
+// Gets the entries of the manifest ordered by name.
+//
+// @Returns
+// - The manifest entries
+//
+func (manifest *Manifest) Entries() []Entry {
+    // If the manifest was never generated
+    if manifest == nil {
+        return nil
+    }
+
+    manifest.mutx.Lock()
+    defer manifest.mutx.Unlock()
+
+    entries := make([]Entry, 0, len(manifest.entries))
+    for _, entry := range manifest.entries {
+        entries = append(entries, entry)
+    }
+
+    slices.SortFunc(entries, func(a, b Entry) int {
+        return strings.Compare(a.Name, b.Name)
+    })
+
+    return entries
+}
+
+
+// Writes the entries of the manifest as indented JSON.
+//
+// @Parameters
+// - manifestPath:  The path where the JSON manifest is written
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (manifest *Manifest) WriteJson(manifestPath string) error {
+    manifestJson, err := json.MarshalIndent(manifest.Entries(), "", "  ")
+    if err != nil {
+        return err
+    }
+
+    return os.WriteFile(manifestPath, append(manifestJson, '\n'), 0644)
+}
+
+
+// Formats the message a client sends when a received wordlist fails verification.
+//
+// @Parameters
+// - fileName:  The name of the rejected wordlist
+//
+// @Returns
+// - The formatted rejection message
+//
+func FormatRejection(fileName string) []byte {
+    message := append([]byte{}, globals.WORDLIST_REJECTED_PREFIX...)
+    message = append(message, fileName...)
+    return append(message, globals.TRANSFER_SUFFIX...)
+}
+
+
+// Parses the name of the wordlist from the rejection message.
+//
+// @Parameters
+// - message:  The rejection message
+//
+// @Returns
+// - The name of the rejected wordlist
+// - Error if it occurs, otherwise nil on success
+//
+func ParseRejection(message []byte) (string, error) {
+    // If the message does not start with prefix or end with closed bracket
+    if !bytes.HasPrefix(message, globals.WORDLIST_REJECTED_PREFIX) ||
+       !bytes.HasSuffix(message, globals.TRANSFER_SUFFIX) {
+        return "", fmt.Errorf("improper prefix or suffix in wordlist rejection message")
+    }
+
+    fileName := bytes.TrimSuffix(bytes.TrimPrefix(message, globals.WORDLIST_REJECTED_PREFIX),
+                                 globals.TRANSFER_SUFFIX)
+    // If the wordlist name is missing
+    if len(fileName) == 0 {
+        return "", fmt.Errorf("empty wordlist name in wordlist rejection message")
+    }
+
+    return filepath.Base(string(fileName)), nil
+}
@@ -0,0 +1,79 @@
+package manifest_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestCompute(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    dirPath := t.TempDir()
+    wordlists := map[string]string{"terminated.txt": "one\ntwo\n",
+                                   "unterminated.txt": "one\ntwo\nthree",
+                                   "empty.txt": ""}
+    for name, content := range wordlists {
+        assert.Equal(nil, os.WriteFile(filepath.Join(dirPath, name), []byte(content), 0644))
+    }
+
+    wordlistManifest := manifest.New()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, wordlistManifest.AddDir(dirPath))
+
+    entries := wordlistManifest.Entries()
+    assert.Equal(3, len(entries))
+    // Ensure the entries are ordered by name and the lines are counted
+    assert.Equal(manifest.Entry{Lines: 0, Name: "empty.txt", Size: 0,
+                                Sha256: "e3b0c44298fc1c149afbf4c8996fb924" +
+                                        "27ae41e4649b934ca495991b7852b855"}, entries[0])
+    assert.Equal(int64(2), entries[1].Lines)
+    assert.Equal(int64(3), entries[2].Lines)
+
+    unterminated := filepath.Join(dirPath, "unterminated.txt")
+    // Ensure the file verifies against its own digest
+    assert.Equal(nil, manifest.Verify(unterminated, entries[2].Digest()))
+
+    // Ensure a resized file is recomputed rather than served from the manifest
+    assert.Equal(nil, os.WriteFile(unterminated, []byte("one\n"), 0644))
+    entry, err := wordlistManifest.Get(unterminated)
+    assert.Equal(nil, err)
+    assert.Equal(int64(1), entry.Lines)
+
+    // Ensure a changed file fails verification
+    err = manifest.Verify(unterminated, entries[2].Digest())
+    assert.True(errors.Is(err, manifest.ErrMismatch))
+
+    falacies := []string{"", "abc:1", entries[0].Sha256, entries[0].Sha256 + ":-1",
+                         entries[0].Sha256 + ":x"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        _, _, err = manifest.ParseDigest(falacy)
+        assert.NotEqual(nil, err)
+    }
+}
+
+
+func TestFormatParseRejection(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    fileName, err := manifest.ParseRejection(manifest.FormatRejection("rockyou.txt"))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("rockyou.txt", fileName)
+
+    falacies := [][]byte{[]byte("<WORDLIST_REJECTED:>"), []byte("<WORDLIST_REJECTED:a.txt"),
+                         []byte("<WORK_START:a.txt>")}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        _, err = manifest.ParseRejection(falacy)
+        assert.NotEqual(nil, err)
+    }
+}
@@ -0,0 +1,270 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metric types of the Prometheus text exposition format
+const TypeCounter = "counter"
+const TypeGauge = "gauge"
+
+
+// metric is a single registered metric whose values are collected at scrape time
+type metric struct {
+    collect func() map[string]float64
+    help    string
+    kind    string
+    label   string
+    name    string
+}
+
+
+// Registry stores the metrics served in the Prometheus text format
+type Registry struct {
+    metrics []metric
+    mutx    sync.Mutex
+    server  *http.Server
+}
+
+// Creates a new empty metrics registry.
+//
+// @Returns
+// - The initialized registry
+//
+func NewRegistry() *Registry {
+    return &Registry{}
+}
+
+// Registers a counter whose value is read from the passed in function at scrape time.
+//
+// @Parameters
+// - name:  The name of the metric, counters should end in _total
+// - help:  The description of the metric
+// - value:  Function returning the current value of the counter
+//
+func (registry *Registry) Counter(name string, help string, value func() float64) {
+    registry.add(metric{name: name, help: help, kind: TypeCounter,
+                        collect: func() map[string]float64 {
+                            return map[string]float64{"": value()}
+                        }})
+}
+
+// Registers a gauge whose value is read from the passed in function at scrape time.
+//
+// @Parameters
+// - name:  The name of the metric
+// - help:  The description of the metric
+// - value:  Function returning the current value of the gauge
+//
+func (registry *Registry) Gauge(name string, help string, value func() float64) {
+    registry.add(metric{name: name, help: help, kind: TypeGauge,
+                        collect: func() map[string]float64 {
+                            return map[string]float64{"": value()}
+                        }})
+}
+
+// Registers a gauge with one sample per value of the label, read from the passed in
+// function at scrape time.
+//
+// @Parameters
+// - name:  The name of the metric
+// - help:  The description of the metric
+// - label:  The name of the label distinguishing the samples
+// - values:  Function returning the current value keyed by label value
+//
+func (registry *Registry) GaugeVec(name string, help string, label string,
+                                   values func() map[string]float64) {
+    registry.add(metric{name: name, help: help, kind: TypeGauge, label: label,
+                        collect: values})
+}
+
+// Adds the metric to the registry.
+//
+// @Parameters
+// - newMetric:  The metric to be added
+//
+func (registry *Registry) add(newMetric metric) {
+    registry.mutx.Lock()
+    defer registry.mutx.Unlock()
+
+    registry.metrics = append(registry.metrics, newMetric)
+}
+
+// Writes the current value of every metric in the Prometheus text exposition format.
+//
+// @Parameters
+// - writer:  Where the formatted metrics are written
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (registry *Registry) Write(writer io.Writer) error {
+    registry.mutx.Lock()
+    metrics := make([]metric, len(registry.metrics))
+    copy(metrics, registry.metrics)
+    registry.mutx.Unlock()
+
+    buffer := bufio.NewWriter(writer)
+
+    // Iterate through the metrics in the order they were registered
+    for _, current := range metrics {
+        fmt.Fprintf(buffer, "# HELP %s %s\n", current.name, escapeHelp(current.help))
+        fmt.Fprintf(buffer, "# TYPE %s %s\n", current.name, current.kind)
+
+        values := current.collect()
+        labelValues := make([]string, 0, len(values))
+        // Sort the samples by label value for stable output
+        for labelValue := range values {
+            labelValues = append(labelValues, labelValue)
+        }
+        sort.Strings(labelValues)
+
+        // Iterate through the samples writing a line for each
+        for _, labelValue := range labelValues {
+            // If the metric is unlabeled
+            if current.label == "" {
+                fmt.Fprintf(buffer, "%s %s\n", current.name,
+                            formatValue(values[labelValue]))
+                continue
+            }
+
+            fmt.Fprintf(buffer, "%s{%s=\"%s\"} %s\n", current.name, current.label,
+                        escapeLabel(labelValue), formatValue(values[labelValue]))
+        }
+    }
+
+    return buffer.Flush()
+}
+
+// Sets up the HTTP route the metrics are scraped from.
+//
+// @Returns
+// - The handler serving the metrics on /metrics
+//
+func (registry *Registry) Handler() http.Handler {
+    mux := http.NewServeMux()
+
+    // Serve the metrics in the text exposition format
+    mux.HandleFunc("/metrics", func(writer http.ResponseWriter, req *http.Request) {
+        writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+        registry.Write(writer)
+    })
+
+    return mux
+}
+
+// Starts the HTTP server in a separate goroutine, serving HTTPS if a
+// TLS certificate is passed in.
+//
+// @Parameters
+// - listenIp:  The IP address of the network interface to listen on
+// - port:  The port to listen on
+// - cert:  The TLS certificate to serve HTTPS with, nil for plain HTTP
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (registry *Registry) Start(listenIp string, port int, cert *tls.Certificate) error {
+    // Bind the listener first so errors are returned to the caller
+    listener, err := net.Listen("tcp", listenIp + ":" + strconv.Itoa(port))
+    if err != nil {
+        return err
+    }
+
+    registry.server = &http.Server{
+        Handler:           registry.Handler(),
+        ReadHeaderTimeout: 10 * time.Second,
+    }
+
+    // If a certificate was passed in, wrap the listener in TLS
+    if cert != nil {
+        listener = tls.NewListener(listener, &tls.Config{
+            Certificates: []tls.Certificate{*cert},
+            MinVersion:   tls.VersionTLS12,
+        })
+    }
+
+    // Serve until the server is shut down
+    go registry.server.Serve(listener)
+
+    return nil
+}
+
+// Gracefully shuts down the HTTP server if it is running.
+//
+// @Parameters
+// - timeout:  The max amount of time to wait for active requests
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (registry *Registry) Stop(timeout time.Duration) error {
+    if registry == nil || registry.server == nil {
+        return nil
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), timeout)
+    defer cancel()
+
+    return registry.server.Shutdown(ctx)
+}
+
+
+// Escapes the backslashes and newlines of a help string.
+//
+// @Parameters
+// - help:  The help string to escape
+//
+// @Returns
+// - The escaped help string
+//
+func escapeHelp(help string) string {
+    return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+
+// Escapes the backslashes, quotes, and newlines of a label value.
+//
+// @Parameters
+// - value:  The label value to escape
+//
+// @Returns
+// - The escaped label value
+//
+func escapeLabel(value string) string {
+    return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+
+// Formats a sample value, using the special values of the format for infinity and NaN.
+//
+// @Parameters
+// - value:  The sample value to format
+//
+// @Returns
+// - The formatted sample value
+//
+func formatValue(value float64) string {
+    switch {
+    case math.IsNaN(value):
+        return "NaN"
+    case math.IsInf(value, 1):
+        return "+Inf"
+    case math.IsInf(value, -1):
+        return "-Inf"
+    default:
+        return strconv.FormatFloat(value, 'g', -1, 64)
+    }
+}
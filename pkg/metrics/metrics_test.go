@@ -0,0 +1,92 @@
+package metrics_test
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestWrite(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    registry := metrics.NewRegistry()
+    connections := 3.0
+
+    // Register one metric of each kind
+    registry.Gauge("kk_active_connections", "Active connections", func() float64 {
+        return connections
+    })
+    registry.Counter("kk_transferred_bytes_total", "Bytes transferred", func() float64 {
+        return 1048576
+    })
+    registry.GaugeVec("kk_instances", "Instances by state", "state",
+                      func() map[string]float64 {
+                          return map[string]float64{"running": 2, "pending\"": 1,
+                                                    "bad": math.Inf(1)}
+                      })
+
+    var buffer bytes.Buffer
+    // Write the metrics in the text format
+    err := registry.Write(&buffer)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    expected := "# HELP kk_active_connections Active connections\n" +
+                "# TYPE kk_active_connections gauge\n" +
+                "kk_active_connections 3\n" +
+                "# HELP kk_transferred_bytes_total Bytes transferred\n" +
+                "# TYPE kk_transferred_bytes_total counter\n" +
+                "kk_transferred_bytes_total 1.048576e+06\n" +
+                "# HELP kk_instances Instances by state\n" +
+                "# TYPE kk_instances gauge\n" +
+                "kk_instances{state=\"bad\"} +Inf\n" +
+                "kk_instances{state=\"pending\\\"\"} 1\n" +
+                "kk_instances{state=\"running\"} 2\n"
+    // Ensure the metrics are formatted in registration and label order
+    assert.Equal(expected, buffer.String())
+
+    connections = 1
+    buffer.Reset()
+    // Ensure values are collected again on each write
+    registry.Write(&buffer)
+    assert.Contains(buffer.String(), "kk_active_connections 1\n")
+}
+
+
+func TestHandler(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    registry := metrics.NewRegistry()
+    registry.Counter("kk_cracked_hashes_total", "Cracked hashes", func() float64 {
+        return 42
+    })
+
+    server := httptest.NewServer(registry.Handler())
+    defer server.Close()
+
+    // Scrape the metrics endpoint
+    resp, err := http.Get(server.URL + "/metrics")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    defer resp.Body.Close()
+
+    body, err := io.ReadAll(resp.Body)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(http.StatusOK, resp.StatusCode)
+    assert.Contains(resp.Header.Get("Content-Type"), "text/plain")
+    assert.Contains(string(body), "kk_cracked_hashes_total 42\n")
+
+    // Ensure stopping a disabled registry is a no-op
+    var disabled *metrics.Registry
+    assert.Equal(nil, disabled.Stop(0))
+}
@@ -0,0 +1,107 @@
+package netio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+)
+
+// Classes of transfer errors, wrapped into the returned errors so callers can react
+// to each class with errors.Is
+var (
+    ErrChecksumMismatch    = errors.New("checksum mismatch")
+    ErrDiskFull            = errors.New("disk full")
+    ErrMalformedMessage    = errors.New("malformed message")
+    ErrPeerClosed          = errors.New("peer closed the connection")
+    ErrTimeout             = errors.New("connection timed out")
+    ErrTransferAborted     = errors.New("transfer aborted")
+    ErrUnsupportedEncoding = errors.New("unsupported transfer encoding")
+)
+
+// Package level variables
+var errorClasses = []struct {  // Short names of the error classes for logs and events
+    err  error
+    name string
+}{
+    {ErrChecksumMismatch, "checksum_mismatch"},
+    {ErrDiskFull, "disk_full"},
+    {ErrMalformedMessage, "malformed_message"},
+    {ErrPeerClosed, "peer_closed"},
+    {ErrTimeout, "timeout"},
+    {ErrTransferAborted, "transfer_aborted"},
+    {ErrUnsupportedEncoding, "unsupported_encoding"},
+}
+
+
+// Gets the short name of the class the error belongs to, for logs and events.
+//
+// @Parameters
+// - err:  The error to get the class of
+//
+// @Returns
+// - The name of the class, empty if the error is not of a known class
+//
+func ErrorClass(err error) string {
+    // Iterate through the classes returning the first the error belongs to
+    for _, class := range errorClasses {
+        if errors.Is(err, class.err) {
+            return class.name
+        }
+    }
+
+    return ""
+}
+
+
+// Checks whether the operation that returned the error can succeed when tried again,
+// such as over a new connection or with another client. Malformed messages,
+// unsupported encodings, and full disks fail the same way until something changes.
+//
+// @Parameters
+// - err:  The error to check
+//
+// @Returns
+// - true/false depending on whether the operation is worth retrying
+//
+func IsRetryable(err error) bool {
+    return errors.Is(err, ErrPeerClosed) || errors.Is(err, ErrTimeout) ||
+           errors.Is(err, ErrTransferAborted) || errors.Is(err, ErrChecksumMismatch)
+}
+
+
+// Wraps the error returned by a socket or file operation with its class, errors of an
+// unknown class are wrapped with the operation only.
+//
+// @Parameters
+// - operation:  Description of the operation that failed
+// - err:  The error returned by the operation
+//
+// @Returns
+// - The wrapped error, nil if the operation succeeded
+//
+func wrapError(operation string, err error) error {
+    var netErr net.Error
+
+    switch {
+    case err == nil:
+        return nil
+    // If the error is already classified
+    case ErrorClass(err) != "":
+        return fmt.Errorf("%s - %w", operation, err)
+    // If the disk or its quota ran out of space while writing
+    case errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EDQUOT):
+        return fmt.Errorf("%s - %w - %w", operation, ErrDiskFull, err)
+    // If the peer closed or reset the connection
+    case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+         errors.Is(err, net.ErrClosed) || errors.Is(err, syscall.ECONNRESET) ||
+         errors.Is(err, syscall.EPIPE):
+        return fmt.Errorf("%s - %w - %w", operation, ErrPeerClosed, err)
+    // If a deadline on the connection passed
+    case errors.As(err, &netErr) && netErr.Timeout():
+        return fmt.Errorf("%s - %w - %w", operation, ErrTimeout, err)
+    default:
+        return fmt.Errorf("%s - %w", operation, err)
+    }
+}
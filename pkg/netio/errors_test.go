@@ -0,0 +1,93 @@
+package netio_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/netio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorClass(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Ensure wrapped sentinels report their class
+    assert.Equal("disk_full", netio.ErrorClass(fmt.Errorf("writing - %w", netio.ErrDiskFull)))
+    assert.Equal("malformed_message", netio.ErrorClass(netio.ErrMalformedMessage))
+    // Ensure errors of an unknown class report no class
+    assert.Equal("", netio.ErrorClass(errors.New("unknown")))
+    assert.Equal("", netio.ErrorClass(nil))
+
+    // Parse a transfer reply missing its colon separator
+    buffer := append([]byte(nil), globals.START_TRANSFER_PREFIX...)
+    buffer = append(buffer, []byte("path.txt")...)
+    _, _, _, _, err := netio.GetFileInfo(buffer, globals.START_TRANSFER_PREFIX, len(buffer))
+    // Ensure the error is a malformed message
+    assert.True(errors.Is(err, netio.ErrMalformedMessage))
+
+    clientConn, serverConn := net.Pipe()
+    // Close the peer so the read fails
+    serverConn.Close()
+
+    readBuffer := make([]byte, 64)
+    _, err = netio.ReadHandler(clientConn, &readBuffer)
+    // Ensure reading from a closed peer reports the peer closed
+    assert.True(errors.Is(err, netio.ErrPeerClosed))
+    assert.Equal("peer_closed", netio.ErrorClass(err))
+    clientConn.Close()
+}
+
+
+func TestIsRetryable(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := []error{netio.ErrChecksumMismatch, netio.ErrPeerClosed, netio.ErrTimeout,
+                      fmt.Errorf("range - %w", netio.ErrTransferAborted)}
+    falacies := []error{netio.ErrDiskFull, netio.ErrMalformedMessage,
+                        netio.ErrUnsupportedEncoding, errors.New("unknown"), nil}
+
+    // Iterate through the retryable errors ensuring each is retryable
+    for _, err := range truths {
+        assert.True(netio.IsRetryable(err))
+    }
+
+    // Iterate through the permanent errors ensuring none are retryable
+    for _, err := range falacies {
+        assert.False(netio.IsRetryable(err))
+    }
+}
+
+
+func TestManifestMismatch(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    storePath := t.TempDir()
+    clientConn, serverConn := net.Pipe()
+
+    go func() {
+        // Send data other than what the digest describes
+        serverConn.Write([]byte("letmein\n"))
+        serverConn.Close()
+    } ()
+
+    digest := strings.Repeat("0", 64) + ":1"
+    _, err := netio.HandleTransferRecv(clientConn, storePath, "wordlist.txt", 8,
+                                       netio.EncodingNone, digest)
+    // Ensure the mismatch is reported as a retryable checksum mismatch
+    assert.True(errors.Is(err, netio.ErrChecksumMismatch))
+    assert.True(netio.IsRetryable(err))
+    clientConn.Close()
+
+    // Ensure the rejected file was removed rather than committed
+    entries, err := os.ReadDir(storePath)
+    assert.Equal(nil, err)
+    assert.Equal(0, len(entries))
+}
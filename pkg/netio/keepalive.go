@@ -0,0 +1,116 @@
+package netio
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+)
+
+
+// Keepalive probes the peer on an interval while the connection is otherwise idle, so
+// a hung peer is detected by both sides instead of blocking their reads forever
+type Keepalive struct {
+    interval time.Duration
+    stopCh   chan struct{}
+    stopOnce sync.Once
+    wg       sync.WaitGroup
+}
+
+
+// Sends a keepalive probe and waits for the acknowledgement of the peer.
+//
+// @Parameters
+// - connection:  The network connection to probe
+// - timeout:  The max time to wait for the acknowledgement, 0 waits forever
+//
+// @Returns
+// - Error if the probe failed or the reply was not an acknowledgement, otherwise nil
+//
+func Probe(connection net.Conn, timeout time.Duration) error {
+    _, err := WriteHandler(connection, globals.KEEPALIVE_MARKER, len(globals.KEEPALIVE_MARKER))
+    if err != nil {
+        return err
+    }
+
+    buffer := make([]byte, globals.MESSAGE_BUFFER_SIZE)
+    // Wait for the acknowledgement of the probe
+    bytesRead, err := ReadHandlerTimeout(connection, &buffer, timeout)
+    if err != nil {
+        return err
+    }
+
+    // If the peer replied with anything other than the acknowledgement
+    if !bytes.Equal(buffer[:bytesRead], globals.KEEPALIVE_ACK) {
+        return fmt.Errorf("%w - unexpected keepalive reply %q", ErrMalformedMessage,
+                          buffer[:bytesRead])
+    }
+
+    return nil
+}
+
+
+// Creates a keepalive probing on the passed in interval.
+//
+// @Parameters
+// - interval:  The duration of time between probes
+//
+// @Returns
+// - The initialized keepalive
+//
+func NewKeepalive(interval time.Duration) *Keepalive {
+    return &Keepalive{interval: interval, stopCh: make(chan struct{})}
+}
+
+
+// Starts probing in a Goroutine until a probe fails or Stop() is called.
+//
+// @Parameters
+// - probe:  Probes the peer, holding any lock the messaging on the connection needs
+// - onFail:  Called once with the error of the first failed probe
+//
+func (keepalive *Keepalive) Start(probe func() error, onFail func(error)) {
+    keepalive.wg.Add(1)
+
+    go func() {
+        defer keepalive.wg.Done()
+
+        ticker := time.NewTicker(keepalive.interval)
+        defer ticker.Stop()
+
+        for {
+            select {
+            case <-ticker.C:
+                err := probe()
+                // If the peer acknowledged the probe
+                if err == nil {
+                    continue
+                }
+
+                onFail(err)
+                return
+            case <-keepalive.stopCh:
+                return
+            }
+        }
+    } ()
+}
+
+
+// Stops the keepalive, waiting for a probe in progress to complete.
+//
+func (keepalive *Keepalive) Stop() {
+    // If the keepalive was never created
+    if keepalive == nil {
+        return
+    }
+
+    keepalive.stopOnce.Do(func() {
+        close(keepalive.stopCh)
+    })
+
+    keepalive.wg.Wait()
+}
@@ -0,0 +1,77 @@
+package netio_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/netio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeepalive(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    clientConn, serverConn := net.Pipe()
+    defer clientConn.Close()
+
+    go func() {
+        buffer := make([]byte, globals.MESSAGE_BUFFER_SIZE)
+        // Acknowledge the first probe, then hang without replying to the second
+        netio.ReadHandler(serverConn, &buffer)
+        netio.WriteHandler(serverConn, globals.KEEPALIVE_ACK, len(globals.KEEPALIVE_ACK))
+        netio.ReadHandler(serverConn, &buffer)
+    } ()
+
+    // Ensure the acknowledged probe succeeds
+    assert.Equal(nil, netio.Probe(clientConn, time.Second))
+
+    failed := make(chan error, 1)
+    keepalive := netio.NewKeepalive(10 * time.Millisecond)
+    keepalive.Start(func() error {
+        return netio.Probe(clientConn, 50 * time.Millisecond)
+    }, func(err error) {
+        failed <- err
+    })
+
+    select {
+    // Ensure the hung peer is reported as a timeout
+    case err := <-failed:
+        assert.True(errors.Is(err, netio.ErrTimeout))
+    case <-time.After(5 * time.Second):
+        t.Fatal("keepalive did not detect the hung peer")
+    }
+
+    keepalive.Stop()
+    serverConn.Close()
+
+    // Ensure stopping a keepalive that was never created is a no-op
+    var unused *netio.Keepalive
+    unused.Stop()
+}
+
+
+func TestReadHandlerTimeout(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    clientConn, serverConn := net.Pipe()
+    defer clientConn.Close()
+    defer serverConn.Close()
+
+    buffer := make([]byte, 64)
+    _, err := netio.ReadHandlerTimeout(clientConn, &buffer, 20 * time.Millisecond)
+    // Ensure a read with no data past the deadline is a timeout
+    assert.True(errors.Is(err, netio.ErrTimeout))
+
+    go serverConn.Write([]byte("message"))
+
+    // Ensure the deadline was cleared so the next read waits for the message
+    bytesRead, err := netio.ReadHandlerTimeout(clientConn, &buffer, 0)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("message", string(buffer[:bytesRead]))
+}
@@ -2,48 +2,298 @@ package netio
 
 import (
 	"bytes"
+	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
 	"net"
+	"net/netip"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ngimb64/Kloud-Kraken/internal/globals"
 	"github.com/ngimb64/Kloud-Kraken/pkg/data"
+	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
+	"github.com/ngimb64/Kloud-Kraken/pkg/manifest"
 )
 
+// Transfer encodings negotiated in the transfer reply
+const EncodingGzip = "gzip"
+const EncodingNone = ""
+// Sent in place of an encoding when the file is staged in S3 instead of sent over a socket
+const EncodingS3 = "s3"
+// Sent after the client listener port when plaintext transfers are negotiated, telling the
+// client whether the server dials the data connection with TLS
+const TransportTls byte = 0
+const TransportPlaintext byte = 1
+// Max bytes sent per zero-copy call, so the progress is counted between calls
+const zeroCopyChunk = 4 * globals.MB
+
+// Package level variables
+var ReadTimeout time.Duration   // Max time ReadHandler waits for a message, 0 waits forever
+var WriteTimeout time.Duration  // Max time WriteHandler waits to send a message, 0 waits forever
+
+
+// Creates the part file received data is stored in until the file is complete, adding
+// random characters to the beginning of the name if a file or part file with the same
+// name already exists.
+//
+// @Parameters
+// - storePath:  The directory where the file will be stored
+// - fileName:  The name of the file to store
+//
+// @Returns
+// - The open file descriptor of the part file
+// - The path the file is renamed to once complete
+// - Error if it occurs, otherwise nil on success
+//
+func createRecvFile(storePath string, fileName string) (*os.File, string, error) {
+    // Format the path where the file will be stored
+    filePath := storePath + "/" + fileName
+
+    for {
+        // Open the part file for writing
+        file, err := os.OpenFile(filePath + disk.PartSuffix,
+                                 os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+        // If the file is already being received under the same name
+        if os.IsExist(err) {
+            // Add random characters to beginning of name, then try again
+            filePath = storePath + "/" + data.RandStringBytes(8) + "_" + fileName
+            continue
+        } else if err != nil {
+            return nil, "", wrapError("error creating received file", err)
+        }
+
+        // If a complete file with the same name already exists
+        if _, err = os.Stat(filePath); err == nil {
+            file.Close()
+            os.Remove(filePath + disk.PartSuffix)
+            // Add random characters to beginning of name, then try again
+            filePath = storePath + "/" + data.RandStringBytes(8) + "_" + fileName
+            continue
+        }
+
+        return file, filePath, nil
+    }
+}
+
+
+// Renames the flushed part file of a completely received file to its final path, so
+// the file only appears under its name once all of its data is on disk.
+//
+// @Parameters
+// - filePath:  The path the part file is renamed to
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func commitRecvFile(filePath string) error {
+    err := os.Rename(filePath + disk.PartSuffix, filePath)
+    if err != nil {
+        os.Remove(filePath + disk.PartSuffix)
+        return wrapError("error renaming received file", err)
+    }
+
+    return nil
+}
+
+
+// Verifies the part file of a completely received file against the manifest digest from
+// the transfer reply, removing it if it does not match so it is never processed.
+//
+// @Parameters
+// - filePath:  The path the part file is renamed to once verified
+// - digest:  The manifest digest of the file, empty if the file is not verified
+//
+// @Returns
+// - Error wrapping ErrChecksumMismatch if the file differs, otherwise nil on success
+//
+func verifyRecvFile(filePath string, digest string) error {
+    // If the sender did not include a digest
+    if digest == "" {
+        return nil
+    }
+
+    err := manifest.Verify(filePath + disk.PartSuffix, digest)
+    if err != nil {
+        os.Remove(filePath + disk.PartSuffix)
+
+        // If the file was read but differs from the manifest
+        if errors.Is(err, manifest.ErrMismatch) {
+            return fmt.Errorf("%s failed manifest verification - %w - %w",
+                              filepath.Base(filePath), ErrChecksumMismatch, err)
+        }
+
+        return wrapError("error verifying received file", err)
+    }
+
+    return nil
+}
+
+
+// Reads gzip compressed data from the socket, decompressing it into the passed in file
+// descriptor until the end of the compressed stream or the expected file size is reached.
+//
+// @Parameters
+// - file:  The open file descriptor of where the decompressed data will be stored
+// - connection:  Active socket connection for reading compressed data
+// - transferBuffer:  Buffer allocated for file transfer based on file size
+// - fileSize:  The decompressed size of the file to be received
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func DecompressToFileCopy(file *os.File, connection net.Conn,
+                          transferBuffer []byte, fileSize int64) error {
+    // Close file on local exit
+    defer file.Close()
+
+    // Set up the gzip reader on the compressed stream
+    gzipReader, err := gzip.NewReader(connection)
+    if err != nil {
+        return wrapError("error reading compressed stream", err)
+    }
+    // Close the gzip reader on local exit
+    defer gzipReader.Close()
+    // Stop at the end of the stream instead of waiting on the connection for another
+    gzipReader.Multistream(false)
+
+    // Set up limited reader so a corrupt stream can not exceed the expected size
+    limitedReader := &io.LimitedReader{R: gzipReader, N: fileSize}
+
+    // Transfer decompressed data from connection to open file
+    bytesWrote, err := io.CopyBuffer(file, limitedReader, transferBuffer)
+    if err != nil {
+        return wrapError("error receiving compressed file", err)
+    }
+
+    // If the compressed stream ended before the whole file was received
+    if bytesWrote != fileSize {
+        return fmt.Errorf("compressed transfer incomplete, %d of %d bytes received - %w",
+                          bytesWrote, fileSize, ErrTransferAborted)
+    }
+
+    // Flush the received data to disk so it survives a crash once the file is renamed
+    err = file.Sync()
+    if err != nil {
+        return wrapError("error syncing received file", err)
+    }
+
+    return nil
+}
+
+
+// Handle reading data from the passed in file descriptor, compressing it with gzip
+// as it is written to the socket.
+//
+// @Parameters
+// - connection:  The active TCP socket connection to transmit data
+// - file:  A pointer to the open file descriptor
+// - transferBuffer:  The buffer used to store file data that is transferred
+// - tracker:  Tracks the uncompressed bytes sent, nil when untracked
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func FileToSocketCompress(connection net.Conn, file *os.File,
+                          transferBuffer []byte, tracker *Tracker) error {
+    // Close the file on local exit
+    defer file.Close()
+
+    return compressToSocket(connection, tracker.Reader(file), transferBuffer)
+}
+
+
+// Compresses the data of the reader with gzip as it is written to the socket.
+//
+// @Parameters
+// - connection:  The active TCP socket connection to transmit data
+// - reader:  The reader of the data to be sent
+// - transferBuffer:  The buffer used to store data that is transferred
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func compressToSocket(connection net.Conn, reader io.Reader, transferBuffer []byte) error {
+    // Favor speed over ratio so compression keeps up with the network
+    gzipWriter, err := gzip.NewWriterLevel(connection, gzip.BestSpeed)
+    if err != nil {
+        return err
+    }
+
+    // Transfer compressed data from the reader to connection
+    _, err = io.CopyBuffer(gzipWriter, reader, transferBuffer)
+    if err != nil {
+        gzipWriter.Close()
+        return wrapError("error sending compressed file", err)
+    }
+
+    // Flush the remaining compressed data and the gzip footer
+    return wrapError("error sending compressed file", gzipWriter.Close())
+}
+
+
 // Handle reading data from the passed in file descriptor and write to
-// the socket to client.
+// the socket to client. Plain TCP sockets, such as plaintext data connections
+// inside the VPC or over loopback, are handed the file in chunks through their
+// ReadFrom, which the kernel copies with sendfile where supported. TLS connections
+// copy through the transfer buffer.
 //
 // @Parameters
 // - connection:  The active TCP socket connection to transmit data
 // - file:  A pointer to the open file descriptor
 // - transferBuffer:  The buffer used to store file data that is transferred
+// - tracker:  Tracks the bytes sent, nil when untracked
 //
 // @Returns
 // - Error if it occurs, otherwise nil on success
 //
 func FileToSocketCopy(connection net.Conn, file *os.File,
-                      transferBuffer []byte) error {
+                      transferBuffer []byte, tracker *Tracker) error {
     // Close the file on local exit
     defer file.Close()
 
-    // Transfer data from open file to connection
-    _, err := io.CopyBuffer(connection, file, transferBuffer)
+    // If the connection is a plain TCP socket, let the kernel copy the file
+    if tcpConn, ok := connection.(*net.TCPConn); ok {
+        for {
+            // Limit each call so the progress is counted while the file is sent
+            bytesSent, err := tcpConn.ReadFrom(io.LimitReader(file, zeroCopyChunk))
+            tracker.Add(bytesSent)
+            if err != nil {
+                return wrapError("error sending file", err)
+            }
+
+            // If the end of the file was reached
+            if bytesSent == 0 {
+                return nil
+            }
+        }
+    }
+
+    // Transfer data from open file to connection, counting it when tracked
+    _, err := io.CopyBuffer(connection, tracker.Reader(file), transferBuffer)
     if err != nil {
-        return err
+        return wrapError("error sending file", err)
     }
 
     return nil
 }
 
 
-// Format the transfer reply in buffer the file path and size sent to the client.
+// Format the transfer reply in buffer the file path and size sent to the client, followed
+// by the transfer encoding when the file is sent encoded and the manifest digest the
+// receiver verifies the file against.
 //
 // @Parameters
 // - filePath:  The path to the file to be transfered
 // - fileSize:  The size of the file to be transfered
+// - encoding:  The encoding the file is sent with, EncodingNone if sent as is
+// - digest:  The manifest digest of the file, empty if the file is not verified
 // - buffer:  The buffer where the transfer reply is formatted
 // - prefix:  The prefix used on the message
 //
@@ -51,8 +301,8 @@ func FileToSocketCopy(connection net.Conn, file *os.File,
 // - Return the length of the formatted transfer reply
 // - Error if it occurs, otherwise nil on success
 //
-func FormatTransferReply(filePath string, fileSize int64, buffer *[]byte,
-                         prefix []byte) (int, error) {
+func FormatTransferReply(filePath string, fileSize int64, encoding string, digest string,
+                         buffer *[]byte, prefix []byte) (int, error) {
     byteFilePath := []byte(filePath)
     byteFileSize := []byte(strconv.FormatInt(fileSize, 10))
     // Grab the file name from the end of the path
@@ -67,11 +317,19 @@ func FormatTransferReply(filePath string, fileSize int64, buffer *[]byte,
     *buffer = append(prefix, fileName...)
     *buffer = append(*buffer, globals.COLON_DELIMITER...)
     *buffer = append(*buffer, byteFileSize...)
+    // If the file is sent encoded or verified, append the encoding after the size
+    if encoding != EncodingNone || digest != "" {
+        *buffer = append(*buffer, globals.COLON_DELIMITER...)
+        *buffer = append(*buffer, encoding...)
+    }
+    // If the file is verified, append the digest after the encoding
+    if digest != "" {
+        *buffer = append(*buffer, globals.COLON_DELIMITER...)
+        *buffer = append(*buffer, digest...)
+    }
     *buffer = append(*buffer, globals.TRANSFER_SUFFIX...)
-    // Calculate the len of the transfer reply message
-    sendLength := len(prefix) + len(fileName) + len(globals.COLON_DELIMITER) +
-                  len(byteFileSize) + len(globals.TRANSFER_SUFFIX)
-    return sendLength, nil
+
+    return len(*buffer), nil
 }
 
 
@@ -103,7 +361,72 @@ func GetAvailableListener() (net.Listener, int) {
 }
 
 
-// Parse file name:size from buffer data based on colon separator.
+// PeerListener only accepts connections from a single host, so a plaintext listener
+// inside the VPC is not handed a connection from another instance
+type PeerListener struct {
+    net.Listener
+    Host string  // IP address the connections must come from
+}
+
+// Accepts the next connection from the host, closing any from other addresses.
+//
+// @Returns
+// - The accepted connection
+// - Error if the listener fails or is closed, otherwise nil on success
+//
+func (listener *PeerListener) Accept() (net.Conn, error) {
+    for {
+        connection, err := listener.Listener.Accept()
+        if err != nil {
+            return nil, err
+        }
+
+        host, _, err := net.SplitHostPort(connection.RemoteAddr().String())
+        // If the connection came from the expected host
+        if err == nil && host == listener.Host {
+            return connection, nil
+        }
+
+        connection.Close()
+    }
+}
+
+
+// Checks whether the address is inside the VPC or loopback, meaning the peer is reached
+// within the VPC or on the same host rather than over the internet or a peered network.
+//
+// @Parameters
+// - addr:  The IP address of the peer, with or without a port
+// - vpcCidrs:  The CIDR blocks of the VPC, empty when not running in one
+//
+// @Returns
+// - true if the address is inside the VPC or loopback, otherwise false
+//
+func PrivateAddr(addr string, vpcCidrs []netip.Prefix) bool {
+    host, _, err := net.SplitHostPort(addr)
+    // If the address has no port
+    if err != nil {
+        host = addr
+    }
+
+    ip, err := netip.ParseAddr(host)
+    if err != nil {
+        return false
+    }
+    ip = ip.Unmap()
+
+    // If the peer is on the same host
+    if ip.IsLoopback() {
+        return true
+    }
+
+    return slices.ContainsFunc(vpcCidrs, func(cidr netip.Prefix) bool {
+        return cidr.Contains(ip)
+    })
+}
+
+
+// Parse file name:size[:encoding[:digest]] from buffer data based on colon separator.
 //
 // @Parameters
 // - buffer:  The data read from socket buffer to be parsed
@@ -113,30 +436,82 @@ func GetAvailableListener() (net.Listener, int) {
 // @Returns
 // - The byte slice with the file name
 // - A integer file size
+// - The transfer encoding, EncodingNone if the file is sent as is
+// - The manifest digest of the file, empty if the file is not verified
 // - Error if it occurs, otherwise nil on success
 //
-func GetFileInfo(buffer []byte, prefix []byte, bytesRead int) ([]byte, int64, error) {
+func GetFileInfo(buffer []byte, prefix []byte,
+                 bytesRead int) ([]byte, int64, string, string, error) {
+    // If the read length is outside the buffer or too short to hold the delimiters
+    if bytesRead > len(buffer) || bytesRead < len(prefix) + len(globals.TRANSFER_SUFFIX) {
+        return []byte(""), 0, EncodingNone, "", fmt.Errorf("invalid message length %d - %w",
+                                                           bytesRead, ErrMalformedMessage)
+    }
+
     // Trim the delimiters around the file info
-    buffer = buffer[len(prefix):bytesRead-1]
+    buffer = buffer[len(prefix):bytesRead-len(globals.TRANSFER_SUFFIX)]
 
     // Get the position of the colon delimiter
     colonPos := bytes.IndexByte(buffer, ':')
     // If the colon separator is missing
     if colonPos == -1 {
-        return []byte(""), 0, fmt.Errorf("invalid message structure, colon missing")
+        return []byte(""), 0, EncodingNone, "", fmt.Errorf("invalid message structure, " +
+                                                           "colon missing - %w",
+                                                           ErrMalformedMessage)
     }
 
     // Extract the file path and size
     fileName := buffer[:colonPos]
-    fileSizeStr := string(buffer[colonPos+1:])
+    // If the name could place the file outside of the directory it is stored in
+    if !validFileName(fileName) {
+        return fileName, 0, EncodingNone, "", fmt.Errorf("invalid file name %q - %w",
+                                                         fileName, ErrMalformedMessage)
+    }
+
+    fields := strings.SplitN(string(buffer[colonPos+1:]), ":", 3)
+    encoding := EncodingNone
+    digest := ""
+
+    // If an encoding follows the size, split it off
+    if len(fields) > 1 {
+        encoding = fields[1]
+    }
+    // If a digest follows the encoding, split it off
+    if len(fields) > 2 {
+        digest = fields[2]
+    }
 
     // Convert the size string to an 64 bit integr
-    fileSize, err := strconv.ParseInt(fileSizeStr, 10, 64)
+    fileSize, err := strconv.ParseInt(fields[0], 10, 64)
     if err != nil {
-        return fileName, fileSize, err
+        return fileName, fileSize, encoding, digest, fmt.Errorf("invalid file size - %w - %w",
+                                                                ErrMalformedMessage, err)
     }
 
-    return fileName, fileSize, nil
+    // If the size is negative
+    if fileSize < 0 {
+        return fileName, fileSize, encoding, digest, fmt.Errorf("invalid file size %d - %w",
+                                                                fileSize, ErrMalformedMessage)
+    }
+
+    return fileName, fileSize, encoding, digest, nil
+}
+
+
+// Checks whether the file name of a transfer reply is a plain name, since the senders
+// only ever send the base name and anything else could write outside the store path.
+//
+// @Parameters
+// - fileName:  The file name parsed from the transfer reply
+//
+// @Returns
+// - true/false depending on whether the name is safe to store the file under
+//
+func validFileName(fileName []byte) bool {
+    name := string(fileName)
+
+    return name != "" && name != "." && name != ".." &&
+           !strings.ContainsAny(name, "/\\\x00")
 }
 
 
@@ -211,36 +586,49 @@ func GetOptimalBufferSize(fileSize int64) int {
 // - storePath:  The directory where read socket data will be stored as files
 // - fileName:  The name of the file to store
 // - fileSize:  The size of the to be stored on disk from read socket data
+// - encoding:  The encoding the file is sent with, EncodingNone if sent as is
+// - digest:  The manifest digest the file is verified against, empty to skip verifying
 //
 // @Returns
 // - Error if it occurs, otherwise nil on success
 //
 func HandleTransferRecv(connection net.Conn, storePath string, fileName string,
-                        fileSize int64) (string, error) {
-    var file *os.File
-    var err error
+                        fileSize int64, encoding string, digest string) (string, error) {
+    // If the encoding is not one the receiver can decode
+    if encoding != EncodingNone && encoding != EncodingGzip {
+        return "", fmt.Errorf("%w - %q", ErrUnsupportedEncoding, encoding)
+    }
+
     //  Create buffer to optimal size based on expected file size
     transferBuffer := make([]byte, GetOptimalBufferSize(fileSize))
-    // Format the path where the file will be stored
-    filePath := storePath + "/" + fileName
 
-    for {
-        // Open the file for writing
-        file, err = os.OpenFile(filePath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
-        // If a file with the same name already exists
-        if os.IsExist(err) {
-            // Add random characters to beginning of name, then try again
-            filePath = storePath + "/" + data.RandStringBytes(8) + "_" + fileName
-            continue
-        } else if err != nil {
-            return "", err
-        }
+    // Create the file the received data is stored in
+    file, filePath, err := createRecvFile(storePath, fileName)
+    if err != nil {
+        return "", err
+    }
+
+    // If the file is compressed, decompress it as it is written to the file path
+    if encoding == EncodingGzip {
+        err = DecompressToFileCopy(file, connection, transferBuffer, fileSize)
+    } else {
+        // Read data from the socket and write to the file path
+        err = SocketToFileCopy(file, connection, transferBuffer, fileSize, nil)
+    }
+    if err != nil {
+        // Remove the partially received file so it is never processed
+        os.Remove(filePath + disk.PartSuffix)
+        return "", err
+    }
 
-        break
+    // Ensure the received file matches the manifest before it is processed
+    err = verifyRecvFile(filePath, digest)
+    if err != nil {
+        return "", err
     }
 
-    // Read data from the socket and write to the file path
-    err = SocketToFileCopy(file, connection, transferBuffer, fileSize)
+    // Move the complete file to its final path
+    err = commitRecvFile(filePath)
     if err != nil {
         return "", err
     }
@@ -260,10 +648,47 @@ func HandleTransferRecv(connection net.Conn, storePath string, fileName string,
 // - Error if it occurs, otherwise nil on success
 //
 func ReadHandler(connection net.Conn, buffer *[]byte) (int, error) {
+    // If reads are limited, a hung peer fails the read instead of blocking forever,
+    // otherwise any deadline set by the caller is left in place
+    if ReadTimeout > 0 {
+        return ReadHandlerTimeout(connection, buffer, ReadTimeout)
+    }
+
     // Perform read operation via passed in connection
     bytesRead, err := connection.Read(*buffer)
     if err != nil {
-        return bytesRead, fmt.Errorf("error reading from connection - %w", err)
+        return bytesRead, wrapError("error reading from connection", err)
+    }
+
+    return bytesRead, nil
+}
+
+
+// Handler for network socket read operations that wait on the peer for a duration
+// other than the read timeout, such as a control loop idling between messages.
+//
+// @Parameters
+// - connection:  The network connection where data will be read from
+// - buffer:  The buffer where the read data will be stored
+// - timeout:  The max time to wait for data, 0 leaves any deadline already set
+//
+// @Returns
+// - The number of bytes read into the buffer
+// - Error if it occurs, wrapping ErrTimeout if the deadline passed, otherwise nil
+//
+func ReadHandlerTimeout(connection net.Conn, buffer *[]byte,
+                        timeout time.Duration) (int, error) {
+    // If the wait is limited, set when the read gives up and clear it once done so
+    // file data streamed over the connection after the message is not cut off
+    if timeout > 0 {
+        connection.SetReadDeadline(time.Now().Add(timeout))
+        defer connection.SetReadDeadline(time.Time{})
+    }
+
+    // Perform read operation via passed in connection
+    bytesRead, err := connection.Read(*buffer)
+    if err != nil {
+        return bytesRead, wrapError("error reading from connection", err)
     }
 
     return bytesRead, nil
@@ -291,14 +716,41 @@ func ReceiveFile(connection net.Conn, buffer []byte, storePath string,
         return "", err
     }
 
+    return ReceiveFileReply(connection, buffer, bytesRead, storePath, prefix)
+}
+
+
+// Parses the file name and size from an already read start transfer message,
+// then receives the file. Used when the caller has to inspect the message first.
+//
+// @Parameters
+// - connection:  Active socket connection for receiving data
+// - buffer:  The buffer storing the read transfer reply
+// - bytesRead:  The number of bytes of the transfer reply in the buffer
+// - storePath:  The path where the received file will be stored
+// - prefix:  The expected prefix for the transfer reply
+//
+// @Returns
+// - The formatted file path with the received file name
+// - Error if it occurs, otherwise nil on success
+//
+func ReceiveFileReply(connection net.Conn, buffer []byte, bytesRead int, storePath string,
+                      prefix []byte) (string, error) {
+    // If the read length is outside the buffer
+    if bytesRead < 0 || bytesRead > len(buffer) {
+        return "", fmt.Errorf("invalid transfer reply length %d - %w", bytesRead,
+                              ErrMalformedMessage)
+    }
+
     // If read data does not start with delimiter or end with closed bracket
-    if !bytes.HasPrefix(buffer, prefix) ||
+    if !bytes.HasPrefix(buffer[:bytesRead], prefix) ||
     !bytes.HasSuffix(buffer[:bytesRead], globals.TRANSFER_SUFFIX) {
-        return "", fmt.Errorf("improper prefix or suffix in transfer reply")
+        return "", fmt.Errorf("improper prefix or suffix in transfer reply - %w",
+                              ErrMalformedMessage)
     }
 
-    // Extract the file name and size from the initial transfer message
-    fileName, fileSize, err := GetFileInfo(buffer, prefix, bytesRead)
+    // Extract the file name, size, encoding, and digest from the initial transfer message
+    fileName, fileSize, encoding, digest, err := GetFileInfo(buffer, prefix, bytesRead)
     if err != nil {
         return "", err
     }
@@ -312,7 +764,7 @@ func ReceiveFile(connection net.Conn, buffer []byte, storePath string,
 
     // Receive the file from server
     filePath, err := HandleTransferRecv(connection, storePath,
-                                        string(fileName), fileSize)
+                                        string(fileName), fileSize, encoding, digest)
     if err != nil {
         return "", err
     }
@@ -329,12 +781,13 @@ func ReceiveFile(connection net.Conn, buffer []byte, storePath string,
 // - connection:  Active socket connection for reading data to be stored and processed
 // - transferBuffer:  Buffer allocated for file transfer based on file size
 // - fileSize:  The size of the file to be received
+// - tracker:  Tracks the bytes received, nil when untracked
 //
 // @Returns
 // - Error if it occurs, otherwise nil on success
 //
 func SocketToFileCopy(file *os.File, connection net.Conn,
-                        transferBuffer []byte, fileSize int64) error {
+                        transferBuffer []byte, fileSize int64, tracker *Tracker) error {
     // Close file on local exit
     defer file.Close()
 
@@ -342,9 +795,21 @@ func SocketToFileCopy(file *os.File, connection net.Conn,
     limitedReader := &io.LimitedReader{R: connection, N: fileSize}
 
     // Transfer data from connection to open file
-    _, err := io.CopyBuffer(file, limitedReader, transferBuffer)
+    bytesWrote, err := io.CopyBuffer(file, tracker.Reader(limitedReader), transferBuffer)
     if err != nil {
-        return err
+        return wrapError("error receiving file", err)
+    }
+
+    // If the connection closed before the whole file was received
+    if bytesWrote != fileSize {
+        return fmt.Errorf("transfer incomplete, %d of %d bytes received - %w", bytesWrote,
+                          fileSize, ErrTransferAborted)
+    }
+
+    // Flush the received data to disk so it survives a crash once the file is renamed
+    err = file.Sync()
+    if err != nil {
+        return wrapError("error syncing received file", err)
     }
 
     return nil
@@ -359,11 +824,14 @@ func SocketToFileCopy(file *os.File, connection net.Conn,
 // - connection:  The network connection where the file will be sent
 // - filePath:  The path to the file to be transfered
 // - fileSize:  The size of the file to be transfered
+// - encoding:  The encoding the file is sent with, EncodingNone to send as is
+// - tracker:  Tracks the progress of the transfer, nil when untracked
 //
 // @Returns
 // - Error if it occurs, otherwise nil on success
 //
-func TransferFile(connection net.Conn, filePath string, fileSize int64) error {
+func TransferFile(connection net.Conn, filePath string, fileSize int64,
+                  encoding string, tracker *Tracker) error {
     // Create buffer to optimal size based on expected file size
     transferBuffer := make([]byte, GetOptimalBufferSize(fileSize))
 
@@ -373,8 +841,13 @@ func TransferFile(connection net.Conn, filePath string, fileSize int64) error {
         return err
     }
 
-    // Read the file chunk by chunk and send to client
-    err = FileToSocketCopy(connection, file, transferBuffer)
+    // If the file is to be compressed, compress it chunk by chunk as it is sent
+    if encoding == EncodingGzip {
+        err = FileToSocketCompress(connection, file, transferBuffer, tracker)
+    } else {
+        // Read the file chunk by chunk and send to client
+        err = FileToSocketCopy(connection, file, transferBuffer, tracker)
+    }
     if err != nil {
         return err
     }
@@ -383,6 +856,78 @@ func TransferFile(connection net.Conn, filePath string, fileSize int64) error {
 }
 
 
+// Sends only the section of the file, such as a line range of a wordlist assigned to the
+// client, as if it were a file of its own.
+//
+// @Parameters
+// - connection:  The network connection where the section will be sent
+// - filePath:  The path to the file the section is read from
+// - section:  The offset and length of the section in the file
+// - encoding:  The encoding the section is sent with, EncodingNone to send as is
+// - tracker:  Tracks the progress of the transfer, nil when untracked
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func TransferSection(connection net.Conn, filePath string, section Range, encoding string,
+                     tracker *Tracker) error {
+    // Create buffer to optimal size based on expected section size
+    transferBuffer := make([]byte, GetOptimalBufferSize(section.Length))
+
+    // Open the file
+    file, err := os.Open(filePath)
+    if err != nil {
+        return err
+    }
+    // Close the file on local exit
+    defer file.Close()
+
+    reader := tracker.Reader(io.NewSectionReader(file, section.Offset, section.Length))
+    // If the section is to be compressed, compress it chunk by chunk as it is sent
+    if encoding == EncodingGzip {
+        return compressToSocket(connection, reader, transferBuffer)
+    }
+
+    // Read the section chunk by chunk and send to client
+    _, err = io.CopyBuffer(connection, reader, transferBuffer)
+    return wrapError("error sending file section", err)
+}
+
+
+// Sets up a reader of the file sent over the socket, decompressing it if sent compressed,
+// so the file can be consumed as it arrives without being stored on disk.
+//
+// @Parameters
+// - connection:  Active socket connection the file is read from
+// - fileSize:  The size of the file to be received
+// - encoding:  The encoding the file is sent with, EncodingNone if sent as is
+//
+// @Returns
+// - The reader of the file data, closing it does not close the connection
+// - Error if it occurs, otherwise nil on success
+//
+func TransferReader(connection net.Conn, fileSize int64, encoding string) (io.ReadCloser,
+                                                                           error) {
+    switch encoding {
+    case EncodingNone:
+        // Set up limited reader to prevent connection from hanging after the file
+        return io.NopCloser(&io.LimitedReader{R: connection, N: fileSize}), nil
+    case EncodingGzip:
+        // Set up the gzip reader on the compressed stream
+        gzipReader, err := gzip.NewReader(connection)
+        if err != nil {
+            return nil, wrapError("error reading compressed stream", err)
+        }
+        // Stop at the end of the stream instead of waiting on the connection for another
+        gzipReader.Multistream(false)
+
+        return gzipReader, nil
+    default:
+        return nil, fmt.Errorf("%w - %q", ErrUnsupportedEncoding, encoding)
+    }
+}
+
+
 // Gets the file size, formats and sends the transfer reply, and calls transfer method.
 //
 // @Parameters
@@ -406,7 +951,8 @@ func UploadFile(connection net.Conn, buffer []byte, filePath string,
     fileSize := fileInfo.Size()
 
     // Format the transfer reply
-    sendLength, err := FormatTransferReply(filePath, fileSize, &buffer, prefix)
+    sendLength, err := FormatTransferReply(filePath, fileSize, EncodingNone, "", &buffer,
+                                           prefix)
     if err != nil {
         return err
     }
@@ -425,11 +971,12 @@ func UploadFile(connection net.Conn, buffer []byte, filePath string,
 
     // If the transfer initiated message format is invalid
     if !bytes.Contains(buffer[:bytesRead], globals.TRANSFER_INITIATED_MARKER) {
-        return fmt.Errorf("transfer initiated message format invalid")
+        return fmt.Errorf("transfer initiated message format invalid - %w",
+                          ErrMalformedMessage)
     }
 
     // Transfer the file to client
-    err = TransferFile(connection, filePath, fileSize)
+    err = TransferFile(connection, filePath, fileSize, EncodingNone, nil)
     if err != nil {
         return err
     }
@@ -451,10 +998,16 @@ func UploadFile(connection net.Conn, buffer []byte, filePath string,
 //
 func WriteHandler(connection net.Conn, buffer []byte,
                   writeBytes int) (int, error) {
+    // If writes are limited, a peer that stopped reading fails the write
+    if WriteTimeout > 0 {
+        connection.SetWriteDeadline(time.Now().Add(WriteTimeout))
+        defer connection.SetWriteDeadline(time.Time{})
+    }
+
     // Perform write operation via passed in connection
     bytesWrote, err := connection.Write(buffer[:writeBytes])
     if err != nil {
-        return 0, err
+        return 0, wrapError("error writing to connection", err)
     }
 
     return bytesWrote, nil
@@ -1,15 +1,21 @@
 package netio_test
 
 import (
+	"bytes"
+	"errors"
 	"io"
 	"net"
+	"net/netip"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/ngimb64/Kloud-Kraken/internal/globals"
 	"github.com/ngimb64/Kloud-Kraken/pkg/data"
 	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
+	"github.com/ngimb64/Kloud-Kraken/pkg/manifest"
 	"github.com/ngimb64/Kloud-Kraken/pkg/netio"
 	"github.com/stretchr/testify/assert"
 )
@@ -93,7 +99,7 @@ func TestFileToSocketCopy(t *testing.T) {
     transferBuffer := make([]byte, 64 * globals.KB)
 
     // Transfer file directly through the socket
-    err = netio.FileToSocketCopy(serverConn, inFile, transferBuffer)
+    err = netio.FileToSocketCopy(serverConn, inFile, transferBuffer, nil)
     // Ensure the error is nil meaning successful operation
     assert.Equal(nil, err)
 
@@ -125,6 +131,105 @@ func TestFileToSocketCopy(t *testing.T) {
 }
 
 
+func TestFileToSocketCopyOffset(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    listener, _ := netio.GetAvailableListener()
+    // Close listener on local exit
+    defer listener.Close()
+
+    received := make(chan []byte)
+
+    go func() {
+        clientConn, err := listener.Accept()
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        // Close connection on local exit
+        defer clientConn.Close()
+
+        contents, _ := io.ReadAll(clientConn)
+        received <- contents
+    } ()
+
+    serverConn, err := net.Dial("tcp", listener.Addr().String())
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    filePath := t.TempDir() + "/wordlist.txt"
+    err = os.WriteFile(filePath, []byte("alpha\nbravo\ncharlie\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    file, err := os.Open(filePath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    _, err = file.Seek(6, io.SeekStart)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure only the data after the file offset is sent over the plain TCP socket
+    err = netio.FileToSocketCopy(serverConn, file, make([]byte, 4 * globals.KB), nil)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    serverConn.Close()
+
+    assert.Equal("bravo\ncharlie\n", string(<-received))
+}
+
+
+func TestFileToSocketCopyZeroCopy(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    listener, _ := netio.GetAvailableListener()
+    // Close listener on local exit
+    defer listener.Close()
+
+    received := make(chan int)
+
+    go func() {
+        clientConn, err := listener.Accept()
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        // Close connection on local exit
+        defer clientConn.Close()
+
+        bytesRead, _ := io.Copy(io.Discard, clientConn)
+        received <- int(bytesRead)
+    } ()
+
+    serverConn, err := net.Dial("tcp", listener.Addr().String())
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    fileSize := 9 * globals.MB
+    filePath := t.TempDir() + "/wordlist.txt"
+    err = os.WriteFile(filePath, bytes.Repeat([]byte("a"), fileSize), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    file, err := os.Open(filePath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    var reports []int64
+    tracker := netio.NewTracker(int64(fileSize), 0, func(progress netio.Progress) {
+        reports = append(reports, progress.Done)
+    })
+
+    // Ensure the file is handed to the kernel in chunks with the progress counted
+    // between them, rather than copied through the 4KB transfer buffer
+    err = netio.FileToSocketCopy(serverConn, file, make([]byte, 4 * globals.KB), tracker)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    serverConn.Close()
+
+    assert.Equal(fileSize, <-received)
+    assert.Equal([]int64{4 * globals.MB, 8 * globals.MB, int64(fileSize)}, reports)
+}
+
+
 func TestFormatTransferReply(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -141,8 +246,8 @@ func TestFormatTransferReply(t *testing.T) {
     assert.Equal(nil, err)
 
     // Format the transfer reply in passed in buffer
-    sendLength, err := netio.FormatTransferReply(filePath, fileSize, &buffer,
-                                                 globals.START_TRANSFER_PREFIX)
+    sendLength, err := netio.FormatTransferReply(filePath, fileSize, netio.EncodingNone, "",
+                                                 &buffer, globals.START_TRANSFER_PREFIX)
     // Ensure the error is nil meaning successful operation
     assert.Equal(nil, err)
 
@@ -190,8 +295,8 @@ func TestGetFileInfo(t *testing.T) {
     buffer := make([]byte, 256)
 
     // Format the transfer reply in passed in buffer
-    sendLength, err := netio.FormatTransferReply(filePath, fileSize, &buffer,
-                                                 globals.START_TRANSFER_PREFIX)
+    sendLength, err := netio.FormatTransferReply(filePath, fileSize, netio.EncodingNone, "",
+                                                 &buffer, globals.START_TRANSFER_PREFIX)
     // Ensure the error is nil meaning successful operation
     assert.Equal(nil, err)
 
@@ -199,13 +304,99 @@ func TestGetFileInfo(t *testing.T) {
     assert.Equal(sendLength, len(globals.START_TRANSFER_PREFIX)+(len(filePath)-6)+1+len(byteFileSize)+1)
 
     // Parse the file name and size from the transfer reply message in buffer
-    resFileName, resFileSize, err := netio.GetFileInfo(buffer, globals.START_TRANSFER_PREFIX, sendLength)
+    resFileName, resFileSize, resEncoding, resDigest, err := netio.GetFileInfo(buffer,
+                                                                    globals.START_TRANSFER_PREFIX,
+                                                                    sendLength)
     // Ensure the error is nil meaning successful operation
     assert.Equal(nil, err)
     // Ensure the parsed file name is correct
     assert.Equal([]byte("path.txt"), resFileName)
     // Ensure the parsed file size is correct
     assert.Equal(fileSize, resFileSize)
+    // Ensure no encoding or digest is parsed when the file is sent as is
+    assert.Equal(netio.EncodingNone, resEncoding)
+    assert.Equal("", resDigest)
+
+    // Format the transfer reply with the file sent compressed
+    sendLength, err = netio.FormatTransferReply(filePath, fileSize, netio.EncodingGzip, "",
+                                                &buffer, globals.START_TRANSFER_PREFIX)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Parse the file name, size, and encoding from the compressed transfer reply
+    resFileName, resFileSize, resEncoding, _, err = netio.GetFileInfo(buffer,
+                                                                   globals.START_TRANSFER_PREFIX,
+                                                                   sendLength)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the parsed file name, size, and encoding are correct
+    assert.Equal([]byte("path.txt"), resFileName)
+    assert.Equal(fileSize, resFileSize)
+    assert.Equal(netio.EncodingGzip, resEncoding)
+
+    digest := strings.Repeat("a", 64) + ":42"
+    // Format the transfer reply with the manifest digest of a file sent as is
+    sendLength, err = netio.FormatTransferReply(filePath, fileSize, netio.EncodingNone, digest,
+                                                &buffer, globals.START_TRANSFER_PREFIX)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Parse the file info along with the digest following the empty encoding
+    _, resFileSize, resEncoding, resDigest, err = netio.GetFileInfo(buffer,
+                                                                   globals.START_TRANSFER_PREFIX,
+                                                                   sendLength)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the parsed size, encoding, and digest are correct
+    assert.Equal(fileSize, resFileSize)
+    assert.Equal(netio.EncodingNone, resEncoding)
+    assert.Equal(digest, resDigest)
+
+    falacies := []string{"<START_TRANSFER:../../etc/cron.d/x:10>", "<START_TRANSFER:a/b:10>",
+                         "<START_TRANSFER:..:10>", "<START_TRANSFER::10>",
+                         "<START_TRANSFER:a\\b:10>", "<START_TRANSFER:a:-10>",
+                         "<START_TRANSFER:a:ten>", "<START_TRANSFER:a>", "<START_TRANSFER:"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        _, _, _, _, err = netio.GetFileInfo([]byte(falacy), globals.START_TRANSFER_PREFIX,
+                                            len(falacy))
+        // Ensure the message is rejected as malformed
+        assert.ErrorIs(err, netio.ErrMalformedMessage, falacy)
+    }
+
+    // Ensure read lengths outside the buffer are rejected instead of slicing past it
+    _, _, _, _, err = netio.GetFileInfo(buffer[:8], globals.START_TRANSFER_PREFIX, sendLength)
+    assert.ErrorIs(err, netio.ErrMalformedMessage)
+    _, _, _, _, err = netio.GetFileInfo(buffer, globals.START_TRANSFER_PREFIX, 0)
+    assert.ErrorIs(err, netio.ErrMalformedMessage)
+}
+
+
+func FuzzGetFileInfo(f *testing.F) {
+    f.Add([]byte("<START_TRANSFER:path.txt:13631488>"), 34)
+    f.Add([]byte("<START_TRANSFER:path.txt:13631488:gzip:" + strings.Repeat("a", 64) +
+                 ":42>"), 109)
+    f.Add([]byte("<START_TRANSFER:../path.txt:1>"), 31)
+    f.Add([]byte("<START_TRANSFER:"), 1)
+
+    f.Fuzz(func(t *testing.T, buffer []byte, bytesRead int) {
+        fileName, fileSize, _, _, err := netio.GetFileInfo(buffer,
+                                                           globals.START_TRANSFER_PREFIX,
+                                                           bytesRead)
+        // If the message was rejected, it must be as malformed
+        if err != nil {
+            if !errors.Is(err, netio.ErrMalformedMessage) {
+                t.Fatalf("error is not a malformed message - %v", err)
+            }
+            return
+        }
+
+        // Ensure accepted messages can only store a plain file of a real size
+        if fileSize < 0 || len(fileName) == 0 || bytes.ContainsAny(fileName, "/\\\x00") ||
+        string(fileName) == "." || string(fileName) == ".." {
+            t.Fatalf("accepted file name %q with size %d", fileName, fileSize)
+        }
+    })
 }
 
 
@@ -304,12 +495,17 @@ func TestHandleTransferRecv(t *testing.T) {
 
         // Read data from the socket and write to the file path
         outFilePath, err := netio.HandleTransferRecv(clientConn, "./", "output_test.txt",
-                                                     int64(20 * globals.MB))
+                                                     int64(20 * globals.MB),
+                                                     netio.EncodingNone, "")
         // Ensure the error is nil meaning successful operation
         assert.Equal(nil, err)
         // Add the created file to slice for later removal
         testFiles = append(testFiles, outFilePath)
 
+        // Ensure the part file was renamed to the final path once complete
+        _, err = os.Stat(outFilePath + disk.PartSuffix)
+        assert.True(os.IsNotExist(err))
+
         // Send complete signal via channel
         isComplete <- true
     } ()
@@ -350,7 +546,7 @@ func TestHandleTransferRecv(t *testing.T) {
     transferBuffer := make([]byte, 64 * globals.KB)
 
     // Transfer the file to the client
-    err = netio.FileToSocketCopy(serverConn, inFile, transferBuffer)
+    err = netio.FileToSocketCopy(serverConn, inFile, transferBuffer, nil)
     // Ensure the error is nil meaning successful operation
     assert.Equal(nil, err)
 
@@ -379,6 +575,84 @@ func TestHandleTransferRecv(t *testing.T) {
 }
 
 
+func TestPeerListener(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    peerListener := &netio.PeerListener{Listener: listener, Host: "127.0.0.1"}
+    go func() {
+        conn, err := net.Dial("tcp", listener.Addr().String())
+        if err == nil {
+            conn.Close()
+        }
+    } ()
+
+    // Ensure a connection from the expected host is accepted
+    conn, err := peerListener.Accept()
+    assert.Equal(nil, err)
+    conn.Close()
+
+    // Ensure a connection from any other host is closed without being returned
+    peerListener.Host = "10.0.0.9"
+    dropped := make(chan error, 1)
+    go func() {
+        conn, err := net.Dial("tcp", listener.Addr().String())
+        if err != nil {
+            dropped <- err
+            return
+        }
+        // Close the connection on local exit
+        defer conn.Close()
+
+        _, err = conn.Read(make([]byte, 1))
+        dropped <- err
+        listener.Close()
+    } ()
+
+    _, err = peerListener.Accept()
+    assert.NotEqual(nil, err)
+    assert.ErrorIs(<-dropped, io.EOF)
+}
+
+
+func TestPrivateAddr(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    vpcCidrs := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/16"),
+                               netip.MustParsePrefix("172.31.0.0/16")}
+    tests := []struct {
+        addr     string
+        expected bool
+    } {
+        {"10.0.1.25:6969", true},
+        {"172.31.0.4", true},
+        {"[::ffff:10.0.8.1]:6969", true},
+        {"127.0.0.1:5000", true},
+        {"[::1]:5000", true},
+        {"10.1.0.4:6969", false},
+        {"192.168.1.10:443", false},
+        {"54.12.8.1:6969", false},
+        {"8.8.8.8", false},
+        {"not-an-ip:6969", false},
+    }
+
+    // Iterate through test case slice
+    for _, test := range tests {
+        // Ensure only peers inside the VPC or on the host are private
+        assert.Equal(test.expected, netio.PrivateAddr(test.addr, vpcCidrs), test.addr)
+    }
+
+    // Ensure only loopback peers are private outside of a VPC
+    assert.True(netio.PrivateAddr("127.0.0.1:5000", nil))
+    assert.False(netio.PrivateAddr("10.0.1.25:6969", nil))
+}
+
+
 func TestReadHandler(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -457,6 +731,45 @@ func TestReadHandler(t *testing.T) {
 }
 
 
+// discardConn is a connection of a peer that accepts every write and sends nothing
+type discardConn struct {
+    net.Conn
+}
+
+func (conn discardConn) Read(buffer []byte) (int, error) {
+    return 0, io.EOF
+}
+
+func (conn discardConn) Write(buffer []byte) (int, error) {
+    return len(buffer), nil
+}
+
+
+func FuzzReceiveFileReply(f *testing.F) {
+    f.Add([]byte("<START_TRANSFER:path.txt:0>"), 27)
+    f.Add([]byte("<START_TRANSFER:path.txt:10:gzip>"), 33)
+    f.Add([]byte("<START_TRANSFER:../../path.txt:10>"), 34)
+    f.Add([]byte("<START_TRANSFER:path.txt:10>"), 300)
+    f.Add([]byte("<LOOT_FLUSH:10>"), -1)
+
+    f.Fuzz(func(t *testing.T, buffer []byte, bytesRead int) {
+        storePath := t.TempDir()
+
+        filePath, err := netio.ReceiveFileReply(discardConn{}, buffer, bytesRead, storePath,
+                                                globals.START_TRANSFER_PREFIX)
+        // If the reply was rejected or the peer closed before sending the file
+        if err != nil {
+            return
+        }
+
+        // Ensure a received file is always stored directly in the store path
+        if filepath.Dir(filePath) != storePath {
+            t.Fatalf("file stored outside of the store path - %s", filePath)
+        }
+    })
+}
+
+
 func TestSocketToFileCopy(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -488,7 +801,7 @@ func TestSocketToFileCopy(t *testing.T) {
 
         // Transfer received data from connection to file
         err = netio.SocketToFileCopy(outFile, clientConn, receiveBuffer,
-                                     int64(20 * globals.MB))
+                                     int64(20 * globals.MB), nil)
         // Ensure the error is nil meaning successful operation
         assert.Equal(nil, err)
 
@@ -601,7 +914,7 @@ func TestTransferFile(t *testing.T) {
 
         // Read data from the socket and write to the file path
         err = netio.SocketToFileCopy(outFile, clientConn, transferBuffer,
-                                     20 * globals.MB)
+                                     20 * globals.MB, nil)
         // Ensure the error is nil meaning successful operation
         assert.Equal(nil, err)
 
@@ -638,7 +951,7 @@ func TestTransferFile(t *testing.T) {
     inFile.Close()
 
     // Transfer the file to the client
-    err = netio.TransferFile(serverConn, inFilePath, int64(bytesWrote))
+    err = netio.TransferFile(serverConn, inFilePath, int64(bytesWrote), netio.EncodingNone, nil)
     // Ensure the error is nil meaning successful operation
     assert.Equal(nil, err)
 
@@ -664,6 +977,216 @@ func TestTransferFile(t *testing.T) {
 }
 
 
+func TestTransferFileCompressed(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Get available listener and its corresponding port
+    listener, listenerPort := netio.GetAvailableListener()
+    // Close listener on local exit
+    defer listener.Close()
+
+    // Make wordlist like data that compresses well
+    inData := bytes.Repeat([]byte("password123\nletmein\nqwerty\n"), 512 * globals.KB)
+    outFilePath := ""
+    isComplete := make(chan bool)
+
+    // Digest the data so the decompressed file is verified against its manifest
+    digester := manifest.NewDigester()
+    digester.Write(inData)
+
+    go func() {
+        // Wait for an incoming connection
+        clientConn, err := listener.Accept()
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        // Close connection on local exit
+        defer clientConn.Close()
+
+        // Receive and decompress the file
+        outFilePath, err = netio.HandleTransferRecv(clientConn, "./", "output_test.txt",
+                                                    int64(len(inData)), netio.EncodingGzip,
+                                                    digester.Entry("").Digest())
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+
+        // Send complete signal via channel
+        isComplete <- true
+    } ()
+
+    // Make a connection to the listener
+    serverConn, err := net.Dial("tcp", ":" + strconv.Itoa(listenerPort))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Close connection on local exit
+    defer serverConn.Close()
+
+    inFilePath := "input_compressed_test.txt"
+    // Write the input file to be transferred
+    err = os.WriteFile(inFilePath, inData, 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Transfer the file compressed
+    err = netio.TransferFile(serverConn, inFilePath, int64(len(inData)), netio.EncodingGzip,
+                             nil)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Wait for the channel to send complete signal
+    <-isComplete
+
+    // Ensure the decompressed file matches the input
+    outData, err := os.ReadFile(outFilePath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(inData, outData)
+
+    // Iterate through list of test files and delete them
+    for _, file := range []string{inFilePath, outFilePath} {
+        err = os.Remove(file)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+    }
+}
+
+
+func TestTransferReader(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    inData := bytes.Repeat([]byte("password123\nletmein\nqwerty\n"), 64 * globals.KB)
+    inFilePath := "input_reader_test.txt"
+    // Write the input file to be transferred
+    err := os.WriteFile(inFilePath, inData, 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Delete the input file on local exit
+    defer os.Remove(inFilePath)
+
+    // Iterate through the encodings the file can be streamed with
+    for _, encoding := range []string{netio.EncodingNone, netio.EncodingGzip} {
+        // Get available listener and its corresponding port
+        listener, listenerPort := netio.GetAvailableListener()
+        outData := []byte{}
+        isComplete := make(chan bool)
+
+        go func() {
+            // Wait for an incoming connection
+            clientConn, err := listener.Accept()
+            // Ensure the error is nil meaning successful operation
+            assert.Equal(nil, err)
+            // Close connection on local exit
+            defer clientConn.Close()
+
+            // Set up the reader of the streamed file
+            reader, err := netio.TransferReader(clientConn, int64(len(inData)), encoding)
+            // Ensure the error is nil meaning successful operation
+            assert.Equal(nil, err)
+            // Close the reader on local exit
+            defer reader.Close()
+
+            // Read the streamed file as it arrives
+            outData, err = io.ReadAll(reader)
+            // Ensure the error is nil meaning successful operation
+            assert.Equal(nil, err)
+
+            // Send complete signal via channel
+            isComplete <- true
+        } ()
+
+        // Make a connection to the listener
+        serverConn, err := net.Dial("tcp", ":" + strconv.Itoa(listenerPort))
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+
+        // Transfer the file with the encoding
+        err = netio.TransferFile(serverConn, inFilePath, int64(len(inData)), encoding, nil)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+
+        // Wait for the channel to send complete signal
+        <-isComplete
+
+        // Ensure the streamed data matches the input
+        assert.Equal(inData, outData)
+
+        serverConn.Close()
+        listener.Close()
+    }
+
+    // Ensure an unsupported encoding is an error
+    _, err = netio.TransferReader(nil, 0, netio.EncodingS3)
+    assert.NotNil(err)
+}
+
+
+func TestTransferSection(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    inData := bytes.Repeat([]byte("password123\nletmein\nqwerty\n"), 16 * globals.KB)
+    inFilePath := "input_section_test.txt"
+    // Write the input file the section is read from
+    err := os.WriteFile(inFilePath, inData, 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Delete the input file on local exit
+    defer os.Remove(inFilePath)
+
+    section := netio.Range{Length: 27 * 1000, Offset: 27 * 50}
+    // Iterate through the encodings the section can be sent with
+    for _, encoding := range []string{netio.EncodingNone, netio.EncodingGzip} {
+        // Get available listener and its corresponding port
+        listener, listenerPort := netio.GetAvailableListener()
+        outData := []byte{}
+        isComplete := make(chan bool)
+
+        go func() {
+            // Wait for an incoming connection
+            clientConn, err := listener.Accept()
+            // Ensure the error is nil meaning successful operation
+            assert.Equal(nil, err)
+            // Close connection on local exit
+            defer clientConn.Close()
+
+            // Set up the reader of the section as a file of its own
+            reader, err := netio.TransferReader(clientConn, section.Length, encoding)
+            // Ensure the error is nil meaning successful operation
+            assert.Equal(nil, err)
+            // Close the reader on local exit
+            defer reader.Close()
+
+            outData, err = io.ReadAll(reader)
+            // Ensure the error is nil meaning successful operation
+            assert.Equal(nil, err)
+
+            // Send complete signal via channel
+            isComplete <- true
+        } ()
+
+        // Make a connection to the listener
+        serverConn, err := net.Dial("tcp", ":" + strconv.Itoa(listenerPort))
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+
+        // Transfer only the section of the file
+        err = netio.TransferSection(serverConn, inFilePath, section, encoding, nil)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        serverConn.Close()
+
+        // Wait for the channel to send complete signal
+        <-isComplete
+
+        // Ensure only the section was received
+        assert.Equal(inData[section.Offset:section.Offset + section.Length], outData)
+
+        listener.Close()
+    }
+}
+
+
 func TestWriteHandler(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
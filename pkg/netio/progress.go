@@ -0,0 +1,187 @@
+package netio
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Progress is a snapshot of a file transfer in progress
+type Progress struct {
+    Done  int64          // Bytes of the file transferred
+    Eta   time.Duration  // Estimated time until the transfer completes, 0 when unknown
+    Rate  float64        // Average bytes transferred per second
+    Total int64          // Size of the file in bytes
+}
+
+
+// ProgressFunc receives the periodic progress of a transfer
+type ProgressFunc func(progress Progress)
+
+
+// Tracker counts the bytes of a transfer and reports its progress on an interval, it is
+// safe to share between the connections of a transfer split into ranges
+type Tracker struct {
+    done       atomic.Int64
+    interval   time.Duration
+    lastReport time.Time
+    mutx       sync.Mutex
+    report     ProgressFunc
+    start      time.Time
+    total      int64
+}
+
+
+// progressReader counts the bytes read through it into its tracker
+type progressReader struct {
+    reader  io.Reader
+    tracker *Tracker
+}
+
+
+// progressWriter counts the bytes written through it into its tracker
+type progressWriter struct {
+    tracker *Tracker
+    writer  io.Writer
+}
+
+
+// Creates a tracker of the transfer of a file of the passed in size.
+//
+// @Parameters
+// - total:  The size of the file in bytes
+// - interval:  The min duration of time between progress reports
+// - report:  Receives the progress reports
+//
+// @Returns
+// - The initialized tracker
+//
+func NewTracker(total int64, interval time.Duration, report ProgressFunc) *Tracker {
+    now := time.Now()
+    return &Tracker{
+        interval:   interval,
+        lastReport: now,
+        report:     report,
+        start:      now,
+        total:      total,
+    }
+}
+
+
+// Adds transferred bytes to the tracker, reporting the progress if the interval passed.
+//
+// @Parameters
+// - count:  The number of bytes transferred
+//
+func (tracker *Tracker) Add(count int64) {
+    // If the transfer is not tracked
+    if tracker == nil || count <= 0 {
+        return
+    }
+
+    tracker.done.Add(count)
+
+    // If another connection is reporting, skip rather than wait on it
+    if !tracker.mutx.TryLock() {
+        return
+    }
+    defer tracker.mutx.Unlock()
+
+    // If the last report was too recent
+    if time.Since(tracker.lastReport) < tracker.interval {
+        return
+    }
+
+    tracker.lastReport = time.Now()
+    tracker.report(tracker.Snapshot())
+}
+
+
+// Reports the final progress of the transfer.
+//
+func (tracker *Tracker) Finish() {
+    // If the transfer is not tracked
+    if tracker == nil {
+        return
+    }
+
+    tracker.mutx.Lock()
+    defer tracker.mutx.Unlock()
+
+    tracker.report(tracker.Snapshot())
+}
+
+
+// Gets the current progress of the transfer.
+//
+// @Returns
+// - The progress with the rate and ETA calculated from the elapsed time
+//
+func (tracker *Tracker) Snapshot() Progress {
+    progress := Progress{Done: tracker.done.Load(), Total: tracker.total}
+
+    elapsed := time.Since(tracker.start).Seconds()
+    // If time has passed to calculate a rate from
+    if elapsed > 0 {
+        progress.Rate = float64(progress.Done) / elapsed
+    }
+
+    // If the rate is known and the transfer is not complete
+    if progress.Rate > 0 && progress.Done < progress.Total {
+        remaining := float64(progress.Total - progress.Done) / progress.Rate
+        progress.Eta = time.Duration(remaining * float64(time.Second)).Round(time.Second)
+    }
+
+    return progress
+}
+
+
+// Wraps the reader so the bytes read through it are tracked.
+//
+// @Parameters
+// - reader:  The reader of the transferred data
+//
+// @Returns
+// - The tracked reader, the passed in reader if the transfer is not tracked
+//
+func (tracker *Tracker) Reader(reader io.Reader) io.Reader {
+    // If the transfer is not tracked
+    if tracker == nil {
+        return reader
+    }
+
+    return &progressReader{reader: reader, tracker: tracker}
+}
+
+
+// Wraps the writer so the bytes written through it are tracked.
+//
+// @Parameters
+// - writer:  The writer of the transferred data
+//
+// @Returns
+// - The tracked writer, the passed in writer if the transfer is not tracked
+//
+func (tracker *Tracker) Writer(writer io.Writer) io.Writer {
+    // If the transfer is not tracked
+    if tracker == nil {
+        return writer
+    }
+
+    return &progressWriter{tracker: tracker, writer: writer}
+}
+
+
+func (reader *progressReader) Read(buffer []byte) (int, error) {
+    bytesRead, err := reader.reader.Read(buffer)
+    reader.tracker.Add(int64(bytesRead))
+    return bytesRead, err
+}
+
+
+func (writer *progressWriter) Write(buffer []byte) (int, error) {
+    bytesWrote, err := writer.writer.Write(buffer)
+    writer.tracker.Add(int64(bytesWrote))
+    return bytesWrote, err
+}
@@ -0,0 +1,50 @@
+package netio_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/netio"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestTracker(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    var reports []netio.Progress
+    tracker := netio.NewTracker(4096, 0, func(progress netio.Progress) {
+        reports = append(reports, progress)
+    })
+
+    // Copy through the tracked reader in chunks so progress is reported along the way,
+    // hiding ReadFrom of the destination so the chunk buffer is used
+    _, err := io.CopyBuffer(struct{ io.Writer }{io.Discard},
+                            tracker.Reader(bytes.NewReader(make([]byte, 4096))),
+                            make([]byte, 1024))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    tracker.Finish()
+
+    // Ensure each chunk was reported followed by the final report
+    assert.Equal(5, len(reports))
+    assert.Equal(int64(1024), reports[0].Done)
+    assert.Equal(int64(4096), reports[0].Total)
+
+    final := reports[len(reports)-1]
+    assert.Equal(int64(4096), final.Done)
+    assert.Equal(time.Duration(0), final.Eta)
+    assert.Greater(final.Rate, float64(0))
+
+    // Ensure a tracker that was never created passes the data through untracked
+    var untracked *netio.Tracker
+    var written bytes.Buffer
+    _, err = io.Copy(untracked.Writer(&written), untracked.Reader(bytes.NewReader([]byte("x"))))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("x", written.String())
+    untracked.Finish()
+}
@@ -0,0 +1,460 @@
+package netio
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
+)
+
+// Sent in place of an encoding when the file is split into ranges over parallel connections
+const EncodingRanges = "ranges"
+// Max number of parallel connections a file can be split across
+const MaxRangeConnections = 16
+
+// Package level variables
+const rangeAck = byte(1)         // Sent by the receiver when the range checksum matches
+const rangeHeaderSize = 24       // Offset, length, and range count of the range
+const rangeNack = byte(0)        // Sent by the receiver when the range checksum mismatches
+// Max time the remaining range connections of a file are waited on before it is aborted
+var RangeAcceptTimeout = 30 * time.Second
+
+
+// Range is a span of a file sent over its own connection
+type Range struct {
+    Length int64
+    Offset int64
+}
+
+
+// Receives a file split into ranges over parallel connections, the first connection is
+// already accepted and its header tells how many more are accepted from the listener.
+// The ranges are written into their offsets of the file as they arrive in any order. If
+// the remaining connections are not accepted within RangeAcceptTimeout, the listener is
+// closed and the partial file is deleted.
+//
+// @Parameters
+// - connection:  The first accepted range connection, closed by the caller
+// - listener:  The listener the remaining range connections are accepted from, closed
+//   early when they are not accepted in time
+// - storePath:  The directory where the file will be stored
+// - fileName:  The name of the file to store
+// - fileSize:  The size of the file to be received
+// - digest:  The manifest digest the file is verified against, empty to skip verifying
+//
+// @Returns
+// - The path of the received file
+// - Error if it occurs, otherwise nil on success
+//
+func HandleRangesRecv(connection net.Conn, listener net.Listener, storePath string,
+                      fileName string, fileSize int64, digest string) (string, error) {
+    var waitGroup sync.WaitGroup
+
+    // Read the first header to learn how many ranges are sent
+    rng, count, err := readRangeHeader(connection, fileSize)
+    if err != nil {
+        return "", err
+    }
+
+    // Create the file the received data is stored in
+    file, filePath, err := createRecvFile(storePath, fileName)
+    if err != nil {
+        return "", err
+    }
+
+    ranges := []Range{rng}
+    errChannel := make(chan error, count)
+
+    waitGroup.Add(1)
+    go func() {
+        defer waitGroup.Done()
+        errChannel <- receiveRange(connection, file, rng)
+    }()
+
+    // Close the listener once the accept deadline passes to unblock the pending accept,
+    // since listeners wrapped in TLS have no deadline of their own
+    acceptTimer := time.AfterFunc(RangeAcceptTimeout, func() {
+        listener.Close()
+    })
+
+    // Accept the connections of the remaining ranges
+    for range count - 1 {
+        rangeConn, err := listener.Accept()
+        if err != nil {
+            // If the listener was closed on the accept deadline
+            if !acceptTimer.Stop() {
+                errChannel <- fmt.Errorf("range connections not accepted within %v - %w",
+                                         RangeAcceptTimeout, ErrTimeout)
+                break
+            }
+
+            errChannel <- wrapError("error accepting range connection", err)
+            break
+        }
+        // Close the range connection on local exit
+        defer rangeConn.Close()
+
+        rng, _, err := readRangeHeader(rangeConn, fileSize)
+        if err != nil {
+            errChannel <- err
+            break
+        }
+
+        ranges = append(ranges, rng)
+
+        waitGroup.Add(1)
+        go func() {
+            defer waitGroup.Done()
+            errChannel <- receiveRange(rangeConn, file, rng)
+        }()
+    }
+
+    acceptTimer.Stop()
+    waitGroup.Wait()
+    close(errChannel)
+
+    // Flush the received ranges to disk so they survive a crash once the file is renamed,
+    // then close it since Windows can not remove or rename a file that is still open
+    syncErr := file.Sync()
+    closeErr := file.Close()
+
+    // Iterate through the results of the ranges
+    for err = range errChannel {
+        if err != nil {
+            os.Remove(filePath + disk.PartSuffix)
+            return "", err
+        }
+    }
+
+    // Sort the ranges by offset to ensure they cover the whole file without overlap
+    slices.SortFunc(ranges, func(a, b Range) int {
+        return cmp.Compare(a.Offset, b.Offset)
+    })
+
+    var covered int64
+    for _, rng := range ranges {
+        // If the range does not start where the previous one ended
+        if rng.Offset != covered {
+            os.Remove(filePath + disk.PartSuffix)
+            return "", fmt.Errorf("ranges do not cover the file at offset %d - %w", covered,
+                                  ErrTransferAborted)
+        }
+
+        covered += rng.Length
+    }
+
+    // If the ranges end before the end of the file
+    if covered != fileSize {
+        os.Remove(filePath + disk.PartSuffix)
+        return "", fmt.Errorf("ranges cover %d of %d bytes - %w", covered, fileSize,
+                              ErrTransferAborted)
+    }
+
+    // If the received file could not be flushed or closed
+    err = errors.Join(syncErr, closeErr)
+    if err != nil {
+        os.Remove(filePath + disk.PartSuffix)
+        return "", wrapError("error syncing received file", err)
+    }
+
+    // Ensure the received file matches the manifest before it is processed
+    err = verifyRecvFile(filePath, digest)
+    if err != nil {
+        return "", err
+    }
+
+    // Move the complete file to its final path
+    err = commitRecvFile(filePath)
+    if err != nil {
+        return "", err
+    }
+
+    return filePath, nil
+}
+
+
+// Reads the header sent before the range data, ensuring the range lies within the file.
+//
+// @Parameters
+// - connection:  The connection the range is sent over
+// - fileSize:  The size of the file the range is part of
+//
+// @Returns
+// - The range sent over the connection
+// - The total number of ranges the file is split into
+// - Error if it occurs, otherwise nil on success
+//
+func readRangeHeader(connection net.Conn, fileSize int64) (Range, int, error) {
+    header := make([]byte, rangeHeaderSize)
+    // Read the full header from the connection
+    _, err := io.ReadFull(connection, header)
+    if err != nil {
+        return Range{}, 0, wrapError("error reading range header", err)
+    }
+
+    rng := Range{Length: int64(binary.LittleEndian.Uint64(header[8:16])),
+                 Offset: int64(binary.LittleEndian.Uint64(header[:8]))}
+    count := binary.LittleEndian.Uint64(header[16:])
+
+    // If the range falls outside the file or the count is not usable
+    if rng.Offset < 0 || rng.Length < 0 || rng.Offset > fileSize - rng.Length ||
+       count < 1 || count > MaxRangeConnections {
+        return Range{}, 0, fmt.Errorf("invalid range %d+%d of %d ranges - %w", rng.Offset,
+                                      rng.Length, count, ErrMalformedMessage)
+    }
+
+    return rng, int(count), nil
+}
+
+
+// Reads the range data into its offset of the file, verifying it against the checksum
+// sent after it and replying to the sender whether it matched.
+//
+// @Parameters
+// - connection:  The connection the range is sent over
+// - file:  The open file descriptor the range is written into
+// - rng:  The range sent over the connection
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func receiveRange(connection net.Conn, file *os.File, rng Range) error {
+    transferBuffer := make([]byte, GetOptimalBufferSize(rng.Length))
+    hash := sha256.New()
+    // Set up limited reader to prevent connection from hanging after the range
+    limitedReader := &io.LimitedReader{R: connection, N: rng.Length}
+
+    // Write the range into its offset of the file while hashing it
+    bytesWrote, err := io.CopyBuffer(io.MultiWriter(io.NewOffsetWriter(file, rng.Offset), hash),
+                                     limitedReader, transferBuffer)
+    if err != nil {
+        return wrapError("error receiving range", err)
+    }
+
+    // If the connection closed before the whole range was received
+    if bytesWrote != rng.Length {
+        return fmt.Errorf("range %d+%d incomplete, %d bytes received - %w", rng.Offset,
+                          rng.Length, bytesWrote, ErrTransferAborted)
+    }
+
+    checksum := make([]byte, sha256.Size)
+    // Read the checksum sent after the range data
+    _, err = io.ReadFull(connection, checksum)
+    if err != nil {
+        return wrapError("error reading range checksum", err)
+    }
+
+    matched := bytes.Equal(checksum, hash.Sum(nil))
+    reply := rangeAck
+    // If the received data does not match what was sent
+    if !matched {
+        reply = rangeNack
+    }
+
+    // Let the sender know whether the range was received intact
+    _, err = connection.Write([]byte{reply})
+    if err != nil {
+        return wrapError("error replying to range", err)
+    }
+
+    if !matched {
+        return fmt.Errorf("range %d+%d failed verification - %w", rng.Offset, rng.Length,
+                          ErrChecksumMismatch)
+    }
+
+    return nil
+}
+
+
+// Splits the file size into the number of contiguous ranges, the last range takes any
+// remainder. Files smaller than the number of ranges are split into single bytes.
+//
+// @Parameters
+// - fileSize:  The size of the file to be split
+// - parts:  The number of ranges to split the file into
+//
+// @Returns
+// - The ranges covering the file in order
+//
+func SplitRanges(fileSize int64, parts int) []Range {
+    // Ensure there is at least one range and no empty ranges past the first
+    count := max(min(int64(parts), fileSize), 1)
+    rangeSize := fileSize / count
+    ranges := make([]Range, 0, count)
+
+    for index := range count {
+        offset := index * rangeSize
+        length := rangeSize
+
+        // If this is the last range, it takes the remainder
+        if index == count - 1 {
+            length = fileSize - offset
+        }
+
+        ranges = append(ranges, Range{Length: length, Offset: offset})
+    }
+
+    return ranges
+}
+
+
+// Sends the file split into ranges over parallel connections, the first range is sent
+// over the passed in connection and each remaining range over a newly dialed one.
+//
+// @Parameters
+// - connection:  The connection the first range is sent over, closed by the caller
+// - dial:  Dials a new connection to the receiver for each remaining range
+// - filePath:  The path to the file to be transfered
+// - fileSize:  The size of the file to be transfered
+// - parts:  The number of ranges to split the file into
+// - tracker:  Tracks the progress across the ranges, nil when untracked
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func TransferFileRanges(connection net.Conn, dial func() (net.Conn, error), filePath string,
+                        fileSize int64, parts int, tracker *Tracker) error {
+    return TransferSectionRanges(connection, dial, filePath, Range{Length: fileSize}, parts,
+                                 tracker)
+}
+
+
+// Sends only the section of the file split into ranges over parallel connections, the
+// receiver stores the section as if it were a file of its own.
+//
+// @Parameters
+// - connection:  The connection the first range is sent over, closed by the caller
+// - dial:  Dials a new connection to the receiver for each remaining range
+// - filePath:  The path to the file the section is read from
+// - section:  The offset and length of the section in the file
+// - parts:  The number of ranges to split the section into
+// - tracker:  Tracks the progress across the ranges, nil when untracked
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func TransferSectionRanges(connection net.Conn, dial func() (net.Conn, error),
+                           filePath string, section Range, parts int, tracker *Tracker) error {
+    var waitGroup sync.WaitGroup
+
+    // Open the file
+    file, err := os.Open(filePath)
+    if err != nil {
+        return err
+    }
+    // Close file on local exit
+    defer file.Close()
+
+    // Read the ranges relative to the start of the section
+    sectionReader := io.NewSectionReader(file, section.Offset, section.Length)
+    ranges := SplitRanges(section.Length, min(parts, MaxRangeConnections))
+    errChannel := make(chan error, len(ranges))
+
+    // Iterate through the ranges sending each over its own connection
+    for index, rng := range ranges {
+        waitGroup.Add(1)
+
+        go func() {
+            defer waitGroup.Done()
+            rangeConn := connection
+
+            // If this is not the first range, dial a new connection for it
+            if index > 0 {
+                dialConn, err := dial()
+                if err != nil {
+                    errChannel <- wrapError("error dialing range connection", err)
+                    return
+                }
+                // Close the range connection on local exit
+                defer dialConn.Close()
+
+                rangeConn = dialConn
+            }
+
+            errChannel <- transferRange(rangeConn, sectionReader, rng, len(ranges), tracker)
+        }()
+    }
+
+    waitGroup.Wait()
+    close(errChannel)
+
+    // Iterate through the results of the ranges
+    for err = range errChannel {
+        if err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+
+// Sends the header, data, and checksum of the range then waits for the receiver to
+// confirm the checksum matched.
+//
+// @Parameters
+// - connection:  The connection the range is sent over
+// - file:  The file or section of one the range is read from
+// - rng:  The range to be sent
+// - count:  The total number of ranges the file is split into
+// - tracker:  Tracks the bytes sent across the ranges, nil when untracked
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func transferRange(connection net.Conn, file io.ReaderAt, rng Range, count int,
+                   tracker *Tracker) error {
+    header := make([]byte, rangeHeaderSize)
+    binary.LittleEndian.PutUint64(header[:8], uint64(rng.Offset))
+    binary.LittleEndian.PutUint64(header[8:16], uint64(rng.Length))
+    binary.LittleEndian.PutUint64(header[16:], uint64(count))
+
+    // Send the header so the receiver knows where the range goes
+    _, err := WriteHandler(connection, header, len(header))
+    if err != nil {
+        return err
+    }
+
+    transferBuffer := make([]byte, GetOptimalBufferSize(rng.Length))
+    hash := sha256.New()
+
+    // Send the range data while hashing it
+    _, err = io.CopyBuffer(io.MultiWriter(connection, hash),
+                           tracker.Reader(io.NewSectionReader(file, rng.Offset, rng.Length)),
+                           transferBuffer)
+    if err != nil {
+        return wrapError("error sending range", err)
+    }
+
+    checksum := hash.Sum(nil)
+    // Send the checksum of the range data
+    _, err = WriteHandler(connection, checksum, len(checksum))
+    if err != nil {
+        return err
+    }
+
+    reply := make([]byte, 1)
+    // Wait for the receiver to verify the range
+    _, err = io.ReadFull(connection, reply)
+    if err != nil {
+        return wrapError("error reading range reply", err)
+    }
+
+    // If the receiver got different data than was sent
+    if reply[0] != rangeAck {
+        return fmt.Errorf("range %d+%d failed verification - %w", rng.Offset, rng.Length,
+                          ErrChecksumMismatch)
+    }
+
+    return nil
+}
@@ -0,0 +1,242 @@
+package netio_test
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
+	"github.com/ngimb64/Kloud-Kraken/pkg/netio"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestSplitRanges(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Ensure the last range takes the remainder
+    assert.Equal([]netio.Range{{Length: 3, Offset: 0}, {Length: 3, Offset: 3},
+                               {Length: 4, Offset: 6}}, netio.SplitRanges(10, 3))
+    // Ensure files smaller than the number of ranges are split into single bytes
+    assert.Equal([]netio.Range{{Length: 1, Offset: 0}, {Length: 1, Offset: 1}},
+                 netio.SplitRanges(2, 8))
+    // Ensure an empty file is still sent as a single range
+    assert.Equal([]netio.Range{{Length: 0, Offset: 0}}, netio.SplitRanges(0, 4))
+}
+
+
+func TestTransferFileRanges(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Get available listener and its corresponding port
+    listener, listenerPort := netio.GetAvailableListener()
+    // Close listener on local exit
+    defer listener.Close()
+
+    inData := bytes.Repeat([]byte("password123\nletmein\nqwerty\n"), 64 * globals.KB)
+    inFilePath := "input_ranges_test.txt"
+    // Write the input file to be transferred
+    err := os.WriteFile(inFilePath, inData, 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    outFilePath := ""
+    isComplete := make(chan bool)
+
+    go func() {
+        // Wait for the connection of the first range
+        clientConn, err := listener.Accept()
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        // Close connection on local exit
+        defer clientConn.Close()
+
+        // Receive the ranges and reassemble the file
+        outFilePath, err = netio.HandleRangesRecv(clientConn, listener, "./",
+                                                  "output_ranges_test.txt",
+                                                  int64(len(inData)), "")
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+
+        // Send complete signal via channel
+        isComplete <- true
+    } ()
+
+    dial := func() (net.Conn, error) {
+        return net.Dial("tcp", ":" + strconv.Itoa(listenerPort))
+    }
+
+    // Make a connection to the listener for the first range
+    serverConn, err := dial()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Close connection on local exit
+    defer serverConn.Close()
+
+    // Transfer the file split into ranges over parallel connections
+    err = netio.TransferFileRanges(serverConn, dial, inFilePath, int64(len(inData)), 4, nil)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Wait for the channel to send complete signal
+    <-isComplete
+
+    // Ensure the reassembled file matches the input
+    outData, err := os.ReadFile(outFilePath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(inData, outData)
+
+    // Iterate through list of test files and delete them
+    for _, file := range []string{inFilePath, outFilePath} {
+        err = os.Remove(file)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+    }
+}
+
+
+func TestTransferSectionRanges(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Get available listener and its corresponding port
+    listener, listenerPort := netio.GetAvailableListener()
+    // Close listener on local exit
+    defer listener.Close()
+
+    inData := bytes.Repeat([]byte("password123\nletmein\nqwerty\n"), 16 * globals.KB)
+    inFilePath := "input_section_ranges_test.txt"
+    // Write the input file the section is read from
+    err := os.WriteFile(inFilePath, inData, 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    section := netio.Range{Length: 27 * 4000, Offset: 27 * 300}
+    outFilePath := ""
+    isComplete := make(chan bool)
+
+    go func() {
+        // Wait for the connection of the first range
+        clientConn, err := listener.Accept()
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        // Close connection on local exit
+        defer clientConn.Close()
+
+        // Receive the ranges of the section as a file of its own
+        outFilePath, err = netio.HandleRangesRecv(clientConn, listener, "./",
+                                                  "output_section_ranges_test.txt",
+                                                  section.Length, "")
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+
+        // Send complete signal via channel
+        isComplete <- true
+    } ()
+
+    dial := func() (net.Conn, error) {
+        return net.Dial("tcp", ":" + strconv.Itoa(listenerPort))
+    }
+
+    // Make a connection to the listener for the first range
+    serverConn, err := dial()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Close connection on local exit
+    defer serverConn.Close()
+
+    // Transfer the section split into ranges over parallel connections
+    err = netio.TransferSectionRanges(serverConn, dial, inFilePath, section, 3, nil)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Wait for the channel to send complete signal
+    <-isComplete
+
+    // Ensure only the section was received
+    outData, err := os.ReadFile(outFilePath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(inData[section.Offset:section.Offset + section.Length], outData)
+
+    // Iterate through list of test files and delete them
+    for _, file := range []string{inFilePath, outFilePath} {
+        err = os.Remove(file)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+    }
+}
+
+
+func TestHandleRangesRecvTimeout(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Get available listener and its corresponding port
+    listener, listenerPort := netio.GetAvailableListener()
+    // Close listener on local exit
+    defer listener.Close()
+
+    // Shorten the accept deadline and restore it on local exit
+    acceptTimeout := netio.RangeAcceptTimeout
+    netio.RangeAcceptTimeout = 200 * time.Millisecond
+    defer func() {
+        netio.RangeAcceptTimeout = acceptTimeout
+    } ()
+
+    inData := bytes.Repeat([]byte("password123\n"), 4 * globals.KB)
+    inFilePath := "input_ranges_timeout_test.txt"
+    // Write the input file to be transferred
+    err := os.WriteFile(inFilePath, inData, 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Delete the input file on local exit
+    defer os.Remove(inFilePath)
+
+    outFileName := "output_ranges_timeout_test.txt"
+    recvErr := make(chan error)
+
+    go func() {
+        // Wait for the connection of the first range
+        clientConn, err := listener.Accept()
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        // Close connection on local exit
+        defer clientConn.Close()
+
+        _, err = netio.HandleRangesRecv(clientConn, listener, "./", outFileName,
+                                        int64(len(inData)), "")
+        recvErr <- err
+    } ()
+
+    // Make a connection to the listener for the first range
+    serverConn, err := net.Dial("tcp", ":" + strconv.Itoa(listenerPort))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Close connection on local exit
+    defer serverConn.Close()
+
+    // Send only the first range, failing to dial the connections of the others
+    err = netio.TransferFileRanges(serverConn, func() (net.Conn, error) {
+        return nil, errors.New("dial refused")
+    }, inFilePath, int64(len(inData)), 3, nil)
+    assert.NotEqual(nil, err)
+
+    // Ensure the receiver gives up on the missing ranges once the deadline passes
+    err = <-recvErr
+    assert.ErrorIs(err, netio.ErrTimeout)
+
+    // Ensure neither the partial nor the final file is left behind
+    for _, filePath := range []string{outFileName, outFileName + disk.PartSuffix} {
+        _, err = os.Stat(filePath)
+        assert.True(os.IsNotExist(err))
+    }
+}
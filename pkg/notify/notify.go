@@ -0,0 +1,308 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Events operators can be notified of
+const (
+    BudgetThreshold = "budget_threshold"
+    ClientFailure   = "client_failure"
+    FirstCrack      = "first_crack"
+    RunComplete     = "run_complete"
+)
+
+// Types of the sinks notifications are sent to
+const (
+    SinkDiscord = "discord"
+    SinkSlack   = "slack"
+    SinkSns     = "sns"
+    SinkWebhook = "webhook"
+)
+
+// Max time a sink has to accept a notification
+const SendTimeout = 30 * time.Second
+
+// Package level variables
+var EventTitles = map[string]string{  // Titles of the events in the text of notifications
+    BudgetThreshold: "Budget threshold exceeded",
+    ClientFailure:   "Client failed",
+    FirstCrack:      "First hashes cracked",
+    RunComplete:     "Run complete",
+}
+var ReSnsTopicArn = regexp.MustCompile(`^arn:aws[a-z-]*:sns:[a-z0-9-]+:\d{12}:` +
+                                       `[A-Za-z0-9_-]{1,256}$`)  // Standard SNS topic ARN
+var SinkTypes = []string{SinkDiscord, SinkSlack, SinkSns, SinkWebhook}
+
+
+// SinkConfig is a sink notifications are sent to and the events it receives
+type SinkConfig struct {
+    Events   []string `yaml:"events"`     // Every event when empty
+    TopicArn string   `yaml:"topic_arn"`  // SNS topic of sns sinks
+    Type     string   `yaml:"type"`
+    Url      string   `yaml:"url"`        // Webhook URL of discord, slack, and webhook sinks
+}
+
+
+// Notification is a run event sent to the sinks
+type Notification struct {
+    Event   string         `json:"event"`
+    Fields  map[string]any `json:"fields"`
+    RunName string         `json:"run_name,omitempty"`
+    Time    string         `json:"time"`
+}
+
+
+// Formats the notification as text for the chat and SNS sinks.
+//
+// @Returns
+// - The title of the event followed by a line for each field sorted by key
+//
+func (notification Notification) Text() string {
+    var builder strings.Builder
+    builder.WriteString(notification.Subject())
+
+    // Iterate through the fields in sorted order adding a line for each
+    for _, key := range slices.Sorted(maps.Keys(notification.Fields)) {
+        fmt.Fprintf(&builder, "\n%s: %v", key, notification.Fields[key])
+    }
+
+    return builder.String()
+}
+
+
+// Formats the single line subject of the notification.
+//
+// @Returns
+// - The title of the event along with the run name if the run is named
+//
+func (notification Notification) Subject() string {
+    subject := "Kloud-Kraken: " + notification.Event
+    // If the event has a title
+    if title, ok := EventTitles[notification.Event]; ok {
+        subject = "Kloud-Kraken: " + title
+    }
+
+    // If the run is named, add it so runs in the same channel can be told apart
+    if notification.RunName != "" {
+        subject += " (" + notification.RunName + ")"
+    }
+
+    return subject
+}
+
+
+// Sink sends notifications to an external service
+type Sink interface {
+    Send(ctx context.Context, notification Notification) error
+}
+
+
+// route is a sink and the events sent to it
+type route struct {
+    events []string
+    name   string
+    sink   Sink
+}
+
+
+// Notifier sends run events to the sinks subscribed to them in the background, so a slow
+// or unreachable sink never holds up the run
+type Notifier struct {
+    onError func(sink string, event string, err error)
+    routes  []route
+    runName string
+    wg      sync.WaitGroup
+}
+
+
+// Ensures each sink config has a supported type, the destination its type needs, and
+// only known events.
+//
+// @Parameters
+// - configs:  The sink configs to be validated
+//
+// @Returns
+// - Error if a sink config is invalid, otherwise nil
+//
+func ValidateSinks(configs []SinkConfig) error {
+    // Iterate through the sink configs validating each
+    for index, config := range configs {
+        switch config.Type {
+        case SinkSns:
+            // If the topic is not the ARN of a standard SNS topic
+            if !ReSnsTopicArn.MatchString(config.TopicArn) {
+                return fmt.Errorf("notification %d has improper topic_arn %q", index,
+                                  config.TopicArn)
+            }
+        case SinkDiscord, SinkSlack, SinkWebhook:
+            parsed, err := url.Parse(config.Url)
+            // Chat webhooks are only served over HTTPS, generic webhooks may be internal
+            if err != nil || parsed.Host == "" || (parsed.Scheme != "https" &&
+            (config.Type != SinkWebhook || parsed.Scheme != "http")) {
+                return fmt.Errorf("notification %d has improper %s url", index, config.Type)
+            }
+        default:
+            return fmt.Errorf("notification %d has unsupported type %q, must be one of %s",
+                              index, config.Type, strings.Join(SinkTypes, ", "))
+        }
+
+        // Iterate through the events of the sink ensuring each is known
+        for _, event := range config.Events {
+            if _, ok := EventTitles[event]; !ok {
+                return fmt.Errorf("notification %d has unknown event %q", index, event)
+            }
+        }
+    }
+
+    return nil
+}
+
+
+// Gets the ARNs of the SNS topics notifications are published to, which the role sending
+// the notifications needs to be allowed to publish to.
+//
+// @Parameters
+// - configs:  The sink configs
+//
+// @Returns
+// - The ARNs of the topics of the sns sinks
+//
+func SnsTopics(configs []SinkConfig) []string {
+    var topics []string
+    for _, config := range configs {
+        if config.Type == SinkSns && !slices.Contains(topics, config.TopicArn) {
+            topics = append(topics, config.TopicArn)
+        }
+    }
+
+    return topics
+}
+
+
+// Creates the sink of the config.
+//
+// @Parameters
+// - config:  The validated sink config
+// - awsConfig:  The AWS config SNS notifications are published with
+//
+// @Returns
+// - The initialized sink
+// - Error if it occurs, otherwise nil on success
+//
+func NewSink(config SinkConfig, awsConfig aws.Config) (Sink, error) {
+    switch config.Type {
+    case SinkDiscord:
+        return &ChatSink{TextKey: "content", Url: config.Url}, nil
+    case SinkSlack:
+        return &ChatSink{TextKey: "text", Url: config.Url}, nil
+    case SinkSns:
+        return NewSnsSink(awsConfig, config.TopicArn), nil
+    case SinkWebhook:
+        return &WebhookSink{Url: config.Url}, nil
+    default:
+        return nil, fmt.Errorf("unsupported notification type %q", config.Type)
+    }
+}
+
+
+// Creates a notifier sending to the sinks of the configs.
+//
+// @Parameters
+// - configs:  The validated sink configs
+// - awsConfig:  The AWS config SNS notifications are published with
+// - runName:  The name of the run added to the notifications, empty if unnamed
+// - onError:  Called with the type of the sink and event when a send fails
+//
+// @Returns
+// - The initialized notifier
+// - Error if it occurs, otherwise nil on success
+//
+func NewNotifier(configs []SinkConfig, awsConfig aws.Config, runName string,
+                 onError func(sink string, event string, err error)) (*Notifier, error) {
+    notifier := &Notifier{onError: onError, runName: runName}
+
+    // Iterate through the configs creating the sink of each
+    for _, config := range configs {
+        sink, err := NewSink(config, awsConfig)
+        if err != nil {
+            return nil, err
+        }
+
+        notifier.AddSink(config.Type, sink, config.Events)
+    }
+
+    return notifier, nil
+}
+
+
+// Adds a sink receiving the passed in events.
+//
+// @Parameters
+// - name:  The name of the sink in error messages
+// - sink:  The sink notifications are sent to
+// - events:  The events sent to the sink, every event when empty
+//
+func (notifier *Notifier) AddSink(name string, sink Sink, events []string) {
+    notifier.routes = append(notifier.routes, route{events: events, name: name, sink: sink})
+}
+
+
+// Sends the event to each sink subscribed to it in the background.
+//
+// @Parameters
+// - event:  The event that occurred
+// - fields:  The details of the event, never modified once passed in
+//
+func (notifier *Notifier) Notify(event string, fields map[string]any) {
+    // If notifications are disabled
+    if notifier == nil {
+        return
+    }
+
+    notification := Notification{Event: event, Fields: fields, RunName: notifier.runName,
+                                 Time: time.Now().UTC().Format(time.RFC3339)}
+
+    // Iterate through the sinks sending to the ones subscribed to the event
+    for _, route := range notifier.routes {
+        if len(route.events) > 0 && !slices.Contains(route.events, event) {
+            continue
+        }
+
+        notifier.wg.Add(1)
+
+        go func() {
+            defer notifier.wg.Done()
+
+            ctx, cancel := context.WithTimeout(context.Background(), SendTimeout)
+            defer cancel()
+
+            err := route.sink.Send(ctx, notification)
+            if err != nil && notifier.onError != nil {
+                notifier.onError(route.name, event, err)
+            }
+        } ()
+    }
+}
+
+
+// Waits for the notifications being sent, so the final ones are delivered before exit.
+//
+func (notifier *Notifier) Wait() {
+    // If notifications are disabled
+    if notifier == nil {
+        return
+    }
+
+    notifier.wg.Wait()
+}
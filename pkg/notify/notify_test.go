@@ -0,0 +1,208 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/ngimb64/Kloud-Kraken/pkg/notify"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestValidateSinks(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := [][]notify.SinkConfig{
+        nil,
+        {{Type: notify.SinkSlack, Url: "https://hooks.slack.com/services/T0/B0/x"}},
+        {{Type: notify.SinkDiscord, Url: "https://discord.com/api/webhooks/1/x",
+          Events: []string{notify.FirstCrack, notify.RunComplete}}},
+        {{Type: notify.SinkWebhook, Url: "http://10.0.0.5:8080/hook"}},
+        {{Type: notify.SinkSns, TopicArn: "arn:aws:sns:us-east-1:123456789012:alerts"}},
+    }
+    // Iterate through slice of truths and test them
+    for _, truth := range truths {
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, notify.ValidateSinks(truth))
+    }
+
+    falacies := [][]notify.SinkConfig{
+        {{Type: "email", Url: "https://example.com"}},
+        {{Type: notify.SinkSlack, Url: "http://hooks.slack.com/services/T0/B0/x"}},
+        {{Type: notify.SinkWebhook, Url: "ftp://example.com/hook"}},
+        {{Type: notify.SinkWebhook, Url: ""}},
+        {{Type: notify.SinkSns, TopicArn: "alerts"}},
+        {{Type: notify.SinkSns, TopicArn: "arn:aws:sns:us-east-1:123456789012:a.fifo"}},
+        {{Type: notify.SinkSlack, Url: "https://hooks.slack.com/services/T0/B0/x",
+          Events: []string{"first_blood"}}},
+    }
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        // Ensure the error is not nil meaning failed operation
+        assert.NotEqual(nil, notify.ValidateSinks(falacy))
+    }
+
+    // Ensure each SNS topic is listed once for the publish permissions
+    assert.Equal([]string{"arn:aws:sns:us-east-1:123456789012:alerts"},
+                 notify.SnsTopics(append(truths[4], truths[4][0], truths[1][0])))
+}
+
+
+func TestNotificationText(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    notification := notify.Notification{Event: notify.FirstCrack, RunName: "acme-q3",
+                                        Fields: map[string]any{"cracked": 3,
+                                                               "client": "10.0.0.5:4444"}}
+    assert.Equal("Kloud-Kraken: First hashes cracked (acme-q3)", notification.Subject())
+    // Ensure the fields follow the subject sorted by key
+    assert.Equal("Kloud-Kraken: First hashes cracked (acme-q3)\nclient: 10.0.0.5:4444\n" +
+                 "cracked: 3", notification.Text())
+
+    // Ensure unknown events are named by their type
+    assert.Equal("Kloud-Kraken: custom", notify.Notification{Event: "custom"}.Subject())
+}
+
+
+func TestNotifier(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    var mutx sync.Mutex
+    received := map[string][]map[string]any{}
+
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter,
+                                                       request *http.Request) {
+        body, _ := io.ReadAll(request.Body)
+
+        var payload map[string]any
+        json.Unmarshal(body, &payload)
+
+        mutx.Lock()
+        received[request.URL.Path] = append(received[request.URL.Path], payload)
+        mutx.Unlock()
+
+        // Reject the notifications sent to the failing path
+        if request.URL.Path == "/fail" {
+            writer.WriteHeader(http.StatusInternalServerError)
+        }
+    }))
+    // Close the server on local exit
+    defer server.Close()
+
+    configs := []notify.SinkConfig{
+        {Type: notify.SinkWebhook, Url: server.URL + "/webhook"},
+        {Type: notify.SinkSlack, Url: server.URL + "/slack",
+         Events: []string{notify.RunComplete}},
+        {Type: notify.SinkDiscord, Url: server.URL + "/discord",
+         Events: []string{notify.FirstCrack}},
+        {Type: notify.SinkWebhook, Url: server.URL + "/fail"},
+    }
+
+    var failures []string
+    notifier, err := notify.NewNotifier(configs, aws.Config{}, "acme-q3",
+                                        func(sink string, event string, err error) {
+        mutx.Lock()
+        failures = append(failures, sink + ":" + event)
+        mutx.Unlock()
+    })
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    notifier.Notify(notify.FirstCrack, map[string]any{"client": "10.0.0.5:4444",
+                                                      "cracked": 3})
+    notifier.Wait()
+
+    // Ensure the generic webhook receives the notification as JSON
+    assert.Equal(1, len(received["/webhook"]))
+    assert.Equal("first_crack", received["/webhook"][0]["event"])
+    assert.Equal("acme-q3", received["/webhook"][0]["run_name"])
+    assert.Equal(3.0, received["/webhook"][0]["fields"].(map[string]any)["cracked"])
+    // Ensure the chat sinks only receive their subscribed events as text
+    assert.Equal(0, len(received["/slack"]))
+    assert.Equal(1, len(received["/discord"]))
+    assert.Contains(received["/discord"][0]["content"], "First hashes cracked")
+    // Ensure the rejected notification is reported
+    assert.Equal([]string{"webhook:first_crack"}, failures)
+
+    notifier.Notify(notify.RunComplete, map[string]any{"cracked_hashes": 10})
+    notifier.Wait()
+
+    assert.Equal(2, len(received["/webhook"]))
+    assert.Equal(1, len(received["/slack"]))
+    assert.Contains(received["/slack"][0]["text"], "cracked_hashes: 10")
+    assert.Equal(1, len(received["/discord"]))
+
+    // Ensure a disabled notifier can be used without checks
+    var disabled *notify.Notifier
+    disabled.Notify(notify.RunComplete, nil)
+    disabled.Wait()
+}
+
+
+// fakeSns records the published messages in place of the SNS client
+type fakeSns struct {
+    published []*sns.PublishInput
+}
+
+
+func (fake *fakeSns) Publish(ctx context.Context, params *sns.PublishInput,
+                             optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+    fake.published = append(fake.published, params)
+    return &sns.PublishOutput{}, nil
+}
+
+
+func TestSinkTruncation(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Multi-byte characters straddling the limits of the subject and chat text
+    notification := notify.Notification{Event: notify.RunComplete,
+                                        Fields: map[string]any{"note": strings.Repeat("é", 3000)},
+                                        RunName: strings.Repeat("ü", 150)}
+
+    fake := &fakeSns{}
+    sink := notify.NewSnsSinkWithClient(fake, "arn:aws:sns:us-east-1:123456789012:run")
+
+    err := sink.Send(context.Background(), notification)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the topic receives the full text with the subject cut between characters
+    assert.Equal(1, len(fake.published))
+    assert.Equal("arn:aws:sns:us-east-1:123456789012:run", *fake.published[0].TopicArn)
+    assert.Equal(notification.Text(), *fake.published[0].Message)
+    assert.True(utf8.ValidString(*fake.published[0].Subject))
+    assert.Equal(notify.MaxSubjectLength, utf8.RuneCountInString(*fake.published[0].Subject))
+
+    var text string
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter,
+                                                       request *http.Request) {
+        var payload map[string]string
+        json.NewDecoder(request.Body).Decode(&payload)
+        text = payload["content"]
+    }))
+    // Close the server on local exit
+    defer server.Close()
+
+    chat := &notify.ChatSink{TextKey: "content", Url: server.URL}
+    err = chat.Send(context.Background(), notification)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the chat text is cut between characters
+    assert.True(utf8.ValidString(text))
+    assert.Equal(notify.MaxChatLength, utf8.RuneCountInString(text))
+}
@@ -0,0 +1,217 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+)
+
+// Most characters of a Discord message, longer text is truncated
+const MaxChatLength = 2000
+// Most characters of an SNS subject, longer subjects are truncated
+const MaxSubjectLength = 100
+
+
+// Truncates the text to the passed in number of characters, cutting between characters
+// so a multi-byte UTF-8 character is never split.
+//
+// @Parameters
+// - text:  The text to truncate
+// - limit:  The max number of characters kept
+//
+// @Returns
+// - The text cut to the limit, as is when it is not longer
+//
+func truncate(text string, limit int) string {
+    count := 0
+    // Iterate through the start of each character until the limit is reached
+    for index := range text {
+        if count == limit {
+            return text[:index]
+        }
+
+        count += 1
+    }
+
+    return text
+}
+
+
+// Sends the payload as JSON to the URL.
+//
+// @Parameters
+// - ctx:  The context the request is cancelled with
+// - url:  The URL the payload is posted to
+// - payload:  The payload to encode
+//
+// @Returns
+// - Error if it occurs or the response is not a success, otherwise nil on success
+//
+func postJson(ctx context.Context, url string, payload any) error {
+    body, err := json.Marshal(payload)
+    if err != nil {
+        return fmt.Errorf("error encoding notification - %w", err)
+    }
+
+    request, err := http.NewRequestWithContext(ctx, http.MethodPost, url,
+                                               bytes.NewReader(body))
+    if err != nil {
+        return err
+    }
+    request.Header.Set("Content-Type", "application/json")
+
+    return doRequest(request)
+}
+
+
+// Sends the request, ensuring the response is a success.
+//
+// @Parameters
+// - request:  The request to send
+//
+// @Returns
+// - Error if it occurs or the response is not a success, otherwise nil on success
+//
+func doRequest(request *http.Request) error {
+    response, err := http.DefaultClient.Do(request)
+    if err != nil {
+        return fmt.Errorf("error sending notification - %w", err)
+    }
+    // Close the response body on local exit
+    defer response.Body.Close()
+
+    // If the service rejected the notification
+    if response.StatusCode < 200 || response.StatusCode > 299 {
+        body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+        return fmt.Errorf("notification rejected with status %d - %s", response.StatusCode,
+                          bytes.TrimSpace(body))
+    }
+
+    return nil
+}
+
+
+// WebhookSink posts the notification as JSON to a generic webhook
+type WebhookSink struct {
+    Url string
+}
+
+
+// Posts the notification to the webhook.
+//
+// @Parameters
+// - ctx:  The context the request is cancelled with
+// - notification:  The notification to send
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (sink *WebhookSink) Send(ctx context.Context, notification Notification) error {
+    return postJson(ctx, sink.Url, notification)
+}
+
+
+// ChatSink posts the text of the notification to a Slack or Discord incoming webhook
+type ChatSink struct {
+    TextKey string  // Key of the message text, text for Slack and content for Discord
+    Url     string
+}
+
+
+// Posts the text of the notification to the chat webhook.
+//
+// @Parameters
+// - ctx:  The context the request is cancelled with
+// - notification:  The notification to send
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (sink *ChatSink) Send(ctx context.Context, notification Notification) error {
+    // Cut the text to the length chat services accept
+    text := truncate(notification.Text(), MaxChatLength)
+
+    return postJson(ctx, sink.Url, map[string]string{sink.TextKey: text})
+}
+
+
+// SnsApi is the subset of the SNS client used to publish notifications, satisfied by
+// *sns.Client and the fakes in tests
+type SnsApi interface {
+    Publish(ctx context.Context, params *sns.PublishInput,
+            optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+
+// SnsSink publishes the text of the notification to an SNS topic, which delivers it to
+// the email, SMS, or other subscriptions of the topic
+type SnsSink struct {
+    client   SnsApi
+    topicArn string
+}
+
+
+// Creates a sink publishing to the SNS topic in the region of its ARN.
+//
+// @Parameters
+// - awsConfig:  The AWS config with the credentials the notifications are published with
+// - topicArn:  The ARN of the SNS topic
+//
+// @Returns
+// - The initialized SNS sink
+//
+func NewSnsSink(awsConfig aws.Config, topicArn string) *SnsSink {
+    client := sns.NewFromConfig(awsConfig, func(options *sns.Options) {
+        // If the ARN has a region field, publish in the region of the topic
+        if fields := strings.Split(topicArn, ":"); len(fields) > 3 && fields[3] != "" {
+            options.Region = fields[3]
+        }
+    })
+
+    return NewSnsSinkWithClient(client, topicArn)
+}
+
+
+// Creates a sink publishing to the SNS topic through the passed in client.
+//
+// @Parameters
+// - client:  The SNS client the notifications are published with
+// - topicArn:  The ARN of the SNS topic
+//
+// @Returns
+// - The initialized SNS sink
+//
+func NewSnsSinkWithClient(client SnsApi, topicArn string) *SnsSink {
+    return &SnsSink{client: client, topicArn: topicArn}
+}
+
+
+// Publishes the notification to the topic.
+//
+// @Parameters
+// - ctx:  The context the request is cancelled with
+// - notification:  The notification to send
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (sink *SnsSink) Send(ctx context.Context, notification Notification) error {
+    _, err := sink.client.Publish(ctx, &sns.PublishInput{
+        Message:  aws.String(notification.Text()),
+        // Cut the subject to the length SNS accepts
+        Subject:  aws.String(truncate(notification.Subject(), MaxSubjectLength)),
+        TopicArn: aws.String(sink.topicArn),
+    })
+    if err != nil {
+        return fmt.Errorf("error publishing to %s - %w", sink.topicArn, err)
+    }
+
+    return nil
+}
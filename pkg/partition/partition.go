@@ -0,0 +1,222 @@
+package partition
+
+import (
+	_ "embed"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Package level variables
+//go:embed partitions.json
+var partitionsJson []byte
+var partitions = loadPartitions()  // Partitions in the order of the SDK v2 data
+const DefaultId = "aws"            // Partition assumed for regions of no known partition
+
+
+// Partition is an AWS partition with the regions in it, the ID is the partition field of
+// its ARNs and the DNS suffix is the domain its service endpoints are under
+type Partition struct {
+    DnsSuffix   string
+    Id          string
+    regionRegex *regexp.Regexp
+    regions     map[string]string
+}
+
+
+// partitionsFile is the layout of the SDK v2 partitions data
+type partitionsFile struct {
+    Partitions []struct {
+        Id      string `json:"id"`
+        Outputs struct {
+            DnsSuffix string `json:"dnsSuffix"`
+        } `json:"outputs"`
+        RegionRegex string `json:"regionRegex"`
+        Regions     map[string]struct {
+            Description string `json:"description"`
+        } `json:"regions"`
+    } `json:"partitions"`
+}
+
+
+// Parses the embedded partitions data, copied from the AWS SDK v2 which only exposes
+// it to its own packages.
+//
+// @Returns
+// - The parsed partitions
+//
+func loadPartitions() []Partition {
+    var data partitionsFile
+    err := json.Unmarshal(partitionsJson, &data)
+    if err != nil {
+        panic("partition: invalid embedded partitions data - " + err.Error())
+    }
+
+    parsed := make([]Partition, 0, len(data.Partitions))
+    // Iterate through the partitions collecting their regions
+    for _, entry := range data.Partitions {
+        regions := map[string]string{}
+        for region, info := range entry.Regions {
+            regions[region] = info.Description
+        }
+
+        parsed = append(parsed, Partition{
+            DnsSuffix:   entry.Outputs.DnsSuffix,
+            Id:          entry.Id,
+            regionRegex: regexp.MustCompile(entry.RegionRegex),
+            regions:     regions,
+        })
+    }
+
+    return parsed
+}
+
+
+// Gets the partition the region is in, matching the known regions first and then the
+// region name format of each partition so newly launched regions still resolve.
+//
+// @Parameters
+// - region:  The AWS region to look up
+//
+// @Returns
+// - The partition of the region
+// - true/false depending on whether the region belongs to a partition
+//
+func Lookup(region string) (Partition, bool) {
+    // Iterate through the partitions checking their known regions
+    for _, partition := range partitions {
+        if _, exists := partition.regions[region]; exists {
+            return partition, true
+        }
+    }
+
+    // Iterate through the partitions matching their region name format
+    for _, partition := range partitions {
+        if partition.regionRegex.MatchString(region) {
+            return partition, true
+        }
+    }
+
+    return Partition{}, false
+}
+
+
+// Gets the partition of the region, falling back to the commercial partition when the
+// region is unknown.
+//
+// @Parameters
+// - region:  The AWS region to look up
+//
+// @Returns
+// - The partition of the region
+//
+func ForRegion(region string) Partition {
+    partition, found := Lookup(region)
+    // If the region is not in any partition, assume commercial AWS
+    if !found {
+        partition, _ = Lookup("us-east-1")
+    }
+
+    return partition
+}
+
+
+// Gets the ARN partition field of the region, aws, aws-us-gov, aws-cn, etc.
+//
+// @Parameters
+// - region:  The AWS region to look up
+//
+// @Returns
+// - The partition ID of the region
+//
+func Id(region string) string {
+    return ForRegion(region).Id
+}
+
+
+// Ensure the region is a known region of a partition, the pseudo regions used for
+// global endpoints (aws-global, aws-cn-global, etc.) are not accepted.
+//
+// @Parameters
+// - region:  The AWS region to be validated
+//
+// @Returns
+// - true/false depending on whether the region is known
+//
+func IsRegion(region string) bool {
+    // If the region is a global endpoint pseudo region
+    if strings.HasSuffix(region, "-global") {
+        return false
+    }
+
+    // Iterate through the partitions checking their known regions
+    for _, partition := range partitions {
+        if _, exists := partition.regions[region]; exists {
+            return true
+        }
+    }
+
+    return false
+}
+
+
+// Ensure both regions are in the same partition, since credentials, ARNs, and IAM
+// roles do not cross partitions.
+//
+// @Parameters
+// - first:  The first AWS region
+// - second:  The second AWS region
+//
+// @Returns
+// - true/false depending on whether the regions share a partition
+//
+func SamePartition(first string, second string) bool {
+    return Id(first) == Id(second)
+}
+
+
+// Formats an ARN in the partition of the region.
+//
+// @Parameters
+// - region:  The AWS region the partition is looked up from
+// - service:  The service namespace of the resource
+// - arnRegion:  The region field of the ARN, empty for global resources
+// - accountId:  The account field of the ARN, empty when not owned by an account
+// - resource:  The resource field of the ARN
+//
+// @Returns
+// - The formatted ARN
+//
+func Arn(region string, service string, arnRegion string, accountId string,
+         resource string) string {
+    return strings.Join([]string{"arn", Id(region), service, arnRegion, accountId, resource},
+                        ":")
+}
+
+
+// Gets the service endpoint host of the region, under the DNS suffix of its partition.
+//
+// @Parameters
+// - service:  The service endpoint prefix
+// - region:  The AWS region of the endpoint
+//
+// @Returns
+// - The endpoint host of the service
+//
+func EndpointHost(service string, region string) string {
+    return service + "." + region + "." + ForRegion(region).DnsSuffix
+}
+
+
+// Gets the service principal of the service in the partition of the region.
+//
+// @Parameters
+// - service:  The service the principal is for (ex: ec2)
+// - region:  The AWS region the partition is looked up from
+//
+// @Returns
+// - The service principal
+//
+func ServicePrincipal(service string, region string) string {
+    return service + "." + ForRegion(region).DnsSuffix
+}
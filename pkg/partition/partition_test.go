@@ -0,0 +1,73 @@
+package partition_test
+
+import (
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/partition"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestLookup(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := map[string]string{
+        "us-east-1":      "aws",
+        "eu-west-2":      "aws",
+        "us-gov-west-1":  "aws-us-gov",
+        "cn-northwest-1": "aws-cn",
+        "us-iso-east-1":  "aws-iso",
+        "ap-future-9":    "aws",
+    }
+    // Iterate through map of truths and test them
+    for region, id := range truths {
+        found, ok := partition.Lookup(region)
+        assert.True(ok)
+        assert.Equal(id, found.Id)
+    }
+
+    falacies := []string{"test", "string", "nonsense", ""}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        _, ok := partition.Lookup(falacy)
+        assert.False(ok)
+        assert.Equal(partition.DefaultId, partition.Id(falacy))
+    }
+
+    assert.Equal("amazonaws.com.cn", partition.ForRegion("cn-north-1").DnsSuffix)
+    assert.True(partition.SamePartition("us-gov-east-1", "us-gov-west-1"))
+    assert.False(partition.SamePartition("us-east-1", "us-gov-west-1"))
+}
+
+
+func TestIsRegion(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    truths := []string{"us-east-1", "us-west-2", "us-gov-west-1", "cn-north-1"}
+    // Iterate through slice of truths and test them
+    for _, truth := range truths {
+        assert.True(partition.IsRegion(truth))
+    }
+
+    falacies := []string{"aws-global", "aws-us-gov-global", "ap-future-9", "nonsense"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        assert.False(partition.IsRegion(falacy))
+    }
+}
+
+
+func TestFormatting(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    assert.Equal("arn:aws-us-gov:s3:::bucket/*",
+                 partition.Arn("us-gov-west-1", "s3", "", "", "bucket/*"))
+    assert.Equal("arn:aws-cn:iam::123456789012:role/Client",
+                 partition.Arn("cn-north-1", "iam", "", "123456789012", "role/Client"))
+    assert.Equal("servicequotas.cn-north-1.amazonaws.com.cn",
+                 partition.EndpointHost("servicequotas", "cn-north-1"))
+    assert.Equal("ec2.amazonaws.com", partition.ServicePrincipal("ec2", "us-gov-west-1"))
+}
@@ -0,0 +1,563 @@
+package peer
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/netio"
+	"github.com/ngimb64/Kloud-Kraken/pkg/tlsutils"
+)
+
+// FetchInfo stores what a client needs to fetch a file from a seeding peer
+type FetchInfo struct {
+    FileName string
+    FileSize int64
+    IpAddr   string
+    Mac      string
+    Nonce    string
+    Port     int
+}
+
+// SeedInfo stores the connection details of a client seeding files
+type SeedInfo struct {
+    CertPem []byte
+    IpAddr  string
+    Port    int
+    Secret  string
+    served  int
+}
+
+
+// Generates a random hex encoded secret used by a seeder to verify tokens.
+//
+// @Returns
+// - The hex encoded secret
+// - Error if it occurs, otherwise nil on success
+//
+func GenerateSecret() (string, error) {
+    secret := make([]byte, 16)
+    // Fill the secret with cryptographically secure random bytes
+    _, err := rand.Read(secret)
+    if err != nil {
+        return "", err
+    }
+
+    return hex.EncodeToString(secret), nil
+}
+
+
+// Computes the token MAC of the nonce and file name with the seeder secret.
+//
+// @Parameters
+// - secret:  The seeder secret the MAC is keyed with
+// - nonce:  The one-time nonce of the token
+// - fileName:  The name of the file the token grants access to
+//
+// @Returns
+// - The truncated hex encoded MAC
+//
+func computeMac(secret string, nonce string, fileName string) string {
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write([]byte(nonce + ":" + fileName))
+    return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+
+// Creates a one-time token granting access to a single file on a seeder.
+//
+// @Parameters
+// - secret:  The secret registered by the seeder
+// - fileName:  The name of the file the token grants access to
+//
+// @Returns
+// - The hex encoded nonce of the token
+// - The MAC of the token
+// - Error if it occurs, otherwise nil on success
+//
+func NewToken(secret string, fileName string) (string, string, error) {
+    nonceBytes := make([]byte, 16)
+    // Fill the nonce with cryptographically secure random bytes
+    _, err := rand.Read(nonceBytes)
+    if err != nil {
+        return "", "", err
+    }
+
+    nonce := hex.EncodeToString(nonceBytes)
+    return nonce, computeMac(secret, nonce, fileName), nil
+}
+
+
+// Verifies the token MAC matches the nonce and file name.
+//
+// @Parameters
+// - secret:  The seeder secret the MAC is keyed with
+// - nonce:  The one-time nonce of the token
+// - mac:  The MAC of the token to verify
+// - fileName:  The name of the requested file
+//
+// @Returns
+// - true/false boolean depending on whether the token is valid or not
+//
+func VerifyToken(secret string, nonce string, mac string, fileName string) bool {
+    return hmac.Equal([]byte(computeMac(secret, nonce, fileName)), []byte(mac))
+}
+
+
+// Formats the peer fetch message the server sends to a client.
+//
+// @Parameters
+// - info:  The fetch info to be formatted
+//
+// @Returns
+// - The formatted peer fetch message
+//
+func FormatFetch(info FetchInfo) []byte {
+    return []byte(fmt.Sprintf("%s%d:%s:%s:%d:%s:%s%s", globals.PEER_FETCH_PREFIX, info.Port,
+                              info.Nonce, info.Mac, info.FileSize, info.IpAddr,
+                              info.FileName, globals.TRANSFER_SUFFIX))
+}
+
+
+// Parses the peer fetch message received from the server, the file name
+// is the last field so it may contain the delimiter.
+//
+// @Parameters
+// - message:  The peer fetch message
+//
+// @Returns
+// - The parsed fetch info
+// - Error if it occurs, otherwise nil on success
+//
+func ParseFetch(message []byte) (FetchInfo, error) {
+    var info FetchInfo
+
+    // Strip the prefix and suffix then split on the delimiter
+    fields, err := splitMessage(message, globals.PEER_FETCH_PREFIX, 6)
+    if err != nil {
+        return info, err
+    }
+
+    // Parse the seeder port
+    info.Port, err = strconv.Atoi(fields[0])
+    if err != nil {
+        return info, fmt.Errorf("error parsing peer port - %w", err)
+    }
+
+    // Parse the file size
+    info.FileSize, err = strconv.ParseInt(fields[3], 10, 64)
+    if err != nil {
+        return info, fmt.Errorf("error parsing peer file size - %w", err)
+    }
+
+    info.Nonce = fields[1]
+    info.Mac = fields[2]
+    info.IpAddr = fields[4]
+    info.FileName = fields[5]
+
+    return info, nil
+}
+
+
+// Formats the seed registration message a client sends to the server.
+//
+// @Parameters
+// - port:  The port the seeder is listening on
+// - secret:  The seeder secret used to create tokens
+//
+// @Returns
+// - The formatted seed message
+//
+func FormatSeed(port int, secret string) []byte {
+    return []byte(fmt.Sprintf("%s%d:%s%s", globals.PEER_SEED_PREFIX, port,
+                              secret, globals.TRANSFER_SUFFIX))
+}
+
+
+// Parses the seed registration message received from a client.
+//
+// @Parameters
+// - message:  The seed registration message
+//
+// @Returns
+// - The port the seeder is listening on
+// - The seeder secret used to create tokens
+// - Error if it occurs, otherwise nil on success
+//
+func ParseSeed(message []byte) (int, string, error) {
+    // Strip the prefix and suffix then split on the delimiter
+    fields, err := splitMessage(message, globals.PEER_SEED_PREFIX, 2)
+    if err != nil {
+        return -1, "", err
+    }
+
+    // Parse the seeder port
+    port, err := strconv.Atoi(fields[0])
+    if err != nil {
+        return -1, "", fmt.Errorf("error parsing seed port - %w", err)
+    }
+
+    return port, fields[1], nil
+}
+
+
+// Strips the prefix and suffix from the message and splits it into the
+// expected number of fields, the last field keeps any extra delimiters.
+//
+// @Parameters
+// - message:  The message to be split
+// - prefix:  The prefix the message should start with
+// - numFields:  The number of fields expected in the message
+//
+// @Returns
+// - Slice of the parsed fields
+// - Error if it occurs, otherwise nil on success
+//
+func splitMessage(message []byte, prefix []byte, numFields int) ([]string, error) {
+    // If the message does not start with prefix or end with closed bracket
+    if !bytes.HasPrefix(message, prefix) || !bytes.HasSuffix(message, globals.TRANSFER_SUFFIX) {
+        return nil, fmt.Errorf("improper prefix or suffix in peer message")
+    }
+
+    body := bytes.TrimSuffix(bytes.TrimPrefix(message, prefix), globals.TRANSFER_SUFFIX)
+    fields := bytes.SplitN(body, globals.COLON_DELIMITER, numFields)
+    // If the message is missing fields
+    if len(fields) != numFields {
+        return nil, fmt.Errorf("improper number of fields in peer message")
+    }
+
+    parsed := make([]string, numFields)
+    // Convert the fields to strings ensuring none are empty
+    for index, field := range fields {
+        if len(field) == 0 {
+            return nil, fmt.Errorf("empty field in peer message")
+        }
+
+        parsed[index] = string(field)
+    }
+
+    return parsed, nil
+}
+
+
+// Registry tracks which clients are seeding which files on the server
+type Registry struct {
+    files    map[string][]string
+    mutx     sync.Mutex
+    seeders  map[string]*SeedInfo
+}
+
+// Creates a new empty seeder registry.
+//
+// @Returns
+// - The initialized registry
+//
+func NewRegistry() *Registry {
+    return &Registry{
+        files:   make(map[string][]string),
+        seeders: make(map[string]*SeedInfo),
+    }
+}
+
+// Registers the client as a seeder of the passed in server side file paths.
+//
+// @Parameters
+// - client:  The address of the client seeding the files
+// - info:  The connection details of the seeder
+// - filePaths:  The server side paths of the files the client is seeding
+//
+func (reg *Registry) Register(client string, info SeedInfo, filePaths ...string) {
+    reg.mutx.Lock()
+    defer reg.mutx.Unlock()
+
+    reg.seeders[client] = &info
+
+    // Iterate through the file paths and add the client as a seeder
+    for _, filePath := range filePaths {
+        reg.files[filePath] = append(reg.files[filePath], client)
+    }
+}
+
+// Removes the client from the seeders, used when a client disconnects.
+//
+// @Parameters
+// - client:  The address of the client to remove
+//
+func (reg *Registry) Remove(client string) {
+    // If peer sharing is not in use
+    if reg == nil {
+        return
+    }
+
+    reg.mutx.Lock()
+    defer reg.mutx.Unlock()
+
+    delete(reg.seeders, client)
+
+    // Iterate through the files and remove the client from their seeders
+    for filePath, clients := range reg.files {
+        remaining := clients[:0]
+
+        for _, seeder := range clients {
+            if seeder != client {
+                remaining = append(remaining, seeder)
+            }
+        }
+
+        reg.files[filePath] = remaining
+    }
+}
+
+// Selects the seeder of the file that has served the fewest fetches, so the
+// load is spread across the fleet instead of a single seeder.
+//
+// @Parameters
+// - filePath:  The server side path of the file to be fetched
+// - exclude:  The address of the requesting client, which can not seed to itself
+//
+// @Returns
+// - The connection details of the selected seeder
+// - Whether a seeder was found
+//
+func (reg *Registry) Select(filePath string, exclude string) (SeedInfo, bool) {
+    // If peer sharing is not in use
+    if reg == nil {
+        return SeedInfo{}, false
+    }
+
+    reg.mutx.Lock()
+    defer reg.mutx.Unlock()
+
+    var selected *SeedInfo
+
+    // Iterate through the seeders of the file
+    for _, client := range reg.files[filePath] {
+        seeder, exists := reg.seeders[client]
+        if !exists || client == exclude {
+            continue
+        }
+
+        // If the seeder has served less than the current selection
+        if selected == nil || seeder.served < selected.served {
+            selected = seeder
+        }
+    }
+
+    // If no seeder is available
+    if selected == nil {
+        return SeedInfo{}, false
+    }
+
+    selected.served += 1
+    return *selected, true
+}
+
+
+// Seeder serves received files to other clients that present a valid token
+type Seeder struct {
+    files      map[string]string
+    listener   net.Listener
+    mutx       sync.Mutex
+    secret     string
+    usedNonces map[string]bool
+}
+
+// Creates a new seeder that verifies tokens with the passed in secret.
+//
+// @Parameters
+// - secret:  The secret used to verify tokens
+//
+// @Returns
+// - The initialized seeder
+//
+func NewSeeder(secret string) *Seeder {
+    return &Seeder{
+        files:      make(map[string]string),
+        secret:     secret,
+        usedNonces: make(map[string]bool),
+    }
+}
+
+// Adds a file to be served under the passed in name.
+//
+// @Parameters
+// - fileName:  The name peers request the file by
+// - filePath:  The local path of the file to be served
+//
+func (seeder *Seeder) AddFile(fileName string, filePath string) {
+    seeder.mutx.Lock()
+    defer seeder.mutx.Unlock()
+
+    seeder.files[fileName] = filePath
+}
+
+// Starts the TLS listener on a random available port and serves peers
+// in a separate goroutine.
+//
+// @Parameters
+// - cert:  The TLS certificate the seeder presents to peers
+//
+// @Returns
+// - The port the seeder is listening on
+// - Error if it occurs, otherwise nil on success
+//
+func (seeder *Seeder) Start(cert tls.Certificate) (int, error) {
+    // Get random available port as a listener
+    listener, port := netio.GetAvailableListener()
+
+    // Wrap the raw listener in TLS
+    seeder.listener = tls.NewListener(listener, &tls.Config{
+        Certificates:     []tls.Certificate{cert},
+        CurvePreferences: []tls.CurveID{tls.CurveP256},
+        MinVersion:       tls.VersionTLS13,
+    })
+
+    go func() {
+        for {
+            // Wait for an incoming peer connection
+            connection, err := seeder.listener.Accept()
+            if err != nil {
+                // If the listener was closed, stop serving
+                if errors.Is(err, net.ErrClosed) {
+                    return
+                }
+
+                continue
+            }
+
+            go seeder.handlePeer(connection)
+        }
+    } ()
+
+    return port, nil
+}
+
+// Stops the seeder listener if it is running.
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (seeder *Seeder) Stop() error {
+    if seeder == nil || seeder.listener == nil {
+        return nil
+    }
+
+    return seeder.listener.Close()
+}
+
+// Reads the peer get request, verifies its token has not been used and is
+// valid for the requested file, then transfers the file to the peer.
+//
+// @Parameters
+// - connection:  The TLS connection from the peer
+//
+func (seeder *Seeder) handlePeer(connection net.Conn) {
+    // Close the peer connection on local exit
+    defer connection.Close()
+
+    buffer := make([]byte, globals.MESSAGE_BUFFER_SIZE)
+    // Read the peer get request, ensuring a stalled peer does not hold the connection open
+    bytesRead, err := netio.ReadHandlerTimeout(connection, &buffer, 30 * time.Second)
+    if err != nil {
+        return
+    }
+
+    // Parse the nonce, MAC, and file name from the request
+    fields, err := splitMessage(buffer[:bytesRead], globals.PEER_GET_PREFIX, 3)
+    if err != nil {
+        return
+    }
+
+    nonce, mac, fileName := fields[0], fields[1], fields[2]
+
+    seeder.mutx.Lock()
+    filePath, exists := seeder.files[fileName]
+    // If the file is not served, the nonce was already used, or the token is invalid
+    if !exists || seeder.usedNonces[nonce] ||
+    !VerifyToken(seeder.secret, nonce, mac, fileName) {
+        seeder.mutx.Unlock()
+        return
+    }
+
+    // Mark the nonce as used so the token can not be replayed
+    seeder.usedNonces[nonce] = true
+    seeder.mutx.Unlock()
+
+    // Get the size of the file to be transferred
+    fileInfo, err := os.Stat(filePath)
+    if err != nil {
+        return
+    }
+
+    // Transfer the file to the peer
+    netio.TransferFile(connection, filePath, fileInfo.Size(), netio.EncodingNone, nil)
+}
+
+
+// Fetches a file from a seeding peer, verifying the peer with its PEM certificate.
+//
+// @Parameters
+// - info:  The fetch info received from the server
+// - certPem:  The PEM certificate of the seeding peer
+// - storePath:  The directory where the fetched file is stored
+//
+// @Returns
+// - The path of the fetched file
+// - Error if it occurs, otherwise nil on success
+//
+func Fetch(info FetchInfo, certPem []byte, storePath string) (string, error) {
+    certPool := x509.NewCertPool()
+    // Add the seeder certificate to the pool
+    if !certPool.AppendCertsFromPEM(certPem) {
+        return "", fmt.Errorf("unable to parse seeder PEM certificate")
+    }
+
+    seederAddr := net.JoinHostPort(info.IpAddr, strconv.Itoa(info.Port))
+    dialer := &net.Dialer{Timeout: 30 * time.Second}
+
+    // Make a connection to the seeding peer
+    connection, err := tls.DialWithDialer(dialer, "tcp", seederAddr,
+                                          tlsutils.NewClientTLSConfig(certPool, info.IpAddr))
+    if err != nil {
+        return "", fmt.Errorf("error connecting to seeder - %w", err)
+    }
+    // Close the connection on local exit
+    defer connection.Close()
+
+    request := []byte(string(globals.PEER_GET_PREFIX) + info.Nonce + ":" + info.Mac + ":" +
+                      info.FileName + string(globals.TRANSFER_SUFFIX))
+    // Send the get request with the one-time token
+    _, err = netio.WriteHandler(connection, request, len(request))
+    if err != nil {
+        return "", err
+    }
+
+    // Receive the file from the seeder
+    filePath, err := netio.HandleTransferRecv(connection, storePath, filepath.Base(info.FileName),
+                                              info.FileSize, netio.EncodingNone, "")
+    if err != nil {
+        return "", err
+    }
+
+    // Ensure the whole file was received since the seeder closes on failure
+    fileInfo, err := os.Stat(filePath)
+    if err != nil || fileInfo.Size() != info.FileSize {
+        os.Remove(filePath)
+        return "", fmt.Errorf("incomplete file received from seeder")
+    }
+
+    return filePath, nil
+}
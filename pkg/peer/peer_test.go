@@ -0,0 +1,156 @@
+package peer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/peer"
+	"github.com/ngimb64/Kloud-Kraken/pkg/tlsutils"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestToken(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    secret, err := peer.GenerateSecret()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Create a token for the test file
+    nonce, mac, err := peer.NewToken(secret, "hashes.txt")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the token is only valid for the file and secret it was created with
+    assert.True(peer.VerifyToken(secret, nonce, mac, "hashes.txt"))
+    assert.False(peer.VerifyToken(secret, nonce, mac, "ruleset.rule"))
+    assert.False(peer.VerifyToken("wrongsecret", nonce, mac, "hashes.txt"))
+}
+
+
+func TestFormatParseFetch(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    info := peer.FetchInfo{
+        FileName: "hash:file.txt",
+        FileSize: 4096,
+        IpAddr:   "10.0.0.5",
+        Mac:      "abcdef",
+        Nonce:    "123456",
+        Port:     40000,
+    }
+
+    // Format the fetch info then parse it back
+    parsed, err := peer.ParseFetch(peer.FormatFetch(info))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the file name with delimiter was preserved
+    assert.Equal(info, parsed)
+
+    // Format the seed message then parse it back
+    port, secret, err := peer.ParseSeed(peer.FormatSeed(40001, "secret"))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(40001, port)
+    assert.Equal("secret", secret)
+
+    // Ensure improper messages are rejected
+    _, err = peer.ParseFetch([]byte("<PEER_FETCH:40000:missing>"))
+    assert.NotEqual(nil, err)
+    _, _, err = peer.ParseSeed([]byte("<PEER_SEED:port:secret>"))
+    assert.NotEqual(nil, err)
+}
+
+
+func TestRegistry(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    registry := peer.NewRegistry()
+    registry.Register("10.0.0.1:5000", peer.SeedInfo{IpAddr: "10.0.0.1", Port: 1},
+                      "/hashes.txt")
+    registry.Register("10.0.0.2:5000", peer.SeedInfo{IpAddr: "10.0.0.2", Port: 2},
+                      "/hashes.txt")
+
+    // Ensure a client is never selected to seed to itself
+    seed, found := registry.Select("/hashes.txt", "10.0.0.1:5000")
+    assert.True(found)
+    assert.Equal("10.0.0.2", seed.IpAddr)
+
+    // Ensure the least used seeder is selected next
+    seed, found = registry.Select("/hashes.txt", "10.0.0.3:5000")
+    assert.True(found)
+    assert.Equal("10.0.0.1", seed.IpAddr)
+
+    // Ensure removed clients are no longer selected
+    registry.Remove("10.0.0.1:5000")
+    registry.Remove("10.0.0.2:5000")
+    _, found = registry.Select("/hashes.txt", "10.0.0.3:5000")
+    assert.False(found)
+
+    var nilRegistry *peer.Registry
+    // Ensure a disabled registry never selects a seeder
+    _, found = nilRegistry.Select("/hashes.txt", "10.0.0.3:5000")
+    assert.False(found)
+}
+
+
+func TestSeederFetch(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    tlsMan := new(tlsutils.TlsManager)
+    // Generate the seeder TLS certificate and key
+    err := tlsMan.PemCertAndKeyGenHandler("Kloud Kraken", false)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    err = tlsMan.CertGenAndPool(tlsMan.CertPemBlock, tlsMan.KeyPemBlock, tlsMan.CaCertPemBlocks)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    seedDir := t.TempDir()
+    fetchDir := t.TempDir()
+    seedPath := filepath.Join(seedDir, "hashes.txt")
+    // Write the file to be seeded
+    err = os.WriteFile(seedPath, []byte("hash1\nhash2\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    secret, err := peer.GenerateSecret()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    seeder := peer.NewSeeder(secret)
+    seeder.AddFile("hashes.txt", seedPath)
+    // Start the seeder listener
+    port, err := seeder.Start(tlsMan.TlsCertificate)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    defer seeder.Stop()
+
+    nonce, mac, err := peer.NewToken(secret, "hashes.txt")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    info := peer.FetchInfo{FileName: "hashes.txt", FileSize: 12, IpAddr: "localhost",
+                           Mac: mac, Nonce: nonce, Port: port}
+
+    // Fetch the file from the seeder
+    filePath, err := peer.Fetch(info, tlsMan.CertPemBlock, fetchDir)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    fetchedData, err := os.ReadFile(filePath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the fetched data matches the seeded file
+    assert.Equal("hash1\nhash2\n", string(fetchedData))
+
+    // Ensure the one-time token can not be replayed
+    _, err = peer.Fetch(info, tlsMan.CertPemBlock, fetchDir)
+    assert.NotEqual(nil, err)
+}
@@ -0,0 +1,415 @@
+package potfile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/netio"
+)
+
+// Max bytes of cracked hashes sent in a single flush
+const MaxFlushSize = 256 * 1024
+// Prefix shared by the markers written to the loot file between cracked hashes
+const markerPrefix = "#KLOUD_KRAKEN_"
+
+
+// Flusher sends the cracked hashes appended to the loot file since the last flush, once
+// enough hashes are cracked or on an interval, so a client that crashes before its
+// final upload has already delivered most of its results
+type Flusher struct {
+    cracks    int
+    interval  time.Duration
+    lootPath  string
+    mutx      sync.Mutex
+    offset    int64
+    signalCh  chan struct{}
+    stopCh    chan struct{}
+    stopOnce  sync.Once
+    threshold int
+    wg        sync.WaitGroup
+}
+
+// Creates a flusher of the loot file at the passed in path.
+//
+// @Parameters
+// - lootPath:  The path of the loot file cracked hashes are appended to
+// - threshold:  The number of cracked hashes that triggers a flush, 0 disables it
+// - interval:  The duration of time between flushes, 0 disables it
+//
+// @Returns
+// - The initialized flusher
+//
+func NewFlusher(lootPath string, threshold int, interval time.Duration) *Flusher {
+    return &Flusher{
+        interval:  interval,
+        lootPath:  lootPath,
+        signalCh:  make(chan struct{}, 1),
+        stopCh:    make(chan struct{}),
+        threshold: threshold,
+    }
+}
+
+// Starts flushing in a Goroutine until Stop() is called.
+//
+// @Parameters
+// - send:  Sends a formatted flush message, called once per flush
+//
+func (flusher *Flusher) Start(send func(message []byte) error) {
+    flusher.wg.Add(1)
+
+    go func() {
+        defer flusher.wg.Done()
+
+        var tickerCh <-chan time.Time
+        // If flushes are sent on an interval
+        if flusher.interval > 0 {
+            ticker := time.NewTicker(flusher.interval)
+            defer ticker.Stop()
+            tickerCh = ticker.C
+        }
+
+        for {
+            select {
+            case <-tickerCh:
+                flusher.Flush(send)
+            case <-flusher.signalCh:
+                flusher.Flush(send)
+            case <-flusher.stopCh:
+                return
+            }
+        }
+    } ()
+}
+
+// Counts hashes appended to the loot file, signaling a flush once the threshold of
+// unflushed hashes is reached.
+//
+// @Parameters
+// - count:  The number of cracked hashes appended
+//
+func (flusher *Flusher) Cracked(count int) {
+    // If incremental flushes are disabled
+    if flusher == nil || count <= 0 {
+        return
+    }
+
+    flusher.mutx.Lock()
+    flusher.cracks += count
+    reached := flusher.threshold > 0 && flusher.cracks >= flusher.threshold
+    flusher.mutx.Unlock()
+
+    // If enough hashes are unflushed, signal without blocking the hashcat job
+    if reached {
+        select {
+        case flusher.signalCh <- struct{}{}:
+        default:
+        }
+    }
+}
+
+// Sends the complete lines appended to the loot file since the last flush, split into
+// flushes of at most MaxFlushSize bytes.
+//
+// @Parameters
+// - send:  Sends a formatted flush message, called once per flush
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (flusher *Flusher) Flush(send func(message []byte) error) error {
+    flusher.mutx.Lock()
+    defer flusher.mutx.Unlock()
+
+    file, err := os.Open(flusher.lootPath)
+    if err != nil {
+        // If nothing has been cracked to a file yet
+        if os.IsNotExist(err) {
+            return nil
+        }
+
+        return err
+    }
+    // Close the file on local exit
+    defer file.Close()
+
+    chunk := make([]byte, MaxFlushSize)
+
+    for {
+        bytesRead, err := file.ReadAt(chunk, flusher.offset)
+        if err != nil && err != io.EOF {
+            return err
+        }
+
+        lastNewline := bytes.LastIndexByte(chunk[:bytesRead], '\n')
+        // If there is no new complete line, a partially appended one waits until it is
+        if lastNewline == -1 {
+            flusher.cracks = 0
+            return nil
+        }
+
+        data := chunk[:lastNewline+1]
+        err = send(FormatFlush(data))
+        if err != nil {
+            return err
+        }
+
+        flusher.offset += int64(len(data))
+    }
+}
+
+// Stops the flusher, waiting for a flush in progress to complete. The final loot
+// upload carries anything cracked since the last flush.
+//
+func (flusher *Flusher) Stop() {
+    // If the flusher was never created
+    if flusher == nil {
+        return
+    }
+
+    flusher.stopOnce.Do(func() {
+        close(flusher.stopCh)
+    })
+
+    flusher.wg.Wait()
+}
+
+
+// Formats a flush message with the cracked hashes following the header.
+//
+// @Parameters
+// - data:  The cracked hash lines of the flush
+//
+// @Returns
+// - The formatted flush message
+//
+func FormatFlush(data []byte) []byte {
+    message := slices.Clone(globals.LOOT_FLUSH_PREFIX)
+    message = append(message, strconv.Itoa(len(data))...)
+    message = append(message, globals.TRANSFER_SUFFIX...)
+
+    return append(message, data...)
+}
+
+
+// Reads the cracked hashes of a flush, starting with any data read along with the header.
+//
+// @Parameters
+// - connection:  The connection the flush is read from
+// - message:  The message read from the connection starting with the flush header
+//
+// @Returns
+// - The cracked hash lines of the flush
+// - Error if it occurs, otherwise nil on success
+//
+func ReadFlush(connection io.Reader, message []byte) ([]byte, error) {
+    // If the message does not start with the flush header
+    if !bytes.HasPrefix(message, globals.LOOT_FLUSH_PREFIX) {
+        return nil, fmt.Errorf("message is not a loot flush - %w",
+                               netio.ErrMalformedMessage)
+    }
+
+    message = message[len(globals.LOOT_FLUSH_PREFIX):]
+    suffixPos := bytes.Index(message, globals.TRANSFER_SUFFIX)
+    // If the header is not terminated
+    if suffixPos == -1 {
+        return nil, fmt.Errorf("invalid loot flush header, suffix missing - %w",
+                               netio.ErrMalformedMessage)
+    }
+
+    size, err := strconv.Atoi(string(message[:suffixPos]))
+    // If the size is not a number or larger than a flush can be
+    if err != nil || size < 0 || size > MaxFlushSize {
+        return nil, fmt.Errorf("invalid loot flush size - %q - %w", message[:suffixPos],
+                               netio.ErrMalformedMessage)
+    }
+
+    data := make([]byte, size)
+    // Copy the data read along with the header
+    copied := copy(data, message[suffixPos+1:])
+
+    // Read the rest of the flush data
+    _, err = io.ReadFull(connection, data[copied:])
+    if err != nil {
+        return nil, fmt.Errorf("error reading loot flush - %w", err)
+    }
+
+    return data, nil
+}
+
+
+// Potfile is the consolidated file of every unique cracked hash of the run, fed by the
+// incremental flushes and final loot of each client
+type Potfile struct {
+    file  *os.File
+    lines map[string]struct{}
+    mutx  sync.Mutex
+    path  string
+}
+
+// Opens the potfile at the passed in path, loading any cracked hashes it already has so
+// they are not written again.
+//
+// @Parameters
+// - path:  The path of the potfile
+//
+// @Returns
+// - The opened potfile
+// - Error if it occurs, otherwise nil on success
+//
+func Open(path string) (*Potfile, error) {
+    file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+    if err != nil {
+        return nil, fmt.Errorf("error opening potfile - %w", err)
+    }
+
+    pot := &Potfile{file: file, lines: map[string]struct{}{}, path: path}
+
+    scanner := bufio.NewScanner(file)
+    scanner.Buffer(make([]byte, 0, 64 * 1024), MaxFlushSize)
+    // Iterate through the existing lines of the potfile
+    for scanner.Scan() {
+        pot.lines[scanner.Text()] = struct{}{}
+    }
+
+    err = scanner.Err()
+    if err != nil {
+        file.Close()
+        return nil, fmt.Errorf("error reading potfile - %w", err)
+    }
+
+    return pot, nil
+}
+
+// Appends the cracked hash lines not already in the potfile, skipping the loot markers.
+//
+// @Parameters
+// - data:  The cracked hash lines to be added
+//
+// @Returns
+// - The number of new cracked hashes written
+// - Error if it occurs, otherwise nil on success
+//
+func (pot *Potfile) Add(data []byte) (int, error) {
+    // If the potfile is disabled
+    if pot == nil {
+        return 0, nil
+    }
+
+    pot.mutx.Lock()
+    defer pot.mutx.Unlock()
+
+    var added bytes.Buffer
+    count := 0
+
+    // Iterate through the lines of the data
+    for _, line := range bytes.Split(data, []byte("\n")) {
+        line = bytes.TrimRight(line, "\r")
+        // If the line is empty, a marker, or the no cracked hashes message
+        if len(line) == 0 || bytes.HasPrefix(line, []byte(markerPrefix)) ||
+           bytes.Equal(line, globals.NO_CRACKED_HASHES) {
+            continue
+        }
+
+        // If the cracked hash is already in the potfile
+        if _, exists := pot.lines[string(line)]; exists {
+            continue
+        }
+
+        pot.lines[string(line)] = struct{}{}
+        added.Write(line)
+        added.WriteByte('\n')
+        count++
+    }
+
+    // If there are no new cracked hashes
+    if count == 0 {
+        return 0, nil
+    }
+
+    _, err := pot.file.Write(added.Bytes())
+    if err != nil {
+        return 0, fmt.Errorf("error writing potfile - %w", err)
+    }
+
+    return count, nil
+}
+
+// Adds the cracked hashes of the loot file at the passed in path.
+//
+// @Parameters
+// - lootPath:  The path of the loot file
+//
+// @Returns
+// - The number of new cracked hashes written
+// - Error if it occurs, otherwise nil on success
+//
+func (pot *Potfile) AddFile(lootPath string) (int, error) {
+    // If the potfile is disabled
+    if pot == nil {
+        return 0, nil
+    }
+
+    lootData, err := os.ReadFile(lootPath)
+    if err != nil {
+        return 0, err
+    }
+
+    return pot.Add(lootData)
+}
+
+// Gets the number of unique cracked hashes in the potfile.
+//
+// @Returns
+// - The number of unique cracked hashes
+//
+func (pot *Potfile) Count() int {
+    // If the potfile is disabled
+    if pot == nil {
+        return 0
+    }
+
+    pot.mutx.Lock()
+    defer pot.mutx.Unlock()
+
+    return len(pot.lines)
+}
+
+// Gets the path of the potfile.
+//
+// @Returns
+// - The path of the potfile
+//
+func (pot *Potfile) Path() string {
+    return pot.path
+}
+
+// Syncs and closes the potfile.
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (pot *Potfile) Close() error {
+    // If the potfile is disabled
+    if pot == nil {
+        return nil
+    }
+
+    pot.mutx.Lock()
+    defer pot.mutx.Unlock()
+
+    err := pot.file.Sync()
+    if err != nil {
+        pot.file.Close()
+        return err
+    }
+
+    return pot.file.Close()
+}
@@ -0,0 +1,116 @@
+package potfile_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/fuzzseed"
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/potfile"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestFlusher(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    lootPath := filepath.Join(t.TempDir(), "loot.txt")
+    flusher := potfile.NewFlusher(lootPath, 2, 0)
+    flushes := make(chan []byte, 4)
+
+    flusher.Start(func(message []byte) error {
+        data, err := potfile.ReadFlush(bytes.NewReader(nil), message)
+        flushes <- data
+        return err
+    })
+    defer flusher.Stop()
+
+    // Write a marker, a complete crack, and a partially appended one
+    err := os.WriteFile(lootPath, []byte("#KLOUD_KRAKEN_SOURCE:a\nhash1:pass1\nhash2:pa"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure nothing is flushed until the threshold is reached
+    flusher.Cracked(1)
+    select {
+    case <-flushes:
+        t.Fatal("flushed before the threshold was reached")
+    case <-time.After(50 * time.Millisecond):
+    }
+
+    flusher.Cracked(1)
+    select {
+    case data := <-flushes:
+        assert.Equal("#KLOUD_KRAKEN_SOURCE:a\nhash1:pass1\n", string(data))
+    case <-time.After(time.Second):
+        t.Fatal("threshold did not trigger a flush")
+    }
+}
+
+
+func TestPotfile(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    potPath := filepath.Join(t.TempDir(), "run.potfile")
+    pot, err := potfile.Open(potPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    added, err := pot.Add([]byte("#KLOUD_KRAKEN_SOURCE:a\nhash1:pass1\nhash2:pass2\n"))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(2, added)
+
+    // Ensure cracks flushed earlier are not written again by the final loot
+    lootPath := filepath.Join(t.TempDir(), "loot.txt")
+    err = os.WriteFile(lootPath, []byte("hash1:pass1\r\nhash3:pass3\n" +
+                                        string(globals.NO_CRACKED_HASHES)), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    added, err = pot.AddFile(lootPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(1, added)
+    assert.Equal(3, pot.Count())
+    assert.Equal(nil, pot.Close())
+
+    // Ensure a reopened potfile keeps deduplicating against its lines
+    pot, err = potfile.Open(potPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    added, _ = pot.Add([]byte("hash2:pass2\n"))
+    assert.Equal(0, added)
+    pot.Close()
+
+    potData, _ := os.ReadFile(potPath)
+    assert.Equal("hash1:pass1\nhash2:pass2\nhash3:pass3\n", string(potData))
+
+    // Ensure malformed flush headers are rejected
+    for _, falacy := range []string{"<LOOT_FLUSH:abc>", "<LOOT_FLUSH:10", "<LOG_BATCH:1>a"} {
+        _, err = potfile.ReadFlush(bytes.NewReader(nil), []byte(falacy))
+        assert.NotEqual(nil, err)
+    }
+}
+
+
+func FuzzReadFlush(f *testing.F) {
+    fuzzseed.Framed(f, globals.LOOT_FLUSH_PREFIX, "hash1:pass1\n")
+
+    f.Fuzz(func(t *testing.T, message []byte, rest []byte) {
+        data, err := potfile.ReadFlush(bytes.NewReader(rest), message)
+        // If the flush was rejected
+        if err != nil {
+            return
+        }
+
+        // Ensure accepted flushes are within the max size
+        if len(data) > potfile.MaxFlushSize {
+            t.Fatalf("accepted flush of %d bytes", len(data))
+        }
+    })
+}
@@ -0,0 +1,247 @@
+package protocol
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+)
+
+// Version of the wire protocol spoken by this build, bumped on any breaking change
+const Version = 2
+// Oldest protocol version a peer can speak and still be accepted
+const MinVersion = 2
+// Number of random bytes in a connection token, hex encoded when sent
+const TokenSize = 16
+
+// Optional capabilities advertised in the hello exchange
+const (
+    FeatureAdmission     = "admission"       // Transfers requested with the free client space
+    FeatureAudit         = "audit"           // Hashcat executions reported for the audit log
+    FeatureCertRotation  = "cert_rotation"   // Rotated server certificates sent on request
+    FeatureCompression   = "compression"     // Wordlists transferred with gzip encoding
+    FeatureDevices       = "devices"         // Backend devices assigned by the server
+    FeatureHibernate     = "hibernate"       // Hashcat checkpointed before the fleet is stopped
+    FeatureKeepalive     = "keepalive"       // Idle connections probed to detect hung peers
+    FeatureKeyspace      = "keyspace"        // Mask keyspace processed in assigned ranges
+    FeatureLootFlush     = "loot_flush"      // Cracked hashes flushed before the final loot
+    FeatureManifest      = "manifest"        // Wordlists verified against a sent digest
+    FeatureMetadata      = "metadata"        // Instance metadata reported after the GPU inventory
+    FeatureParallel      = "parallel"        // Large wordlists split over parallel connections
+    FeaturePlaintext     = "plaintext"       // Wordlist data sent over plaintext TCP in the VPC
+    FeatureRestore       = "restore"         // Restore files returned when a run is aborted
+    FeatureResultsAck    = "results_ack"     // Stored results acknowledged before client cleanup
+    FeatureStatus        = "status"          // Hashcat status forwarded while cracking
+    FeatureWordlistStats = "wordlist_stats"  // Cracked hashes reported per wordlist
+    FeatureWorkStealing  = "work_stealing"   // Unstarted wordlists split with idle clients
+)
+
+// Package level variables
+var Supported = []string{FeatureAdmission, FeatureAudit, FeatureCertRotation,
+                         FeatureCompression, FeatureDevices, FeatureHibernate, FeatureKeepalive,
+                         FeatureKeyspace, FeatureLootFlush, FeatureManifest, FeatureMetadata,
+                         FeatureParallel, FeaturePlaintext, FeatureRestore, FeatureResultsAck,
+                         FeatureStatus, FeatureWordlistStats, FeatureWorkStealing}
+
+
+// Hello is the protocol version and features a peer speaks, or the negotiated
+// session once both sides have exchanged theirs
+type Hello struct {
+    Features []string
+    Token    string  // Per run token the client authenticates with, empty when unused
+    Version  int
+}
+
+// Creates the hello of this build with every supported feature.
+//
+// @Returns
+// - The hello advertising this build
+//
+func Local() Hello {
+    return Hello{Features: slices.Clone(Supported), Version: Version}
+}
+
+// Checks whether the passed in feature is supported.
+//
+// @Parameters
+// - feature:  The feature to check for
+//
+// @Returns
+// - true/false depending on whether the feature is supported
+//
+func (hello Hello) Supports(feature string) bool {
+    return slices.Contains(hello.Features, feature)
+}
+
+
+// Negotiates the session between the local and remote hello, downgrading to the lower
+// version and the features both sides support.
+//
+// @Parameters
+// - local:  The hello of this side of the connection
+// - remote:  The hello received from the peer
+//
+// @Returns
+// - The negotiated session
+// - Error if the peer speaks a version that can not be downgraded to
+//
+func Negotiate(local Hello, remote Hello) (Hello, error) {
+    // If the peer is too old to speak a compatible version
+    if remote.Version < MinVersion {
+        return Hello{}, fmt.Errorf("peer protocol version %d is older than the minimum %d",
+                                   remote.Version, MinVersion)
+    }
+
+    session := Hello{Version: min(local.Version, remote.Version)}
+    // Iterate through the local features keeping the ones the peer supports
+    for _, feature := range local.Features {
+        if remote.Supports(feature) {
+            session.Features = append(session.Features, feature)
+        }
+    }
+
+    return session, nil
+}
+
+
+// Formats the hello message sent when a connection is established.
+//
+// @Parameters
+// - hello:  The version and features to advertise
+//
+// @Returns
+// - The formatted hello message
+//
+func FormatHello(hello Hello) []byte {
+    message := append([]byte{}, globals.HELLO_PREFIX...)
+    message = strconv.AppendInt(message, int64(hello.Version), 10)
+    message = append(message, globals.COLON_DELIMITER...)
+    message = append(message, strings.Join(hello.Features, ",")...)
+    // If a token is presented, append it as the last field
+    if hello.Token != "" {
+        message = append(message, globals.COLON_DELIMITER...)
+        message = append(message, hello.Token...)
+    }
+    return append(message, globals.TRANSFER_SUFFIX...)
+}
+
+
+// Parses the hello message sent when a connection is established. Unknown features
+// are kept so they are dropped in negotiation rather than rejected, and the token is
+// an optional trailing field.
+//
+// @Parameters
+// - message:  The message containing the hello
+//
+// @Returns
+// - The parsed hello
+// - Error if it occurs, otherwise nil on success
+//
+func ParseHello(message []byte) (Hello, error) {
+    // If the message does not start with prefix or end with closed bracket
+    if !bytes.HasPrefix(message, globals.HELLO_PREFIX) ||
+       !bytes.HasSuffix(message, globals.TRANSFER_SUFFIX) {
+        return Hello{}, fmt.Errorf("improper prefix or suffix in hello message")
+    }
+
+    // Strip the prefix and suffix then split on the delimiter
+    body := bytes.TrimSuffix(bytes.TrimPrefix(message, globals.HELLO_PREFIX),
+                             globals.TRANSFER_SUFFIX)
+    parts := bytes.Split(body, globals.COLON_DELIMITER)
+    if len(parts) != 2 && len(parts) != 3 {
+        return Hello{}, fmt.Errorf("improper number of fields in hello message")
+    }
+
+    // Parse the protocol version
+    version, err := strconv.Atoi(string(parts[0]))
+    if err != nil || version < 1 {
+        return Hello{}, fmt.Errorf("improper version in hello message")
+    }
+
+    hello := Hello{Version: version}
+    // If any features were advertised
+    if len(parts[1]) > 0 {
+        hello.Features = strings.Split(string(parts[1]), ",")
+    }
+    // If a token was presented
+    if len(parts) == 3 {
+        hello.Token = string(parts[2])
+    }
+
+    return hello, nil
+}
+
+
+// Generates a random token clients of a run present in their hello.
+//
+// @Returns
+// - The hex encoded token
+// - Error if it occurs, otherwise nil on success
+//
+func NewToken() (string, error) {
+    token := make([]byte, TokenSize)
+    _, err := rand.Read(token)
+    if err != nil {
+        return "", fmt.Errorf("error generating connection token - %w", err)
+    }
+
+    return hex.EncodeToString(token), nil
+}
+
+
+// Checks whether the presented token matches the expected one in constant time, so
+// response timing does not leak how much of a guess was correct.
+//
+// @Parameters
+// - expected:  The token of the run
+// - presented:  The token sent in the client hello
+//
+// @Returns
+// - true/false depending on whether the tokens match
+//
+func TokenMatches(expected string, presented string) bool {
+    return subtle.ConstantTimeCompare([]byte(expected), []byte(presented)) == 1
+}
+
+
+// Formats the message refusing a peer, sent in place of the hello reply.
+//
+// @Parameters
+// - reason:  Why the peer was refused
+//
+// @Returns
+// - The formatted refused message
+//
+func FormatRefused(reason string) []byte {
+    message := append([]byte{}, globals.HELLO_REFUSED_PREFIX...)
+    message = append(message, reason...)
+    return append(message, globals.TRANSFER_SUFFIX...)
+}
+
+
+// Parses the hello reply of the server, either its negotiated hello or the reason
+// the client was refused.
+//
+// @Parameters
+// - message:  The hello reply message
+//
+// @Returns
+// - The negotiated session
+// - Error with the refusal reason if refused, or if the reply is improper
+//
+func ParseReply(message []byte) (Hello, error) {
+    // If the server refused the connection
+    if bytes.HasPrefix(message, globals.HELLO_REFUSED_PREFIX) {
+        reason := bytes.TrimSuffix(bytes.TrimPrefix(message, globals.HELLO_REFUSED_PREFIX),
+                                   globals.TRANSFER_SUFFIX)
+        return Hello{}, fmt.Errorf("refused by server - %s", reason)
+    }
+
+    return ParseHello(message)
+}
@@ -0,0 +1,172 @@
+package protocol_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestNegotiate(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    local := protocol.Hello{Features: []string{protocol.FeatureCompression,
+                                               protocol.FeatureKeyspace}, Version: 3}
+    remote := protocol.Hello{Features: []string{protocol.FeatureKeyspace, "future"},
+                             Version: protocol.MinVersion}
+
+    // Ensure the session downgrades to the lower version and the shared features
+    session, err := protocol.Negotiate(local, remote)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(protocol.MinVersion, session.Version)
+    assert.Equal([]string{protocol.FeatureKeyspace}, session.Features)
+    assert.True(session.Supports(protocol.FeatureKeyspace))
+    assert.False(session.Supports(protocol.FeatureCompression))
+
+    // Ensure a peer older than the minimum version is refused
+    _, err = protocol.Negotiate(local, protocol.Hello{Version: protocol.MinVersion - 1})
+    assert.NotNil(err)
+}
+
+
+func TestFormatParseHello(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    hello, err := protocol.ParseHello(protocol.FormatHello(protocol.Local()))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(protocol.Local(), hello)
+
+    // Ensure a hello without features parses with none
+    hello, err = protocol.ParseHello([]byte("<HELLO:2:>"))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(protocol.Hello{Version: 2}, hello)
+
+    // Ensure a presented token is carried through the hello
+    local := protocol.Local()
+    local.Token = "0123456789abcdef"
+    hello, err = protocol.ParseHello(protocol.FormatHello(local))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(local, hello)
+
+    falacies := []string{"<HELLO:2>", "<HELLO:x:compression>", "<HELLO:0:>",
+                         "<HELLO:2:compression", "<HELLO:2:compression:token:extra>",
+                         "-----BEGIN CERTIFICATE-----"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        _, err = protocol.ParseHello([]byte(falacy))
+        assert.NotNil(err)
+    }
+}
+
+
+func TestParseReply(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    session, err := protocol.ParseReply(protocol.FormatHello(protocol.Local()))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(protocol.Version, session.Version)
+
+    // Ensure a refusal is returned as an error with its reason
+    _, err = protocol.ParseReply(protocol.FormatRefused("version too old"))
+    assert.ErrorContains(err, "version too old")
+}
+
+
+func TestToken(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    token, err := protocol.NewToken()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Len(token, protocol.TokenSize * 2)
+
+    // Ensure each run gets a different token
+    other, err := protocol.NewToken()
+    assert.Equal(nil, err)
+    assert.NotEqual(token, other)
+
+    assert.True(protocol.TokenMatches(token, token))
+    falacies := []string{other, "", token[:len(token) - 1]}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        assert.False(protocol.TokenMatches(token, falacy))
+    }
+}
+
+
+// Adds the hello and refusal messages shared by the hello fuzz targets to their corpus
+func helloSeeds(f *testing.F) {
+    local := protocol.Local()
+    local.Token = "0123456789abcdef"
+
+    seeds := [][]byte{protocol.FormatHello(protocol.Local()), protocol.FormatHello(local),
+                      protocol.FormatRefused("version too old"), []byte("<HELLO:2:>"),
+                      []byte("<HELLO:x:compression>"), []byte("<HELLO:2:compression"),
+                      []byte("<HELLO:2:a:b:c>"), []byte("<HELLO_REFUSED:")}
+    // Iterate through the seeds adding each to the corpus
+    for _, seed := range seeds {
+        f.Add(seed)
+    }
+}
+
+
+func FuzzParseHello(f *testing.F) {
+    helloSeeds(f)
+
+    f.Fuzz(func(t *testing.T, message []byte) {
+        hello, err := protocol.ParseHello(message)
+        // If the hello was rejected
+        if err != nil {
+            return
+        }
+
+        // Ensure an accepted hello formats back to a hello parsing the same
+        reparsed, err := protocol.ParseHello(protocol.FormatHello(hello))
+        if err != nil || !reflect.DeepEqual(hello, reparsed) {
+            t.Fatalf("hello %+v reparsed as %+v - %v", hello, reparsed, err)
+        }
+
+        session, err := protocol.Negotiate(protocol.Local(), hello)
+        // Ensure a negotiated session never exceeds the version of either side
+        if err == nil && (session.Version > hello.Version ||
+                          session.Version > protocol.Version) {
+            t.Fatalf("negotiated version %d from %d", session.Version, hello.Version)
+        }
+    })
+}
+
+
+func FuzzParseReply(f *testing.F) {
+    helloSeeds(f)
+
+    f.Fuzz(func(t *testing.T, message []byte) {
+        session, err := protocol.ParseReply(message)
+
+        // Ensure a refusal is never accepted as a session
+        if bytes.HasPrefix(message, globals.HELLO_REFUSED_PREFIX) {
+            if err == nil {
+                t.Fatalf("refusal accepted as session %+v", session)
+            }
+            return
+        }
+
+        // Ensure any other reply parses the same as a hello
+        hello, helloErr := protocol.ParseHello(message)
+        if (err == nil) != (helloErr == nil) || !reflect.DeepEqual(hello, session) {
+            t.Fatalf("reply parsed as %+v but hello as %+v", session, hello)
+        }
+    })
+}
@@ -0,0 +1,78 @@
+package publicip
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+
+// cacheEntry is the discovered IPs stored on disk with when they were discovered
+type cacheEntry struct {
+    Ips  []string  `json:"ips"`
+    Time time.Time `json:"time"`
+}
+
+
+// Loads the cached IPs if they were discovered within the time to live.
+//
+// @Parameters
+// - cachePath:  The path of the cache file
+// - ttl:  How long cached IPs are used for
+// - now:  The current time
+//
+// @Returns
+// - The cached IPs
+// - Whether the cache exists, is readable, and is still fresh
+//
+func LoadCache(cachePath string, ttl time.Duration, now time.Time) ([]string, bool) {
+    data, err := os.ReadFile(cachePath)
+    if err != nil {
+        return nil, false
+    }
+
+    var entry cacheEntry
+    // If the cache is corrupt, the IPs are rediscovered and rewritten
+    if json.Unmarshal(data, &entry) != nil || len(entry.Ips) == 0 {
+        return nil, false
+    }
+
+    // If the IPs were discovered too long ago, or in the future after a clock change
+    if now.Sub(entry.Time) > ttl || entry.Time.After(now) {
+        return nil, false
+    }
+
+    return entry.Ips, true
+}
+
+
+// Saves the discovered IPs to the cache file, creating its dir if missing.
+//
+// @Parameters
+// - cachePath:  The path of the cache file
+// - ipAddrs:  The discovered IPs
+// - now:  The time the IPs were discovered
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func SaveCache(cachePath string, ipAddrs []string, now time.Time) error {
+    data, err := json.Marshal(cacheEntry{Ips: ipAddrs, Time: now})
+    if err != nil {
+        return fmt.Errorf("error encoding public IP cache - %w", err)
+    }
+
+    err = os.MkdirAll(filepath.Dir(cachePath), 0700)
+    if err != nil {
+        return fmt.Errorf("error creating public IP cache dir - %w", err)
+    }
+
+    err = os.WriteFile(cachePath, data, 0600)
+    if err != nil {
+        return fmt.Errorf("error writing public IP cache - %w", err)
+    }
+
+    return nil
+}
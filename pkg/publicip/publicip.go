@@ -0,0 +1,239 @@
+package publicip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Max time each resolver has to discover the public IPs
+const DefaultTimeout = 5 * time.Second
+
+// Package level variables
+var Client = &http.Client{Timeout: 5 * time.Minute}  // Shared client reusing connections
+var DefaultEndpoints = []string{"https://api.ipify.org", "https://ifconfig.me/ip",
+                                "https://checkip.amazonaws.com", "https://icanhazip.com"}
+var DefaultStunServers = []string{"stun.l.google.com:19302", "stun.cloudflare.com:3478"}
+var ReIpAddr = regexp.MustCompile(
+    `\b(?:\d{1,3}\.){3}\d{1,3}\b|` +  // IPv4
+    `\b(?:[0-9A-Fa-f]{1,4}:){2,7}[0-9A-Fa-f]{1,4}\b`,  // IPv6 (simple form)
+)
+
+
+// Resolver discovers the public IPs the host is reached at
+type Resolver interface {
+    Resolve(ctx context.Context) ([]string, error)
+}
+
+
+// HttpResolver discovers the public IPs from an HTTP service echoing the address the
+// request came from
+type HttpResolver struct {
+    Url string
+}
+
+
+// Sends a GET request to the service, parsing the IPs out of the response.
+//
+// @Parameters
+// - ctx:  The context the request is cancelled with
+//
+// @Returns
+// - The IP addresses in the response
+// - Error if it occurs, otherwise nil on success
+//
+func (resolver *HttpResolver) Resolve(ctx context.Context) ([]string, error) {
+    request, err := http.NewRequestWithContext(ctx, http.MethodGet, resolver.Url, nil)
+    if err != nil {
+        return nil, err
+    }
+
+    response, err := Client.Do(request)
+    if err != nil {
+        return nil, err
+    }
+    // Close the response body on local exit
+    defer response.Body.Close()
+
+    // If the service did not reply with the address
+    if response.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("%s replied with status %d", resolver.Url, response.StatusCode)
+    }
+
+    data, err := io.ReadAll(io.LimitReader(response.Body, 4096))
+    if err != nil {
+        return nil, err
+    }
+
+    return ReIpAddr.FindAllString(strings.TrimSpace(string(data)), -1), nil
+}
+
+
+// Discoverer finds the public IPs of the host from its primary resolvers, falling back to
+// the others when none are found, and optionally caches them on disk
+type Discoverer struct {
+    CachePath string         // File the discovered IPs are cached in, empty disables caching
+    CacheTtl  time.Duration  // How long cached IPs are used before rediscovery
+    Fallback  []Resolver     // Tried only when the primary resolvers find no IPs
+    Primary   []Resolver
+    Timeout   time.Duration  // Max time each resolver has to respond
+}
+
+
+// Creates a discoverer querying the HTTP endpoints and falling back to the STUN servers.
+//
+// @Parameters
+// - endpoints:  The URLs of the HTTP services, the defaults when empty
+// - stunServers:  The host:port addresses of the STUN servers, the defaults when empty
+//
+// @Returns
+// - The initialized discoverer
+//
+func NewDiscoverer(endpoints []string, stunServers []string) *Discoverer {
+    // If no endpoints are configured, use the defaults
+    if len(endpoints) == 0 {
+        endpoints = DefaultEndpoints
+    }
+
+    // If no STUN servers are configured, use the defaults
+    if len(stunServers) == 0 {
+        stunServers = DefaultStunServers
+    }
+
+    discoverer := &Discoverer{Timeout: DefaultTimeout}
+    for _, endpoint := range endpoints {
+        discoverer.Primary = append(discoverer.Primary, &HttpResolver{Url: endpoint})
+    }
+    for _, server := range stunServers {
+        discoverer.Fallback = append(discoverer.Fallback, &StunResolver{Server: server})
+    }
+
+    return discoverer
+}
+
+
+// Discovers the public IPs of the host, using the cached IPs while they are fresh.
+//
+// @Returns
+// - The unique public IPs in the order they were discovered
+// - Error if no IPs could be discovered, otherwise nil on success
+//
+func (discoverer *Discoverer) Discover() ([]string, error) {
+    // If caching is enabled and the cached IPs are still fresh
+    if discoverer.CachePath != "" {
+        ipAddrs, ok := LoadCache(discoverer.CachePath, discoverer.CacheTtl, time.Now())
+        if ok {
+            return ipAddrs, nil
+        }
+    }
+
+    ipAddrs := discoverer.resolveAll(discoverer.Primary)
+    // If the primary resolvers found nothing, such as when HTTP egress is blocked
+    if len(ipAddrs) == 0 {
+        ipAddrs = discoverer.resolveAll(discoverer.Fallback)
+    }
+
+    // If no public IPs were discovered
+    if len(ipAddrs) == 0 {
+        return nil, errors.New("could not retrieve public IP from any resolvers")
+    }
+
+    // If caching is enabled, a failed write only means the IPs are rediscovered next time
+    if discoverer.CachePath != "" {
+        SaveCache(discoverer.CachePath, ipAddrs, time.Now())
+    }
+
+    return ipAddrs, nil
+}
+
+
+// Queries each of the resolvers, collecting the unique IPs they found.
+//
+// @Parameters
+// - resolvers:  The resolvers to query in order
+//
+// @Returns
+// - The unique IPs in the order they were found
+//
+func (discoverer *Discoverer) resolveAll(resolvers []Resolver) []string {
+    var ipAddrs []string
+    uniqueAddrs := make(map[string]struct{})
+
+    // Iterate through the resolvers, skipping any that fail
+    for _, resolver := range resolvers {
+        ctx, cancel := context.WithTimeout(context.Background(), discoverer.Timeout)
+        matches, err := resolver.Resolve(ctx)
+        cancel()
+        if err != nil {
+            continue
+        }
+
+        // Iterate through the found IPs adding the ones not already found
+        for _, match := range matches {
+            if _, exists := uniqueAddrs[match]; !exists {
+                uniqueAddrs[match] = struct{}{}
+                ipAddrs = append(ipAddrs, match)
+            }
+        }
+    }
+
+    return ipAddrs
+}
+
+
+// Ensures the HTTP endpoints are absolute http or https URLs and the STUN servers are
+// host:port addresses.
+//
+// @Parameters
+// - endpoints:  The URLs of the HTTP services
+// - stunServers:  The addresses of the STUN servers
+//
+// @Returns
+// - Error if an endpoint or server is improper, otherwise nil
+//
+func ValidateResolvers(endpoints []string, stunServers []string) error {
+    // Iterate through the endpoints ensuring each is a usable URL
+    for _, endpoint := range endpoints {
+        parsed, err := url.Parse(endpoint)
+        if err != nil || parsed.Host == "" ||
+           (parsed.Scheme != "http" && parsed.Scheme != "https") {
+            return fmt.Errorf("improper IP discovery endpoint %q", endpoint)
+        }
+    }
+
+    // Iterate through the STUN servers ensuring each has a host and port
+    for _, server := range stunServers {
+        host, port, err := net.SplitHostPort(server)
+        if err != nil || host == "" || port == "" {
+            return fmt.Errorf("improper STUN server %q, must be host:port", server)
+        }
+    }
+
+    return nil
+}
+
+
+// Ensures each of the IPs is a valid IPv4 or IPv6 address.
+//
+// @Parameters
+// - ipAddrs:  The IP addresses to validate
+//
+// @Returns
+// - Error if an IP is improper, otherwise nil
+//
+func ValidateIps(ipAddrs []string) error {
+    for _, ipAddr := range ipAddrs {
+        if net.ParseIP(ipAddr) == nil {
+            return fmt.Errorf("improper IP address %q", ipAddr)
+        }
+    }
+
+    return nil
+}
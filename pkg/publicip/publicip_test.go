@@ -0,0 +1,189 @@
+package publicip_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/publicip"
+	"github.com/stretchr/testify/assert"
+)
+
+
+// staticResolver returns fixed IPs, or an error when none are set
+type staticResolver []string
+
+func (resolver staticResolver) Resolve(ctx context.Context) ([]string, error) {
+    if len(resolver) == 0 {
+        return nil, errors.New("unreachable")
+    }
+
+    return resolver, nil
+}
+
+
+func TestHttpResolver(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter,
+                                                       request *http.Request) {
+        writer.Write([]byte("  203.0.113.7\n"))
+    }))
+    // Close the server on local exit
+    defer server.Close()
+
+    resolver := &publicip.HttpResolver{Url: server.URL}
+    ipAddrs, err := resolver.Resolve(context.Background())
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal([]string{"203.0.113.7"}, ipAddrs)
+}
+
+
+func TestDiscoverer(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    cachePath := filepath.Join(t.TempDir(), "cache", "public_ips.json")
+    discoverer := &publicip.Discoverer{
+        CachePath: cachePath,
+        CacheTtl:  time.Hour,
+        Fallback:  []publicip.Resolver{staticResolver{"198.51.100.2"}},
+        Primary:   []publicip.Resolver{staticResolver{}, staticResolver{"203.0.113.7"},
+                                       staticResolver{"203.0.113.7", "2001:db8::7"}},
+        Timeout:   time.Second,
+    }
+
+    ipAddrs, err := discoverer.Discover()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the IPs of the primary resolvers are unique and the fallback is unused
+    assert.Equal([]string{"203.0.113.7", "2001:db8::7"}, ipAddrs)
+
+    // Ensure the cached IPs are used while fresh
+    discoverer.Primary = nil
+    ipAddrs, err = discoverer.Discover()
+    assert.Equal(nil, err)
+    assert.Equal([]string{"203.0.113.7", "2001:db8::7"}, ipAddrs)
+
+    // Ensure the fallback is used when the primary resolvers find nothing
+    discoverer.CachePath = ""
+    ipAddrs, err = discoverer.Discover()
+    assert.Equal(nil, err)
+    assert.Equal([]string{"198.51.100.2"}, ipAddrs)
+
+    // Ensure an error is returned when no resolver finds an IP
+    discoverer.Fallback = []publicip.Resolver{staticResolver{}}
+    _, err = discoverer.Discover()
+    assert.NotEqual(nil, err)
+}
+
+
+func TestCache(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    cachePath := filepath.Join(t.TempDir(), "public_ips.json")
+    now := time.Now()
+
+    // Ensure a missing cache is not used
+    _, ok := publicip.LoadCache(cachePath, time.Hour, now)
+    assert.False(ok)
+
+    err := publicip.SaveCache(cachePath, []string{"203.0.113.7"}, now)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    ipAddrs, ok := publicip.LoadCache(cachePath, time.Hour, now.Add(time.Minute))
+    assert.True(ok)
+    assert.Equal([]string{"203.0.113.7"}, ipAddrs)
+
+    // Ensure a stale cache is not used
+    _, ok = publicip.LoadCache(cachePath, time.Hour, now.Add(2 * time.Hour))
+    assert.False(ok)
+}
+
+
+func TestStunResolver(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Close the listener on local exit
+    defer listener.Close()
+
+    mappedIp := net.ParseIP("203.0.113.7").To4()
+
+    // Serve binding responses with the mapped address XORed with the magic cookie
+    go func() {
+        request := make([]byte, 1500)
+        for {
+            bytesRead, addr, err := listener.ReadFrom(request)
+            if err != nil {
+                return
+            }
+            if bytesRead < 20 {
+                continue
+            }
+
+            response := make([]byte, 20, 32)
+            binary.BigEndian.PutUint16(response[0:2], publicip.StunBindingSuccess)
+            binary.BigEndian.PutUint16(response[2:4], 12)
+            copy(response[4:20], request[4:20])
+
+            attribute := make([]byte, 12)
+            binary.BigEndian.PutUint16(attribute[0:2], publicip.StunXorMappedAddress)
+            binary.BigEndian.PutUint16(attribute[2:4], 8)
+            attribute[5] = 0x01
+            binary.BigEndian.PutUint16(attribute[6:8], 4444 ^ (publicip.StunMagicCookie >> 16))
+            binary.BigEndian.PutUint32(attribute[8:12],
+                                       binary.BigEndian.Uint32(mappedIp) ^
+                                       publicip.StunMagicCookie)
+
+            listener.WriteTo(append(response, attribute...), addr)
+        }
+    } ()
+
+    ctx, cancel := context.WithTimeout(context.Background(), 5 * time.Second)
+    defer cancel()
+
+    resolver := &publicip.StunResolver{Server: listener.LocalAddr().String()}
+    ipAddrs, err := resolver.Resolve(ctx)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal([]string{"203.0.113.7"}, ipAddrs)
+}
+
+
+func TestValidate(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, publicip.ValidateResolvers([]string{"https://api.ipify.org",
+                                                          "http://10.0.0.5/ip"},
+                                                 []string{"stun.l.google.com:19302"}))
+    assert.Equal(nil, publicip.ValidateIps([]string{"203.0.113.7", "2001:db8::7"}))
+
+    falacies := []error{
+        publicip.ValidateResolvers([]string{"ftp://example.com"}, nil),
+        publicip.ValidateResolvers([]string{"api.ipify.org"}, nil),
+        publicip.ValidateResolvers(nil, []string{"stun.l.google.com"}),
+        publicip.ValidateIps([]string{"203.0.113"}),
+        publicip.ValidateIps([]string{"server.example.com"}),
+    }
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        // Ensure the error is not nil meaning failed operation
+        assert.NotEqual(nil, falacy)
+    }
+}
@@ -0,0 +1,224 @@
+package publicip
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// STUN message values of RFC 5389 used by the binding request
+const (
+    StunBindingRequest   = 0x0001
+    StunBindingSuccess   = 0x0101
+    StunMagicCookie      = 0x2112A442
+    StunMappedAddress    = 0x0001
+    StunXorMappedAddress = 0x0020
+)
+
+// Time waited for a binding response before the request is sent again
+const StunRetryInterval = time.Second
+
+
+// StunResolver discovers the public IP from the mapped address a STUN server sees the
+// binding request come from, working where HTTP egress is blocked but UDP is not
+type StunResolver struct {
+    Server string  // The host:port address of the STUN server
+}
+
+
+// Sends binding requests to the STUN server until it responds or the context is done.
+//
+// @Parameters
+// - ctx:  The context the request is cancelled with
+//
+// @Returns
+// - The mapped IP address the server saw
+// - Error if it occurs, otherwise nil on success
+//
+func (resolver *StunResolver) Resolve(ctx context.Context) ([]string, error) {
+    var dialer net.Dialer
+    connection, err := dialer.DialContext(ctx, "udp", resolver.Server)
+    if err != nil {
+        return nil, err
+    }
+    // Close the connection on local exit
+    defer connection.Close()
+
+    request := make([]byte, 20)
+    binary.BigEndian.PutUint16(request[0:2], StunBindingRequest)
+    binary.BigEndian.PutUint32(request[4:8], StunMagicCookie)
+    // Generate the transaction ID the response is matched against
+    _, err = rand.Read(request[8:20])
+    if err != nil {
+        return nil, err
+    }
+
+    response := make([]byte, 1500)
+
+    // Resend the request on each interval since UDP datagrams may be dropped
+    for ctx.Err() == nil {
+        _, err = connection.Write(request)
+        if err != nil {
+            return nil, err
+        }
+
+        deadline := time.Now().Add(StunRetryInterval)
+        // If the context ends before the retry interval
+        if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+            deadline = ctxDeadline
+        }
+        connection.SetReadDeadline(deadline)
+
+        bytesRead, err := connection.Read(response)
+        // If no response arrived in time, send the request again
+        if errors.Is(err, context.DeadlineExceeded) || isTimeout(err) {
+            continue
+        }
+        if err != nil {
+            return nil, err
+        }
+
+        ipAddr, err := parseBindingResponse(response[:bytesRead], request[8:20])
+        if err != nil {
+            return nil, fmt.Errorf("improper response from STUN server %s - %w",
+                                  resolver.Server, err)
+        }
+
+        return []string{ipAddr}, nil
+    }
+
+    return nil, ctx.Err()
+}
+
+
+// Checks whether the error is a network timeout.
+//
+// @Parameters
+// - err:  The error to check
+//
+// @Returns
+// - Whether the error is a timeout
+//
+func isTimeout(err error) bool {
+    var netErr net.Error
+    return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+
+// Parses the mapped address out of a binding success response, preferring the XOR mapped
+// address since NATs rewriting addresses in payloads can not alter it.
+//
+// @Parameters
+// - message:  The received STUN message
+// - transactionId:  The transaction ID of the request
+//
+// @Returns
+// - The mapped IP address
+// - Error if the message is not a matching success response with a mapped address
+//
+func parseBindingResponse(message []byte, transactionId []byte) (string, error) {
+    // If the message is shorter than the header
+    if len(message) < 20 {
+        return "", errors.New("message shorter than the STUN header")
+    }
+
+    // If the message is not the success response to the request
+    if binary.BigEndian.Uint16(message[0:2]) != StunBindingSuccess ||
+       binary.BigEndian.Uint32(message[4:8]) != StunMagicCookie ||
+       !bytes.Equal(message[8:20], transactionId) {
+        return "", errors.New("not a binding success response to the request")
+    }
+
+    length := int(binary.BigEndian.Uint16(message[2:4]))
+    // If the attributes run past the end of the message
+    if 20 + length > len(message) {
+        return "", errors.New("attributes truncated")
+    }
+
+    attributes := message[20:20 + length]
+    mapped := ""
+
+    // Iterate through the attributes, each padded to a multiple of 4 bytes
+    for len(attributes) >= 4 {
+        attrType := binary.BigEndian.Uint16(attributes[0:2])
+        attrLength := int(binary.BigEndian.Uint16(attributes[2:4]))
+        if 4 + attrLength > len(attributes) {
+            return "", errors.New("attribute truncated")
+        }
+
+        value := attributes[4:4 + attrLength]
+
+        switch attrType {
+        case StunXorMappedAddress:
+            return decodeAddress(value, message[4:20])
+        case StunMappedAddress:
+            mapped, _ = decodeAddress(value, nil)
+        }
+
+        padded := (attrLength + 3) &^ 3
+        // If the padding runs past the end of the attributes
+        if 4 + padded > len(attributes) {
+            break
+        }
+        attributes = attributes[4 + padded:]
+    }
+
+    // If only the plain mapped address was sent, such as by older servers
+    if mapped != "" {
+        return mapped, nil
+    }
+
+    return "", errors.New("no mapped address attribute")
+}
+
+
+// Decodes the IP of an address attribute, XORed with the magic cookie and transaction ID
+// when the mask is passed in.
+//
+// @Parameters
+// - value:  The value of the address attribute
+// - mask:  The magic cookie followed by the transaction ID, nil when not XORed
+//
+// @Returns
+// - The decoded IP address
+// - Error if the address family or length is improper
+//
+func decodeAddress(value []byte, mask []byte) (string, error) {
+    // If the value is shorter than the family and port
+    if len(value) < 4 {
+        return "", errors.New("address attribute truncated")
+    }
+
+    var ipLength int
+    switch value[1] {
+    case 0x01:
+        ipLength = net.IPv4len
+    case 0x02:
+        ipLength = net.IPv6len
+    default:
+        return "", fmt.Errorf("unknown address family %d", value[1])
+    }
+
+    // If the value does not hold the full address
+    if len(value) < 4 + ipLength {
+        return "", errors.New("address attribute truncated")
+    }
+
+    ip := make(net.IP, ipLength)
+    copy(ip, value[4:4 + ipLength])
+
+    // If the address is XORed, the IPv4 address with the cookie and IPv6 with the
+    // cookie followed by the transaction ID
+    if mask != nil {
+        for index := range ip {
+            ip[index] ^= mask[index]
+        }
+    }
+
+    return ip.String(), nil
+}
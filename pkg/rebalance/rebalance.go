@@ -0,0 +1,710 @@
+package rebalance
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
+)
+
+
+// Wordlist is a wordlist delivered to a client that it has not reported processing
+type Wordlist struct {
+    copying  bool           // Whether its second half is being copied for an idle client
+    done     chan struct{}  // Closed once a claimed split is committed or aborted
+    Path     string
+    Size     int64
+    Started  bool
+    Truncate int64
+}
+
+
+// queue is the wordlists delivered to a client and the progress it has reported
+type queue struct {
+    first     time.Time
+    processed int64
+    wordlists []*Wordlist
+}
+
+// Computes the bytes of the delivered wordlists the client has not reported processing.
+//
+// @Returns
+// - The remaining bytes of the queue
+//
+func (queue *queue) remaining() int64 {
+    var remaining int64
+    // Iterate through the wordlists summing their sizes
+    for _, wordlist := range queue.wordlists {
+        remaining += wordlist.Size
+    }
+
+    return remaining
+}
+
+// Computes the bytes the client processes per second from the wordlists it reported.
+//
+// @Returns
+// - The rate of bytes per second, 0 if nothing has been reported yet
+//
+func (queue *queue) rate() float64 {
+    elapsed := time.Since(queue.first).Seconds()
+    // If nothing has been processed yet
+    if queue.processed <= 0 || elapsed <= 0 {
+        return 0
+    }
+
+    return float64(queue.processed) / elapsed
+}
+
+
+// Tracker follows the queue depth and estimated completion of each client, so the
+// unstarted wordlist of a client that is far from done can be split with an idle one
+type Tracker struct {
+    clients map[string]*queue
+    minSize int64
+    mutx    sync.Mutex
+}
+
+// Creates a tracker that only splits wordlists of at least the passed in size.
+//
+// @Parameters
+// - minSize:  The smallest wordlist that is split with an idle client
+//
+// @Returns
+// - The initialized tracker
+//
+func New(minSize int64) *Tracker {
+    return &Tracker{clients: map[string]*queue{}, minSize: minSize}
+}
+
+// Adds a wordlist delivered to the client to its queue.
+//
+// @Parameters
+// - client:  The address of the client the wordlist was delivered to
+// - filePath:  The path of the wordlist in the load dir
+// - size:  The size of the wordlist
+//
+func (tracker *Tracker) Add(client string, filePath string, size int64) {
+    // If work stealing is disabled
+    if tracker == nil {
+        return
+    }
+
+    tracker.mutx.Lock()
+    defer tracker.mutx.Unlock()
+
+    clientQueue, exists := tracker.clients[client]
+    if !exists {
+        clientQueue = &queue{}
+        tracker.clients[client] = clientQueue
+    }
+
+    clientQueue.wordlists = append(clientQueue.wordlists,
+                                   &Wordlist{Path: filePath, Size: size})
+}
+
+// Marks a wordlist as started by the client, waiting out any split in progress, and
+// returns the offset the client truncates its copy to if it was split.
+//
+// @Parameters
+// - client:  The address of the client starting the wordlist
+// - fileName:  The name of the wordlist
+//
+// @Returns
+// - The offset the wordlist is truncated to
+// - true/false depending on whether the wordlist was split
+//
+func (tracker *Tracker) Start(client string, fileName string) (int64, bool) {
+    // If work stealing is disabled
+    if tracker == nil {
+        return 0, false
+    }
+
+    tracker.mutx.Lock()
+    wordlist := tracker.find(client, fileName)
+    // If the wordlist is not tracked
+    if wordlist == nil {
+        tracker.mutx.Unlock()
+        return 0, false
+    }
+
+    // If the wordlist is being split, wait for the split to finish
+    if done := wordlist.done; done != nil {
+        tracker.mutx.Unlock()
+        <-done
+        tracker.mutx.Lock()
+    }
+    defer tracker.mutx.Unlock()
+
+    wordlist.Started = true
+    // If the first wordlist of the client is starting, its rate is measured from now
+    if clientQueue, exists := tracker.clients[client]; exists && clientQueue.first.IsZero() {
+        clientQueue.first = time.Now()
+    }
+
+    return wordlist.Truncate, wordlist.Truncate > 0
+}
+
+// Removes a wordlist the client reported processing from its queue.
+//
+// @Parameters
+// - client:  The address of the client that processed the wordlist
+// - fileName:  The name of the processed wordlist
+// - size:  The size of the processed wordlist
+//
+func (tracker *Tracker) Done(client string, fileName string, size int64) {
+    // If work stealing is disabled
+    if tracker == nil {
+        return
+    }
+
+    tracker.mutx.Lock()
+    defer tracker.mutx.Unlock()
+
+    clientQueue, exists := tracker.clients[client]
+    if !exists {
+        return
+    }
+
+    clientQueue.processed += size
+    // Iterate through the queue removing the processed wordlist
+    for index, wordlist := range clientQueue.wordlists {
+        if filepath.Base(wordlist.Path) == fileName {
+            clientQueue.wordlists = append(clientQueue.wordlists[:index],
+                                           clientQueue.wordlists[index+1:]...)
+            return
+        }
+    }
+}
+
+// Removes the queue of a disconnected client.
+//
+// @Parameters
+// - client:  The address of the disconnected client
+//
+func (tracker *Tracker) Remove(client string) {
+    // If work stealing is disabled
+    if tracker == nil {
+        return
+    }
+
+    tracker.mutx.Lock()
+    defer tracker.mutx.Unlock()
+
+    delete(tracker.clients, client)
+}
+
+// Gets the queue depth of the client.
+//
+// @Parameters
+// - client:  The address of the client
+//
+// @Returns
+// - The number of wordlists the client has not reported processing
+// - The bytes of the wordlists the client has not reported processing
+//
+func (tracker *Tracker) Depth(client string) (int, int64) {
+    // If work stealing is disabled
+    if tracker == nil {
+        return 0, 0
+    }
+
+    tracker.mutx.Lock()
+    defer tracker.mutx.Unlock()
+
+    clientQueue, exists := tracker.clients[client]
+    if !exists {
+        return 0, 0
+    }
+
+    return len(clientQueue.wordlists), clientQueue.remaining()
+}
+
+// Estimates how long the client needs to process its queue at its reported rate, or
+// the fleet rate if it has not reported any wordlists yet.
+//
+// @Parameters
+// - client:  The address of the client
+//
+// @Returns
+// - The estimated time until the client completes its queue
+// - true/false depending on whether any rate was known to estimate with
+//
+func (tracker *Tracker) Estimate(client string) (time.Duration, bool) {
+    // If work stealing is disabled
+    if tracker == nil {
+        return 0, false
+    }
+
+    tracker.mutx.Lock()
+    defer tracker.mutx.Unlock()
+
+    return tracker.estimate(client)
+}
+
+// Selects the largest unstarted wordlist of the client estimated to finish last and
+// marks its second half as being copied, nothing is selected while the requesting client
+// still has unstarted wordlists of its own. The client can still start the wordlist
+// while it is copied, so Claim it once copied, then finish the split with Split or Abort.
+//
+// @Parameters
+// - idle:  The address of the idle client requesting work
+//
+// @Returns
+// - The address of the client the wordlist is split from
+// - The path of the wordlist in the load dir
+// - true/false depending on whether a wordlist was selected
+//
+func (tracker *Tracker) Steal(idle string) (string, string, bool) {
+    // If work stealing is disabled
+    if tracker == nil {
+        return "", "", false
+    }
+
+    tracker.mutx.Lock()
+    defer tracker.mutx.Unlock()
+
+    // If the idle client still has wordlists it has not started
+    if idleQueue, exists := tracker.clients[idle]; exists {
+        for _, wordlist := range idleQueue.wordlists {
+            if !wordlist.Started {
+                return "", "", false
+            }
+        }
+    }
+
+    var victim string
+    var selected *Wordlist
+    var longest time.Duration
+
+    // Iterate through the other clients finding the one estimated to finish last
+    for client, clientQueue := range tracker.clients {
+        if client == idle {
+            continue
+        }
+
+        var largest *Wordlist
+        // Iterate through the queue finding the largest unstarted wordlist to split
+        for _, wordlist := range clientQueue.wordlists {
+            if wordlist.Started || wordlist.copying || wordlist.done != nil ||
+               wordlist.Truncate > 0 || wordlist.Size < tracker.minSize {
+                continue
+            }
+
+            if largest == nil || wordlist.Size > largest.Size {
+                largest = wordlist
+            }
+        }
+
+        // If the client has no wordlist that can be split
+        if largest == nil {
+            continue
+        }
+
+        estimate, known := tracker.estimate(client)
+        // Without any rate to estimate with, the remaining bytes are compared instead
+        if !known {
+            estimate = time.Duration(clientQueue.remaining())
+        }
+
+        if selected == nil || estimate > longest {
+            victim = client
+            selected = largest
+            longest = estimate
+        }
+    }
+
+    // If no client has a wordlist that can be split
+    if selected == nil {
+        return "", "", false
+    }
+
+    selected.copying = true
+    return victim, selected.Path, true
+}
+
+// Claims the copied wordlist for the split unless its client started it meanwhile, so
+// starting it waits only for the split to be committed rather than the copy.
+//
+// @Parameters
+// - client:  The address of the client the wordlist was selected from
+// - filePath:  The path of the wordlist in the load dir
+//
+// @Returns
+// - true if the wordlist was claimed, false if it was started or its client disconnected
+//
+func (tracker *Tracker) Claim(client string, filePath string) bool {
+    // If work stealing is disabled
+    if tracker == nil {
+        return false
+    }
+
+    tracker.mutx.Lock()
+    defer tracker.mutx.Unlock()
+
+    wordlist := tracker.find(client, filepath.Base(filePath))
+    // If the client disconnected while the wordlist was being copied
+    if wordlist == nil || !wordlist.copying {
+        return false
+    }
+
+    wordlist.copying = false
+    // If the client started the wordlist while it was being copied, it stays whole
+    if wordlist.Started {
+        return false
+    }
+
+    wordlist.done = make(chan struct{})
+    return true
+}
+
+// Records the offset the selected wordlist was split at, so its client truncates its
+// copy when it starts the wordlist.
+//
+// @Parameters
+// - client:  The address of the client the wordlist was split from
+// - filePath:  The path of the wordlist in the load dir
+// - offset:  The offset the wordlist was truncated to
+//
+func (tracker *Tracker) Split(client string, filePath string, offset int64) {
+    tracker.finish(client, filePath, offset)
+}
+
+// Releases the selected wordlist without splitting it.
+//
+// @Parameters
+// - client:  The address of the client the wordlist was selected from
+// - filePath:  The path of the wordlist in the load dir
+//
+func (tracker *Tracker) Abort(client string, filePath string) {
+    tracker.finish(client, filePath, 0)
+}
+
+// Ends the split of the selected wordlist, waking any client waiting to start it.
+//
+// @Parameters
+// - client:  The address of the client the wordlist was selected from
+// - filePath:  The path of the wordlist in the load dir
+// - offset:  The offset the wordlist was truncated to, 0 if it was not split
+//
+func (tracker *Tracker) finish(client string, filePath string, offset int64) {
+    // If work stealing is disabled
+    if tracker == nil {
+        return
+    }
+
+    tracker.mutx.Lock()
+    defer tracker.mutx.Unlock()
+
+    wordlist := tracker.find(client, filepath.Base(filePath))
+    // If the client disconnected while the wordlist was being split
+    if wordlist == nil {
+        return
+    }
+
+    wordlist.copying = false
+    // If the split was never claimed, no client is waiting on it
+    if wordlist.done == nil {
+        return
+    }
+
+    // If the wordlist was split, only the first part is left to the client
+    if offset > 0 {
+        wordlist.Size = offset
+        wordlist.Truncate = offset
+    }
+
+    close(wordlist.done)
+    wordlist.done = nil
+}
+
+// Gets the tracked wordlist of the client, the caller holds the mutex.
+//
+// @Parameters
+// - client:  The address of the client
+// - fileName:  The name of the wordlist
+//
+// @Returns
+// - The tracked wordlist, nil if not tracked
+//
+func (tracker *Tracker) find(client string, fileName string) *Wordlist {
+    clientQueue, exists := tracker.clients[client]
+    if !exists {
+        return nil
+    }
+
+    // Iterate through the queue of the client matching the name
+    for _, wordlist := range clientQueue.wordlists {
+        if filepath.Base(wordlist.Path) == fileName {
+            return wordlist
+        }
+    }
+
+    return nil
+}
+
+// Estimates the time until the client completes its queue, the caller holds the mutex.
+//
+// @Parameters
+// - client:  The address of the client
+//
+// @Returns
+// - The estimated time until the client completes its queue
+// - true/false depending on whether any rate was known to estimate with
+//
+func (tracker *Tracker) estimate(client string) (time.Duration, bool) {
+    clientQueue, exists := tracker.clients[client]
+    if !exists {
+        return 0, false
+    }
+
+    rate := clientQueue.rate()
+    // If the client has not reported a wordlist, use the rate of the fleet
+    if rate <= 0 {
+        var processed float64
+        var rates int
+
+        // Iterate through the clients averaging the rates that are known
+        for _, other := range tracker.clients {
+            if otherRate := other.rate(); otherRate > 0 {
+                processed += otherRate
+                rates++
+            }
+        }
+
+        // If no client has reported a wordlist yet
+        if rates == 0 {
+            return 0, false
+        }
+
+        rate = processed / float64(rates)
+    }
+
+    seconds := float64(clientQueue.remaining()) / rate
+    return time.Duration(seconds * float64(time.Second)), true
+}
+
+
+// Copies the second half of the wordlist, from the line boundary nearest its middle, to
+// the part file of the destination, leaving the wordlist whole until CommitHalf.
+//
+// @Parameters
+// - filePath:  The path of the wordlist to split
+// - destPath:  The path the second half is written to once committed
+//
+// @Returns
+// - The offset the wordlist is truncated to on commit
+// - Error if it occurs, otherwise nil on success
+//
+func CopyHalf(filePath string, destPath string) (int64, error) {
+    file, err := os.Open(filePath)
+    if err != nil {
+        return 0, fmt.Errorf("error opening wordlist to split - %w", err)
+    }
+    // Close file on local exit
+    defer file.Close()
+
+    info, err := file.Stat()
+    if err != nil {
+        return 0, err
+    }
+
+    // Seek to the middle then past the end of the line it falls in
+    _, err = file.Seek(info.Size() / 2, io.SeekStart)
+    if err != nil {
+        return 0, err
+    }
+
+    reader := bufio.NewReader(file)
+    line, err := reader.ReadBytes('\n')
+    // If the middle falls in the last line, there is no second half
+    if errors.Is(err, io.EOF) {
+        return 0, fmt.Errorf("wordlist %s has no line boundary after its middle", filePath)
+    } else if err != nil {
+        return 0, err
+    }
+
+    offset := info.Size() / 2 + int64(len(line))
+    // If the line boundary is the end of the file, there is no second half
+    if offset >= info.Size() {
+        return 0, fmt.Errorf("wordlist %s has no line boundary after its middle", filePath)
+    }
+
+    // Write the second half to a part file renamed once complete
+    destFile, err := os.OpenFile(destPath + disk.PartSuffix,
+                                 os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+    if err != nil {
+        return 0, fmt.Errorf("error creating split wordlist - %w", err)
+    }
+
+    _, err = io.Copy(destFile, reader)
+    if err == nil {
+        err = destFile.Sync()
+    }
+    destFile.Close()
+    if err != nil {
+        os.Remove(destPath + disk.PartSuffix)
+        return 0, fmt.Errorf("error writing split wordlist - %w", err)
+    }
+
+    return offset, nil
+}
+
+
+// Commits the split copied by CopyHalf, truncating the wordlist to its first half then
+// making the second half selectable under the destination path.
+//
+// @Parameters
+// - filePath:  The path of the wordlist being split
+// - destPath:  The path the second half is moved to
+// - offset:  The offset returned by CopyHalf the wordlist is truncated to
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func CommitHalf(filePath string, destPath string, offset int64) error {
+    // Truncate the wordlist before the second half is made selectable, so no line is
+    // ever in both
+    err := os.Truncate(filePath, offset)
+    if err != nil {
+        os.Remove(destPath + disk.PartSuffix)
+        return fmt.Errorf("error truncating split wordlist - %w", err)
+    }
+
+    err = os.Rename(destPath + disk.PartSuffix, destPath)
+    if err != nil {
+        return fmt.Errorf("error renaming split wordlist - %w", err)
+    }
+
+    return nil
+}
+
+
+// Formats the path the second half of a split wordlist is written to, which keeps the
+// family of the wordlist so it shares its stats.
+//
+// @Parameters
+// - filePath:  The path of the wordlist being split
+//
+// @Returns
+// - The path of the second half
+//
+func SplitPath(filePath string) string {
+    ext := filepath.Ext(filePath)
+    base := strings.TrimSuffix(filePath, ext)
+
+    // Iterate through the split numbers until one is not already in use
+    for number := 1; ; number++ {
+        splitPath := base + "_" + strconv.Itoa(number) + ext
+        if _, err := os.Stat(splitPath); os.IsNotExist(err) {
+            return splitPath
+        }
+    }
+}
+
+
+// Formats the message a client sends before it starts processing a wordlist.
+//
+// @Parameters
+// - fileName:  The name of the wordlist
+//
+// @Returns
+// - The formatted start message
+//
+func FormatStart(fileName string) []byte {
+    message := append([]byte{}, globals.WORK_START_PREFIX...)
+    message = append(message, fileName...)
+    return append(message, globals.TRANSFER_SUFFIX...)
+}
+
+
+// Parses the name of the wordlist from the start message.
+//
+// @Parameters
+// - message:  The start message
+//
+// @Returns
+// - The name of the wordlist
+// - Error if it occurs, otherwise nil on success
+//
+func ParseStart(message []byte) (string, error) {
+    // If the message does not start with prefix or end with closed bracket
+    if !bytes.HasPrefix(message, globals.WORK_START_PREFIX) ||
+       !bytes.HasSuffix(message, globals.TRANSFER_SUFFIX) {
+        return "", fmt.Errorf("improper prefix or suffix in work start message")
+    }
+
+    fileName := bytes.TrimSuffix(bytes.TrimPrefix(message, globals.WORK_START_PREFIX),
+                                 globals.TRANSFER_SUFFIX)
+    // If the wordlist name is missing
+    if len(fileName) == 0 {
+        return "", fmt.Errorf("empty wordlist name in work start message")
+    }
+
+    return filepath.Base(string(fileName)), nil
+}
+
+
+// Formats the reply to a start message, the truncate message when the wordlist was split
+// and the keep marker otherwise.
+//
+// @Parameters
+// - offset:  The offset the wordlist is truncated to
+// - truncate:  Whether the wordlist was split
+//
+// @Returns
+// - The formatted reply
+//
+func FormatReply(offset int64, truncate bool) []byte {
+    // If the wordlist is processed whole
+    if !truncate {
+        return globals.WORK_KEEP_MARKER
+    }
+
+    message := append([]byte{}, globals.WORK_TRUNCATE_PREFIX...)
+    message = strconv.AppendInt(message, offset, 10)
+    return append(message, globals.TRANSFER_SUFFIX...)
+}
+
+
+// Parses the reply to a start message.
+//
+// @Parameters
+// - message:  The reply to the start message
+//
+// @Returns
+// - The offset the wordlist is truncated to
+// - true/false depending on whether the wordlist was split
+// - Error if it occurs, otherwise nil on success
+//
+func ParseReply(message []byte) (int64, bool, error) {
+    // If the wordlist is processed whole
+    if bytes.Equal(message, globals.WORK_KEEP_MARKER) {
+        return 0, false, nil
+    }
+
+    // If the message does not start with prefix or end with closed bracket
+    if !bytes.HasPrefix(message, globals.WORK_TRUNCATE_PREFIX) ||
+       !bytes.HasSuffix(message, globals.TRANSFER_SUFFIX) {
+        return 0, false, fmt.Errorf("improper prefix or suffix in work start reply")
+    }
+
+    body := bytes.TrimSuffix(bytes.TrimPrefix(message, globals.WORK_TRUNCATE_PREFIX),
+                             globals.TRANSFER_SUFFIX)
+    offset, err := strconv.ParseInt(string(body), 10, 64)
+    if err != nil || offset <= 0 {
+        return 0, false, fmt.Errorf("improper offset in work start reply")
+    }
+
+    return offset, true, nil
+}
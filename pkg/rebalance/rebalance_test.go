@@ -0,0 +1,165 @@
+package rebalance_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/rebalance"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestSteal(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    tracker := rebalance.New(1 * globals.MB)
+    tracker.Add("10.0.0.1:5000", "/load/rockyou.txt", 8 * globals.MB)
+    tracker.Add("10.0.0.1:5000", "/load/leaks.txt", 4 * globals.MB)
+    tracker.Add("10.0.0.2:5000", "/load/small.txt", 512 * globals.KB)
+    tracker.Add("10.0.0.3:5000", "/load/names.txt", 2 * globals.MB)
+
+    count, remaining := tracker.Depth("10.0.0.1:5000")
+    assert.Equal(2, count)
+    assert.Equal(int64(12 * globals.MB), remaining)
+
+    // Ensure a client with unstarted wordlists of its own is not idle
+    _, _, ok := tracker.Steal("10.0.0.3:5000")
+    assert.False(ok)
+
+    // Ensure the largest unstarted wordlist of the client with the most work is selected
+    tracker.Start("10.0.0.1:5000", "rockyou.txt")
+    tracker.Start("10.0.0.2:5000", "small.txt")
+    victim, filePath, ok := tracker.Steal("10.0.0.2:5000")
+    assert.True(ok)
+    assert.Equal("10.0.0.1:5000", victim)
+    assert.Equal("/load/leaks.txt", filePath)
+    // Ensure a wordlist being copied is not selected again
+    other, otherPath, ok := tracker.Steal("10.0.0.2:5000")
+    assert.True(ok)
+    assert.Equal("/load/names.txt", otherPath)
+    tracker.Abort(other, otherPath)
+    // Ensure the copied wordlist is claimed while its client has not started it
+    assert.True(tracker.Claim(victim, filePath))
+
+    startDone := make(chan int64)
+    // Ensure starting the wordlist waits for the split to finish
+    go func() {
+        offset, truncate := tracker.Start("10.0.0.1:5000", "leaks.txt")
+        assert.True(truncate)
+        startDone <- offset
+    }()
+
+    select {
+    case <-startDone:
+        t.Fatal("wordlist started before the split finished")
+    case <-time.After(50 * time.Millisecond):
+    }
+
+    tracker.Split(victim, filePath, 2 * globals.MB)
+    assert.Equal(int64(2 * globals.MB), <-startDone)
+
+    count, remaining = tracker.Depth("10.0.0.1:5000")
+    assert.Equal(2, count)
+    assert.Equal(int64(10 * globals.MB), remaining)
+
+    // Ensure a wordlist started while it was copied is not claimed and stays whole
+    victim, filePath, ok = tracker.Steal("10.0.0.2:5000")
+    assert.True(ok)
+    assert.Equal("/load/names.txt", filePath)
+    _, truncate := tracker.Start("10.0.0.3:5000", "names.txt")
+    assert.False(truncate)
+    assert.False(tracker.Claim(victim, filePath))
+    tracker.Abort(victim, filePath)
+
+    // Ensure started, split, and too small wordlists are not selected
+    _, _, ok = tracker.Steal("10.0.0.2:5000")
+    assert.False(ok)
+
+    tracker.Done("10.0.0.3:5000", "names.txt", 2 * globals.MB)
+
+    // Ensure the estimate uses the rate of the fleet for clients yet to report
+    _, known := tracker.Estimate("10.0.0.1:5000")
+    assert.True(known)
+    tracker.Remove("10.0.0.3:5000")
+    _, known = tracker.Estimate("10.0.0.1:5000")
+    assert.False(known)
+}
+
+
+func TestCopyCommitHalf(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    testDir := t.TempDir()
+    filePath := filepath.Join(testDir, "rockyou.txt")
+    err := os.WriteFile(filePath, []byte("alpha\nbravo\ncharlie\ndelta\necho\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    splitPath := rebalance.SplitPath(filePath)
+    assert.Equal(filepath.Join(testDir, "rockyou_1.txt"), splitPath)
+
+    offset, err := rebalance.CopyHalf(filePath, splitPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(int64(20), offset)
+
+    // Ensure the wordlist is whole and the second half unselectable until committed
+    whole, _ := os.ReadFile(filePath)
+    assert.Equal("alpha\nbravo\ncharlie\ndelta\necho\n", string(whole))
+    _, err = os.Stat(splitPath)
+    assert.True(os.IsNotExist(err))
+
+    err = rebalance.CommitHalf(filePath, splitPath, offset)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure every line is in exactly one half
+    first, _ := os.ReadFile(filePath)
+    second, _ := os.ReadFile(splitPath)
+    assert.Equal("alpha\nbravo\ncharlie\n", string(first))
+    assert.Equal("delta\necho\n", string(second))
+
+    // Ensure a wordlist whose middle falls in its last line is not split
+    err = os.WriteFile(filePath, []byte("alpha\nbravo-charlie-delta"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    _, err = rebalance.CopyHalf(filePath, rebalance.SplitPath(filePath))
+    assert.NotEqual(nil, err)
+}
+
+
+func TestFormatParseWork(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    fileName, err := rebalance.ParseStart(rebalance.FormatStart("rockyou.txt"))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("rockyou.txt", fileName)
+
+    _, err = rebalance.ParseStart([]byte("<WORK_START:>"))
+    assert.NotEqual(nil, err)
+
+    offset, truncate, err := rebalance.ParseReply(rebalance.FormatReply(0, false))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.False(truncate)
+    assert.Equal(int64(0), offset)
+
+    offset, truncate, err = rebalance.ParseReply(rebalance.FormatReply(4096, true))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.True(truncate)
+    assert.Equal(int64(4096), offset)
+
+    // Ensure malformed replies are rejected
+    for _, falacy := range []string{"<WORK_TRUNCATE:abc>", "<WORK_TRUNCATE:-1>", "<WORK"} {
+        _, _, err = rebalance.ParseReply([]byte(falacy))
+        assert.NotEqual(nil, err)
+    }
+}
@@ -0,0 +1,721 @@
+package relay
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/tlsutils"
+)
+
+// Commands the server sends the relay after connecting to it
+const CmdAccept = "ACCEPT"
+const CmdDial = "DIAL"
+// Version tag every request header starts with
+const HeaderMagic = "KRELAY1"
+// Max bytes of a request or reply header line
+const MaxHeaderSize = 512
+
+// Package level variables
+var DialTimeout = 10 * time.Second     // Max time the relay waits connecting to a client
+var HeaderTimeout = 10 * time.Second   // Max time the relay waits for a request header
+var PairTimeout = 30 * time.Second     // Max time a client waits for a server connection
+var RetryInterval = 2 * time.Second    // Time between reconnects of the server to the relay
+// Whether the relay may connect to the target address of a DIAL request, limited to
+// private addresses so it can not be used to reach hosts outside of the VPC, the target
+// must also be the address of a client that connected through the relay
+var AllowedTarget = func(addr netip.Addr) bool { return addr.IsPrivate() }
+
+
+// Addr is the address of the relay a listener receives its connections through
+type Addr string
+
+// Gets the name of the network.
+//
+// @Returns
+// - The network name
+//
+func (addr Addr) Network() string {
+    return "tcp"
+}
+
+// Gets the host and port of the relay.
+//
+// @Returns
+// - The relay address
+//
+func (addr Addr) String() string {
+    return string(addr)
+}
+
+
+// Conn is a connection piped through the relay, its remote address is the address of the
+// peer on the other side of the relay rather than the relay itself so the client
+// certificate is verified against the client
+type Conn struct {
+    net.Conn
+    remote net.Addr
+}
+
+// Gets the address of the peer on the other side of the relay.
+//
+// @Returns
+// - The peer address
+//
+func (conn *Conn) RemoteAddr() net.Addr {
+    return conn.remote
+}
+
+
+// Broker is the relay run on an instance in the VPC, it pairs the connections the server
+// makes out to it with the connections of clients in private subnets and pipes the TLS
+// streams between them without terminating them
+type Broker struct {
+    clients map[netip.Addr]struct{}  // Addresses of the clients that connected to the relay
+    mutx    sync.Mutex
+    token   string
+    waiting chan net.Conn
+}
+
+
+// Creates a broker accepting server connections authenticated with the passed in token.
+//
+// @Parameters
+// - token:  The shared secret server connections must present
+// - backlog:  The max number of server connections waiting for a client
+//
+// @Returns
+// - The initialized broker
+//
+func NewBroker(token string, backlog int) *Broker {
+    return &Broker{
+        clients: make(map[netip.Addr]struct{}),
+        token:   token,
+        waiting: make(chan net.Conn, backlog),
+    }
+}
+
+
+// Loads the certificate the relay serves the server connections over TLS with, generating
+// a self-signed one on the first start so its fingerprint stays the same across restarts.
+//
+// @Parameters
+// - certPath:  The path of the PEM certificate
+// - keyPath:  The path of the PEM key
+//
+// @Returns
+// - The certificate of the relay
+// - The hex encoded SHA-256 fingerprint the server pins the certificate with
+// - Error if it occurs, otherwise nil on success
+//
+func LoadCert(certPath string, keyPath string) (tls.Certificate, string, error) {
+    _, err := os.Stat(certPath)
+    // If the certificate was not generated yet
+    if errors.Is(err, os.ErrNotExist) {
+        var tlsMan tlsutils.TlsManager
+
+        err = tlsMan.PemCertAndKeyGenHandler("Kloud Kraken Relay", false)
+        if err != nil {
+            return tls.Certificate{}, "", err
+        }
+
+        // Write the key first so a certificate is never left without its key
+        err = os.WriteFile(keyPath, tlsMan.KeyPemBlock, 0600)
+        if err != nil {
+            return tls.Certificate{}, "", err
+        }
+
+        err = os.WriteFile(certPath, tlsMan.CertPemBlock, 0644)
+        if err != nil {
+            return tls.Certificate{}, "", err
+        }
+    }
+
+    certPem, err := os.ReadFile(certPath)
+    if err != nil {
+        return tls.Certificate{}, "", err
+    }
+
+    keyPem, err := os.ReadFile(keyPath)
+    if err != nil {
+        return tls.Certificate{}, "", err
+    }
+
+    cert, err := tls.X509KeyPair(certPem, keyPem)
+    if err != nil {
+        return tls.Certificate{}, "", err
+    }
+
+    fingerprint, err := tlsutils.Fingerprint(certPem)
+    if err != nil {
+        return tls.Certificate{}, "", err
+    }
+
+    return cert, fingerprint, nil
+}
+
+
+// Wraps the listener the server connects to in TLS, so the token in the request headers
+// is never sent in the clear.
+//
+// @Parameters
+// - listener:  The listener the server connects to
+// - cert:  The certificate of the relay
+//
+// @Returns
+// - The TLS listener
+//
+func TlsListener(listener net.Listener, cert tls.Certificate) net.Listener {
+    return tls.NewListener(listener, &tls.Config{
+        Certificates: []tls.Certificate{cert},
+        MinVersion:   tls.VersionTLS13,
+    })
+}
+
+
+// Accepts the connections of the server until the listener is closed. ACCEPT requests
+// wait for the next client, DIAL requests are connected to the client listener in the
+// VPC a file is transferred to.
+//
+// @Parameters
+// - listener:  The TLS listener the server connects to, see TlsListener()
+//
+// @Returns
+// - Error if it occurs, otherwise nil when the listener is closed
+//
+func (broker *Broker) ServeServers(listener net.Listener) error {
+    for {
+        conn, err := listener.Accept()
+        if err != nil {
+            // If the listener was closed
+            if errors.Is(err, net.ErrClosed) {
+                return nil
+            }
+
+            return err
+        }
+
+        go broker.handleServer(conn)
+    }
+}
+
+
+// Accepts the connections of the clients until the listener is closed, pairing each with
+// a waiting server connection.
+//
+// @Parameters
+// - listener:  The listener the clients connect to
+//
+// @Returns
+// - Error if it occurs, otherwise nil when the listener is closed
+//
+func (broker *Broker) ServeClients(listener net.Listener) error {
+    for {
+        conn, err := listener.Accept()
+        if err != nil {
+            // If the listener was closed
+            if errors.Is(err, net.ErrClosed) {
+                return nil
+            }
+
+            return err
+        }
+
+        go broker.handleClient(conn)
+    }
+}
+
+
+// Authenticates the request of a server connection and handles its command.
+//
+// @Parameters
+// - conn:  The connection of the server
+//
+func (broker *Broker) handleServer(conn net.Conn) {
+    conn.SetReadDeadline(time.Now().Add(HeaderTimeout))
+
+    header, err := readLine(conn)
+    if err != nil {
+        conn.Close()
+        return
+    }
+
+    conn.SetReadDeadline(time.Time{})
+
+    fields := strings.Fields(header)
+    // If the header is malformed or the token does not match
+    if len(fields) < 3 || fields[0] != HeaderMagic ||
+       subtle.ConstantTimeCompare([]byte(fields[1]), []byte(broker.token)) != 1 {
+        replyError(conn, "unauthorized")
+        return
+    }
+
+    switch {
+    case fields[2] == CmdAccept && len(fields) == 3:
+        select {
+        case broker.waiting <- conn:
+        default:
+            replyError(conn, "backlog full")
+        }
+    case fields[2] == CmdDial && len(fields) == 4:
+        broker.handleDial(conn, fields[3])
+    default:
+        replyError(conn, "unknown command")
+    }
+}
+
+
+// Records the address of a client that connected to the relay, so the server can dial
+// its listener.
+//
+// @Parameters
+// - remote:  The remote address of the client connection
+//
+func (broker *Broker) register(remote net.Addr) {
+    addrPort, err := netip.ParseAddrPort(remote.String())
+    if err != nil {
+        return
+    }
+
+    broker.mutx.Lock()
+    defer broker.mutx.Unlock()
+
+    broker.clients[addrPort.Addr().Unmap()] = struct{}{}
+}
+
+
+// Checks whether the address belongs to a client that connected to the relay.
+//
+// @Parameters
+// - addr:  The address to be checked
+//
+// @Returns
+// - true if a client connected from the address, otherwise false
+//
+func (broker *Broker) registered(addr netip.Addr) bool {
+    broker.mutx.Lock()
+    defer broker.mutx.Unlock()
+
+    _, exists := broker.clients[addr.Unmap()]
+    return exists
+}
+
+
+// Connects to the client listener the server requested and pipes the connections.
+//
+// @Parameters
+// - conn:  The connection of the server
+// - target:  The host and port of the client listener
+//
+func (broker *Broker) handleDial(conn net.Conn, target string) {
+    addrPort, err := netip.ParseAddrPort(target)
+    // If the target is not an address the relay is allowed to connect to
+    if err != nil || !AllowedTarget(addrPort.Addr()) || !broker.registered(addrPort.Addr()) {
+        replyError(conn, "target must be the private IP address and port of a client " +
+                   "connected through the relay")
+        return
+    }
+
+    clientConn, err := net.DialTimeout("tcp", target, DialTimeout)
+    if err != nil {
+        replyError(conn, "error connecting to target")
+        return
+    }
+
+    _, err = conn.Write([]byte("OK " + target + "\n"))
+    if err != nil {
+        conn.Close()
+        clientConn.Close()
+        return
+    }
+
+    pipe(conn, clientConn)
+}
+
+
+// Pairs the client connection with a waiting server connection and pipes them, skipping
+// server connections that were dropped while waiting.
+//
+// @Parameters
+// - conn:  The connection of the client
+//
+func (broker *Broker) handleClient(conn net.Conn) {
+    broker.register(conn.RemoteAddr())
+
+    timer := time.NewTimer(PairTimeout)
+    defer timer.Stop()
+
+    for {
+        select {
+        case serverConn := <-broker.waiting:
+            // Tell the server the address of the client it is paired with
+            _, err := serverConn.Write([]byte("OK " + conn.RemoteAddr().String() + "\n"))
+            if err != nil {
+                serverConn.Close()
+                continue
+            }
+
+            pipe(serverConn, conn)
+            return
+        case <-timer.C:
+            conn.Close()
+            return
+        }
+    }
+}
+
+
+// Listener is the net.Listener of the server in relay mode, it keeps a backlog of ACCEPT
+// connections waiting at the relay so the server only makes outbound connections
+type Listener struct {
+    acceptCh    chan net.Conn
+    addr        string
+    closeOnce   sync.Once
+    closedCh    chan struct{}
+    fingerprint string
+    mutx        sync.Mutex
+    pending     map[net.Conn]struct{}
+    token       string
+    wg          sync.WaitGroup
+}
+
+
+// Connects to the relay over TLS, verifying its self-signed certificate against the
+// pinned fingerprint instead of a CA.
+//
+// @Parameters
+// - relayAddr:  The host and port of the relay the server connects to
+// - fingerprint:  The hex encoded SHA-256 fingerprint of the relay certificate
+//
+// @Returns
+// - The TLS connection to the relay
+// - Error if it occurs, otherwise nil on success
+//
+func dialRelay(relayAddr string, fingerprint string) (net.Conn, error) {
+    dialer := &net.Dialer{Timeout: DialTimeout}
+
+    return tls.DialWithDialer(dialer, "tcp", relayAddr, &tls.Config{
+        // The certificate is self-signed, so it is pinned by fingerprint below
+        InsecureSkipVerify: true,
+        MinVersion:         tls.VersionTLS13,
+        VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+            // If the relay presented no certificate
+            if len(rawCerts) == 0 {
+                return errors.New("relay presented no certificate")
+            }
+
+            sum := sha256.Sum256(rawCerts[0])
+            // If the certificate is not the one pinned in the config
+            if !strings.EqualFold(hex.EncodeToString(sum[:]), fingerprint) {
+                return errors.New("relay certificate does not match the pinned fingerprint")
+            }
+
+            return nil
+        },
+    })
+}
+
+
+// Starts a listener receiving client connections through the relay.
+//
+// @Parameters
+// - relayAddr:  The host and port of the relay the server connects to
+// - token:  The shared secret of the relay
+// - fingerprint:  The hex encoded SHA-256 fingerprint of the relay certificate
+// - backlog:  The number of connections kept waiting at the relay for clients
+//
+// @Returns
+// - The started listener
+//
+func Listen(relayAddr string, token string, fingerprint string, backlog int) *Listener {
+    listener := &Listener{
+        acceptCh:    make(chan net.Conn),
+        addr:        relayAddr,
+        closedCh:    make(chan struct{}),
+        fingerprint: fingerprint,
+        pending:     map[net.Conn]struct{}{},
+        token:       token,
+    }
+
+    // Keep the backlog of connections waiting at the relay
+    for range backlog {
+        listener.wg.Add(1)
+        go listener.wait()
+    }
+
+    return listener
+}
+
+
+// Connects to the relay and waits to be paired with a client, reconnecting after each
+// pairing or error until the listener is closed.
+//
+func (listener *Listener) wait() {
+    defer listener.wg.Done()
+
+    for {
+        conn, remote, err := listener.pair()
+        if err != nil {
+            // If the listener was closed, stop reconnecting
+            select {
+            case <-listener.closedCh:
+                return
+            case <-time.After(RetryInterval):
+                continue
+            }
+        }
+
+        select {
+        case listener.acceptCh <- &Conn{Conn: conn, remote: remote}:
+        case <-listener.closedCh:
+            conn.Close()
+            return
+        }
+    }
+}
+
+
+// Makes an ACCEPT request to the relay and waits for a client to be paired with it.
+//
+// @Returns
+// - The connection piped to the client
+// - The address of the client
+// - Error if it occurs, otherwise nil on success
+//
+func (listener *Listener) pair() (net.Conn, net.Addr, error) {
+    conn, err := dialRelay(listener.addr, listener.fingerprint)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    listener.mutx.Lock()
+    // If the listener was closed while connecting
+    select {
+    case <-listener.closedCh:
+        listener.mutx.Unlock()
+        conn.Close()
+        return nil, nil, net.ErrClosed
+    default:
+    }
+    // Track the waiting connection so Close() can interrupt it
+    listener.pending[conn] = struct{}{}
+    listener.mutx.Unlock()
+
+    remote, err := request(conn, listener.token, CmdAccept)
+
+    listener.mutx.Lock()
+    delete(listener.pending, conn)
+    listener.mutx.Unlock()
+
+    if err != nil {
+        conn.Close()
+        return nil, nil, err
+    }
+
+    addrPort, err := netip.ParseAddrPort(remote)
+    if err != nil {
+        conn.Close()
+        return nil, nil, fmt.Errorf("invalid client address from relay - %w", err)
+    }
+
+    return conn, net.TCPAddrFromAddrPort(addrPort), nil
+}
+
+
+// Waits for the next client connection piped through the relay.
+//
+// @Returns
+// - The connection of the client
+// - Error if it occurs, otherwise nil on success
+//
+func (listener *Listener) Accept() (net.Conn, error) {
+    select {
+    case conn := <-listener.acceptCh:
+        return conn, nil
+    case <-listener.closedCh:
+        return nil, net.ErrClosed
+    }
+}
+
+
+// Closes the listener and the connections waiting at the relay.
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (listener *Listener) Close() error {
+    err := net.ErrClosed
+
+    listener.closeOnce.Do(func() {
+        err = nil
+        close(listener.closedCh)
+
+        listener.mutx.Lock()
+        // Iterate through the waiting connections interrupting them
+        for conn := range listener.pending {
+            conn.Close()
+        }
+        listener.mutx.Unlock()
+
+        listener.wg.Wait()
+    })
+
+    return err
+}
+
+
+// Gets the address of the relay.
+//
+// @Returns
+// - The relay address
+//
+func (listener *Listener) Addr() net.Addr {
+    return Addr(listener.addr)
+}
+
+
+// Connects to the listener of a client in the VPC through the relay.
+//
+// @Parameters
+// - relayAddr:  The host and port of the relay the server connects to
+// - token:  The shared secret of the relay
+// - fingerprint:  The hex encoded SHA-256 fingerprint of the relay certificate
+// - target:  The private IP address and port of the client listener
+//
+// @Returns
+// - The connection piped to the client listener
+// - Error if it occurs, otherwise nil on success
+//
+func Dial(relayAddr string, token string, fingerprint string, target string) (net.Conn,
+                                                                                error) {
+    conn, err := dialRelay(relayAddr, fingerprint)
+    if err != nil {
+        return nil, fmt.Errorf("error connecting to relay - %w", err)
+    }
+
+    remote, err := request(conn, token, CmdDial + " " + target)
+    if err != nil {
+        conn.Close()
+        return nil, err
+    }
+
+    addrPort, err := netip.ParseAddrPort(remote)
+    if err != nil {
+        conn.Close()
+        return nil, fmt.Errorf("invalid client address from relay - %w", err)
+    }
+
+    return &Conn{Conn: conn, remote: net.TCPAddrFromAddrPort(addrPort)}, nil
+}
+
+
+// Sends the request header to the relay and reads its reply.
+//
+// @Parameters
+// - conn:  The connection to the relay
+// - token:  The shared secret of the relay
+// - command:  The command and its arguments
+//
+// @Returns
+// - The peer address in the reply
+// - Error if it occurs, otherwise nil on success
+//
+func request(conn net.Conn, token string, command string) (string, error) {
+    _, err := conn.Write([]byte(HeaderMagic + " " + token + " " + command + "\n"))
+    if err != nil {
+        return "", fmt.Errorf("error sending relay request - %w", err)
+    }
+
+    reply, err := readLine(conn)
+    if err != nil {
+        return "", fmt.Errorf("error reading relay reply - %w", err)
+    }
+
+    status, detail, _ := strings.Cut(reply, " ")
+    // If the relay refused the request
+    if status != "OK" {
+        return "", fmt.Errorf("relay refused request - %s", detail)
+    }
+
+    return detail, nil
+}
+
+
+// Reads a header line a byte at a time, so none of the piped stream following it is
+// consumed.
+//
+// @Parameters
+// - reader:  The connection the line is read from
+//
+// @Returns
+// - The line without its newline
+// - Error if it occurs, otherwise nil on success
+//
+func readLine(reader io.Reader) (string, error) {
+    var line []byte
+    char := make([]byte, 1)
+
+    for len(line) < MaxHeaderSize {
+        _, err := io.ReadFull(reader, char)
+        if err != nil {
+            return "", err
+        }
+
+        // If the end of the line was reached
+        if char[0] == '\n' {
+            return string(line), nil
+        }
+
+        line = append(line, char[0])
+    }
+
+    return "", fmt.Errorf("relay header exceeds %d bytes", MaxHeaderSize)
+}
+
+
+// Sends an error reply and closes the connection.
+//
+// @Parameters
+// - conn:  The connection of the server
+// - message:  The reason the request was refused
+//
+func replyError(conn net.Conn, message string) {
+    conn.Write([]byte("ERR " + message + "\n"))
+    conn.Close()
+}
+
+
+// Copies data between the connections in both directions until either side closes.
+//
+// @Parameters
+// - first:  The first connection
+// - second:  The second connection
+//
+func pipe(first net.Conn, second net.Conn) {
+    var wg sync.WaitGroup
+    wg.Add(2)
+
+    copyClose := func(dst net.Conn, src net.Conn) {
+        defer wg.Done()
+        io.Copy(dst, src)
+        // Close both so the copy in the other direction is interrupted
+        dst.Close()
+        src.Close()
+    }
+
+    go copyClose(first, second)
+    go copyClose(second, first)
+
+    wg.Wait()
+}
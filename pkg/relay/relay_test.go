@@ -0,0 +1,185 @@
+package relay_test
+
+import (
+	"io"
+	"net"
+	"net/netip"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/relay"
+	"github.com/stretchr/testify/assert"
+)
+
+
+// Starts a broker on loopback listeners for the server and clients.
+//
+// @Parameters
+// - t:  The testing instance the listeners are cleaned up with
+//
+// @Returns
+// - The address the server connects to
+// - The address the clients connect to
+// - The fingerprint of the relay certificate
+//
+func startBroker(t *testing.T) (string, string, string) {
+    certDir := t.TempDir()
+    cert, fingerprint, err := relay.LoadCert(filepath.Join(certDir, "cert.pem"),
+                                             filepath.Join(certDir, "key.pem"))
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    serverListener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    clientListener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    t.Cleanup(func() {
+        serverListener.Close()
+        clientListener.Close()
+    })
+
+    broker := relay.NewBroker("secret", 4)
+    go broker.ServeServers(relay.TlsListener(serverListener, cert))
+    go broker.ServeClients(clientListener)
+
+    return serverListener.Addr().String(), clientListener.Addr().String(), fingerprint
+}
+
+
+func TestLoadCert(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    certPath := filepath.Join(t.TempDir(), "cert.pem")
+    keyPath := filepath.Join(t.TempDir(), "key.pem")
+
+    _, fingerprint, err := relay.LoadCert(certPath, keyPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(64, len(fingerprint))
+
+    // Ensure the certificate is reused on the next start
+    _, reloaded, err := relay.LoadCert(certPath, keyPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(fingerprint, reloaded)
+}
+
+
+func TestListener(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    serverAddr, clientAddr, fingerprint := startBroker(t)
+
+    listener := relay.Listen(serverAddr, "secret", fingerprint, 2)
+    defer listener.Close()
+
+    assert.Equal(serverAddr, listener.Addr().String())
+
+    // Wait for the server connections to be waiting at the relay
+    time.Sleep(100 * time.Millisecond)
+
+    clientConn, err := net.Dial("tcp", clientAddr)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    defer clientConn.Close()
+
+    serverConn, err := listener.Accept()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    defer serverConn.Close()
+
+    // Ensure the server sees the client address rather than the relay address
+    assert.Equal(clientConn.LocalAddr().String(), serverConn.RemoteAddr().String())
+
+    _, err = clientConn.Write([]byte("hello"))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    received := make([]byte, 5)
+    _, err = io.ReadFull(serverConn, received)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("hello", string(received))
+
+    // Ensure closing the listener stops Accept()
+    assert.Equal(nil, listener.Close())
+    _, err = listener.Accept()
+    assert.ErrorIs(err, net.ErrClosed)
+}
+
+
+func TestDial(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    serverAddr, clientAddr, fingerprint := startBroker(t)
+
+    target, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer target.Close()
+
+    // Ensure loopback targets are refused by default
+    _, err = relay.Dial(serverAddr, "secret", fingerprint, target.Addr().String())
+    assert.NotEqual(nil, err)
+
+    allowed := relay.AllowedTarget
+    relay.AllowedTarget = func(addr netip.Addr) bool { return addr.IsLoopback() }
+    defer func() { relay.AllowedTarget = allowed } ()
+
+    // Ensure targets no client connected from are refused
+    _, err = relay.Dial(serverAddr, "secret", fingerprint, target.Addr().String())
+    assert.NotEqual(nil, err)
+
+    clientConn, err := net.Dial("tcp", clientAddr)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    defer clientConn.Close()
+    // Wait for the relay to register the client address
+    time.Sleep(100 * time.Millisecond)
+
+    // Ensure a bad token or relay certificate is refused
+    _, err = relay.Dial(serverAddr, "wrong", fingerprint, target.Addr().String())
+    assert.NotEqual(nil, err)
+    _, err = relay.Dial(serverAddr, "secret", strings.Repeat("0", 64), target.Addr().String())
+    assert.NotEqual(nil, err)
+
+    go func() {
+        conn, err := target.Accept()
+        if err != nil {
+            return
+        }
+        defer conn.Close()
+
+        io.Copy(conn, conn)
+    } ()
+
+    conn, err := relay.Dial(serverAddr, "secret", fingerprint, target.Addr().String())
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    defer conn.Close()
+
+    assert.Equal(target.Addr().String(), conn.RemoteAddr().String())
+
+    _, err = conn.Write([]byte("ping"))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    received := make([]byte, 4)
+    _, err = io.ReadFull(conn, received)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("ping", string(received))
+}
@@ -0,0 +1,226 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Client the cracked hashes of prior results are attributed to in the report
+const PriorClient = "prior_results"
+
+
+// Loads the cracked hashes of a prior run, either a report it wrote in JSON or CSV
+// format, or a hashcat potfile or loot file of hash:plaintext lines.
+//
+// @Parameters
+// - priorPath:  The path of the prior results
+//
+// @Returns
+// - The cracked lines of the prior results
+// - Error if it occurs, otherwise nil on success
+//
+func LoadPrior(priorPath string) ([]Crack, error) {
+    var cracks []Crack
+    var err error
+
+    switch strings.ToLower(filepath.Ext(priorPath)) {
+    case ".json":
+        cracks, err = loadPriorJson(priorPath)
+    case ".csv":
+        cracks, err = loadPriorCsv(priorPath)
+    default:
+        cracks, err = ParseLoot(priorPath)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("error loading prior results %s - %w", priorPath, err)
+    }
+
+    // Iterate through the cracks, attributing the ones without a source to the file
+    // they were loaded from, such as the lines of a potfile
+    for index := range cracks {
+        if cracks[index].Source == "" {
+            cracks[index].Source = filepath.Base(priorPath)
+        }
+    }
+
+    return cracks, nil
+}
+
+
+// Loads the entries of a JSON report written by a prior run.
+//
+// @Parameters
+// - priorPath:  The path of the JSON report
+//
+// @Returns
+// - The cracked lines of the report entries
+// - Error if it occurs, otherwise nil on success
+//
+func loadPriorJson(priorPath string) ([]Crack, error) {
+    reportJson, err := os.ReadFile(priorPath)
+    if err != nil {
+        return nil, err
+    }
+
+    var prior Report
+    err = json.Unmarshal(reportJson, &prior)
+    if err != nil {
+        return nil, err
+    }
+
+    cracks := make([]Crack, 0, len(prior.Entries))
+    // Iterate through the entries rejoining their hash and plaintext
+    for _, entry := range prior.Entries {
+        cracks = append(cracks, Crack{HashFile: entry.HashFile,
+                                      Line: entry.Hash + ":" + entry.Plaintext,
+                                      Source: entry.Wordlist, Time: entry.Timestamp})
+    }
+
+    return cracks, nil
+}
+
+
+// Loads the rows of a CSV report written by a prior run, locating the columns by the
+// names in its header row.
+//
+// @Parameters
+// - priorPath:  The path of the CSV report
+//
+// @Returns
+// - The cracked lines of the report rows
+// - Error if it occurs, otherwise nil on success
+//
+func loadPriorCsv(priorPath string) ([]Crack, error) {
+    file, err := os.Open(priorPath)
+    if err != nil {
+        return nil, err
+    }
+    // Close file on local exit
+    defer file.Close()
+
+    reader := csv.NewReader(file)
+    header, err := reader.Read()
+    if err != nil {
+        return nil, fmt.Errorf("error reading header row - %w", err)
+    }
+
+    columns := make(map[string]int)
+    for index, name := range header {
+        columns[name] = index
+    }
+
+    // If the hash or plaintext column is missing, the CSV is not a report
+    if _, exists := columns["hash"]; !exists {
+        return nil, fmt.Errorf("no hash column in header row")
+    }
+    if _, exists := columns["plaintext"]; !exists {
+        return nil, fmt.Errorf("no plaintext column in header row")
+    }
+
+    // Gets the value of the named column, empty if the report does not have it
+    column := func(row []string, name string) string {
+        if index, exists := columns[name]; exists && index < len(row) {
+            return row[index]
+        }
+
+        return ""
+    }
+
+    var cracks []Crack
+    // Iterate through the rows of the report
+    for {
+        row, err := reader.Read()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, err
+        }
+
+        crackTime, _ := time.Parse(time.RFC3339, column(row, "timestamp"))
+        cracks = append(cracks, Crack{HashFile: column(row, "hash_file"),
+                                      Line: column(row, "hash") + ":" +
+                                            column(row, "plaintext"),
+                                      Source: column(row, "wordlist"), Time: crackTime})
+    }
+
+    return cracks, nil
+}
+
+
+// Keeps the cracked lines whose hash is in one of the hash files, so the unrelated hashes
+// of a shared potfile are not reported as cracked outside of the hash files.
+//
+// @Parameters
+// - hashFilePaths:  The paths of the hash files of the run
+// - cracks:  The cracked lines to filter
+//
+// @Returns
+// - The cracked lines of hashes in the hash files
+// - Error if it occurs, otherwise nil on success
+//
+func KeepMatched(hashFilePaths []string, cracks []Crack) ([]Crack, error) {
+    var hashes []map[string]string
+
+    // Iterate through the hash files reading their hashes
+    for _, hashFilePath := range hashFilePaths {
+        fileHashes, err := readHashes(hashFilePath)
+        if err != nil {
+            return nil, fmt.Errorf("error reading hash file - %w", err)
+        }
+
+        hashes = append(hashes, fileHashes)
+    }
+
+    var matched []Crack
+    // Iterate through the cracks keeping those matched in any of the hash files
+    for _, crack := range cracks {
+        for _, fileHashes := range hashes {
+            if _, _, ok := splitCrack(crack.Line, fileHashes); ok {
+                matched = append(matched, crack)
+                break
+            }
+        }
+    }
+
+    return matched, nil
+}
+
+
+// Writes the cracked lines as a loot file, with the hash file and source markers before
+// them, so they are merged into the report like the loot of a client.
+//
+// @Parameters
+// - lootPath:  The path where the loot file is written
+// - cracks:  The cracked lines to write
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func WriteLoot(lootPath string, cracks []Crack) error {
+    var loot []byte
+    var last Crack
+
+    // Iterate through the cracks, writing the markers whenever they change
+    for index, crack := range cracks {
+        if index == 0 || crack.HashFile != last.HashFile {
+            loot = append(loot, FormatHashFile(crack.HashFile)...)
+        }
+        if index == 0 || crack.HashFile != last.HashFile || crack.Source != last.Source ||
+           !crack.Time.Equal(last.Time) {
+            loot = append(loot, FormatSource(crack.Source, crack.Time)...)
+        }
+
+        loot = append(loot, crack.Line...)
+        loot = append(loot, '\n')
+        last = crack
+    }
+
+    return os.WriteFile(lootPath, loot, 0644)
+}
@@ -0,0 +1,107 @@
+package report_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestLoadPrior(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    testDir := t.TempDir()
+
+    crackTime := time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC)
+    prior := &report.Report{Entries: []report.Entry{{
+        Client: "10.0.0.5:40122", Hash: "8846f7eaee8fb117ad06bdd830b7586c",
+        HashFile: "hashes.txt", Plaintext: "pass:word", Timestamp: crackTime,
+        Wordlist: "rockyou.txt",
+    }}}
+
+    jsonPath := filepath.Join(testDir, "cracked_report.json")
+    csvPath := filepath.Join(testDir, "cracked_report.csv")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, prior.WriteJson(jsonPath))
+    assert.Equal(nil, prior.WriteCsv(csvPath))
+
+    expected := report.Crack{HashFile: "hashes.txt",
+                             Line: "8846f7eaee8fb117ad06bdd830b7586c:pass:word",
+                             Source: "rockyou.txt", Time: crackTime}
+
+    // Iterate through the report formats ensuring each loads the same crack
+    for _, priorPath := range []string{jsonPath, csvPath} {
+        cracks, err := report.LoadPrior(priorPath)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        assert.Equal([]report.Crack{expected}, cracks)
+    }
+
+    potPath := filepath.Join(testDir, "hashcat.potfile")
+    err := os.WriteFile(potPath, []byte("32ed87bdb5fdc5e9cba88547376818d4:123456\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    cracks, err := report.LoadPrior(potPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the cracks of a potfile are attributed to it
+    assert.Equal([]report.Crack{{Line: "32ed87bdb5fdc5e9cba88547376818d4:123456",
+                                 Source: "hashcat.potfile"}}, cracks)
+
+    // Ensure a CSV without the report columns is rejected
+    err = os.WriteFile(csvPath, []byte("username,password\nadmin,admin\n"), 0644)
+    assert.Equal(nil, err)
+    _, err = report.LoadPrior(csvPath)
+    assert.NotEqual(nil, err)
+}
+
+
+func TestWriteLoot(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    testDir := t.TempDir()
+
+    crackTime := time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC)
+    cracks := []report.Crack{
+        {HashFile: "hashes.txt", Line: "8846f7eaee8fb117ad06bdd830b7586c:password",
+         Source: "rockyou.txt", Time: crackTime},
+        {HashFile: "ntlm.txt", Line: "32ed87bdb5fdc5e9cba88547376818d4:123456",
+         Source: "hashcat.potfile"},
+    }
+
+    lootPath := filepath.Join(testDir, "prior_results.txt")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, report.WriteLoot(lootPath, cracks))
+
+    // Ensure the written loot parses back to the same cracks
+    parsed, err := report.ParseLoot(lootPath)
+    assert.Equal(nil, err)
+    assert.Equal(cracks, parsed)
+
+    hashFilePath := filepath.Join(testDir, "hashes.txt")
+    err = os.WriteFile(hashFilePath, []byte("8846F7EAEE8FB117AD06BDD830B7586C\n" +
+                                            "5f4dcc3b5aa765d61d8327deb882cf99\n"), 0644)
+    assert.Equal(nil, err)
+
+    remainingPath := filepath.Join(testDir, "remaining.txt")
+    count, err := report.Uncracked(hashFilePath, cracks, remainingPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure only the hash not cracked before remains
+    assert.Equal(1, count)
+
+    remaining, err := os.ReadFile(remainingPath)
+    assert.Equal(nil, err)
+    assert.Equal("5f4dcc3b5aa765d61d8327deb882cf99\n", string(remaining))
+
+    matched, err := report.KeepMatched([]string{hashFilePath}, cracks)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the crack of a hash outside of the hash file is dropped
+    assert.Equal(cracks[:1], matched)
+}
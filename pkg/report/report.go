@@ -0,0 +1,662 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+)
+
+// LootFile is a cracked hashes file received from a client
+type LootFile struct {
+    Client string
+    Path   string
+}
+
+
+// Crack is a single cracked line of a loot file with the source it was cracked from
+type Crack struct {
+    HashFile string
+    Line     string
+    Source   string
+    Time     time.Time
+}
+
+
+// Entry is a single deduplicated cracked hash in the report
+type Entry struct {
+    Client    string    `json:"client"`
+    Hash      string    `json:"hash"`
+    HashFile  string    `json:"hash_file"`
+    Plaintext string    `json:"plaintext"`
+    Timestamp time.Time `json:"timestamp"`
+    Wordlist  string    `json:"wordlist"`
+}
+
+
+// Summary stores the crack rate of the run
+type Summary struct {
+    Cracked     int     `json:"cracked"`
+    CrackRate   float64 `json:"crack_rate"`
+    TotalHashes int     `json:"total_hashes"`
+    Unmatched   int     `json:"unmatched"`
+}
+
+
+// FileSummary stores the crack rate of a single hash file of the run
+type FileSummary struct {
+    HashFile string  `json:"hash_file"`
+    Summary  Summary `json:"summary"`
+}
+
+
+// TimedOut is a wordlist or keyspace range whose hashcat process ran past the job timeout
+type TimedOut struct {
+    Client    string    `json:"client"`
+    Source    string    `json:"source"`
+    Timestamp time.Time `json:"timestamp"`
+}
+
+
+// Report stores the cracked hashes of every client joined against the hash files
+type Report struct {
+    Entries  []Entry       `json:"entries"`
+    Files    []FileSummary `json:"files"`
+    Summary  Summary       `json:"summary"`
+    TimedOut []TimedOut    `json:"timed_out"`
+}
+
+
+// Formats the source marker a client writes to its loot file before the hashes
+// cracked from the source, so the server can attribute each cracked hash.
+//
+// @Parameters
+// - source:  The wordlist or keyspace range the hashes were cracked from
+// - crackTime:  The time the hashes were cracked
+//
+// @Returns
+// - The newline terminated source marker
+//
+func FormatSource(source string, crackTime time.Time) []byte {
+    // Newlines would split the marker so they are replaced
+    source = strings.ReplaceAll(source, "\n", " ")
+    return []byte(fmt.Sprintf("%s%d:%s\n", globals.LOOT_SOURCE_PREFIX, crackTime.Unix(),
+                              source))
+}
+
+
+// Formats the timed out marker a client writes to its loot file when hashcat is killed
+// for running past the job timeout, after any hashes it cracked before then.
+//
+// @Parameters
+// - source:  The wordlist or keyspace range that timed out
+// - timeoutTime:  The time hashcat was killed
+//
+// @Returns
+// - The newline terminated timed out marker
+//
+func FormatTimedOut(source string, timeoutTime time.Time) []byte {
+    // Newlines would split the marker so they are replaced
+    source = strings.ReplaceAll(source, "\n", " ")
+    return []byte(fmt.Sprintf("%s%d:%s\n", globals.LOOT_TIMED_OUT_PREFIX, timeoutTime.Unix(),
+                              source))
+}
+
+
+// Parses the time and source of a source or timed out marker line.
+//
+// @Parameters
+// - line:  The marker line
+// - prefix:  The prefix of the marker
+//
+// @Returns
+// - The source of the marker, empty if malformed
+// - The time of the marker, zero if malformed
+//
+func parseMarker(line string, prefix []byte) (string, time.Time) {
+    fields := strings.SplitN(strings.TrimPrefix(line, string(prefix)), ":", 2)
+    // If the time or source is missing
+    if len(fields) != 2 {
+        return "", time.Time{}
+    }
+
+    var markerTime time.Time
+    unix, err := strconv.ParseInt(fields[0], 10, 64)
+    if err == nil {
+        markerTime = time.Unix(unix, 0).UTC()
+    }
+
+    return fields[1], markerTime
+}
+
+
+// Formats the hash file marker a client writes to its loot file before the source
+// marker, so the server keeps the cracked hashes of each hash file separated.
+//
+// @Parameters
+// - hashFile:  The name of the hash file the hashes were cracked from
+//
+// @Returns
+// - The newline terminated hash file marker
+//
+func FormatHashFile(hashFile string) []byte {
+    // Newlines would split the marker so they are replaced
+    hashFile = strings.ReplaceAll(hashFile, "\n", " ")
+    return []byte(string(globals.LOOT_HASH_FILE_PREFIX) + hashFile + "\n")
+}
+
+
+// Parses the cracked lines of a loot file, attributing each to the hash file and
+// source markers before it and skipping the no cracked hashes message.
+//
+// @Parameters
+// - lootPath:  The path of the loot file to parse
+//
+// @Returns
+// - The cracked lines in the order they appear
+// - Error if it occurs, otherwise nil on success
+//
+func ParseLoot(lootPath string) ([]Crack, error) {
+    var cracks []Crack
+    var hashFile string
+    var source string
+    var sourceTime time.Time
+
+    // Open the loot file for reading
+    file, err := os.Open(lootPath)
+    if err != nil {
+        return nil, err
+    }
+    // Close file on local exit
+    defer file.Close()
+
+    scanner := bufio.NewScanner(file)
+    scanner.Buffer(make([]byte, 64 * globals.KB), globals.MB)
+
+    // Iterate through the loot file line by line
+    for scanner.Scan() {
+        line := scanner.Text()
+
+        // If the line is a hash file marker, attribute the following lines to it
+        if strings.HasPrefix(line, string(globals.LOOT_HASH_FILE_PREFIX)) {
+            hashFile = strings.TrimPrefix(line, string(globals.LOOT_HASH_FILE_PREFIX))
+            continue
+        }
+
+        // If the line is a source marker, attribute the following lines to it
+        if strings.HasPrefix(line, string(globals.LOOT_SOURCE_PREFIX)) {
+            source, sourceTime = parseMarker(line, globals.LOOT_SOURCE_PREFIX)
+            continue
+        }
+
+        // If the line is a timed out marker, it is parsed by ParseTimedOut
+        if strings.HasPrefix(line, string(globals.LOOT_TIMED_OUT_PREFIX)) {
+            continue
+        }
+
+        // If the line is empty or the client did not crack any hashes
+        if strings.TrimSpace(line) == "" ||
+        bytes.Equal([]byte(strings.TrimSpace(line)), globals.NO_CRACKED_HASHES) {
+            continue
+        }
+
+        cracks = append(cracks, Crack{HashFile: hashFile, Line: line, Source: source,
+                                      Time: sourceTime})
+    }
+
+    return cracks, scanner.Err()
+}
+
+
+// Parses the timed out markers of a loot file.
+//
+// @Parameters
+// - lootPath:  The path of the loot file to parse
+// - client:  The client the loot file was received from
+//
+// @Returns
+// - The wordlists and keyspace ranges that timed out in the order they appear
+// - Error if it occurs, otherwise nil on success
+//
+func ParseTimedOut(lootPath string, client string) ([]TimedOut, error) {
+    var timedOut []TimedOut
+
+    // Open the loot file for reading
+    file, err := os.Open(lootPath)
+    if err != nil {
+        return nil, err
+    }
+    // Close file on local exit
+    defer file.Close()
+
+    scanner := bufio.NewScanner(file)
+    scanner.Buffer(make([]byte, 64 * globals.KB), globals.MB)
+
+    // Iterate through the loot file line by line
+    for scanner.Scan() {
+        line := scanner.Text()
+
+        // If the line is a timed out marker
+        if strings.HasPrefix(line, string(globals.LOOT_TIMED_OUT_PREFIX)) {
+            source, timeoutTime := parseMarker(line, globals.LOOT_TIMED_OUT_PREFIX)
+            timedOut = append(timedOut, TimedOut{Client: client, Source: source,
+                                                 Timestamp: timeoutTime})
+        }
+    }
+
+    return timedOut, scanner.Err()
+}
+
+
+// Reads the unique hashes of the hash file keyed by their lowercase form, since
+// hashcat may output hex hashes in a different case than they were provided.
+//
+// @Parameters
+// - hashFilePath:  The path of the original hash file
+//
+// @Returns
+// - The hashes keyed by their lowercase form
+// - Error if it occurs, otherwise nil on success
+//
+func readHashes(hashFilePath string) (map[string]string, error) {
+    hashes := make(map[string]string)
+
+    // Open the hash file for reading
+    file, err := os.Open(hashFilePath)
+    if err != nil {
+        return nil, err
+    }
+    // Close file on local exit
+    defer file.Close()
+
+    scanner := bufio.NewScanner(file)
+    scanner.Buffer(make([]byte, 64 * globals.KB), globals.MB)
+
+    // Iterate through the hash file line by line
+    for scanner.Scan() {
+        hash := strings.TrimSpace(scanner.Text())
+        if hash != "" {
+            hashes[strings.ToLower(hash)] = hash
+        }
+    }
+
+    return hashes, scanner.Err()
+}
+
+
+// Splits the cracked line into its hash and plaintext, preferring the shortest prefix
+// that is a hash in the hash file since both may contain colons.
+//
+// @Parameters
+// - line:  The cracked line in hash:plaintext format
+// - hashes:  The hashes of the hash file keyed by their lowercase form
+//
+// @Returns
+// - The hash of the line as it appears in the hash file when matched
+// - The plaintext of the hash
+// - true if the hash is in the hash file, otherwise false
+//
+func splitCrack(line string, hashes map[string]string) (string, string, bool) {
+    // Iterate through each colon in the line as a possible separator
+    for index := strings.Index(line, ":"); index != -1; {
+        if hash, ok := hashes[strings.ToLower(line[:index])]; ok {
+            return hash, line[index + 1:], true
+        }
+
+        next := strings.Index(line[index + 1:], ":")
+        // If there are no more colons
+        if next == -1 {
+            break
+        }
+
+        index += next + 1
+    }
+
+    hash, plaintext, _ := strings.Cut(line, ":")
+    return hash, plaintext, false
+}
+
+
+// Gets the hash file a cracked line belongs to, the one named by its marker, the
+// only hash file of the run, or otherwise the first hash file containing its hash.
+//
+// @Parameters
+// - crack:  The cracked line
+// - hashFiles:  The names of the hash files of the run in order
+// - hashes:  The hashes of each hash file keyed by name
+//
+// @Returns
+// - The name of the hash file the crack belongs to
+//
+func crackHashFile(crack Crack, hashFiles []string,
+                   hashes map[string]map[string]string) string {
+    // If the crack is marked with a hash file of the run
+    if _, exists := hashes[crack.HashFile]; exists {
+        return crack.HashFile
+    }
+
+    // If there is only one hash file, such as when it was split into shards
+    if len(hashFiles) == 1 {
+        return hashFiles[0]
+    }
+
+    // Iterate through the hash files looking for one containing the hash
+    for _, hashFile := range hashFiles {
+        if _, _, matched := splitCrack(crack.Line, hashes[hashFile]); matched {
+            return hashFile
+        }
+    }
+
+    return hashFiles[0]
+}
+
+
+// Merges the loot files of all clients, deduplicates the cracked hashes, and joins
+// them against the hash files to build the report, keeping the cracked hashes and
+// crack rate of each hash file separated.
+//
+// @Parameters
+// - hashFilePaths:  The paths of the original hash files
+// - lootFiles:  The loot files received from clients
+//
+// @Returns
+// - The built report
+// - Error if it occurs, otherwise nil on success
+//
+func Build(hashFilePaths []string, lootFiles []LootFile) (*Report, error) {
+    var report Report
+    var hashFiles []string
+    hashes := make(map[string]map[string]string)
+    seen := make(map[string]bool)
+    summaries := make(map[string]*Summary)
+
+    // If there are no hash files to join the cracked hashes against
+    if len(hashFilePaths) == 0 {
+        return nil, fmt.Errorf("no hash files to build the report from")
+    }
+
+    // Iterate through the hash files reading the hashes attempted to be cracked
+    for _, hashFilePath := range hashFilePaths {
+        hashFile := filepath.Base(hashFilePath)
+
+        fileHashes, err := readHashes(hashFilePath)
+        if err != nil {
+            return nil, fmt.Errorf("error reading hash file - %w", err)
+        }
+
+        hashFiles = append(hashFiles, hashFile)
+        hashes[hashFile] = fileHashes
+        summaries[hashFile] = &Summary{TotalHashes: len(fileHashes)}
+    }
+
+    // Iterate through the loot files of each client
+    for _, lootFile := range lootFiles {
+        cracks, err := ParseLoot(lootFile.Path)
+        if err != nil {
+            return nil, fmt.Errorf("error parsing loot file %s - %w", lootFile.Path, err)
+        }
+
+        timedOut, err := ParseTimedOut(lootFile.Path, lootFile.Client)
+        if err != nil {
+            return nil, fmt.Errorf("error parsing loot file %s - %w", lootFile.Path, err)
+        }
+        report.TimedOut = append(report.TimedOut, timedOut...)
+
+        // Iterate through the cracked lines adding the first occurrence of each hash
+        for _, crack := range cracks {
+            hashFile := crackHashFile(crack, hashFiles, hashes)
+            hash, plaintext, matched := splitCrack(crack.Line, hashes[hashFile])
+            // Hashes are deduplicated within each hash file
+            seenKey := hashFile + "\x00" + strings.ToLower(hash)
+            if seen[seenKey] {
+                continue
+            }
+
+            seen[seenKey] = true
+            summary := summaries[hashFile]
+
+            // If the cracked hash is not in the hash file, such as from a stale potfile
+            if !matched {
+                summary.Unmatched += 1
+            } else {
+                summary.Cracked += 1
+            }
+
+            report.Entries = append(report.Entries, Entry{Client: lootFile.Client,
+                                                          Hash: hash, HashFile: hashFile,
+                                                          Plaintext: plaintext,
+                                                          Timestamp: crack.Time,
+                                                          Wordlist: crack.Source})
+        }
+    }
+
+    // Sort the entries by when they were cracked, then by hash
+    slices.SortStableFunc(report.Entries, func(a, b Entry) int {
+        if compare := a.Timestamp.Compare(b.Timestamp); compare != 0 {
+            return compare
+        }
+
+        return strings.Compare(a.Hash, b.Hash)
+    })
+
+    // Iterate through the hash files in order totaling their summaries
+    for _, hashFile := range hashFiles {
+        summary := summaries[hashFile]
+        summary.setCrackRate()
+
+        report.Files = append(report.Files, FileSummary{HashFile: hashFile,
+                                                        Summary: *summary})
+        report.Summary.Cracked += summary.Cracked
+        report.Summary.TotalHashes += summary.TotalHashes
+        report.Summary.Unmatched += summary.Unmatched
+    }
+
+    report.Summary.setCrackRate()
+    return &report, nil
+}
+
+
+// Calculates the percentage of hashes cracked if there were hashes to crack.
+//
+func (summary *Summary) setCrackRate() {
+    // If there were hashes to crack, calculate the percentage cracked
+    if summary.TotalHashes > 0 {
+        summary.CrackRate = float64(summary.Cracked) / float64(summary.TotalHashes) * 100
+    }
+}
+
+
+// Writes the report with its summary as indented JSON.
+//
+// @Parameters
+// - reportPath:  The path where the JSON report is written
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (report *Report) WriteJson(reportPath string) error {
+    // Encode the report into indented JSON
+    reportJson, err := json.MarshalIndent(report, "", "  ")
+    if err != nil {
+        return err
+    }
+
+    return os.WriteFile(reportPath, append(reportJson, '\n'), 0644)
+}
+
+
+// Writes the report entries as CSV with a header row.
+//
+// @Parameters
+// - reportPath:  The path where the CSV report is written
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (report *Report) WriteCsv(reportPath string) error {
+    // Create the CSV file, truncating any previous data
+    file, err := os.Create(reportPath)
+    if err != nil {
+        return err
+    }
+    // Close file on local exit
+    defer file.Close()
+
+    writer := csv.NewWriter(file)
+    // Write the header row
+    err = writer.Write([]string{"hash", "plaintext", "client", "wordlist", "timestamp",
+                                "hash_file"})
+    if err != nil {
+        return err
+    }
+
+    // Iterate through the entries writing a row for each
+    for _, entry := range report.Entries {
+        timestamp := ""
+        // If the time the hash was cracked is known
+        if !entry.Timestamp.IsZero() {
+            timestamp = entry.Timestamp.Format(time.RFC3339)
+        }
+
+        err = writer.Write([]string{entry.Hash, entry.Plaintext, entry.Client,
+                                    entry.Wordlist, timestamp, entry.HashFile})
+        if err != nil {
+            return err
+        }
+    }
+
+    writer.Flush()
+    return writer.Error()
+}
+
+
+// Formats the crack rate summary of the report, followed by the summary of each hash
+// file when there is more than one.
+//
+// @Returns
+// - The formatted summary
+//
+func (report *Report) FormatSummary() string {
+    formatted := fmt.Sprintf("%d of %d hashes cracked (%.2f%%), %d cracked not in hash file",
+                             report.Summary.Cracked, report.Summary.TotalHashes,
+                             report.Summary.CrackRate, report.Summary.Unmatched)
+
+    // If there are multiple hash files, add the summary of each
+    if len(report.Files) > 1 {
+        for _, file := range report.Files {
+            formatted += fmt.Sprintf("\n  %s:  %d of %d cracked (%.2f%%)", file.HashFile,
+                                     file.Summary.Cracked, file.Summary.TotalHashes,
+                                     file.Summary.CrackRate)
+        }
+    }
+
+    // If any wordlists or keyspace ranges were cut short, list them
+    if len(report.TimedOut) > 0 {
+        sources := make([]string, 0, len(report.TimedOut))
+        for _, timedOut := range report.TimedOut {
+            sources = append(sources, timedOut.Source)
+        }
+
+        formatted += fmt.Sprintf("\n  %d timed out:  %s", len(sources),
+                                 strings.Join(sources, ", "))
+    }
+
+    return formatted
+}
+
+
+// Writes the hashes of the hash file not cracked in the loot file, in the order of the
+// hash file, so an aborted run can be resumed against only the remaining hashes.
+//
+// @Parameters
+// - hashFilePath:  The path of the hash file
+// - lootPath:  The path of the loot file the cracked hashes were appended to
+// - remainingPath:  The path where the uncracked hashes are written
+//
+// @Returns
+// - The number of uncracked hashes written
+// - Error if it occurs, otherwise nil on success
+//
+func Remaining(hashFilePath string, lootPath string, remainingPath string) (int, error) {
+    cracks, err := ParseLoot(lootPath)
+    // If the loot file failed to parse, a missing one means nothing was cracked
+    if err != nil && !os.IsNotExist(err) {
+        return 0, err
+    }
+
+    return Uncracked(hashFilePath, cracks, remainingPath)
+}
+
+
+// Writes the hashes of the hash file not in the cracked lines, in the order of the hash
+// file, so hashes already cracked are not distributed again.
+//
+// @Parameters
+// - hashFilePath:  The path of the hash file
+// - cracks:  The cracked lines whose hashes are removed
+// - remainingPath:  The path where the uncracked hashes are written
+//
+// @Returns
+// - The number of uncracked hashes written
+// - Error if it occurs, otherwise nil on success
+//
+func Uncracked(hashFilePath string, cracks []Crack, remainingPath string) (int, error) {
+    hashes, err := readHashes(hashFilePath)
+    if err != nil {
+        return 0, err
+    }
+
+    // Iterate through the cracked lines removing their hashes
+    for _, crack := range cracks {
+        if hash, _, matched := splitCrack(crack.Line, hashes); matched {
+            delete(hashes, strings.ToLower(hash))
+        }
+    }
+
+    hashFile, err := os.Open(hashFilePath)
+    if err != nil {
+        return 0, err
+    }
+    // Close file on local exit
+    defer hashFile.Close()
+
+    remainingFile, err := os.Create(remainingPath)
+    if err != nil {
+        return 0, err
+    }
+    // Close file on local exit
+    defer remainingFile.Close()
+
+    scanner := bufio.NewScanner(hashFile)
+    scanner.Buffer(make([]byte, 64 * globals.KB), globals.MB)
+    writer := bufio.NewWriter(remainingFile)
+    count := 0
+
+    // Iterate through the hash file writing the uncracked hashes once each
+    for scanner.Scan() {
+        key := strings.ToLower(strings.TrimSpace(scanner.Text()))
+        hash, exists := hashes[key]
+        if !exists {
+            continue
+        }
+
+        delete(hashes, key)
+        writer.WriteString(hash + "\n")
+        count++
+    }
+
+    if err := scanner.Err(); err != nil {
+        return 0, err
+    }
+
+    return count, writer.Flush()
+}
@@ -0,0 +1,256 @@
+package report_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestParseLoot(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    crackTime := time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC)
+    lootData := string(report.FormatSource("rockyou.txt", crackTime)) +
+                "8846f7eaee8fb117ad06bdd830b7586c:password\n\n" +
+                string(report.FormatSource("keyspace 0+1000", crackTime.Add(time.Hour))) +
+                "32ed87bdb5fdc5e9cba88547376818d4:123456\n"
+
+    lootPath := filepath.Join(t.TempDir(), "loot.txt")
+    // Write the loot file with source markers
+    err := os.WriteFile(lootPath, []byte(lootData), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    cracks, err := report.ParseLoot(lootPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the markers and empty lines are not counted as cracks
+    assert.Equal(2, len(cracks))
+    assert.Equal(report.Crack{Line: "8846f7eaee8fb117ad06bdd830b7586c:password",
+                              Source: "rockyou.txt", Time: crackTime}, cracks[0])
+    assert.Equal("keyspace 0+1000", cracks[1].Source)
+
+    // Write a loot file of a client that did not crack any hashes
+    err = os.WriteFile(lootPath, append(globals.NO_CRACKED_HASHES, '\n'), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    cracks, err = report.ParseLoot(lootPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(0, len(cracks))
+}
+
+
+func TestBuild(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    testDir := t.TempDir()
+
+    hashFilePath := filepath.Join(testDir, "hashes.txt")
+    // Write the hash file including a salted hash that contains a colon
+    err := os.WriteFile(hashFilePath, []byte("8846F7EAEE8FB117AD06BDD830B7586C\n" +
+                                             "5f4dcc3b5aa765d61d8327deb882cf99:salt\n" +
+                                             "32ed87bdb5fdc5e9cba88547376818d4\n" +
+                                             "e10adc3949ba59abbe56e057f20f883e\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    crackTime := time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC)
+    firstLoot := filepath.Join(testDir, "loot1.txt")
+    // Write the first client loot with a plaintext that contains a colon
+    err = os.WriteFile(firstLoot, []byte(string(report.FormatSource("a.txt", crackTime)) +
+                                         "8846f7eaee8fb117ad06bdd830b7586c:pass:word\n" +
+                                         "5f4dcc3b5aa765d61d8327deb882cf99:salt:letmein\n"),
+                       0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    secondLoot := filepath.Join(testDir, "loot2.txt")
+    // Write the second client loot with a duplicate, a hash not in the hash file, and
+    // the wordlist timing out after its cracks
+    err = os.WriteFile(secondLoot,
+                       []byte(string(report.FormatSource("b.txt", crackTime.Add(time.Minute))) +
+                              "8846f7eaee8fb117ad06bdd830b7586c:pass:word\n" +
+                              "32ed87bdb5fdc5e9cba88547376818d4:123456\n" +
+                              "ffffffffffffffffffffffffffffffff:stale\n" +
+                              string(report.FormatTimedOut("b.txt", crackTime.Add(time.Hour)))),
+                       0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    crackReport, err := report.Build([]string{hashFilePath}, []report.LootFile{
+        {Client: "10.0.0.1:5000", Path: firstLoot},
+        {Client: "10.0.0.2:5000", Path: secondLoot},
+    })
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure duplicates are removed and the hashes are joined against the hash file
+    assert.Equal(4, len(crackReport.Entries))
+    assert.Equal(report.Entry{Client: "10.0.0.1:5000",
+                              Hash: "5f4dcc3b5aa765d61d8327deb882cf99:salt",
+                              HashFile: "hashes.txt", Plaintext: "letmein",
+                              Timestamp: crackTime, Wordlist: "a.txt"},
+                 crackReport.Entries[0])
+    assert.Equal("8846F7EAEE8FB117AD06BDD830B7586C", crackReport.Entries[1].Hash)
+    assert.Equal("pass:word", crackReport.Entries[1].Plaintext)
+    assert.Equal(report.Summary{Cracked: 3, CrackRate: 75, TotalHashes: 4, Unmatched: 1},
+                 crackReport.Summary)
+    assert.Contains(crackReport.FormatSummary(), "3 of 4 hashes cracked (75.00%)")
+
+    // Ensure the timed out marker is reported rather than counted as a crack
+    assert.Equal([]report.TimedOut{{Client: "10.0.0.2:5000", Source: "b.txt",
+                                    Timestamp: crackTime.Add(time.Hour)}},
+                 crackReport.TimedOut)
+    assert.Contains(crackReport.FormatSummary(), "1 timed out:  b.txt")
+
+    jsonPath := filepath.Join(testDir, "report.json")
+    // Write the JSON report
+    err = crackReport.WriteJson(jsonPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    reportJson, err := os.ReadFile(jsonPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    var parsed report.Report
+    // Ensure the JSON report parses back into the report
+    err = json.Unmarshal(reportJson, &parsed)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(crackReport.Summary, parsed.Summary)
+
+    csvPath := filepath.Join(testDir, "report.csv")
+    // Write the CSV report
+    err = crackReport.WriteCsv(csvPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    csvData, err := os.ReadFile(csvPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    rows, err := csv.NewReader(strings.NewReader(string(csvData))).ReadAll()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure there is a header row followed by a row per entry
+    assert.Equal(5, len(rows))
+    assert.Equal([]string{"hash", "plaintext", "client", "wordlist", "timestamp",
+                          "hash_file"}, rows[0])
+    assert.Equal("2025-03-14T09:26:53Z", rows[1][4])
+    assert.Equal("hashes.txt", rows[1][5])
+}
+
+
+func TestBuildMultipleHashFiles(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    testDir := t.TempDir()
+
+    ntlmPath := filepath.Join(testDir, "ntlm.txt")
+    md5Path := filepath.Join(testDir, "md5.txt")
+    // Write two hash files sharing a hash, each cracked as a different hash type
+    err := os.WriteFile(ntlmPath, []byte("8846f7eaee8fb117ad06bdd830b7586c\n" +
+                                         "32ed87bdb5fdc5e9cba88547376818d4\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    err = os.WriteFile(md5Path, []byte("5f4dcc3b5aa765d61d8327deb882cf99\n" +
+                                       "32ed87bdb5fdc5e9cba88547376818d4\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    crackTime := time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC)
+    lootPath := filepath.Join(testDir, "loot.txt")
+    // Write a loot file with the cracks of each hash file under its marker
+    err = os.WriteFile(lootPath, []byte(string(report.FormatHashFile("ntlm.txt")) +
+                                        string(report.FormatSource("a.txt", crackTime)) +
+                                        "32ed87bdb5fdc5e9cba88547376818d4:123456\n" +
+                                        string(report.FormatHashFile("md5.txt")) +
+                                        string(report.FormatSource("a.txt", crackTime)) +
+                                        "32ed87bdb5fdc5e9cba88547376818d4:other\n" +
+                                        "5f4dcc3b5aa765d61d8327deb882cf99:password\n"),
+                       0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    cracks, err := report.ParseLoot(lootPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("ntlm.txt", cracks[0].HashFile)
+    assert.Equal("md5.txt", cracks[1].HashFile)
+
+    crackReport, err := report.Build([]string{ntlmPath, md5Path}, []report.LootFile{
+        {Client: "10.0.0.1:5000", Path: lootPath},
+    })
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the same hash is kept separately for each hash file
+    assert.Equal(3, len(crackReport.Entries))
+    assert.Equal([]report.FileSummary{
+        {HashFile: "ntlm.txt", Summary: report.Summary{Cracked: 1, CrackRate: 50,
+                                                       TotalHashes: 2}},
+        {HashFile: "md5.txt", Summary: report.Summary{Cracked: 2, CrackRate: 100,
+                                                      TotalHashes: 2}},
+    }, crackReport.Files)
+    assert.Equal(report.Summary{Cracked: 3, CrackRate: 75, TotalHashes: 4},
+                 crackReport.Summary)
+    assert.Contains(crackReport.FormatSummary(), "md5.txt:  2 of 2 cracked (100.00%)")
+
+    // Ensure a report can not be built without a hash file
+    _, err = report.Build(nil, nil)
+    assert.NotNil(err)
+}
+
+
+func TestRemaining(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    testDir := t.TempDir()
+
+    hashFilePath := filepath.Join(testDir, "hashes.txt")
+    lootPath := filepath.Join(testDir, "loot.txt")
+    remainingPath := filepath.Join(testDir, "remaining.txt")
+
+    // Write a hash file with a duplicate and a hash of a different case than cracked
+    err := os.WriteFile(hashFilePath, []byte("8846F7EAEE8FB117AD06BDD830B7586C\n" +
+                                             "32ed87bdb5fdc5e9cba88547376818d4\n" +
+                                             "5f4dcc3b5aa765d61d8327deb882cf99\n\n" +
+                                             "32ed87bdb5fdc5e9cba88547376818d4\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure every hash remains when nothing was cracked to a loot file
+    count, err := report.Remaining(hashFilePath, lootPath, remainingPath)
+    assert.Equal(nil, err)
+    assert.Equal(3, count)
+
+    err = os.WriteFile(lootPath, []byte(string(report.FormatSource("a.txt", time.Now())) +
+                                        "8846f7eaee8fb117ad06bdd830b7586c:password\n"),
+                       0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    count, err = report.Remaining(hashFilePath, lootPath, remainingPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(2, count)
+
+    remaining, err := os.ReadFile(remainingPath)
+    assert.Equal(nil, err)
+    // Ensure the uncracked hashes are written once each in the order of the hash file
+    assert.Equal("32ed87bdb5fdc5e9cba88547376818d4\n5f4dcc3b5aa765d61d8327deb882cf99\n",
+                 string(remaining))
+}
@@ -0,0 +1,373 @@
+package restore
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Name of the list of wordlists a client had not processed when its run was aborted
+const RemainingWordlists = "remaining_wordlists.txt"
+// Prefix of the uncracked hash files in the restore bundle
+const RemainingPrefix = "remaining-"
+// Suffix of the file naming the source a checkpointed session was processing
+const SourceSuffix = ".source"
+
+
+// Checkpoint is a hashcat session left with a restore file when its run was hibernated
+type Checkpoint struct {
+    HashFile string  // Path of the hash file the session cracked, empty if not recorded
+    Session  string  // Name of the hashcat session
+    Source   string  // Wordlist or range the session was processing, empty if not recorded
+}
+
+
+// Watcher polls the server on an interval for an abort of the run, so hashcat can be
+// interrupted while it is still able to write its restore file
+type Watcher struct {
+    interval time.Duration
+    stopCh   chan struct{}
+    stopOnce sync.Once
+    wg       sync.WaitGroup
+}
+
+
+// Gets the hashcat arguments that name the session and where its restore file is
+// written, so concurrent jobs do not overwrite each other's restore file.
+//
+// @Parameters
+// - restoreDir:  The dir where restore files are written
+// - session:  The name of the hashcat session
+//
+// @Returns
+// - The hashcat session and restore file path arguments
+//
+func SessionArgs(restoreDir string, session string) []string {
+    return []string{"--session", session, "--restore-file-path",
+                    filepath.Join(restoreDir, session + ".restore")}
+}
+
+
+// Gets the hashcat arguments resuming the session from its restore file, hashcat takes
+// the rest of its arguments from the restore file.
+//
+// @Parameters
+// - restoreDir:  The dir where restore files are written
+// - session:  The name of the hashcat session
+//
+// @Returns
+// - The hashcat arguments restoring the session
+//
+func ResumeArgs(restoreDir string, session string) []string {
+    return append(SessionArgs(restoreDir, session), "--restore")
+}
+
+
+// Gets the name of the hashcat session from its arguments.
+//
+// @Parameters
+// - cmdArgs:  The args passed into hashcat
+//
+// @Returns
+// - The name of the session, empty if the args do not name one
+//
+func ArgsSession(cmdArgs []string) string {
+    index := slices.Index(cmdArgs, "--session")
+    // If the session is not named or its name is missing
+    if index < 0 || index + 1 >= len(cmdArgs) {
+        return ""
+    }
+
+    return cmdArgs[index + 1]
+}
+
+
+// Records the hash file and source the session was processing when it was checkpointed,
+// so its cracks are attributed once it is resumed.
+//
+// @Parameters
+// - restoreDir:  The dir where restore files are written
+// - session:  The name of the hashcat session
+// - hashFile:  The path of the hash file the session cracked
+// - source:  The wordlist or range the session was processing
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func WriteSource(restoreDir string, session string, hashFile string, source string) error {
+    return os.WriteFile(filepath.Join(restoreDir, session + SourceSuffix),
+                        []byte(hashFile + "\n" + source), 0644)
+}
+
+
+// Gets the sessions left with a restore file in the restore dir, along with the hash file
+// and source each was processing when recorded.
+//
+// @Parameters
+// - restoreDir:  The dir where restore files are written
+//
+// @Returns
+// - The checkpointed sessions ordered by name
+// - Error if it occurs, otherwise nil on success
+//
+func Checkpoints(restoreDir string) ([]Checkpoint, error) {
+    filePaths, err := filepath.Glob(filepath.Join(restoreDir, "*.restore"))
+    if err != nil {
+        return nil, err
+    }
+
+    checkpoints := make([]Checkpoint, 0, len(filePaths))
+    // Iterate through the restore files reading the source recorded for each
+    for _, filePath := range filePaths {
+        session := strings.TrimSuffix(filepath.Base(filePath), ".restore")
+
+        recorded, err := os.ReadFile(filepath.Join(restoreDir, session + SourceSuffix))
+        if err != nil && !os.IsNotExist(err) {
+            return nil, err
+        }
+
+        hashFile, source, _ := strings.Cut(string(recorded), "\n")
+        checkpoints = append(checkpoints, Checkpoint{HashFile: hashFile, Session: session,
+                                                     Source: source})
+    }
+
+    return checkpoints, nil
+}
+
+
+// Deletes the restore file of the session and the source recorded for it.
+//
+// @Parameters
+// - restoreDir:  The dir where restore files are written
+// - session:  The name of the hashcat session
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func Clear(restoreDir string, session string) error {
+    // Iterate through the files of the session removing those that exist
+    for _, fileName := range []string{session + ".restore", session + SourceSuffix} {
+        err := os.Remove(filepath.Join(restoreDir, fileName))
+        if err != nil && !os.IsNotExist(err) {
+            return err
+        }
+    }
+
+    return nil
+}
+
+
+// Creates a watcher polling on the passed in interval.
+//
+// @Parameters
+// - interval:  The duration of time between polls
+//
+// @Returns
+// - The initialized watcher
+//
+func NewWatcher(interval time.Duration) *Watcher {
+    return &Watcher{interval: interval, stopCh: make(chan struct{})}
+}
+
+
+// Starts polling in a Goroutine until the run is aborted or Stop() is called.
+//
+// @Parameters
+// - check:  Asks the server whether the run is aborted
+// - onAbort:  Called once when the server aborts the run
+//
+func (watcher *Watcher) Start(check func() (bool, error), onAbort func()) {
+    watcher.wg.Add(1)
+
+    go func() {
+        defer watcher.wg.Done()
+
+        ticker := time.NewTicker(watcher.interval)
+        defer ticker.Stop()
+
+        for {
+            select {
+            case <-ticker.C:
+                aborted, err := check()
+                // If the check failed it is retried on the next poll
+                if err != nil || !aborted {
+                    continue
+                }
+
+                onAbort()
+                return
+            case <-watcher.stopCh:
+                return
+            }
+        }
+    } ()
+}
+
+
+// Stops the watcher, waiting for a poll in progress to complete.
+//
+func (watcher *Watcher) Stop() {
+    // If the watcher was never created
+    if watcher == nil {
+        return
+    }
+
+    watcher.stopOnce.Do(func() {
+        close(watcher.stopCh)
+    })
+
+    watcher.wg.Wait()
+}
+
+
+// Adds the file at the passed in path to the tar archive under its base name.
+//
+// @Parameters
+// - tarWriter:  The tar archive writer
+// - filePath:  The path of the file to add
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func addFile(tarWriter *tar.Writer, filePath string) error {
+    file, err := os.Open(filePath)
+    if err != nil {
+        return err
+    }
+    // Close the file on local exit
+    defer file.Close()
+
+    fileInfo, err := file.Stat()
+    if err != nil {
+        return err
+    }
+
+    header, err := tar.FileInfoHeader(fileInfo, "")
+    if err != nil {
+        return err
+    }
+
+    header.Name = filepath.Base(filePath)
+    err = tarWriter.WriteHeader(header)
+    if err != nil {
+        return err
+    }
+
+    _, err = io.Copy(tarWriter, file)
+    return err
+}
+
+
+// Writes the restore files and remaining hash lists to a gzipped tar archive, skipping
+// files that do not exist such as the restore file of a job that already finished.
+//
+// @Parameters
+// - bundlePath:  The path where the archive is written
+// - filePaths:  The paths of the files to bundle, stored under their base names
+//
+// @Returns
+// - The number of files bundled
+// - Error if it occurs, otherwise nil on success
+//
+func WriteBundle(bundlePath string, filePaths []string) (int, error) {
+    bundle, err := os.Create(bundlePath)
+    if err != nil {
+        return 0, err
+    }
+    // Close the file on local exit
+    defer bundle.Close()
+
+    gzipWriter := gzip.NewWriter(bundle)
+    tarWriter := tar.NewWriter(gzipWriter)
+    count := 0
+
+    // Iterate through the files adding the existing ones to the archive
+    for _, filePath := range filePaths {
+        err = addFile(tarWriter, filePath)
+        if err != nil {
+            // If the file was never written
+            if os.IsNotExist(err) {
+                continue
+            }
+
+            return 0, fmt.Errorf("error adding %s to restore bundle - %w", filePath, err)
+        }
+
+        count++
+    }
+
+    err = tarWriter.Close()
+    if err != nil {
+        return 0, err
+    }
+
+    err = gzipWriter.Close()
+    if err != nil {
+        return 0, err
+    }
+
+    return count, bundle.Close()
+}
+
+
+// Reads the list of wordlists the client had not processed from its restore bundle.
+//
+// @Parameters
+// - bundlePath:  The path of the restore bundle
+//
+// @Returns
+// - The names of the unprocessed wordlists, empty if the bundle has no list
+// - Error if it occurs, otherwise nil on success
+//
+func BundleWordlists(bundlePath string) ([]string, error) {
+    bundle, err := os.Open(bundlePath)
+    if err != nil {
+        return nil, err
+    }
+    // Close the file on local exit
+    defer bundle.Close()
+
+    gzipReader, err := gzip.NewReader(bundle)
+    if err != nil {
+        return nil, fmt.Errorf("error reading restore bundle - %w", err)
+    }
+
+    tarReader := tar.NewReader(gzipReader)
+    // Iterate through the archive until the wordlist list is found
+    for {
+        header, err := tarReader.Next()
+        if err == io.EOF {
+            return nil, nil
+        }
+        if err != nil {
+            return nil, fmt.Errorf("error reading restore bundle - %w", err)
+        }
+
+        // If the entry is not the wordlist list
+        if header.Name != RemainingWordlists {
+            continue
+        }
+
+        wordlists, err := io.ReadAll(io.LimitReader(tarReader, 16 * 1024 * 1024))
+        if err != nil {
+            return nil, fmt.Errorf("error reading remaining wordlists - %w", err)
+        }
+
+        var names []string
+        // Iterate through the lines of the list keeping the wordlist names
+        for _, name := range strings.Split(string(wordlists), "\n") {
+            if name != "" {
+                names = append(names, name)
+            }
+        }
+
+        return names, nil
+    }
+}
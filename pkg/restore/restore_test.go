@@ -0,0 +1,167 @@
+package restore_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/restore"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestWriteBundle(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    testDir := t.TempDir()
+
+    restorePath := filepath.Join(testDir, "kloudkraken.restore")
+    remainingPath := filepath.Join(testDir, restore.RemainingPrefix + "hashes.txt")
+    assert.Equal(nil, os.WriteFile(restorePath, []byte("restore"), 0644))
+    assert.Equal(nil, os.WriteFile(remainingPath, []byte("hash\n"), 0644))
+
+    bundlePath := filepath.Join(testDir, "restore.tar.gz")
+    count, err := restore.WriteBundle(bundlePath, []string{
+        restorePath, filepath.Join(testDir, "missing.restore"), remainingPath,
+    })
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the missing restore file is skipped
+    assert.Equal(2, count)
+
+    bundle, err := os.Open(bundlePath)
+    assert.Equal(nil, err)
+    defer bundle.Close()
+
+    gzipReader, err := gzip.NewReader(bundle)
+    assert.Equal(nil, err)
+
+    var names []string
+    tarReader := tar.NewReader(gzipReader)
+    // Iterate through the archive collecting the names of the files
+    for {
+        header, err := tarReader.Next()
+        if err != nil {
+            break
+        }
+
+        names = append(names, header.Name)
+    }
+
+    // Ensure the files are stored under their base names in order
+    assert.Equal([]string{"kloudkraken.restore", "remaining-hashes.txt"}, names)
+}
+
+
+func TestBundleWordlists(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    testDir := t.TempDir()
+
+    wordlistsPath := filepath.Join(testDir, restore.RemainingWordlists)
+    assert.Equal(nil, os.WriteFile(wordlistsPath, []byte("rock you.txt\nmerged.txt\n"), 0644))
+
+    bundlePath := filepath.Join(testDir, "restore.tar.gz")
+    _, err := restore.WriteBundle(bundlePath, []string{wordlistsPath})
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    wordlists, err := restore.BundleWordlists(bundlePath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal([]string{"rock you.txt", "merged.txt"}, wordlists)
+
+    // Ensure a bundle without the list has no wordlists
+    _, err = restore.WriteBundle(bundlePath, nil)
+    assert.Equal(nil, err)
+    wordlists, err = restore.BundleWordlists(bundlePath)
+    assert.Equal(nil, err)
+    assert.Equal(0, len(wordlists))
+}
+
+
+func TestCheckpoints(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    testDir := t.TempDir()
+
+    // Ensure the restored session keeps its session and restore file args
+    assert.Equal([]string{"--session", "kloudkraken-2", "--restore-file-path",
+                          filepath.Join(testDir, "kloudkraken-2.restore"), "--restore"},
+                 restore.ResumeArgs(testDir, "kloudkraken-2"))
+
+    for _, session := range []string{"kloudkraken-1", "kloudkraken-2"} {
+        err := os.WriteFile(filepath.Join(testDir, session + ".restore"), []byte("restore"),
+                            0644)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+    }
+
+    // Ensure the session is read from the hashcat args
+    assert.Equal("kloudkraken-2", restore.ArgsSession(restore.ResumeArgs(testDir,
+                                                                         "kloudkraken-2")))
+    assert.Equal("", restore.ArgsSession([]string{"-a", "0", "--session"}))
+
+    err := restore.WriteSource(testDir, "kloudkraken-2", "/hashes/hashes.txt", "rockyou.txt")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    checkpoints, err := restore.Checkpoints(testDir)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure a session without a recorded source is still resumed
+    assert.Equal([]restore.Checkpoint{{Session: "kloudkraken-1"},
+                                      {HashFile: "/hashes/hashes.txt", Session: "kloudkraken-2",
+                                       Source: "rockyou.txt"}},
+                 checkpoints)
+
+    // Ensure clearing a session removes its restore file and source
+    assert.Equal(nil, restore.Clear(testDir, "kloudkraken-2"))
+    assert.Equal(nil, restore.Clear(testDir, "kloudkraken-2"))
+    checkpoints, err = restore.Checkpoints(testDir)
+    assert.Equal(nil, err)
+    assert.Equal([]restore.Checkpoint{{Session: "kloudkraken-1"}}, checkpoints)
+}
+
+
+func TestWatcher(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    var checks atomic.Int32
+    aborted := make(chan struct{})
+
+    watcher := restore.NewWatcher(time.Millisecond)
+    watcher.Start(func() (bool, error) {
+        // Fail the first check, then report the abort on the third
+        switch checks.Add(1) {
+        case 1:
+            return false, errors.New("connection reset")
+        case 2:
+            return false, nil
+        default:
+            return true, nil
+        }
+    }, func() {
+        close(aborted)
+    })
+
+    select {
+    case <-aborted:
+    case <-time.After(5 * time.Second):
+        t.Fatal("watcher never reported the abort")
+    }
+
+    watcher.Stop()
+    // Ensure polling stopped after the abort
+    assert.Equal(int32(3), checks.Load())
+
+    // Ensure stopping a watcher that was never created is safe
+    var nilWatcher *restore.Watcher
+    nilWatcher.Stop()
+}
@@ -0,0 +1,152 @@
+package schedule
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Characters that delimit the pairings when they are passed to clients as a flag
+const PairingDelimiters = ",:+"
+
+
+// Pairing assigns the rulesets the wordlists matching its pattern are run with
+type Pairing struct {
+    Rulesets  []string `yaml:"rulesets"`
+    Wordlists string   `yaml:"wordlists"`
+}
+
+
+// Gets the rulesets a wordlist is run with, one hashcat pass each. The first pairing
+// whose pattern matches the name or family of the wordlist is used, a pairing with no
+// rulesets runs the wordlist without rules, and wordlists matching no pairing get a
+// pass with every ruleset.
+//
+// @Parameters
+// - fileName:  The name of the wordlist
+// - pairings:  The pairings of wordlists with the rulesets they are run with
+// - rulesets:  The names of every ruleset in use
+//
+// @Returns
+// - The names of the rulesets of each pass, empty if run without rules
+//
+func Passes(fileName string, pairings []Pairing, rulesets []string) []string {
+    // Iterate through the pairings in the order they are listed
+    for _, pairing := range pairings {
+        // If the name matches the pattern or its family does
+        if matched, _ := filepath.Match(pairing.Wordlists, fileName); matched ||
+           pairing.Wordlists == Family(fileName) {
+            return pairing.Rulesets
+        }
+    }
+
+    return rulesets
+}
+
+
+// Ensures the pairings have valid patterns and only reference known rulesets.
+//
+// @Parameters
+// - pairings:  The pairings to be validated
+// - rulesets:  The names of every ruleset in use
+//
+// @Returns
+// - Error if a pairing is invalid, otherwise nil
+//
+func ValidatePairings(pairings []Pairing, rulesets []string) error {
+    known := map[string]bool{}
+    // Iterate through the rulesets noting their names
+    for _, ruleset := range rulesets {
+        known[ruleset] = true
+    }
+
+    // Iterate through the pairings validating each
+    for _, pairing := range pairings {
+        // Ensure the pattern is valid before it is used for matching
+        _, err := filepath.Match(pairing.Wordlists, "")
+        if pairing.Wordlists == "" || err != nil {
+            return fmt.Errorf("improper wordlists pattern %q in ruleset pairing",
+                              pairing.Wordlists)
+        }
+
+        // The pattern is passed to clients in a flag split on the delimiters
+        if strings.ContainsAny(pairing.Wordlists, PairingDelimiters) {
+            return fmt.Errorf("wordlists pattern %q can not contain any of %q",
+                              pairing.Wordlists, PairingDelimiters)
+        }
+
+        // Iterate through the rulesets of the pairing ensuring each is in use
+        for _, ruleset := range pairing.Rulesets {
+            if !known[ruleset] {
+                return fmt.Errorf("ruleset %s paired with %s is not in use", ruleset,
+                                  pairing.Wordlists)
+            }
+
+            // The ruleset name is passed to clients in the same flag
+            if strings.ContainsAny(ruleset, PairingDelimiters) {
+                return fmt.Errorf("paired ruleset name %q can not contain any of %q",
+                                  ruleset, PairingDelimiters)
+            }
+        }
+    }
+
+    return nil
+}
+
+
+// Formats the pairings into the value of the client flag they are passed in, with the
+// pairings comma separated, the pattern colon separated from its rulesets, and the
+// rulesets plus separated.
+//
+// @Parameters
+// - pairings:  The pairings of wordlists with the rulesets they are run with
+//
+// @Returns
+// - The formatted pairings
+//
+func FormatPairings(pairings []Pairing) string {
+    var formatted []string
+    // Iterate through the pairings formatting each
+    for _, pairing := range pairings {
+        formatted = append(formatted,
+                           pairing.Wordlists + ":" + strings.Join(pairing.Rulesets, "+"))
+    }
+
+    return strings.Join(formatted, ",")
+}
+
+
+// Parses the pairings from the value of the client flag they are passed in.
+//
+// @Parameters
+// - value:  The formatted pairings
+//
+// @Returns
+// - The pairings of wordlists with the rulesets they are run with
+// - Error if it occurs, otherwise nil on success
+//
+func ParsePairings(value string) ([]Pairing, error) {
+    // If no pairings were passed
+    if value == "" {
+        return nil, nil
+    }
+
+    var pairings []Pairing
+    // Iterate through the formatted pairings parsing each
+    for _, formatted := range strings.Split(value, ",") {
+        pattern, rulesets, found := strings.Cut(formatted, ":")
+        if !found || pattern == "" {
+            return nil, fmt.Errorf("improper ruleset pairing %q", formatted)
+        }
+
+        pairing := Pairing{Wordlists: pattern}
+        // If the wordlists are run with rules
+        if rulesets != "" {
+            pairing.Rulesets = strings.Split(rulesets, "+")
+        }
+
+        pairings = append(pairings, pairing)
+    }
+
+    return pairings, nil
+}
@@ -0,0 +1,106 @@
+package schedule_test
+
+import (
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/schedule"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func testPairings() []schedule.Pairing {
+    return []schedule.Pairing{
+        {Wordlists: "rockyou", Rulesets: []string{"best64.rule", "dive.rule"}},
+        {Wordlists: "names*", Rulesets: nil},
+    }
+}
+
+
+func TestPasses(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    rulesets := []string{"best64.rule", "dive.rule", "toggles.rule"}
+
+    // Ensure a split or merged wordlist is paired through its family
+    assert.Equal([]string{"best64.rule", "dive.rule"},
+                 schedule.Passes("rockyou_2.txt", testPairings(), rulesets))
+    // Ensure a pairing without rulesets runs the wordlist without rules
+    assert.Equal(0, len(schedule.Passes("names.txt", testPairings(), rulesets)))
+    // Ensure an unpaired wordlist gets a pass with every ruleset
+    assert.Equal(rulesets, schedule.Passes("leaks_1.txt", testPairings(), rulesets))
+    assert.Equal(0, len(schedule.Passes("leaks_1.txt", nil, nil)))
+}
+
+
+func TestValidatePairings(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    rulesets := []string{"best64.rule", "dive.rule"}
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, schedule.ValidatePairings(testPairings(), rulesets))
+
+    falacies := [][]schedule.Pairing{
+        {{Wordlists: "", Rulesets: rulesets}},
+        {{Wordlists: "[bad", Rulesets: rulesets}},
+        {{Wordlists: "rock:you", Rulesets: rulesets}},
+        {{Wordlists: "rockyou", Rulesets: []string{"missing.rule"}}},
+    }
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        assert.NotEqual(nil, schedule.ValidatePairings(falacy, rulesets))
+    }
+
+    // Ensure ruleset names containing the delimiters can not be paired
+    assert.NotEqual(nil, schedule.ValidatePairings(
+        []schedule.Pairing{{Wordlists: "rockyou", Rulesets: []string{"a+b.rule"}}},
+        []string{"a+b.rule"}))
+}
+
+
+func TestFormatParsePairings(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    formatted := schedule.FormatPairings(testPairings())
+    assert.Equal("rockyou:best64.rule+dive.rule,names*:", formatted)
+
+    pairings, err := schedule.ParsePairings(formatted)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(testPairings(), pairings)
+
+    pairings, err = schedule.ParsePairings("")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(0, len(pairings))
+
+    // Ensure pairings missing a pattern or delimiter are rejected
+    for _, falacy := range []string{"rockyou", ":best64.rule", "rockyou:best64.rule,"} {
+        _, err = schedule.ParsePairings(falacy)
+        assert.NotEqual(nil, err)
+    }
+}
+
+
+func TestOrderHitRatePasses(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    scheduler, err := schedule.New(schedule.StrategyHitRate, "",
+                                   []string{"best64.rule", "dive.rule"}, testPairings())
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    scheduler.Record("rockyou_1.txt", 100, 1 * globals.MB)
+    // Ensure the stats are shared with wordlists of the same family and passes
+    yield, exists := scheduler.Yield("rockyou_2.txt")
+    assert.True(exists)
+    assert.Equal(int64(100), yield.Cracked)
+
+    // Ensure wordlists run with other passes do not share the stats
+    _, exists = scheduler.Yield("names.txt")
+    assert.False(exists)
+}
@@ -0,0 +1,401 @@
+package schedule
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
+)
+
+// Ordering strategies of the wordlists remaining in the load dir
+const (
+    StrategyHitRate       = "hit_rate"
+    StrategyLargestFirst  = "largest_first"
+    StrategyPriority      = "priority"
+    StrategyRoundRobin    = "round_robin"
+    StrategySize          = "size"
+    StrategySmallestFirst = "smallest_first"
+)
+
+// Matches the numbered suffix of a split or merged wordlist name
+var ReNumberSuffix = regexp.MustCompile(`[-_.]?[0-9]+$`)
+
+
+// Yield is the cracked hashes and processed bytes of a wordlist family
+type Yield struct {
+    Bytes   int64
+    Cracked int64
+}
+
+// Returns the cracked hashes per MB processed.
+//
+// @Returns
+// - The rate of cracked hashes per MB
+//
+func (yield Yield) Rate() float64 {
+    // If nothing has been processed yet
+    if yield.Bytes <= 0 {
+        return 0
+    }
+
+    return float64(yield.Cracked) / (float64(yield.Bytes) / float64(globals.MB))
+}
+
+
+// Scheduler orders the remaining wordlists of the load dir by the selected strategy,
+// tracking the cracked hashes clients report per wordlist for the hit rate strategy
+type Scheduler struct {
+    mutx       sync.Mutex
+    pairings   []Pairing
+    priorities []string
+    rulesets   []string
+    selection  disk.Strategy
+    stats      map[string]Yield
+    strategy   string
+}
+
+// Creates a scheduler with the passed in strategy.
+//
+// @Parameters
+// - strategy:  The ordering strategy, empty keeps the load dir order
+// - priorityPath:  The path of the priority file used by the priority strategy
+// - rulesets:  The names of the rulesets in use, stats are tracked per wordlist and
+//              rule passes combination
+// - pairings:  The pairings of wordlists with the rulesets they are run with
+//
+// @Returns
+// - The initialized scheduler
+// - Error if it occurs, otherwise nil on success
+//
+func New(strategy string, priorityPath string, rulesets []string,
+         pairings []Pairing) (*Scheduler, error) {
+    scheduler := &Scheduler{
+        pairings: pairings,
+        rulesets: rulesets,
+        stats:    map[string]Yield{},
+        strategy: strategy,
+    }
+
+    switch strategy {
+    // If the priority strategy is used, load the patterns in priority order
+    case StrategyPriority:
+        priorities, err := LoadPriorities(priorityPath)
+        if err != nil {
+            return nil, err
+        }
+
+        scheduler.priorities = priorities
+    case StrategyLargestFirst:
+        scheduler.selection = disk.LargestFirst{}
+    case StrategyRoundRobin:
+        scheduler.selection = disk.NewRoundRobin()
+    // Otherwise size and smallest first order the wordlists by size ascending
+    default:
+        scheduler.selection = disk.SmallestFirst{}
+    }
+
+    return scheduler, nil
+}
+
+// Records the cracked hashes a client reported for a processed wordlist.
+//
+// @Parameters
+// - fileName:  The name of the processed wordlist
+// - cracked:  The number of hashes cracked from the wordlist
+// - size:  The size of the processed wordlist
+//
+func (scheduler *Scheduler) Record(fileName string, cracked int64, size int64) {
+    // If the scheduler was never created
+    if scheduler == nil {
+        return
+    }
+
+    scheduler.mutx.Lock()
+    defer scheduler.mutx.Unlock()
+
+    key := scheduler.key(fileName)
+    yield := scheduler.stats[key]
+    yield.Bytes += size
+    yield.Cracked += cracked
+    scheduler.stats[key] = yield
+}
+
+// Gets the recorded yield of the family the passed in wordlist belongs to.
+//
+// @Parameters
+// - fileName:  The name of the wordlist
+//
+// @Returns
+// - The recorded yield of the wordlist family
+// - true/false depending on whether any yield was recorded
+//
+func (scheduler *Scheduler) Yield(fileName string) (Yield, bool) {
+    // If the scheduler was never created
+    if scheduler == nil {
+        return Yield{}, false
+    }
+
+    scheduler.mutx.Lock()
+    defer scheduler.mutx.Unlock()
+
+    yield, exists := scheduler.stats[scheduler.key(fileName)]
+    return yield, exists
+}
+
+// Sorts the candidate wordlists into the order they are distributed, matching the
+// disk.Ordering signature.
+//
+// @Parameters
+// - candidates:  The candidate wordlists in the load dir
+//
+func (scheduler *Scheduler) Order(candidates []disk.Candidate) {
+    // If the scheduler was never created or the load dir order is kept
+    if scheduler == nil || scheduler.strategy == "" {
+        return
+    }
+
+    scheduler.mutx.Lock()
+    defer scheduler.mutx.Unlock()
+
+    // Sort ascending by size as the base order, ties broken by name
+    bySize := disk.CompareSize
+
+    switch scheduler.strategy {
+    case StrategyHitRate:
+        average := scheduler.averageRate()
+        rates := make(map[string]float64, len(candidates))
+
+        // Iterate through the candidates scoring each by the rate of its family, families
+        // not processed yet score the fleet average so they are still explored early
+        for _, candidate := range candidates {
+            yield, exists := scheduler.stats[scheduler.key(candidate.Name)]
+            if exists {
+                rates[candidate.Path] = yield.Rate()
+            } else {
+                rates[candidate.Path] = average
+            }
+        }
+
+        slices.SortStableFunc(candidates, func(a disk.Candidate, b disk.Candidate) int {
+            // If the rates differ, the higher yield goes first
+            if rates[a.Path] != rates[b.Path] {
+                if rates[a.Path] > rates[b.Path] {
+                    return -1
+                }
+
+                return 1
+            }
+
+            return bySize(a, b)
+        })
+    case StrategyPriority:
+        slices.SortStableFunc(candidates, func(a disk.Candidate, b disk.Candidate) int {
+            aRank := scheduler.rank(a.Name)
+            bRank := scheduler.rank(b.Name)
+            // If the ranks differ, the earlier pattern goes first
+            if aRank != bRank {
+                return aRank - bRank
+            }
+
+            return bySize(a, b)
+        })
+    default:
+        scheduler.selection.Order(candidates)
+    }
+}
+
+// Formats the stats key of a wordlist from its family and the rulesets of its passes.
+//
+// @Parameters
+// - fileName:  The name of the wordlist
+//
+// @Returns
+// - The stats key of the wordlist
+//
+func (scheduler *Scheduler) key(fileName string) string {
+    key := Family(fileName)
+    // If rulesets are in use, track the combination
+    for _, ruleset := range Passes(fileName, scheduler.pairings, scheduler.rulesets) {
+        key += "+" + ruleset
+    }
+
+    return key
+}
+
+// Computes the rate of cracked hashes per MB across every recorded family.
+//
+// @Returns
+// - The average rate, 0 if nothing has been recorded
+//
+func (scheduler *Scheduler) averageRate() float64 {
+    var total Yield
+    // Iterate through the recorded yields summing them
+    for _, yield := range scheduler.stats {
+        total.Bytes += yield.Bytes
+        total.Cracked += yield.Cracked
+    }
+
+    return total.Rate()
+}
+
+// Gets the rank of the wordlist in the priority file, the index of the first pattern
+// it matches or the number of patterns if none match.
+//
+// @Parameters
+// - fileName:  The name of the wordlist
+//
+// @Returns
+// - The rank of the wordlist, lower goes first
+//
+func (scheduler *Scheduler) rank(fileName string) int {
+    // Iterate through the patterns in priority order
+    for index, pattern := range scheduler.priorities {
+        // If the name matches the pattern or its family does
+        if matched, _ := filepath.Match(pattern, fileName); matched ||
+           pattern == Family(fileName) {
+            return index
+        }
+    }
+
+    return len(scheduler.priorities)
+}
+
+
+// Gets the family of a wordlist, its name without the extension or numbered suffix
+// added when wordlists are split or merged, so related wordlists share their stats.
+//
+// @Parameters
+// - fileName:  The name of the wordlist
+//
+// @Returns
+// - The family of the wordlist
+//
+func Family(fileName string) string {
+    family := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+    stripped := ReNumberSuffix.ReplaceAllString(family, "")
+
+    // If the name is only a number, keep it as is
+    if stripped == "" {
+        return family
+    }
+
+    return stripped
+}
+
+
+// Loads the patterns of a priority file, one file name or glob pattern per line with
+// the highest priority first, blank lines and lines starting with # are skipped.
+//
+// @Parameters
+// - priorityPath:  The path of the priority file
+//
+// @Returns
+// - The patterns in priority order
+// - Error if it occurs, otherwise nil on success
+//
+func LoadPriorities(priorityPath string) ([]string, error) {
+    file, err := os.Open(priorityPath)
+    if err != nil {
+        return nil, fmt.Errorf("error opening priority file - %w", err)
+    }
+    // Close file on local exit
+    defer file.Close()
+
+    var priorities []string
+    scanner := bufio.NewScanner(file)
+
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        // If the line is blank or a comment
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+
+        // Ensure the pattern is valid before it is used for matching
+        _, err = filepath.Match(line, "")
+        if err != nil {
+            return nil, fmt.Errorf("improper pattern %q in priority file - %w", line, err)
+        }
+
+        priorities = append(priorities, line)
+    }
+
+    err = scanner.Err()
+    if err != nil {
+        return nil, fmt.Errorf("error reading priority file - %w", err)
+    }
+
+    return priorities, nil
+}
+
+
+// Formats the stats message a client sends after processing a wordlist.
+//
+// @Parameters
+// - fileName:  The name of the processed wordlist
+// - cracked:  The number of hashes cracked from the wordlist
+// - size:  The size of the processed wordlist
+//
+// @Returns
+// - The formatted stats message
+//
+func FormatStats(fileName string, cracked int64, size int64) []byte {
+    message := append([]byte{}, globals.WORDLIST_STATS_PREFIX...)
+    message = strconv.AppendInt(message, cracked, 10)
+    message = append(message, globals.COLON_DELIMITER...)
+    message = strconv.AppendInt(message, size, 10)
+    message = append(message, globals.COLON_DELIMITER...)
+    message = append(message, fileName...)
+    return append(message, globals.TRANSFER_SUFFIX...)
+}
+
+
+// Parses the stats message a client sends after processing a wordlist.
+//
+// @Parameters
+// - message:  The message containing the stats
+//
+// @Returns
+// - The name of the processed wordlist
+// - The number of hashes cracked from the wordlist
+// - The size of the processed wordlist
+// - Error if it occurs, otherwise nil on success
+//
+func ParseStats(message []byte) (string, int64, int64, error) {
+    // If the message does not start with prefix or end with closed bracket
+    if !bytes.HasPrefix(message, globals.WORDLIST_STATS_PREFIX) ||
+       !bytes.HasSuffix(message, globals.TRANSFER_SUFFIX) {
+        return "", 0, 0, fmt.Errorf("improper prefix or suffix in stats message")
+    }
+
+    // Strip the prefix and suffix then split on the delimiter, the name may contain it
+    body := bytes.TrimSuffix(bytes.TrimPrefix(message, globals.WORDLIST_STATS_PREFIX),
+                             globals.TRANSFER_SUFFIX)
+    parts := bytes.SplitN(body, globals.COLON_DELIMITER, 3)
+    if len(parts) != 3 || len(parts[2]) == 0 {
+        return "", 0, 0, fmt.Errorf("improper number of fields in stats message")
+    }
+
+    // Parse the cracked count
+    cracked, err := strconv.ParseInt(string(parts[0]), 10, 64)
+    if err != nil || cracked < 0 {
+        return "", 0, 0, fmt.Errorf("improper cracked count in stats message")
+    }
+
+    // Parse the wordlist size
+    size, err := strconv.ParseInt(string(parts[1]), 10, 64)
+    if err != nil || size < 0 {
+        return "", 0, 0, fmt.Errorf("improper wordlist size in stats message")
+    }
+
+    return filepath.Base(string(parts[2])), cracked, size, nil
+}
@@ -0,0 +1,164 @@
+package schedule_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
+	"github.com/ngimb64/Kloud-Kraken/pkg/schedule"
+	"github.com/stretchr/testify/assert"
+)
+
+
+// Gets the names of the candidates in their current order
+func candidateNames(candidates []disk.Candidate) []string {
+    var names []string
+    for _, candidate := range candidates {
+        names = append(names, candidate.Name)
+    }
+
+    return names
+}
+
+
+func testCandidates() []disk.Candidate {
+    return []disk.Candidate{
+        {Name: "rockyou_2.txt", Path: "/load/rockyou_2.txt", Size: 3 * globals.MB},
+        {Name: "leaks_1.txt", Path: "/load/leaks_1.txt", Size: 1 * globals.MB},
+        {Name: "names.txt", Path: "/load/names.txt", Size: 2 * globals.MB},
+        {Name: "leaks_2.txt", Path: "/load/leaks_2.txt", Size: 4 * globals.MB},
+    }
+}
+
+
+func TestFamily(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    assert.Equal("rockyou", schedule.Family("rockyou_001.txt"))
+    assert.Equal("rockyou", schedule.Family("rockyou-2.txt"))
+    assert.Equal("rockyou", schedule.Family("rockyou.txt"))
+    assert.Equal("2019", schedule.Family("2019.txt"))
+}
+
+
+func TestOrderSize(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    scheduler, err := schedule.New(schedule.StrategySize, "", nil, nil)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    candidates := testCandidates()
+    scheduler.Order(candidates)
+    assert.Equal([]string{"leaks_1.txt", "names.txt", "rockyou_2.txt", "leaks_2.txt"},
+                 candidateNames(candidates))
+
+    // Ensure largest first reverses the size order
+    scheduler, err = schedule.New(schedule.StrategyLargestFirst, "", nil, nil)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    candidates = testCandidates()
+    scheduler.Order(candidates)
+    assert.Equal([]string{"leaks_2.txt", "rockyou_2.txt", "names.txt", "leaks_1.txt"},
+                 candidateNames(candidates))
+
+    // Ensure no strategy keeps the load dir order
+    scheduler, err = schedule.New("", "", nil, nil)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    candidates = testCandidates()
+    scheduler.Order(candidates)
+    assert.Equal(candidateNames(testCandidates()), candidateNames(candidates))
+}
+
+
+func TestOrderHitRate(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    scheduler, err := schedule.New(schedule.StrategyHitRate, "", []string{"best64.rule"},
+                                   nil)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // With no stats the order falls back to size ascending
+    candidates := testCandidates()
+    scheduler.Order(candidates)
+    assert.Equal([]string{"leaks_1.txt", "names.txt", "rockyou_2.txt", "leaks_2.txt"},
+                 candidateNames(candidates))
+
+    // Rockyou yields well, leaks yields nothing
+    scheduler.Record("rockyou_1.txt", 100, 1 * globals.MB)
+    scheduler.Record("leaks_0.txt", 0, 2 * globals.MB)
+
+    yield, exists := scheduler.Yield("rockyou_2.txt")
+    assert.True(exists)
+    assert.Equal(int64(100), yield.Cracked)
+
+    // The high yield family goes first, the unseen family scores the fleet average
+    candidates = testCandidates()
+    scheduler.Order(candidates)
+    assert.Equal([]string{"rockyou_2.txt", "names.txt", "leaks_1.txt", "leaks_2.txt"},
+                 candidateNames(candidates))
+}
+
+
+func TestOrderPriority(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Ensure a missing priority file is an error
+    _, err := schedule.New(schedule.StrategyPriority, "nonexistent.txt", nil, nil)
+    assert.NotNil(err)
+
+    priorityPath := filepath.Join(t.TempDir(), "priority.txt")
+    err = os.WriteFile(priorityPath, []byte("# highest first\nnames.txt\n\nleaks\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    scheduler, err := schedule.New(schedule.StrategyPriority, priorityPath, nil, nil)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Listed wordlists go first in file order, the rest by size
+    candidates := testCandidates()
+    scheduler.Order(candidates)
+    assert.Equal([]string{"names.txt", "leaks_1.txt", "leaks_2.txt", "rockyou_2.txt"},
+                 candidateNames(candidates))
+
+    // Ensure an improper pattern is rejected
+    err = os.WriteFile(priorityPath, []byte("[bad\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    _, err = schedule.New(schedule.StrategyPriority, priorityPath, nil, nil)
+    assert.NotNil(err)
+}
+
+
+func TestFormatParseStats(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    message := schedule.FormatStats("rock:you.txt", 42, 1024)
+    fileName, cracked, size, err := schedule.ParseStats(message)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("rock:you.txt", fileName)
+    assert.Equal(int64(42), cracked)
+    assert.Equal(int64(1024), size)
+
+    falacies := []string{"<WORDLIST_STATS:1:2>", "<WORDLIST_STATS:a:2:x.txt>",
+                         "<WORDLIST_STATS:1:-2:x.txt>", "<KEYSPACE_COMPLETE:1:2>",
+                         "<WORDLIST_STATS:1:2:x.txt"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        _, _, _, err = schedule.ParseStats([]byte(falacy))
+        assert.NotNil(err)
+    }
+}
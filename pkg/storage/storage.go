@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
+)
+
+// Store persists the results of a run, such as cracked hashes, client logs, and reports
+type Store interface {
+    // Persists the local file under the slash separated name relative to the results
+    Put(filePath string, name string) error
+    // Gets where the results are persisted for display
+    Location() string
+}
+
+
+// Uploader is the S3 operation the S3 store requires, implemented by awsutils.S3Manager
+type Uploader interface {
+    UploadS3Object(bucketName string, key string, body io.Reader,
+                   callTime time.Duration) error
+}
+
+
+// Formats the per-run key prefix results are stored under in a bucket.
+//
+// @Parameters
+// - start:  The time the run started
+//
+// @Returns
+// - The run prefix
+//
+func RunPrefix(start time.Time) string {
+    return "runs/" + start.UTC().Format("2006-01-02T15-04-05Z")
+}
+
+
+// Gets the name a result file is persisted under, its path relative to the root dir
+// when inside it so the per-client dirs are kept, otherwise its base name.
+//
+// @Parameters
+// - rootDir:  The dir the result files are received and written in
+// - filePath:  The path of the result file
+//
+// @Returns
+// - The slash separated name of the result
+//
+func Name(rootDir string, filePath string) string {
+    relPath, err := filepath.Rel(rootDir, filePath)
+    // If the file is outside the root dir
+    if err != nil || relPath == ".." ||
+       strings.HasPrefix(relPath, ".." + string(filepath.Separator)) {
+        return filepath.Base(filePath)
+    }
+
+    return filepath.ToSlash(relPath)
+}
+
+
+// LocalStore persists results to a directory on the local filesystem
+type LocalStore struct {
+    dir string
+}
+
+// Creates a local store, making the directory if it does not exist.
+//
+// @Parameters
+// - dir:  The directory where results are persisted
+//
+// @Returns
+// - The initialized local store
+// - Error if it occurs, otherwise nil on success
+//
+func NewLocalStore(dir string) (*LocalStore, error) {
+    // Make the results directory if it does not exist
+    err := os.MkdirAll(dir, 0755)
+    if err != nil {
+        return nil, fmt.Errorf("error making results dir - %w", err)
+    }
+
+    return &LocalStore{dir: dir}, nil
+}
+
+// Copies the local file into the results directory under the passed in name, files
+// received directly into the results directory are already persisted.
+//
+// @Parameters
+// - filePath:  The path of the file to persist
+// - name:  The slash separated name the file is stored under
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (store *LocalStore) Put(filePath string, name string) error {
+    destPath := filepath.Join(store.dir, filepath.FromSlash(name))
+
+    // If the file is already in the results directory
+    if filepath.Clean(filePath) == destPath {
+        return nil
+    }
+
+    // Make the dir of the file, such as the dir of the client it was received from
+    err := os.MkdirAll(filepath.Dir(destPath), 0755)
+    if err != nil {
+        return fmt.Errorf("error making results dir - %w", err)
+    }
+
+    return disk.CopyFile(filePath, destPath)
+}
+
+// Gets the results directory.
+//
+// @Returns
+// - The results directory path
+//
+func (store *LocalStore) Location() string {
+    return store.dir
+}
+
+
+// S3Store persists results to a bucket under a per-run prefix
+type S3Store struct {
+    bucket   string
+    prefix   string
+    uploader Uploader
+}
+
+// Creates an S3 store that uploads results under the prefix of the bucket.
+//
+// @Parameters
+// - uploader:  Uploads the results to S3
+// - bucket:  The name of the results bucket
+// - prefix:  The key prefix results are stored under
+//
+// @Returns
+// - The initialized S3 store
+//
+func NewS3Store(uploader Uploader, bucket string, prefix string) *S3Store {
+    return &S3Store{bucket: bucket, prefix: prefix, uploader: uploader}
+}
+
+// Uploads the local file to the bucket under the run prefix and passed in name.
+//
+// @Parameters
+// - filePath:  The path of the file to persist
+// - name:  The slash separated name the file is stored under
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (store *S3Store) Put(filePath string, name string) error {
+    // Open the file to stream it to S3
+    file, err := os.Open(filePath)
+    if err != nil {
+        return err
+    }
+    // Close file on local exit
+    defer file.Close()
+
+    key := path.Join(store.prefix, name)
+    // Upload the file to the results bucket
+    err = store.uploader.UploadS3Object(store.bucket, key, file, 5 * time.Minute)
+    if err != nil {
+        return fmt.Errorf("error uploading %s to s3://%s - %w", key, store.bucket, err)
+    }
+
+    return nil
+}
+
+// Gets the S3 URI of the run prefix.
+//
+// @Returns
+// - The S3 URI where results are stored
+//
+func (store *S3Store) Location() string {
+    return "s3://" + store.bucket + "/" + store.prefix
+}
@@ -0,0 +1,139 @@
+package storage_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// Records the uploaded objects in memory in place of S3
+type fakeUploader struct {
+    err     error
+    objects map[string]string
+}
+
+func (uploader *fakeUploader) UploadS3Object(bucketName string, key string, body io.Reader,
+                                             callTime time.Duration) error {
+    // If the upload should fail
+    if uploader.err != nil {
+        return uploader.err
+    }
+
+    data, err := io.ReadAll(body)
+    if err != nil {
+        return err
+    }
+
+    uploader.objects[bucketName + "/" + key] = string(data)
+    return nil
+}
+
+
+func TestRunPrefix(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    start := time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC)
+    // Ensure the prefix is unique to the run start time
+    assert.Equal("runs/2025-03-14T09-26-53Z", storage.RunPrefix(start))
+}
+
+
+func TestName(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Ensure files in the root dir keep their path relative to it
+    assert.Equal("loot.txt", storage.Name("/tmp/received", "/tmp/received/loot.txt"))
+    assert.Equal("clients/a1b2c3d4/10.0.0.5/loot.txt",
+                 storage.Name("/tmp/received", "/tmp/received/clients/a1b2c3d4/10.0.0.5/loot.txt"))
+    // Ensure files outside the root dir are stored under their base name
+    assert.Equal("potfile.txt", storage.Name("/tmp/received", "/tmp/results/potfile.txt"))
+    assert.Equal("received2.txt", storage.Name("/tmp/received", "/tmp/received2.txt"))
+}
+
+
+func TestLocalStore(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    stagingDir := t.TempDir()
+    resultsDir := filepath.Join(t.TempDir(), "results")
+
+    // Create the local store and its directory
+    store, err := storage.NewLocalStore(resultsDir)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(resultsDir, store.Location())
+
+    lootPath := filepath.Join(stagingDir, "loot.txt")
+    // Write the staged loot file
+    err = os.WriteFile(lootPath, []byte("hash:password\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Persist the staged file into the results dir
+    err = store.Put(lootPath, "loot.txt")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    stored, err := os.ReadFile(filepath.Join(resultsDir, "loot.txt"))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("hash:password\n", string(stored))
+
+    // Ensure a file already in the results dir is left as is
+    err = store.Put(filepath.Join(resultsDir, "loot.txt"), "loot.txt")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Persist the staged file under the dir of the client it was received from
+    err = store.Put(lootPath, "clients/a1b2c3d4/10.0.0.5/loot.txt")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    stored, err = os.ReadFile(filepath.Join(resultsDir, "clients", "a1b2c3d4", "10.0.0.5",
+                                            "loot.txt"))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("hash:password\n", string(stored))
+}
+
+
+func TestS3Store(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    uploader := &fakeUploader{objects: make(map[string]string)}
+    store := storage.NewS3Store(uploader, "results-bucket", "runs/2025-03-14T09-26-53Z")
+    // Ensure the location is the S3 URI of the run
+    assert.Equal("s3://results-bucket/runs/2025-03-14T09-26-53Z", store.Location())
+
+    logPath := filepath.Join(t.TempDir(), "client.log")
+    // Write the staged log file
+    err := os.WriteFile(logPath, []byte("log data"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Upload the staged file under the run prefix
+    err = store.Put(logPath, "clients/a1b2c3d4/10.0.0.5/client.log")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("log data", uploader.objects["results-bucket/runs/2025-03-14T09-26-53Z/" +
+                                              "clients/a1b2c3d4/10.0.0.5/client.log"])
+
+    // Ensure upload failures are returned
+    uploader.err = errors.New("access denied")
+    err = store.Put(logPath, "client.log")
+    assert.NotEqual(nil, err)
+
+    // Ensure missing files are returned as errors
+    err = store.Put(filepath.Join(t.TempDir(), "missing.log"), "missing.log")
+    assert.NotEqual(nil, err)
+}
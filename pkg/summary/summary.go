@@ -0,0 +1,339 @@
+package summary
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/data"
+	"github.com/ngimb64/Kloud-Kraken/pkg/instance"
+	"github.com/ngimb64/Kloud-Kraken/pkg/manifest"
+	"github.com/ngimb64/Kloud-Kraken/pkg/report"
+)
+
+// Supported summary export formats
+const (
+    FormatJson     = "json"
+    FormatMarkdown = "markdown"
+)
+
+
+// ClientSummary is the contribution of a single client to the run
+type ClientSummary struct {
+    Bytes     int64  `json:"bytes"`
+    Client    string `json:"client"`
+    Cracked   int    `json:"cracked"`
+    Transfers int    `json:"transfers"`
+}
+
+
+// InstanceSummary is the instance a client reported running on and its estimated spend
+type InstanceSummary struct {
+    instance.Metadata
+    Client string  `json:"client"`
+    Cost   float64 `json:"cost"`
+}
+
+
+// Summary is the final summary of a run displayed and exported when it completes
+type Summary struct {
+    Clients          []ClientSummary   `json:"clients"`
+    CrackRate        float64           `json:"crack_rate"`
+    Cracked          int               `json:"cracked"`
+    EstimatedCost    float64           `json:"estimated_cost"`
+    InstanceCost     float64           `json:"instance_cost,omitempty"`
+    Instances        []InstanceSummary `json:"instances,omitempty"`
+    RunId            string            `json:"run_id"`
+    RunName          string            `json:"run_name,omitempty"`
+    Runtime          time.Duration     `json:"-"`
+    RuntimeSeconds   int64             `json:"runtime_seconds"`
+    TotalHashes      int               `json:"total_hashes"`
+    TransferredBytes int64             `json:"transferred_bytes"`
+    Wordlists        []manifest.Entry  `json:"wordlists"`
+}
+
+
+// Builds the run summary from the cracked hashes report and the per client transfer
+// stats, the per client cracked counts come from the report entries.
+//
+// @Parameters
+// - runId:  The unique ID of the run
+// - runtime:  The duration of the run
+// - crackReport:  The cracked hashes report, nil if it could not be built
+// - cracked:  The cracked hashes counted during the run, used when there is no report
+// - transfers:  The transfer stats of each client
+// - estimatedCost:  The estimated spend of the fleet in USD
+//
+// @Returns
+// - The run summary
+//
+func New(runId string, runtime time.Duration, crackReport *report.Report, cracked int64,
+         transfers map[string]data.TransferStats, estimatedCost float64) Summary {
+    summary := Summary{
+        Cracked:        int(cracked),
+        EstimatedCost:  estimatedCost,
+        RunId:          runId,
+        Runtime:        runtime.Round(time.Second),
+        RuntimeSeconds: int64(runtime.Seconds()),
+    }
+
+    clients := map[string]*ClientSummary{}
+    // Gets the summary of the client, adding it if not seen yet
+    clientSummary := func(client string) *ClientSummary {
+        if _, ok := clients[client]; !ok {
+            clients[client] = &ClientSummary{Client: client}
+        }
+
+        return clients[client]
+    }
+
+    // If the report was built, use its totals and count the cracks of each client
+    if crackReport != nil {
+        summary.CrackRate = crackReport.Summary.CrackRate
+        summary.Cracked = crackReport.Summary.Cracked
+        summary.TotalHashes = crackReport.Summary.TotalHashes
+
+        for _, entry := range crackReport.Entries {
+            clientSummary(entry.Client).Cracked += 1
+        }
+    }
+
+    // Iterate through the transfer stats of each client
+    for client, stats := range transfers {
+        clientStats := clientSummary(client)
+        clientStats.Bytes = stats.Bytes
+        clientStats.Transfers = stats.Transfers
+        summary.TransferredBytes += stats.Bytes
+    }
+
+    // Order the clients by most cracked, then by name for a stable display
+    for _, clientStats := range clients {
+        summary.Clients = append(summary.Clients, *clientStats)
+    }
+    slices.SortFunc(summary.Clients, func(a, b ClientSummary) int {
+        if a.Cracked != b.Cracked {
+            return b.Cracked - a.Cracked
+        }
+
+        return strings.Compare(a.Client, b.Client)
+    })
+
+    return summary
+}
+
+
+// Adds the instances the clients reported running on, estimating the spend of each from
+// its instance type over the time it was connected. Unlike the fleet estimate, this
+// accounts for mixed instance types and clients that joined or left mid-run.
+//
+// @Parameters
+// - records:  The instance metadata reported by the clients
+// - override:  The configured hourly price, 0 uses the built in estimate
+// - now:  The time the clients still connected are billed until
+//
+func (summary *Summary) AddInstances(records []instance.Record, override float64,
+                                     now time.Time) {
+    // Iterate through the reported instances adding each with its spend
+    for _, record := range records {
+        // If the client is not on EC2, there is no instance to account
+        if record.Metadata == (instance.Metadata{}) {
+            continue
+        }
+
+        instanceCost := record.Cost(override, now)
+        summary.InstanceCost += instanceCost
+        summary.Instances = append(summary.Instances, InstanceSummary{
+            Metadata: record.Metadata,
+            Client:   record.Addr,
+            Cost:     instanceCost,
+        })
+    }
+}
+
+
+// Formats the summary as lines for display.
+//
+// @Returns
+// - The formatted summary lines
+//
+func (summary Summary) Lines() []string {
+    lines := []string{
+        fmt.Sprintf("Run ID:  %s", summary.RunId),
+        fmt.Sprintf("Runtime:  %s", summary.Runtime),
+        fmt.Sprintf("Hashes cracked:  %d of %d (%.2f%%)", summary.Cracked,
+                    summary.TotalHashes, summary.CrackRate),
+        fmt.Sprintf("Data transferred:  %.2f MB", float64(summary.TransferredBytes) /
+                                                 float64(globals.MB)),
+        fmt.Sprintf("Estimated cost:  $%.2f", summary.EstimatedCost),
+    }
+
+    // If the clients reported their instances, include the spend accounted from them
+    if len(summary.Instances) > 0 {
+        lines = append(lines, fmt.Sprintf("Reported instance cost:  $%.2f",
+                                          summary.InstanceCost))
+    }
+
+    // If the run is named, list the name under the ID
+    if summary.RunName != "" {
+        lines = slices.Insert(lines, 1, fmt.Sprintf("Run name:  %s", summary.RunName))
+    }
+
+    // If any clients contributed, list each
+    if len(summary.Clients) > 0 {
+        lines = append(lines, "", "Client | Cracked | Transfers | Data")
+    }
+    for _, client := range summary.Clients {
+        lines = append(lines, fmt.Sprintf("%s | %d | %d | %.2f MB", client.Client,
+                                          client.Cracked, client.Transfers,
+                                          float64(client.Bytes) / float64(globals.MB)))
+    }
+
+    // If the clients reported their instances, list each with its spend
+    if len(summary.Instances) > 0 {
+        lines = append(lines, "", "Client | Instance | Cost")
+    }
+    for _, instanceSummary := range summary.Instances {
+        lines = append(lines, fmt.Sprintf("%s | %s | $%.2f", instanceSummary.Client,
+                                          instanceSummary.Summary(), instanceSummary.Cost))
+    }
+
+    return lines
+}
+
+
+// Writes the summary as indented JSON.
+//
+// @Parameters
+// - summaryPath:  The path where the JSON summary is written
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (summary Summary) WriteJson(summaryPath string) error {
+    // Encode the summary into indented JSON
+    summaryJson, err := json.MarshalIndent(summary, "", "  ")
+    if err != nil {
+        return err
+    }
+
+    return os.WriteFile(summaryPath, append(summaryJson, '\n'), 0644)
+}
+
+
+// Writes the summary as a markdown document with a table of the client contributions.
+//
+// @Parameters
+// - summaryPath:  The path where the markdown summary is written
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (summary Summary) WriteMarkdown(summaryPath string) error {
+    var builder strings.Builder
+
+    builder.WriteString(fmt.Sprintf("# Kloud Kraken run %s\n\n", summary.RunId))
+    // If the run is named, include the name so the engagement is known
+    if summary.RunName != "" {
+        builder.WriteString(fmt.Sprintf("- Run name:  %s\n", summary.RunName))
+    }
+    builder.WriteString(fmt.Sprintf("- Runtime:  %s\n", summary.Runtime))
+    builder.WriteString(fmt.Sprintf("- Hashes cracked:  %d of %d (%.2f%%)\n",
+                                    summary.Cracked, summary.TotalHashes, summary.CrackRate))
+    builder.WriteString(fmt.Sprintf("- Data transferred:  %.2f MB\n",
+                                    float64(summary.TransferredBytes) / float64(globals.MB)))
+    builder.WriteString(fmt.Sprintf("- Estimated cost:  $%.2f\n", summary.EstimatedCost))
+    // If the clients reported their instances, include the spend accounted from them
+    if len(summary.Instances) > 0 {
+        builder.WriteString(fmt.Sprintf("- Reported instance cost:  $%.2f\n",
+                                        summary.InstanceCost))
+    }
+
+    builder.WriteString("\n## Clients\n\n")
+    builder.WriteString("| Client | Cracked | Transfers | Data |\n")
+    builder.WriteString("| --- | --- | --- | --- |\n")
+    // Iterate through the clients adding a table row for each
+    for _, client := range summary.Clients {
+        builder.WriteString(fmt.Sprintf("| %s | %d | %d | %.2f MB |\n", client.Client,
+                                        client.Cracked, client.Transfers,
+                                        float64(client.Bytes) / float64(globals.MB)))
+    }
+
+    // If the clients reported their instances, list each with its spend
+    if len(summary.Instances) > 0 {
+        builder.WriteString("\n## Instances\n\n")
+        builder.WriteString("| Client | Instance ID | Type | Lifecycle | Zone | AMI | Cost |\n")
+        builder.WriteString("| --- | --- | --- | --- | --- | --- | --- |\n")
+    }
+    for _, instanceSummary := range summary.Instances {
+        builder.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s | $%.2f |\n",
+                                        instanceSummary.Client, instanceSummary.InstanceId,
+                                        instanceSummary.InstanceType, instanceSummary.Lifecycle,
+                                        instanceSummary.AvailabilityZone,
+                                        instanceSummary.AmiId, instanceSummary.Cost))
+    }
+
+    // If the wordlists were manifested, list each so the run can be reproduced
+    if len(summary.Wordlists) > 0 {
+        builder.WriteString("\n## Wordlists\n\n")
+        builder.WriteString("| Wordlist | Size | Lines | SHA-256 |\n")
+        builder.WriteString("| --- | --- | --- | --- |\n")
+    }
+    for _, entry := range summary.Wordlists {
+        builder.WriteString(fmt.Sprintf("| %s | %d | %d | %s |\n", entry.Name, entry.Size,
+                                        entry.Lines, entry.Sha256))
+    }
+
+    return os.WriteFile(summaryPath, []byte(builder.String()), 0644)
+}
+
+
+// Exports the summary in each of the passed in formats to the dir, the file names are
+// prefixed with the name of the run when it is named.
+//
+// @Parameters
+// - dirPath:  The dir where the summary files are written
+// - formats:  The formats to export the summary as (json, markdown)
+//
+// @Returns
+// - The paths of the written summary files
+// - Error if it occurs, otherwise nil on success
+//
+func (summary Summary) Export(dirPath string, formats []string) ([]string, error) {
+    var summaryPaths []string
+    baseName := "run_summary"
+
+    // If the run is named, prefix the files with the name
+    if summary.RunName != "" {
+        baseName = summary.RunName + "_" + baseName
+    }
+
+    // Iterate through the formats writing the summary in each
+    for _, format := range formats {
+        var err error
+        var summaryPath string
+
+        switch format {
+        case FormatJson:
+            summaryPath = filepath.Join(dirPath, baseName + ".json")
+            err = summary.WriteJson(summaryPath)
+        case FormatMarkdown:
+            summaryPath = filepath.Join(dirPath, baseName + ".md")
+            err = summary.WriteMarkdown(summaryPath)
+        default:
+            err = fmt.Errorf("unsupported summary format - %q", format)
+        }
+
+        if err != nil {
+            return summaryPaths, err
+        }
+
+        summaryPaths = append(summaryPaths, summaryPath)
+    }
+
+    return summaryPaths, nil
+}
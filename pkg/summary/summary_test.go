@@ -0,0 +1,139 @@
+package summary_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/data"
+	"github.com/ngimb64/Kloud-Kraken/pkg/instance"
+	"github.com/ngimb64/Kloud-Kraken/pkg/manifest"
+	"github.com/ngimb64/Kloud-Kraken/pkg/report"
+	"github.com/ngimb64/Kloud-Kraken/pkg/summary"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestNew(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    crackReport := &report.Report{
+        Entries: []report.Entry{{Client: "10.0.0.2"}, {Client: "10.0.0.2"},
+                                {Client: "10.0.0.1"}},
+        Summary: report.Summary{Cracked: 3, CrackRate: 30.0, TotalHashes: 10},
+    }
+    transfers := map[string]data.TransferStats{
+        "10.0.0.1": {Bytes: 1000, Transfers: 2},
+        "10.0.0.2": {Bytes: 500, Transfers: 1},
+        "10.0.0.3": {Bytes: 250, Transfers: 1},
+    }
+
+    runSummary := summary.New("abc123", 90 * time.Minute, crackReport, 0, transfers, 12.5)
+    assert.Equal(3, runSummary.Cracked)
+    assert.Equal(10, runSummary.TotalHashes)
+    assert.Equal(int64(1750), runSummary.TransferredBytes)
+    assert.Equal(int64(5400), runSummary.RuntimeSeconds)
+    // Ensure the clients are ordered by most cracked
+    assert.Equal([]summary.ClientSummary{
+        {Bytes: 500, Client: "10.0.0.2", Cracked: 2, Transfers: 1},
+        {Bytes: 1000, Client: "10.0.0.1", Cracked: 1, Transfers: 2},
+        {Bytes: 250, Client: "10.0.0.3", Cracked: 0, Transfers: 1},
+    }, runSummary.Clients)
+    assert.Contains(runSummary.Lines(), "Estimated cost:  $12.50")
+
+    // Ensure the counted cracks are used when the report could not be built
+    runSummary = summary.New("abc123", time.Minute, nil, 7, nil, 0)
+    assert.Equal(7, runSummary.Cracked)
+    assert.Equal(0, len(runSummary.Clients))
+}
+
+
+func TestAddInstances(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    start := time.Now()
+    runSummary := summary.New("abc123", 2 * time.Hour, nil, 0, nil, 10.0)
+    runSummary.AddInstances([]instance.Record{
+        {Addr: "10.0.0.1:5000", Connected: start, Disconnected: start.Add(time.Hour),
+         Metadata: instance.Metadata{InstanceId: "i-0123", InstanceType: "g4dn.xlarge",
+                                     Lifecycle: instance.LifecycleSpot}},
+        {Addr: "10.0.0.2:5000", Connected: start,
+         Metadata: instance.Metadata{InstanceType: "g4dn.xlarge"}},
+    }, 2.0, start.Add(2 * time.Hour))
+
+    // Ensure each instance is billed for the time its client was connected
+    assert.Equal(2, len(runSummary.Instances))
+    assert.InDelta(2.0, runSummary.Instances[0].Cost, 0.001)
+    assert.InDelta(6.0, runSummary.InstanceCost, 0.001)
+    assert.Contains(runSummary.Lines(), "Reported instance cost:  $6.00")
+    assert.Contains(runSummary.Lines(), "10.0.0.1:5000 | g4dn.xlarge spot i-0123 | $2.00")
+
+    summaryPath := filepath.Join(t.TempDir(), "run_summary.md")
+    err := runSummary.WriteMarkdown(summaryPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    markdown, err := os.ReadFile(summaryPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Contains(string(markdown),
+                    "| 10.0.0.1:5000 | i-0123 | g4dn.xlarge | spot |  |  | $2.00 |")
+}
+
+
+func TestExport(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    dirPath := t.TempDir()
+    runSummary := summary.New("abc123", time.Hour, nil, 4,
+                              map[string]data.TransferStats{"10.0.0.1": {Bytes: 100}}, 3.0)
+    runSummary.Wordlists = []manifest.Entry{{Lines: 2, Name: "rockyou.txt", Sha256: "ab12",
+                                             Size: 10}}
+
+    summaryPaths, err := runSummary.Export(dirPath, []string{summary.FormatJson,
+                                                             summary.FormatMarkdown})
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal([]string{filepath.Join(dirPath, "run_summary.json"),
+                          filepath.Join(dirPath, "run_summary.md")}, summaryPaths)
+
+    jsonData, err := os.ReadFile(summaryPaths[0])
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    var decoded map[string]any
+    err = json.Unmarshal(jsonData, &decoded)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("abc123", decoded["run_id"])
+    assert.Equal(float64(3600), decoded["runtime_seconds"])
+
+    markdown, err := os.ReadFile(summaryPaths[1])
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Contains(string(markdown), "| 10.0.0.1 | 0 | 0 | 0.00 MB |")
+    // Ensure the wordlist manifest is included for reproducing the run
+    assert.Contains(string(markdown), "| rockyou.txt | 10 | 2 | ab12 |")
+    assert.Equal("ab12", decoded["wordlists"].([]any)[0].(map[string]any)["sha256"])
+
+    // Ensure an unsupported format is an error
+    _, err = runSummary.Export(dirPath, []string{"xml"})
+    assert.NotNil(err)
+
+    // Ensure the summaries of a named run are prefixed with the name
+    runSummary.RunName = "acme-q3"
+    summaryPaths, err = runSummary.Export(dirPath, []string{summary.FormatMarkdown})
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal([]string{filepath.Join(dirPath, "acme-q3_run_summary.md")}, summaryPaths)
+
+    markdown, err = os.ReadFile(summaryPaths[0])
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Contains(string(markdown), "- Run name:  acme-q3")
+}
@@ -0,0 +1,169 @@
+package tlsutils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+)
+
+
+// Gets the current certificate, which changes once it is rotated.
+//
+// @Returns
+// - The PEM block of the current certificate
+// - The current TLS certificate
+//
+func (TlsMan *TlsManager) Current() ([]byte, tls.Certificate) {
+    TlsMan.certMutx.RLock()
+    defer TlsMan.certMutx.RUnlock()
+
+    return TlsMan.CertPemBlock, TlsMan.TlsCertificate
+}
+
+// Reissues the certificate and key with the organization and hosts they were first
+// generated with, then swaps them in so new handshakes use the new certificate. TLS 1.3
+// has no renegotiation, so established connections keep the session they negotiated.
+//
+// @Returns
+// - The PEM block of the new certificate
+// - Error if it occurs, otherwise nil on success
+//
+func (TlsMan *TlsManager) Rotate() ([]byte, error) {
+    // If the certificate was never generated, there are no settings to reissue it with
+    if TlsMan.hosts == "" {
+        return nil, errors.New("no certificate has been generated to rotate")
+    }
+
+    // Generate the new certificate and key, rewriting the PEM file in test mode
+    certPem, keyPem, err := TlsMan.pemCertAndKeyGen(TlsMan.orgName, TlsMan.hosts,
+                                                    TlsMan.testMode)
+    if err != nil {
+        return nil, err
+    }
+
+    // Generate the TLS certificate from the new PEM blocks
+    cert, err := tls.X509KeyPair(certPem, keyPem)
+    if err != nil {
+        return nil, err
+    }
+
+    TlsMan.certMutx.Lock()
+    defer TlsMan.certMutx.Unlock()
+
+    TlsMan.CertPemBlock = certPem
+    TlsMan.KeyPemBlock = keyPem
+    TlsMan.TlsCertificate = cert
+
+    return certPem, nil
+}
+
+
+// Computes the SHA-256 fingerprint of the certificate in the PEM block, used to tell
+// whether a client trusts the current server certificate.
+//
+// @Parameters
+// - pemBlock:  The PEM block of the certificate
+//
+// @Returns
+// - The hex encoded fingerprint
+// - Error if the PEM block holds no certificate, otherwise nil
+//
+func Fingerprint(pemBlock []byte) (string, error) {
+    block, _ := pem.Decode(pemBlock)
+    // If the PEM block could not be decoded or is not a certificate
+    if block == nil || block.Type != "CERTIFICATE" {
+        return "", errors.New("no certificate in PEM block")
+    }
+
+    sum := sha256.Sum256(block.Bytes)
+    return hex.EncodeToString(sum[:]), nil
+}
+
+
+// Verifies a rotated server certificate is currently valid for the host the server was
+// dialed at, checked before it is trusted for later handshakes.
+//
+// @Parameters
+// - pemBlock:  The PEM block of the rotated certificate
+// - host:  The IP address or hostname the server was dialed at
+//
+// @Returns
+// - Error if the certificate is invalid for the host, otherwise nil
+//
+func VerifyServerCert(pemBlock []byte, host string) error {
+    block, _ := pem.Decode(pemBlock)
+    // If the PEM block could not be decoded or is not a certificate
+    if block == nil || block.Type != "CERTIFICATE" {
+        return errors.New("no certificate in PEM block")
+    }
+
+    cert, err := x509.ParseCertificate(block.Bytes)
+    if err != nil {
+        return fmt.Errorf("error parsing certificate - %w", err)
+    }
+
+    // The certificate is self-signed so it is its own root
+    roots := x509.NewCertPool()
+    roots.AddCert(cert)
+
+    _, err = cert.Verify(x509.VerifyOptions{
+        DNSName:   host,
+        KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+        Roots:     roots,
+    })
+    if err != nil {
+        return fmt.Errorf("certificate not valid for %s - %w", host, err)
+    }
+
+    return nil
+}
+
+
+// Formats the certificate check message a client sends with the fingerprint of the
+// server certificate it trusts.
+//
+// @Parameters
+// - fingerprint:  The fingerprint of the trusted server certificate
+//
+// @Returns
+// - The formatted certificate check message
+//
+func FormatCertCheck(fingerprint string) []byte {
+    message := append([]byte{}, globals.CERT_CHECK_PREFIX...)
+    message = append(message, fingerprint...)
+    return append(message, globals.TRANSFER_SUFFIX...)
+}
+
+
+// Parses the fingerprint from the certificate check message.
+//
+// @Parameters
+// - message:  The certificate check message
+//
+// @Returns
+// - The fingerprint of the server certificate the client trusts
+// - Error if it occurs, otherwise nil on success
+//
+func ParseCertCheck(message []byte) (string, error) {
+    // If the message does not start with prefix or end with closed bracket
+    if !bytes.HasPrefix(message, globals.CERT_CHECK_PREFIX) ||
+    !bytes.HasSuffix(message, globals.TRANSFER_SUFFIX) {
+        return "", fmt.Errorf("improper prefix or suffix in certificate check message")
+    }
+
+    fingerprint := bytes.TrimSuffix(bytes.TrimPrefix(message, globals.CERT_CHECK_PREFIX),
+                                    globals.TRANSFER_SUFFIX)
+    // If the fingerprint is missing
+    if len(fingerprint) == 0 {
+        return "", fmt.Errorf("empty fingerprint in certificate check message")
+    }
+
+    return string(fingerprint), nil
+}
@@ -0,0 +1,81 @@
+package tlsutils_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/tlsutils"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestRotate(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    tlsMan := &tlsutils.TlsManager{Lifetime: time.Hour}
+    // Ensure rotating before a certificate was generated fails
+    _, err := tlsMan.Rotate()
+    assert.NotEqual(nil, err)
+
+    err = tlsMan.PemCertAndKeyGenHandler("Kloud Kraken", false, "127.0.0.1")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    err = tlsMan.CertGenAndPool(tlsMan.CertPemBlock, tlsMan.KeyPemBlock, nil)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    block, _ := pem.Decode(tlsMan.CertPemBlock)
+    cert, err := x509.ParseCertificate(block.Bytes)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the configured lifetime was applied
+    assert.Equal(time.Hour, cert.NotAfter.Sub(cert.NotBefore))
+
+    original, err := tlsutils.Fingerprint(tlsMan.CertPemBlock)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    rotated, err := tlsMan.Rotate()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    currentPem, currentCert := tlsMan.Current()
+    // Ensure the rotated certificate is the current one
+    assert.Equal(rotated, currentPem)
+    assert.Equal(1, len(currentCert.Certificate))
+
+    fingerprint, err := tlsutils.Fingerprint(rotated)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.NotEqual(original, fingerprint)
+
+    // Ensure the rotated certificate is valid for the hosts it was first generated with
+    assert.Equal(nil, tlsutils.VerifyServerCert(rotated, "127.0.0.1"))
+    assert.Equal(nil, tlsutils.VerifyServerCert(rotated, "localhost"))
+    assert.NotEqual(nil, tlsutils.VerifyServerCert(rotated, "203.0.113.10"))
+    assert.NotEqual(nil, tlsutils.VerifyServerCert([]byte("not a certificate"), "localhost"))
+}
+
+
+func TestFormatParseCertCheck(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    message := tlsutils.FormatCertCheck("ab12cd34")
+    assert.Equal([]byte("<CERT_CHECK:ab12cd34>"), message)
+
+    fingerprint, err := tlsutils.ParseCertCheck(message)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("ab12cd34", fingerprint)
+
+    // Ensure malformed and empty checks are rejected
+    _, err = tlsutils.ParseCertCheck([]byte("<VERSION_CHECK:ab12cd34>"))
+    assert.NotEqual(nil, err)
+    _, err = tlsutils.ParseCertCheck([]byte("<CERT_CHECK:>"))
+    assert.NotEqual(nil, err)
+}
@@ -11,112 +11,17 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
-	"io"
 	"math/big"
 	"net"
-	"net/http"
 	"os"
-	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
-// HTTP shared client (reuses connections) with global timeout
-var Client = &http.Client{Timeout: 5*time.Minute}
-// Pre-compile IPv4/IPv6 regex once
-var ReIpAddr = regexp.MustCompile(
-    `\b(?:\d{1,3}\.){3}\d{1,3}\b|` +  // IPv4
-    `\b(?:[0-9A-Fa-f]{1,4}:){2,7}[0-9A-Fa-f]{1,4}\b`,  // IPv6 (simple form)
-)
-
-
-// Attempts a GET request to retrieve IP data from passed in API URL.
-//
-// @Parameters
-// - ctx:  The context handler for the request
-// - cancel:  The cancel function for the context handler
-// - url:  The url of the API to attempt to retrieve IP data
-//
-// @Returns
-// - The retrieved IP data from the GET request
-// - Error if it occurs, otherwise nil on success
-//
-func getIpData(ctx context.Context, cancel context.CancelFunc, url string) (
-               []byte, error) {
-    // Cancel request context on local exit
-    defer cancel()
-    // Initialize HTTP GET request
-    req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-    if err != nil {
-        return []byte(""), err
-    }
-
-    // Send HTTP GET request
-    response, err := Client.Do(req)
-    if err != nil {
-        return []byte(""), err
-    }
-
-    // Read the response data of the request
-    data, err := io.ReadAll(response.Body)
-    response.Body.Close()
-    if err != nil {
-        return []byte(""), err
-    }
-
-    return data, nil
-}
-
-
-// GetPublicIP tries each endpoint in turn until one returns a valid IPv4/6 string.
-//
-// @Returns
-// - A slice of string IP address retrieved from APIs
-// - Error if it occurs, otherwise nil on success
-//
-func GetPublicIps() ([]string, error) {
-    var ipAddrs []string
-    uniqueAddrs := make(map[string]struct{})
-    // list of public‐IP endpoints to try, in order
-    endpoints := []string{"https://api.ipify.org", "https://ifconfig.me/ip",
-                          "https://checkip.amazonaws.com", "https://icanhazip.com"}
-
-    // Iterate through list of IP API enpoints
-    for _, url := range endpoints {
-        // Create a fresh 5s context for each request
-        ctx, cancel := context.WithTimeout(context.Background(), 5 * time.Second)
-        // Execute GET request to retrieve IP data
-        data, err := getIpData(ctx, cancel, url)
-        if err != nil {
-            continue
-        }
-
-        // Convert data to string and remove any outer whitespace
-        textData := strings.TrimSpace(string(data))
-        // Regex search for all IP address
-        matches := ReIpAddr.FindAllString(textData, -1)
-
-        // Iterate through matched IP addresses
-        for _, match := range matches {
-            // Check to see if IP has been matched already
-            _, exists := uniqueAddrs[match]
-            // If IP does not exist in map
-            if !exists {
-                // Add it to map and resulting slice
-                uniqueAddrs[match] = struct{}{}
-                ipAddrs = append(ipAddrs, match)
-            }
-        }
-    }
-
-    // If no public IPs were discovered
-    if len(ipAddrs) == 0 {
-        return nil, errors.New("could not retrieve public IP from any APIs")
-    }
-
-    return ipAddrs, nil
-}
+// Validity of generated certificates when no lifetime is configured
+const DefaultCertLifetime = 365 * 24 * time.Hour
 
 
 // Get the assigned valid public and private IP address assigned
@@ -193,9 +98,14 @@ type TlsManager struct {
     addr            string
     CaCertPemBlocks [][]byte
     CaCertPool      *x509.CertPool
+    certMutx        sync.RWMutex
     CertPemBlock    []byte
     ctx   	        context.Context
+    hosts           string
     KeyPemBlock     []byte
+    Lifetime        time.Duration  // How long generated certificates are valid, 0 is a year
+    orgName         string
+    testMode        bool
     TlsCertificate  tls.Certificate
     tlsConfig       *tls.Config
 }
@@ -325,6 +235,11 @@ func (TlsMan *TlsManager) PemCertAndKeyGenHandler(orgName string, testMode bool,
         hosts += ("," + ipAddr)
     }
 
+    // Save the certificate settings so it can be reissued on rotation
+    TlsMan.hosts = hosts
+    TlsMan.orgName = orgName
+    TlsMan.testMode = testMode
+
     // Generate the TLS certificate/key and save them in app config
     TlsMan.CertPemBlock,
     TlsMan.KeyPemBlock, err = TlsMan.pemCertAndKeyGen(orgName, hosts, testMode)
@@ -357,6 +272,12 @@ func (TlsMan *TlsManager) pemCertAndKeyGen(name string, hosts string, generateFi
         return nil, nil, err
     }
 
+    lifetime := TlsMan.Lifetime
+    // If no lifetime was configured, the certificate is valid for a year
+    if lifetime <= 0 {
+        lifetime = DefaultCertLifetime
+    }
+
     // Get the time for certifcate generation
     notBefore := time.Now().Add(-15 * time.Minute)
     // Set up the TLS certificate settings
@@ -366,7 +287,7 @@ func (TlsMan *TlsManager) pemCertAndKeyGen(name string, hosts string, generateFi
             Organization: []string{name},
         },
         NotBefore:   notBefore,
-        NotAfter:    notBefore.Add(1 * 365 * 24 * time.Hour),
+        NotAfter:    notBefore.Add(lifetime),
         KeyUsage:    x509.KeyUsageDigitalSignature,
         ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
         BasicConstraintsValid: true,
@@ -504,7 +425,7 @@ func (TlsMan *TlsManager) SetupTlsListenerHandler(cert tls.Certificate, certPool
     // Create a TLS configuration instance
     tlsConfig := &tls.Config{
         Certificates:       []tls.Certificate{cert},
-        GetConfigForClient: TlsMan.getServerTlsConfig(cert, certPool),
+        GetConfigForClient: TlsMan.getServerTlsConfig(certPool),
     }
 
     // Format listener address with port
@@ -522,18 +443,19 @@ func (TlsMan *TlsManager) SetupTlsListenerHandler(cert tls.Certificate, certPool
     return tlsListener, nil
 }
 
-// Function for handling the TLS config generation and client verification.
+// Function for handling the TLS config generation and client verification, each
+// handshake uses the current certificate so rotated certificates apply to new connections.
 //
 // @Parameters
-// - cert:  The TLS certificate to be used in config generation
 // - serverPool:  The servers TLS certificate pool
 //
 // @Returns
 // - function that returns the TLS config and errors if any occur
 //
-func (TlsMan *TlsManager) getServerTlsConfig(cert tls.Certificate, serverPool *x509.CertPool) func(
+func (TlsMan *TlsManager) getServerTlsConfig(serverPool *x509.CertPool) func(
                                              *tls.ClientHelloInfo) (*tls.Config, error) {
     return func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+        _, cert := TlsMan.Current()
         // Generate new TLS configuration instance
         cfg := TlsMan.newServerTlsConfig(cert)
         // Inject the VerifyPeerCertificate callback with access to hello
@@ -2,9 +2,11 @@ package tui
 
 import (
 	"fmt"
+	"maps"
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pterm/pterm"
@@ -17,18 +19,26 @@ const AnsiReset = "\033[0m"
 // TUI manages a two-panel display: left=panel1, right=panel2.
 type TUI struct {
     area             *pterm.AreaPrinter
+    dropped          atomic.Int64
     first            bool
+    footer           func() string
+    header           func() string
+    headless         bool
+    hooks            []func(panel string, msg string)
     leftPanelBuffer  []string
     LeftPanelCh      chan string
     leftPanelName    string
     maxBuffer        int
     mutx             sync.Mutex
+    progress         map[string]string
     redrawInterval   time.Duration
     rightColOffset   uint16
     rightPanelBuffer []string
     RightPanelCh     chan string
     rightPanelName   string
     stopCh           chan struct{}
+    tail             func() (string, []string)
+    tailRows         int
 }
 
 // Creates a new TUI instance with given channel buffer sizes.
@@ -49,6 +59,7 @@ func NewTUI(maxBuffer int, leftPanelName string, redrawInterval time.Duration,
         LeftPanelCh:      make(chan string, maxBuffer),
         leftPanelName:    leftPanelName,
         maxBuffer:        maxBuffer,
+        progress:         map[string]string{},
         redrawInterval:   redrawInterval,
         rightColOffset:   rightColOffset,
         rightPanelBuffer: make([]string, 0, maxBuffer),
@@ -76,6 +87,9 @@ func (t *TUI) Start(leftPanelHeaderColor string, rightPanelHeaderColor string,
         select {
         // If there is data in the left panel buffer
         case msg := <-t.LeftPanelCh:
+            // Pass the message to any registered hooks
+            t.runHooks(t.leftPanelName, msg)
+
             t.mutx.Lock()
             // Add the message to the left panel buffer slice
             t.leftPanelBuffer = append(t.leftPanelBuffer, msg)
@@ -85,6 +99,9 @@ func (t *TUI) Start(leftPanelHeaderColor string, rightPanelHeaderColor string,
 
         // If there is data in the right panel buffer
         case msg := <-t.RightPanelCh:
+            // Pass the message to any registered hooks
+            t.runHooks(t.rightPanelName, msg)
+
             t.mutx.Lock()
             // Add the message to the right panel buffer slice
             t.rightPanelBuffer = append(t.rightPanelBuffer, msg)
@@ -94,10 +111,19 @@ func (t *TUI) Start(leftPanelHeaderColor string, rightPanelHeaderColor string,
 
         // If the ticker interval has been reached
         case <-ticker.C:
+            // If running headless there is nothing to render
+            if t.headless {
+                continue
+            }
+
             t.mutx.Lock()
             // Make a copy of each pannels buffer for rendering output
             bufferLeftCopy := slices.Clone(t.leftPanelBuffer)
             bufferRightCopy := slices.Clone(t.rightPanelBuffer)
+            // Pin the progress of the active transfers below the right panel messages
+            for _, key := range slices.Sorted(maps.Keys(t.progress)) {
+                bufferRightCopy = append(bufferRightCopy, t.progress[key])
+            }
             t.mutx.Unlock()
 
             // If the first ticker occurs
@@ -118,6 +144,211 @@ func (t *TUI) Start(leftPanelHeaderColor string, rightPanelHeaderColor string,
     }
 }
 
+// Posts a message to the left panel without blocking, see post().
+//
+// @Parameters
+// - msg:  The message to be displayed
+//
+func (t *TUI) PostLeft(msg string) {
+    // If the TUI was never created
+    if t == nil {
+        return
+    }
+
+    t.post(t.LeftPanelCh, msg)
+}
+
+// Posts a message to the right panel without blocking, see post().
+//
+// @Parameters
+// - msg:  The message to be displayed
+//
+func (t *TUI) PostRight(msg string) {
+    // If the TUI was never created
+    if t == nil {
+        return
+    }
+
+    t.post(t.RightPanelCh, msg)
+}
+
+// Sends a message to the panel channel without blocking the caller. If the display
+// has stalled and the channel is full, the oldest queued message is dropped to make
+// room, since the panels only show the most recent messages anyway.
+//
+// @Parameters
+// - panelCh:  The channel of the panel the message is posted to
+// - msg:  The message to be displayed
+//
+func (t *TUI) post(panelCh chan string, msg string) {
+    for {
+        select {
+        case panelCh <- msg:
+            return
+        default:
+        }
+
+        // The channel is full, drop the oldest message unless the TUI just read it
+        select {
+        case <-panelCh:
+            t.dropped.Add(1)
+        default:
+        }
+    }
+}
+
+// Sets the progress line of an active task, such as a file transfer, rendered below the
+// right panel messages and replaced in place on each update until cleared.
+//
+// @Parameters
+// - key:  Identifies the task, lines are ordered by their keys
+// - line:  The progress line to be displayed
+//
+func (t *TUI) SetProgress(key string, line string) {
+    // If the TUI was never created
+    if t == nil {
+        return
+    }
+
+    t.mutx.Lock()
+    defer t.mutx.Unlock()
+
+    t.progress[key] = line
+}
+
+// Removes the progress line of a task that is no longer active.
+//
+// @Parameters
+// - key:  Identifies the task
+//
+func (t *TUI) ClearProgress(key string) {
+    // If the TUI was never created
+    if t == nil {
+        return
+    }
+
+    t.mutx.Lock()
+    defer t.mutx.Unlock()
+
+    delete(t.progress, key)
+}
+
+// Renders a text progress bar of the passed in width.
+//
+// @Parameters
+// - fraction:  The fraction complete from 0 to 1
+// - width:  The number of characters between the brackets
+//
+// @Returns
+// - The progress bar
+//
+func ProgressBar(fraction float64, width int) string {
+    filled := int(min(max(fraction, 0), 1) * float64(width))
+    return "[" + strings.Repeat("#", filled) + strings.Repeat("-", width - filled) + "]"
+}
+
+// Gets the number of messages dropped because the display could not keep up.
+//
+// @Returns
+// - The number of dropped messages
+//
+func (t *TUI) Dropped() int64 {
+    return t.dropped.Load()
+}
+
+// Registers a hook that is called with the panel name and message for every
+// message received by the TUI, allowing other displays to mirror the output.
+// Hooks must be registered before Start() is called.
+//
+// @Parameters
+// - hook:  The function to be called for each received panel message
+//
+func (t *TUI) AddHook(hook func(panel string, msg string)) {
+    t.hooks = append(t.hooks, hook)
+}
+
+// Toggles headless mode where panel messages are still consumed and passed
+// to hooks, but nothing is rendered to the terminal.
+//
+// @Parameters
+// - headless:  Boolean toggle to enable or disable terminal rendering
+//
+func (t *TUI) SetHeadless(headless bool) {
+    t.headless = headless
+}
+
+// Sets a function that is called on each redraw to render a line pinned to the
+// bottom of the display below both panels, such as a run summary.
+// The footer must be set before Start() is called.
+//
+// @Parameters
+// - footer:  The function returning the footer line to be rendered
+//
+func (t *TUI) SetFooter(footer func() string) {
+    t.footer = footer
+}
+
+// Sets a function that is called on each redraw to render a status line at the top of
+// the display above both panels, such as the combined rate of the fleet.
+// The header must be set before Start() is called.
+//
+// @Parameters
+// - header:  The function returning the header line to be rendered
+//
+func (t *TUI) SetHeader(header func() string) {
+    t.header = header
+}
+
+// Sets a function that is called on each redraw to render a full width view below
+// both panels, such as the tail of a log. The title is rendered as a header line
+// above the most recent lines. The tail must be set before Start() is called.
+//
+// @Parameters
+// - rows:  The number of lines rendered below the header
+// - tail:  The function returning the title and lines of the view
+//
+func (t *TUI) SetTail(rows int, tail func() (string, []string)) {
+    t.tail = tail
+    t.tailRows = rows
+}
+
+// Renders a full width summary view framed by dividers below the current output once
+// the panels are stopped, such as the final summary of a run.
+//
+// @Parameters
+// - title:  The title rendered in the header
+// - lines:  The lines of the summary
+// - headerColor:  The color of the header
+// - dividerColor:  The color of the divider between the header and the summary
+//
+func RenderSummary(title string, lines []string, headerColor string, dividerColor string) {
+    width := pterm.GetTerminalWidth()
+
+    fmt.Println()
+    fmt.Println(headerColor + title + AnsiReset)
+    fmt.Println(dividerColor + strings.Repeat("-", width) + AnsiReset)
+
+    // Iterate through the summary lines printing each
+    for _, line := range lines {
+        fmt.Println(line)
+    }
+
+    fmt.Println(dividerColor + strings.Repeat("-", width) + AnsiReset)
+}
+
+// Passes the received panel message into each of the registered hooks.
+//
+// @Parameters
+// - panel:  The name of the panel the message was sent to
+// - msg:  The message received on the panel channel
+//
+func (t *TUI) runHooks(panel string, msg string) {
+    // Iterate through the registered hooks
+    for _, hook := range t.hooks {
+        hook(panel, msg)
+    }
+}
+
 // Stop signals the TUI to exit its update loop.
 func (t *TUI) Stop() {
     close(t.stopCh)
@@ -178,12 +409,32 @@ func (t *TUI) updateContent(bufferLeft []string, bufferRight []string) {
 
     // We only have (height−2) rows for content (rows 2..height−1)
     contentRows := max(height - 2, 0)
+    // If there is a footer, reserve the last content row for it
+    if t.footer != nil {
+        contentRows = max(contentRows - 1, 0)
+    }
+    // If there is a header, reserve the first content row for it
+    if t.header != nil {
+        contentRows = max(contentRows - 1, 0)
+    }
+
+    tailRows := 0
+    // If there is a tail view, reserve its header and lines without hiding the panels
+    if t.tail != nil {
+        tailRows = min(t.tailRows + 1, contentRows / 2)
+        contentRows -= tailRows
+    }
 
     // Trim each buffer to at most contentRows lines
     bufferLeft = t.trimToMax(bufferLeft, contentRows)
     bufferRight = t.trimToMax(bufferRight, contentRows)
 
-    lines := make([]string, contentRows)
+    lines := make([]string, 0, contentRows + tailRows + 2)
+
+    // If there is a header, pin it above the panels
+    if t.header != nil {
+        lines = append(lines, t.padOrTrim(t.header(), width - 1))
+    }
 
     // Iterate through slice of content rows
     for row := range contentRows {
@@ -206,7 +457,29 @@ func (t *TUI) updateContent(bufferLeft []string, bufferRight []string) {
         }
 
         // Combine left and right pane lines into a single full-width line
-        lines[row] = leftLine + rightLine
+        lines = append(lines, leftLine + rightLine)
+    }
+
+    // If there is room for the tail view, render it below the panels
+    if tailRows > 0 {
+        title, tailLines := t.tail()
+        tailLines = t.trimToMax(tailLines, tailRows - 1)
+
+        lines = append(lines, t.padOrTrim(title, width - 1))
+        // Iterate through the tail rows, filling any without a line with spaces
+        for row := range tailRows - 1 {
+            tailLine := ""
+            if row < len(tailLines) {
+                tailLine = tailLines[row]
+            }
+
+            lines = append(lines, t.padOrTrim(tailLine, width - 1))
+        }
+    }
+
+    // If there is a footer, pin it below the panels
+    if t.footer != nil {
+        lines = append(lines, t.padOrTrim(t.footer(), width - 1))
     }
 
     // Update the single AreaPrinter (t.area) with the joined lines
@@ -0,0 +1,62 @@
+package tui_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/tui"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestPost(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // The TUI is never started, so nothing reads the panel channels
+    display := tui.NewTUI(3, "Left", time.Second, 2, "Right")
+
+    done := make(chan struct{})
+    go func() {
+        // Iterate posting more messages than the channel holds
+        for index := range 5 {
+            display.PostLeft(fmt.Sprintf("left %d", index))
+        }
+        display.PostRight("right")
+        close(done)
+    } ()
+
+    // Ensure posting never blocks on the stalled display
+    select {
+    case <-done:
+    case <-time.After(time.Second):
+        t.Fatal("posting to a full panel channel blocked")
+    }
+
+    // Ensure the oldest messages were dropped to make room for the newest
+    assert.Equal(int64(2), display.Dropped())
+    assert.Equal("left 2", <-display.LeftPanelCh)
+    assert.Equal("left 3", <-display.LeftPanelCh)
+    assert.Equal("left 4", <-display.LeftPanelCh)
+    assert.Equal("right", <-display.RightPanelCh)
+
+    // Ensure posting to a TUI that was never created is a no-op
+    var missing *tui.TUI
+    missing.PostRight("ignored")
+}
+
+
+func TestProgress(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    assert.Equal("[----------]", tui.ProgressBar(0, 10))
+    assert.Equal("[#####-----]", tui.ProgressBar(0.5, 10))
+    assert.Equal("[##########]", tui.ProgressBar(1.7, 10))
+
+    // Ensure progress on a TUI that was never created is a no-op
+    var missing *tui.TUI
+    missing.SetProgress("transfer", "line")
+    missing.ClearProgress("transfer")
+}
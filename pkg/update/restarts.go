@@ -0,0 +1,86 @@
+package update
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Restarts tracks the clients restarting on a new version per host, so a client whose
+// restart fails is counted as gone once it misses the reconnect deadline
+type Restarts struct {
+    expired map[string]int            // Restarts per host that missed the deadline
+    mutx    sync.Mutex
+    pending map[string][]*time.Timer  // Deadlines of the restarts per host, oldest first
+}
+
+// Creates a restart tracker with no clients restarting.
+//
+// @Returns
+// - The initialized restart tracker
+//
+func NewRestarts() *Restarts {
+    return &Restarts{expired: make(map[string]int), pending: make(map[string][]*time.Timer)}
+}
+
+// Expects a client of the host to reconnect within the deadline after restarting,
+// calling onExpire if it does not.
+//
+// @Parameters
+// - host:  The host of the restarting client
+// - deadline:  The length of time the client has to reconnect
+// - onExpire:  Called once the deadline passes without the client reconnecting
+//
+func (restarts *Restarts) Expect(host string, deadline time.Duration, onExpire func()) {
+    restarts.mutx.Lock()
+    defer restarts.mutx.Unlock()
+
+    var timer *time.Timer
+    timer = time.AfterFunc(deadline, func() {
+        restarts.mutx.Lock()
+        timers := restarts.pending[host]
+        index := slices.Index(timers, timer)
+        // If the client reconnected as the deadline passed, it is not gone
+        if index < 0 {
+            restarts.mutx.Unlock()
+            return
+        }
+
+        restarts.pending[host] = slices.Delete(timers, index, index + 1)
+        restarts.expired[host] += 1
+        restarts.mutx.Unlock()
+
+        onExpire()
+    })
+
+    restarts.pending[host] = append(restarts.pending[host], timer)
+}
+
+// Marks a client of the host as reconnected, stopping the deadline of its oldest restart.
+//
+// @Parameters
+// - host:  The host of the connected client
+//
+// @Returns
+// - true if the client reconnected after its deadline passed and was counted as gone,
+//   otherwise false
+//
+func (restarts *Restarts) Reconnected(host string) bool {
+    restarts.mutx.Lock()
+    defer restarts.mutx.Unlock()
+
+    // If a restart of the host is still within its deadline
+    if timers := restarts.pending[host]; len(timers) > 0 {
+        timers[0].Stop()
+        restarts.pending[host] = timers[1:]
+        return false
+    }
+
+    // If a restart of the host missed its deadline, it was counted as gone
+    if restarts.expired[host] > 0 {
+        restarts.expired[host] -= 1
+        return true
+    }
+
+    return false
+}
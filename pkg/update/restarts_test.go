@@ -0,0 +1,39 @@
+package update_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/update"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestRestarts(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    restarts := update.NewRestarts()
+
+    var gone atomic.Int32
+    onExpire := func() {
+        gone.Add(1)
+    }
+
+    // Ensure a client reconnecting before its deadline is never counted as gone
+    restarts.Expect("10.0.0.1", time.Hour, onExpire)
+    assert.False(restarts.Reconnected("10.0.0.1"))
+
+    // Ensure a client missing its deadline is counted as gone
+    restarts.Expect("10.0.0.2", 10 * time.Millisecond, onExpire)
+    assert.Eventually(func() bool {
+        return gone.Load() == 1
+    }, time.Second, 5 * time.Millisecond)
+
+    // Ensure a late reconnect is reported so the client is counted again, only once
+    assert.True(restarts.Reconnected("10.0.0.2"))
+    assert.False(restarts.Reconnected("10.0.0.2"))
+
+    // Ensure an unknown host was never restarting
+    assert.False(restarts.Reconnected("10.0.0.3"))
+}
@@ -0,0 +1,302 @@
+package update
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/kloudlogs"
+	"go.uber.org/zap"
+)
+
+// Publisher stores the current client binary version and the S3 key it is stored under
+type Publisher struct {
+    key     string
+    mutx    sync.Mutex
+    version string
+}
+
+
+// Computes the version of a client binary, which is the hex encoded SHA-256 of its data.
+//
+// @Parameters
+// - data:  The client binary data
+//
+// @Returns
+// - The hex encoded version hash
+//
+func HashBytes(data []byte) string {
+    sum := sha256.Sum256(data)
+    return hex.EncodeToString(sum[:])
+}
+
+
+// Computes the version of the client binary at the passed in path.
+//
+// @Parameters
+// - filePath:  The path to the client binary
+//
+// @Returns
+// - The hex encoded version hash
+// - Error if it occurs, otherwise nil on success
+//
+func HashFile(filePath string) (string, error) {
+    // Read the binary into memory
+    data, err := os.ReadFile(filePath)
+    if err != nil {
+        return "", err
+    }
+
+    return HashBytes(data), nil
+}
+
+
+// Creates a publisher with the initial client binary version and S3 key.
+//
+// @Parameters
+// - version:  The version hash of the initially uploaded client binary
+// - key:  The S3 key the initial client binary is stored under
+//
+// @Returns
+// - The initialized publisher
+//
+func NewPublisher(version string, key string) *Publisher {
+    return &Publisher{key: key, version: version}
+}
+
+// Gets the current client binary version and S3 key.
+//
+// @Returns
+// - The current version hash, empty when auto update is disabled
+// - The S3 key the current client binary is stored under
+//
+func (pub *Publisher) Current() (string, string) {
+    // If auto update is not in use
+    if pub == nil {
+        return "", ""
+    }
+
+    pub.mutx.Lock()
+    defer pub.mutx.Unlock()
+
+    return pub.version, pub.key
+}
+
+// Hashes the client binary at the passed in path, if it differs from the current
+// version it is uploaded with the passed in function and published as current.
+//
+// @Parameters
+// - binPath:  The path to the local client binary
+// - upload:  Uploads the binary data and returns the S3 key it is stored under
+//
+// @Returns
+// - Whether a new version was published
+// - Error if it occurs, otherwise nil on success
+//
+func (pub *Publisher) Check(binPath string, upload func([]byte) (string, error)) (bool, error) {
+    // Read the current local client binary
+    data, err := os.ReadFile(binPath)
+    if err != nil {
+        return false, err
+    }
+
+    version := HashBytes(data)
+    // If the binary has not changed since it was last published
+    if current, _ := pub.Current(); current == version {
+        return false, nil
+    }
+
+    // Upload the new binary to S3
+    key, err := upload(data)
+    if err != nil {
+        return false, fmt.Errorf("error uploading client binary - %w", err)
+    }
+
+    pub.mutx.Lock()
+    pub.key = key
+    pub.version = version
+    pub.mutx.Unlock()
+
+    return true, nil
+}
+
+// Periodically checks the local client binary and publishes any new version until
+// the context is cancelled.
+//
+// @Parameters
+// - ctx:  The context that stops the watcher when cancelled
+// - binPath:  The path to the local client binary
+// - interval:  The time between checks of the client binary
+// - upload:  Uploads the binary data and returns the S3 key it is stored under
+// - logMan:  The kloudlogs logger manager for local logging
+//
+func (pub *Publisher) Watch(ctx context.Context, binPath string, interval time.Duration,
+                            upload func([]byte) (string, error),
+                            logMan *kloudlogs.LoggerManager) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            // Check the client binary for a new version
+            published, err := pub.Check(binPath, upload)
+            if err != nil {
+                logMan.LogMessage("error", "Error checking client binary for updates:  %v", err)
+                continue
+            }
+
+            // If a new version was published
+            if published {
+                version, key := pub.Current()
+                logMan.LogMessage("info", "New client binary version published",
+                                  zap.String("version", version), zap.String("key", key))
+            }
+        }
+    }
+}
+
+
+// Formats the version check message a client sends with its current version.
+//
+// @Parameters
+// - version:  The version hash of the running client binary
+//
+// @Returns
+// - The formatted version check message
+//
+func FormatCheck(version string) []byte {
+    message := append([]byte{}, globals.VERSION_CHECK_PREFIX...)
+    message = append(message, version...)
+    return append(message, globals.TRANSFER_SUFFIX...)
+}
+
+
+// Parses the client version from the version check message.
+//
+// @Parameters
+// - message:  The version check message
+//
+// @Returns
+// - The version hash of the client binary
+// - Error if it occurs, otherwise nil on success
+//
+func ParseCheck(message []byte) (string, error) {
+    // If the message does not start with prefix or end with closed bracket
+    if !bytes.HasPrefix(message, globals.VERSION_CHECK_PREFIX) ||
+    !bytes.HasSuffix(message, globals.TRANSFER_SUFFIX) {
+        return "", fmt.Errorf("improper prefix or suffix in version check message")
+    }
+
+    version := bytes.TrimSuffix(bytes.TrimPrefix(message, globals.VERSION_CHECK_PREFIX),
+                                globals.TRANSFER_SUFFIX)
+    // If the version is missing
+    if len(version) == 0 {
+        return "", fmt.Errorf("empty version in version check message")
+    }
+
+    return string(version), nil
+}
+
+
+// Formats the client version message the server replies to a version check with.
+//
+// @Parameters
+// - version:  The version hash of the current client binary
+// - key:  The S3 key the current client binary is stored under
+//
+// @Returns
+// - The formatted client version message
+//
+func FormatVersion(version string, key string) []byte {
+    return []byte(fmt.Sprintf("%s%s:%s%s", globals.CLIENT_VERSION_PREFIX, version, key,
+                              globals.TRANSFER_SUFFIX))
+}
+
+
+// Parses the current version and S3 key from the client version message.
+//
+// @Parameters
+// - message:  The client version message
+//
+// @Returns
+// - The version hash of the current client binary
+// - The S3 key the current client binary is stored under
+// - Error if it occurs, otherwise nil on success
+//
+func ParseVersion(message []byte) (string, string, error) {
+    // If the message does not start with prefix or end with closed bracket
+    if !bytes.HasPrefix(message, globals.CLIENT_VERSION_PREFIX) ||
+    !bytes.HasSuffix(message, globals.TRANSFER_SUFFIX) {
+        return "", "", fmt.Errorf("improper prefix or suffix in client version message")
+    }
+
+    body := bytes.TrimSuffix(bytes.TrimPrefix(message, globals.CLIENT_VERSION_PREFIX),
+                             globals.TRANSFER_SUFFIX)
+    fields := bytes.SplitN(body, globals.COLON_DELIMITER, 2)
+    // If the version or key are missing
+    if len(fields) != 2 || len(fields[0]) == 0 || len(fields[1]) == 0 {
+        return "", "", fmt.Errorf("improper fields in client version message")
+    }
+
+    return string(fields[0]), string(fields[1]), nil
+}
+
+
+// Verifies the downloaded binary matches the published version, then atomically
+// replaces the executable at the passed in path with it.
+//
+// @Parameters
+// - data:  The downloaded client binary data
+// - version:  The published version hash the data must match
+// - exePath:  The path of the executable to be replaced
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func Apply(data []byte, version string, exePath string) error {
+    // If the downloaded binary does not match the published version
+    if HashBytes(data) != version {
+        return fmt.Errorf("downloaded client binary does not match version %s", version)
+    }
+
+    stagedPath := filepath.Join(filepath.Dir(exePath), "." + filepath.Base(exePath) + ".new")
+    // Write the new binary next to the executable so the rename is atomic
+    err := os.WriteFile(stagedPath, data, 0755)
+    if err != nil {
+        return fmt.Errorf("error staging client binary - %w", err)
+    }
+
+    // Replace the executable with the staged binary
+    err = os.Rename(stagedPath, exePath)
+    if err != nil {
+        os.Remove(stagedPath)
+        return fmt.Errorf("error replacing client binary - %w", err)
+    }
+
+    return nil
+}
+
+
+// Replaces the running process with a fresh execution of the passed in executable
+// using the original arguments and environment.
+//
+// @Parameters
+// - exePath:  The path of the executable to run
+//
+// @Returns
+// - Error if it occurs, on success the function does not return
+//
+func Reexec(exePath string) error {
+    return syscall.Exec(exePath, os.Args, os.Environ())
+}
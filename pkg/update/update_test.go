@@ -0,0 +1,129 @@
+package update_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/update"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestPublisherCheck(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    binPath := filepath.Join(t.TempDir(), "client")
+    // Write the initial client binary
+    err := os.WriteFile(binPath, []byte("version one"), 0755)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    publisher := update.NewPublisher(update.HashBytes([]byte("version one")), "client-1")
+    uploads := 0
+    upload := func(data []byte) (string, error) {
+        uploads += 1
+        return "client-2", nil
+    }
+
+    // Ensure an unchanged binary is not uploaded
+    published, err := publisher.Check(binPath, upload)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.False(published)
+    assert.Equal(0, uploads)
+
+    // Replace the client binary with a new version
+    err = os.WriteFile(binPath, []byte("version two"), 0755)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the new binary is uploaded and published
+    published, err = publisher.Check(binPath, upload)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.True(published)
+
+    version, key := publisher.Current()
+    assert.Equal(update.HashBytes([]byte("version two")), version)
+    assert.Equal("client-2", key)
+
+    // Ensure a failed upload does not change the published version
+    err = os.WriteFile(binPath, []byte("version three"), 0755)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    _, err = publisher.Check(binPath, func(data []byte) (string, error) {
+        return "", errors.New("upload failed")
+    })
+    assert.NotEqual(nil, err)
+
+    version, _ = publisher.Current()
+    assert.Equal(update.HashBytes([]byte("version two")), version)
+
+    var nilPublisher *update.Publisher
+    // Ensure a disabled publisher has no version
+    version, _ = nilPublisher.Current()
+    assert.Equal("", version)
+}
+
+
+func TestFormatParseMessages(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    version := update.HashBytes([]byte("client"))
+
+    // Format the version check then parse it back
+    parsed, err := update.ParseCheck(update.FormatCheck(version))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(version, parsed)
+
+    // Format the client version then parse it back
+    parsed, key, err := update.ParseVersion(update.FormatVersion(version, "client-3"))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(version, parsed)
+    assert.Equal("client-3", key)
+
+    // Ensure improper messages are rejected
+    _, err = update.ParseCheck([]byte("<VERSION_CHECK:>"))
+    assert.NotEqual(nil, err)
+    _, _, err = update.ParseVersion([]byte("<CLIENT_VERSION:missingkey>"))
+    assert.NotEqual(nil, err)
+}
+
+
+func TestApply(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    exePath := filepath.Join(t.TempDir(), "client")
+    // Write the running client binary
+    err := os.WriteFile(exePath, []byte("old binary"), 0755)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure a binary that does not match the version is rejected
+    err = update.Apply([]byte("tampered"), update.HashBytes([]byte("new binary")), exePath)
+    assert.NotEqual(nil, err)
+
+    // Apply the new binary with its matching version
+    err = update.Apply([]byte("new binary"), update.HashBytes([]byte("new binary")), exePath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    exeData, err := os.ReadFile(exePath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the executable was replaced
+    assert.Equal("new binary", string(exeData))
+
+    // Ensure the replaced executable is still executable
+    exeInfo, err := os.Stat(exePath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(os.FileMode(0755), exeInfo.Mode().Perm())
+}
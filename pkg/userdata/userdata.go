@@ -0,0 +1,125 @@
+package userdata
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Package level variables
+//go:embed userdata.sh.tmpl
+var scriptTemplate string
+//go:embed userdata.ps1.tmpl
+var windowsTemplate string
+var HookDelimiter = "KLOUD_KRAKEN_HOOK_EOF"  // Heredoc delimiter the hook scripts are written with
+var MaxSize = 16 * 1024                       // Largest user data EC2 accepts before encoding
+
+
+// Params are the values the user data template is rendered with
+type Params struct {
+    BucketName       string
+    EbsFallback      bool
+    GeneratorPackage string
+    HardeningUser    string
+    HashcatArtifact  string  // S3 key of the custom hashcat build installed instead of a release
+    HashcatSha256    string  // Checksum the downloaded custom hashcat build is verified against
+    HashcatRelease   string
+    KeyName          string
+    Launch           string
+    PostHook         string
+    PreHook          string
+    Region           string
+    SelfTerminate    bool  // Shut the instance down once the client exits successfully
+    SsmSessions      bool
+    Windows          bool  // Render the PowerShell bootstrap of Windows clients
+}
+
+
+// hook is an operator script written to a file on the instance and run with bash
+type hook struct {
+    Delimiter string
+    Name      string
+    Script    string
+}
+
+
+// Quotes an arg for a PowerShell command line, so the args of the Windows client launch
+// are passed through verbatim instead of being parsed by PowerShell.
+//
+// @Parameters
+// - arg:  The arg to be quoted
+//
+// @Returns
+// - The single quoted arg
+//
+func PowerShellQuote(arg string) string {
+    return "'" + strings.ReplaceAll(arg, "'", "''") + "'"
+}
+
+
+// Renders the EC2 user data from the template sections, the bash script on Linux and the
+// PowerShell script on Windows. The operator hook scripts are written to files and run in
+// their own process, the pre hook before anything else is set up and the post hook right
+// before the client is launched, so an exit in a hook does not skip the rest of the
+// bootstrap.
+//
+// @Parameters
+// - params:  The values the template is rendered with
+//
+// @Returns
+// - The rendered user data script
+// - Error if it occurs, otherwise nil on success
+//
+func Render(params Params) (string, error) {
+    source := scriptTemplate
+    heredocScripts := []string{params.PreHook, params.PostHook}
+    // If the clients run Windows, the hooks are written from base64 with no heredoc
+    if params.Windows {
+        source = windowsTemplate
+        heredocScripts = nil
+    }
+
+    // Iterate through the hook scripts ensuring they can not end the heredoc early
+    for _, script := range heredocScripts {
+
+        for _, line := range strings.Split(script, "\n") {
+            if strings.TrimSpace(line) == HookDelimiter {
+                return "", fmt.Errorf("hook script contains the reserved line %s",
+                                      HookDelimiter)
+            }
+        }
+    }
+
+    tmpl, err := template.New("userdata").Funcs(template.FuncMap{
+        "base64": func(script string) string {
+            return base64.StdEncoding.EncodeToString([]byte(script))
+        },
+        "hook": func(name string, script string) hook {
+            return hook{
+                Delimiter: HookDelimiter,
+                Name:      name,
+                Script:    strings.TrimRight(script, "\n"),
+            }
+        },
+    }).Parse(source)
+    if err != nil {
+        return "", fmt.Errorf("error parsing user data template - %w", err)
+    }
+
+    var rendered bytes.Buffer
+    err = tmpl.Execute(&rendered, params)
+    if err != nil {
+        return "", fmt.Errorf("error rendering user data template - %w", err)
+    }
+
+    // If the hooks pushed the user data past the EC2 limit
+    if rendered.Len() > MaxSize {
+        return "", fmt.Errorf("user data is %d bytes, EC2 accepts at most %d",
+                              rendered.Len(), MaxSize)
+    }
+
+    return rendered.String(), nil
+}
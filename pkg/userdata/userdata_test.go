@@ -0,0 +1,145 @@
+package userdata_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/userdata"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestRender(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    params := userdata.Params{
+        BucketName: "test-bucket",
+        KeyName:    "client",
+        Launch:     "$CWD/client -region=us-east-1",
+        PostHook:   "systemctl start monitoring-agent\n",
+        PreHook:    "wg-quick up wg0\nexit 0\n",
+        Region:     "us-east-1",
+    }
+
+    script, err := userdata.Render(params)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.True(strings.HasPrefix(script, "#!/bin/bash\n"))
+    assert.Contains(script, "apt install -y hashcat\n")
+    assert.Contains(script, "aws s3 cp s3://test-bucket/client")
+    // Ensure the bootstrap reruns on boot so a hibernated fleet relaunches its clients
+    assert.Contains(script, "cp \"$0\" /var/lib/cloud/scripts/per-boot/kloud-kraken.sh\n")
+    assert.NotContains(script, "SSM Session Manager")
+
+    // Ensure the hooks run in their own process in order around the bootstrap
+    assert.Contains(script, "wg-quick up wg0\nexit 0\n" + userdata.HookDelimiter + "\n" +
+                            "bash /var/lib/kloud-kraken/pre-hook.sh\n")
+    preIndex := strings.Index(script, "pre-hook.sh")
+    storageIndex := strings.Index(script, "instance-store setup")
+    postIndex := strings.Index(script, "post-hook.sh")
+    launchIndex := strings.Index(script, "$CWD/client -region")
+    assert.True(preIndex < storageIndex && storageIndex < postIndex && postIndex < launchIndex)
+
+    // Ensure optional sections are rendered when enabled
+    params.HashcatRelease = "v6.2.6"
+    params.SsmSessions = true
+    script, err = userdata.Render(params)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Contains(script, "--branch v6.2.6")
+    assert.Contains(script, "SSM Session Manager")
+    assert.NotContains(script, "client -region=us-east-1\nshutdown")
+
+    // Ensure a self terminating instance is shut down once the client exits
+    params.SelfTerminate = true
+    script, err = userdata.Render(params)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.True(strings.HasSuffix(script, "$CWD/client -region=us-east-1\n# The client only " +
+                                          "exits successfully once the server acknowledged " +
+                                          "its results\nshutdown -h now\n"))
+
+    // Ensure a custom hashcat build is verified and installed in place of the release
+    params.HashcatArtifact = "kloud-kraken/a1b2c3d4/hashcat"
+    params.HashcatSha256 = strings.Repeat("ab", 32)
+    script, err = userdata.Render(params)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Contains(script, "aws s3 cp s3://test-bucket/kloud-kraken/a1b2c3d4/hashcat")
+    assert.Contains(script, "echo \"" + params.HashcatSha256 + "  /tmp/hashcat.tar.gz\" | " +
+                            "sha256sum -c -")
+    assert.NotContains(script, "--branch v6.2.6")
+    assert.NotContains(script, "apt install -y hashcat")
+
+    // Ensure a hook can not end its heredoc early or exceed the EC2 limit
+    falacies := []string{"echo\n" + userdata.HookDelimiter + "\nreboot",
+                         strings.Repeat("#", userdata.MaxSize)}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        params.PostHook = falacy
+        _, err = userdata.Render(params)
+        assert.NotEqual(nil, err)
+    }
+}
+
+
+func TestRenderWindows(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    params := userdata.Params{
+        BucketName:     "test-bucket",
+        EbsFallback:    true,
+        HashcatRelease: "v6.2.6",
+        KeyName:        "client",
+        Launch:         "& \"$Cwd\\client.exe\" " + userdata.PowerShellQuote("-hashMask=?a'?d"),
+        PostHook:       userdata.HookDelimiter + "\nStart-Service monitoring-agent\n",
+        Region:         "us-east-1",
+        Windows:        true,
+    }
+
+    script, err := userdata.Render(params)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.True(strings.HasPrefix(script, "<powershell>\n"))
+    assert.True(strings.HasSuffix(script, "</powershell>\n"))
+    assert.Contains(script, "Read-S3Object -BucketName 'test-bucket' -Key 'client'")
+    assert.Contains(script, "hashcat-$Release.7z")
+    assert.Contains(script, "if ($Disks.Count -eq 0) {\n")
+    assert.Contains(script, "using the gp3 EBS data volume")
+    assert.NotContains(script, "pre-hook.ps1")
+    assert.NotContains(script, "apt install")
+
+    // Ensure the launch args are quoted with embedded quotes doubled
+    assert.Contains(script, "& \"$Cwd\\client.exe\" '-hashMask=?a''?d'\n")
+
+    // Ensure the hook is written from base64, so the bash heredoc delimiter is allowed
+    encodedHook := base64.StdEncoding.EncodeToString([]byte(
+        strings.TrimRight(params.PostHook, "\n")))
+    assert.Contains(script, "FromBase64String('" + encodedHook + "')")
+    postIndex := strings.Index(script, "post-hook.ps1")
+    launchIndex := strings.Index(script, "client.exe\" '-hashMask")
+    assert.True(postIndex > 0 && postIndex < launchIndex)
+
+    // Ensure a custom hashcat build is verified and expanded in place of the release
+    params.HashcatArtifact = "kloud-kraken/a1b2c3d4/hashcat"
+    params.HashcatSha256 = strings.Repeat("ab", 32)
+    script, err = userdata.Render(params)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Contains(script, "-Key 'kloud-kraken/a1b2c3d4/hashcat'")
+    assert.Contains(script, "if ($ArtifactSum -ne '" + params.HashcatSha256 + "')")
+    assert.NotContains(script, "hashcat-$Release.7z")
+    assert.True(strings.HasSuffix(script, "'-hashMask=?a''?d'\n</powershell>\n"))
+
+    // Ensure a self terminating instance is only shut down when the client succeeds
+    params.SelfTerminate = true
+    script, err = userdata.Render(params)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Contains(script, "'-hashMask=?a''?d'\n# The client only exits successfully once " +
+                            "the server acknowledged its results\nif ($LASTEXITCODE -eq 0) {\n" +
+                            "    Stop-Computer -Force\n}\n")
+}
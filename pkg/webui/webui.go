@@ -0,0 +1,459 @@
+package webui
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/display"
+)
+
+// Package level variables
+//go:embed dashboard.html
+var dashboardPage []byte
+
+
+// ClientStatus stores the state of a single connected remote client
+type ClientStatus struct {
+    Address          string    `json:"address"`
+    ActiveTransfers  int       `json:"active_transfers"`
+    ConnectedAt      time.Time `json:"connected_at"`
+    Connected        bool      `json:"connected"`
+    CrackedHashes    int       `json:"cracked_hashes"`
+    Eta              string    `json:"eta"`             // Time left in the job, empty if unknown
+    FilesTransferred int       `json:"files_transferred"`
+    HashcatStatus    string    `json:"hashcat_status"`  // State of the job, empty until reported
+    Progress         float64   `json:"progress"`        // Fraction of the hashcat job processed
+    Speed            float64   `json:"speed"`           // Hashes per second of the hashcat job
+}
+
+// Event stores a single message that was sent to a TUI panel
+type Event struct {
+    Message string    `json:"message"`
+    Panel   string    `json:"panel"`
+    Time    time.Time `json:"time"`
+}
+
+// Status is the snapshot of the run state served by the JSON API
+type Status struct {
+    ActiveConnections int            `json:"active_connections"`
+    ActiveTransfers   int            `json:"active_transfers"`
+    Clients           []ClientStatus `json:"clients"`
+    Coverage          float64        `json:"coverage"`  // Fraction of the fleet keyspace processed
+    CrackedHashes     int            `json:"cracked_hashes"`
+    Eta               string         `json:"eta"`       // Time left in the run, empty if unknown
+    Events            []Event        `json:"events"`
+    FilesTransferred  int            `json:"files_transferred"`
+    Speed             float64        `json:"speed"`     // Hashes per second of the fleet
+    StartTime         time.Time      `json:"start_time"`
+    Uptime            string         `json:"uptime"`
+}
+
+
+// Dashboard tracks the run state and serves it over HTTP(S)
+type Dashboard struct {
+    clients   map[string]*ClientStatus
+    coverage  float64        // Fraction of the fleet keyspace processed
+    eta       time.Duration  // Time left in the run, 0 when unknown
+    events    []Event
+    maxEvents int
+    mutx      sync.Mutex
+    server    *http.Server
+    speed     float64        // Hashes per second of the fleet
+    startTime time.Time
+    token     string  // Bearer token required by the JSON API, empty for none
+}
+
+// Creates a new dashboard instance that keeps up to the passed in number of events.
+//
+// @Parameters
+// - maxEvents:  The max number of panel events to keep in memory
+// - token:  The bearer token required to read the JSON API, empty to require none
+//
+// @Returns
+// - The initialized dashboard
+//
+func NewDashboard(maxEvents int, token string) *Dashboard {
+    return &Dashboard{
+        clients:   make(map[string]*ClientStatus),
+        events:    make([]Event, 0, maxEvents),
+        maxEvents: maxEvents,
+        startTime: time.Now(),
+        token:     token,
+    }
+}
+
+// Records a panel message with any ANSI color codes removed, the signature
+// matches tui.AddHook() so the dashboard can mirror the TUI output.
+//
+// @Parameters
+// - panel:  The name of the panel the message was sent to
+// - msg:  The message to be recorded
+//
+func (dash *Dashboard) AddEvent(panel string, msg string) {
+    if dash == nil {
+        return
+    }
+
+    dash.mutx.Lock()
+    defer dash.mutx.Unlock()
+
+    // Add the stripped message to the event slice
+    dash.events = append(dash.events, Event{
+        Message: display.StripAnsi(msg),
+        Panel:   panel,
+        Time:    time.Now(),
+    })
+
+    // If the events exceed the max, discard the oldest
+    if len(dash.events) > dash.maxEvents {
+        dash.events = dash.events[len(dash.events)-dash.maxEvents:]
+    }
+}
+
+// Marks the client as connected, creating an entry if it does not exist.
+//
+// @Parameters
+// - addr:  The address of the remote client
+//
+func (dash *Dashboard) ClientConnected(addr string) {
+    if dash == nil {
+        return
+    }
+
+    dash.mutx.Lock()
+    defer dash.mutx.Unlock()
+
+    client := dash.getClient(addr)
+    client.Connected = true
+    client.ConnectedAt = time.Now()
+}
+
+// Marks the client as disconnected.
+//
+// @Parameters
+// - addr:  The address of the remote client
+//
+func (dash *Dashboard) ClientDisconnected(addr string) {
+    if dash == nil {
+        return
+    }
+
+    dash.mutx.Lock()
+    defer dash.mutx.Unlock()
+
+    client := dash.getClient(addr)
+    client.Connected = false
+    // The hashcat job of the client ended with the connection
+    client.Eta = ""
+    client.Speed = 0
+}
+
+// Records the latest hashcat status forwarded by a client.
+//
+// @Parameters
+// - addr:  The address of the remote client
+// - state:  The name of the hashcat state
+// - progress:  The fraction of the hashcat job processed from 0 to 1
+// - speed:  The hashes per second of the hashcat job
+// - eta:  The time left in the hashcat job, 0 when unknown
+//
+func (dash *Dashboard) HashcatProgress(addr string, state string, progress float64,
+                                       speed float64, eta time.Duration) {
+    if dash == nil {
+        return
+    }
+
+    dash.mutx.Lock()
+    defer dash.mutx.Unlock()
+
+    client := dash.getClient(addr)
+    client.Eta = formatEta(eta)
+    client.HashcatStatus = state
+    client.Progress = progress
+    client.Speed = speed
+}
+
+// Records the combined hashcat speed, keyspace coverage, and completion estimate of the
+// fleet.
+//
+// @Parameters
+// - speed:  The hashes per second of the fleet
+// - coverage:  The fraction of the fleet keyspace processed from 0 to 1
+// - eta:  The time left in the run, 0 when unknown
+//
+func (dash *Dashboard) SetFleetRate(speed float64, coverage float64, eta time.Duration) {
+    if dash == nil {
+        return
+    }
+
+    dash.mutx.Lock()
+    defer dash.mutx.Unlock()
+
+    dash.coverage = coverage
+    dash.eta = eta
+    dash.speed = speed
+}
+
+// Adds the number of cracked hashes received from a client.
+//
+// @Parameters
+// - addr:  The address of the remote client
+// - count:  The number of cracked hashes received
+//
+func (dash *Dashboard) AddCrackedHashes(addr string, count int) {
+    if dash == nil {
+        return
+    }
+
+    dash.mutx.Lock()
+    defer dash.mutx.Unlock()
+
+    dash.getClient(addr).CrackedHashes += count
+}
+
+// Increments the active transfer count of a client.
+//
+// @Parameters
+// - addr:  The address of the remote client
+//
+func (dash *Dashboard) TransferStarted(addr string) {
+    if dash == nil {
+        return
+    }
+
+    dash.mutx.Lock()
+    defer dash.mutx.Unlock()
+
+    dash.getClient(addr).ActiveTransfers += 1
+}
+
+// Decrements the active transfer count of a client and increments the
+// number of files transferred on success.
+//
+// @Parameters
+// - addr:  The address of the remote client
+// - success:  Whether the transfer completed successfully or not
+//
+func (dash *Dashboard) TransferCompleted(addr string, success bool) {
+    if dash == nil {
+        return
+    }
+
+    dash.mutx.Lock()
+    defer dash.mutx.Unlock()
+
+    client := dash.getClient(addr)
+    client.ActiveTransfers -= 1
+
+    if success {
+        client.FilesTransferred += 1
+    }
+}
+
+// Gets the status entry of the passed in client address, creating it if it
+// does not exist. The dashboard mutex must be held by the caller.
+//
+// @Parameters
+// - addr:  The address of the remote client
+//
+// @Returns
+// - The status entry of the client
+//
+func (dash *Dashboard) getClient(addr string) *ClientStatus {
+    client, exists := dash.clients[addr]
+    // If the client does not have an entry, create one
+    if !exists {
+        client = &ClientStatus{Address: addr}
+        dash.clients[addr] = client
+    }
+
+    return client
+}
+
+// Creates a copy of the current run state with aggregated totals.
+//
+// @Returns
+// - The snapshot of the current run state
+//
+func (dash *Dashboard) Snapshot() Status {
+    dash.mutx.Lock()
+    defer dash.mutx.Unlock()
+
+    status := Status{
+        Clients:   make([]ClientStatus, 0, len(dash.clients)),
+        Coverage:  dash.coverage,
+        Eta:       formatEta(dash.eta),
+        Events:    make([]Event, len(dash.events)),
+        Speed:     dash.speed,
+        StartTime: dash.startTime,
+        Uptime:    time.Since(dash.startTime).Round(time.Second).String(),
+    }
+
+    copy(status.Events, dash.events)
+
+    // Iterate through the clients and aggregate the totals
+    for _, client := range dash.clients {
+        status.Clients = append(status.Clients, *client)
+        status.ActiveTransfers += client.ActiveTransfers
+        status.CrackedHashes += client.CrackedHashes
+        status.FilesTransferred += client.FilesTransferred
+
+        if client.Connected {
+            status.ActiveConnections += 1
+        }
+    }
+
+    // Sort the clients by address for stable output
+    sort.Slice(status.Clients, func(i, j int) bool {
+        return status.Clients[i].Address < status.Clients[j].Address
+    })
+
+    return status
+}
+
+// Formats a completion estimate rounded to the second.
+//
+// @Parameters
+// - eta:  The time left, 0 when unknown
+//
+// @Returns
+// - The formatted estimate, empty when unknown
+//
+func formatEta(eta time.Duration) string {
+    // If there is no estimate
+    if eta <= 0 {
+        return ""
+    }
+
+    return eta.Round(time.Second).String()
+}
+
+// Checks the bearer token in the Authorization header of the request in constant time.
+//
+// @Parameters
+// - req:  The HTTP request to be authorized
+//
+// @Returns
+// - true if no token is required or the request carries it, otherwise false
+//
+func (dash *Dashboard) authorized(req *http.Request) bool {
+    // If the dashboard requires no token
+    if dash.token == "" {
+        return true
+    }
+
+    token, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+    if !found {
+        return false
+    }
+
+    return subtle.ConstantTimeCompare([]byte(token), []byte(dash.token)) == 1
+}
+
+// Sets up the HTTP routes used by the dashboard. The page itself holds no run state
+// and is served to anyone, the JSON API requires the bearer token if one is set.
+//
+// @Returns
+// - The handler with the dashboard page and JSON API routes
+//
+func (dash *Dashboard) Handler() http.Handler {
+    mux := http.NewServeMux()
+
+    // Serve the dashboard page
+    mux.HandleFunc("/", func(writer http.ResponseWriter, req *http.Request) {
+        if req.URL.Path != "/" {
+            http.NotFound(writer, req)
+            return
+        }
+
+        writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+        writer.Write(dashboardPage)
+    })
+
+    // Serve the JSON status snapshot
+    mux.HandleFunc("/api/status", func(writer http.ResponseWriter, req *http.Request) {
+        // If the request does not carry the bearer token
+        if !dash.authorized(req) {
+            writer.Header().Set("WWW-Authenticate", "Bearer")
+            http.Error(writer, "unauthorized", http.StatusUnauthorized)
+            return
+        }
+
+        writer.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(writer).Encode(dash.Snapshot())
+    })
+
+    return mux
+}
+
+// Starts the HTTP server in a separate goroutine, serving HTTPS if a
+// TLS certificate is passed in.
+//
+// @Parameters
+// - listenIp:  The IP address of the network interface to listen on
+// - port:  The port to listen on
+// - cert:  The TLS certificate to serve HTTPS with, nil for plain HTTP
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (dash *Dashboard) Start(listenIp string, port int, cert *tls.Certificate) error {
+    // Bind the listener first so errors are returned to the caller
+    listener, err := net.Listen("tcp", net.JoinHostPort(listenIp, strconv.Itoa(port)))
+    if err != nil {
+        return err
+    }
+
+    dash.server = &http.Server{
+        Handler:           dash.Handler(),
+        ReadHeaderTimeout: 10 * time.Second,
+    }
+
+    // If a certificate was passed in, wrap the listener in TLS
+    if cert != nil {
+        listener = tls.NewListener(listener, &tls.Config{
+            Certificates: []tls.Certificate{*cert},
+            MinVersion:   tls.VersionTLS12,
+        })
+    }
+
+    go func() {
+        // Serve until the server is shut down
+        err := dash.server.Serve(listener)
+        if err != nil && !errors.Is(err, http.ErrServerClosed) {
+            dash.AddEvent("Web UI", "Web UI server stopped:  " + err.Error())
+        }
+    } ()
+
+    return nil
+}
+
+// Gracefully shuts down the HTTP server if it is running.
+//
+// @Parameters
+// - timeout:  The max amount of time to wait for active requests
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (dash *Dashboard) Stop(timeout time.Duration) error {
+    if dash == nil || dash.server == nil {
+        return nil
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), timeout)
+    defer cancel()
+
+    return dash.server.Shutdown(ctx)
+}
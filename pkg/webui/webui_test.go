@@ -0,0 +1,181 @@
+package webui_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/webui"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestSnapshot(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    dash := webui.NewDashboard(2, "")
+
+    // Add more events than the max to ensure oldest are discarded
+    dash.AddEvent("Connections", "\x1b[38;2;255;0;0mfirst\x1b[0m")
+    dash.AddEvent("Connections", "second")
+    dash.AddEvent("File Transfers", "\x1b[38;2;0;255;0mthird\x1b[0m")
+
+    // Simulate the lifecycle of two clients
+    dash.ClientConnected("10.0.0.2:5000")
+    dash.ClientConnected("10.0.0.1:5000")
+    dash.TransferStarted("10.0.0.1:5000")
+    dash.TransferStarted("10.0.0.1:5000")
+    dash.TransferCompleted("10.0.0.1:5000", true)
+    dash.TransferCompleted("10.0.0.2:5000", false)
+    dash.AddCrackedHashes("10.0.0.1:5000", 7)
+    dash.HashcatProgress("10.0.0.1:5000", "Running", 0.25, 1500, 90 * time.Second)
+    dash.HashcatProgress("10.0.0.2:5000", "Running", 0.5, 800, 0)
+    dash.SetFleetRate(2300, 0.4, 2 * time.Minute)
+    dash.ClientDisconnected("10.0.0.2:5000")
+
+    status := dash.Snapshot()
+
+    // Ensure only the max number of events are kept without color codes
+    assert.Equal(2, len(status.Events))
+    assert.Equal("second", status.Events[0].Message)
+    assert.Equal("third", status.Events[1].Message)
+    assert.Equal("File Transfers", status.Events[1].Panel)
+
+    // Ensure the clients are sorted by address
+    assert.Equal(2, len(status.Clients))
+    assert.Equal("10.0.0.1:5000", status.Clients[0].Address)
+
+    // Ensure the totals were aggregated from the clients
+    assert.Equal(1, status.ActiveConnections)
+    assert.Equal(0, status.ActiveTransfers)
+    assert.Equal(7, status.CrackedHashes)
+    assert.Equal(1, status.FilesTransferred)
+
+    // Ensure the hashcat progress of each client and the fleet is reported
+    assert.Equal("Running", status.Clients[0].HashcatStatus)
+    assert.Equal(0.25, status.Clients[0].Progress)
+    assert.Equal(1500.0, status.Clients[0].Speed)
+    assert.Equal("1m30s", status.Clients[0].Eta)
+    assert.Equal(2300.0, status.Speed)
+    assert.Equal(0.4, status.Coverage)
+    assert.Equal("2m0s", status.Eta)
+
+    // Ensure a disconnected client keeps its progress without a speed or estimate
+    assert.Equal(0.5, status.Clients[1].Progress)
+    assert.Equal(0.0, status.Clients[1].Speed)
+    assert.Equal("", status.Clients[1].Eta)
+}
+
+
+func TestNilDashboard(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    var dash *webui.Dashboard
+
+    // Ensure calls on a disabled dashboard do not panic
+    assert.NotPanics(func() {
+        dash.AddEvent("Connections", "message")
+        dash.ClientConnected("10.0.0.1:5000")
+        dash.TransferStarted("10.0.0.1:5000")
+        dash.TransferCompleted("10.0.0.1:5000", true)
+        dash.AddCrackedHashes("10.0.0.1:5000", 1)
+        dash.HashcatProgress("10.0.0.1:5000", "Running", 0.5, 100, time.Minute)
+        dash.SetFleetRate(100, 0.5, time.Minute)
+        dash.ClientDisconnected("10.0.0.1:5000")
+    })
+
+    // Ensure stopping a disabled dashboard is a no-op
+    assert.Equal(nil, dash.Stop(0))
+}
+
+
+func TestHandler(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    dash := webui.NewDashboard(10, "")
+    dash.ClientConnected("10.0.0.1:5000")
+    dash.AddCrackedHashes("10.0.0.1:5000", 3)
+
+    server := httptest.NewServer(dash.Handler())
+    defer server.Close()
+
+    // Request the dashboard page
+    resp, err := http.Get(server.URL + "/")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the page was served
+    assert.Equal(http.StatusOK, resp.StatusCode)
+    assert.Contains(resp.Header.Get("Content-Type"), "text/html")
+    resp.Body.Close()
+
+    // Request the JSON status
+    resp, err = http.Get(server.URL + "/api/status")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    var status webui.Status
+    // Decode the JSON status into struct
+    err = json.NewDecoder(resp.Body).Decode(&status)
+    resp.Body.Close()
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the status reflects the recorded state
+    assert.Equal(1, status.ActiveConnections)
+    assert.Equal(3, status.CrackedHashes)
+
+    // Request an unknown path
+    resp, err = http.Get(server.URL + "/unknown")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure unknown paths are not found
+    assert.Equal(http.StatusNotFound, resp.StatusCode)
+    resp.Body.Close()
+}
+
+
+func TestHandlerToken(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    dash := webui.NewDashboard(10, "0123456789abcdef")
+    server := httptest.NewServer(dash.Handler())
+    defer server.Close()
+
+    // Ensure the page is served without the token
+    resp, err := http.Get(server.URL + "/")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(http.StatusOK, resp.StatusCode)
+    resp.Body.Close()
+
+    // Ensure the JSON status is refused without the token or with a wrong one
+    for _, header := range []string{"", "Bearer wrong", "0123456789abcdef"} {
+        req, err := http.NewRequest(http.MethodGet, server.URL + "/api/status", nil)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        req.Header.Set("Authorization", header)
+
+        resp, err = http.DefaultClient.Do(req)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        assert.Equal(http.StatusUnauthorized, resp.StatusCode)
+        resp.Body.Close()
+    }
+
+    req, err := http.NewRequest(http.MethodGet, server.URL + "/api/status", nil)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    req.Header.Set("Authorization", "Bearer 0123456789abcdef")
+
+    // Ensure the JSON status is served with the token
+    resp, err = http.DefaultClient.Do(req)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(http.StatusOK, resp.StatusCode)
+    resp.Body.Close()
+}
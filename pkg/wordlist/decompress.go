@@ -0,0 +1,265 @@
+package wordlist
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
+)
+
+// Extensions of the compressed wordlist formats
+const (
+    ExtBzip2    = ".bz2"
+    ExtGzip     = ".gz"
+    ExtSevenZip = ".7z"
+    ExtZstd     = ".zst"
+)
+
+// Package level variables
+var Decompressors = map[string]Decompressor{  // Decompressors by file extension
+    ExtBzip2:    decompressBzip2,
+    ExtGzip:     decompressGzip,
+    ExtSevenZip: decompressSevenZip,
+    ExtZstd:     decompressZstd,
+}
+
+
+// Decompressor writes the decompressed contents of the compressed file to the writer
+type Decompressor func(filePath string, writer io.Writer) error
+
+
+// Decompresses a gzip file, including files of multiple concatenated gzip members.
+//
+// @Parameters
+// - filePath:  The path to the compressed file
+// - writer:  Where the decompressed contents are written
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func decompressGzip(filePath string, writer io.Writer) error {
+    file, err := os.Open(filePath)
+    if err != nil {
+        return err
+    }
+    // Close the file on local exit
+    defer file.Close()
+
+    reader, err := gzip.NewReader(file)
+    if err != nil {
+        return err
+    }
+    // Close the reader on local exit
+    defer reader.Close()
+
+    _, err = io.Copy(writer, reader)
+    return err
+}
+
+
+// Decompresses a bzip2 file.
+//
+// @Parameters
+// - filePath:  The path to the compressed file
+// - writer:  Where the decompressed contents are written
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func decompressBzip2(filePath string, writer io.Writer) error {
+    file, err := os.Open(filePath)
+    if err != nil {
+        return err
+    }
+    // Close the file on local exit
+    defer file.Close()
+
+    _, err = io.Copy(writer, bzip2.NewReader(file))
+    return err
+}
+
+
+// Decompresses a zstd file through the zstd command, which must be on the PATH.
+//
+// @Parameters
+// - filePath:  The path to the compressed file
+// - writer:  Where the decompressed contents are written
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func decompressZstd(filePath string, writer io.Writer) error {
+    return runDecompressor(writer, "zstd", "-d", "-c", "-q", "--", filePath)
+}
+
+
+// Decompresses a 7z archive through the 7z command, which must be on the PATH. Every
+// file in the archive is extracted to the writer one after the other.
+//
+// @Parameters
+// - filePath:  The path to the compressed archive
+// - writer:  Where the decompressed contents are written
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func decompressSevenZip(filePath string, writer io.Writer) error {
+    return runDecompressor(writer, "7z", "x", "-so", "-bd", "--", filePath)
+}
+
+
+// Runs an external decompression command with its output written to the writer.
+//
+// @Parameters
+// - writer:  Where the decompressed contents are written
+// - name:  The name of the command
+// - args:  The arguments of the command
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func runDecompressor(writer io.Writer, name string, args ...string) error {
+    // Ensure the command is installed before anything is written
+    _, err := exec.LookPath(name)
+    if err != nil {
+        return fmt.Errorf("%s is required on the PATH to decompress - %w", name, err)
+    }
+
+    var stderr bytes.Buffer
+    cmd := exec.Command(name, args...)
+    cmd.Stdout = writer
+    cmd.Stderr = &stderr
+
+    err = cmd.Run()
+    if err != nil {
+        return fmt.Errorf("%s failed - %w - %s", name, err, strings.TrimSpace(stderr.String()))
+    }
+
+    return nil
+}
+
+
+// Reports whether the file is a compressed wordlist based on its extension.
+//
+// @Parameters
+// - filePath:  The path to the file
+//
+// @Returns
+// - true if the file has the extension of a supported compression format
+//
+func IsCompressed(filePath string) bool {
+    _, exists := Decompressors[strings.ToLower(filepath.Ext(filePath))]
+    return exists
+}
+
+
+// Decompresses the wordlist next to itself under its name without the compression
+// extension, deleting the compressed file once the decompressed one is complete. The
+// output is written to a part file and renamed so an interrupted run never leaves a
+// truncated wordlist behind.
+//
+// @Parameters
+// - filePath:  The path to the compressed wordlist
+//
+// @Returns
+// - The path to the decompressed wordlist
+// - Error if it occurs, otherwise nil on success
+//
+func Decompress(filePath string) (string, error) {
+    extension := filepath.Ext(filePath)
+    decompressor, exists := Decompressors[strings.ToLower(extension)]
+    if !exists {
+        return "", fmt.Errorf("unsupported compression format for %s", filePath)
+    }
+
+    outPath := strings.TrimSuffix(filePath, extension)
+    // If a wordlist with the decompressed name already exists, do not overwrite it
+    if _, err := os.Stat(outPath); err == nil {
+        return "", fmt.Errorf("decompressed wordlist %s already exists", outPath)
+    } else if !errors.Is(err, os.ErrNotExist) {
+        return "", err
+    }
+
+    partPath := outPath + disk.PartSuffix
+    outFile, err := os.OpenFile(partPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+    if err != nil {
+        return "", err
+    }
+
+    err = decompressor(filePath, outFile)
+    // Flush the decompressed data to disk before it is renamed into place
+    if err == nil {
+        err = outFile.Sync()
+    }
+    closeErr := outFile.Close()
+    if err == nil {
+        err = closeErr
+    }
+    if err != nil {
+        os.Remove(partPath)
+        return "", fmt.Errorf("error decompressing %s - %w", filePath, err)
+    }
+
+    err = os.Rename(partPath, outPath)
+    if err != nil {
+        os.Remove(partPath)
+        return "", err
+    }
+
+    // Delete the compressed file now that its data is in place
+    err = os.Remove(filePath)
+    if err != nil {
+        return "", err
+    }
+
+    return outPath, nil
+}
+
+
+// Decompresses every compressed wordlist in the dir and its subdirs.
+//
+// @Parameters
+// - dirPath:  The path to the dir of wordlists
+//
+// @Returns
+// - The number of wordlists decompressed
+// - Error if it occurs, otherwise nil on success
+//
+func DecompressDir(dirPath string) (int, error) {
+    var compressed []string
+
+    // Collect the compressed wordlists first so the decompressed ones are not walked
+    err := filepath.WalkDir(dirPath, func(path string, entry os.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+
+        // If the item is a compressed file
+        if !entry.IsDir() && IsCompressed(path) {
+            compressed = append(compressed, path)
+        }
+
+        return nil
+    })
+    if err != nil {
+        return 0, err
+    }
+
+    // Iterate through the compressed wordlists decompressing each
+    for _, filePath := range compressed {
+        _, err = Decompress(filePath)
+        if err != nil {
+            return 0, err
+        }
+    }
+
+    return len(compressed), nil
+}
@@ -0,0 +1,84 @@
+package wordlist_test
+
+import (
+	"compress/gzip"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/wordlist"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestDecompressDir(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    dirPath := t.TempDir()
+    candidates := []byte("summer\n123456\nPassword1\n")
+
+    // Write a gzip file of two members to ensure every member is decompressed
+    gzipFile, err := os.Create(filepath.Join(dirPath, "rockyou.txt.gz"))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    for _, member := range [][]byte{candidates[:7], candidates[7:]} {
+        writer := gzip.NewWriter(gzipFile)
+        writer.Write(member)
+        writer.Close()
+    }
+    gzipFile.Close()
+
+    // If the zstd command is installed, include a zstd wordlist
+    zstdPath := filepath.Join(dirPath, "names.txt")
+    if _, err = exec.LookPath("zstd"); err == nil {
+        err = os.WriteFile(zstdPath, candidates, 0644)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        err = exec.Command("zstd", "-q", "--rm", zstdPath).Run()
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+    }
+
+    // Ensure uncompressed wordlists are left as is
+    err = os.WriteFile(filepath.Join(dirPath, "leaks.txt"), candidates, 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    count, err := wordlist.DecompressDir(dirPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Iterate through the decompressed and untouched wordlists
+    for _, name := range []string{"rockyou.txt", "leaks.txt"} {
+        contents, err := os.ReadFile(filepath.Join(dirPath, name))
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+        assert.Equal(candidates, contents)
+    }
+
+    _, err = os.Stat(filepath.Join(dirPath, "rockyou.txt.gz"))
+    // Ensure the compressed file was deleted
+    assert.True(os.IsNotExist(err))
+
+    if _, err = os.Stat(zstdPath); err == nil {
+        assert.Equal(2, count)
+    } else {
+        assert.Equal(1, count)
+    }
+
+    // Ensure corrupt data fails without leaving a partial wordlist
+    corruptPath := filepath.Join(dirPath, "corrupt.txt.gz")
+    err = os.WriteFile(corruptPath, []byte("not gzip data"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    _, err = wordlist.Decompress(corruptPath)
+    assert.NotEqual(nil, err)
+    _, err = os.Stat(filepath.Join(dirPath, "corrupt.txt"))
+    assert.True(os.IsNotExist(err))
+
+    // Ensure an existing wordlist is not overwritten
+    _, err = wordlist.Decompress(filepath.Join(dirPath, "leaks.txt.gz"))
+    assert.NotEqual(nil, err)
+}
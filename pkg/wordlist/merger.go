@@ -0,0 +1,507 @@
+package wordlist
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/data"
+	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
+)
+
+// How the catted wordlists of a merge are combined
+const (
+    StrategyConcat = "concat"  // Catted together as is, keeping any duplicate lines
+    StrategyDedupe = "dedupe"  // Catted together and run through duplicut
+)
+
+
+// MergeProgress is a snapshot of the merging of the wordlists in a dir
+type MergeProgress struct {
+    BytesMerged    int64          // Bytes of the original wordlists processed
+    BytesTotal     int64          // Bytes of the original wordlists in the dir
+    Eta            time.Duration  // Estimated time until merging completes, 0 when unknown
+    FilesProcessed int
+    FilesTotal     int
+}
+
+
+// MergeProgressFunc receives the periodic progress of the merge
+type MergeProgressFunc func(progress MergeProgress)
+
+
+// MergeReadyFunc receives each wordlist as soon as the merge will no longer change it
+type MergeReadyFunc func(filePath string)
+
+
+// Options configures a Merger, the zero value of the optional fields uses their default
+type Options struct {
+    MaxCutSize       int64              // Size over which dd shaves instead of split, 1GB if 0
+    MaxFileSize      int64              // Max size of a merged wordlist, required
+    MaxMergingSize   int64              // Size a wordlist is merged up to, MaxFileSize if 0
+    MaxRange         float64            // Percent range within the max that registers as full
+    OnProgress       MergeProgressFunc  // Receives the periodic progress, nil when unreported
+    OnReady          MergeReadyFunc     // Receives each wordlist once final, nil when unreported
+    ProgressInterval time.Duration      // Min time between progress reports, 1 second if 0
+    Scheduler        *disk.IoScheduler  // Lets higher priority IO preempt merging, nil if unused
+    Strategy         string             // How catted wordlists are combined, dedupe if empty
+    TempDir          string             // Dir the catted data is staged in, the merged dir if empty
+    Workers          int                // Threads duplicut runs with, its default if 0
+}
+
+
+// Merger merges the wordlists of a dir into files up to the max file size, the pipeline
+// is resumable since each step only deletes its input once its output is complete
+type Merger struct {
+    options Options
+}
+
+// Creates a new merger, validating the options and filling in the defaults.
+//
+// @Parameters
+// - options:  The options the wordlists are merged with
+//
+// @Returns
+// - The initialized merger
+// - Error if the options are improper, otherwise nil on success
+//
+func NewMerger(options Options) (*Merger, error) {
+    // If there is no max file size, no merged wordlist could be sized
+    if options.MaxFileSize <= 0 {
+        return nil, fmt.Errorf("merger max file size must be greater than 0")
+    }
+
+    // If the merging size is unset, wordlists are merged up to the max file size
+    if options.MaxMergingSize <= 0 {
+        options.MaxMergingSize = options.MaxFileSize
+    }
+
+    // If the cut size is unset, dd shaves files over 1GB
+    if options.MaxCutSize <= 0 {
+        options.MaxCutSize = int64(1 * globals.GB)
+    }
+
+    // If the progress interval is unset, report at most once a second
+    if options.ProgressInterval <= 0 {
+        options.ProgressInterval = time.Second
+    }
+
+    switch options.Strategy {
+    case "":
+        options.Strategy = StrategyDedupe
+    case StrategyConcat, StrategyDedupe:
+    default:
+        return nil, fmt.Errorf("unknown merge strategy %q", options.Strategy)
+    }
+
+    // If a temp dir is set, ensure it is a dir the catted data can be staged in
+    if options.TempDir != "" {
+        tempInfo, err := os.Stat(options.TempDir)
+        if err != nil {
+            return nil, fmt.Errorf("error checking merge temp dir - %w", err)
+        }
+
+        if !tempInfo.IsDir() {
+            return nil, fmt.Errorf("merge temp dir %s is not a dir", options.TempDir)
+        }
+    }
+
+    return &Merger{options: options}, nil
+}
+
+
+// Sums the sizes of the wordlists in the dir and its subdirs before merging, which the
+// merge progress is measured against.
+//
+// @Parameters
+// - dirPath:  The path to the directory where wordlist merging occurs
+//
+// @Returns
+// - The sizes of the wordlists keyed by their paths
+// - The progress with the totals set
+// - Error if it occurs, otherwise nil on success
+//
+func mergeTotals(dirPath string) (map[string]int64, MergeProgress, error) {
+    sizes := make(map[string]int64)
+    progress := MergeProgress{}
+
+    // Iterate through the wordlists adding their sizes to the totals
+    err := filepath.Walk(dirPath, func(path string, itemInfo os.FileInfo, err error) error {
+        if err != nil {
+            return err
+        }
+
+        // If the item is a dir, skip to next
+        if itemInfo.IsDir() {
+            return nil
+        }
+
+        sizes[path] = itemInfo.Size()
+        progress.BytesTotal += itemInfo.Size()
+        progress.FilesTotal += 1
+        return nil
+    })
+
+    return sizes, progress, err
+}
+
+
+// Sets up the cat files slice and out files map, then walks the dir merging each
+// wordlist until complete. The context is checked between wordlists and cancels the
+// running command, each step only deletes its input once its output is complete, so
+// merging a canceled dir again resumes from the wordlists merged so far.
+//
+// @Parameters
+// - ctx:  Stops the merge when canceled, such as on Ctrl-C
+// - dirPath:  The path to the directory where wordlist merging occurs
+//
+// @Returns
+// - Error if it occurs, wrapping the context error if canceled, otherwise nil on success
+//
+func (merger *Merger) Run(ctx context.Context, dirPath string) error {
+    options := merger.options
+    catFiles := []string{}
+    outFilesMap := make(map[string]struct{})
+    reported := make(map[string]struct{})
+
+    sizes, progress, err := mergeTotals(dirPath)
+    if err != nil {
+        return err
+    }
+
+    start := time.Now()
+    lastReport := start
+
+    // Iterate through the contents of the directory and any subdirectories, merging wordlists
+    err = filepath.Walk(dirPath, func(path string, itemInfo os.FileInfo, walkErr error) error {
+        // If the merge was canceled, stop before the next wordlist
+        if err := ctx.Err(); err != nil {
+            return err
+        }
+
+        // Wait until no higher priority disk IO, such as a transfer, is running
+        release, err := options.Scheduler.Acquire(ctx, disk.PriorityMerge)
+        if err != nil {
+            return err
+        }
+
+        err = merger.step(ctx, dirPath, &catFiles, outFilesMap, path, itemInfo, walkErr)
+        release()
+        if err != nil {
+            return err
+        }
+
+        // If ready wordlists are reported, pass on the ones finalized by this step
+        if options.OnReady != nil {
+            for outPath := range outFilesMap {
+                if _, exists := reported[outPath]; !exists {
+                    reported[outPath] = struct{}{}
+                    options.OnReady(outPath)
+                }
+            }
+        }
+
+        size, original := sizes[path]
+        // If the item is not one of the original wordlists, such as a merge output
+        if !original {
+            return nil
+        }
+
+        progress.BytesMerged += size
+        progress.FilesProcessed += 1
+
+        // If progress is reported and the interval passed
+        if options.OnProgress != nil && time.Since(lastReport) >= options.ProgressInterval {
+            lastReport = time.Now()
+            progress.Eta = mergeEta(progress, time.Since(start))
+            options.OnProgress(progress)
+        }
+
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+
+    // Report the completed merge
+    if options.OnProgress != nil {
+        progress.Eta = 0
+        options.OnProgress(progress)
+    }
+
+    return nil
+}
+
+
+// Estimates the time remaining in the merge from the rate of the bytes merged so far.
+//
+// @Parameters
+// - progress:  The current progress of the merge
+// - elapsed:  The duration of time the merge has run
+//
+// @Returns
+// - The estimated time remaining, 0 when unknown
+//
+func mergeEta(progress MergeProgress, elapsed time.Duration) time.Duration {
+    // If nothing was merged yet, there is no rate to estimate from
+    if progress.BytesMerged <= 0 {
+        return 0
+    }
+
+    remaining := float64(progress.BytesTotal - progress.BytesMerged)
+    eta := time.Duration(remaining / float64(progress.BytesMerged) * float64(elapsed))
+
+    return eta.Round(time.Second)
+}
+
+
+// Cats the queued files into a single wordlist combined by the merge strategy, deleting
+// the originals. With the dedupe strategy the catted data is staged in the temp dir and
+// run through duplicut into the merged dir, otherwise it is catted straight into it.
+//
+// @Parameters
+// - ctx:  Cancels the cat and duplicut commands, such as on Ctrl-C
+// - dirPath:  The path to the directory where wordlist merging occurs
+// - catFiles:  The slice of file paths to pass into CatAndDelete()
+//
+// @Returns
+// - The path to the combined wordlist
+// - The size of the combined wordlist
+// - Error if it occurs, otherwise nil on success
+//
+func (merger *Merger) combine(ctx context.Context, dirPath string,
+                              catFiles *[]string) (string, int64, error) {
+    options := merger.options
+
+    // If duplicates are kept, the catted file is the combined wordlist
+    if options.Strategy == StrategyConcat {
+        catPath, _, err := disk.CreateRandFile(dirPath, globals.RAND_STRING_SIZE,
+                                               "kloudkraken-data-", "txt", false)
+        if err != nil {
+            return "", -1, err
+        }
+
+        // Cat files in cat slice into result deleting originals
+        err = CatAndDelete(ctx, catFiles, catPath)
+        if err != nil {
+            return "", -1, err
+        }
+
+        catInfo, err := os.Stat(catPath)
+        if err != nil {
+            return "", -1, err
+        }
+
+        return catPath, catInfo.Size(), nil
+    }
+
+    tempDir := options.TempDir
+    // If no temp dir is set, stage the catted data in the merged dir
+    if tempDir == "" {
+        tempDir = dirPath
+    }
+
+    // Create random file for cat command output
+    catPath, _, err := disk.CreateRandFile(tempDir, globals.RAND_STRING_SIZE,
+                                           "kloudkraken-data-", "txt", false)
+    if err != nil {
+        return "", -1, err
+    }
+
+    // Cat files in cat slice into result deleting originals
+    err = CatAndDelete(ctx, catFiles, catPath)
+    if err != nil {
+        return "", -1, err
+    }
+
+    // Create a new file for filtered output
+    filterPath, _, err := disk.CreateRandFile(dirPath, globals.RAND_STRING_SIZE,
+                                              "kloudkraken-data-", "txt", false)
+    if err != nil {
+        return "", -1, err
+    }
+
+    // Pause before duplicut reads the catted data back while higher priority IO runs
+    err = options.Scheduler.Yield(ctx, disk.PriorityMerge)
+    if err != nil {
+        return "", -1, err
+    }
+
+    // Run the oversized file via duplicut to output file, deleting original file
+    filterSize, err := DuplicutAndDelete(ctx, catPath, filterPath, options.Workers)
+    if err != nil {
+        return "", -1, err
+    }
+
+    return filterPath, filterSize, nil
+}
+
+
+// Merges the current item of the walk, appending files to the cat list until
+// multiple are available, then combining them by the merge strategy while the
+// original files are deleted. If the resulting file size is equal to the max file
+// size OR is within the specified max range of the file size it will be added to a
+// map for managing completed files. If it is less than the bottom of the max range
+// it will be added back to the cat file list and re-iterate. If greater then
+// if will either use cut (small files) or dd (larger files) to shave the exess
+// data into a new file and save the original to the output files list.
+//
+// @Parameters
+// - ctx:  Cancels the cat and duplicut commands, such as on Ctrl-C
+// - dirPath:  The path to the directory where wordlist merging occurs
+// - catFiles:  The slice of file paths to pass into CatAndDelete()
+// - outFilesMap:  The map used to ensure only files that have not been
+//                 processed are selected
+// - path:  Path to the currently selected item in merge directory
+// - itemInfo:  The info of currently seleted item
+// - err:  Error if it occurs during walk, otherwise nil on success
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (merger *Merger) step(ctx context.Context, dirPath string, catFiles *[]string,
+                           outFilesMap map[string]struct{}, path string,
+                           itemInfo os.FileInfo, err error) error {
+    if err != nil {
+        return err
+    }
+
+    // If the item is a dir, skip to next
+    if itemInfo.IsDir() {
+        return nil
+    }
+
+    // If current file exists in the out files map, skip to next
+    _, exists := outFilesMap[path]
+    if exists {
+        return nil
+    }
+
+    options := merger.options
+    maxMergingSize := options.MaxMergingSize
+    maxFileSize := options.MaxFileSize
+    maxRange := options.MaxRange
+    var filterPath string
+    destFileSize := itemInfo.Size()
+
+    // If the file is within the max file size and exceeds or
+    // is within upper percentile of merging max size
+    if (destFileSize <= maxFileSize && destFileSize >= maxMergingSize) ||
+    data.IsInPercentRange(float64(maxMergingSize), float64(destFileSize), maxRange) {
+        // Add the resulting path to out files map
+        outFilesMap[path] = struct{}{}
+        return nil
+    }
+
+    // If the current file size is less than or is not within 15% of merging max size
+    if destFileSize < maxMergingSize &&
+    !data.IsInPercentRange(float64(maxMergingSize), float64(destFileSize), maxRange) {
+        // Append the current file path to cat files slice
+        *catFiles = append(*catFiles, path)
+
+        // If there is less than 2 files in the cat files slice, skip to next
+        if len(*catFiles) < 2 {
+            return nil
+        }
+
+        // Combine the files in the cat slice into one, deleting the originals
+        filterPath, destFileSize, err = merger.combine(ctx, dirPath, catFiles)
+        if err != nil {
+            return err
+        }
+
+        // If the size of the dest file is equal to max OR resides within the max range
+        if destFileSize == maxMergingSize || (destFileSize < maxMergingSize &&
+        data.IsInPercentRange(float64(maxMergingSize), float64(destFileSize), maxRange)) {
+            // Add the resulting path to out files map
+            outFilesMap[filterPath] = struct{}{}
+            return nil
+        // If the size of the dest file is less than max
+        } else if destFileSize < maxMergingSize {
+            // Add the output file to cat files list for further processing
+            *catFiles = append(*catFiles, filterPath)
+            return nil
+        }
+    } else {
+        filterPath = path
+    }
+
+    // Create a new file for file shaving process
+    shavePath, _, err := disk.CreateRandFile(dirPath, globals.RAND_STRING_SIZE,
+                                             "kloudkraken-data-", "txt", false)
+    if err != nil {
+        return err
+    }
+
+    // For file greater than threshold, dd is optimal for resource scalability
+    if destFileSize > options.MaxCutSize {
+        // Get the optimal block size for file shaving operation based on the file size
+        blockSize, err := GetOptimalBlockSize(destFileSize)
+        if err != nil {
+            return err
+        }
+
+        for {
+            // Create a new file for original file data after excess filtered
+            originalPath, _, err := disk.CreateRandFile(dirPath, globals.RAND_STRING_SIZE,
+                                                        "kloudkraken-data-", "txt", false)
+            if err != nil {
+                return err
+            }
+
+            // Shaves any data large than excess size into new file
+            shaveFileSize, err := FileShaveDD(filterPath, shavePath, originalPath,
+                                              blockSize, maxFileSize)
+            if err != nil {
+                return err
+            }
+
+            // Add the maxed out file to the out files map
+            outFilesMap[originalPath] = struct{}{}
+
+            // If the shaved file still exceeds max file size
+            if shaveFileSize > maxFileSize {
+                // Set result path as input and make new shave path for next iteration
+                filterPath = shavePath
+                shavePath, _, err = disk.CreateRandFile(dirPath, globals.RAND_STRING_SIZE,
+                                                        "kloudkraken-data-", "txt", false)
+                if err != nil {
+                    return err
+                }
+
+                // Reset the optimal block size based on size of result of first dd operation
+                blockSize, err = GetOptimalBlockSize(shaveFileSize)
+                if err != nil {
+                    return err
+                }
+
+                continue
+
+            // If the shaved file is within or above the max merging range
+            } else if (shaveFileSize >= maxMergingSize ||
+            data.IsInPercentRange(float64(maxMergingSize), float64(shaveFileSize), maxRange)) &&
+            shaveFileSize <= maxFileSize {
+                // Add the shaved file to the out files map
+                outFilesMap[shavePath] = struct{}{}
+                break
+            }
+
+            // Add the file with extra shaved data to cat files slice
+            *catFiles = append(*catFiles, shavePath)
+            break
+        }
+    // For files less than threshold, split is optimal parsing entries line by line
+    } else {
+        // Shaves any data large than excess size into new file
+        err = FileShaveSplit(filterPath, shavePath, maxFileSize,
+                             catFiles, outFilesMap)
+        if err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
@@ -0,0 +1,142 @@
+package wordlist_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/data"
+	"github.com/ngimb64/Kloud-Kraken/pkg/wordlist"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestNewMerger(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    // Ensure a merger can not be created without a max file size
+    _, err := wordlist.NewMerger(wordlist.Options{})
+    assert.NotEqual(nil, err)
+
+    // Ensure unknown strategies are rejected
+    _, err = wordlist.NewMerger(wordlist.Options{MaxFileSize: 1024, Strategy: "shuffle"})
+    assert.NotEqual(nil, err)
+
+    // Ensure a temp dir that does not exist is rejected
+    _, err = wordlist.NewMerger(wordlist.Options{MaxFileSize: 1024,
+                                                 TempDir: "/nonexistent/kloudkraken"})
+    assert.NotEqual(nil, err)
+
+    _, err = wordlist.NewMerger(wordlist.Options{MaxFileSize: 1024,
+                                                 Strategy: wordlist.StrategyConcat})
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+}
+
+
+func TestMergerRun(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    dirPath := "testdir"
+    // Create the test directory
+    err := os.Mkdir(dirPath, os.ModePerm)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Copy the directory with test wordlist data to test dir
+    err = os.CopyFS(dirPath, os.DirFS("../../testdata"))
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    maxMergingSize := int64(20 * globals.MB)
+    maxFileSize := int64(30 * globals.MB)
+
+    var reports []wordlist.MergeProgress
+    var readyPaths []string
+    merger, err := wordlist.NewMerger(wordlist.Options{
+        MaxFileSize:    maxFileSize,
+        MaxMergingSize: maxMergingSize,
+        MaxRange:       15.0,
+        OnProgress:     func(progress wordlist.MergeProgress) {
+            reports = append(reports, progress)
+        },
+        OnReady:        func(filePath string) {
+            readyPaths = append(readyPaths, filePath)
+        },
+        TempDir:        t.TempDir(),
+    })
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    canceled, cancel := context.WithCancel(context.Background())
+    cancel()
+    // Ensure a canceled merge stops without touching the wordlists
+    err = merger.Run(canceled, dirPath)
+    assert.True(errors.Is(err, context.Canceled))
+    assert.Equal(0, len(reports))
+
+    // Merge the created wordlists in the wordlist dir
+    err = merger.Run(context.Background(), dirPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the final report covers every original wordlist
+    final := reports[len(reports) - 1]
+    assert.Greater(final.FilesTotal, 0)
+    assert.Equal(final.FilesTotal, final.FilesProcessed)
+    assert.Equal(final.BytesTotal, final.BytesMerged)
+    assert.Equal(time.Duration(0), final.Eta)
+
+    dirItems, err := os.ReadDir(dirPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    fullFiles := []string{}
+    shaveFiles := []string{}
+
+    // Iterate through the items in the test dir
+    for _, item := range dirItems {
+        if item.IsDir() {
+            continue
+        }
+
+        // Set the path to current file
+        itemPath := dirPath + "/" + item.Name()
+
+        // Get the current file info
+        itemInfo, err := os.Stat(itemPath)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+
+        // Get the current file size and ensure it is less than max
+        fileSize := itemInfo.Size()
+        assert.Less(fileSize, maxFileSize)
+
+        // If the file is within 5 percent or equal to the max file size
+        if data.IsInPercentRange(float64(maxFileSize), float64(fileSize), 15.0) ||
+        fileSize == maxFileSize {
+            fullFiles = append(fullFiles, itemPath)
+        } else if (fileSize != 0) {
+            shaveFiles = append(shaveFiles, itemPath)
+        }
+    }
+
+    // Ensure there are 3 full files
+    assert.Equal(3, len(fullFiles))
+    // Ensure each full file was reported ready before the merge completed
+    for _, fullFile := range fullFiles {
+        assert.Contains(readyPaths, fullFile)
+    }
+    // Ensure there are two leftover files
+    assert.Equal(2, len(shaveFiles))
+
+    // Delete test directory and its contents after test
+    err = os.RemoveAll(dirPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+}
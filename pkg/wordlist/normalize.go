@@ -0,0 +1,372 @@
+package wordlist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Max candidate length in bytes hashcat accepts, longer lines can never be cracked
+const DefaultMaxLineLength = 256
+
+// Byte order marks the encoding of a wordlist is detected from
+var (
+    BomUtf8    = []byte{0xEF, 0xBB, 0xBF}
+    BomUtf16Be = []byte{0xFE, 0xFF}
+    BomUtf16Le = []byte{0xFF, 0xFE}
+)
+
+
+// Normalizer rewrites wordlists as LF terminated UTF-8, dropping the lines hashcat
+// could never match
+type Normalizer struct {
+    MaxLength int  // Max line length in bytes, longer lines are dropped
+}
+
+
+// NormalizeStats are the lines kept and dropped by the normalization pass
+type NormalizeStats struct {
+    Binary     int64  // Lines with control characters or invalid UTF-8
+    Files      int
+    Kept       int64
+    TooLong    int64  // Lines over the max length
+    Transcoded int    // Wordlists transcoded from UTF-16
+}
+
+
+// utf16Decoder transcodes a UTF-16 stream into UTF-8 as it is read
+type utf16Decoder struct {
+    order   binary.ByteOrder
+    pending []byte
+    reader  *bufio.Reader
+}
+
+
+// Creates a normalizer dropping lines over the max length.
+//
+// @Parameters
+// - maxLength:  The max line length in bytes, 0 uses the hashcat limit
+//
+// @Returns
+// - The initialized normalizer
+//
+func NewNormalizer(maxLength int) *Normalizer {
+    // If no max length is set, use the longest candidate hashcat accepts
+    if maxLength == 0 {
+        maxLength = DefaultMaxLineLength
+    }
+
+    return &Normalizer{MaxLength: maxLength}
+}
+
+
+// Reads UTF-8 transcoded from the UTF-16 stream, unpaired surrogates are replaced with
+// U+FFFD so the lines holding them are dropped as invalid.
+//
+// @Parameters
+// - buffer:  The buffer the UTF-8 is read into
+//
+// @Returns
+// - The number of bytes read
+// - Error if it occurs, io.EOF once the stream is consumed
+//
+func (decoder *utf16Decoder) Read(buffer []byte) (int, error) {
+    // Keep decoding units until there is UTF-8 to return
+    for len(decoder.pending) < len(buffer) {
+        unit, err := decoder.readUnit()
+        if err != nil {
+            // If there is decoded data left, return it before the error
+            if len(decoder.pending) > 0 {
+                break
+            }
+
+            return 0, err
+        }
+
+        char := rune(unit)
+        // If the unit is half of a surrogate pair
+        if utf16.IsSurrogate(char) {
+            char = decoder.decodePair(char)
+        }
+
+        decoder.pending = utf8.AppendRune(decoder.pending, char)
+    }
+
+    count := copy(buffer, decoder.pending)
+    decoder.pending = decoder.pending[count:]
+    return count, nil
+}
+
+
+// Combines the high surrogate with the low surrogate following it, the next unit is
+// left unread if it is not a low surrogate so a newline after a broken pair is kept.
+//
+// @Parameters
+// - high:  The surrogate unit that was read
+//
+// @Returns
+// - The combined rune, U+FFFD if the pair is broken
+//
+func (decoder *utf16Decoder) decodePair(high rune) rune {
+    // If the unit is a low surrogate without a high surrogate before it
+    if high >= 0xDC00 {
+        return utf8.RuneError
+    }
+
+    next, err := decoder.reader.Peek(2)
+    if err != nil {
+        return utf8.RuneError
+    }
+
+    char := utf16.DecodeRune(high, rune(decoder.order.Uint16(next)))
+    // If the next unit completed the pair, consume it
+    if char != utf8.RuneError {
+        decoder.reader.Discard(2)
+    }
+
+    return char
+}
+
+
+// Reads a single UTF-16 code unit in the byte order of the stream.
+//
+// @Returns
+// - The code unit
+// - Error if it occurs, io.EOF once the stream is consumed
+//
+func (decoder *utf16Decoder) readUnit() (uint16, error) {
+    var unit [2]byte
+
+    _, err := io.ReadFull(decoder.reader, unit[:])
+    // If a trailing odd byte is left, there is no unit to decode
+    if errors.Is(err, io.ErrUnexpectedEOF) {
+        return 0, io.EOF
+    }
+    if err != nil {
+        return 0, err
+    }
+
+    return decoder.order.Uint16(unit[:]), nil
+}
+
+
+// Detects whether the wordlist is UTF-16 from its byte order mark, or from the NUL
+// bytes ASCII characters leave in every other byte when there is no mark.
+//
+// @Parameters
+// - reader:  The reader at the start of the wordlist, the mark is consumed if found
+//
+// @Returns
+// - The byte order of the UTF-16 wordlist, nil if it is not UTF-16
+//
+func detectUtf16(reader *bufio.Reader) binary.ByteOrder {
+    sample, _ := reader.Peek(4096)
+
+    switch {
+    case bytes.HasPrefix(sample, BomUtf16Le):
+        reader.Discard(len(BomUtf16Le))
+        return binary.LittleEndian
+    case bytes.HasPrefix(sample, BomUtf16Be):
+        reader.Discard(len(BomUtf16Be))
+        return binary.BigEndian
+    // If the sample is too small to tell from NUL bytes
+    case len(sample) < 16:
+        return nil
+    }
+
+    var evenNuls, oddNuls int
+    // Iterate through the sample counting the NUL bytes at even and odd offsets
+    for index, char := range sample {
+        if char != 0 {
+            continue
+        }
+
+        if index % 2 == 0 {
+            evenNuls += 1
+        } else {
+            oddNuls += 1
+        }
+    }
+
+    half := len(sample) / 2
+
+    switch {
+    // If most of the high bytes are NUL and the low bytes are not, it is little endian
+    case oddNuls > half * 2 / 5 && evenNuls < half / 20:
+        return binary.LittleEndian
+    case evenNuls > half * 2 / 5 && oddNuls < half / 20:
+        return binary.BigEndian
+    default:
+        return nil
+    }
+}
+
+
+// Checks whether the line is junk, holding control characters or invalid UTF-8.
+//
+// @Parameters
+// - line:  The line with its line ending removed
+//
+// @Returns
+// - true/false depending on whether the line is binary junk
+//
+func isBinary(line []byte) bool {
+    // Iterate through the bytes of the line checking for control characters
+    for _, char := range line {
+        if (char < 0x20 && char != '\t') || char == 0x7F {
+            return true
+        }
+    }
+
+    return !utf8.Valid(line)
+}
+
+
+// Normalizes the wordlist in place with streaming IO, stripping byte order marks and
+// carriage returns, transcoding UTF-16 to UTF-8, and dropping empty, binary, and
+// overlong lines.
+//
+// @Parameters
+// - filePath:  The path to the wordlist
+// - stats:  The stats the kept and dropped lines are counted into
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (normalizer *Normalizer) NormalizeFile(filePath string, stats *NormalizeStats) error {
+    file, err := os.Open(filePath)
+    if err != nil {
+        return err
+    }
+    // Close the file on local exit
+    defer file.Close()
+
+    normalizedPath := filePath + ".normalized"
+    normalized, err := os.Create(normalizedPath)
+    if err != nil {
+        return err
+    }
+
+    var source io.Reader
+    buffered := bufio.NewReaderSize(file, 64 * 1024)
+
+    // If the wordlist is UTF-16, transcode it as it is read
+    if order := detectUtf16(buffered); order != nil {
+        source = &utf16Decoder{order: order, reader: buffered}
+        stats.Transcoded += 1
+    } else {
+        source = buffered
+    }
+
+    reader := bufio.NewReaderSize(source, 64 * 1024)
+    writer := bufio.NewWriter(normalized)
+    first := true
+
+    // Iterate through the lines of the wordlist writing the ones kept
+    for {
+        line, isPrefix, readErr := reader.ReadLine()
+        if readErr != nil {
+            // If the whole wordlist was read
+            if readErr == io.EOF {
+                break
+            }
+
+            err = readErr
+            break
+        }
+
+        // If the line does not fit the buffer, skip the rest of it since it is too long
+        if isPrefix {
+            for isPrefix && readErr == nil {
+                _, isPrefix, readErr = reader.ReadLine()
+            }
+
+            stats.TooLong += 1
+            continue
+        }
+
+        // If it is the first line, strip the UTF-8 byte order mark
+        if first {
+            line = bytes.TrimPrefix(line, BomUtf8)
+            first = false
+        }
+
+        line = bytes.TrimRight(line, "\r")
+
+        switch {
+        case len(line) == 0:
+            continue
+        case len(line) > normalizer.MaxLength:
+            stats.TooLong += 1
+            continue
+        case isBinary(line):
+            stats.Binary += 1
+            continue
+        }
+
+        stats.Kept += 1
+        writer.Write(line)
+        writer.WriteByte('\n')
+    }
+
+    // If reading succeeded, flush the kept lines
+    if err == nil {
+        err = writer.Flush()
+    }
+
+    closeErr := normalized.Close()
+    // If the wordlist was not normalized completely, keep the original
+    if err != nil || closeErr != nil {
+        os.Remove(normalizedPath)
+        return fmt.Errorf("error normalizing %s - %w", filePath, errors.Join(err, closeErr))
+    }
+
+    return os.Rename(normalizedPath, filePath)
+}
+
+
+// Normalizes every wordlist in the dir and its subdirs, so mixed line endings and
+// encodings from merged sources do not produce candidates that never match.
+//
+// @Parameters
+// - dirPath:  The path to the dir of wordlists
+//
+// @Returns
+// - The stats of the kept and dropped lines
+// - Error if it occurs, otherwise nil on success
+//
+func (normalizer *Normalizer) NormalizeDir(dirPath string) (*NormalizeStats, error) {
+    stats := &NormalizeStats{}
+
+    // Iterate through the wordlists normalizing each
+    err := filepath.WalkDir(dirPath, func(path string, entry os.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+
+        // If the item is a dir, skip to next
+        if entry.IsDir() {
+            return nil
+        }
+
+        err = normalizer.NormalizeFile(path, stats)
+        if err != nil {
+            return err
+        }
+
+        stats.Files += 1
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return stats, nil
+}
@@ -0,0 +1,80 @@
+package wordlist_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/wordlist"
+	"github.com/stretchr/testify/assert"
+)
+
+
+// Encodes the text as UTF-16 little endian with a byte order mark.
+//
+// @Parameters
+// - text:  The text to encode
+//
+// @Returns
+// - The UTF-16 encoded text
+//
+func encodeUtf16Le(text string) []byte {
+    encoded := append([]byte{}, wordlist.BomUtf16Le...)
+    // Iterate through the code units writing each low byte first
+    for _, unit := range utf16.Encode([]rune(text)) {
+        encoded = append(encoded, byte(unit), byte(unit >> 8))
+    }
+
+    return encoded
+}
+
+
+func TestNormalizeDir(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    dirPath := t.TempDir()
+    wordlists := map[string][]byte{
+        "crlf.txt":  []byte("\xEF\xBB\xBFpassword\r\nletmein\r\n\r\nsummer\r\n"),
+        "junk.txt":  []byte("hunter2\nbad\x00line\n\xFF\xFEinvalid\n" +
+                            strings.Repeat("a", 300) + "\ntab\tbed\n"),
+        "utf16.txt": encodeUtf16Le("Äpfel\r\n🔑key\r\nqwerty"),
+    }
+    for name, content := range wordlists {
+        assert.Equal(nil, os.WriteFile(filepath.Join(dirPath, name), content, 0644))
+    }
+
+    stats, err := wordlist.NewNormalizer(0).NormalizeDir(dirPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(3, stats.Files)
+    assert.Equal(1, stats.Transcoded)
+    assert.Equal(int64(8), stats.Kept)
+    assert.Equal(int64(2), stats.Binary)
+    assert.Equal(int64(1), stats.TooLong)
+
+    expected := map[string]string{
+        "crlf.txt":  "password\nletmein\nsummer\n",
+        "junk.txt":  "hunter2\ntab\tbed\n",
+        "utf16.txt": "Äpfel\n🔑key\nqwerty\n",
+    }
+    // Ensure each wordlist was rewritten as LF terminated UTF-8
+    for name, content := range expected {
+        data, err := os.ReadFile(filepath.Join(dirPath, name))
+        assert.Equal(nil, err)
+        assert.Equal(content, string(data))
+    }
+
+    // Ensure lines longer than the read buffer are dropped rather than split
+    longPath := filepath.Join(dirPath, "long.txt")
+    assert.Equal(nil, os.WriteFile(longPath,
+                                   []byte(strings.Repeat("b", 100000) + "\nshort\n"), 0644))
+    longStats := &wordlist.NormalizeStats{}
+    assert.Equal(nil, wordlist.NewNormalizer(0).NormalizeFile(longPath, longStats))
+    assert.Equal(int64(1), longStats.TooLong)
+    data, err := os.ReadFile(longPath)
+    assert.Equal(nil, err)
+    assert.Equal("short\n", string(data))
+}
@@ -0,0 +1,357 @@
+package wordlist
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/pkg/data"
+)
+
+// How the planned files of a merge are produced
+const (
+    PlanKept     = "kept"      // Already within the merging range, left as is
+    PlanMerged   = "merged"    // Catted together and run through duplicut
+    PlanSplit    = "split"     // Shaved off a file over the max file size
+    PlanUnmerged = "unmerged"  // Left over too small to merge with anything else
+)
+
+// Package level variables
+var PlanSampleSize = int64(64 * globals.MB)  // Bytes sampled across the dir for duplicates
+
+
+// PlannedFile is a wordlist the merge would produce
+type PlannedFile struct {
+    Kind    string   `json:"kind"`     // How the file is produced
+    Size    int64    `json:"size"`     // Estimated size in bytes
+    Sources []string `json:"sources"`  // Wordlists its data comes from, relative to the dir
+}
+
+// MergePlan is the layout the merge of a dir would produce, computed without writing
+type MergePlan struct {
+    Compressed     int           `json:"compressed"`       // Wordlists planned compressed
+    DuplicateRatio float64       `json:"duplicate_ratio"`  // Sampled ratio of duplicate lines
+    Files          []PlannedFile `json:"files"`
+    InputBytes     int64         `json:"input_bytes"`
+    InputFiles     int           `json:"input_files"`
+    OutputBytes    int64         `json:"output_bytes"`
+    RemovedBytes   int64         `json:"removed_bytes"`    // Estimated bytes duplicut removes
+}
+
+
+// planPiece is a file queued to be catted in the plan
+type planPiece struct {
+    raw     int64     // Bytes of the piece that were not run through duplicut yet
+    size    int64
+    sources []string
+}
+
+
+// Computes how Run would cat, deduplicate, and split the wordlists in the dir with the
+// same options, without writing anything. The sizes duplicut reduces merged files to
+// are estimated from the ratio of duplicate lines sampled from the start of each
+// wordlist.
+//
+// @Parameters
+// - dirPath:  The path to the directory where wordlist merging occurs
+//
+// @Returns
+// - The planned layout of the merged wordlists
+// - Error if it occurs, otherwise nil on success
+//
+func (merger *Merger) Plan(dirPath string) (*MergePlan, error) {
+    maxMergingSize := merger.options.MaxMergingSize
+    maxFileSize := merger.options.MaxFileSize
+    maxRange := merger.options.MaxRange
+    maxCutSize := merger.options.MaxCutSize
+    plan := &MergePlan{}
+    sizes := make(map[string]int64)
+    var paths []string
+
+    // Collect the wordlists in the order the merge walks them
+    err := filepath.Walk(dirPath, func(path string, itemInfo os.FileInfo, err error) error {
+        if err != nil {
+            return err
+        }
+
+        // If the item is a dir, skip to next
+        if itemInfo.IsDir() {
+            return nil
+        }
+
+        paths = append(paths, path)
+        sizes[path] = itemInfo.Size()
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    // If duplicates are removed, estimate how many from a sample
+    if merger.options.Strategy == StrategyDedupe {
+        plan.DuplicateRatio, err = sampleDuplicates(paths, PlanSampleSize)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    var catPieces []planPiece
+
+    // Adds a file to the plan output
+    output := func(kind string, size int64, sources []string) {
+        plan.Files = append(plan.Files, PlannedFile{Kind: kind, Size: size, Sources: sources})
+        plan.OutputBytes += size
+    }
+
+    // Checks whether the size is within the max range of the merging size
+    inRange := func(size int64) bool {
+        return data.IsInPercentRange(float64(maxMergingSize), float64(size), maxRange)
+    }
+
+    // Shaves the piece into max file size files like FileShaveDD and FileShaveSplit
+    shave := func(piece planPiece) {
+        // Data the shaved files were split from keeps the share not deduplicated yet
+        rawShare := float64(piece.raw) / float64(piece.size)
+
+        // For files greater than threshold, dd shaves one max file size file at a time
+        if piece.size > maxCutSize {
+            remaining := piece.size
+
+            for remaining > maxFileSize {
+                output(PlanSplit, maxFileSize, piece.sources)
+                remaining -= maxFileSize
+            }
+
+            // If the shaved file is within or above the max merging range
+            if remaining >= maxMergingSize || inRange(remaining) {
+                output(PlanSplit, remaining, piece.sources)
+                return
+            }
+
+            catPieces = append(catPieces, planPiece{raw: int64(float64(remaining) * rawShare),
+                                                    size: remaining, sources: piece.sources})
+            return
+        }
+
+        remaining := piece.size
+        // Split writes full max file size files until the remainder
+        for remaining >= maxFileSize {
+            output(PlanSplit, maxFileSize, piece.sources)
+            remaining -= maxFileSize
+        }
+
+        // If the remainder is within the top 5% of max file size meaning its full
+        if data.IsInPercentRange(float64(maxFileSize), float64(remaining), 5.0) {
+            output(PlanSplit, remaining, piece.sources)
+        } else if remaining > 0 {
+            catPieces = append(catPieces, planPiece{raw: int64(float64(remaining) * rawShare),
+                                                    size: remaining, sources: piece.sources})
+        }
+    }
+
+    // Iterate through the wordlists, following the decisions of the merge steps
+    for _, path := range paths {
+        size := sizes[path]
+        name, err := filepath.Rel(dirPath, path)
+        if err != nil {
+            name = path
+        }
+
+        plan.InputBytes += size
+        plan.InputFiles += 1
+        // If the wordlist is compressed, it is planned at its compressed size
+        if IsCompressed(path) {
+            plan.Compressed += 1
+        }
+
+        // If the file is within the max file size and exceeds or
+        // is within upper percentile of merging max size
+        if (size <= maxFileSize && size >= maxMergingSize) || inRange(size) {
+            output(PlanKept, size, []string{name})
+            continue
+        }
+
+        // If the file is over the max file size, it is shaved without merging
+        if size >= maxMergingSize {
+            shave(planPiece{raw: size, size: size, sources: []string{name}})
+            continue
+        }
+
+        catPieces = append(catPieces, planPiece{raw: size, size: size,
+                                                sources: []string{name}})
+        // If there is less than 2 files queued to cat, skip to next
+        if len(catPieces) < 2 {
+            continue
+        }
+
+        merged := planPiece{}
+        // Iterate through the queued pieces, catting them into one
+        for _, piece := range catPieces {
+            merged.size += piece.size
+            merged.raw += piece.raw
+            merged.sources = append(merged.sources, piece.sources...)
+        }
+        catPieces = nil
+
+        // Estimate the duplicates removed from the data not deduplicated before
+        removed := int64(float64(merged.raw) * plan.DuplicateRatio)
+        plan.RemovedBytes += removed
+        merged.size -= removed
+        merged.raw = 0
+
+        // If the size of the merged file is equal to max OR resides within the max range
+        if merged.size == maxMergingSize ||
+        (merged.size < maxMergingSize && inRange(merged.size)) {
+            output(PlanMerged, merged.size, merged.sources)
+        // If the size of the merged file is less than max, it is queued to cat again
+        } else if merged.size < maxMergingSize {
+            catPieces = append(catPieces, merged)
+        } else {
+            shave(merged)
+        }
+    }
+
+    // Iterate through any pieces left queued, which are sent as is
+    for _, piece := range catPieces {
+        output(PlanUnmerged, piece.size, piece.sources)
+    }
+
+    return plan, nil
+}
+
+
+// Estimates the ratio of duplicate lines across the wordlists by hashing the lines
+// in a sample from the start of each, the sample size split evenly between them.
+// Compressed wordlists are skipped since their bytes are not lines.
+//
+// @Parameters
+// - paths:  The paths of the wordlists to sample
+// - sampleSize:  The total number of bytes sampled across the wordlists
+//
+// @Returns
+// - The ratio of sampled lines that were duplicates, 0 when nothing was sampled
+// - Error if it occurs, otherwise nil on success
+//
+func sampleDuplicates(paths []string, sampleSize int64) (float64, error) {
+    // If there is nothing to sample
+    if len(paths) == 0 || sampleSize <= 0 {
+        return 0, nil
+    }
+
+    fileSample := sampleSize / int64(len(paths))
+    seen := make(map[uint64]struct{})
+    var lines, duplicates int64
+
+    // Iterate through the wordlists sampling their lines
+    for _, path := range paths {
+        if IsCompressed(path) {
+            continue
+        }
+
+        file, err := os.Open(path)
+        if err != nil {
+            return 0, err
+        }
+
+        scanner := bufio.NewScanner(io.LimitReader(file, fileSample))
+        // Iterate through the lines of the sample
+        for scanner.Scan() {
+            hasher := fnv.New64a()
+            hasher.Write(scanner.Bytes())
+            sum := hasher.Sum64()
+
+            lines += 1
+            // If the line was sampled before, duplicut would remove it
+            if _, exists := seen[sum]; exists {
+                duplicates += 1
+                continue
+            }
+
+            seen[sum] = struct{}{}
+        }
+
+        err = scanner.Err()
+        file.Close()
+        // If a line is longer than the scan buffer, the sample ends before it
+        if err != nil && !errors.Is(err, bufio.ErrTooLong) {
+            return 0, err
+        }
+    }
+
+    // If no lines were sampled
+    if lines == 0 {
+        return 0, nil
+    }
+
+    return float64(duplicates) / float64(lines), nil
+}
+
+
+// Formats the merge plan into a human-readable layout.
+//
+// @Returns
+// - The formatted plan
+//
+func (plan *MergePlan) Format() string {
+    var output strings.Builder
+
+    output.WriteString(fmt.Sprintf("Merge plan - %d wordlists (%.2f MB) into %d files " +
+                                   "(%.2f MB)\n", plan.InputFiles,
+                                   float64(plan.InputBytes) / float64(globals.MB),
+                                   len(plan.Files),
+                                   float64(plan.OutputBytes) / float64(globals.MB)))
+    output.WriteString(fmt.Sprintf("Duplicates:  %.1f%% of sampled lines, %.2f MB " +
+                                   "estimated to be removed by duplicut\n",
+                                   plan.DuplicateRatio * 100,
+                                   float64(plan.RemovedBytes) / float64(globals.MB)))
+
+    // If compressed wordlists are present, their decompressed sizes are unknown
+    if plan.Compressed > 0 {
+        output.WriteString(fmt.Sprintf("Note:  %d compressed wordlists are planned at " +
+                                       "their compressed size\n", plan.Compressed))
+    }
+
+    output.WriteString("\n")
+    // Iterate through the planned files
+    for index, file := range plan.Files {
+        sources := file.Sources
+        more := ""
+        // If the file comes from many wordlists, only the first few are listed
+        if len(sources) > 3 {
+            more = fmt.Sprintf(", +%d more", len(sources) - 3)
+            sources = sources[:3]
+        }
+
+        output.WriteString(fmt.Sprintf("%4d. %-8s %10.2f MB  %s%s\n", index + 1, file.Kind,
+                                       float64(file.Size) / float64(globals.MB),
+                                       strings.Join(sources, ", "), more))
+    }
+
+    return output.String()
+}
+
+
+// Writes the merge plan as JSON to the passed in path.
+//
+// @Parameters
+// - planPath:  The path where the JSON plan is written
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (plan *MergePlan) WriteJson(planPath string) error {
+    // Encode the plan into indented JSON
+    planJson, err := json.MarshalIndent(plan, "", "  ")
+    if err != nil {
+        return err
+    }
+
+    return os.WriteFile(planPath, append(planJson, '\n'), 0644)
+}
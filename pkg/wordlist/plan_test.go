@@ -0,0 +1,74 @@
+package wordlist_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/wordlist"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestMergerPlan(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+    testDir := t.TempDir()
+
+    // Writes a wordlist of 10 byte lines numbered from start up to end
+    writeWordlist := func(name string, start int, end int) {
+        var lines strings.Builder
+        for number := start; number < end; number++ {
+            lines.WriteString(fmt.Sprintf("word%05d\n", number))
+        }
+
+        err := os.WriteFile(filepath.Join(testDir, name), []byte(lines.String()), 0644)
+        // Ensure the error is nil meaning successful operation
+        assert.Equal(nil, err)
+    }
+
+    writeWordlist("a.txt", 0, 120)
+    writeWordlist("b.txt", 1000, 1040)
+    writeWordlist("c.txt", 1000, 1040)
+    writeWordlist("d.txt", 2000, 2350)
+
+    merger, err := wordlist.NewMerger(wordlist.Options{MaxFileSize: 1500,
+                                                       MaxMergingSize: 1000, MaxRange: 15.0})
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    plan, err := merger.Plan(testDir)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the lines of c.txt repeating b.txt are sampled as duplicates
+    assert.InDelta(40.0 / 550.0, plan.DuplicateRatio, 0.0001)
+    removed := int64(800 * plan.DuplicateRatio)
+    assert.Equal(removed, plan.RemovedBytes)
+
+    expected := []wordlist.PlannedFile{
+        {Kind: wordlist.PlanKept, Size: 1200, Sources: []string{"a.txt"}},
+        {Kind: wordlist.PlanSplit, Size: 1500, Sources: []string{"d.txt"}},
+        {Kind: wordlist.PlanSplit, Size: 1500, Sources: []string{"d.txt"}},
+        {Kind: wordlist.PlanUnmerged, Size: 800 - removed, Sources: []string{"b.txt", "c.txt"}},
+        {Kind: wordlist.PlanUnmerged, Size: 500, Sources: []string{"d.txt"}},
+    }
+    // Ensure the layout follows the decisions of the merge
+    assert.Equal(expected, plan.Files)
+    assert.Equal(4, plan.InputFiles)
+    assert.Equal(int64(5500), plan.InputBytes)
+    assert.Equal(5500 - removed, plan.OutputBytes)
+    assert.True(strings.HasPrefix(plan.Format(), "Merge plan - 4 wordlists"))
+
+    dirItems, err := os.ReadDir(testDir)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure planning did not write to the dir
+    assert.Equal(4, len(dirItems))
+
+    planPath := filepath.Join(t.TempDir(), "merge_plan.json")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, plan.WriteJson(planPath))
+}
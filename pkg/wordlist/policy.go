@@ -0,0 +1,279 @@
+package wordlist
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Character classes a password policy can require, in the order they are counted
+var PolicyClasses = []string{ClassLower, ClassUpper, ClassDigits, ClassSpecial}
+
+
+// Policy is the password policy of the target, candidates that could not be a password
+// under it are dropped before the wordlists are merged and distributed
+type Policy struct {
+    MaxLength  int             // Max candidate length in characters, 0 is unlimited
+    MinClasses int             // Min number of the character classes used
+    MinLength  int             // Min candidate length in characters
+    Pattern    *regexp.Regexp  // Expression the candidates must match, nil matches all
+    Require    []string        // Character classes every candidate must use
+}
+
+
+// PolicyStats are the candidates kept and dropped by the password policy filter
+type PolicyStats struct {
+    Dropped int64
+    Files   int
+    Kept    int64
+}
+
+
+// Parses the password policy from its description and regex. The description is a
+// space separated list of rules:
+//
+//   min_length=N       Candidates have at least N characters
+//   max_length=N       Candidates have at most N characters
+//   min_classes=N      Candidates use at least N of the lower, upper, digits, and special
+//                      character classes
+//   require=a+b        Candidates use every listed class (ex: require=upper+digits)
+//
+// @Parameters
+// - description:  The rules of the policy, empty for none
+// - pattern:  The expression the candidates must match, empty for none
+//
+// @Returns
+// - The parsed policy, nil if neither the description or pattern are set
+// - Error if it occurs, otherwise nil on success
+//
+func ParsePolicy(description string, pattern string) (*Policy, error) {
+    // If there is no policy to filter by
+    if strings.TrimSpace(description) == "" && pattern == "" {
+        return nil, nil
+    }
+
+    policy := &Policy{}
+
+    // Iterate through the rules of the description
+    for _, rule := range strings.Fields(description) {
+        key, value, found := strings.Cut(rule, "=")
+        // If the rule is not a key value pair
+        if !found || value == "" {
+            return nil, fmt.Errorf("invalid password policy rule - %q", rule)
+        }
+
+        // If the rule is the required classes
+        if key == "require" {
+            for _, class := range strings.Split(value, "+") {
+                if !slices.Contains(PolicyClasses, class) {
+                    return nil, fmt.Errorf("unknown password policy class - %q", class)
+                }
+
+                policy.Require = append(policy.Require, class)
+            }
+
+            continue
+        }
+
+        number, err := strconv.Atoi(value)
+        // If the value is not a positive number
+        if err != nil || number < 1 {
+            return nil, fmt.Errorf("password policy %s must be a positive number", key)
+        }
+
+        switch key {
+        case "min_length":
+            policy.MinLength = number
+        case "max_length":
+            policy.MaxLength = number
+        case "min_classes":
+            // If more classes are required than exist
+            if number > len(PolicyClasses) {
+                return nil, fmt.Errorf("password policy min_classes can be at most %d",
+                                       len(PolicyClasses))
+            }
+
+            policy.MinClasses = number
+        default:
+            return nil, fmt.Errorf("unknown password policy rule - %q", key)
+        }
+    }
+
+    // If the length bounds exclude every candidate
+    if policy.MaxLength > 0 && policy.MinLength > policy.MaxLength {
+        return nil, fmt.Errorf("password policy min_length is above max_length")
+    }
+
+    // If there is an expression the candidates must match
+    if pattern != "" {
+        compiled, err := regexp.Compile(pattern)
+        if err != nil {
+            return nil, fmt.Errorf("invalid password policy regex - %w", err)
+        }
+
+        policy.Pattern = compiled
+    }
+
+    return policy, nil
+}
+
+
+// Checks whether the candidate could be a password under the policy.
+//
+// @Parameters
+// - candidate:  The password candidate
+//
+// @Returns
+// - true/false depending on whether the candidate satisfies the policy
+//
+func (policy *Policy) Allows(candidate string) bool {
+    length := utf8.RuneCountInString(candidate)
+    // If the candidate is outside the length bounds
+    if length < policy.MinLength || (policy.MaxLength > 0 && length > policy.MaxLength) {
+        return false
+    }
+
+    // If the classes used by the candidate are checked
+    if policy.MinClasses > 0 || len(policy.Require) > 0 {
+        used := map[string]bool{}
+        // Iterate through the runes of the candidate marking the classes used
+        for _, char := range candidate {
+            switch {
+            case unicode.IsLower(char):
+                used[ClassLower] = true
+            case unicode.IsUpper(char):
+                used[ClassUpper] = true
+            case unicode.IsDigit(char):
+                used[ClassDigits] = true
+            default:
+                used[ClassSpecial] = true
+            }
+        }
+
+        // If the candidate uses too few classes
+        if len(used) < policy.MinClasses {
+            return false
+        }
+
+        // Iterate through the required classes ensuring each is used
+        for _, class := range policy.Require {
+            if !used[class] {
+                return false
+            }
+        }
+    }
+
+    // If the candidate does not match the policy expression
+    if policy.Pattern != nil && !policy.Pattern.MatchString(candidate) {
+        return false
+    }
+
+    return true
+}
+
+
+// Drops the candidates of the wordlist the policy does not allow, rewriting it in place.
+//
+// @Parameters
+// - filePath:  The path to the wordlist
+// - stats:  The stats the kept and dropped candidates are counted into
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (policy *Policy) FilterFile(filePath string, stats *PolicyStats) error {
+    file, err := os.Open(filePath)
+    if err != nil {
+        return err
+    }
+    // Close the file on local exit
+    defer file.Close()
+
+    filteredPath := filePath + ".filtered"
+    filtered, err := os.Create(filteredPath)
+    if err != nil {
+        return err
+    }
+
+    writer := bufio.NewWriter(filtered)
+    scanner := bufio.NewScanner(file)
+    // Allow long candidates rather than failing on them
+    scanner.Buffer(make([]byte, 64 * 1024), 1024 * 1024)
+
+    // Iterate through the candidates writing the allowed ones
+    for scanner.Scan() {
+        candidate := strings.TrimSuffix(scanner.Text(), "\r")
+        // If the line is empty or could not be a password under the policy
+        if candidate == "" || !policy.Allows(candidate) {
+            stats.Dropped += 1
+            continue
+        }
+
+        stats.Kept += 1
+        writer.WriteString(candidate)
+        writer.WriteByte('\n')
+    }
+
+    err = scanner.Err()
+    // If reading succeeded, flush the allowed candidates
+    if err == nil {
+        err = writer.Flush()
+    }
+
+    closeErr := filtered.Close()
+    // If the wordlist was not filtered completely, keep the original
+    if err != nil || closeErr != nil {
+        os.Remove(filteredPath)
+        return fmt.Errorf("error filtering %s - %w", filePath, errors.Join(err, closeErr))
+    }
+
+    return os.Rename(filteredPath, filePath)
+}
+
+
+// Filters every wordlist in the dir and its subdirs by the policy, so candidates that
+// could not be the password are never merged, transferred, or run through hashcat.
+//
+// @Parameters
+// - dirPath:  The path to the dir of wordlists
+//
+// @Returns
+// - The stats of the kept and dropped candidates
+// - Error if it occurs, otherwise nil on success
+//
+func (policy *Policy) FilterDir(dirPath string) (*PolicyStats, error) {
+    stats := &PolicyStats{}
+
+    // Iterate through the wordlists filtering each
+    err := filepath.WalkDir(dirPath, func(path string, entry os.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+
+        // If the item is a dir, skip to next
+        if entry.IsDir() {
+            return nil
+        }
+
+        err = policy.FilterFile(path, stats)
+        if err != nil {
+            return err
+        }
+
+        stats.Files += 1
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return stats, nil
+}
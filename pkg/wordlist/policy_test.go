@@ -0,0 +1,83 @@
+package wordlist_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/wordlist"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestParsePolicy(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    policy, err := wordlist.ParsePolicy("", "")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Nil(policy)
+
+    policy, err = wordlist.ParsePolicy("min_length=8 max_length=16 min_classes=3 " +
+                                       "require=upper+digits", `^[^ ]+$`)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(8, policy.MinLength)
+    assert.Equal(16, policy.MaxLength)
+    assert.Equal(3, policy.MinClasses)
+    assert.Equal([]string{wordlist.ClassUpper, wordlist.ClassDigits}, policy.Require)
+
+    truths := []string{"Password1", "Summer2024!", "Äpfelbaum9"}
+    // Iterate through slice of truths and test them
+    for _, truth := range truths {
+        assert.True(policy.Allows(truth))
+    }
+
+    falacies := []string{"Pass1", "password1", "PASSWORD!", "Password 1",
+                         "Password1Password1"}
+    // Iterate through slice of falacies and test them
+    for _, falacy := range falacies {
+        assert.False(policy.Allows(falacy))
+    }
+
+    invalids := [][]string{
+        {"min_length", ""}, {"min_length=0", ""}, {"min_classes=5", ""},
+        {"require=emoji", ""}, {"max_age=90", ""}, {"min_length=9 max_length=8", ""},
+        {"", "[unclosed"},
+    }
+    // Iterate through the invalid policies and ensure they are rejected
+    for _, invalid := range invalids {
+        _, err = wordlist.ParsePolicy(invalid[0], invalid[1])
+        assert.NotEqual(nil, err)
+    }
+}
+
+
+func TestPolicyFilterDir(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    dirPath := t.TempDir()
+    filePath := filepath.Join(dirPath, "wordlist.txt")
+    err := os.WriteFile(filePath, []byte("summer\nSummer2024\n123456\n\nWinter2023\r\n"),
+                        0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    policy, err := wordlist.ParsePolicy("min_length=8 require=upper+digits", "")
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    stats, err := policy.FilterDir(dirPath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal(1, stats.Files)
+    assert.Equal(int64(2), stats.Kept)
+    assert.Equal(int64(3), stats.Dropped)
+
+    filtered, err := os.ReadFile(filePath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    assert.Equal("Summer2024\nWinter2023\n", string(filtered))
+}
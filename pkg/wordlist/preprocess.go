@@ -0,0 +1,264 @@
+package wordlist
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Preprocessing stages run over the load dir wordlists before merging
+const (
+    StageFrequencySort = "frequency_sort"
+    StageStats         = "stats"
+)
+
+// Character classes the candidates are counted by
+const (
+    ClassDigits  = "digits"
+    ClassLower   = "lower"
+    ClassMixed   = "mixed"
+    ClassSpecial = "special"
+    ClassUpper   = "upper"
+)
+
+// Package level variables
+var Stages = map[string]Stage{  // Preprocessing stages by name
+    StageFrequencySort: FrequencySort,
+    StageStats:         CollectStats,
+}
+
+
+// Stage preprocesses a single wordlist in place, recording into the candidate stats
+type Stage func(filePath string, stats *CandidateStats) error
+
+// CandidateStats are the statistics of the password candidates in the load dir
+type CandidateStats struct {
+    Classes map[string]int64 `json:"classes"`  // Candidates by character class
+    Files   int              `json:"files"`    // Wordlists the stats were collected from
+    Lengths map[int]int64    `json:"lengths"`  // Candidates by length in bytes
+    Lines   int64            `json:"lines"`    // Candidates including duplicates
+}
+
+
+// Gets the character class of the candidate, candidates using more than one of the
+// lower, upper, and digit classes without special characters are mixed.
+//
+// @Parameters
+// - candidate:  The password candidate
+//
+// @Returns
+// - The character class of the candidate
+//
+func candidateClass(candidate string) string {
+    var lower, upper, digits bool
+
+    // Iterate through the runes of the candidate marking the classes used
+    for _, char := range candidate {
+        switch {
+        case unicode.IsLower(char):
+            lower = true
+        case unicode.IsUpper(char):
+            upper = true
+        case unicode.IsDigit(char):
+            digits = true
+        default:
+            return ClassSpecial
+        }
+    }
+
+    switch {
+    case lower && !upper && !digits:
+        return ClassLower
+    case upper && !lower && !digits:
+        return ClassUpper
+    case digits && !lower && !upper:
+        return ClassDigits
+    default:
+        return ClassMixed
+    }
+}
+
+
+// Collects the length and character class statistics of the candidates in the wordlist.
+//
+// @Parameters
+// - filePath:  The path to the wordlist
+// - stats:  The stats the candidates are counted into
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func CollectStats(filePath string, stats *CandidateStats) error {
+    file, err := os.Open(filePath)
+    if err != nil {
+        return err
+    }
+    // Close the file on local exit
+    defer file.Close()
+
+    scanner := bufio.NewScanner(file)
+    // Allow long candidates rather than failing on them
+    scanner.Buffer(make([]byte, 64 * 1024), 1024 * 1024)
+
+    // Iterate through the candidates counting each
+    for scanner.Scan() {
+        candidate := strings.TrimSuffix(scanner.Text(), "\r")
+        // If the line is empty
+        if candidate == "" {
+            continue
+        }
+
+        stats.Lines += 1
+        stats.Lengths[len(candidate)] += 1
+        stats.Classes[candidateClass(candidate)] += 1
+    }
+
+    return scanner.Err()
+}
+
+
+// Sorts the wordlist by how often each candidate occurs, most frequent first, collapsing
+// the duplicates. Runs through sort and uniq so wordlists larger than memory are sorted
+// with temporary files.
+//
+// @Parameters
+// - filePath:  The path to the wordlist
+// - stats:  Unused, frequency sorting records no stats
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func FrequencySort(filePath string, _ *CandidateStats) error {
+    sortedPath := filePath + ".sorted"
+
+    // If the shell pipeline can not run on this platform, such as on Windows
+    _, err := exec.LookPath("sh")
+    if err != nil {
+        return fmt.Errorf("frequency sorting requires sh, sort, uniq and sed - %w", err)
+    }
+
+    // Count each candidate, order by the counts, then strip the counts leaving the
+    // candidates, with byte ordering so any encoding is sorted the same way
+    cmd := exec.Command("sh", "-c", `sort "$1" | uniq -c | sort -k1,1nr -s | ` +
+                        `sed 's/^ *[0-9]* //' > "$2"`, "sh", filePath, sortedPath)
+    cmd.Env = append(os.Environ(), "LC_ALL=C")
+
+    output, err := cmd.CombinedOutput()
+    if err != nil {
+        os.Remove(sortedPath)
+        return fmt.Errorf("error frequency sorting %s - %w - %s", filePath, err, output)
+    }
+
+    return os.Rename(sortedPath, filePath)
+}
+
+
+// Runs the preprocessing stages in order over every wordlist in the dir and its subdirs.
+//
+// @Parameters
+// - dirPath:  The path to the dir of wordlists
+// - stageNames:  The names of the stages to run in order
+//
+// @Returns
+// - The stats of the candidates collected by the stats stage
+// - Error if it occurs, otherwise nil on success
+//
+func Preprocess(dirPath string, stageNames []string) (*CandidateStats, error) {
+    stats := &CandidateStats{
+        Classes: map[string]int64{},
+        Lengths: map[int]int64{},
+    }
+
+    // Ensure the stages exist before any wordlist is modified
+    for _, name := range stageNames {
+        if _, ok := Stages[name]; !ok {
+            return nil, fmt.Errorf("unknown preprocessing stage - %q", name)
+        }
+    }
+
+    // Iterate through the wordlists running each stage over them
+    err := filepath.WalkDir(dirPath, func(path string, entry os.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+
+        // If the item is a dir, skip to next
+        if entry.IsDir() {
+            return nil
+        }
+
+        for _, name := range stageNames {
+            err = Stages[name](path, stats)
+            if err != nil {
+                return fmt.Errorf("%s stage failed - %w", name, err)
+            }
+        }
+
+        stats.Files += 1
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return stats, nil
+}
+
+
+// Formats the candidate stats into a human-readable summary.
+//
+// @Returns
+// - The formatted stats
+//
+func (stats *CandidateStats) Format() string {
+    var output strings.Builder
+
+    output.WriteString(fmt.Sprintf("Candidates:  %d in %d wordlists\n", stats.Lines,
+                                   stats.Files))
+
+    lengths := make([]int, 0, len(stats.Lengths))
+    for length := range stats.Lengths {
+        lengths = append(lengths, length)
+    }
+    slices.Sort(lengths)
+
+    output.WriteString("Lengths:\n")
+    // Iterate through the lengths in order
+    for _, length := range lengths {
+        output.WriteString(fmt.Sprintf("  %3d:  %d\n", length, stats.Lengths[length]))
+    }
+
+    output.WriteString("Classes:\n")
+    // Iterate through the classes in order
+    for _, class := range []string{ClassLower, ClassUpper, ClassDigits, ClassMixed,
+                                   ClassSpecial} {
+        output.WriteString(fmt.Sprintf("  %-8s %d\n", class + ":", stats.Classes[class]))
+    }
+
+    return output.String()
+}
+
+
+// Writes the candidate stats as JSON to the passed in path.
+//
+// @Parameters
+// - statsPath:  The path where the JSON stats are written
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func (stats *CandidateStats) WriteJson(statsPath string) error {
+    // Encode the stats into indented JSON
+    statsJson, err := json.MarshalIndent(stats, "", "  ")
+    if err != nil {
+        return err
+    }
+
+    return os.WriteFile(statsPath, append(statsJson, '\n'), 0644)
+}
@@ -0,0 +1,48 @@
+package wordlist_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ngimb64/Kloud-Kraken/pkg/wordlist"
+	"github.com/stretchr/testify/assert"
+)
+
+
+func TestPreprocess(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    dirPath := t.TempDir()
+    filePath := filepath.Join(dirPath, "wordlist.txt")
+    err := os.WriteFile(filePath, []byte("summer\n123456\nPassword1\n123456\nsummer\n" +
+                                         "123456\nABC\np@ss\n"), 0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    stats, err := wordlist.Preprocess(dirPath, []string{wordlist.StageStats,
+                                                        wordlist.StageFrequencySort})
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    // Ensure the stats count the candidates before they are collapsed
+    assert.Equal(1, stats.Files)
+    assert.Equal(int64(8), stats.Lines)
+    assert.Equal(int64(5), stats.Lengths[6])
+    assert.Equal(int64(2), stats.Classes[wordlist.ClassLower])
+    assert.Equal(int64(3), stats.Classes[wordlist.ClassDigits])
+    assert.Equal(int64(1), stats.Classes[wordlist.ClassUpper])
+    assert.Equal(int64(1), stats.Classes[wordlist.ClassMixed])
+    assert.Equal(int64(1), stats.Classes[wordlist.ClassSpecial])
+
+    sorted, err := os.ReadFile(filePath)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+    // Ensure the candidates are collapsed with the most frequent first
+    assert.Equal("123456\nsummer\nABC\nPassword1\np@ss\n", string(sorted))
+
+    // Ensure unknown stages are rejected before anything is modified
+    _, err = wordlist.Preprocess(dirPath, []string{"markov"})
+    assert.NotEqual(nil, err)
+}
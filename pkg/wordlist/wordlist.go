@@ -1,43 +1,122 @@
 package wordlist
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"hash/maphash"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strconv"
 
 	"github.com/ngimb64/Kloud-Kraken/internal/globals"
 	"github.com/ngimb64/Kloud-Kraken/pkg/data"
-	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
 )
 
-// Performs the Linux cat command on a slice of files to the passed in
-// output path. Prior to executing the command the original source file
-// is deleted and the cat file slice is reset for the next execution.
+// Package level variables
+var DuplicutPath = filepath.Join("..", "..", "duplicut", "duplicut")  // Path of the duplicut binary
+
+// Size of the buffers used when streaming wordlists
+const streamBufferSize = 1 * globals.MB
+
+
+// Copies the reader to the writer a buffer at a time, stopping once the context is done.
+//
+// @Parameters
+// - ctx:  Cancels the copy, such as on Ctrl-C
+// - writer:  The destination of the data
+// - reader:  The source of the data
+// - buffer:  The buffer the data is read into
+//
+// @Returns
+// - Error if it occurs or the context is done, otherwise nil on success
+//
+func copyContext(ctx context.Context, writer io.Writer, reader io.Reader, buffer []byte) error {
+    for {
+        // If the copy was cancelled
+        if ctx.Err() != nil {
+            return ctx.Err()
+        }
+
+        bytesRead, err := reader.Read(buffer)
+        if bytesRead > 0 {
+            _, writeErr := writer.Write(buffer[:bytesRead])
+            if writeErr != nil {
+                return writeErr
+            }
+        }
+
+        // If the end of the reader was hit
+        if errors.Is(err, io.EOF) {
+            return nil
+        } else if err != nil {
+            return err
+        }
+    }
+}
+
+
+// Concatenates the passed in files into the output file in order.
 //
 // @Parameters
-// - catFiles:  Slice of the file paths of files to be concatenated via cat
-// - catPath:  The path to the resulting output file of the cat command
+// - ctx:  Cancels the concatenation, such as on Ctrl-C
+// - filePaths:  The paths of the files to concatenate
+// - outPath:  The path to the resulting output file
 //
 // @Returns
 // - Error if it occurs, otherwise nil on success
 //
-func CatAndDelete(catFiles *[]string, catPath string) error {
-    catCmd := "cat"
-    // Iterate through the file path and apppend them
-    for _, file := range *catFiles {
-        catCmd += " " + file
+func concatFiles(ctx context.Context, filePaths []string, outPath string) error {
+    outFile, err := os.Create(outPath)
+    if err != nil {
+        return err
     }
+    // Close the output file on local exit
+    defer outFile.Close()
 
-    // Append the rest of the command args
-    catCmd += " 2>/dev/null > " + catPath
+    buffer := make([]byte, streamBufferSize)
 
-    // Format the unique merging command with current file to output file
-    cmd := exec.Command("sh", "-c", catCmd)
-    // Execute the command and wait until it is complete
-    err := cmd.Run()
+    // Iterate through the files appending each to the output
+    for _, filePath := range filePaths {
+        file, err := os.Open(filePath)
+        if err != nil {
+            return err
+        }
+
+        err = copyContext(ctx, outFile, file, buffer)
+        file.Close()
+        if err != nil {
+            return err
+        }
+    }
+
+    return outFile.Close()
+}
+
+
+// Concatenates a slice of files to the passed in output path. After the
+// concatenation completes the original source files are deleted and the
+// cat file slice is reset for the next execution. If the concatenation
+// is interrupted, the partial output is removed so the original files
+// remain the only copy of their data.
+//
+// @Parameters
+// - ctx:  Cancels the concatenation, such as on Ctrl-C
+// - catFiles:  Slice of the file paths of files to be concatenated
+// - catPath:  The path to the resulting output file
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func CatAndDelete(ctx context.Context, catFiles *[]string, catPath string) error {
+    err := concatFiles(ctx, *catFiles, catPath)
     if err != nil {
+        os.Remove(catPath)
         return err
     }
 
@@ -57,25 +136,203 @@ func CatAndDelete(catFiles *[]string, catPath string) error {
 }
 
 
+// Checks whether the duplicut binary can be run on this platform.
+//
+// @Returns
+// - true if duplicut is present and built for the platform, otherwise false
+//
+func duplicutAvailable() bool {
+    // If the platform can not run the Linux duplicut build
+    if runtime.GOOS == "windows" {
+        return false
+    }
+
+    _, err := os.Stat(DuplicutPath)
+    return err == nil
+}
+
+
+// Checks whether the line at the offset of the source file matches the passed in line.
+//
+// @Parameters
+// - srcFile:  The source file being de-duplicated
+// - offset:  The offset of the kept line in the source file
+// - line:  The line to compare, without its newline
+// - buffer:  Reused buffer the kept line is read into
+//
+// @Returns
+// - true if the kept line holds the same bytes, otherwise false
+// - Error if it occurs, otherwise nil on success
+//
+func lineAt(srcFile *os.File, offset int64, line []byte, buffer []byte) (bool, error) {
+    buffer = buffer[:len(line) + 1]
+
+    read, err := srcFile.ReadAt(buffer, offset)
+    if err != nil && !errors.Is(err, io.EOF) {
+        return false, err
+    }
+
+    // If the kept line is shorter than the line
+    if read < len(line) || !bytes.Equal(buffer[:len(line)], line) {
+        return false, nil
+    }
+
+    // The kept line matches if it ends where the line does
+    return read == len(line) || buffer[len(line)] == '\n', nil
+}
+
+
+// Removes the duplicate lines of the source file keeping the first occurrence of each,
+// the way duplicut does. Rather than the lines themselves, a 64 bit hash and the source
+// offset of each unique line is held in memory, which grows with the number of unique
+// lines but not their length. A hash hit is confirmed against the bytes of the kept line
+// in the source file, so lines with colliding hashes are never dropped.
+//
+// @Parameters
+// - ctx:  Cancels the de-duplication, such as on Ctrl-C
+// - srcPath:  The path to the source file that needs de-duplication
+// - destPath:  The path to the resulting output file
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func dedupeLines(ctx context.Context, srcPath string, destPath string) error {
+    srcFile, err := os.Open(srcPath)
+    if err != nil {
+        return err
+    }
+    // Close the source file on local exit
+    defer srcFile.Close()
+
+    destFile, err := os.Create(destPath)
+    if err != nil {
+        return err
+    }
+    // Close the output file on local exit
+    defer destFile.Close()
+
+    reader := bufio.NewReaderSize(srcFile, streamBufferSize)
+    writer := bufio.NewWriterSize(destFile, streamBufferSize)
+    seed := maphash.MakeSeed()
+    // Offset of the first kept line of each hash, and of the later ones colliding with it
+    seen := make(map[uint64]int64)
+    collisions := make(map[uint64][]int64)
+    var buffer []byte
+    var lines int64
+    var offset int64
+
+    for {
+        line, readErr := reader.ReadBytes('\n')
+        lineOffset := offset
+        offset += int64(len(line))
+        line = bytes.TrimSuffix(line, []byte("\n"))
+
+        lines += 1
+        // If the de-duplication was cancelled, checked periodically to keep it cheap
+        if lines % 100000 == 0 && ctx.Err() != nil {
+            return ctx.Err()
+        }
+
+        // If the line has content and was not seen before
+        if len(line) > 0 {
+            hash := maphash.Bytes(seed, line)
+            keptOffset, exists := seen[hash]
+            duplicate := false
+
+            // If the hash was seen, compare the kept lines sharing it
+            if exists {
+                // If the buffer can not hold the line and its newline
+                if cap(buffer) < len(line) + 1 {
+                    buffer = make([]byte, len(line) + 1)
+                }
+
+                duplicate, err = lineAt(srcFile, keptOffset, line, buffer)
+                if err != nil {
+                    return err
+                }
+
+                // Iterate through the kept lines colliding with the hash until one matches
+                for index := 0; !duplicate && index < len(collisions[hash]); index++ {
+                    duplicate, err = lineAt(srcFile, collisions[hash][index], line, buffer)
+                    if err != nil {
+                        return err
+                    }
+                }
+            }
+
+            // If the line is not a duplicate of a kept line
+            if !duplicate {
+                // If the hash collides with a different kept line, keep both
+                if exists {
+                    collisions[hash] = append(collisions[hash], lineOffset)
+                } else {
+                    seen[hash] = lineOffset
+                }
+
+                writer.Write(line)
+                err = writer.WriteByte('\n')
+                if err != nil {
+                    return err
+                }
+            }
+        }
+
+        // If the end of the file was hit
+        if errors.Is(readErr, io.EOF) {
+            break
+        } else if readErr != nil {
+            return readErr
+        }
+    }
+
+    err = writer.Flush()
+    if err != nil {
+        return err
+    }
+
+    return destFile.Close()
+}
+
+
 // Runs the source file through duplicut with the resulting output written
 // to the destination file and comparing its size to the max file size.
+// Where duplicut can not run, such as on Windows, the duplicates are
+// removed in Go instead. If the de-duplication is interrupted, the
+// partial output is removed and the source file is kept.
 //
 // @Parameters
+// - ctx:  Cancels the de-duplication, such as on Ctrl-C
 // - srcPath:  The path to the source file that needs de-deplication
 // - destPath:  The path to the resulting output file of duplicut
+// - threads:  The number of threads duplicut runs with, 0 for its default
 //
 // @Returns
 // - The size of the duplicut output file
 // - Error if it occurs, otherwise nil on success
 //
-func DuplicutAndDelete(srcPath string, destPath string) (int64, error) {
-    // Format duplicut command to be executed
-    duplicutCmd := "../../duplicut/duplicut " + srcPath + " -o " +
-                   destPath + " 1>/dev/null 2>/dev/null"
-    cmd := exec.Command("sh", "-c", duplicutCmd)
-    // Execute the command and wait until it is complete
-    err := cmd.Run()
+func DuplicutAndDelete(ctx context.Context, srcPath string, destPath string,
+                       threads int) (int64, error) {
+    var err error
+
+    // If duplicut can run, use it for its multithreaded de-duplication
+    if duplicutAvailable() {
+        args := []string{srcPath, "-o", destPath}
+        // If the threads are set, limit duplicut to them
+        if threads > 0 {
+            args = append(args, "-t", strconv.Itoa(threads))
+        }
+
+        // Execute the command and wait until it is complete
+        err = exec.CommandContext(ctx, DuplicutPath, args...).Run()
+        if err != nil {
+            err = errors.Join(ctx.Err(), err)
+        }
+    } else {
+        err = dedupeLines(ctx, srcPath, destPath)
+    }
+
     if err != nil {
+        os.Remove(destPath)
         return -1, err
     }
 
@@ -96,15 +353,42 @@ func DuplicutAndDelete(srcPath string, destPath string) (int64, error) {
 }
 
 
+// Copies the reader into a newly created file at the passed in path.
+//
+// @Parameters
+// - filePath:  The path of the file to create
+// - reader:  The source of the data
+// - buffer:  The buffer the data is read into
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func copyToFile(filePath string, reader io.Reader, buffer []byte) error {
+    file, err := os.Create(filePath)
+    if err != nil {
+        return err
+    }
+    // Close the file on local exit
+    defer file.Close()
+
+    _, err = io.CopyBuffer(file, reader, buffer)
+    if err != nil {
+        return err
+    }
+
+    return file.Close()
+}
+
+
 // Takes the file that is over the max allowed size and move
-// any data over that max into a new file via dd command.
+// any data over that max into a new file, the way dd would.
 //
 // @Parameters
 // - filterPath:  The source file that is over the max size that needs
 //                excess data to be filtered
 // - shavePath:  The destination file there the excess data is written to
 // - originalPath:  Path to original file data after excess filtered
-// - blockSize:  The size of the block of data for dd to send at a time
+// - blockSize:  The size of the block of data to copy at a time
 // - maxFileSize:  The max allowed size for wordlist file
 //
 // @Returns
@@ -113,30 +397,21 @@ func DuplicutAndDelete(srcPath string, destPath string) (int64, error) {
 //
 func FileShaveDD(filterPath string, shavePath string, originalPath string,
                  blockSize int64, maxFileSize int64) (int64, error) {
-    // Divide the max file size by the block size to get
-    // the number of blocks to skip
-    skipSize := float64(maxFileSize) / float64(blockSize)
-    countSize := skipSize
-    blockSizeStr := strconv.FormatInt(blockSize, 10)
-    // If the max file size divided by the block size has a remainder
-    if maxFileSize % blockSize != 0 {
-        skipSize += 1
-    }
-
-    // Format the dd command to copy exceeding data to new file
-    cmd := exec.Command("dd", "if=" + filterPath, "of=" + shavePath, "bs=" + blockSizeStr,
-                        "skip=" + strconv.FormatFloat(skipSize, 'f', -1, 64))
-    // Execute the dd command
-    err := cmd.Run()
+    filterFile, err := os.Open(filterPath)
     if err != nil {
         return -1, err
     }
 
-    // Format the dd command to copy original data to new file
-    cmd = exec.Command("dd", "if=" + filterPath, "of=" + originalPath, "bs=" + blockSizeStr,
-                       "count=" + strconv.FormatFloat(countSize, 'f', -1, 64))
-    // Execute the dd command
-    err = cmd.Run()
+    buffer := make([]byte, blockSize)
+    // Copy the data up to the max size to the original file
+    err = copyToFile(originalPath, io.LimitReader(filterFile, maxFileSize), buffer)
+    if err == nil {
+        // Copy the exceeding data after it to the shave file
+        err = copyToFile(shavePath, filterFile, buffer)
+    }
+
+    // Close the source before removing it, which Windows requires
+    filterFile.Close()
     if err != nil {
         return -1, err
     }
@@ -157,8 +432,125 @@ func FileShaveDD(filterPath string, shavePath string, originalPath string,
 }
 
 
+// Splits the file into numbered files of whole lines no larger than the max size, the
+// way split -d -C does. Lines longer than the read buffer are cut where a file fills up.
+//
+// @Parameters
+// - filterPath:  The source file to split
+// - shavePath:  The path the two digit number of each file is appended to
+// - maxFileSize:  The max allowed size of each file
+//
+// @Returns
+// - The paths of the resulting files in order
+// - Error if it occurs, otherwise nil on success
+//
+func splitLines(filterPath string, shavePath string, maxFileSize int64) ([]string, error) {
+    var outPaths []string
+    var outFile *os.File
+    var writer *bufio.Writer
+    var written int64
+
+    srcFile, err := os.Open(filterPath)
+    if err != nil {
+        return nil, err
+    }
+    // Close the source file on local exit
+    defer srcFile.Close()
+
+    // Flushes and closes the current output file
+    closeOut := func() error {
+        // If no output file is open yet
+        if outFile == nil {
+            return nil
+        }
+
+        err := writer.Flush()
+        if err != nil {
+            outFile.Close()
+            return err
+        }
+
+        return outFile.Close()
+    }
+    // Close any output file left open on local exit
+    defer closeOut()
+
+    // Starts the next numbered output file
+    rotate := func() error {
+        err := closeOut()
+        if err != nil {
+            return err
+        }
+
+        outPath := shavePath + fmt.Sprintf("%02d", len(outPaths))
+        outFile, err = os.Create(outPath)
+        if err != nil {
+            outFile = nil
+            return err
+        }
+
+        writer = bufio.NewWriterSize(outFile, streamBufferSize)
+        outPaths = append(outPaths, outPath)
+        written = 0
+        return nil
+    }
+
+    reader := bufio.NewReaderSize(srcFile, streamBufferSize)
+
+    for {
+        piece, readErr := reader.ReadSlice('\n')
+        // If the line is longer than the buffer, the piece is only part of it
+        partial := errors.Is(readErr, bufio.ErrBufferFull)
+
+        // If a whole line fits in a new file but not in what is left of the current one
+        if !partial && outFile != nil && written > 0 &&
+           written + int64(len(piece)) > maxFileSize && int64(len(piece)) <= maxFileSize {
+            err = rotate()
+            if err != nil {
+                return nil, err
+            }
+        }
+
+        // Write the piece, cutting it where a file fills up
+        for len(piece) > 0 {
+            // If no file is open or the current one is full
+            if outFile == nil || written >= maxFileSize {
+                err = rotate()
+                if err != nil {
+                    return nil, err
+                }
+            }
+
+            size := min(int64(len(piece)), maxFileSize - written)
+            _, err = writer.Write(piece[:size])
+            if err != nil {
+                return nil, err
+            }
+
+            written += size
+            piece = piece[size:]
+        }
+
+        // If the end of the file was hit
+        if errors.Is(readErr, io.EOF) {
+            break
+        } else if readErr != nil && !partial {
+            return nil, readErr
+        }
+    }
+
+    err = closeOut()
+    outFile = nil
+    if err != nil {
+        return nil, err
+    }
+
+    return outPaths, nil
+}
+
+
 // Takes the file that is over the max allowed size and move
-// any data over that max into a new file via cut command.
+// any data over that max into new files of whole lines.
 //
 // @Parameters
 // - filterPath:  The source file that is over the max size that
@@ -174,12 +566,7 @@ func FileShaveDD(filterPath string, shavePath string, originalPath string,
 //
 func FileShaveSplit(filterPath string, shavePath string, maxFileSize int64,
                     catFiles *[]string, outFilesMap map[string]struct{}) error {
-    // Convert the max file size to string
-    maxFileSizeStr := strconv.FormatInt(maxFileSize, 10)
-    // Format the cut command to be executed
-    cmd := exec.Command("split", "-d", "-C", maxFileSizeStr, filterPath, shavePath)
-    // Execute split command
-    err := cmd.Run()
+    outPaths, err := splitLines(filterPath, shavePath, maxFileSize)
     if err != nil {
         return err
     }
@@ -190,20 +577,13 @@ func FileShaveSplit(filterPath string, shavePath string, maxFileSize int64,
         return err
     }
 
-    var outerCount uint64 = 0
-    var innerCount uint64 = 0
     maxSizeFloat := float64(maxFileSize)
 
-    for {
-        // Format the current count on the end of the output path
-        outPath := shavePath + strconv.FormatUint(innerCount, 10) + strconv.FormatUint(outerCount, 10)
-
+    // Iterate through the split files sorting out the full ones
+    for _, outPath := range outPaths {
         // Get the current file info
         fileInfo, err := os.Stat(outPath)
-        // If the output file does not exist
-        if errors.Is(err, os.ErrNotExist) {
-            break
-        } else if err != nil {
+        if err != nil {
             return err
         }
 
@@ -215,14 +595,6 @@ func FileShaveSplit(filterPath string, shavePath string, maxFileSize int64,
             // Add the current file to the cat files list
             *catFiles = append(*catFiles, outPath)
         }
-
-        if outerCount >= 9 {
-            innerCount += 1
-            outerCount = 0
-            continue
-        }
-
-        outerCount += 1
     }
 
     return nil
@@ -259,226 +631,6 @@ func GetOptimalBlockSize(fileSize int64) (int64, error) {
 }
 
 
-// Sets up the cat files slice and out files map, gets the block size, and
-// call filepath walk with closure function above until complete.
-//
-// @Parameters
-// - dirPath:  The path to the directory where wordlist merging occurs
-// - maxMergingSize:  The maximum allowed size until merging process is skipped
-// - maxFileSize:  The maximum size a wordlist should be
-// - maxRange:  The range within the max that makes a file register as full
-// - maxCutSize:  The max size threshold where dd is utilized instead of cut
-//
-// @Returns
-// - Error if it occurs, otherwise nil on success
-//
-func MergeWordlistDir(dirPath string, maxMergingSize int64, maxFileSize int64,
-                      maxRange float64, maxCutSize int64) error {
-    catFiles := []string{}
-    outFilesMap := make(map[string]struct{})
-
-    // Iterate through the contents of the directory and any subdirectories, merging wordlists
-    err := filepath.Walk(dirPath, func(path string, itemInfo os.FileInfo, walkErr error) error {
-        return MergeWordlists(dirPath, maxMergingSize, maxFileSize, maxRange, maxCutSize,
-                              &catFiles, outFilesMap, path, itemInfo, walkErr)
-    })
-
-    if err != nil {
-        return err
-    }
-
-    return nil
-}
-
-
-// Walks through passed in dir path appending files to the cat list until
-// multiple are available, then performing cat on them while original files
-// are deleted. After the cat result is passed into duplicut where the original
-// file is deleted again. If the resulting file size is equal to the max file size
-// OR is within the specified max range of the file size it will be added to a
-// map for managing completed files. If it is less than the bottom of the max range
-// it will be added back to the cat file list and re-iterate. If greater then
-// if will either use cut (small files) or dd (larger files) to shave the exess
-// data into a new file and save the original to the output files list.
-//
-// @Parameters
-// - dirPath:  The path to the directory where wordlist merging occurs
-// - maxMergingSize:  The maximum allowed size until merging process is skipped
-// - maxFileSize:  The maximum allowed size a wordlist that can be sent
-// - maxRange:  The range within the max that makes a file register as full
-// - maxCutSize:  The max size threshold where dd is utilized instead of cut
-// - catFiles:  The slice of file paths to pass into CatAndDelete()
-// - outFilesMap:  The map used to ensure only files that have not been
-//                 processed are selected
-// - path:  Path to the currently selected item in merge directory
-// - itemInfo:  The info of currently seleted item
-// - err:  Error if it occurs during walk, otherwise nil on success
-//
-// @Returns
-// - Error if it occurs, otherwise nil on success
-//
-func MergeWordlists(dirPath string, maxMergingSize int64, maxFileSize int64,
-                    maxRange float64, maxCutSize int64, catFiles *[]string,
-                    outFilesMap map[string]struct{}, path string,
-                    itemInfo os.FileInfo, err error) error {
-    if err != nil {
-        return err
-    }
-
-    // If the item is a dir, skip to next
-    if itemInfo.IsDir() {
-        return nil
-    }
-
-    // If current file exists in the out files map, skip to next
-    _, exists := outFilesMap[path]
-    if exists {
-        return nil
-    }
-
-    var filterPath string
-    destFileSize := itemInfo.Size()
-
-    // If the file is within the max file size and exceeds or
-    // is within upper percentile of merging max size
-    if (destFileSize <= maxFileSize && destFileSize >= maxMergingSize) ||
-    data.IsInPercentRange(float64(maxMergingSize), float64(destFileSize), maxRange) {
-        // Add the resulting path to out files map
-        outFilesMap[path] = struct{}{}
-        return nil
-    }
-
-    // If the current file size is less than or is not within 15% of merging max size
-    if destFileSize < maxMergingSize &&
-    !data.IsInPercentRange(float64(maxMergingSize), float64(destFileSize), maxRange) {
-        // Append the current file path to cat files slice
-        *catFiles = append(*catFiles, path)
-
-        // If there is less than 2 files in the cat files slice, skip to next
-        if len(*catFiles) < 2 {
-            return nil
-        }
-
-        // Create random file for cat command output
-        catPath, _, err := disk.CreateRandFile(dirPath, globals.RAND_STRING_SIZE,
-                                               "kloudkraken-data-", "txt", false)
-        if err != nil {
-            return err
-        }
-
-        // Cat files in cat slice into result deleting originals
-        err = CatAndDelete(catFiles, catPath)
-        if err != nil {
-            return err
-        }
-
-        // Create a new file for filtered output
-        filterPath, _, err = disk.CreateRandFile(dirPath, globals.RAND_STRING_SIZE,
-                                                 "kloudkraken-data-", "txt", false)
-        if err != nil {
-            return err
-        }
-
-        // Run the oversized file via duplicut to output file, deleting original file
-        destFileSize, err = DuplicutAndDelete(catPath, filterPath)
-        if err != nil {
-            return err
-        }
-
-        // If the size of the dest file is equal to max OR resides within the max range
-        if destFileSize == maxMergingSize || (destFileSize < maxMergingSize &&
-        data.IsInPercentRange(float64(maxMergingSize), float64(destFileSize), maxRange)) {
-            // Add the resulting path to out files map
-            outFilesMap[filterPath] = struct{}{}
-            return nil
-        // If the size of the dest file is less than max
-        } else if destFileSize < maxMergingSize {
-            // Add the output file to cat files list for further processing
-            *catFiles = append(*catFiles, filterPath)
-            return nil
-        }
-    } else {
-        filterPath = path
-    }
-
-    // Create a new file for file shaving process
-    shavePath, _, err := disk.CreateRandFile(dirPath, globals.RAND_STRING_SIZE,
-                                             "kloudkraken-data-", "txt", false)
-    if err != nil {
-        return err
-    }
-
-    // For file greater than threshold, dd is optimal for resource scalability
-    if destFileSize > maxCutSize {
-        // Get the optimal block size for file shaving operation based on the file size
-        blockSize, err := GetOptimalBlockSize(destFileSize)
-        if err != nil {
-            return err
-        }
-
-        for {
-            // Create a new file for original file data after excess filtered
-            originalPath, _, err := disk.CreateRandFile(dirPath, globals.RAND_STRING_SIZE,
-                                                        "kloudkraken-data-", "txt", false)
-            if err != nil {
-                return err
-            }
-
-            // Shaves any data large than excess size into new file
-            shaveFileSize, err := FileShaveDD(filterPath, shavePath, originalPath,
-                                              blockSize, maxFileSize)
-            if err != nil {
-                return err
-            }
-
-            // Add the maxed out file to the out files map
-            outFilesMap[originalPath] = struct{}{}
-
-            // If the shaved file still exceeds max file size
-            if shaveFileSize > maxFileSize {
-                // Set result path as input and make new shave path for next iteration
-                filterPath = shavePath
-                shavePath, _, err = disk.CreateRandFile(dirPath, globals.RAND_STRING_SIZE,
-                                                        "kloudkraken-data-", "txt", false)
-                if err != nil {
-                    return err
-                }
-
-                // Reset the optimal block size based on size of result of first dd operation
-                blockSize, err = GetOptimalBlockSize(shaveFileSize)
-                if err != nil {
-                    return err
-                }
-
-                continue
-
-            // If the shaved file is within or above the max merging range
-            } else if (shaveFileSize >= maxMergingSize ||
-            data.IsInPercentRange(float64(maxMergingSize), float64(shaveFileSize), maxRange)) &&
-            shaveFileSize <= maxFileSize {
-                // Add the shaved file to the out files map
-                outFilesMap[shavePath] = struct{}{}
-                break
-            }
-
-            // Add the file with extra shaved data to cat files slice
-            *catFiles = append(*catFiles, shavePath)
-            break
-        }
-    // For files less than threshold, split is optimal parsing entries line by line
-    } else {
-        // Shaves any data large than excess size into new file
-        err = FileShaveSplit(filterPath, shavePath, maxFileSize,
-                             catFiles, outFilesMap)
-        if err != nil {
-            return err
-        }
-    }
-
-    return nil
-}
-
-
 // Deletes any subdirs and their contents in passed in dir path.
 //
 // @Parameters
@@ -1,7 +1,9 @@
 package wordlist_test
 
 import (
+	"context"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/ngimb64/Kloud-Kraken/internal/globals"
@@ -56,7 +58,7 @@ func TestCatAndDelete(t *testing.T) {
     catOutfile.Close()
 
     // Execute the cat command that deletes the input files
-    err = wordlist.CatAndDelete(&catFiles, catOutfile.Name())
+    err = wordlist.CatAndDelete(context.Background(), &catFiles, catOutfile.Name())
     // Ensure the error is nil meaning successful operation
     assert.Equal(nil, err)
 
@@ -115,7 +117,8 @@ func TestDuplicutAndDelete(t *testing.T) {
     duplicutOutFile.Close()
 
     // Execute the cat command that filters duplicates in files
-    size, err := wordlist.DuplicutAndDelete(file1.Name(), duplicutOutFile.Name())
+    size, err := wordlist.DuplicutAndDelete(context.Background(), file1.Name(),
+                                            duplicutOutFile.Name(), 0)
     // Ensure the error is nil meaning successful operation
     assert.Equal(nil, err)
     // Ensure the size is equal to the expected data
@@ -128,6 +131,40 @@ func TestDuplicutAndDelete(t *testing.T) {
 }
 
 
+func TestDuplicutFallback(t *testing.T) {
+    // Make reusable assert instance
+    assert := assert.New(t)
+
+    duplicutPath := wordlist.DuplicutPath
+    // Point at a missing binary so the duplicates are removed in Go
+    wordlist.DuplicutPath = filepath.Join(t.TempDir(), "duplicut")
+    // Reset the duplicut path on local exit
+    defer func() {
+        wordlist.DuplicutPath = duplicutPath
+    } ()
+
+    srcPath := filepath.Join(t.TempDir(), "wordlist.txt")
+    destPath := filepath.Join(t.TempDir(), "deduped.txt")
+    err := os.WriteFile(srcPath, []byte("foo\nbar\nfoo\n\nbaz\nbar\nfoobar\nqux\nfo\nqux"),
+                        0644)
+    // Ensure the error is nil meaning successful operation
+    assert.Equal(nil, err)
+
+    size, err := wordlist.DuplicutAndDelete(context.Background(), srcPath, destPath, 0)
+    assert.Equal(nil, err)
+
+    // Ensure the first occurrence of each line is kept in order
+    output, err := os.ReadFile(destPath)
+    assert.Equal(nil, err)
+    assert.Equal("foo\nbar\nbaz\nfoobar\nqux\nfo\n", string(output))
+    assert.Equal(int64(len(output)), size)
+
+    // Ensure the source file was deleted
+    _, err = os.Stat(srcPath)
+    assert.True(os.IsNotExist(err))
+}
+
+
 func TestFileShaveDD(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -274,75 +311,6 @@ func TestGetOptimalBlockSize(t *testing.T) {
 }
 
 
-func TestMergeWordlistDir(t *testing.T) {
-    // Make reusable assert instance
-    assert := assert.New(t)
-
-    dirPath := "testdir"
-    // Create the test directory
-    err := os.Mkdir(dirPath, os.ModePerm)
-    // Ensure the error is nil meaning successful operation
-    assert.Equal(nil, err)
-
-    // Copy the directory with test wordlist data to test dir
-    err = os.CopyFS(dirPath, os.DirFS("../../testdata"))
-    // Ensure the error is nil meaning successful operation
-    assert.Equal(nil, err)
-
-    maxMergingSize := int64(20 * globals.MB)
-    maxFileSize := int64(30 * globals.MB)
-    // Merge the created wordlists in the wordlist dir
-    err = wordlist.MergeWordlistDir(dirPath, maxMergingSize, maxFileSize,
-                                    15.0, int64(1 * globals.GB))
-    // Ensure the error is nil meaning successful operation
-    assert.Equal(nil, err)
-
-    dirItems, err := os.ReadDir(dirPath)
-    // Ensure the error is nil meaning successful operation
-    assert.Equal(nil, err)
-
-    fullFiles := []string{}
-    shaveFiles := []string{}
-
-    // Iterate through the items in the test dir
-    for _, item := range dirItems {
-        if item.IsDir() {
-            continue
-        }
-
-        // Set the path to current file
-        itemPath := dirPath + "/" + item.Name()
-
-        // Get the current file info
-        itemInfo, err := os.Stat(itemPath)
-        // Ensure the error is nil meaning successful operation
-        assert.Equal(nil, err)
-
-        // Get the current file size and ensure it is less than max
-        fileSize := itemInfo.Size()
-        assert.Less(fileSize, maxFileSize)
-
-        // If the file is within 5 percent or equal to the max file size
-        if data.IsInPercentRange(float64(maxFileSize), float64(fileSize), 15.0) ||
-        fileSize == maxFileSize {
-            fullFiles = append(fullFiles, itemPath)
-        } else if (fileSize != 0) {
-            shaveFiles = append(shaveFiles, itemPath)
-        }
-    }
-
-    // Ensure there are 3 full files
-    assert.Equal(3, len(fullFiles))
-    // Ensure there are two leftover files
-    assert.Equal(2, len(shaveFiles))
-
-    // Delete test directory and its contents after test
-    err = os.RemoveAll(dirPath)
-    // Ensure the error is nil meaning successful operation
-    assert.Equal(nil, err)
-}
-
-
 func TestRemoveMergeSubdirs(t *testing.T) {
     // Make reusable assert instance
     assert := assert.New(t)
@@ -2,18 +2,21 @@ package main
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"crypto/tls"
 	"encoding/binary"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"os"
 	"os/exec"
-	"path"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -22,80 +25,1705 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/ngimb64/Kloud-Kraken/internal/globals"
+	"github.com/ngimb64/Kloud-Kraken/internal/validate"
+	"github.com/ngimb64/Kloud-Kraken/pkg/admission"
+	"github.com/ngimb64/Kloud-Kraken/pkg/audit"
 	"github.com/ngimb64/Kloud-Kraken/pkg/awsutils"
+	"github.com/ngimb64/Kloud-Kraken/pkg/controlplane"
 	"github.com/ngimb64/Kloud-Kraken/pkg/data"
 	"github.com/ngimb64/Kloud-Kraken/pkg/disk"
+	"github.com/ngimb64/Kloud-Kraken/pkg/gpu"
+	"github.com/ngimb64/Kloud-Kraken/pkg/harden"
 	"github.com/ngimb64/Kloud-Kraken/pkg/hashcat"
+	"github.com/ngimb64/Kloud-Kraken/pkg/instance"
+	"github.com/ngimb64/Kloud-Kraken/pkg/keyspace"
 	"github.com/ngimb64/Kloud-Kraken/pkg/kloudlogs"
+	"github.com/ngimb64/Kloud-Kraken/pkg/logstream"
+	"github.com/ngimb64/Kloud-Kraken/pkg/manifest"
 	"github.com/ngimb64/Kloud-Kraken/pkg/netio"
+	"github.com/ngimb64/Kloud-Kraken/pkg/peer"
+	"github.com/ngimb64/Kloud-Kraken/pkg/potfile"
+	"github.com/ngimb64/Kloud-Kraken/pkg/protocol"
+	"github.com/ngimb64/Kloud-Kraken/pkg/rebalance"
+	"github.com/ngimb64/Kloud-Kraken/pkg/report"
+	"github.com/ngimb64/Kloud-Kraken/pkg/restore"
+	"github.com/ngimb64/Kloud-Kraken/pkg/schedule"
 	"github.com/ngimb64/Kloud-Kraken/pkg/tlsutils"
+	"github.com/ngimb64/Kloud-Kraken/pkg/update"
 	"go.uber.org/zap"
 )
 
+// HashFile is a received hash file with the hashcat hash type it is cracked as
+type HashFile struct {
+    HashType string
+    Path     string
+}
+
+// WordlistStream is a wordlist read from its transfer connection into hashcat stdin
+type WordlistStream struct {
+    Done   chan struct{}  // Closed once hashcat is done reading the wordlist
+    Name   string
+    Reader io.Reader
+    Size   int64
+}
+
+// WordlistJob is a wordlist in the wordlist dir claimed by a hashcat job
+type WordlistJob struct {
+    Name string
+    Size int64
+}
+
 // Package level variables
+var AbortCtx, AbortRun = context.WithCancel(context.Background())  // Canceled on a run abort
+var AbortWatcher *restore.Watcher          // Polls the server for a run abort, nil if unused
+var Admission *admission.Controller        // Admits transfers by the space they need
+var AuditReporter *audit.Reporter          // Reports hashcat runs to the server, nil if unused
+var AutoUpdate bool                         // Toggle for restarting on new client versions
+var BucketName string                       // S3 bucket where client binary versions are stored
 var BufferMutex = &sync.Mutex{}             // Mutex for message buffer synchronization
+var ClientVersion string                    // Version hash of the running client binary
+var ConnectionToken string                  // Token presented in the hello, empty if not required
 var DataPath string                         // Path where data dirs will be stored
+var ErrJobTimeout = errors.New("hashcat ran past the job timeout")  // Hashcat was killed on timeout
+var ErrRunAborted = errors.New("run aborted by the server")  // Hashcat was interrupted on abort
+var ExePath string                          // Path of the running client binary
+var GpuMonitor *gpu.Monitor                 // Samples the GPUs and pauses hashcat when hot, nil if unused
 var HashcatArgs = new(hashcat.HashcatArgs)  // Initialze where hashcat args are stored
-var HashFilePath string  // Stores hash file path when received
+var Hibernating atomic.Bool                 // Set once the server hibernates the run to stop the fleet
+var HashFiles []HashFile // Stores the received hash files with their hash types
 var HashesPath string    // Path where hash files are stored
-var HasRuleset bool      // Toggle for specifying whether ruleset is in use
+var HashQuota int64      // Max size of the hashes dir, 0 is unlimited
+var ControlPlane string      // Channel the server is connected over, tls or sqs
+var IdleTimeout time.Duration  // Max time the server connection may sit idle, 0 is unlimited
+var Inventory gpu.Inventory  // GPUs and hashcat backend devices detected at startup
+var JobTimeout time.Duration // Max runtime of each hashcat process, 0 is unlimited
+var Keepalive *netio.Keepalive  // Probes the server while otherwise idle, nil if unused
+var KeyspaceMode bool    // Toggle for processing mask keyspace ranges from server
+var LogForwarder *logstream.Forwarder  // Streams the log file to the server, nil when disabled
 var LogPath string       // Stores log file to be returned to client
+var LogStreaming bool    // Toggle for streaming the log file to the server during the run
+var LootFlushCracks int  // Number of cracked hashes that triggers a loot flush, 0 is disabled
+var LootFlushInterval time.Duration  // Duration between loot flushes, 0 is disabled
+var LootFlusher *potfile.Flusher     // Flushes cracked hashes to the server, nil when disabled
+var LootMutex sync.Mutex // Mutex for synchronizing hashcat jobs appending to the loot file
+var Metadata instance.Metadata  // EC2 identity of the client instance, empty when not on EC2
+var HashcatJobs int      // Number of hashcat processes run concurrently on wordlists
 var MaxTransfers atomic.Int32  // Number of file transfers allowed simultaniously
 var MaxTransfersInt32 int32    // Stores converted int maxTransfers arg
-var RulesetFilePath string     // Stores ruleset file when received
+var PeerSharing bool           // Toggle for fetching and seeding shared files with peers
+var PlaintextTransfers bool    // Toggle for receiving wordlist data over TCP inside the VPC
+var QueuePrefix string         // Prefix of the SQS control plane queue names of the run
+var Reserve disk.Reserve       // Space kept free on the data disk for the OS
+var ResultsAcked atomic.Bool    // Set once the server confirmed the results are stored
+var RulesetCount int           // Number of ruleset files sent by the server
+var RulesetNames []string      // Stores the names of the received ruleset files
+var RulesetPairings []schedule.Pairing  // Pairings of wordlists with the rulesets they run with
 var RulesetPath string         // Path where ruleset files are stored
+var RulesetQuota int64         // Max size of the rulesets dir, 0 is unlimited
+var RestorePath string         // Path where hashcat restore files are written
+var S3Man *awsutils.S3Manager  // S3 manager for downloading client updates, nil when disabled
+var ScrubOnCompletion bool     // Toggle for shredding the run data once results are acknowledged
+var SeedPath string            // Path where copies of seeded files are stored
+var ServerCertPem []byte       // PEM block of the latest trusted server certificate
+var ServerHost string          // Address the server was dialed at, empty over SQS
+var ServerNames sync.Map       // Names the server sent renamed wordlists under, by final path
+var Session protocol.Hello     // Protocol version and features negotiated with the server
+var Seeder *peer.Seeder        // Serves shared files to peers, nil when not seeding
+var SelfTerminate bool         // Toggle for exiting with failure unless results are acknowledged
+var StatusSender func(message []byte) error  // Forwards hashcat status to the server, nil if unused
+var StreamWordlists bool       // Toggle for feeding wordlists into hashcat without storing them
 var TlsMan = new(tlsutils.TlsManager)  // Struct for managing TLS certs, keys, etc.
+var Tunings map[string]hashcat.Tuning  // Tuning applied to each hash type, empty when disabled
+var UpdateStaged atomic.Bool           // Set once a new client version replaced the binary
 var WordlistPath string                // Path where wordlists are stored
+var WordlistQuota int64                // Max size of the wordlists dir, 0 is unlimited
+var WorkStealing bool                  // Toggle for claiming wordlists the server may split
 
 
 // Ensure the final cracked hashes file exists and has a message informing
 // the user no hashes were cracked.
 //
-// @Parmeters
-// - lootFile:  The file path where final cracked hashes are stored
+// @Parmeters
+// - lootFile:  The file path where final cracked hashes are stored
+//
+// @ Returns
+// - Error if it occurs, otherwise nil on success
+//
+func createFailureResult(lootPath string) error {
+    // Open the final cracked hashes file or create if it does not exist
+    hashesHandle, err := os.OpenFile(lootPath, os.O_RDWR|os.O_CREATE, 0644)
+    if err != nil {
+        return err
+    }
+
+    // Close the opened cracked hashes file on local exit
+    defer hashesHandle.Close()
+
+    // Write a message letting user know that no hashes were cracked
+    _, err = hashesHandle.Write(globals.NO_CRACKED_HASHES)
+    if err != nil {
+        return err
+    }
+
+    return nil
+}
+
+
+// Lock mutux for messaging connection and related buffer, send the processing complete message,
+// or the processing aborted message if the server aborted the run.
+//
+// @Parameters
+// - connection:  network socket connection where procesing complete message is sent
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+//
+func sendProcessingComplete(connection net.Conn, logMan *kloudlogs.LoggerManager) {
+    // Stop polling for an abort, the server no longer replies to it after this message
+    AbortWatcher.Stop()
+    Keepalive.Stop()
+    // Send the last streamed log lines before the server stops reading them
+    LogForwarder.Stop()
+    // Stop flushing, the final loot upload carries what was cracked since the last flush
+    LootFlusher.Stop()
+
+    // Lock the mutex and ensure it unlocks on local exit
+    BufferMutex.Lock()
+    defer BufferMutex.Unlock()
+
+    completeMsg := globals.PROCESSING_COMPLETE
+    // If the run was aborted, the server receives the restore bundle after the loot
+    if AbortCtx.Err() != nil {
+        completeMsg = globals.PROCESSING_ABORTED
+    }
+
+    // Send the processing complete message
+    _, err := netio.WriteHandler(connection, completeMsg, len(completeMsg))
+    if err != nil {
+        logMan.LogMessage("error", "Error sending processing complete message:  %v", err)
+        return
+    }
+}
+
+
+// Waits for the server to acknowledge the loot and any restore bundle are stored, so the
+// instance is only scrubbed once the results can no longer be lost with it.
+//
+// @Parameters
+// - connection:  network socket connection where the acknowledgement is received
+// - buffer:  The buffer used for processing socket messaging
+//
+// @Returns
+// - Error if it occurs or the server replies with anything else, otherwise nil on success
+//
+func awaitResultsAck(connection net.Conn, buffer []byte) error {
+    bytesRead, err := netio.ReadHandler(connection, &buffer)
+    if err != nil {
+        return err
+    }
+
+    // If the server replied with something other than the acknowledgement
+    if !bytes.Equal(buffer[:bytesRead], globals.RESULTS_ACK) {
+        return fmt.Errorf("unexpected reply to results - %q", buffer[:bytesRead])
+    }
+
+    return nil
+}
+
+
+// Appends the source marker to the loot file so the cracked hashes appended after
+// it are attributed to the hash file and source in the server report.
+//
+// @Parameters
+// - lootPath:  The path of the final loot file
+// - hashFilePath:  The path of the hash file the hashes were cracked from
+// - source:  The wordlist or keyspace range the hashes were cracked from
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func appendLootSource(lootPath string, hashFilePath string, source string) error {
+    // Open the loot file for appending
+    lootFile, err := os.OpenFile(lootPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+    if err != nil {
+        return err
+    }
+    // Close the loot file on local exit
+    defer lootFile.Close()
+
+    marker := report.FormatHashFile(filepath.Base(hashFilePath))
+    marker = append(marker, report.FormatSource(source, time.Now())...)
+
+    _, err = lootFile.Write(marker)
+    return err
+}
+
+
+// Appends the timed out marker to the loot file so the server report lists the source
+// as cut short by the job timeout.
+//
+// @Parameters
+// - lootPath:  The path of the final loot file
+// - source:  The wordlist or keyspace range that timed out
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func appendLootTimedOut(lootPath string, source string) error {
+    // Open the loot file for appending
+    lootFile, err := os.OpenFile(lootPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+    if err != nil {
+        return err
+    }
+    // Close the loot file on local exit
+    defer lootFile.Close()
+
+    _, err = lootFile.Write(report.FormatTimedOut(source, time.Now()))
+    return err
+}
+
+
+// Executes hashcat with the passed in args, forwarding each status it prints to the server,
+// then appends any cracked hashes to the final loot file after a marker of their source and
+// logs the final hashcat status.
+// If hashcat runs past the job timeout it is killed, the hashes it cracked are kept, and
+// the source is marked as timed out in the loot file. If the server aborts the run,
+// hashcat is interrupted instead so it writes its restore file.
+//
+// @Parameters
+// - cmdArgs:  The args to pass into hashcat
+// - hashFilePath:  The path of the hash file being cracked
+// - source:  The wordlist or keyspace range being processed
+// - crackedPath:  The path where hashcat stores cracked hashes
+// - lootPath:  The path of the final loot file cracked hashes are appended to
+// - stdin:  The reader of the candidates hashcat reads from stdin, nil if unused
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+//
+// @Returns
+// - The number of hashes cracked
+// - ErrJobTimeout if hashcat was killed on the job timeout, ErrRunAborted if it was
+//   interrupted on a run abort, otherwise error if it occurs or nil on success
+//
+func runHashcat(cmdArgs []string, hashFilePath string, source string, crackedPath string,
+                lootPath string, stdin io.Reader,
+                logMan *kloudlogs.LoggerManager) (int64, error) {
+    var cracked int64
+
+    ctx := AbortCtx
+    // If a job timeout is set, kill hashcat once it runs past it
+    if JobTimeout > 0 {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(ctx, JobTimeout)
+        defer cancel()
+    }
+
+    execution := audit.Execution{Args: cmdArgs, HashFile: filepath.Base(hashFilePath),
+                                 Source: source, Start: time.Now()}
+    // If executions are reported, hash the hash file before hashcat removes cracked hashes
+    if AuditReporter != nil {
+        hashSum, err := audit.FileSha256(hashFilePath)
+        if err != nil {
+            logMan.LogMessage("error", "Error hashing hash file for audit:  %v", err)
+        }
+
+        execution.HashFileSha256 = hashSum
+    }
+
+    cmd := exec.CommandContext(ctx, hashcat.Binary, cmdArgs...)
+    cmd.Stdin = stdin
+    // Do not wait on a stdin reader that is still blocked once hashcat is killed
+    cmd.WaitDelay = 10 * time.Second
+    cmd.Cancel = func() error {
+        // Release hashcat from the GPU monitor so it is not paused while signaled to exit
+        GpuMonitor.Release(cmd.Process)
+
+        // If the run was aborted, interrupt hashcat so it writes its restore file
+        if AbortCtx.Err() != nil {
+            return cmd.Process.Signal(os.Interrupt)
+        }
+
+        return cmd.Process.Kill()
+    }
+    // Parse the status lines of the output, forwarding each to the server as it is printed
+    statusWriter := hashcat.NewStatusWriter(func(status hashcat.Status) {
+        // If the server does not display the status of the clients
+        if StatusSender == nil {
+            return
+        }
+
+        message, err := hashcat.FormatStatus(status)
+        if err == nil {
+            err = StatusSender(message)
+        }
+        if err != nil {
+            logMan.LogMessage("error", "Error forwarding hashcat status:  %v", err)
+        }
+    })
+    cmd.Stdout = statusWriter
+    cmd.Stderr = statusWriter
+
+    // Execute the hashcat command with populated arg list
+    err := cmd.Start()
+    if err == nil {
+        // Track hashcat so it is paused with the others while the GPUs are too hot
+        trackErr := GpuMonitor.Track(cmd.Process)
+        if trackErr != nil {
+            logMan.LogMessage("error", "Error pausing hashcat on start:  %v", trackErr)
+        }
+
+        err = cmd.Wait()
+        GpuMonitor.Release(cmd.Process)
+    }
+    output := statusWriter.Output()
+    timedOut := errors.Is(ctx.Err(), context.DeadlineExceeded)
+    aborted := errors.Is(ctx.Err(), context.Canceled)
+
+    execution.Duration = time.Since(execution.Start).Seconds()
+    execution.ExitCode = cmd.ProcessState.ExitCode()
+    execution.TimedOut = timedOut
+    // Report the execution to the server for the audit log
+    reportErr := AuditReporter.Report(execution)
+    if reportErr != nil {
+        logMan.LogMessage("error", "Error reporting hashcat execution:  %v", reportErr)
+    }
+
+    // If hashcat was killed on the job timeout, keep what it cracked before then
+    if timedOut {
+        logMan.LogMessage("warn", "Hashcat killed on the job timeout",
+                          zap.String("source", source), zap.Duration("timeout", JobTimeout))
+
+        LootMutex.Lock()
+        err = appendLootTimedOut(lootPath, source)
+        LootMutex.Unlock()
+        if err != nil {
+            return 0, fmt.Errorf("error marking timed out source in %s - %w", lootPath, err)
+        }
+    // If hashcat was interrupted on the run abort, keep what it cracked before then
+    } else if aborted {
+        logMan.LogMessage("warn", "Hashcat interrupted on the run abort",
+                          zap.String("source", source))
+
+        // If the run was hibernated, record what the session processed so it is resumed
+        if Hibernating.Load() {
+            err = restore.WriteSource(RestorePath, restore.ArgsSession(cmdArgs), hashFilePath,
+                                      source)
+            if err != nil {
+                return 0, fmt.Errorf("error recording checkpointed session - %w", err)
+            }
+        }
+    // If the error was an exit type error
+    } else if exitErr, ok := err.(*exec.ExitError); ok {
+        code := exitErr.ExitCode()
+
+        // If the code is not exhausted
+        if code != 1 {
+            return 0, fmt.Errorf("hashcat exited with code %d - %s", code, output)
+        }
+    // If hashcat could not be run or its stdin could not be read
+    } else if err != nil {
+        return 0, fmt.Errorf("error running hashcat - %w", err)
+    }
+
+    // Check to see if cracked hashes file exits after hashcat after processing
+    exists, isDir, hasData, err := disk.PathExists(crackedPath)
+    if err != nil {
+        return 0, fmt.Errorf("error checking cracked hashes file existence - %w", err)
+    }
+
+    // If cracked hashes file exists and has data
+    if exists && !isDir && hasData {
+        // Lock the mutex so concurrent hashcat jobs do not interleave in the loot file
+        LootMutex.Lock()
+        defer LootMutex.Unlock()
+
+        // Count the cracked hashes before they are moved into the loot file
+        cracked, err = disk.CountLines(crackedPath)
+        if err != nil {
+            return 0, fmt.Errorf("error counting cracked hashes in %s - %w", crackedPath, err)
+        }
+
+        // Mark the source of the cracked hashes for the server report
+        err = appendLootSource(lootPath, hashFilePath, source)
+        if err != nil {
+            return 0, fmt.Errorf("error marking cracked hashes source in %s - %w",
+                                 lootPath, err)
+        }
+
+        // If there is data in cracked user hash file prior to processing,
+        // append it to the final loot file
+        err = disk.AppendFile(crackedPath, lootPath)
+        if err != nil {
+            return 0, fmt.Errorf("error appending cracked hashes to %s - %w", lootPath, err)
+        }
+
+        // Count the appended hashes toward the next loot flush
+        LootFlusher.Cracked(int(cracked))
+    }
+
+    // If hashcat printed its status, log the final status with kloudlogs
+    if status, ok := statusWriter.Last(); ok {
+        logMan.LogMessage("info", "Hashcat processing results",
+                          append(status.LogArgs(), zap.String("source", source))...)
+    } else {
+        logMan.LogMessage("info", "Hashcat processing results", zap.String("source", source))
+    }
+
+    // If hashcat timed out, the caller moves on to the next wordlist or range
+    if timedOut {
+        return cracked, ErrJobTimeout
+    }
+    // If the run was aborted, the caller stops processing
+    if aborted {
+        return cracked, ErrRunAborted
+    }
+
+    return cracked, nil
+}
+
+
+// Runs the attack against each received hash file with its hash type, collecting the
+// cracked hashes of every hash file into the loot file.
+//
+// @Parameters
+// - cmdOptions:  The hashcat options used by all attack modes
+// - attackArgs:  The args of the attack following the hash file
+// - source:  The wordlist or keyspace range being processed
+// - crackedPath:  The path where hashcat stores cracked hashes
+// - lootPath:  The path of the final loot file cracked hashes are appended to
+// - stdin:  The reader of the candidates hashcat reads from stdin, nil if unused
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+//
+// @Returns
+// - The number of hashes cracked across the hash files
+// - Error if it occurs, otherwise nil on success
+//
+func runHashFiles(cmdOptions []string, attackArgs []string, source string,
+                  crackedPath string, lootPath string, stdin io.Reader,
+                  logMan *kloudlogs.LoggerManager) (int64, error) {
+    var cracked int64
+
+    // Iterate through the hash files running the attack against each
+    for _, hashFile := range HashFiles {
+        // Apply the tuning of the hash type to the options of the attack
+        cmdArgs := append(hashcat.ApplyTuning(cmdOptions, Tunings[hashFile.HashType]),
+                          "-o", crackedPath, "-m", hashFile.HashType, hashFile.Path)
+        cmdArgs = append(cmdArgs, attackArgs...)
+
+        // Run hashcat and collect any cracked hashes into the loot file
+        fileCracked, err := runHashcat(cmdArgs, hashFile.Path, source, crackedPath,
+                                       lootPath, stdin, logMan)
+        cracked += fileCracked
+        // If hashcat failed or timed out, the remaining hash files are skipped
+        if err != nil {
+            return cracked, err
+        }
+    }
+
+    return cracked, nil
+}
+
+
+// Appends the options running hashcat with the rules of the passed in ruleset.
+//
+// @Parameters
+// - cmdOptions:  The hashcat options the rules are applied to
+// - rulesetName:  The name of the received ruleset
+//
+// @Returns
+// - The hashcat options with the ruleset applied
+//
+func ruleOptions(cmdOptions []string, rulesetName string) []string {
+    return append(slices.Clone(cmdOptions), "-r", filepath.Join(RulesetPath, rulesetName),
+                  "--loopback")
+}
+
+
+// Builds the hashcat options of each rule pass the wordlist is paired with, a single
+// pass without rules when it is not run with any ruleset.
+//
+// @Parameters
+// - cmdOptions:  The hashcat options the rules are applied to
+// - fileName:  The name of the wordlist
+//
+// @Returns
+// - The hashcat options of each rule pass
+//
+func rulePasses(cmdOptions []string, fileName string) [][]string {
+    rulesets := schedule.Passes(fileName, RulesetPairings, RulesetNames)
+    // If the wordlist is run without rules
+    if len(rulesets) == 0 {
+        return [][]string{cmdOptions}
+    }
+
+    passes := make([][]string, 0, len(rulesets))
+    // Iterate through the paired rulesets building the options of each pass
+    for _, rulesetName := range rulesets {
+        passes = append(passes, ruleOptions(cmdOptions, rulesetName))
+    }
+
+    return passes
+}
+
+
+// Runs the attack against each received hash file with the candidates of the generator
+// fed into hashcat stdin, restarting the generator over the wordlist for each hash file.
+//
+// @Parameters
+// - cmdOptions:  The hashcat options used by all attack modes
+// - filePath:  The path to the wordlist the candidates are generated from
+// - source:  The wordlist being processed
+// - crackedPath:  The path where hashcat stores cracked hashes
+// - lootPath:  The path of the final loot file cracked hashes are appended to
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+//
+// @Returns
+// - The number of hashes cracked across the hash files
+// - Error if it occurs, otherwise nil on success
+//
+func runGenerator(cmdOptions []string, filePath string, source string, crackedPath string,
+                  lootPath string, logMan *kloudlogs.LoggerManager) (int64, error) {
+    var cracked int64
+
+    // Iterate through the hash files generating the candidates for each
+    for _, hashFile := range HashFiles {
+        generator, err := hashcat.GeneratorCommand(HashcatArgs.CandidateGenerator, filePath)
+        if err != nil {
+            return cracked, err
+        }
+
+        candidates, err := generator.StdoutPipe()
+        if err != nil {
+            return cracked, fmt.Errorf("error piping candidate generator - %w", err)
+        }
+
+        err = generator.Start()
+        if err != nil {
+            return cracked, fmt.Errorf("error starting candidate generator - %w", err)
+        }
+
+        // With no wordlist arg hashcat reads the candidates from stdin, with the tuning of
+        // the hash type applied
+        cmdArgs := append(hashcat.ApplyTuning(cmdOptions, Tunings[hashFile.HashType]),
+                          "-o", crackedPath, "-m", hashFile.HashType, hashFile.Path)
+        fileCracked, err := runHashcat(cmdArgs, hashFile.Path, source, crackedPath, lootPath,
+                                       candidates, logMan)
+
+        // Stop the generator in case hashcat exited before reading every candidate
+        generator.Process.Kill()
+        generator.Wait()
+
+        cracked += fileCracked
+        // If hashcat failed or timed out, the remaining hash files are skipped
+        if err != nil {
+            return cracked, err
+        }
+    }
+
+    return cracked, nil
+}
+
+
+// Gets the name the server sent the wordlist under, which differs from the name it is
+// stored under when a file with the same name was already received.
+//
+// @Parameters
+// - filePath:  The final path the wordlist is stored at
+//
+// @Returns
+// - The name the server knows the wordlist by
+//
+func serverName(filePath string) string {
+    // If the wordlist was stored under another name than it was sent with
+    if name, exists := ServerNames.Load(filePath); exists {
+        return name.(string)
+    }
+
+    return filepath.Base(filePath)
+}
+
+
+// Reports the hashes cracked from a processed wordlist to the server, which uses them
+// to prioritize the remaining wordlists.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - fileName:  The name of the processed wordlist
+// - cracked:  The number of hashes cracked from the wordlist
+// - fileSize:  The size of the processed wordlist
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+//
+func sendWordlistStats(connection net.Conn, fileName string, cracked int64, fileSize int64,
+                       logMan *kloudlogs.LoggerManager) {
+    // If the server did not agree to wordlist stats, it would misread them
+    if !Session.Supports(protocol.FeatureWordlistStats) {
+        return
+    }
+
+    statsMsg := schedule.FormatStats(fileName, cracked, fileSize)
+    // If the name is too long for the server message buffer, skip reporting
+    if len(statsMsg) > globals.MESSAGE_BUFFER_SIZE {
+        logMan.LogMessage("error", "Wordlist name too long to report stats",
+                          zap.String("wordlist", fileName))
+        return
+    }
+
+    // Lock the mutex and ensure it unlocks on local exit
+    BufferMutex.Lock()
+    defer BufferMutex.Unlock()
+
+    _, err := netio.WriteHandler(connection, statsMsg, len(statsMsg))
+    if err != nil {
+        logMan.LogMessage("error", "Error sending wordlist stats:  %v", err)
+    }
+}
+
+
+// Informs the server a received wordlist failed manifest verification and was deleted,
+// so the server requeues it for another transfer.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - fileName:  The name of the rejected wordlist
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+//
+func sendWordlistRejected(connection net.Conn, fileName string,
+                          logMan *kloudlogs.LoggerManager) {
+    rejectMsg := manifest.FormatRejection(fileName)
+
+    // Lock the mutex and ensure it unlocks on local exit
+    BufferMutex.Lock()
+    defer BufferMutex.Unlock()
+
+    _, err := netio.WriteHandler(connection, rejectMsg, len(rejectMsg))
+    if err != nil {
+        logMan.LogMessage("error", "Error sending wordlist rejection:  %v", err)
+    }
+}
+
+
+// Informs the server the wordlist is about to be processed so it can no longer be split
+// for idle clients. If the server already moved its second half to another client, the
+// wordlist is truncated to the first half.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - job:  The wordlist about to be processed
+// - filePath:  The path to the wordlist
+// - transferManager:  Manages the size of the files stored on disk
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+//
+// @Returns
+// - The size of the wordlist to be processed
+//
+func claimWordlist(connection net.Conn, job WordlistJob, filePath string,
+                   transferManager *data.TransferManager,
+                   logMan *kloudlogs.LoggerManager) int64 {
+    startMsg := rebalance.FormatStart(serverName(filePath))
+    // If the name is too long for the server message buffer, it was never split
+    if len(startMsg) > globals.MESSAGE_BUFFER_SIZE {
+        return job.Size
+    }
+
+    // Lock the mutex and ensure it unlocks on local exit
+    BufferMutex.Lock()
+    defer BufferMutex.Unlock()
+
+    _, err := netio.WriteHandler(connection, startMsg, len(startMsg))
+    if err != nil {
+        logMan.LogMessage("error", "Error sending work start message:  %v", err)
+        return job.Size
+    }
+
+    replyBuffer := make([]byte, globals.MESSAGE_BUFFER_SIZE)
+    // Wait for the server to reply whether the wordlist was split
+    bytesRead, err := netio.ReadHandler(connection, &replyBuffer)
+    if err != nil {
+        logMan.LogMessage("error", "Error reading work start reply:  %v", err)
+        return job.Size
+    }
+
+    offset, truncate, err := rebalance.ParseReply(replyBuffer[:bytesRead])
+    if err != nil {
+        logMan.LogMessage("error", "Error parsing work start reply:  %v", err)
+        return job.Size
+    }
+
+    // If the wordlist is processed whole
+    if !truncate || offset >= job.Size {
+        return job.Size
+    }
+
+    // Drop the second half now being processed by another client
+    err = os.Truncate(filePath, offset)
+    if err != nil {
+        logMan.LogMessage("error", "Error truncating split wordlist:  %v", err)
+        return job.Size
+    }
+
+    // Remove the dropped half from the size of the files stored on disk
+    transferManager.RemoveTransferSize(job.Size - offset)
+    // Wake the admission wait now that the dropped half is freed
+    Admission.Notify()
+
+    logMan.LogMessage("info", "Wordlist split for an idle client",
+                      zap.String("wordlist", job.Name), zap.Int64("size", offset))
+    return offset
+}
+
+
+// Checks the running client version with the server, if a new version is published
+// it is downloaded from S3 and replaces the client binary, then the server is notified
+// the client will restart on the new version once its current work is returned.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - buffer:  The buffer storing network messaging
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+//
+// @Returns
+// - true/false boolean depending on whether a new version was staged
+//
+func checkClientUpdate(connection net.Conn, buffer []byte,
+                       logMan *kloudlogs.LoggerManager) bool {
+    // If auto update is not in use or there is no S3 access to download from
+    if !AutoUpdate || S3Man == nil || UpdateStaged.Load() {
+        return false
+    }
+
+    // Lock the mutex and ensure it unlocks on local exit
+    BufferMutex.Lock()
+    defer BufferMutex.Unlock()
+
+    checkMsg := update.FormatCheck(ClientVersion)
+    // Send the running client version to the server
+    _, err := netio.WriteHandler(connection, checkMsg, len(checkMsg))
+    if err != nil {
+        logMan.LogMessage("error", "Error sending version check:  %v", err)
+        return false
+    }
+
+    // Wait for the current client version from the server
+    bytesRead, err := netio.ReadHandler(connection, &buffer)
+    if err != nil {
+        logMan.LogMessage("error", "Error reading client version reply:  %v", err)
+        return false
+    }
+
+    // Parse the current version and S3 key from the reply
+    version, key, err := update.ParseVersion(buffer[:bytesRead])
+    if err != nil {
+        logMan.LogMessage("error", "Error parsing client version reply:  %v", err)
+        return false
+    }
+
+    // If the running client is the current version
+    if version == ClientVersion {
+        return false
+    }
+
+    // Download the new client binary from S3
+    binData, err := S3Man.GetS3Object(BucketName, key, 2 * time.Minute)
+    if err != nil {
+        logMan.LogMessage("error", "Error downloading new client version:  %v", err)
+        return false
+    }
+
+    // Verify the new binary and replace the running client binary with it
+    err = update.Apply(binData, version, ExePath)
+    if err != nil {
+        logMan.LogMessage("error", "Error applying new client version:  %v", err)
+        return false
+    }
+
+    UpdateStaged.Store(true)
+    logMan.LogMessage("info", "New client version staged, restarting once work is returned",
+                      zap.String("version", version))
+
+    // Notify the server the client will restart on the new version
+    _, err = netio.WriteHandler(connection, globals.CLIENT_UPDATE_MARKER,
+                                len(globals.CLIENT_UPDATE_MARKER))
+    if err != nil {
+        logMan.LogMessage("error", "Error sending client update message:  %v", err)
+    }
+
+    return true
+}
+
+
+// Checks whether the server rotated its certificate since the client last trusted one,
+// if so the new certificate is verified for the server address and added to the cert
+// pool so later handshakes, such as reconnecting after an update, are accepted. The
+// established connection keeps the session it negotiated.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+//
+func checkServerCert(connection net.Conn, logMan *kloudlogs.LoggerManager) {
+    // If the server does not send rotated certificates or the client connects over SQS
+    if !Session.Supports(protocol.FeatureCertRotation) || ServerHost == "" {
+        return
+    }
+
+    // Lock the mutex and ensure it unlocks on local exit
+    BufferMutex.Lock()
+    defer BufferMutex.Unlock()
+
+    fingerprint, err := tlsutils.Fingerprint(ServerCertPem)
+    if err != nil {
+        logMan.LogMessage("error", "Error computing server certificate fingerprint:  %v", err)
+        return
+    }
+
+    checkMsg := tlsutils.FormatCertCheck(fingerprint)
+    // Send the fingerprint of the trusted server certificate to the server
+    _, err = netio.WriteHandler(connection, checkMsg, len(checkMsg))
+    if err != nil {
+        logMan.LogMessage("error", "Error sending certificate check:  %v", err)
+        return
+    }
+
+    // The reply may be a whole certificate, which does not fit the message buffer
+    certBuffer := make([]byte, 2 * globals.KB)
+    // Wait for the current marker or the rotated certificate from the server
+    bytesRead, err := netio.ReadHandler(connection, &certBuffer)
+    if err != nil {
+        logMan.LogMessage("error", "Error reading certificate check reply:  %v", err)
+        return
+    }
+
+    reply := bytes.Clone(certBuffer[:bytesRead])
+    // If the trusted certificate is still current
+    if bytes.Equal(reply, globals.CERT_CURRENT_MARKER) {
+        return
+    }
+
+    // Ensure the rotated certificate is valid for the server before trusting it
+    err = tlsutils.VerifyServerCert(reply, ServerHost)
+    if err != nil {
+        logMan.LogMessage("error", "Rotated server certificate rejected:  %v", err)
+        return
+    }
+
+    err = TlsMan.AddCACert(reply)
+    if err != nil {
+        logMan.LogMessage("error", "Error adding rotated server cert to pool:  %v", err)
+        return
+    }
+
+    ServerCertPem = reply
+    logMan.LogMessage("info", "Rotated server certificate trusted")
+}
+
+
+// Asks the server whether the operator aborted or hibernated the run, both of which
+// interrupt hashcat so it writes its restore file.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+//
+// @Returns
+// - true if the run was aborted or hibernated, otherwise false
+// - Error if it occurs, otherwise nil on success
+//
+func checkAbort(connection net.Conn) (bool, error) {
+    // Lock the mutex and ensure it unlocks on local exit
+    BufferMutex.Lock()
+    defer BufferMutex.Unlock()
+
+    // Send the abort check message
+    _, err := netio.WriteHandler(connection, globals.ABORT_CHECK_MARKER,
+                                 len(globals.ABORT_CHECK_MARKER))
+    if err != nil {
+        return false, err
+    }
+
+    buffer := make([]byte, globals.MESSAGE_BUFFER_SIZE)
+    // Wait for the abort or continue marker from the server
+    bytesRead, err := netio.ReadHandler(connection, &buffer)
+    if err != nil {
+        return false, err
+    }
+
+    // If the run was hibernated, the fleet is stopped once hashcat is checkpointed
+    if bytes.Equal(buffer[:bytesRead], globals.HIBERNATE_MARKER) {
+        Hibernating.Store(true)
+        return true, nil
+    }
+
+    return bytes.Equal(buffer[:bytesRead], globals.ABORT_MARKER), nil
+}
+
+
+// Writes the bundle an aborted run is resumed from, holding the hashcat restore files,
+// the uncracked hashes of each hash file, and the list of unprocessed wordlists.
+//
+// @Parameters
+// - lootPath:  The path of the final loot file
+//
+// @Returns
+// - The path of the written bundle
+// - Error if it occurs, otherwise nil on success
+//
+func writeRestoreBundle(lootPath string) (string, error) {
+    filePaths, err := filepath.Glob(filepath.Join(RestorePath, "*.restore"))
+    if err != nil {
+        return "", err
+    }
+
+    // Iterate through the hash files writing the hashes not yet cracked in each
+    for _, hashFile := range HashFiles {
+        remainingPath := filepath.Join(RestorePath,
+                                       restore.RemainingPrefix + filepath.Base(hashFile.Path))
+        _, err = report.Remaining(hashFile.Path, lootPath, remainingPath)
+        if err != nil {
+            return "", fmt.Errorf("error writing remaining hashes - %w", err)
+        }
+
+        filePaths = append(filePaths, remainingPath)
+    }
+
+    dirEntries, err := os.ReadDir(WordlistPath)
+    if err != nil {
+        return "", err
+    }
+
+    var wordlists strings.Builder
+    // Iterate through the wordlists that were not processed before the abort
+    for _, dirEntry := range dirEntries {
+        if dirEntry.Type().IsRegular() {
+            wordlists.WriteString(dirEntry.Name() + "\n")
+        }
+    }
+
+    wordlistsPath := filepath.Join(RestorePath, restore.RemainingWordlists)
+    err = os.WriteFile(wordlistsPath, []byte(wordlists.String()), 0644)
+    if err != nil {
+        return "", err
+    }
+
+    bundlePath := filepath.Join(DataPath, "restore.tar.gz")
+    _, err = restore.WriteBundle(bundlePath, append(filePaths, wordlistsPath))
+    if err != nil {
+        return "", err
+    }
+
+    return bundlePath, nil
+}
+
+
+// Resumes the hashcat sessions checkpointed when the run was hibernated, so the instance
+// started on resume finishes the wordlists and ranges where they were interrupted. A
+// wordlist run against a single hash file without rule passes is then deleted, otherwise
+// it is kept to be processed again for the hash files and passes the session did not reach.
+//
+// @Parameters
+// - crackedPath:  The path where hashcat stores cracked hashes
+// - lootPath:  The path of the final loot file cracked hashes are appended to
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+//
+// @Returns
+// - ErrRunAborted if the run was aborted again, otherwise error if it occurs or nil
+//
+func resumeCheckpoints(crackedPath string, lootPath string,
+                       logMan *kloudlogs.LoggerManager) error {
+    checkpoints, err := restore.Checkpoints(RestorePath)
+    if err != nil {
+        return err
+    }
+
+    // Iterate through the checkpointed sessions restoring each
+    for _, checkpoint := range checkpoints {
+        hashFile := checkpoint.HashFile
+        // If the hash file was not recorded, attribute the cracks to the first
+        if hashFile == "" && len(HashFiles) > 0 {
+            hashFile = HashFiles[0].Path
+        }
+
+        logMan.LogMessage("info", "Resuming checkpointed hashcat session",
+                          zap.String("session", checkpoint.Session),
+                          zap.String("source", checkpoint.Source))
+
+        // A job session writes to the cracked hashes file suffixed with its job number
+        sessionCrackedPath := strings.TrimSuffix(crackedPath, ".txt") +
+                              strings.TrimPrefix(checkpoint.Session, "kloudkraken") + ".txt"
+
+        _, err = runHashcat(restore.ResumeArgs(RestorePath, checkpoint.Session), hashFile,
+                            checkpoint.Source, sessionCrackedPath, lootPath, nil, logMan)
+        if err != nil && !errors.Is(err, ErrJobTimeout) {
+            return err
+        }
+
+        err = restore.Clear(RestorePath, checkpoint.Session)
+        if err != nil {
+            return err
+        }
+
+        // If the session covered everything the wordlist is run with, it is done
+        if checkpoint.Source != "" && len(HashFiles) == 1 &&
+           len(rulePasses(nil, checkpoint.Source)) == 1 {
+            os.Remove(filepath.Join(WordlistPath, filepath.Base(checkpoint.Source)))
+        }
+    }
+
+    return nil
+}
+
+
+// Requests the next keyspace range from the server.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - buffer:  The buffer storing network messaging
+//
+// @Returns
+// - The assigned keyspace range
+// - The reply marker, either the range prefix, wait marker, or end transfer marker
+// - Error if it occurs, otherwise nil on success
+//
+func requestKeyspaceRange(connection net.Conn, buffer []byte) (keyspace.Range, []byte, error) {
+    // Lock the mutex and ensure it unlocks on local exit
+    BufferMutex.Lock()
+    defer BufferMutex.Unlock()
+
+    // Send the keyspace request message
+    _, err := netio.WriteHandler(connection, globals.KEYSPACE_REQUEST_MARKER,
+                                 len(globals.KEYSPACE_REQUEST_MARKER))
+    if err != nil {
+        return keyspace.Range{}, nil, err
+    }
+
+    // Wait for the keyspace reply from the server
+    bytesRead, err := netio.ReadHandler(connection, &buffer)
+    if err != nil {
+        return keyspace.Range{}, nil, err
+    }
+
+    readBuffer := buffer[:bytesRead]
+
+    // If the server has no ranges left or they are all assigned
+    if bytes.Equal(readBuffer, globals.END_TRANSFER_MARKER) ||
+    bytes.Equal(readBuffer, globals.KEYSPACE_WAIT_MARKER) {
+        return keyspace.Range{}, readBuffer, nil
+    }
+
+    // Parse the assigned range from the reply
+    rng, err := keyspace.ParseRange(readBuffer, globals.KEYSPACE_RANGE_PREFIX)
+    if err != nil {
+        return keyspace.Range{}, nil, err
+    }
+
+    return rng, globals.KEYSPACE_RANGE_PREFIX, nil
+}
+
+
+// Continually requests keyspace ranges from the server and runs the mask attack
+// on each range with --skip and --limit, reporting each range once completed.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - buffer:  The buffer storing network messaging
+// - cmdOptions:  The hashcat options used by all attack modes
+// - charsets:  The custom charsets used in the hash mask
+// - crackedPath:  The path where hashcat stores cracked hashes
+// - lootPath:  The path of the final loot file cracked hashes are appended to
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func processKeyspace(connection net.Conn, buffer []byte, cmdOptions []string,
+                     charsets []string, crackedPath string, lootPath string,
+                     logMan *kloudlogs.LoggerManager) error {
+    for {
+        // If the server aborted the run, stop taking ranges
+        if AbortCtx.Err() != nil {
+            return nil
+        }
+
+        // Trust the server certificate if it was rotated since the last range
+        checkServerCert(connection, logMan)
+
+        // If a new client version was staged, stop taking ranges so the client can restart
+        if checkClientUpdate(connection, buffer, logMan) {
+            return nil
+        }
+
+        // Request the next keyspace range from the server
+        rng, reply, err := requestKeyspaceRange(connection, buffer)
+        if err != nil {
+            return err
+        }
+
+        // If all the keyspace ranges are complete
+        if bytes.Equal(reply, globals.END_TRANSFER_MARKER) {
+            return nil
+        }
+
+        // If the remaining ranges are assigned to other clients, wait in
+        // case any are requeued from a client that disconnects
+        if bytes.Equal(reply, globals.KEYSPACE_WAIT_MARKER) {
+            time.Sleep(10 * time.Second)
+            continue
+        }
+
+        // Append the range and charsets then the hash mask
+        attackArgs := []string{"--skip", strconv.FormatInt(rng.Skip, 10),
+                               "--limit", strconv.FormatInt(rng.Limit, 10)}
+        hashcat.AppendCharsets(&attackArgs, charsets)
+        attackArgs = append(attackArgs, HashcatArgs.HashMask)
+
+        logMan.LogMessage("info", "Processing keyspace range",
+                          zap.Int64("skip", rng.Skip), zap.Int64("limit", rng.Limit))
+
+        // Run the range against each hash file collecting cracked hashes into the loot file
+        source := fmt.Sprintf("keyspace %d+%d", rng.Skip, rng.Limit)
+        _, err = runHashFiles(cmdOptions, attackArgs, source, crackedPath, lootPath, nil,
+                              logMan)
+        // If the run was aborted, the range is left incomplete for the restore file
+        if errors.Is(err, ErrRunAborted) {
+            return nil
+        }
+        // If the range timed out it is marked in the loot file and still completed
+        if err != nil && !errors.Is(err, ErrJobTimeout) {
+            return err
+        }
+
+        completeMsg := keyspace.FormatRange(globals.KEYSPACE_COMPLETE_PREFIX, rng)
+
+        BufferMutex.Lock()
+        // Notify the server the range is complete
+        _, err = netio.WriteHandler(connection, completeMsg, len(completeMsg))
+        BufferMutex.Unlock()
+        if err != nil {
+            return err
+        }
+    }
+}
+
+
+// Runs straight mode against each wordlist streamed over its transfer connection into
+// hashcat stdin until the receiving routine signals all transfers are complete.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - streamChannel:  Channel the streamed wordlists are received on
+// - transferChannel:  Channel signaled once all wordlists have been transferred
+// - cmdOptions:  The hashcat options used by all attack modes
+// - crackedPath:  The path where hashcat stores cracked hashes
+// - lootPath:  The path of the final loot file cracked hashes are appended to
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func processStreams(connection net.Conn, streamChannel chan WordlistStream,
+                    transferChannel chan struct{}, cmdOptions []string, crackedPath string,
+                    lootPath string, logMan *kloudlogs.LoggerManager) error {
+    for {
+        select {
+        case stream := <-streamChannel:
+            logMan.LogMessage("info", "Processing streamed wordlist",
+                              zap.String("wordlist", stream.Name))
+
+            // A stream is read once, so only a single ruleset is allowed and it has one pass
+            streamOptions := rulePasses(cmdOptions, stream.Name)[0]
+
+            // With no wordlist arg hashcat reads the candidates from stdin
+            cracked, err := runHashFiles(streamOptions, []string{}, stream.Name, crackedPath,
+                                         lootPath, stream.Reader, logMan)
+            // Release the transfer connection now hashcat is done reading it
+            close(stream.Done)
+            // If the run was aborted, stop processing streams
+            if errors.Is(err, ErrRunAborted) {
+                return nil
+            }
+            // If the wordlist timed out it is marked in the loot file, move to the next
+            if err != nil && !errors.Is(err, ErrJobTimeout) {
+                return err
+            }
+
+            // Report the yield of the wordlist so the server can prioritize the rest
+            sendWordlistStats(connection, stream.Name, cracked, stream.Size, logMan)
+        // If all the wordlists have been transferred
+        case <-transferChannel:
+            return nil
+        // If the server aborted the run
+        case <-AbortCtx.Done():
+            return nil
+        }
+    }
+}
+
+
+// Runs the attack against each wordlist received into the wordlist dir with a pool of
+// hashcat jobs until the receiving routine signals all transfers are complete and no
+// wordlists remain. Each wordlist is claimed so only a single job processes it.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - transferChannel:  Channel signaled once all wordlists have been transferred
+// - cmdOptions:  The hashcat options used by all attack modes
+// - deviceOptions:  The options of a single job using every selected backend device
+// - charsets:  The custom charsets used in the hash mask
+// - crackedPath:  The path where hashcat stores cracked hashes
+// - lootPath:  The path of the final loot file cracked hashes are appended to
+// - transferManager:  Manages calculating the amount of data being transferred locally
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func processWordlists(connection net.Conn, transferChannel chan struct{},
+                      cmdOptions []string, deviceOptions []string, charsets []string,
+                      crackedPath string, lootPath string,
+                      transferManager *data.TransferManager,
+                      logMan *kloudlogs.LoggerManager) error {
+    var failOnce sync.Once
+    var jobErr error
+    var waitGroup sync.WaitGroup
+    claims := disk.NewClaimRegistry()
+    completed := false
+    failed := make(chan struct{})
+    jobChannel := make(chan WordlistJob)
+
+    jobOptions, jobCrackedPaths := hashcatJobs(cmdOptions, deviceOptions, crackedPath)
+
+    // Iterate through the hashcat jobs starting a worker for each
+    for index := range jobOptions {
+        waitGroup.Add(1)
+
+        go func() {
+            defer waitGroup.Done()
+
+            for job := range jobChannel {
+                filePath := filepath.Join(WordlistPath, job.Name)
+                // If the server splits queued wordlists, claim it before it is processed
+                if WorkStealing && Session.Supports(protocol.FeatureWorkStealing) {
+                    job.Size = claimWordlist(connection, job, filePath, transferManager,
+                                             logMan)
+                }
+
+                var cracked int64
+                var err error
+
+                // Iterate through the rule passes the wordlist is paired with
+                for _, passOptions := range rulePasses(jobOptions[index], job.Name) {
+                    var passCracked int64
+
+                    // If a candidate generator is set, feed its candidates into hashcat
+                    if HashcatArgs.CandidateGenerator != "" {
+                        passCracked, err = runGenerator(passOptions, filePath, job.Name,
+                                                        jobCrackedPaths[index], lootPath,
+                                                        logMan)
+                    } else {
+                        // Run the wordlist against each hash file collecting cracked hashes
+                        passCracked, err = runHashFiles(passOptions,
+                                                        wordlistAttackArgs(filePath, charsets),
+                                                        job.Name, jobCrackedPaths[index],
+                                                        lootPath, nil, logMan)
+                    }
+                    if err != nil {
+                        break
+                    }
+
+                    cracked += passCracked
+                }
+                // If the wordlist timed out it is marked in the loot file, move to the next
+                if errors.Is(err, ErrJobTimeout) {
+                    err = nil
+                }
+                // If the run was aborted, the wordlist is kept for the restore bundle
+                if errors.Is(err, ErrRunAborted) {
+                    return
+                }
+                if err != nil {
+                    failOnce.Do(func() {
+                        jobErr = fmt.Errorf("error running hashcat - %w", err)
+                        close(failed)
+                    })
+                    return
+                }
+
+                // Report the yield of the wordlist so the server can prioritize the rest
+                sendWordlistStats(connection, serverName(filePath), cracked, job.Size,
+                                  logMan)
+
+                // Delete the processed file
+                os.Remove(filePath)
+                ServerNames.Delete(filePath)
+                // Remove the file size from transfer manager after deletion
+                transferManager.RemoveTransferSize(job.Size)
+                claims.Release(filePath)
+                // Wake the admission wait now that the wordlist space is freed
+                Admission.Notify()
+            }
+        }()
+    }
+
+    // Wait for the jobs to finish their wordlists on local exit
+    defer func() {
+        close(jobChannel)
+        waitGroup.Wait()
+    }()
+
+    for {
+        // If the server aborted the run, the remaining wordlists are listed in the bundle
+        if AbortCtx.Err() != nil {
+            return nil
+        }
+
+        // Attempt to get the next available wordlist not already being processed
+        fileName, fileSize, err := disk.CheckUnclaimedFiles(WordlistPath, claims)
+        if err != nil {
+            return fmt.Errorf("error retrieving wordlist from wordlist dir - %w", err)
+        }
+
+        // If there was no wordlist available in designated directory
+        if fileName == "" {
+            // If the receiving handler routine is complete, no more files will arrive
+            if completed {
+                return nil
+            }
+
+            select {
+            // Poll channel for complete signal
+            case <-transferChannel:
+                // Set outer boolean toggle and check again to ensure no data is missed
+                completed = true
+            case <-failed:
+                return jobErr
+            case <-AbortCtx.Done():
+                return nil
+            // Sleep a bit and re-iterate to see if wordlist is available
+            case <-time.After(3 * time.Second):
+            }
+
+            continue
+        }
+
+        filePath := filepath.Join(WordlistPath, fileName)
+        claims.Claim(filePath, "processing")
+
+        select {
+        // Hand the wordlist to the next free hashcat job
+        case jobChannel <- WordlistJob{Name: fileName, Size: fileSize}:
+        case <-failed:
+            return jobErr
+        case <-AbortCtx.Done():
+            return nil
+        }
+    }
+}
+
+
+// Builds the options and cracked hashes path of each hashcat job. A single job uses
+// every selected backend device, while concurrent jobs each get their own session,
+// cracked hashes file, and subset of the devices when there are enough to go around.
+// Concurrent jobs share the hash files, so they leave cracked hashes in place for the
+// potfile to skip rather than rewriting the hash files with --remove.
+//
+// @Parameters
+// - cmdOptions:  The hashcat options used by all attack modes
+// - deviceOptions:  The options of a single job using every selected backend device
+// - crackedPath:  The path where hashcat stores cracked hashes
+//
+// @Returns
+// - The hashcat options of each job
+// - The path where each job stores cracked hashes
+//
+func hashcatJobs(cmdOptions []string, deviceOptions []string,
+                 crackedPath string) ([][]string, []string) {
+    // If wordlists are processed one at a time
+    if HashcatJobs <= 1 {
+        return [][]string{deviceOptions}, []string{crackedPath}
+    }
+
+    var groups []string
+    // If backend devices are selected, partition them, otherwise the detected GPUs
+    if HashcatArgs.BackendDevices != "" {
+        groups = gpu.PartitionIds(strings.Split(HashcatArgs.BackendDevices, ","), HashcatJobs)
+    } else {
+        groups = gpu.PartitionDevices(Inventory.HashcatGpus, HashcatJobs)
+    }
+
+    jobOptions := make([][]string, 0, HashcatJobs)
+    jobCrackedPaths := make([]string, 0, HashcatJobs)
+
+    // Iterate through the jobs building the options of each
+    for index := range HashcatJobs {
+        jobArgs := *HashcatArgs
+        // If there are enough devices for each job to have its own
+        if groups != nil {
+            jobArgs.BackendDevices = groups[index]
+        }
+
+        options := append(slices.Clone(cmdOptions),
+                          restore.SessionArgs(RestorePath,
+                                              fmt.Sprintf("kloudkraken-%d", index + 1))...)
+        hashcat.AppendDeviceArgs(&options, &jobArgs)
+
+        jobOptions = append(jobOptions, options)
+        jobCrackedPaths = append(jobCrackedPaths,
+                                 strings.TrimSuffix(crackedPath, ".txt") +
+                                 fmt.Sprintf("-%d.txt", index + 1))
+    }
+
+    return jobOptions, jobCrackedPaths
+}
+
+
+// Builds the attack args following the hash file for the wordlist based on the
+// cracking mode.
+//
+// @Parameters
+// - filePath:  The path to the wordlist
+// - charsets:  The custom charsets used in the hash mask
+//
+// @Returns
+// - The attack args of the wordlist
+//
+func wordlistAttackArgs(filePath string, charsets []string) []string {
+    var attackArgs []string
+
+    switch HashcatArgs.CrackingMode {
+    case "3":
+        // Appened incremental mode and available charsets for hash mask
+        attackArgs = []string{"--incremental"}
+        hashcat.AppendCharsets(&attackArgs, charsets)
+        // Append the hash mask
+        attackArgs = append(attackArgs, HashcatArgs.HashMask)
+    case "6":
+        // Appened incremental mode and available charsets for hash mask
+        attackArgs = []string{"--incremental"}
+        hashcat.AppendCharsets(&attackArgs, charsets)
+        // Append the wordlist path then the hash mask
+        attackArgs = append(attackArgs, filePath, HashcatArgs.HashMask)
+    case "7":
+        // Appened incremental mode and available charsets for hash mask
+        attackArgs = []string{"--incremental"}
+        hashcat.AppendCharsets(&attackArgs, charsets)
+        // Append the hash mask then the wordlist path
+        attackArgs = append(attackArgs, HashcatArgs.HashMask, filePath)
+    default:
+        // For straight mode (0), just append the wordlist path
+        attackArgs = []string{filePath}
+    }
+
+    return attackArgs
+}
+
+
+// Feeds the wordlist sent over the transfer connection to the processing routine,
+// waiting until hashcat is done reading it so the connection is not closed early.
+//
+// @Parameters
+// - transferConn:  The connection the wordlist is sent over
+// - streamChannel:  Channel the streamed wordlists are sent to the processing routine on
+// - fileName:  The name of the wordlist
+// - fileSize:  The size of the wordlist
+// - encoding:  The encoding the wordlist is sent with
+// - digest:  The manifest digest the wordlist is verified against, empty to skip verifying
 //
-// @ Returns
+// @Returns
 // - Error if it occurs, otherwise nil on success
 //
-func createFailureResult(lootPath string) error {
-    // Open the final cracked hashes file or create if it does not exist
-    hashesHandle, err := os.OpenFile(lootPath, os.O_RDWR|os.O_CREATE, 0644)
+func streamTransfer(transferConn net.Conn, streamChannel chan WordlistStream,
+                    fileName string, fileSize int64, encoding string, digest string) error {
+    // Set up the reader of the wordlist as it arrives
+    reader, err := netio.TransferReader(transferConn, fileSize, encoding)
     if err != nil {
         return err
     }
+    // Close the reader on local exit
+    defer reader.Close()
 
-    // Close the opened cracked hashes file on local exit
-    defer hashesHandle.Close()
+    // Digest the wordlist as hashcat reads it so it is verified once read
+    digester := manifest.NewDigester()
 
-    // Write a message letting user know that no hashes were cracked
-    _, err = hashesHandle.Write([]byte("No available cracked hashses after processing"))
+    stream := WordlistStream{
+        Done:   make(chan struct{}),
+        Name:   fileName,
+        Reader: io.TeeReader(reader, digester),
+        Size:   fileSize,
+    }
+
+    // Hand the wordlist to the processing routine and wait until it is read
+    streamChannel <- stream
+    <-stream.Done
+
+    // If the wordlist is verified and hashcat read all of it
+    if digest != "" && digester.Entry(fileName).Size == fileSize {
+        err = digester.Verify(digest)
+        if err != nil {
+            return fmt.Errorf("streamed %s failed manifest verification - %w - %w",
+                              fileName, netio.ErrChecksumMismatch, err)
+        }
+    }
+
+    return nil
+}
+
+
+// Ensures the files received into a dir do not exceed the dir quota.
+//
+// @Parameters
+// - dirPath:  The path to the dir the quota applies to
+// - quota:  The max size of the files in the dir, 0 or less is unlimited
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func checkQuota(dirPath string, quota int64) error {
+    remaining, err := disk.QuotaRemaining(dirPath, quota)
     if err != nil {
         return err
     }
 
+    // If the received files exceed the quota
+    if remaining < 0 {
+        return fmt.Errorf("files in %s exceed the quota of %d bytes by %d bytes",
+                          dirPath, quota, -remaining)
+    }
+
     return nil
 }
 
 
-// Lock mutux for messaging connection and related buffer, send the processing complete message.
+// Receives a file sent to every client, which is either uploaded by the server or
+// fetched from a seeding peer when the server replies with a peer fetch message.
+// If the peer fetch fails the server is notified and uploads the file directly.
 //
 // @Parameters
-// - connection:  network socket connection where procesing complete message is sent
+// - connection:  Network socket connection for handling messaging
+// - buffer:  The buffer storing network messaging
+// - storePath:  The path where the received file will be stored
+// - prefix:  The expected prefix for the transfer reply
 // - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
 //
-func sendProcessingComplete(connection net.Conn, logMan *kloudlogs.LoggerManager) {
+// @Returns
+// - The path of the received file
+// - Error if it occurs, otherwise nil on success
+//
+func receiveSharedFile(connection net.Conn, buffer []byte, storePath string, prefix []byte,
+                       logMan *kloudlogs.LoggerManager) (string, error) {
+    // Wait for the transfer reply or peer fetch message
+    bytesRead, err := netio.ReadHandler(connection, &buffer)
+    if err != nil {
+        return "", err
+    }
+
+    // If the server is uploading the file directly
+    if !bytes.HasPrefix(buffer[:bytesRead], globals.PEER_FETCH_PREFIX) {
+        return netio.ReceiveFileReply(connection, buffer, bytesRead, storePath, prefix)
+    }
+
+    // Parse the seeding peer and token from the peer fetch message
+    fetchInfo, err := peer.ParseFetch(buffer[:bytesRead])
+    if err != nil {
+        return "", err
+    }
+
+    certBuffer := make([]byte, 2 * globals.KB)
+    // Receive the PEM certificate of the seeding peer
+    certRead, err := netio.ReadHandler(connection, &certBuffer)
+    if err != nil {
+        return "", err
+    }
+
+    // Fetch the file from the seeding peer
+    filePath, err := peer.Fetch(fetchInfo, certBuffer[:certRead], storePath)
+    if err == nil {
+        logMan.LogMessage("info", "Shared file fetched from peer",
+                          zap.String("file", fetchInfo.FileName),
+                          zap.String("peer", fetchInfo.IpAddr))
+
+        // Notify the server the peer fetch succeeded
+        _, err = netio.WriteHandler(connection, globals.PEER_DONE_MARKER,
+                                    len(globals.PEER_DONE_MARKER))
+        return filePath, err
+    }
+
+    logMan.LogMessage("error", "Error fetching shared file from peer, " +
+                      "falling back to server:  %v", err)
+
+    // Notify the server the peer fetch failed so it uploads the file directly
+    _, err = netio.WriteHandler(connection, globals.PEER_FAILED_MARKER,
+                                len(globals.PEER_FAILED_MARKER))
+    if err != nil {
+        return "", err
+    }
+
+    return netio.ReceiveFile(connection, buffer, storePath, prefix)
+}
+
+
+// Copies the received hash and ruleset files into the seed dir so hashcat can
+// not modify them, starts the seeder, and registers it with the server.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func startSeeding(connection net.Conn, logMan *kloudlogs.LoggerManager) error {
+    // Generate the secret the server uses to create fetch tokens
+    secret, err := peer.GenerateSecret()
+    if err != nil {
+        return err
+    }
+
+    seeder := peer.NewSeeder(secret)
+
+    var sharedPaths []string
+    // Iterate through the hash files collecting their paths
+    for _, hashFile := range HashFiles {
+        sharedPaths = append(sharedPaths, hashFile.Path)
+    }
+
+    // Iterate through the rulesets collecting their paths
+    for _, rulesetName := range RulesetNames {
+        sharedPaths = append(sharedPaths, filepath.Join(RulesetPath, rulesetName))
+    }
+
+    // Iterate through the received shared files
+    for _, filePath := range sharedPaths {
+
+        fileName := filepath.Base(filePath)
+        seedFilePath := filepath.Join(SeedPath, fileName)
+
+        // Copy the file into the seed dir
+        err = disk.CopyFile(filePath, seedFilePath)
+        if err != nil {
+            return err
+        }
+
+        seeder.AddFile(fileName, seedFilePath)
+    }
+
+    // Start serving the seeded files to peers
+    port, err := seeder.Start(TlsMan.TlsCertificate)
+    if err != nil {
+        return err
+    }
+
+    Seeder = seeder
+    seedMsg := peer.FormatSeed(port, secret)
+
     // Lock the mutex and ensure it unlocks on local exit
     BufferMutex.Lock()
     defer BufferMutex.Unlock()
 
-    // Send the processing complete message
-    _, err := netio.WriteHandler(connection, globals.PROCESSING_COMPLETE,
-                                 len(globals.PROCESSING_COMPLETE))
+    // Register the seeder with the server
+    _, err = netio.WriteHandler(connection, seedMsg, len(seedMsg))
     if err != nil {
-        logMan.LogMessage("error", "Error sending processing complete message:  %v", err)
-        return
+        return err
     }
+
+    logMan.LogMessage("info", "Seeding shared files to peers", zap.Int("port", port))
+    return nil
 }
 
 
@@ -107,15 +1735,15 @@ func sendProcessingComplete(connection net.Conn, logMan *kloudlogs.LoggerManager
 // - connection:  Active socket connection for reading data to be stored and processed
 // - hashcatOptChannel:  Channel to signal when the hash and ruleset files has been received
 // - transferChannel:  Channel to transmit filenames after transfer to initiate data processing
+// - streamChannel:  Channel the streamed wordlists are received on when streaming
 // - waitGroup:  Acts as a barrier for the Goroutines running
 // - transferManager:  Manages calculating the amount of data being transferred locally
 // - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
 //
 func processingHandler(connection net.Conn, hashcatOptChannel chan struct{},
-                       transferChannel chan struct{}, waitGroup *sync.WaitGroup,
-                       transferManager *data.TransferManager,
+                       transferChannel chan struct{}, streamChannel chan WordlistStream,
+                       waitGroup *sync.WaitGroup, transferManager *data.TransferManager,
                        logMan *kloudlogs.LoggerManager) {
-    completed := false
     var err error
     // Set the message buffer size
     buffer := make([]byte, globals.MESSAGE_BUFFER_SIZE)
@@ -123,6 +1751,12 @@ func processingHandler(connection net.Conn, hashcatOptChannel chan struct{},
     defer waitGroup.Done()
 
     defer func() {
+        // Stop polling and streaming, if processing ended early they are still running
+        AbortWatcher.Stop()
+        Keepalive.Stop()
+        LogForwarder.Stop()
+        LootFlusher.Stop()
+
         // Lock the mutex and ensure it unlocks on defered function exit
         BufferMutex.Lock()
         defer BufferMutex.Unlock()
@@ -146,7 +1780,7 @@ func processingHandler(connection net.Conn, hashcatOptChannel chan struct{},
     }
 
     // Format the path for temp & permanent cracked hashes files
-    crackedPath := path.Join(cwd, "cracked.txt")
+    crackedPath := filepath.Join(cwd, "cracked.txt")
     lootPath := filepath.Join(HashesPath, "loot.txt")
 
     // If GPU optimization is to be applied, append it to options slice
@@ -157,127 +1791,144 @@ func processingHandler(connection net.Conn, hashcatOptChannel chan struct{},
     // Wait for signal that hash and ruleset files are received
     <-hashcatOptChannel
 
-    // Append command args used by all attack modes
-    cmdOptions = append(cmdOptions, "--remove", "-o", crackedPath, "-a",
-                        HashcatArgs.CrackingMode, "-m", HashcatArgs.HashType,
-                        "-w", HashcatArgs.Workload, HashFilePath)
+    // Append command args used by all attack modes, the output path, hash type and hash
+    // file of each hash file are appended when it is cracked
+    cmdOptions = append(cmdOptions, "-a", HashcatArgs.CrackingMode, "-w", HashcatArgs.Workload)
+    // Print the status as JSON lines so it is parsed and forwarded while cracking
+    cmdOptions = append(cmdOptions, hashcat.StatusArgs(hashcat.StatusInterval)...)
+    // Append the allow-listed extra args of the config
+    cmdOptions = append(cmdOptions, HashcatArgs.ExtraArgs...)
+
+    // If log streaming is enabled, start forwarding now the server reads messages in its
+    // main loop where batches are handled
+    if LogStreaming {
+        LogForwarder = logstream.NewForwarder(LogPath, 5 * time.Second)
+        LogForwarder.Start(func(batch []byte) error {
+            BufferMutex.Lock()
+            defer BufferMutex.Unlock()
+
+            _, err := netio.WriteHandler(connection, batch, len(batch))
+            return err
+        })
+    }
 
-    // If a ruleset is in use and it has a path
-    if HasRuleset && RulesetFilePath != "" {
-        // Append it to the command args
-        cmdOptions = append(cmdOptions, "-r", RulesetFilePath, "--loopback")
+    // If cracked hashes are flushed during the run and the server can merge them
+    if (LootFlushCracks > 0 || LootFlushInterval > 0) &&
+       Session.Supports(protocol.FeatureLootFlush) {
+        LootFlusher = potfile.NewFlusher(lootPath, LootFlushCracks, LootFlushInterval)
+        LootFlusher.Start(func(message []byte) error {
+            BufferMutex.Lock()
+            defer BufferMutex.Unlock()
+
+            _, err := netio.WriteHandler(connection, message, len(message))
+            return err
+        })
     }
 
-    for {
-        // Attempt to get the next available wordlist
-        fileName, fileSize, err := disk.CheckDirFiles(WordlistPath)
-        if err != nil {
-            logMan.LogMessage("error", "Error retrieving wordlist from wordlist dir:  %v",
-                              err, zap.String("wordlist directory", WordlistPath))
-            return
-        }
+    // If the server audits the run, report each hashcat execution to it
+    if Session.Supports(protocol.FeatureAudit) {
+        AuditReporter = audit.NewReporter(func(message []byte) error {
+            BufferMutex.Lock()
+            defer BufferMutex.Unlock()
 
-        select {
-        // Poll channel for complete signal
-        case <-transferChannel:
-            // Set outer boolean toggle
-            completed = true
+            _, err := netio.WriteHandler(connection, message, len(message))
+            return err
+        })
+    }
 
-            // Try again to get the next available wordlist to ensure no data is missed
-            fileName, fileSize, err = disk.CheckDirFiles(WordlistPath)
-            if err != nil {
-                logMan.LogMessage("error", "Error retrieving wordlist from wordlist dir:  %v",
-                                  err, zap.String("wordlist directory", WordlistPath))
-                return
-            }
-        default:
-            // If there was no wordlist available in designated directory
-            if fileName == "" {
-                // Sleep a bit and re-iterate to see if wordlist is available
-                time.Sleep(3 * time.Second)
-                continue
-            }
-        }
+    // If the server displays the status of the clients, forward hashcat status to it
+    if Session.Supports(protocol.FeatureStatus) {
+        StatusSender = func(message []byte) error {
+            BufferMutex.Lock()
+            defer BufferMutex.Unlock()
 
-        // If the receiving handler routine is complete and
-        // there are no more files to be processed
-        if completed && fileName == "" {
-            // Send the processing complete message to server
-            sendProcessingComplete(connection, logMan)
-            break
+            _, err := netio.WriteHandler(connection, message, len(message))
+            return err
         }
+    }
 
-        // Format the path to the wordlist
-        filePath := filepath.Join(WordlistPath, fileName)
-
-        var cmdArgs []string
-
-        switch HashcatArgs.CrackingMode {
-        case "3":
-            // Appened incremental mode and available charsets for hash mask
-            cmdArgs = append(cmdOptions, "--incremental")
-            hashcat.AppendCharsets(&cmdArgs, charsets)
-            // Append the hash mask
-            cmdArgs = append(cmdArgs, HashcatArgs.HashMask)
-        case "6":
-            // Appened incremental mode and available charsets for hash mask
-            cmdArgs = append(cmdOptions, "--incremental")
-            hashcat.AppendCharsets(&cmdArgs, charsets)
-            // Append the wordlist path then the hash mask
-            cmdArgs = append(cmdArgs, filePath, HashcatArgs.HashMask)
-        case "7":
-            // Appened incremental mode and available charsets for hash mask
-            cmdArgs = append(cmdOptions, "--incremental")
-            hashcat.AppendCharsets(&cmdArgs, charsets)
-            // Append the hash mask then the wordlist path
-            cmdArgs = append(cmdArgs, HashcatArgs.HashMask, filePath)
-        default:
-            // For straight mode (0), just append the wordlist path
-            cmdArgs = append(cmdOptions, filePath)
+    // If the brain runs on the server host, use the address of the connected server
+    if HashcatArgs.BrainClient && HashcatArgs.BrainHost == "" {
+        HashcatArgs.BrainHost, _, err = net.SplitHostPort(connection.RemoteAddr().String())
+        if err != nil {
+            logMan.LogMessage("error", "Error parsing server address for brain:  %v", err)
         }
+    }
 
-        // Execute the hashcat command with populated arg list
-        output, err := exec.Command("hashcat", cmdArgs...).CombinedOutput()
-        // If the error was an exit type error
-        if exitErr, ok := err.(*exec.ExitError); ok {
-            code := exitErr.ExitCode()
-
-            // If the code is not exhausted
-            if code != 1 {
-                logMan.LogMessage("error", "Error executing command:  %v", output)
-                return
+    // Append the brain client options so candidates tried by other clients are skipped
+    hashcat.AppendBrainArgs(&cmdOptions, HashcatArgs)
+
+    // Options of a single hashcat process removing cracked hashes from the hash files and
+    // using every selected backend device
+    deviceOptions := append(slices.Clone(cmdOptions), "--remove")
+    deviceOptions = append(deviceOptions, restore.SessionArgs(RestorePath, "kloudkraken")...)
+    hashcat.AppendDeviceArgs(&deviceOptions, HashcatArgs)
+
+    // If the server can abort the run, poll it so hashcat is interrupted with a restore file
+    if Session.Supports(protocol.FeatureRestore) {
+        AbortWatcher = restore.NewWatcher(10 * time.Second)
+        AbortWatcher.Start(func() (bool, error) {
+            return checkAbort(connection)
+        }, func() {
+            // If the run was hibernated, the client exits once checkpointed and is
+            // relaunched when its instance is started again
+            if Hibernating.Load() {
+                logMan.LogMessage("warn", "Run hibernated by the server, checkpointing hashcat")
+            } else {
+                logMan.LogMessage("warn", "Run aborted by the server, interrupting hashcat")
             }
+            AbortRun()
+        })
+    }
+
+    // Finish the hashcat sessions checkpointed when the run was hibernated
+    err = resumeCheckpoints(crackedPath, lootPath, logMan)
+    if err != nil && !errors.Is(err, ErrRunAborted) {
+        logMan.LogMessage("error", "Error resuming checkpointed hashcat sessions:  %v", err)
+        return
+    }
+
+    // If the mask keyspace is split into ranges by the server
+    if KeyspaceMode {
+        keyspaceOptions := deviceOptions
+        // Mask ranges have no wordlist to pair, so the single ruleset allowed applies to each
+        if len(RulesetNames) > 0 {
+            keyspaceOptions = ruleOptions(deviceOptions, RulesetNames[0])
         }
 
-        // Check to see if cracked hashes file exits after hashcat after processing
-        exists, isDir, hasData, err := disk.PathExists(crackedPath)
+        // Process keyspace ranges until the server has none remaining
+        err = processKeyspace(connection, buffer, keyspaceOptions, charsets,
+                              crackedPath, lootPath, logMan)
         if err != nil {
-            logMan.LogMessage("error", "Error checking cracked hashes file existence:  %v", err)
+            logMan.LogMessage("error", "Error processing keyspace ranges:  %v", err)
             return
         }
 
-        // If cracked hashes file exists and has data
-        if exists && !isDir && hasData {
-            // If there is data in cracked user hash file prior to processing,
-            // append it to the final loot file
-            err = disk.AppendFile(crackedPath, lootPath)
-            if err != nil {
-                logMan.LogMessage("error", "Error appending data to file:  %v", err,
-                                  zap.String("source file", "cracked.txt"),
-                                  zap.String("destination file", lootPath))
-                return
-            }
+        // Send the processing complete message to server
+        sendProcessingComplete(connection, logMan)
+    // If wordlists are streamed into hashcat instead of stored on disk
+    } else if StreamWordlists {
+        // Process streamed wordlists until the server has none remaining
+        err = processStreams(connection, streamChannel, transferChannel, deviceOptions,
+                             crackedPath, lootPath, logMan)
+        if err != nil {
+            logMan.LogMessage("error", "Error processing streamed wordlists:  %v", err)
+            return
         }
 
-        // Parse the hashcat output
-        logArgs := hashcat.ParseHashcatOutput(output, []byte("=>"))
-        // Log the hashcat output with kloudlogs
-        logMan.LogMessage("info", "Hashcat processing results", logArgs...)
+        // Send the processing complete message to server
+        sendProcessingComplete(connection, logMan)
+    } else {
+        // Process the wordlists with the pool of hashcat jobs until none remain
+        err = processWordlists(connection, transferChannel, cmdOptions, deviceOptions,
+                               charsets, crackedPath, lootPath, transferManager, logMan)
+        if err != nil {
+            logMan.LogMessage("error", "Error processing wordlists:  %v", err)
+            return
+        }
 
-        // Delete the processed file
-        os.Remove(filePath)
-        // Remove the file size from transfer manager after deletion
-        transferManager.RemoveTransferSize(fileSize)
+        // Send the processing complete message to server
+        sendProcessingComplete(connection, logMan)
     }
 
     // Check to see if final cracked hashes file exits before sending back to server
@@ -309,6 +1960,36 @@ func processingHandler(connection net.Conn, hashcatOptChannel chan struct{},
         logMan.LogMessage("error", "Error occured sending the cracked hashes to server:  %v", err)
         return
     }
+
+    // If the run was aborted, send what the run can be resumed from
+    if AbortCtx.Err() != nil {
+        // Bundle the restore files and what remains so the run can be resumed
+        bundlePath, err := writeRestoreBundle(lootPath)
+        if err != nil {
+            logMan.LogMessage("error", "Error writing the restore bundle:  %v", err)
+            return
+        }
+
+        // Transfer the restore bundle to server
+        err = netio.UploadFile(connection, buffer, bundlePath, globals.RESTORE_TRANSFER_PREFIX)
+        if err != nil {
+            logMan.LogMessage("error", "Error occured sending the restore bundle to server:  %v",
+                              err)
+            return
+        }
+    }
+
+    // If the server confirms the results are stored, wait for it before they may be scrubbed
+    if Session.Supports(protocol.FeatureResultsAck) {
+        err = awaitResultsAck(connection, buffer)
+        if err != nil {
+            logMan.LogMessage("error", "Error receiving results acknowledgement:  %v", err)
+            return
+        }
+
+        ResultsAcked.Store(true)
+        logMan.LogMessage("info", "Results acknowledged by server")
+    }
 }
 
 
@@ -322,29 +2003,40 @@ func processingHandler(connection net.Conn, hashcatOptChannel chan struct{},
 // - buffer:  The buffer used for processing socket messaging
 // - waitGroup:  Used to synchronize the Goroutines running
 // - transferManager:  Manages calculating the amount of data being transferred locally
+// - available:  The bytes free for the wordlist sent in the request, 0 to request any
 // - transferComplete:  boolean toggle that is to signify when all files have been transfered
+// - streamChannel:  Channel the streamed wordlists are sent on when streaming
 // - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
 //
+// @Returns
+// - true if no remaining wordlist fits the free space until more is freed, otherwise false
+//
 func processTransfer(connection net.Conn, buffer []byte, waitGroup *sync.WaitGroup,
-                     transferManager *data.TransferManager, transferComplete *bool,
-                     logMan *kloudlogs.LoggerManager) {
+                     transferManager *data.TransferManager, available int64,
+                     transferComplete *bool, streamChannel chan WordlistStream,
+                     logMan *kloudlogs.LoggerManager) bool {
     // Lock the mutex and ensure it unlocks on local exit
     BufferMutex.Lock()
     defer BufferMutex.Unlock()
 
+    request := globals.TRANSFER_REQUEST_MARKER
+    // If the free space is reported, the server selects a wordlist that fits it
+    if available > 0 {
+        request = admission.FormatRequest(available)
+    }
+
     // Send the transfer request message to initiate file transfer
-    _, err := netio.WriteHandler(connection, globals.TRANSFER_REQUEST_MARKER,
-                                 len(globals.TRANSFER_REQUEST_MARKER))
+    _, err := netio.WriteHandler(connection, request, len(request))
     if err != nil {
         logMan.LogMessage("error", "Error sending the transfer request to brain server:  %v", err)
-        return
+        return false
     }
 
     // Wait to receive the start transfer message from the server
     bytesRead, err := netio.ReadHandler(connection, &buffer)
     if err != nil {
         logMan.LogMessage("error", "Error start transfer message from server:  %v", err)
-        return
+        return false
     }
 
     // Slice off any unused bytes in buffer
@@ -353,22 +2045,37 @@ func processTransfer(connection net.Conn, buffer []byte, waitGroup *sync.WaitGro
     // If the server has completed transferring all data
     if bytes.Contains(readBuffer, globals.END_TRANSFER_MARKER) {
         *transferComplete = true
-        return
+        return false
+    }
+
+    // If no wordlist is ready yet, such as when the rest are larger than the free space
+    // or still being merged, request again later
+    if bytes.Equal(readBuffer, globals.TRANSFER_WAIT_MARKER) {
+        return true
     }
 
     // If the read data does not start with special delimiter or end with closed bracket
     if !bytes.HasPrefix(readBuffer, globals.START_TRANSFER_PREFIX) ||
     !bytes.HasSuffix(readBuffer, globals.TRANSFER_SUFFIX) {
         logMan.LogMessage("error", "Unusual format in receieved start transfer message")
-        return
+        return false
     }
 
-    // Extract the file name and size from the stripped initial transfer message
-    fileName, fileSize, err := netio.GetFileInfo(buffer, globals.START_TRANSFER_PREFIX, bytesRead)
+    // Extract the file name, size, encoding, and digest from the stripped transfer message
+    fileName, fileSize, encoding, digest, err := netio.GetFileInfo(buffer,
+                                                                   globals.START_TRANSFER_PREFIX,
+                                                                   bytesRead)
     if err != nil {
         logMan.LogMessage("error", "Error extracting file name and " +
                           "size from start transfer message:  %v", err)
-        return
+        return false
+    }
+
+    // If the file was staged in S3 by the SQS control plane, download it from there
+    if encoding == netio.EncodingS3 {
+        downloadStagedFile(connection, string(fileName), fileSize, digest, waitGroup,
+                           transferManager, logMan)
+        return false
     }
 
     // Make buffer for int port bytes
@@ -383,17 +2090,39 @@ func processTransfer(connection net.Conn, buffer []byte, waitGroup *sync.WaitGro
     _, err = netio.WriteHandler(connection, intBuffer, len(intBuffer))
     if err != nil {
         logMan.LogMessage("error", "Error occurred sending converted int32 port to server:  %v", err)
-        return
+        return false
+    }
+
+    plaintext := false
+    // If plaintext transfers were negotiated, the server tells which transport it dials
+    if Session.Supports(protocol.FeaturePlaintext) {
+        var transport byte
+
+        err = binary.Read(connection, binary.LittleEndian, &transport)
+        if err != nil {
+            logMan.LogMessage("error", "Error receiving the transfer transport:  %v", err)
+            listener.Close()
+            return false
+        }
+
+        plaintext = transport == netio.TransportPlaintext
     }
 
     // Set up context handler for TLS listener
     ctx, cancel := context.WithCancel(context.Background())
-    // Setup up TLS listener from existing raw TCP listener
-    tlsListener, err := TlsMan.SetupTlsListenerHandler(TlsMan.TlsCertificate,
-                                                       TlsMan.CaCertPool, ctx,
-                                                       "", port, listener)
-    if err != nil {
-        logMan.LogMessage("error", "Error setting TLS listener on client:  %v", err)
+    var tlsListener net.Listener
+    // If the wordlist data skips TLS inside the VPC, only accept the host of the server
+    if plaintext {
+        serverHost, _, _ := net.SplitHostPort(connection.RemoteAddr().String())
+        tlsListener = &netio.PeerListener{Listener: listener, Host: serverHost}
+    } else {
+        // Setup up TLS listener from existing raw TCP listener
+        tlsListener, err = TlsMan.SetupTlsListenerHandler(TlsMan.TlsCertificate,
+                                                          TlsMan.CaCertPool, ctx,
+                                                          "", port, listener)
+        if err != nil {
+            logMan.LogMessage("error", "Error setting TLS listener on client:  %v", err)
+        }
     }
 
     // Wait for an incoming connection
@@ -409,7 +2138,7 @@ func processTransfer(connection net.Conn, buffer []byte, waitGroup *sync.WaitGro
 
         // Call cancel function to ensure raw TCP socket is closed
         cancel()
-        return
+        return false
     }
 
     waitGroup.Add(1)
@@ -437,15 +2166,132 @@ func processTransfer(connection net.Conn, buffer []byte, waitGroup *sync.WaitGro
             waitGroup.Done()
         } ()
 
-        // Receive the file from remote server
-        _, err = netio.HandleTransferRecv(transferConn, WordlistPath, string(fileName), fileSize)
-        if err != nil {
+        var storedPath string
+
+        // If streaming, feed the file into hashcat instead of storing it on disk
+        if StreamWordlists {
+            err = streamTransfer(transferConn, streamChannel, string(fileName), fileSize,
+                                 encoding, digest)
+        // If the file is split, receive its ranges over parallel connections
+        } else if encoding == netio.EncodingRanges {
+            storedPath, err = netio.HandleRangesRecv(transferConn, tlsListener, WordlistPath,
+                                                     string(fileName), fileSize, digest)
+        } else {
+            // Receive the file from remote server, decompressing it if sent compressed
+            storedPath, err = netio.HandleTransferRecv(transferConn, WordlistPath,
+                                                       string(fileName), fileSize, encoding,
+                                                       digest)
+        }
+        switch {
+        case err == nil:
+            // If the name was taken, track the file by its final path so the messages
+            // about it use the name the server knows it by
+            if storedPath != "" && filepath.Base(storedPath) != string(fileName) {
+                ServerNames.Store(storedPath, string(fileName))
+            }
+        // If the wordlist differs from the manifest, have the server send it again
+        case errors.Is(err, netio.ErrChecksumMismatch):
+            logMan.LogMessage("error", "Wordlist rejected, it was deleted for the server to " +
+                              "requeue:  %v", err)
+            sendWordlistRejected(connection, string(fileName), logMan)
+        // If the disk filled, the transfer fails the same way until space is freed
+        case errors.Is(err, netio.ErrDiskFull):
+            logMan.LogMessage("error", "Disk full during file transfer, wordlist left " +
+                              "for the server to requeue:  %v", err)
+        // If the server went away or stalled, the wordlist is requeued server side
+        case errors.Is(err, netio.ErrPeerClosed) || errors.Is(err, netio.ErrTimeout):
+            logMan.LogMessage("warn", "File transfer interrupted by the server:  %v", err)
+        default:
             logMan.LogMessage("error", "Error during file transfer:  %v", err)
         }
 
         MaxTransfers.Add(-1)
         // Subtract the file size of the file transfer that is complete
         transferManager.RemoveTransferSize(fileSize)
+        // Wake the admission wait now that a transfer slot is free
+        Admission.Notify()
+    }()
+
+    return false
+}
+
+
+// Downloads a file staged in S3 by the server over the SQS control plane in a separate
+// Goroutine, moving it into the wordlist dir once complete so it is never processed
+// partially downloaded, then deletes the staged object.
+//
+// @Parameters
+// - connection:  Network socket connection for handling messaging
+// - fileName:  The name of the staged file
+// - fileSize:  The size of the staged file
+// - digest:  The manifest digest the file is verified against, empty to skip verifying
+// - waitGroup:  Used to synchronize the Goroutines running
+// - transferManager:  Manages calculating the amount of data being transferred locally
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+//
+func downloadStagedFile(connection net.Conn, fileName string, fileSize int64, digest string,
+                        waitGroup *sync.WaitGroup, transferManager *data.TransferManager,
+                        logMan *kloudlogs.LoggerManager) {
+    waitGroup.Add(1)
+    MaxTransfers.Add(1)
+    // Add the file size of the file to be transfered to transfer manager
+    transferManager.AddTransferSize(fileSize)
+
+    go func() {
+        defer func() {
+            MaxTransfers.Add(-1)
+            // Subtract the file size of the file transfer that is complete
+            transferManager.RemoveTransferSize(fileSize)
+            // Wake the admission wait now that a transfer slot is free
+            Admission.Notify()
+            // Decrement the waitgroup
+            waitGroup.Done()
+        } ()
+
+        key := controlplane.TransferKey(QueuePrefix, fileName)
+        // Download outside the wordlist dir so the processing handler skips it until complete
+        partPath := filepath.Join(DataPath, "." + fileName + disk.PartSuffix)
+
+        // Download the staged file from S3
+        bytesWrote, err := S3Man.DownloadS3Object(BucketName, key, partPath, 30 * time.Minute)
+        if err != nil {
+            logMan.LogMessage("error", "Error downloading staged file:  %v", err)
+            os.Remove(partPath)
+            return
+        }
+
+        // If the download was cut short
+        if bytesWrote != fileSize {
+            logMan.LogMessage("error", "Staged file %s size mismatch, expected %d bytes got %d",
+                              fileName, fileSize, bytesWrote)
+            os.Remove(partPath)
+            return
+        }
+
+        // If the wordlist is verified, ensure it matches the manifest before processing
+        if digest != "" {
+            err = manifest.Verify(partPath, digest)
+            if err != nil {
+                logMan.LogMessage("error", "Staged file %s rejected, it was deleted for the " +
+                                  "server to requeue:  %v", fileName, err)
+                os.Remove(partPath)
+                sendWordlistRejected(connection, fileName, logMan)
+                return
+            }
+        }
+
+        // Move the completed file into the wordlist dir for processing
+        err = os.Rename(partPath, filepath.Join(WordlistPath, fileName))
+        if err != nil {
+            logMan.LogMessage("error", "Error moving staged file to wordlist dir:  %v", err)
+            return
+        }
+
+        // Delete the staged object now that it is stored locally
+        err = S3Man.DeleteS3Object(BucketName, key, 1 * time.Minute)
+        if err != nil {
+            logMan.LogMessage("error", "Error deleting staged file from S3:  %v", err)
+        }
     }()
 }
 
@@ -453,58 +2299,168 @@ func processTransfer(connection net.Conn, buffer []byte, waitGroup *sync.WaitGro
 // Sets up messaging buffer, receives the hash and ruleset files (if optional ruleset applied).
 // Goes into continual loop where it checks the disk space and the size on the ongoing file
 // transfers where the combined information is used to decide whether there is a proper amount
-// of disk space to initiate the transfer (if not there is a brief sleep to reiterate). After
-// the loop concludes the cracked hashes and log files are sent back to the server.
+// of disk space to initiate the transfer (if not it waits until space is freed by a processed
+// wordlist or finished transfer, rechecking periodically). After the loop concludes the cracked
+// hashes and log files are sent back to the server.
 //
 // @Parameters
 // - connection:  Active socket connection for reading data to be stored and processed
 // - hashcatOptChannel:  Channel to signal when the hash and ruleset files has been received
 // - transferChannel:  Channel to transmit filenames after transfer to initiate data processing
+// - streamChannel:  Channel the streamed wordlists are sent on when streaming
 // - waitGroup:  Used to synchronize the Goroutines running
 // - transferManager:  Manages calculating the amount of data being transferred locally
 // - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
 // - maxFileSize:  The maximum allowed size for a file to be transferred
 //
 func receivingHandler(connection net.Conn, hashcatOptChannel chan struct{},
-                      transferChannel chan struct{}, waitGroup *sync.WaitGroup,
-                      transferManager *data.TransferManager,
+                      transferChannel chan struct{}, streamChannel chan WordlistStream,
+                      waitGroup *sync.WaitGroup, transferManager *data.TransferManager,
                       logMan *kloudlogs.LoggerManager, maxFileSizeInt64 int64) {
     // Decrements wait group counter upon local exit
     defer waitGroup.Done()
     transferComplete := false
 
-    // Upload the client TLS PEM cert to the server to be added to its cert pool
-    _, err := netio.WriteHandler(connection, TlsMan.CertPemBlock, len(TlsMan.CertPemBlock))
+    // Upload the client TLS PEM cert to the server to be added to its cert pool
+    _, err := netio.WriteHandler(connection, TlsMan.CertPemBlock, len(TlsMan.CertPemBlock))
+    if err != nil {
+        logMan.LogMessage("error", "Error sending client PEM certificate:  %v", err)
+        return
+    }
+
+    // Report the GPU inventory to the server before work is assigned
+    inventoryMessage := gpu.FormatInventory(Inventory)
+    _, err = netio.WriteHandler(connection, inventoryMessage, len(inventoryMessage))
+    if err != nil {
+        logMan.LogMessage("error", "Error sending GPU inventory:  %v", err)
+        return
+    }
+
+    // If the server stores the instance metadata, report it after the inventory
+    if Session.Supports(protocol.FeatureMetadata) {
+        metadataMessage := instance.FormatMetadata(Metadata)
+        _, err = netio.WriteHandler(connection, metadataMessage, len(metadataMessage))
+        if err != nil {
+            logMan.LogMessage("error", "Error sending instance metadata:  %v", err)
+            return
+        }
+    }
+
+    // Make buffer to messaging size
+    buffer := make([]byte, globals.MESSAGE_BUFFER_SIZE)
+
+    // If the server assigns the backend devices of the client
+    if Session.Supports(protocol.FeatureDevices) {
+        // Wait for the devices assigned by the server
+        bytesRead, err := netio.ReadHandler(connection, &buffer)
+        if err != nil {
+            logMan.LogMessage("error", "Error receiving device assignment:  %v", err)
+            return
+        }
+
+        devices, err := gpu.ParseAssignment(buffer[:bytesRead])
+        if err != nil {
+            logMan.LogMessage("error", "Error parsing device assignment:  %v", err)
+            return
+        }
+
+        // If devices were assigned, they replace the configured devices
+        if devices != "" {
+            HashcatArgs.BackendDevices = devices
+            logMan.LogMessage("info", "Backend devices assigned by server",
+                              zap.String("devices", devices))
+        }
+    }
+
+    // Wait for the hash type of each hash file sent by the server
+    bytesRead, err := netio.ReadHandler(connection, &buffer)
+    if err != nil {
+        logMan.LogMessage("error", "Error receiving hash types:  %v", err)
+        return
+    }
+
+    // Parse the hash types in the order the hash files are sent
+    hashTypes, err := hashcat.ParseHashTypes(buffer[:bytesRead])
+    if err != nil {
+        logMan.LogMessage("error", "Error parsing hash types:  %v", err)
+        return
+    }
+
+    // Iterate through the hash types receiving the hash file of each
+    for _, hashType := range hashTypes {
+        // Receive the hash file from the server or a seeding peer
+        hashFilePath, err := receiveSharedFile(connection, buffer, HashesPath,
+                                               globals.HASHES_TRANSFER_PREFIX, logMan)
+        if err != nil {
+            logMan.LogMessage("error", "Error receiving hash file:  %v", err)
+            return
+        }
+
+        HashFiles = append(HashFiles, HashFile{HashType: hashType, Path: hashFilePath})
+    }
+
+    // Ensure the received hash files fit in the hashes dir quota
+    err = checkQuota(HashesPath, HashQuota)
     if err != nil {
-        logMan.LogMessage("error", "Error sending client PEM certificate:  %v", err)
+        logMan.LogMessage("error", "Error receiving hash files:  %v", err)
         return
     }
 
-    // Make buffer to messaging size
-    buffer := make([]byte, globals.MESSAGE_BUFFER_SIZE)
+    // Iterate through the rulesets the server sends receiving each
+    for range RulesetCount {
+        // Receive the ruleset from the server or a seeding peer
+        rulesetFilePath, err := receiveSharedFile(connection, buffer, RulesetPath,
+                                                  globals.RULESET_TRANSFER_PREFIX, logMan)
+        if err != nil {
+            logMan.LogMessage("error", "Error receiving ruleset file:  %v", err)
+            return
+        }
 
-    // Receive the hash file from the server
-    HashFilePath, err = netio.ReceiveFile(connection, buffer, HashesPath,
-                                          globals.HASHES_TRANSFER_PREFIX)
-    if err != nil {
-        logMan.LogMessage("error", "Error receiving hash file:  %v", err)
-        return
+        RulesetNames = append(RulesetNames, filepath.Base(rulesetFilePath))
     }
 
-    // If a rule set was specified
-    if HasRuleset {
-        // Receive the ruleset from the server
-        RulesetFilePath, err = netio.ReceiveFile(connection, buffer, RulesetPath,
-                                                 globals.RULESET_TRANSFER_PREFIX)
+    // If rulesets were received, ensure they fit in the rulesets dir quota
+    if RulesetCount > 0 {
+        err = checkQuota(RulesetPath, RulesetQuota)
         if err != nil {
-            logMan.LogMessage("error", "Error receiving ruleset file:  %v", err)
+            logMan.LogMessage("error", "Error receiving ruleset files:  %v", err)
             return
         }
     }
 
+    // If peer sharing is enabled, seed the received files before hashcat modifies them
+    if PeerSharing {
+        err = startSeeding(connection, logMan)
+        if err != nil {
+            logMan.LogMessage("error", "Error starting peer seeding:  %v", err)
+        }
+    }
+
+    // If the server drops idle connections, probe it so a hung server fails the
+    // messaging of the control loops instead of blocking them forever
+    if IdleTimeout > 0 && Session.Supports(protocol.FeatureKeepalive) {
+        Keepalive = netio.NewKeepalive(IdleTimeout / 3)
+        Keepalive.Start(func() error {
+            // Lock the mutex and ensure it unlocks on function exit
+            BufferMutex.Lock()
+            defer BufferMutex.Unlock()
+
+            return netio.Probe(connection, IdleTimeout)
+        }, func(err error) {
+            logMan.LogMessage("error", "Server failed keepalive probe, closing connection:  %v",
+                              err)
+            connection.Close()
+        })
+    }
+
     // Send signal to other routine that hash and ruleset file has been received
     hashcatOptChannel <- struct{}{}
 
+    // If processing keyspace ranges, no wordlists are transferred
+    if KeyspaceMode {
+        return
+    }
+
     var diskPath string
     // If the program is being run in testing mode
     if DataPath == "/tmp" {
@@ -516,27 +2472,66 @@ func receivingHandler(connection net.Conn, hashcatOptChannel chan struct{},
         diskPath = DataPath
     }
 
-    for {
+    Admission = admission.New(func() (int64, error) {
         // Get the remaining available and total disk space
-        remainingSpace, total, err := disk.GetDiskSpace(diskPath, globals.OS_RESERVED_SPACE)
+        remainingSpace, total, err := disk.GetDiskSpace(diskPath, Reserve)
         if err != nil {
-            logMan.LogMessage("error", "Error checking disk space on client:  %v", err)
-            return
+            return 0, fmt.Errorf("error checking disk space - %w", err)
+        }
+
+        // Get the space remaining under the wordlists dir quota
+        quotaRemaining, err := disk.QuotaRemaining(WordlistPath, WordlistQuota)
+        if err != nil {
+            return 0, fmt.Errorf("error checking wordlist quota - %w", err)
         }
 
         logMan.LogMessage("info", "Client disk statistics queried",
                           zap.Int64("remaining space", remainingSpace),
-                          zap.Int64("total space", total))
-        // Get the ongoing transfer size from transfer manager
-        ongoingTransferSize := transferManager.GetOngoingTransfersSize()
+                          zap.Int64("total space", total),
+                          zap.Int64("quota remaining", quotaRemaining))
+
+        // The wordlists can only fill the smaller of the disk and their quota
+        return min(remainingSpace, quotaRemaining), nil
+    }, 30 * time.Second)
+
+    // If the server selects wordlists by the free space, smaller ones are admitted early
+    fitting := !StreamWordlists && Session.Supports(protocol.FeatureAdmission)
+
+    for {
+        // Take the change channel before checking so a freeing event is not missed
+        changed := Admission.Changed()
+
+        // Get the space free once the ongoing file transfers are accounted for
+        available, err := Admission.Available(transferManager.GetOngoingTransfersSize())
+        if err != nil {
+            logMan.LogMessage("error", "Error checking space on client:  %v", err)
+            return
+        }
 
-        // If the remaining space minus the ongoing file transfers is greater than or
-        // equal to the max file size AND number of transfers is less than allowed max
-        if (remainingSpace - ongoingTransferSize) >= maxFileSizeInt64 &&
+        waiting := false
+        // If the available space fits the max file size, or any wordlist when the server
+        // selects by free space, or wordlists are streamed and never stored,
+        // AND number of transfers is less than allowed max
+        if (StreamWordlists || available >= maxFileSizeInt64 || (fitting && available > 0)) &&
         MaxTransfers.Load() != MaxTransfersInt32 {
-            // Process the transfer of a file and return file size for the next
-            processTransfer(connection, buffer, waitGroup, transferManager,
-                            &transferComplete, logMan)
+            // Trust the server certificate if it was rotated since the last transfer
+            checkServerCert(connection, logMan)
+
+            var fitSize int64
+            // If the max file size does not fit, request a wordlist that does
+            if fitting && available < maxFileSizeInt64 {
+                fitSize = available
+            }
+
+            // If a new client version was staged, stop transfers so the client can restart
+            if checkClientUpdate(connection, buffer, logMan) {
+                transferComplete = true
+            } else {
+                // Process the transfer of a file, waiting if none fit the free space
+                waiting = processTransfer(connection, buffer, waitGroup, transferManager,
+                                          fitSize, &transferComplete, streamChannel, logMan)
+            }
+
             // If all the transfers are complete exit the data receiving loop
             if transferComplete {
                 // Sleep to ensure other routine has time to poll for wordlists
@@ -546,12 +2541,70 @@ func receivingHandler(connection net.Conn, hashcatOptChannel chan struct{},
                 break
             }
 
-            continue
+            // If a transfer was started, check for the next one
+            if !waiting {
+                continue
+            }
         }
 
-        // Sleep to avoid excessive syscalls during idle activity
-        time.Sleep(5 * time.Second)
+        // Wait for space to be freed or a transfer slot to open instead of polling the disk
+        Admission.Wait(context.Background(), changed)
+    }
+}
+
+
+// Sends the hello of the client and waits for the negotiated session in reply, the
+// server replies with the reason instead if the client was refused.
+//
+// @Parameters
+// - connection:  The network socket connection for handling messaging
+//
+// @Returns
+// - The negotiated session
+// - Error if it occurs or the client was refused, otherwise nil on success
+//
+func negotiateSession(connection net.Conn) (protocol.Hello, error) {
+    local := protocol.Local()
+    // Present the connection token of the run, if the server requires one
+    local.Token = ConnectionToken
+    // If the wordlist data must stay TLS, keep the server from choosing plaintext
+    if !PlaintextTransfers {
+        local.Features = slices.DeleteFunc(local.Features, func(feature string) bool {
+            return feature == protocol.FeaturePlaintext
+        })
+    }
+
+    hello := protocol.FormatHello(local)
+    // Send the protocol version and features the client supports
+    _, err := netio.WriteHandler(connection, hello, len(hello))
+    if err != nil {
+        return protocol.Hello{}, fmt.Errorf("error sending hello - %w", err)
+    }
+
+    buffer := make([]byte, globals.MESSAGE_BUFFER_SIZE)
+    // Wait for the negotiated session or the refusal
+    bytesRead, err := netio.ReadHandler(connection, &buffer)
+    if err != nil {
+        return protocol.Hello{}, fmt.Errorf("error reading hello reply - %w", err)
+    }
+
+    session, err := protocol.ParseReply(buffer[:bytesRead])
+    if err != nil {
+        return protocol.Hello{}, err
+    }
+
+    // Ensure the server did not reply with a version the client can not speak
+    session, err = protocol.Negotiate(protocol.Local(), session)
+    if err != nil {
+        return protocol.Hello{}, err
+    }
+
+    // If keyspace ranges are expected but the server did not agree to them
+    if KeyspaceMode && !session.Supports(protocol.FeatureKeyspace) {
+        return protocol.Hello{}, fmt.Errorf("keyspace ranges not supported by the server")
     }
+
+    return session, nil
 }
 
 
@@ -565,26 +2618,46 @@ func receivingHandler(connection net.Conn, hashcatOptChannel chan struct{},
 //
 func handleConnection(connection net.Conn, logMan *kloudlogs.LoggerManager,
                       maxFileSizeInt64 int64) {
+    var err error
+
+    // Negotiate the protocol with the server before anything else is exchanged
+    Session, err = negotiateSession(connection)
+    if err != nil {
+        logMan.LogMessage("error", "Error negotiating protocol with server:  %v", err)
+        return
+    }
+
+    logMan.LogMessage("info", "Protocol negotiated with server",
+                      zap.Int("version", Session.Version),
+                      zap.Strings("features", Session.Features))
+
     // Initialize a transfer mananager used to track the size of active file transfers
     transferManager := data.NewTransferManager()
 
     // Create channels for the goroutines to communicate
     hashcatOptChannel := make(chan struct{})
     transferChannel := make(chan struct{})
+    streamChannel := make(chan WordlistStream)
     // Establish a wait group
     var waitGroup sync.WaitGroup
     // Add two goroutines to the wait group
     waitGroup.Add(2)
 
     // Start the goroutine to write data to the file
-    go receivingHandler(connection, hashcatOptChannel, transferChannel, &waitGroup,
-                        transferManager, logMan, maxFileSizeInt64)
+    go receivingHandler(connection, hashcatOptChannel, transferChannel, streamChannel,
+                        &waitGroup, transferManager, logMan, maxFileSizeInt64)
     // Start the goroutine to process the file
-    go processingHandler(connection, hashcatOptChannel, transferChannel, &waitGroup,
-                         transferManager, logMan)
+    go processingHandler(connection, hashcatOptChannel, transferChannel, streamChannel,
+                         &waitGroup, transferManager, logMan)
 
     // Wait for both goroutines to finish
     waitGroup.Wait()
+
+    // Stop seeding shared files to peers
+    err = Seeder.Stop()
+    if err != nil {
+        logMan.LogMessage("error", "Error stopping peer seeder:  %v", err)
+    }
 }
 
 
@@ -627,6 +2700,9 @@ func connectRemote(ipAddrs string, port int, logMan *kloudlogs.LoggerManager,
         logMan.LogMessage("info", "Connected to remote server",
                           zap.String("ip address", addr), zap.Int("port", port))
 
+        // Save the address rotated server certificates are verified against
+        ServerHost = addr
+
         // Set up goroutines for receiving and processing data
         handleConnection(connection, logMan, maxFileSizeInt64)
         return err
@@ -636,86 +2712,416 @@ func connectRemote(ipAddrs string, port int, logMan *kloudlogs.LoggerManager,
 }
 
 
+// Register with the server over the SQS control plane, then pass the connection to
+// Goroutine handler.
+//
+// @Parameters
+// - awsConfig:  The AWS configuration used to establish the SQS client
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+// - maxFileSize:  The maximum allowed size for a file to be transferred
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func connectControlPlane(awsConfig aws.Config, logMan *kloudlogs.LoggerManager,
+                         maxFileSizeInt64 int64) (err error) {
+    // Generate the ID the queues of the client are named with
+    clientId, err := controlplane.NewClientId()
+    if err != nil {
+        return err
+    }
+
+    // Register with the server and wait for the client queues to be created
+    connection, err := controlplane.Dial(sqs.NewFromConfig(awsConfig), QueuePrefix, clientId,
+                                         10 * time.Minute)
+    if err != nil {
+        return err
+    }
+
+    defer func() {
+        // Close connection to remote server
+        cerr := connection.Close()
+        if cerr != nil {
+            err = errors.Join(err, fmt.Errorf("closing client connection:  %w", cerr))
+        }
+    } ()
+
+    logMan.LogMessage("info", "Connected to remote server over SQS control plane",
+                      zap.String("queue prefix", QueuePrefix), zap.String("client id", clientId))
+
+    // Set up goroutines for receiving and processing data
+    handleConnection(connection, logMan, maxFileSizeInt64)
+    return nil
+}
+
+
 // Create the required dirs for program operation.
 //
 func makeClientDirs() {
     // Set the program directories
-    programDirs := []string{WordlistPath, HashesPath}
+    programDirs := []string{WordlistPath, HashesPath, RestorePath}
 
-    // If there is a ruleset, append its path to program dirs
-    if HasRuleset {
+    // If there are rulesets, append their path to program dirs
+    if RulesetCount > 0 {
         programDirs = append(programDirs, RulesetPath)
     }
 
+    // If shared files are seeded to peers, append the seed path to program dirs
+    if PeerSharing {
+        programDirs = append(programDirs, SeedPath)
+    }
+
     // Create needed directories
     disk.MakeDirs(programDirs)
 }
 
 
+// Hands the data dirs and log file over to the unprivileged user and permanently drops
+// the root privileges of the process to it, so hashcat and the handling of received
+// wordlists never run as root.
+//
+// @Parameters
+// - username:  The name of the unprivileged user to drop to
+//
+// @Returns
+// - Error if it occurs, otherwise nil on success
+//
+func hardenClient(username string) error {
+    identity, err := harden.LookupUser(username)
+    if err != nil {
+        return err
+    }
+
+    // The log path is made absolute since the working dir changes to the home dir
+    LogPath, err = filepath.Abs(LogPath)
+    if err != nil {
+        return err
+    }
+
+    // Hashcat keeps its potfile, sessions and kernel cache under the home dir
+    homePath := filepath.Join(DataPath, "home")
+    disk.MakeDirs([]string{homePath})
+
+    restrictedPaths := []string{homePath, HashesPath, RestorePath, WordlistPath}
+    // If the log file exists, which it does not when only logging to CloudWatch
+    if _, err = os.Stat(LogPath); err == nil {
+        restrictedPaths = append(restrictedPaths, LogPath)
+    }
+
+    // If there are rulesets, restrict their dir
+    if RulesetCount > 0 {
+        restrictedPaths = append(restrictedPaths, RulesetPath)
+    }
+
+    // If shared files are seeded to peers, restrict the seed dir
+    if PeerSharing {
+        restrictedPaths = append(restrictedPaths, SeedPath)
+    }
+
+    err = harden.RestrictPaths(identity, restrictedPaths)
+    if err != nil {
+        return err
+    }
+
+    err = harden.DropPrivileges(identity)
+    if err != nil {
+        return err
+    }
+
+    err = os.Setenv("HOME", homePath)
+    if err != nil {
+        return err
+    }
+
+    // Work from the home dir so the cracked hashes file is written where the user can
+    return os.Chdir(homePath)
+}
+
+
+// Gets the paths hashcat may have kept its potfile at, under the data dir of the home dir
+// in newer releases, the legacy dir in older releases, and next to the binary on Windows.
+//
+// @Returns
+// - The potfile paths, which may not exist
+//
+func hashcatPotfiles() []string {
+    var potfilePaths []string
+
+    // If the home dir is known, add the XDG data dir and the legacy dir under it
+    if homePath, err := os.UserHomeDir(); err == nil {
+        dataHome := cmp.Or(os.Getenv("XDG_DATA_HOME"),
+                           filepath.Join(homePath, ".local", "share"))
+        potfilePaths = append(potfilePaths,
+                              filepath.Join(dataHome, "hashcat", "hashcat.potfile"),
+                              filepath.Join(homePath, ".hashcat", "hashcat.potfile"))
+    }
+
+    // If the hashcat binary resolves, add the potfile of a portable install beside it
+    if binaryPath, err := exec.LookPath(hashcat.Binary); err == nil {
+        potfilePaths = append(potfilePaths,
+                              filepath.Join(filepath.Dir(binaryPath), "hashcat.potfile"))
+    }
+
+    return potfilePaths
+}
+
+
+// Shreds the wordlists, hash files, rulesets, restore files, loot, and hashcat potfiles
+// the run left on the instance, so nothing sensitive remains on the instance store once
+// the server has stored the results.
+//
+// @Parameters
+// - logMan:  The kloudlogs logger manager for local and Cloudwatch logging
+//
+func scrubClient(logMan *kloudlogs.LoggerManager) {
+    // Iterate through the data dirs shredding each, the loot file is in the hashes dir
+    for _, dirPath := range []string{WordlistPath, HashesPath, RulesetPath, SeedPath,
+                                     RestorePath} {
+        err := disk.ShredDir(dirPath)
+        if err != nil {
+            logMan.LogMessage("error", "Error shredding dir:  %v", err,
+                              zap.String("path", dirPath))
+        }
+    }
+
+    filePaths := hashcatPotfiles()
+    // Add any cracked hashes files hashcat jobs left in the working dir
+    crackedPaths, _ := filepath.Glob("cracked*.txt")
+    filePaths = append(filePaths, crackedPaths...)
+
+    // Iterate through the files shredding each
+    for _, filePath := range filePaths {
+        err := disk.ShredFile(filePath)
+        if err != nil {
+            logMan.LogMessage("error", "Error shredding file:  %v", err,
+                              zap.String("path", filePath))
+        }
+    }
+
+    logMan.LogMessage("info", "Scrubbed run data from instance")
+}
+
+
 // Parse the command like flags into local and package level variables, make any
 // required dirs for program operation. Set up the AWS access config with key and
 // secret, set up logging manager, and set up connection with server.
 //
 func main() {
     var awsRegion string
+    var brainSsmParam string
     var certSsmParam string
+    var dataPath string
+    var err error
+    var extraHashcatArgs string
+    var gpuMonitorInterval time.Duration
+    var gpuTempLimit int
+    var hardening bool
+    var hardeningUser string
     var ipAddrs string
     var isTesting bool
     var logMode string
     var maxFileSizeInt64 int64
     var maxTransfers int
     var port int
+    var reservedSpace string
+    var rulesetPairings string
+    var runId string
+    var runName string
     var testPemCert string
+    var tokenSsmParam string
+    var tunings string
 
     // Define command line flags with default values and descriptions
     flag.BoolVar(&HashcatArgs.ApplyOptimization, "applyOptimization", false,
                  "Apply the -O flag for GPU optimization")
+    flag.BoolVar(&AutoUpdate, "autoUpdate", false,
+                 "Toggle to restart on new client versions published by the server")
     flag.StringVar(&awsRegion, "awsRegion", "us-east-1", "The AWS region to deploy EC2 instances")
+    flag.StringVar(&HashcatArgs.BackendDevices, "backendDevices", "",
+                   "Hashcat backend device IDs to use in CSV format, all devices if empty")
+    flag.BoolVar(&HashcatArgs.BrainClient, "brainClient", false,
+                 "Toggle to skip candidates already attempted using the hashcat brain")
+    flag.StringVar(&HashcatArgs.BrainHost, "brainHost", "",
+                   "The hashcat brain host, the connected server is used if empty")
+    flag.StringVar(&HashcatArgs.BrainPassword, "brainPassword", "",
+                   "The password to authenticate to the hashcat brain with, fetched from " +
+                   "brainSsmParam if empty")
+    flag.IntVar(&HashcatArgs.BrainPort, "brainPort", 13743, "The port of the hashcat brain")
+    flag.StringVar(&brainSsmParam, "brainSsmParam", "",
+                   "The parameter for the brain password in SSM param store, empty if unused")
+    flag.StringVar(&BucketName, "bucketName", "", "The S3 bucket where the client binary is stored")
+    flag.StringVar(&HashcatArgs.CandidateGenerator, "candidateGenerator", "",
+                   "Generator the wordlists are fed through into hashcat (prince or combinator)")
     flag.StringVar(&certSsmParam, "certSsmParam", "", "The parameter for TLS cert in SSM param store")
     flag.StringVar(&HashcatArgs.CharSet1, "charSet1", "", "Custom character set 1 for masks")
     flag.StringVar(&HashcatArgs.CharSet2, "charSet2", "", "Custom character set 2 for masks")
     flag.StringVar(&HashcatArgs.CharSet3, "charSet3", "", "Custom character set 3 for masks")
     flag.StringVar(&HashcatArgs.CharSet4, "charSet4", "", "Custom character set 4 for masks")
+    flag.StringVar(&ConnectionToken, "connectionToken", "",
+                   "The token presented in the hello, fetched from tokenSsmParam if empty")
+    flag.StringVar(&ControlPlane, "controlPlane", "tls",
+                   "The channel to connect to the server over, tls or sqs")
     flag.StringVar(&HashcatArgs.CrackingMode, "crackingMode", "0", "Hashcat cracking mode")
+    flag.StringVar(&dataPath, "dataPath", "",
+                   "Path where data dirs are stored, overrides the default of the mode")
+    flag.StringVar(&HashcatArgs.DeviceTypes, "deviceTypes", "",
+                   "Hashcat device types to use in CSV format, all types if empty")
+    flag.StringVar(&extraHashcatArgs, "extraHashcatArgs", "",
+                   "Allow-listed hashcat flags appended to every run in CSV format")
+    flag.DurationVar(&gpuMonitorInterval, "gpuMonitorInterval", 0,
+                     "Interval the GPUs are sampled with nvidia-smi on, 0 is disabled")
+    flag.IntVar(&gpuTempLimit, "gpuTempLimit", 0,
+                "GPU temperature in Celsius hashcat is paused at, 0 is disabled")
+    flag.BoolVar(&hardening, "hardening", false,
+                 "Toggle to drop root privileges to the hardening user after setup")
+    flag.StringVar(&hardeningUser, "hardeningUser", harden.DefaultUser,
+                   "The unprivileged user to drop to when hardening is enabled")
+    flag.IntVar(&HashcatJobs, "hashcatJobs", 1,
+                "Number of hashcat processes run concurrently on stored wordlists")
+    flag.StringVar(&hashcat.Binary, "hashcatPath", "hashcat",
+                   "Path of the hashcat binary to execute, resolved from PATH if not a path")
     flag.StringVar(&HashcatArgs.HashMask, "hashMask", "", "Mask to apply to hash cracking attempts")
+    flag.Int64Var(&HashQuota, "hashQuota", 0, "Max size of the hashes dir, 0 is unlimited")
     flag.StringVar(&HashcatArgs.HashType, "hashType", "1000", "Hashcat hash type to crack")
-    flag.BoolVar(&HasRuleset, "hasRuleset", false, "Toggle to specify if ruleset is in use")
+    flag.DurationVar(&IdleTimeout, "idleTimeout", 0,
+                     "Max time the server connection may sit idle, 0 is unlimited")
     flag.StringVar(&ipAddrs, "ipAddrs", "localhost", "IP addresses of server to connect to in CSV format")
     flag.BoolVar(&isTesting, "isTesting", false, "Toggle to enable testing mode")
+    flag.DurationVar(&JobTimeout, "jobTimeout", 0,
+                     "Max runtime of each hashcat process before it is killed, 0 is unlimited")
+    flag.StringVar(&HashcatArgs.KernelAccel, "kernelAccel", "",
+                   "Hashcat kernel accel, a single value or one per backend device in CSV format")
+    flag.StringVar(&HashcatArgs.KernelLoops, "kernelLoops", "",
+                   "Hashcat kernel loops, a single value or one per backend device in CSV format")
+    flag.StringVar(&HashcatArgs.KernelThreads, "kernelThreads", "",
+                   "Hashcat kernel threads, a single value or one per backend device in CSV format")
+    flag.BoolVar(&KeyspaceMode, "keyspaceMode", false,
+                 "Toggle to process mask keyspace ranges assigned by the server")
     flag.StringVar(&logMode, "logMode", "local",
                    "The mode of logging, which support local, CloudWatch, or both")
     flag.StringVar(&LogPath, "logPath", "/tmp/KloudKraken.log", "Path to the log file")
+    flag.BoolVar(&LogStreaming, "logStreaming", false,
+                 "Toggle to stream the log file to the server during the run")
+    flag.IntVar(&LootFlushCracks, "lootFlushCracks", 0,
+                "Number of cracked hashes that triggers a loot flush, 0 is disabled")
+    flag.DurationVar(&LootFlushInterval, "lootFlushInterval", 0,
+                     "Duration between loot flushes to the server, 0 is disabled")
     flag.Int64Var(&maxFileSizeInt64, "maxFileSizeInt64", 0,
                   "The max size for file to be transmitted at once")
     flag.IntVar(&maxTransfers, "maxTransfers", 3, "Maximum number of files to transfer simultaniously")
+    flag.BoolVar(&PeerSharing, "peerSharing", false,
+                 "Toggle to fetch and seed the hash and ruleset files with peers")
+    flag.BoolVar(&PlaintextTransfers, "plaintextTransfers", false,
+                 "Toggle to receive wordlist data over plaintext TCP inside the VPC")
     flag.IntVar(&port, "port", 6969, "TCP port to connect to on brain server")
+    flag.StringVar(&QueuePrefix, "queuePrefix", "",
+                   "The prefix of the SQS control plane queue names of the run")
+    flag.StringVar(&reservedSpace, "reservedSpace", "",
+                   "Space kept free for the OS as a size or percentage of the disk (ex: 5%)")
+    flag.IntVar(&RulesetCount, "rulesetCount", 0, "Number of ruleset files sent by the server")
+    flag.StringVar(&rulesetPairings, "rulesetPairings", "",
+                   "Pairings of wordlist patterns with the rulesets each is run with")
+    flag.Int64Var(&RulesetQuota, "rulesetQuota", 0, "Max size of the rulesets dir, 0 is unlimited")
+    flag.StringVar(&runId, "runId", "", "The unique ID of the run scoping the CloudWatch log group")
+    flag.StringVar(&runName, "runName", "",
+                   "The name of the run labeling the logs and CloudWatch stream, empty if unnamed")
+    flag.BoolVar(&ScrubOnCompletion, "scrubOnCompletion", false,
+                 "Toggle to shred the run data once the server acknowledges the results")
+    flag.BoolVar(&SelfTerminate, "selfTerminate", false,
+                 "Toggle to exit with failure unless the results are acknowledged, so the " +
+                 "instance is only shut down after they are stored")
+    flag.BoolVar(&StreamWordlists, "streamWordlists", false,
+                 "Toggle for feeding wordlists into hashcat stdin without storing them")
     flag.StringVar(&testPemCert, "testPemCert", "", "Path to TLS PEM certificate file for local testing")
+    flag.StringVar(&tokenSsmParam, "tokenSsmParam", "",
+                   "The parameter for the connection token in SSM param store, empty if unused")
+    flag.StringVar(&tunings, "tunings", "",
+                   "Tuning of each hash type in hashType:key=value/key=value+... format")
+    flag.Int64Var(&WordlistQuota, "wordlistQuota", 0,
+                  "Max size of the wordlists dir, 0 is unlimited")
+    flag.BoolVar(&WorkStealing, "workStealing", false,
+                 "Toggle for claiming wordlists the server may split for idle clients")
     flag.StringVar(&HashcatArgs.Workload, "workload", "3", "Workload profile number to apply")
 
     // Parse the command line flags
     flag.Parse()
 
+    // Bound the waits of server messaging so a hung server does not block the control loops
+    netio.ReadTimeout = IdleTimeout
+    netio.WriteTimeout = IdleTimeout
+
+    // Ensure the hashcat binary exists before anything is set up around it
+    _, err = exec.LookPath(hashcat.Binary)
+    if err != nil {
+        log.Fatalf("Error locating hashcat binary %s:  %v", hashcat.Binary, err)
+    }
+
+    // If the log path is a Unix path on Windows, such as the default under /tmp, keep the
+    // log file in the Windows temp dir instead
+    if runtime.GOOS == "windows" && !filepath.IsAbs(LogPath) {
+        LogPath = filepath.Join(os.TempDir(), filepath.Base(LogPath))
+    }
+
     // Ensure the max transfers is proper data type
     MaxTransfersInt32 = int32(maxTransfers)
+    // If streaming, hashcat reads a single wordlist at a time from stdin
+    if StreamWordlists {
+        MaxTransfersInt32 = 1
+    }
+
+    // Parse the space reserved for the OS as a fixed size or percentage of the disk
+    Reserve, err = validate.ValidateReservedSpace(reservedSpace)
+    if err != nil {
+        log.Fatalf("Error parsing reserved space:  %v", err)
+    }
 
+    // Parse the pairings of wordlists with the rulesets they are run with
+    RulesetPairings, err = schedule.ParsePairings(rulesetPairings)
+    if err != nil {
+        log.Fatalf("Error parsing ruleset pairings:  %v", err)
+    }
+
+    // Parse the tuning applied to the options of each hash type
+    Tunings, err = hashcat.ParseTunings(tunings)
+    if err != nil {
+        log.Fatalf("Error parsing hash type tunings:  %v", err)
+    }
+
+    // Parse the extra args appended to hashcat, rejecting any outside the allow-list
+    HashcatArgs.ExtraArgs, err = hashcat.ParseExtraArgs(extraHashcatArgs)
+    if err != nil {
+        log.Fatalf("Error parsing extra hashcat args:  %v", err)
+    }
+
+    // If a data path was specified, such as a client spawned in local mode
+    if dataPath != "" {
+        DataPath = dataPath
+    // If the program is being run in full mode on Windows, where the user data mounts
+    // the striped instance store as a drive
+    } else if !isTesting && runtime.GOOS == "windows" {
+        DataPath = `K:\`
     // If the program is being run in full mode (not testing)
-    if !isTesting {
+    } else if !isTesting {
         DataPath = "/mnt/instance-store"
+    // If the program is being run in testing mode on Windows
+    } else if runtime.GOOS == "windows" {
+        DataPath = os.TempDir()
     // If the program is being run in testing mode
     } else {
         DataPath = "/tmp"
     }
 
     // Join the base path to the data folders to be created
-    HashesPath = path.Join(DataPath, "hashes")
-    RulesetPath = path.Join(DataPath, "rulesets")
-    WordlistPath = path.Join(DataPath, "wordlists")
+    HashesPath = filepath.Join(DataPath, "hashes")
+    RestorePath = filepath.Join(DataPath, "restores")
+    RulesetPath = filepath.Join(DataPath, "rulesets")
+    SeedPath = filepath.Join(DataPath, "seeds")
+    WordlistPath = filepath.Join(DataPath, "wordlists")
 
     // Create directories for client
     makeClientDirs()
 
     var awsConfig aws.Config
-    var err error
     var serverCertPemBlock []byte
 
     // If the program is being run in full mode (not testing)
@@ -725,8 +3131,13 @@ func main() {
             log.Fatalf("Missing parameter to retrieve TLS from SSM param store")
         }
 
+        // If logging to CloudWatch without the run ID to scope the log group
+        if logMode != "local" && runId == "" {
+            log.Fatalf("Missing run ID to scope the CloudWatch log group")
+        }
+
         // Load default config, which will include the instance-profile credentials
-        awsConfig, err := config.LoadDefaultConfig(
+        awsConfig, err = config.LoadDefaultConfig(
             context.TODO(),
             config.WithRegion(awsRegion),
         )
@@ -734,8 +3145,14 @@ func main() {
             log.Fatalf("Error loading AWS config: %v", err)
         }
 
+        // If auto update or the SQS control plane is in use, establish client to S3 for
+        // downloading new versions and staged wordlists
+        if AutoUpdate || ControlPlane == controlplane.ModeSqs {
+            S3Man = awsutils.NewS3ManagerFromConfig(awsConfig)
+        }
+
         // Establish client to SSM
-        ssmMan := awsutils.NewSsmManager(awsConfig)
+        ssmMan := awsutils.NewSsmManagerFromConfig(awsConfig)
         // Retrieve the server TLS cert from SSM param store
         certPemString, err := ssmMan.GetSsmParameter(certSsmParam, 1*time.Minute)
         if err != nil {
@@ -745,6 +3162,23 @@ func main() {
         // Convert retrieved TLS cert PEM block to bytes
         serverCertPemBlock = []byte(certPemString)
 
+        // If the server requires a connection token, retrieve it from SSM param store
+        if tokenSsmParam != "" && ConnectionToken == "" {
+            ConnectionToken, err = ssmMan.GetSsmParameter(tokenSsmParam, 1*time.Minute)
+            if err != nil {
+                log.Fatalf("Error getting connection token via SSM Param Store:  %v", err)
+            }
+        }
+
+        // If the brain is in use, retrieve its password from SSM param store
+        if brainSsmParam != "" && HashcatArgs.BrainPassword == "" {
+            HashcatArgs.BrainPassword, err = ssmMan.GetSsmParameter(brainSsmParam,
+                                                                    1*time.Minute)
+            if err != nil {
+                log.Fatalf("Error getting brain password via SSM Param Store:  %v", err)
+            }
+        }
+
     // If the program is being run in testing mode
     } else {
         // Load the servers TLS certifcate PEM block
@@ -754,8 +3188,15 @@ func main() {
         }
     }
 
+    var certHosts []string
+    // If testing, include the loopback address since usable IPs exclude it and
+    // the server connects back over it when the client runs on the same host
+    if isTesting {
+        certHosts = append(certHosts, "127.0.0.1")
+    }
+
     // Generate the servers TLS PEM certificate and key and save in TLS manager
-    err = TlsMan.PemCertAndKeyGenHandler("Kloud Kraken", false)
+    err = TlsMan.PemCertAndKeyGenHandler("Kloud Kraken", false, certHosts...)
     if err != nil {
         log.Fatalf("Error creating TLS PEM certificate and key:  %v", err)
     }
@@ -772,17 +3213,158 @@ func main() {
     if err != nil {
         log.Fatalf("Error adding PEM cert to pool:  %v", err)
     }
+    // Save the server cert so rotated certificates can be detected
+    ServerCertPem = serverCertPemBlock
+
+    // If the run is named, prefix the CloudWatch stream of the client with the name
+    if runName != "" {
+        kloudlogs.StreamPrefix = runName + "-"
+    }
 
     // Initialize the LoggerManager based on the flags
     logMan, err := kloudlogs.NewLoggerManager(logMode, LogPath, awsConfig,
-                                              "Kloud-Kraken", false)
+                                              awsutils.LogGroup(runId), false)
     if err != nil {
         log.Fatalf("Error initializing logger manager:  %v", err)
     }
 
-    // Connect to remote server to begin receiving data for processing
-    err = connectRemote(ipAddrs, port, logMan, maxFileSizeInt64)
+    // If the run is named, label every log line with the name
+    if runName != "" {
+        logMan.Fields = []zap.Field{zap.String("run_name", runName)}
+    }
+    // Flush the loggers and drain the CloudWatch queue on local exit
+    defer func() {
+        if err := logMan.Close(); err != nil {
+            log.Printf("Error closing logger manager:  %v", err)
+        }
+    }()
+
+    // If auto update is in use, get the version of the running client binary
+    if AutoUpdate {
+        ExePath, err = os.Executable()
+        if err != nil {
+            log.Fatalf("Error getting client executable path:  %v", err)
+        }
+
+        ClientVersion, err = update.HashFile(ExePath)
+        if err != nil {
+            log.Fatalf("Error hashing client executable:  %v", err)
+        }
+    }
+
+    // If hardening is enabled, drop to the unprivileged user now setup is complete
+    if hardening {
+        err = hardenClient(hardeningUser)
+        if err != nil {
+            logMan.LogMessage("error", "Error dropping privileges:  %v", err)
+            return
+        }
+
+        logMan.LogMessage("info", "Dropped privileges", zap.String("user", hardeningUser))
+    }
+
+    // Detect the GPUs and ensure hashcat can use them, only required in full mode
+    Inventory, err = gpu.Detect(!isTesting, 2 * time.Minute)
+    if err != nil {
+        logMan.LogMessage("error", "GPU pre-flight check failed:  %v", err)
+        return
+    }
+
+    logMan.LogMessage("info", "GPU pre-flight check passed",
+                      zap.String("gpus", Inventory.Summary()),
+                      zap.Int("hashcat devices", Inventory.HashcatDevices),
+                      zap.Int("hashcat gpus", Inventory.HashcatGpus))
+
+    // If running on EC2, get the instance identity reported to the server
+    if !isTesting {
+        Metadata, err = instance.Fetch(5 * time.Second)
+        if err != nil {
+            logMan.LogMessage("warn", "Error fetching instance metadata:  %v", err)
+        } else {
+            logMan.LogMessage("info", "Instance metadata fetched",
+                              zap.String("instance", Metadata.Summary()),
+                              zap.String("ami", Metadata.AmiId))
+        }
+    }
+
+    // If the GPUs are monitored, log their samples and pause hashcat over the limit
+    if gpuMonitorInterval > 0 {
+        GpuMonitor = gpu.NewMonitor(gpuMonitorInterval, gpuTempLimit)
+        GpuMonitor.Start(func() ([]gpu.Sample, error) {
+            return gpu.QuerySamples(30 * time.Second)
+        }, func(samples []gpu.Sample, err error) {
+            if err != nil {
+                logMan.LogMessage("warn", "Error sampling GPUs:  %v", err)
+                return
+            }
+
+            logMan.LogMessage("info", "GPU sample", gpu.SampleLogArgs(samples)...)
+        }, func(paused bool, temp int, err error) {
+            if err != nil {
+                logMan.LogMessage("error", "Error pausing or resuming hashcat:  %v", err)
+            }
+
+            // If hashcat was paused on the limit, otherwise it was resumed once cooled
+            if paused {
+                logMan.LogMessage("warn", "GPU temperature limit reached, pausing hashcat",
+                                  zap.Int("temp", temp), zap.Int("limit", gpuTempLimit))
+            } else {
+                logMan.LogMessage("info", "GPUs cooled, resuming hashcat",
+                                  zap.Int("temp", temp))
+            }
+        })
+        // Stop sampling on local exit, resuming hashcat if it is still paused
+        defer GpuMonitor.Stop()
+    }
+
+    // If the SQS control plane is used, register on its queues instead of dialing
+    if ControlPlane == controlplane.ModeSqs {
+        err = connectControlPlane(awsConfig, logMan, maxFileSizeInt64)
+    } else {
+        // Connect to remote server to begin receiving data for processing
+        err = connectRemote(ipAddrs, port, logMan, maxFileSizeInt64)
+    }
     if err != nil {
         logMan.LogMessage("Error", "Error connecting to remote server:  %v", err)
     }
+
+    // If the server stored the results, scrub what the run left on the instance unless it
+    // was hibernated, where the restore files and wordlists are resumed once started again
+    if ScrubOnCompletion && ResultsAcked.Load() && !Hibernating.Load() {
+        scrubClient(logMan)
+    }
+
+    // If a new client version was staged, clear the returned data and restart on it
+    if UpdateStaged.Load() {
+        for _, dirPath := range []string{HashesPath, RulesetPath, SeedPath} {
+            os.RemoveAll(dirPath)
+        }
+
+        logMan.LogMessage("info", "Restarting on new client version")
+        // Drain the CloudWatch queue since deferred calls do not run on exec
+        logMan.Close()
+
+        // Replace the process with the new client binary
+        err = update.Reexec(ExePath)
+        if err != nil {
+            logMan.LogMessage("error", "Error restarting on new client version:  %v", err)
+        }
+    }
+
+    // If the instance is shut down once the client exits, fail the exit when the results
+    // were not stored so the bootstrap leaves the instance for the server to terminate, or
+    // the run was hibernated so the server stops the instance and starts it on resume
+    if SelfTerminate && (!ResultsAcked.Load() || Hibernating.Load()) {
+        // If the instance is left for the server to stop
+        if Hibernating.Load() {
+            logMan.LogMessage("info", "Run hibernated, skipping self-termination")
+        } else {
+            logMan.LogMessage("warn", "Results not acknowledged by server, " +
+                              "skipping self-termination")
+        }
+
+        // Drain the CloudWatch queue since deferred calls do not run on exit
+        logMan.Close()
+        os.Exit(1)
+    }
 }